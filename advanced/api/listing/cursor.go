@@ -0,0 +1,101 @@
+package listing
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"strconv"
+	"time"
+)
+
+// cursor 的內容對 client 不透明：base64url(JSON)，值以字串保存，解碼時依 Kind 還原型別
+type cursor struct {
+	Values      []string `json:"v"`
+	Fingerprint string   `json:"f"`
+}
+
+// fingerprint 涵蓋排序與過濾條件，但不包含 limit：換每頁筆數時 cursor 仍然有效
+func fingerprint(spec Spec) string {
+	h := fnv.New64a()
+	for _, s := range spec.Sort {
+		fmt.Fprintf(h, "s:%s:%t;", s.Param, s.Desc)
+	}
+	for _, f := range spec.Filters {
+		fmt.Fprintf(h, "f:%s:%s:", f.Param, f.Op)
+		for _, v := range f.Values {
+			fmt.Fprintf(h, "%s,", formatValue(v))
+		}
+		h.Write([]byte{';'})
+	}
+	return strconv.FormatUint(h.Sum64(), 36)
+}
+
+func formatValue(v any) string {
+	switch v := v.(type) {
+	case time.Time:
+		return v.UTC().Format(time.RFC3339Nano)
+	case float64:
+		return strconv.FormatFloat(v, 'g', -1, 64)
+	}
+	return fmt.Sprint(v)
+}
+
+// EncodeCursor 以一列的排序值（順序與 spec.Sort 相同）產生 cursor
+func EncodeCursor(spec Spec, values []any) string {
+	c := cursor{Fingerprint: fingerprint(spec)}
+	for _, v := range values {
+		c.Values = append(c.Values, formatValue(v))
+	}
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeCursor(s string, spec Spec) ([]any, error) {
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, fmt.Errorf("%w: not base64url", ErrInvalidCursor)
+	}
+	var c cursor
+	if err := json.Unmarshal(data, &c); err != nil {
+		return nil, fmt.Errorf("%w: malformed", ErrInvalidCursor)
+	}
+	if c.Fingerprint != fingerprint(spec) {
+		return nil, fmt.Errorf("%w: sort or filters changed since the cursor was issued", ErrInvalidCursor)
+	}
+	if len(c.Values) != len(spec.Sort) {
+		return nil, fmt.Errorf("%w: expected %d values, got %d", ErrInvalidCursor, len(spec.Sort), len(c.Values))
+	}
+	out := make([]any, len(c.Values))
+	for i, raw := range c.Values {
+		v, err := parseValue(spec.Sort[i].Column.Kind, raw)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidCursor, err)
+		}
+		out[i] = v
+	}
+	return out, nil
+}
+
+// Page 是一頁的結果；NextCursor 為空表示沒有下一頁
+type Page[T any] struct {
+	Items      []T    `json:"items"`
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+// NewPage 從多查一筆（Limit+1）的結果組出一頁；value 回傳 item 在某個排序參數上的值
+func NewPage[T any](spec Spec, rows []T, value func(item T, param string) any) Page[T] {
+	if len(rows) <= spec.Limit {
+		if rows == nil {
+			rows = []T{}
+		}
+		return Page[T]{Items: rows}
+	}
+	rows = rows[:spec.Limit]
+	last := rows[len(rows)-1]
+	values := make([]any, len(spec.Sort))
+	for i, s := range spec.Sort {
+		values[i] = value(last, s.Param)
+	}
+	return Page[T]{Items: rows, NextCursor: EncodeCursor(spec, values)}
+}
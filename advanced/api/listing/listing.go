@@ -0,0 +1,230 @@
+package listing
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"advanced/httpbind"
+)
+
+/*
+列表 API 共用的分頁、過濾與排序：
+
+	GET /products?limit=20&sort=-price,name&status=active&price[gte]=100&tag[in]=a,b&cursor=eyJ2Ijpb...
+
+  - Parse 依 Schema 檢查參數，轉成型別化的 Spec；只有 Schema 列出的欄位可以排序或過濾，
+    參數名稱對應到固定的 column，使用者的字串不會出現在 SQL 中（值一律用 placeholder）
+  - 排序最後一定加上唯一的 Key（例如 id），相同排序值的列也有固定順序
+  - 分頁用 keyset（seek method）而不是 OFFSET：cursor 編碼上一頁最後一列的排序值，
+    下一頁查詢 WHERE (price, name, id) 排在它之後的列。
+    OFFSET 越後面越慢，資料在翻頁時被新增/刪除還會重複或漏掉；keyset 只依賴排序值，兩個問題都沒有
+  - cursor 中帶有排序與過濾條件的指紋，換了 sort/filter 再用舊的 cursor 會回傳 ErrInvalidCursor
+
+Parse 的錯誤是 *httpbind.DecodeError，handler 直接 httpbind.Error 就是 400 problem+json。
+*/
+
+var (
+	ErrInvalidCursor = errors.New("invalid cursor")
+	ErrInvalidParam  = errors.New("invalid parameter")
+)
+
+type Kind int
+
+const (
+	String Kind = iota
+	Int
+	Float
+	Bool
+	Time // RFC 3339
+)
+
+// Column 是可以排序或過濾的欄位；Name 是 SQL 中的欄位名稱
+type Column struct {
+	Name string
+	Kind Kind
+}
+
+type Op string
+
+const (
+	Eq  Op = "eq"
+	Ne  Op = "ne"
+	Lt  Op = "lt"
+	Lte Op = "lte"
+	Gt  Op = "gt"
+	Gte Op = "gte"
+	In  Op = "in"
+)
+
+var sqlOps = map[Op]string{Eq: "=", Ne: "<>", Lt: "<", Lte: "<=", Gt: ">", Gte: ">="}
+
+type SortField struct {
+	Param string
+	Desc  bool
+}
+
+// Schema 描述一個列表 API 允許的參數
+type Schema struct {
+	Columns      map[string]Column // 參數名稱 → 欄位
+	Sortable     []string          // 可以排序的參數
+	Filterable   []string          // 可以過濾的參數
+	Key          string            // 唯一欄位的參數名稱（必須在 Columns 中），排序的最後一個條件
+	DefaultSort  []SortField
+	DefaultLimit int // 預設 20
+	MaxLimit     int // 預設 100
+}
+
+type Sort struct {
+	Param  string
+	Column Column
+	Desc   bool
+}
+
+type Filter struct {
+	Param  string
+	Column Column
+	Op     Op
+	Values []any // In 有多個值，其他只有一個
+}
+
+// Spec 是解析後的列表查詢
+type Spec struct {
+	Limit   int
+	Sort    []Sort // 最後一個是 Key
+	Filters []Filter
+	After   []any // 上一頁最後一列的排序值，第一頁為 nil
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+func invalid(param string, err error) error {
+	return &httpbind.DecodeError{In: "query", Field: param, Err: err}
+}
+
+// Parse 解析 limit、sort、cursor 與過濾參數；其他不認得的參數會被忽略
+func (s *Schema) Parse(q url.Values) (Spec, error) {
+	spec := Spec{Limit: s.DefaultLimit}
+	if spec.Limit == 0 {
+		spec.Limit = 20
+	}
+	max := s.MaxLimit
+	if max == 0 {
+		max = 100
+	}
+	if v := q.Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > max {
+			return Spec{}, invalid("limit", fmt.Errorf("%w: must be an integer in [1, %d]", ErrInvalidParam, max))
+		}
+		spec.Limit = n
+	}
+
+	sorts := s.DefaultSort
+	if v := q.Get("sort"); v != "" {
+		sorts = nil
+		for _, f := range strings.Split(v, ",") {
+			sf := SortField{Param: strings.TrimPrefix(f, "-"), Desc: strings.HasPrefix(f, "-")}
+			if !contains(s.Sortable, sf.Param) {
+				return Spec{}, invalid("sort", fmt.Errorf("%w: cannot sort by %q", ErrInvalidParam, sf.Param))
+			}
+			sorts = append(sorts, sf)
+		}
+	}
+	seen := make(map[string]bool)
+	for _, sf := range sorts {
+		if seen[sf.Param] {
+			return Spec{}, invalid("sort", fmt.Errorf("%w: %q listed twice", ErrInvalidParam, sf.Param))
+		}
+		seen[sf.Param] = true
+		spec.Sort = append(spec.Sort, Sort{Param: sf.Param, Column: s.Columns[sf.Param], Desc: sf.Desc})
+	}
+	if !seen[s.Key] {
+		// 沒有指定方向時，Key 跟著最後一個排序欄位的方向
+		desc := len(spec.Sort) > 0 && spec.Sort[len(spec.Sort)-1].Desc
+		spec.Sort = append(spec.Sort, Sort{Param: s.Key, Column: s.Columns[s.Key], Desc: desc})
+	}
+
+	// 參數依名稱排序，讓 Filters 的順序（與 cursor 指紋）固定
+	params := make([]string, 0, len(q))
+	for p := range q {
+		params = append(params, p)
+	}
+	sort.Strings(params)
+	for _, p := range params {
+		name, op := p, Eq
+		if i := strings.IndexByte(p, '['); i > 0 && strings.HasSuffix(p, "]") {
+			name, op = p[:i], Op(p[i+1:len(p)-1])
+		}
+		if !contains(s.Filterable, name) {
+			continue
+		}
+		if _, ok := sqlOps[op]; !ok && op != In {
+			return Spec{}, invalid(p, fmt.Errorf("%w: unknown operator %q", ErrInvalidParam, op))
+		}
+		col := s.Columns[name]
+		raw := []string{q.Get(p)}
+		if op == In {
+			raw = strings.Split(raw[0], ",")
+		}
+		f := Filter{Param: name, Column: col, Op: op}
+		for _, r := range raw {
+			v, err := parseValue(col.Kind, r)
+			if err != nil {
+				return Spec{}, invalid(p, fmt.Errorf("%w: %v", ErrInvalidParam, err))
+			}
+			f.Values = append(f.Values, v)
+		}
+		spec.Filters = append(spec.Filters, f)
+	}
+
+	if c := q.Get("cursor"); c != "" {
+		after, err := decodeCursor(c, spec)
+		if err != nil {
+			return Spec{}, invalid("cursor", err)
+		}
+		spec.After = after
+	}
+	return spec, nil
+}
+
+func parseValue(k Kind, s string) (any, error) {
+	switch k {
+	case Int:
+		n, err := strconv.ParseInt(s, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%q is not an integer", s)
+		}
+		return n, nil
+	case Float:
+		n, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%q is not a number", s)
+		}
+		return n, nil
+	case Bool:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return nil, fmt.Errorf("%q is not a boolean", s)
+		}
+		return b, nil
+	case Time:
+		t, err := time.Parse(time.RFC3339Nano, s)
+		if err != nil {
+			return nil, fmt.Errorf("%q is not an RFC 3339 time", s)
+		}
+		return t.UTC(), nil
+	}
+	return s, nil
+}
@@ -0,0 +1,224 @@
+package listing
+
+import (
+	"context"
+	"database/sql"
+	"encoding/base64"
+	"fmt"
+	"net/url"
+	"sort"
+	"testing"
+
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"advanced/httpbind"
+)
+
+var schema = &Schema{
+	Columns: map[string]Column{
+		"id":     {Name: "id", Kind: Int},
+		"name":   {Name: "name", Kind: String},
+		"price":  {Name: "price_cents", Kind: Int},
+		"status": {Name: "status", Kind: String},
+	},
+	Sortable:     []string{"id", "name", "price"},
+	Filterable:   []string{"price", "status"},
+	Key:          "id",
+	DefaultSort:  []SortField{{Param: "id"}},
+	DefaultLimit: 5,
+	MaxLimit:     50,
+}
+
+type product struct {
+	ID     int64
+	Name   string
+	Price  int64
+	Status string
+}
+
+func (p product) value(param string) any {
+	switch param {
+	case "id":
+		return p.ID
+	case "name":
+		return p.Name
+	case "price":
+		return p.Price
+	}
+	panic(param)
+}
+
+func setup(t *testing.T) *sql.DB {
+	db, err := sql.Open("sqlite3", ":memory:")
+	require.NoError(t, err)
+	db.SetMaxOpenConns(1)
+	t.Cleanup(func() { db.Close() })
+	_, err = db.Exec(`CREATE TABLE products (id INTEGER PRIMARY KEY, name TEXT, price_cents INTEGER, status TEXT)`)
+	require.NoError(t, err)
+	// 價格只有 5 種、名稱只有 7 種，有大量相同的排序值
+	for i := 1; i <= 60; i++ {
+		status := "active"
+		if i%4 == 0 {
+			status = "archived"
+		}
+		insert(t, db, product{ID: int64(i), Name: fmt.Sprintf("item-%d", i%7), Price: int64(i%5) * 100, Status: status})
+	}
+	return db
+}
+
+func insert(t *testing.T, db *sql.DB, p product) {
+	_, err := db.Exec(`INSERT INTO products VALUES ($1, $2, $3, $4)`, p.ID, p.Name, p.Price, p.Status)
+	require.NoError(t, err)
+}
+
+func list(t *testing.T, db *sql.DB, q url.Values) (Page[product], error) {
+	spec, err := schema.Parse(q)
+	if err != nil {
+		return Page[product]{}, err
+	}
+	query, args := spec.Query("id, name, price_cents, status", "products")
+	rows, err := db.QueryContext(context.Background(), query, args...)
+	require.NoError(t, err, query)
+	defer rows.Close()
+	var out []product
+	for rows.Next() {
+		var p product
+		require.NoError(t, rows.Scan(&p.ID, &p.Name, &p.Price, &p.Status))
+		out = append(out, p)
+	}
+	require.NoError(t, rows.Err())
+	return NewPage(spec, out, product.value), nil
+}
+
+// all 一頁一頁讀到最後
+func all(t *testing.T, db *sql.DB, q url.Values, between func()) []product {
+	var out []product
+	for pages := 0; ; pages++ {
+		require.Less(t, pages, 100)
+		page, err := list(t, db, q)
+		require.NoError(t, err)
+		out = append(out, page.Items...)
+		if page.NextCursor == "" {
+			return out
+		}
+		q.Set("cursor", page.NextCursor)
+		if between != nil {
+			between()
+		}
+	}
+}
+
+func TestKeysetPagination(t *testing.T) {
+	db := setup(t)
+	everything := all(t, db, url.Values{"limit": {"50"}}, nil)
+	require.Len(t, everything, 60)
+
+	for _, sortParam := range []string{"id", "-id", "-price,name", "name,-price", "price,-name,id"} {
+		t.Run(sortParam, func(t *testing.T) {
+			got := all(t, db, url.Values{"sort": {sortParam}, "limit": {"7"}}, nil)
+
+			want := append([]product(nil), everything...)
+			spec, _ := schema.Parse(url.Values{"sort": {sortParam}})
+			sort.SliceStable(want, func(i, j int) bool {
+				for _, s := range spec.Sort {
+					a, b := fmt.Sprint(want[i].value(s.Param)), fmt.Sprint(want[j].value(s.Param))
+					if s.Column.Kind == Int {
+						a, b = fmt.Sprintf("%010s", a), fmt.Sprintf("%010s", b)
+					}
+					if a != b {
+						return (a < b) != s.Desc
+					}
+				}
+				return false
+			})
+			assert.Equal(t, want, got)
+		})
+	}
+}
+
+func TestFilters(t *testing.T) {
+	db := setup(t)
+	got := all(t, db, url.Values{"status": {"active"}, "price[gte]": {"200"}, "price[in]": {"200,400"}, "sort": {"-price"}}, nil)
+	require.NotEmpty(t, got)
+	for _, p := range got {
+		assert.Equal(t, "active", p.Status)
+		assert.Contains(t, []int64{200, 400}, p.Price)
+	}
+	assert.Len(t, got, 18)
+	assert.Equal(t, int64(400), got[0].Price)
+}
+
+// 翻頁期間插入的資料不會讓已經看過的列重複出現
+func TestStableUnderInserts(t *testing.T) {
+	db := setup(t)
+	next := int64(-1)
+	got := all(t, db, url.Values{"sort": {"price"}}, func() {
+		insert(t, db, product{ID: next, Name: "new", Price: 0, Status: "active"}) // 排在已經讀過的範圍
+		next--
+	})
+	seen := make(map[int64]bool)
+	for _, p := range got {
+		require.False(t, seen[p.ID], "duplicate %d", p.ID)
+		seen[p.ID] = true
+	}
+	assert.Len(t, got, 60)
+}
+
+func TestParseErrors(t *testing.T) {
+	for _, q := range []url.Values{
+		{"limit": {"0"}},
+		{"limit": {"51"}},
+		{"limit": {"ten"}},
+		{"sort": {"status"}},
+		{"sort": {"price,-price"}},
+		{"price[like]": {"1"}},
+		{"price": {"cheap"}},
+		{"price[in]": {"1,x"}},
+	} {
+		_, err := schema.Parse(q)
+		assert.ErrorIs(t, err, ErrInvalidParam, q.Encode())
+		assert.Equal(t, 400, httpbind.ProblemFor(err).Status)
+	}
+}
+
+func TestInvalidCursor(t *testing.T) {
+	db := setup(t)
+	page, err := list(t, db, url.Values{"sort": {"-price"}, "status": {"active"}})
+	require.NoError(t, err)
+	valid := page.NextCursor
+	require.NotEmpty(t, valid)
+
+	spec, _ := schema.Parse(url.Values{"sort": {"-price"}, "status": {"active"}})
+	b64 := func(s string) string { return base64.RawURLEncoding.EncodeToString([]byte(s)) }
+	for name, q := range map[string]url.Values{
+		"not base64":      {"sort": {"-price"}, "status": {"active"}, "cursor": {"%%%"}},
+		"not json":        {"sort": {"-price"}, "status": {"active"}, "cursor": {b64("hello")}},
+		"other sort":      {"sort": {"price"}, "status": {"active"}, "cursor": {valid}},
+		"other filter":    {"sort": {"-price"}, "status": {"archived"}, "cursor": {valid}},
+		"tampered values": {"sort": {"-price"}, "status": {"active"}, "cursor": {b64(`{"v":["100"],"f":"` + fingerprint(spec) + `"}`)}},
+		"wrong type":      {"sort": {"-price"}, "status": {"active"}, "cursor": {b64(`{"v":["x","1"],"f":"` + fingerprint(spec) + `"}`)}},
+	} {
+		_, err := list(t, db, q)
+		assert.ErrorIs(t, err, ErrInvalidCursor, name)
+		p := httpbind.ProblemFor(err)
+		assert.Equal(t, 400, p.Status, name)
+		assert.Equal(t, "cursor", p.Errors[0].Field, name)
+	}
+
+	// 換每頁筆數時 cursor 仍然有效
+	_, err = list(t, db, url.Values{"sort": {"-price"}, "status": {"active"}, "limit": {"10"}, "cursor": {valid}})
+	assert.NoError(t, err)
+}
+
+func TestQuery(t *testing.T) {
+	spec, err := schema.Parse(url.Values{"sort": {"-price,name"}, "status": {"active"}})
+	require.NoError(t, err)
+	spec.After = []any{int64(300), "b", int64(9)}
+	q, args := spec.Query("*", "products")
+	assert.Equal(t, "SELECT * FROM products WHERE status = $1 AND "+
+		"((price_cents < $2) OR (price_cents = $3 AND name > $4) OR (price_cents = $5 AND name = $6 AND id > $7)) "+
+		"ORDER BY price_cents DESC, name ASC, id ASC LIMIT 6", q)
+	assert.Equal(t, []any{"active", int64(300), int64(300), "b", int64(300), "b", int64(9)}, args)
+}
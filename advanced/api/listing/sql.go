@@ -0,0 +1,71 @@
+package listing
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Where 回傳過濾與 keyset 條件（不含 WHERE 關鍵字），placeholder 從 $start 開始編號
+func (s Spec) Where(start int) (string, []any) {
+	var (
+		conds []string
+		args  []any
+	)
+	ph := func(v any) string {
+		args = append(args, v)
+		return fmt.Sprintf("$%d", start+len(args)-1)
+	}
+	for _, f := range s.Filters {
+		if f.Op == In {
+			phs := make([]string, len(f.Values))
+			for i, v := range f.Values {
+				phs[i] = ph(v)
+			}
+			conds = append(conds, fmt.Sprintf("%s IN (%s)", f.Column.Name, strings.Join(phs, ", ")))
+			continue
+		}
+		conds = append(conds, fmt.Sprintf("%s %s %s", f.Column.Name, sqlOps[f.Op], ph(f.Values[0])))
+	}
+	if s.After != nil {
+		// (a, b, c) 排在 cursor 之後，方向可以不同，所以展開成：
+		// a > ? OR (a = ? AND b < ?) OR (a = ? AND b = ? AND c > ?)
+		var ors []string
+		for i, col := range s.Sort {
+			var ands []string
+			for j := 0; j < i; j++ {
+				ands = append(ands, fmt.Sprintf("%s = %s", s.Sort[j].Column.Name, ph(s.After[j])))
+			}
+			op := ">"
+			if col.Desc {
+				op = "<"
+			}
+			ands = append(ands, fmt.Sprintf("%s %s %s", col.Column.Name, op, ph(s.After[i])))
+			ors = append(ors, "("+strings.Join(ands, " AND ")+")")
+		}
+		conds = append(conds, "("+strings.Join(ors, " OR ")+")")
+	}
+	return strings.Join(conds, " AND "), args
+}
+
+// OrderBy 回傳 ORDER BY 的內容（不含關鍵字）
+func (s Spec) OrderBy() string {
+	parts := make([]string, len(s.Sort))
+	for i, o := range s.Sort {
+		dir := "ASC"
+		if o.Desc {
+			dir = "DESC"
+		}
+		parts[i] = o.Column.Name + " " + dir
+	}
+	return strings.Join(parts, ", ")
+}
+
+// Query 組出完整的 SELECT；LIMIT 多取一筆，讓 NewPage 知道有沒有下一頁
+func (s Spec) Query(columns, table string) (string, []any) {
+	q := "SELECT " + columns + " FROM " + table
+	where, args := s.Where(1)
+	if where != "" {
+		q += " WHERE " + where
+	}
+	return fmt.Sprintf("%s ORDER BY %s LIMIT %d", q, s.OrderBy(), s.Limit+1), args
+}
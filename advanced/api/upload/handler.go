@@ -0,0 +1,44 @@
+package upload
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"advanced/httpbind"
+)
+
+type response struct {
+	Files  []File            `json:"files"`
+	Fields map[string]string `json:"fields,omitempty"`
+}
+
+// Handler 接收 POST 的上傳並回傳 201 與檔案清單；錯誤以 problem+json 回傳
+func Handler(sink Sink, opts ...Option) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			httpbind.Error(w, r, httpbind.NewProblem(http.StatusMethodNotAllowed, r.Method+" not allowed"))
+			return
+		}
+		files, fields, err := Receive(r, sink, opts...)
+		if err != nil {
+			httpbind.Error(w, r, problemFor(err))
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(response{Files: files, Fields: fields})
+	})
+}
+
+func problemFor(err error) error {
+	switch {
+	case errors.Is(err, ErrFileTooLarge), errors.Is(err, ErrTotalTooLarge), errors.Is(err, ErrFieldsTooLarge):
+		return httpbind.NewProblem(http.StatusRequestEntityTooLarge, err.Error())
+	case errors.Is(err, ErrTypeNotAllowed), errors.Is(err, ErrNotMultipart):
+		return httpbind.NewProblem(http.StatusUnsupportedMediaType, err.Error())
+	case errors.Is(err, ErrTooManyFiles), errors.Is(err, ErrMalformed):
+		return httpbind.NewProblem(http.StatusBadRequest, err.Error())
+	}
+	return err
+}
@@ -0,0 +1,82 @@
+package upload
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+
+	"advanced/cas"
+)
+
+// Sink 保存一個檔案，回傳之後取用它的位置；r 回傳錯誤時必須清掉寫到一半的內容
+type Sink interface {
+	Save(name string, r io.Reader) (location string, err error)
+}
+
+// DirSink 把檔案寫到目錄中，檔名為隨機前綴加上清理過的原始檔名
+type DirSink struct {
+	dir string
+}
+
+func NewDirSink(dir string) (*DirSink, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	return &DirSink{dir: dir}, nil
+}
+
+var unsafeChars = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+// safeName 去掉路徑（避免 ../../etc/passwd）與特殊字元
+func safeName(name string) string {
+	name = unsafeChars.ReplaceAllString(filepath.Base(filepath.Clean("/"+name)), "_")
+	if len(name) > 100 {
+		name = name[len(name)-100:]
+	}
+	if name == "" || name == "." || name == "_" {
+		name = "file"
+	}
+	return name
+}
+
+func (s *DirSink) Save(name string, r io.Reader) (string, error) {
+	tmp, err := os.CreateTemp(s.dir, ".upload-*")
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(tmp.Name()) // rename 成功後是 no-op
+	if _, err := io.Copy(tmp, r); err != nil {
+		tmp.Close()
+		return "", err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return "", err
+	}
+	if err := tmp.Close(); err != nil {
+		return "", err
+	}
+	var prefix [8]byte
+	rand.Read(prefix[:])
+	final := hex.EncodeToString(prefix[:]) + "-" + safeName(name)
+	if err := os.Rename(tmp.Name(), filepath.Join(s.dir, final)); err != nil {
+		return "", err
+	}
+	return final, nil
+}
+
+// CASSink 把檔案寫進 cas.Store，位置是內容的 digest；相同內容只存一份
+type CASSink struct {
+	Store *cas.Store
+}
+
+func (s CASSink) Save(name string, r io.Reader) (string, error) {
+	d, _, err := s.Store.Put(r)
+	if err != nil {
+		return "", err
+	}
+	return d.String(), nil
+}
@@ -0,0 +1,234 @@
+package upload
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+
+	"advanced/progress"
+)
+
+/*
+串流處理 multipart/form-data 上傳，檔案不會整個放進記憶體：
+
+	files, fields, err := upload.Receive(r, upload.NewDirSink("/var/uploads"),
+		upload.WithMaxFileSize(10<<20), upload.WithAllowedTypes("image/png", "image/jpeg"))
+
+  - 用 r.MultipartReader() 一個 part 一個 part 讀，而不是 ParseMultipartForm
+    （後者會先把整個 request 讀完，超過 maxMemory 的部分寫到暫存檔，限制檢查要等全部讀完才做得到）
+  - 每個檔案先讀 512 bytes 用 http.DetectContentType 判斷型別，不相信 client 送來的 Content-Type，
+    不在允許清單中就立刻拒絕，剩下的內容不必再讀
+  - 單檔與總量限制在讀取過程中檢查，超過時 Sink 收到錯誤並清掉寫到一半的檔案
+  - Sink 決定檔案存到哪裡：DirSink 寫到目錄（暫存檔 + rename），CASSink 寫進 cas.Store（以 digest 去重）
+  - WithProgress 為每個檔案建立 progress.Reporter（Total 未知為 -1）
+
+發生錯誤時回傳已經存好的檔案與錯誤，由呼叫端決定要保留還是刪除；Handler 把錯誤轉成 problem+json。
+*/
+
+var (
+	ErrNotMultipart   = errors.New("upload: request is not multipart/form-data")
+	ErrFileTooLarge   = errors.New("upload: file too large")
+	ErrTotalTooLarge  = errors.New("upload: request too large")
+	ErrTooManyFiles   = errors.New("upload: too many files")
+	ErrTypeNotAllowed = errors.New("upload: content type not allowed")
+	ErrFieldsTooLarge = errors.New("upload: form fields too large")
+	ErrMalformed      = errors.New("upload: malformed multipart body")
+	errSinkIncomplete = errors.New("upload: sink did not consume the whole file")
+)
+
+// File 是一個存好的檔案
+type File struct {
+	Field       string `json:"field"`
+	Name        string `json:"name"`         // client 提供的檔名，只供顯示
+	ContentType string `json:"content_type"` // 由內容判斷
+	Size        int64  `json:"size"`
+	Location    string `json:"location"` // Sink 回傳的位置：檔名或 digest
+}
+
+type config struct {
+	maxFileSize  int64
+	maxTotalSize int64
+	maxFiles     int
+	maxFieldSize int64
+	allowed      []string
+	updates      chan<- progress.Update
+}
+
+type Option func(*config)
+
+// WithMaxFileSize 設定單一檔案的上限，預設 10 MiB
+func WithMaxFileSize(n int64) Option {
+	return func(c *config) { c.maxFileSize = n }
+}
+
+// WithMaxTotalSize 設定整個 request body 的上限，預設 50 MiB
+func WithMaxTotalSize(n int64) Option {
+	return func(c *config) { c.maxTotalSize = n }
+}
+
+// WithMaxFiles 設定檔案數量上限，預設 10
+func WithMaxFiles(n int) Option {
+	return func(c *config) { c.maxFiles = n }
+}
+
+// WithAllowedTypes 設定允許的 media type（不含參數），結尾為 / 的表示整個類別，例如 "image/"；預設全部允許
+func WithAllowedTypes(types ...string) Option {
+	return func(c *config) { c.allowed = types }
+}
+
+// WithProgress 把每個檔案的進度送到 ch
+func WithProgress(ch chan<- progress.Update) Option {
+	return func(c *config) { c.updates = ch }
+}
+
+func (c *config) allowedType(contentType string) bool {
+	if len(c.allowed) == 0 {
+		return true
+	}
+	mt, _, _ := mime.ParseMediaType(contentType)
+	for _, a := range c.allowed {
+		if mt == a || (a[len(a)-1] == '/' && len(mt) > len(a) && mt[:len(a)] == a) {
+			return true
+		}
+	}
+	return false
+}
+
+// Receive 讀取 r 中所有的 part：檔案交給 sink，其他欄位回傳在 fields 中
+func Receive(r *http.Request, sink Sink, opts ...Option) (files []File, fields map[string]string, err error) {
+	c := config{maxFileSize: 10 << 20, maxTotalSize: 50 << 20, maxFiles: 10, maxFieldSize: 64 << 10}
+	for _, o := range opts {
+		o(&c)
+	}
+	mt, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if mt != "multipart/form-data" {
+		return nil, nil, ErrNotMultipart
+	}
+	if r.ContentLength > c.maxTotalSize {
+		return nil, nil, ErrTotalTooLarge
+	}
+	body := &limitReader{r: r.Body, remaining: c.maxTotalSize, err: ErrTotalTooLarge}
+	r.Body = io.NopCloser(body)
+	mr, err := r.MultipartReader()
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: %v", ErrNotMultipart, err)
+	}
+
+	fields = make(map[string]string)
+	var fieldBytes int64
+	for {
+		part, err := mr.NextPart()
+		if err == io.EOF {
+			return files, fields, nil
+		}
+		if err != nil {
+			return files, fields, body.malformed(err)
+		}
+		if part.FileName() == "" {
+			v, err := io.ReadAll(io.LimitReader(part, c.maxFieldSize-fieldBytes+1))
+			if err != nil {
+				return files, fields, body.malformed(err)
+			}
+			if fieldBytes += int64(len(v)); fieldBytes > c.maxFieldSize {
+				return files, fields, ErrFieldsTooLarge
+			}
+			fields[part.FormName()] = string(v)
+			continue
+		}
+		if len(files) == c.maxFiles {
+			return files, fields, ErrTooManyFiles
+		}
+		f, err := c.receiveFile(part, sink)
+		if err != nil {
+			if body.exceeded && !errors.Is(err, ErrTotalTooLarge) {
+				err = fmt.Errorf("%w: %v", ErrTotalTooLarge, err)
+			}
+			return files, fields, err
+		}
+		files = append(files, f)
+	}
+}
+
+func (c *config) receiveFile(part *multipart.Part, sink Sink) (File, error) {
+	f := File{Field: part.FormName(), Name: part.FileName()}
+	head := make([]byte, 512)
+	n, err := io.ReadFull(part, head)
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		return f, err
+	}
+	head = head[:n]
+	f.ContentType = http.DetectContentType(head)
+	if !c.allowedType(f.ContentType) {
+		return f, fmt.Errorf("%w: %s", ErrTypeNotAllowed, f.ContentType)
+	}
+
+	var src io.Reader = &limitReader{r: io.MultiReader(bytes.NewReader(head), part), remaining: c.maxFileSize, err: ErrFileTooLarge}
+	var rep *progress.Reporter
+	if c.updates != nil {
+		rep = progress.NewReporter(c.updates, f.Name, -1)
+		src = progress.NewReader(src, rep)
+	}
+	counted := &countingReader{r: src}
+	f.Location, err = sink.Save(f.Name, counted)
+	if err == nil && !counted.eof {
+		// Sink 沒有讀到 EOF 就回傳，剩下的內容不算在檔案裡
+		err = errSinkIncomplete
+	}
+	if rep != nil {
+		rep.Finish(err) // 已經 Finish 過（讀到 EOF）時不會重複送出
+	}
+	f.Size = counted.n
+	return f, err
+}
+
+// limitReader 超過 remaining 時回傳 err，而不是像 io.LimitReader 一樣默默截斷
+type limitReader struct {
+	r         io.Reader
+	remaining int64
+	err       error
+	exceeded  bool
+}
+
+func (l *limitReader) Read(p []byte) (int, error) {
+	if l.exceeded {
+		return 0, l.err
+	}
+	if int64(len(p)) > l.remaining+1 {
+		p = p[:l.remaining+1]
+	}
+	n, err := l.r.Read(p)
+	if int64(n) > l.remaining {
+		l.exceeded = true
+		return int(l.remaining), l.err
+	}
+	l.remaining -= int64(n)
+	return n, err
+}
+
+// malformed 把 multipart 解析的錯誤包成 ErrMalformed；超過總量時 multipart 也會因為讀不到結尾而出錯，
+// 這時回報 ErrTotalTooLarge
+func (l *limitReader) malformed(err error) error {
+	if l.exceeded {
+		return fmt.Errorf("%w: %v", l.err, err)
+	}
+	return fmt.Errorf("%w: %v", ErrMalformed, err)
+}
+
+type countingReader struct {
+	r   io.Reader
+	n   int64
+	eof bool
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	if err == io.EOF {
+		c.eof = true
+	}
+	return n, err
+}
@@ -0,0 +1,308 @@
+package upload
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"advanced/cas"
+	"advanced/httpbind"
+	"advanced/progress"
+)
+
+var pngHeader = []byte("\x89PNG\r\n\x1a\n")
+
+type part struct {
+	field, name string
+	body        []byte
+}
+
+// newRequest 組出 multipart request；name 為空的 part 是一般欄位
+func newRequest(t *testing.T, parts ...part) *http.Request {
+	t.Helper()
+	var buf bytes.Buffer
+	mw := multipart.NewWriter(&buf)
+	for _, p := range parts {
+		var w io.Writer
+		var err error
+		if p.name == "" {
+			w, err = mw.CreateFormField(p.field)
+		} else {
+			w, err = mw.CreateFormFile(p.field, p.name)
+		}
+		require.NoError(t, err)
+		w.Write(p.body)
+	}
+	require.NoError(t, mw.Close())
+	r := httptest.NewRequest(http.MethodPost, "/upload", &buf)
+	r.Header.Set("Content-Type", mw.FormDataContentType())
+	return r
+}
+
+// leftovers 回傳目錄中所有檔案名稱
+func leftovers(t *testing.T, dir string) []string {
+	t.Helper()
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	var names []string
+	for _, e := range entries {
+		names = append(names, e.Name())
+	}
+	return names
+}
+
+func TestReceiveDirSink(t *testing.T) {
+	dir := t.TempDir()
+	sink, err := NewDirSink(dir)
+	require.NoError(t, err)
+
+	img := append(append([]byte{}, pngHeader...), bytes.Repeat([]byte{1}, 2000)...)
+	r := newRequest(t,
+		part{field: "title", body: []byte("holiday")},
+		part{field: "photo", name: "../../etc/a b.png", body: img},
+		part{field: "notes", name: "notes.txt", body: []byte("hello")},
+	)
+	files, fields, err := Receive(r, sink)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"title": "holiday"}, fields)
+	require.Len(t, files, 2)
+
+	assert.Equal(t, "photo", files[0].Field)
+	assert.Equal(t, "image/png", files[0].ContentType)
+	assert.EqualValues(t, len(img), files[0].Size)
+	assert.True(t, strings.HasSuffix(files[0].Location, "-a_b.png"), files[0].Location)
+	got, err := os.ReadFile(filepath.Join(dir, files[0].Location))
+	require.NoError(t, err)
+	assert.Equal(t, img, got)
+
+	assert.Equal(t, "text/plain; charset=utf-8", files[1].ContentType)
+	assert.EqualValues(t, 5, files[1].Size)
+	assert.Len(t, leftovers(t, dir), 2, "no temp files left behind")
+}
+
+func TestReceiveCASSink(t *testing.T) {
+	store, err := cas.New(t.TempDir())
+	require.NoError(t, err)
+	r := newRequest(t,
+		part{field: "a", name: "a.txt", body: []byte("same content")},
+		part{field: "b", name: "b.txt", body: []byte("same content")},
+	)
+	files, _, err := Receive(r, CASSink{Store: store})
+	require.NoError(t, err)
+	require.Len(t, files, 2)
+	assert.Equal(t, files[0].Location, files[1].Location, "same content, same digest")
+
+	d, err := cas.ParseDigest(files[0].Location)
+	require.NoError(t, err)
+	got, err := store.Get(d)
+	require.NoError(t, err)
+	assert.Equal(t, "same content", string(got))
+	all, err := store.List()
+	require.NoError(t, err)
+	assert.Len(t, all, 1)
+}
+
+func TestLimits(t *testing.T) {
+	t.Run("file", func(t *testing.T) {
+		dir := t.TempDir()
+		sink, _ := NewDirSink(dir)
+		r := newRequest(t,
+			part{field: "ok", name: "ok.txt", body: bytes.Repeat([]byte("a"), 100)},
+			part{field: "big", name: "big.txt", body: bytes.Repeat([]byte("a"), 101)},
+		)
+		files, _, err := Receive(r, sink, WithMaxFileSize(100))
+		assert.ErrorIs(t, err, ErrFileTooLarge)
+		require.Len(t, files, 1, "files saved before the error are returned")
+		assert.Len(t, leftovers(t, dir), 1, "partial file removed")
+	})
+
+	t.Run("total", func(t *testing.T) {
+		dir := t.TempDir()
+		sink, _ := NewDirSink(dir)
+		r := newRequest(t,
+			part{field: "a", name: "a.txt", body: bytes.Repeat([]byte("a"), 600)},
+			part{field: "b", name: "b.txt", body: bytes.Repeat([]byte("b"), 600)},
+		)
+		r.ContentLength = -1 // 不知道長度時只能邊讀邊檢查
+		files, _, err := Receive(r, sink, WithMaxTotalSize(1000))
+		assert.ErrorIs(t, err, ErrTotalTooLarge)
+		assert.Len(t, files, 1)
+		assert.Len(t, leftovers(t, dir), 1)
+
+		// Content-Length 已經超過時不讀 body
+		r = newRequest(t, part{field: "a", name: "a.txt", body: bytes.Repeat([]byte("a"), 2000)})
+		_, _, err = Receive(r, sink, WithMaxTotalSize(1000))
+		assert.ErrorIs(t, err, ErrTotalTooLarge)
+	})
+
+	t.Run("count", func(t *testing.T) {
+		sink, _ := NewDirSink(t.TempDir())
+		r := newRequest(t,
+			part{field: "a", name: "a.txt", body: []byte("a")},
+			part{field: "b", name: "b.txt", body: []byte("b")},
+			part{field: "c", name: "c.txt", body: []byte("c")},
+		)
+		files, _, err := Receive(r, sink, WithMaxFiles(2))
+		assert.ErrorIs(t, err, ErrTooManyFiles)
+		assert.Len(t, files, 2)
+	})
+
+	t.Run("fields", func(t *testing.T) {
+		sink, _ := NewDirSink(t.TempDir())
+		r := newRequest(t, part{field: "big", body: bytes.Repeat([]byte("x"), 65<<10)})
+		_, _, err := Receive(r, sink)
+		assert.ErrorIs(t, err, ErrFieldsTooLarge)
+	})
+}
+
+func TestContentTypeSniffing(t *testing.T) {
+	dir := t.TempDir()
+	sink, _ := NewDirSink(dir)
+	allow := WithAllowedTypes("image/png", "image/jpeg")
+
+	files, _, err := Receive(newRequest(t, part{field: "f", name: "x.png", body: pngHeader}), sink, allow)
+	require.NoError(t, err)
+	assert.Equal(t, "image/png", files[0].ContentType)
+
+	// 副檔名與 client 宣稱的型別都不可信，只看內容
+	_, _, err = Receive(newRequest(t, part{field: "f", name: "evil.png", body: []byte("<html><script>")}), sink, allow)
+	assert.ErrorIs(t, err, ErrTypeNotAllowed)
+	assert.Len(t, leftovers(t, dir), 1)
+
+	// 結尾為 / 表示整個類別
+	_, _, err = Receive(newRequest(t, part{field: "f", name: "x.png", body: pngHeader}), sink, WithAllowedTypes("image/"))
+	assert.NoError(t, err)
+	_, _, err = Receive(newRequest(t, part{field: "f", name: "x.txt", body: []byte("text")}), sink, WithAllowedTypes("image/"))
+	assert.ErrorIs(t, err, ErrTypeNotAllowed)
+}
+
+func TestNotMultipart(t *testing.T) {
+	sink, _ := NewDirSink(t.TempDir())
+	r := httptest.NewRequest(http.MethodPost, "/upload", strings.NewReader(`{}`))
+	r.Header.Set("Content-Type", "application/json")
+	_, _, err := Receive(r, sink)
+	assert.ErrorIs(t, err, ErrNotMultipart)
+
+	r = httptest.NewRequest(http.MethodPost, "/upload", strings.NewReader("garbage"))
+	r.Header.Set("Content-Type", "multipart/form-data; boundary=xyz")
+	_, _, err = Receive(r, sink)
+	assert.ErrorIs(t, err, ErrMalformed)
+}
+
+func TestProgress(t *testing.T) {
+	sink, _ := NewDirSink(t.TempDir())
+	updates := make(chan progress.Update, 100)
+	r := newRequest(t,
+		part{field: "a", name: "a.bin", body: bytes.Repeat([]byte("a"), 3000)},
+		part{field: "b", name: "b.bin", body: bytes.Repeat([]byte("b"), 10)},
+	)
+	_, _, err := Receive(r, sink, WithProgress(updates))
+	require.NoError(t, err)
+	close(updates)
+
+	final := map[string]progress.Update{}
+	for u := range updates {
+		if u.Finished {
+			_, dup := final[u.Task]
+			assert.False(t, dup, "finished once per file")
+			final[u.Task] = u
+		}
+	}
+	require.Len(t, final, 2)
+	assert.EqualValues(t, 3000, final["a.bin"].Done)
+	assert.EqualValues(t, 10, final["b.bin"].Done)
+	assert.EqualValues(t, -1, final["a.bin"].Total)
+	assert.NoError(t, final["a.bin"].Err)
+}
+
+func TestSafeName(t *testing.T) {
+	for in, want := range map[string]string{
+		"photo.png":                       "photo.png",
+		"../../etc/passwd":                "passwd",
+		`..\..\windows.ini`:               ".._.._windows.ini",
+		"a b/c d.txt":                     "c_d.txt",
+		"":                                "file",
+		"..":                              "file",
+		"/":                               "file",
+		"照片.jpg":                          "_.jpg",
+		strings.Repeat("x", 200) + ".txt": strings.Repeat("x", 96) + ".txt",
+	} {
+		assert.Equal(t, want, safeName(in), in)
+	}
+}
+
+// 上傳 32 MiB 的檔案，記憶體配置量應該遠小於檔案大小
+func TestStreamingMemory(t *testing.T) {
+	const size = 32 << 20
+	sink, _ := NewDirSink(t.TempDir())
+
+	pr, pw := io.Pipe()
+	mw := multipart.NewWriter(pw)
+	go func() {
+		w, _ := mw.CreateFormFile("f", "big.bin")
+		chunk := bytes.Repeat([]byte{0xAB}, 32<<10)
+		for i := 0; i < size/len(chunk); i++ {
+			if _, err := w.Write(chunk); err != nil {
+				pw.CloseWithError(err)
+				return
+			}
+		}
+		pw.CloseWithError(mw.Close())
+	}()
+	r := httptest.NewRequest(http.MethodPost, "/upload", pr)
+	r.Header.Set("Content-Type", mw.FormDataContentType())
+
+	var before, after runtime.MemStats
+	runtime.ReadMemStats(&before)
+	files, _, err := Receive(r, sink, WithMaxFileSize(64<<20))
+	runtime.ReadMemStats(&after)
+	require.NoError(t, err)
+	assert.EqualValues(t, size, files[0].Size)
+
+	alloc := after.TotalAlloc - before.TotalAlloc
+	t.Logf("allocated %d KiB for a %d MiB upload", alloc>>10, size>>20)
+	assert.Less(t, alloc, uint64(size/4))
+}
+
+func TestHandler(t *testing.T) {
+	sink, _ := NewDirSink(t.TempDir())
+	h := Handler(sink, WithMaxFileSize(100), WithAllowedTypes("text/"))
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, newRequest(t, part{field: "note", name: "n.txt", body: []byte("hi")}, part{field: "k", body: []byte("v")}))
+	require.Equal(t, http.StatusCreated, rec.Code)
+	var resp response
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&resp))
+	require.Len(t, resp.Files, 1)
+	assert.Equal(t, "n.txt", resp.Files[0].Name)
+	assert.Equal(t, "v", resp.Fields["k"])
+
+	for name, tc := range map[string]struct {
+		r    *http.Request
+		want int
+	}{
+		"too large":     {newRequest(t, part{field: "f", name: "f.txt", body: bytes.Repeat([]byte("a"), 101)}), http.StatusRequestEntityTooLarge},
+		"wrong type":    {newRequest(t, part{field: "f", name: "f.txt", body: pngHeader}), http.StatusUnsupportedMediaType},
+		"not multipart": {httptest.NewRequest(http.MethodPost, "/", strings.NewReader("{}")), http.StatusUnsupportedMediaType},
+		"method":        {httptest.NewRequest(http.MethodGet, "/", nil), http.StatusMethodNotAllowed},
+	} {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, tc.r)
+		assert.Equal(t, tc.want, rec.Code, name)
+		assert.Equal(t, "application/problem+json", rec.Header().Get("Content-Type"), name)
+		var p httpbind.Problem
+		require.NoError(t, json.NewDecoder(rec.Body).Decode(&p), name)
+		assert.Equal(t, tc.want, p.Status, name)
+	}
+}
@@ -0,0 +1,243 @@
+package appkit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+)
+
+/*
+把 main() 裡「連 DB → 建 cache → 起 HTTP server → 跑排程」的接線正式化：
+
+	app := appkit.New(appkit.WithStopTimeout(10 * time.Second))
+	app.Add("db", db)
+	app.Add("cache", cache, appkit.DependsOn("db"))
+	app.Add("http", appkit.HTTPServer(srv, ln), appkit.DependsOn("db", "cache"))
+	app.Add("scheduler", appkit.Background(sched.Run), appkit.DependsOn("db"))
+	err := app.Run(ctx)
+
+這是 dependency injection 的另一半：建構時把 *sql.DB 注入給需要的人，
+appkit 負責的是「生命週期」—— 依賴的服務要先啟動好、後停止。
+
+  - Run 依拓撲排序（相同層級依註冊順序）逐一 Start，前一個 Start 回傳後才啟動下一個，
+    所以 Start 應該在服務「可以使用」時才回傳（例如已經 Listen、已經 Ping 過 DB）
+  - 啟動失敗：停止已經啟動的服務，回傳啟動錯誤
+  - 執行中：等 ctx 被取消（收到 signal），或任一個服務回報致命錯誤（實作 Failer）
+  - 停止：依啟動的相反順序逐一 Stop，每個 Stop 最多等 StopTimeout；
+    Stop 失敗不會中斷後面的 Stop，所有錯誤合併回傳
+
+Run 回傳 nil 表示因 ctx 取消而正常結束。依賴不存在或有環時 Run 直接回傳錯誤，不啟動任何服務。
+*/
+
+var (
+	ErrUnknownDependency = errors.New("appkit: unknown dependency")
+	ErrCycle             = errors.New("appkit: dependency cycle")
+	ErrDuplicate         = errors.New("appkit: duplicate service")
+)
+
+// Service 的 Start 在服務可以使用時回傳；長時間的工作應該在背景執行，由 Stop 結束
+type Service interface {
+	Start(ctx context.Context) error
+	Stop(ctx context.Context) error
+}
+
+// Failer 是可選的介面：服務在執行中意外結束時，從 Failed 回傳的 channel 送出錯誤
+type Failer interface {
+	Failed() <-chan error
+}
+
+type entry struct {
+	name string
+	svc  Service
+	deps []string
+}
+
+type AddOption func(*entry)
+
+// DependsOn 宣告 names 必須先啟動、後停止
+func DependsOn(names ...string) AddOption {
+	return func(e *entry) { e.deps = append(e.deps, names...) }
+}
+
+type config struct {
+	stopTimeout time.Duration
+	logger      *slog.Logger
+}
+
+type Option func(*config)
+
+// WithStopTimeout 設定每個服務 Stop 的時限，預設 30 秒
+func WithStopTimeout(d time.Duration) Option {
+	return func(c *config) { c.stopTimeout = d }
+}
+
+// WithLogger 記錄每個服務的啟動與停止，預設不記錄
+func WithLogger(l *slog.Logger) Option {
+	return func(c *config) { c.logger = l }
+}
+
+type App struct {
+	cfg      config
+	services []*entry
+	err      error // Add 的錯誤延後到 Run 回傳，讓接線的程式碼保持簡潔
+}
+
+func New(opts ...Option) *App {
+	cfg := config{stopTimeout: 30 * time.Second}
+	for _, o := range opts {
+		o(&cfg)
+	}
+	return &App{cfg: cfg}
+}
+
+// Add 註冊服務；名稱重複的錯誤由 Run 回傳
+func (a *App) Add(name string, svc Service, opts ...AddOption) {
+	for _, e := range a.services {
+		if e.name == name && a.err == nil {
+			a.err = fmt.Errorf("%w: %q", ErrDuplicate, name)
+		}
+	}
+	e := &entry{name: name, svc: svc}
+	for _, o := range opts {
+		o(e)
+	}
+	a.services = append(a.services, e)
+}
+
+// Order 回傳啟動順序
+func (a *App) Order() ([]string, error) {
+	order, err := a.order()
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, len(order))
+	for i, e := range order {
+		names[i] = e.name
+	}
+	return names, nil
+}
+
+// order 用 Kahn's algorithm 排序；每一輪依註冊順序挑出所有依賴都已排入的服務
+func (a *App) order() ([]*entry, error) {
+	if a.err != nil {
+		return nil, a.err
+	}
+	byName := make(map[string]*entry, len(a.services))
+	for _, e := range a.services {
+		byName[e.name] = e
+	}
+	for _, e := range a.services {
+		for _, d := range e.deps {
+			if _, ok := byName[d]; !ok {
+				return nil, fmt.Errorf("%w: %q depends on %q", ErrUnknownDependency, e.name, d)
+			}
+		}
+	}
+	placed := make(map[string]bool, len(a.services))
+	order := make([]*entry, 0, len(a.services))
+	for len(order) < len(a.services) {
+		var ready []*entry
+		for _, e := range a.services {
+			if placed[e.name] {
+				continue
+			}
+			ok := true
+			for _, d := range e.deps {
+				ok = ok && placed[d]
+			}
+			if ok {
+				ready = append(ready, e)
+			}
+		}
+		if len(ready) == 0 {
+			var rest []string
+			for _, e := range a.services {
+				if !placed[e.name] {
+					rest = append(rest, e.name)
+				}
+			}
+			return nil, fmt.Errorf("%w among %q", ErrCycle, rest)
+		}
+		for _, e := range ready {
+			placed[e.name] = true
+			order = append(order, e)
+		}
+	}
+	return order, nil
+}
+
+func (a *App) log(msg string, args ...any) {
+	if a.cfg.logger != nil {
+		a.cfg.logger.Info(msg, args...)
+	}
+}
+
+// Run 啟動所有服務，等 ctx 被取消或第一個致命錯誤，再依相反順序停止
+func (a *App) Run(ctx context.Context) error {
+	order, err := a.order()
+	if err != nil {
+		return err
+	}
+
+	type failure struct {
+		name string
+		err  error
+	}
+	failed := make(chan failure, 1)
+	stopping := make(chan struct{})
+	defer close(stopping)
+
+	var started []*entry
+	var cause error
+	for _, e := range order {
+		if err := ctx.Err(); err != nil {
+			break // 啟動途中收到 signal：停止已經啟動的部分
+		}
+		start := time.Now()
+		if err := e.svc.Start(ctx); err != nil {
+			cause = fmt.Errorf("appkit: start %q: %w", e.name, err)
+			break
+		}
+		a.log("started", "service", e.name, "took", time.Since(start))
+		started = append(started, e)
+		if f, ok := e.svc.(Failer); ok {
+			go func(name string, ch <-chan error) {
+				select {
+				case err := <-ch:
+					select {
+					case failed <- failure{name, err}:
+					default: // 已經有別的服務先失敗了
+					}
+				case <-stopping:
+				}
+			}(e.name, f.Failed())
+		}
+	}
+
+	if cause == nil && len(started) == len(order) {
+		select {
+		case <-ctx.Done():
+			a.log("shutting down", "reason", context.Cause(ctx))
+		case f := <-failed:
+			cause = fmt.Errorf("appkit: %q failed: %w", f.name, f.err)
+			a.log("shutting down", "reason", cause)
+		}
+	}
+
+	errs := []error{cause}
+	for i := len(started) - 1; i >= 0; i-- {
+		e := started[i]
+		// 原本的 ctx 可能已經取消，Stop 用獨立的時限
+		stopCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), a.cfg.stopTimeout)
+		err := e.svc.Stop(stopCtx)
+		cancel()
+		if err != nil {
+			errs = append(errs, fmt.Errorf("appkit: stop %q: %w", e.name, err))
+			continue
+		}
+		a.log("stopped", "service", e.name)
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,184 @@
+package appkit
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type journal struct {
+	mu     sync.Mutex
+	events []string
+}
+
+func (j *journal) add(s string) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.events = append(j.events, s)
+}
+
+func (j *journal) get() []string {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return append([]string(nil), j.events...)
+}
+
+func (j *journal) service(name string, startErr error) Service {
+	return Hook{
+		OnStart: func(context.Context) error {
+			if startErr != nil {
+				return startErr
+			}
+			j.add("start " + name)
+			return nil
+		},
+		OnStop: func(context.Context) error {
+			j.add("stop " + name)
+			return nil
+		},
+	}
+}
+
+func TestOrder(t *testing.T) {
+	j := &journal{}
+	app := New()
+	app.Add("http", j.service("http", nil), DependsOn("cache", "db"))
+	app.Add("scheduler", j.service("scheduler", nil), DependsOn("db"))
+	app.Add("cache", j.service("cache", nil), DependsOn("db"))
+	app.Add("db", j.service("db", nil))
+
+	order, err := app.Order()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"db", "scheduler", "cache", "http"}, order)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- app.Run(ctx) }()
+	require.Eventually(t, func() bool { return len(j.get()) == 4 }, time.Second, time.Millisecond)
+	cancel()
+	require.NoError(t, <-done)
+	assert.Equal(t, []string{
+		"start db", "start scheduler", "start cache", "start http",
+		"stop http", "stop cache", "stop scheduler", "stop db",
+	}, j.get())
+}
+
+func TestInvalidGraph(t *testing.T) {
+	j := &journal{}
+	app := New()
+	app.Add("a", j.service("a", nil), DependsOn("b"))
+	app.Add("b", j.service("b", nil), DependsOn("c"))
+	app.Add("c", j.service("c", nil), DependsOn("a"))
+	app.Add("d", j.service("d", nil))
+	assert.ErrorIs(t, app.Run(context.Background()), ErrCycle)
+
+	app = New()
+	app.Add("a", j.service("a", nil), DependsOn("missing"))
+	assert.ErrorIs(t, app.Run(context.Background()), ErrUnknownDependency)
+
+	app = New()
+	app.Add("a", j.service("a", nil))
+	app.Add("a", j.service("a", nil))
+	assert.ErrorIs(t, app.Run(context.Background()), ErrDuplicate)
+	assert.Empty(t, j.get(), "圖有錯時不啟動任何服務")
+}
+
+func TestStartFailureStopsStarted(t *testing.T) {
+	j := &journal{}
+	boom := errors.New("connection refused")
+	app := New()
+	app.Add("db", j.service("db", nil))
+	app.Add("cache", j.service("cache", boom), DependsOn("db"))
+	app.Add("http", j.service("http", nil), DependsOn("cache"))
+
+	err := app.Run(context.Background())
+	assert.ErrorIs(t, err, boom)
+	assert.Contains(t, err.Error(), `start "cache"`)
+	assert.Equal(t, []string{"start db", "stop db"}, j.get())
+}
+
+func TestFatalErrorAndStopErrors(t *testing.T) {
+	j := &journal{}
+	crash := errors.New("consumer lost connection")
+	release := make(chan struct{})
+	stopErr := errors.New("flush failed")
+
+	app := New()
+	app.Add("db", Hook{OnStop: func(context.Context) error { j.add("stop db"); return stopErr }})
+	app.Add("consumer", Background(func(ctx context.Context) error {
+		<-release
+		return crash
+	}), DependsOn("db"))
+	app.Add("scheduler", Background(func(ctx context.Context) error {
+		<-ctx.Done()
+		j.add("stop scheduler")
+		return ctx.Err()
+	}), DependsOn("db"))
+
+	done := make(chan error)
+	go func() { done <- app.Run(context.Background()) }()
+	close(release)
+	err := <-done
+	assert.ErrorIs(t, err, crash)
+	assert.ErrorIs(t, err, stopErr, "Stop 的錯誤也一起回傳")
+	assert.Contains(t, err.Error(), `"consumer" failed`)
+	assert.Equal(t, []string{"stop scheduler", "stop db"}, j.get())
+}
+
+func TestStopTimeout(t *testing.T) {
+	app := New(WithStopTimeout(20 * time.Millisecond))
+	app.Add("stuck", Hook{OnStop: func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	}})
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	// 啟動前就取消：不啟動任何服務
+	assert.NoError(t, app.Run(ctx))
+
+	ctx, cancel = context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, app.Run(ctx), context.DeadlineExceeded)
+}
+
+func TestHTTPServer(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	srv := &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, "ok")
+	})}
+	app := New()
+	app.Add("http", HTTPServer(srv, ln))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- app.Run(ctx) }()
+	require.Eventually(t, func() bool {
+		resp, err := http.Get("http://" + ln.Addr().String())
+		if err != nil {
+			return false
+		}
+		resp.Body.Close()
+		return resp.StatusCode == http.StatusOK
+	}, time.Second, 5*time.Millisecond)
+	cancel()
+	require.NoError(t, <-done)
+	_, err = http.Get("http://" + ln.Addr().String())
+	assert.Error(t, err, "Stop 之後不再接受連線")
+
+	// 位址已被占用：Start 就失敗
+	busy, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer busy.Close()
+	app = New()
+	app.Add("http", HTTPServer(&http.Server{Addr: busy.Addr().String()}, nil))
+	assert.Error(t, app.Run(context.Background()))
+}
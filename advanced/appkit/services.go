@@ -0,0 +1,115 @@
+package appkit
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+)
+
+// Hook 把兩個函式組成 Service，nil 表示不需要做事
+type Hook struct {
+	OnStart func(ctx context.Context) error
+	OnStop  func(ctx context.Context) error
+}
+
+func (h Hook) Start(ctx context.Context) error {
+	if h.OnStart == nil {
+		return nil
+	}
+	return h.OnStart(ctx)
+}
+
+func (h Hook) Stop(ctx context.Context) error {
+	if h.OnStop == nil {
+		return nil
+	}
+	return h.OnStop(ctx)
+}
+
+type background struct {
+	fn     func(ctx context.Context) error
+	cancel context.CancelFunc
+	done   chan struct{}
+	failed chan error
+	err    error
+}
+
+// Background 把「阻塞到 ctx 被取消」的函式（排程器、consumer）包成 Service：
+// Start 在背景執行 fn，Stop 取消 ctx 並等 fn 回傳；Stop 之前 fn 就回傳（包含回傳 nil）視為致命錯誤
+func Background(fn func(ctx context.Context) error) Service {
+	return &background{fn: fn}
+}
+
+var errExited = errors.New("exited before stop")
+
+func (b *background) Start(ctx context.Context) error {
+	ctx, b.cancel = context.WithCancel(context.WithoutCancel(ctx))
+	b.done = make(chan struct{})
+	b.failed = make(chan error, 1)
+	go func() {
+		defer close(b.done)
+		err := b.fn(ctx)
+		if ctx.Err() == nil {
+			// 已經透過 Failed 回報，Stop 不再重複回傳
+			if err == nil {
+				err = errExited
+			}
+			b.failed <- err
+			return
+		}
+		b.err = err
+	}()
+	return nil
+}
+
+func (b *background) Failed() <-chan error { return b.failed }
+
+func (b *background) Stop(ctx context.Context) error {
+	b.cancel()
+	select {
+	case <-b.done:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	if errors.Is(b.err, context.Canceled) {
+		return nil
+	}
+	return b.err
+}
+
+type httpServer struct {
+	srv    *http.Server
+	ln     net.Listener
+	failed chan error
+}
+
+// HTTPServer 在 Start 時開始 Serve（ln 為 nil 時 Listen srv.Addr，位址被占用的錯誤在 Start 就會回傳），
+// Stop 呼叫 Shutdown 等待進行中的 request
+func HTTPServer(srv *http.Server, ln net.Listener) Service {
+	return &httpServer{srv: srv, ln: ln, failed: make(chan error, 1)}
+}
+
+func (h *httpServer) Start(ctx context.Context) error {
+	if h.ln == nil {
+		addr := h.srv.Addr
+		if addr == "" {
+			addr = ":http"
+		}
+		ln, err := net.Listen("tcp", addr)
+		if err != nil {
+			return err
+		}
+		h.ln = ln
+	}
+	go func() {
+		if err := h.srv.Serve(h.ln); !errors.Is(err, http.ErrServerClosed) {
+			h.failed <- err
+		}
+	}()
+	return nil
+}
+
+func (h *httpServer) Failed() <-chan error { return h.failed }
+
+func (h *httpServer) Stop(ctx context.Context) error { return h.srv.Shutdown(ctx) }
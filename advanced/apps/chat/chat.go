@@ -0,0 +1,313 @@
+package chat
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"net"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"golang.org/x/time/rate"
+
+	"advanced/bus"
+	"advanced/kv"
+	"advanced/metrics"
+	"advanced/timex"
+)
+
+/*
+聊天室：把 repo 裡的幾個模組組合成一個完整的服務。
+
+	TCP client ──┐                      ┌── bus（pubsub，topic = chat.<room>）
+	             ├── session ──say──────┤
+	WS client  ──┘     ▲                └── kv（history/<room>/<seq>）
+	                   └──── 訂閱的房間 ◀── bus
+
+  - frontend：TCP（一行一個 JSON）與 WebSocket 共用 Conn 介面，之後的邏輯完全一樣
+  - hub：每個 session 加入房間就是訂閱 bus 的 chat.<room>（不指定 group，每個 session 都收到一份）；
+    換成 bus.NATS 就能跨多台機器
+  - 歷史訊息：存在 kv.Store，每個房間保留最近 N 則，加入房間時補送；
+    WithHistoryFile 時啟動會載入 snapshot、關機時存檔
+  - rate limit：每個使用者一個 token bucket（同一個使用者開多條連線共用）
+  - 慢的 client：每個 session 有固定大小的 outbox，滿了就丟掉新訊息並計數，不會卡住 bus
+  - graceful shutdown：ctx 結束時停止接受新連線，通知所有 client 後關閉，
+    等 session 送完 outbox 再存 history；超過 WithShutdownGrace 強制斷線
+  - metrics：chat.connections、chat.messages、chat.rate_limited、chat.dropped
+
+加入房間時先訂閱再讀歷史，兩者之間的訊息可能同時出現在歷史與即時訊息中，以 seq 去掉重複的那份。
+*/
+
+var ErrServerClosed = errors.New("chat: server closed")
+
+type config struct {
+	bus         bus.Bus
+	registry    *metrics.Registry
+	limit       rate.Limit
+	burst       int
+	historySize int
+	historyFile string
+	outbox      int
+	grace       time.Duration
+	clock       timex.Clock
+}
+
+type Option func(*config)
+
+// WithBus 使用外部的 bus（例如 NATS），預設為 bus.NewMemory；外部的 bus 由呼叫端關閉
+func WithBus(b bus.Bus) Option {
+	return func(c *config) { c.bus = b }
+}
+
+// WithRegistry 設定 metrics registry，預設 metrics.Default
+func WithRegistry(r *metrics.Registry) Option {
+	return func(c *config) { c.registry = r }
+}
+
+// WithRateLimit 設定每個使用者的發言速率，預設每秒 5 則、burst 10
+func WithRateLimit(r rate.Limit, burst int) Option {
+	return func(c *config) { c.limit, c.burst = r, burst }
+}
+
+// WithHistory 設定每個房間保留的歷史訊息數，預設 50
+func WithHistory(n int) Option {
+	return func(c *config) { c.historySize = n }
+}
+
+// WithHistoryFile 啟動時從 path 載入歷史訊息，關機時存回去
+func WithHistoryFile(path string) Option {
+	return func(c *config) { c.historyFile = path }
+}
+
+// WithShutdownGrace 設定關機時等待 client 收完訊息的時間，預設 5 秒
+func WithShutdownGrace(d time.Duration) Option {
+	return func(c *config) { c.grace = d }
+}
+
+// WithClock 設定訊息時間與發言速率限制的時鐘，預設 timex.Real；關機的 grace 一律以實際時間計算
+func WithClock(c timex.Clock) Option {
+	return func(cfg *config) { cfg.clock = c }
+}
+
+type Server struct {
+	cfg      config
+	ownBus   bool
+	history  history
+	seq      atomic.Uint64
+	upgrader websocket.Upgrader
+
+	mu       sync.Mutex
+	limiters map[string]*rate.Limiter
+	pending  map[Conn]struct{} // 還沒送 hello 的連線
+	sessions map[*session]struct{}
+	closing  bool
+	wg       sync.WaitGroup
+
+	connections *metrics.Gauge
+	messages    *metrics.Counter
+	rateLimited *metrics.Counter
+	dropped     *metrics.Counter
+}
+
+func New(opts ...Option) (*Server, error) {
+	cfg := config{
+		registry:    metrics.Default,
+		limit:       5,
+		burst:       10,
+		historySize: 50,
+		outbox:      64,
+		grace:       5 * time.Second,
+		clock:       timex.Real{},
+	}
+	for _, o := range opts {
+		o(&cfg)
+	}
+	s := &Server{
+		cfg:         cfg,
+		limiters:    make(map[string]*rate.Limiter),
+		pending:     make(map[Conn]struct{}),
+		sessions:    make(map[*session]struct{}),
+		connections: cfg.registry.Gauge("chat.connections"),
+		messages:    cfg.registry.Counter("chat.messages"),
+		rateLimited: cfg.registry.Counter("chat.rate_limited"),
+		dropped:     cfg.registry.Counter("chat.dropped"),
+	}
+	if s.cfg.bus == nil {
+		s.cfg.bus = bus.NewMemory()
+		s.ownBus = true
+	}
+	store := kv.New()
+	if cfg.historyFile != "" {
+		if err := store.Load(cfg.historyFile); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return nil, err
+		}
+	}
+	s.history = history{store: store, limit: cfg.historySize}
+	s.seq.Store(s.history.maxSeq())
+	return s, nil
+}
+
+// Serve 在 tcp 與 ws 上接受連線（任一個可以是 nil），直到 ctx 結束後 graceful shutdown
+func (s *Server) Serve(ctx context.Context, tcp, ws net.Listener) error {
+	s.mu.Lock()
+	if s.closing {
+		s.mu.Unlock()
+		return ErrServerClosed
+	}
+	s.mu.Unlock()
+
+	var accept sync.WaitGroup
+	if tcp != nil {
+		accept.Add(1)
+		go func() {
+			defer accept.Done()
+			for {
+				c, err := tcp.Accept()
+				if err != nil {
+					return // listener 被關閉
+				}
+				go s.handle(newLineConn(c))
+			}
+		}()
+	}
+	var httpSrv *http.Server
+	if ws != nil {
+		httpSrv = &http.Server{Handler: s}
+		accept.Add(1)
+		go func() {
+			defer accept.Done()
+			httpSrv.Serve(ws)
+		}()
+	}
+
+	<-ctx.Done()
+	return s.shutdown(tcp, httpSrv, &accept)
+}
+
+func (s *Server) shutdown(tcp net.Listener, httpSrv *http.Server, accept *sync.WaitGroup) error {
+	s.mu.Lock()
+	s.closing = true
+	sessions := make([]*session, 0, len(s.sessions))
+	for sess := range s.sessions {
+		sessions = append(sessions, sess)
+	}
+	for conn := range s.pending {
+		conn.Close() // 還沒送 hello 的連線直接關閉
+	}
+	s.mu.Unlock()
+
+	// 1. 停止接受新連線；hijack 過的 WebSocket 不歸 http.Server 管，下面自己關
+	if tcp != nil {
+		tcp.Close()
+	}
+	if httpSrv != nil {
+		httpSrv.Close()
+	}
+	accept.Wait()
+
+	// 2. 通知每個 client，送完 outbox 後由 writer 關閉連線
+	for _, sess := range sessions {
+		sess.send(Event{Type: TypeSystem, Text: "server shutting down"})
+		sess.closeOutbox()
+	}
+	done, allClosed := context.WithCancel(context.Background())
+	go func() {
+		s.wg.Wait()
+		allClosed()
+	}()
+	// Sleep 回傳 nil 表示 grace 用完時還有 session 沒結束
+	if timex.Sleep(done, s.cfg.grace) == nil {
+		for _, sess := range sessions {
+			sess.conn.Close()
+		}
+		<-done.Done()
+	}
+
+	// 3. 所有 session 都結束後才存檔，不會漏掉最後的訊息
+	var err error
+	if s.cfg.historyFile != "" {
+		err = s.history.store.Save(s.cfg.historyFile)
+	}
+	if s.ownBus {
+		s.cfg.bus.Close()
+	}
+	return err
+}
+
+// ServeHTTP 是 WebSocket frontend
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	conn, err := s.upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		return
+	}
+	s.handle(conn)
+}
+
+func (s *Server) allow(user string) bool {
+	s.mu.Lock()
+	l := s.limiters[user]
+	if l == nil {
+		l = rate.NewLimiter(s.cfg.limit, s.cfg.burst)
+		s.limiters[user] = l
+	}
+	s.mu.Unlock()
+	return l.AllowN(s.cfg.clock.Now(), 1)
+}
+
+// handle 執行一個連線的完整生命週期，連線結束才返回
+func (s *Server) handle(conn Conn) {
+	s.mu.Lock()
+	if s.closing {
+		s.mu.Unlock()
+		conn.WriteJSON(Event{Type: TypeError, Error: ErrServerClosed.Error()})
+		conn.Close()
+		return
+	}
+	s.wg.Add(1)
+	s.pending[conn] = struct{}{}
+	s.mu.Unlock()
+	defer s.wg.Done()
+
+	var hello Event
+	err := conn.ReadJSON(&hello)
+	s.mu.Lock()
+	delete(s.pending, conn)
+	closing := s.closing
+	s.mu.Unlock()
+	if err != nil || closing || hello.Type != TypeHello || hello.User == "" {
+		if !closing {
+			conn.WriteJSON(Event{Type: TypeError, Error: "first event must be hello with a user"})
+		}
+		conn.Close()
+		return
+	}
+
+	sess := newSession(s, conn, hello.User)
+	s.mu.Lock()
+	if s.closing {
+		s.mu.Unlock()
+		conn.Close()
+		return
+	}
+	s.sessions[sess] = struct{}{}
+	s.mu.Unlock()
+	s.connections.Add(1)
+
+	writerDone := make(chan struct{})
+	go func() {
+		defer close(writerDone)
+		sess.writeLoop()
+	}()
+	sess.readLoop()
+
+	sess.leaveAll()
+	s.mu.Lock()
+	delete(s.sessions, sess)
+	s.mu.Unlock()
+	sess.closeOutbox()
+	<-writerDone
+	s.connections.Add(-1)
+}
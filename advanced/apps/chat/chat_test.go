@@ -0,0 +1,256 @@
+package chat
+
+import (
+	"context"
+	"net"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/time/rate"
+
+	"advanced/metrics"
+	"advanced/timex"
+)
+
+type stack struct {
+	tcp  string
+	ws   string
+	reg  *metrics.Registry
+	stop func() error // 觸發 graceful shutdown 並回傳 Serve 的結果
+}
+
+func startStack(t *testing.T, opts ...Option) *stack {
+	t.Helper()
+	reg := metrics.NewRegistry()
+	srv, err := New(append([]Option{WithRegistry(reg)}, opts...)...)
+	require.NoError(t, err)
+	tcp, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	ws, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	errc := make(chan error, 1)
+	go func() { errc <- srv.Serve(ctx, tcp, ws) }()
+	var stopped bool
+	var result error
+	stop := func() error {
+		if !stopped {
+			stopped = true
+			cancel()
+			result = <-errc
+		}
+		return result
+	}
+	t.Cleanup(func() { stop() })
+	return &stack{tcp: tcp.Addr().String(), ws: "ws://" + ws.Addr().String(), reg: reg, stop: stop}
+}
+
+type client struct {
+	t      *testing.T
+	conn   Conn
+	events chan Event
+}
+
+func connect(t *testing.T, conn Conn, user string) *client {
+	t.Helper()
+	c := &client{t: t, conn: conn, events: make(chan Event, 100)}
+	t.Cleanup(func() { conn.Close() })
+	go func() {
+		defer close(c.events)
+		for {
+			var e Event
+			if err := conn.ReadJSON(&e); err != nil {
+				return
+			}
+			c.events <- e
+		}
+	}()
+	c.send(Event{Type: TypeHello, User: user})
+	return c
+}
+
+func (s *stack) tcpClient(t *testing.T, user string) *client {
+	conn, err := DialTCP(s.tcp)
+	require.NoError(t, err)
+	return connect(t, conn, user)
+}
+
+func (s *stack) wsClient(t *testing.T, user string) *client {
+	conn, _, err := websocket.DefaultDialer.Dial(s.ws, nil)
+	require.NoError(t, err)
+	return connect(t, conn, user)
+}
+
+func (c *client) send(e Event) {
+	c.t.Helper()
+	require.NoError(c.t, c.conn.WriteJSON(e))
+}
+
+func (c *client) join(room string) { c.send(Event{Type: TypeJoin, Room: room}) }
+
+func (c *client) say(room, text string) { c.send(Event{Type: TypeSay, Room: room, Text: text}) }
+
+// next 回傳下一個事件；連線關閉時 ok 為 false
+func (c *client) next() (Event, bool) {
+	c.t.Helper()
+	select {
+	case e, ok := <-c.events:
+		return e, ok
+	case <-time.After(2 * time.Second):
+		c.t.Fatal("timed out waiting for event")
+		return Event{}, false
+	}
+}
+
+// texts 讀取 n 則 message 事件，回傳 "user: text"
+func (c *client) texts(n int) []string {
+	c.t.Helper()
+	var out []string
+	for len(out) < n {
+		e, ok := c.next()
+		require.True(c.t, ok, "connection closed")
+		require.Equal(c.t, TypeMessage, e.Type, "%+v", e)
+		out = append(out, e.User+": "+e.Text)
+	}
+	return out
+}
+
+func TestEndToEnd(t *testing.T) {
+	historyFile := filepath.Join(t.TempDir(), "history.snap")
+	clock := timex.NewFake(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	s := startStack(t, WithHistoryFile(historyFile), WithRateLimit(rate.Every(time.Hour), 3), WithClock(clock))
+
+	alice := s.tcpClient(t, "alice")
+	bob := s.wsClient(t, "bob")
+	alice.join("go")
+	bob.join("go")
+	bob.join("rust")
+	alice.say("go", "hi")
+	// bob 若在 alice 發言後才完成加入，會以歷史訊息收到；兩種情況都只收到一次
+	assert.Equal(t, []string{"alice: hi"}, bob.texts(1))
+	assert.Equal(t, []string{"alice: hi"}, alice.texts(1))
+
+	bob.say("go", "hello from ws")
+	bob.say("rust", "only rust")
+	assert.Equal(t, []string{"bob: hello from ws"}, alice.texts(1), "alice 沒有加入 rust")
+	assert.ElementsMatch(t, []string{"bob: hello from ws", "bob: only rust"}, bob.texts(2), "不同房間之間沒有順序")
+
+	// 之後加入的人收到歷史訊息
+	carol := s.tcpClient(t, "carol")
+	carol.join("go")
+	for _, want := range []string{"alice: hi", "bob: hello from ws"} {
+		e, _ := carol.next()
+		assert.True(t, e.History)
+		assert.Equal(t, want, e.User+": "+e.Text)
+		assert.True(t, clock.Now().Equal(e.Time), "stamped by the server clock")
+	}
+
+	// rate limit：每個使用者 burst 3，alice 已經說過 1 句
+	alice.say("go", "2")
+	alice.say("go", "3")
+	alice.say("go", "4")
+	// 錯誤直接送回，訊息則經過 bus，兩者的先後不固定
+	var got []string
+	for i := 0; i < 3; i++ {
+		e, _ := alice.next()
+		got = append(got, e.Type+" "+e.Text+e.Error)
+	}
+	assert.ElementsMatch(t, []string{"message 2", "message 3", "error rate limited"}, got)
+	assert.Equal(t, []string{"alice: 2", "alice: 3"}, carol.texts(2))
+
+	assert.Equal(t, int64(3), s.reg.Gauge("chat.connections").Value())
+	assert.Equal(t, int64(5), s.reg.Counter("chat.messages").Value())
+	assert.Equal(t, int64(1), s.reg.Counter("chat.rate_limited").Value())
+
+	// 一小時後補回一次
+	clock.Advance(time.Hour)
+	alice.say("go", "5")
+	assert.Equal(t, []string{"alice: 5"}, carol.texts(1))
+
+	// graceful shutdown：每個 client 收到通知後連線被關閉，history 存檔
+	require.NoError(t, s.stop())
+	for _, c := range []*client{alice, bob, carol} {
+		var last Event
+		for e := range c.events {
+			last = e
+		}
+		assert.Equal(t, Event{Type: TypeSystem, Text: "server shutting down"}, last)
+	}
+	assert.Zero(t, s.reg.Gauge("chat.connections").Value())
+	assert.FileExists(t, historyFile)
+
+	// 重新啟動：歷史訊息還在，seq 接著編號
+	s2 := startStack(t, WithHistoryFile(historyFile))
+	dave := s2.wsClient(t, "dave")
+	dave.join("go")
+	var seqs []uint64
+	var texts []string
+	for i := 0; i < 5; i++ {
+		e, _ := dave.next()
+		seqs = append(seqs, e.Seq)
+		texts = append(texts, e.Text)
+	}
+	assert.Equal(t, []string{"hi", "hello from ws", "2", "3", "5"}, texts)
+	dave.say("go", "after restart")
+	e, _ := dave.next()
+	assert.Greater(t, e.Seq, seqs[len(seqs)-1])
+}
+
+func TestProtocolErrors(t *testing.T) {
+	s := startStack(t, WithHistory(2))
+
+	conn, err := DialTCP(s.tcp)
+	require.NoError(t, err)
+	anon := connect(t, conn, "")
+	e, _ := anon.next()
+	assert.Equal(t, TypeError, e.Type, "沒有 user 的 hello")
+	_, ok := anon.next()
+	assert.False(t, ok, "連線被關閉")
+
+	c := s.tcpClient(t, "erin")
+	c.join("Bad Room!")
+	c.say("go", "not joined")
+	c.send(Event{Type: "dance"})
+	var errs []string
+	for i := 0; i < 3; i++ {
+		e, _ := c.next()
+		errs = append(errs, e.Error)
+	}
+	assert.Equal(t, []string{`invalid room "Bad Room!"`, `not in room "go"`, `unknown event type "dance"`}, errs)
+
+	// 只保留最近 2 則
+	c.join("go")
+	for _, text := range []string{"1", "2", "3"} {
+		c.say("go", text)
+	}
+	c.texts(3)
+	late := s.wsClient(t, "frank")
+	late.join("go")
+	assert.Equal(t, []string{"erin: 2", "erin: 3"}, late.texts(2))
+}
+
+// TestSlowClient 不讀取的 client 只會讓自己的訊息被丟掉，其他人照常收到
+func TestSlowClient(t *testing.T) {
+	s := startStack(t, WithRateLimit(rate.Inf, 0), WithShutdownGrace(100*time.Millisecond))
+	slow, err := net.Dial("tcp", s.tcp)
+	require.NoError(t, err)
+	defer slow.Close()
+	_, err = slow.Write([]byte(`{"type":"hello","user":"slow"}` + "\n" + `{"type":"join","room":"go"}` + "\n"))
+	require.NoError(t, err)
+
+	fast := s.wsClient(t, "fast")
+	fast.join("go")
+	text := strings.Repeat("x", 64<<10)
+	for i := 0; i < 300; i++ {
+		fast.say("go", text)
+		fast.texts(1)
+	}
+	assert.Positive(t, s.reg.Counter("chat.dropped").Value())
+	require.NoError(t, s.stop(), "卡住的 client 在 grace 之後被強制斷線")
+}
@@ -0,0 +1,78 @@
+package chat
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"advanced/kv"
+)
+
+// history 把每則訊息存成 kv 中的一個 key：history/<room>/<seq 補零到 20 位>，
+// kv.Keys 已經排序，同一個房間的訊息依 seq 排在一起
+type history struct {
+	store *kv.Store
+	limit int // 每個房間保留的則數
+}
+
+func historyKey(room string, seq uint64) string {
+	return fmt.Sprintf("history/%s/%020d", room, seq)
+}
+
+func (h *history) keys(room string) []string {
+	prefix := "history/" + room + "/"
+	var out []string
+	for _, k := range h.store.Keys() {
+		if strings.HasPrefix(k, prefix) {
+			out = append(out, k)
+		}
+	}
+	return out
+}
+
+// append 寫入訊息並刪除超出 limit 的舊訊息
+func (h *history) append(e Event) error {
+	b, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	h.store.Set(historyKey(e.Room, e.Seq), b)
+	keys := h.keys(e.Room)
+	for len(keys) > h.limit {
+		h.store.Delete(keys[0])
+		keys = keys[1:]
+	}
+	return nil
+}
+
+// recent 依 seq 順序回傳房間內保留的訊息
+func (h *history) recent(room string) []Event {
+	var out []Event
+	for _, k := range h.keys(room) {
+		b, ok := h.store.Get(k)
+		if !ok {
+			continue // 剛好被刪掉
+		}
+		var e Event
+		if json.Unmarshal(b, &e) == nil {
+			out = append(out, e)
+		}
+	}
+	return out
+}
+
+// maxSeq 回傳所有房間中最大的 seq，重啟後接著編號
+func (h *history) maxSeq() uint64 {
+	var max uint64
+	for _, k := range h.store.Keys() {
+		i := strings.LastIndexByte(k, '/')
+		if !strings.HasPrefix(k, "history/") || i < 0 {
+			continue
+		}
+		if n, err := strconv.ParseUint(k[i+1:], 10, 64); err == nil && n > max {
+			max = n
+		}
+	}
+	return max
+}
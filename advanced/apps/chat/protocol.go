@@ -0,0 +1,75 @@
+package chat
+
+import (
+	"bufio"
+	"encoding/json"
+	"net"
+	"sync"
+	"time"
+)
+
+// 事件類型
+const (
+	TypeHello   = "hello"   // client → server，連線後第一個事件，帶 User
+	TypeJoin    = "join"    // client → server
+	TypeLeave   = "leave"   // client → server
+	TypeSay     = "say"     // client → server
+	TypeMessage = "message" // server → client，History 為 true 表示是加入時補送的歷史訊息
+	TypeError   = "error"   // server → client
+	TypeSystem  = "system"  // server → client，例如關機通知
+)
+
+// Event 是 TCP（一行一個 JSON）與 WebSocket（一個 frame 一個 JSON）共用的格式
+type Event struct {
+	Type    string    `json:"type"`
+	User    string    `json:"user,omitempty"`
+	Room    string    `json:"room,omitempty"`
+	Text    string    `json:"text,omitempty"`
+	Seq     uint64    `json:"seq,omitempty"`
+	Time    time.Time `json:"time,omitempty"`
+	History bool      `json:"history,omitempty"`
+	Error   string    `json:"error,omitempty"`
+}
+
+// Conn 隱藏 TCP 與 WebSocket 的差異；*websocket.Conn 本身就符合這個介面
+type Conn interface {
+	ReadJSON(v any) error
+	WriteJSON(v any) error
+	Close() error
+}
+
+// lineConn 是以換行分隔的 JSON over TCP
+type lineConn struct {
+	c   net.Conn
+	dec *json.Decoder
+	mu  sync.Mutex
+	w   *bufio.Writer
+	enc *json.Encoder
+}
+
+func newLineConn(c net.Conn) *lineConn {
+	w := bufio.NewWriter(c)
+	return &lineConn{c: c, dec: json.NewDecoder(bufio.NewReader(c)), w: w, enc: json.NewEncoder(w)}
+}
+
+func (l *lineConn) ReadJSON(v any) error { return l.dec.Decode(v) }
+
+func (l *lineConn) WriteJSON(v any) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if err := l.enc.Encode(v); err != nil {
+		return err
+	}
+	return l.w.Flush()
+}
+
+func (l *lineConn) Close() error { return l.c.Close() }
+
+// DialTCP 連線到 TCP frontend，回傳的 Conn 以 JSON 收送 Event
+func DialTCP(addr string) (Conn, error) {
+	c, err := net.Dial("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+	return newLineConn(c), nil
+}
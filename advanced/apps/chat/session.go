@@ -0,0 +1,184 @@
+package chat
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sync"
+
+	"advanced/bus"
+)
+
+var roomName = regexp.MustCompile(`^[a-z0-9][a-z0-9-]{0,31}$`)
+
+func topic(room string) string { return "chat." + room }
+
+type room struct {
+	sub     bus.Subscription
+	history map[uint64]bool // 已經以歷史訊息送出的 seq，即時訊息遇到時略過
+}
+
+type session struct {
+	srv  *Server
+	conn Conn
+	user string
+
+	mu     sync.Mutex // 保護 rooms、closed 與 outbox 的 send
+	rooms  map[string]*room
+	outbox chan Event
+	closed bool
+}
+
+func newSession(s *Server, conn Conn, user string) *session {
+	return &session{srv: s, conn: conn, user: user, rooms: make(map[string]*room), outbox: make(chan Event, s.cfg.outbox)}
+}
+
+func (s *session) send(e Event) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.sendLocked(e)
+}
+
+// sendLocked 不會阻塞：outbox 滿了代表 client 太慢，丟掉這則訊息
+func (s *session) sendLocked(e Event) {
+	if s.closed {
+		return
+	}
+	select {
+	case s.outbox <- e:
+	default:
+		s.srv.dropped.Add(1)
+	}
+}
+
+func (s *session) closeOutbox() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.closed {
+		s.closed = true
+		close(s.outbox)
+	}
+}
+
+// writeLoop 送出 outbox 中的事件；outbox 關閉或寫入失敗時關閉連線，readLoop 也會跟著結束
+func (s *session) writeLoop() {
+	defer s.conn.Close()
+	for e := range s.outbox {
+		if err := s.conn.WriteJSON(e); err != nil {
+			return
+		}
+	}
+}
+
+func (s *session) readLoop() {
+	for {
+		var e Event
+		if err := s.conn.ReadJSON(&e); err != nil {
+			return
+		}
+		switch e.Type {
+		case TypeJoin:
+			s.join(e.Room)
+		case TypeLeave:
+			s.leave(e.Room)
+		case TypeSay:
+			s.say(e.Room, e.Text)
+		default:
+			s.fail(e.Room, "unknown event type %q", e.Type)
+		}
+	}
+}
+
+func (s *session) fail(room, format string, args ...any) {
+	s.send(Event{Type: TypeError, Room: room, Error: fmt.Sprintf(format, args...)})
+}
+
+func (s *session) join(name string) {
+	if !roomName.MatchString(name) {
+		s.fail(name, "invalid room %q", name)
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.rooms[name]; ok || s.closed {
+		return
+	}
+	// 持有 s.mu 時訂閱：handler 也要取得 s.mu，歷史訊息一定排在即時訊息前面
+	sub, err := s.srv.cfg.bus.Subscribe(context.Background(), topic(name), "", s.deliver)
+	if err != nil {
+		s.sendLocked(Event{Type: TypeError, Room: name, Error: err.Error()})
+		return
+	}
+	r := &room{sub: sub, history: make(map[uint64]bool)}
+	for _, e := range s.srv.history.recent(name) {
+		e.History = true
+		r.history[e.Seq] = true
+		s.sendLocked(e)
+	}
+	s.rooms[name] = r
+}
+
+func (s *session) deliver(_ context.Context, m *bus.Message) error {
+	var e Event
+	if err := json.Unmarshal(m.Data, &e); err != nil {
+		return nil // 格式錯誤的訊息重送也沒用
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	r, ok := s.rooms[e.Room]
+	if !ok || r.history[e.Seq] {
+		return nil
+	}
+	s.sendLocked(e)
+	return nil
+}
+
+func (s *session) leave(name string) {
+	s.mu.Lock()
+	r, ok := s.rooms[name]
+	delete(s.rooms, name)
+	s.mu.Unlock()
+	if ok {
+		// Unsubscribe 會等 handler 結束，handler 需要 s.mu，所以不能持有鎖
+		r.sub.Unsubscribe()
+	}
+}
+
+func (s *session) leaveAll() {
+	s.mu.Lock()
+	names := make([]string, 0, len(s.rooms))
+	for name := range s.rooms {
+		names = append(names, name)
+	}
+	s.mu.Unlock()
+	for _, name := range names {
+		s.leave(name)
+	}
+}
+
+func (s *session) say(name, text string) {
+	s.mu.Lock()
+	_, joined := s.rooms[name]
+	s.mu.Unlock()
+	if !joined {
+		s.fail(name, "not in room %q", name)
+		return
+	}
+	if !s.srv.allow(s.user) {
+		s.srv.rateLimited.Add(1)
+		s.fail(name, "rate limited")
+		return
+	}
+	e := Event{Type: TypeMessage, User: s.user, Room: name, Text: text, Seq: s.srv.seq.Add(1), Time: s.srv.cfg.clock.Now()}
+	if err := s.srv.history.append(e); err != nil {
+		s.send(Event{Type: TypeError, Room: name, Error: err.Error()})
+		return
+	}
+	data, _ := json.Marshal(e)
+	if err := s.srv.cfg.bus.Publish(context.Background(), topic(name), data, nil); err != nil {
+		s.send(Event{Type: TypeError, Room: name, Error: err.Error()})
+		return
+	}
+	s.srv.messages.Add(1)
+}
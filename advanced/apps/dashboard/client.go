@@ -0,0 +1,118 @@
+package dashboard
+
+import (
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+type client struct {
+	conn         *websocket.Conn
+	writeTimeout time.Duration
+
+	mu   sync.Mutex
+	all  bool // 沒有指定訂閱時收全部
+	subs map[string]bool
+
+	mailbox   chan Frame
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+func newClient(conn *websocket.Conn, sets []string, writeTimeout time.Duration) *client {
+	c := &client{
+		conn:         conn,
+		writeTimeout: writeTimeout,
+		all:          len(sets) == 0,
+		subs:         make(map[string]bool),
+		mailbox:      make(chan Frame, 1),
+		done:         make(chan struct{}),
+	}
+	for _, s := range sets {
+		c.subs[s] = true
+	}
+	return c
+}
+
+// offer 把 f 中有訂閱的部分放進 mailbox，絕不阻塞；回傳是否取代了還沒送出的舊 frame
+func (c *client) offer(f Frame) (replaced bool) {
+	f = c.filter(f)
+	if len(f.Metrics) == 0 {
+		return false
+	}
+	for {
+		select {
+		case c.mailbox <- f:
+			return replaced
+		default:
+		}
+		select {
+		case <-c.mailbox:
+			replaced = true
+		default:
+		}
+	}
+}
+
+func (c *client) filter(f Frame) Frame {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.all {
+		return f
+	}
+	out := Frame{Time: f.Time, Metrics: make(map[string]map[string]float64, len(c.subs))}
+	for name, m := range f.Metrics {
+		if c.subs[name] {
+			out.Metrics[name] = m
+		}
+	}
+	return out
+}
+
+func (c *client) apply(req Request) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(req.Subscribe) > 0 {
+		c.all = false
+	}
+	for _, s := range req.Subscribe {
+		c.subs[s] = true
+	}
+	for _, s := range req.Unsubscribe {
+		delete(c.subs, s)
+	}
+}
+
+// writeLoop 是唯一寫入 conn 的 goroutine；寫入失敗或逾時就關閉連線，readLoop 也會跟著結束
+func (c *client) writeLoop() {
+	defer c.close()
+	for {
+		select {
+		case f := <-c.mailbox:
+			c.conn.SetWriteDeadline(time.Now().Add(c.writeTimeout))
+			if err := c.conn.WriteJSON(f); err != nil {
+				return
+			}
+		case <-c.done:
+			return
+		}
+	}
+}
+
+func (c *client) readLoop() {
+	for {
+		var req Request
+		if err := c.conn.ReadJSON(&req); err != nil {
+			return
+		}
+		c.apply(req)
+	}
+}
+
+func (c *client) close() {
+	c.closeOnce.Do(func() {
+		close(c.done)
+		c.conn.Close()
+	})
+}
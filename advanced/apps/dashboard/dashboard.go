@@ -0,0 +1,209 @@
+package dashboard
+
+import (
+	"context"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gorilla/websocket"
+
+	"advanced/timex"
+)
+
+/*
+即時 metrics dashboard 的後端：每隔 interval 取樣一次所有 Source，透過 WebSocket 推給連線中的 client。
+
+	sampler ──offer──> [client 1 mailbox(1)] ──writer──> ws
+	        ──offer──> [client 2 mailbox(1)] ──writer──> ws（很慢）
+
+  - 取樣頻率由 hub 統一控制（throttle），不管有多少 client、資料變化多快，每個 client 最多每 interval 收到一次
+  - 每個 client 可以只訂閱部分 metric set（例如只看 "pool"），連線時以 ?sets=runtime,pool 指定，
+    之後送 {"subscribe":[...],"unsubscribe":[...]} 調整；沒有指定時訂閱全部
+  - 慢的 client 不能拖慢 sampler：sampler 對每個 client 只做 non-blocking 的 offer，
+    mailbox 只有一格，舊的 frame 還沒送出就直接換成新的（conflation），dashboard 只在乎最新的值；
+    被換掉的次數記在 Stats.Dropped
+  - 每個 client 有自己的 writer goroutine，寫入設有 deadline，
+    網路卡住超過 WithWriteTimeout 就斷線，不會讓 goroutine 永遠卡在 Write
+*/
+
+// Source 回傳一組 metric 目前的值，由 sampler goroutine 呼叫
+type Source func() map[string]float64
+
+type Frame struct {
+	Time    time.Time                     `json:"time"`
+	Metrics map[string]map[string]float64 `json:"metrics"`
+}
+
+// Request 是 client 送來的訂閱變更
+type Request struct {
+	Subscribe   []string `json:"subscribe,omitempty"`
+	Unsubscribe []string `json:"unsubscribe,omitempty"`
+}
+
+type Stats struct {
+	Clients int
+	Frames  int64 // 取樣次數
+	Dropped int64 // 因 client 太慢被新 frame 取代的次數
+}
+
+type config struct {
+	interval     time.Duration
+	clock        timex.Clock
+	writeTimeout time.Duration
+}
+
+type Option func(*config)
+
+// WithInterval 設定取樣間隔，預設 1 秒
+func WithInterval(d time.Duration) Option {
+	return func(c *config) { c.interval = d }
+}
+
+// WithClock 測試時用 timex.Fake 控制取樣時機
+func WithClock(c timex.Clock) Option {
+	return func(cfg *config) { cfg.clock = c }
+}
+
+// WithWriteTimeout 設定單次寫入的上限，超過就斷開該 client，預設 5 秒
+func WithWriteTimeout(d time.Duration) Option {
+	return func(c *config) { c.writeTimeout = d }
+}
+
+type Hub struct {
+	cfg      config
+	upgrader websocket.Upgrader
+
+	mu      sync.Mutex
+	sources map[string]Source
+	clients map[*client]struct{}
+	closed  bool
+
+	frames  atomic.Int64
+	dropped atomic.Int64
+}
+
+func New(opts ...Option) *Hub {
+	cfg := config{interval: time.Second, clock: timex.Real{}, writeTimeout: 5 * time.Second}
+	for _, o := range opts {
+		o(&cfg)
+	}
+	return &Hub{
+		cfg:     cfg,
+		sources: make(map[string]Source),
+		clients: make(map[*client]struct{}),
+	}
+}
+
+// Register 以 name 加入一組 metric，重複的 name 會取代舊的
+func (h *Hub) Register(name string, src Source) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.sources[name] = src
+}
+
+// Sets 回傳已註冊的 metric set 名稱
+func (h *Hub) Sets() []string {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	names := make([]string, 0, len(h.sources))
+	for name := range h.sources {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func (h *Hub) Stats() Stats {
+	h.mu.Lock()
+	n := len(h.clients)
+	h.mu.Unlock()
+	return Stats{Clients: n, Frames: h.frames.Load(), Dropped: h.dropped.Load()}
+}
+
+// Run 每隔 interval 取樣並推送，ctx 結束時斷開所有 client 後返回
+func (h *Hub) Run(ctx context.Context) {
+	defer h.shutdown()
+	for {
+		t := h.cfg.clock.NewTimer(h.cfg.interval)
+		select {
+		case <-t.C():
+			h.broadcast(h.sample())
+		case <-ctx.Done():
+			t.Stop()
+			return
+		}
+	}
+}
+
+// sample 在鎖外呼叫 Source，Source 慢也不會擋住 client 連線或訂閱
+func (h *Hub) sample() Frame {
+	h.mu.Lock()
+	sources := make(map[string]Source, len(h.sources))
+	for name, src := range h.sources {
+		sources[name] = src
+	}
+	h.mu.Unlock()
+
+	f := Frame{Time: h.cfg.clock.Now(), Metrics: make(map[string]map[string]float64, len(sources))}
+	for name, src := range sources {
+		f.Metrics[name] = src()
+	}
+	h.frames.Add(1)
+	return f
+}
+
+func (h *Hub) broadcast(f Frame) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for c := range h.clients {
+		if c.offer(f) {
+			h.dropped.Add(1)
+		}
+	}
+}
+
+func (h *Hub) shutdown() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.closed = true
+	for c := range h.clients {
+		c.close()
+	}
+}
+
+// ServeHTTP 把連線升級為 WebSocket，直到 client 斷線或 Run 結束才返回
+func (h *Hub) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	conn, err := h.upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		return // Upgrade 已經回應錯誤
+	}
+	c := newClient(conn, parseSets(r.URL.Query().Get("sets")), h.cfg.writeTimeout)
+
+	h.mu.Lock()
+	if h.closed {
+		h.mu.Unlock()
+		conn.Close()
+		return
+	}
+	h.clients[c] = struct{}{}
+	h.mu.Unlock()
+
+	go c.writeLoop()
+	c.readLoop()
+
+	h.mu.Lock()
+	delete(h.clients, c)
+	h.mu.Unlock()
+	c.close()
+}
+
+func parseSets(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return strings.Split(s, ",")
+}
@@ -0,0 +1,177 @@
+package dashboard
+
+import (
+	"context"
+	"fmt"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"advanced/concurrency/workerpool"
+	"advanced/timex"
+)
+
+const interval = time.Second
+
+type harness struct {
+	hub   *Hub
+	clock *timex.Fake
+	url   string
+}
+
+func start(t *testing.T, opts ...Option) *harness {
+	clock := timex.NewFake(time.Unix(0, 0))
+	h := New(append([]Option{WithInterval(interval), WithClock(clock)}, opts...)...)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		h.Run(ctx)
+	}()
+	srv := httptest.NewServer(h)
+	t.Cleanup(func() {
+		cancel()
+		<-done
+		srv.Close()
+	})
+	return &harness{hub: h, clock: clock, url: "ws" + strings.TrimPrefix(srv.URL, "http")}
+}
+
+// dial 連線並等到 hub 登記了這個 client
+func (hs *harness) dial(t *testing.T, sets string) *websocket.Conn {
+	t.Helper()
+	before := hs.hub.Stats().Clients
+	url := hs.url
+	if sets != "" {
+		url += "?sets=" + sets
+	}
+	conn, _, err := websocket.DefaultDialer.Dial(url, nil)
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+	require.Eventually(t, func() bool { return hs.hub.Stats().Clients > before }, time.Second, time.Millisecond)
+	return conn
+}
+
+// tick 等 sampler 開始等待下一次取樣後推進時間；能回傳代表 sampler 沒有被任何 client 卡住
+func (hs *harness) tick() {
+	hs.clock.BlockUntil(1)
+	hs.clock.Advance(interval)
+}
+
+func read(t *testing.T, conn *websocket.Conn) Frame {
+	t.Helper()
+	conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	var f Frame
+	require.NoError(t, conn.ReadJSON(&f))
+	return f
+}
+
+func sets(f Frame) []string {
+	var out []string
+	for name := range f.Metrics {
+		out = append(out, name)
+	}
+	sort.Strings(out)
+	return out
+}
+
+func constant(v float64) Source {
+	return func() map[string]float64 { return map[string]float64{"v": v} }
+}
+
+func TestSubscriptions(t *testing.T) {
+	hs := start(t)
+	hs.hub.Register("a", constant(1))
+	hs.hub.Register("b", constant(2))
+	assert.Equal(t, []string{"a", "b"}, hs.hub.Sets())
+
+	all := hs.dial(t, "")
+	onlyB := hs.dial(t, "b")
+	hs.tick()
+	f := read(t, all)
+	assert.Equal(t, []string{"a", "b"}, sets(f))
+	assert.Equal(t, time.Unix(0, 0).Add(interval), f.Time.Local())
+	assert.Equal(t, map[string]map[string]float64{"b": {"v": 2}}, read(t, onlyB).Metrics)
+
+	require.NoError(t, onlyB.WriteJSON(Request{Subscribe: []string{"a"}, Unsubscribe: []string{"b"}}))
+	// 訂閱變更由 server 的 read loop 非同步套用，持續取樣直到生效
+	require.Eventually(t, func() bool {
+		hs.tick()
+		read(t, all)
+		return assert.ObjectsAreEqual([]string{"a"}, sets(read(t, onlyB)))
+	}, time.Second, time.Millisecond)
+	assert.Zero(t, hs.hub.Stats().Dropped)
+}
+
+// TestSlowClient 不讀取的 client 不會拖慢 sampler 與其他 client：舊 frame 被取代，最後因寫入逾時被斷線
+func TestSlowClient(t *testing.T) {
+	hs := start(t, WithWriteTimeout(100*time.Millisecond))
+	big := make(map[string]float64, 2000)
+	for i := 0; i < 2000; i++ {
+		big[fmt.Sprintf("metric_%04d", i)] = float64(i)
+	}
+	hs.hub.Register("big", func() map[string]float64 { return big })
+
+	slow := hs.dial(t, "")
+	fast := hs.dial(t, "")
+	_ = slow // 從不讀取，TCP buffer 滿了之後 writer 卡在 Write
+
+	var last time.Time
+	for i := 0; i < 300; i++ {
+		hs.tick()
+		f := read(t, fast)
+		assert.True(t, f.Time.After(last), "fast client 每次都拿到最新的 frame")
+		last = f.Time
+	}
+	assert.Equal(t, int64(300), hs.hub.Stats().Frames)
+	assert.Positive(t, hs.hub.Stats().Dropped)
+	require.Eventually(t, func() bool { return hs.hub.Stats().Clients == 1 }, 2*time.Second, 10*time.Millisecond,
+		"寫入逾時的 client 被斷開")
+
+	hs.tick()
+	read(t, fast)
+}
+
+func TestShutdown(t *testing.T) {
+	clock := timex.NewFake(time.Unix(0, 0))
+	h := New(WithClock(clock))
+	srv := httptest.NewServer(h)
+	defer srv.Close()
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		h.Run(ctx)
+	}()
+
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(srv.URL, "http"), nil)
+	require.NoError(t, err)
+	defer conn.Close()
+	require.Eventually(t, func() bool { return h.Stats().Clients == 1 }, time.Second, time.Millisecond)
+
+	cancel()
+	<-done
+	conn.SetReadDeadline(time.Now().Add(time.Second))
+	_, _, err = conn.ReadMessage()
+	assert.Error(t, err, "Run 結束時斷開所有 client")
+	require.Eventually(t, func() bool { return h.Stats().Clients == 0 }, time.Second, time.Millisecond)
+}
+
+func TestSources(t *testing.T) {
+	rt := Runtime()()
+	assert.Positive(t, rt["goroutines"])
+	assert.Positive(t, rt["heap_bytes"])
+	assert.Contains(t, rt, "gc_cycles")
+
+	p := workerpool.New(3)
+	defer p.Close()
+	p.Pause()
+	assert.Equal(t, map[string]float64{"workers": 3, "active": 0, "queued": 0, "paused": 1}, Pool(p)())
+	p.Resume()
+}
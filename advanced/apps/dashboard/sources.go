@@ -0,0 +1,55 @@
+package dashboard
+
+import (
+	"runtime/metrics"
+
+	"advanced/concurrency/workerpool"
+)
+
+var runtimeSamples = map[string]string{
+	"goroutines": "/sched/goroutines:goroutines",
+	"heap_bytes": "/memory/classes/heap/objects:bytes",
+	"gc_cycles":  "/gc/cycles/total:gc-cycles",
+	"gomaxprocs": "/sched/gomaxprocs:threads",
+}
+
+// Runtime 以 runtime/metrics 讀取 goroutine 數、heap 與 GC 次數；
+// 和 runtime.ReadMemStats 不同，不需要 stop-the-world，適合週期性取樣
+func Runtime() Source {
+	names := make([]string, 0, len(runtimeSamples))
+	samples := make([]metrics.Sample, 0, len(runtimeSamples))
+	for name, key := range runtimeSamples {
+		names = append(names, name)
+		samples = append(samples, metrics.Sample{Name: key})
+	}
+	return func() map[string]float64 {
+		metrics.Read(samples)
+		out := make(map[string]float64, len(samples))
+		for i, s := range samples {
+			switch s.Value.Kind() {
+			case metrics.KindUint64:
+				out[names[i]] = float64(s.Value.Uint64())
+			case metrics.KindFloat64:
+				out[names[i]] = s.Value.Float64()
+			}
+		}
+		return out
+	}
+}
+
+// Pool 回報 worker pool 的狀態
+func Pool(p *workerpool.Pool) Source {
+	return func() map[string]float64 {
+		s := p.Stats()
+		paused := 0.0
+		if s.Paused {
+			paused = 1
+		}
+		return map[string]float64{
+			"workers": float64(s.Workers),
+			"active":  float64(s.Active),
+			"queued":  float64(s.Queued),
+			"paused":  paused,
+		}
+	}
+}
@@ -0,0 +1,96 @@
+package importer
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+// writer 把 parser 的結果排回檔案順序，湊滿一批後連同 checkpoint 在同一個交易內寫入
+type writer struct {
+	db     *sql.DB
+	source string
+	cp     Checkpoint
+	cfg    config
+	stats  *Stats
+
+	next    int64
+	pending map[int64]result
+	batch   []result
+}
+
+func (w *writer) run(ctx context.Context, results <-chan result) error {
+	w.pending = make(map[int64]result)
+	for r := range results {
+		w.pending[r.seq] = r
+		for {
+			r, ok := w.pending[w.next]
+			if !ok {
+				break
+			}
+			delete(w.pending, w.next)
+			w.next++
+			if r.err != nil {
+				// 先把前面正確的列寫進去，checkpoint 停在這一列之前
+				if err := w.flush(ctx); err != nil {
+					return err
+				}
+				return fmt.Errorf("importer: line %d: %w", r.line, r.err)
+			}
+			w.batch = append(w.batch, r)
+			if len(w.batch) >= w.cfg.batchSize {
+				if err := w.flush(ctx); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	if err := ctx.Err(); err != nil {
+		// reader 或 parser 失敗：results 提早關閉，已排序的部分仍然可以寫入
+		if ferr := w.flush(context.WithoutCancel(ctx)); ferr != nil {
+			return ferr
+		}
+		return err
+	}
+	return w.flush(ctx)
+}
+
+func (w *writer) flush(ctx context.Context) error {
+	if len(w.batch) == 0 {
+		return nil
+	}
+	tx, err := w.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	stmt, err := tx.PrepareContext(ctx, `INSERT INTO products (sku, name, price_cents, qty) VALUES ($1, $2, $3, $4)
+		ON CONFLICT (sku) DO UPDATE SET name = excluded.name, price_cents = excluded.price_cents, qty = excluded.qty`)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+	for _, r := range w.batch {
+		if _, err := stmt.ExecContext(ctx, r.row.SKU, r.row.Name, r.row.PriceCents, r.row.Qty); err != nil {
+			return fmt.Errorf("importer: line %d: %w", r.line, err)
+		}
+	}
+	last := w.batch[len(w.batch)-1]
+	cp := Checkpoint{Offset: last.end, Line: last.line, Rows: w.cp.Rows + int64(len(w.batch))}
+	if _, err := tx.ExecContext(ctx, `INSERT INTO import_checkpoints (source, byte_offset, line, rows) VALUES ($1, $2, $3, $4)
+		ON CONFLICT (source) DO UPDATE SET byte_offset = excluded.byte_offset, line = excluded.line, rows = excluded.rows`,
+		w.source, cp.Offset, cp.Line, cp.Rows); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	if w.cfg.progress != nil {
+		w.cfg.progress.Add(cp.Offset - w.cp.Offset)
+	}
+	w.stats.Rows += int64(len(w.batch))
+	w.stats.Batches++
+	w.cp = cp
+	w.batch = w.batch[:0]
+	return nil
+}
@@ -0,0 +1,242 @@
+package importer
+
+import (
+	"context"
+	"database/sql"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"golang.org/x/sync/errgroup"
+
+	"advanced/progress"
+)
+
+/*
+把很大的 CSV 匯入資料庫，中斷後可以從上次的位置繼續。
+
+	reader（1 個）──jobs──> parser × N ──results──> 排序 + 批次寫入（1 個）
+
+  - CSV 只能循序讀，reader 只負責切出 record 並編上序號；比較花 CPU 的欄位解析交給 N 個 parser 並行
+  - parser 的完成順序是亂的，寫入端用序號把結果排回檔案順序（reorder buffer），
+    同一個 sku 出現多次時，最後寫入的一定是檔案中最後那一筆
+  - 每 BatchSize 筆在「同一個交易」內寫入資料並更新 checkpoint（檔案的 byte offset 與行號），
+    資料和進度一起成功或一起失敗，不會有「資料寫了但進度沒記」的中間狀態
+  - 重新執行時讀取 checkpoint，直接 Seek 到該 offset，前面已經匯入的部分不必再讀一次
+  - 寫入用 upsert（ON CONFLICT DO UPDATE），即使同一批被重做（例如換了 source 名稱重跑）結果也相同
+
+解析失敗的那一列之前的資料會先寫入並更新 checkpoint，修正檔案後重新執行就會從那一列開始。
+*/
+
+var ErrHeader = errors.New("importer: unexpected header")
+
+// Header 是 CSV 預期的欄位
+var Header = []string{"sku", "name", "price_cents", "qty"}
+
+type Row struct {
+	SKU        string
+	Name       string
+	PriceCents int64
+	Qty        int
+}
+
+// Checkpoint 記錄 source 已經匯入到哪裡；Offset 是下一筆 record 在檔案中的 byte 位置
+type Checkpoint struct {
+	Offset int64
+	Line   int64
+	Rows   int64
+}
+
+type Stats struct {
+	Resumed Checkpoint // 開始時的 checkpoint
+	Rows    int64      // 這次寫入的列數
+	Batches int
+}
+
+type config struct {
+	batchSize int
+	workers   int
+	progress  *progress.Reporter
+}
+
+type Option func(*config)
+
+// WithBatchSize 設定每個交易寫入的列數，預設 500
+func WithBatchSize(n int) Option {
+	return func(c *config) { c.batchSize = n }
+}
+
+// WithWorkers 設定解析的 goroutine 數，預設 4
+func WithWorkers(n int) Option {
+	return func(c *config) { c.workers = n }
+}
+
+// WithProgress 每寫入一批就以已匯入的 byte 數回報進度，Total 應設為檔案大小
+func WithProgress(r *progress.Reporter) Option {
+	return func(c *config) { c.progress = r }
+}
+
+// Setup 建立 products 與 import_checkpoints 資料表
+func Setup(ctx context.Context, db *sql.DB) error {
+	for _, stmt := range []string{
+		`CREATE TABLE IF NOT EXISTS products (
+			sku         TEXT PRIMARY KEY,
+			name        TEXT NOT NULL,
+			price_cents INTEGER NOT NULL,
+			qty         INTEGER NOT NULL
+		)`,
+		`CREATE TABLE IF NOT EXISTS import_checkpoints (
+			source      TEXT PRIMARY KEY,
+			byte_offset INTEGER NOT NULL,
+			line        INTEGER NOT NULL,
+			rows        INTEGER NOT NULL
+		)`,
+	} {
+		if _, err := db.ExecContext(ctx, stmt); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Load 讀取 source 的 checkpoint，沒有時回傳零值
+func Load(ctx context.Context, db *sql.DB, source string) (Checkpoint, error) {
+	var cp Checkpoint
+	err := db.QueryRowContext(ctx, `SELECT byte_offset, line, rows FROM import_checkpoints WHERE source = $1`, source).
+		Scan(&cp.Offset, &cp.Line, &cp.Rows)
+	if errors.Is(err, sql.ErrNoRows) {
+		return Checkpoint{}, nil
+	}
+	return cp, err
+}
+
+type job struct {
+	seq    int64
+	line   int64
+	end    int64 // 這筆 record 結束的 offset
+	fields []string
+}
+
+type result struct {
+	job
+	row Row
+	err error
+}
+
+// Import 從 src 的 checkpoint 位置開始匯入，source 是 checkpoint 的名稱（通常是檔名）
+func Import(ctx context.Context, db *sql.DB, source string, src io.ReadSeeker, opts ...Option) (Stats, error) {
+	cfg := config{batchSize: 500, workers: 4}
+	for _, o := range opts {
+		o(&cfg)
+	}
+	cp, err := Load(ctx, db, source)
+	if err != nil {
+		return Stats{}, err
+	}
+	stats := Stats{Resumed: cp}
+	if _, err := src.Seek(cp.Offset, io.SeekStart); err != nil {
+		return stats, err
+	}
+	if cfg.progress != nil {
+		cfg.progress.Add(cp.Offset)
+	}
+
+	g, ctx := errgroup.WithContext(ctx)
+	jobs := make(chan job, cfg.workers*2)
+	results := make(chan result, cfg.workers*2)
+
+	g.Go(func() error {
+		defer close(jobs)
+		return read(ctx, src, cp, jobs)
+	})
+	parsers, pctx := errgroup.WithContext(ctx)
+	for i := 0; i < cfg.workers; i++ {
+		parsers.Go(func() error {
+			for j := range jobs {
+				row, err := parse(j.fields)
+				select {
+				case results <- result{job: j, row: row, err: err}:
+				case <-pctx.Done():
+					return nil
+				}
+			}
+			return nil
+		})
+	}
+	g.Go(func() error {
+		defer close(results)
+		return parsers.Wait()
+	})
+	g.Go(func() error {
+		// 回傳錯誤時 ctx 被取消，reader 與 parser 會跟著停下
+		w := &writer{db: db, source: source, cp: cp, cfg: cfg, stats: &stats}
+		return w.run(ctx, results)
+	})
+	err = g.Wait()
+	if cfg.progress != nil {
+		cfg.progress.Finish(err)
+	}
+	return stats, err
+}
+
+func read(ctx context.Context, src io.Reader, cp Checkpoint, jobs chan<- job) error {
+	r := csv.NewReader(src)
+	// 從頭讀時 FieldsPerRecord 為 0，由 header 決定欄位數，欄位數不對的 header 也回傳 ErrHeader
+	if cp.Offset > 0 {
+		r.FieldsPerRecord = len(Header)
+	} else {
+		h, err := r.Read()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("importer: header: %w", err)
+		}
+		if strings.Join(h, ",") != strings.Join(Header, ",") {
+			return fmt.Errorf("%w: %v", ErrHeader, h)
+		}
+	}
+	// Seek 之後 csv.Reader 的行號與 offset 都從 0 開始，要加回 checkpoint 的位置
+	lineBase := cp.Line
+	if cp.Offset == 0 {
+		lineBase = 0
+	}
+	for seq := int64(0); ; seq++ {
+		fields, err := r.Read()
+		if err == io.EOF {
+			return nil
+		}
+		line, _ := r.FieldPos(0)
+		if err != nil {
+			var pe *csv.ParseError
+			if errors.As(err, &pe) {
+				line = pe.StartLine
+			}
+			return fmt.Errorf("importer: line %d: %w", lineBase+int64(line), err)
+		}
+		j := job{seq: seq, line: lineBase + int64(line), end: cp.Offset + r.InputOffset(), fields: fields}
+		select {
+		case jobs <- j:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+func parse(fields []string) (Row, error) {
+	row := Row{SKU: strings.TrimSpace(fields[0]), Name: strings.TrimSpace(fields[1])}
+	if row.SKU == "" {
+		return row, errors.New("empty sku")
+	}
+	var err error
+	if row.PriceCents, err = strconv.ParseInt(strings.TrimSpace(fields[2]), 10, 64); err != nil {
+		return row, fmt.Errorf("price_cents: %w", err)
+	}
+	if row.Qty, err = strconv.Atoi(strings.TrimSpace(fields[3])); err != nil {
+		return row, fmt.Errorf("qty: %w", err)
+	}
+	return row, nil
+}
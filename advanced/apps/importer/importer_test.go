@@ -0,0 +1,190 @@
+package importer
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"advanced/progress"
+)
+
+func openDB(t *testing.T) *sql.DB {
+	db, err := sql.Open("sqlite3", "file:"+filepath.Join(t.TempDir(), "import.db")+"?_busy_timeout=5000")
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+	require.NoError(t, Setup(context.Background(), db))
+	return db
+}
+
+// genCSV 產生 n 列，sku 每 dup 列重複一次（後面的值較新）；回傳檔案內容與匯入後預期的資料
+func genCSV(n, dup int) ([]byte, map[string]Row) {
+	var b bytes.Buffer
+	b.WriteString(strings.Join(Header, ",") + "\n")
+	want := make(map[string]Row)
+	for i := 0; i < n; i++ {
+		row := Row{SKU: fmt.Sprintf("sku-%05d", i%dup), Name: fmt.Sprintf("item %d", i), PriceCents: int64(i * 10), Qty: i % 7}
+		if i%50 == 0 {
+			row.Name = fmt.Sprintf("item, \"%d\"", i) // 需要跳脫的欄位
+		}
+		fmt.Fprintf(&b, "%s,%q,%d,%d\n", row.SKU, row.Name, row.PriceCents, row.Qty)
+		want[row.SKU] = row
+	}
+	return []byte(strings.ReplaceAll(b.String(), `\"`, `""`)), want
+}
+
+func products(t *testing.T, db *sql.DB) map[string]Row {
+	rows, err := db.Query(`SELECT sku, name, price_cents, qty FROM products`)
+	require.NoError(t, err)
+	defer rows.Close()
+	got := make(map[string]Row)
+	for rows.Next() {
+		var r Row
+		require.NoError(t, rows.Scan(&r.SKU, &r.Name, &r.PriceCents, &r.Qty))
+		got[r.SKU] = r
+	}
+	require.NoError(t, rows.Err())
+	return got
+}
+
+func TestImport(t *testing.T) {
+	ctx := context.Background()
+	db := openDB(t)
+	data, want := genCSV(2000, 700)
+
+	updates := make(chan progress.Update, 1024)
+	rep := progress.NewReporter(updates, "import", int64(len(data)))
+	stats, err := Import(ctx, db, "products.csv", bytes.NewReader(data), WithBatchSize(64), WithWorkers(4), WithProgress(rep))
+	require.NoError(t, err)
+	assert.Equal(t, int64(2000), stats.Rows)
+	assert.Equal(t, 32, stats.Batches)
+	assert.Equal(t, want, products(t, db), "重複的 sku 以檔案中最後一筆為準")
+
+	cp, err := Load(ctx, db, "products.csv")
+	require.NoError(t, err)
+	assert.Equal(t, Checkpoint{Offset: int64(len(data)), Line: 2001, Rows: 2000}, cp)
+
+	var last progress.Update
+	for len(updates) > 0 {
+		last = <-updates
+	}
+	assert.True(t, last.Finished)
+	assert.Equal(t, int64(len(data)), last.Done)
+
+	// 已經匯入完成，再執行一次什麼都不做
+	stats, err = Import(ctx, db, "products.csv", bytes.NewReader(data))
+	require.NoError(t, err)
+	assert.Zero(t, stats.Rows)
+	assert.Equal(t, cp, stats.Resumed)
+}
+
+// failingReader 讀到 limit bytes 後回傳錯誤，模擬匯入途中 I/O 失敗或程序被中斷
+type failingReader struct {
+	*bytes.Reader
+	limit int64
+	err   error
+}
+
+func (f *failingReader) Read(p []byte) (int, error) {
+	pos, _ := f.Seek(0, io.SeekCurrent)
+	if pos >= f.limit {
+		return 0, f.err
+	}
+	if int64(len(p)) > f.limit-pos {
+		p = p[:f.limit-pos]
+	}
+	return f.Reader.Read(p)
+}
+
+func TestResume(t *testing.T) {
+	ctx := context.Background()
+	data, want := genCSV(5000, 1800)
+	errDisk := errors.New("disk error")
+
+	for _, cut := range []int64{10, int64(len(data)) / 3, int64(len(data)) - 5} {
+		t.Run(fmt.Sprint(cut), func(t *testing.T) {
+			db := openDB(t)
+			first, err := Import(ctx, db, "products.csv", &failingReader{bytes.NewReader(data), cut, errDisk}, WithBatchSize(100))
+			assert.ErrorIs(t, err, errDisk)
+			cp, err := Load(ctx, db, "products.csv")
+			require.NoError(t, err)
+			assert.LessOrEqual(t, cp.Offset, cut)
+			assert.Equal(t, first.Rows, cp.Rows, "中斷前完整讀到的列都已寫入")
+
+			second, err := Import(ctx, db, "products.csv", bytes.NewReader(data), WithBatchSize(100))
+			require.NoError(t, err)
+			assert.Equal(t, cp, second.Resumed)
+			assert.Equal(t, int64(5000), first.Rows+second.Rows, "每一列剛好寫入一次")
+			assert.Equal(t, want, products(t, db))
+		})
+	}
+}
+
+// cancelReader 讀到一半時取消 context，模擬收到 SIGINT
+type cancelReader struct {
+	*bytes.Reader
+	at     int64
+	cancel context.CancelFunc
+}
+
+func (c *cancelReader) Read(p []byte) (int, error) {
+	if pos, _ := c.Seek(0, io.SeekCurrent); pos >= c.at {
+		c.cancel()
+		time.Sleep(time.Millisecond)
+	}
+	return c.Reader.Read(p[:min(len(p), 512)])
+}
+
+func TestCancel(t *testing.T) {
+	data, want := genCSV(3000, 3000)
+	db := openDB(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	first, err := Import(ctx, db, "products.csv", &cancelReader{bytes.NewReader(data), int64(len(data) / 2), cancel}, WithBatchSize(50))
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Less(t, first.Rows, int64(3000))
+
+	second, err := Import(context.Background(), db, "products.csv", bytes.NewReader(data), WithBatchSize(50))
+	require.NoError(t, err)
+	assert.Equal(t, int64(3000), first.Rows+second.Rows)
+	assert.Equal(t, want, products(t, db))
+}
+
+// TestBadRow 解析失敗時前面的列照常寫入，修正檔案後從那一列繼續
+func TestBadRow(t *testing.T) {
+	ctx := context.Background()
+	db := openDB(t)
+	good := "sku,name,price_cents,qty\na,A,100,1\nb,B,200,2\nc,C,300,3\n"
+	bad := good + "d,D,oops,4\ne,E,500,5\n"
+	_, err := Import(ctx, db, "f.csv", strings.NewReader(bad), WithBatchSize(2))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "line 5: price_cents")
+	cp, err := Load(ctx, db, "f.csv")
+	require.NoError(t, err)
+	assert.Equal(t, Checkpoint{Offset: int64(len(good)), Line: 4, Rows: 3}, cp)
+	assert.Len(t, products(t, db), 3)
+
+	fixed := good + "d,D,400,4\ne,E,500,5\n"
+	stats, err := Import(ctx, db, "f.csv", strings.NewReader(fixed), WithBatchSize(2))
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), stats.Rows)
+	assert.Equal(t, Row{SKU: "d", Name: "D", PriceCents: 400, Qty: 4}, products(t, db)["d"])
+	cp, err = Load(ctx, db, "f.csv")
+	require.NoError(t, err)
+	assert.Equal(t, Checkpoint{Offset: int64(len(fixed)), Line: 6, Rows: 5}, cp)
+
+	_, err = Import(ctx, db, "other.csv", strings.NewReader("id,name\n1,x\n"))
+	assert.ErrorIs(t, err, ErrHeader)
+	_, err = Import(ctx, db, "short.csv", strings.NewReader("sku,name,price_cents,qty\na,A,1\n"))
+	assert.ErrorIs(t, err, csv.ErrFieldCount)
+}
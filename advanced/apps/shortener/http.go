@@ -0,0 +1,119 @@
+package shortener
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+
+	"advanced/httpbind"
+)
+
+/*
+	POST /api/links        {"url": "...", "code": "可省略"}  → 201 Link（另加 short_url）
+	GET  /api/links/{code}                                   → 200 Link（含點擊數）
+	GET  /{code}                                             → 302 轉址，並計一次點擊
+
+request 由 httpbind 綁定與驗證，錯誤以 application/problem+json（RFC 7807）回傳：
+格式錯誤 400、找不到 404、代碼已存在 409、欄位驗證失敗 422。
+*/
+
+type createRequest struct {
+	URL  string `json:"url" validate:"required,max=2048"`
+	Code string `json:"code,omitempty" validate:"max=32"`
+}
+
+type createResponse struct {
+	Link
+	ShortURL string `json:"short_url"`
+}
+
+type statsRequest struct {
+	Code string `path:"code" validate:"required"`
+}
+
+// Handler 回傳 HTTP API；baseURL 用來組出 short_url，例如 "https://go.example"
+func (s *Service) Handler(baseURL string) http.Handler {
+	baseURL = strings.TrimSuffix(baseURL, "/")
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/links", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			methodNotAllowed(w, r)
+			return
+		}
+		req, err := httpbind.Bind[createRequest](r)
+		if err != nil {
+			httpbind.Error(w, r, err)
+			return
+		}
+		l, err := s.Shorten(r.Context(), req.URL, req.Code)
+		if err != nil {
+			httpbind.Error(w, r, problemFor(err))
+			return
+		}
+		writeJSON(w, http.StatusCreated, createResponse{Link: l, ShortURL: baseURL + "/" + l.Code})
+	})
+	mux.HandleFunc("/api/links/", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			methodNotAllowed(w, r)
+			return
+		}
+		vals, ok := httpbind.Match("/api/links/{code}", r.URL.Path)
+		if !ok {
+			httpbind.Error(w, r, httpbind.NewProblem(http.StatusNotFound, ""))
+			return
+		}
+		req, err := httpbind.Bind[statsRequest](httpbind.WithPathValues(r, vals))
+		if err != nil {
+			httpbind.Error(w, r, err)
+			return
+		}
+		l, err := s.Stats(r.Context(), req.Code)
+		if err != nil {
+			httpbind.Error(w, r, problemFor(err))
+			return
+		}
+		writeJSON(w, http.StatusOK, l)
+	})
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			methodNotAllowed(w, r)
+			return
+		}
+		code := strings.TrimPrefix(r.URL.Path, "/")
+		if !codePattern.MatchString(code) {
+			httpbind.Error(w, r, problemFor(ErrNotFound))
+			return
+		}
+		target, err := s.Resolve(r.Context(), code)
+		if err != nil {
+			httpbind.Error(w, r, problemFor(err))
+			return
+		}
+		http.Redirect(w, r, target, http.StatusFound)
+	})
+	return mux
+}
+
+// problemFor 把 Service 的錯誤對應到 status；其他錯誤交給 httpbind 當成 500
+func problemFor(err error) error {
+	switch {
+	case errors.Is(err, ErrInvalidURL), errors.Is(err, ErrInvalidCode):
+		return httpbind.NewProblem(http.StatusBadRequest, err.Error())
+	case errors.Is(err, ErrNotFound):
+		return httpbind.NewProblem(http.StatusNotFound, err.Error())
+	case errors.Is(err, ErrExists):
+		return httpbind.NewProblem(http.StatusConflict, err.Error())
+	}
+	return err
+}
+
+func methodNotAllowed(w http.ResponseWriter, r *http.Request) {
+	httpbind.Error(w, r, httpbind.NewProblem(http.StatusMethodNotAllowed, r.Method+" not allowed"))
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,121 @@
+package shortener
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	ErrNotFound = errors.New("shortener: link not found")
+	ErrExists   = errors.New("shortener: code already exists")
+)
+
+type Link struct {
+	Code    string    `json:"code"`
+	URL     string    `json:"url"`
+	Hits    int64     `json:"hits"`
+	Created time.Time `json:"created"`
+}
+
+// Repository 隱藏儲存方式，Service 只依賴這個介面；測試對每個實作跑同一組案例
+type Repository interface {
+	// Create 在 Code 已存在時回傳 ErrExists
+	Create(ctx context.Context, l Link) error
+	Get(ctx context.Context, code string) (Link, error)
+	AddHits(ctx context.Context, code string, n int64) error
+}
+
+type MemoryRepo struct {
+	mu    sync.Mutex
+	links map[string]Link
+}
+
+func NewMemoryRepo() *MemoryRepo {
+	return &MemoryRepo{links: make(map[string]Link)}
+}
+
+func (r *MemoryRepo) Create(_ context.Context, l Link) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.links[l.Code]; ok {
+		return ErrExists
+	}
+	r.links[l.Code] = l
+	return nil
+}
+
+func (r *MemoryRepo) Get(_ context.Context, code string) (Link, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	l, ok := r.links[code]
+	if !ok {
+		return Link{}, ErrNotFound
+	}
+	return l, nil
+}
+
+func (r *MemoryRepo) AddHits(_ context.Context, code string, n int64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	l, ok := r.links[code]
+	if !ok {
+		return ErrNotFound
+	}
+	l.Hits += n
+	r.links[code] = l
+	return nil
+}
+
+// SQLRepo 使用 database/sql，driver 由呼叫端 import（測試用 SQLite）
+type SQLRepo struct {
+	db *sql.DB
+}
+
+// NewSQLRepo 建立資料表（已存在則略過）
+func NewSQLRepo(ctx context.Context, db *sql.DB) (*SQLRepo, error) {
+	_, err := db.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS links (
+		code    TEXT PRIMARY KEY,
+		url     TEXT NOT NULL,
+		hits    INTEGER NOT NULL DEFAULT 0,
+		created TIMESTAMP NOT NULL
+	)`)
+	if err != nil {
+		return nil, err
+	}
+	return &SQLRepo{db: db}, nil
+}
+
+func (r *SQLRepo) Create(ctx context.Context, l Link) error {
+	_, err := r.db.ExecContext(ctx, `INSERT INTO links (code, url, hits, created) VALUES ($1, $2, $3, $4)`,
+		l.Code, l.URL, l.Hits, l.Created.UTC())
+	// database/sql 沒有統一的 unique violation 錯誤，SQLite 與 PostgreSQL 的訊息都包含 "unique"
+	if err != nil && strings.Contains(strings.ToLower(err.Error()), "unique") {
+		return ErrExists
+	}
+	return err
+}
+
+func (r *SQLRepo) Get(ctx context.Context, code string) (Link, error) {
+	l := Link{Code: code}
+	err := r.db.QueryRowContext(ctx, `SELECT url, hits, created FROM links WHERE code = $1`, code).
+		Scan(&l.URL, &l.Hits, &l.Created)
+	if errors.Is(err, sql.ErrNoRows) {
+		return Link{}, ErrNotFound
+	}
+	return l, err
+}
+
+func (r *SQLRepo) AddHits(ctx context.Context, code string, n int64) error {
+	res, err := r.db.ExecContext(ctx, `UPDATE links SET hits = hits + $1 WHERE code = $2`, n, code)
+	if err != nil {
+		return err
+	}
+	if affected, err := res.RowsAffected(); err == nil && affected == 0 {
+		return ErrNotFound
+	}
+	return err
+}
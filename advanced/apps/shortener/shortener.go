@@ -0,0 +1,124 @@
+package shortener
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"regexp"
+	"time"
+
+	"advanced/idgen"
+	"advanced/lru"
+	"advanced/timex"
+)
+
+/*
+短網址服務，把幾個套件組起來：
+
+  - 代碼：idgen.Snowflake 產生不重複的 64 bits ID，再用 base62 編成約 11 個字元；
+    不需要查資料庫確認是否重複，多台機器只要 node 不同就不會衝突。也可以自訂代碼（alias）
+  - 儲存：Repository 介面，有記憶體與 database/sql（SQLite / PostgreSQL）兩種實作
+  - 快取：cache-aside，轉址時先查 lru.Cache，miss 才讀 Repository 並放回快取；
+    code → URL 建立後就不會再變，所以不需要處理失效，只有 Hits 會變，它不放在快取裡
+  - 點擊數：每次轉址都 AddHits(1)，直接寫回 Repository
+
+HTTP API 見 http.go。
+*/
+
+var (
+	ErrInvalidURL  = errors.New("shortener: invalid url")
+	ErrInvalidCode = errors.New("shortener: invalid code")
+)
+
+var codePattern = regexp.MustCompile(`^[0-9A-Za-z_-]{1,32}$`)
+
+type config struct {
+	cacheSize int
+	node      int64
+	clock     timex.Clock
+}
+
+type Option func(*config)
+
+// WithCacheSize 設定 LRU 快取的容量，預設 1024
+func WithCacheSize(n int) Option {
+	return func(c *config) { c.cacheSize = n }
+}
+
+// WithNode 設定 Snowflake 的節點編號，多台機器共用同一個 Repository 時必須不同
+func WithNode(node int64) Option {
+	return func(c *config) { c.node = node }
+}
+
+// WithClock 設定 Link.Created 的時間來源，預設 timex.Real
+func WithClock(clock timex.Clock) Option {
+	return func(c *config) { c.clock = clock }
+}
+
+type Service struct {
+	repo  Repository
+	ids   *idgen.Snowflake
+	cache *lru.Cache[string, string]
+	clock timex.Clock
+}
+
+func New(repo Repository, opts ...Option) (*Service, error) {
+	cfg := config{cacheSize: 1024, clock: timex.Real{}}
+	for _, o := range opts {
+		o(&cfg)
+	}
+	ids, err := idgen.NewSnowflake(cfg.node)
+	if err != nil {
+		return nil, err
+	}
+	return &Service{repo: repo, ids: ids, cache: lru.New[string, string](cfg.cacheSize), clock: cfg.clock}, nil
+}
+
+func validURL(raw string) bool {
+	u, err := url.Parse(raw)
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+}
+
+// Shorten 建立短網址；code 為空字串時自動產生
+func (s *Service) Shorten(ctx context.Context, target, code string) (Link, error) {
+	if !validURL(target) {
+		return Link{}, fmt.Errorf("%w: %q", ErrInvalidURL, target)
+	}
+	if code == "" {
+		id, err := s.ids.Next()
+		if err != nil {
+			return Link{}, err
+		}
+		code = idgen.EncodeBase62(uint64(id))
+	} else if !codePattern.MatchString(code) {
+		return Link{}, fmt.Errorf("%w: %q", ErrInvalidCode, code)
+	}
+	l := Link{Code: code, URL: target, Created: s.clock.Now().UTC().Truncate(time.Second)}
+	if err := s.repo.Create(ctx, l); err != nil {
+		return Link{}, err
+	}
+	return l, nil
+}
+
+// Resolve 回傳 code 對應的網址並計一次點擊
+func (s *Service) Resolve(ctx context.Context, code string) (string, error) {
+	target, ok := s.cache.Get(code)
+	if !ok {
+		l, err := s.repo.Get(ctx, code)
+		if err != nil {
+			return "", err
+		}
+		target = l.URL
+		s.cache.Set(code, target)
+	}
+	if err := s.repo.AddHits(ctx, code, 1); err != nil {
+		return "", err
+	}
+	return target, nil
+}
+
+// Stats 直接讀 Repository，Hits 是最新的值
+func (s *Service) Stats(ctx context.Context, code string) (Link, error) {
+	return s.repo.Get(ctx, code)
+}
@@ -0,0 +1,191 @@
+package shortener
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"advanced/timex"
+)
+
+var repos = map[string]func(t *testing.T) Repository{
+	"memory": func(t *testing.T) Repository { return NewMemoryRepo() },
+	"sqlite": func(t *testing.T) Repository {
+		db, err := sql.Open("sqlite3", "file:"+filepath.Join(t.TempDir(), "links.db")+"?_busy_timeout=5000")
+		require.NoError(t, err)
+		t.Cleanup(func() { db.Close() })
+		r, err := NewSQLRepo(context.Background(), db)
+		require.NoError(t, err)
+		return r
+	},
+}
+
+// countingRepo 記錄 Get 的次數，用來確認快取有沒有命中
+type countingRepo struct {
+	Repository
+	gets atomic.Int64
+}
+
+func (c *countingRepo) Get(ctx context.Context, code string) (Link, error) {
+	c.gets.Add(1)
+	return c.Repository.Get(ctx, code)
+}
+
+type api struct {
+	t      *testing.T
+	srv    *httptest.Server
+	repo   *countingRepo
+	client *http.Client
+}
+
+func newAPI(t *testing.T, repo Repository, opts ...Option) *api {
+	cr := &countingRepo{Repository: repo}
+	clock := timex.NewFake(time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC))
+	svc, err := New(cr, append([]Option{WithClock(clock)}, opts...)...)
+	require.NoError(t, err)
+	srv := httptest.NewServer(svc.Handler("https://go.example/"))
+	t.Cleanup(srv.Close)
+	client := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse }}
+	return &api{t: t, srv: srv, repo: cr, client: client}
+}
+
+func (a *api) do(method, path, body string) (*http.Response, map[string]any) {
+	a.t.Helper()
+	req, err := http.NewRequest(method, a.srv.URL+path, strings.NewReader(body))
+	require.NoError(a.t, err)
+	if body != "" {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	resp, err := a.client.Do(req)
+	require.NoError(a.t, err)
+	defer resp.Body.Close()
+	var out map[string]any
+	if ct := resp.Header.Get("Content-Type"); strings.HasPrefix(ct, "application/json") || ct == "application/problem+json" {
+		require.NoError(a.t, json.NewDecoder(resp.Body).Decode(&out))
+	}
+	return resp, out
+}
+
+func TestAPI(t *testing.T) {
+	for name, newRepo := range repos {
+		t.Run(name, func(t *testing.T) {
+			a := newAPI(t, newRepo(t))
+
+			resp, link := a.do("POST", "/api/links", `{"url":"https://go.dev/doc/effective_go"}`)
+			require.Equal(t, http.StatusCreated, resp.StatusCode, link)
+			code := link["code"].(string)
+			assert.Regexp(t, `^[0-9A-Za-z]{10,11}$`, code)
+			assert.Equal(t, "https://go.example/"+code, link["short_url"])
+			assert.Equal(t, "2024-05-01T12:00:00Z", link["created"])
+
+			for i := 0; i < 3; i++ {
+				resp, _ = a.do("GET", "/"+code, "")
+				assert.Equal(t, http.StatusFound, resp.StatusCode)
+				assert.Equal(t, "https://go.dev/doc/effective_go", resp.Header.Get("Location"))
+			}
+			assert.Equal(t, int64(1), a.repo.gets.Load(), "cache-aside：只有第一次讀 repository")
+
+			resp, stats := a.do("GET", "/api/links/"+code, "")
+			assert.Equal(t, http.StatusOK, resp.StatusCode)
+			assert.Equal(t, float64(3), stats["hits"])
+			assert.Equal(t, "https://go.dev/doc/effective_go", stats["url"])
+			assert.Equal(t, "2024-05-01T12:00:00Z", stats["created"])
+
+			// 自訂代碼
+			resp, link = a.do("POST", "/api/links", `{"url":"http://example.com/a?b=c","code":"my-link"}`)
+			assert.Equal(t, http.StatusCreated, resp.StatusCode)
+			assert.Equal(t, "my-link", link["code"])
+			resp, body := a.do("POST", "/api/links", `{"url":"http://example.com/other","code":"my-link"}`)
+			assert.Equal(t, http.StatusConflict, resp.StatusCode)
+			assert.Equal(t, ErrExists.Error(), body["detail"])
+			resp, _ = a.do("GET", "/my-link", "")
+			assert.Equal(t, "http://example.com/a?b=c", resp.Header.Get("Location"), "衝突沒有覆蓋原本的網址")
+		})
+	}
+}
+
+func TestAPIErrors(t *testing.T) {
+	for name, newRepo := range repos {
+		t.Run(name, func(t *testing.T) {
+			a := newAPI(t, newRepo(t))
+			for _, tc := range []struct {
+				method, path, body string
+				status             int
+			}{
+				{"POST", "/api/links", `{"url":"ftp://example.com"}`, http.StatusBadRequest},
+				{"POST", "/api/links", `{"url":"not a url"}`, http.StatusBadRequest},
+				{"POST", "/api/links", `{"url":"https://example.com","code":"bad code!"}`, http.StatusBadRequest},
+				{"POST", "/api/links", `{`, http.StatusBadRequest},
+				{"POST", "/api/links", `{"url":""}`, http.StatusUnprocessableEntity},
+				{"POST", "/api/links", `{"url":"https://example.com","code":"` + strings.Repeat("x", 33) + `"}`, http.StatusUnprocessableEntity},
+				{"GET", "/api/links", "", http.StatusMethodNotAllowed},
+				{"DELETE", "/api/links/abc", "", http.StatusMethodNotAllowed},
+				{"POST", "/abc", "", http.StatusMethodNotAllowed},
+				{"GET", "/api/links/missing", "", http.StatusNotFound},
+				{"GET", "/missing", "", http.StatusNotFound},
+				{"GET", "/", "", http.StatusNotFound},
+			} {
+				resp, body := a.do(tc.method, tc.path, tc.body)
+				assert.Equal(t, tc.status, resp.StatusCode, "%s %s %s", tc.method, tc.path, tc.body)
+				assert.Equal(t, float64(tc.status), body["status"], "%s %s", tc.method, tc.path)
+				assert.Equal(t, tc.path, body["instance"])
+			}
+		})
+	}
+}
+
+// TestUniqueCodes 並行建立時代碼不重複
+func TestUniqueCodes(t *testing.T) {
+	svc, err := New(NewMemoryRepo(), WithNode(7))
+	require.NoError(t, err)
+	codes := make(chan string, 400)
+	errs := make(chan error, 400)
+	for g := 0; g < 4; g++ {
+		go func() {
+			for i := 0; i < 100; i++ {
+				l, err := svc.Shorten(context.Background(), "https://example.com", "")
+				codes <- l.Code
+				errs <- err
+			}
+		}()
+	}
+	seen := make(map[string]bool)
+	for i := 0; i < 400; i++ {
+		require.NoError(t, <-errs)
+		c := <-codes
+		assert.False(t, seen[c], c)
+		seen[c] = true
+	}
+}
+
+// TestCacheEviction 容量不足被淘汰的代碼會重新從 repository 讀
+func TestCacheEviction(t *testing.T) {
+	repo := &countingRepo{Repository: NewMemoryRepo()}
+	svc, err := New(repo, WithCacheSize(1))
+	require.NoError(t, err)
+	ctx := context.Background()
+	a, err := svc.Shorten(ctx, "https://a.example", "a")
+	require.NoError(t, err)
+	_, err = svc.Shorten(ctx, "https://b.example", "b")
+	require.NoError(t, err)
+
+	for _, code := range []string{"a", "a", "b", "a"} {
+		_, err := svc.Resolve(ctx, code)
+		require.NoError(t, err)
+	}
+	assert.Equal(t, int64(3), repo.gets.Load())
+	l, err := svc.Stats(ctx, a.Code)
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), l.Hits)
+}
@@ -0,0 +1,85 @@
+package assets
+
+import (
+	"embed"
+	"io/fs"
+	"net/http"
+	"os"
+	"sort"
+)
+
+/*
+go:embed 在編譯時把檔案打包進 binary，部署只需要一個執行檔。
+但開發時每改一次 html 都要重新編譯很麻煩，所以這裡統一用 fs.FS 介面：
+	- 正式環境：使用 embed.FS
+	- 開發環境：使用 os.DirFS 直接讀硬碟，改完重新整理就生效
+兩者對呼叫端來說沒有差別。
+
+注意：embed 的路徑不能包含 ".."，所以檔案必須放在這個 package 底下。
+*/
+
+//go:embed migrations/*.sql templates/*.html static
+var embedded embed.FS
+
+// Embedded 回傳編譯時嵌入的檔案
+func Embedded() fs.FS {
+	return embedded
+}
+
+// Load 依 dir 決定來源：dir 為空使用嵌入的檔案，否則讀取硬碟上的目錄
+func Load(dir string) fs.FS {
+	if dir == "" {
+		return embedded
+	}
+	return os.DirFS(dir)
+}
+
+// FromEnv 讀取 ASSETS_DIR 環境變數，方便開發時切換成硬碟模式
+func FromEnv() fs.FS {
+	return Load(os.Getenv("ASSETS_DIR"))
+}
+
+func sub(fsys fs.FS, dir string) fs.FS {
+	s, err := fs.Sub(fsys, dir)
+	if err != nil {
+		// fs.Sub 只有在 dir 不合法時才會出錯，dir 都是常數
+		panic(err)
+	}
+	return s
+}
+
+func Migrations(fsys fs.FS) fs.FS { return sub(fsys, "migrations") }
+func Templates(fsys fs.FS) fs.FS  { return sub(fsys, "templates") }
+func Static(fsys fs.FS) fs.FS     { return sub(fsys, "static") }
+
+// Migration 為一個 SQL migration 檔案
+type Migration struct {
+	Name string
+	SQL  string
+}
+
+// ListMigrations 依檔名排序回傳所有 migration
+func ListMigrations(fsys fs.FS) ([]Migration, error) {
+	mfs := Migrations(fsys)
+	names, err := fs.Glob(mfs, "*.sql")
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(names)
+	migrations := make([]Migration, 0, len(names))
+	for _, name := range names {
+		b, err := fs.ReadFile(mfs, name)
+		if err != nil {
+			return nil, err
+		}
+		migrations = append(migrations, Migration{Name: name, SQL: string(b)})
+	}
+	return migrations, nil
+}
+
+// StaticHandler 提供靜態檔案，掛載時記得搭配 http.StripPrefix
+//
+//	mux.Handle("/static/", http.StripPrefix("/static/", assets.StaticHandler(fsys)))
+func StaticHandler(fsys fs.FS) http.Handler {
+	return http.FileServer(http.FS(Static(fsys)))
+}
@@ -0,0 +1,101 @@
+package assets
+
+import (
+	"html/template"
+	"io/fs"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// 在暫存目錄建立一份與嵌入內容不同的檔案，模擬開發模式
+func diskAssets(t *testing.T) string {
+	dir := t.TempDir()
+	files := map[string]string{
+		"migrations/0001_init.sql": "CREATE TABLE disk (id INTEGER);",
+		"templates/layout.html":    `{{block "content" .}}{{end}}`,
+		"templates/index.html":     `{{define "content"}}disk {{.Name}}{{end}}`,
+		"static/style.css":         "body { color: red; }",
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		assert.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		assert.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+	}
+	return dir
+}
+
+func TestListMigrations(t *testing.T) {
+	ms, err := ListMigrations(Load(""))
+	assert.NoError(t, err)
+	assert.Len(t, ms, 2)
+	assert.Equal(t, "0001_create_users.sql", ms[0].Name)
+	assert.Contains(t, ms[1].SQL, "CREATE TABLE IF NOT EXISTS jobs")
+
+	ms, err = ListMigrations(Load(diskAssets(t)))
+	assert.NoError(t, err)
+	assert.Len(t, ms, 1)
+	assert.Equal(t, "0001_init.sql", ms[0].Name)
+}
+
+func TestTemplatesBothModes(t *testing.T) {
+	for name, fsys := range map[string]fs.FS{
+		"embedded": Embedded(),
+		"disk":     Load(diskAssets(t)),
+	} {
+		t.Run(name, func(t *testing.T) {
+			tmpl, err := template.ParseFS(Templates(fsys), "layout.html", "index.html")
+			assert.NoError(t, err)
+			var sb strings.Builder
+			assert.NoError(t, tmpl.ExecuteTemplate(&sb, "layout.html", map[string]string{"Name": "Tom"}))
+			assert.Contains(t, sb.String(), "Tom")
+		})
+	}
+}
+
+func TestStaticHandler(t *testing.T) {
+	for name, tc := range map[string]struct {
+		fsys fs.FS
+		want string
+	}{
+		"embedded": {Embedded(), "font-family"},
+		"disk":     {Load(diskAssets(t)), "color: red"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			mux := http.NewServeMux()
+			mux.Handle("/static/", http.StripPrefix("/static/", StaticHandler(tc.fsys)))
+			srv := httptest.NewServer(mux)
+			defer srv.Close()
+
+			resp, err := http.Get(srv.URL + "/static/style.css")
+			assert.NoError(t, err)
+			defer resp.Body.Close()
+			assert.Equal(t, http.StatusOK, resp.StatusCode)
+			assert.Contains(t, resp.Header.Get("Content-Type"), "text/css")
+			body := make([]byte, 128)
+			n, _ := resp.Body.Read(body)
+			assert.Contains(t, string(body[:n]), tc.want)
+
+			resp2, err := http.Get(srv.URL + "/static/missing.css")
+			assert.NoError(t, err)
+			resp2.Body.Close()
+			assert.Equal(t, http.StatusNotFound, resp2.StatusCode)
+		})
+	}
+}
+
+func TestFromEnv(t *testing.T) {
+	dir := diskAssets(t)
+	t.Setenv("ASSETS_DIR", dir)
+	_, err := fs.Stat(FromEnv(), "migrations/0001_init.sql")
+	assert.NoError(t, err)
+
+	t.Setenv("ASSETS_DIR", "")
+	_, err = fs.Stat(FromEnv(), "migrations/0001_create_users.sql")
+	assert.NoError(t, err)
+}
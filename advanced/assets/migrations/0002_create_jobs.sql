@@ -0,0 +1,5 @@
+CREATE TABLE IF NOT EXISTS jobs (
+    id      INTEGER PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES users(id),
+    status  TEXT NOT NULL DEFAULT 'pending'
+);
@@ -0,0 +1,275 @@
+package jwt
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"advanced/idgen"
+	"advanced/timex"
+)
+
+/*
+JWT = base64url(header) + "." + base64url(claims) + "." + base64url(signature)
+簽章涵蓋前兩段，所以內容可以被任何人讀取但無法被竄改，不要放機密資料。
+
+時間驗證（單位皆為 unix 秒）：
+	exp：now - leeway >= exp 即過期
+	nbf：now + leeway <  nbf 表示尚未生效
+leeway 用來容忍發行端與驗證端的時鐘誤差，通常設幾十秒。
+
+Refresh flow：
+  - Issue 發出一組 access（短效）+ refresh（長效）token，以 typ 區分
+  - Refresh 驗證 refresh token 後發出新的一組，舊的 refresh token 標記為已使用（rotation），
+    重複使用會回傳 ErrRefreshReused，代表 token 可能外洩
+  - 已使用的 jti 只保存在記憶體，到期後清除；多個 instance 時需改存到共用的 store
+*/
+
+var (
+	ErrMalformed     = errors.New("jwt: malformed token")
+	ErrAlgorithm     = errors.New("jwt: unexpected algorithm")
+	ErrSignature     = errors.New("jwt: invalid signature")
+	ErrExpired       = errors.New("jwt: token expired")
+	ErrNotYetValid   = errors.New("jwt: token not yet valid")
+	ErrIssuer        = errors.New("jwt: invalid issuer")
+	ErrAudience      = errors.New("jwt: invalid audience")
+	ErrTokenType     = errors.New("jwt: unexpected token type")
+	ErrRefreshReused = errors.New("jwt: refresh token already used")
+)
+
+const (
+	TypeAccess  = "access"
+	TypeRefresh = "refresh"
+)
+
+type Claims struct {
+	Issuer    string `json:"iss,omitempty"`
+	Subject   string `json:"sub,omitempty"`
+	Audience  string `json:"aud,omitempty"`
+	ExpiresAt int64  `json:"exp,omitempty"`
+	NotBefore int64  `json:"nbf,omitempty"`
+	IssuedAt  int64  `json:"iat,omitempty"`
+	ID        string `json:"jti,omitempty"`
+	Type      string `json:"typ,omitempty"`
+	Scope     string `json:"scope,omitempty"`
+}
+
+// HasScope 檢查以空白分隔的 scope 中是否包含 s
+func (c *Claims) HasScope(s string) bool {
+	for _, f := range strings.Fields(c.Scope) {
+		if f == s {
+			return true
+		}
+	}
+	return false
+}
+
+type header struct {
+	Alg string `json:"alg"`
+	Typ string `json:"typ"`
+}
+
+type TokenPair struct {
+	AccessToken  string    `json:"access_token"`
+	RefreshToken string    `json:"refresh_token"`
+	ExpiresAt    time.Time `json:"expires_at"`
+}
+
+type Manager struct {
+	signer     Signer
+	clock      timex.Clock
+	leeway     time.Duration
+	issuer     string
+	audience   string
+	accessTTL  time.Duration
+	refreshTTL time.Duration
+	ids        *idgen.ULIDGenerator
+
+	mu   sync.Mutex
+	used map[string]int64 // 已使用的 refresh jti → exp
+}
+
+type Option func(*Manager)
+
+func WithClock(c timex.Clock) Option {
+	return func(m *Manager) { m.clock = c }
+}
+
+// WithLeeway 設定可容忍的時鐘誤差，預設 30 秒
+func WithLeeway(d time.Duration) Option {
+	return func(m *Manager) { m.leeway = d }
+}
+
+// WithIssuer 設定簽發時寫入、驗證時要求的 iss
+func WithIssuer(iss string) Option {
+	return func(m *Manager) { m.issuer = iss }
+}
+
+// WithAudience 設定簽發時寫入、驗證時要求的 aud
+func WithAudience(aud string) Option {
+	return func(m *Manager) { m.audience = aud }
+}
+
+// WithTTL 設定 access 與 refresh token 的有效期，預設 15 分鐘與 7 天
+func WithTTL(access, refresh time.Duration) Option {
+	return func(m *Manager) { m.accessTTL, m.refreshTTL = access, refresh }
+}
+
+func New(signer Signer, opts ...Option) *Manager {
+	m := &Manager{
+		signer:     signer,
+		clock:      timex.Real{},
+		leeway:     30 * time.Second,
+		accessTTL:  15 * time.Minute,
+		refreshTTL: 7 * 24 * time.Hour,
+		used:       make(map[string]int64),
+	}
+	for _, opt := range opts {
+		opt(m)
+	}
+	m.ids = idgen.NewULIDGenerator(idgen.WithULIDClock(m.clock.Now))
+	return m
+}
+
+var b64 = base64.RawURLEncoding
+
+// Sign 直接把 claims 簽成 token，不會補上任何欄位
+func (m *Manager) Sign(c *Claims) (string, error) {
+	h, _ := json.Marshal(header{Alg: m.signer.Alg(), Typ: "JWT"})
+	body, err := json.Marshal(c)
+	if err != nil {
+		return "", err
+	}
+	signing := b64.EncodeToString(h) + "." + b64.EncodeToString(body)
+	sig, err := m.signer.Sign([]byte(signing))
+	if err != nil {
+		return "", err
+	}
+	return signing + "." + b64.EncodeToString(sig), nil
+}
+
+// Parse 驗證簽章、時間、iss 與 aud，成功時回傳 claims
+func (m *Manager) Parse(token string) (*Claims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, ErrMalformed
+	}
+	var h header
+	if err := decodeSegment(parts[0], &h); err != nil {
+		return nil, err
+	}
+	if h.Alg != m.signer.Alg() {
+		return nil, fmt.Errorf("%w: %q", ErrAlgorithm, h.Alg)
+	}
+	sig, err := b64.DecodeString(parts[2])
+	if err != nil {
+		return nil, ErrMalformed
+	}
+	if err := m.signer.Verify([]byte(parts[0]+"."+parts[1]), sig); err != nil {
+		return nil, err
+	}
+	var c Claims
+	if err := decodeSegment(parts[1], &c); err != nil {
+		return nil, err
+	}
+	if err := m.validate(&c); err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+func decodeSegment(s string, v interface{}) error {
+	b, err := b64.DecodeString(s)
+	if err != nil {
+		return ErrMalformed
+	}
+	dec := json.NewDecoder(bytes.NewReader(b))
+	if err := dec.Decode(v); err != nil {
+		return fmt.Errorf("%w: %v", ErrMalformed, err)
+	}
+	return nil
+}
+
+func (m *Manager) validate(c *Claims) error {
+	now := m.clock.Now()
+	if c.ExpiresAt != 0 && !now.Add(-m.leeway).Before(time.Unix(c.ExpiresAt, 0)) {
+		return ErrExpired
+	}
+	if c.NotBefore != 0 && now.Add(m.leeway).Before(time.Unix(c.NotBefore, 0)) {
+		return ErrNotYetValid
+	}
+	if m.issuer != "" && c.Issuer != m.issuer {
+		return ErrIssuer
+	}
+	if m.audience != "" && c.Audience != m.audience {
+		return ErrAudience
+	}
+	return nil
+}
+
+// Issue 為 subject 簽發一組 access 與 refresh token
+func (m *Manager) Issue(subject, scope string) (TokenPair, error) {
+	now := m.clock.Now()
+	access := m.claims(now, subject, scope, TypeAccess, m.accessTTL)
+	refresh := m.claims(now, subject, scope, TypeRefresh, m.refreshTTL)
+	var p TokenPair
+	var err error
+	if p.AccessToken, err = m.Sign(access); err != nil {
+		return TokenPair{}, err
+	}
+	if p.RefreshToken, err = m.Sign(refresh); err != nil {
+		return TokenPair{}, err
+	}
+	p.ExpiresAt = time.Unix(access.ExpiresAt, 0)
+	return p, nil
+}
+
+func (m *Manager) claims(now time.Time, subject, scope, typ string, ttl time.Duration) *Claims {
+	return &Claims{
+		Issuer:    m.issuer,
+		Subject:   subject,
+		Audience:  m.audience,
+		IssuedAt:  now.Unix(),
+		NotBefore: now.Unix(),
+		ExpiresAt: now.Add(ttl).Unix(),
+		ID:        m.ids.MustNew().String(),
+		Type:      typ,
+		Scope:     scope,
+	}
+}
+
+// Refresh 以 refresh token 換一組新的 token，舊的 refresh token 之後不能再用
+func (m *Manager) Refresh(refreshToken string) (TokenPair, error) {
+	c, err := m.Parse(refreshToken)
+	if err != nil {
+		return TokenPair{}, err
+	}
+	if c.Type != TypeRefresh {
+		return TokenPair{}, ErrTokenType
+	}
+	if err := m.markUsed(c); err != nil {
+		return TokenPair{}, err
+	}
+	return m.Issue(c.Subject, c.Scope)
+}
+
+func (m *Manager) markUsed(c *Claims) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	now := m.clock.Now().Add(-m.leeway).Unix()
+	for id, exp := range m.used {
+		if exp <= now {
+			delete(m.used, id) // 已過期的 token 會在 Parse 就被拒絕，不需要再記錄
+		}
+	}
+	if _, ok := m.used[c.ID]; ok {
+		return ErrRefreshReused
+	}
+	m.used[c.ID] = c.ExpiresAt
+	return nil
+}
@@ -0,0 +1,162 @@
+package jwt
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"advanced/timex"
+)
+
+var start = time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+
+func newHMAC(opts ...Option) (*Manager, *timex.Fake) {
+	clock := timex.NewFake(start)
+	opts = append([]Option{WithClock(clock), WithIssuer("go_learn")}, opts...)
+	return New(NewHMAC([]byte("secret")), opts...), clock
+}
+
+func TestSignParse(t *testing.T) {
+	m, _ := newHMAC()
+	pair, err := m.Issue("tom", "read write")
+	require.NoError(t, err)
+	assert.Equal(t, start.Add(15*time.Minute), pair.ExpiresAt.UTC())
+
+	c, err := m.Parse(pair.AccessToken)
+	require.NoError(t, err)
+	assert.Equal(t, "tom", c.Subject)
+	assert.Equal(t, "go_learn", c.Issuer)
+	assert.Equal(t, TypeAccess, c.Type)
+	assert.True(t, c.HasScope("write"))
+	assert.False(t, c.HasScope("admin"))
+	assert.NotEmpty(t, c.ID)
+}
+
+func TestRSA(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	clock := timex.NewFake(start)
+	issuer := New(NewRSA(key), WithClock(clock))
+	verifier := New(NewRSAVerifier(&key.PublicKey), WithClock(clock))
+
+	tok, err := issuer.Sign(&Claims{Subject: "tom", ExpiresAt: start.Add(time.Minute).Unix()})
+	require.NoError(t, err)
+	c, err := verifier.Parse(tok)
+	require.NoError(t, err)
+	assert.Equal(t, "tom", c.Subject)
+
+	_, err = verifier.Sign(&Claims{})
+	assert.ErrorIs(t, err, ErrNoPrivateKey)
+
+	// 用公鑰當 HMAC secret 偽造的 token 必須被拒絕
+	forged := New(NewHMAC(key.PublicKey.N.Bytes()), WithClock(clock))
+	tok, err = forged.Sign(&Claims{Subject: "admin"})
+	require.NoError(t, err)
+	_, err = verifier.Parse(tok)
+	assert.ErrorIs(t, err, ErrAlgorithm)
+}
+
+func TestTampered(t *testing.T) {
+	m, _ := newHMAC()
+	tok, err := m.Sign(&Claims{Subject: "tom"})
+	require.NoError(t, err)
+	parts := strings.Split(tok, ".")
+
+	body := base64.RawURLEncoding.EncodeToString([]byte(`{"sub":"admin"}`))
+	_, err = m.Parse(parts[0] + "." + body + "." + parts[2])
+	assert.ErrorIs(t, err, ErrSignature)
+
+	none := base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"none","typ":"JWT"}`))
+	_, err = m.Parse(none + "." + body + ".")
+	assert.ErrorIs(t, err, ErrAlgorithm)
+
+	_, err = m.Parse("a.b")
+	assert.ErrorIs(t, err, ErrMalformed)
+	_, err = m.Parse("!!." + parts[1] + "." + parts[2])
+	assert.ErrorIs(t, err, ErrMalformed)
+
+	other := New(NewHMAC([]byte("other")))
+	_, err = other.Parse(tok)
+	assert.ErrorIs(t, err, ErrSignature)
+}
+
+func TestClockSkew(t *testing.T) {
+	m, clock := newHMAC(WithLeeway(30 * time.Second))
+	tok, err := m.Sign(&Claims{
+		Issuer:    "go_learn",
+		NotBefore: start.Add(20 * time.Second).Unix(), // 簽發端的時鐘快了 20 秒
+		ExpiresAt: start.Add(time.Minute).Unix(),
+	})
+	require.NoError(t, err)
+
+	_, err = m.Parse(tok)
+	assert.NoError(t, err, "nbf 在 leeway 內")
+
+	clock.Advance(80 * time.Second)
+	_, err = m.Parse(tok)
+	assert.NoError(t, err, "exp 過了 20 秒，仍在 leeway 內")
+
+	clock.Advance(10 * time.Second)
+	_, err = m.Parse(tok)
+	assert.ErrorIs(t, err, ErrExpired)
+
+	early, err := m.Sign(&Claims{Issuer: "go_learn", NotBefore: clock.Now().Add(time.Minute).Unix()})
+	require.NoError(t, err)
+	_, err = m.Parse(early)
+	assert.ErrorIs(t, err, ErrNotYetValid)
+}
+
+func TestIssuerAudience(t *testing.T) {
+	m, clock := newHMAC(WithAudience("api"))
+	tok, err := m.Sign(&Claims{Issuer: "evil", Audience: "api"})
+	require.NoError(t, err)
+	_, err = m.Parse(tok)
+	assert.ErrorIs(t, err, ErrIssuer)
+
+	other := New(NewHMAC([]byte("secret")), WithClock(clock), WithIssuer("go_learn"), WithAudience("admin"))
+	pair, err := other.Issue("tom", "")
+	require.NoError(t, err)
+	_, err = m.Parse(pair.AccessToken)
+	assert.ErrorIs(t, err, ErrAudience)
+}
+
+func TestRefresh(t *testing.T) {
+	m, clock := newHMAC(WithTTL(time.Minute, time.Hour))
+	pair, err := m.Issue("tom", "read")
+	require.NoError(t, err)
+
+	_, err = m.Refresh(pair.AccessToken)
+	assert.ErrorIs(t, err, ErrTokenType)
+
+	clock.Advance(2 * time.Minute)
+	_, err = m.Parse(pair.AccessToken)
+	assert.ErrorIs(t, err, ErrExpired)
+
+	next, err := m.Refresh(pair.RefreshToken)
+	require.NoError(t, err)
+	c, err := m.Parse(next.AccessToken)
+	require.NoError(t, err)
+	assert.Equal(t, "tom", c.Subject)
+	assert.Equal(t, "read", c.Scope)
+	assert.NotEqual(t, pair.RefreshToken, next.RefreshToken)
+
+	_, err = m.Refresh(pair.RefreshToken)
+	assert.ErrorIs(t, err, ErrRefreshReused)
+
+	clock.Advance(2 * time.Hour)
+	_, err = m.Refresh(next.RefreshToken)
+	assert.ErrorIs(t, err, ErrExpired)
+
+	// 下一次 Refresh 時會清掉已過期的 jti
+	fresh, err := m.Issue("amy", "")
+	require.NoError(t, err)
+	_, err = m.Refresh(fresh.RefreshToken)
+	require.NoError(t, err)
+	assert.Len(t, m.used, 1)
+}
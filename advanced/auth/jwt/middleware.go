@@ -0,0 +1,131 @@
+package jwt
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+
+	"advanced/ctxutil"
+)
+
+/*
+Middleware 從 "Authorization: Bearer <token>" 取出 access token，驗證成功後
+以 typed key 把 *Claims 放進 request context，handler 用 ClaimsFrom 取出；
+同時寫入 ctxutil.ClaimsKey，讓只依賴 ctxutil 的程式（例如 log）也拿得到 sub。
+失敗時回傳 401 並依 RFC 6750 帶上 WWW-Authenticate。
+*/
+
+var claimsKey = ctxutil.NewKey[*Claims]("jwt.claims")
+
+// ClaimsFrom 取出 Middleware 驗證過的 claims
+func ClaimsFrom(r *http.Request) (*Claims, bool) {
+	return claimsKey.From(r.Context())
+}
+
+func Middleware(m *Manager) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			token, ok := bearer(r)
+			if !ok {
+				unauthorized(w, "")
+				return
+			}
+			c, err := m.Parse(token)
+			if err == nil && c.Type != TypeAccess {
+				err = ErrTokenType
+			}
+			if err != nil {
+				unauthorized(w, description(err))
+				return
+			}
+			ctx := claimsKey.With(r.Context(), c)
+			ctx = ctxutil.ClaimsKey.With(ctx, ctxutil.Claims{"sub": c.Subject, "scope": c.Scope})
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// RequireScope 必須放在 Middleware 之後，缺少 scope 時回傳 403
+func RequireScope(scope string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			c, ok := ClaimsFrom(r)
+			if !ok || !c.HasScope(scope) {
+				http.Error(w, "insufficient scope", http.StatusForbidden)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func bearer(r *http.Request) (string, bool) {
+	h := r.Header.Get("Authorization")
+	const prefix = "Bearer "
+	if len(h) <= len(prefix) || !strings.EqualFold(h[:len(prefix)], prefix) {
+		return "", false
+	}
+	return strings.TrimSpace(h[len(prefix):]), true
+}
+
+func unauthorized(w http.ResponseWriter, desc string) {
+	v := `Bearer`
+	if desc != "" {
+		v += ` error="invalid_token", error_description="` + desc + `"`
+	}
+	w.Header().Set("WWW-Authenticate", v)
+	http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+}
+
+// RefreshHandler 接受 {"refresh_token": "..."}，回傳新的 TokenPair
+func RefreshHandler(m *Manager) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.Header().Set("Allow", http.MethodPost)
+			http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+			return
+		}
+		var req struct {
+			RefreshToken string `json:"refresh_token"`
+		}
+		if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<16)).Decode(&req); err != nil || req.RefreshToken == "" {
+			http.Error(w, "missing refresh_token", http.StatusBadRequest)
+			return
+		}
+		pair, err := m.Refresh(req.RefreshToken)
+		if err != nil {
+			status := http.StatusUnauthorized
+			if !isTokenError(err) {
+				status = http.StatusInternalServerError // 簽發失敗
+			}
+			http.Error(w, err.Error(), status)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", "no-store")
+		_ = json.NewEncoder(w).Encode(pair)
+	})
+}
+
+var tokenErrors = []error{ErrMalformed, ErrAlgorithm, ErrSignature, ErrExpired, ErrNotYetValid, ErrIssuer, ErrAudience, ErrTokenType, ErrRefreshReused}
+
+func isTokenError(err error) bool {
+	for _, e := range tokenErrors {
+		if errors.Is(err, e) {
+			return true
+		}
+	}
+	return false
+}
+
+// description 只用 sentinel error 的固定文字：err.Error() 可能帶有 token 的內容（例如 %q 格式化的 alg），
+// 直接放進 error_description 的 quoted-string 會破壞 header
+func description(err error) string {
+	for _, e := range tokenErrors {
+		if errors.Is(err, e) {
+			return e.Error()
+		}
+	}
+	return "invalid token"
+}
@@ -0,0 +1,96 @@
+package jwt
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"advanced/ctxutil"
+)
+
+func serve(h http.Handler, method, path, auth, body string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(method, path, strings.NewReader(body))
+	if auth != "" {
+		r.Header.Set("Authorization", auth)
+	}
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	return w
+}
+
+func TestMiddleware(t *testing.T) {
+	m, clock := newHMAC()
+	h := Middleware(m)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		c, ok := ClaimsFrom(r)
+		require.True(t, ok)
+		assert.Equal(t, "tom", ctxutil.ClaimsKey.MustFrom(r.Context())["sub"])
+		_, _ = w.Write([]byte(c.Subject))
+	}))
+	pair, err := m.Issue("tom", "read")
+	require.NoError(t, err)
+
+	w := serve(h, http.MethodGet, "/", "Bearer "+pair.AccessToken, "")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "tom", w.Body.String())
+
+	w = serve(h, http.MethodGet, "/", "", "")
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.Equal(t, "Bearer", w.Header().Get("WWW-Authenticate"))
+
+	w = serve(h, http.MethodGet, "/", "Bearer "+pair.RefreshToken, "")
+	assert.Equal(t, http.StatusUnauthorized, w.Code, "refresh token 不能當 access token 用")
+	assert.Contains(t, w.Header().Get("WWW-Authenticate"), "unexpected token type")
+
+	clock.Advance(time.Hour)
+	w = serve(h, http.MethodGet, "/", "bearer "+pair.AccessToken, "")
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.Contains(t, w.Header().Get("WWW-Authenticate"), "expired")
+
+	// alg 會以 %q 出現在錯誤訊息中，header 只帶固定的說明
+	alg := base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"x\"y","typ":"JWT"}`))
+	w = serve(h, http.MethodGet, "/", "Bearer "+alg+".e30.", "")
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.Equal(t, `Bearer error="invalid_token", error_description="jwt: unexpected algorithm"`, w.Header().Get("WWW-Authenticate"))
+}
+
+func TestRequireScope(t *testing.T) {
+	m, _ := newHMAC()
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+	h := Middleware(m)(RequireScope("write")(ok))
+
+	reader, err := m.Issue("tom", "read")
+	require.NoError(t, err)
+	writer, err := m.Issue("amy", "read write")
+	require.NoError(t, err)
+
+	assert.Equal(t, http.StatusForbidden, serve(h, http.MethodGet, "/", "Bearer "+reader.AccessToken, "").Code)
+	assert.Equal(t, http.StatusOK, serve(h, http.MethodGet, "/", "Bearer "+writer.AccessToken, "").Code)
+}
+
+func TestRefreshHandler(t *testing.T) {
+	m, _ := newHMAC()
+	h := RefreshHandler(m)
+	pair, err := m.Issue("tom", "read")
+	require.NoError(t, err)
+
+	w := serve(h, http.MethodPost, "/token/refresh", "", `{"refresh_token":"`+pair.RefreshToken+`"}`)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "no-store", w.Header().Get("Cache-Control"))
+	var next TokenPair
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&next))
+	c, err := m.Parse(next.AccessToken)
+	require.NoError(t, err)
+	assert.Equal(t, "tom", c.Subject)
+
+	w = serve(h, http.MethodPost, "/token/refresh", "", `{"refresh_token":"`+pair.RefreshToken+`"}`)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.Equal(t, http.StatusBadRequest, serve(h, http.MethodPost, "/", "", `{}`).Code)
+	assert.Equal(t, http.StatusMethodNotAllowed, serve(h, http.MethodGet, "/", "", "").Code)
+}
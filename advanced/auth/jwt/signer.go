@@ -0,0 +1,85 @@
+package jwt
+
+import (
+	"crypto"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"errors"
+)
+
+/*
+簽章演算法：
+  - HS256：HMAC-SHA256，簽發與驗證用同一把 secret，適合單一服務自己發自己驗
+  - RS256：RSA PKCS#1 v1.5 + SHA256，私鑰簽發、公鑰驗證，
+    適合 auth server 發 token、其他服務只拿公鑰驗證的架構
+驗證時一律以 Signer 的演算法為準，不相信 header 裡的 alg，
+避免經典的 "alg: none" 與「拿 RSA 公鑰當 HMAC secret」攻擊。
+*/
+
+var ErrNoPrivateKey = errors.New("jwt: signer has no private key")
+
+type Signer interface {
+	Alg() string
+	Sign(data []byte) ([]byte, error)
+	Verify(data, sig []byte) error
+}
+
+type hmacSigner struct {
+	secret []byte
+}
+
+func NewHMAC(secret []byte) Signer {
+	return hmacSigner{secret: secret}
+}
+
+func (hmacSigner) Alg() string { return "HS256" }
+
+func (s hmacSigner) Sign(data []byte) ([]byte, error) {
+	mac := hmac.New(sha256.New, s.secret)
+	mac.Write(data)
+	return mac.Sum(nil), nil
+}
+
+func (s hmacSigner) Verify(data, sig []byte) error {
+	want, _ := s.Sign(data)
+	// 用固定時間比較，避免從回應時間推測出正確的簽章
+	if !hmac.Equal(want, sig) {
+		return ErrSignature
+	}
+	return nil
+}
+
+type rsaSigner struct {
+	priv *rsa.PrivateKey
+	pub  *rsa.PublicKey
+}
+
+// NewRSA 同時可簽發與驗證
+func NewRSA(priv *rsa.PrivateKey) Signer {
+	return rsaSigner{priv: priv, pub: &priv.PublicKey}
+}
+
+// NewRSAVerifier 只有公鑰，Sign 會回傳 ErrNoPrivateKey
+func NewRSAVerifier(pub *rsa.PublicKey) Signer {
+	return rsaSigner{pub: pub}
+}
+
+func (rsaSigner) Alg() string { return "RS256" }
+
+func (s rsaSigner) Sign(data []byte) ([]byte, error) {
+	if s.priv == nil {
+		return nil, ErrNoPrivateKey
+	}
+	sum := sha256.Sum256(data)
+	return rsa.SignPKCS1v15(rand.Reader, s.priv, crypto.SHA256, sum[:])
+}
+
+func (s rsaSigner) Verify(data, sig []byte) error {
+	sum := sha256.Sum256(data)
+	if err := rsa.VerifyPKCS1v15(s.pub, crypto.SHA256, sum[:], sig); err != nil {
+		return ErrSignature
+	}
+	return nil
+}
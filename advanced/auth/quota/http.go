@@ -0,0 +1,81 @@
+package quota
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+	"net/http"
+	"strconv"
+
+	"advanced/ctxutil"
+)
+
+// HeaderName 是 client 放 API key 的 header
+const HeaderName = "X-API-Key"
+
+var keyCtx = ctxutil.NewKey[Key]("quota.key")
+
+// KeyFrom 取出 Middleware 驗證過的 key
+func KeyFrom(r *http.Request) (Key, bool) {
+	return keyCtx.From(r.Context())
+}
+
+// Middleware 驗證 API key 並記錄一次用量；key 無效回 401，超過配額回 429
+func Middleware(m *Manager) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			key, err := m.Authenticate(r.Header.Get(HeaderName))
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusUnauthorized)
+				return
+			}
+			u, err := m.Use(key, 1)
+			setRateHeaders(w.Header(), u)
+			var le *LimitError
+			if errors.As(err, &le) {
+				secs := math.Ceil(le.ResetAt.Sub(m.clock.Now()).Seconds())
+				w.Header().Set("Retry-After", strconv.Itoa(int(max(secs, 1))))
+				http.Error(w, le.Error(), http.StatusTooManyRequests)
+				return
+			}
+			next.ServeHTTP(w, r.WithContext(keyCtx.With(r.Context(), key)))
+		})
+	}
+}
+
+// setRateHeaders 以每日配額填 X-RateLimit-*（沒有每日限制時用每月）
+func setRateHeaders(h http.Header, u Usage) {
+	p := u.Daily
+	if p.Limit == 0 {
+		p = u.Month
+	}
+	if p.Limit == 0 {
+		return
+	}
+	h.Set("X-RateLimit-Limit", strconv.FormatInt(p.Limit, 10))
+	h.Set("X-RateLimit-Remaining", strconv.FormatInt(p.Remaining(), 10))
+	h.Set("X-RateLimit-Reset", strconv.FormatInt(p.ResetAt.Unix(), 10))
+}
+
+// ReportHandler 回傳呼叫者自己的用量；查詢用量本身不計入配額
+func ReportHandler(m *Manager) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			w.Header().Set("Allow", http.MethodGet)
+			http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+			return
+		}
+		key, err := m.Authenticate(r.Header.Get(HeaderName))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+		u, err := m.Usage(key.ID)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(u)
+	})
+}
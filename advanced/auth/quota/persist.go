@@ -0,0 +1,64 @@
+package quota
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"sync/atomic"
+	"time"
+
+	"advanced/snapshot"
+)
+
+// state 是寫進 snapshot 的內容
+type state struct {
+	Keys  []Key
+	Usage map[usageKey]int64
+}
+
+// Save 把所有 key 與目前期間的用量寫到 path；已經過去的期間不再需要，順便從記憶體移除
+func (m *Manager) Save(path string) error {
+	day, month := periods(m.clock.Now())
+	m.mu.Lock()
+	st := state{Usage: make(map[usageKey]int64, len(m.usage))}
+	for _, k := range m.keys {
+		st.Keys = append(st.Keys, *k)
+	}
+	for k, c := range m.usage {
+		if k.Period != day && k.Period != month {
+			delete(m.usage, k)
+			continue
+		}
+		st.Usage[k] = c.Load()
+	}
+	m.mu.Unlock()
+	return snapshot.WriteFile(path, st)
+}
+
+// Load 從 path 還原 key 與用量；檔案不存在時什麼都不做
+func (m *Manager) Load(path string) error {
+	var st state
+	if err := snapshot.ReadFile(path, &st); err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil
+		}
+		return err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for i := range st.Keys {
+		k := st.Keys[i]
+		m.keys[k.ID] = &k
+	}
+	for k, v := range st.Usage {
+		c := new(atomic.Int64)
+		c.Store(v)
+		m.usage[k] = c
+	}
+	return nil
+}
+
+// Run 每隔 interval 存一次檔，ctx 結束時再存一次後返回
+func (m *Manager) Run(ctx context.Context, path string, interval time.Duration, onErr func(error)) {
+	snapshot.Run(ctx, interval, func() error { return m.Save(path) }, onErr)
+}
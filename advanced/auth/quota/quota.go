@@ -0,0 +1,294 @@
+package quota
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"advanced/timex"
+)
+
+/*
+API key 與用量配額：
+
+	m := quota.NewManager()
+	secret, key, _ := m.Issue("alice", quota.Plan{Name: "free", Daily: 1000, Monthly: 20000})
+	mux.Handle("/api/", quota.Middleware(m)(api))
+	mux.Handle("/usage", quota.ReportHandler(m))
+
+  - Key：secret 的格式是 gl_<id>_<亂數>，只把亂數的 SHA-256 存起來，資料外洩也拿不到可以用的 key；
+    驗證時依 id 找到 key 再以 constant-time 比對 hash
+  - 用量：每個 key、每個期間（d:2024-01-02、m:2024-01，UTC）一個 atomic 計數器，
+    熱路徑只有讀鎖 + atomic 加法；期間一換自然就是新的計數器，不需要排程去歸零
+  - 限制：先加再檢查，超過就減回去，被拒絕的請求不算用量；同時到達的請求最多只會有一個「剛好超過」後被退回
+  - 持久化：Save / Load 透過 snapshot 套件寫成 atomic 的檔案，Run 定期存檔、結束時再存一次；
+    當機時最多遺失一個 interval 的用量（對配額來說是可以接受的誤差，換到的是每個請求都不用寫磁碟）
+  - Middleware 從 X-API-Key 取得 key，超過配額回 429 並帶 Retry-After 與 X-RateLimit-* header
+*/
+
+var (
+	ErrUnknownKey    = errors.New("quota: unknown api key")
+	ErrRevoked       = errors.New("quota: api key revoked")
+	ErrQuotaExceeded = errors.New("quota: quota exceeded")
+)
+
+// Plan 是一組配額，0 表示不限制
+type Plan struct {
+	Name    string
+	Daily   int64
+	Monthly int64
+}
+
+type Key struct {
+	ID      string
+	Owner   string
+	Plan    Plan
+	Hash    []byte // secret 的 SHA-256
+	Created time.Time
+	Revoked bool
+}
+
+// LimitError 說明是哪一個期間的配額用完了，以及什麼時候重置
+type LimitError struct {
+	Period  string // "daily" 或 "monthly"
+	Limit   int64
+	ResetAt time.Time
+}
+
+func (e *LimitError) Error() string {
+	return fmt.Sprintf("quota: %s quota of %d exceeded, resets at %s", e.Period, e.Limit, e.ResetAt.Format(time.RFC3339))
+}
+
+func (e *LimitError) Unwrap() error { return ErrQuotaExceeded }
+
+type usageKey struct {
+	KeyID  string
+	Period string // d:2006-01-02 或 m:2006-01
+}
+
+type Manager struct {
+	clock timex.Clock
+
+	mu    sync.RWMutex
+	keys  map[string]*Key
+	usage map[usageKey]*atomic.Int64
+}
+
+type Option func(*Manager)
+
+// WithClock 設定判斷用量期間與 Created 的時鐘，預設 timex.Real
+func WithClock(c timex.Clock) Option {
+	return func(m *Manager) { m.clock = c }
+}
+
+func NewManager(opts ...Option) *Manager {
+	m := &Manager{clock: timex.Real{}, keys: make(map[string]*Key), usage: make(map[usageKey]*atomic.Int64)}
+	for _, o := range opts {
+		o(m)
+	}
+	return m
+}
+
+const secretPrefix = "gl_"
+
+func hashSecret(s string) []byte {
+	h := sha256.Sum256([]byte(s))
+	return h[:]
+}
+
+// Issue 建立新的 key，回傳的 secret 只有這一次拿得到
+func (m *Manager) Issue(owner string, plan Plan) (secret string, key Key, err error) {
+	var id [8]byte
+	var raw [24]byte
+	if _, err := rand.Read(id[:]); err != nil {
+		return "", Key{}, err
+	}
+	if _, err := rand.Read(raw[:]); err != nil {
+		return "", Key{}, err
+	}
+	s := base64.RawURLEncoding.EncodeToString(raw[:])
+	k := &Key{ID: hex.EncodeToString(id[:]), Owner: owner, Plan: plan, Hash: hashSecret(s), Created: m.clock.Now().UTC()}
+	m.mu.Lock()
+	m.keys[k.ID] = k
+	m.mu.Unlock()
+	return secretPrefix + k.ID + "_" + s, *k, nil
+}
+
+// Authenticate 驗證 secret 並回傳對應的 key
+func (m *Manager) Authenticate(secret string) (Key, error) {
+	rest, ok := strings.CutPrefix(secret, secretPrefix)
+	if !ok {
+		return Key{}, ErrUnknownKey
+	}
+	id, s, ok := strings.Cut(rest, "_")
+	if !ok {
+		return Key{}, ErrUnknownKey
+	}
+	m.mu.RLock()
+	k, ok := m.keys[id]
+	var key Key
+	if ok {
+		key = *k
+	}
+	m.mu.RUnlock()
+	if !ok || subtle.ConstantTimeCompare(key.Hash, hashSecret(s)) != 1 {
+		return Key{}, ErrUnknownKey
+	}
+	if key.Revoked {
+		return Key{}, ErrRevoked
+	}
+	return key, nil
+}
+
+func (m *Manager) Revoke(id string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	k, ok := m.keys[id]
+	if !ok {
+		return ErrUnknownKey
+	}
+	k.Revoked = true
+	return nil
+}
+
+// SetPlan 更換 key 的方案，已經用掉的量不變
+func (m *Manager) SetPlan(id string, plan Plan) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	k, ok := m.keys[id]
+	if !ok {
+		return ErrUnknownKey
+	}
+	k.Plan = plan
+	return nil
+}
+
+func (m *Manager) Keys() []Key {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	keys := make([]Key, 0, len(m.keys))
+	for _, k := range m.keys {
+		keys = append(keys, *k)
+	}
+	return keys
+}
+
+func periods(t time.Time) (day, month string) {
+	t = t.UTC()
+	return "d:" + t.Format("2006-01-02"), "m:" + t.Format("2006-01")
+}
+
+// resets 回傳下一個 UTC 日與月的開始
+func resets(t time.Time) (day, month time.Time) {
+	t = t.UTC()
+	y, mo, d := t.Date()
+	return time.Date(y, mo, d+1, 0, 0, 0, 0, time.UTC), time.Date(y, mo+1, 1, 0, 0, 0, 0, time.UTC)
+}
+
+// counter 回傳計數器，不存在時建立
+func (m *Manager) counter(k usageKey) *atomic.Int64 {
+	m.mu.RLock()
+	c, ok := m.usage[k]
+	m.mu.RUnlock()
+	if ok {
+		return c
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if c, ok = m.usage[k]; !ok {
+		c = new(atomic.Int64)
+		m.usage[k] = c
+	}
+	return c
+}
+
+// Use 記錄 key 的 n 次用量；超過任何一個配額時不記錄，回傳 *LimitError
+func (m *Manager) Use(key Key, n int64) (Usage, error) {
+	now := m.clock.Now()
+	day, month := periods(now)
+	dayReset, monthReset := resets(now)
+	dc := m.counter(usageKey{key.ID, day})
+	mc := m.counter(usageKey{key.ID, month})
+
+	d := dc.Add(n)
+	if key.Plan.Daily > 0 && d > key.Plan.Daily {
+		dc.Add(-n)
+		return m.usageOf(key, now), &LimitError{Period: "daily", Limit: key.Plan.Daily, ResetAt: dayReset}
+	}
+	mo := mc.Add(n)
+	if key.Plan.Monthly > 0 && mo > key.Plan.Monthly {
+		mc.Add(-n)
+		dc.Add(-n)
+		return m.usageOf(key, now), &LimitError{Period: "monthly", Limit: key.Plan.Monthly, ResetAt: monthReset}
+	}
+	return Usage{
+		KeyID: key.ID,
+		Plan:  key.Plan.Name,
+		Daily: Period{Used: d, Limit: key.Plan.Daily, ResetAt: dayReset},
+		Month: Period{Used: mo, Limit: key.Plan.Monthly, ResetAt: monthReset},
+	}, nil
+}
+
+// Period 是一個期間的用量
+type Period struct {
+	Used    int64     `json:"used"`
+	Limit   int64     `json:"limit"` // 0 表示不限制
+	ResetAt time.Time `json:"resets_at"`
+}
+
+// Remaining 回傳剩下的量，不限制時回傳 -1
+func (p Period) Remaining() int64 {
+	if p.Limit == 0 {
+		return -1
+	}
+	return max(0, p.Limit-p.Used)
+}
+
+type Usage struct {
+	KeyID string `json:"key_id"`
+	Plan  string `json:"plan"`
+	Daily Period `json:"daily"`
+	Month Period `json:"monthly"`
+}
+
+// Usage 回傳 key 目前的用量
+func (m *Manager) Usage(id string) (Usage, error) {
+	m.mu.RLock()
+	k, ok := m.keys[id]
+	var key Key
+	if ok {
+		key = *k
+	}
+	m.mu.RUnlock()
+	if !ok {
+		return Usage{}, ErrUnknownKey
+	}
+	return m.usageOf(key, m.clock.Now()), nil
+}
+
+func (m *Manager) usageOf(key Key, now time.Time) Usage {
+	day, month := periods(now)
+	dayReset, monthReset := resets(now)
+	load := func(p string) int64 {
+		m.mu.RLock()
+		defer m.mu.RUnlock()
+		if c, ok := m.usage[usageKey{key.ID, p}]; ok {
+			return c.Load()
+		}
+		return 0
+	}
+	return Usage{
+		KeyID: key.ID,
+		Plan:  key.Plan.Name,
+		Daily: Period{Used: load(day), Limit: key.Plan.Daily, ResetAt: dayReset},
+		Month: Period{Used: load(month), Limit: key.Plan.Monthly, ResetAt: monthReset},
+	}
+}
@@ -0,0 +1,243 @@
+package quota
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"advanced/timex"
+)
+
+func newManager(t *testing.T) (*Manager, *timex.Fake) {
+	c := timex.NewFake(time.Date(2024, 3, 15, 23, 0, 0, 0, time.UTC))
+	return NewManager(WithClock(c)), c
+}
+
+// setClock 把 c 往前推到 to
+func setClock(c *timex.Fake, to time.Time) { c.Advance(to.Sub(c.Now())) }
+
+func TestIssueAndAuthenticate(t *testing.T) {
+	m, _ := newManager(t)
+	secret, key, err := m.Issue("alice", Plan{Name: "free", Daily: 10})
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(secret, "gl_"+key.ID+"_"))
+	assert.NotContains(t, string(key.Hash), secret, "secret itself is never stored")
+
+	got, err := m.Authenticate(secret)
+	require.NoError(t, err)
+	assert.Equal(t, "alice", got.Owner)
+
+	for _, bad := range []string{"", "nope", "gl_" + key.ID + "_wrong", "gl_unknown_x", secret + "x"} {
+		_, err := m.Authenticate(bad)
+		assert.ErrorIs(t, err, ErrUnknownKey, bad)
+	}
+
+	require.NoError(t, m.Revoke(key.ID))
+	_, err = m.Authenticate(secret)
+	assert.ErrorIs(t, err, ErrRevoked)
+	assert.ErrorIs(t, m.Revoke("missing"), ErrUnknownKey)
+}
+
+func TestDailyAndMonthlyLimits(t *testing.T) {
+	m, c := newManager(t)
+	secret, key, _ := m.Issue("bob", Plan{Name: "basic", Daily: 3, Monthly: 5})
+
+	for i := 0; i < 3; i++ {
+		_, err := m.Use(key, 1)
+		require.NoError(t, err)
+	}
+	u, err := m.Use(key, 1)
+	var le *LimitError
+	require.ErrorAs(t, err, &le)
+	assert.ErrorIs(t, err, ErrQuotaExceeded)
+	assert.Equal(t, "daily", le.Period)
+	assert.Equal(t, time.Date(2024, 3, 16, 0, 0, 0, 0, time.UTC), le.ResetAt)
+	assert.EqualValues(t, 3, u.Daily.Used, "rejected request not counted")
+
+	// 隔天每日配額重置，但每月只剩 2
+	setClock(c, time.Date(2024, 3, 16, 1, 0, 0, 0, time.UTC))
+	for i := 0; i < 2; i++ {
+		_, err := m.Use(key, 1)
+		require.NoError(t, err)
+	}
+	_, err = m.Use(key, 1)
+	require.ErrorAs(t, err, &le)
+	assert.Equal(t, "monthly", le.Period)
+	assert.Equal(t, time.Date(2024, 4, 1, 0, 0, 0, 0, time.UTC), le.ResetAt)
+
+	u, err = m.Usage(key.ID)
+	require.NoError(t, err)
+	assert.EqualValues(t, 2, u.Daily.Used)
+	assert.EqualValues(t, 5, u.Month.Used)
+	assert.EqualValues(t, 0, u.Month.Remaining())
+
+	// 下個月
+	setClock(c, time.Date(2024, 4, 1, 0, 0, 0, 0, time.UTC))
+	_, err = m.Use(key, 1)
+	assert.NoError(t, err)
+
+	// 升級方案立即生效
+	require.NoError(t, m.SetPlan(key.ID, Plan{Name: "unlimited"}))
+	key, err = m.Authenticate(secret)
+	require.NoError(t, err)
+	for i := 0; i < 100; i++ {
+		_, err := m.Use(key, 1)
+		require.NoError(t, err)
+	}
+}
+
+// 並發使用時不會超過配額，而且沒有被拒絕的請求被算進去
+func TestConcurrentUseExact(t *testing.T) {
+	m, _ := newManager(t)
+	_, key, _ := m.Issue("carol", Plan{Daily: 1000})
+	var ok, rejected atomic.Int64
+	var wg sync.WaitGroup
+	for g := 0; g < 20; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 100; i++ {
+				if _, err := m.Use(key, 1); err == nil {
+					ok.Add(1)
+				} else {
+					rejected.Add(1)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	assert.EqualValues(t, 1000, ok.Load())
+	assert.EqualValues(t, 1000, rejected.Load())
+	u, _ := m.Usage(key.ID)
+	assert.EqualValues(t, 1000, u.Daily.Used)
+}
+
+func TestPersistence(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "quota.snap")
+	m, c := newManager(t)
+	secret, key, _ := m.Issue("dave", Plan{Daily: 10, Monthly: 100})
+	for i := 0; i < 4; i++ {
+		_, err := m.Use(key, 1)
+		require.NoError(t, err)
+	}
+	require.NoError(t, m.Save(path))
+
+	restored := NewManager(WithClock(c))
+	require.NoError(t, restored.Load(path))
+	got, err := restored.Authenticate(secret)
+	require.NoError(t, err)
+	u, err := restored.Usage(got.ID)
+	require.NoError(t, err)
+	assert.EqualValues(t, 4, u.Daily.Used)
+	assert.EqualValues(t, 4, u.Month.Used)
+
+	// 沒有檔案時從空的開始
+	assert.NoError(t, NewManager().Load(filepath.Join(t.TempDir(), "missing")))
+
+	// 過去的期間在存檔時清掉
+	setClock(c, time.Date(2024, 4, 2, 0, 0, 0, 0, time.UTC))
+	require.NoError(t, restored.Save(path))
+	restored.mu.RLock()
+	assert.Empty(t, restored.usage)
+	restored.mu.RUnlock()
+}
+
+func TestRunSavesOnShutdown(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "quota.snap")
+	m, _ := newManager(t)
+	_, key, _ := m.Issue("erin", Plan{})
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		m.Run(ctx, path, time.Hour, func(err error) { t.Error(err) })
+	}()
+	_, err := m.Use(key, 7)
+	require.NoError(t, err)
+	cancel()
+	<-done
+
+	restored := NewManager(WithClock(m.clock))
+	require.NoError(t, restored.Load(path))
+	u, err := restored.Usage(key.ID)
+	require.NoError(t, err)
+	assert.EqualValues(t, 7, u.Daily.Used)
+}
+
+func TestMiddleware(t *testing.T) {
+	m, _ := newManager(t)
+	secret, _, _ := m.Issue("frank", Plan{Name: "tiny", Daily: 2})
+	h := Middleware(m)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		k, ok := KeyFrom(r)
+		require.True(t, ok)
+		w.Write([]byte(k.Owner))
+	}))
+	do := func(secret string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodGet, "/api", nil)
+		if secret != "" {
+			r.Header.Set(HeaderName, secret)
+		}
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, r)
+		return rec
+	}
+
+	assert.Equal(t, http.StatusUnauthorized, do("").Code)
+	assert.Equal(t, http.StatusUnauthorized, do("gl_bad_key").Code)
+
+	rec := do(secret)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "frank", rec.Body.String())
+	assert.Equal(t, "2", rec.Header().Get("X-RateLimit-Limit"))
+	assert.Equal(t, "1", rec.Header().Get("X-RateLimit-Remaining"))
+
+	do(secret)
+	rec = do(secret)
+	assert.Equal(t, http.StatusTooManyRequests, rec.Code)
+	assert.Equal(t, "0", rec.Header().Get("X-RateLimit-Remaining"))
+	assert.Equal(t, "3600", rec.Header().Get("Retry-After"), "one hour until midnight UTC")
+	assert.Contains(t, rec.Body.String(), "daily quota")
+}
+
+func TestReportHandler(t *testing.T) {
+	m, _ := newManager(t)
+	secret, key, _ := m.Issue("gina", Plan{Name: "pro", Daily: 100, Monthly: 1000})
+	_, _ = m.Use(key, 5)
+
+	report := func() (int, Usage) {
+		r := httptest.NewRequest(http.MethodGet, "/usage", nil)
+		r.Header.Set(HeaderName, secret)
+		rec := httptest.NewRecorder()
+		ReportHandler(m).ServeHTTP(rec, r)
+		var u Usage
+		if rec.Code == http.StatusOK {
+			require.NoError(t, json.NewDecoder(rec.Body).Decode(&u))
+		}
+		return rec.Code, u
+	}
+	code, u := report()
+	require.Equal(t, http.StatusOK, code)
+	assert.Equal(t, key.ID, u.KeyID)
+	assert.Equal(t, "pro", u.Plan)
+	assert.EqualValues(t, 5, u.Daily.Used)
+	assert.EqualValues(t, 100, u.Daily.Limit)
+	assert.EqualValues(t, 5, u.Month.Used)
+
+	_, u = report()
+	assert.EqualValues(t, 5, u.Daily.Used, "reports do not count")
+
+	r := httptest.NewRequest(http.MethodGet, "/usage", nil)
+	rec := httptest.NewRecorder()
+	ReportHandler(m).ServeHTTP(rec, r)
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+}
@@ -0,0 +1,83 @@
+package bus
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+/*
+訊息匯流排的抽象層，讓業務程式不需要知道底層是記憶體還是 NATS。
+
+語意：
+  - at-least-once：handler 回傳 nil 才算 ack；回傳 error（或 panic）就是 nack，訊息會重新投遞。
+    因此 handler 必須是 idempotent 的（bus/dedup 以記錄處理過的訊息 ID 達成）
+  - consumer group：同一個 group 的 subscriber 分攤訊息（每則訊息只交給其中一個），
+    不同 group 各自收到一份；group 為空字串時，每個 subscription 自成一組
+  - 超過 MaxDeliver 次仍失敗的訊息交給 DeadLetter 處理，不再重送
+*/
+
+type Message struct {
+	ID      string
+	Topic   string
+	Data    []byte
+	Headers map[string]string
+	// Attempt 從 1 開始，重送時遞增
+	Attempt int
+}
+
+// Handler 回傳 nil 表示 ack，回傳 error 表示 nack
+type Handler func(ctx context.Context, msg *Message) error
+
+type Publisher interface {
+	Publish(ctx context.Context, topic string, data []byte, headers map[string]string) error
+}
+
+type Subscriber interface {
+	// Subscribe 在 ctx 結束或 Unsubscribe 時停止接收；處理中的訊息會被 nack
+	Subscribe(ctx context.Context, topic, group string, h Handler) (Subscription, error)
+}
+
+type Subscription interface {
+	Unsubscribe() error
+}
+
+type Bus interface {
+	Publisher
+	Subscriber
+	Close() error
+}
+
+var (
+	ErrClosed       = errors.New("bus: closed")
+	ErrInvalidTopic = errors.New("bus: invalid topic")
+)
+
+type Options struct {
+	MaxDeliver      int           // 預設 5
+	RedeliveryDelay time.Duration // nack 後多久重送，預設 100ms
+	DeadLetter      func(*Message, error)
+}
+
+type Option func(*Options)
+
+func WithMaxDeliver(n int) Option {
+	return func(o *Options) { o.MaxDeliver = n }
+}
+
+func WithRedeliveryDelay(d time.Duration) Option {
+	return func(o *Options) { o.RedeliveryDelay = d }
+}
+
+// WithDeadLetter 設定超過 MaxDeliver 的訊息要如何處理，err 為最後一次的錯誤
+func WithDeadLetter(fn func(msg *Message, err error)) Option {
+	return func(o *Options) { o.DeadLetter = fn }
+}
+
+func buildOptions(opts []Option) Options {
+	o := Options{MaxDeliver: 5, RedeliveryDelay: 100 * time.Millisecond}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
@@ -0,0 +1,238 @@
+// Package bustest 是 bus.Bus 的 contract test，每個實作都要通過同一組測試。
+package bustest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"advanced/bus"
+	"advanced/timex"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Factory 建立一個新的 Bus；測試會傳入較短的 redelivery 設定，結束時由測試呼叫 Close
+type Factory func(t *testing.T, opts ...bus.Option) bus.Bus
+
+const waitFor = 5 * time.Second
+
+// Run 執行所有 contract test，每個子測試使用獨立的 Bus 與 topic
+func Run(t *testing.T, newBus Factory) {
+	tests := []struct {
+		name string
+		fn   func(*testing.T, Factory)
+	}{
+		{"PublishSubscribe", testPublishSubscribe},
+		{"FanOutAcrossGroups", testFanOut},
+		{"GroupSharesMessages", testGroupShares},
+		{"NackRedelivers", testNackRedelivers},
+		{"PanicIsNack", testPanicIsNack},
+		{"DeadLetter", testDeadLetter},
+		{"Unsubscribe", testUnsubscribe},
+		{"Closed", testClosed},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) { tt.fn(t, newBus) })
+	}
+}
+
+func fast() []bus.Option {
+	return []bus.Option{bus.WithRedeliveryDelay(10 * time.Millisecond)}
+}
+
+func subscribe(t *testing.T, b bus.Bus, topic, group string, h bus.Handler) bus.Subscription {
+	sub, err := b.Subscribe(context.Background(), topic, group, h)
+	require.NoError(t, err)
+	t.Cleanup(func() { sub.Unsubscribe() })
+	return sub
+}
+
+func publish(t *testing.T, b bus.Bus, topic string, n int) {
+	for i := 0; i < n; i++ {
+		require.NoError(t, b.Publish(context.Background(), topic, []byte(fmt.Sprint(i)), map[string]string{"seq": fmt.Sprint(i)}))
+	}
+}
+
+func testPublishSubscribe(t *testing.T, newBus Factory) {
+	b := newBus(t, fast()...)
+	defer b.Close()
+
+	got := make(chan *bus.Message, 1)
+	subscribe(t, b, "orders.created", "", func(ctx context.Context, m *bus.Message) error {
+		got <- m
+		return nil
+	})
+	require.NoError(t, b.Publish(context.Background(), "orders.created", []byte("hello"), map[string]string{"trace": "abc"}))
+
+	select {
+	case m := <-got:
+		assert.Equal(t, "orders.created", m.Topic)
+		assert.Equal(t, []byte("hello"), m.Data)
+		assert.Equal(t, "abc", m.Headers["trace"])
+		assert.Equal(t, 1, m.Attempt)
+		assert.NotEmpty(t, m.ID)
+	case <-time.After(waitFor):
+		t.Fatal("message not delivered")
+	}
+}
+
+// 不同 group 各自收到所有訊息
+func testFanOut(t *testing.T, newBus Factory) {
+	b := newBus(t, fast()...)
+	defer b.Close()
+
+	const n = 20
+	var a, c atomic.Int32
+	subscribe(t, b, "fanout", "billing", func(ctx context.Context, m *bus.Message) error { a.Add(1); return nil })
+	subscribe(t, b, "fanout", "shipping", func(ctx context.Context, m *bus.Message) error { c.Add(1); return nil })
+	publish(t, b, "fanout", n)
+
+	assert.Eventually(t, func() bool { return a.Load() == n && c.Load() == n }, waitFor, 5*time.Millisecond)
+}
+
+// 同一個 group 的 member 分攤訊息，每則只處理一次
+func testGroupShares(t *testing.T, newBus Factory) {
+	b := newBus(t, fast()...)
+	defer b.Close()
+
+	const n = 50
+	var mu sync.Mutex
+	seen := make(map[string]int)
+	perMember := make([]int, 2)
+	for i := 0; i < 2; i++ {
+		i := i
+		subscribe(t, b, "work", "workers", func(ctx context.Context, m *bus.Message) error {
+			mu.Lock()
+			seen[string(m.Data)]++
+			perMember[i]++
+			mu.Unlock()
+			return timex.Sleep(ctx, time.Millisecond)
+		})
+	}
+	publish(t, b, "work", n)
+
+	assert.Eventually(t, func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return len(seen) == n
+	}, waitFor, 5*time.Millisecond)
+	_ = timex.Sleep(context.Background(), 50*time.Millisecond)
+	mu.Lock()
+	defer mu.Unlock()
+	for k, v := range seen {
+		assert.Equal(t, 1, v, "message %s delivered %d times", k, v)
+	}
+	assert.Equal(t, n, perMember[0]+perMember[1])
+}
+
+func testNackRedelivers(t *testing.T, newBus Factory) {
+	b := newBus(t, fast()...)
+	defer b.Close()
+
+	attempts := make(chan int, 10)
+	subscribe(t, b, "retry", "", func(ctx context.Context, m *bus.Message) error {
+		attempts <- m.Attempt
+		if m.Attempt < 3 {
+			return errors.New("temporary")
+		}
+		return nil
+	})
+	publish(t, b, "retry", 1)
+
+	for want := 1; want <= 3; want++ {
+		select {
+		case got := <-attempts:
+			assert.Equal(t, want, got)
+		case <-time.After(waitFor):
+			t.Fatalf("attempt %d not delivered", want)
+		}
+	}
+	// ack 之後不再重送
+	select {
+	case a := <-attempts:
+		t.Fatalf("unexpected redelivery, attempt %d", a)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+func testPanicIsNack(t *testing.T, newBus Factory) {
+	b := newBus(t, fast()...)
+	defer b.Close()
+
+	var calls atomic.Int32
+	done := make(chan struct{})
+	subscribe(t, b, "panic", "", func(ctx context.Context, m *bus.Message) error {
+		if calls.Add(1) == 1 {
+			panic("boom")
+		}
+		close(done)
+		return nil
+	})
+	publish(t, b, "panic", 1)
+	select {
+	case <-done:
+	case <-time.After(waitFor):
+		t.Fatal("message not redelivered after panic")
+	}
+}
+
+func testDeadLetter(t *testing.T, newBus Factory) {
+	dead := make(chan *bus.Message, 1)
+	b := newBus(t, append(fast(),
+		bus.WithMaxDeliver(2),
+		bus.WithDeadLetter(func(m *bus.Message, err error) {
+			assert.EqualError(t, err, "permanent")
+			dead <- m
+		}))...)
+	defer b.Close()
+
+	var calls atomic.Int32
+	subscribe(t, b, "poison", "", func(ctx context.Context, m *bus.Message) error {
+		calls.Add(1)
+		return errors.New("permanent")
+	})
+	publish(t, b, "poison", 1)
+
+	select {
+	case m := <-dead:
+		assert.Equal(t, 2, m.Attempt)
+		assert.Equal(t, []byte("0"), m.Data)
+	case <-time.After(waitFor):
+		t.Fatal("message not dead-lettered")
+	}
+	_ = timex.Sleep(context.Background(), 100*time.Millisecond)
+	assert.Equal(t, int32(2), calls.Load())
+}
+
+func testUnsubscribe(t *testing.T, newBus Factory) {
+	b := newBus(t, fast()...)
+	defer b.Close()
+
+	var calls atomic.Int32
+	sub := subscribe(t, b, "unsub", "", func(ctx context.Context, m *bus.Message) error {
+		calls.Add(1)
+		return nil
+	})
+	publish(t, b, "unsub", 1)
+	assert.Eventually(t, func() bool { return calls.Load() == 1 }, waitFor, 5*time.Millisecond)
+
+	require.NoError(t, sub.Unsubscribe())
+	publish(t, b, "unsub", 1)
+	_ = timex.Sleep(context.Background(), 100*time.Millisecond)
+	assert.Equal(t, int32(1), calls.Load())
+}
+
+func testClosed(t *testing.T, newBus Factory) {
+	b := newBus(t, fast()...)
+	require.NoError(t, b.Close())
+	assert.ErrorIs(t, b.Publish(context.Background(), "x", nil, nil), bus.ErrClosed)
+	_, err := b.Subscribe(context.Background(), "x", "", func(context.Context, *bus.Message) error { return nil })
+	assert.ErrorIs(t, err, bus.ErrClosed)
+}
@@ -0,0 +1,248 @@
+package dedup
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"hash/maphash"
+	"sync/atomic"
+	"time"
+
+	"advanced/bus"
+	"advanced/stream/dedupe"
+	"advanced/timex"
+)
+
+/*
+以消費端去重複模擬 exactly-once。bus 只保證 at-least-once，同一則訊息可能被處理不只一次：
+  - handler 做完副作用、ack 之前 process 掛掉 → 重送
+  - handler 做完副作用，但 ack 沒送到（回傳 error、連線中斷）→ 重送
+  - producer 重試 Publish → bus ID 不同、內容相同的兩則訊息（用 Message-Id header 帶上業務 ID）
+
+消費端記住「處理過的訊息 ID」，重複的直接 ack，下游看起來就像每則訊息只被處理一次。
+關鍵是「記下 ID」和「副作用」在同一個交易中 commit：
+
+	BEGIN
+	INSERT INTO dedup_processed (consumer, msg_key, processed_at) VALUES (...)
+	    ON CONFLICT DO NOTHING                -- 影響 0 筆 = 已經處理過，直接 ack
+	...handler 的副作用，用同一個 tx...
+	COMMIT                                    -- 之後才 ack
+
+  - COMMIT 之前掛掉：ID 與副作用一起 rollback，重送時重新處理
+  - COMMIT 之後、ack 之前掛掉：重送時 INSERT 影響 0 筆，不會再做一次
+  - 先記 ID 再做副作用會掉訊息，先做副作用再記 ID 會重複執行；兩者都留下一個窗口
+副作用不在同一個資料庫時（寄信、呼叫外部 API）做不到真正的 exactly-once，
+只能讓下游也接受 idempotency key（見 idempotency 套件）。
+
+精確集合（資料表）前面放一個 Bloom filter（stream/dedupe.Filter）：
+  - 絕大多數訊息是第一次出現，Bloom 說「一定沒看過」時直接開寫入交易，省掉一次查詢
+  - Bloom 說「可能看過」才查資料表；確定重複就 ack，不必開寫入交易（SQLite 的寫鎖是整個資料庫）
+  - 誤判只多一次查詢；Filter 輪替後忘記的舊 ID 則由 INSERT 的 PRIMARY KEY 擋下。
+    正確性永遠只靠資料表，Bloom 只是加速
+  - Open 時從資料表載入最近的 ID 重建 Bloom，重啟後緊接著的重送一樣走快速路徑
+
+資料表會一直長，Prune 刪掉超過重送期限的 ID；被刪掉的 ID 若再出現會被當成新訊息。
+*/
+
+// HeaderMessageID 是 producer 指定的業務訊息 ID；沒有時改用 bus 給的 Message.ID
+const HeaderMessageID = "Message-Id"
+
+var ErrNoKey = errors.New("dedup: message has no key")
+
+// TxHandler 在 tx 中執行副作用；回傳 error（或 panic）時副作用與 ID 一起 rollback，訊息會重送
+type TxHandler func(ctx context.Context, tx *sql.Tx, msg *bus.Message) error
+
+type config struct {
+	window int
+	fpRate float64
+	seed   maphash.Seed
+	key    func(*bus.Message) string
+	clock  timex.Clock
+}
+
+type Option func(*config)
+
+// WithWindow 設定 Bloom filter 至少記住的最近 ID 數，也是 Open 時載入的數量，預設 100000
+func WithWindow(n int) Option {
+	return func(c *config) { c.window = n }
+}
+
+// WithFalsePositiveRate 設定 Bloom filter 的誤判率，預設 0.01
+func WithFalsePositiveRate(p float64) Option {
+	return func(c *config) { c.fpRate = p }
+}
+
+// WithKey 設定如何從訊息取出去重複用的 ID，預設為 Message-Id header，沒有時用 Message.ID
+func WithKey(fn func(*bus.Message) string) Option {
+	return func(c *config) { c.key = fn }
+}
+
+// WithClock 設定記錄 processed_at 的時鐘（Prune 依它判斷新舊），預設 timex.Real
+func WithClock(clock timex.Clock) Option {
+	return func(c *config) { c.clock = clock }
+}
+
+func defaultKey(msg *bus.Message) string {
+	if id := msg.Headers[HeaderMessageID]; id != "" {
+		return id
+	}
+	return msg.ID
+}
+
+// Stats 是 Consumer 的累計統計
+type Stats struct {
+	Processed      int64 // 第一次處理並 commit
+	Duplicates     int64 // 判定為重複而直接 ack
+	FalsePositives int64 // Bloom 說可能看過，查表後發現沒有
+}
+
+// Consumer 是某個 consumer（通常等於 consumer group）處理過的訊息 ID 集合，可由多個 goroutine 共用
+type Consumer struct {
+	db     *sql.DB
+	name   string
+	cfg    config
+	filter *dedupe.Filter
+
+	processed      atomic.Int64
+	duplicates     atomic.Int64
+	falsePositives atomic.Int64
+}
+
+// Open 建立資料表（已存在則沿用），並從中載入 name 最近處理過的 ID；
+// db 由呼叫端管理，SQLite 建議用 _txlock=immediate 讓並行的交易排隊而不是互相 busy
+func Open(ctx context.Context, db *sql.DB, name string, opts ...Option) (*Consumer, error) {
+	cfg := config{window: 100000, fpRate: 0.01, seed: maphash.MakeSeed(), key: defaultKey, clock: timex.Real{}}
+	for _, o := range opts {
+		o(&cfg)
+	}
+	if _, err := db.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS dedup_processed (
+		consumer     TEXT NOT NULL,
+		msg_key      TEXT NOT NULL,
+		processed_at INTEGER NOT NULL,
+		PRIMARY KEY (consumer, msg_key)
+	)`); err != nil {
+		return nil, err
+	}
+	c := &Consumer{
+		db:   db,
+		name: name,
+		cfg:  cfg,
+		filter: dedupe.NewFilter(
+			dedupe.WithWindow(cfg.window),
+			dedupe.WithFalsePositiveRate(cfg.fpRate),
+			dedupe.WithSeed(cfg.seed),
+		),
+	}
+	if err := c.load(ctx); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// load 依處理時間由舊到新把最近 window 個 ID 放進 Bloom，最新的最不會被輪替掉
+func (c *Consumer) load(ctx context.Context) error {
+	rows, err := c.db.QueryContext(ctx, `SELECT msg_key FROM (
+		SELECT msg_key, processed_at FROM dedup_processed WHERE consumer = $1
+		 ORDER BY processed_at DESC LIMIT $2
+	) recent ORDER BY processed_at`, c.name, c.cfg.window)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var key string
+		if err := rows.Scan(&key); err != nil {
+			return err
+		}
+		c.filter.Seen(key)
+	}
+	return rows.Err()
+}
+
+// Contains 回傳 key 是否已經處理過（查資料表，沒有誤判）
+func (c *Consumer) Contains(ctx context.Context, key string) (bool, error) {
+	var one int
+	err := c.db.QueryRowContext(ctx, `SELECT 1 FROM dedup_processed WHERE consumer = $1 AND msg_key = $2`,
+		c.name, key).Scan(&one)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// Handler 把 h 包成 bus.Handler：重複的訊息直接 ack，新的訊息與它的 ID 在同一個交易中 commit
+func (c *Consumer) Handler(h TxHandler) bus.Handler {
+	return func(ctx context.Context, msg *bus.Message) error {
+		key := c.cfg.key(msg)
+		if key == "" {
+			return ErrNoKey
+		}
+		if c.filter.Contains(key) {
+			seen, err := c.Contains(ctx, key)
+			if err != nil {
+				return err
+			}
+			if seen {
+				c.duplicates.Add(1)
+				return nil
+			}
+			c.falsePositives.Add(1)
+		}
+		first, err := c.process(ctx, key, msg, h)
+		if err != nil {
+			return err
+		}
+		c.filter.Seen(key)
+		if first {
+			c.processed.Add(1)
+		} else {
+			c.duplicates.Add(1)
+		}
+		return nil
+	}
+}
+
+// process 回傳 false 表示 ID 已經在資料表中：Bloom 已經忘記它，或另一個 member 剛處理完同一個 ID
+func (c *Consumer) process(ctx context.Context, key string, msg *bus.Message, h TxHandler) (bool, error) {
+	tx, err := c.db.BeginTx(ctx, nil)
+	if err != nil {
+		return false, err
+	}
+	// commit 之後是 no-op；h 回傳 error 或 panic 時副作用與 ID 一起丟掉
+	defer tx.Rollback()
+
+	res, err := tx.ExecContext(ctx, `INSERT INTO dedup_processed (consumer, msg_key, processed_at)
+		VALUES ($1, $2, $3) ON CONFLICT DO NOTHING`, c.name, key, c.cfg.clock.Now().UnixNano())
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	if n == 0 {
+		return false, nil
+	}
+	if err := h(ctx, tx, msg); err != nil {
+		return false, err
+	}
+	return true, tx.Commit()
+}
+
+// Prune 刪掉 before 之前處理的 ID，回傳刪除的筆數；before 要早於 bus 可能重送的最長期限
+func (c *Consumer) Prune(ctx context.Context, before time.Time) (int64, error) {
+	res, err := c.db.ExecContext(ctx, `DELETE FROM dedup_processed WHERE consumer = $1 AND processed_at < $2`,
+		c.name, before.UnixNano())
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+func (c *Consumer) Stats() Stats {
+	return Stats{
+		Processed:      c.processed.Load(),
+		Duplicates:     c.duplicates.Load(),
+		FalsePositives: c.falsePositives.Load(),
+	}
+}
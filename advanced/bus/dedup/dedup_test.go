@@ -0,0 +1,256 @@
+package dedup
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"advanced/bus"
+	"advanced/timex"
+
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// openDB 開啟檔案型 SQLite；_txlock=immediate 讓並行的寫入交易排隊
+func openDB(t *testing.T, path string) *sql.DB {
+	db, err := sql.Open("sqlite3", "file:"+path+"?_txlock=immediate&_busy_timeout=5000")
+	require.NoError(t, err)
+	_, err = db.Exec(`CREATE TABLE IF NOT EXISTS balances (account TEXT PRIMARY KEY, amount INTEGER NOT NULL)`)
+	require.NoError(t, err)
+	return db
+}
+
+// deposit 是不具 idempotency 的副作用：重複執行就會多加錢
+func deposit(ctx context.Context, tx *sql.Tx, msg *bus.Message) error {
+	amount, err := strconv.Atoi(string(msg.Data))
+	if err != nil {
+		return err
+	}
+	_, err = tx.ExecContext(ctx, `INSERT INTO balances (account, amount) VALUES ('alice', $1)
+		ON CONFLICT (account) DO UPDATE SET amount = amount + excluded.amount`, amount)
+	return err
+}
+
+func balance(t *testing.T, db *sql.DB) int {
+	var n int
+	err := db.QueryRow(`SELECT amount FROM balances WHERE account = 'alice'`).Scan(&n)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0
+	}
+	require.NoError(t, err)
+	return n
+}
+
+func open(t *testing.T, db *sql.DB, opts ...Option) *Consumer {
+	c, err := Open(context.Background(), db, "ledger", opts...)
+	require.NoError(t, err)
+	return c
+}
+
+// countAcks 計算外層 handler 回傳 nil（ack）的次數
+func countAcks(h bus.Handler, acks *atomic.Int64) bus.Handler {
+	return func(ctx context.Context, msg *bus.Message) error {
+		err := h(ctx, msg)
+		if err == nil {
+			acks.Add(1)
+		}
+		return err
+	}
+}
+
+func subscribe(t *testing.T, b *bus.Memory, h bus.Handler) {
+	_, err := b.Subscribe(context.Background(), "deposits", "ledger", h)
+	require.NoError(t, err)
+}
+
+func publish(t *testing.T, b *bus.Memory, n int, id func(i int) string) {
+	for i := 0; i < n; i++ {
+		var headers map[string]string
+		if id != nil {
+			headers = map[string]string{HeaderMessageID: id(i)}
+		}
+		require.NoError(t, b.Publish(context.Background(), "deposits", []byte("1"), headers))
+	}
+}
+
+// COMMIT 之前掛掉（回傳 error 或 panic）：副作用與 ID 一起 rollback，重送後剛好處理一次
+func TestCrashBeforeCommitIsRedelivered(t *testing.T) {
+	db := openDB(t, filepath.Join(t.TempDir(), "ledger.db"))
+	defer db.Close()
+	c := open(t, db)
+	b := bus.NewMemory(bus.WithRedeliveryDelay(time.Millisecond))
+	defer b.Close()
+
+	const n = 20
+	var acks atomic.Int64
+	subscribe(t, b, countAcks(c.Handler(func(ctx context.Context, tx *sql.Tx, msg *bus.Message) error {
+		if err := deposit(ctx, tx, msg); err != nil {
+			return err
+		}
+		if msg.Attempt == 1 {
+			if msg.ID[len(msg.ID)-1]%2 == 0 {
+				panic("crash after side effect")
+			}
+			return errors.New("crash after side effect")
+		}
+		return nil
+	}), &acks))
+	publish(t, b, n, nil)
+
+	require.Eventually(t, func() bool { return acks.Load() == n }, 5*time.Second, time.Millisecond)
+	assert.Equal(t, n, balance(t, db))
+	assert.Equal(t, Stats{Processed: n}, c.Stats())
+}
+
+// COMMIT 之後、ack 之前掛掉：重送時認出處理過了，不會再加一次錢
+func TestCrashAfterCommitIsDeduplicated(t *testing.T) {
+	db := openDB(t, filepath.Join(t.TempDir(), "ledger.db"))
+	defer db.Close()
+	c := open(t, db)
+	b := bus.NewMemory(bus.WithRedeliveryDelay(time.Millisecond))
+	defer b.Close()
+
+	const n = 20
+	var acks atomic.Int64
+	inner := c.Handler(deposit)
+	subscribe(t, b, countAcks(func(ctx context.Context, msg *bus.Message) error {
+		if err := inner(ctx, msg); err != nil {
+			return err
+		}
+		if msg.Attempt == 1 {
+			return errors.New("ack lost")
+		}
+		return nil
+	}, &acks))
+	publish(t, b, n, nil)
+
+	require.Eventually(t, func() bool { return acks.Load() == n }, 5*time.Second, time.Millisecond)
+	assert.Equal(t, n, balance(t, db))
+	assert.Equal(t, Stats{Processed: n, Duplicates: n}, c.Stats())
+}
+
+// producer 重試：同一個 Message-Id 發了兩次，而且由同一個 group 的兩個 member 並行處理
+func TestProducerRetriesAcrossMembers(t *testing.T) {
+	db := openDB(t, filepath.Join(t.TempDir(), "ledger.db"))
+	defer db.Close()
+	c := open(t, db)
+	b := bus.NewMemory(bus.WithRedeliveryDelay(time.Millisecond))
+	defer b.Close()
+
+	const n = 50
+	var acks atomic.Int64
+	h := countAcks(c.Handler(deposit), &acks)
+	subscribe(t, b, h)
+	subscribe(t, b, h)
+	id := func(i int) string { return fmt.Sprintf("deposit-%d", i) }
+	publish(t, b, n, id)
+	publish(t, b, n, id)
+
+	require.Eventually(t, func() bool { return acks.Load() == 2*n }, 5*time.Second, time.Millisecond)
+	assert.Equal(t, n, balance(t, db))
+	st := c.Stats()
+	assert.EqualValues(t, n, st.Processed)
+	assert.EqualValues(t, n, st.Duplicates)
+}
+
+// process 重啟：記憶體中的 Bloom 消失，重新 Open 時從資料表重建；broker 重送所有未 ack 的訊息
+func TestRestartRebuildsFromStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ledger.db")
+	ctx := context.Background()
+	msgs := make([]*bus.Message, 30)
+	for i := range msgs {
+		msgs[i] = &bus.Message{ID: strconv.Itoa(i + 1), Topic: "deposits", Data: []byte("1"), Attempt: 1}
+	}
+
+	db := openDB(t, path)
+	h := open(t, db).Handler(deposit)
+	for _, m := range msgs {
+		require.NoError(t, h(ctx, m))
+	}
+	require.NoError(t, db.Close()) // 掛掉前全部 commit 了，但一個 ack 都沒送出
+
+	db = openDB(t, path)
+	defer db.Close()
+	c := open(t, db)
+	h = c.Handler(deposit)
+	for _, m := range msgs {
+		redelivered := *m
+		redelivered.Attempt = 2
+		require.NoError(t, h(ctx, &redelivered))
+	}
+	assert.Equal(t, len(msgs), balance(t, db))
+	assert.Equal(t, Stats{Duplicates: int64(len(msgs))}, c.Stats(), "every redelivery hit the rebuilt filter")
+
+	require.NoError(t, h(ctx, &bus.Message{ID: "31", Data: []byte("1"), Attempt: 1}))
+	assert.Equal(t, len(msgs)+1, balance(t, db))
+}
+
+// Bloom 誤判或忘記舊 ID 都不影響正確性：前者多查一次表，後者由 PRIMARY KEY 擋下
+func TestFilterIsOnlyAnOptimization(t *testing.T) {
+	db := openDB(t, filepath.Join(t.TempDir(), "ledger.db"))
+	defer db.Close()
+	c := open(t, db, WithWindow(8), WithFalsePositiveRate(0.5))
+	h := c.Handler(deposit)
+	ctx := context.Background()
+
+	const n = 200
+	for i := 0; i < n; i++ {
+		require.NoError(t, h(ctx, &bus.Message{ID: strconv.Itoa(i), Data: []byte("1")}))
+	}
+	assert.Equal(t, n, balance(t, db))
+	st := c.Stats()
+	assert.EqualValues(t, n, st.Processed)
+	assert.Positive(t, st.FalsePositives)
+
+	// 最早的 ID 早就被輪替掉了，仍然不會重複處理
+	for i := 0; i < n; i++ {
+		require.NoError(t, h(ctx, &bus.Message{ID: strconv.Itoa(i), Data: []byte("1")}))
+	}
+	assert.Equal(t, n, balance(t, db))
+	assert.EqualValues(t, n, c.Stats().Duplicates)
+}
+
+func TestHandlerErrorsAreNacks(t *testing.T) {
+	db := openDB(t, filepath.Join(t.TempDir(), "ledger.db"))
+	defer db.Close()
+	c := open(t, db, WithKey(func(m *bus.Message) string { return m.Headers["order"] }))
+	ctx := context.Background()
+
+	assert.ErrorIs(t, c.Handler(deposit)(ctx, &bus.Message{ID: "1", Data: []byte("1")}), ErrNoKey)
+
+	msg := &bus.Message{ID: "1", Data: []byte("not a number"), Headers: map[string]string{"order": "o-1"}}
+	assert.Error(t, c.Handler(deposit)(ctx, msg))
+	seen, err := c.Contains(ctx, "o-1")
+	require.NoError(t, err)
+	assert.False(t, seen, "a failed handler must not mark the message")
+}
+
+func TestPrune(t *testing.T) {
+	db := openDB(t, filepath.Join(t.TempDir(), "ledger.db"))
+	defer db.Close()
+	clock := timex.NewFake(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	c := open(t, db, WithClock(clock))
+	h := c.Handler(deposit)
+	ctx := context.Background()
+
+	require.NoError(t, h(ctx, &bus.Message{ID: "old", Data: []byte("1")}))
+	clock.Advance(time.Hour)
+	require.NoError(t, h(ctx, &bus.Message{ID: "new", Data: []byte("1")}))
+
+	pruned, err := c.Prune(ctx, clock.Now().Add(-time.Minute))
+	require.NoError(t, err)
+	assert.EqualValues(t, 1, pruned)
+	for id, want := range map[string]bool{"old": false, "new": true} {
+		seen, err := c.Contains(ctx, id)
+		require.NoError(t, err)
+		assert.Equal(t, want, seen, id)
+	}
+}
@@ -0,0 +1,290 @@
+package bus
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"advanced/concurrency/pubsub"
+)
+
+// Memory 是單一 process 內的 Bus 實作，訊息只存在記憶體中，process 結束就消失。
+// topic 到各個 group 的扇出交給 concurrency/pubsub（每個 group 一個 Block 訂閱，不會漏訊息）；
+// pubsub 只負責送達，group 內的分配、ack 與重送由這裡處理
+type Memory struct {
+	opts   Options
+	broker *pubsub.Broker[*Message]
+
+	mu     sync.Mutex
+	topics map[string]map[string]*group // topic -> group name -> group
+	// 已經交給 group、但 group 被移除時還沒 ack 的訊息，等同名的 group 再出現時接手
+	pending map[groupKey][]*Message
+	closed  bool
+	subs    map[*memorySub]struct{}
+	wg      sync.WaitGroup
+	seq     atomic.Uint64
+	anon    atomic.Uint64
+}
+
+var _ Bus = (*Memory)(nil)
+
+func NewMemory(opts ...Option) *Memory {
+	return &Memory{
+		opts:    buildOptions(opts),
+		broker:  pubsub.New[*Message](),
+		topics:  make(map[string]map[string]*group),
+		pending: make(map[groupKey][]*Message),
+		subs:    make(map[*memorySub]struct{}),
+	}
+}
+
+type groupKey struct{ topic, name string }
+
+// group 內的 subscriber 共用一個佇列
+type group struct {
+	key     groupKey
+	anon    bool // 匿名 group 不會再有人加入，移除時不保留訊息
+	sub     *pubsub.Subscription[*Message]
+	pumped  chan struct{} // pump 結束時關閉
+	mu      sync.Mutex
+	queue   []*Message
+	notify  chan struct{}
+	members int
+}
+
+func (g *group) push(m *Message) {
+	g.mu.Lock()
+	g.queue = append(g.queue, m)
+	g.mu.Unlock()
+	select {
+	case g.notify <- struct{}{}:
+	default:
+	}
+}
+
+// pump 把 broker 送來的訊息複製一份放進佇列，避免 handler 修改到其他 group 的資料
+func (g *group) pump() {
+	defer close(g.pumped)
+	for m := range g.sub.C() {
+		g.push(&Message{ID: m.ID, Topic: m.Topic, Data: append([]byte(nil), m.Data...), Headers: copyHeaders(m.Headers), Attempt: 1})
+	}
+}
+
+func (g *group) pop() (*Message, bool) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if len(g.queue) == 0 {
+		return nil, false
+	}
+	m := g.queue[0]
+	g.queue = g.queue[1:]
+	// 還有訊息時喚醒其他 member
+	if len(g.queue) > 0 {
+		select {
+		case g.notify <- struct{}{}:
+		default:
+		}
+	}
+	return m, true
+}
+
+func (b *Memory) Publish(ctx context.Context, topic string, data []byte, headers map[string]string) error {
+	if topic == "" {
+		return ErrInvalidTopic
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	id := strconv.FormatUint(b.seq.Add(1), 10)
+	// group 的 pump 只是把訊息放進佇列，Block 不會卡住 publisher 太久
+	_, err := b.broker.Publish(ctx, topic, &Message{ID: id, Topic: topic, Data: data, Headers: headers})
+	if errors.Is(err, pubsub.ErrClosed) {
+		return ErrClosed
+	}
+	return err
+}
+
+func copyHeaders(h map[string]string) map[string]string {
+	if h == nil {
+		return nil
+	}
+	out := make(map[string]string, len(h))
+	for k, v := range h {
+		out[k] = v
+	}
+	return out
+}
+
+type memorySub struct {
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+func (s *memorySub) Unsubscribe() error {
+	s.cancel()
+	<-s.done
+	return nil
+}
+
+func (b *Memory) Subscribe(ctx context.Context, topic, groupName string, h Handler) (Subscription, error) {
+	if topic == "" {
+		return nil, ErrInvalidTopic
+	}
+	b.mu.Lock()
+	if b.closed {
+		b.mu.Unlock()
+		return nil, ErrClosed
+	}
+	anon := groupName == ""
+	if anon {
+		groupName = fmt.Sprintf("_anon.%d", b.anon.Add(1))
+	}
+	groups := b.topics[topic]
+	if groups == nil {
+		groups = make(map[string]*group)
+		b.topics[topic] = groups
+	}
+	g := groups[groupName]
+	if g == nil {
+		key := groupKey{topic: topic, name: groupName}
+		sub, err := b.broker.Subscribe(context.Background(), topic, pubsub.WithBuffer(64), pubsub.WithPolicy(pubsub.Block))
+		if err != nil {
+			b.mu.Unlock()
+			return nil, err
+		}
+		g = &group{key: key, anon: anon, sub: sub, pumped: make(chan struct{}), notify: make(chan struct{}, 1), queue: b.pending[key]}
+		delete(b.pending, key)
+		groups[groupName] = g
+		go g.pump()
+	}
+	g.members++
+	ctx, cancel := context.WithCancel(ctx)
+	sub := &memorySub{cancel: cancel, done: make(chan struct{})}
+	b.subs[sub] = struct{}{}
+	b.wg.Add(1)
+	b.mu.Unlock()
+
+	go func() {
+		defer b.wg.Done()
+		defer close(sub.done)
+		defer b.leave(g, sub)
+		b.consume(ctx, g, h)
+	}()
+	return sub, nil
+}
+
+// leave 在最後一個 member 離開時移除 group，之後發佈的訊息不再保留給它；
+// 已經在佇列中的訊息留給之後加入的同名 group
+func (b *Memory) leave(g *group, sub *memorySub) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	delete(b.subs, sub)
+	g.members--
+	if g.members == 0 {
+		delete(b.topics[g.key.topic], g.key.name)
+		// 停止訂閱並等 pump 把已經送達的訊息放進佇列，再一起保留
+		g.sub.Unsubscribe()
+		<-g.pumped
+		g.mu.Lock()
+		left := g.queue
+		g.queue = nil
+		g.mu.Unlock()
+		b.keep(g, left...)
+	} else {
+		// 讓其他 member 接手可能留下的訊息
+		select {
+		case g.notify <- struct{}{}:
+		default:
+		}
+	}
+}
+
+func (b *Memory) consume(ctx context.Context, g *group, h Handler) {
+	for {
+		m, ok := g.pop()
+		if !ok {
+			select {
+			case <-g.notify:
+				continue
+			case <-ctx.Done():
+				return
+			}
+		}
+		if ctx.Err() != nil {
+			// 已經取消：放回佇列給其他 member
+			b.requeue(g, m)
+			return
+		}
+		err := safeHandle(ctx, h, m)
+		if err == nil {
+			continue
+		}
+		if ctx.Err() != nil {
+			// consumer 被取消造成的 nack 不是 handler 失敗，不計入 Attempt
+			b.requeue(g, m)
+			return
+		}
+		if m.Attempt < b.opts.MaxDeliver {
+			b.redeliver(g, m)
+			continue
+		}
+		if b.opts.DeadLetter != nil {
+			b.opts.DeadLetter(m, err)
+		}
+	}
+}
+
+func (b *Memory) redeliver(g *group, m *Message) {
+	next := *m
+	next.Attempt++
+	time.AfterFunc(b.opts.RedeliveryDelay, func() { b.requeue(g, &next) })
+}
+
+// requeue 把訊息放回 g 的名稱目前對應的 group；group 已經被移除時先保留，不會默默丟掉
+func (b *Memory) requeue(g *group, m *Message) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if cur := b.topics[g.key.topic][g.key.name]; cur != nil {
+		cur.push(m)
+		return
+	}
+	b.keep(g, m)
+}
+
+// keep 保留已移除 group 的訊息，呼叫時要持有 b.mu；匿名 group 不會再有人加入，直接丟掉
+func (b *Memory) keep(g *group, msgs ...*Message) {
+	if g.anon || len(msgs) == 0 {
+		return
+	}
+	b.pending[g.key] = append(b.pending[g.key], msgs...)
+}
+
+func safeHandle(ctx context.Context, h Handler, m *Message) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("bus: handler panic: %v", r)
+		}
+	}()
+	return h(ctx, m)
+}
+
+// Close 停止接受新的 Publish/Subscribe，取消所有 subscription 並等待處理中的 handler 結束
+func (b *Memory) Close() error {
+	b.mu.Lock()
+	if b.closed {
+		b.mu.Unlock()
+		return nil
+	}
+	b.closed = true
+	b.broker.Close()
+	for sub := range b.subs {
+		sub.cancel()
+	}
+	b.mu.Unlock()
+	b.wg.Wait()
+	return nil
+}
@@ -0,0 +1,140 @@
+package bus_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"advanced/bus"
+	"advanced/bus/bustest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemoryContract(t *testing.T) {
+	bustest.Run(t, func(t *testing.T, opts ...bus.Option) bus.Bus { return bus.NewMemory(opts...) })
+}
+
+// 訂閱前發佈的訊息不會保留（與 NATS core 相同，需要持久化請使用 JetStream）
+func TestMemoryNoRetention(t *testing.T) {
+	b := bus.NewMemory()
+	defer b.Close()
+	assert.NoError(t, b.Publish(context.Background(), "t", []byte("lost"), nil))
+
+	got := make(chan struct{}, 1)
+	_, err := b.Subscribe(context.Background(), "t", "", func(ctx context.Context, m *bus.Message) error {
+		got <- struct{}{}
+		return nil
+	})
+	assert.NoError(t, err)
+	select {
+	case <-got:
+		t.Fatal("unexpected delivery")
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+// 取消訂閱時，group 中尚未處理的訊息交給其他 member
+func TestMemoryHandoverOnLeave(t *testing.T) {
+	b := bus.NewMemory(bus.WithRedeliveryDelay(time.Millisecond))
+	defer b.Close()
+
+	block := make(chan struct{})
+	first, err := b.Subscribe(context.Background(), "t", "g", func(ctx context.Context, m *bus.Message) error {
+		select {
+		case <-block:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+		return nil
+	})
+	assert.NoError(t, err)
+	for i := 0; i < 3; i++ {
+		assert.NoError(t, b.Publish(context.Background(), "t", []byte{byte(i)}, nil))
+	}
+
+	received := make(chan byte, 3)
+	_, err = b.Subscribe(context.Background(), "t", "g", func(ctx context.Context, m *bus.Message) error {
+		received <- m.Data[0]
+		return nil
+	})
+	assert.NoError(t, err)
+	time.Sleep(20 * time.Millisecond)
+	assert.NoError(t, first.Unsubscribe()) // 處理中的訊息被 nack，重送給第二個 member
+
+	seen := map[byte]bool{}
+	for len(seen) < 3 {
+		select {
+		case d := <-received:
+			seen[d] = true
+		case <-time.After(time.Second):
+			t.Fatalf("only got %v", seen)
+		}
+	}
+}
+
+// 重送時 group 已經沒有 member：訊息留給之後加入的同名 group，不會默默消失
+func TestMemoryRedeliveryOutlivesGroup(t *testing.T) {
+	b := bus.NewMemory(bus.WithRedeliveryDelay(20 * time.Millisecond))
+	defer b.Close()
+
+	nacked := make(chan struct{})
+	first, err := b.Subscribe(context.Background(), "t", "g", func(ctx context.Context, m *bus.Message) error {
+		close(nacked)
+		return errors.New("try again")
+	})
+	require.NoError(t, err)
+	require.NoError(t, b.Publish(context.Background(), "t", []byte("x"), nil))
+	<-nacked
+	require.NoError(t, first.Unsubscribe()) // 最後一個 member 離開，group 被移除
+	time.Sleep(50 * time.Millisecond)       // 重送在 group 不存在時觸發
+
+	got := make(chan *bus.Message, 1)
+	_, err = b.Subscribe(context.Background(), "t", "g", func(ctx context.Context, m *bus.Message) error {
+		got <- m
+		return nil
+	})
+	require.NoError(t, err)
+	select {
+	case m := <-got:
+		assert.Equal(t, "x", string(m.Data))
+		assert.Equal(t, 2, m.Attempt)
+	case <-time.After(time.Second):
+		t.Fatal("redelivered message was dropped")
+	}
+}
+
+// consumer 被取消造成的 nack 不計入 Attempt，不會因此被送進 dead letter
+func TestMemoryCancelledNackKeepsAttempt(t *testing.T) {
+	dead := make(chan *bus.Message, 1)
+	b := bus.NewMemory(bus.WithMaxDeliver(1), bus.WithDeadLetter(func(m *bus.Message, err error) { dead <- m }))
+	defer b.Close()
+
+	started := make(chan struct{})
+	first, err := b.Subscribe(context.Background(), "t", "g", func(ctx context.Context, m *bus.Message) error {
+		close(started)
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	require.NoError(t, err)
+	require.NoError(t, b.Publish(context.Background(), "t", []byte("x"), nil))
+	<-started
+	require.NoError(t, first.Unsubscribe())
+
+	got := make(chan *bus.Message, 1)
+	_, err = b.Subscribe(context.Background(), "t", "g", func(ctx context.Context, m *bus.Message) error {
+		got <- m
+		return nil
+	})
+	require.NoError(t, err)
+	select {
+	case m := <-got:
+		assert.Equal(t, 1, m.Attempt)
+	case m := <-dead:
+		t.Fatalf("dead-lettered after a cancelled nack: %+v", m)
+	case <-time.After(time.Second):
+		t.Fatal("message lost")
+	}
+}
@@ -0,0 +1,200 @@
+package bus
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nuid"
+)
+
+/*
+NATS 的 adapter。NATS core 只有 at-most-once，沒有 ack，所以這裡使用 JetStream：
+  - 每個 NATS bus 對應一個 stream，subject 為 "<prefix>.<topic>"
+  - consumer group 對應一個 durable push consumer（DeliverGroup 讓同組 member 分攤訊息）
+  - ack/nack 對應 msg.Ack() / msg.NakWithDelay()，超過 MaxDeliver 則 Term() 並交給 DeadLetter
+  - consumer 由這裡建立後再 Bind，任何一個 member Unsubscribe 都不會刪掉整組的 consumer
+*/
+
+type NATS struct {
+	js     nats.JetStreamContext
+	opts   Options
+	stream string
+	prefix string
+
+	mu     sync.Mutex
+	closed bool
+	subs   map[*natsSub]struct{}
+}
+
+var _ Bus = (*NATS)(nil)
+
+// NewNATS 在 nc 上建立（或沿用）名為 stream 的 JetStream stream；nc 由呼叫端負責關閉
+func NewNATS(nc *nats.Conn, stream string, opts ...Option) (*NATS, error) {
+	js, err := nc.JetStream()
+	if err != nil {
+		return nil, err
+	}
+	prefix := strings.ToLower(stream)
+	_, err = js.AddStream(&nats.StreamConfig{
+		Name:     stream,
+		Subjects: []string{prefix + ".>"},
+		Storage:  nats.FileStorage,
+	})
+	if err != nil && !errors.Is(err, nats.ErrStreamNameAlreadyInUse) {
+		return nil, fmt.Errorf("bus: add stream: %w", err)
+	}
+	return &NATS{js: js, opts: buildOptions(opts), stream: stream, prefix: prefix, subs: make(map[*natsSub]struct{})}, nil
+}
+
+func (b *NATS) subject(topic string) string { return b.prefix + "." + topic }
+
+func (b *NATS) Publish(ctx context.Context, topic string, data []byte, headers map[string]string) error {
+	if topic == "" {
+		return ErrInvalidTopic
+	}
+	b.mu.Lock()
+	closed := b.closed
+	b.mu.Unlock()
+	if closed {
+		return ErrClosed
+	}
+	msg := nats.NewMsg(b.subject(topic))
+	msg.Data = data
+	for k, v := range headers {
+		msg.Header.Set(k, v)
+	}
+	// Nats-Msg-Id 讓 JetStream 在 duplicate window 內去除重複發佈
+	_, err := b.js.PublishMsg(msg, nats.MsgId(nuid.Next()), nats.Context(ctx))
+	return err
+}
+
+type natsSub struct {
+	sub    *nats.Subscription
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+func (s *natsSub) Unsubscribe() error {
+	s.cancel()
+	<-s.done
+	return nil
+}
+
+func (b *NATS) Subscribe(ctx context.Context, topic, group string, h Handler) (Subscription, error) {
+	if topic == "" {
+		return nil, ErrInvalidTopic
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed {
+		return nil, ErrClosed
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	s := &natsSub{cancel: cancel, done: make(chan struct{})}
+	cb := func(m *nats.Msg) { b.handle(ctx, topic, m, h) }
+	subject := b.subject(topic)
+
+	var err error
+	if group == "" {
+		// 沒有 group：ephemeral consumer，Unsubscribe 時由 JetStream 刪除
+		s.sub, err = b.js.Subscribe(subject, cb,
+			nats.BindStream(b.stream), nats.ManualAck(), nats.DeliverNew(), nats.MaxDeliver(b.opts.MaxDeliver))
+	} else {
+		durable := durableName(topic, group)
+		_, err = b.js.AddConsumer(b.stream, &nats.ConsumerConfig{
+			Durable:        durable,
+			DeliverSubject: "_bus.deliver." + b.prefix + "." + durable,
+			DeliverGroup:   durable,
+			DeliverPolicy:  nats.DeliverNewPolicy,
+			AckPolicy:      nats.AckExplicitPolicy,
+			MaxDeliver:     b.opts.MaxDeliver,
+			FilterSubject:  subject,
+		})
+		if err == nil {
+			s.sub, err = b.js.QueueSubscribe(subject, durable, cb, nats.Bind(b.stream, durable), nats.ManualAck())
+		}
+	}
+	if err != nil {
+		cancel()
+		return nil, fmt.Errorf("bus: subscribe %s: %w", topic, err)
+	}
+
+	b.subs[s] = struct{}{}
+	go func() {
+		<-ctx.Done()
+		// Drain 會等處理中的 callback 結束後才關閉 subscription
+		closed := s.sub.StatusChanged(nats.SubscriptionClosed)
+		if err := s.sub.Drain(); err == nil {
+			<-closed
+		}
+		b.mu.Lock()
+		delete(b.subs, s)
+		b.mu.Unlock()
+		close(s.done)
+	}()
+	return s, nil
+}
+
+// durable 名稱不能包含 "."、"*"、">"
+func durableName(topic, group string) string {
+	r := strings.NewReplacer(".", "_", "*", "_", ">", "_")
+	return r.Replace(topic) + "__" + r.Replace(group)
+}
+
+func (b *NATS) handle(ctx context.Context, topic string, m *nats.Msg, h Handler) {
+	msg := &Message{Topic: topic, Data: m.Data, Attempt: 1}
+	if meta, err := m.Metadata(); err == nil {
+		msg.Attempt = int(meta.NumDelivered)
+		msg.ID = strconv.FormatUint(meta.Sequence.Stream, 10)
+	}
+	if id := m.Header.Get(nats.MsgIdHdr); id != "" {
+		msg.ID = id
+	}
+	for k := range m.Header {
+		if strings.HasPrefix(k, "Nats-") {
+			continue
+		}
+		if msg.Headers == nil {
+			msg.Headers = make(map[string]string)
+		}
+		msg.Headers[k] = m.Header.Get(k)
+	}
+
+	err := safeHandle(ctx, h, msg)
+	switch {
+	case err == nil:
+		m.Ack()
+	case ctx.Err() == nil && msg.Attempt >= b.opts.MaxDeliver:
+		if b.opts.DeadLetter != nil {
+			b.opts.DeadLetter(msg, err)
+		}
+		m.Term()
+	default:
+		m.NakWithDelay(b.opts.RedeliveryDelay)
+	}
+}
+
+// Close 取消所有 subscription 並等待處理中的 handler 結束，不會關閉 nats.Conn
+func (b *NATS) Close() error {
+	b.mu.Lock()
+	if b.closed {
+		b.mu.Unlock()
+		return nil
+	}
+	b.closed = true
+	subs := make([]*natsSub, 0, len(b.subs))
+	for s := range b.subs {
+		subs = append(subs, s)
+	}
+	b.mu.Unlock()
+	for _, s := range subs {
+		s.Unsubscribe()
+	}
+	return nil
+}
@@ -0,0 +1,53 @@
+package bus_test
+
+import (
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"advanced/bus"
+	"advanced/bus/bustest"
+
+	"github.com/nats-io/nats-server/v2/server"
+	"github.com/nats-io/nats.go"
+	"github.com/stretchr/testify/require"
+)
+
+// 在 process 內啟動一個開啟 JetStream 的 nats-server，不需要外部服務
+func runServer(t *testing.T) *nats.Conn {
+	t.Helper()
+	ns, err := server.NewServer(&server.Options{
+		Host:      "127.0.0.1",
+		Port:      -1,
+		JetStream: true,
+		StoreDir:  t.TempDir(),
+		NoLog:     true,
+		NoSigs:    true,
+	})
+	require.NoError(t, err)
+	go ns.Start()
+	if !ns.ReadyForConnections(5 * time.Second) {
+		t.Fatal("nats-server not ready")
+	}
+	t.Cleanup(ns.Shutdown)
+
+	nc, err := nats.Connect(ns.ClientURL())
+	require.NoError(t, err)
+	t.Cleanup(nc.Close)
+	return nc
+}
+
+func TestNATSContract(t *testing.T) {
+	if testing.Short() {
+		t.Skip("starts an embedded nats-server")
+	}
+	nc := runServer(t)
+	var n atomic.Int32
+	bustest.Run(t, func(t *testing.T, opts ...bus.Option) bus.Bus {
+		// 每個子測試使用獨立的 stream，避免互相影響
+		b, err := bus.NewNATS(nc, fmt.Sprintf("BUSTEST%d", n.Add(1)), opts...)
+		require.NoError(t, err)
+		return b
+	})
+}
@@ -0,0 +1,143 @@
+package hashring
+
+import (
+	"hash/fnv"
+	"sort"
+	"strconv"
+	"sync"
+)
+
+/*
+Consistent hashing：
+  - 把每個節點以 replicas 個虛擬節點（"<node>#<i>" 的 hash）放到 0 ~ 2^32-1 的環上
+  - key 的 hash 往順時針方向找到的第一個虛擬節點就是它的 owner
+  - 增減一個節點時只有相鄰區間的 key 會換 owner（約 1/N），不像 hash % N 幾乎全部搬家
+虛擬節點越多分布越平均，但 Get 的二分搜尋與記憶體成本也越高，常見取 100~200。
+crc32 對 "node#1"、"node#2" 這類只差一個字元的字串分布很差（測試中節點間差到 ±35%），
+所以預設用 fnv-1a 再經過 murmur3 的 finalizer 把 bit 打散。
+*/
+
+type Hash func([]byte) uint32
+
+func defaultHash(b []byte) uint32 {
+	h := fnv.New32a()
+	_, _ = h.Write(b)
+	x := h.Sum32()
+	x ^= x >> 16
+	x *= 0x85ebca6b
+	x ^= x >> 13
+	x *= 0xc2b2ae35
+	x ^= x >> 16
+	return x
+}
+
+type Ring struct {
+	mu       sync.RWMutex
+	replicas int
+	hash     Hash
+	points   []uint32          // 已排序的虛擬節點 hash
+	owners   map[uint32]string // 虛擬節點 hash → 實體節點
+	nodes    map[string]struct{}
+}
+
+type Option func(*Ring)
+
+// WithHash 替換 hash 函式（預設 fnv-1a + mix），測試時可用來固定位置
+func WithHash(h Hash) Option {
+	return func(r *Ring) { r.hash = h }
+}
+
+func New(replicas int, opts ...Option) *Ring {
+	if replicas <= 0 {
+		replicas = 1
+	}
+	r := &Ring{
+		replicas: replicas,
+		hash:     defaultHash,
+		owners:   make(map[uint32]string),
+		nodes:    make(map[string]struct{}),
+	}
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r
+}
+
+func (r *Ring) Add(nodes ...string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, n := range nodes {
+		if _, ok := r.nodes[n]; ok {
+			continue
+		}
+		r.nodes[n] = struct{}{}
+		for i := 0; i < r.replicas; i++ {
+			h := r.hash([]byte(n + "#" + strconv.Itoa(i)))
+			// hash 碰撞時保留先加入的節點，避免同一個點的 owner 隨加入順序改變
+			if _, ok := r.owners[h]; ok {
+				continue
+			}
+			r.owners[h] = n
+			r.points = append(r.points, h)
+		}
+	}
+	sort.Slice(r.points, func(i, j int) bool { return r.points[i] < r.points[j] })
+}
+
+func (r *Ring) Remove(node string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.nodes[node]; !ok {
+		return
+	}
+	delete(r.nodes, node)
+	points := r.points[:0]
+	for _, p := range r.points {
+		if r.owners[p] == node {
+			delete(r.owners, p)
+			continue
+		}
+		points = append(points, p)
+	}
+	r.points = points
+}
+
+// Get 回傳 key 的 owner，環是空的時回傳 false
+func (r *Ring) Get(key string) (string, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if len(r.points) == 0 {
+		return "", false
+	}
+	h := r.hash([]byte(key))
+	i := sort.Search(len(r.points), func(i int) bool { return r.points[i] >= h })
+	if i == len(r.points) {
+		i = 0 // 繞回環的起點
+	}
+	return r.owners[r.points[i]], true
+}
+
+func (r *Ring) Has(node string) bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	_, ok := r.nodes[node]
+	return ok
+}
+
+// Nodes 回傳排序後的實體節點
+func (r *Ring) Nodes() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	nodes := make([]string, 0, len(r.nodes))
+	for n := range r.nodes {
+		nodes = append(nodes, n)
+	}
+	sort.Strings(nodes)
+	return nodes
+}
+
+func (r *Ring) Len() int {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return len(r.nodes)
+}
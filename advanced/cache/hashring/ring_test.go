@@ -0,0 +1,58 @@
+package hashring
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEmpty(t *testing.T) {
+	_, ok := New(10).Get("k")
+	assert.False(t, ok)
+}
+
+func TestDistribution(t *testing.T) {
+	r := New(128)
+	r.Add("a", "b", "c", "d")
+	count := map[string]int{}
+	for i := 0; i < 40000; i++ {
+		n, ok := r.Get(fmt.Sprintf("key-%d", i))
+		require.True(t, ok)
+		count[n]++
+	}
+	for n, c := range count {
+		// 理想值 10000，虛擬節點讓誤差維持在 ±25% 內
+		assert.InDelta(t, 10000, c, 2500, "node %s", n)
+	}
+}
+
+func TestMinimalMovement(t *testing.T) {
+	r := New(128)
+	r.Add("a", "b", "c", "d")
+	before := map[string]string{}
+	for i := 0; i < 10000; i++ {
+		k := fmt.Sprintf("key-%d", i)
+		before[k], _ = r.Get(k)
+	}
+
+	r.Add("e")
+	moved := 0
+	for k, old := range before {
+		n, _ := r.Get(k)
+		if n != old {
+			assert.Equal(t, "e", n, "key 只會搬到新節點")
+			moved++
+		}
+	}
+	// 理想值 1/5
+	assert.InDelta(t, 2000, moved, 600)
+
+	r.Remove("e")
+	for k, old := range before {
+		n, _ := r.Get(k)
+		assert.Equal(t, old, n)
+	}
+	assert.Equal(t, []string{"a", "b", "c", "d"}, r.Nodes())
+}
@@ -0,0 +1,180 @@
+package httpcache
+
+import (
+	"container/list"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"advanced/timex"
+)
+
+type entry struct {
+	key     string
+	header  http.Header
+	body    []byte
+	expires time.Time
+}
+
+func (e *entry) size() int64 {
+	n := int64(len(e.key) + len(e.body))
+	for k, vs := range e.header {
+		for _, v := range vs {
+			n += int64(len(k) + len(v))
+		}
+	}
+	return n
+}
+
+type Stats struct {
+	Hits, Misses, Evictions int64
+	Entries                 int
+	Bytes                   int64
+}
+
+type Cache struct {
+	maxBytes int64
+	ttl      time.Duration
+	vary     []string
+	clock    timex.Clock
+
+	mu    sync.Mutex
+	ll    *list.List // 前面是最近使用的
+	items map[string]*list.Element
+	stats Stats
+}
+
+type Option func(*Cache)
+
+// WithMaxBytes 設定快取容量（回應的 header + body），預設 32 MiB
+func WithMaxBytes(n int64) Option {
+	return func(c *Cache) { c.maxBytes = n }
+}
+
+// WithTTL 設定回應快取多久，預設 1 分鐘
+func WithTTL(d time.Duration) Option {
+	return func(c *Cache) { c.ttl = d }
+}
+
+// WithVary 把 request header 加入快取的 key，內容依這些 header 而不同時使用
+func WithVary(headers ...string) Option {
+	return func(c *Cache) { c.vary = headers }
+}
+
+// WithClock 設定判斷過期的時鐘，預設 timex.Real
+func WithClock(clock timex.Clock) Option {
+	return func(c *Cache) { c.clock = clock }
+}
+
+func NewCache(opts ...Option) *Cache {
+	c := &Cache{maxBytes: 32 << 20, ttl: time.Minute, clock: timex.Real{}, ll: list.New(), items: make(map[string]*list.Element)}
+	for _, o := range opts {
+		o(c)
+	}
+	return c
+}
+
+func (c *Cache) Stats() Stats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	s := c.stats
+	s.Entries = c.ll.Len()
+	return s
+}
+
+func (c *Cache) key(r *http.Request) string {
+	var b strings.Builder
+	b.WriteString(r.URL.RequestURI())
+	for _, h := range c.vary {
+		b.WriteString("\x00" + r.Header.Get(h))
+	}
+	return b.String()
+}
+
+func (c *Cache) get(key string) (*entry, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	el, ok := c.items[key]
+	if !ok {
+		c.stats.Misses++
+		return nil, false
+	}
+	e := el.Value.(*entry)
+	if !c.clock.Now().Before(e.expires) {
+		c.remove(el)
+		c.stats.Misses++
+		return nil, false
+	}
+	c.ll.MoveToFront(el)
+	c.stats.Hits++
+	return e, true
+}
+
+func (c *Cache) remove(el *list.Element) {
+	e := c.ll.Remove(el).(*entry)
+	delete(c.items, e.key)
+	c.stats.Bytes -= e.size()
+}
+
+func (c *Cache) set(e *entry) {
+	size := e.size()
+	if size > c.maxBytes {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if el, ok := c.items[e.key]; ok {
+		c.remove(el)
+	}
+	c.items[e.key] = c.ll.PushFront(e)
+	c.stats.Bytes += size
+	for c.stats.Bytes > c.maxBytes {
+		c.remove(c.ll.Back())
+		c.stats.Evictions++
+	}
+}
+
+func cacheable(h http.Header) bool {
+	for _, d := range strings.Split(h.Get("Cache-Control"), ",") {
+		switch strings.ToLower(strings.TrimSpace(d)) {
+		case "no-store", "private":
+			return false
+		}
+	}
+	return true
+}
+
+// Middleware 快取 GET 的 200 回應
+func (c *Cache) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			next.ServeHTTP(w, r)
+			return
+		}
+		key := c.key(r)
+		if !strings.Contains(r.Header.Get("Cache-Control"), "no-cache") {
+			if e, ok := c.get(key); ok {
+				for k, vs := range e.header {
+					w.Header()[k] = append([]string(nil), vs...)
+				}
+				w.Header().Set("X-Cache", "HIT")
+				w.WriteHeader(http.StatusOK)
+				w.Write(e.body)
+				return
+			}
+		}
+
+		buf := newBufferedWriter()
+		next.ServeHTTP(buf, r)
+		if buf.status == http.StatusOK && cacheable(buf.header) {
+			c.set(&entry{key: key, header: buf.header.Clone(), body: buf.body.Bytes(), expires: c.clock.Now().Add(c.ttl)})
+		}
+		for k, vs := range buf.header {
+			w.Header()[k] = vs
+		}
+		w.Header().Set("X-Cache", "MISS")
+		w.WriteHeader(buf.status)
+		w.Write(buf.body.Bytes())
+	})
+}
@@ -0,0 +1,129 @@
+package httpcache
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"strings"
+	"time"
+)
+
+/*
+HTTP 條件請求與回應快取，兩個 middleware 通常一起用：
+
+	h = httpcache.Conditional(httpcache.NewCache(httpcache.WithMaxBytes(64 << 20)).Middleware(h))
+
+Conditional（RFC 9110 條件請求）：
+  - GET/HEAD 的 200 回應先暫存起來，handler 沒有設定 ETag 時以 body 的 SHA-256 算出 strong ETag
+  - If-None-Match 有相同的 ETag（或 *）→ 304 Not Modified，不送 body
+  - 沒有 If-None-Match 時才看 If-Modified-Since：handler 設了 Last-Modified 且沒有比它新 → 304
+  - 304 只保留與快取相關的 header（ETag、Cache-Control、Last-Modified、Vary、Expires）
+
+client 帶著 ETag 回來問「變了沒」，沒變就只回一個空的 304，省下傳輸；
+但 handler 還是要執行一次才知道 body，要連計算都省下就要搭配 Cache。
+
+Cache：server 端的回應快取，只快取 GET 的 200 回應
+  - key 是 path + query，WithVary 可以加入 request header（例如 Accept-Encoding）
+  - 回應的 Cache-Control 有 no-store 或 private 時不快取；request 帶 Cache-Control: no-cache 時略過快取重新產生
+  - 依 body 大小計算容量，超過 MaxBytes 時淘汰最久沒用到的（LRU）；單一回應超過上限就不快取
+  - 命中時加上 X-Cache: HIT，Conditional 在外層照樣可以回 304
+*/
+
+// bufferedWriter 暫存 handler 的輸出，之後決定要回 200 還是 304
+type bufferedWriter struct {
+	header      http.Header
+	body        bytes.Buffer
+	status      int
+	wroteHeader bool
+}
+
+func newBufferedWriter() *bufferedWriter {
+	return &bufferedWriter{header: make(http.Header), status: http.StatusOK}
+}
+
+func (b *bufferedWriter) Header() http.Header { return b.header }
+
+func (b *bufferedWriter) WriteHeader(status int) {
+	if !b.wroteHeader {
+		b.status, b.wroteHeader = status, true
+	}
+}
+
+func (b *bufferedWriter) Write(p []byte) (int, error) {
+	b.wroteHeader = true
+	return b.body.Write(p)
+}
+
+// StrongETag 以 body 的 SHA-256 前 16 bytes 產生 ETag（含引號）
+func StrongETag(body []byte) string {
+	sum := sha256.Sum256(body)
+	return `"` + hex.EncodeToString(sum[:16]) + `"`
+}
+
+// Conditional 為 GET/HEAD 的 200 回應加上 ETag，並處理 If-None-Match / If-Modified-Since
+func Conditional(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			next.ServeHTTP(w, r)
+			return
+		}
+		buf := newBufferedWriter()
+		next.ServeHTTP(buf, r)
+
+		h := w.Header()
+		for k, vs := range buf.header {
+			h[k] = vs
+		}
+		if buf.status != http.StatusOK {
+			w.WriteHeader(buf.status)
+			w.Write(buf.body.Bytes())
+			return
+		}
+		etag := h.Get("ETag")
+		if etag == "" {
+			etag = StrongETag(buf.body.Bytes())
+			h.Set("ETag", etag)
+		}
+		if notModified(r, etag, h.Get("Last-Modified")) {
+			for k := range h {
+				switch k {
+				case "Etag", "Cache-Control", "Last-Modified", "Vary", "Expires", "Date":
+				default:
+					h.Del(k)
+				}
+			}
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		w.Write(buf.body.Bytes())
+	})
+}
+
+func notModified(r *http.Request, etag, lastModified string) bool {
+	if inm := r.Header.Get("If-None-Match"); inm != "" {
+		// If-None-Match 用 weak comparison：W/"x" 與 "x" 視為相同
+		for _, tag := range strings.Split(inm, ",") {
+			tag = strings.TrimSpace(tag)
+			if tag == "*" || strings.TrimPrefix(tag, "W/") == strings.TrimPrefix(etag, "W/") {
+				return true
+			}
+		}
+		return false
+	}
+	ims := r.Header.Get("If-Modified-Since")
+	if ims == "" || lastModified == "" {
+		return false
+	}
+	since, err := http.ParseTime(ims)
+	if err != nil {
+		return false
+	}
+	modified, err := http.ParseTime(lastModified)
+	if err != nil {
+		return false
+	}
+	// HTTP 日期只到秒
+	return !modified.Truncate(time.Second).After(since)
+}
@@ -0,0 +1,204 @@
+package httpcache
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"advanced/timex"
+)
+
+func get(h http.Handler, path string, header ...string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(http.MethodGet, path, nil)
+	for i := 0; i+1 < len(header); i += 2 {
+		r.Header.Set(header[i], header[i+1])
+	}
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	return w
+}
+
+func TestConditionalETag(t *testing.T) {
+	body := "hello"
+	h := Conditional(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain")
+		w.Header().Set("Cache-Control", "max-age=60")
+		fmt.Fprint(w, body)
+	}))
+
+	w := get(h, "/")
+	require.Equal(t, http.StatusOK, w.Code)
+	etag := w.Header().Get("ETag")
+	assert.Equal(t, StrongETag([]byte("hello")), etag)
+	assert.Equal(t, "hello", w.Body.String())
+
+	for _, inm := range []string{etag, `"other", ` + etag, "W/" + etag, "*"} {
+		w = get(h, "/", "If-None-Match", inm)
+		assert.Equal(t, http.StatusNotModified, w.Code, inm)
+		assert.Empty(t, w.Body.String())
+		assert.Equal(t, etag, w.Header().Get("ETag"))
+		assert.Equal(t, "max-age=60", w.Header().Get("Cache-Control"))
+		assert.Empty(t, w.Header().Get("Content-Type"), "304 不帶 representation header")
+	}
+
+	body = "changed"
+	w = get(h, "/", "If-None-Match", etag)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "changed", w.Body.String())
+	assert.NotEqual(t, etag, w.Header().Get("ETag"))
+}
+
+func TestConditionalLastModified(t *testing.T) {
+	modified := time.Date(2024, 5, 1, 12, 0, 0, 500, time.UTC)
+	h := Conditional(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("ETag", `"v1"`) // handler 自己的 ETag 不會被覆蓋
+		w.Header().Set("Last-Modified", modified.Format(http.TimeFormat))
+		fmt.Fprint(w, "doc")
+	}))
+
+	w := get(h, "/", "If-Modified-Since", modified.Format(http.TimeFormat))
+	assert.Equal(t, http.StatusNotModified, w.Code)
+	assert.Equal(t, `"v1"`, w.Header().Get("ETag"))
+
+	w = get(h, "/", "If-Modified-Since", modified.Add(-time.Hour).Format(http.TimeFormat))
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	// 有 If-None-Match 時忽略 If-Modified-Since
+	w = get(h, "/", "If-None-Match", `"v0"`, "If-Modified-Since", modified.Format(http.TimeFormat))
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	w = get(h, "/", "If-Modified-Since", "yesterday")
+	assert.Equal(t, http.StatusOK, w.Code)
+}
+
+func TestConditionalSkipsNonOK(t *testing.T) {
+	h := Conditional(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "nope", http.StatusNotFound)
+	}))
+	w := get(h, "/", "If-None-Match", "*")
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.Empty(t, w.Header().Get("ETag"))
+
+	r := httptest.NewRequest(http.MethodPost, "/", nil)
+	r.Header.Set("If-None-Match", "*")
+	w = httptest.NewRecorder()
+	Conditional(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { fmt.Fprint(w, "ok") })).ServeHTTP(w, r)
+	assert.Equal(t, http.StatusOK, w.Code)
+}
+
+type counter struct {
+	mu    sync.Mutex
+	calls map[string]int
+}
+
+func (c *counter) handler(size int) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		c.mu.Lock()
+		c.calls[r.URL.RequestURI()]++
+		c.mu.Unlock()
+		switch r.URL.Path {
+		case "/private":
+			w.Header().Set("Cache-Control", "private")
+		case "/missing":
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "text/plain")
+		fmt.Fprint(w, strings.Repeat("x", size))
+	})
+}
+
+func (c *counter) get(path string) int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.calls[path]
+}
+
+func TestCache(t *testing.T) {
+	clock := timex.NewFake(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	c := NewCache(WithTTL(time.Minute), WithClock(clock))
+	cnt := &counter{calls: map[string]int{}}
+	h := c.Middleware(cnt.handler(10))
+
+	assert.Equal(t, "MISS", get(h, "/a?x=1").Header().Get("X-Cache"))
+	w := get(h, "/a?x=1")
+	assert.Equal(t, "HIT", w.Header().Get("X-Cache"))
+	assert.Equal(t, "text/plain", w.Header().Get("Content-Type"))
+	assert.Equal(t, strings.Repeat("x", 10), w.Body.String())
+	assert.Equal(t, 1, cnt.get("/a?x=1"))
+
+	get(h, "/a?x=2") // query 不同是不同的 key
+	assert.Equal(t, 1, cnt.get("/a?x=2"))
+
+	get(h, "/a?x=1", "Cache-Control", "no-cache")
+	assert.Equal(t, 2, cnt.get("/a?x=1"), "no-cache 重新產生")
+
+	for _, p := range []string{"/private", "/private", "/missing", "/missing"} {
+		get(h, p)
+	}
+	assert.Equal(t, 2, cnt.get("/private"))
+	assert.Equal(t, 2, cnt.get("/missing"))
+
+	clock.Advance(time.Minute)
+	get(h, "/a?x=1")
+	assert.Equal(t, 3, cnt.get("/a?x=1"), "過期後重新產生")
+
+	st := c.Stats()
+	assert.EqualValues(t, 1, st.Hits)
+	assert.Equal(t, 2, st.Entries)
+}
+
+func TestCacheEviction(t *testing.T) {
+	cnt := &counter{calls: map[string]int{}}
+	// 每個回應約 1 KiB，容量放得下 3 個
+	c := NewCache(WithMaxBytes(3*1024 + 200))
+	h := c.Middleware(cnt.handler(1024))
+	for _, p := range []string{"/1", "/2", "/3", "/1", "/4"} { // /1 最近用過，淘汰 /2
+		get(h, p)
+	}
+	st := c.Stats()
+	assert.Equal(t, 3, st.Entries)
+	assert.EqualValues(t, 1, st.Evictions)
+	assert.LessOrEqual(t, st.Bytes, int64(3*1024+200))
+
+	get(h, "/1")
+	get(h, "/2")
+	assert.Equal(t, 1, cnt.get("/1"))
+	assert.Equal(t, 2, cnt.get("/2"))
+
+	// 比整個快取還大的回應不快取，也不會把其他項目擠掉
+	big := NewCache(WithMaxBytes(100))
+	hb := big.Middleware(cnt.handler(1000))
+	get(hb, "/big")
+	get(hb, "/big")
+	assert.Equal(t, 2, cnt.get("/big"))
+	assert.Zero(t, big.Stats().Entries)
+}
+
+func TestCacheVaryAndConditional(t *testing.T) {
+	cnt := &counter{calls: map[string]int{}}
+	h := Conditional(NewCache(WithVary("Accept-Language")).Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		cnt.mu.Lock()
+		cnt.calls[r.Header.Get("Accept-Language")]++
+		cnt.mu.Unlock()
+		fmt.Fprint(w, "hi "+r.Header.Get("Accept-Language"))
+	})))
+
+	en := get(h, "/", "Accept-Language", "en")
+	zh := get(h, "/", "Accept-Language", "zh-TW")
+	assert.Equal(t, "hi en", en.Body.String())
+	assert.Equal(t, "hi zh-TW", zh.Body.String())
+	assert.NotEqual(t, en.Header().Get("ETag"), zh.Header().Get("ETag"))
+
+	// 命中快取的回應一樣可以回 304
+	w := get(h, "/", "Accept-Language", "en", "If-None-Match", en.Header().Get("ETag"))
+	assert.Equal(t, http.StatusNotModified, w.Code)
+	assert.Equal(t, 1, cnt.get("en"))
+}
@@ -0,0 +1,335 @@
+package proxycache
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"golang.org/x/sync/singleflight"
+
+	"advanced/lru"
+	"advanced/timex"
+)
+
+/*
+放在 upstream HTTP 服務前面的快取 reverse proxy：
+
+	p := proxycache.New(upstreamURL, proxycache.WithTTL(30*time.Second), proxycache.WithStaleWhileRevalidate(time.Minute))
+	http.ListenAndServe(":8080", p)
+
+  - 快取：lru.Cache 以 RequestURI 為 key，存 status、header 與 body
+  - 合併（request coalescing）：同一個 key 同時有 100 個 miss，只會打 upstream 一次；
+    其他人透過 singleflight 等同一個結果。熱門 key 過期的瞬間不會有一大群請求同時打到 upstream（thundering herd）
+  - stale-while-revalidate：過期後的一段時間內先回傳舊的內容，同時在背景重新抓一次；
+    背景更新同樣經過 singleflight，並帶 If-None-Match / If-Modified-Since，upstream 回 304 時只更新時間
+  - 新鮮度：upstream 有 Cache-Control: max-age（或 s-maxage）、stale-while-revalidate 時以它為準，
+    否則用 WithTTL 與 WithStaleWhileRevalidate 的設定；no-store、private 不快取
+  - upstream 失敗（連線錯誤或 5xx）時，有舊的內容就繼續用舊的
+
+只快取沒有 Authorization、Cookie 的 GET，其他請求直接轉給 upstream（X-Cache: BYPASS），
+避免把某個使用者的回應給了別人。
+
+upstream 的請求不使用任何一個 client 的 ctx（只有 WithTimeout 的逾時）：
+第一個 client 斷線不應該讓一起等的其他人跟著失敗，每個等待者各自依自己的 ctx 離開。
+*/
+
+type entry struct {
+	status     int
+	header     http.Header
+	body       []byte
+	stored     time.Time
+	freshUntil time.Time
+	staleUntil time.Time
+}
+
+type Stats struct {
+	Hits     uint64
+	Misses   uint64
+	Stale    uint64 // 回傳舊內容的次數
+	Bypass   uint64
+	Upstream uint64 // 實際打到 upstream 的次數（不含 BYPASS）
+}
+
+type Proxy struct {
+	upstream *url.URL
+	client   *http.Client
+	bypass   *httputil.ReverseProxy
+	cache    *lru.Cache[string, *entry]
+	group    singleflight.Group
+	ttl      time.Duration
+	swr      time.Duration
+	timeout  time.Duration
+	clock    timex.Clock
+
+	hits, misses, stale, bypassed, upstreamCalls atomic.Uint64
+	bg                                           sync.WaitGroup
+}
+
+type config struct {
+	capacity int
+	ttl      time.Duration
+	swr      time.Duration
+	timeout  time.Duration
+	client   *http.Client
+	clock    timex.Clock
+}
+
+type Option func(*config)
+
+// WithCapacity 設定最多快取幾個回應，預設 1024
+func WithCapacity(n int) Option {
+	return func(c *config) { c.capacity = n }
+}
+
+// WithTTL 設定 upstream 沒有給 max-age 時的新鮮時間，預設 1 分鐘
+func WithTTL(d time.Duration) Option {
+	return func(c *config) { c.ttl = d }
+}
+
+// WithStaleWhileRevalidate 設定過期後還可以先回傳舊內容的時間，預設 0（不使用）
+func WithStaleWhileRevalidate(d time.Duration) Option {
+	return func(c *config) { c.swr = d }
+}
+
+// WithTimeout 設定每次打 upstream 的逾時，預設 30 秒
+func WithTimeout(d time.Duration) Option {
+	return func(c *config) { c.timeout = d }
+}
+
+func WithClient(client *http.Client) Option {
+	return func(c *config) { c.client = client }
+}
+
+// WithClock 設定判斷新鮮度與 Age 的時鐘，預設 timex.Real
+func WithClock(clock timex.Clock) Option {
+	return func(c *config) { c.clock = clock }
+}
+
+func New(upstream *url.URL, opts ...Option) *Proxy {
+	c := config{capacity: 1024, ttl: time.Minute, timeout: 30 * time.Second, client: http.DefaultClient, clock: timex.Real{}}
+	for _, o := range opts {
+		o(&c)
+	}
+	bypass := httputil.NewSingleHostReverseProxy(upstream)
+	bypass.Transport = c.client.Transport
+	return &Proxy{
+		upstream: upstream,
+		client:   c.client,
+		bypass:   bypass,
+		cache:    lru.New[string, *entry](c.capacity),
+		ttl:      c.ttl,
+		swr:      c.swr,
+		timeout:  c.timeout,
+		clock:    c.clock,
+	}
+}
+
+func (p *Proxy) Stats() Stats {
+	return Stats{
+		Hits:     p.hits.Load(),
+		Misses:   p.misses.Load(),
+		Stale:    p.stale.Load(),
+		Bypass:   p.bypassed.Load(),
+		Upstream: p.upstreamCalls.Load(),
+	}
+}
+
+// Wait 等待背景的 revalidate 完成（測試與關閉時使用）
+func (p *Proxy) Wait() { p.bg.Wait() }
+
+func cacheable(r *http.Request) bool {
+	return r.Method == http.MethodGet && r.Header.Get("Authorization") == "" && r.Header.Get("Cookie") == ""
+}
+
+func (p *Proxy) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !cacheable(r) {
+		p.bypassed.Add(1)
+		w.Header().Set("X-Cache", "BYPASS")
+		p.bypass.ServeHTTP(w, r)
+		return
+	}
+	key := r.URL.RequestURI()
+	now := p.clock.Now()
+	if e, ok := p.cache.Get(key); ok {
+		switch {
+		case now.Before(e.freshUntil):
+			p.hits.Add(1)
+			p.write(w, e, "HIT", now)
+			return
+		case now.Before(e.staleUntil):
+			p.stale.Add(1)
+			p.revalidate(key, e)
+			p.write(w, e, "STALE", now)
+			return
+		}
+	}
+
+	p.misses.Add(1)
+	ch := p.group.DoChan(key, func() (interface{}, error) {
+		return p.fetch(key, nil)
+	})
+	select {
+	case res := <-ch:
+		if res.Err != nil {
+			http.Error(w, http.StatusText(http.StatusBadGateway), http.StatusBadGateway)
+			return
+		}
+		p.write(w, res.Val.(*entry), "MISS", p.clock.Now())
+	case <-r.Context().Done():
+		// client 離開了；upstream 的請求繼續，結果會留給其他人
+	}
+}
+
+// revalidate 在背景更新 e；同一個 key 同時只會有一個更新在跑
+func (p *Proxy) revalidate(key string, e *entry) {
+	p.bg.Add(1)
+	go func() {
+		defer p.bg.Done()
+		p.group.Do(key, func() (interface{}, error) {
+			return p.fetch(key, e)
+		})
+	}()
+}
+
+// fetch 向 upstream 取得 key 並寫入快取；old 不為 nil 時帶條件式 header，304 時沿用 old 的內容。
+// upstream 失敗而且有舊內容時回傳舊內容
+func (p *Proxy) fetch(key string, old *entry) (*entry, error) {
+	if old == nil {
+		// 等待期間別人可能已經放進快取了
+		if e, ok := p.cache.Peek(key); ok && p.clock.Now().Before(e.freshUntil) {
+			return e, nil
+		}
+		old, _ = p.cache.Peek(key)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), p.timeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.upstream.String()+key, nil)
+	if err != nil {
+		return nil, err
+	}
+	if old != nil {
+		if etag := old.header.Get("ETag"); etag != "" {
+			req.Header.Set("If-None-Match", etag)
+		}
+		if lm := old.header.Get("Last-Modified"); lm != "" {
+			req.Header.Set("If-Modified-Since", lm)
+		}
+	}
+
+	p.upstreamCalls.Add(1)
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return p.fallback(old, err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return p.fallback(old, err)
+	}
+
+	now := p.clock.Now()
+	if resp.StatusCode == http.StatusNotModified && old != nil {
+		e := *old
+		e.header = old.header.Clone()
+		for _, h := range []string{"Cache-Control", "Date", "Expires", "ETag", "Last-Modified"} {
+			if v := resp.Header.Get(h); v != "" {
+				e.header.Set(h, v)
+			}
+		}
+		p.setFreshness(&e, now)
+		p.cache.Set(key, &e)
+		return &e, nil
+	}
+	if resp.StatusCode >= 500 && old != nil {
+		return old, nil
+	}
+
+	e := &entry{status: resp.StatusCode, header: resp.Header.Clone(), body: body}
+	dropHeaders(e.header)
+	if p.setFreshness(e, now) && cacheableStatus(resp.StatusCode) {
+		p.cache.Set(key, e)
+	} else {
+		p.cache.Delete(key)
+	}
+	return e, nil
+}
+
+func (p *Proxy) fallback(old *entry, err error) (*entry, error) {
+	if old != nil {
+		return old, nil
+	}
+	return nil, err
+}
+
+func cacheableStatus(code int) bool {
+	switch code {
+	case http.StatusOK, http.StatusNonAuthoritativeInfo, http.StatusNoContent,
+		http.StatusMovedPermanently, http.StatusNotFound, http.StatusGone:
+		return true
+	}
+	return false
+}
+
+// setFreshness 依 Cache-Control 設定新鮮與可以回傳舊內容的期限，回傳 false 表示不可快取
+func (p *Proxy) setFreshness(e *entry, now time.Time) bool {
+	ttl, swr := p.ttl, p.swr
+	var maxAge, sMaxAge time.Duration = -1, -1
+	for _, d := range strings.Split(e.header.Get("Cache-Control"), ",") {
+		name, value, _ := strings.Cut(strings.TrimSpace(d), "=")
+		secs, err := strconv.Atoi(strings.Trim(value, `"`))
+		switch strings.ToLower(name) {
+		case "no-store", "private", "no-cache":
+			return false
+		case "max-age":
+			if err == nil {
+				maxAge = time.Duration(secs) * time.Second
+			}
+		case "s-maxage":
+			if err == nil {
+				sMaxAge = time.Duration(secs) * time.Second
+			}
+		case "stale-while-revalidate":
+			if err == nil {
+				swr = time.Duration(secs) * time.Second
+			}
+		}
+	}
+	// 給共用快取的 s-maxage 優先於 max-age
+	if sMaxAge >= 0 {
+		ttl = sMaxAge
+	} else if maxAge >= 0 {
+		ttl = maxAge
+	}
+	e.stored = now
+	e.freshUntil = now.Add(ttl)
+	e.staleUntil = e.freshUntil.Add(swr)
+	return true
+}
+
+// 只對單一連線有意義（hop-by-hop）或不能給其他使用者（Set-Cookie）的 header
+var uncached = []string{"Connection", "Keep-Alive", "Proxy-Authenticate", "Proxy-Authorization", "Te", "Trailer", "Transfer-Encoding", "Upgrade", "Set-Cookie"}
+
+func dropHeaders(h http.Header) {
+	for _, k := range uncached {
+		h.Del(k)
+	}
+}
+
+func (p *Proxy) write(w http.ResponseWriter, e *entry, status string, now time.Time) {
+	h := w.Header()
+	for k, vs := range e.header {
+		h[k] = append([]string(nil), vs...)
+	}
+	h.Set("X-Cache", status)
+	h.Set("Age", strconv.Itoa(int(now.Sub(e.stored).Seconds())))
+	h.Set("Content-Length", strconv.Itoa(len(e.body)))
+	w.WriteHeader(e.status)
+	w.Write(e.body)
+}
@@ -0,0 +1,291 @@
+package proxycache
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"advanced/timex"
+)
+
+// upstream 記錄被呼叫的次數，回應內容帶版本號
+type upstream struct {
+	calls   atomic.Int64
+	version atomic.Int64
+	gate    chan struct{} // 不為 nil 時每個請求都等它關閉
+	handler func(w http.ResponseWriter, r *http.Request)
+}
+
+func newUpstream(t *testing.T, u *upstream) *url.URL {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		u.calls.Add(1)
+		if u.gate != nil {
+			<-u.gate
+		}
+		if u.handler != nil {
+			u.handler(w, r)
+			return
+		}
+		fmt.Fprintf(w, "%s v%d", r.URL.Path, u.version.Load())
+	}))
+	t.Cleanup(srv.Close)
+	target, err := url.Parse(srv.URL)
+	require.NoError(t, err)
+	return target
+}
+
+func get(t *testing.T, h http.Handler, target string, header ...string) (string, string) {
+	t.Helper()
+	r := httptest.NewRequest(http.MethodGet, target, nil)
+	for i := 0; i+1 < len(header); i += 2 {
+		r.Header.Set(header[i], header[i+1])
+	}
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, r)
+	return rec.Body.String(), rec.Header().Get("X-Cache")
+}
+
+func TestHitAndMiss(t *testing.T) {
+	up := &upstream{}
+	p := New(newUpstream(t, up))
+
+	body, status := get(t, p, "/a")
+	assert.Equal(t, "/a v0", body)
+	assert.Equal(t, "MISS", status)
+	up.version.Store(1)
+	body, status = get(t, p, "/a")
+	assert.Equal(t, "/a v0", body)
+	assert.Equal(t, "HIT", status)
+
+	// query 不同是不同的 key
+	_, status = get(t, p, "/a?page=2")
+	assert.Equal(t, "MISS", status)
+	assert.EqualValues(t, 2, up.calls.Load())
+	assert.Equal(t, Stats{Hits: 1, Misses: 2, Upstream: 2}, p.Stats())
+}
+
+// 同一個 key 同時 50 個 miss，只打 upstream 一次
+func TestCoalescing(t *testing.T) {
+	up := &upstream{gate: make(chan struct{})}
+	p := New(newUpstream(t, up))
+
+	const n = 50
+	bodies := make([]string, n)
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			bodies[i], _ = get(t, p, "/hot")
+		}(i)
+	}
+	require.Eventually(t, func() bool { return p.Stats().Misses == n }, time.Second, time.Millisecond)
+	require.Eventually(t, func() bool { return up.calls.Load() == 1 }, time.Second, time.Millisecond)
+	close(up.gate)
+	wg.Wait()
+
+	assert.EqualValues(t, 1, up.calls.Load())
+	assert.EqualValues(t, 1, p.Stats().Upstream)
+	for _, b := range bodies {
+		assert.Equal(t, "/hot v0", b)
+	}
+}
+
+func TestStaleWhileRevalidate(t *testing.T) {
+	c := timex.NewFake(time.Unix(1700000000, 0))
+	up := &upstream{}
+	p := New(newUpstream(t, up), WithTTL(10*time.Second), WithStaleWhileRevalidate(30*time.Second), WithClock(c))
+
+	get(t, p, "/a")
+	up.version.Store(1)
+	c.Advance(15 * time.Second)
+
+	// 過期但在 stale 期間：立刻回傳舊內容，背景更新
+	up.gate = make(chan struct{})
+	for i := 0; i < 20; i++ {
+		body, status := get(t, p, "/a")
+		assert.Equal(t, "/a v0", body)
+		assert.Equal(t, "STALE", status)
+	}
+	require.Eventually(t, func() bool { return up.calls.Load() == 2 }, time.Second, time.Millisecond)
+	close(up.gate)
+	p.Wait()
+	assert.EqualValues(t, 2, up.calls.Load(), "20 stale hits, one revalidation")
+
+	body, status := get(t, p, "/a")
+	assert.Equal(t, "/a v1", body)
+	assert.Equal(t, "HIT", status)
+
+	// 超過 stale 期間：同步抓新的
+	up.version.Store(2)
+	c.Advance(time.Minute)
+	body, status = get(t, p, "/a")
+	assert.Equal(t, "/a v2", body)
+	assert.Equal(t, "MISS", status)
+	assert.EqualValues(t, 3, up.calls.Load())
+}
+
+// revalidate 帶 If-None-Match，upstream 回 304 時沿用舊的 body
+func TestConditionalRevalidation(t *testing.T) {
+	c := timex.NewFake(time.Unix(1700000000, 0))
+	var notModified atomic.Int64
+	up := &upstream{handler: func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("ETag", `"v1"`)
+		w.Header().Set("Cache-Control", "max-age=10, stale-while-revalidate=60")
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			notModified.Add(1)
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		io.WriteString(w, "payload")
+	}}
+	p := New(newUpstream(t, up), WithClock(c))
+
+	get(t, p, "/doc")
+	c.Advance(20 * time.Second)
+	body, status := get(t, p, "/doc")
+	assert.Equal(t, "STALE", status)
+	assert.Equal(t, "payload", body)
+	p.Wait()
+	assert.EqualValues(t, 1, notModified.Load())
+
+	body, status = get(t, p, "/doc")
+	assert.Equal(t, "HIT", status, "304 refreshed the entry")
+	assert.Equal(t, "payload", body)
+}
+
+func TestUpstreamCacheControl(t *testing.T) {
+	c := timex.NewFake(time.Unix(1700000000, 0))
+	up := &upstream{handler: func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/short":
+			w.Header().Set("Cache-Control", "public, max-age=5")
+		case "/shared":
+			w.Header().Set("Cache-Control", "max-age=5, s-maxage=100")
+		case "/private":
+			w.Header().Set("Cache-Control", "private, max-age=100")
+		case "/nostore":
+			w.Header().Set("Cache-Control", "no-store")
+		case "/error":
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+		w.Header().Set("Set-Cookie", "session=secret")
+		io.WriteString(w, r.URL.Path)
+	}}
+	p := New(newUpstream(t, up), WithTTL(time.Hour), WithClock(c))
+
+	for _, path := range []string{"/short", "/shared", "/private", "/nostore", "/error"} {
+		get(t, p, path)
+	}
+	c.Advance(10 * time.Second)
+	calls := up.calls.Load()
+	for path, want := range map[string]string{"/short": "MISS", "/shared": "HIT", "/private": "MISS", "/nostore": "MISS", "/error": "MISS"} {
+		_, status := get(t, p, path)
+		assert.Equal(t, want, status, path)
+	}
+	assert.EqualValues(t, calls+4, up.calls.Load())
+
+	r := httptest.NewRequest(http.MethodGet, "/shared", nil)
+	rec := httptest.NewRecorder()
+	p.ServeHTTP(rec, r)
+	assert.Empty(t, rec.Header().Get("Set-Cookie"), "Set-Cookie never comes from the cache")
+	assert.Equal(t, "10", rec.Header().Get("Age"))
+}
+
+// upstream 掛掉時，有舊內容就繼續用
+func TestStaleOnError(t *testing.T) {
+	c := timex.NewFake(time.Unix(1700000000, 0))
+	var fail atomic.Bool
+	up := &upstream{handler: func(w http.ResponseWriter, r *http.Request) {
+		if fail.Load() {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		io.WriteString(w, "good")
+	}}
+	p := New(newUpstream(t, up), WithTTL(time.Second), WithClock(c))
+	get(t, p, "/a")
+
+	fail.Store(true)
+	c.Advance(time.Minute)
+	body, status := get(t, p, "/a")
+	assert.Equal(t, "good", body)
+	assert.Equal(t, "MISS", status)
+
+	// 完全沒有快取的 key 失敗時回 502（upstream 本身的狀態碼）
+	r := httptest.NewRequest(http.MethodGet, "/b", nil)
+	rec := httptest.NewRecorder()
+	p.ServeHTTP(rec, r)
+	assert.Equal(t, http.StatusBadGateway, rec.Code)
+}
+
+func TestBypass(t *testing.T) {
+	up := &upstream{}
+	p := New(newUpstream(t, up))
+
+	for i := 0; i < 3; i++ {
+		_, status := get(t, p, "/me", "Authorization", "Bearer x")
+		assert.Equal(t, "BYPASS", status)
+		_, status = get(t, p, "/me", "Cookie", "session=1")
+		assert.Equal(t, "BYPASS", status)
+
+		r := httptest.NewRequest(http.MethodPost, "/me", nil)
+		rec := httptest.NewRecorder()
+		p.ServeHTTP(rec, r)
+		assert.Equal(t, "BYPASS", rec.Header().Get("X-Cache"))
+	}
+	assert.EqualValues(t, 9, up.calls.Load())
+	assert.EqualValues(t, 0, p.Stats().Upstream)
+	assert.EqualValues(t, 9, p.Stats().Bypass)
+}
+
+// 第一個 client 離開不影響一起等的其他人
+func TestClientCancelDoesNotAffectOthers(t *testing.T) {
+	up := &upstream{gate: make(chan struct{})}
+	p := New(newUpstream(t, up))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	first := make(chan struct{})
+	go func() {
+		defer close(first)
+		r := httptest.NewRequest(http.MethodGet, "/slow", nil).WithContext(ctx)
+		p.ServeHTTP(httptest.NewRecorder(), r)
+	}()
+	require.Eventually(t, func() bool { return up.calls.Load() == 1 }, time.Second, time.Millisecond)
+
+	second := make(chan string)
+	go func() {
+		body, _ := get(t, p, "/slow")
+		second <- body
+	}()
+	require.Eventually(t, func() bool { return p.Stats().Misses == 2 }, time.Second, time.Millisecond)
+	cancel()
+	<-first
+	close(up.gate)
+	assert.Equal(t, "/slow v0", <-second)
+	assert.EqualValues(t, 1, up.calls.Load())
+}
+
+func TestEviction(t *testing.T) {
+	up := &upstream{}
+	p := New(newUpstream(t, up), WithCapacity(2))
+	get(t, p, "/a")
+	get(t, p, "/b")
+	get(t, p, "/a") // a 最近使用
+	get(t, p, "/c") // 淘汰 b
+
+	_, status := get(t, p, "/a")
+	assert.Equal(t, "HIT", status)
+	_, status = get(t, p, "/b")
+	assert.Equal(t, "MISS", status)
+}
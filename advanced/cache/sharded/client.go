@@ -0,0 +1,252 @@
+package sharded
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+
+	"golang.org/x/sync/singleflight"
+
+	"advanced/cache/hashring"
+)
+
+/*
+Sharded cache client：
+  - 以 hashring 決定每個 key 屬於哪個節點，Get / Set / Delete 只碰 owner
+  - Get miss 且有設定 Loader 時做 read-through：用 singleflight 合併同一個 key 的並發載入，
+    載入結果寫回 owner 再回傳
+  - AddNode / RemoveNode 會 rebalance：
+    加入節點 → 掃描既有節點，把 owner 變成新節點的 key 搬過去
+    移除節點 → 把該節點的所有 key 依新的環搬到其他節點（graceful leave，節點仍可讀）
+    搬移期間持有寫鎖，一般讀寫會等待；consistent hashing 讓搬移量只有約 1/N
+*/
+
+var (
+	ErrNoNodes     = errors.New("sharded: no cache nodes")
+	ErrDuplicate   = errors.New("sharded: node already exists")
+	ErrUnknownNode = errors.New("sharded: unknown node")
+)
+
+// Loader 在 cache miss 時從資料來源載入；回傳 found=false 表示來源也沒有
+type Loader func(ctx context.Context, key string) (value []byte, found bool, err error)
+
+type Stats struct {
+	Hits   uint64
+	Misses uint64
+	Loads  uint64 // 實際呼叫 Loader 的次數（singleflight 合併後）
+	Moved  uint64 // rebalance 搬移的 key 數
+}
+
+type Client struct {
+	mu     sync.RWMutex
+	ring   *hashring.Ring
+	nodes  map[string]Cache
+	loader Loader
+	group  singleflight.Group
+
+	hits, misses, loads, moved atomic.Uint64
+}
+
+type Option func(*clientConfig)
+
+type clientConfig struct {
+	replicas int
+	hash     hashring.Hash
+	loader   Loader
+}
+
+// WithReplicas 設定每個節點的虛擬節點數，預設 128
+func WithReplicas(n int) Option {
+	return func(c *clientConfig) { c.replicas = n }
+}
+
+func WithHash(h hashring.Hash) Option {
+	return func(c *clientConfig) { c.hash = h }
+}
+
+func WithLoader(l Loader) Option {
+	return func(c *clientConfig) { c.loader = l }
+}
+
+func New(opts ...Option) *Client {
+	cfg := clientConfig{replicas: 128}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	var ringOpts []hashring.Option
+	if cfg.hash != nil {
+		ringOpts = append(ringOpts, hashring.WithHash(cfg.hash))
+	}
+	return &Client{
+		ring:   hashring.New(cfg.replicas, ringOpts...),
+		nodes:  make(map[string]Cache),
+		loader: cfg.loader,
+	}
+}
+
+// Owner 回傳 key 目前所屬的節點名稱
+func (c *Client) Owner(key string) (string, bool) {
+	return c.ring.Get(key)
+}
+
+func (c *Client) Nodes() []string {
+	return c.ring.Nodes()
+}
+
+func (c *Client) owner(key string) (Cache, error) {
+	name, ok := c.ring.Get(key)
+	if !ok {
+		return nil, ErrNoNodes
+	}
+	return c.nodes[name], nil
+}
+
+func (c *Client) Get(ctx context.Context, key string) ([]byte, bool, error) {
+	c.mu.RLock()
+	node, err := c.owner(key)
+	if err != nil {
+		c.mu.RUnlock()
+		return nil, false, err
+	}
+	v, ok, err := node.Get(ctx, key)
+	c.mu.RUnlock()
+	if err != nil {
+		return nil, false, err
+	}
+	if ok {
+		c.hits.Add(1)
+		return v, true, nil
+	}
+	c.misses.Add(1)
+	if c.loader == nil {
+		return nil, false, nil
+	}
+	return c.load(ctx, key)
+}
+
+type loaded struct {
+	value []byte
+	found bool
+}
+
+func (c *Client) load(ctx context.Context, key string) ([]byte, bool, error) {
+	res, err, _ := c.group.Do(key, func() (interface{}, error) {
+		c.loads.Add(1)
+		v, found, err := c.loader(ctx, key)
+		if err != nil || !found {
+			return loaded{}, err
+		}
+		// 載入期間 membership 可能改變，寫回時重新找 owner
+		if err := c.Set(ctx, key, v); err != nil {
+			return nil, err
+		}
+		return loaded{v, true}, nil
+	})
+	if err != nil {
+		return nil, false, fmt.Errorf("sharded: load %q: %w", key, err)
+	}
+	l := res.(loaded)
+	return l.value, l.found, nil
+}
+
+func (c *Client) Set(ctx context.Context, key string, value []byte) error {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	node, err := c.owner(key)
+	if err != nil {
+		return err
+	}
+	return node.Set(ctx, key, value)
+}
+
+func (c *Client) Delete(ctx context.Context, key string) error {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	node, err := c.owner(key)
+	if err != nil {
+		return err
+	}
+	return node.Delete(ctx, key)
+}
+
+// AddNode 加入節點並把應歸新節點管理的 key 從其他節點搬過來；搬移失敗時移除該節點，回到加入前的狀態
+func (c *Client) AddNode(ctx context.Context, name string, node Cache) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, ok := c.nodes[name]; ok {
+		return fmt.Errorf("%w: %s", ErrDuplicate, name)
+	}
+	c.nodes[name] = node
+	c.ring.Add(name)
+	for other, src := range c.nodes {
+		if other == name {
+			continue
+		}
+		if err := c.migrate(ctx, other, src); err != nil {
+			// 搬到一半失敗：還原環，已經搬到新節點的 key 再依原本的環搬回去
+			c.ring.Remove(name)
+			delete(c.nodes, name)
+			if rerr := c.migrate(ctx, name, node); rerr != nil {
+				return errors.Join(err, rerr)
+			}
+			return err
+		}
+	}
+	return nil
+}
+
+// RemoveNode 把節點移出環，並將它的 key 搬到新的 owner
+func (c *Client) RemoveNode(ctx context.Context, name string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	src, ok := c.nodes[name]
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrUnknownNode, name)
+	}
+	c.ring.Remove(name)
+	delete(c.nodes, name)
+	if c.ring.Len() == 0 {
+		return nil // 最後一個節點，資料無處可搬
+	}
+	return c.migrate(ctx, name, src)
+}
+
+// migrate 把 src 中 owner 已不是 from 的 key 搬到新的 owner；呼叫者需持有寫鎖
+func (c *Client) migrate(ctx context.Context, from string, src Cache) error {
+	keys, err := src.Keys(ctx)
+	if err != nil {
+		return fmt.Errorf("sharded: list keys on %s: %w", from, err)
+	}
+	for _, key := range keys {
+		to, _ := c.ring.Get(key)
+		if to == from {
+			continue
+		}
+		v, ok, err := src.Get(ctx, key)
+		if err != nil {
+			return fmt.Errorf("sharded: move %q from %s: %w", key, from, err)
+		}
+		if !ok {
+			continue // 掃描後被淘汰
+		}
+		if err := c.nodes[to].Set(ctx, key, v); err != nil {
+			return fmt.Errorf("sharded: move %q to %s: %w", key, to, err)
+		}
+		if err := src.Delete(ctx, key); err != nil {
+			return fmt.Errorf("sharded: move %q from %s: %w", key, from, err)
+		}
+		c.moved.Add(1)
+	}
+	return nil
+}
+
+func (c *Client) Stats() Stats {
+	return Stats{
+		Hits:   c.hits.Load(),
+		Misses: c.misses.Load(),
+		Loads:  c.loads.Load(),
+		Moved:  c.moved.Load(),
+	}
+}
@@ -0,0 +1,173 @@
+package sharded
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newClient(t *testing.T, n int, opts ...Option) (*Client, map[string]*LRUNode) {
+	t.Helper()
+	c := New(opts...)
+	nodes := map[string]*LRUNode{}
+	for i := 0; i < n; i++ {
+		name := fmt.Sprintf("node-%d", i)
+		nodes[name] = NewLRUNode(1000)
+		require.NoError(t, c.AddNode(context.Background(), name, nodes[name]))
+	}
+	return c, nodes
+}
+
+// assertPlacement 檢查每個 key 都只存在於它的 owner 上
+func assertPlacement(t *testing.T, c *Client, nodes map[string]*LRUNode, keys int) {
+	t.Helper()
+	ctx := context.Background()
+	for i := 0; i < keys; i++ {
+		k := fmt.Sprintf("k%d", i)
+		owner, _ := c.Owner(k)
+		for name, n := range nodes {
+			_, ok, _ := n.Get(ctx, k)
+			if name == owner {
+				assert.True(t, ok, "%s should be on %s", k, name)
+			} else {
+				assert.False(t, ok, "%s should not be on %s", k, name)
+			}
+		}
+	}
+}
+
+func TestNoNodes(t *testing.T) {
+	_, _, err := New().Get(context.Background(), "k")
+	assert.ErrorIs(t, err, ErrNoNodes)
+}
+
+func TestRouting(t *testing.T) {
+	ctx := context.Background()
+	c, nodes := newClient(t, 3)
+	for i := 0; i < 300; i++ {
+		require.NoError(t, c.Set(ctx, fmt.Sprintf("k%d", i), []byte{byte(i)}))
+	}
+	assertPlacement(t, c, nodes, 300)
+	for _, n := range nodes {
+		assert.Greater(t, n.Len(), 0)
+	}
+
+	v, ok, err := c.Get(ctx, "k7")
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, []byte{7}, v)
+
+	require.NoError(t, c.Delete(ctx, "k7"))
+	_, ok, _ = c.Get(ctx, "k7")
+	assert.False(t, ok)
+}
+
+func TestRebalance(t *testing.T) {
+	ctx := context.Background()
+	c, nodes := newClient(t, 3)
+	for i := 0; i < 1000; i++ {
+		require.NoError(t, c.Set(ctx, fmt.Sprintf("k%d", i), []byte("v")))
+	}
+
+	nodes["node-3"] = NewLRUNode(1000)
+	require.NoError(t, c.AddNode(ctx, "node-3", nodes["node-3"]))
+	assertPlacement(t, c, nodes, 1000)
+	moved := c.Stats().Moved
+	assert.InDelta(t, 250, moved, 100, "只有約 1/N 的 key 需要搬")
+	assert.Equal(t, int(moved), nodes["node-3"].Len())
+
+	require.NoError(t, c.RemoveNode(ctx, "node-1"))
+	delete(nodes, "node-1")
+	assertPlacement(t, c, nodes, 1000)
+
+	assert.ErrorIs(t, c.AddNode(ctx, "node-0", NewLRUNode(1)), ErrDuplicate)
+	assert.ErrorIs(t, c.RemoveNode(ctx, "node-9"), ErrUnknownNode)
+}
+
+// failingNode 在成功寫入 n 次之後，之後的 Set 都失敗
+type failingNode struct {
+	*LRUNode
+	n int
+}
+
+func (f *failingNode) Set(ctx context.Context, key string, value []byte) error {
+	if f.n == 0 {
+		return fmt.Errorf("disk full")
+	}
+	f.n--
+	return f.LRUNode.Set(ctx, key, value)
+}
+
+func TestAddNodeRollback(t *testing.T) {
+	ctx := context.Background()
+	c, nodes := newClient(t, 2)
+	for i := 0; i < 1000; i++ {
+		require.NoError(t, c.Set(ctx, fmt.Sprintf("k%d", i), []byte("v")))
+	}
+
+	bad := &failingNode{LRUNode: NewLRUNode(1000), n: 50}
+	require.Error(t, c.AddNode(ctx, "node-2", bad))
+	assert.ElementsMatch(t, []string{"node-0", "node-1"}, c.Nodes())
+	assert.Zero(t, bad.Len(), "已經搬過去的 key 要搬回原本的 owner")
+	assertPlacement(t, c, nodes, 1000)
+}
+
+func TestReadThrough(t *testing.T) {
+	ctx := context.Background()
+	var calls atomic.Int32
+	release := make(chan struct{})
+	loader := func(_ context.Context, key string) ([]byte, bool, error) {
+		calls.Add(1)
+		<-release
+		if key == "missing" {
+			return nil, false, nil
+		}
+		return []byte("loaded:" + key), true, nil
+	}
+	c, nodes := newClient(t, 2, WithLoader(loader))
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			v, ok, err := c.Get(ctx, "user:1")
+			assert.NoError(t, err)
+			assert.True(t, ok)
+			assert.Equal(t, "loaded:user:1", string(v))
+		}()
+	}
+	// 等所有 goroutine 都 miss 後才放行，確認 singleflight 只載入一次
+	require.Eventually(t, func() bool { return c.Stats().Misses == 10 }, time.Second, time.Millisecond)
+	close(release)
+	wg.Wait()
+	assert.Equal(t, int32(1), calls.Load())
+
+	owner, _ := c.Owner("user:1")
+	_, ok, _ := nodes[owner].Get(ctx, "user:1")
+	assert.True(t, ok, "載入結果寫回 owner")
+
+	_, ok, err := c.Get(ctx, "user:1")
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, uint64(1), c.Stats().Hits)
+
+	_, ok, err = c.Get(ctx, "missing")
+	require.NoError(t, err)
+	assert.False(t, ok)
+}
+
+func TestLoaderError(t *testing.T) {
+	boom := fmt.Errorf("db down")
+	c, _ := newClient(t, 1, WithLoader(func(context.Context, string) ([]byte, bool, error) {
+		return nil, false, boom
+	}))
+	_, _, err := c.Get(context.Background(), "k")
+	assert.ErrorIs(t, err, boom)
+}
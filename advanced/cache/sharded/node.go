@@ -0,0 +1,47 @@
+package sharded
+
+import (
+	"context"
+
+	"advanced/lru"
+)
+
+// Cache 是單一 cache 節點需要提供的操作；Keys 只在 rebalance 時用來找出要搬移的 key
+type Cache interface {
+	Get(ctx context.Context, key string) ([]byte, bool, error)
+	Set(ctx context.Context, key string, value []byte) error
+	Delete(ctx context.Context, key string) error
+	Keys(ctx context.Context) ([]string, error)
+}
+
+// LRUNode 把 lru.Cache 包成一個 in-process 節點，測試與範例用
+type LRUNode struct {
+	c *lru.Cache[string, []byte]
+}
+
+var _ Cache = (*LRUNode)(nil)
+
+func NewLRUNode(capacity int) *LRUNode {
+	return &LRUNode{c: lru.New[string, []byte](capacity)}
+}
+
+func (n *LRUNode) Get(_ context.Context, key string) ([]byte, bool, error) {
+	v, ok := n.c.Get(key)
+	return v, ok, nil
+}
+
+func (n *LRUNode) Set(_ context.Context, key string, value []byte) error {
+	n.c.Set(key, value)
+	return nil
+}
+
+func (n *LRUNode) Delete(_ context.Context, key string) error {
+	n.c.Delete(key)
+	return nil
+}
+
+func (n *LRUNode) Keys(_ context.Context) ([]string, error) {
+	return n.c.Keys(), nil
+}
+
+func (n *LRUNode) Len() int { return n.c.Len() }
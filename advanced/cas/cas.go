@@ -0,0 +1,225 @@
+package cas
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+/*
+Content-addressable storage：以內容的 SHA-256 當作 key，
+  - 相同內容只會存一份（dedup），Put 同樣的資料直接回傳既有的 digest
+  - 內容與 key 綁定，讀取時重新計算 hash 即可驗證資料沒有損壞或被竄改
+  - 寫入後內容不會再變，適合做快取、備份、下載續傳的驗證
+
+目錄結構（以 digest 前兩碼分目錄，避免單一目錄檔案過多）：
+	<root>/blobs/ab/abcdef...    完整的 blob
+	<root>/tmp/                  寫入中的暫存檔
+	<root>/uploads/<id>          可續傳的上傳（見 upload.go）
+
+Put 邊寫暫存檔邊計算 hash，大檔案不需要整個讀進記憶體；
+完成後 fsync + rename 進 blobs，讀取端永遠不會看到寫到一半的 blob。
+*/
+
+var (
+	ErrNotFound      = errors.New("cas: blob not found")
+	ErrCorrupt       = errors.New("cas: content does not match digest")
+	ErrInvalidDigest = errors.New("cas: invalid digest")
+)
+
+type Digest [sha256.Size]byte
+
+func Sum(b []byte) Digest {
+	return sha256.Sum256(b)
+}
+
+func (d Digest) String() string {
+	return hex.EncodeToString(d[:])
+}
+
+func ParseDigest(s string) (Digest, error) {
+	var d Digest
+	if len(s) != hex.EncodedLen(len(d)) {
+		return d, ErrInvalidDigest
+	}
+	if _, err := hex.Decode(d[:], []byte(s)); err != nil {
+		return d, ErrInvalidDigest
+	}
+	return d, nil
+}
+
+type Store struct {
+	root string
+}
+
+func New(root string) (*Store, error) {
+	for _, dir := range []string{"blobs", "tmp", "uploads"} {
+		if err := os.MkdirAll(filepath.Join(root, dir), 0o755); err != nil {
+			return nil, err
+		}
+	}
+	return &Store{root: root}, nil
+}
+
+func (s *Store) path(d Digest) string {
+	h := d.String()
+	return filepath.Join(s.root, "blobs", h[:2], h)
+}
+
+// Put 寫入 r 的全部內容，回傳 digest 與大小；內容已存在時不會重複寫入
+func (s *Store) Put(r io.Reader) (Digest, int64, error) {
+	tmp, err := os.CreateTemp(filepath.Join(s.root, "tmp"), "put-*")
+	if err != nil {
+		return Digest{}, 0, err
+	}
+	defer os.Remove(tmp.Name())
+
+	h := sha256.New()
+	n, err := io.Copy(io.MultiWriter(tmp, h), r)
+	if err != nil {
+		tmp.Close()
+		return Digest{}, 0, err
+	}
+	var d Digest
+	h.Sum(d[:0])
+	if err := s.commit(tmp, d); err != nil {
+		return Digest{}, 0, err
+	}
+	return d, n, nil
+}
+
+func (s *Store) PutBytes(b []byte) (Digest, error) {
+	d, _, err := s.Put(bytes.NewReader(b))
+	return d, err
+}
+
+// commit 把已寫完的暫存檔移到 d 的位置並關閉；已存在時直接丟棄暫存檔
+func (s *Store) commit(tmp *os.File, d Digest) error {
+	if s.Has(d) {
+		return tmp.Close()
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	dst := s.path(d)
+	if err := os.MkdirAll(filepath.Dir(dst), 0o755); err != nil {
+		return err
+	}
+	// blob 一旦寫入就不應再被修改
+	if err := os.Chmod(tmp.Name(), 0o444); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), dst)
+}
+
+func (s *Store) Has(d Digest) bool {
+	_, err := os.Stat(s.path(d))
+	return err == nil
+}
+
+func (s *Store) Size(d Digest) (int64, error) {
+	fi, err := os.Stat(s.path(d))
+	if errors.Is(err, fs.ErrNotExist) {
+		return 0, fmt.Errorf("%w: %s", ErrNotFound, d)
+	}
+	if err != nil {
+		return 0, err
+	}
+	return fi.Size(), nil
+}
+
+// Open 回傳的 reader 在讀到 EOF 時會比對 hash，不符則回傳 ErrCorrupt 而不是 io.EOF
+func (s *Store) Open(d Digest) (io.ReadCloser, error) {
+	f, err := os.Open(s.path(d))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("%w: %s", ErrNotFound, d)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &verifyReader{f: f, h: sha256.New(), want: d}, nil
+}
+
+// Get 讀出整個 blob 並驗證
+func (s *Store) Get(d Digest) ([]byte, error) {
+	rc, err := s.Open(d)
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+	return io.ReadAll(rc)
+}
+
+// Verify 重新計算 blob 的 hash，不需要內容時比 Get 省記憶體
+func (s *Store) Verify(d Digest) error {
+	rc, err := s.Open(d)
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+	_, err = io.Copy(io.Discard, rc)
+	return err
+}
+
+func (s *Store) Delete(d Digest) error {
+	err := os.Remove(s.path(d))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	return err
+}
+
+// List 回傳所有 blob 的 digest，順序依檔名排序
+func (s *Store) List() ([]Digest, error) {
+	var out []Digest
+	err := filepath.WalkDir(filepath.Join(s.root, "blobs"), func(path string, e fs.DirEntry, err error) error {
+		if err != nil || e.IsDir() {
+			return err
+		}
+		d, err := ParseDigest(e.Name())
+		if err != nil {
+			return nil // 略過不是 blob 的檔案
+		}
+		out = append(out, d)
+		return nil
+	})
+	return out, err
+}
+
+type verifyReader struct {
+	f    *os.File
+	h    hash.Hash
+	want Digest
+}
+
+func (v *verifyReader) Read(p []byte) (int, error) {
+	n, err := v.f.Read(p)
+	v.h.Write(p[:n])
+	if err == io.EOF {
+		var got Digest
+		v.h.Sum(got[:0])
+		if got != v.want {
+			return n, fmt.Errorf("%w: want %s, got %s", ErrCorrupt, v.want, got)
+		}
+	}
+	return n, err
+}
+
+func (v *verifyReader) Close() error { return v.f.Close() }
+
+// validID 避免 upload id 跳出 uploads 目錄或與 .state 檔撞名
+func validID(id string) bool {
+	return id != "" && !strings.ContainsAny(id, `/\.`)
+}
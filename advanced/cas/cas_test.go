@@ -0,0 +1,150 @@
+package cas
+
+import (
+	"bytes"
+	"crypto/rand"
+	"io"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newStore(t *testing.T) *Store {
+	s, err := New(t.TempDir())
+	require.NoError(t, err)
+	return s
+}
+
+func TestPutGet(t *testing.T) {
+	s := newStore(t)
+	d, err := s.PutBytes([]byte("hello"))
+	require.NoError(t, err)
+	assert.Equal(t, "2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824", d.String())
+
+	got, err := s.Get(d)
+	require.NoError(t, err)
+	assert.Equal(t, "hello", string(got))
+
+	n, err := s.Size(d)
+	require.NoError(t, err)
+	assert.Equal(t, int64(5), n)
+
+	parsed, err := ParseDigest(d.String())
+	require.NoError(t, err)
+	assert.Equal(t, d, parsed)
+	_, err = ParseDigest("xyz")
+	assert.ErrorIs(t, err, ErrInvalidDigest)
+
+	_, err = s.Get(Sum([]byte("missing")))
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	require.NoError(t, s.Delete(d))
+	assert.False(t, s.Has(d))
+	require.NoError(t, s.Delete(d), "刪除不存在的 blob 不是錯誤")
+}
+
+func TestDedup(t *testing.T) {
+	s := newStore(t)
+	d1, err := s.PutBytes([]byte("same"))
+	require.NoError(t, err)
+	d2, n, err := s.Put(strings.NewReader("same"))
+	require.NoError(t, err)
+	assert.Equal(t, d1, d2)
+	assert.Equal(t, int64(4), n)
+	_, err = s.PutBytes([]byte("other"))
+	require.NoError(t, err)
+
+	list, err := s.List()
+	require.NoError(t, err)
+	assert.Len(t, list, 2)
+
+	tmp, err := os.ReadDir(s.root + "/tmp")
+	require.NoError(t, err)
+	assert.Empty(t, tmp, "暫存檔都被清掉")
+}
+
+func TestStreamLarge(t *testing.T) {
+	s := newStore(t)
+	data := make([]byte, 8<<20)
+	_, _ = rand.Read(data)
+	d, n, err := s.Put(bytes.NewReader(data))
+	require.NoError(t, err)
+	assert.Equal(t, int64(len(data)), n)
+	assert.Equal(t, Sum(data), d)
+	assert.NoError(t, s.Verify(d))
+}
+
+func TestCorruption(t *testing.T) {
+	s := newStore(t)
+	d, err := s.PutBytes([]byte("important"))
+	require.NoError(t, err)
+
+	p := s.path(d)
+	require.NoError(t, os.Chmod(p, 0o644))
+	require.NoError(t, os.WriteFile(p, []byte("importanT"), 0o644))
+
+	_, err = s.Get(d)
+	assert.ErrorIs(t, err, ErrCorrupt)
+	assert.ErrorIs(t, s.Verify(d), ErrCorrupt)
+}
+
+func TestResumeUpload(t *testing.T) {
+	s := newStore(t)
+	data := make([]byte, 1<<20)
+	_, _ = rand.Read(data)
+	want := Sum(data)
+
+	u, err := s.Begin("download-1")
+	require.NoError(t, err)
+	_, err = u.Write(data[:300_000])
+	require.NoError(t, err)
+	require.NoError(t, u.Checkpoint())
+	// checkpoint 之後又寫了一段，但 state 還沒更新就當機
+	_, err = u.Write(data[300_000:400_000])
+	require.NoError(t, err)
+	require.NoError(t, u.f.Close())
+
+	u, err = s.Resume("download-1")
+	require.NoError(t, err)
+	assert.Equal(t, int64(300_000), u.Offset(), "回到最後一次 checkpoint")
+	_, err = io.Copy(u, bytes.NewReader(data[u.Offset():]))
+	require.NoError(t, err)
+
+	d, err := u.Commit(want)
+	require.NoError(t, err)
+	assert.Equal(t, want, d)
+	got, err := s.Get(d)
+	require.NoError(t, err)
+	assert.Equal(t, data, got)
+
+	_, err = s.Resume("download-1")
+	assert.ErrorIs(t, err, os.ErrNotExist, "commit 後上傳檔被移除")
+}
+
+func TestUploadMismatch(t *testing.T) {
+	s := newStore(t)
+	u, err := s.Begin("u")
+	require.NoError(t, err)
+	_, err = u.Write([]byte("partial"))
+	require.NoError(t, err)
+	require.NoError(t, u.Close())
+
+	// 資料比 state 記錄的短，表示檔案被截斷
+	require.NoError(t, os.Truncate(s.uploadPath("u"), 3))
+	_, err = s.Resume("u")
+	assert.ErrorIs(t, err, ErrUploadCorrupt)
+
+	u, err = s.Begin("u")
+	require.NoError(t, err)
+	_, err = u.Write([]byte("wrong"))
+	require.NoError(t, err)
+	_, err = u.Commit(Sum([]byte("right")))
+	assert.ErrorIs(t, err, ErrCorrupt)
+	assert.False(t, s.Has(Sum([]byte("wrong"))))
+
+	_, err = s.Begin("../escape")
+	assert.Error(t, err)
+}
@@ -0,0 +1,158 @@
+package cas
+
+import (
+	"crypto/sha256"
+	"encoding"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+/*
+可續傳的寫入（例如下載到一半斷線）：
+  - 資料 append 到 uploads/<id>，sha256 的內部狀態（MarshalBinary）與 offset 存到 uploads/<id>.state
+  - Resume 時還原 hash 狀態，不需要從頭重算已下載的部分；檔案比 state 長（state 寫入前當機）
+    就截斷回 offset，比 state 短則代表資料遺失，回傳 ErrUploadCorrupt
+  - Commit 比對最終 digest 與預期值，符合才搬進 blobs，下載端可以確認續傳後的檔案完整
+
+state 只在 Checkpoint / Close 時寫入，兩次 checkpoint 之間的資料當機後會被截斷重下。
+*/
+
+var ErrUploadCorrupt = errors.New("cas: upload state does not match data")
+
+type Upload struct {
+	s      *Store
+	id     string
+	f      *os.File
+	h      hash.Hash
+	offset int64
+}
+
+func (s *Store) uploadPath(id string) string {
+	return filepath.Join(s.root, "uploads", id)
+}
+
+// Begin 開始一個新的上傳，同 id 的舊上傳會被捨棄
+func (s *Store) Begin(id string) (*Upload, error) {
+	if !validID(id) {
+		return nil, fmt.Errorf("cas: invalid upload id %q", id)
+	}
+	f, err := os.Create(s.uploadPath(id))
+	if err != nil {
+		return nil, err
+	}
+	u := &Upload{s: s, id: id, f: f, h: sha256.New()}
+	if err := u.Checkpoint(); err != nil {
+		f.Close()
+		return nil, err
+	}
+	return u, nil
+}
+
+// Resume 從上次的 checkpoint 繼續，回傳的 Offset 即為應該從哪個位置繼續下載
+func (s *Store) Resume(id string) (*Upload, error) {
+	if !validID(id) {
+		return nil, fmt.Errorf("cas: invalid upload id %q", id)
+	}
+	state, err := os.ReadFile(s.uploadPath(id) + ".state")
+	if err != nil {
+		return nil, err
+	}
+	if len(state) < 8 {
+		return nil, ErrUploadCorrupt
+	}
+	offset := int64(binary.BigEndian.Uint64(state[:8]))
+	h := sha256.New()
+	if err := h.(encoding.BinaryUnmarshaler).UnmarshalBinary(state[8:]); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrUploadCorrupt, err)
+	}
+
+	f, err := os.OpenFile(s.uploadPath(id), os.O_RDWR, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	fi, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	if fi.Size() < offset {
+		f.Close()
+		return nil, fmt.Errorf("%w: have %d bytes, state says %d", ErrUploadCorrupt, fi.Size(), offset)
+	}
+	if err := f.Truncate(offset); err != nil {
+		f.Close()
+		return nil, err
+	}
+	if _, err := f.Seek(offset, io.SeekStart); err != nil {
+		f.Close()
+		return nil, err
+	}
+	return &Upload{s: s, id: id, f: f, h: h, offset: offset}, nil
+}
+
+func (u *Upload) Offset() int64 { return u.offset }
+
+func (u *Upload) Write(p []byte) (int, error) {
+	n, err := u.f.Write(p)
+	u.h.Write(p[:n])
+	u.offset += int64(n)
+	return n, err
+}
+
+// Checkpoint 把資料 fsync 後寫入 hash 狀態，之後當機可從這裡 Resume
+func (u *Upload) Checkpoint() error {
+	if err := u.f.Sync(); err != nil {
+		return err
+	}
+	st, err := u.h.(encoding.BinaryMarshaler).MarshalBinary()
+	if err != nil {
+		return err
+	}
+	buf := make([]byte, 8, 8+len(st))
+	binary.BigEndian.PutUint64(buf, uint64(u.offset))
+	buf = append(buf, st...)
+
+	path := u.s.uploadPath(u.id) + ".state"
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, buf, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// Close 寫入 checkpoint 後關閉檔案，之後可用 Resume 繼續
+func (u *Upload) Close() error {
+	err := u.Checkpoint()
+	if cerr := u.f.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+// Commit 驗證內容符合 want 後存入 store；want 為零值時不驗證
+func (u *Upload) Commit(want Digest) (Digest, error) {
+	var got Digest
+	u.h.Sum(got[:0])
+	if want != (Digest{}) && got != want {
+		u.f.Close()
+		return got, fmt.Errorf("%w: want %s, got %s", ErrCorrupt, want, got)
+	}
+	if err := u.s.commit(u.f, got); err != nil {
+		return got, err
+	}
+	os.Remove(u.s.uploadPath(u.id))
+	os.Remove(u.s.uploadPath(u.id) + ".state")
+	return got, nil
+}
+
+// Abort 捨棄上傳
+func (u *Upload) Abort() error {
+	u.f.Close()
+	os.Remove(u.s.uploadPath(u.id) + ".state")
+	return os.Remove(u.s.uploadPath(u.id))
+}
@@ -0,0 +1,236 @@
+package gossip
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// sim 同步投遞訊息，以固定 seed 模擬掉封包，結果可重現
+type sim struct {
+	nodes map[string]*Node
+	names []string
+	down  map[string]bool
+	loss  float64
+	rand  *rand.Rand
+}
+
+func newSim(size int, loss float64, seed int64) *sim {
+	s := &sim{nodes: make(map[string]*Node), down: make(map[string]bool), loss: loss, rand: rand.New(rand.NewSource(seed))}
+	for i := 0; i < size; i++ {
+		s.names = append(s.names, fmt.Sprintf("node-%d", i))
+	}
+	for i, name := range s.names {
+		// 每個節點只知道 node-0，其他成員靠 gossip 得知
+		s.nodes[name] = NewNode(Config{Name: name, Seeds: []string{"node-0"}, Seed: seed + int64(i)})
+	}
+	return s
+}
+
+func (s *sim) deliver() {
+	for {
+		var envs []Envelope
+		for _, name := range s.names {
+			envs = append(envs, s.nodes[name].Messages()...)
+		}
+		if len(envs) == 0 {
+			return
+		}
+		for _, e := range envs {
+			if s.down[e.To] || s.down[e.Msg.From] || s.rand.Float64() < s.loss {
+				continue
+			}
+			s.nodes[e.To].Handle(e.Msg)
+		}
+	}
+}
+
+func (s *sim) tick() {
+	for _, name := range s.names {
+		if !s.down[name] {
+			s.nodes[name].Tick()
+		}
+	}
+	s.deliver()
+}
+
+// runUntil 回傳達成條件所需的 period 數
+func (s *sim) runUntil(t *testing.T, max int, cond func() bool) int {
+	t.Helper()
+	for i := 1; i <= max; i++ {
+		s.tick()
+		if cond() {
+			return i
+		}
+	}
+	t.Fatalf("not converged after %d periods", max)
+	return 0
+}
+
+// converged 檢查所有存活節點看到的存活成員都是 want
+func (s *sim) converged(want []string) bool {
+	for _, name := range s.names {
+		if s.down[name] {
+			continue
+		}
+		if !assert.ObjectsAreEqual(want, s.nodes[name].Alive()) {
+			return false
+		}
+	}
+	return true
+}
+
+func (s *sim) up() []string {
+	var out []string
+	for _, name := range s.names {
+		if !s.down[name] {
+			out = append(out, name)
+		}
+	}
+	sort.Strings(out)
+	return out
+}
+
+func TestJoinConverges(t *testing.T) {
+	for _, loss := range []float64{0, 0.1, 0.3} {
+		t.Run(fmt.Sprint("loss=", loss), func(t *testing.T) {
+			s := newSim(8, loss, 1)
+			rounds := s.runUntil(t, 100, func() bool { return s.converged(s.up()) })
+			t.Logf("converged after %d periods", rounds)
+		})
+	}
+}
+
+func TestFailureDetection(t *testing.T) {
+	s := newSim(6, 0.1, 2)
+	s.runUntil(t, 100, func() bool { return s.converged(s.up()) })
+
+	s.down["node-3"] = true
+	s.runUntil(t, 200, func() bool { return s.converged(s.up()) })
+	for _, name := range s.up() {
+		for _, m := range s.nodes[name].Members() {
+			if m.Name == "node-3" {
+				assert.Equal(t, Dead, m.State, "as seen by %s", name)
+			}
+		}
+	}
+}
+
+func TestSuspicionRefuted(t *testing.T) {
+	s := newSim(4, 0, 3)
+	s.runUntil(t, 50, func() bool { return s.converged(s.up()) })
+
+	// node-1 收到自己被懷疑的消息，應該提高 incarnation 反駁
+	s.nodes["node-1"].Handle(Message{Type: MsgAck, From: "node-2", Updates: []Member{{Name: "node-1", State: Suspect}}})
+	s.nodes["node-2"].Handle(Message{Type: MsgAck, From: "node-0", Updates: []Member{{Name: "node-1", State: Suspect}}})
+	s.runUntil(t, 50, func() bool {
+		for _, name := range s.names {
+			for _, m := range s.nodes[name].Members() {
+				if m.Name == "node-1" && (m.State != Alive || m.Incarnation != 1) {
+					return false
+				}
+			}
+		}
+		return true
+	})
+}
+
+func TestOverrides(t *testing.T) {
+	alive := func(inc uint64) Member { return Member{State: Alive, Incarnation: inc} }
+	suspect := func(inc uint64) Member { return Member{State: Suspect, Incarnation: inc} }
+	dead := func(inc uint64) Member { return Member{State: Dead, Incarnation: inc} }
+
+	tests := []struct {
+		u, cur Member
+		want   bool
+	}{
+		{alive(1), alive(0), true},
+		{alive(0), alive(0), false},
+		{alive(1), suspect(0), true},
+		{alive(0), suspect(0), false},
+		{alive(2), dead(1), true},
+		{suspect(0), alive(0), true},
+		{suspect(0), alive(1), false},
+		{suspect(0), suspect(0), false},
+		{suspect(1), dead(0), false},
+		{dead(0), alive(0), true},
+		{dead(0), suspect(0), true},
+		{dead(1), dead(0), false},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, overrides(tt.u, tt.cur), "%v over %v", tt.u, tt.cur)
+	}
+}
+
+func TestEncodeDecode(t *testing.T) {
+	m := Message{Type: MsgPingReq, From: "a", Seq: 9, Target: "b", Updates: []Member{{Name: "c", State: Suspect, Incarnation: 2}}}
+	b, err := Encode(m)
+	require.NoError(t, err)
+	got, err := Decode(b)
+	require.NoError(t, err)
+	assert.Equal(t, m, got)
+}
+
+func TestRunMemNetwork(t *testing.T) {
+	nw := NewMemNetwork(0.2, 1)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	names := []string{"a", "b", "c", "d"}
+	nodes := make([]*Node, len(names))
+	done := make(chan struct{})
+	for i, name := range names {
+		nodes[i] = NewNode(Config{Name: name, Seeds: []string{"a"}, Seed: int64(i)})
+		tr := nw.Listen(name)
+		go func(n *Node) {
+			_ = Run(ctx, n, tr, 5*time.Millisecond)
+			done <- struct{}{}
+		}(nodes[i])
+	}
+	assert.Eventually(t, func() bool {
+		for _, n := range nodes {
+			if len(n.Alive()) != len(names) {
+				return false
+			}
+		}
+		return true
+	}, 5*time.Second, 10*time.Millisecond)
+	cancel()
+	for range nodes {
+		<-done
+	}
+}
+
+func TestRunUDP(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var transports []*UDPTransport
+	for i := 0; i < 3; i++ {
+		tr, err := ListenUDP("127.0.0.1:0")
+		require.NoError(t, err)
+		defer tr.Close()
+		transports = append(transports, tr)
+	}
+	seed := transports[0].Addr()
+	var nodes []*Node
+	for i, tr := range transports {
+		n := NewNode(Config{Name: tr.Addr(), Seeds: []string{seed}, Seed: int64(i)})
+		nodes = append(nodes, n)
+		go func(tr Transport) { _ = Run(ctx, n, tr, 10*time.Millisecond) }(tr)
+	}
+	assert.Eventually(t, func() bool {
+		for _, n := range nodes {
+			if len(n.Alive()) != 3 {
+				return false
+			}
+		}
+		return true
+	}, 5*time.Second, 10*time.Millisecond)
+}
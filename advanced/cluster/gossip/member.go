@@ -0,0 +1,81 @@
+package gossip
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+type State int
+
+const (
+	Alive State = iota
+	Suspect
+	Dead
+)
+
+func (s State) String() string {
+	switch s {
+	case Alive:
+		return "alive"
+	case Suspect:
+		return "suspect"
+	case Dead:
+		return "dead"
+	}
+	return fmt.Sprintf("State(%d)", int(s))
+}
+
+// Member 是某個節點在 membership 中的狀態；Incarnation 只有節點本人可以增加，用來推翻對自己的懷疑
+type Member struct {
+	Name        string
+	State       State
+	Incarnation uint64
+}
+
+type MsgType int
+
+const (
+	MsgPing    MsgType = iota
+	MsgAck             // 回覆 ping，Seq 與 ping 相同
+	MsgPingReq         // 請對方代為 ping Target（間接探測）
+)
+
+// Message 在節點之間傳遞，Updates 是順便夾帶（piggyback）的 membership 變化
+type Message struct {
+	Type    MsgType
+	From    string
+	Seq     uint64
+	Target  string   `json:",omitempty"`
+	Updates []Member `json:",omitempty"`
+}
+
+// Envelope 是待送出的訊息
+type Envelope struct {
+	To  string
+	Msg Message
+}
+
+func Encode(m Message) ([]byte, error) { return json.Marshal(m) }
+
+func Decode(b []byte) (Message, error) {
+	var m Message
+	err := json.Unmarshal(b, &m)
+	return m, err
+}
+
+// overrides 實作 SWIM 的覆蓋規則：
+//   - Alive 只能被更大 incarnation 的 Alive 取代
+//   - Suspect 覆蓋相同或較舊 incarnation 的 Alive、較舊的 Suspect
+//   - Dead 覆蓋相同或較舊 incarnation 的任何狀態
+func overrides(u, cur Member) bool {
+	switch u.State {
+	case Alive:
+		return u.Incarnation > cur.Incarnation
+	case Suspect:
+		return cur.State == Alive && u.Incarnation >= cur.Incarnation ||
+			cur.State == Suspect && u.Incarnation > cur.Incarnation
+	case Dead:
+		return cur.State != Dead && u.Incarnation >= cur.Incarnation
+	}
+	return false
+}
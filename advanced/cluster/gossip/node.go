@@ -0,0 +1,338 @@
+package gossip
+
+import (
+	"math"
+	"math/rand"
+	"sort"
+	"sync"
+)
+
+/*
+SWIM-lite membership（Scalable Weakly-consistent Infection-style Membership）。
+
+每個 protocol period（一次 Tick）：
+ 1. 依序挑一個成員送 ping，期望在同一個 period 內收到 ack
+ 2. 沒收到 ack：下一個 period 請 k 個其他成員代為 ping（ping-req），避免因單一路徑不通而誤判
+ 3. 還是沒有 ack：把對方標成 Suspect 並廣播
+ 4. Suspect 持續 SuspectTicks 個 period 沒被推翻就標成 Dead
+被懷疑的節點聽到消息後把自己的 incarnation 加一並廣播 Alive，推翻懷疑。
+
+membership 的變化不另外送訊息，而是夾帶在 ping / ack 裡（infection-style dissemination），
+每個變化最多轉送 RetransmitMult * log(n) 次。夾帶空間有剩時隨機附上其他成員的目前狀態，
+即使廣播全部遺失，最後仍會收斂（簡化版的 anti-entropy）。
+
+Node 本身不碰網路與時間，只把要送的訊息放在 outbox，由 Run 或測試負責投遞。
+*/
+
+type Config struct {
+	Name  string
+	Seeds []string // 啟動時已知的其他節點
+	// IndirectChecks 是 ping-req 的數量，預設 3
+	IndirectChecks int
+	// SuspectTicks 是 Suspect 轉為 Dead 前等待的 period 數，預設 5
+	SuspectTicks int
+	// RetransmitMult 控制每個變化的轉送次數，預設 3
+	RetransmitMult int
+	// MaxPiggyback 是每則訊息夾帶的最大更新數，預設 8
+	MaxPiggyback int
+	Seed         int64
+}
+
+type memberState struct {
+	Member
+	suspectAt int
+}
+
+type probe struct {
+	target   string
+	seq      uint64
+	acked    bool
+	indirect bool
+}
+
+type relay struct {
+	origin string
+	seq    uint64
+	tick   int
+}
+
+type broadcast struct {
+	update Member
+	sent   int
+}
+
+type Node struct {
+	mu  sync.Mutex
+	cfg Config
+
+	incarnation uint64
+	members     map[string]*memberState
+	order       []string
+	orderIdx    int
+
+	tick   int
+	seq    uint64
+	probe  *probe
+	relays map[uint64]relay
+	queue  []*broadcast
+	out    []Envelope
+	rand   *rand.Rand
+}
+
+func NewNode(cfg Config) *Node {
+	if cfg.IndirectChecks == 0 {
+		cfg.IndirectChecks = 3
+	}
+	if cfg.SuspectTicks == 0 {
+		cfg.SuspectTicks = 5
+	}
+	if cfg.RetransmitMult == 0 {
+		cfg.RetransmitMult = 3
+	}
+	if cfg.MaxPiggyback == 0 {
+		cfg.MaxPiggyback = 8
+	}
+	n := &Node{
+		cfg:     cfg,
+		members: make(map[string]*memberState),
+		relays:  make(map[uint64]relay),
+		rand:    rand.New(rand.NewSource(cfg.Seed)),
+	}
+	for _, s := range cfg.Seeds {
+		if s != cfg.Name {
+			n.members[s] = &memberState{Member: Member{Name: s}}
+		}
+	}
+	n.enqueue(n.self())
+	return n
+}
+
+func (n *Node) Name() string { return n.cfg.Name }
+
+func (n *Node) self() Member {
+	return Member{Name: n.cfg.Name, State: Alive, Incarnation: n.incarnation}
+}
+
+// Members 回傳包含自己在內的所有已知成員，依名稱排序
+func (n *Node) Members() []Member {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	out := []Member{n.self()}
+	for _, m := range n.members {
+		out = append(out, m.Member)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out
+}
+
+// Alive 回傳目前認為存活（含 Suspect）的成員名稱
+func (n *Node) Alive() []string {
+	var out []string
+	for _, m := range n.Members() {
+		if m.State != Dead {
+			out = append(out, m.Name)
+		}
+	}
+	return out
+}
+
+// Messages 取出並清空 outbox
+func (n *Node) Messages() []Envelope {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	out := n.out
+	n.out = nil
+	return out
+}
+
+// Tick 執行一個 protocol period
+func (n *Node) Tick() {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.tick++
+
+	for _, m := range n.members {
+		if m.State == Suspect && n.tick-m.suspectAt >= n.cfg.SuspectTicks {
+			n.apply(Member{Name: m.Name, State: Dead, Incarnation: m.Incarnation})
+		}
+	}
+	for seq, r := range n.relays {
+		if n.tick-r.tick > 2 {
+			delete(n.relays, seq)
+		}
+	}
+
+	if p := n.probe; p != nil && !p.acked {
+		if !p.indirect {
+			p.indirect = true
+			if n.pingReq(p) > 0 {
+				return
+			}
+		}
+		if m, ok := n.members[p.target]; ok && m.State == Alive {
+			n.apply(Member{Name: p.target, State: Suspect, Incarnation: m.Incarnation})
+		}
+	}
+	n.probe = nil
+
+	target := n.nextTarget()
+	if target == "" {
+		return
+	}
+	n.seq++
+	n.probe = &probe{target: target, seq: n.seq}
+	n.send(target, Message{Type: MsgPing, Seq: n.seq})
+}
+
+func (n *Node) pingReq(p *probe) int {
+	var helpers []string
+	for name, m := range n.members {
+		if name != p.target && m.State == Alive {
+			helpers = append(helpers, name)
+		}
+	}
+	sort.Strings(helpers)
+	n.rand.Shuffle(len(helpers), func(i, j int) { helpers[i], helpers[j] = helpers[j], helpers[i] })
+	if len(helpers) > n.cfg.IndirectChecks {
+		helpers = helpers[:n.cfg.IndirectChecks]
+	}
+	for _, h := range helpers {
+		n.send(h, Message{Type: MsgPingReq, Seq: p.seq, Target: p.target})
+	}
+	return len(helpers)
+}
+
+// nextTarget 以打亂後的 round-robin 挑選探測對象，確保每個成員在有限時間內都會被探測到
+func (n *Node) nextTarget() string {
+	for {
+		if n.orderIdx >= len(n.order) {
+			n.order = n.order[:0]
+			for name, m := range n.members {
+				if m.State != Dead {
+					n.order = append(n.order, name)
+				}
+			}
+			if len(n.order) == 0 {
+				return ""
+			}
+			sort.Strings(n.order)
+			n.rand.Shuffle(len(n.order), func(i, j int) { n.order[i], n.order[j] = n.order[j], n.order[i] })
+			n.orderIdx = 0
+		}
+		name := n.order[n.orderIdx]
+		n.orderIdx++
+		if m, ok := n.members[name]; ok && m.State != Dead {
+			return name
+		}
+	}
+}
+
+// Handle 處理收到的訊息
+func (n *Node) Handle(m Message) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	for _, u := range m.Updates {
+		n.apply(u)
+	}
+	switch m.Type {
+	case MsgPing:
+		n.send(m.From, Message{Type: MsgAck, Seq: m.Seq})
+	case MsgPingReq:
+		n.seq++
+		n.relays[n.seq] = relay{origin: m.From, seq: m.Seq, tick: n.tick}
+		n.send(m.Target, Message{Type: MsgPing, Seq: n.seq})
+	case MsgAck:
+		if r, ok := n.relays[m.Seq]; ok {
+			delete(n.relays, m.Seq)
+			n.send(r.origin, Message{Type: MsgAck, Seq: r.seq})
+			return
+		}
+		if n.probe != nil && n.probe.seq == m.Seq {
+			n.probe.acked = true
+		}
+	}
+}
+
+func (n *Node) apply(u Member) {
+	if u.Name == n.cfg.Name {
+		// 有人懷疑自己：提高 incarnation 推翻它
+		if u.State != Alive && u.Incarnation >= n.incarnation {
+			n.incarnation = u.Incarnation + 1
+			n.enqueue(n.self())
+		}
+		return
+	}
+	cur, ok := n.members[u.Name]
+	if !ok {
+		cur = &memberState{Member: u}
+		n.members[u.Name] = cur
+	} else if !overrides(u, cur.Member) {
+		return
+	}
+	cur.Member = u
+	if u.State == Suspect {
+		cur.suspectAt = n.tick
+	}
+	n.enqueue(u)
+}
+
+func (n *Node) enqueue(u Member) {
+	for i, b := range n.queue {
+		if b.update.Name == u.Name {
+			n.queue = append(n.queue[:i], n.queue[i+1:]...)
+			break
+		}
+	}
+	n.queue = append(n.queue, &broadcast{update: u})
+}
+
+func (n *Node) retransmitLimit() int {
+	return n.cfg.RetransmitMult * int(math.Ceil(math.Log10(float64(len(n.members)+2))))
+}
+
+func (n *Node) send(to string, m Message) {
+	m.From = n.cfg.Name
+	m.Updates = n.piggyback()
+	n.out = append(n.out, Envelope{To: to, Msg: m})
+}
+
+// piggyback 優先夾帶轉送次數最少的變化，剩下的空間隨機附上成員目前的狀態
+func (n *Node) piggyback() []Member {
+	sort.SliceStable(n.queue, func(i, j int) bool { return n.queue[i].sent < n.queue[j].sent })
+	limit := n.retransmitLimit()
+	var out []Member
+	seen := make(map[string]bool)
+	kept := n.queue[:0]
+	for _, b := range n.queue {
+		if len(out) < n.cfg.MaxPiggyback {
+			out = append(out, b.update)
+			seen[b.update.Name] = true
+			b.sent++
+		}
+		if b.sent < limit {
+			kept = append(kept, b)
+		}
+	}
+	n.queue = kept
+
+	if len(out) < n.cfg.MaxPiggyback && !seen[n.cfg.Name] {
+		out = append(out, n.self())
+	}
+	if len(out) < n.cfg.MaxPiggyback {
+		names := make([]string, 0, len(n.members))
+		for name := range n.members {
+			if !seen[name] {
+				names = append(names, name)
+			}
+		}
+		sort.Strings(names)
+		n.rand.Shuffle(len(names), func(i, j int) { names[i], names[j] = names[j], names[i] })
+		for _, name := range names {
+			if len(out) >= n.cfg.MaxPiggyback {
+				break
+			}
+			out = append(out, n.members[name].Member)
+		}
+	}
+	return out
+}
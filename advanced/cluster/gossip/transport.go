@@ -0,0 +1,166 @@
+package gossip
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"net"
+	"sync"
+	"time"
+)
+
+// Packet 是從 transport 收到的原始資料
+type Packet struct {
+	From string
+	Data []byte
+}
+
+// Transport 以位址傳送封包，不保證送達、不保證順序，與 UDP 的語意相同
+type Transport interface {
+	Addr() string
+	Send(to string, b []byte) error
+	Packets() <-chan Packet
+	Close() error
+}
+
+var ErrClosed = errors.New("gossip: transport closed")
+
+// UDPTransport 一個封包就是一則訊息，超過 MTU 的訊息不處理（教學用）
+type UDPTransport struct {
+	conn    *net.UDPConn
+	packets chan Packet
+}
+
+func ListenUDP(addr string) (*UDPTransport, error) {
+	ua, err := net.ResolveUDPAddr("udp", addr)
+	if err != nil {
+		return nil, err
+	}
+	conn, err := net.ListenUDP("udp", ua)
+	if err != nil {
+		return nil, err
+	}
+	t := &UDPTransport{conn: conn, packets: make(chan Packet, 256)}
+	go t.readLoop()
+	return t, nil
+}
+
+func (t *UDPTransport) readLoop() {
+	defer close(t.packets)
+	buf := make([]byte, 64<<10)
+	for {
+		n, from, err := t.conn.ReadFromUDP(buf)
+		if err != nil {
+			return
+		}
+		p := Packet{From: from.String(), Data: append([]byte(nil), buf[:n]...)}
+		select {
+		case t.packets <- p:
+		default: // 處理不及就丟掉，跟網路掉封包一樣
+		}
+	}
+}
+
+func (t *UDPTransport) Addr() string { return t.conn.LocalAddr().String() }
+
+func (t *UDPTransport) Send(to string, b []byte) error {
+	ua, err := net.ResolveUDPAddr("udp", to)
+	if err != nil {
+		return err
+	}
+	_, err = t.conn.WriteToUDP(b, ua)
+	return err
+}
+
+func (t *UDPTransport) Packets() <-chan Packet { return t.packets }
+func (t *UDPTransport) Close() error           { return t.conn.Close() }
+
+// MemNetwork 是記憶體中的網路，可以設定掉封包的機率
+type MemNetwork struct {
+	mu    sync.Mutex
+	nodes map[string]*memTransport
+	loss  float64
+	rand  *rand.Rand
+}
+
+func NewMemNetwork(loss float64, seed int64) *MemNetwork {
+	return &MemNetwork{nodes: make(map[string]*memTransport), loss: loss, rand: rand.New(rand.NewSource(seed))}
+}
+
+func (nw *MemNetwork) Listen(addr string) Transport {
+	nw.mu.Lock()
+	defer nw.mu.Unlock()
+	t := &memTransport{nw: nw, addr: addr, packets: make(chan Packet, 256)}
+	nw.nodes[addr] = t
+	return t
+}
+
+type memTransport struct {
+	nw      *MemNetwork
+	addr    string
+	packets chan Packet
+	closed  bool
+}
+
+func (t *memTransport) Addr() string { return t.addr }
+
+func (t *memTransport) Send(to string, b []byte) error {
+	nw := t.nw
+	nw.mu.Lock()
+	defer nw.mu.Unlock()
+	if t.closed {
+		return ErrClosed
+	}
+	dst, ok := nw.nodes[to]
+	if !ok || dst.closed || nw.rand.Float64() < nw.loss {
+		return nil
+	}
+	select {
+	case dst.packets <- Packet{From: t.addr, Data: append([]byte(nil), b...)}:
+	default:
+	}
+	return nil
+}
+
+func (t *memTransport) Packets() <-chan Packet { return t.packets }
+
+func (t *memTransport) Close() error {
+	t.nw.mu.Lock()
+	defer t.nw.mu.Unlock()
+	if !t.closed {
+		t.closed = true
+		close(t.packets)
+	}
+	return nil
+}
+
+// Run 以 interval 為 protocol period 驅動 Node，直到 ctx 結束或 transport 關閉。
+// Node 的名稱必須是 transport 的位址。
+func Run(ctx context.Context, n *Node, tr Transport, interval time.Duration) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+			n.Tick()
+		case p, ok := <-tr.Packets():
+			if !ok {
+				return ErrClosed
+			}
+			m, err := Decode(p.Data)
+			if err != nil {
+				continue
+			}
+			n.Handle(m)
+		}
+		for _, env := range n.Messages() {
+			b, err := Encode(env.Msg)
+			if err != nil {
+				return err
+			}
+			_ = tr.Send(env.To, b)
+		}
+	}
+}
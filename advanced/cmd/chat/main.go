@@ -0,0 +1,61 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"net"
+	"os"
+
+	"advanced/apps/chat"
+	"advanced/osx/signals"
+)
+
+const usage = `chat 啟動聊天室服務，Ctrl-C 時 graceful shutdown
+
+usage:
+	chat [-tcp :7000] [-ws :8080] [-history chat.snap]
+
+TCP client 一行送一個 JSON，例如：
+	{"type":"hello","user":"alice"}
+	{"type":"join","room":"go"}
+	{"type":"say","room":"go","text":"hi"}
+WebSocket client 連到 ws://host:8080/ 送同樣的 JSON。
+`
+
+func main() {
+	flag.Usage = func() { fmt.Fprint(os.Stderr, usage) }
+	tcpAddr := flag.String("tcp", ":7000", "TCP frontend address")
+	wsAddr := flag.String("ws", ":8080", "WebSocket frontend address")
+	history := flag.String("history", "", "load and save message history to this file")
+	flag.Parse()
+
+	if err := run(*tcpAddr, *wsAddr, *history); err != nil {
+		fmt.Fprintln(os.Stderr, "chat:", err)
+		os.Exit(1)
+	}
+}
+
+func run(tcpAddr, wsAddr, history string) error {
+	var opts []chat.Option
+	if history != "" {
+		opts = append(opts, chat.WithHistoryFile(history))
+	}
+	srv, err := chat.New(opts...)
+	if err != nil {
+		return err
+	}
+	tcp, err := net.Listen("tcp", tcpAddr)
+	if err != nil {
+		return err
+	}
+	ws, err := net.Listen("tcp", wsAddr)
+	if err != nil {
+		tcp.Close()
+		return err
+	}
+	ctx, stop := signals.NotifyContext(context.Background())
+	defer stop()
+	fmt.Fprintf(os.Stderr, "chat: tcp %s, websocket %s\n", tcp.Addr(), ws.Addr())
+	return srv.Serve(ctx, tcp, ws)
+}
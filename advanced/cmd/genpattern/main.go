@@ -0,0 +1,62 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"advanced/genpattern"
+)
+
+// go run ./cmd/genpattern -spec store.yaml -o store_gen.go
+// 或在原始碼中：//go:generate go run advanced/cmd/genpattern -src $GOFILE
+func main() {
+	spec := flag.String("spec", "", "YAML spec file")
+	src := flag.String("src", "", "Go source file with //genpattern: directives")
+	out := flag.String("o", "", "output file (default: <src>_genpattern.go for -src, stdout for -spec)")
+	flag.Parse()
+	log.SetFlags(0)
+	log.SetPrefix("genpattern: ")
+
+	var (
+		s   *genpattern.Spec
+		err error
+	)
+	switch {
+	case *spec != "" && *src == "":
+		var data []byte
+		if data, err = os.ReadFile(*spec); err == nil {
+			s, err = genpattern.ParseYAML(data)
+		}
+	case *src != "" && *spec == "":
+		var data []byte
+		if data, err = os.ReadFile(*src); err == nil {
+			s, err = genpattern.ParseSource(*src, data)
+		}
+		if *out == "" {
+			*out = strings.TrimSuffix(*src, filepath.Ext(*src)) + "_genpattern.go"
+		}
+	default:
+		fmt.Fprintln(os.Stderr, "exactly one of -spec or -src is required")
+		flag.Usage()
+		os.Exit(2)
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	code, err := genpattern.Generate(s)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if *out == "" {
+		os.Stdout.Write(code)
+		return
+	}
+	if err := os.WriteFile(*out, code, 0o644); err != nil {
+		log.Fatal(err)
+	}
+}
@@ -0,0 +1,73 @@
+package main
+
+import "unicode/utf8"
+
+// key 是一般字元本身（"q"、"j"），或下列特殊鍵的名稱
+type key string
+
+const (
+	keyUp        key = "<up>"
+	keyDown      key = "<down>"
+	keyPgUp      key = "<pgup>"
+	keyPgDown    key = "<pgdown>"
+	keyEnter     key = "<enter>"
+	keyEsc       key = "<esc>"
+	keyBackspace key = "<backspace>"
+	keyCtrlC     key = "<ctrl-c>"
+)
+
+var escapes = map[string]key{
+	"\x1b[A":  keyUp,
+	"\x1b[B":  keyDown,
+	"\x1bOA":  keyUp, // application cursor mode
+	"\x1bOB":  keyDown,
+	"\x1b[5~": keyPgUp,
+	"\x1b[6~": keyPgDown,
+}
+
+// parseKeys 把 raw mode 下一次 Read 到的位元組拆成按鍵；
+// 無法辨識的 escape sequence 會被略過，單獨的 ESC 視為 keyEsc
+func parseKeys(b []byte) []key {
+	var keys []key
+	for len(b) > 0 {
+		switch c := b[0]; {
+		case c == 0x1b:
+			n, k := parseEscape(b)
+			if k != "" {
+				keys = append(keys, k)
+			}
+			b = b[n:]
+			continue
+		case c == '\r' || c == '\n':
+			keys = append(keys, keyEnter)
+		case c == 0x7f || c == 0x08:
+			keys = append(keys, keyBackspace)
+		case c == 0x03:
+			keys = append(keys, keyCtrlC)
+		case c < 0x20:
+			// 其他控制字元不處理
+		default:
+			r, size := utf8.DecodeRune(b)
+			if r != utf8.RuneError {
+				keys = append(keys, key(string(r)))
+			}
+			b = b[size:]
+			continue
+		}
+		b = b[1:]
+	}
+	return keys
+}
+
+func parseEscape(b []byte) (int, key) {
+	if len(b) == 1 || (b[1] != '[' && b[1] != 'O') {
+		return 1, keyEsc
+	}
+	// CSI：參數位元組之後以 0x40–0x7e 結尾
+	for i := 2; i < len(b); i++ {
+		if b[i] >= 0x40 && b[i] <= 0x7e {
+			return i + 1, escapes[string(b[:i+1])]
+		}
+	}
+	return len(b), ""
+}
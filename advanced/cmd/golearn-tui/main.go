@@ -0,0 +1,266 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"golang.org/x/term"
+
+	"advanced/diag"
+	"advanced/golearn"
+	"advanced/lifecycle"
+)
+
+/*
+golearn-tui 是 golearn 的互動版本：左上列出各 module 的範例，選擇後執行並即時顯示
+輸出與子行程的 goroutine 數量。
+
+	go run ./cmd/golearn-tui [-race] [-n 1] [-procs 0] [-trace 250ms]
+
+執行流程（每次 enter / r）：
+ 1. lifecycle.Runner 取消並等待上一次執行，然後在新的 goroutine 開始
+ 2. golearn.Build 以 go test -c 編譯範例所在的套件
+ 3. 直接啟動測試執行檔，只有它帶 GODEBUG=schedtrace，go 工具本身不會輸出 trace
+ 4. stdout 逐行送到輸出面板；stderr 經過 diag.Demux，trace 轉成取樣，其他行也送到輸出面板
+
+按 c 時取消 context：exec.Cmd 先送 os.Interrupt，測試執行檔在 WaitDelay 內沒結束才 kill。
+
+所有事件（按鍵、輸出、取樣、狀態、tick）都送進同一個 channel，由主迴圈依序更新 model，
+畫面每 100ms 重繪一次，按鍵則立即重繪。
+*/
+
+func main() {
+	root := flag.String("root", golearn.RepoRoot(), "repository root")
+	race := flag.Bool("race", false, "build examples with the race detector")
+	iterations := flag.Int("n", 1, "iterations (-test.count)")
+	procs := flag.Int("procs", 0, "GOMAXPROCS for the example (-test.cpu), 0 keeps the default")
+	interval := flag.Duration("trace", 250*time.Millisecond, "schedtrace interval used for goroutine counts")
+	flag.Parse()
+
+	if err := run(*root, *race, golearn.RunConfig{Iterations: *iterations, GOMAXPROCS: *procs, Verbose: true}, *interval); err != nil {
+		fmt.Fprintln(os.Stderr, "golearn-tui:", err)
+		os.Exit(1)
+	}
+}
+
+type (
+	keyEvent    key
+	lineEvent   string
+	sampleEvent diag.Sample
+	statusEvent lifecycle.Status
+	tickEvent   time.Time
+)
+
+type app struct {
+	events   chan any
+	runner   *lifecycle.Runner
+	race     bool
+	cfg      golearn.RunConfig
+	interval time.Duration
+	binDir   string
+}
+
+func run(root string, race bool, cfg golearn.RunConfig, interval time.Duration) error {
+	fd := int(os.Stdin.Fd())
+	if !term.IsTerminal(fd) {
+		return errors.New("stdin is not a terminal, use golearn instead")
+	}
+	examples, err := golearn.Discover(root)
+	if err != nil {
+		return err
+	}
+	binDir, err := os.MkdirTemp("", "golearn-tui")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(binDir)
+
+	a := &app{
+		events:   make(chan any, 1024),
+		race:     race,
+		cfg:      cfg,
+		interval: interval,
+		binDir:   binDir,
+	}
+	a.runner = lifecycle.NewRunner(lifecycle.WithOnChange(func(st lifecycle.Status) {
+		a.events <- statusEvent(st)
+	}))
+
+	state, err := term.MakeRaw(fd)
+	if err != nil {
+		return err
+	}
+	defer term.Restore(fd, state)
+
+	out := bufio.NewWriter(os.Stdout)
+	out.WriteString("\x1b[?1049h\x1b[?25l") // 切到 alternate screen 並隱藏游標
+	defer func() {
+		out.WriteString("\x1b[?25h\x1b[?1049l")
+		out.Flush()
+	}()
+
+	go a.readKeys(os.Stdin)
+	ticker := time.NewTicker(100 * time.Millisecond)
+	defer ticker.Stop()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer func() {
+		cancel()
+		// 等待時繼續消化事件，避免執行中的 goroutine 卡在送出輸出
+		done := make(chan struct{})
+		go func() {
+			a.runner.Wait()
+			close(done)
+		}()
+		for {
+			select {
+			case <-a.events:
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	m := newModel(examples)
+	selfAt := time.Now()
+	m.self = diag.ReadSelf()
+	a.resize(m, fd)
+	render(out, m)
+	for {
+		var ev any
+		select {
+		case ev = <-a.events:
+		case t := <-ticker.C:
+			ev = tickEvent(t)
+		}
+		switch ev := ev.(type) {
+		case keyEvent:
+			switch m.key(key(ev)) {
+			case actRun, actRerun:
+				e := *m.current
+				go a.runner.Start(ctx, func(ctx context.Context) error { return a.runExample(ctx, e) })
+			case actCancel:
+				a.runner.Cancel()
+			case actQuit:
+				return nil
+			}
+			render(out, m)
+		case lineEvent:
+			m.addLine(string(ev))
+		case sampleEvent:
+			m.addSample(diag.Sample(ev))
+		case statusEvent:
+			m.setStatus(lifecycle.Status(ev))
+		case tickEvent:
+			if t := time.Time(ev); t.Sub(selfAt) >= time.Second {
+				m.self, selfAt = diag.ReadSelf(), t
+			}
+			a.resize(m, fd)
+			render(out, m)
+		}
+	}
+}
+
+// resize 每次 tick 查詢終端大小，避免依賴只有 unix 才有的 SIGWINCH
+func (a *app) resize(m *model, fd int) {
+	if w, h, err := term.GetSize(fd); err == nil {
+		m.width, m.height = w, h
+	}
+}
+
+func (a *app) readKeys(r io.Reader) {
+	buf := make([]byte, 64)
+	for {
+		n, err := r.Read(buf)
+		for _, k := range parseKeys(buf[:n]) {
+			a.events <- keyEvent(k)
+		}
+		if err != nil {
+			return
+		}
+	}
+}
+
+func (a *app) runExample(ctx context.Context, e golearn.Example) error {
+	a.events <- lineEvent(fmt.Sprintf("$ go test -c ./%s (%s)", e.Package, e.Module))
+	bin, err := golearn.Build(ctx, []golearn.Example{e}, a.race, a.binDir)
+	if err != nil {
+		for _, line := range strings.Split(strings.TrimSpace(err.Error()), "\n") {
+			a.events <- lineEvent(line)
+		}
+		return err
+	}
+
+	stdout := &lineWriter{emit: func(s string) { a.events <- lineEvent(s) }}
+	pr, pw := io.Pipe()
+	cfg := a.cfg
+	cfg.Stdout, cfg.Stderr = stdout, pw
+	cmd := bin.Command(ctx, cfg)
+	cmd.Env = append(os.Environ(), "GODEBUG="+diag.GODEBUG(a.interval))
+	cmd.Cancel = func() error { return cmd.Process.Signal(os.Interrupt) }
+	cmd.WaitDelay = 2 * time.Second
+	a.events <- lineEvent("$ " + filepath.Base(bin.Path) + " " + strings.Join(cmd.Args[1:], " "))
+
+	demuxed := make(chan struct{})
+	go func() {
+		defer close(demuxed)
+		stderr := &lineWriter{emit: stdout.emit}
+		diag.Demux(pr, stderr, func(s diag.Sample) { a.events <- sampleEvent(s) })
+		stderr.Flush()
+		io.Copy(io.Discard, pr) // Demux 出錯時仍要讀完，避免子行程卡在寫入
+	}()
+	err = cmd.Run()
+	pw.Close()
+	<-demuxed
+	stdout.Flush()
+	return err
+}
+
+// lineWriter 把寫入的資料切成完整的行再送出
+type lineWriter struct {
+	buf  []byte
+	emit func(string)
+}
+
+func (w *lineWriter) Write(p []byte) (int, error) {
+	w.buf = append(w.buf, p...)
+	for {
+		i := bytes.IndexByte(w.buf, '\n')
+		if i < 0 {
+			return len(p), nil
+		}
+		w.emit(string(w.buf[:i]))
+		w.buf = w.buf[i+1:]
+	}
+}
+
+// Flush 送出最後一段沒有換行結尾的資料
+func (w *lineWriter) Flush() {
+	if len(w.buf) > 0 {
+		w.emit(string(w.buf))
+		w.buf = nil
+	}
+}
+
+// render 回到左上角逐行覆寫並清掉行尾，比整個畫面清除閃爍少
+func render(w *bufio.Writer, m *model) {
+	w.WriteString("\x1b[H")
+	for i, line := range m.view() {
+		if i > 0 {
+			w.WriteString("\r\n")
+		}
+		w.WriteString(line)
+		w.WriteString("\x1b[K")
+	}
+	w.WriteString("\x1b[J")
+	w.Flush()
+}
@@ -0,0 +1,191 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"advanced/diag"
+	"advanced/golearn"
+	"advanced/lifecycle"
+)
+
+func TestParseKeys(t *testing.T) {
+	assert.Equal(t, []key{"j", keyUp, keyDown, keyEnter, keyCtrlC}, parseKeys([]byte("j\x1b[A\x1bOB\r\x03")))
+	assert.Equal(t, []key{keyEsc}, parseKeys([]byte("\x1b")))
+	assert.Equal(t, []key{keyPgDown, "q"}, parseKeys([]byte("\x1b[6~q")))
+	assert.Equal(t, []key{"x"}, parseKeys([]byte("\x1b[1;5Cx")), "不認識的 sequence 整段略過")
+	assert.Equal(t, []key{"通", keyBackspace}, parseKeys([]byte("通\x7f")))
+}
+
+func examples() []golearn.Example {
+	return []golearn.Example{
+		{Module: "advanced", Package: "concurrency/workerpool", Name: "pool", Func: "TestPool"},
+		{Module: "basic", Package: "goroutine", Name: "use-select", Func: "TestGoroutineUseSelect", Doc: "示範 select"},
+		{Module: "basic", Package: "goroutine", Name: "wait-group", Func: "TestGoroutineWaitGroup"},
+	}
+}
+
+func TestModelNavigationAndFilter(t *testing.T) {
+	m := newModel(examples())
+	assert.Equal(t, actNone, m.key(keyUp), "已在最上面")
+	m.key("j")
+	m.key(keyDown)
+	m.key(keyDown)
+	e, _ := m.selected()
+	assert.Equal(t, "wait-group", e.Name)
+
+	assert.Equal(t, actNone, m.key("r"), "還沒執行過不能 re-run")
+	assert.Equal(t, actRun, m.key(keyEnter))
+	assert.Equal(t, "wait-group", m.current.Name)
+	assert.Equal(t, actRerun, m.key("r"))
+
+	m.key("/")
+	for _, k := range []key{"s", "e", "l", "x", keyBackspace} {
+		assert.Equal(t, actNone, m.key(k), "filter 模式下字母不是指令")
+	}
+	assert.Equal(t, "sel", m.filter)
+	m.key(keyEnter)
+	assert.False(t, m.filtering)
+	e, _ = m.selected()
+	assert.Equal(t, "use-select", e.Name)
+	assert.Len(t, m.visible, 1)
+
+	m.key(keyEsc)
+	assert.Len(t, m.visible, 3)
+	assert.Equal(t, actCancel, m.key("c"))
+	assert.Equal(t, actQuit, m.key("q"))
+}
+
+func TestModelRunLifecycle(t *testing.T) {
+	m := newModel(examples())
+	m.key(keyEnter)
+	m.addLine("stale")
+
+	m.setStatus(lifecycle.Status{State: lifecycle.Running, Run: 1, Started: time.Now()})
+	assert.Empty(t, m.output, "新的執行清掉上一次的輸出")
+	m.addLine("=== RUN\tTestPool")
+	for _, n := range []int{4, 12, 7} {
+		m.addSample(diag.Sample{Goroutines: n, Threads: 5})
+	}
+	assert.Equal(t, 12, m.peak)
+	assert.Equal(t, []string{"=== RUN    TestPool"}, m.output)
+
+	status := m.statusView()
+	assert.Contains(t, status, "● running advanced:concurrency/workerpool/pool #1")
+	assert.Contains(t, status, "goroutines 7 (peak 12)  threads 5  ▃█▅")
+
+	m.setStatus(lifecycle.Status{State: lifecycle.Cancelled, Run: 1, Started: time.Now(), Ended: time.Now()})
+	assert.Contains(t, m.statusView(), "■ cancelled")
+	assert.Equal(t, 12, m.peak, "結束後保留最後的取樣")
+}
+
+func TestModelView(t *testing.T) {
+	m := newModel(examples())
+	m.width, m.height = 60, 12
+	m.key(keyDown)
+	for i := 0; i < 20; i++ {
+		m.addLine("line " + string(rune('a'+i)))
+	}
+
+	lines := m.view()
+	require.Len(t, lines, 12)
+	assert.Contains(t, lines[0], "3 examples")
+	assert.Contains(t, lines[2], "> basic     goroutine/use-select")
+	assert.Contains(t, lines[len(lines)-2], "line t", "輸出面板顯示最後幾行")
+	assert.Contains(t, lines[len(lines)-1], "q quit")
+	for _, line := range lines {
+		plain := strings.NewReplacer(styleReverse, "", styleBold, "", styleReset, "").Replace(line)
+		assert.LessOrEqual(t, width(plain), 60, plain)
+	}
+}
+
+func TestFit(t *testing.T) {
+	assert.Equal(t, "ab  ", fit("ab", 4))
+	assert.Equal(t, "示 ", fit("示範", 3), "寬字元不會被切一半")
+	assert.Equal(t, "▁▄█", sparkline([]int{0, 4, 8}))
+}
+
+func TestLineWriter(t *testing.T) {
+	var got []string
+	w := &lineWriter{emit: func(s string) { got = append(got, s) }}
+	w.Write([]byte("a\nb"))
+	w.Write([]byte("c\nd"))
+	w.Flush()
+	assert.Equal(t, []string{"a", "bc", "d"}, got)
+}
+
+func TestRunExample(t *testing.T) {
+	root := t.TempDir()
+	files := map[string]string{
+		"m/go.mod": "module m\n\ngo 1.18\n",
+		"m/p/p_test.go": `package p
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSleepers(t *testing.T) {
+	for i := 0; i < 20; i++ {
+		go func() { time.Sleep(time.Minute) }()
+	}
+	time.Sleep(300 * time.Millisecond)
+	t.Log("slept")
+}
+
+func TestForever(t *testing.T) { select {} }
+`,
+	}
+	for name, content := range files {
+		path := filepath.Join(root, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+	}
+	ex, err := golearn.Discover(root)
+	require.NoError(t, err)
+
+	a := &app{events: make(chan any, 4096), cfg: golearn.RunConfig{Verbose: true}, interval: 50 * time.Millisecond, binDir: t.TempDir()}
+	sleepers, err := golearn.Match(ex, "p/sleepers")
+	require.NoError(t, err)
+	require.NoError(t, a.runExample(context.Background(), sleepers[0]))
+
+	var out []string
+	peak := 0
+	for len(a.events) > 0 {
+		switch ev := (<-a.events).(type) {
+		case lineEvent:
+			out = append(out, string(ev))
+		case sampleEvent:
+			peak = max(peak, ev.Goroutines)
+		}
+	}
+	assert.GreaterOrEqual(t, peak, 20, "子行程的 goroutine 數量")
+	joined := strings.Join(out, "\n")
+	assert.Contains(t, joined, "slept")
+	assert.Contains(t, joined, "--- PASS: TestSleepers")
+	assert.NotContains(t, joined, "SCHED", "trace 不會出現在輸出面板")
+
+	// 取消：測試執行檔收到 interrupt 後結束
+	forever, err := golearn.Match(ex, "p/forever")
+	require.NoError(t, err)
+	r := lifecycle.NewRunner()
+	started := time.Now()
+	r.Start(context.Background(), func(ctx context.Context) error { return a.runExample(ctx, forever[0]) })
+	go func() {
+		for ev := range a.events {
+			if s, ok := ev.(lineEvent); ok && strings.Contains(string(s), "=== RUN") {
+				r.Cancel()
+			}
+		}
+	}()
+	st := r.Wait()
+	assert.Equal(t, lifecycle.Cancelled, st.State)
+	assert.Less(t, time.Since(started), 30*time.Second)
+}
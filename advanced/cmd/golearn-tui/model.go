@@ -0,0 +1,376 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+	"unicode"
+
+	"advanced/diag"
+	"advanced/golearn"
+	"advanced/lifecycle"
+)
+
+/*
+model 保存畫面需要的所有狀態，只在主迴圈的 goroutine 中修改，因此不需要鎖：
+  - 鍵盤、子行程輸出、schedtrace 取樣、Runner 狀態都轉成事件送到主迴圈
+  - key 回傳要執行的動作，實際的啟動 / 取消由 main.go 處理
+  - view 依目前大小組出整個畫面，每一行都截斷在終端寬度內
+
+畫面配置：
+
+	golearn-tui  52 examples                         self: 6 goroutines, 3.2 MiB
+	  basic     goroutine/use-select        示範 select 的多路復用
+	> basic     goroutine/wait-group        ...
+	● running basic:goroutine/use-select #2  1.2s  goroutines 12 (peak 40)  threads 5  ▁▂▅▇█
+	=== RUN   TestGoroutineUseSelect
+	...
+	↑/↓ move  enter run  c cancel  r re-run  / filter  q quit
+*/
+
+const (
+	maxOutput  = 1000 // 輸出面板保留的行數
+	maxHistory = 40   // goroutine 數量走勢圖的取樣數
+)
+
+type action int
+
+const (
+	actNone action = iota
+	actRun
+	actRerun
+	actCancel
+	actQuit
+)
+
+type model struct {
+	examples []golearn.Example
+	visible  []int // 符合 filter 的 examples 索引
+	cursor   int   // visible 中的位置
+	top      int   // 清單捲動位置
+
+	filter    string
+	filtering bool
+
+	current   *golearn.Example // 最近一次執行的範例
+	status    lifecycle.Status
+	sample    diag.Sample
+	hasSample bool
+	peak      int
+	history   []int
+	output    []string
+	self      diag.Self
+
+	width, height int
+}
+
+func newModel(examples []golearn.Example) *model {
+	m := &model{examples: examples, width: 80, height: 24}
+	m.applyFilter()
+	return m
+}
+
+func (m *model) selected() (golearn.Example, bool) {
+	if len(m.visible) == 0 {
+		return golearn.Example{}, false
+	}
+	return m.examples[m.visible[m.cursor]], true
+}
+
+func (m *model) applyFilter() {
+	m.visible = m.visible[:0]
+	q := strings.ToLower(m.filter)
+	for i, e := range m.examples {
+		if q == "" || strings.Contains(strings.ToLower(e.Module+":"+e.ID()), q) {
+			m.visible = append(m.visible, i)
+		}
+	}
+	m.cursor, m.top = 0, 0
+}
+
+func (m *model) move(delta int) {
+	m.cursor += delta
+	if m.cursor >= len(m.visible) {
+		m.cursor = len(m.visible) - 1
+	}
+	if m.cursor < 0 {
+		m.cursor = 0
+	}
+}
+
+// key 處理一個按鍵，回傳 main.go 要執行的動作
+func (m *model) key(k key) action {
+	if m.filtering {
+		switch k {
+		case keyEnter, keyEsc:
+			m.filtering = false
+		case keyBackspace:
+			if r := []rune(m.filter); len(r) > 0 {
+				m.filter = string(r[:len(r)-1])
+				m.applyFilter()
+			}
+		case keyCtrlC:
+			return actQuit
+		default:
+			if r := []rune(string(k)); len(r) == 1 && unicode.IsPrint(r[0]) {
+				m.filter += string(k)
+				m.applyFilter()
+			}
+		}
+		return actNone
+	}
+
+	switch k {
+	case keyUp, "k":
+		m.move(-1)
+	case keyDown, "j":
+		m.move(1)
+	case keyPgUp:
+		m.move(-m.listHeight())
+	case keyPgDown:
+		m.move(m.listHeight())
+	case "g":
+		m.move(-len(m.visible))
+	case "G":
+		m.move(len(m.visible))
+	case "/":
+		m.filtering = true
+	case keyEsc:
+		if m.filter != "" {
+			m.filter = ""
+			m.applyFilter()
+		}
+	case keyEnter:
+		if e, ok := m.selected(); ok {
+			m.current = &e
+			return actRun
+		}
+	case "r":
+		if m.current != nil {
+			return actRerun
+		}
+	case "c":
+		return actCancel
+	case "q", keyCtrlC:
+		return actQuit
+	}
+	return actNone
+}
+
+// setStatus 在新的一次執行開始時清掉上一次的輸出與取樣
+func (m *model) setStatus(st lifecycle.Status) {
+	if st.State == lifecycle.Running && st.Run != m.status.Run {
+		m.output = m.output[:0]
+		m.history = m.history[:0]
+		m.sample, m.hasSample, m.peak = diag.Sample{}, false, 0
+	}
+	m.status = st
+}
+
+func (m *model) addSample(s diag.Sample) {
+	m.sample, m.hasSample = s, true
+	if s.Goroutines > m.peak {
+		m.peak = s.Goroutines
+	}
+	m.history = append(m.history, s.Goroutines)
+	if len(m.history) > maxHistory {
+		m.history = m.history[len(m.history)-maxHistory:]
+	}
+}
+
+func (m *model) addLine(line string) {
+	m.output = append(m.output, strings.ReplaceAll(strings.TrimRight(line, "\r"), "\t", "    "))
+	if len(m.output) > maxOutput {
+		m.output = append(m.output[:0], m.output[len(m.output)-maxOutput:]...)
+	}
+}
+
+// listHeight 清單與輸出面板大約對分標題、狀態、說明以外的空間
+func (m *model) listHeight() int {
+	h := (m.height - 3) / 2
+	if h < 3 {
+		h = 3
+	}
+	return h
+}
+
+func (m *model) outputHeight() int {
+	h := m.height - 3 - m.listHeight()
+	if h < 1 {
+		h = 1
+	}
+	return h
+}
+
+const (
+	styleReverse = "\x1b[7m"
+	styleBold    = "\x1b[1m"
+	styleReset   = "\x1b[0m"
+)
+
+// view 回傳整個畫面的每一行（不含換行）
+func (m *model) view() []string {
+	lines := make([]string, 0, m.height)
+
+	header := fmt.Sprintf(" golearn-tui  %d examples", len(m.examples))
+	if m.filter != "" || m.filtering {
+		header += fmt.Sprintf("  filter: %s", m.filter)
+		if m.filtering {
+			header += "_"
+		}
+	}
+	right := fmt.Sprintf("self: %d goroutines, %.1f MiB ", m.self.Goroutines, float64(m.self.HeapAlloc)/(1<<20))
+	lines = append(lines, styleReverse+fit(spread(header, right, m.width), m.width)+styleReset)
+
+	lines = append(lines, m.listView()...)
+	lines = append(lines, styleReverse+fit(m.statusView(), m.width)+styleReset)
+
+	out := m.output
+	if h := m.outputHeight(); len(out) > h {
+		out = out[len(out)-h:]
+	}
+	for i := 0; i < m.outputHeight(); i++ {
+		line := ""
+		if i < len(out) {
+			line = out[i]
+		}
+		lines = append(lines, fit(line, m.width))
+	}
+
+	lines = append(lines, fit(" ↑/↓ move  enter run  c cancel  r re-run  / filter  q quit", m.width))
+	return lines
+}
+
+func (m *model) listView() []string {
+	h := m.listHeight()
+	if m.cursor < m.top {
+		m.top = m.cursor
+	}
+	if m.cursor >= m.top+h {
+		m.top = m.cursor - h + 1
+	}
+
+	modW, idW := 0, 0
+	for _, i := range m.visible {
+		modW = max(modW, width(m.examples[i].Module))
+		idW = min(max(idW, width(m.examples[i].ID())), 40)
+	}
+
+	lines := make([]string, 0, h)
+	for row := 0; row < h; row++ {
+		pos := m.top + row
+		if pos >= len(m.visible) {
+			if len(m.visible) == 0 && row == 0 {
+				lines = append(lines, fit("  no matching examples", m.width))
+				continue
+			}
+			lines = append(lines, "")
+			continue
+		}
+		e := m.examples[m.visible[pos]]
+		line := fmt.Sprintf("  %s  %s  %s", pad(e.Module, modW), pad(e.ID(), idW), e.Doc)
+		if pos == m.cursor {
+			lines = append(lines, styleBold+"> "+fit(line[2:], m.width-2)+styleReset)
+			continue
+		}
+		lines = append(lines, fit(line, m.width))
+	}
+	return lines
+}
+
+func (m *model) statusView() string {
+	st := m.status
+	if m.current == nil || st.State == lifecycle.Idle {
+		return " idle, press enter to run the selected example"
+	}
+	var icon string
+	switch st.State {
+	case lifecycle.Running:
+		icon = "● running"
+	case lifecycle.Succeeded:
+		icon = "✓ passed"
+	case lifecycle.Failed:
+		icon = "✗ failed"
+	case lifecycle.Cancelled:
+		icon = "■ cancelled"
+	}
+	s := fmt.Sprintf(" %s %s:%s #%d  %s", icon, m.current.Module, m.current.ID(), st.Run, st.Elapsed().Round(100*time.Millisecond))
+	if st.State == lifecycle.Failed && st.Err != nil {
+		s += "  (" + st.Err.Error() + ")"
+	}
+	if m.hasSample {
+		s += fmt.Sprintf("  goroutines %d (peak %d)  threads %d  %s", m.sample.Goroutines, m.peak, m.sample.Threads, sparkline(m.history))
+	}
+	return s
+}
+
+var sparks = []rune("▁▂▃▄▅▆▇█")
+
+// sparkline 以最大值為基準畫出走勢
+func sparkline(values []int) string {
+	hi := 0
+	for _, v := range values {
+		hi = max(hi, v)
+	}
+	var b strings.Builder
+	for _, v := range values {
+		i := 0
+		if hi > 0 {
+			i = v * (len(sparks) - 1) / hi
+		}
+		b.WriteRune(sparks[i])
+	}
+	return b.String()
+}
+
+// width 回傳字串在終端中佔的欄數，中日韓文字與全形符號算兩欄
+func width(s string) int {
+	n := 0
+	for _, r := range s {
+		n += runeWidth(r)
+	}
+	return n
+}
+
+func runeWidth(r rune) int {
+	switch {
+	case unicode.Is(unicode.Han, r),
+		r >= 0x3000 && r <= 0x303f, // 中文標點
+		r >= 0xff00 && r <= 0xff60, // 全形符號
+		r >= 0x3040 && r <= 0x30ff, // 假名
+		r >= 0xac00 && r <= 0xd7a3: // 韓文
+		return 2
+	}
+	return 1
+}
+
+// fit 截斷到 w 欄，不足時補空白，讓反白的標題列填滿整行
+func fit(s string, w int) string {
+	var b strings.Builder
+	n := 0
+	for _, r := range s {
+		rw := runeWidth(r)
+		if n+rw > w {
+			break
+		}
+		b.WriteRune(r)
+		n += rw
+	}
+	return b.String() + strings.Repeat(" ", max(w-n, 0))
+}
+
+func pad(s string, w int) string {
+	if width(s) >= w {
+		return fit(s, w)
+	}
+	return s + strings.Repeat(" ", w-width(s))
+}
+
+// spread 把 right 靠右放，空間不夠時只保留 left
+func spread(left, right string, w int) string {
+	gap := w - width(left) - width(right)
+	if gap < 1 {
+		return left
+	}
+	return left + strings.Repeat(" ", gap) + right
+}
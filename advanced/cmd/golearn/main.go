@@ -0,0 +1,112 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"advanced/golearn"
+	"advanced/osx/signals"
+)
+
+const usage = `golearn 列出並執行 repo 中各 module 的範例
+
+usage:
+	golearn list [-module basic] [filter]
+	golearn run [-n iterations] [-procs n] [-race] [-q] <example>
+
+example 可以是完整 ID（goroutine/use-select）、套件（select）或片段（goroutine/select），
+加上 "<module>:" 前綴可限定 module，例如 basic:timers。
+`
+
+func main() {
+	if len(os.Args) < 2 {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+	var err error
+	switch os.Args[1] {
+	case "list":
+		err = list(os.Args[2:])
+	case "run":
+		err = run(os.Args[2:])
+	case "-h", "-help", "--help", "help":
+		fmt.Print(usage)
+		return
+	default:
+		err = fmt.Errorf("unknown command %q", os.Args[1])
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "golearn:", err)
+		os.Exit(1)
+	}
+}
+
+func list(args []string) error {
+	fs := flag.NewFlagSet("list", flag.ExitOnError)
+	root := fs.String("root", golearn.RepoRoot(), "repository root")
+	module := fs.String("module", "", "only list examples in this module")
+	fs.Parse(args)
+	filter := fs.Arg(0)
+
+	examples, err := golearn.Discover(*root)
+	if err != nil {
+		return err
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "MODULE\tEXAMPLE\tDESCRIPTION")
+	n := 0
+	for _, e := range examples {
+		if *module != "" && e.Module != *module {
+			continue
+		}
+		if filter != "" && !strings.Contains(e.ID(), filter) {
+			continue
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\n", e.Module, e.ID(), e.Doc)
+		n++
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "%d examples\n", n)
+	return nil
+}
+
+func run(args []string) error {
+	fs := flag.NewFlagSet("run", flag.ExitOnError)
+	root := fs.String("root", golearn.RepoRoot(), "repository root")
+	n := fs.Int("n", 1, "iterations (go test -count)")
+	procs := fs.Int("procs", 0, "GOMAXPROCS while running (go test -cpu), 0 = default")
+	race := fs.Bool("race", false, "enable the race detector")
+	quiet := fs.Bool("q", false, "do not pass -v to go test")
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		return errors.New("run needs exactly one example")
+	}
+
+	examples, err := golearn.Discover(*root)
+	if err != nil {
+		return err
+	}
+	matched, err := golearn.Match(examples, fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	for _, e := range matched {
+		fmt.Fprintf(os.Stderr, "==> %s:%s (%s)\n", e.Module, e.ID(), e.Func)
+	}
+
+	ctx, stop := signals.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	return golearn.Run(ctx, matched, golearn.RunConfig{
+		Iterations: *n,
+		GOMAXPROCS: *procs,
+		Race:       *race,
+		Verbose:    !*quiet,
+	})
+}
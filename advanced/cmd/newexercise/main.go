@@ -0,0 +1,97 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"advanced/exercise"
+)
+
+const usage = `newexercise 產生一致格式的練習目錄（skeleton、測試、以 build tag 隔開的參考解答）
+
+usage:
+	newexercise [-dir exercises] [-title 說明] [-import path] -func 'Reverse(s string) string' [-func ...] <topic>
+	newexercise -check <dir>
+
+完成參考解答與測試案例後，以 -check 確認 skeleton 的測試失敗、參考解答的測試通過。
+`
+
+// multiFlag 讓 -func、-import 可以重複指定
+type multiFlag []string
+
+func (m *multiFlag) String() string     { return strings.Join(*m, ", ") }
+func (m *multiFlag) Set(v string) error { *m = append(*m, v); return nil }
+
+func main() {
+	flag.Usage = func() { fmt.Fprint(os.Stderr, usage) }
+	var funcs, imports multiFlag
+	dir := flag.String("dir", "exercises", "parent directory of the new exercise")
+	title := flag.String("title", "", "one-line description")
+	check := flag.String("check", "", "verify an existing exercise directory")
+	flag.Var(&funcs, "func", "function signature to implement (repeatable)")
+	flag.Var(&imports, "import", "import path for packages used in signatures (repeatable)")
+	flag.Parse()
+
+	var err error
+	if *check != "" {
+		err = exercise.Check(context.Background(), *check)
+		if err == nil {
+			fmt.Println("ok:", *check)
+		}
+	} else {
+		err = scaffold(*dir, *title, flag.Arg(0), funcs, imports)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "newexercise:", err)
+		os.Exit(1)
+	}
+}
+
+func scaffold(dir, title, topic string, sigs, imports []string) error {
+	if topic == "" || len(sigs) == 0 {
+		flag.Usage()
+		return errors.New("topic and at least one -func are required")
+	}
+	spec := exercise.Spec{Topic: topic, Title: title, Imports: imports, Path: modulePath(filepath.Join(dir, topic))}
+	for _, sig := range sigs {
+		f, err := exercise.ParseFunc(sig)
+		if err != nil {
+			return err
+		}
+		spec.Funcs = append(spec.Funcs, f)
+	}
+	paths, err := exercise.Scaffold(dir, spec)
+	if err != nil {
+		return err
+	}
+	for _, p := range paths {
+		fmt.Println("created", p)
+	}
+	target := filepath.Join(dir, topic)
+	fmt.Printf("\nnext:\n  1. 填寫 doc.go 的說明與 %s_solution.go 的參考解答\n  2. 在 %s_test.go 加入測試案例\n  3. newexercise -check %s\n",
+		spec.Package(), spec.Package(), target)
+	return nil
+}
+
+// modulePath 回傳 dir 相對於所屬 module 根目錄的路徑，用在產生的說明中
+func modulePath(dir string) string {
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return filepath.ToSlash(dir)
+	}
+	for d := filepath.Dir(abs); ; d = filepath.Dir(d) {
+		if _, err := os.Stat(filepath.Join(d, "go.mod")); err == nil {
+			if rel, err := filepath.Rel(d, abs); err == nil {
+				return filepath.ToSlash(rel)
+			}
+		}
+		if d == filepath.Dir(d) {
+			return filepath.ToSlash(dir)
+		}
+	}
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"advanced/osx/signals"
+	"advanced/quiz"
+)
+
+const usage = `quiz 預測小型並行程式的輸出或行為，答案由實際執行評分
+
+usage:
+	quiz list
+	quiz [-id loop-closure-go121] [-show]
+
+不指定 -id 時依序作答所有題目；-show 在評分後顯示每個 seed 的 stdout / stderr。
+`
+
+func main() {
+	flag.Usage = func() { fmt.Fprint(os.Stderr, usage) }
+	id := flag.String("id", "", "only play this scenario")
+	show := flag.Bool("show", false, "show the output of every run after grading")
+	flag.Parse()
+
+	bank, err := quiz.Load()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "quiz:", err)
+		os.Exit(1)
+	}
+	if flag.Arg(0) == "list" {
+		for _, s := range bank {
+			fmt.Printf("%-36s %s\n", s.ID, s.Title)
+		}
+		return
+	}
+
+	ctx, stop := signals.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	if err := play(ctx, bank, *id, *show, os.Stdin, os.Stdout); err != nil {
+		fmt.Fprintln(os.Stderr, "quiz:", err)
+		os.Exit(1)
+	}
+}
+
+func play(ctx context.Context, bank quiz.Bank, id string, show bool, in io.Reader, out io.Writer) error {
+	e, err := quiz.NewEngine()
+	if err != nil {
+		return err
+	}
+	defer e.Close()
+
+	var scenarios []*quiz.Scenario
+	for _, s := range bank {
+		if id == "" || s.ID == id {
+			scenarios = append(scenarios, s)
+		}
+	}
+	if len(scenarios) == 0 {
+		return fmt.Errorf("%w: %s", quiz.ErrUnknownScenario, id)
+	}
+
+	r := bufio.NewReader(in)
+	score := 0
+	for i, s := range scenarios {
+		fmt.Fprintf(out, "\n[%d/%d] %s (go %s, GOMAXPROCS=%d", i+1, len(scenarios), s.Title, s.Go, s.Procs)
+		if s.Race {
+			fmt.Fprint(out, ", -race")
+		}
+		fmt.Fprintf(out, ")\n\n%s\n%s\n", s.Source, s.Question)
+		for _, c := range s.Choices {
+			fmt.Fprintf(out, "  %s) %s\n", c.ID, c.Text)
+		}
+		fmt.Fprint(out, "> ")
+		answer, err := r.ReadString('\n')
+		if err != nil && answer == "" {
+			return err
+		}
+
+		v, err := e.Grade(ctx, s, answer)
+		if err != nil {
+			return err
+		}
+		expected := v.Expected
+		if c, ok := s.Choice(v.Expected); ok {
+			expected = c.ID + ") " + c.Text
+		}
+		if v.Correct {
+			score++
+			fmt.Fprintf(out, "✓ 正確：%s\n", expected)
+		} else {
+			fmt.Fprintf(out, "✗ 答案：%s\n", expected)
+		}
+		fmt.Fprint(out, strings.TrimRight(s.Explain, "\n")+"\n")
+		if show {
+			for _, res := range v.Results {
+				fmt.Fprintf(out, "--- seed %d: %s (exit %d, %s)\n%s%s", res.Seed, res.Outcome, res.ExitCode, res.Duration.Round(1e6), res.Stdout, res.Stderr)
+			}
+		}
+	}
+	fmt.Fprintf(out, "\n得分 %d/%d\n", score, len(scenarios))
+	return nil
+}
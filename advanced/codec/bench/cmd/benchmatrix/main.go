@@ -0,0 +1,33 @@
+package main
+
+import (
+	"flag"
+	"log"
+	"os"
+
+	"advanced/codec/bench"
+)
+
+// go run ./codec/bench/cmd/benchmatrix -o codec/bench/results.csv
+func main() {
+	out := flag.String("o", "", "output CSV file (default stdout)")
+	codecs := flag.String("codecs", "", "comma separated codec names (default all)")
+	flag.Parse()
+
+	results, err := bench.Run(bench.Select(*codecs))
+	if err != nil {
+		log.Fatal(err)
+	}
+	w := os.Stdout
+	if *out != "" {
+		f, err := os.Create(*out)
+		if err != nil {
+			log.Fatal(err)
+		}
+		defer f.Close()
+		w = f
+	}
+	if err := bench.WriteCSV(w, results); err != nil {
+		log.Fatal(err)
+	}
+}
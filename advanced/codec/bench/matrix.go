@@ -0,0 +1,73 @@
+package bench
+
+import (
+	"encoding/csv"
+	"io"
+	"strconv"
+	"testing"
+)
+
+// Payloads 是矩陣中的 payload 大小
+var Payloads = []struct {
+	Name string
+	Size int
+}{
+	{"small", 64},
+	{"large", 64 << 10},
+}
+
+type Result struct {
+	Codec       string
+	Payload     string
+	Size        int // 編碼後 bytes
+	NsPerOp     int64
+	AllocsPerOp int64
+	BytesPerOp  int64
+}
+
+// Run 以 testing.Benchmark 對每個 codec × payload 量測一次 encode + decode
+func Run(codecs []Codec) ([]Result, error) {
+	var results []Result
+	for _, p := range Payloads {
+		m := Sample(p.Size)
+		for _, c := range codecs {
+			encoded, err := c.Marshal(m)
+			if err != nil {
+				return nil, err
+			}
+			r := testing.Benchmark(func(b *testing.B) {
+				b.ReportAllocs()
+				for i := 0; i < b.N; i++ {
+					out, _ := c.Marshal(m)
+					var got Message
+					_ = c.Unmarshal(out, &got)
+				}
+			})
+			results = append(results, Result{
+				Codec:       c.Name,
+				Payload:     p.Name,
+				Size:        len(encoded),
+				NsPerOp:     r.NsPerOp(),
+				AllocsPerOp: r.AllocsPerOp(),
+				BytesPerOp:  r.AllocedBytesPerOp(),
+			})
+		}
+	}
+	return results, nil
+}
+
+func WriteCSV(w io.Writer, results []Result) error {
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"codec", "payload", "size_bytes", "ns_per_op", "allocs_per_op", "bytes_per_op"})
+	for _, r := range results {
+		cw.Write([]string{
+			r.Codec, r.Payload,
+			strconv.Itoa(r.Size),
+			strconv.FormatInt(r.NsPerOp, 10),
+			strconv.FormatInt(r.AllocsPerOp, 10),
+			strconv.FormatInt(r.BytesPerOp, 10),
+		})
+	}
+	cw.Flush()
+	return cw.Error()
+}
@@ -0,0 +1,223 @@
+package bench
+
+import (
+	"bytes"
+	"encoding/gob"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/vmihailenco/msgpack/v5"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// Message 是比較用的代表性訊息：數字、字串、map、slice、binary payload 都有
+type Message struct {
+	ID        uint64            `json:"id" msgpack:"id"`
+	Topic     string            `json:"topic" msgpack:"topic"`
+	Timestamp int64             `json:"ts" msgpack:"ts"`
+	Headers   map[string]string `json:"headers" msgpack:"headers"`
+	Tags      []string          `json:"tags" msgpack:"tags"`
+	Payload   []byte            `json:"payload" msgpack:"payload"`
+}
+
+// Sample 產生 payload 大小為 size bytes 的訊息
+func Sample(size int) *Message {
+	return &Message{
+		ID:        987654321,
+		Topic:     "orders.created",
+		Timestamp: 1650000000123456789,
+		Headers:   map[string]string{"trace-id": "4bf92f3577b34da6", "content-type": "application/json"},
+		Tags:      []string{"eu-west", "priority", "v2"},
+		Payload:   bytes.Repeat([]byte("0123456789abcdef"), size/16+1)[:size],
+	}
+}
+
+type Codec struct {
+	Name      string
+	Marshal   func(*Message) ([]byte, error)
+	Unmarshal func([]byte, *Message) error
+}
+
+/*
+四種格式：
+  - json：文字格式、可讀，[]byte 會轉 base64，體積膨脹約 1/3
+  - gob：Go 專用，每個 Encoder 第一次送某型別時會先送型別描述；
+    這裡每個訊息都用新的 Encoder，模擬「一次一個訊息」的 RPC / 儲存情境
+  - protobuf：用 protowire 手寫編碼（等同 protoc-gen-go 產生的 wire format，欄位編號見下方）
+  - msgpack：binary 版的 JSON，有欄位名稱但不需 schema
+*/
+var Codecs = []Codec{
+	{"json", func(m *Message) ([]byte, error) { return json.Marshal(m) }, func(b []byte, m *Message) error { return json.Unmarshal(b, m) }},
+	{"gob", marshalGob, unmarshalGob},
+	{"protobuf", func(m *Message) ([]byte, error) { return MarshalProto(nil, m), nil }, UnmarshalProto},
+	{"msgpack", func(m *Message) ([]byte, error) { return msgpack.Marshal(m) }, func(b []byte, m *Message) error { return msgpack.Unmarshal(b, m) }},
+}
+
+func marshalGob(m *Message) ([]byte, error) {
+	var buf bytes.Buffer
+	err := gob.NewEncoder(&buf).Encode(m)
+	return buf.Bytes(), err
+}
+
+func unmarshalGob(b []byte, m *Message) error {
+	return gob.NewDecoder(bytes.NewReader(b)).Decode(m)
+}
+
+/*
+對應的 .proto：
+	message Message {
+	  uint64 id = 1;
+	  string topic = 2;
+	  int64 timestamp = 3;
+	  map<string, string> headers = 4;
+	  repeated string tags = 5;
+	  bytes payload = 6;
+	}
+map 在 wire format 上等同 repeated 的 entry message { key = 1; value = 2; }
+*/
+
+// MarshalProto 以 protobuf wire format 編碼；map 依 key 排序，輸出結果固定
+func MarshalProto(b []byte, m *Message) []byte {
+	if m.ID != 0 {
+		b = protowire.AppendTag(b, 1, protowire.VarintType)
+		b = protowire.AppendVarint(b, m.ID)
+	}
+	if m.Topic != "" {
+		b = protowire.AppendTag(b, 2, protowire.BytesType)
+		b = protowire.AppendString(b, m.Topic)
+	}
+	if m.Timestamp != 0 {
+		b = protowire.AppendTag(b, 3, protowire.VarintType)
+		b = protowire.AppendVarint(b, uint64(m.Timestamp))
+	}
+	keys := make([]string, 0, len(m.Headers))
+	for k := range m.Headers {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		var entry []byte
+		entry = protowire.AppendTag(entry, 1, protowire.BytesType)
+		entry = protowire.AppendString(entry, k)
+		entry = protowire.AppendTag(entry, 2, protowire.BytesType)
+		entry = protowire.AppendString(entry, m.Headers[k])
+		b = protowire.AppendTag(b, 4, protowire.BytesType)
+		b = protowire.AppendBytes(b, entry)
+	}
+	for _, t := range m.Tags {
+		b = protowire.AppendTag(b, 5, protowire.BytesType)
+		b = protowire.AppendString(b, t)
+	}
+	if len(m.Payload) > 0 {
+		b = protowire.AppendTag(b, 6, protowire.BytesType)
+		b = protowire.AppendBytes(b, m.Payload)
+	}
+	return b
+}
+
+// UnmarshalProto 解碼 protobuf wire format，不認得的欄位直接略過（向前相容）
+func UnmarshalProto(b []byte, m *Message) error {
+	*m = Message{}
+	for len(b) > 0 {
+		num, typ, n := protowire.ConsumeTag(b)
+		if n < 0 {
+			return protowire.ParseError(n)
+		}
+		b = b[n:]
+		switch {
+		case num == 1 && typ == protowire.VarintType:
+			v, n := protowire.ConsumeVarint(b)
+			if n < 0 {
+				return protowire.ParseError(n)
+			}
+			m.ID, b = v, b[n:]
+		case num == 3 && typ == protowire.VarintType:
+			v, n := protowire.ConsumeVarint(b)
+			if n < 0 {
+				return protowire.ParseError(n)
+			}
+			m.Timestamp, b = int64(v), b[n:]
+		case num == 2 || num == 4 || num == 5 || num == 6:
+			if typ != protowire.BytesType {
+				return fmt.Errorf("protobuf: field %d has wire type %d", num, typ)
+			}
+			v, n := protowire.ConsumeBytes(b)
+			if n < 0 {
+				return protowire.ParseError(n)
+			}
+			b = b[n:]
+			switch num {
+			case 2:
+				m.Topic = string(v)
+			case 4:
+				k, val, err := consumeMapEntry(v)
+				if err != nil {
+					return err
+				}
+				if m.Headers == nil {
+					m.Headers = make(map[string]string)
+				}
+				m.Headers[k] = val
+			case 5:
+				m.Tags = append(m.Tags, string(v))
+			case 6:
+				m.Payload = append([]byte(nil), v...)
+			}
+		default:
+			n := protowire.ConsumeFieldValue(num, typ, b)
+			if n < 0 {
+				return protowire.ParseError(n)
+			}
+			b = b[n:]
+		}
+	}
+	return nil
+}
+
+func consumeMapEntry(b []byte) (key, value string, err error) {
+	for len(b) > 0 {
+		num, typ, n := protowire.ConsumeTag(b)
+		if n < 0 {
+			return "", "", protowire.ParseError(n)
+		}
+		b = b[n:]
+		if typ != protowire.BytesType {
+			n = protowire.ConsumeFieldValue(num, typ, b)
+			if n < 0 {
+				return "", "", protowire.ParseError(n)
+			}
+			b = b[n:]
+			continue
+		}
+		v, n := protowire.ConsumeBytes(b)
+		if n < 0 {
+			return "", "", protowire.ParseError(n)
+		}
+		b = b[n:]
+		switch num {
+		case 1:
+			key = string(v)
+		case 2:
+			value = string(v)
+		}
+	}
+	return key, value, nil
+}
+
+// Select 依名稱（逗號分隔）挑選 codec，空字串表示全部
+func Select(names string) []Codec {
+	if names == "" {
+		return Codecs
+	}
+	var out []Codec
+	for _, c := range Codecs {
+		for _, n := range strings.Split(names, ",") {
+			if c.Name == strings.TrimSpace(n) {
+				out = append(out, c)
+			}
+		}
+	}
+	return out
+}
@@ -0,0 +1,214 @@
+package binpack
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+)
+
+/*
+encoding/binary 的兩種用法：
+ 1. 固定長度的 struct：binary.Write / binary.Read 直接依欄位順序讀寫，
+    欄位只能是固定大小的型別（int32、[4]byte…，不能是 int、string、slice）
+ 2. varint：數字越小用越少 bytes（1~10 bytes），適合 ID、長度這類通常很小的值
+
+訊息格式：
+	+---------+---------+-------+--------+----------------------+
+	| magic   | version | flags | length | body (length bytes)  |
+	| 2 bytes | 1 byte  | 1 byte| 4 bytes|                      |
+	+---------+---------+-------+--------+----------------------+
+	flags bit0 = 1 表示 length 與 body 中的固定長度欄位為 little endian
+
+body:
+	v1: id(uvarint) kind(1 byte) payload_len(uvarint) payload
+	v2: v1 + timestamp(varint, unix nano)
+解碼端同時支援 v1 與 v2，新欄位只加在尾端，舊資料缺少的欄位為零值。
+*/
+
+var (
+	ErrBadMagic           = errors.New("binpack: bad magic")
+	ErrUnsupportedVersion = errors.New("binpack: unsupported version")
+	ErrTooLarge           = errors.New("binpack: message too large")
+)
+
+var Magic = [2]byte{'G', 'L'}
+
+const (
+	Version1       = 1
+	Version2       = 2
+	CurrentVersion = Version2
+
+	FlagLittleEndian = 1 << 0
+
+	HeaderSize = 8
+	MaxBody    = 16 << 20
+)
+
+type Header struct {
+	Magic   [2]byte
+	Version uint8
+	Flags   uint8
+	Length  uint32
+}
+
+func (h Header) Order() binary.ByteOrder {
+	if h.Flags&FlagLittleEndian != 0 {
+		return binary.LittleEndian
+	}
+	return binary.BigEndian
+}
+
+type Message struct {
+	ID        uint64
+	Kind      uint8
+	Payload   []byte
+	Timestamp int64 // v2 才有
+}
+
+// WriteFixed / ReadFixed 是 binary.Write / binary.Read 的薄包裝，示範固定格式 struct 的讀寫
+func WriteFixed(w io.Writer, order binary.ByteOrder, v interface{}) error {
+	return binary.Write(w, order, v)
+}
+
+func ReadFixed(r io.Reader, order binary.ByteOrder, v interface{}) error {
+	return binary.Read(r, order, v)
+}
+
+// Encoder 決定輸出的版本與 byte order
+type Encoder struct {
+	Order   binary.ByteOrder
+	Version uint8
+}
+
+func NewEncoder(order binary.ByteOrder) *Encoder {
+	return &Encoder{Order: order, Version: CurrentVersion}
+}
+
+// Append 把訊息編碼後加到 dst 後面
+func (e *Encoder) Append(dst []byte, m Message) ([]byte, error) {
+	if e.Version != Version1 && e.Version != Version2 {
+		return nil, fmt.Errorf("%w: %d", ErrUnsupportedVersion, e.Version)
+	}
+	var body []byte
+	body = binary.AppendUvarint(body, m.ID)
+	body = append(body, m.Kind)
+	body = binary.AppendUvarint(body, uint64(len(m.Payload)))
+	body = append(body, m.Payload...)
+	if e.Version >= Version2 {
+		body = binary.AppendVarint(body, m.Timestamp)
+	}
+	if len(body) > MaxBody {
+		return nil, ErrTooLarge
+	}
+
+	var flags uint8
+	if e.Order == binary.LittleEndian {
+		flags |= FlagLittleEndian
+	}
+	dst = append(dst, Magic[0], Magic[1], e.Version, flags)
+	var length [4]byte
+	e.Order.PutUint32(length[:], uint32(len(body)))
+	dst = append(dst, length[:]...)
+	return append(dst, body...), nil
+}
+
+func (e *Encoder) Encode(w io.Writer, m Message) error {
+	b, err := e.Append(nil, m)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(b)
+	return err
+}
+
+// ParseHeader 解析固定 8 bytes 的 header
+func ParseHeader(b []byte) (Header, error) {
+	var h Header
+	if len(b) < HeaderSize {
+		return h, io.ErrUnexpectedEOF
+	}
+	copy(h.Magic[:], b[:2])
+	if h.Magic != Magic {
+		return h, ErrBadMagic
+	}
+	h.Version = b[2]
+	h.Flags = b[3]
+	if h.Version != Version1 && h.Version != Version2 {
+		return h, fmt.Errorf("%w: %d", ErrUnsupportedVersion, h.Version)
+	}
+	h.Length = h.Order().Uint32(b[4:8])
+	if h.Length > MaxBody {
+		return h, ErrTooLarge
+	}
+	return h, nil
+}
+
+// Decode 從 b 開頭解析一個訊息，回傳使用的 bytes 數；Payload 會複製一份，不與 b 共用
+func Decode(b []byte) (Message, int, error) {
+	var m Message
+	h, err := ParseHeader(b)
+	if err != nil {
+		return m, 0, err
+	}
+	end := HeaderSize + int(h.Length)
+	if len(b) < end {
+		return m, 0, io.ErrUnexpectedEOF
+	}
+	if err := decodeBody(b[HeaderSize:end], h.Version, &m); err != nil {
+		return m, 0, err
+	}
+	return m, end, nil
+}
+
+func decodeBody(body []byte, version uint8, m *Message) error {
+	r := bytes.NewReader(body)
+	var err error
+	if m.ID, err = binary.ReadUvarint(r); err != nil {
+		return fmt.Errorf("binpack: id: %w", err)
+	}
+	if m.Kind, err = r.ReadByte(); err != nil {
+		return fmt.Errorf("binpack: kind: %w", io.ErrUnexpectedEOF)
+	}
+	n, err := binary.ReadUvarint(r)
+	if err != nil {
+		return fmt.Errorf("binpack: payload length: %w", err)
+	}
+	if n > uint64(r.Len()) {
+		return fmt.Errorf("binpack: payload: %w", io.ErrUnexpectedEOF)
+	}
+	m.Payload = make([]byte, n)
+	_, _ = io.ReadFull(r, m.Payload)
+	if version >= Version2 {
+		if m.Timestamp, err = binary.ReadVarint(r); err != nil {
+			return fmt.Errorf("binpack: timestamp: %w", err)
+		}
+	}
+	if r.Len() != 0 {
+		return fmt.Errorf("binpack: %d trailing bytes", r.Len())
+	}
+	return nil
+}
+
+// ReadMessage 從 stream 讀取一個訊息
+func ReadMessage(r io.Reader) (Message, error) {
+	var buf [HeaderSize]byte
+	if _, err := io.ReadFull(r, buf[:]); err != nil {
+		return Message{}, err
+	}
+	h, err := ParseHeader(buf[:])
+	if err != nil {
+		return Message{}, err
+	}
+	body := make([]byte, h.Length)
+	if _, err := io.ReadFull(r, body); err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return Message{}, err
+	}
+	var m Message
+	err = decodeBody(body, h.Version, &m)
+	return m, err
+}
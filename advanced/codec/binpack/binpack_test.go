@@ -0,0 +1,207 @@
+package binpack
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/gob"
+	"encoding/json"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// 固定長度的 struct 可以直接用 binary.Write/Read
+type Point struct {
+	X, Y int32
+	Tag  [4]byte
+}
+
+func TestFixedLayoutEndianness(t *testing.T) {
+	p := Point{X: 1, Y: -2, Tag: [4]byte{'a', 'b', 'c', 'd'}}
+
+	var be, le bytes.Buffer
+	assert.NoError(t, WriteFixed(&be, binary.BigEndian, p))
+	assert.NoError(t, WriteFixed(&le, binary.LittleEndian, p))
+	assert.Equal(t, []byte{0, 0, 0, 1, 0xff, 0xff, 0xff, 0xfe, 'a', 'b', 'c', 'd'}, be.Bytes())
+	assert.Equal(t, []byte{1, 0, 0, 0, 0xfe, 0xff, 0xff, 0xff, 'a', 'b', 'c', 'd'}, le.Bytes())
+	assert.Equal(t, 12, binary.Size(p))
+
+	var got Point
+	assert.NoError(t, ReadFixed(&le, binary.LittleEndian, &got))
+	assert.Equal(t, p, got)
+
+	// 用錯 byte order 會讀出錯誤的值
+	var wrong Point
+	assert.NoError(t, ReadFixed(bytes.NewReader(be.Bytes()), binary.LittleEndian, &wrong))
+	assert.Equal(t, int32(1<<24), wrong.X)
+
+	// int、string 這類長度不固定的型別不能用
+	assert.Error(t, WriteFixed(io.Discard, binary.BigEndian, struct{ N int }{1}))
+}
+
+func TestVarintSize(t *testing.T) {
+	for _, c := range []struct {
+		v    uint64
+		size int
+	}{{0, 1}, {127, 1}, {128, 2}, {1 << 20, 3}, {1<<64 - 1, 10}} {
+		assert.Equal(t, c.size, len(binary.AppendUvarint(nil, c.v)), c.v)
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	m := Message{ID: 300, Kind: 7, Payload: []byte("hello"), Timestamp: -1234567890}
+	for _, order := range []binary.ByteOrder{binary.BigEndian, binary.LittleEndian} {
+		b, err := NewEncoder(order).Append(nil, m)
+		assert.NoError(t, err)
+		got, n, err := Decode(b)
+		assert.NoError(t, err)
+		assert.Equal(t, len(b), n)
+		assert.Equal(t, m, got)
+
+		h, _ := ParseHeader(b)
+		assert.Equal(t, order, h.Order())
+	}
+}
+
+// v2 的解碼器可以讀 v1 的資料，缺少的 Timestamp 為零值
+func TestVersionCompatibility(t *testing.T) {
+	v1 := &Encoder{Order: binary.BigEndian, Version: Version1}
+	b, err := v1.Append(nil, Message{ID: 1, Kind: 2, Payload: []byte("x"), Timestamp: 99})
+	assert.NoError(t, err)
+	assert.Equal(t, uint8(Version1), b[2])
+
+	got, _, err := Decode(b)
+	assert.NoError(t, err)
+	assert.Equal(t, Message{ID: 1, Kind: 2, Payload: []byte("x")}, got)
+
+	_, err = (&Encoder{Order: binary.BigEndian, Version: 9}).Append(nil, Message{})
+	assert.ErrorIs(t, err, ErrUnsupportedVersion)
+	b[2] = 9
+	_, _, err = Decode(b)
+	assert.ErrorIs(t, err, ErrUnsupportedVersion)
+}
+
+func TestDecodeErrors(t *testing.T) {
+	good, _ := NewEncoder(binary.BigEndian).Append(nil, Message{ID: 1, Payload: []byte("abc")})
+
+	_, _, err := Decode(good[:5])
+	assert.ErrorIs(t, err, io.ErrUnexpectedEOF)
+	_, _, err = Decode(good[:len(good)-1])
+	assert.ErrorIs(t, err, io.ErrUnexpectedEOF)
+
+	bad := append([]byte(nil), good...)
+	bad[0] = 'X'
+	_, _, err = Decode(bad)
+	assert.ErrorIs(t, err, ErrBadMagic)
+
+	huge := append([]byte(nil), good[:4]...)
+	huge = binary.BigEndian.AppendUint32(huge, MaxBody+1)
+	_, _, err = Decode(huge)
+	assert.ErrorIs(t, err, ErrTooLarge)
+
+	// body 的長度欄位與實際內容不符
+	lying := append([]byte(nil), good...)
+	binary.BigEndian.PutUint32(lying[4:], uint32(len(good)-HeaderSize-1))
+	_, _, err = Decode(lying)
+	assert.Error(t, err)
+}
+
+func TestReadMessageStream(t *testing.T) {
+	var buf bytes.Buffer
+	enc := NewEncoder(binary.LittleEndian)
+	for i := uint64(0); i < 3; i++ {
+		assert.NoError(t, enc.Encode(&buf, Message{ID: i, Payload: bytes.Repeat([]byte{'x'}, int(i))}))
+	}
+	for i := uint64(0); i < 3; i++ {
+		m, err := ReadMessage(&buf)
+		assert.NoError(t, err)
+		assert.Equal(t, i, m.ID)
+		assert.Len(t, m.Payload, int(i))
+	}
+	_, err := ReadMessage(&buf)
+	assert.Equal(t, io.EOF, err)
+
+	b, _ := enc.Append(nil, Message{ID: 1, Payload: []byte("abc")})
+	_, err = ReadMessage(bytes.NewReader(b[:len(b)-2]))
+	assert.ErrorIs(t, err, io.ErrUnexpectedEOF)
+}
+
+// go test -fuzz=FuzzDecode ./codec/binpack
+// 任意輸入都不應該 panic；能解析成功的輸入重新編碼後要能得到相同的訊息
+func FuzzDecode(f *testing.F) {
+	for _, order := range []binary.ByteOrder{binary.BigEndian, binary.LittleEndian} {
+		b, _ := NewEncoder(order).Append(nil, Message{ID: 42, Kind: 1, Payload: []byte("seed"), Timestamp: 7})
+		f.Add(b)
+	}
+	v1, _ := (&Encoder{Order: binary.BigEndian, Version: Version1}).Append(nil, Message{ID: 1})
+	f.Add(v1)
+	f.Add([]byte("GL\x02\x00\x00\x00\x00\x01\xff"))
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		m, n, err := Decode(data)
+		if err != nil {
+			return
+		}
+		h, _ := ParseHeader(data)
+		again, err := (&Encoder{Order: h.Order(), Version: h.Version}).Append(nil, m)
+		if err != nil {
+			t.Fatalf("re-encode failed: %v", err)
+		}
+		m2, _, err := Decode(again)
+		if err != nil {
+			t.Fatalf("decode of re-encoded message failed: %v", err)
+		}
+		assert.Equal(t, m, m2)
+		assert.LessOrEqual(t, n, len(data))
+	})
+}
+
+func benchMessage() Message {
+	return Message{ID: 123456, Kind: 3, Payload: bytes.Repeat([]byte("payload-"), 16), Timestamp: 1650000000000000000}
+}
+
+func BenchmarkBinpackRoundTrip(b *testing.B) {
+	m := benchMessage()
+	enc := NewEncoder(binary.BigEndian)
+	buf := make([]byte, 0, 256)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		out, _ := enc.Append(buf[:0], m)
+		_, _, _ = Decode(out)
+		b.SetBytes(int64(len(out)))
+	}
+}
+
+func BenchmarkJSONRoundTrip(b *testing.B) {
+	m := benchMessage()
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		out, _ := json.Marshal(m)
+		var got Message
+		_ = json.Unmarshal(out, &got)
+		b.SetBytes(int64(len(out)))
+	}
+}
+
+func BenchmarkGobRoundTrip(b *testing.B) {
+	m := benchMessage()
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		var buf bytes.Buffer
+		_ = gob.NewEncoder(&buf).Encode(m)
+		b.SetBytes(int64(buf.Len()))
+		var got Message
+		_ = gob.NewDecoder(&buf).Decode(&got)
+	}
+}
+
+/*
+go test -run xxx -bench . -benchmem ./codec/binpack
+BenchmarkBinpackRoundTrip 	 5596651	       224.9 ns/op	 671.40 MB/s	     324 B/op	       4 allocs/op
+BenchmarkJSONRoundTrip    	  622075	      3119 ns/op	  76.62 MB/s	     512 B/op	       5 allocs/op
+BenchmarkGobRoundTrip     	   75236	     16372 ns/op	  13.19 MB/s	    9400 B/op	     186 allocs/op
+
+binpack 每個訊息 151 bytes、JSON 約 239 bytes（payload 經 base64）；
+gob 每次都建立新的 Encoder，會重送型別描述，適合長連線而不是單一訊息。
+*/
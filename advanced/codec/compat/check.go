@@ -0,0 +1,121 @@
+package compat
+
+import (
+	"fmt"
+
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// Violation 是一項會讓舊資料讀錯或讀不回來的 schema 修改
+type Violation struct {
+	Field  string // 例如 "Snapshot.entries.value" 或 "Snapshot#4"
+	Reason string
+}
+
+func (v Violation) String() string {
+	return v.Field + ": " + v.Reason
+}
+
+// wireGroup 把 wire 上可以互相解碼的型別歸成同一組；不同組互換時，值會被讀成 unknown 或錯誤的數字
+func wireGroup(k protoreflect.Kind) string {
+	switch k {
+	case protoreflect.Int32Kind, protoreflect.Int64Kind, protoreflect.Uint32Kind, protoreflect.Uint64Kind,
+		protoreflect.BoolKind, protoreflect.EnumKind:
+		return "varint" // 數值範圍不同時會被截斷，但至少讀得到
+	case protoreflect.Sint32Kind, protoreflect.Sint64Kind:
+		return "zigzag"
+	case protoreflect.Fixed32Kind, protoreflect.Sfixed32Kind:
+		return "fixed32"
+	case protoreflect.Fixed64Kind, protoreflect.Sfixed64Kind:
+		return "fixed64"
+	case protoreflect.FloatKind:
+		return "float"
+	case protoreflect.DoubleKind:
+		return "double"
+	case protoreflect.StringKind, protoreflect.BytesKind:
+		return "bytes"
+	case protoreflect.MessageKind, protoreflect.GroupKind:
+		return "message"
+	}
+	return k.String()
+}
+
+// Check 比較 prev 與 next 兩個版本，回傳所有破壞 wire 相容性的修改；nil 表示新程式可以安全讀取舊資料
+func Check(prev, next protoreflect.MessageDescriptor) []Violation {
+	var out []Violation
+	check(prev, next, string(prev.Name()), map[protoreflect.FullName]bool{}, &out)
+	return out
+}
+
+func check(prev, next protoreflect.MessageDescriptor, path string, seen map[protoreflect.FullName]bool, out *[]Violation) {
+	if seen[prev.FullName()] {
+		return
+	}
+	seen[prev.FullName()] = true
+	add := func(field, format string, args ...any) {
+		*out = append(*out, Violation{Field: path + field, Reason: fmt.Sprintf(format, args...)})
+	}
+
+	oldFields, newFields := prev.Fields(), next.Fields()
+	for i := 0; i < oldFields.Len(); i++ {
+		of := oldFields.Get(i)
+		nf := newFields.ByNumber(of.Number())
+		if nf == nil {
+			if !next.ReservedRanges().Has(of.Number()) {
+				add(fmt.Sprintf("#%d", of.Number()), "field %q removed without reserving its number", of.Name())
+			}
+			if !next.ReservedNames().Has(of.Name()) {
+				add(fmt.Sprintf("#%d", of.Number()), "field %q removed without reserving its name", of.Name())
+			}
+			continue
+		}
+		name := "." + string(of.Name())
+		if wireGroup(of.Kind()) != wireGroup(nf.Kind()) {
+			add(name, "type changed from %v to %v", of.Kind(), nf.Kind())
+		}
+		if of.IsList() != nf.IsList() || of.IsMap() != nf.IsMap() {
+			add(name, "cardinality changed")
+		}
+		if moved := newFields.ByName(of.Name()); moved != nil && moved.Number() != of.Number() {
+			add(name, "renumbered from %d to %d", of.Number(), moved.Number())
+		}
+		if of.Kind() == protoreflect.MessageKind && nf.Kind() == protoreflect.MessageKind && !of.IsMap() {
+			check(of.Message(), nf.Message(), path+name, seen, out)
+		}
+	}
+
+	// 新欄位不能用到舊版 reserved 的編號：舊檔中那個編號的資料是別的意思
+	for i := 0; i < newFields.Len(); i++ {
+		nf := newFields.Get(i)
+		if prev.ReservedRanges().Has(nf.Number()) {
+			add("."+string(nf.Name()), "reuses reserved number %d", nf.Number())
+		}
+		if prev.ReservedNames().Has(nf.Name()) {
+			add("."+string(nf.Name()), "reuses reserved name")
+		}
+	}
+
+	// 新的 oneof 最多只能收一個既有欄位
+	oneofs := next.Oneofs()
+	for i := 0; i < oneofs.Len(); i++ {
+		o := oneofs.Get(i)
+		if o.IsSynthetic() {
+			continue
+		}
+		var existing []protoreflect.Name
+		for j := 0; j < o.Fields().Len(); j++ {
+			f := o.Fields().Get(j)
+			of := oldFields.ByNumber(f.Number())
+			if of == nil {
+				continue
+			}
+			if oo := of.ContainingOneof(); oo != nil && !oo.IsSynthetic() && oo.Name() == o.Name() {
+				continue // 原本就在同一個 oneof
+			}
+			existing = append(existing, f.Name())
+		}
+		if len(existing) > 1 {
+			add("."+string(o.Name()), "oneof takes %d existing fields %v; old data with more than one set keeps only the last", len(existing), existing)
+		}
+	}
+}
@@ -0,0 +1,244 @@
+package compat
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+// go test ./codec/compat -update 重新產生 golden 檔。
+// 只有在「刻意」改變某一版的 fixture 時才可以更新；舊版的 .bin 代表已經寫在使用者磁碟上的檔案，不應該變
+var update = flag.Bool("update", false, "update golden files")
+
+func set(m *dynamicpb.Message, name string, v any) {
+	fd := m.Descriptor().Fields().ByName(protoreflect.Name(name))
+	if fd == nil {
+		panic("no field " + name)
+	}
+	m.Set(fd, protoreflect.ValueOf(v))
+}
+
+func get(m protoreflect.Message, name string) protoreflect.Value {
+	return m.Get(m.Descriptor().Fields().ByName(protoreflect.Name(name)))
+}
+
+func addEntry(m *dynamicpb.Message, fields map[string]any) {
+	list := m.Mutable(m.Descriptor().Fields().ByName("entries")).List()
+	e := list.NewElement().Message().(*dynamicpb.Message)
+	for k, v := range fields {
+		set(e, k, v)
+	}
+	list.Append(protoreflect.ValueOfMessage(e))
+}
+
+// fixture 是各版本程式寫出的 snapshot
+func fixture(version int) *dynamicpb.Message {
+	m := New(version)
+	set(m, "id", fmt.Sprintf("snap-v%d", version))
+	set(m, "taken_at_unix", int64(1700000000))
+	switch version {
+	case 1:
+		set(m, "note", "written by v1")
+		addEntry(m, map[string]any{"key": "a", "value": []byte("1")})
+	case 2:
+		set(m, "format_version", uint32(2))
+		set(m, "path", "/var/lib/app/snap")
+		addEntry(m, map[string]any{"key": "a", "value": []byte("1"), "expires_at_unix": int64(1800000000)})
+	case 3:
+		set(m, "format_version", uint32(3))
+		set(m, "url", "s3://bucket/snap")
+		set(m, "compressed", true)
+		addEntry(m, map[string]any{"key": "a", "value": []byte("1"), "expires_at_unix": int64(1800000000)})
+	}
+	addEntry(m, map[string]any{"key": "b", "value": []byte("2")})
+	return m
+}
+
+func goldenPath(version int) string {
+	return filepath.Join("testdata", fmt.Sprintf("snapshot_v%d.bin", version))
+}
+
+// 同一版的程式寫出的 bytes 不能變：schema 或 fixture 被意外修改時會在這裡失敗
+func TestGoldenFixtures(t *testing.T) {
+	for v := 1; v <= Latest; v++ {
+		got, err := proto.MarshalOptions{Deterministic: true}.Marshal(fixture(v))
+		require.NoError(t, err)
+		if *update {
+			require.NoError(t, os.WriteFile(goldenPath(v), got, 0o644))
+		}
+		want, err := os.ReadFile(goldenPath(v))
+		require.NoError(t, err)
+		assert.Equal(t, want, got, "v%d wire bytes changed", v)
+	}
+}
+
+func readGolden(t *testing.T, version, as int) *dynamicpb.Message {
+	b, err := os.ReadFile(goldenPath(version))
+	require.NoError(t, err)
+	m := New(as)
+	require.NoError(t, proto.Unmarshal(b, m))
+	return m
+}
+
+// 最新版的程式要讀得懂每一個曾經寫出去的檔案
+func TestLatestReadsEveryGolden(t *testing.T) {
+	origin := Snapshot(Latest).Oneofs().ByName("origin")
+	for v := 1; v <= Latest; v++ {
+		t.Run(fmt.Sprintf("v%d", v), func(t *testing.T) {
+			m := readGolden(t, v, Latest)
+			assert.Equal(t, fmt.Sprintf("snap-v%d", v), get(m, "id").String())
+			assert.EqualValues(t, 1700000000, get(m, "taken_at_unix").Int())
+			entries := get(m, "entries").List()
+			require.Equal(t, 2, entries.Len())
+			assert.Equal(t, "a", get(entries.Get(0).Message(), "key").String())
+			assert.Equal(t, []byte("2"), get(entries.Get(1).Message(), "value").Bytes())
+
+			switch v {
+			case 1:
+				// 新欄位是零值；reserved 的 note 成為 unknown field，不會被讀成別的東西
+				assert.Zero(t, get(m, "format_version").Uint())
+				assert.Zero(t, get(entries.Get(0).Message(), "expires_at_unix").Int())
+				assert.Nil(t, m.WhichOneof(origin))
+				assert.NotEmpty(t, m.GetUnknown())
+			case 2:
+				// 搬進 oneof 的 path 照樣讀得到
+				require.NotNil(t, m.WhichOneof(origin))
+				assert.Equal(t, protoreflect.Name("path"), m.WhichOneof(origin).Name())
+				assert.Equal(t, "/var/lib/app/snap", get(m, "path").String())
+				assert.EqualValues(t, 1800000000, get(entries.Get(0).Message(), "expires_at_unix").Int())
+			case 3:
+				assert.Equal(t, protoreflect.Name("url"), m.WhichOneof(origin).Name())
+				assert.True(t, get(m, "compressed").Bool())
+				assert.Empty(t, m.GetUnknown())
+			}
+		})
+	}
+}
+
+// 新程式讀舊檔、修改、寫回，舊程式仍然看得到 note（unknown field 會被保留）
+func TestUnknownFieldsSurviveRewrite(t *testing.T) {
+	m := readGolden(t, 1, Latest)
+	set(m, "format_version", uint32(Latest))
+	b, err := proto.Marshal(m)
+	require.NoError(t, err)
+
+	old := New(1)
+	require.NoError(t, proto.Unmarshal(b, old))
+	assert.Equal(t, "written by v1", get(old, "note").String())
+	assert.NotEmpty(t, old.GetUnknown(), "format_version is unknown to v1")
+}
+
+// 反方向：舊程式讀新檔，認得的欄位照常，其餘成為 unknown
+func TestOldReaderNewFile(t *testing.T) {
+	m := readGolden(t, Latest, 1)
+	assert.Equal(t, "snap-v3", get(m, "id").String())
+	assert.Equal(t, 2, get(m, "entries").List().Len())
+	assert.Empty(t, get(m, "note").String())
+	assert.NotEmpty(t, m.GetUnknown())
+}
+
+func TestShippedVersionsAreCompatible(t *testing.T) {
+	for v := 2; v <= Latest; v++ {
+		assert.Empty(t, Check(Snapshot(v-1), Snapshot(v)), "v%d -> v%d", v-1, v)
+	}
+}
+
+func mustMessage(t *testing.T, version int, msgs ...*descriptorpb.DescriptorProto) protoreflect.MessageDescriptor {
+	md, err := build(version, msgs...)
+	require.NoError(t, err)
+	return md
+}
+
+func TestCheckCatchesBreakingChanges(t *testing.T) {
+	str := descriptorpb.FieldDescriptorProto_TYPE_STRING
+	i64 := descriptorpb.FieldDescriptorProto_TYPE_INT64
+	base := mustMessage(t, 100, snapshotV1(), entryV1())
+
+	cases := []struct {
+		name string
+		next protoreflect.MessageDescriptor
+		want []string
+	}{
+		{
+			name: "removed without reserved",
+			next: mustMessage(t, 101, message("Snapshot",
+				scalar("id", 1, str), scalar("taken_at_unix", 2, i64), repeated(messageField("entries", 3, "Entry")),
+			), entryV1()),
+			want: []string{
+				`Snapshot#4: field "note" removed without reserving its number`,
+				`Snapshot#4: field "note" removed without reserving its name`,
+			},
+		},
+		{
+			name: "type changed and renumbered",
+			next: mustMessage(t, 102, message("Snapshot",
+				scalar("id", 1, str), scalar("taken_at_unix", 2, str), repeated(messageField("entries", 3, "Entry")),
+				scalar("note", 5, str), scalar("legacy", 4, str),
+			), entryV1()),
+			want: []string{
+				"Snapshot.taken_at_unix: type changed from int64 to string",
+				"Snapshot.note: renumbered from 4 to 5",
+			},
+		},
+		{
+			name: "nested message",
+			next: mustMessage(t, 103, snapshotV1(), message("Entry",
+				scalar("key", 1, str), scalar("value", 2, i64),
+			)),
+			want: []string{"Snapshot.entries.value: type changed from bytes to int64"},
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			var got []string
+			for _, v := range Check(base, tc.next) {
+				got = append(got, v.String())
+			}
+			assert.Equal(t, tc.want, got)
+		})
+	}
+
+	// 重用 v2 已經 reserved 的編號
+	reused := mustMessage(t, 104, reserve(message("Snapshot",
+		scalar("id", 1, str), scalar("taken_at_unix", 2, i64), repeated(messageField("entries", 3, "Entry")),
+		scalar("format_version", 5, descriptorpb.FieldDescriptorProto_TYPE_UINT32), scalar("path", 7, str),
+		scalar("owner", 4, str),
+	), nil, "note"), entryV2())
+	require.Len(t, Check(Snapshot(2), reused), 1)
+	assert.Equal(t, "Snapshot.owner: reuses reserved number 4", Check(Snapshot(2), reused)[0].String())
+}
+
+// 兩個既有欄位搬進同一個 oneof：Check 會擋下，而且實際解碼真的會掉資料
+func TestOneofFoldLosesData(t *testing.T) {
+	str := descriptorpb.FieldDescriptorProto_TYPE_STRING
+	both := mustMessage(t, 110, message("Snapshot",
+		scalar("id", 1, str), scalar("path", 7, str), scalar("url", 8, str),
+	))
+	folded := mustMessage(t, 111, oneof(message("Snapshot",
+		scalar("id", 1, str), scalar("path", 7, str), scalar("url", 8, str),
+	), "origin", "path", "url"))
+
+	violations := Check(both, folded)
+	require.Len(t, violations, 1)
+	assert.Contains(t, violations[0].String(), "oneof takes 2 existing fields [path url]")
+
+	old := dynamicpb.NewMessage(both)
+	set(old, "path", "/backup")
+	set(old, "url", "s3://bucket")
+	b, err := proto.Marshal(old)
+	require.NoError(t, err)
+
+	m := dynamicpb.NewMessage(folded)
+	require.NoError(t, proto.Unmarshal(b, m))
+	assert.Equal(t, "s3://bucket", get(m, "url").String())
+	assert.Empty(t, get(m, "path").String(), "path was silently dropped")
+}
@@ -0,0 +1,211 @@
+package compat
+
+import (
+	"fmt"
+
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+/*
+snapshot 檔案格式的三個版本，示範 protobuf schema 怎麼演進才不會讀不回舊檔：
+
+	// v1
+	message Snapshot {
+	  string id = 1;
+	  int64 taken_at_unix = 2;
+	  repeated Entry entries = 3;
+	  string note = 4;
+	}
+	message Entry { string key = 1; bytes value = 2; }
+
+	// v2：刪掉 note，編號與名稱都 reserved；尾端加欄位
+	message Snapshot {
+	  reserved 4;
+	  reserved "note";
+	  string id = 1;
+	  int64 taken_at_unix = 2;
+	  repeated Entry entries = 3;
+	  uint32 format_version = 5;
+	  string path = 7;
+	}
+	message Entry { string key = 1; bytes value = 2; int64 expires_at_unix = 3; }
+
+	// v3：把既有的 path 放進新的 oneof，加入 url
+	message Snapshot {
+	  ...（同 v2）
+	  oneof origin {
+	    string path = 7;
+	    string url = 8;
+	  }
+	  bool compressed = 9;
+	}
+
+規則（Check 會檢查）：
+  - 編號就是 wire 上的身分：不能改編號、不能改成不相容的型別
+  - 刪除欄位要 reserved 編號與名稱，否則之後有人重用這個編號，舊檔的資料會被讀成別的意思
+  - 只有一個既有欄位可以搬進新的 oneof；兩個以上時，舊資料若同時有值，解碼只會留下最後一個
+
+protocol/compat_test.go 已經用產生的程式碼示範「加欄位」與「改編號」；這裡的重點是 reserved、oneof，
+以及用 commit 進 repo 的舊版二進位檔（testdata/*.bin）守住相容性：新程式必須永遠讀得懂它們。
+
+環境中沒有 protoc，三個版本的 schema 直接用 descriptorpb 組出來，訊息用 dynamicpb 操作；
+wire format 與產生的程式碼完全相同。
+*/
+
+const Latest = 3
+
+var snapshots = map[int]protoreflect.MessageDescriptor{
+	1: mustBuild(1, snapshotV1(), entryV1()),
+	2: mustBuild(2, snapshotV2(), entryV2()),
+	3: mustBuild(3, snapshotV3(), entryV2()),
+}
+
+// Snapshot 回傳第 version 版的 Snapshot descriptor
+func Snapshot(version int) protoreflect.MessageDescriptor {
+	md, ok := snapshots[version]
+	if !ok {
+		panic(fmt.Sprintf("compat: unknown version %d", version))
+	}
+	return md
+}
+
+// New 建立第 version 版的空 Snapshot
+func New(version int) *dynamicpb.Message {
+	return dynamicpb.NewMessage(Snapshot(version))
+}
+
+func snapshotV1() *descriptorpb.DescriptorProto {
+	return message("Snapshot",
+		scalar("id", 1, descriptorpb.FieldDescriptorProto_TYPE_STRING),
+		scalar("taken_at_unix", 2, descriptorpb.FieldDescriptorProto_TYPE_INT64),
+		repeated(messageField("entries", 3, "Entry")),
+		scalar("note", 4, descriptorpb.FieldDescriptorProto_TYPE_STRING),
+	)
+}
+
+func snapshotV2() *descriptorpb.DescriptorProto {
+	m := message("Snapshot",
+		scalar("id", 1, descriptorpb.FieldDescriptorProto_TYPE_STRING),
+		scalar("taken_at_unix", 2, descriptorpb.FieldDescriptorProto_TYPE_INT64),
+		repeated(messageField("entries", 3, "Entry")),
+		scalar("format_version", 5, descriptorpb.FieldDescriptorProto_TYPE_UINT32),
+		scalar("path", 7, descriptorpb.FieldDescriptorProto_TYPE_STRING),
+	)
+	return reserve(m, []int32{4}, "note")
+}
+
+func snapshotV3() *descriptorpb.DescriptorProto {
+	m := message("Snapshot",
+		scalar("id", 1, descriptorpb.FieldDescriptorProto_TYPE_STRING),
+		scalar("taken_at_unix", 2, descriptorpb.FieldDescriptorProto_TYPE_INT64),
+		repeated(messageField("entries", 3, "Entry")),
+		scalar("format_version", 5, descriptorpb.FieldDescriptorProto_TYPE_UINT32),
+		scalar("path", 7, descriptorpb.FieldDescriptorProto_TYPE_STRING),
+		scalar("url", 8, descriptorpb.FieldDescriptorProto_TYPE_STRING),
+		scalar("compressed", 9, descriptorpb.FieldDescriptorProto_TYPE_BOOL),
+	)
+	m = oneof(m, "origin", "path", "url")
+	return reserve(m, []int32{4}, "note")
+}
+
+func entryV1() *descriptorpb.DescriptorProto {
+	return message("Entry",
+		scalar("key", 1, descriptorpb.FieldDescriptorProto_TYPE_STRING),
+		scalar("value", 2, descriptorpb.FieldDescriptorProto_TYPE_BYTES),
+	)
+}
+
+func entryV2() *descriptorpb.DescriptorProto {
+	return message("Entry",
+		scalar("key", 1, descriptorpb.FieldDescriptorProto_TYPE_STRING),
+		scalar("value", 2, descriptorpb.FieldDescriptorProto_TYPE_BYTES),
+		scalar("expires_at_unix", 3, descriptorpb.FieldDescriptorProto_TYPE_INT64),
+	)
+}
+
+// 以下是組 descriptor 的小工具，相當於手寫 .proto
+
+func message(name string, fields ...*descriptorpb.FieldDescriptorProto) *descriptorpb.DescriptorProto {
+	return &descriptorpb.DescriptorProto{Name: &name, Field: fields}
+}
+
+func scalar(name string, num int32, typ descriptorpb.FieldDescriptorProto_Type) *descriptorpb.FieldDescriptorProto {
+	return &descriptorpb.FieldDescriptorProto{
+		Name:     &name,
+		JsonName: &name,
+		Number:   &num,
+		Type:     typ.Enum(),
+		Label:    descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL.Enum(),
+	}
+}
+
+// messageField 的 typeName 是同一個檔案中的 message 名稱，build 時補上 package
+func messageField(name string, num int32, typeName string) *descriptorpb.FieldDescriptorProto {
+	f := scalar(name, num, descriptorpb.FieldDescriptorProto_TYPE_MESSAGE)
+	f.TypeName = &typeName
+	return f
+}
+
+func repeated(f *descriptorpb.FieldDescriptorProto) *descriptorpb.FieldDescriptorProto {
+	f.Label = descriptorpb.FieldDescriptorProto_LABEL_REPEATED.Enum()
+	return f
+}
+
+func reserve(m *descriptorpb.DescriptorProto, nums []int32, names ...string) *descriptorpb.DescriptorProto {
+	for _, n := range nums {
+		start, end := n, n+1 // end 不包含
+		m.ReservedRange = append(m.ReservedRange, &descriptorpb.DescriptorProto_ReservedRange{Start: &start, End: &end})
+	}
+	m.ReservedName = append(m.ReservedName, names...)
+	return m
+}
+
+func oneof(m *descriptorpb.DescriptorProto, name string, fields ...string) *descriptorpb.DescriptorProto {
+	idx := int32(len(m.OneofDecl))
+	m.OneofDecl = append(m.OneofDecl, &descriptorpb.OneofDescriptorProto{Name: &name})
+	for _, f := range m.Field {
+		for _, want := range fields {
+			if f.GetName() == want {
+				f.OneofIndex = &idx
+			}
+		}
+	}
+	return m
+}
+
+// build 把 messages 放進 golearn.codec.compat.v<version> 這個 proto3 檔案，回傳第一個 message
+func build(version int, msgs ...*descriptorpb.DescriptorProto) (protoreflect.MessageDescriptor, error) {
+	pkg := fmt.Sprintf("golearn.codec.compat.v%d", version)
+	for _, m := range msgs {
+		for _, f := range m.Field {
+			if f.TypeName != nil && (*f.TypeName)[0] != '.' {
+				full := "." + pkg + "." + *f.TypeName
+				f.TypeName = &full
+			}
+		}
+	}
+	name := fmt.Sprintf("compat/v%d/snapshot.proto", version)
+	syntax := "proto3"
+	fd, err := protodesc.NewFile(&descriptorpb.FileDescriptorProto{
+		Name:        &name,
+		Package:     &pkg,
+		Syntax:      &syntax,
+		MessageType: msgs,
+	}, new(protoregistry.Files))
+	if err != nil {
+		return nil, err
+	}
+	return fd.Messages().Get(0), nil
+}
+
+func mustBuild(version int, msgs ...*descriptorpb.DescriptorProto) protoreflect.MessageDescriptor {
+	md, err := build(version, msgs...)
+	if err != nil {
+		panic(err)
+	}
+	return md
+}
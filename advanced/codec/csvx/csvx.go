@@ -0,0 +1,189 @@
+package csvx
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+)
+
+// RowError 記錄哪一行、哪個欄位出錯，Line 為 CSV 檔案中的行號（header 為第 1 行）：
+// 欄位錯誤是該欄位開始的行，整列的錯誤是這一列開始的行；引號內換行的欄位會佔用多行，所以不等於第幾列
+type RowError struct {
+	Line   int
+	Column string
+	Err    error
+}
+
+func (e *RowError) Error() string {
+	if e.Column == "" {
+		return fmt.Sprintf("line %d: %v", e.Line, e.Err)
+	}
+	return fmt.Sprintf("line %d, column %q: %v", e.Line, e.Column, e.Err)
+}
+
+func (e *RowError) Unwrap() error { return e.Err }
+
+// Errors 收集所有壞掉的行，讓呼叫端一次看到全部問題，而不是修一個跑一次
+type Errors []*RowError
+
+func (es Errors) Error() string {
+	msgs := make([]string, len(es))
+	for i, e := range es {
+		msgs[i] = e.Error()
+	}
+	return fmt.Sprintf("csvx: %d invalid rows:\n%s", len(es), strings.Join(msgs, "\n"))
+}
+
+// Validator 由 row 型別實作，每一行轉換成功後會呼叫
+type Validator interface {
+	Validate() error
+}
+
+// Reader 逐行讀取 CSV 並轉換成 T，不會一次把整個檔案載入記憶體
+type Reader[T any] struct {
+	r       *csv.Reader
+	columns []int // fields[i] 對應的 CSV 欄位位置，-1 表示 header 沒有這個欄位
+	fields  []field
+	line    int
+}
+
+func NewReader[T any](r io.Reader) (*Reader[T], error) {
+	var zero T
+	fields, err := schemaOf(reflect.TypeOf(zero))
+	if err != nil {
+		return nil, err
+	}
+	cr := csv.NewReader(r)
+	cr.ReuseRecord = true
+	header, err := cr.Read()
+	if err != nil {
+		return nil, fmt.Errorf("csvx: read header: %w", err)
+	}
+	pos := make(map[string]int, len(header))
+	for i, h := range header {
+		pos[strings.TrimSpace(h)] = i
+	}
+	columns := make([]int, len(fields))
+	for i, f := range fields {
+		c, ok := pos[f.name]
+		if !ok {
+			c = -1
+		}
+		columns[i] = c
+	}
+	return &Reader[T]{r: cr, columns: columns, fields: fields, line: 1}, nil
+}
+
+// Read 回傳下一行，檔案結束時回傳 io.EOF；單一行的錯誤以 *RowError 回傳，可以繼續讀下一行
+func (r *Reader[T]) Read() (T, error) {
+	var row T
+	record, err := r.r.Read()
+	if err == io.EOF {
+		return row, io.EOF
+	}
+	if err != nil {
+		var perr *csv.ParseError
+		if errors.As(err, &perr) {
+			r.line = perr.StartLine
+			return row, &RowError{Line: perr.Line, Err: perr.Err}
+		}
+		return row, err
+	}
+	// 空行會被略過、引號內可以換行，行號要問 csv.Reader，不能自己數
+	r.line, _ = r.r.FieldPos(0)
+	v := reflect.ValueOf(&row).Elem()
+	for i, f := range r.fields {
+		c := r.columns[i]
+		if c < 0 || c >= len(record) {
+			continue
+		}
+		if err := decodeValue(v.FieldByIndex(f.index), strings.TrimSpace(record[c]), f.layout); err != nil {
+			line, _ := r.r.FieldPos(c)
+			return row, &RowError{Line: line, Column: f.name, Err: err}
+		}
+	}
+	if val, ok := interface{}(&row).(Validator); ok {
+		if err := val.Validate(); err != nil {
+			return row, &RowError{Line: r.line, Err: err}
+		}
+	}
+	return row, nil
+}
+
+// Line 回傳最後一次 Read 的那一列開始的行號
+func (r *Reader[T]) Line() int {
+	return r.line
+}
+
+// ReadAll 讀取所有行，壞掉的行會被略過並收集在 Errors 中；
+// 只有 I/O 錯誤這類無法繼續的問題才回傳 error
+func ReadAll[T any](r io.Reader) ([]T, Errors, error) {
+	cr, err := NewReader[T](r)
+	if err != nil {
+		return nil, nil, err
+	}
+	var rows []T
+	var rowErrs Errors
+	for {
+		row, err := cr.Read()
+		if err == io.EOF {
+			return rows, rowErrs, nil
+		}
+		var rowErr *RowError
+		if errors.As(err, &rowErr) {
+			rowErrs = append(rowErrs, rowErr)
+			continue
+		}
+		if err != nil {
+			return rows, rowErrs, err
+		}
+		rows = append(rows, row)
+	}
+}
+
+// Writer 把 T 寫成 CSV，第一次 Write 時輸出 header
+type Writer[T any] struct {
+	w           *csv.Writer
+	fields      []field
+	wroteHeader bool
+	record      []string
+}
+
+func NewWriter[T any](w io.Writer) (*Writer[T], error) {
+	var zero T
+	fields, err := schemaOf(reflect.TypeOf(zero))
+	if err != nil {
+		return nil, err
+	}
+	return &Writer[T]{w: csv.NewWriter(w), fields: fields, record: make([]string, len(fields))}, nil
+}
+
+func (w *Writer[T]) Write(row T) error {
+	if !w.wroteHeader {
+		for i, f := range w.fields {
+			w.record[i] = f.name
+		}
+		if err := w.w.Write(w.record); err != nil {
+			return err
+		}
+		w.wroteHeader = true
+	}
+	v := reflect.ValueOf(row)
+	for i, f := range w.fields {
+		s, err := encodeValue(v.FieldByIndex(f.index), f.layout)
+		if err != nil {
+			return fmt.Errorf("csvx: column %q: %w", f.name, err)
+		}
+		w.record[i] = s
+	}
+	return w.w.Write(w.record)
+}
+
+// Flush 寫出緩衝區並回傳過程中的錯誤
+func (w *Writer[T]) Flush() error {
+	w.w.Flush()
+	return w.w.Error()
+}
@@ -0,0 +1,204 @@
+package csvx
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type Order struct {
+	ID       int       `csv:"id"`
+	Customer string    `csv:"customer"`
+	Amount   float64   `csv:"amount"`
+	Paid     bool      `csv:"paid"`
+	Date     time.Time `csv:"date,layout=2006-01-02"`
+	Coupon   *string   `csv:"coupon"`
+	Internal string    `csv:"-"`
+}
+
+func (o *Order) Validate() error {
+	if o.Customer == "" {
+		return errors.New("customer is required")
+	}
+	if o.Amount < 0 {
+		return errors.New("amount must be positive")
+	}
+	return nil
+}
+
+const orders = `date,id,customer,amount,paid,coupon
+2022-01-01,1,alice,10.5,true,NEW
+2022-01-02,2,bob,20,false,
+2022-01-03,x,carol,1,true,
+2022-01-04,4,,1,true,
+2022-01-05,5,dave,-3,true,
+2022-01-06,6,erin,7,maybe,
+2022-01-07,7,frank,8,true,
+`
+
+func TestReadAllCollectsErrors(t *testing.T) {
+	rows, rowErrs, err := ReadAll[Order](strings.NewReader(orders))
+	assert.NoError(t, err)
+	assert.Len(t, rows, 3)
+
+	assert.Equal(t, 1, rows[0].ID)
+	assert.Equal(t, "NEW", *rows[0].Coupon)
+	assert.Equal(t, time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC), rows[0].Date)
+	assert.Nil(t, rows[1].Coupon)
+	assert.Equal(t, 7, rows[2].ID)
+
+	assert.Len(t, rowErrs, 4)
+	assert.Equal(t, 4, rowErrs[0].Line)
+	assert.Equal(t, "id", rowErrs[0].Column)
+	assert.ErrorIs(t, rowErrs[0], strconv.ErrSyntax)
+	assert.Equal(t, `line 5: customer is required`, rowErrs[1].Error())
+	assert.Equal(t, 6, rowErrs[2].Line)
+	assert.Equal(t, "paid", rowErrs[3].Column)
+	assert.Contains(t, rowErrs.Error(), "4 invalid rows")
+}
+
+func TestReaderStreaming(t *testing.T) {
+	r, err := NewReader[Order](strings.NewReader("customer,id,amount\nx,1,2\n"))
+	assert.NoError(t, err)
+	o, err := r.Read()
+	assert.NoError(t, err)
+	assert.Equal(t, Order{ID: 1, Customer: "x", Amount: 2}, o)
+	_, err = r.Read()
+	assert.Equal(t, io.EOF, err)
+
+	_, err = NewReader[Order](strings.NewReader(""))
+	assert.Error(t, err)
+	_, err = NewReader[int](strings.NewReader("a\n"))
+	assert.Error(t, err)
+}
+
+func TestMalformedCSV(t *testing.T) {
+	_, rowErrs, err := ReadAll[Order](strings.NewReader("id,customer\n1,\"bad\n"))
+	assert.NoError(t, err)
+	assert.Len(t, rowErrs, 1)
+}
+
+func TestWriterRoundTrip(t *testing.T) {
+	coupon := "VIP"
+	in := []Order{
+		{ID: 1, Customer: "alice, inc", Amount: 1.25, Paid: true, Date: time.Date(2022, 2, 3, 0, 0, 0, 0, time.UTC), Coupon: &coupon},
+		{ID: 2, Customer: `say "hi"`, Amount: 3},
+	}
+	var sb strings.Builder
+	w, err := NewWriter[Order](&sb)
+	assert.NoError(t, err)
+	for _, o := range in {
+		assert.NoError(t, w.Write(o))
+	}
+	assert.NoError(t, w.Flush())
+	assert.Equal(t, "id,customer,amount,paid,date,coupon\n"+
+		"1,\"alice, inc\",1.25,true,2022-02-03,VIP\n"+
+		"2,\"say \"\"hi\"\"\",3,false,,\n", sb.String())
+
+	out, rowErrs, err := ReadAll[Order](strings.NewReader(sb.String()))
+	assert.NoError(t, err)
+	assert.Empty(t, rowErrs)
+	assert.Equal(t, in, out)
+}
+
+type Invoice struct {
+	OrderID int     `csv:"order_id"`
+	Total   float64 `csv:"total"`
+}
+
+func TestProcessOrdered(t *testing.T) {
+	var sb strings.Builder
+	sb.WriteString("id,customer,amount\n")
+	for i := 1; i <= 500; i++ {
+		fmt.Fprintf(&sb, "%d,c%d,%d\n", i, i, i)
+	}
+	sb.WriteString("bad,c,1\n")
+
+	var out strings.Builder
+	rowErrs, err := Process(context.Background(), strings.NewReader(sb.String()), &out, 8,
+		func(o Order) (Invoice, error) {
+			time.Sleep(time.Duration(rand.Intn(100)) * time.Microsecond)
+			if o.ID == 13 {
+				return Invoice{}, errors.New("unlucky")
+			}
+			return Invoice{OrderID: o.ID, Total: o.Amount * 1.05}, nil
+		})
+	assert.NoError(t, err)
+	assert.Len(t, rowErrs, 2)
+	assert.Equal(t, 14, rowErrs[0].Line)
+	assert.Equal(t, 502, rowErrs[1].Line)
+
+	invoices, _, err := ReadAll[Invoice](strings.NewReader(out.String()))
+	assert.NoError(t, err)
+	assert.Len(t, invoices, 499)
+	prev := 0
+	for _, inv := range invoices {
+		assert.Greater(t, inv.OrderID, prev)
+		prev = inv.OrderID
+	}
+}
+
+func TestProcessCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err := Process(ctx, strings.NewReader(orders), io.Discard, 2,
+		func(o Order) (Invoice, error) { return Invoice{}, nil })
+	assert.ErrorIs(t, err, context.Canceled)
+}
+
+// 引號內換行的欄位佔用多行，空行被略過，行號都以檔案中的實際位置為準
+func TestLineNumbersAcrossQuotedNewlines(t *testing.T) {
+	const in = "id,customer,amount\n" +
+		"1,\"alice\nand bob\",1\n" + // 第 2～3 行
+		"\n" +
+		"x,carol,1\n" + // 第 5 行
+		"3,\"dave\n\",oops\n" + // 第 6～7 行，amount 在第 7 行
+		"4,\"erin\",1\n" // 第 8 行
+	_, rowErrs, err := ReadAll[Order](strings.NewReader(in))
+	assert.NoError(t, err)
+	if assert.Len(t, rowErrs, 2) {
+		assert.Equal(t, 5, rowErrs[0].Line)
+		assert.Equal(t, 7, rowErrs[1].Line)
+		assert.Equal(t, "amount", rowErrs[1].Column)
+	}
+
+	rowErrs, err = Process(context.Background(), strings.NewReader(in), io.Discard, 2,
+		func(o Order) (Invoice, error) {
+			if o.ID == 4 {
+				return Invoice{}, errors.New("rejected")
+			}
+			return Invoice{OrderID: o.ID}, nil
+		})
+	assert.NoError(t, err)
+	var lines []int
+	for _, e := range rowErrs {
+		lines = append(lines, e.Line)
+	}
+	assert.Equal(t, []int{5, 7, 8}, lines)
+}
+
+// 寫入失敗時 Process 回傳錯誤，讀取與 worker 的 goroutine 都會結束
+func TestProcessWriteError(t *testing.T) {
+	var sb strings.Builder
+	sb.WriteString("id,customer,amount\n")
+	for i := 1; i <= 100; i++ {
+		fmt.Fprintf(&sb, "%d,c%d,%d\n", i, i, i)
+	}
+	_, err := Process(context.Background(), strings.NewReader(sb.String()), failingWriter{}, 4,
+		func(o Order) (Invoice, error) { return Invoice{OrderID: o.ID}, nil })
+	assert.ErrorIs(t, err, errDiskFull)
+}
+
+var errDiskFull = errors.New("disk full")
+
+type failingWriter struct{}
+
+func (failingWriter) Write([]byte) (int, error) { return 0, errDiskFull }
@@ -0,0 +1,141 @@
+package csvx
+
+import (
+	"context"
+	"errors"
+	"io"
+	"sort"
+	"sync"
+
+	"advanced/concurrency/pipeline"
+)
+
+/*
+Process 串起整個流程：
+
+	Reader[T] ──> pipeline.Transform(fn: T -> U) × N ──> 依序號排回原本的順序 ──> Writer[U]
+
+	- 轉換由 concurrency/pipeline 的 Transform 執行，多個 worker 時輸出是亂序的，
+	  由 sink 依讀取的序號暫存、排好再寫出，所以輸出順序與輸入相同
+	- 讀取與寫入是串流的：讀取前要先拿到 window 的位置，寫出一列才歸還，
+	  記憶體中（channel 與排序暫存）最多只有 workers*2 列，慢的一列不會讓暫存無限長大
+	- 壞掉的行（解析失敗、Validate 失敗、fn 回傳錯誤）收集到 Errors，其餘照常輸出；
+	  fn 的錯誤包在結果裡，不當成 stage 的錯誤，否則 pipeline 會在第一個錯誤就停下來
+*/
+
+type job[T any] struct {
+	seq  int // 第幾個交給 worker 的列，排序用；解析失敗的列不佔序號
+	line int
+	row  T
+}
+
+type result[U any] struct {
+	seq  int
+	line int
+	row  U
+	err  error
+}
+
+func Process[T, U any](ctx context.Context, r io.Reader, w io.Writer, workers int, fn func(T) (U, error)) (Errors, error) {
+	if workers <= 0 {
+		workers = 1
+	}
+	reader, err := NewReader[T](r)
+	if err != nil {
+		return nil, err
+	}
+	writer, err := NewWriter[U](w)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		mu      sync.Mutex
+		rowErrs Errors
+	)
+	addErr := func(e *RowError) {
+		mu.Lock()
+		rowErrs = append(rowErrs, e)
+		mu.Unlock()
+	}
+
+	// 讀取的 goroutine 跟著 Run 一起結束，Run 提早回傳（寫入失敗）時也不會卡在 window 上
+	runCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	window := make(chan struct{}, workers*2)
+	src := make(chan job[T])
+	var readErr error
+	readDone := make(chan struct{})
+	go func() {
+		defer close(readDone)
+		defer close(src)
+		for seq := 0; ; {
+			select {
+			case window <- struct{}{}:
+			case <-runCtx.Done():
+				return
+			}
+			row, err := reader.Read()
+			var rowErr *RowError
+			if errors.As(err, &rowErr) {
+				// 解析失敗的行不交給 worker，記下來之後繼續讀下一行
+				addErr(rowErr)
+				<-window
+				continue
+			}
+			if err != nil {
+				if err != io.EOF {
+					readErr = err
+				}
+				return
+			}
+			select {
+			case src <- job[T]{seq: seq, line: reader.Line(), row: row}:
+				seq++
+			case <-runCtx.Done():
+				return
+			}
+		}
+	}()
+
+	transform := pipeline.Transform(func(_ context.Context, j job[T]) (result[U], error) {
+		row, err := fn(j.row)
+		return result[U]{seq: j.seq, line: j.line, row: row, err: err}, nil
+	}, pipeline.WithWorkers(workers), pipeline.WithBuffer(workers))
+
+	pending := make(map[int]result[U])
+	next := 0
+	err = pipeline.Run(runCtx, src, transform, func(res result[U]) error {
+		pending[res.seq] = res
+		for {
+			res, ok := pending[next]
+			if !ok {
+				return nil
+			}
+			delete(pending, next)
+			next++
+			<-window
+			if res.err != nil {
+				addErr(&RowError{Line: res.line, Err: res.err})
+				continue
+			}
+			if err := writer.Write(res.row); err != nil {
+				return err
+			}
+		}
+	})
+	cancel()
+	<-readDone
+	if err == nil {
+		err = readErr
+	}
+	if err != nil && ctx.Err() == nil {
+		return rowErrs, err
+	}
+	if err := writer.Flush(); err != nil {
+		return rowErrs, err
+	}
+	// 解析錯誤與轉換錯誤來自不同 goroutine，依行號排序方便閱讀
+	sort.SliceStable(rowErrs, func(i, j int) bool { return rowErrs[i].Line < rowErrs[j].Line })
+	return rowErrs, ctx.Err()
+}
@@ -0,0 +1,116 @@
+package csvx
+
+import (
+	"encoding"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"advanced/reflectx"
+)
+
+/*
+欄位對應規則：
+	Name    string    `csv:"name"`
+	Created time.Time `csv:"created,layout=2006-01-02"`
+	Note    string    `csv:"-"`      // 略過
+沒有 tag 的欄位使用欄位名稱；CSV 的欄位順序以 header 為準，不需要與 struct 一致。
+*/
+
+type field struct {
+	index  []int // 給 FieldByIndex，嵌入的 struct 欄位被攤平
+	name   string
+	layout string
+}
+
+var textMarshaler = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+
+func schemaOf(t reflect.Type) ([]field, error) {
+	if t.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("csvx: %s is not a struct", t)
+	}
+	var fields []field
+	// CSV 是扁平的：每個欄位都是一欄，不往巢狀的 struct 裡走
+	err := reflectx.Walk(reflect.New(t).Interface(), func(f reflectx.Field) error {
+		name, opts := reflectx.TagName(f.Struct, "csv")
+		if name == "-" {
+			return reflectx.SkipStruct
+		}
+		if name == "" {
+			name = f.Struct.Name
+		}
+		fd := field{index: f.Index, name: name, layout: time.RFC3339}
+		for _, opt := range opts {
+			if strings.HasPrefix(opt, "layout=") {
+				fd.layout = strings.TrimPrefix(opt, "layout=")
+			}
+		}
+		fields = append(fields, fd)
+		return reflectx.SkipStruct
+	})
+	return fields, err
+}
+
+func decodeValue(v reflect.Value, s string, layout string) error {
+	// time.Time 也實作了 TextUnmarshaler，要先判斷才能套用 layout
+	if v.Type() == reflect.TypeOf(time.Time{}) {
+		if s == "" {
+			return nil
+		}
+		t, err := time.Parse(layout, s)
+		if err != nil {
+			return err
+		}
+		v.Set(reflect.ValueOf(t))
+		return nil
+	}
+	// 空字串的 pointer 欄位保持 nil
+	if v.Kind() == reflect.Ptr {
+		if s == "" {
+			return nil
+		}
+		p := reflect.New(v.Type().Elem())
+		if err := decodeValue(p.Elem(), s, layout); err != nil {
+			return err
+		}
+		v.Set(p)
+		return nil
+	}
+	return reflectx.FromString(v, s)
+}
+
+func encodeValue(v reflect.Value, layout string) (string, error) {
+	if t, ok := v.Interface().(time.Time); ok {
+		if t.IsZero() {
+			return "", nil
+		}
+		return t.Format(layout), nil
+	}
+	if v.Type().Implements(textMarshaler) {
+		if v.Kind() == reflect.Ptr && v.IsNil() {
+			return "", nil
+		}
+		b, err := v.Interface().(encoding.TextMarshaler).MarshalText()
+		return string(b), err
+	}
+	switch v.Kind() {
+	case reflect.Ptr:
+		if v.IsNil() {
+			return "", nil
+		}
+		return encodeValue(v.Elem(), layout)
+	case reflect.String:
+		return v.String(), nil
+	case reflect.Bool:
+		return strconv.FormatBool(v.Bool()), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(v.Int(), 10), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(v.Uint(), 10), nil
+	case reflect.Float32, reflect.Float64:
+		return strconv.FormatFloat(v.Float(), 'f', -1, v.Type().Bits()), nil
+	}
+	return "", fmt.Errorf("unsupported type %s", v.Type())
+}
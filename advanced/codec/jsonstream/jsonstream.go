@@ -0,0 +1,125 @@
+package jsonstream
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+/*
+json.Unmarshal 需要先把整份文件讀進記憶體，
+幾百 MB 的 JSON 陣列會讓記憶體用量跟檔案一樣大。
+
+json.Decoder 可以用 Token() 一個一個讀取 token：
+	[ {...} {...} ... ]
+先讀掉開頭的 '['，接著每次 Decode 一個元素，More() 為 false 時再讀掉結尾的 ']'，
+記憶體中同一時間只有一個元素。
+*/
+
+// ArrayDecoder 逐一解碼 JSON 陣列中的元素
+type ArrayDecoder[T any] struct {
+	dec     *json.Decoder
+	started bool
+	done    bool
+	index   int
+}
+
+func NewArrayDecoder[T any](r io.Reader) *ArrayDecoder[T] {
+	return &ArrayDecoder[T]{dec: json.NewDecoder(r)}
+}
+
+// Next 回傳下一個元素，陣列結束時回傳 io.EOF
+func (d *ArrayDecoder[T]) Next() (T, error) {
+	var v T
+	if d.done {
+		return v, io.EOF
+	}
+	if !d.started {
+		if err := expectDelim(d.dec, '['); err != nil {
+			return v, err
+		}
+		d.started = true
+	}
+	if !d.dec.More() {
+		d.done = true
+		if err := expectDelim(d.dec, ']'); err != nil {
+			return v, err
+		}
+		return v, io.EOF
+	}
+	if err := d.dec.Decode(&v); err != nil {
+		return v, fmt.Errorf("jsonstream: element %d: %w", d.index, err)
+	}
+	d.index++
+	return v, nil
+}
+
+func expectDelim(dec *json.Decoder, want json.Delim) error {
+	tok, err := dec.Token()
+	if err != nil {
+		if err == io.EOF {
+			return io.ErrUnexpectedEOF
+		}
+		return err
+	}
+	if d, ok := tok.(json.Delim); !ok || d != want {
+		return fmt.Errorf("jsonstream: expected %q, got %v", want, tok)
+	}
+	return nil
+}
+
+// DecodeArray 對每個元素呼叫 fn，fn 回傳錯誤時停止
+func DecodeArray[T any](r io.Reader, fn func(T) error) error {
+	d := NewArrayDecoder[T](r)
+	for {
+		v, err := d.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if err := fn(v); err != nil {
+			return err
+		}
+	}
+}
+
+// EncodeArray 把 channel 中的值寫成一個 JSON 陣列，channel 關閉時寫出結尾的 ']'；
+// ctx 取消時停止，但已寫出的內容不會是合法的 JSON
+func EncodeArray[T any](ctx context.Context, w io.Writer, in <-chan T) error {
+	bw := bufio.NewWriter(w)
+	if err := bw.WriteByte('['); err != nil {
+		return err
+	}
+	first := true
+	for {
+		select {
+		case <-ctx.Done():
+			_ = bw.Flush()
+			return ctx.Err()
+		case v, ok := <-in:
+			if !ok {
+				if err := bw.WriteByte(']'); err != nil {
+					return err
+				}
+				return bw.Flush()
+			}
+			if !first {
+				if err := bw.WriteByte(','); err != nil {
+					return err
+				}
+			}
+			first = false
+			b, err := json.Marshal(v)
+			if err != nil {
+				return err
+			}
+			if _, err := bw.Write(b); err != nil {
+				return err
+			}
+		}
+	}
+}
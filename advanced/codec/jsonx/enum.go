@@ -0,0 +1,73 @@
+package jsonx
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+/*
+Go 沒有 enum，常見寫法是 type Status string 加上一組常數，
+但 json.Unmarshal 不會檢查值是否合法，"pendingg" 也能成功解析。
+
+EnumSet 提供檢查，在自訂型別的 UnmarshalJSON 中使用：
+
+	var statuses = jsonx.NewEnumSet(Pending, Done)
+	func (s *Status) UnmarshalJSON(b []byte) error { return statuses.Unmarshal(b, s) }
+*/
+
+type EnumSet[T ~string] struct {
+	values []T
+	set    map[T]struct{}
+}
+
+func NewEnumSet[T ~string](values ...T) *EnumSet[T] {
+	set := make(map[T]struct{}, len(values))
+	for _, v := range values {
+		set[v] = struct{}{}
+	}
+	return &EnumSet[T]{values: values, set: set}
+}
+
+func (e *EnumSet[T]) Valid(v T) bool {
+	_, ok := e.set[v]
+	return ok
+}
+
+func (e *EnumSet[T]) Values() []T {
+	return append([]T(nil), e.values...)
+}
+
+// Parse 檢查字串是否為合法值
+func (e *EnumSet[T]) Parse(s string) (T, error) {
+	v := T(s)
+	if !e.Valid(v) {
+		names := make([]string, len(e.values))
+		for i, val := range e.values {
+			names[i] = string(val)
+		}
+		return v, fmt.Errorf("jsonx: invalid value %q, must be one of [%s]", s, strings.Join(names, ", "))
+	}
+	return v, nil
+}
+
+func (e *EnumSet[T]) Unmarshal(data []byte, v *T) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+	parsed, err := e.Parse(s)
+	if err != nil {
+		return err
+	}
+	*v = parsed
+	return nil
+}
+
+// Marshal 輸出時也檢查，避免程式內部誤用非法值
+func (e *EnumSet[T]) Marshal(v T) ([]byte, error) {
+	if _, err := e.Parse(string(v)); err != nil {
+		return nil, err
+	}
+	return json.Marshal(string(v))
+}
@@ -0,0 +1,192 @@
+package jsonx
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type Profile struct {
+	Name     string       `json:"name"`
+	Age      Null[int]    `json:"age"`
+	Nickname Null[string] `json:"nickname"`
+}
+
+func TestNull(t *testing.T) {
+	cases := []struct {
+		in   string
+		want Profile
+		out  string
+	}{
+		{`{"name":"a","age":0,"nickname":""}`, Profile{"a", NewNull(0), NewNull("")}, `{"name":"a","age":0,"nickname":""}`},
+		{`{"name":"b","age":null,"nickname":"bb"}`, Profile{"b", Null[int]{}, NewNull("bb")}, `{"name":"b","age":null,"nickname":"bb"}`},
+		{`{"name":"c"}`, Profile{Name: "c"}, `{"name":"c","age":null,"nickname":null}`},
+	}
+	for _, c := range cases {
+		var p Profile
+		assert.NoError(t, json.Unmarshal([]byte(c.in), &p))
+		assert.Equal(t, c.want, p)
+		out, err := json.Marshal(p)
+		assert.NoError(t, err)
+		assert.JSONEq(t, c.out, string(out))
+	}
+
+	var n Null[int]
+	assert.Error(t, json.Unmarshal([]byte(`"x"`), &n))
+	assert.False(t, n.Valid)
+	assert.Nil(t, n.Ptr())
+	assert.Equal(t, 7, n.Or(7))
+	assert.Equal(t, 3, *NewNull(3).Ptr())
+
+	// 重複使用時 null 會清掉舊值
+	n = NewNull(5)
+	assert.NoError(t, json.Unmarshal([]byte(`null`), &n))
+	assert.Equal(t, Null[int]{}, n)
+}
+
+func TestRFC3339Time(t *testing.T) {
+	taipei := time.FixedZone("CST", 8*3600)
+	v := struct {
+		At RFC3339Time `json:"at"`
+	}{RFC3339Time{time.Date(2022, 5, 6, 15, 4, 5, 999, taipei)}}
+	b, err := json.Marshal(v)
+	assert.NoError(t, err)
+	assert.Equal(t, `{"at":"2022-05-06T07:04:05Z"}`, string(b))
+
+	var got RFC3339Time
+	assert.NoError(t, json.Unmarshal([]byte(`"2022-05-06T15:04:05+08:00"`), &got))
+	assert.Equal(t, time.Date(2022, 5, 6, 7, 4, 5, 0, time.UTC), got.Time)
+
+	assert.NoError(t, json.Unmarshal([]byte(`null`), &got))
+	assert.True(t, got.IsZero())
+	b, _ = json.Marshal(got)
+	assert.Equal(t, "null", string(b))
+	assert.Error(t, json.Unmarshal([]byte(`"yesterday"`), &got))
+	assert.Error(t, json.Unmarshal([]byte(`123`), &got))
+}
+
+func TestUnixTime(t *testing.T) {
+	at := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+	b, err := json.Marshal(UnixTime{at})
+	assert.NoError(t, err)
+	assert.Equal(t, "1640995200", string(b))
+
+	for _, in := range []string{`1640995200`, `"1640995200"`} {
+		var u UnixTime
+		assert.NoError(t, json.Unmarshal([]byte(in), &u))
+		assert.Equal(t, at, u.Time)
+	}
+	var u UnixTime
+	assert.NoError(t, json.Unmarshal([]byte(`1640995200.5`), &u))
+	assert.Equal(t, at.Add(500*time.Millisecond), u.Time)
+	assert.Error(t, json.Unmarshal([]byte(`"soon"`), &u))
+	assert.NoError(t, json.Unmarshal([]byte(`null`), &u))
+	assert.True(t, u.IsZero())
+
+	b, err = json.Marshal(UnixMilliTime{at.Add(123 * time.Millisecond)})
+	assert.NoError(t, err)
+	assert.Equal(t, "1640995200123", string(b))
+	var ms UnixMilliTime
+	assert.NoError(t, json.Unmarshal(b, &ms))
+	assert.Equal(t, at.Add(123*time.Millisecond), ms.Time)
+	b, _ = json.Marshal(UnixMilliTime{})
+	assert.Equal(t, "null", string(b))
+}
+
+type Status string
+
+const (
+	Pending Status = "pending"
+	Done    Status = "done"
+)
+
+var statuses = NewEnumSet(Pending, Done)
+
+func (s Status) MarshalJSON() ([]byte, error)  { return statuses.Marshal(s) }
+func (s *Status) UnmarshalJSON(b []byte) error { return statuses.Unmarshal(b, s) }
+
+func TestEnum(t *testing.T) {
+	var s Status
+	assert.NoError(t, json.Unmarshal([]byte(`"done"`), &s))
+	assert.Equal(t, Done, s)
+
+	err := json.Unmarshal([]byte(`"pendingg"`), &s)
+	assert.EqualError(t, err, `jsonx: invalid value "pendingg", must be one of [pending, done]`)
+	assert.Error(t, json.Unmarshal([]byte(`1`), &s))
+
+	b, err := json.Marshal(Pending)
+	assert.NoError(t, err)
+	assert.Equal(t, `"pending"`, string(b))
+	_, err = json.Marshal(Status("bogus"))
+	assert.Error(t, err)
+
+	assert.Equal(t, []Status{Pending, Done}, statuses.Values())
+	assert.True(t, statuses.Valid(Done))
+}
+
+type Event interface {
+	Kind() string
+}
+
+type Created struct {
+	ID int `json:"id"`
+}
+
+type Deleted struct {
+	ID     int    `json:"id"`
+	Reason string `json:"reason"`
+}
+
+func (Created) Kind() string  { return "created" }
+func (*Deleted) Kind() string { return "deleted" }
+
+func newEvents() *Union[Event] {
+	u := NewUnion[Event]()
+	Register[Created](u, "created")
+	Register[*Deleted](u, "deleted")
+	return u
+}
+
+func TestUnionRoundTrip(t *testing.T) {
+	u := newEvents()
+	for _, e := range []Event{Created{ID: 1}, &Deleted{ID: 2, Reason: "spam"}} {
+		b, err := u.Marshal(e)
+		assert.NoError(t, err)
+		got, err := u.Unmarshal(b)
+		assert.NoError(t, err)
+		assert.Equal(t, e, got)
+	}
+
+	b, _ := u.Marshal(&Deleted{ID: 2, Reason: "spam"})
+	assert.JSONEq(t, `{"type":"deleted","data":{"id":2,"reason":"spam"}}`, string(b))
+}
+
+func TestUnionErrors(t *testing.T) {
+	u := newEvents()
+	_, err := u.Unmarshal([]byte(`{"type":"updated","data":{}}`))
+	assert.ErrorIs(t, err, ErrUnknownType)
+	_, err = u.Unmarshal([]byte(`{"type":"created","data":{"id":"x"}}`))
+	assert.ErrorContains(t, err, "decode created")
+	_, err = u.Unmarshal([]byte(`[]`))
+	assert.Error(t, err)
+	_, err = NewUnion[Event]().Marshal(Created{}) // 沒有註冊
+	assert.ErrorIs(t, err, ErrUnknownType)
+
+	got, err := u.Unmarshal([]byte(`{"type":"created"}`))
+	assert.NoError(t, err)
+	assert.Equal(t, Created{}, got)
+
+	assert.Panics(t, func() { Register[Created](u, "created") })
+	assert.Panics(t, func() { Register[string](u, "string") })
+}
+
+func TestDecodeAs(t *testing.T) {
+	b := []byte(`{"type":"created","data":{"id":9}}`)
+	c, err := DecodeAs[Created](b, "created")
+	assert.NoError(t, err)
+	assert.Equal(t, 9, c.ID)
+	_, err = DecodeAs[Deleted](b, "deleted")
+	assert.Error(t, err)
+}
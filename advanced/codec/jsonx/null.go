@@ -0,0 +1,61 @@
+package jsonx
+
+import (
+	"bytes"
+	"encoding/json"
+)
+
+/*
+Go 的零值無法區分「沒有值」與「值為 0 / 空字串」，
+用 *int 可以但到處都要判斷 nil。Null[T] 明確記錄是否有值：
+	{"age": null}  -> Null[int]{Valid: false}
+	{"age": 0}     -> Null[int]{Value: 0, Valid: true}
+
+注意：json 的 omitempty 對 struct 沒有作用，欄位不存在時 Null 會保持零值（Valid=false）。
+*/
+
+type Null[T any] struct {
+	Value T
+	Valid bool
+}
+
+func NewNull[T any](v T) Null[T] {
+	return Null[T]{Value: v, Valid: true}
+}
+
+// Ptr 轉成 pointer，沒有值時回傳 nil
+func (n Null[T]) Ptr() *T {
+	if !n.Valid {
+		return nil
+	}
+	v := n.Value
+	return &v
+}
+
+// Or 沒有值時回傳 def
+func (n Null[T]) Or(def T) T {
+	if !n.Valid {
+		return def
+	}
+	return n.Value
+}
+
+func (n Null[T]) MarshalJSON() ([]byte, error) {
+	if !n.Valid {
+		return []byte("null"), nil
+	}
+	return json.Marshal(n.Value)
+}
+
+func (n *Null[T]) UnmarshalJSON(data []byte) error {
+	if bytes.Equal(bytes.TrimSpace(data), []byte("null")) {
+		var zero T
+		n.Value, n.Valid = zero, false
+		return nil
+	}
+	if err := json.Unmarshal(data, &n.Value); err != nil {
+		return err
+	}
+	n.Valid = true
+	return nil
+}
@@ -0,0 +1,93 @@
+package jsonx
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// RFC3339Time 固定以 RFC3339（秒）輸出並統一轉成 UTC，
+// time.Time 預設輸出 RFC3339Nano，前端與其他語言常常解析不了奈秒
+type RFC3339Time struct {
+	time.Time
+}
+
+func (t RFC3339Time) MarshalJSON() ([]byte, error) {
+	if t.IsZero() {
+		return []byte("null"), nil
+	}
+	return json.Marshal(t.UTC().Format(time.RFC3339))
+}
+
+func (t *RFC3339Time) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		t.Time = time.Time{}
+		return nil
+	}
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+	parsed, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		return err
+	}
+	t.Time = parsed.UTC()
+	return nil
+}
+
+// UnixTime 以 epoch 秒數表示，例如 JWT 的 exp、iat
+type UnixTime struct {
+	time.Time
+}
+
+func (t UnixTime) MarshalJSON() ([]byte, error) {
+	if t.IsZero() {
+		return []byte("null"), nil
+	}
+	return []byte(fmt.Sprint(t.Unix())), nil
+}
+
+func (t *UnixTime) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		t.Time = time.Time{}
+		return nil
+	}
+	// 有些系統會送出小數或字串形式的秒數
+	var n json.Number
+	if err := json.Unmarshal(data, &n); err != nil {
+		return fmt.Errorf("jsonx: invalid unix time %s", data)
+	}
+	f, err := n.Float64()
+	if err != nil {
+		return err
+	}
+	sec := int64(f)
+	t.Time = time.Unix(sec, int64((f-float64(sec))*1e9)).UTC()
+	return nil
+}
+
+// UnixMilliTime 以 epoch 毫秒表示，JavaScript 的 Date.now() 就是這個格式
+type UnixMilliTime struct {
+	time.Time
+}
+
+func (t UnixMilliTime) MarshalJSON() ([]byte, error) {
+	if t.IsZero() {
+		return []byte("null"), nil
+	}
+	return []byte(fmt.Sprint(t.UnixMilli())), nil
+}
+
+func (t *UnixMilliTime) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		t.Time = time.Time{}
+		return nil
+	}
+	var ms int64
+	if err := json.Unmarshal(data, &ms); err != nil {
+		return err
+	}
+	t.Time = time.UnixMilli(ms).UTC()
+	return nil
+}
@@ -0,0 +1,94 @@
+package jsonx
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+)
+
+/*
+tagged union：同一個欄位可能是不同型別的資料，用 type 欄位區分
+	{"type": "created", "data": {"id": 1}}
+	{"type": "deleted", "data": {"id": 1, "reason": "spam"}}
+
+Union[I] 記錄 type 名稱與具體型別的對應，I 通常是所有事件共同的介面。
+*/
+
+var ErrUnknownType = errors.New("jsonx: unknown union type")
+
+type Envelope struct {
+	Type string          `json:"type"`
+	Data json.RawMessage `json:"data"`
+}
+
+type Union[I any] struct {
+	byName map[string]reflect.Type
+	byType map[reflect.Type]string
+}
+
+func NewUnion[I any]() *Union[I] {
+	return &Union[I]{
+		byName: make(map[string]reflect.Type),
+		byType: make(map[reflect.Type]string),
+	}
+}
+
+// Register 註冊一個具體型別，T 必須實作 I
+func Register[T any, I any](u *Union[I], name string) {
+	t := reflect.TypeOf((*T)(nil)).Elem()
+	if !t.Implements(reflect.TypeOf((*I)(nil)).Elem()) {
+		panic(fmt.Sprintf("jsonx: %s does not implement %s", t, reflect.TypeOf((*I)(nil)).Elem()))
+	}
+	if _, ok := u.byName[name]; ok {
+		panic("jsonx: duplicate union type " + name)
+	}
+	u.byName[name] = t
+	u.byType[t] = name
+}
+
+func (u *Union[I]) Marshal(v I) ([]byte, error) {
+	t := reflect.TypeOf(v)
+	name, ok := u.byType[t]
+	if !ok {
+		return nil, fmt.Errorf("%w: %v", ErrUnknownType, t)
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(Envelope{Type: name, Data: data})
+}
+
+func (u *Union[I]) Unmarshal(b []byte) (I, error) {
+	var zero I
+	var env Envelope
+	if err := json.Unmarshal(b, &env); err != nil {
+		return zero, err
+	}
+	t, ok := u.byName[env.Type]
+	if !ok {
+		return zero, fmt.Errorf("%w: %q", ErrUnknownType, env.Type)
+	}
+	p := reflect.New(t)
+	if len(env.Data) > 0 {
+		if err := json.Unmarshal(env.Data, p.Interface()); err != nil {
+			return zero, fmt.Errorf("jsonx: decode %s: %w", env.Type, err)
+		}
+	}
+	return p.Elem().Interface().(I), nil
+}
+
+// DecodeAs 不經過 Union，直接把 envelope 的 data 解成 T，並確認 type 相符
+func DecodeAs[T any](b []byte, name string) (T, error) {
+	var v T
+	var env Envelope
+	if err := json.Unmarshal(b, &env); err != nil {
+		return v, err
+	}
+	if env.Type != name {
+		return v, fmt.Errorf("jsonx: expected type %q, got %q", name, env.Type)
+	}
+	err := json.Unmarshal(env.Data, &v)
+	return v, err
+}
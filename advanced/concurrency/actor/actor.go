@@ -0,0 +1,284 @@
+package actor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime/debug"
+	"sync"
+	"time"
+
+	"advanced/timex"
+)
+
+/*
+角色模型（actor model）：狀態只屬於一個 goroutine，其他人不能直接碰，只能把訊息丟進它的 mailbox。
+
+	a := actor.Spawn(func() actor.Behavior[Msg] { return &counter{} })
+	a.Send(ctx, Inc{N: 2})
+	n, err := actor.Ask(ctx, a, func(reply chan<- int) Msg { return Get{Reply: reply} })
+	a.Stop()
+
+和「共用變數 + Mutex」相比：
+  - Behavior 的狀態一次只被一則訊息處理，不需要鎖，也不會有 data race
+  - 訊息依照進入 mailbox 的順序處理；mailbox 是有容量的 channel，滿了 Send 會等（或 ctx 結束）
+  - 要拿結果就在訊息裡帶一個 reply channel（Ask 幫忙做這件事）
+
+監督（supervision）：Receive panic 時依 Strategy 決定：
+  - Resume：丟掉這則訊息，狀態保留，繼續處理下一則
+  - Restart（預設）：丟掉這則訊息，用 factory 重新建立 Behavior（狀態歸零），
+    像 Erlang/Akka 一樣假設「壞掉的狀態」是 panic 的原因；WithMaxRestarts 時間窗內重啟太多次就停止
+  - Stop：停止 actor，Err() 回傳 *PanicError
+
+Stop 是優雅停止：不再接受新訊息，但已經進入 mailbox 的訊息都會處理完。
+Send 在 mailbox 的讀鎖內檢查 stopped 並送出，Stop 拿寫鎖設定 stopped，
+所以 Stop 之後不可能再有訊息進入 mailbox，「接受了卻沒處理」的訊息不會出現。
+*/
+
+var (
+	ErrStopped         = errors.New("actor: stopped")
+	ErrTooManyRestarts = errors.New("actor: too many restarts")
+	errUnknownStrategy = errors.New("actor: unknown strategy")
+)
+
+const (
+	defaultMailbox      = 64
+	defaultMaxRestarts  = 10
+	defaultRestartsSpan = time.Minute
+)
+
+// Behavior 處理訊息；同一個 Behavior 不會被同時呼叫
+type Behavior[M any] interface {
+	Receive(msg M)
+}
+
+// BehaviorFunc 讓一般函式當作 Behavior（狀態放在 closure 裡）
+type BehaviorFunc[M any] func(msg M)
+
+func (f BehaviorFunc[M]) Receive(msg M) { f(msg) }
+
+type Strategy int
+
+const (
+	Restart Strategy = iota
+	Resume
+	Stop
+)
+
+// PanicError 記錄讓 actor 出錯的訊息與 panic
+type PanicError struct {
+	Msg   any
+	Value any
+	Stack []byte
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("actor: panic handling %T: %v", e.Msg, e.Value)
+}
+
+type config struct {
+	mailbox     int
+	strategy    Strategy
+	maxRestarts int
+	within      time.Duration
+	onPanic     func(*PanicError)
+	clock       timex.Clock
+}
+
+type Option func(*config)
+
+// WithMailbox 設定 mailbox 容量，預設 64
+func WithMailbox(n int) Option {
+	return func(c *config) { c.mailbox = n }
+}
+
+// WithStrategy 設定 panic 時的處理方式，預設 Restart
+func WithStrategy(s Strategy) Option {
+	return func(c *config) { c.strategy = s }
+}
+
+// WithMaxRestarts 設定 within 時間內最多重啟 n 次，超過就停止；預設一分鐘 10 次
+func WithMaxRestarts(n int, within time.Duration) Option {
+	return func(c *config) { c.maxRestarts, c.within = n, within }
+}
+
+// WithClock 設定計算重啟時間窗的時鐘，預設 timex.Real
+func WithClock(c timex.Clock) Option {
+	return func(cfg *config) { cfg.clock = c }
+}
+
+// WithOnPanic 在每次 panic 時被呼叫（在 actor 的 goroutine 中），用來記 log 或計數
+func WithOnPanic(f func(*PanicError)) Option {
+	return func(c *config) { c.onPanic = f }
+}
+
+type Actor[M any] struct {
+	cfg     config
+	factory func() Behavior[M]
+	mailbox chan M
+
+	mu       sync.RWMutex // Send 持有讀鎖，Stop 持有寫鎖
+	stopped  bool
+	stopOnce sync.Once
+	stopping chan struct{}
+	done     chan struct{}
+	err      error // 在 close(done) 之前寫入
+
+	restarts []time.Time
+}
+
+// Spawn 以 factory 建立 Behavior 並啟動 actor；Restart 時會再呼叫 factory
+func Spawn[M any](factory func() Behavior[M], opts ...Option) *Actor[M] {
+	cfg := config{mailbox: defaultMailbox, maxRestarts: defaultMaxRestarts, within: defaultRestartsSpan, clock: timex.Real{}}
+	for _, o := range opts {
+		o(&cfg)
+	}
+	a := &Actor[M]{
+		cfg:      cfg,
+		factory:  factory,
+		mailbox:  make(chan M, cfg.mailbox),
+		stopping: make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+	go a.run()
+	return a
+}
+
+// Send 把 msg 放進 mailbox；mailbox 滿時等待，actor 已停止回傳 ErrStopped
+func (a *Actor[M]) Send(ctx context.Context, msg M) error {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	if a.stopped {
+		return ErrStopped
+	}
+	// mailbox 有空位時 select 會隨機挑，先確認 actor 沒有因為 panic 停止
+	select {
+	case <-a.done:
+		return ErrStopped
+	default:
+	}
+	select {
+	case a.mailbox <- msg:
+		return nil
+	case <-a.done: // 因為 panic 停止了，不會再有人讀 mailbox
+		return ErrStopped
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Stop 停止接受新訊息，等 mailbox 中的訊息處理完後返回；可以重複呼叫
+func (a *Actor[M]) Stop() {
+	a.stopOnce.Do(func() {
+		a.mu.Lock()
+		a.stopped = true
+		a.mu.Unlock()
+		close(a.stopping)
+	})
+	<-a.done
+}
+
+// Done 在 actor 結束時關閉
+func (a *Actor[M]) Done() <-chan struct{} { return a.done }
+
+// Err 回傳 actor 結束的原因：Stop 為 nil，Stop 策略為 *PanicError，重啟太多次為包住 *PanicError 的 ErrTooManyRestarts
+func (a *Actor[M]) Err() error {
+	select {
+	case <-a.done:
+		return a.err
+	default:
+		return nil
+	}
+}
+
+func (a *Actor[M]) run() {
+	defer close(a.done)
+	b := a.factory()
+	for {
+		select {
+		case msg := <-a.mailbox:
+			if !a.handle(&b, msg) {
+				return
+			}
+		case <-a.stopping:
+			// Stop 已經拿過寫鎖，不會再有新訊息進來，處理完剩下的就結束
+			for {
+				select {
+				case msg := <-a.mailbox:
+					if !a.handle(&b, msg) {
+						return
+					}
+				default:
+					return
+				}
+			}
+		}
+	}
+}
+
+// handle 處理一則訊息，回傳 false 表示 actor 要停止
+func (a *Actor[M]) handle(b *Behavior[M], msg M) (ok bool) {
+	defer func() {
+		r := recover()
+		if r == nil {
+			return
+		}
+		pe := &PanicError{Msg: msg, Value: r, Stack: debug.Stack()}
+		if a.cfg.onPanic != nil {
+			a.cfg.onPanic(pe)
+		}
+		ok = a.supervise(b, pe)
+	}()
+	(*b).Receive(msg)
+	return true
+}
+
+func (a *Actor[M]) supervise(b *Behavior[M], pe *PanicError) bool {
+	switch a.cfg.strategy {
+	case Resume:
+		return true
+	case Restart:
+		now := a.cfg.clock.Now()
+		kept := a.restarts[:0]
+		for _, t := range a.restarts {
+			if now.Sub(t) < a.cfg.within {
+				kept = append(kept, t)
+			}
+		}
+		a.restarts = append(kept, now)
+		if len(a.restarts) > a.cfg.maxRestarts {
+			a.err = fmt.Errorf("%w: %d within %v: %w", ErrTooManyRestarts, len(a.restarts), a.cfg.within, pe)
+			return false
+		}
+		*b = a.factory()
+		return true
+	case Stop:
+		a.err = pe
+		return false
+	}
+	a.err = fmt.Errorf("%w %d: %w", errUnknownStrategy, a.cfg.strategy, pe)
+	return false
+}
+
+// Ask 送出帶有 reply channel 的訊息並等待回覆；Receive panic 時不會有回覆，ctx 記得設期限
+func Ask[M, R any](ctx context.Context, a *Actor[M], build func(reply chan<- R) M) (R, error) {
+	var zero R
+	reply := make(chan R, 1)
+	if err := a.Send(ctx, build(reply)); err != nil {
+		return zero, err
+	}
+	select {
+	case r := <-reply:
+		return r, nil
+	case <-ctx.Done():
+		return zero, ctx.Err()
+	case <-a.done:
+		// 回覆和結束可能同時發生，select 是隨機挑的，再看一次 reply
+		select {
+		case r := <-reply:
+			return r, nil
+		default:
+			return zero, ErrStopped
+		}
+	}
+}
@@ -0,0 +1,205 @@
+package actor
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/goleak"
+
+	"advanced/timex"
+)
+
+func TestMain(m *testing.M) {
+	goleak.VerifyTestMain(m)
+}
+
+// 測試用的訊息：加到狀態上，"boom" 會 panic，"get" 回覆目前的狀態
+type msg struct {
+	op    string
+	n     int
+	reply chan<- []int
+}
+
+type recorder struct{ seen []int }
+
+func (r *recorder) Receive(m msg) {
+	switch m.op {
+	case "boom":
+		panic("boom")
+	case "get":
+		m.reply <- append([]int(nil), r.seen...)
+	default:
+		r.seen = append(r.seen, m.n)
+	}
+}
+
+func spawn(opts ...Option) *Actor[msg] {
+	return Spawn(func() Behavior[msg] { return &recorder{} }, opts...)
+}
+
+func get(t *testing.T, a *Actor[msg]) []int {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	seen, err := Ask(ctx, a, func(reply chan<- []int) msg { return msg{op: "get", reply: reply} })
+	require.NoError(t, err)
+	return seen
+}
+
+func TestMessagesInOrder(t *testing.T) {
+	a := spawn()
+	defer a.Stop()
+	for i := 0; i < 100; i++ {
+		require.NoError(t, a.Send(context.Background(), msg{n: i}))
+	}
+	seen := get(t, a)
+	require.Len(t, seen, 100)
+	for i, n := range seen {
+		assert.Equal(t, i, n)
+	}
+}
+
+// Stop 會處理完 mailbox 裡的訊息，之後的 Send 回傳 ErrStopped
+func TestStopDrainsMailbox(t *testing.T) {
+	var mu sync.Mutex
+	var handled []int
+	release := make(chan struct{})
+	a := Spawn(func() Behavior[int] {
+		return BehaviorFunc[int](func(n int) {
+			<-release
+			mu.Lock()
+			handled = append(handled, n)
+			mu.Unlock()
+		})
+	}, WithMailbox(10))
+	for i := 0; i < 5; i++ {
+		require.NoError(t, a.Send(context.Background(), i))
+	}
+	stopped := make(chan struct{})
+	go func() {
+		a.Stop()
+		close(stopped)
+	}()
+	require.Eventually(t, func() bool { return a.Send(context.Background(), 99) == ErrStopped }, time.Second, time.Millisecond)
+	close(release)
+	<-stopped
+	assert.Equal(t, []int{0, 1, 2, 3, 4}, handled)
+	assert.NoError(t, a.Err())
+	a.Stop() // 重複呼叫沒事
+}
+
+func TestSendRespectsContext(t *testing.T) {
+	release := make(chan struct{})
+	a := Spawn(func() Behavior[int] {
+		return BehaviorFunc[int](func(int) { <-release })
+	}, WithMailbox(1))
+	ctx := context.Background()
+	require.NoError(t, a.Send(ctx, 1)) // 處理中
+	require.Eventually(t, func() bool { return len(a.mailbox) == 0 }, time.Second, time.Millisecond)
+	require.NoError(t, a.Send(ctx, 2)) // 佔滿 mailbox
+
+	tctx, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, a.Send(tctx, 3), context.DeadlineExceeded)
+	close(release)
+	a.Stop()
+}
+
+func TestResumeKeepsState(t *testing.T) {
+	var panics []*PanicError
+	a := spawn(WithStrategy(Resume), WithOnPanic(func(pe *PanicError) { panics = append(panics, pe) }))
+	defer a.Stop()
+	ctx := context.Background()
+	_ = a.Send(ctx, msg{n: 1})
+	_ = a.Send(ctx, msg{op: "boom"})
+	_ = a.Send(ctx, msg{n: 2})
+	assert.Equal(t, []int{1, 2}, get(t, a))
+	require.Len(t, panics, 1)
+	assert.Equal(t, "boom", panics[0].Value)
+	assert.Equal(t, "boom", panics[0].Msg.(msg).op)
+}
+
+func TestRestartResetsState(t *testing.T) {
+	a := spawn()
+	defer a.Stop()
+	ctx := context.Background()
+	_ = a.Send(ctx, msg{n: 1})
+	_ = a.Send(ctx, msg{op: "boom"})
+	_ = a.Send(ctx, msg{n: 2})
+	assert.Equal(t, []int{2}, get(t, a), "a fresh behavior after the restart")
+}
+
+func TestTooManyRestarts(t *testing.T) {
+	a := spawn(WithMaxRestarts(2, time.Minute), WithClock(timex.NewFake(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))))
+	ctx := context.Background()
+
+	for i := 0; i < 2; i++ {
+		require.NoError(t, a.Send(ctx, msg{op: "boom"}))
+	}
+	assert.Empty(t, get(t, a), "two restarts are within the budget")
+
+	require.NoError(t, a.Send(ctx, msg{op: "boom"}))
+	<-a.Done()
+	assert.ErrorIs(t, a.Err(), ErrTooManyRestarts)
+	var pe *PanicError
+	assert.ErrorAs(t, a.Err(), &pe)
+	assert.ErrorIs(t, a.Send(ctx, msg{n: 1}), ErrStopped)
+	_, err := Ask(ctx, a, func(reply chan<- []int) msg { return msg{op: "get", reply: reply} })
+	assert.ErrorIs(t, err, ErrStopped)
+	a.Stop()
+}
+
+// 時間窗外的重啟不算
+func TestRestartWindowSlides(t *testing.T) {
+	clock := timex.NewFake(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	a := spawn(WithMaxRestarts(1, time.Minute), WithClock(clock))
+	defer a.Stop()
+	ctx := context.Background()
+	for i := 0; i < 5; i++ {
+		require.NoError(t, a.Send(ctx, msg{op: "boom"}))
+		get(t, a)
+		clock.Advance(2 * time.Minute)
+	}
+	assert.NoError(t, a.Err())
+}
+
+func TestStopStrategy(t *testing.T) {
+	a := spawn(WithStrategy(Stop))
+	require.NoError(t, a.Send(context.Background(), msg{op: "boom"}))
+	<-a.Done()
+	var pe *PanicError
+	require.ErrorAs(t, a.Err(), &pe)
+	assert.Contains(t, string(pe.Stack), "actor_test.go")
+	a.Stop()
+}
+
+// 多個 goroutine 同時 Send 與 Stop；配合 -race，被接受的訊息一定都被處理
+func TestConcurrentSendAndStop(t *testing.T) {
+	var handled int
+	a := Spawn(func() Behavior[int] {
+		return BehaviorFunc[int](func(int) { handled++ })
+	}, WithMailbox(4))
+	var accepted sync.WaitGroup
+	var mu sync.Mutex
+	ok := 0
+	for g := 0; g < 8; g++ {
+		accepted.Add(1)
+		go func() {
+			defer accepted.Done()
+			for i := 0; i < 200; i++ {
+				if a.Send(context.Background(), i) == nil {
+					mu.Lock()
+					ok++
+					mu.Unlock()
+				}
+			}
+		}()
+	}
+	time.Sleep(time.Millisecond)
+	a.Stop()
+	accepted.Wait()
+	assert.Equal(t, ok, handled)
+}
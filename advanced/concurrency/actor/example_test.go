@@ -0,0 +1,49 @@
+package actor_test
+
+import (
+	"context"
+	"fmt"
+
+	"advanced/concurrency/actor"
+)
+
+// 計數器 actor 的訊息：一個介面加上幾種具體型別，Receive 用 type switch 分派
+type CounterMsg interface{ counterMsg() }
+
+type Inc struct{ N int }
+
+type Get struct{ Reply chan<- int }
+
+func (Inc) counterMsg() {}
+func (Get) counterMsg() {}
+
+// counter 的 n 只會在 actor 的 goroutine 中讀寫，不需要鎖
+type counter struct{ n int }
+
+func (c *counter) Receive(msg CounterMsg) {
+	switch m := msg.(type) {
+	case Inc:
+		c.n += m.N
+	case Get:
+		m.Reply <- c.n
+	}
+}
+
+func Example_counter() {
+	ctx := context.Background()
+	a := actor.Spawn(func() actor.Behavior[CounterMsg] { return &counter{} })
+	defer a.Stop()
+
+	for i := 1; i <= 10; i++ {
+		go a.Send(ctx, Inc{N: i})
+	}
+	// 只保證每則訊息被處理一次，不保證不同 goroutine 送出的先後；這裡等到全部加完
+	for {
+		n, _ := actor.Ask(ctx, a, func(reply chan<- int) CounterMsg { return Get{Reply: reply} })
+		if n == 55 {
+			fmt.Println(n)
+			return
+		}
+	}
+	// Output: 55
+}
@@ -0,0 +1,187 @@
+package budget
+
+import (
+	"context"
+	"net/http"
+	"sync"
+
+	"advanced/ctxutil"
+)
+
+/*
+每個 request 的 goroutine 預算。
+
+API handler 常見的寫法是對每個子資源開一個 goroutine，子呼叫裡又再 fan-out，
+一個 request 10 × 10 就是 100 個 goroutine，流量一大 goroutine 數量以乘法放大（fan-out amplification）。
+
+做法是在 request 的 context 放一個 Budget，所有從這個 context 衍生的 Group 共用同一份額度：
+  - 有額度：開 goroutine 執行，結束後歸還額度
+  - 沒額度：
+      Inline：直接在呼叫端執行，等於退化成循序執行，永遠不會卡住
+      Queue ：放進 queue，等有 goroutine 結束時接著執行；呼叫端不會被卡住
+
+Queue 模式有一個陷阱：外層任務佔著額度在 Wait 內層任務，內層任務卻在 queue 裡等額度 → 死結。
+所以 Group.Wait 會先把自己還在 queue 裡的任務拿出來 inline 執行，再等其他已經在跑的任務。
+*/
+
+type Mode int
+
+const (
+	Inline Mode = iota
+	Queue
+)
+
+var key = ctxutil.NewKey[*Budget]("budget")
+
+type Stats struct {
+	Limit   int
+	InUse   int
+	Peak    int // 同時使用的最大額度
+	Spawned int // 開 goroutine 執行的任務數
+	Inline  int // 因為沒有額度而在呼叫端執行的任務數
+	Queued  int // 曾經進入 queue 的任務數
+}
+
+type task struct {
+	g  *Group
+	fn func()
+}
+
+type Budget struct {
+	mu    sync.Mutex
+	mode  Mode
+	queue []*task
+	stats Stats
+}
+
+func New(limit int, mode Mode) *Budget {
+	return &Budget{mode: mode, stats: Stats{Limit: limit}}
+}
+
+// With 把 b 放進 context，之後由這個 context 衍生的 Group 都共用 b 的額度
+func With(ctx context.Context, b *Budget) context.Context {
+	return key.With(ctx, b)
+}
+
+func From(ctx context.Context) (*Budget, bool) {
+	return key.From(ctx)
+}
+
+func (b *Budget) Stats() Stats {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.stats
+}
+
+// acquire 回傳任務該如何執行：true 表示已取得額度，要開 goroutine
+func (b *Budget) acquire() bool {
+	if b.stats.InUse < b.stats.Limit {
+		b.stats.InUse++
+		if b.stats.InUse > b.stats.Peak {
+			b.stats.Peak = b.stats.InUse
+		}
+		b.stats.Spawned++
+		return true
+	}
+	return false
+}
+
+// worker 執行 t 之後，持著同一份額度繼續執行 queue 中的任務，queue 空了才歸還
+func (b *Budget) worker(t *task) {
+	for t != nil {
+		t.fn()
+		b.mu.Lock()
+		t = nil
+		if len(b.queue) > 0 {
+			t = b.queue[0]
+			b.queue = b.queue[1:]
+		} else {
+			b.stats.InUse--
+		}
+		b.mu.Unlock()
+	}
+}
+
+// takeQueued 從 queue 中拿出屬於 g 的任務
+func (b *Budget) takeQueued(g *Group) *task {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for i, t := range b.queue {
+		if t.g == g {
+			b.queue = append(b.queue[:i], b.queue[i+1:]...)
+			return t
+		}
+	}
+	return nil
+}
+
+// Group 類似 errgroup：Go 執行任務、Wait 等全部完成並回傳第一個錯誤，任一任務失敗就取消 ctx
+type Group struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+	b      *Budget // nil 表示 context 中沒有預算，不限制
+	wg     sync.WaitGroup
+	once   sync.Once
+	err    error
+}
+
+func NewGroup(ctx context.Context) (*Group, context.Context) {
+	ctx, cancel := context.WithCancel(ctx)
+	b, _ := From(ctx)
+	return &Group{ctx: ctx, cancel: cancel, b: b}, ctx
+}
+
+func (g *Group) Go(fn func(ctx context.Context) error) {
+	g.wg.Add(1)
+	t := &task{g: g, fn: func() {
+		defer g.wg.Done()
+		if err := fn(g.ctx); err != nil {
+			g.once.Do(func() {
+				g.err = err
+				g.cancel()
+			})
+		}
+	}}
+	if g.b == nil {
+		go t.fn()
+		return
+	}
+
+	b := g.b
+	b.mu.Lock()
+	if b.acquire() {
+		b.mu.Unlock()
+		go b.worker(t)
+		return
+	}
+	if b.mode == Queue {
+		b.queue = append(b.queue, t)
+		b.stats.Queued++
+		b.mu.Unlock()
+		return
+	}
+	b.stats.Inline++
+	b.mu.Unlock()
+	t.fn()
+}
+
+func (g *Group) Wait() error {
+	if g.b != nil {
+		// 自己還在 queue 裡的任務由自己執行，避免佔著額度等待造成死結
+		for t := g.b.takeQueued(g); t != nil; t = g.b.takeQueued(g) {
+			t.fn()
+		}
+	}
+	g.wg.Wait()
+	g.cancel()
+	return g.err
+}
+
+// Middleware 為每個 request 建立一份 limit 大小的預算
+func Middleware(limit int, mode Mode) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			next.ServeHTTP(w, r.WithContext(With(r.Context(), New(limit, mode))))
+		})
+	}
+}
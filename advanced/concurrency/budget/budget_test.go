@@ -0,0 +1,129 @@
+package budget
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// 前兩個任務會卡住直到 release 關閉，額度因此一直被佔著
+func blockFirst(release chan struct{}, ran *atomic.Int32) func(i int) func(context.Context) error {
+	return func(i int) func(context.Context) error {
+		return func(context.Context) error {
+			if i < 2 {
+				<-release
+			}
+			ran.Add(1)
+			return nil
+		}
+	}
+}
+
+func TestInline(t *testing.T) {
+	b := New(2, Inline)
+	g, _ := NewGroup(With(context.Background(), b))
+	release := make(chan struct{})
+	var ran atomic.Int32
+	task := blockFirst(release, &ran)
+	for i := 0; i < 10; i++ {
+		g.Go(task(i))
+	}
+	// 沒有額度的 8 個任務已經在 Go 裡面執行完了
+	assert.EqualValues(t, 8, ran.Load())
+	close(release)
+	require.NoError(t, g.Wait())
+
+	assert.Equal(t, Stats{Limit: 2, Peak: 2, Spawned: 2, Inline: 8}, b.Stats())
+}
+
+func TestQueue(t *testing.T) {
+	b := New(2, Queue)
+	ctx := With(context.Background(), b)
+	g, _ := NewGroup(ctx)
+	release := make(chan struct{})
+	var ran atomic.Int32
+	task := blockFirst(release, &ran)
+	for i := 0; i < 10; i++ {
+		g.Go(task(i))
+	}
+	// Go 不會卡住，也不會在呼叫端執行
+	assert.EqualValues(t, 0, ran.Load())
+	assert.Equal(t, 8, b.Stats().Queued)
+	close(release)
+	require.NoError(t, g.Wait())
+	assert.EqualValues(t, 10, ran.Load())
+	s := b.Stats()
+	assert.Equal(t, 2, s.Peak)
+	assert.Equal(t, 0, s.InUse)
+}
+
+func TestNoBudgetIsUnlimited(t *testing.T) {
+	g, _ := NewGroup(context.Background())
+	var ran atomic.Int32
+	for i := 0; i < 5; i++ {
+		g.Go(func(context.Context) error { ran.Add(1); return nil })
+	}
+	require.NoError(t, g.Wait())
+	assert.EqualValues(t, 5, ran.Load())
+}
+
+func TestFirstErrorCancels(t *testing.T) {
+	g, ctx := NewGroup(With(context.Background(), New(4, Inline)))
+	boom := errors.New("boom")
+	g.Go(func(context.Context) error { return boom })
+	g.Go(func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	assert.ErrorIs(t, g.Wait(), boom)
+	assert.Error(t, ctx.Err())
+}
+
+// API handler：每個 request 對 6 個服務 fan-out，每個服務再對 6 個分片 fan-out。
+// 沒有預算時一個 request 會開 42 個 goroutine；有預算時同時使用的額度不超過 4，兩種模式都不會死結。
+func TestAPIFanOutIsBounded(t *testing.T) {
+	for _, mode := range []Mode{Inline, Queue} {
+		var budget *Budget
+		var calls atomic.Int32
+		handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			budget, _ = From(r.Context())
+			g, _ := NewGroup(r.Context())
+			for svc := 0; svc < 6; svc++ {
+				g.Go(func(ctx context.Context) error {
+					inner, _ := NewGroup(ctx)
+					for shard := 0; shard < 6; shard++ {
+						inner.Go(func(context.Context) error {
+							calls.Add(1)
+							return nil
+						})
+					}
+					return inner.Wait()
+				})
+			}
+			if err := g.Wait(); err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+			w.WriteHeader(http.StatusNoContent)
+		})
+
+		srv := httptest.NewServer(Middleware(4, mode)(handler))
+		resp, err := http.Get(srv.URL)
+		require.NoError(t, err)
+		resp.Body.Close()
+		srv.Close()
+
+		assert.Equal(t, http.StatusNoContent, resp.StatusCode)
+		assert.EqualValues(t, 36, calls.Load())
+		s := budget.Stats()
+		assert.LessOrEqual(t, s.Peak, 4)
+		assert.Equal(t, 0, s.InUse)
+		assert.Equal(t, 42, s.Spawned+s.Inline+s.Queued, "every task is accounted for")
+	}
+}
@@ -0,0 +1,214 @@
+package dagrun
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"advanced/timex"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func noop(context.Context) error { return nil }
+
+func TestValidate(t *testing.T) {
+	g := New()
+	require.NoError(t, g.Add("a", noop))
+	assert.ErrorIs(t, g.Add("a", noop), ErrDuplicate)
+	require.NoError(t, g.Add("b", noop, "missing"))
+	assert.ErrorIs(t, g.Validate(), ErrUnknownDep)
+
+	g = New()
+	require.NoError(t, g.Add("a", noop, "c"))
+	require.NoError(t, g.Add("b", noop, "a"))
+	require.NoError(t, g.Add("c", noop, "b"))
+	require.NoError(t, g.Add("d", noop))
+	_, err := g.Run(context.Background())
+	var ce *CycleError
+	require.ErrorAs(t, err, &ce)
+	assert.Equal(t, []string{"a", "c", "b", "a"}, ce.Path)
+	assert.EqualError(t, err, "dagrun: cycle: a -> c -> b -> a")
+}
+
+// 菱形相依：b、c 都依賴 a，d 依賴 b、c。b 與 c 必須同時執行
+func TestDiamondRunsInParallel(t *testing.T) {
+	var mu sync.Mutex
+	var order []string
+	record := func(name string) { mu.Lock(); order = append(order, name); mu.Unlock() }
+
+	barrier := make(chan struct{})
+	var arrived int32
+	meet := func(name string) TaskFunc {
+		return func(ctx context.Context) error {
+			record(name)
+			if atomic.AddInt32(&arrived, 1) == 2 {
+				close(barrier)
+			}
+			select {
+			case <-barrier:
+				return nil
+			case <-time.After(time.Second):
+				return errors.New("not parallel")
+			}
+		}
+	}
+
+	g := New()
+	require.NoError(t, g.Add("d", func(context.Context) error { record("d"); return nil }, "b", "c"))
+	require.NoError(t, g.Add("b", meet("b"), "a"))
+	require.NoError(t, g.Add("c", meet("c"), "a"))
+	require.NoError(t, g.Add("a", func(context.Context) error { record("a"); return nil }))
+
+	report, err := g.Run(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "a", order[0])
+	assert.ElementsMatch(t, []string{"b", "c"}, order[1:3])
+	assert.Equal(t, "d", order[3])
+	for _, r := range report.Results() {
+		assert.Equal(t, Succeeded, r.Status, r.Name)
+	}
+}
+
+func TestConcurrencyLimit(t *testing.T) {
+	var cur, peak int32
+	task := func(context.Context) error {
+		c := atomic.AddInt32(&cur, 1)
+		for {
+			p := atomic.LoadInt32(&peak)
+			if c <= p || atomic.CompareAndSwapInt32(&peak, p, c) {
+				break
+			}
+		}
+		time.Sleep(5 * time.Millisecond)
+		atomic.AddInt32(&cur, -1)
+		return nil
+	}
+	g := New()
+	for _, name := range []string{"a", "b", "c", "d", "e", "f"} {
+		require.NoError(t, g.Add(name, task))
+	}
+	_, err := g.Run(context.Background(), WithConcurrency(2))
+	require.NoError(t, err)
+	assert.EqualValues(t, 2, peak)
+}
+
+func buildFailing(t *testing.T, slowStarted chan struct{}) *Graph {
+	boom := errors.New("boom")
+	g := New()
+	require.NoError(t, g.Add("a", noop))
+	require.NoError(t, g.Add("fail", func(context.Context) error {
+		<-slowStarted
+		return boom
+	}, "a"))
+	require.NoError(t, g.Add("after-fail", noop, "fail"))
+	require.NoError(t, g.Add("after-after", noop, "after-fail"))
+	require.NoError(t, g.Add("slow", func(ctx context.Context) error {
+		close(slowStarted)
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(50 * time.Millisecond):
+			return nil
+		}
+	}, "a"))
+	require.NoError(t, g.Add("after-slow", noop, "slow"))
+	return g
+}
+
+func statuses(r *Report) map[string]Status {
+	m := make(map[string]Status)
+	for _, res := range r.Results() {
+		m[res.Name] = res.Status
+	}
+	return m
+}
+
+func TestFailFast(t *testing.T) {
+	report, err := buildFailing(t, make(chan struct{})).Run(context.Background())
+	var te *TaskError
+	require.ErrorAs(t, err, &te)
+	assert.Equal(t, "fail", te.Task)
+	assert.EqualError(t, te.Unwrap(), "boom")
+
+	assert.Equal(t, map[string]Status{
+		"a":           Succeeded,
+		"fail":        Failed,
+		"after-fail":  Skipped,
+		"after-after": Skipped,
+		"slow":        Cancelled, // 被 ctx 中斷
+		"after-slow":  Cancelled,
+	}, statuses(report))
+}
+
+func TestContinueOnError(t *testing.T) {
+	report, err := buildFailing(t, make(chan struct{})).Run(context.Background(), WithMode(ContinueOnError))
+	assert.ErrorContains(t, err, "boom")
+	assert.Equal(t, map[string]Status{
+		"a":           Succeeded,
+		"fail":        Failed,
+		"after-fail":  Skipped,
+		"after-after": Skipped,
+		"slow":        Succeeded,
+		"after-slow":  Succeeded,
+	}, statuses(report))
+}
+
+func TestParentCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	g := New()
+	require.NoError(t, g.Add("a", func(context.Context) error { cancel(); return nil }))
+	require.NoError(t, g.Add("b", noop, "a"))
+	report, err := g.Run(ctx)
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Equal(t, map[string]Status{"a": Succeeded, "b": Cancelled}, statuses(report))
+}
+
+func TestTimeline(t *testing.T) {
+	t0 := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	at := func(ms int) time.Time { return t0.Add(time.Duration(ms) * time.Millisecond) }
+	r := &Report{
+		Started:  t0,
+		Finished: at(100),
+		order:    []string{"fetch", "build", "lint", "release", "notify"},
+		results: map[string]*Result{
+			"fetch":   {Name: "fetch", Status: Succeeded, Start: at(0), End: at(20)},
+			"lint":    {Name: "lint", Status: Succeeded, Start: at(20), End: at(40)},
+			"build":   {Name: "build", Status: Succeeded, Start: at(20), End: at(60)},
+			"release": {Name: "release", Status: Failed, Err: errors.New("boom"), Start: at(60), End: at(100)},
+			"notify":  {Name: "notify", Status: Skipped},
+		},
+	}
+	want := "" +
+		"fetch   |##        |   20ms ok\n" +
+		"build   |  ####    |   40ms ok\n" +
+		"lint    |  ##      |   20ms ok\n" +
+		"release |      ####|   40ms failed: boom\n" +
+		"notify  |          |        skipped\n"
+	assert.Equal(t, want, r.Timeline(10))
+}
+
+// 以 timex.Fake 執行，任務的起訖時間完全由任務推進的時間決定
+func TestRunWithClock(t *testing.T) {
+	t0 := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	clock := timex.NewFake(t0)
+	step := func(d time.Duration) func(context.Context) error {
+		return func(context.Context) error { clock.Advance(d); return nil }
+	}
+	g := New()
+	require.NoError(t, g.Add("fetch", step(20*time.Millisecond)))
+	require.NoError(t, g.Add("build", step(40*time.Millisecond), "fetch"))
+	report, err := g.Run(context.Background(), WithClock(clock))
+	require.NoError(t, err)
+
+	assert.Equal(t, t0, report.Started)
+	assert.Equal(t, t0.Add(60*time.Millisecond), report.Finished)
+	build, ok := report.Result("build")
+	require.True(t, ok)
+	assert.Equal(t, t0.Add(20*time.Millisecond), build.Start)
+	assert.Equal(t, t0.Add(60*time.Millisecond), build.End)
+}
@@ -0,0 +1,120 @@
+package dagrun
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+/*
+DAG executor：宣告任務與相依關係，依拓撲順序執行，沒有相依關係的任務盡量並行。
+
+	g := dagrun.New()
+	g.Add("fetch", fetch)
+	g.Add("build", build, "fetch")
+	g.Add("lint", lint, "fetch")
+	g.Add("release", release, "build", "lint")
+	report, err := g.Run(ctx)
+
+Run 之前先檢查：
+  - 相依的任務不存在 → ErrUnknownDep
+  - 有環 → *CycleError，Path 為環上的任務（首尾相同），方便看出是哪幾個任務互相依賴
+*/
+
+var (
+	ErrDuplicate  = errors.New("dagrun: duplicate task")
+	ErrUnknownDep = errors.New("dagrun: unknown dependency")
+)
+
+type CycleError struct {
+	Path []string
+}
+
+func (e *CycleError) Error() string {
+	return "dagrun: cycle: " + strings.Join(e.Path, " -> ")
+}
+
+type TaskFunc func(ctx context.Context) error
+
+type task struct {
+	name string
+	fn   TaskFunc
+	deps []string
+}
+
+type Graph struct {
+	tasks map[string]*task
+	order []string // 加入順序，讓輸出穩定
+}
+
+func New() *Graph {
+	return &Graph{tasks: make(map[string]*task)}
+}
+
+// Add 加入任務；deps 可以是之後才加入的任務，Run 時才檢查
+func (g *Graph) Add(name string, fn TaskFunc, deps ...string) error {
+	if _, ok := g.tasks[name]; ok {
+		return fmt.Errorf("%w: %s", ErrDuplicate, name)
+	}
+	g.tasks[name] = &task{name: name, fn: fn, deps: append([]string(nil), deps...)}
+	g.order = append(g.order, name)
+	return nil
+}
+
+// Validate 檢查相依是否存在以及是否有環
+func (g *Graph) Validate() error {
+	for _, name := range g.order {
+		for _, d := range g.tasks[name].deps {
+			if _, ok := g.tasks[d]; !ok {
+				return fmt.Errorf("%w: %s needs %s", ErrUnknownDep, name, d)
+			}
+		}
+	}
+
+	// DFS 三色標記：white 未拜訪、gray 在目前路徑上、black 已完成。走到 gray 代表有環
+	const (
+		white = iota
+		gray
+		black
+	)
+	color := make(map[string]int, len(g.tasks))
+	var stack []string
+	var visit func(string) error
+	visit = func(n string) error {
+		color[n] = gray
+		stack = append(stack, n)
+		for _, d := range g.tasks[n].deps {
+			switch color[d] {
+			case gray:
+				i := indexOf(stack, d)
+				path := append(append([]string(nil), stack[i:]...), d)
+				return &CycleError{Path: path}
+			case white:
+				if err := visit(d); err != nil {
+					return err
+				}
+			}
+		}
+		stack = stack[:len(stack)-1]
+		color[n] = black
+		return nil
+	}
+	for _, name := range g.order {
+		if color[name] == white {
+			if err := visit(name); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func indexOf(s []string, v string) int {
+	for i, x := range s {
+		if x == v {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,90 @@
+package dagrun
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+/*
+Report 保存每個任務的結果，Timeline 畫出簡易的甘特圖，除錯時可以看出哪些任務有並行、誰在等誰：
+
+	fetch   |####                          |  10ms ok
+	build   |    ##########                |  25ms ok
+	lint    |    ######                    |  15ms ok
+	release |              ################|  40ms failed: boom
+	notify  |                              |       skipped
+*/
+
+type Report struct {
+	Started  time.Time
+	Finished time.Time
+
+	results map[string]*Result
+	order   []string
+}
+
+func (r *Report) Result(name string) (Result, bool) {
+	res, ok := r.results[name]
+	if !ok {
+		return Result{}, false
+	}
+	return *res, true
+}
+
+// Results 依開始時間排序，沒有執行的任務排在最後（依加入順序）
+func (r *Report) Results() []Result {
+	out := make([]Result, 0, len(r.order))
+	for _, name := range r.order {
+		out = append(out, *r.results[name])
+	}
+	sort.SliceStable(out, func(i, j int) bool {
+		a, b := out[i], out[j]
+		if a.Start.IsZero() != b.Start.IsZero() {
+			return !a.Start.IsZero()
+		}
+		return a.Start.Before(b.Start)
+	})
+	return out
+}
+
+// Timeline 以 width 個字元寬的長條表示整個執行期間
+func (r *Report) Timeline(width int) string {
+	results := r.Results()
+	total := r.Finished.Sub(r.Started)
+	nameWidth := 0
+	for _, res := range results {
+		if len(res.Name) > nameWidth {
+			nameWidth = len(res.Name)
+		}
+	}
+	col := func(t time.Time) int {
+		if total <= 0 {
+			return 0
+		}
+		return int(int64(t.Sub(r.Started)) * int64(width) / int64(total))
+	}
+
+	var b strings.Builder
+	for _, res := range results {
+		bar := []byte(strings.Repeat(" ", width))
+		dur := ""
+		if !res.Start.IsZero() {
+			from, to := col(res.Start), col(res.End)
+			if to == from && to < width {
+				to++ // 很短的任務至少畫一格
+			}
+			for i := from; i < to && i < width; i++ {
+				bar[i] = '#'
+			}
+			dur = res.End.Sub(res.Start).Round(time.Millisecond).String()
+		}
+		status := res.Status.String()
+		if res.Err != nil && res.Status == Failed {
+			status += ": " + res.Err.Error()
+		}
+		fmt.Fprintf(&b, "%-*s |%s| %6s %s\n", nameWidth, res.Name, bar, dur, status)
+	}
+	return b.String()
+}
@@ -0,0 +1,221 @@
+package dagrun
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"advanced/timex"
+)
+
+/*
+排程方式：只有一個 goroutine（Run 本身）管理狀態，任務在各自的 goroutine 執行，結束時把結果送回 done channel。
+每個任務記錄還沒完成的相依數，降到 0 時：
+  - 所有相依都成功 → 開始執行
+  - 有相依失敗或被略過 → 標記 Skipped，並繼續往下傳
+
+兩種錯誤處理模式：
+  - FailFast（預設）：第一個錯誤發生後取消 ctx，不再啟動新任務，未啟動的任務標記 Cancelled
+  - ContinueOnError：只略過依賴失敗任務的下游，其他分支照常執行
+*/
+
+type Status int
+
+const (
+	Pending Status = iota
+	Succeeded
+	Failed
+	Skipped   // 相依的任務失敗
+	Cancelled // FailFast 或 ctx 取消，沒有執行
+)
+
+func (s Status) String() string {
+	switch s {
+	case Succeeded:
+		return "ok"
+	case Failed:
+		return "failed"
+	case Skipped:
+		return "skipped"
+	case Cancelled:
+		return "cancelled"
+	}
+	return "pending"
+}
+
+type Mode int
+
+const (
+	FailFast Mode = iota
+	ContinueOnError
+)
+
+type TaskError struct {
+	Task string
+	Err  error
+}
+
+func (e *TaskError) Error() string { return fmt.Sprintf("dagrun: %s: %v", e.Task, e.Err) }
+func (e *TaskError) Unwrap() error { return e.Err }
+
+type Result struct {
+	Name   string
+	Status Status
+	Err    error
+	Start  time.Time // 沒有執行的任務為零值
+	End    time.Time
+}
+
+type config struct {
+	mode        Mode
+	concurrency int
+	clock       timex.Clock
+}
+
+type Option func(*config)
+
+func WithMode(m Mode) Option {
+	return func(c *config) { c.mode = m }
+}
+
+// WithConcurrency 限制同時執行的任務數，0 表示不限制
+func WithConcurrency(n int) Option {
+	return func(c *config) { c.concurrency = n }
+}
+
+// WithClock 設定記錄 Started、Finished 與每個任務起訖時間的時鐘，預設 timex.Real
+func WithClock(c timex.Clock) Option {
+	return func(cfg *config) { cfg.clock = c }
+}
+
+type done struct {
+	name       string
+	err        error
+	start, end time.Time
+}
+
+// Run 執行所有任務並回傳每個任務的結果；有任務失敗時 err 為所有 *TaskError 的 errors.Join
+func (g *Graph) Run(ctx context.Context, opts ...Option) (*Report, error) {
+	if err := g.Validate(); err != nil {
+		return nil, err
+	}
+	cfg := config{clock: timex.Real{}}
+	for _, o := range opts {
+		o(&cfg)
+	}
+	parent := ctx
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	remaining := make(map[string]int, len(g.tasks))
+	dependents := make(map[string][]string)
+	for _, name := range g.order {
+		t := g.tasks[name]
+		remaining[name] = len(t.deps)
+		for _, d := range t.deps {
+			dependents[d] = append(dependents[d], name)
+		}
+	}
+
+	report := &Report{Started: cfg.clock.Now(), results: make(map[string]*Result, len(g.tasks)), order: g.order}
+	for _, name := range g.order {
+		report.results[name] = &Result{Name: name}
+	}
+
+	var ready []string
+	for _, name := range g.order {
+		if remaining[name] == 0 {
+			ready = append(ready, name)
+		}
+	}
+
+	doneCh := make(chan done)
+	running := 0
+	stopping := false
+	var errs []error
+
+	// settle 在任務結束（或被略過）後更新下游
+	var settle func(name string)
+	settle = func(name string) {
+		for _, next := range dependents[name] {
+			remaining[next]--
+			if remaining[next] > 0 {
+				continue
+			}
+			switch st := g.depsStatus(report, next); st {
+			case Succeeded:
+				ready = append(ready, next)
+			default:
+				report.results[next].Status = st
+				settle(next)
+			}
+		}
+	}
+
+	for {
+		if ctx.Err() != nil {
+			stopping = true // 外部取消：不再啟動新任務，但仍要等執行中的任務回報
+		}
+		for !stopping && len(ready) > 0 && (cfg.concurrency == 0 || running < cfg.concurrency) {
+			name := ready[0]
+			ready = ready[1:]
+			running++
+			go func(t *task) {
+				d := done{name: t.name, start: cfg.clock.Now()}
+				d.err = t.fn(ctx)
+				d.end = cfg.clock.Now()
+				doneCh <- d
+			}(g.tasks[name])
+		}
+		if running == 0 {
+			break
+		}
+
+		d := <-doneCh
+		running--
+		r := report.results[d.name]
+		r.Start, r.End, r.Err = d.start, d.end, d.err
+		switch {
+		case d.err == nil:
+			r.Status = Succeeded
+		case stopping && errors.Is(d.err, context.Canceled):
+			// 因為其他任務失敗而被中斷，不算是這個任務的錯誤
+			r.Status = Cancelled
+		default:
+			r.Status = Failed
+			errs = append(errs, &TaskError{Task: d.name, Err: d.err})
+			if cfg.mode == FailFast {
+				stopping = true
+				cancel()
+			}
+		}
+		settle(d.name)
+	}
+
+	for _, r := range report.results {
+		if r.Status == Pending {
+			r.Status = Cancelled
+		}
+	}
+	report.Finished = cfg.clock.Now()
+	if len(errs) > 0 {
+		return report, errors.Join(errs...)
+	}
+	return report, parent.Err()
+}
+
+// depsStatus 決定相依都結束的任務該怎麼處理：全部成功才執行；
+// 有相依失敗或被略過就略過；剩下的情況是相依被取消，跟著取消
+func (g *Graph) depsStatus(report *Report, name string) Status {
+	st := Succeeded
+	for _, d := range g.tasks[name].deps {
+		switch report.results[d].Status {
+		case Failed, Skipped:
+			return Skipped
+		case Cancelled:
+			st = Cancelled
+		}
+	}
+	return st
+}
@@ -0,0 +1,171 @@
+package fanout
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"golang.org/x/time/rate"
+)
+
+/*
+Fan-out：把一批 task 分給多個 worker 並行處理，每個 task 產生一個 Result。
+
+實務上下游通常有限制，例如每個 API 金鑰每秒只能呼叫 N 次、每天最多 M 次：
+  - WithKeyLimit：每個 Task.Key（目的地、租戶…）各自一個 token bucket
+  - WithWorkerLimit：每個 worker 各自一個 token bucket（例如每個 worker 是一條連線）
+  - WithQuota：每個 Task.Key 每天的配額，計數存在 KV store，重啟後仍然有效
+超過配額的 task 不會執行，錯誤放在 Result.Err（*QuotaError），不影響其他 task。
+*/
+
+type Task[T any] struct {
+	Key   string
+	Input T
+}
+
+type Result[T, R any] struct {
+	Key    string
+	Input  T
+	Output R
+	Err    error
+}
+
+type config struct {
+	workers     int
+	keyLimit    rate.Limit
+	keyBurst    int
+	workerLimit rate.Limit
+	workerBurst int
+	quota       *Quota
+}
+
+type Option func(*config)
+
+// WithWorkers 設定 worker 數量，預設 4
+func WithWorkers(n int) Option {
+	return func(c *config) { c.workers = n }
+}
+
+// WithKeyLimit 讓每個 key 各自以 r 的速率執行，最多累積 burst 個
+func WithKeyLimit(r rate.Limit, burst int) Option {
+	return func(c *config) { c.keyLimit, c.keyBurst = r, burst }
+}
+
+// WithWorkerLimit 讓每個 worker 各自以 r 的速率執行
+func WithWorkerLimit(r rate.Limit, burst int) Option {
+	return func(c *config) { c.workerLimit, c.workerBurst = r, burst }
+}
+
+// WithQuota 以 q 限制每個 key 每天的執行次數
+func WithQuota(q *Quota) Option {
+	return func(c *config) { c.quota = q }
+}
+
+// limiters 依 key 延遲建立 limiter
+type limiters struct {
+	mu    sync.Mutex
+	r     rate.Limit
+	burst int
+	m     map[string]*rate.Limiter
+}
+
+func (l *limiters) get(key string) *rate.Limiter {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	lim, ok := l.m[key]
+	if !ok {
+		lim = rate.NewLimiter(l.r, l.burst)
+		l.m[key] = lim
+	}
+	return lim
+}
+
+// Stream 從 in 讀取 task 並行處理，in 關閉且全部處理完後關閉輸出 channel。
+// 結果的順序與輸入不同；ctx 取消後尚未執行的 task 以 ctx.Err() 作為錯誤回傳。
+func Stream[T, R any](ctx context.Context, in <-chan Task[T], fn func(context.Context, T) (R, error), opts ...Option) <-chan Result[T, R] {
+	cfg := config{workers: 4}
+	for _, o := range opts {
+		o(&cfg)
+	}
+	var byKey *limiters
+	if cfg.keyLimit != 0 {
+		byKey = &limiters{r: cfg.keyLimit, burst: cfg.keyBurst, m: make(map[string]*rate.Limiter)}
+	}
+
+	out := make(chan Result[T, R])
+	var wg sync.WaitGroup
+	for w := 0; w < cfg.workers; w++ {
+		var own *rate.Limiter
+		if cfg.workerLimit != 0 {
+			own = rate.NewLimiter(cfg.workerLimit, cfg.workerBurst)
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for t := range in {
+				out <- process(ctx, t, fn, &cfg, byKey, own)
+			}
+		}()
+	}
+	go func() {
+		wg.Wait()
+		close(out)
+	}()
+	return out
+}
+
+func process[T, R any](ctx context.Context, t Task[T], fn func(context.Context, T) (R, error), cfg *config, byKey *limiters, own *rate.Limiter) Result[T, R] {
+	res := Result[T, R]{Key: t.Key, Input: t.Input}
+	if own != nil {
+		if res.Err = own.Wait(ctx); res.Err != nil {
+			return res
+		}
+	}
+	if byKey != nil {
+		if res.Err = byKey.get(t.Key).Wait(ctx); res.Err != nil {
+			return res
+		}
+	}
+	if res.Err = ctx.Err(); res.Err != nil {
+		return res
+	}
+	// 配額在真正要執行前才扣，等待 rate limit 時被取消的 task 不佔配額
+	if cfg.quota != nil {
+		if res.Err = cfg.quota.Take(t.Key); res.Err != nil {
+			return res
+		}
+	}
+	res.Output, res.Err = safeCall(ctx, t.Input, fn)
+	return res
+}
+
+func safeCall[T, R any](ctx context.Context, in T, fn func(context.Context, T) (R, error)) (out R, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("fanout: panic: %v", r)
+		}
+	}()
+	return fn(ctx, in)
+}
+
+// Run 處理一批 task，結果依輸入順序排列
+func Run[T, R any](ctx context.Context, tasks []Task[T], fn func(context.Context, T) (R, error), opts ...Option) []Result[T, R] {
+	type indexed struct {
+		i int
+		T T
+	}
+	in := make(chan Task[indexed])
+	go func() {
+		defer close(in)
+		for i, t := range tasks {
+			in <- Task[indexed]{Key: t.Key, Input: indexed{i, t.Input}}
+		}
+	}()
+	wrapped := func(ctx context.Context, x indexed) (R, error) { return fn(ctx, x.T) }
+
+	results := make([]Result[T, R], len(tasks))
+	for r := range Stream(ctx, in, wrapped, opts...) {
+		results[r.Input.i] = Result[T, R]{Key: r.Key, Input: r.Input.T, Output: r.Output, Err: r.Err}
+	}
+	return results
+}
@@ -0,0 +1,131 @@
+package fanout
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"advanced/kv"
+	"advanced/timex"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/time/rate"
+)
+
+func double(_ context.Context, n int) (int, error) { return n * 2, nil }
+
+func tasks(key string, n int) []Task[int] {
+	ts := make([]Task[int], n)
+	for i := range ts {
+		ts[i] = Task[int]{Key: key, Input: i}
+	}
+	return ts
+}
+
+func TestRunKeepsOrder(t *testing.T) {
+	res := Run(context.Background(), tasks("a", 50), double, WithWorkers(8))
+	require.Len(t, res, 50)
+	for i, r := range res {
+		assert.NoError(t, r.Err)
+		assert.Equal(t, i, r.Input)
+		assert.Equal(t, i*2, r.Output)
+	}
+}
+
+func TestPanicBecomesError(t *testing.T) {
+	res := Run(context.Background(), tasks("a", 1), func(context.Context, int) (int, error) { panic("boom") })
+	assert.ErrorContains(t, res[0].Err, "boom")
+}
+
+// 每個 key 各自一個 limiter：兩個 key 並行，總時間約等於單一 key 的時間
+func TestKeyLimitIsPerKey(t *testing.T) {
+	ts := append(tasks("a", 4), tasks("b", 4)...)
+	start := time.Now()
+	res := Run(context.Background(), ts, double, WithWorkers(8), WithKeyLimit(20, 1))
+	elapsed := time.Since(start)
+	for _, r := range res {
+		assert.NoError(t, r.Err)
+	}
+	// 每個 key 第一個立即執行，之後每 50ms 一個：至少 150ms
+	assert.GreaterOrEqual(t, elapsed, 140*time.Millisecond)
+	// 共用 limiter 的話需要 350ms
+	assert.Less(t, elapsed, 300*time.Millisecond)
+}
+
+func TestWorkerLimit(t *testing.T) {
+	start := time.Now()
+	Run(context.Background(), tasks("a", 4), double, WithWorkers(1), WithWorkerLimit(20, 1))
+	assert.GreaterOrEqual(t, time.Since(start), 140*time.Millisecond)
+}
+
+func TestCancelWhileWaiting(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Millisecond)
+	defer cancel()
+	var calls int32
+	fn := func(ctx context.Context, n int) (int, error) {
+		atomic.AddInt32(&calls, 1)
+		return n, nil
+	}
+	res := Run(ctx, tasks("a", 5), fn, WithWorkers(5), WithKeyLimit(rate.Every(time.Second), 1))
+	var failed int
+	for _, r := range res {
+		if r.Err != nil {
+			failed++
+		}
+	}
+	assert.Equal(t, 4, failed)
+	assert.EqualValues(t, 1, atomic.LoadInt32(&calls))
+}
+
+func TestQuotaExceededInResult(t *testing.T) {
+	q := NewQuota(kv.New(), 3)
+	ts := append(tasks("a", 5), tasks("b", 2)...)
+	res := Run(context.Background(), ts, double, WithQuota(q))
+
+	var okA, exceeded int
+	for _, r := range res {
+		var qe *QuotaError
+		switch {
+		case r.Err == nil && r.Key == "a":
+			okA++
+		case errors.As(r.Err, &qe):
+			exceeded++
+			assert.Equal(t, "a", qe.Key)
+			assert.ErrorIs(t, r.Err, ErrQuotaExceeded)
+		default:
+			assert.NoError(t, r.Err)
+		}
+	}
+	assert.Equal(t, 3, okA)
+	assert.Equal(t, 2, exceeded)
+	assert.Equal(t, 3, q.Used("a"))
+	assert.Equal(t, 2, q.Used("b"))
+}
+
+func TestQuotaPersistedAndResetsDaily(t *testing.T) {
+	clock := timex.NewFake(time.Date(2024, 5, 1, 23, 0, 0, 0, time.UTC))
+
+	store := kv.New()
+	q := NewQuota(store, 2, WithClock(clock))
+	require.NoError(t, q.Take("a"))
+	require.NoError(t, q.Take("a"))
+	path := filepath.Join(t.TempDir(), "quota.json")
+	require.NoError(t, store.Save(path))
+
+	// 模擬重啟：從檔案載入計數，配額仍然用完
+	reloaded := kv.New()
+	require.NoError(t, reloaded.Load(path))
+	q = NewQuota(reloaded, 2, WithClock(clock))
+	var qe *QuotaError
+	require.ErrorAs(t, q.Take("a"), &qe)
+	assert.Equal(t, time.Date(2024, 5, 2, 0, 0, 0, 0, time.UTC), qe.Reset)
+
+	// 隔天重新計算
+	clock.Advance(2 * time.Hour)
+	assert.NoError(t, q.Take("a"))
+	assert.Equal(t, 1, q.Used("a"))
+}
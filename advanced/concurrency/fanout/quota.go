@@ -0,0 +1,90 @@
+package fanout
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"advanced/timex"
+)
+
+var ErrQuotaExceeded = errors.New("fanout: quota exceeded")
+
+// CounterStore 是 Quota 保存計數的地方，*kv.Store 即符合這個介面
+type CounterStore interface {
+	Get(key string) ([]byte, bool)
+	Set(key string, value []byte)
+}
+
+type QuotaError struct {
+	Key   string
+	Limit int
+	Reset time.Time // 配額重置的時間（UTC 隔天 00:00）
+}
+
+func (e *QuotaError) Error() string {
+	return fmt.Sprintf("fanout: quota exceeded for %q: %d per day, resets at %s", e.Key, e.Limit, e.Reset.Format(time.RFC3339))
+}
+
+func (e *QuotaError) Unwrap() error { return ErrQuotaExceeded }
+
+// Quota 以 UTC 日期為單位計數，計數存在 "quota/<key>/<yyyy-mm-dd>"。
+// 舊日期的計數不會自動刪除，需要的話由呼叫端定期清理。
+type Quota struct {
+	store CounterStore
+	limit int
+	clock timex.Clock
+	mu    sync.Mutex // CounterStore 沒有原子的遞增，讀取與寫回之間要持有鎖
+}
+
+type QuotaOption func(*Quota)
+
+// WithClock 設定 Quota 判斷日期用的時鐘，預設 timex.Real
+func WithClock(c timex.Clock) QuotaOption {
+	return func(q *Quota) { q.clock = c }
+}
+
+func NewQuota(store CounterStore, dailyLimit int, opts ...QuotaOption) *Quota {
+	q := &Quota{store: store, limit: dailyLimit, clock: timex.Real{}}
+	for _, o := range opts {
+		o(q)
+	}
+	return q
+}
+
+func counterKey(key string, day time.Time) string {
+	return "quota/" + key + "/" + day.Format("2006-01-02")
+}
+
+// Take 扣一次配額，用完時回傳 *QuotaError
+func (q *Quota) Take(key string) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	t := q.clock.Now().UTC()
+	ck := counterKey(key, t)
+	used := q.used(ck)
+	if used >= q.limit {
+		y, m, d := t.Date()
+		return &QuotaError{Key: key, Limit: q.limit, Reset: time.Date(y, m, d+1, 0, 0, 0, 0, time.UTC)}
+	}
+	q.store.Set(ck, []byte(strconv.Itoa(used+1)))
+	return nil
+}
+
+// Used 回傳 key 今天已使用的次數
+func (q *Quota) Used(key string) int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.used(counterKey(key, q.clock.Now().UTC()))
+}
+
+func (q *Quota) used(ck string) int {
+	b, ok := q.store.Get(ck)
+	if !ok {
+		return 0
+	}
+	n, _ := strconv.Atoi(string(b))
+	return n
+}
@@ -0,0 +1,163 @@
+package future
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime/debug"
+)
+
+/*
+把 basic/goroutine/goroutine_test.go 裡「開 goroutine、把結果塞進 channel、在另一邊等」的寫法包成型別：
+
+	// 原本
+	val := make(chan int)
+	go func() { val <- compute() }()
+	v := <-val                     // 沒有 error、不能取消等待、只能讀一次
+
+	// Future
+	f := future.Async(func() (int, error) { return compute() })
+	v, err := f.Get(ctx)           // 可以重複讀、多個 goroutine 同時等、ctx 結束就不等了
+
+實作只有一個 channel：結果寫入後 close(done)。close 對所有等待者廣播，
+而且 close 之前寫入的 val / err 對 <-done 之後的讀取可見（happens-before），不需要鎖。
+
+組合：
+  - Then(f, fn)：f 成功後把結果交給 fn，失敗則直接傳下去（Go 的 method 不能有型別參數，所以是函式）
+  - All(fs...)：全部成功才成功，結果依照傳入順序；任何一個失敗就立刻失敗，不等其他的
+  - Any(fs...)：第一個成功的結果；全部失敗時回傳 errors.Join 所有錯誤（對應 select 多路複用的「誰先到用誰」）
+
+注意 Get 的 ctx 只取消「等待」，不會停止已經在跑的工作；需要取消工作時把 ctx 傳進 Async 的函式裡。
+Async 裡的 panic 會轉成 *PanicError，不會讓整個程式掛掉。
+*/
+
+var ErrNoFutures = errors.New("future: no futures given")
+
+// PanicError 包住 Async 函式中的 panic 與當時的 stack
+type PanicError struct {
+	Value any
+	Stack []byte
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("future: panic: %v", e.Value)
+}
+
+type Future[T any] struct {
+	done chan struct{}
+	val  T
+	err  error
+}
+
+// Async 在新的 goroutine 執行 f，立刻回傳代表結果的 Future
+func Async[T any](f func() (T, error)) *Future[T] {
+	fut := &Future[T]{done: make(chan struct{})}
+	go func() {
+		defer close(fut.done)
+		defer func() {
+			if r := recover(); r != nil {
+				fut.err = &PanicError{Value: r, Stack: debug.Stack()}
+			}
+		}()
+		fut.val, fut.err = f()
+	}()
+	return fut
+}
+
+// Completed 回傳已經有結果的 Future，常用於測試或快取命中
+func Completed[T any](v T, err error) *Future[T] {
+	fut := &Future[T]{done: make(chan struct{}), val: v, err: err}
+	close(fut.done)
+	return fut
+}
+
+// Done 在結果出來時關閉，可以放進 select
+func (f *Future[T]) Done() <-chan struct{} { return f.done }
+
+// Get 等待結果；ctx 先結束時回傳 ctx.Err()，工作本身繼續執行，之後還可以再 Get
+func (f *Future[T]) Get(ctx context.Context) (T, error) {
+	select {
+	case <-f.done:
+		return f.val, f.err
+	default:
+	}
+	select {
+	case <-f.done:
+		return f.val, f.err
+	case <-ctx.Done():
+		var zero T
+		return zero, ctx.Err()
+	}
+}
+
+func (f *Future[T]) wait() (T, error) {
+	<-f.done
+	return f.val, f.err
+}
+
+// Then 在 f 成功後以它的結果執行 fn；f 失敗時不呼叫 fn，錯誤原樣傳下去
+func Then[T, U any](f *Future[T], fn func(T) (U, error)) *Future[U] {
+	return Async(func() (U, error) {
+		v, err := f.wait()
+		if err != nil {
+			var zero U
+			return zero, err
+		}
+		return fn(v)
+	})
+}
+
+type result[T any] struct {
+	i   int
+	val T
+	err error
+}
+
+// collect 為每個 future 開一個 goroutine 等結果，送進容量足夠的 channel；
+// 提早返回時剩下的 goroutine 也不會卡住，等各自的 future 完成後就結束
+func collect[T any](fs []*Future[T]) <-chan result[T] {
+	ch := make(chan result[T], len(fs))
+	for i, f := range fs {
+		go func(i int, f *Future[T]) {
+			v, err := f.wait()
+			ch <- result[T]{i, v, err}
+		}(i, f)
+	}
+	return ch
+}
+
+// All 等全部成功，結果依照傳入順序；第一個錯誤發生時立刻失敗
+func All[T any](fs ...*Future[T]) *Future[[]T] {
+	return Async(func() ([]T, error) {
+		out := make([]T, len(fs))
+		ch := collect(fs)
+		for range fs {
+			r := <-ch
+			if r.err != nil {
+				return nil, fmt.Errorf("future %d: %w", r.i, r.err)
+			}
+			out[r.i] = r.val
+		}
+		return out, nil
+	})
+}
+
+// Any 回傳第一個成功的結果；全部失敗時回傳所有錯誤的 errors.Join
+func Any[T any](fs ...*Future[T]) *Future[T] {
+	return Async(func() (T, error) {
+		var zero T
+		if len(fs) == 0 {
+			return zero, ErrNoFutures
+		}
+		errs := make([]error, len(fs))
+		ch := collect(fs)
+		for range fs {
+			r := <-ch
+			if r.err == nil {
+				return r.val, nil
+			}
+			errs[r.i] = fmt.Errorf("future %d: %w", r.i, r.err)
+		}
+		return zero, errors.Join(errs...)
+	})
+}
@@ -0,0 +1,157 @@
+package future
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/goleak"
+)
+
+func TestMain(m *testing.M) {
+	goleak.VerifyTestMain(m)
+}
+
+func after[T any](d time.Duration, v T, err error) *Future[T] {
+	return Async(func() (T, error) {
+		time.Sleep(d)
+		return v, err
+	})
+}
+
+func TestGetManyWaiters(t *testing.T) {
+	var calls int
+	f := Async(func() (int, error) {
+		calls++
+		time.Sleep(5 * time.Millisecond)
+		return 42, nil
+	})
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			v, err := f.Get(context.Background())
+			assert.NoError(t, err)
+			assert.Equal(t, 42, v)
+		}()
+	}
+	wg.Wait()
+	<-f.Done()
+	assert.Equal(t, 1, calls, "work runs once no matter how many times Get is called")
+}
+
+// ctx 只取消等待，結果之後還拿得到
+func TestGetContext(t *testing.T) {
+	release := make(chan struct{})
+	f := Async(func() (string, error) {
+		<-release
+		return "late", nil
+	})
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Millisecond)
+	defer cancel()
+	_, err := f.Get(ctx)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+	close(release)
+	v, err := f.Get(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "late", v)
+
+	// 已經有結果時，即使 ctx 已經取消也回傳結果
+	v, err = f.Get(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, "late", v)
+}
+
+func TestPanicBecomesError(t *testing.T) {
+	f := Async(func() (int, error) { panic("boom") })
+	_, err := f.Get(context.Background())
+	var pe *PanicError
+	require.ErrorAs(t, err, &pe)
+	assert.Equal(t, "boom", pe.Value)
+	assert.Contains(t, string(pe.Stack), "future_test.go")
+}
+
+func TestThen(t *testing.T) {
+	ctx := context.Background()
+	parsed := Then(Completed("41", nil), strconv.Atoi)
+	inc := Then(parsed, func(n int) (int, error) { return n + 1, nil })
+	v, err := inc.Get(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 42, v)
+
+	called := false
+	bad := Then(Then(Completed("x", nil), strconv.Atoi), func(n int) (int, error) {
+		called = true
+		return n, nil
+	})
+	_, err = bad.Get(ctx)
+	var ne *strconv.NumError
+	assert.ErrorAs(t, err, &ne)
+	assert.False(t, called, "errors short-circuit the chain")
+}
+
+func TestAll(t *testing.T) {
+	ctx := context.Background()
+	// 完成順序與傳入順序相反，結果仍依傳入順序
+	vs, err := All(
+		after(15*time.Millisecond, "a", nil),
+		after(10*time.Millisecond, "b", nil),
+		after(0, "c", nil),
+	).Get(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a", "b", "c"}, vs)
+
+	vs, err = All[string]().Get(ctx)
+	assert.NoError(t, err)
+	assert.Empty(t, vs)
+
+	// 第一個錯誤就失敗，不等慢的那個
+	boom := errors.New("boom")
+	slow := after(200*time.Millisecond, 1, nil)
+	begin := time.Now()
+	_, err = All(slow, after(0, 0, boom)).Get(ctx)
+	assert.ErrorIs(t, err, boom)
+	assert.EqualError(t, err, "future 1: boom")
+	assert.Less(t, time.Since(begin), 100*time.Millisecond)
+	<-slow.Done() // 讓 goleak 看到乾淨的結尾
+}
+
+func TestAny(t *testing.T) {
+	ctx := context.Background()
+	errA, errB := errors.New("a failed"), errors.New("b failed")
+
+	slow := after(200*time.Millisecond, "slow", nil)
+	v, err := Any(slow, after(0, "", errA), after(5*time.Millisecond, "fast", nil)).Get(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "fast", v, "first success wins, earlier failures are ignored")
+
+	_, err = Any(after(0, "", errA), after(5*time.Millisecond, "", errB)).Get(ctx)
+	assert.ErrorIs(t, err, errA)
+	assert.ErrorIs(t, err, errB)
+
+	_, err = Any[int]().Get(ctx)
+	assert.ErrorIs(t, err, ErrNoFutures)
+	<-slow.Done()
+}
+
+// 對照 goroutine_test.go 的 TestGoroutineUseSelect：誰先完成就用誰
+func TestSelectOnDone(t *testing.T) {
+	first := after(20*time.Millisecond, "first goroutine", nil)
+	second := after(0, "sec goroutine", nil)
+	var got string
+	select {
+	case <-first.Done():
+		got, _ = first.Get(context.Background())
+	case <-second.Done():
+		got, _ = second.Get(context.Background())
+	}
+	assert.Equal(t, "sec goroutine", got)
+	<-first.Done()
+}
@@ -0,0 +1,112 @@
+package group
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+/*
+從零實作 golang.org/x/sync/errgroup，看它是怎麼組起來的：
+
+	g, ctx := group.WithContext(ctx)
+	g.SetLimit(4)
+	for _, url := range urls {
+		g.Go(func() error { return fetch(ctx, url) })
+	}
+	err := g.Wait() // 第一個錯誤
+
+只用到三樣東西：
+  - sync.WaitGroup：Go 時 Add(1)，goroutine 結束時 Done，Wait 等全部結束
+  - sync.Once：只記下第一個錯誤；同時記下錯誤並取消 ctx，
+    其他還在跑的兄弟 goroutine 從 ctx.Done() 得知「有人失敗了」而提早結束（不求同生只求同死）
+  - 容量 n 的 channel 當 semaphore：Go 先送一個 token 進去才開 goroutine，滿了就卡在 Go 這裡；
+    goroutine 結束時取出 token。TryGo 用 select default，滿了直接回傳 false
+
+取消用 context.WithCancelCause，兄弟 goroutine 可以用 context.Cause(ctx) 拿到真正的原因，而不只是 context.Canceled。
+Wait 結束時也會取消 ctx：WithContext 回傳的 ctx 只屬於這一組 goroutine，Wait 之後不應該再被使用。
+和 errgroup 一樣，goroutine 中的 panic 不會被攔截。
+*/
+
+type token struct{}
+
+// Group 的零值可以直接使用：不限制數量、出錯時不取消任何東西
+type Group struct {
+	cancel func(error)
+	wg     sync.WaitGroup
+	sem    chan token
+
+	errOnce sync.Once
+	err     error
+}
+
+// WithContext 回傳新的 Group 與衍生的 ctx；第一個錯誤發生或 Wait 返回時 ctx 會被取消
+func WithContext(ctx context.Context) (*Group, context.Context) {
+	ctx, cancel := context.WithCancelCause(ctx)
+	return &Group{cancel: cancel}, ctx
+}
+
+func (g *Group) done() {
+	if g.sem != nil {
+		<-g.sem
+	}
+	g.wg.Done()
+}
+
+// Go 在新的 goroutine 執行 f；設了 SetLimit 且已滿時會等到有 goroutine 結束
+func (g *Group) Go(f func() error) {
+	if g.sem != nil {
+		g.sem <- token{}
+	}
+	g.start(f)
+}
+
+// TryGo 只有在沒有超過 SetLimit 時才執行 f，回傳是否有執行
+func (g *Group) TryGo(f func() error) bool {
+	if g.sem != nil {
+		select {
+		case g.sem <- token{}:
+		default:
+			return false
+		}
+	}
+	g.start(f)
+	return true
+}
+
+func (g *Group) start(f func() error) {
+	g.wg.Add(1)
+	go func() {
+		defer g.done()
+		if err := f(); err != nil {
+			g.errOnce.Do(func() {
+				g.err = err
+				if g.cancel != nil {
+					g.cancel(err)
+				}
+			})
+		}
+	}()
+}
+
+// Wait 等所有 goroutine 結束，回傳第一個非 nil 的錯誤
+func (g *Group) Wait() error {
+	g.wg.Wait()
+	if g.cancel != nil {
+		g.cancel(g.err)
+	}
+	return g.err
+}
+
+// SetLimit 限制同時執行的 goroutine 數，n < 0 表示不限制。
+// 有 goroutine 在跑時不能修改（token 已經送進舊的 channel，換掉 channel 會讓計數錯亂）
+func (g *Group) SetLimit(n int) {
+	if n < 0 {
+		g.sem = nil
+		return
+	}
+	if len(g.sem) != 0 {
+		panic(fmt.Errorf("group: modify limit while %v goroutines in the group are still active", len(g.sem)))
+	}
+	g.sem = make(chan token, n)
+}
@@ -0,0 +1,204 @@
+package group
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/goleak"
+	"golang.org/x/sync/errgroup"
+)
+
+func TestMain(m *testing.M) {
+	goleak.VerifyTestMain(m)
+}
+
+type group interface {
+	Go(f func() error)
+	TryGo(f func() error) bool
+	Wait() error
+	SetLimit(n int)
+}
+
+// 同一組情境跑在自己的實作與 errgroup 上，行為應該相同
+var impls = map[string]func(ctx context.Context) (group, context.Context){
+	"ours": func(ctx context.Context) (group, context.Context) {
+		return WithContext(ctx)
+	},
+	"errgroup": func(ctx context.Context) (group, context.Context) {
+		return errgroup.WithContext(ctx)
+	},
+}
+
+// 兄弟 goroutine 不求同生只求同死：一個失敗，其他的從 ctx 得知並提早結束
+func TestFirstErrorCancelsSiblings(t *testing.T) {
+	boom := errors.New("boom")
+	for name, newGroup := range impls {
+		t.Run(name, func(t *testing.T) {
+			g, ctx := newGroup(context.Background())
+			var cancelled atomic.Int32
+			for i := 0; i < 5; i++ {
+				g.Go(func() error {
+					select {
+					case <-ctx.Done():
+						cancelled.Add(1)
+						return ctx.Err()
+					case <-time.After(10 * time.Second):
+						return nil
+					}
+				})
+			}
+			g.Go(func() error {
+				time.Sleep(10 * time.Millisecond)
+				return boom
+			})
+
+			begin := time.Now()
+			assert.ErrorIs(t, g.Wait(), boom, "first error wins, not the siblings' context.Canceled")
+			assert.Less(t, time.Since(begin), time.Second)
+			assert.EqualValues(t, 5, cancelled.Load())
+			assert.ErrorIs(t, context.Cause(ctx), boom, "siblings can see why they were cancelled")
+		})
+	}
+}
+
+func TestOnlyFirstErrorKept(t *testing.T) {
+	for name, newGroup := range impls {
+		t.Run(name, func(t *testing.T) {
+			g, _ := newGroup(context.Background())
+			first := errors.New("first")
+			release := make(chan struct{})
+			g.Go(func() error { return first })
+			for i := 0; i < 3; i++ {
+				g.Go(func() error {
+					<-release
+					return errors.New("later")
+				})
+			}
+			time.Sleep(10 * time.Millisecond)
+			close(release)
+			assert.Equal(t, first, g.Wait())
+		})
+	}
+}
+
+func TestWaitCancelsContext(t *testing.T) {
+	for name, newGroup := range impls {
+		t.Run(name, func(t *testing.T) {
+			g, ctx := newGroup(context.Background())
+			g.Go(func() error { return nil })
+			assert.NoError(t, g.Wait())
+			assert.ErrorIs(t, ctx.Err(), context.Canceled)
+		})
+	}
+}
+
+// 父 ctx 取消也會傳到每一個 goroutine
+func TestParentCancellation(t *testing.T) {
+	for name, newGroup := range impls {
+		t.Run(name, func(t *testing.T) {
+			parent, cancel := context.WithCancel(context.Background())
+			g, ctx := newGroup(parent)
+			for i := 0; i < 3; i++ {
+				g.Go(func() error {
+					<-ctx.Done()
+					return ctx.Err()
+				})
+			}
+			cancel()
+			assert.ErrorIs(t, g.Wait(), context.Canceled)
+		})
+	}
+}
+
+func TestSetLimit(t *testing.T) {
+	for name, newGroup := range impls {
+		t.Run(name, func(t *testing.T) {
+			g, _ := newGroup(context.Background())
+			g.SetLimit(2)
+			var running, peak atomic.Int32
+			for i := 0; i < 20; i++ {
+				g.Go(func() error {
+					n := running.Add(1)
+					for {
+						p := peak.Load()
+						if n <= p || peak.CompareAndSwap(p, n) {
+							break
+						}
+					}
+					time.Sleep(time.Millisecond)
+					running.Add(-1)
+					return nil
+				})
+			}
+			require.NoError(t, g.Wait())
+			assert.EqualValues(t, 2, peak.Load())
+		})
+	}
+}
+
+func TestTryGo(t *testing.T) {
+	for name, newGroup := range impls {
+		t.Run(name, func(t *testing.T) {
+			g, _ := newGroup(context.Background())
+			g.SetLimit(1)
+			release := make(chan struct{})
+			assert.True(t, g.TryGo(func() error {
+				<-release
+				return nil
+			}))
+			assert.False(t, g.TryGo(func() error { return nil }), "limit reached")
+			assert.Panics(t, func() { g.SetLimit(3) }, "cannot change limit while active")
+
+			close(release)
+			require.NoError(t, g.Wait())
+			assert.True(t, g.TryGo(func() error { return nil }))
+			require.NoError(t, g.Wait())
+		})
+	}
+}
+
+// Go 在額度滿時卡住呼叫端，直到有 goroutine 結束
+func TestGoBlocksAtLimit(t *testing.T) {
+	var g Group
+	g.SetLimit(1)
+	release := make(chan struct{})
+	g.Go(func() error {
+		<-release
+		return nil
+	})
+	started := make(chan struct{})
+	go func() {
+		g.Go(func() error { return nil })
+		close(started)
+	}()
+	select {
+	case <-started:
+		t.Fatal("Go should block while the limit is reached")
+	case <-time.After(20 * time.Millisecond):
+	}
+	close(release)
+	<-started
+	assert.NoError(t, g.Wait())
+}
+
+// 零值不需要 WithContext 也能用，只是沒有 ctx 可以取消
+func TestZeroGroup(t *testing.T) {
+	var g Group
+	var n atomic.Int32
+	for i := 0; i < 10; i++ {
+		g.Go(func() error {
+			n.Add(1)
+			return nil
+		})
+	}
+	assert.NoError(t, g.Wait())
+	assert.EqualValues(t, 10, n.Load())
+
+	g.Go(func() error { return errors.New("x") })
+	assert.EqualError(t, g.Wait(), "x")
+}
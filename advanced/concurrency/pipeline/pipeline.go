@@ -0,0 +1,227 @@
+package pipeline
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"advanced/metrics"
+)
+
+/*
+以 channel 串接的 pipeline，每個 stage 是 N 個 goroutine，stage 之間是有容量的 channel：
+
+	Source ──ch──> stage A ──ch──> stage B ──ch──> consumer
+
+不需要 semaphore：下游變慢時 channel 會滿，上游的 send 就會卡住，壓力一路往回傳（backpressure）。
+但「卡在哪裡」從外面看不出來，所以每個 stage 記錄三種 metric（名稱為 pipeline.<pipeline>.<stage>.<metric>）：
+
+  - queue   ：每次取出資料時，輸入 channel 中還在排隊的筆數（取平均看佔用率）
+  - latency ：fn 的執行時間
+  - stall   ：送往下游時被卡住的時間
+
+瓶頸 stage 的特徵：它的輸入 queue 幾乎是滿的、下游的 queue 幾乎是空的，
+而它上游的 stage 因為送不出去，stall 時間很長。Bottleneck 依 queue 佔用率找出這個 stage。
+*/
+
+type Pipeline struct {
+	name   string
+	reg    *metrics.Registry
+	mu     sync.Mutex
+	stages []*stage
+}
+
+type stage struct {
+	name      string
+	capacity  int // 輸入 channel 的容量
+	processed *metrics.Counter
+	queue     *metrics.Summary
+	latency   *metrics.Summary
+	stall     *metrics.Summary
+}
+
+// New 建立 pipeline，reg 為 nil 時使用 metrics.Default
+func New(name string, reg *metrics.Registry) *Pipeline {
+	if reg == nil {
+		reg = metrics.Default
+	}
+	return &Pipeline{name: name, reg: reg}
+}
+
+func (p *Pipeline) stage(name string, capacity int) *stage {
+	prefix := "pipeline." + p.name + "." + name + "."
+	s := &stage{
+		name:      name,
+		capacity:  capacity,
+		processed: p.reg.Counter(prefix + "processed"),
+		queue:     p.reg.Summary(prefix + "queue"),
+		latency:   p.reg.Summary(prefix + "latency"),
+		stall:     p.reg.Summary(prefix + "stall"),
+	}
+	p.mu.Lock()
+	p.stages = append(p.stages, s)
+	p.mu.Unlock()
+	return s
+}
+
+type stageConfig struct {
+	workers  int
+	buffer   int
+	pipeline *Pipeline
+	name     string
+}
+
+type StageOption func(*stageConfig)
+
+// WithWorkers 設定 stage 的 goroutine 數，預設 1
+func WithWorkers(n int) StageOption {
+	return func(c *stageConfig) { c.workers = n }
+}
+
+// WithBuffer 設定輸出 channel 的容量，預設 1
+func WithBuffer(n int) StageOption {
+	return func(c *stageConfig) { c.buffer = n }
+}
+
+// WithMetrics 把 stage 的 metric 以 name 記錄到 p；Map 一定會記錄，其他 Stage 需要指定
+func WithMetrics(p *Pipeline, name string) StageOption {
+	return func(c *stageConfig) { c.pipeline, c.name = p, name }
+}
+
+func newStageConfig(opts []StageOption) stageConfig {
+	cfg := stageConfig{workers: 1, buffer: 1}
+	for _, o := range opts {
+		o(&cfg)
+	}
+	return cfg
+}
+
+// register 在 stage 開始執行時登記 metric，沒有 WithMetrics 時回傳 nil
+func (c stageConfig) register(capacity int) *stage {
+	if c.pipeline == nil {
+		return nil
+	}
+	return c.pipeline.stage(c.name, capacity)
+}
+
+// Source 把 items 依序送進 channel，ctx 取消時提早結束
+func Source[T any](ctx context.Context, items []T, buffer int) <-chan T {
+	out := make(chan T, buffer)
+	go func() {
+		defer close(out)
+		for _, v := range items {
+			select {
+			case out <- v:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return out
+}
+
+// Map 新增一個 stage：從 in 讀取，經過 fn 後送到回傳的 channel；in 關閉且處理完後關閉輸出
+func Map[In, Out any](ctx context.Context, p *Pipeline, name string, in <-chan In, fn func(In) Out, opts ...StageOption) <-chan Out {
+	s := Transform(func(_ context.Context, v In) (Out, error) { return fn(v), nil }, append(opts, WithMetrics(p, name))...)
+	out, _ := s(ctx, in) // fn 不會出錯，錯誤 channel 只會被關閉
+	return out
+}
+
+// work 是每個 worker 的迴圈：取出資料、呼叫 fn、把結果送往下游，s 不為 nil 時記錄 metric。
+// fn 回傳 keep=false 時不送出；回傳 drained=true 表示 in 已經關閉且處理完
+func work[In, Out any](ctx context.Context, s *stage, in <-chan In, out chan<- Out, fn func(In) (r Out, keep bool, err error)) (drained bool, err error) {
+	for {
+		var v In
+		var ok bool
+		select {
+		case v, ok = <-in:
+		case <-ctx.Done():
+			return false, nil
+		}
+		if !ok {
+			return true, nil
+		}
+		if s != nil {
+			s.queue.Observe(int64(len(in)))
+		}
+
+		start := time.Now()
+		r, keep, err := fn(v)
+		if err != nil {
+			return false, err
+		}
+		if s != nil {
+			s.latency.ObserveDuration(time.Since(start))
+			s.processed.Add(1)
+		}
+		if keep && !send(ctx, s, out, r) {
+			return false, nil
+		}
+	}
+}
+
+// send 送往下游，ctx 結束時回傳 false；先試著直接送，送不出去才開始計算 stall
+func send[T any](ctx context.Context, s *stage, out chan<- T, v T) bool {
+	select {
+	case out <- v:
+		return true
+	default:
+	}
+	blocked := time.Now()
+	select {
+	case out <- v:
+		if s != nil {
+			s.stall.ObserveDuration(time.Since(blocked))
+		}
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+type StageStats struct {
+	Name      string
+	Processed int64
+	QueueCap  int
+	QueueMean float64       // 輸入 channel 的平均佔用筆數
+	Latency   time.Duration // fn 的平均執行時間
+	Stall     time.Duration // 送往下游被卡住的總時間
+}
+
+// Utilization 回傳輸入 queue 的平均佔用率（0~1）；沒有 buffer 的 stage 回傳 0
+func (s StageStats) Utilization() float64 {
+	if s.QueueCap == 0 {
+		return 0
+	}
+	return s.QueueMean / float64(s.QueueCap)
+}
+
+// Stats 依加入順序回傳每個 stage 的統計
+func (p *Pipeline) Stats() []StageStats {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	out := make([]StageStats, len(p.stages))
+	for i, s := range p.stages {
+		out[i] = StageStats{
+			Name:      s.name,
+			Processed: s.processed.Value(),
+			QueueCap:  s.capacity,
+			QueueMean: s.queue.Mean(),
+			Latency:   time.Duration(s.latency.Mean()),
+			Stall:     time.Duration(s.stall.Sum()),
+		}
+	}
+	return out
+}
+
+// Bottleneck 回傳輸入 queue 佔用率最高的 stage；佔用率相同時取平均執行時間較長的
+func (p *Pipeline) Bottleneck() string {
+	var best StageStats
+	for _, s := range p.Stats() {
+		u, bu := s.Utilization(), best.Utilization()
+		if best.Name == "" || u > bu || (u == bu && s.Latency > best.Latency) {
+			best = s
+		}
+	}
+	return best.Name
+}
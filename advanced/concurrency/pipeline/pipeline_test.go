@@ -0,0 +1,75 @@
+package pipeline
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"advanced/metrics"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMapOrderWithSingleWorker(t *testing.T) {
+	ctx := context.Background()
+	p := New("order", metrics.NewRegistry())
+	nums := Source(ctx, []int{1, 2, 3, 4}, 0)
+	sq := Map(ctx, p, "square", nums, func(n int) int { return n * n })
+	var got []int
+	for v := range sq {
+		got = append(got, v)
+	}
+	assert.Equal(t, []int{1, 4, 9, 16}, got)
+	assert.EqualValues(t, 4, p.Stats()[0].Processed)
+}
+
+func TestCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	p := New("cancel", metrics.NewRegistry())
+	items := make([]int, 1000)
+	out := Map(ctx, p, "id", Source(ctx, items, 0), func(n int) int { return n })
+	<-out
+	cancel()
+	// stage 結束後輸出 channel 會被關閉
+	for range out {
+	}
+}
+
+// 三個 stage 中間那個最慢：它的輸入 queue 會是滿的，上游送不出去而 stall，下游一直在等
+func TestIdentifyBottleneck(t *testing.T) {
+	ctx := context.Background()
+	reg := metrics.NewRegistry()
+	p := New("etl", reg)
+
+	items := make([]string, 60)
+	for i := range items {
+		items[i] = "row"
+	}
+	src := Source(ctx, items, 8)
+	parsed := Map(ctx, p, "parse", src, strings.ToUpper, WithBuffer(8))
+	enriched := Map(ctx, p, "enrich", parsed, func(s string) string {
+		time.Sleep(2 * time.Millisecond)
+		return s + "!"
+	}, WithBuffer(8))
+	written := Map(ctx, p, "write", enriched, func(s string) int { return len(s) }, WithBuffer(8))
+	n := 0
+	for range written {
+		n++
+	}
+	require.Equal(t, 60, n)
+
+	assert.Equal(t, "enrich", p.Bottleneck())
+
+	stats := p.Stats()
+	parse, enrich, write := stats[0], stats[1], stats[2]
+	assert.Greater(t, enrich.Utilization(), 0.5)
+	assert.Less(t, write.Utilization(), 0.5)
+	assert.Greater(t, enrich.Latency, write.Latency)
+	assert.Greater(t, parse.Stall, enrich.Stall, "upstream of the bottleneck stalls")
+
+	var b strings.Builder
+	require.NoError(t, reg.WriteText(&b))
+	assert.Contains(t, b.String(), "pipeline.etl.enrich.processed 60\n")
+}
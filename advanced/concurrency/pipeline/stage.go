@@ -0,0 +1,199 @@
+package pipeline
+
+import (
+	"context"
+	"sync"
+)
+
+/*
+Stage[In, Out] 是可以組合的處理步驟：拿到輸入 channel，回傳輸出 channel 與錯誤 channel。
+
+	parse := pipeline.Transform(parseLine, pipeline.WithWorkers(4))
+	valid := pipeline.Filter(func(r Record) bool { return r.OK })
+	p := pipeline.Then(pipeline.Then(parse, valid), pipeline.Batch[Record](100))
+	err := pipeline.Run(ctx, pipeline.Source(ctx, lines, 16), p, saveBatch)
+
+Map 與 Transform 共用同一個 worker 迴圈：加上 WithMetrics(p, name) 的 Stage 一樣會記錄
+queue、latency、stall，可以用 p.Bottleneck() 找出瓶頸。
+
+與 goroutine 範例中的單一 producer/consumer 相比，每個 stage 都遵守同樣的規則，所以可以任意串接：
+  - 擁有輸出 channel 的 stage 負責關閉它（輸入關閉且處理完、或 ctx 被取消時）
+  - 每一次 send/receive 都同時 select ctx.Done()，任何一處取消，整條 pipeline 都會退出，不會有 goroutine 卡住
+  - stage 發生錯誤時送到錯誤 channel 並停止；Run 收到第一個錯誤就取消 ctx，讓上下游一起收工
+  - src 不屬於 Run（例如上面的 Source 用的是呼叫端的 ctx），提早結束時 Run 在背景把 src 讀完，
+    src 的 producer 不會卡在 send 上
+  - Then 把兩個 stage 的錯誤 channel 合併，組合後仍然是一個 Stage
+
+Transform 在 WithWorkers(n > 1) 時不保證輸出順序。
+*/
+
+// Stage 啟動處理 in 的 goroutine；兩個回傳的 channel 都會在 stage 結束時關閉
+type Stage[In, Out any] func(ctx context.Context, in <-chan In) (<-chan Out, <-chan error)
+
+// Transform 以 fn 處理每個元素，fn 回傳錯誤時 stage 停止
+func Transform[In, Out any](fn func(context.Context, In) (Out, error), opts ...StageOption) Stage[In, Out] {
+	return parallel(newStageConfig(opts), func(ctx context.Context, v In) (Out, bool, error) {
+		r, err := fn(ctx, v)
+		return r, true, err
+	})
+}
+
+// Filter 只讓 keep 回傳 true 的元素通過
+func Filter[T any](keep func(T) bool, opts ...StageOption) Stage[T, T] {
+	return parallel(newStageConfig(opts), func(_ context.Context, v T) (T, bool, error) {
+		return v, keep(v), nil
+	})
+}
+
+// parallel 以 cfg.workers 個 goroutine 執行 work，每個 worker 最多送出一個錯誤
+func parallel[In, Out any](cfg stageConfig, fn func(context.Context, In) (Out, bool, error)) Stage[In, Out] {
+	return func(ctx context.Context, in <-chan In) (<-chan Out, <-chan error) {
+		s := cfg.register(cap(in))
+		out := make(chan Out, cfg.buffer)
+		errc := make(chan error, cfg.workers) // 容量足夠，送錯誤不會卡住
+		var wg sync.WaitGroup
+		wg.Add(cfg.workers)
+		for i := 0; i < cfg.workers; i++ {
+			go func() {
+				defer wg.Done()
+				_, err := work(ctx, s, in, out, func(v In) (Out, bool, error) { return fn(ctx, v) })
+				if err != nil {
+					errc <- err
+				}
+			}()
+		}
+		go func() {
+			wg.Wait()
+			close(out)
+			close(errc)
+		}()
+		return out, errc
+	}
+}
+
+// Batch 每 size 個元素送出一批，輸入結束時送出不足 size 的最後一批；固定只有一個 worker，WithWorkers 無效
+func Batch[T any](size int, opts ...StageOption) Stage[T, []T] {
+	cfg := newStageConfig(opts)
+	return func(ctx context.Context, in <-chan T) (<-chan []T, <-chan error) {
+		s := cfg.register(cap(in))
+		out := make(chan []T, cfg.buffer)
+		errc := make(chan error)
+		go func() {
+			defer close(errc)
+			defer close(out)
+			var buf []T
+			drained, _ := work(ctx, s, in, out, func(v T) ([]T, bool, error) {
+				buf = append(buf, v)
+				if len(buf) < size {
+					return nil, false, nil
+				}
+				full := buf
+				buf = nil
+				return full, true, nil
+			})
+			if drained && len(buf) > 0 {
+				send(ctx, s, out, buf)
+			}
+		}()
+		return out, errc
+	}
+}
+
+// Then 把 a 的輸出接到 b 的輸入
+func Then[A, B, C any](a Stage[A, B], b Stage[B, C]) Stage[A, C] {
+	return func(ctx context.Context, in <-chan A) (<-chan C, <-chan error) {
+		mid, errA := a(ctx, in)
+		out, errB := b(ctx, mid)
+		return out, mergeErrors(errA, errB)
+	}
+}
+
+func mergeErrors(cs ...<-chan error) <-chan error {
+	out := make(chan error, len(cs))
+	var wg sync.WaitGroup
+	wg.Add(len(cs))
+	for _, c := range cs {
+		go func(c <-chan error) {
+			defer wg.Done()
+			for err := range c {
+				out <- err
+			}
+		}(c)
+	}
+	go func() {
+		wg.Wait()
+		close(out)
+	}()
+	return out
+}
+
+// Run 執行 stage 並把輸出交給 sink，回傳第一個錯誤（stage、sink 或 ctx 的）；
+// 回傳時所有 stage 的 goroutine 都已經結束
+func Run[In, Out any](ctx context.Context, src <-chan In, s Stage[In, Out], sink func(Out) error) error {
+	parent := ctx
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	// 提早結束時 stage 不再讀 src，src 的 producer 會卡在 send；在背景讀到 src 關閉或 parent 結束為止
+	defer func() { go drain(parent, src) }()
+	out, errc := s(ctx, src)
+
+	var (
+		mu    sync.Mutex
+		first error
+	)
+	fail := func(err error) {
+		mu.Lock()
+		if first == nil {
+			first = err
+		}
+		mu.Unlock()
+		cancel()
+	}
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for err := range errc {
+			fail(err)
+		}
+	}()
+	for v := range out {
+		if err := sink(v); err != nil {
+			fail(err)
+			break
+		}
+	}
+	// 取消後 stage 會關閉輸出，把剩下的丟掉，讓卡在 send 的 goroutine 可以離開
+	for range out {
+	}
+	<-done
+
+	mu.Lock()
+	defer mu.Unlock()
+	if first == nil {
+		first = ctx.Err() // 沒有錯誤時 cancel 還沒呼叫過，不是 nil 表示外部的 ctx 被取消
+	}
+	return first
+}
+
+func drain[T any](ctx context.Context, src <-chan T) {
+	for {
+		select {
+		case _, ok := <-src:
+			if !ok {
+				return
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// Collect 執行 stage 並收集所有輸出
+func Collect[In, Out any](ctx context.Context, src <-chan In, s Stage[In, Out]) ([]Out, error) {
+	var out []Out
+	err := Run(ctx, src, s, func(v Out) error {
+		out = append(out, v)
+		return nil
+	})
+	return out, err
+}
@@ -0,0 +1,118 @@
+package pipeline
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"strconv"
+	"testing"
+	"time"
+
+	"advanced/metrics"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/goleak"
+)
+
+func parse(ctx context.Context, s string) (int, error) { return strconv.Atoi(s) }
+
+func TestStageComposition(t *testing.T) {
+	defer goleak.VerifyNone(t, goleak.IgnoreCurrent())
+	ctx := context.Background()
+	lines := []string{"1", "2", "3", "4", "5", "6", "7", "8", "9", "10", "11"}
+
+	p := Then(
+		Then(Transform(parse), Filter(func(n int) bool { return n%2 == 1 })),
+		Batch[int](2),
+	)
+	got, err := Collect(ctx, Source(ctx, lines, 0), p)
+	require.NoError(t, err)
+	assert.Equal(t, [][]int{{1, 3}, {5, 7}, {9, 11}}, got)
+
+	// 多個 worker 不保證順序，但每個元素剛好出現一次
+	square := Transform(func(ctx context.Context, n int) (int, error) { return n * n, nil }, WithWorkers(4), WithBuffer(4))
+	sq, err := Collect(ctx, Source(ctx, []int{1, 2, 3, 4, 5}, 0), Then(Transform(func(ctx context.Context, n int) (int, error) { return n, nil }), square))
+	require.NoError(t, err)
+	sort.Ints(sq)
+	assert.Equal(t, []int{1, 4, 9, 16, 25}, sq)
+}
+
+func TestStageErrorCancelsPipeline(t *testing.T) {
+	defer goleak.VerifyNone(t, goleak.IgnoreCurrent())
+	// Source 用的是呼叫端不會取消的 ctx：Run 必須把它讀完，goleak 才會通過
+	ctx := context.Background()
+	items := make([]string, 10000)
+	for i := range items {
+		items[i] = strconv.Itoa(i)
+	}
+	items[50] = "fifty"
+
+	var seen int
+	err := Run(ctx, Source(ctx, items, 8), Then(Transform(parse, WithWorkers(3)), Batch[int](10)), func(b []int) error {
+		seen += len(b)
+		return nil
+	})
+	var ne *strconv.NumError
+	assert.ErrorAs(t, err, &ne)
+	assert.Less(t, seen, len(items), "錯誤之後不再處理剩下的資料")
+}
+
+func TestSinkError(t *testing.T) {
+	defer goleak.VerifyNone(t, goleak.IgnoreCurrent())
+	ctx := context.Background()
+	full := errors.New("disk full")
+	items := make([]int, 1000)
+	var calls int
+	err := Run(ctx, Source(ctx, items, 0), Filter(func(int) bool { return true }), func(int) error {
+		calls++
+		if calls == 3 {
+			return full
+		}
+		return nil
+	})
+	assert.ErrorIs(t, err, full)
+	assert.Equal(t, 3, calls)
+}
+
+func TestRunCancelled(t *testing.T) {
+	defer goleak.VerifyNone(t, goleak.IgnoreCurrent())
+	ctx, cancel := context.WithCancel(context.Background())
+	src := make(chan int) // 永遠不會關閉的來源
+	id := Transform(func(ctx context.Context, n int) (int, error) { return n, nil })
+	go func() {
+		src <- 1
+		time.Sleep(10 * time.Millisecond)
+		cancel()
+	}()
+	var got []int
+	err := Run(ctx, src, id, func(n int) error {
+		got = append(got, n)
+		return nil
+	})
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Equal(t, []int{1}, got)
+}
+
+// 加上 WithMetrics 的 Stage 與 Map 記錄同樣的 metric
+func TestStageMetrics(t *testing.T) {
+	defer goleak.VerifyNone(t, goleak.IgnoreCurrent())
+	ctx := context.Background()
+	p := New("stage-metrics", metrics.NewRegistry())
+	lines := []string{"1", "2", "x3", "4"}
+	s := Then(
+		Filter(func(s string) bool { return s[0] != 'x' }, WithMetrics(p, "filter")),
+		Transform(parse, WithWorkers(2), WithMetrics(p, "parse")),
+	)
+	got, err := Collect(ctx, Source(ctx, lines, 4), s)
+	require.NoError(t, err)
+	sort.Ints(got)
+	assert.Equal(t, []int{1, 2, 4}, got)
+
+	stats := p.Stats()
+	require.Len(t, stats, 2)
+	assert.Equal(t, "filter", stats[0].Name)
+	assert.EqualValues(t, 4, stats[0].Processed)
+	assert.Equal(t, 4, stats[0].QueueCap)
+	assert.EqualValues(t, 3, stats[1].Processed)
+}
@@ -0,0 +1,273 @@
+package pubsub
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+)
+
+/*
+行程內的 pub/sub：Broker[T] 的每個 topic 可以有多個訂閱者，每則訊息複製給所有訂閱者。
+
+	b := pubsub.New[Order]()
+	sub, _ := b.Subscribe(ctx, "orders", pubsub.WithBuffer(64), pubsub.WithPolicy(pubsub.Block))
+	for o := range sub.C() { ... }      // Unsubscribe、ctx 結束或 Broker.Close 時 channel 會被關閉
+	b.Publish(ctx, "orders", order)
+
+basic/csp 的 hub 範例只有一種處理方式（buffer 滿了就丟），而且 publish 時一直持有 hub 的鎖。
+這裡每個訂閱者自己決定跟不上時怎麼辦（Policy）：
+  - Drop：丟掉這一則給它的訊息，記在 Dropped()，publisher 不受影響（預設）
+  - Block：publisher 等到有空位（或 publisher 的 ctx 結束），適合不能漏訊息的訂閱者
+  - Close：直接關閉這個訂閱，Err() 回傳 ErrSlowSubscriber，讓訂閱者知道自己漏了訊息、需要重新同步
+
+鎖的設計：
+  - Publish 在 broker 的讀鎖內只複製一份訂閱者清單，送訊息時不持有 broker 的鎖，
+    Block 的訂閱者卡住 publisher 時，其他人照樣可以 Subscribe / Unsubscribe
+  - 每個訂閱者有自己的讀寫鎖：送訊息持有讀鎖，關閉 channel 要拿寫鎖，所以不會「送到已關閉的 channel」而 panic；
+    關閉前先 close(done)，卡在 Block 的 publisher 會從 select 離開並放開讀鎖，不會死結
+
+防止 goroutine 洩漏：Subscribe 傳入的 ctx 結束時自動取消訂閱（context.AfterFunc，不額外開 goroutine），
+`for range sub.C()` 的迴圈一定會結束。
+*/
+
+var (
+	ErrClosed         = errors.New("pubsub: broker closed")
+	ErrSlowSubscriber = errors.New("pubsub: subscriber too slow")
+)
+
+type Policy int
+
+const (
+	Drop Policy = iota
+	Block
+	Close
+)
+
+func (p Policy) String() string {
+	switch p {
+	case Drop:
+		return "drop"
+	case Block:
+		return "block"
+	case Close:
+		return "close"
+	}
+	return "unknown"
+}
+
+type Broker[T any] struct {
+	mu     sync.RWMutex
+	topics map[string]map[*Subscription[T]]struct{}
+	closed bool
+}
+
+func New[T any]() *Broker[T] {
+	return &Broker[T]{topics: make(map[string]map[*Subscription[T]]struct{})}
+}
+
+type subConfig struct {
+	buffer int
+	policy Policy
+}
+
+type SubOption func(*subConfig)
+
+// WithBuffer 設定訂閱者 channel 的容量，預設 16
+func WithBuffer(n int) SubOption {
+	return func(c *subConfig) { c.buffer = n }
+}
+
+// WithPolicy 設定跟不上時的處理方式，預設 Drop
+func WithPolicy(p Policy) SubOption {
+	return func(c *subConfig) { c.policy = p }
+}
+
+type Subscription[T any] struct {
+	broker *Broker[T]
+	topic  string
+	policy Policy
+	ch     chan T
+	done   chan struct{}
+
+	once    sync.Once
+	mu      sync.RWMutex // 送訊息持有讀鎖，關閉 ch 持有寫鎖
+	stop    func() bool  // 取消 context.AfterFunc
+	closed  bool
+	err     error // 在 close(done) 之前寫入，之後唯讀
+	dropped atomic.Uint64
+}
+
+// Subscribe 訂閱 topic；ctx 結束時自動取消訂閱
+func (b *Broker[T]) Subscribe(ctx context.Context, topic string, opts ...SubOption) (*Subscription[T], error) {
+	c := subConfig{buffer: 16, policy: Drop}
+	for _, o := range opts {
+		o(&c)
+	}
+	s := &Subscription[T]{broker: b, topic: topic, policy: c.policy, ch: make(chan T, c.buffer), done: make(chan struct{})}
+
+	b.mu.Lock()
+	if b.closed {
+		b.mu.Unlock()
+		return nil, ErrClosed
+	}
+	subs := b.topics[topic]
+	if subs == nil {
+		subs = make(map[*Subscription[T]]struct{})
+		b.topics[topic] = subs
+	}
+	subs[s] = struct{}{}
+	b.mu.Unlock()
+
+	// ctx 可能已經結束，callback 會和這裡同時執行，所以 stop 也放在 s.mu 底下
+	stop := context.AfterFunc(ctx, func() { s.close(ctx.Err()) })
+	s.mu.Lock()
+	s.stop = stop
+	closed := s.closed
+	s.mu.Unlock()
+	if closed {
+		stop()
+	}
+	return s, nil
+}
+
+// C 回傳接收訊息的 channel，取消訂閱後會被關閉
+func (s *Subscription[T]) C() <-chan T { return s.ch }
+
+// Unsubscribe 取消訂閱並關閉 C()；可以重複呼叫
+func (s *Subscription[T]) Unsubscribe() { s.close(nil) }
+
+// Done 在訂閱被關閉時關閉；C() 裡可能還有沒讀完的訊息，要立刻知道「被踢掉了」時用它
+func (s *Subscription[T]) Done() <-chan struct{} { return s.done }
+
+// Err 回傳訂閱被關閉的原因：主動取消為 nil，ctx 結束為 ctx.Err()，跟不上為 ErrSlowSubscriber，broker 關閉為 ErrClosed
+func (s *Subscription[T]) Err() error {
+	select {
+	case <-s.done:
+		return s.err // close 在 close(done) 之前寫入 err
+	default:
+		return nil
+	}
+}
+
+// Dropped 回傳 Drop 模式下被丟掉的訊息數
+func (s *Subscription[T]) Dropped() uint64 { return s.dropped.Load() }
+
+func (s *Subscription[T]) close(err error) {
+	s.once.Do(func() {
+		s.err = err
+		close(s.done) // 讓卡在 Block 的 publisher 放開讀鎖
+		s.mu.Lock()
+		s.closed = true
+		close(s.ch)
+		stop := s.stop
+		s.mu.Unlock()
+		if stop != nil {
+			stop()
+		}
+		s.broker.remove(s)
+	})
+}
+
+func (b *Broker[T]) remove(s *Subscription[T]) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if subs := b.topics[s.topic]; subs != nil {
+		delete(subs, s)
+		if len(subs) == 0 {
+			delete(b.topics, s.topic)
+		}
+	}
+}
+
+// send 依 policy 送出 msg，回傳是否送達；tooSlow 表示 Close 模式下應該關閉這個訂閱
+func (s *Subscription[T]) send(ctx context.Context, msg T) (delivered, tooSlow bool, err error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.closed {
+		return false, false, nil
+	}
+	switch s.policy {
+	case Block:
+		select {
+		case s.ch <- msg:
+			return true, false, nil
+		case <-s.done:
+			return false, false, nil
+		case <-ctx.Done():
+			return false, false, ctx.Err()
+		}
+	case Close:
+		select {
+		case s.ch <- msg:
+			return true, false, nil
+		default:
+			return false, true, nil
+		}
+	default:
+		select {
+		case s.ch <- msg:
+			return true, false, nil
+		default:
+			s.dropped.Add(1)
+			return false, false, nil
+		}
+	}
+}
+
+// Publish 把 msg 送給 topic 的所有訂閱者，回傳實際送達的數量；
+// ctx 只影響 Block 的訂閱者，結束時回傳 ctx.Err()（已經送出的不會收回）
+func (b *Broker[T]) Publish(ctx context.Context, topic string, msg T) (int, error) {
+	b.mu.RLock()
+	if b.closed {
+		b.mu.RUnlock()
+		return 0, ErrClosed
+	}
+	subs := make([]*Subscription[T], 0, len(b.topics[topic]))
+	for s := range b.topics[topic] {
+		subs = append(subs, s)
+	}
+	b.mu.RUnlock()
+
+	delivered := 0
+	for _, s := range subs {
+		ok, tooSlow, err := s.send(ctx, msg)
+		if err != nil {
+			return delivered, err
+		}
+		if tooSlow {
+			s.close(ErrSlowSubscriber) // 不能在 send 的讀鎖內關閉
+		}
+		if ok {
+			delivered++
+		}
+	}
+	return delivered, nil
+}
+
+// Subscribers 回傳 topic 目前的訂閱者數量
+func (b *Broker[T]) Subscribers(topic string) int {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return len(b.topics[topic])
+}
+
+// Close 關閉所有訂閱，之後的 Publish 與 Subscribe 回傳 ErrClosed
+func (b *Broker[T]) Close() {
+	b.mu.Lock()
+	if b.closed {
+		b.mu.Unlock()
+		return
+	}
+	b.closed = true
+	var all []*Subscription[T]
+	for _, subs := range b.topics {
+		for s := range subs {
+			all = append(all, s)
+		}
+	}
+	b.mu.Unlock()
+	for _, s := range all {
+		s.close(ErrClosed)
+	}
+}
@@ -0,0 +1,225 @@
+package pubsub
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/goleak"
+)
+
+// 每個測試結束後不能留下任何 goroutine：訂閱者的 for range 都要能結束
+func TestMain(m *testing.M) {
+	goleak.VerifyTestMain(m)
+}
+
+func drain[T any](c <-chan T) []T {
+	var out []T
+	for v := range c {
+		out = append(out, v)
+	}
+	return out
+}
+
+func TestFanOutAndTopics(t *testing.T) {
+	ctx := context.Background()
+	b := New[int]()
+	a1, err := b.Subscribe(ctx, "a")
+	require.NoError(t, err)
+	a2, _ := b.Subscribe(ctx, "a")
+	other, _ := b.Subscribe(ctx, "b")
+	assert.Equal(t, 2, b.Subscribers("a"))
+
+	for i := 0; i < 3; i++ {
+		n, err := b.Publish(ctx, "a", i)
+		require.NoError(t, err)
+		assert.Equal(t, 2, n)
+	}
+	n, _ := b.Publish(ctx, "nobody", 1)
+	assert.Zero(t, n)
+
+	b.Close()
+	assert.Equal(t, []int{0, 1, 2}, drain(a1.C()))
+	assert.Equal(t, []int{0, 1, 2}, drain(a2.C()))
+	assert.Empty(t, drain(other.C()))
+	assert.ErrorIs(t, a1.Err(), ErrClosed)
+
+	_, err = b.Publish(ctx, "a", 1)
+	assert.ErrorIs(t, err, ErrClosed)
+	_, err = b.Subscribe(ctx, "a")
+	assert.ErrorIs(t, err, ErrClosed)
+}
+
+func TestDropPolicy(t *testing.T) {
+	ctx := context.Background()
+	b := New[int]()
+	slow, _ := b.Subscribe(ctx, "t", WithBuffer(2))
+	fast, _ := b.Subscribe(ctx, "t", WithBuffer(10))
+	for i := 0; i < 5; i++ {
+		_, err := b.Publish(ctx, "t", i)
+		require.NoError(t, err)
+	}
+	assert.EqualValues(t, 3, slow.Dropped())
+	assert.Zero(t, fast.Dropped(), "one slow subscriber does not affect the others")
+
+	slow.Unsubscribe()
+	fast.Unsubscribe()
+	assert.Equal(t, []int{0, 1}, drain(slow.C()), "keeps the oldest, drops the newest")
+	assert.Equal(t, []int{0, 1, 2, 3, 4}, drain(fast.C()))
+	assert.Zero(t, b.Subscribers("t"))
+}
+
+func TestBlockPolicy(t *testing.T) {
+	ctx := context.Background()
+	b := New[int]()
+	sub, _ := b.Subscribe(ctx, "t", WithBuffer(1), WithPolicy(Block))
+	_, err := b.Publish(ctx, "t", 1)
+	require.NoError(t, err)
+
+	published := make(chan struct{})
+	go func() {
+		defer close(published)
+		n, err := b.Publish(ctx, "t", 2)
+		assert.NoError(t, err)
+		assert.Equal(t, 1, n)
+	}()
+	select {
+	case <-published:
+		t.Fatal("Publish should block while the buffer is full")
+	case <-time.After(20 * time.Millisecond):
+	}
+	assert.Equal(t, 1, <-sub.C())
+	<-published
+	assert.Equal(t, 2, <-sub.C())
+
+	// publisher 的 ctx 結束就放棄
+	_, _ = b.Publish(ctx, "t", 3)
+	tctx, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer cancel()
+	_, err = b.Publish(tctx, "t", 4)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	sub.Unsubscribe()
+}
+
+// 卡在 Block 訂閱者上的 publisher，在訂閱者取消時要能離開，不能死結或 panic
+func TestUnsubscribeUnblocksPublisher(t *testing.T) {
+	ctx := context.Background()
+	b := New[int]()
+	sub, _ := b.Subscribe(ctx, "t", WithBuffer(0), WithPolicy(Block))
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		n, err := b.Publish(ctx, "t", 1)
+		assert.NoError(t, err)
+		assert.Zero(t, n)
+	}()
+	time.Sleep(10 * time.Millisecond)
+	sub.Unsubscribe()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("publisher still blocked after Unsubscribe")
+	}
+	sub.Unsubscribe() // 重複呼叫沒事
+	assert.NoError(t, sub.Err())
+}
+
+func TestClosePolicy(t *testing.T) {
+	ctx := context.Background()
+	b := New[string]()
+	sub, _ := b.Subscribe(ctx, "t", WithBuffer(1), WithPolicy(Close))
+	n, _ := b.Publish(ctx, "t", "a")
+	assert.Equal(t, 1, n)
+	n, err := b.Publish(ctx, "t", "b")
+	require.NoError(t, err)
+	assert.Zero(t, n)
+
+	select {
+	case <-sub.Done():
+	default:
+		t.Fatal("Done is closed as soon as the subscriber is dropped")
+	}
+	assert.Equal(t, []string{"a"}, drain(sub.C()), "buffered messages are still delivered before close")
+	assert.ErrorIs(t, sub.Err(), ErrSlowSubscriber)
+	assert.Zero(t, b.Subscribers("t"))
+}
+
+func TestContextUnsubscribes(t *testing.T) {
+	b := New[int]()
+	ctx, cancel := context.WithCancel(context.Background())
+	sub, _ := b.Subscribe(ctx, "t")
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for range sub.C() {
+		}
+	}()
+	cancel()
+	<-done
+	assert.ErrorIs(t, sub.Err(), context.Canceled)
+	assert.Zero(t, b.Subscribers("t"))
+
+	// 已經結束的 ctx：立刻被取消訂閱
+	sub, err := b.Subscribe(ctx, "t")
+	require.NoError(t, err)
+	assert.Empty(t, drain(sub.C()))
+}
+
+// 多個 publisher 與一直在加入、離開的訂閱者同時進行；配合 -race 與 goleak
+func TestConcurrentPublishSubscribe(t *testing.T) {
+	ctx := context.Background()
+	b := New[int]()
+	const publishers, perPublisher = 4, 500
+
+	// 固定的 Block 訂閱者一則都不能少
+	steady, _ := b.Subscribe(ctx, "t", WithBuffer(8), WithPolicy(Block))
+	got := make(chan int, 1)
+	go func() {
+		got <- len(drain(steady.C()))
+	}()
+
+	var churn sync.WaitGroup
+	stop := make(chan struct{})
+	for i := 0; i < 4; i++ {
+		churn.Add(1)
+		go func(i int) {
+			defer churn.Done()
+			policies := []Policy{Drop, Block, Close}
+			for {
+				select {
+				case <-stop:
+					return
+				default:
+				}
+				sub, err := b.Subscribe(ctx, "t", WithBuffer(2), WithPolicy(policies[i%len(policies)]))
+				if err != nil {
+					return
+				}
+				<-sub.C()
+				sub.Unsubscribe()
+				drain(sub.C())
+			}
+		}(i)
+	}
+
+	var pubs sync.WaitGroup
+	for p := 0; p < publishers; p++ {
+		pubs.Add(1)
+		go func() {
+			defer pubs.Done()
+			for i := 0; i < perPublisher; i++ {
+				_, err := b.Publish(ctx, "t", i)
+				assert.NoError(t, err)
+			}
+		}()
+	}
+	pubs.Wait()
+	close(stop)
+	b.Close() // 還在等 <-sub.C() 的 churn goroutine 也會被放出來
+	churn.Wait()
+	assert.Equal(t, publishers*perPublisher, <-got)
+}
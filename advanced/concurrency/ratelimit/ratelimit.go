@@ -0,0 +1,206 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"advanced/timex"
+)
+
+/*
+兩種經典的限流演算法，實作同一個 Limiter 介面：
+
+Token bucket（TokenBucket）：
+  - 桶子最多放 burst 個 token，每秒補 rate 個；每個請求拿一個，沒有就拒絕或等待
+  - 平常沒流量時 token 會存起來，之後可以一口氣用掉 burst 個（允許突發）
+  - 不需要背景 goroutine 補 token：記住上次更新的時間，用到時再依經過的時間一次補上（lazy refill）
+  - golang.org/x/time/rate 就是 token bucket
+
+Leaky bucket（LeakyBucket，queue 版本）：
+  - 請求像水滴進桶子，桶底以固定速率漏出；輸出永遠是等間隔的，不會有突發
+  - 每個請求分到一個時間槽：next = max(now, 上一個時槽 + 1/rate)，Wait 睡到那個時間
+  - 桶子的容量是最多能排隊的請求數，滿了 Wait 直接回傳 ErrQueueFull，不再等待
+
+差別在突發：token bucket 允許閒置後的 burst，適合 API 配額；
+leaky bucket 把流量整形成固定速率，適合保護只能承受固定吞吐量的下游。
+
+Wait 都是「先預約再睡覺」：在鎖內算出要等多久並先扣掉，睡覺時不持有鎖，
+1 萬個 goroutine 同時 Wait 也只在預約時短暫競爭同一把鎖。
+ctx 取消時歸還預約（token bucket 補回 token；leaky bucket 的時槽無法歸還，但後面的人不受影響）。
+時間來源是 WithClock 設定的 timex.Clock，測試時用 timex.Fake。預約與睡覺必須用同一個時鐘，
+所以 Wait 以 timex.Sleep 睡覺時會把 ctx 帶的時鐘換成 limiter 的時鐘。
+*/
+
+var (
+	ErrQueueFull = errors.New("ratelimit: queue full")
+	// ErrWouldExceedDeadline 表示就算等下去也趕不上 ctx 的 deadline，不必真的睡到逾時
+	ErrWouldExceedDeadline = errors.New("ratelimit: wait would exceed context deadline")
+)
+
+type Limiter interface {
+	// Allow 現在可以通過就回傳 true，不等待
+	Allow() bool
+	// Wait 等到可以通過，或 ctx 結束
+	Wait(ctx context.Context) error
+}
+
+type config struct {
+	clock timex.Clock
+}
+
+type Option func(*config)
+
+// WithClock 設定 limiter 計算 token、時槽與 Wait 睡覺用的時鐘，預設 timex.Real
+func WithClock(c timex.Clock) Option {
+	return func(cfg *config) { cfg.clock = c }
+}
+
+func newConfig(opts []Option) config {
+	c := config{clock: timex.Real{}}
+	for _, o := range opts {
+		o(&c)
+	}
+	return c
+}
+
+// tooLate 回傳等到 at 是否會超過 ctx 的 deadline
+func tooLate(ctx context.Context, at time.Time) bool {
+	deadline, ok := ctx.Deadline()
+	return ok && at.After(deadline)
+}
+
+type TokenBucket struct {
+	rate  float64 // 每秒補充的 token
+	burst float64
+	clock timex.Clock
+
+	mu     sync.Mutex
+	tokens float64 // 可能是負的：已經被 Wait 預約走了
+	last   time.Time
+}
+
+// NewTokenBucket 每秒補 rate 個 token，最多存 burst 個；一開始是滿的
+func NewTokenBucket(rate float64, burst int, opts ...Option) *TokenBucket {
+	c := newConfig(opts)
+	return &TokenBucket{rate: rate, burst: float64(burst), clock: c.clock, tokens: float64(burst), last: c.clock.Now()}
+}
+
+// refill 依經過的時間補充 token，呼叫時必須持有鎖
+func (b *TokenBucket) refill(now time.Time) {
+	if elapsed := now.Sub(b.last); elapsed > 0 {
+		b.tokens = min(b.burst, b.tokens+elapsed.Seconds()*b.rate)
+		b.last = now
+	}
+}
+
+func (b *TokenBucket) Allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.refill(b.clock.Now())
+	if b.tokens >= 1 {
+		b.tokens--
+		return true
+	}
+	return false
+}
+
+func (b *TokenBucket) Wait(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	b.mu.Lock()
+	now := b.clock.Now()
+	b.refill(now)
+	var wait time.Duration
+	if b.tokens < 1 {
+		wait = time.Duration((1 - b.tokens) / b.rate * float64(time.Second))
+	}
+	if tooLate(ctx, now.Add(wait)) {
+		b.mu.Unlock()
+		return ErrWouldExceedDeadline
+	}
+	b.tokens-- // 先預約，睡醒時這個 token 已經補上了
+	b.mu.Unlock()
+
+	if err := timex.Sleep(timex.WithClock(ctx, b.clock), wait); err != nil {
+		b.mu.Lock()
+		b.refill(b.clock.Now())
+		b.tokens = min(b.burst, b.tokens+1)
+		b.mu.Unlock()
+		return err
+	}
+	return nil
+}
+
+// Tokens 回傳目前可用的 token 數（可能是負的）
+func (b *TokenBucket) Tokens() float64 {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.refill(b.clock.Now())
+	return b.tokens
+}
+
+type LeakyBucket struct {
+	interval time.Duration // 兩個請求之間的間隔 = 1/rate
+	capacity int
+	clock    timex.Clock
+
+	mu   sync.Mutex
+	next time.Time // 下一個可以使用的時間槽
+}
+
+// NewLeakyBucket 每秒放行 rate 個請求，最多 capacity 個在排隊
+func NewLeakyBucket(rate float64, capacity int, opts ...Option) *LeakyBucket {
+	c := newConfig(opts)
+	return &LeakyBucket{interval: time.Duration(float64(time.Second) / rate), capacity: capacity, clock: c.clock}
+}
+
+// Allow 只有在不需要排隊時才通過，所以連續的 Allow 也會被拉開間隔
+func (b *LeakyBucket) Allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	now := b.clock.Now()
+	if b.next.After(now) {
+		return false
+	}
+	b.next = now.Add(b.interval)
+	return true
+}
+
+func (b *LeakyBucket) Wait(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	b.mu.Lock()
+	now := b.clock.Now()
+	slot := b.next
+	if slot.Before(now) {
+		slot = now
+	}
+	wait := slot.Sub(now)
+	if wait > time.Duration(b.capacity)*b.interval {
+		b.mu.Unlock()
+		return ErrQueueFull
+	}
+	if tooLate(ctx, slot) {
+		b.mu.Unlock()
+		return ErrWouldExceedDeadline
+	}
+	b.next = slot.Add(b.interval)
+	b.mu.Unlock()
+	return timex.Sleep(timex.WithClock(ctx, b.clock), wait)
+}
+
+// Queued 回傳目前排隊中的請求數
+func (b *LeakyBucket) Queued() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	ahead := b.next.Sub(b.clock.Now())
+	if ahead <= 0 {
+		return 0
+	}
+	// next 之前的時槽都已經有人預約；最後一個時槽是剛放行的那個
+	return int((ahead+b.interval-1)/b.interval) - 1
+}
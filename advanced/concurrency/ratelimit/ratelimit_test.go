@@ -0,0 +1,229 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/time/rate"
+
+	"advanced/timex"
+)
+
+var (
+	_ Limiter = (*TokenBucket)(nil)
+	_ Limiter = (*LeakyBucket)(nil)
+)
+
+var start = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+func TestTokenBucketBurst(t *testing.T) {
+	clock := timex.NewFake(start)
+	b := NewTokenBucket(10, 5, WithClock(clock))
+
+	for i := 0; i < 5; i++ {
+		assert.True(t, b.Allow(), "burst %d", i)
+	}
+	assert.False(t, b.Allow())
+
+	clock.Advance(100 * time.Millisecond)
+	assert.True(t, b.Allow(), "one token refilled")
+	assert.False(t, b.Allow())
+
+	// 閒置再久也只存到 burst 個
+	clock.Advance(time.Hour)
+	n := 0
+	for b.Allow() {
+		n++
+	}
+	assert.Equal(t, 5, n)
+}
+
+func TestLeakyBucketNoBurst(t *testing.T) {
+	clock := timex.NewFake(start)
+	b := NewLeakyBucket(10, 5, WithClock(clock))
+
+	assert.True(t, b.Allow())
+	assert.False(t, b.Allow(), "requests are spaced, no burst")
+	clock.Advance(50 * time.Millisecond)
+	assert.False(t, b.Allow())
+	clock.Advance(50 * time.Millisecond)
+	assert.True(t, b.Allow())
+
+	clock.Advance(time.Hour)
+	assert.True(t, b.Allow())
+	assert.False(t, b.Allow(), "idle time is not saved up")
+}
+
+// 0 ~ 1000ms 每 50ms 試一次：token bucket 多了一開始的 burst，leaky bucket 是固定速率
+func TestAllowedOverTime(t *testing.T) {
+	for name, tc := range map[string]struct {
+		newLimiter func(timex.Clock) Limiter
+		want       int
+	}{
+		"token": {func(c timex.Clock) Limiter { return NewTokenBucket(10, 5, WithClock(c)) }, 15},
+		"leaky": {func(c timex.Clock) Limiter { return NewLeakyBucket(10, 5, WithClock(c)) }, 11},
+	} {
+		t.Run(name, func(t *testing.T) {
+			clock := timex.NewFake(start)
+			l := tc.newLimiter(clock)
+			allowed := 0
+			for i := 0; i <= 20; i++ {
+				for l.Allow() {
+					allowed++
+				}
+				clock.Advance(50 * time.Millisecond)
+			}
+			assert.Equal(t, tc.want, allowed)
+		})
+	}
+}
+
+// wait 在背景呼叫 Wait，回傳結果的 channel
+func wait(ctx context.Context, l Limiter) <-chan error {
+	ch := make(chan error, 1)
+	go func() { ch <- l.Wait(ctx) }()
+	return ch
+}
+
+func TestWaitSleepsUntilAvailable(t *testing.T) {
+	for name, newLimiter := range map[string]func(timex.Clock) Limiter{
+		"token": func(c timex.Clock) Limiter { return NewTokenBucket(10, 1, WithClock(c)) },
+		"leaky": func(c timex.Clock) Limiter { return NewLeakyBucket(10, 5, WithClock(c)) },
+	} {
+		t.Run(name, func(t *testing.T) {
+			clock := timex.NewFake(start)
+			l := newLimiter(clock)
+			require.NoError(t, l.Wait(context.Background()), "first one passes immediately")
+
+			// ctx 帶的是另一個時鐘：預約與睡覺仍然都用 limiter 的時鐘
+			ctx := timex.WithClock(context.Background(), timex.NewFake(start))
+			done := wait(ctx, l)
+			clock.BlockUntil(1)
+			clock.Advance(99 * time.Millisecond)
+			select {
+			case <-done:
+				t.Fatal("woke up too early")
+			default:
+			}
+			clock.Advance(time.Millisecond)
+			assert.NoError(t, <-done)
+		})
+	}
+}
+
+// 多個 Wait 依序預約：第 n 個等 n 個間隔
+func TestWaitReservationsQueue(t *testing.T) {
+	clock := timex.NewFake(start)
+	l := NewLeakyBucket(10, 3, WithClock(clock))
+	require.True(t, l.Allow())
+
+	var dones []<-chan error
+	for i := 0; i < 3; i++ {
+		dones = append(dones, wait(context.Background(), l))
+		clock.BlockUntil(i + 1)
+	}
+	assert.Equal(t, 3, l.Queued())
+	assert.ErrorIs(t, l.Wait(context.Background()), ErrQueueFull)
+
+	for _, done := range dones {
+		clock.Advance(100 * time.Millisecond)
+		assert.NoError(t, <-done)
+	}
+	assert.Equal(t, 0, l.Queued())
+}
+
+func TestWaitCancelReturnsToken(t *testing.T) {
+	clock := timex.NewFake(start)
+	b := NewTokenBucket(1, 1, WithClock(clock))
+	require.True(t, b.Allow())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := wait(ctx, b)
+	clock.BlockUntil(1)
+	assert.InDelta(t, -1, b.Tokens(), 1e-9, "reserved")
+	cancel()
+	assert.ErrorIs(t, <-done, context.Canceled)
+	assert.InDelta(t, 0, b.Tokens(), 1e-9, "reservation returned")
+
+	clock.Advance(time.Second)
+	assert.True(t, b.Allow())
+}
+
+func TestWaitDeadline(t *testing.T) {
+	for name, l := range map[string]Limiter{
+		"token": NewTokenBucket(1, 1),
+		"leaky": NewLeakyBucket(1, 5),
+	} {
+		t.Run(name, func(t *testing.T) {
+			require.True(t, l.Allow())
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+			defer cancel()
+			begin := time.Now()
+			assert.ErrorIs(t, l.Wait(ctx), ErrWouldExceedDeadline)
+			assert.Less(t, time.Since(begin), 5*time.Millisecond, "fails fast instead of sleeping")
+
+			cancel()
+			assert.ErrorIs(t, l.Wait(ctx), context.Canceled)
+		})
+	}
+}
+
+// 真實時間：rate 200/s、burst 10，60 個請求大約要 (60-10)/200 = 250ms
+func TestTokenBucketRealTime(t *testing.T) {
+	b := NewTokenBucket(200, 10)
+	begin := time.Now()
+	var wg sync.WaitGroup
+	for i := 0; i < 60; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			assert.NoError(t, b.Wait(context.Background()))
+		}()
+	}
+	wg.Wait()
+	elapsed := time.Since(begin)
+	assert.GreaterOrEqual(t, elapsed, 240*time.Millisecond)
+	assert.Less(t, elapsed, time.Second)
+}
+
+// 1 萬個 goroutine 同時 Wait；rate 很高，量的是預約時的鎖競爭與 timer 的成本
+func BenchmarkWait10kGoroutines(b *testing.B) {
+	const goroutines = 10_000
+	for name, newLimiter := range map[string]func() Limiter{
+		"token":  func() Limiter { return NewTokenBucket(1e7, goroutines) },
+		"leaky":  func() Limiter { return NewLeakyBucket(1e7, goroutines) },
+		"x/time": func() Limiter { return rate.NewLimiter(1e7, goroutines) },
+	} {
+		b.Run(name, func(b *testing.B) {
+			l := newLimiter()
+			ctx := context.Background()
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				var wg sync.WaitGroup
+				wg.Add(goroutines)
+				for g := 0; g < goroutines; g++ {
+					go func() {
+						defer wg.Done()
+						_ = l.Wait(ctx)
+					}()
+				}
+				wg.Wait()
+			}
+		})
+	}
+}
+
+/*
+go test -run XXX -bench Wait10k ./concurrency/ratelimit
+
+BenchmarkWait10kGoroutines/x/time         	     106	  13101356 ns/op	  480016 B/op	   10001 allocs/op
+BenchmarkWait10kGoroutines/token          	      93	  12487339 ns/op	  480017 B/op	   10001 allocs/op
+BenchmarkWait10kGoroutines/leaky          	      98	  11698613 ns/op	  480016 B/op	   10001 allocs/op
+
+每個 op 是 1 萬次 Wait，平均一次約 1.2µs，大部分是開 goroutine 的成本（每個 goroutine 一次配置）；
+三者都只在預約時持有鎖，睡覺時不持有，所以差不多。
+*/
@@ -0,0 +1,39 @@
+package semaphore
+
+import "context"
+
+// Chan 是最常見的寫法：容量 n 的 channel，送入代表取得、取出代表釋放。
+// 阻塞中的 sender 在 runtime 裡依序排隊，行為接近 FIFO。
+type Chan chan struct{}
+
+func NewChan(n int) Chan { return make(Chan, n) }
+
+func (s Chan) Acquire() { s <- struct{}{} }
+
+func (s Chan) AcquireContext(ctx context.Context) error {
+	select {
+	case s <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (s Chan) TryAcquire() bool {
+	select {
+	case s <- struct{}{}:
+		return true
+	default:
+		return false
+	}
+}
+
+func (s Chan) Release() {
+	select {
+	case <-s:
+	default:
+		panic("semaphore: release without acquire")
+	}
+}
+
+func (s Chan) Available() int { return cap(s) - len(s) }
@@ -0,0 +1,153 @@
+package semaphore
+
+import (
+	"context"
+	"sync"
+)
+
+/*
+用 sync.Cond 實作 semaphore，練習 condition variable 的三個要點：
+ 1. Wait 前必須持有鎖，Wait 會「原子地」放開鎖並睡覺，醒來時重新持有鎖
+ 2. 條件一定放在 for 迴圈裡檢查
+ 3. Signal 叫醒一個、Broadcast 叫醒全部；條件只對特定等待者成立時只能用 Broadcast
+
+預設模式不保證公平：Release 之後，剛好來呼叫 Acquire 的 goroutine 可能比被叫醒的等待者先搶到（barging），
+吞吐量較好，但等待者可能一直輪不到。
+Fair 模式用排隊號碼（ticket）保證 FIFO：只有號碼等於 serving 的人可以拿，
+因為條件只對其中一人成立，Release 必須 Broadcast，其他人醒來檢查後再睡。
+
+sync.Cond 的 Wait 無法被 context 中斷，AcquireContext 用 context.AfterFunc 在取消時 Broadcast，
+讓等待者醒來自己檢查 ctx.Err()。
+*/
+
+type Cond struct {
+	mu    sync.Mutex
+	cond  *sync.Cond
+	size  int
+	avail int
+	fair  bool
+
+	next      uint64 // 下一個發出的號碼
+	serving   uint64 // 目前可以取得的號碼
+	cancelled map[uint64]bool
+}
+
+type Option func(*Cond)
+
+// Fair 讓等待者依到達順序取得 permit
+func Fair() Option {
+	return func(s *Cond) { s.fair = true }
+}
+
+func NewCond(n int, opts ...Option) *Cond {
+	s := &Cond{size: n, avail: n, cancelled: make(map[uint64]bool)}
+	s.cond = sync.NewCond(&s.mu)
+	for _, o := range opts {
+		o(s)
+	}
+	return s
+}
+
+// NewBinary 建立只有一個 permit 的 semaphore；與 Mutex 不同，可以由另一個 goroutine Release
+func NewBinary(opts ...Option) *Cond { return NewCond(1, opts...) }
+
+func (s *Cond) Acquire() {
+	_ = s.AcquireContext(context.Background())
+}
+
+func (s *Cond) AcquireContext(ctx context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if ctx.Done() != nil {
+		stop := context.AfterFunc(ctx, func() {
+			s.mu.Lock()
+			s.cond.Broadcast()
+			s.mu.Unlock()
+		})
+		defer stop()
+	}
+
+	if !s.fair {
+		for s.avail == 0 {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			s.cond.Wait()
+		}
+		s.avail--
+		return nil
+	}
+
+	ticket := s.next
+	s.next++
+	for s.avail == 0 || s.serving != ticket {
+		if err := ctx.Err(); err != nil {
+			// 放棄排隊：輪到這個號碼時直接跳過
+			s.cancelled[ticket] = true
+			s.skipCancelled()
+			return err
+		}
+		s.cond.Wait()
+	}
+	s.avail--
+	s.serving++
+	s.skipCancelled()
+	return nil
+}
+
+// skipCancelled 跳過已放棄的號碼，並叫醒等待者重新檢查
+func (s *Cond) skipCancelled() {
+	for s.cancelled[s.serving] {
+		delete(s.cancelled, s.serving)
+		s.serving++
+	}
+	if s.avail > 0 && s.serving != s.next {
+		s.cond.Broadcast()
+	}
+}
+
+// TryAcquire 不等待；Fair 模式下有人在排隊時一律失敗，不允許插隊
+func (s *Cond) TryAcquire() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.avail == 0 || (s.fair && s.serving != s.next) {
+		return false
+	}
+	s.avail--
+	if s.fair {
+		s.next++
+		s.serving++
+	}
+	return true
+}
+
+func (s *Cond) Release() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.avail == s.size {
+		panic("semaphore: release without acquire")
+	}
+	s.avail++
+	if s.fair {
+		s.cond.Broadcast()
+	} else {
+		s.cond.Signal()
+	}
+}
+
+// Available 回傳目前可用的 permit 數
+func (s *Cond) Available() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.avail
+}
+
+// waiting 回傳 Fair 模式下排隊中的人數（測試用）
+func (s *Cond) waiting() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return int(s.next - s.serving)
+}
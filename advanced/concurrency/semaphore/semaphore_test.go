@@ -0,0 +1,194 @@
+package semaphore
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type sem interface {
+	Acquire()
+	AcquireContext(ctx context.Context) error
+	TryAcquire() bool
+	Release()
+	Available() int
+}
+
+var impls = map[string]func(n int) sem{
+	"cond":      func(n int) sem { return NewCond(n) },
+	"cond-fair": func(n int) sem { return NewCond(n, Fair()) },
+	"chan":      func(n int) sem { return NewChan(n) },
+}
+
+func TestLimitsConcurrency(t *testing.T) {
+	for name, newSem := range impls {
+		t.Run(name, func(t *testing.T) {
+			s := newSem(3)
+			var cur, peak int32
+			var wg sync.WaitGroup
+			for i := 0; i < 50; i++ {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					s.Acquire()
+					defer s.Release()
+					n := atomic.AddInt32(&cur, 1)
+					for {
+						p := atomic.LoadInt32(&peak)
+						if n <= p || atomic.CompareAndSwapInt32(&peak, p, n) {
+							break
+						}
+					}
+					time.Sleep(time.Millisecond)
+					atomic.AddInt32(&cur, -1)
+				}()
+			}
+			wg.Wait()
+			assert.LessOrEqual(t, peak, int32(3))
+			assert.Equal(t, 3, s.Available())
+		})
+	}
+}
+
+func TestTryAndContext(t *testing.T) {
+	for name, newSem := range impls {
+		t.Run(name, func(t *testing.T) {
+			s := newSem(1)
+			assert.True(t, s.TryAcquire())
+			assert.False(t, s.TryAcquire())
+
+			ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+			defer cancel()
+			assert.ErrorIs(t, s.AcquireContext(ctx), context.DeadlineExceeded)
+
+			s.Release()
+			assert.NoError(t, s.AcquireContext(context.Background()))
+			s.Release()
+			assert.Panics(t, s.Release)
+		})
+	}
+}
+
+// 二元 semaphore 可以由另一個 goroutine 釋放，用來當作「事件發生了」的訊號
+func TestBinaryHandoff(t *testing.T) {
+	s := NewBinary()
+	s.Acquire()
+	done := make(chan struct{})
+	go func() {
+		s.Acquire() // 等待另一個 goroutine 釋放
+		close(done)
+	}()
+	time.Sleep(5 * time.Millisecond)
+	s.Release()
+	<-done
+}
+
+func TestFairFIFO(t *testing.T) {
+	s := NewCond(1, Fair())
+	s.Acquire()
+
+	var mu sync.Mutex
+	var order []int
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			s.Acquire()
+			mu.Lock()
+			order = append(order, i)
+			mu.Unlock()
+			s.Release()
+		}(i)
+		// 確定 i 已經在排隊才啟動下一個
+		require.Eventually(t, func() bool { return s.waiting() == i+1 }, time.Second, time.Millisecond)
+	}
+	s.Release()
+	wg.Wait()
+	assert.Equal(t, []int{0, 1, 2, 3, 4}, order)
+}
+
+// 非公平模式允許插隊：有人在等時，剛 Release 的持有者可以立刻 TryAcquire 成功
+func TestBarging(t *testing.T) {
+	for _, fair := range []bool{false, true} {
+		t.Run(fmt.Sprint("fair=", fair), func(t *testing.T) {
+			var opts []Option
+			if fair {
+				opts = append(opts, Fair())
+			}
+			s := NewCond(1, opts...)
+			s.Acquire()
+			got := make(chan struct{})
+			go func() {
+				s.Acquire()
+				close(got)
+			}()
+			time.Sleep(5 * time.Millisecond)
+
+			s.Release()
+			assert.Equal(t, !fair, s.TryAcquire(), "barging")
+			if !fair {
+				s.Release()
+			}
+			<-got
+		})
+	}
+}
+
+// 取消的等待者不會卡住後面的人
+func TestFairCancelledTicketSkipped(t *testing.T) {
+	s := NewCond(1, Fair())
+	s.Acquire()
+	ctx, cancel := context.WithCancel(context.Background())
+	errc := make(chan error)
+	go func() { errc <- s.AcquireContext(ctx) }()
+	require.Eventually(t, func() bool { return s.waiting() == 1 }, time.Second, time.Millisecond)
+
+	got := make(chan struct{})
+	go func() {
+		s.Acquire()
+		close(got)
+	}()
+	require.Eventually(t, func() bool { return s.waiting() == 2 }, time.Second, time.Millisecond)
+
+	cancel()
+	assert.ErrorIs(t, <-errc, context.Canceled)
+	s.Release()
+	select {
+	case <-got:
+	case <-time.After(time.Second):
+		t.Fatal("waiter behind a cancelled ticket never acquired")
+	}
+}
+
+func BenchmarkSemaphore(b *testing.B) {
+	for _, name := range []string{"chan", "cond", "cond-fair"} {
+		b.Run(name, func(b *testing.B) {
+			s := impls[name](4)
+			b.SetParallelism(4)
+			b.RunParallel(func(pb *testing.PB) {
+				for pb.Next() {
+					s.Acquire()
+					s.Release()
+				}
+			})
+		})
+	}
+}
+
+/*
+go test -run XXX -bench . ./concurrency/semaphore
+
+BenchmarkSemaphore/chan         	21690846	        60.75 ns/op
+BenchmarkSemaphore/cond         	21445148	        67.76 ns/op
+BenchmarkSemaphore/cond-fair    	14568451	        71.73 ns/op
+
+沒有競爭時三者差不多；Fair 模式每次 Release 都要 Broadcast，等待者越多、被白白叫醒的次數越多，
+公平是用吞吐量換來的。
+*/
@@ -0,0 +1,138 @@
+package semaphore
+
+import (
+	"container/list"
+	"context"
+	"sync"
+)
+
+/*
+Weighted：每次可以取得 n 個 permit，例如依檔案大小限制同時處理的總 bytes。
+介面和 golang.org/x/sync/semaphore 相同，實作也是同樣的想法：
+
+  - 狀態（已用量、等待佇列）用 mutex 保護，等待本身靠 channel：
+    每個等待者有自己的 ready channel，輪到時由 Release 關閉，Acquire 用 select 同時等 ready 與 ctx.Done()
+  - 嚴格 FIFO：佇列最前面的人拿不到時，後面要得比較少的人也不能插隊，
+    否則一直有小請求進來時，大請求會永遠拿不到（starvation）
+  - 佇列最前面的人放棄（ctx 取消）時，後面的人可能已經拿得到了，要重新檢查一次
+  - n 大於總量的請求永遠不可能成功，和 x/sync 一樣會等到 ctx 結束
+
+單純用容量 n 的 channel（Chan）做不到「一次取得多個」：逐一送入 n 次的過程中可能和別人各拿一半，互相卡死。
+*/
+
+type Weighted struct {
+	size    int64
+	mu      sync.Mutex
+	cur     int64
+	waiters list.List // *waiter
+}
+
+type waiter struct {
+	n     int64
+	ready chan struct{} // 取得時關閉
+}
+
+func NewWeighted(n int64) *Weighted {
+	return &Weighted{size: n}
+}
+
+// Acquire 取得 n 個 permit，ctx 結束時回傳 ctx.Err() 且不佔用任何 permit
+func (s *Weighted) Acquire(ctx context.Context, n int64) error {
+	done := ctx.Done()
+	s.mu.Lock()
+	select {
+	case <-done:
+		// 已經取消就不要拿，即使剛好有空位
+		s.mu.Unlock()
+		return ctx.Err()
+	default:
+	}
+	if s.size-s.cur >= n && s.waiters.Len() == 0 {
+		s.cur += n
+		s.mu.Unlock()
+		return nil
+	}
+	if n > s.size {
+		// 不可能成功，等到 ctx 結束
+		s.mu.Unlock()
+		<-done
+		return ctx.Err()
+	}
+
+	w := waiter{n: n, ready: make(chan struct{})}
+	elem := s.waiters.PushBack(w)
+	s.mu.Unlock()
+
+	select {
+	case <-w.ready:
+		return nil
+	case <-done:
+		s.mu.Lock()
+		select {
+		case <-w.ready:
+			// 取消與取得同時發生：已經拿到了，當作成功
+			s.mu.Unlock()
+			return nil
+		default:
+		}
+		front := s.waiters.Front() == elem
+		s.waiters.Remove(elem)
+		if front && s.size > s.cur {
+			s.notifyWaiters()
+		}
+		s.mu.Unlock()
+		return ctx.Err()
+	}
+}
+
+// TryAcquire 不等待；有人在排隊時一律失敗
+func (s *Weighted) TryAcquire(n int64) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.size-s.cur >= n && s.waiters.Len() == 0 {
+		s.cur += n
+		return true
+	}
+	return false
+}
+
+func (s *Weighted) Release(n int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.cur -= n
+	if s.cur < 0 {
+		panic("semaphore: released more than held")
+	}
+	s.notifyWaiters()
+}
+
+// notifyWaiters 依序叫醒拿得到的等待者，遇到第一個拿不到的就停（不讓後面的插隊）
+func (s *Weighted) notifyWaiters() {
+	for {
+		next := s.waiters.Front()
+		if next == nil {
+			return
+		}
+		w := next.Value.(waiter)
+		if s.size-s.cur < w.n {
+			return
+		}
+		s.cur += w.n
+		s.waiters.Remove(next)
+		close(w.ready)
+	}
+}
+
+// Available 回傳目前沒有被佔用的 permit 數
+func (s *Weighted) Available() int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.size - s.cur
+}
+
+// queued 回傳排隊中的人數（測試用）
+func (s *Weighted) queued() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.waiters.Len()
+}
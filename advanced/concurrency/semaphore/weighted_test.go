@@ -0,0 +1,217 @@
+package semaphore
+
+import (
+	"context"
+	"math/rand"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	xsem "golang.org/x/sync/semaphore"
+)
+
+type weighted interface {
+	Acquire(ctx context.Context, n int64) error
+	TryAcquire(n int64) bool
+	Release(n int64)
+}
+
+// 同一組測試跑在自己的實作與 x/sync/semaphore 上，行為應該一樣
+var weightedImpls = map[string]func(n int64) weighted{
+	"ours":   func(n int64) weighted { return NewWeighted(n) },
+	"x/sync": func(n int64) weighted { return xsem.NewWeighted(n) },
+}
+
+// x/sync 沒有辦法看佇列長度，只能等一下讓 goroutine 排進去
+func settle() { time.Sleep(10 * time.Millisecond) }
+
+func TestWeightedContention(t *testing.T) {
+	const size = 10
+	for name, newSem := range weightedImpls {
+		t.Run(name, func(t *testing.T) {
+			s := newSem(size)
+			var cur, peak, acquired int64
+			var wg sync.WaitGroup
+			for i := 0; i < 50; i++ {
+				wg.Add(1)
+				go func(seed int64) {
+					defer wg.Done()
+					rng := rand.New(rand.NewSource(seed))
+					for j := 0; j < 100; j++ {
+						n := rng.Int63n(size) + 1
+						if !assert.NoError(t, s.Acquire(context.Background(), n)) {
+							return
+						}
+						c := atomic.AddInt64(&cur, n)
+						for {
+							p := atomic.LoadInt64(&peak)
+							if c <= p || atomic.CompareAndSwapInt64(&peak, p, c) {
+								break
+							}
+						}
+						atomic.AddInt64(&acquired, n)
+						atomic.AddInt64(&cur, -n)
+						s.Release(n)
+					}
+				}(int64(i))
+			}
+			wg.Wait()
+			assert.LessOrEqual(t, peak, int64(size))
+			assert.Greater(t, acquired, int64(0))
+			assert.True(t, s.TryAcquire(size), "all permits returned")
+		})
+	}
+}
+
+// 排在前面的大請求拿不到時，後面的小請求也不能插隊
+func TestWeightedFIFO(t *testing.T) {
+	for name, newSem := range weightedImpls {
+		t.Run(name, func(t *testing.T) {
+			s := newSem(10)
+			require.True(t, s.TryAcquire(10))
+
+			var order []int64
+			var mu sync.Mutex
+			var wg sync.WaitGroup
+			acquire := func(n int64) {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					assert.NoError(t, s.Acquire(context.Background(), n))
+					mu.Lock()
+					order = append(order, n)
+					mu.Unlock()
+				}()
+				settle()
+			}
+			acquire(8)
+			acquire(1)
+
+			s.Release(5) // 5 個可用：夠給 1，但 8 在前面
+			settle()
+			mu.Lock()
+			assert.Empty(t, order, "small request must not jump the queue")
+			mu.Unlock()
+			assert.False(t, s.TryAcquire(1), "TryAcquire fails while others wait")
+
+			s.Release(5) // 10 個可用：8 與 1 同時被放行
+			wg.Wait()
+			assert.ElementsMatch(t, []int64{8, 1}, order)
+			assert.True(t, s.TryAcquire(1))
+			assert.False(t, s.TryAcquire(1))
+			s.Release(10)
+			assert.True(t, s.TryAcquire(10))
+		})
+	}
+}
+
+// 最前面的等待者放棄後，後面拿得到的人要被叫醒
+func TestWeightedCancelFront(t *testing.T) {
+	for name, newSem := range weightedImpls {
+		t.Run(name, func(t *testing.T) {
+			s := newSem(10)
+			require.True(t, s.TryAcquire(5))
+
+			ctx, cancel := context.WithCancel(context.Background())
+			big := make(chan error)
+			go func() { big <- s.Acquire(ctx, 10) }()
+			settle()
+			small := make(chan error)
+			go func() { small <- s.Acquire(context.Background(), 3) }()
+			settle()
+
+			select {
+			case <-small:
+				t.Fatal("small acquired while big was in front")
+			default:
+			}
+			cancel()
+			assert.ErrorIs(t, <-big, context.Canceled)
+			select {
+			case err := <-small:
+				assert.NoError(t, err)
+			case <-time.After(time.Second):
+				t.Fatal("waiter behind a cancelled request never acquired")
+			}
+			s.Release(8)
+			assert.True(t, s.TryAcquire(10))
+		})
+	}
+}
+
+func TestWeightedContext(t *testing.T) {
+	for name, newSem := range weightedImpls {
+		t.Run(name, func(t *testing.T) {
+			s := newSem(2)
+
+			// 超過總量的請求等到 ctx 結束
+			ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+			defer cancel()
+			assert.ErrorIs(t, s.Acquire(ctx, 3), context.DeadlineExceeded)
+
+			// 已取消的 ctx 不會拿到 permit
+			done, cancel2 := context.WithCancel(context.Background())
+			cancel2()
+			assert.Error(t, s.Acquire(done, 1))
+			assert.True(t, s.TryAcquire(2), "nothing leaked")
+			s.Release(2)
+		})
+	}
+}
+
+func TestWeightedReleasePanics(t *testing.T) {
+	s := NewWeighted(3)
+	require.True(t, s.TryAcquire(2))
+	assert.Equal(t, int64(1), s.Available())
+	assert.Panics(t, func() { s.Release(3) })
+}
+
+// 取消與 Release 同時發生很多次，最後 permit 數量要對得上
+func TestWeightedCancelRace(t *testing.T) {
+	s := NewWeighted(4)
+	var wg sync.WaitGroup
+	for i := 0; i < 200; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			ctx, cancel := context.WithTimeout(context.Background(), time.Duration(i%5)*100*time.Microsecond)
+			defer cancel()
+			if s.Acquire(ctx, int64(i%4)+1) == nil {
+				time.Sleep(50 * time.Microsecond)
+				s.Release(int64(i%4) + 1)
+			}
+		}(i)
+	}
+	wg.Wait()
+	assert.Equal(t, int64(4), s.Available())
+	assert.Zero(t, s.queued())
+}
+
+func BenchmarkWeighted(b *testing.B) {
+	for _, name := range []string{"ours", "x/sync"} {
+		b.Run(name, func(b *testing.B) {
+			s := weightedImpls[name](8)
+			ctx := context.Background()
+			b.SetParallelism(4)
+			b.RunParallel(func(pb *testing.PB) {
+				for pb.Next() {
+					_ = s.Acquire(ctx, 3)
+					s.Release(3)
+				}
+			})
+		})
+	}
+}
+
+/*
+go test -run XXX -bench Weighted ./concurrency/semaphore
+
+BenchmarkWeighted/ours          	20114002	       372.1 ns/op
+BenchmarkWeighted/x/sync        	30978327	       372.4 ns/op
+
+兩者的結構相同（mutex 保護狀態、每個等待者一個 channel），速度也差不多；
+比 Chan 慢是因為每次 Acquire 都要檢查 ctx 並可能建立 waiter，換到的是「一次取得 n 個」與嚴格 FIFO。
+*/
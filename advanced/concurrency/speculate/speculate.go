@@ -0,0 +1,149 @@
+package speculate
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"time"
+)
+
+/*
+Speculative execution（hedged request）：主要計算太慢時，過一段時間再啟動一個較便宜的備案，誰先完成就用誰。
+
+	primary  ├──────────────────────────────┤ (被取消)
+	             delay
+	fallback          ├──────────┤ ← 先完成，回傳這個結果
+
+規則：
+  - primary 在 delay 內完成就不啟動 fallback
+  - primary 在 delay 內失敗，立刻啟動 fallback，不必再等
+  - 先「成功」的一方獲勝，另一方的 ctx 被取消；一方失敗時繼續等另一方
+  - 兩者都失敗時回傳兩個錯誤（errors.Join）
+
+delay 太短會讓大部分請求都多跑一次 fallback，太長則等於沒有 speculation。
+Stats 記錄 fallback 被啟動與獲勝的次數，用來調整 delay：
+speculation 很少獲勝表示 delay 可以拉長，減少浪費。
+*/
+
+type Func[T any] func(ctx context.Context) (T, error)
+
+type Winner int
+
+const (
+	None Winner = iota // 兩者都失敗
+	Primary
+	Fallback
+)
+
+func (w Winner) String() string {
+	switch w {
+	case Primary:
+		return "primary"
+	case Fallback:
+		return "fallback"
+	}
+	return "none"
+}
+
+type Stats struct {
+	Calls        int64
+	Speculated   int64 // 有啟動 fallback 的次數
+	PrimaryWins  int64
+	FallbackWins int64
+	Failures     int64 // 兩者都失敗
+}
+
+// WinRate 回傳啟動 fallback 後 fallback 獲勝的比例
+func (s Stats) WinRate() float64 {
+	if s.Speculated == 0 {
+		return 0
+	}
+	return float64(s.FallbackWins) / float64(s.Speculated)
+}
+
+type Speculator[T any] struct {
+	delay time.Duration
+
+	calls, speculated, primaryWins, fallbackWins, failures atomic.Int64
+}
+
+func New[T any](delay time.Duration) *Speculator[T] {
+	return &Speculator[T]{delay: delay}
+}
+
+type outcome[T any] struct {
+	who Winner
+	v   T
+	err error
+}
+
+// Do 執行 primary，必要時加上 fallback，回傳先成功的結果與獲勝的一方。
+// 回傳前會取消落敗的一方，但不等它結束；fn 應該尊重 ctx 盡快返回。
+func (s *Speculator[T]) Do(ctx context.Context, primary, fallback Func[T]) (T, Winner, error) {
+	s.calls.Add(1)
+	pctx, pcancel := context.WithCancel(ctx)
+	defer pcancel()
+	fctx, fcancel := context.WithCancel(ctx)
+	defer fcancel()
+
+	// buffer 2：落敗的一方送出結果時不會因為沒人接收而卡住
+	results := make(chan outcome[T], 2)
+	run := func(ctx context.Context, who Winner, fn Func[T]) {
+		v, err := fn(ctx)
+		results <- outcome[T]{who: who, v: v, err: err}
+	}
+	go run(pctx, Primary, primary)
+
+	timer := time.NewTimer(s.delay)
+	defer timer.Stop()
+	pending := 1
+	launched := false
+	launch := func() {
+		if !launched {
+			launched = true
+			pending++
+			s.speculated.Add(1)
+			go run(fctx, Fallback, fallback)
+		}
+	}
+
+	var errs []error
+	for pending > 0 {
+		select {
+		case <-timer.C:
+			launch()
+		case r := <-results:
+			pending--
+			if r.err == nil {
+				if r.who == Primary {
+					s.primaryWins.Add(1)
+				} else {
+					s.fallbackWins.Add(1)
+				}
+				return r.v, r.who, nil
+			}
+			errs = append(errs, fmt.Errorf("speculate: %s: %w", r.who, r.err))
+			if r.who == Primary {
+				launch()
+			}
+		case <-ctx.Done():
+			s.failures.Add(1)
+			var zero T
+			return zero, None, ctx.Err()
+		}
+	}
+	s.failures.Add(1)
+	var zero T
+	return zero, None, errors.Join(errs...)
+}
+
+func (s *Speculator[T]) Stats() Stats {
+	return Stats{
+		Calls:        s.calls.Load(),
+		Speculated:   s.speculated.Load(),
+		PrimaryWins:  s.primaryWins.Load(),
+		FallbackWins: s.fallbackWins.Load(),
+		Failures:     s.failures.Load(),
+	}
+}
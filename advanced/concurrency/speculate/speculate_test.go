@@ -0,0 +1,93 @@
+package speculate
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func value(v string, d time.Duration) Func[string] {
+	return func(ctx context.Context) (string, error) {
+		select {
+		case <-time.After(d):
+			return v, nil
+		case <-ctx.Done():
+			return "", ctx.Err()
+		}
+	}
+}
+
+func fail(err error) Func[string] {
+	return func(context.Context) (string, error) { return "", err }
+}
+
+func TestPrimaryFastNoSpeculation(t *testing.T) {
+	s := New[string](50 * time.Millisecond)
+	called := false
+	v, w, err := s.Do(context.Background(), value("p", 0), func(context.Context) (string, error) {
+		called = true
+		return "f", nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "p", v)
+	assert.Equal(t, Primary, w)
+	assert.False(t, called)
+	assert.Equal(t, Stats{Calls: 1, PrimaryWins: 1}, s.Stats())
+}
+
+func TestFallbackWinsAndCancelsPrimary(t *testing.T) {
+	s := New[string](10 * time.Millisecond)
+	cancelled := make(chan error, 1)
+	primary := func(ctx context.Context) (string, error) {
+		<-ctx.Done()
+		cancelled <- ctx.Err()
+		return "", ctx.Err()
+	}
+	v, w, err := s.Do(context.Background(), primary, value("f", 0))
+	require.NoError(t, err)
+	assert.Equal(t, "f", v)
+	assert.Equal(t, Fallback, w)
+	assert.ErrorIs(t, <-cancelled, context.Canceled)
+	assert.Equal(t, 1.0, s.Stats().WinRate())
+}
+
+func TestPrimaryWinsAfterSpeculation(t *testing.T) {
+	s := New[string](5 * time.Millisecond)
+	v, w, err := s.Do(context.Background(), value("p", 20*time.Millisecond), value("f", time.Second))
+	require.NoError(t, err)
+	assert.Equal(t, "p", v)
+	assert.Equal(t, Primary, w)
+	assert.Equal(t, Stats{Calls: 1, Speculated: 1, PrimaryWins: 1}, s.Stats())
+	assert.Equal(t, 0.0, s.Stats().WinRate())
+}
+
+// primary 提早失敗時不用等 delay
+func TestPrimaryErrorLaunchesFallbackImmediately(t *testing.T) {
+	s := New[string](time.Hour)
+	v, w, err := s.Do(context.Background(), fail(errors.New("down")), value("f", 0))
+	require.NoError(t, err)
+	assert.Equal(t, "f", v)
+	assert.Equal(t, Fallback, w)
+}
+
+func TestBothFail(t *testing.T) {
+	s := New[string](time.Millisecond)
+	e1, e2 := errors.New("e1"), errors.New("e2")
+	_, w, err := s.Do(context.Background(), fail(e1), fail(e2))
+	assert.Equal(t, None, w)
+	assert.ErrorIs(t, err, e1)
+	assert.ErrorIs(t, err, e2)
+	assert.EqualValues(t, 1, s.Stats().Failures)
+}
+
+func TestContextCancel(t *testing.T) {
+	s := New[string](time.Millisecond)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, _, err := s.Do(ctx, value("p", time.Second), value("f", time.Second))
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
@@ -0,0 +1,83 @@
+package waitgroup
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime/debug"
+	"sync"
+)
+
+/*
+sync.WaitGroup 常見的誤用：
+ 1. 在 goroutine 裡（或 go 之後）才 Add：Wait 可能在 Add 之前執行，看到計數器為 0 直接返回
+ 2. 忘記 Done，或 panic 時沒有 Done：Wait 永遠不會返回
+ 3. 只能等待，拿不到錯誤；也無法在 ctx 取消時放棄等待
+
+Group 把 Add 與 go 綁在一起（Go 方法內先 Add 再啟動 goroutine），
+用 defer 保證 Done，panic 會被 recover 成 *PanicError，所有錯誤以 errors.Join 合併回傳。
+零值即可使用。
+*/
+
+// PanicError 包裝 goroutine 中的 panic
+type PanicError struct {
+	Value any
+	Stack []byte
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("waitgroup: panic: %v\n%s", e.Value, e.Stack)
+}
+
+// Unwrap 讓 panic(err) 的 err 可以用 errors.Is / errors.As 判斷
+func (e *PanicError) Unwrap() error {
+	err, _ := e.Value.(error)
+	return err
+}
+
+type Group struct {
+	wg   sync.WaitGroup
+	mu   sync.Mutex
+	errs []error
+}
+
+// Go 在新的 goroutine 中執行 fn
+func (g *Group) Go(fn func() error) {
+	g.wg.Add(1) // 一定要在 go 之前
+	go func() {
+		defer g.wg.Done()
+		if err := g.run(fn); err != nil {
+			g.mu.Lock()
+			g.errs = append(g.errs, err)
+			g.mu.Unlock()
+		}
+	}()
+}
+
+func (g *Group) run(fn func() error) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = &PanicError{Value: r, Stack: debug.Stack()}
+		}
+	}()
+	return fn()
+}
+
+// Wait 等待所有 goroutine 結束，回傳合併後的錯誤（依完成順序）。
+// ctx 先結束時立即回傳 ctx.Err()，但已啟動的 goroutine 不會被停止，
+// 需要讓它們提早結束的話要把同一個 ctx 傳進 fn。
+func (g *Group) Wait(ctx context.Context) error {
+	done := make(chan struct{})
+	go func() {
+		g.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return errors.Join(g.errs...)
+}
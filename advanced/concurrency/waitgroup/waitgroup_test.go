@@ -0,0 +1,83 @@
+package waitgroup
+
+import (
+	"context"
+	"errors"
+	"io"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWaitAll(t *testing.T) {
+	var g Group
+	var n int32
+	for i := 0; i < 10; i++ {
+		g.Go(func() error {
+			time.Sleep(time.Millisecond)
+			atomic.AddInt32(&n, 1)
+			return nil
+		})
+	}
+	require.NoError(t, g.Wait(context.Background()))
+	assert.Equal(t, int32(10), atomic.LoadInt32(&n))
+}
+
+func TestAggregatedErrors(t *testing.T) {
+	var g Group
+	errA, errB := errors.New("a"), errors.New("b")
+	g.Go(func() error { return errA })
+	g.Go(func() error { return nil })
+	g.Go(func() error { return errB })
+
+	err := g.Wait(context.Background())
+	assert.ErrorIs(t, err, errA)
+	assert.ErrorIs(t, err, errB)
+	assert.Len(t, err.(interface{ Unwrap() []error }).Unwrap(), 2)
+}
+
+func TestPanicCaptured(t *testing.T) {
+	var g Group
+	g.Go(func() error { panic("boom") })
+	g.Go(func() error { panic(io.ErrUnexpectedEOF) })
+
+	err := g.Wait(context.Background())
+	var pe *PanicError
+	require.ErrorAs(t, err, &pe)
+	assert.Contains(t, pe.Error(), "boom")
+	assert.Contains(t, string(pe.Stack), "waitgroup_test.go")
+	assert.ErrorIs(t, err, io.ErrUnexpectedEOF)
+}
+
+func TestWaitContext(t *testing.T) {
+	var g Group
+	release := make(chan struct{})
+	g.Go(func() error {
+		<-release
+		return nil
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, g.Wait(ctx), context.DeadlineExceeded)
+
+	// goroutine 仍在執行；放行後再次 Wait 可以正常結束
+	close(release)
+	assert.NoError(t, g.Wait(context.Background()))
+}
+
+// 與 basic/goroutine 的 TestGoroutineWaitGroup 相同的情境：Add 不會晚於 goroutine 啟動，Wait 一定會等到
+func TestAddBeforeGo(t *testing.T) {
+	var g Group
+	var finished int32
+	g.Go(func() error {
+		time.Sleep(20 * time.Millisecond)
+		atomic.StoreInt32(&finished, 1)
+		return nil
+	})
+	require.NoError(t, g.Wait(context.Background()))
+	assert.Equal(t, int32(1), atomic.LoadInt32(&finished))
+}
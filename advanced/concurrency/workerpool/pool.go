@@ -0,0 +1,210 @@
+package workerpool
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"advanced/timex/histogram"
+)
+
+/*
+可暫停、可調整大小的 worker pool。
+
+basic/csp 的 WorkerPool 用 channel + close 收尾，簡單但無法在執行中改變狀態；
+這裡需要「暫停」「縮減 worker」這類要同時看好幾個狀態的條件，
+改用一把鎖 + sync.Cond，所有狀態改變都 Broadcast，等待者醒來自己檢查條件。
+
+  - Pause：worker 不再從 queue 取任務，等執行中的任務做完才回傳；Submit 仍可放進 queue
+  - Resume：worker 繼續取任務
+  - Resize(n)：變大時直接啟動新的 worker；變小時多出來的 worker 做完手上的任務後在取下一個任務前離開
+  - Close：不再接受任務，把 queue 做完後回傳（暫停中也會恢復執行）
+
+任務只會在 worker 取出時離開 queue，取出後一定會執行完，因此暫停與縮減都不會遺失任務。
+*/
+
+var (
+	ErrClosed      = errors.New("workerpool: closed")
+	ErrInvalidSize = errors.New("workerpool: size must be positive")
+)
+
+type Pool struct {
+	mu   sync.Mutex
+	cond *sync.Cond
+
+	queue     []func()
+	queueSize int
+	latency   *histogram.Histogram
+
+	target  int // 期望的 worker 數
+	running int // 存活的 worker 數，縮減時會暫時大於 target
+	active  int // 正在執行任務的 worker 數
+	paused  bool
+	closed  bool
+
+	wg sync.WaitGroup
+}
+
+type Option func(*Pool)
+
+// WithQueueSize 設定 queue 容量，滿了 Submit 會等待；預設為 worker 數
+func WithQueueSize(n int) Option {
+	return func(p *Pool) { p.queueSize = n }
+}
+
+// WithLatency 把每個任務的執行時間記錄到 h
+func WithLatency(h *histogram.Histogram) Option {
+	return func(p *Pool) { p.latency = h }
+}
+
+func New(workers int, opts ...Option) *Pool {
+	if workers < 1 {
+		panic(ErrInvalidSize)
+	}
+	p := &Pool{target: workers, queueSize: workers}
+	p.cond = sync.NewCond(&p.mu)
+	for _, o := range opts {
+		o(p)
+	}
+	p.mu.Lock()
+	p.spawn()
+	p.mu.Unlock()
+	return p
+}
+
+// spawn 補足 worker 到 target，呼叫前需持有鎖
+func (p *Pool) spawn() {
+	for ; p.running < p.target; p.running++ {
+		p.wg.Add(1)
+		go p.worker()
+	}
+}
+
+func (p *Pool) worker() {
+	defer p.wg.Done()
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for {
+		for !p.shouldExit() && (p.paused || len(p.queue) == 0) {
+			p.cond.Wait()
+		}
+		if p.shouldExit() {
+			p.running--
+			p.cond.Broadcast()
+			return
+		}
+		task := p.queue[0]
+		p.queue[0] = nil
+		p.queue = p.queue[1:]
+		p.active++
+		p.cond.Broadcast() // queue 有空位
+		p.mu.Unlock()
+
+		start := time.Now()
+		task() // 與一般 goroutine 相同，任務 panic 會讓整個程式結束
+		if p.latency != nil {
+			p.latency.Since(start)
+		}
+
+		p.mu.Lock()
+		p.active--
+		p.cond.Broadcast()
+	}
+}
+
+// shouldExit：worker 太多，或已關閉且 queue 做完了
+func (p *Pool) shouldExit() bool {
+	return p.running > p.target || (p.closed && len(p.queue) == 0)
+}
+
+// Submit 把任務放進 queue；queue 滿時等待，ctx 取消或 pool 關閉時回傳錯誤
+func (p *Pool) Submit(ctx context.Context, task func()) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if ctx.Done() != nil {
+		stop := context.AfterFunc(ctx, func() {
+			p.mu.Lock()
+			p.cond.Broadcast()
+			p.mu.Unlock()
+		})
+		defer stop()
+	}
+	for !p.closed && len(p.queue) >= p.queueSize {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		p.cond.Wait()
+	}
+	if p.closed {
+		return ErrClosed
+	}
+	p.queue = append(p.queue, task)
+	p.cond.Broadcast()
+	return nil
+}
+
+// Pause 停止取出新任務，等執行中的任務完成後回傳
+func (p *Pool) Pause() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.paused = true
+	for p.active > 0 {
+		p.cond.Wait()
+	}
+}
+
+func (p *Pool) Resume() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.paused = false
+	p.cond.Broadcast()
+}
+
+// Resize 調整 worker 數；縮減時不等多出來的 worker 離開
+func (p *Pool) Resize(n int) error {
+	if n < 1 {
+		return ErrInvalidSize
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.closed {
+		return ErrClosed
+	}
+	p.target = n
+	p.spawn()
+	p.cond.Broadcast()
+	return nil
+}
+
+// Wait 等到 queue 清空且沒有執行中的任務；暫停中且 queue 不為空時會一直等到 Resume
+func (p *Pool) Wait() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for len(p.queue) > 0 || p.active > 0 {
+		p.cond.Wait()
+	}
+}
+
+// Close 不再接受任務，做完 queue 中剩下的任務、所有 worker 離開後回傳
+func (p *Pool) Close() {
+	p.mu.Lock()
+	p.closed = true
+	p.paused = false
+	p.cond.Broadcast()
+	p.mu.Unlock()
+	p.wg.Wait()
+}
+
+type Stats struct {
+	Workers int // 存活的 worker 數
+	Active  int
+	Queued  int
+	Paused  bool
+}
+
+func (p *Pool) Stats() Stats {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return Stats{Workers: p.running, Active: p.active, Queued: len(p.queue), Paused: p.paused}
+}
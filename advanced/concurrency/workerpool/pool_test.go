@@ -0,0 +1,200 @@
+package workerpool
+
+import (
+	"context"
+	"math/rand"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/goleak"
+
+	"advanced/timex/histogram"
+)
+
+func TestMain(m *testing.M) {
+	goleak.VerifyTestMain(m)
+}
+
+func TestSubmitAndClose(t *testing.T) {
+	p := New(4, WithQueueSize(8))
+	var n int64
+	for i := 0; i < 100; i++ {
+		require.NoError(t, p.Submit(context.Background(), func() { atomic.AddInt64(&n, 1) }))
+	}
+	p.Close()
+	assert.EqualValues(t, 100, n)
+	assert.ErrorIs(t, p.Submit(context.Background(), func() {}), ErrClosed)
+	assert.ErrorIs(t, p.Resize(2), ErrClosed)
+}
+
+func TestPauseStopsIntake(t *testing.T) {
+	p := New(2, WithQueueSize(10))
+	defer p.Close()
+
+	release := make(chan struct{})
+	var n int64
+	task := func() {
+		<-release
+		atomic.AddInt64(&n, 1)
+	}
+	for i := 0; i < 2; i++ {
+		require.NoError(t, p.Submit(context.Background(), task))
+	}
+	require.Eventually(t, func() bool { return p.Stats().Active == 2 }, time.Second, time.Millisecond)
+
+	// Pause 要等執行中的任務完成才回傳
+	paused := make(chan struct{})
+	go func() {
+		p.Pause()
+		close(paused)
+	}()
+	for i := 0; i < 5; i++ {
+		require.NoError(t, p.Submit(context.Background(), task))
+	}
+	select {
+	case <-paused:
+		t.Fatal("Pause returned while tasks are running")
+	case <-time.After(20 * time.Millisecond):
+	}
+	close(release)
+	<-paused
+
+	// 暫停期間沒有任務被取出
+	time.Sleep(20 * time.Millisecond)
+	s := p.Stats()
+	assert.True(t, s.Paused)
+	assert.Equal(t, 0, s.Active)
+	assert.Equal(t, 5, s.Queued)
+	assert.EqualValues(t, 2, atomic.LoadInt64(&n))
+
+	p.Resume()
+	p.Wait()
+	assert.EqualValues(t, 7, atomic.LoadInt64(&n))
+}
+
+func TestResize(t *testing.T) {
+	p := New(2, WithQueueSize(100))
+	defer p.Close()
+
+	var cur, peak int64
+	task := func() {
+		c := atomic.AddInt64(&cur, 1)
+		for {
+			old := atomic.LoadInt64(&peak)
+			if c <= old || atomic.CompareAndSwapInt64(&peak, old, c) {
+				break
+			}
+		}
+		time.Sleep(2 * time.Millisecond)
+		atomic.AddInt64(&cur, -1)
+	}
+	submit := func(n int) {
+		for i := 0; i < n; i++ {
+			require.NoError(t, p.Submit(context.Background(), task))
+		}
+		p.Wait()
+	}
+
+	require.NoError(t, p.Resize(6))
+	assert.Equal(t, 6, p.Stats().Workers)
+	submit(60)
+	assert.EqualValues(t, 6, atomic.LoadInt64(&peak))
+
+	// 縮減後多出來的 worker 會離開，並行數不超過新的大小
+	require.NoError(t, p.Resize(1))
+	require.Eventually(t, func() bool { return p.Stats().Workers == 1 }, time.Second, time.Millisecond)
+	atomic.StoreInt64(&peak, 0)
+	submit(10)
+	assert.EqualValues(t, 1, atomic.LoadInt64(&peak))
+
+	assert.ErrorIs(t, p.Resize(0), ErrInvalidSize)
+}
+
+func TestSubmitContext(t *testing.T) {
+	p := New(1, WithQueueSize(1))
+	p.Pause()
+	require.NoError(t, p.Submit(context.Background(), func() {}))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, p.Submit(ctx, func() {}), context.DeadlineExceeded)
+	p.Close() // 暫停中的 pool 也會把 queue 做完
+	assert.Equal(t, 0, p.Stats().Queued)
+}
+
+// 一邊送任務，一邊不斷暫停、恢復、調整大小，最後所有任務都要剛好執行一次
+func TestNoTaskLossAcrossPauseAndResize(t *testing.T) {
+	const producers, perProducer = 4, 500
+	p := New(3, WithQueueSize(16))
+
+	var mu sync.Mutex
+	done := make(map[int]int)
+	var wg sync.WaitGroup
+	for w := 0; w < producers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for i := 0; i < perProducer; i++ {
+				id := w*perProducer + i
+				require.NoError(t, p.Submit(context.Background(), func() {
+					mu.Lock()
+					done[id]++
+					mu.Unlock()
+				}))
+			}
+		}(w)
+	}
+
+	stop := make(chan struct{})
+	chaos := make(chan struct{})
+	go func() {
+		defer close(chaos)
+		r := rand.New(rand.NewSource(1))
+		for {
+			select {
+			case <-stop:
+				p.Resume()
+				return
+			default:
+			}
+			switch r.Intn(3) {
+			case 0:
+				p.Pause()
+				time.Sleep(time.Duration(r.Intn(200)) * time.Microsecond)
+				p.Resume()
+			case 1:
+				_ = p.Resize(1 + r.Intn(8))
+			case 2:
+				time.Sleep(time.Duration(r.Intn(200)) * time.Microsecond)
+			}
+		}
+	}()
+
+	wg.Wait()
+	close(stop)
+	<-chaos
+	p.Close()
+
+	require.Len(t, done, producers*perProducer)
+	for id, n := range done {
+		if n != 1 {
+			t.Fatalf("task %d ran %d times", id, n)
+		}
+	}
+}
+
+func TestLatencyHistogram(t *testing.T) {
+	h := histogram.New()
+	p := New(2, WithLatency(h))
+	for i := 0; i < 10; i++ {
+		require.NoError(t, p.Submit(context.Background(), func() { time.Sleep(time.Millisecond) }))
+	}
+	p.Close()
+	s := h.Snapshot()
+	assert.EqualValues(t, 10, s.Count)
+	assert.GreaterOrEqual(t, s.Min, time.Millisecond)
+}
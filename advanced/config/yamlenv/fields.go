@@ -0,0 +1,68 @@
+package yamlenv
+
+import (
+	"encoding"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"advanced/reflectx"
+)
+
+var textUnmarshaler = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+
+// fieldName 以 yaml tag 為準，與 yaml.v3 一樣預設為小寫的欄位名稱
+func fieldName(sf reflect.StructField) string {
+	if tag := sf.Tag.Get("yaml"); tag != "" {
+		if name, _, _ := strings.Cut(tag, ","); name != "" && name != "-" {
+			return name
+		}
+	}
+	return strings.ToLower(sf.Name)
+}
+
+// overlayEnv 以 env tag 的環境變數覆蓋欄位；覆蓋了整個 struct 欄位時不再往下處理
+func overlayEnv(out interface{}, lookup func(string) (string, bool), errs *Errors) error {
+	return reflectx.WalkNamed(out, fieldName, func(f reflectx.Field) error {
+		key := f.Tag("env")
+		if key == "" {
+			return nil
+		}
+		s, ok := lookup(key)
+		if !ok {
+			return nil
+		}
+		if err := setString(f.Value, s); err != nil {
+			*errs = append(*errs, &FieldError{Path: f.Path, Err: fmt.Errorf("env %s: %w", key, err)})
+		}
+		return reflectx.SkipStruct
+	})
+}
+
+// setString 把環境變數字串轉換成欄位的型別；slice 以逗號分隔：HOSTS=a,b,c
+func setString(v reflect.Value, s string) error {
+	if v.Kind() == reflect.Slice && !v.Addr().Type().Implements(textUnmarshaler) {
+		parts := strings.Split(s, ",")
+		sl := reflect.MakeSlice(v.Type(), len(parts), len(parts))
+		for i, p := range parts {
+			if err := reflectx.FromString(sl.Index(i), strings.TrimSpace(p)); err != nil {
+				return err
+			}
+		}
+		v.Set(sl)
+		return nil
+	}
+	return reflectx.FromString(v, s)
+}
+
+var ErrRequired = reflectx.ErrRequired
+
+// validate 以 reflectx.Validate 檢查 out，最外層 Validator 的錯誤以 "(root)" 為路徑
+func validate(out interface{}, errs *Errors) error {
+	return reflectx.Validate(out, fieldName, func(path string, err error) {
+		if path == "" {
+			path = "(root)"
+		}
+		*errs = append(*errs, &FieldError{Path: path, Err: err})
+	})
+}
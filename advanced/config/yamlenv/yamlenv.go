@@ -0,0 +1,178 @@
+package yamlenv
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+/*
+載入順序（後面覆蓋前面）：
+ 1. YAML 檔案，載入前先展開 ${VAR} / ${VAR:-default}
+ 2. 有 `env:"DB_HOST"` tag 的欄位，若環境變數存在則覆蓋
+ 3. 驗證：`validate:"required,min=1,max=65535,oneof=debug info"` 與 Validator 介面
+所有驗證錯誤會一次回報（Errors），不會修一個錯才看到下一個。
+
+	type Config struct {
+		DB struct {
+			Host string `yaml:"host" env:"DB_HOST" validate:"required"`
+			Port int    `yaml:"port" env:"DB_PORT" validate:"min=1,max=65535"`
+		} `yaml:"db"`
+	}
+*/
+
+// Validator 讓 struct 自行做跨欄位檢查
+type Validator interface {
+	Validate() error
+}
+
+type FieldError struct {
+	Path string // 以 yaml 名稱組成，例如 db.port
+	Err  error
+}
+
+func (e *FieldError) Error() string { return e.Path + ": " + e.Err.Error() }
+func (e *FieldError) Unwrap() error { return e.Err }
+
+// Errors 收集所有欄位錯誤
+type Errors []*FieldError
+
+// Unwrap 讓 errors.Is 可以檢查其中任一個錯誤
+func (es Errors) Unwrap() []error {
+	out := make([]error, len(es))
+	for i, e := range es {
+		out[i] = e
+	}
+	return out
+}
+
+func (es Errors) Error() string {
+	msgs := make([]string, len(es))
+	for i, e := range es {
+		msgs[i] = e.Error()
+	}
+	return fmt.Sprintf("yamlenv: %d invalid field(s): %s", len(es), strings.Join(msgs, "; "))
+}
+
+var ErrUndefinedVar = errors.New("yamlenv: undefined variable")
+
+type config struct {
+	lookup func(string) (string, bool)
+	strict bool
+}
+
+type Option func(*config)
+
+// WithLookup 替換環境變數來源，測試時使用 map 即可
+func WithLookup(fn func(string) (string, bool)) Option {
+	return func(c *config) { c.lookup = fn }
+}
+
+// WithEnvMap 是 WithLookup 的簡便版本
+func WithEnvMap(env map[string]string) Option {
+	return WithLookup(func(k string) (string, bool) {
+		v, ok := env[k]
+		return v, ok
+	})
+}
+
+// Strict 讓 YAML 中出現 struct 沒有的欄位時回報錯誤（常見的拼字錯誤）
+func Strict() Option {
+	return func(c *config) { c.strict = true }
+}
+
+func Load(path string, out interface{}, opts ...Option) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	if err := Parse(data, out, opts...); err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	return nil
+}
+
+// Parse 依序完成變數展開、YAML 解碼、環境變數覆蓋與驗證；out 必須是 struct 指標
+func Parse(data []byte, out interface{}, opts ...Option) error {
+	c := config{lookup: os.LookupEnv}
+	for _, opt := range opts {
+		opt(&c)
+	}
+	rv := reflect.ValueOf(out)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() || rv.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("yamlenv: out must be a non-nil pointer to struct, got %T", out)
+	}
+
+	expanded, err := Expand(string(data), c.lookup)
+	if err != nil {
+		return err
+	}
+	dec := yaml.NewDecoder(bytes.NewReader([]byte(expanded)))
+	dec.KnownFields(c.strict)
+	// 空檔案回傳 io.EOF，視為全部使用預設值
+	if err := dec.Decode(out); err != nil && !errors.Is(err, io.EOF) {
+		return fmt.Errorf("yamlenv: %w", err)
+	}
+
+	var errs Errors
+	if err := overlayEnv(out, c.lookup, &errs); err != nil {
+		return fmt.Errorf("yamlenv: %w", err)
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+	if err := validate(out, &errs); err != nil {
+		return fmt.Errorf("yamlenv: %w", err)
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// Expand 展開 ${VAR} 與 ${VAR:-default}；未定義且沒有預設值的變數會回報錯誤，$$ 表示字面上的 $
+func Expand(s string, lookup func(string) (string, bool)) (string, error) {
+	var b strings.Builder
+	var missing []string
+	for i := 0; i < len(s); i++ {
+		if s[i] != '$' || i+1 >= len(s) {
+			b.WriteByte(s[i])
+			continue
+		}
+		switch s[i+1] {
+		case '$':
+			b.WriteByte('$')
+			i++
+			continue
+		case '{':
+		default:
+			b.WriteByte('$')
+			continue
+		}
+		end := strings.IndexByte(s[i+2:], '}')
+		if end < 0 {
+			b.WriteString(s[i:])
+			break
+		}
+		expr := s[i+2 : i+2+end]
+		name, def, hasDef := strings.Cut(expr, ":-")
+		if v, ok := lookup(name); ok && (v != "" || !hasDef) {
+			b.WriteString(v)
+		} else if hasDef {
+			b.WriteString(def)
+		} else {
+			missing = append(missing, name)
+		}
+		i += end + 2
+	}
+	if len(missing) > 0 {
+		return "", fmt.Errorf("%w: %s", ErrUndefinedVar, strings.Join(missing, ", "))
+	}
+	return b.String(), nil
+}
@@ -0,0 +1,139 @@
+package yamlenv
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"advanced/reflectx"
+)
+
+type DB struct {
+	Host     string `yaml:"host" env:"DB_HOST" validate:"required"`
+	Port     int    `yaml:"port" env:"DB_PORT" validate:"min=1,max=65535"`
+	User     string `yaml:"user" validate:"required"`
+	Password string `yaml:"password" env:"DB_PASSWORD"`
+}
+
+type AppConfig struct {
+	Name     string `yaml:"name" validate:"min=3"`
+	LogLevel string `yaml:"log_level" env:"LOG_LEVEL" validate:"oneof=debug info warn error"`
+	HTTP     struct {
+		Addr    string        `yaml:"addr"`
+		Timeout time.Duration `yaml:"timeout" env:"HTTP_TIMEOUT"`
+	} `yaml:"http"`
+	DB       DB       `yaml:"db"`
+	Replica  *DB      `yaml:"replica"`
+	Features []string `yaml:"features" env:"FEATURES"`
+}
+
+func (c *AppConfig) Validate() error {
+	if c.Replica != nil && c.Replica.Host == c.DB.Host {
+		return errors.New("replica must differ from primary")
+	}
+	return nil
+}
+
+func TestLoad(t *testing.T) {
+	var cfg AppConfig
+	err := Load(filepath.Join("testdata", "app.yaml"), &cfg, WithEnvMap(map[string]string{
+		"DB_USER": "admin",
+		"PORT":    "9090",
+		"DB_HOST": "db.internal",
+	}))
+	assert.NoError(t, err)
+	assert.Equal(t, "demo", cfg.Name)
+	assert.Equal(t, ":9090", cfg.HTTP.Addr)
+	assert.Equal(t, 5*time.Second, cfg.HTTP.Timeout)
+	assert.Equal(t, "db.internal", cfg.DB.Host) // 環境變數覆蓋 YAML
+	assert.Equal(t, 5432, cfg.DB.Port)
+	assert.Equal(t, "admin", cfg.DB.User)
+	assert.Equal(t, "p$ss", cfg.DB.Password)
+	assert.Equal(t, []string{"search", "export"}, cfg.Features)
+	assert.Nil(t, cfg.Replica)
+}
+
+func TestEnvOverlayTypes(t *testing.T) {
+	var cfg AppConfig
+	err := Parse([]byte("name: app\nlog_level: info\ndb: {host: h, port: 1, user: u}\nreplica: {host: r, port: 2, user: u}\n"), &cfg, WithEnvMap(map[string]string{
+		"HTTP_TIMEOUT": "1m30s",
+		"FEATURES":     "a, b",
+		"DB_PORT":      "6543",
+		"LOG_LEVEL":    "debug",
+	}))
+	assert.NoError(t, err)
+	assert.Equal(t, 90*time.Second, cfg.HTTP.Timeout)
+	assert.Equal(t, []string{"a", "b"}, cfg.Features)
+	assert.Equal(t, "debug", cfg.LogLevel)
+	// 同一個 tag 同時套用在 db 與 replica
+	assert.Equal(t, 6543, cfg.DB.Port)
+	assert.Equal(t, 6543, cfg.Replica.Port)
+
+	var bad AppConfig
+	err = Parse([]byte("name: app\n"), &bad, WithEnvMap(map[string]string{"DB_PORT": "abc", "HTTP_TIMEOUT": "soon"}))
+	var errs Errors
+	assert.True(t, errors.As(err, &errs))
+	assert.Len(t, errs, 2)
+	assert.Equal(t, "http.timeout", errs[0].Path)
+	assert.Equal(t, "db.port", errs[1].Path)
+}
+
+// 所有錯誤一次回報
+func TestAggregatedValidation(t *testing.T) {
+	var cfg AppConfig
+	err := Parse([]byte(`
+name: x
+log_level: verbose
+db: {port: 70000}
+replica: {host: same, port: 1, user: u}
+`), &cfg, WithEnvMap(map[string]string{"DB_HOST": "same"}))
+
+	var errs Errors
+	assert.True(t, errors.As(err, &errs))
+	var paths []string
+	for _, e := range errs {
+		paths = append(paths, e.Path)
+	}
+	assert.Equal(t, []string{"name", "log_level", "db.port", "db.user", "(root)"}, paths)
+	assert.ErrorIs(t, err, ErrRequired)
+	assert.Contains(t, err.Error(), "5 invalid field(s)")
+	assert.Contains(t, err.Error(), "replica must differ from primary")
+}
+
+func TestExpand(t *testing.T) {
+	env := func(k string) (string, bool) {
+		v, ok := map[string]string{"A": "1", "EMPTY": ""}[k]
+		return v, ok
+	}
+	s, err := Expand("a=${A} b=${B:-2} e=${EMPTY:-def} e2=${EMPTY} $$HOME $x ${", env)
+	assert.NoError(t, err)
+	assert.Equal(t, "a=1 b=2 e=def e2= $HOME $x ${", s)
+
+	_, err = Expand("${X} ${Y}", env)
+	assert.ErrorIs(t, err, ErrUndefinedVar)
+	assert.Contains(t, err.Error(), "X, Y")
+}
+
+func TestStrictAndBadInput(t *testing.T) {
+	var cfg AppConfig
+	assert.Error(t, Parse([]byte("nmae: typo\n"), &cfg, Strict(), WithEnvMap(nil)))
+	assert.Error(t, Parse([]byte("db: [1, 2]\n"), &cfg, WithEnvMap(nil)))
+	assert.Error(t, Parse(nil, cfg))
+
+	// 空檔案只做環境變數與驗證
+	var db DB
+	assert.NoError(t, Parse([]byte("user: u\n"), &db, WithEnvMap(map[string]string{"DB_HOST": "h", "DB_PORT": "1"})))
+	err := Parse(nil, &DB{}, WithEnvMap(nil))
+	assert.ErrorIs(t, err, ErrRequired)
+}
+
+func TestBadRule(t *testing.T) {
+	var cfg struct {
+		Port int `yaml:"port" validate:"min=low"`
+	}
+	err := Parse([]byte("port: 1\n"), &cfg, WithEnvMap(nil))
+	assert.ErrorIs(t, err, reflectx.ErrBadRule)
+}
@@ -0,0 +1,88 @@
+package raft
+
+import "sync"
+
+/*
+Network 是 in-process 的 transport：每個節點一個 inbox channel，
+Send 依 msg.To 投遞。可以用 Partition / Isolate 模擬網路分割，被切斷的訊息直接丟掉，
+inbox 滿了也丟掉——Raft 本來就要能容忍訊息遺失，leader 下次 heartbeat 會重送。
+*/
+
+type Transport interface {
+	Send(m Message)
+}
+
+type Network struct {
+	mu      sync.RWMutex
+	inboxes map[uint64]chan Message
+	group   map[uint64]int // 同一個 group 的節點才互通；nil 表示網路正常
+	dropped int
+}
+
+func NewNetwork() *Network {
+	return &Network{inboxes: make(map[uint64]chan Message)}
+}
+
+// Join 註冊節點並回傳它的 inbox
+func (nw *Network) Join(id uint64, buffer int) <-chan Message {
+	nw.mu.Lock()
+	defer nw.mu.Unlock()
+	ch := make(chan Message, buffer)
+	nw.inboxes[id] = ch
+	return ch
+}
+
+func (nw *Network) Send(m Message) {
+	nw.mu.Lock()
+	defer nw.mu.Unlock()
+	if !nw.connected(m.From, m.To) {
+		nw.dropped++
+		return
+	}
+	select {
+	case nw.inboxes[m.To] <- m:
+	default:
+		nw.dropped++
+	}
+}
+
+func (nw *Network) connected(a, b uint64) bool {
+	if _, ok := nw.inboxes[b]; !ok {
+		return false
+	}
+	if nw.group == nil {
+		return true
+	}
+	return nw.group[a] == nw.group[b]
+}
+
+// Partition 把節點分成數個互不相通的 group，沒列到的節點自成一組（group 0）
+func (nw *Network) Partition(groups ...[]uint64) {
+	nw.mu.Lock()
+	defer nw.mu.Unlock()
+	nw.group = make(map[uint64]int)
+	for i, g := range groups {
+		for _, id := range g {
+			nw.group[id] = i + 1
+		}
+	}
+}
+
+// Isolate 讓單一節點與其他人斷線
+func (nw *Network) Isolate(id uint64) {
+	nw.Partition([]uint64{id})
+}
+
+// Heal 恢復全部連線
+func (nw *Network) Heal() {
+	nw.mu.Lock()
+	defer nw.mu.Unlock()
+	nw.group = nil
+}
+
+// Dropped 回傳被丟掉的訊息數
+func (nw *Network) Dropped() int {
+	nw.mu.RLock()
+	defer nw.mu.RUnlock()
+	return nw.dropped
+}
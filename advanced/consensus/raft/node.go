@@ -0,0 +1,380 @@
+package raft
+
+import (
+	"errors"
+	"math/rand"
+	"sort"
+)
+
+/*
+Raft 共識演算法的教學版實作（只有 leader election、log replication 與 commit，
+沒有持久化、snapshot、membership change）。
+
+Node 是純粹的狀態機，不開 goroutine、不讀時間：
+  - Tick() 代表經過一個時間單位（邏輯時鐘），測試可以精確控制「時間」
+  - Step(msg) 處理收到的訊息
+  - 要送出的訊息放在 outbox，由 ReadMessages() 取出後交給 transport
+因此同樣的輸入一定得到同樣的結果，測試是 deterministic 的；實際執行由 Server 負責驅動。
+
+三個角色：
+	Follower ──election timeout──> Candidate ──得到多數票──> Leader
+	   ^                              |                        |
+	   └──────── 看到更大的 term ───────┴────────────────────────┘
+
+安全性的關鍵：
+  - 每個 term 每個節點只投一票，且只投給 log 不比自己舊的 candidate
+  - leader 只以「自己 term 的 entry 被多數節點複製」來推進 commit
+*/
+
+var ErrNotLeader = errors.New("raft: not leader")
+
+type Config struct {
+	ID    uint64
+	Peers []uint64 // 包含自己
+	// ElectionTicks 是 election timeout 的下限，實際為 [ElectionTicks, 2*ElectionTicks) 的亂數，預設 10
+	ElectionTicks int
+	// HeartbeatTicks 是 leader 送 heartbeat 的間隔，必須遠小於 ElectionTicks，預設 1
+	HeartbeatTicks int
+	// Seed 決定 election timeout 的亂數序列，相同的 Seed 會得到相同的行為
+	Seed int64
+}
+
+type Node struct {
+	id    uint64
+	peers []uint64
+
+	state StateType
+	term  uint64
+	vote  uint64
+	lead  uint64
+
+	log     []Entry // log[0] 是 index 0 的空白 entry，簡化邊界判斷
+	commit  uint64
+	applied uint64
+
+	electionTicks     int
+	heartbeatTicks    int
+	electionElapsed   int
+	heartbeatElapsed  int
+	randomizedTimeout int
+	rand              *rand.Rand
+
+	votes map[uint64]bool
+	next  map[uint64]uint64 // leader：下一個要送給各 follower 的 index
+	match map[uint64]uint64 // leader：各 follower 已確認複製的最大 index
+
+	msgs []Message
+}
+
+const maxBatch = 64
+
+func NewNode(cfg Config) *Node {
+	if cfg.ElectionTicks == 0 {
+		cfg.ElectionTicks = 10
+	}
+	if cfg.HeartbeatTicks == 0 {
+		cfg.HeartbeatTicks = 1
+	}
+	peers := append([]uint64(nil), cfg.Peers...)
+	sort.Slice(peers, func(i, j int) bool { return peers[i] < peers[j] })
+	n := &Node{
+		id:             cfg.ID,
+		peers:          peers,
+		log:            []Entry{{}},
+		electionTicks:  cfg.ElectionTicks,
+		heartbeatTicks: cfg.HeartbeatTicks,
+		rand:           rand.New(rand.NewSource(cfg.Seed + int64(cfg.ID))),
+	}
+	n.becomeFollower(0, 0)
+	return n
+}
+
+func (n *Node) Status() Status {
+	return Status{ID: n.id, State: n.state, Term: n.term, Lead: n.lead, Commit: n.commit, LastIndex: n.lastIndex()}
+}
+
+// ReadMessages 取出並清空待送出的訊息
+func (n *Node) ReadMessages() []Message {
+	msgs := n.msgs
+	n.msgs = nil
+	return msgs
+}
+
+// CommittedEntries 回傳上次呼叫之後新 commit 的 entry，呼叫端應依序套用到狀態機
+func (n *Node) CommittedEntries() []Entry {
+	if n.applied >= n.commit {
+		return nil
+	}
+	ents := append([]Entry(nil), n.log[n.applied+1:n.commit+1]...)
+	n.applied = n.commit
+	return ents
+}
+
+func (n *Node) lastIndex() uint64 { return uint64(len(n.log) - 1) }
+func (n *Node) lastTerm() uint64  { return n.log[len(n.log)-1].Term }
+func (n *Node) quorum() int       { return len(n.peers)/2 + 1 }
+
+func (n *Node) termAt(i uint64) uint64 {
+	if i > n.lastIndex() {
+		return 0
+	}
+	return n.log[i].Term
+}
+
+func (n *Node) send(m Message) {
+	m.From = n.id
+	if m.Term == 0 {
+		m.Term = n.term
+	}
+	n.msgs = append(n.msgs, m)
+}
+
+func (n *Node) resetTimeout() {
+	n.electionElapsed = 0
+	n.heartbeatElapsed = 0
+	n.randomizedTimeout = n.electionTicks + n.rand.Intn(n.electionTicks)
+}
+
+func (n *Node) becomeFollower(term, lead uint64) {
+	if term != n.term {
+		n.term = term
+		n.vote = 0
+	}
+	n.state = Follower
+	n.lead = lead
+	n.resetTimeout()
+}
+
+func (n *Node) becomeCandidate() {
+	n.state = Candidate
+	n.term++
+	n.vote = n.id
+	n.lead = 0
+	n.votes = map[uint64]bool{n.id: true}
+	n.resetTimeout()
+}
+
+func (n *Node) becomeLeader() {
+	n.state = Leader
+	n.lead = n.id
+	n.next = make(map[uint64]uint64)
+	n.match = make(map[uint64]uint64)
+	for _, p := range n.peers {
+		n.next[p] = n.lastIndex() + 1
+	}
+	n.resetTimeout()
+	// 新 leader 先寫入一筆 no-op：前任 term 的 entry 只能隨著目前 term 的 entry 一起 commit
+	n.appendEntry(nil)
+	n.bcastAppend()
+}
+
+func (n *Node) appendEntry(data []byte) uint64 {
+	idx := n.lastIndex() + 1
+	n.log = append(n.log, Entry{Term: n.term, Index: idx, Data: data})
+	n.match[n.id] = idx
+	n.next[n.id] = idx + 1
+	n.maybeCommit()
+	return idx
+}
+
+// Tick 推進邏輯時鐘一個單位
+func (n *Node) Tick() {
+	switch n.state {
+	case Leader:
+		n.heartbeatElapsed++
+		if n.heartbeatElapsed >= n.heartbeatTicks {
+			n.heartbeatElapsed = 0
+			n.bcastAppend()
+		}
+	default:
+		n.electionElapsed++
+		if n.electionElapsed >= n.randomizedTimeout {
+			n.campaign()
+		}
+	}
+}
+
+func (n *Node) campaign() {
+	n.becomeCandidate()
+	if n.quorum() == 1 {
+		n.becomeLeader()
+		return
+	}
+	for _, p := range n.peers {
+		if p != n.id {
+			n.send(Message{Type: MsgVote, To: p, LogTerm: n.lastTerm(), Index: n.lastIndex()})
+		}
+	}
+}
+
+// Propose 在 leader 的 log 加入一筆資料並回傳其 index；commit 後才會出現在 CommittedEntries
+func (n *Node) Propose(data []byte) (uint64, error) {
+	if n.state != Leader {
+		return 0, ErrNotLeader
+	}
+	if data == nil {
+		data = []byte{}
+	}
+	idx := n.appendEntry(data)
+	n.bcastAppend()
+	return idx, nil
+}
+
+func (n *Node) bcastAppend() {
+	for _, p := range n.peers {
+		if p != n.id {
+			n.sendAppend(p)
+		}
+	}
+}
+
+func (n *Node) sendAppend(to uint64) {
+	next := n.next[to]
+	prev := next - 1
+	end := n.lastIndex() + 1
+	if end-next > maxBatch {
+		end = next + maxBatch
+	}
+	var ents []Entry
+	if next < end {
+		ents = append(ents, n.log[next:end]...)
+	}
+	n.send(Message{Type: MsgApp, To: to, LogTerm: n.termAt(prev), Index: prev, Entries: ents, Commit: n.commit})
+}
+
+// maybeCommit 找出多數節點都已複製、且屬於目前 term 的最大 index
+func (n *Node) maybeCommit() {
+	for idx := n.lastIndex(); idx > n.commit; idx-- {
+		if n.log[idx].Term != n.term {
+			break
+		}
+		count := 0
+		for _, p := range n.peers {
+			if n.match[p] >= idx {
+				count++
+			}
+		}
+		if count >= n.quorum() {
+			n.commit = idx
+			return
+		}
+	}
+}
+
+// Step 處理一則訊息
+func (n *Node) Step(m Message) {
+	switch {
+	case m.Term > n.term:
+		lead := uint64(0)
+		if m.Type == MsgApp {
+			lead = m.From
+		}
+		n.becomeFollower(m.Term, lead)
+	case m.Term < n.term:
+		// 過時的訊息：回覆目前的 term，讓舊 leader / candidate 知道自己該退位
+		switch m.Type {
+		case MsgVote:
+			n.send(Message{Type: MsgVoteResp, To: m.From, Reject: true})
+		case MsgApp:
+			n.send(Message{Type: MsgAppResp, To: m.From, Reject: true, Hint: n.lastIndex()})
+		}
+		return
+	}
+
+	switch m.Type {
+	case MsgVote:
+		upToDate := m.LogTerm > n.lastTerm() || (m.LogTerm == n.lastTerm() && m.Index >= n.lastIndex())
+		grant := (n.vote == 0 || n.vote == m.From) && upToDate && n.state == Follower
+		if grant {
+			n.vote = m.From
+			n.electionElapsed = 0
+		}
+		n.send(Message{Type: MsgVoteResp, To: m.From, Reject: !grant})
+
+	case MsgVoteResp:
+		if n.state != Candidate {
+			return
+		}
+		n.votes[m.From] = !m.Reject
+		granted, rejected := 0, 0
+		for _, v := range n.votes {
+			if v {
+				granted++
+			} else {
+				rejected++
+			}
+		}
+		if granted >= n.quorum() {
+			n.becomeLeader()
+		} else if rejected >= n.quorum() {
+			n.becomeFollower(n.term, 0)
+		}
+
+	case MsgApp:
+		if n.state != Follower {
+			n.becomeFollower(n.term, m.From)
+		}
+		n.lead = m.From
+		n.electionElapsed = 0
+		n.handleAppend(m)
+
+	case MsgAppResp:
+		if n.state != Leader {
+			return
+		}
+		if m.Reject {
+			// 往回退，直到找到雙方一致的位置
+			next := n.next[m.From] - 1
+			if m.Hint+1 < next {
+				next = m.Hint + 1
+			}
+			if next < 1 {
+				next = 1
+			}
+			n.next[m.From] = next
+			n.sendAppend(m.From)
+			return
+		}
+		if m.Index > n.match[m.From] {
+			n.match[m.From] = m.Index
+			n.next[m.From] = m.Index + 1
+			old := n.commit
+			n.maybeCommit()
+			if n.commit != old {
+				// 立刻通知 follower 新的 commit index
+				n.bcastAppend()
+				return
+			}
+		}
+		if n.next[m.From] <= n.lastIndex() {
+			n.sendAppend(m.From)
+		}
+	}
+}
+
+func (n *Node) handleAppend(m Message) {
+	// prev 不一致：拒絕，leader 會退回更早的位置再送
+	if m.Index > n.lastIndex() || n.termAt(m.Index) != m.LogTerm {
+		hint := n.lastIndex()
+		if m.Index <= hint {
+			hint = m.Index - 1
+		}
+		n.send(Message{Type: MsgAppResp, To: m.From, Reject: true, Hint: hint})
+		return
+	}
+	for _, e := range m.Entries {
+		if e.Index <= n.lastIndex() {
+			if n.termAt(e.Index) == e.Term {
+				continue
+			}
+			// 衝突：刪掉這一筆之後的所有 entry（這些一定還沒 commit）
+			n.log = n.log[:e.Index]
+		}
+		n.log = append(n.log, e)
+	}
+	last := m.Index + uint64(len(m.Entries))
+	if c := min(m.Commit, last); c > n.commit {
+		n.commit = c
+	}
+	n.send(Message{Type: MsgAppResp, To: m.From, Index: last})
+}
@@ -0,0 +1,261 @@
+package raft
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// cluster 在測試中同步投遞訊息，完全不依賴真實時間與 goroutine 排程
+type cluster struct {
+	nodes   map[uint64]*Node
+	ids     []uint64
+	group   map[uint64]int
+	applied map[uint64][]string
+}
+
+func newCluster(size int, seed int64) *cluster {
+	c := &cluster{nodes: make(map[uint64]*Node), applied: make(map[uint64][]string)}
+	for i := 1; i <= size; i++ {
+		c.ids = append(c.ids, uint64(i))
+	}
+	for _, id := range c.ids {
+		c.nodes[id] = NewNode(Config{ID: id, Peers: c.ids, Seed: seed})
+	}
+	return c
+}
+
+func (c *cluster) partition(groups ...[]uint64) {
+	c.group = make(map[uint64]int)
+	for i, g := range groups {
+		for _, id := range g {
+			c.group[id] = i + 1
+		}
+	}
+}
+
+func (c *cluster) heal() { c.group = nil }
+
+// deliver 反覆投遞訊息直到網路安靜
+func (c *cluster) deliver() {
+	for {
+		var msgs []Message
+		for _, id := range c.ids {
+			msgs = append(msgs, c.nodes[id].ReadMessages()...)
+		}
+		if len(msgs) == 0 {
+			break
+		}
+		for _, m := range msgs {
+			if c.group != nil && c.group[m.From] != c.group[m.To] {
+				continue
+			}
+			c.nodes[m.To].Step(m)
+		}
+	}
+	for _, id := range c.ids {
+		for _, e := range c.nodes[id].CommittedEntries() {
+			if e.Data != nil {
+				c.applied[id] = append(c.applied[id], string(e.Data))
+			}
+		}
+	}
+}
+
+func (c *cluster) tick(n int) {
+	for i := 0; i < n; i++ {
+		for _, id := range c.ids {
+			c.nodes[id].Tick()
+		}
+		c.deliver()
+	}
+}
+
+// leaders 回傳在 ids 中自認為 leader 的節點
+func (c *cluster) leaders(ids []uint64) []uint64 {
+	var out []uint64
+	for _, id := range ids {
+		if c.nodes[id].state == Leader {
+			out = append(out, id)
+		}
+	}
+	return out
+}
+
+func (c *cluster) waitLeader(t *testing.T, ids []uint64) uint64 {
+	t.Helper()
+	for i := 0; i < 100; i++ {
+		c.tick(1)
+		if l := c.leaders(ids); len(l) == 1 {
+			return l[0]
+		}
+	}
+	t.Fatalf("no leader elected among %v", ids)
+	return 0
+}
+
+func TestElection(t *testing.T) {
+	c := newCluster(3, 1)
+	lead := c.waitLeader(t, c.ids)
+
+	term := c.nodes[lead].term
+	for _, id := range c.ids {
+		st := c.nodes[id].Status()
+		assert.Equal(t, lead, st.Lead, "node %d", id)
+		assert.Equal(t, term, st.Term, "node %d", id)
+	}
+	// 穩定後 heartbeat 會壓住 election timeout，不會換 leader
+	c.tick(100)
+	assert.Equal(t, []uint64{lead}, c.leaders(c.ids))
+	assert.Equal(t, term, c.nodes[lead].term)
+}
+
+func TestSingleNode(t *testing.T) {
+	c := newCluster(1, 1)
+	c.waitLeader(t, c.ids)
+	_, err := c.nodes[1].Propose([]byte("x"))
+	require.NoError(t, err)
+	c.deliver()
+	assert.Equal(t, []string{"x"}, c.applied[1])
+}
+
+func TestReplication(t *testing.T) {
+	c := newCluster(3, 2)
+	lead := c.waitLeader(t, c.ids)
+
+	for i := 0; i < 3; i++ {
+		_, err := c.nodes[lead].Propose([]byte(fmt.Sprint("set x=", i)))
+		require.NoError(t, err)
+	}
+	c.deliver()
+	want := []string{"set x=0", "set x=1", "set x=2"}
+	for _, id := range c.ids {
+		assert.Equal(t, want, c.applied[id], "node %d", id)
+	}
+
+	follower := c.ids[0]
+	if follower == lead {
+		follower = c.ids[1]
+	}
+	_, err := c.nodes[follower].Propose([]byte("nope"))
+	assert.ErrorIs(t, err, ErrNotLeader)
+}
+
+func TestCommitNeedsMajority(t *testing.T) {
+	c := newCluster(3, 3)
+	lead := c.waitLeader(t, c.ids)
+	c.partition([]uint64{lead})
+
+	idx, err := c.nodes[lead].Propose([]byte("lonely"))
+	require.NoError(t, err)
+	c.deliver()
+	assert.Less(t, c.nodes[lead].commit, idx)
+	assert.Empty(t, c.applied[lead])
+}
+
+func TestPartitionedLeaderStepsDown(t *testing.T) {
+	c := newCluster(5, 4)
+	oldLead := c.waitLeader(t, c.ids)
+	_, err := c.nodes[oldLead].Propose([]byte("before"))
+	require.NoError(t, err)
+	c.deliver()
+
+	// 舊 leader 與一個 follower 落入少數派
+	var minority, majority []uint64
+	minority = append(minority, oldLead)
+	for _, id := range c.ids {
+		if id == oldLead {
+			continue
+		}
+		if len(minority) < 2 {
+			minority = append(minority, id)
+		} else {
+			majority = append(majority, id)
+		}
+	}
+	c.partition(minority, majority)
+
+	_, err = c.nodes[oldLead].Propose([]byte("lost write"))
+	require.NoError(t, err, "old leader does not know it was cut off yet")
+
+	newLead := c.waitLeader(t, majority)
+	assert.Greater(t, c.nodes[newLead].term, c.nodes[oldLead].term)
+	_, err = c.nodes[newLead].Propose([]byte("after"))
+	require.NoError(t, err)
+	c.deliver()
+	for _, id := range majority {
+		assert.Equal(t, []string{"before", "after"}, c.applied[id], "node %d", id)
+	}
+
+	// 恢復後舊 leader 看到較大的 term 而退位，未 commit 的 entry 被覆蓋
+	c.heal()
+	c.tick(5)
+	assert.Equal(t, []uint64{newLead}, c.leaders(c.ids))
+	for _, id := range c.ids {
+		assert.Equal(t, []string{"before", "after"}, c.applied[id], "node %d", id)
+		assert.Equal(t, c.nodes[newLead].log, c.nodes[id].log, "node %d log", id)
+	}
+}
+
+func TestVoteRejectsStaleLog(t *testing.T) {
+	n := NewNode(Config{ID: 1, Peers: []uint64{1, 2, 3}})
+	n.Step(Message{Type: MsgApp, From: 2, To: 1, Term: 2, Entries: []Entry{{Term: 2, Index: 1, Data: []byte("a")}}})
+	n.ReadMessages()
+
+	// candidate 3 的 log 是空的，即使 term 比較大也拿不到票
+	n.Step(Message{Type: MsgVote, From: 3, To: 1, Term: 3})
+	resp := n.ReadMessages()
+	require.Len(t, resp, 1)
+	assert.True(t, resp[0].Reject)
+	assert.Equal(t, uint64(3), n.term)
+
+	// 同一個 term 內已投票給 2，就不能再投給 3
+	n.Step(Message{Type: MsgVote, From: 2, To: 1, Term: 3, LogTerm: 2, Index: 1})
+	n.Step(Message{Type: MsgVote, From: 3, To: 1, Term: 3, LogTerm: 2, Index: 1})
+	resp = n.ReadMessages()
+	require.Len(t, resp, 2)
+	assert.False(t, resp[0].Reject)
+	assert.True(t, resp[1].Reject)
+}
+
+func TestFollowerTruncatesConflicts(t *testing.T) {
+	n := NewNode(Config{ID: 1, Peers: []uint64{1, 2, 3}})
+	n.Step(Message{Type: MsgApp, From: 2, To: 1, Term: 1, Entries: []Entry{
+		{Term: 1, Index: 1, Data: []byte("a")},
+		{Term: 1, Index: 2, Data: []byte("b")},
+	}})
+	// 新 leader 在 index 2 有不同 term 的 entry
+	n.Step(Message{Type: MsgApp, From: 3, To: 1, Term: 2, LogTerm: 1, Index: 1, Entries: []Entry{
+		{Term: 2, Index: 2, Data: []byte("c")},
+	}, Commit: 2})
+	assert.Equal(t, uint64(2), n.lastIndex())
+	assert.Equal(t, "c", string(n.log[2].Data))
+	assert.Equal(t, uint64(2), n.commit)
+
+	// prev 對不上時拒絕並回報自己的最後 index
+	n.ReadMessages()
+	n.Step(Message{Type: MsgApp, From: 3, To: 1, Term: 2, LogTerm: 2, Index: 5})
+	resp := n.ReadMessages()
+	require.Len(t, resp, 1)
+	assert.True(t, resp[0].Reject)
+	assert.Equal(t, uint64(2), resp[0].Hint)
+}
+
+func TestDeterministic(t *testing.T) {
+	run := func() []Status {
+		c := newCluster(5, 42)
+		c.waitLeader(t, c.ids)
+		c.partition([]uint64{1, 2}, []uint64{3, 4, 5})
+		c.tick(50)
+		c.heal()
+		c.tick(10)
+		var out []Status
+		for _, id := range c.ids {
+			out = append(out, c.nodes[id].Status())
+		}
+		return out
+	}
+	assert.Equal(t, run(), run())
+}
@@ -0,0 +1,132 @@
+package raft
+
+import (
+	"context"
+	"time"
+)
+
+/*
+Server 用一個 goroutine 驅動 Node：
+	tick channel ──> node.Tick()
+	inbox        ──> node.Step()
+	Propose      ──> node.Propose()
+每次處理完就把 outbox 交給 transport、把新 commit 的 entry 交給 apply。
+Node 只在這個 goroutine 裡被存取，所以不需要 lock。
+
+tick 由外部提供：正式環境用 Ticker(d)，測試直接往 channel 送值，等於一個可以手動推進的 fake clock。
+*/
+
+type Server struct {
+	node      *Node
+	transport Transport
+	inbox     <-chan Message
+	ticks     <-chan struct{}
+	apply     func(Entry)
+
+	propc   chan proposal
+	statusc chan chan Status
+	done    chan struct{}
+}
+
+type proposal struct {
+	data  []byte
+	reply chan proposeResult
+}
+
+type proposeResult struct {
+	index uint64
+	err   error
+}
+
+// NewServer 建立 Server；apply 會在 Server 的 goroutine 中依序收到 commit 的資料（不含 no-op）
+func NewServer(node *Node, tr Transport, inbox <-chan Message, ticks <-chan struct{}, apply func(Entry)) *Server {
+	if apply == nil {
+		apply = func(Entry) {}
+	}
+	return &Server{
+		node:      node,
+		transport: tr,
+		inbox:     inbox,
+		ticks:     ticks,
+		apply:     apply,
+		propc:     make(chan proposal),
+		statusc:   make(chan chan Status),
+		done:      make(chan struct{}),
+	}
+}
+
+// Ticker 把 time.Ticker 轉成 Server 使用的 tick channel，ctx 結束時停止
+func Ticker(ctx context.Context, d time.Duration) <-chan struct{} {
+	ch := make(chan struct{})
+	go func() {
+		t := time.NewTicker(d)
+		defer t.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-t.C:
+				select {
+				case ch <- struct{}{}:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+	return ch
+}
+
+// Run 直到 ctx 結束
+func (s *Server) Run(ctx context.Context) {
+	defer close(s.done)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-s.ticks:
+			s.node.Tick()
+		case m := <-s.inbox:
+			s.node.Step(m)
+		case p := <-s.propc:
+			idx, err := s.node.Propose(p.data)
+			p.reply <- proposeResult{idx, err}
+		case c := <-s.statusc:
+			c <- s.node.Status()
+		}
+		for _, m := range s.node.ReadMessages() {
+			s.transport.Send(m)
+		}
+		for _, e := range s.node.CommittedEntries() {
+			if e.Data != nil {
+				s.apply(e)
+			}
+		}
+	}
+}
+
+// Propose 回傳 entry 的 index；只代表 leader 已寫入 log，是否 commit 要看 apply
+func (s *Server) Propose(ctx context.Context, data []byte) (uint64, error) {
+	p := proposal{data: data, reply: make(chan proposeResult, 1)}
+	select {
+	case s.propc <- p:
+	case <-ctx.Done():
+		return 0, ctx.Err()
+	case <-s.done:
+		return 0, context.Canceled
+	}
+	r := <-p.reply
+	return r.index, r.err
+}
+
+func (s *Server) Status(ctx context.Context) (Status, error) {
+	c := make(chan Status, 1)
+	select {
+	case s.statusc <- c:
+		return <-c, nil
+	case <-ctx.Done():
+		return Status{}, ctx.Err()
+	case <-s.done:
+		return Status{}, context.Canceled
+	}
+}
@@ -0,0 +1,136 @@
+package raft
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testServer struct {
+	*Server
+	ticks chan struct{}
+
+	mu      sync.Mutex
+	applied []string
+}
+
+func (s *testServer) values() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.applied...)
+}
+
+func startServers(t *testing.T, nw *Network, ids []uint64) map[uint64]*testServer {
+	ctx, cancel := context.WithCancel(context.Background())
+	var wg sync.WaitGroup
+	t.Cleanup(func() {
+		cancel()
+		wg.Wait()
+	})
+	out := make(map[uint64]*testServer)
+	for _, id := range ids {
+		ts := &testServer{ticks: make(chan struct{})}
+		node := NewNode(Config{ID: id, Peers: ids, Seed: 7})
+		ts.Server = NewServer(node, nw, nw.Join(id, 256), ts.ticks, func(e Entry) {
+			ts.mu.Lock()
+			ts.applied = append(ts.applied, string(e.Data))
+			ts.mu.Unlock()
+		})
+		out[id] = ts
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ts.Run(ctx)
+		}()
+	}
+	return out
+}
+
+// tickUntil 手動推進所有節點的時鐘，直到 cond 成立
+func tickUntil(t *testing.T, servers map[uint64]*testServer, cond func() bool) {
+	t.Helper()
+	for i := 0; i < 500; i++ {
+		if cond() {
+			return
+		}
+		for _, s := range servers {
+			s.ticks <- struct{}{}
+		}
+		time.Sleep(time.Millisecond)
+	}
+	t.Fatal("condition not met")
+}
+
+func leaderOf(servers map[uint64]*testServer, ids []uint64) uint64 {
+	var lead uint64
+	for _, id := range ids {
+		st, _ := servers[id].Status(context.Background())
+		if st.State == Leader {
+			if lead != 0 {
+				return 0
+			}
+			lead = id
+		}
+	}
+	return lead
+}
+
+func TestServerReplicationAndPartition(t *testing.T) {
+	ids := []uint64{1, 2, 3}
+	nw := NewNetwork()
+	servers := startServers(t, nw, ids)
+	ctx := context.Background()
+
+	var lead uint64
+	tickUntil(t, servers, func() bool {
+		lead = leaderOf(servers, ids)
+		return lead != 0
+	})
+	_, err := servers[lead].Propose(ctx, []byte("a"))
+	require.NoError(t, err)
+	tickUntil(t, servers, func() bool {
+		for _, s := range servers {
+			if len(s.values()) != 1 {
+				return false
+			}
+		}
+		return true
+	})
+
+	// 隔離 leader，剩下兩個節點選出新 leader 並繼續 commit
+	nw.Isolate(lead)
+	var rest []uint64
+	for _, id := range ids {
+		if id != lead {
+			rest = append(rest, id)
+		}
+	}
+	var newLead uint64
+	tickUntil(t, servers, func() bool {
+		newLead = leaderOf(servers, rest)
+		return newLead != 0
+	})
+	_, err = servers[newLead].Propose(ctx, []byte("b"))
+	require.NoError(t, err)
+
+	nw.Heal()
+	tickUntil(t, servers, func() bool {
+		for _, s := range servers {
+			if len(s.values()) != 2 {
+				return false
+			}
+		}
+		return leaderOf(servers, ids) == newLead
+	})
+	for _, id := range ids {
+		assert.Equal(t, []string{"a", "b"}, servers[id].values(), "node %d", id)
+	}
+	assert.Positive(t, nw.Dropped())
+
+	_, err = servers[lead].Propose(ctx, []byte("c"))
+	assert.ErrorIs(t, err, ErrNotLeader)
+}
@@ -0,0 +1,71 @@
+package raft
+
+import "fmt"
+
+type StateType int
+
+const (
+	Follower StateType = iota
+	Candidate
+	Leader
+)
+
+func (s StateType) String() string {
+	switch s {
+	case Follower:
+		return "follower"
+	case Candidate:
+		return "candidate"
+	case Leader:
+		return "leader"
+	}
+	return fmt.Sprintf("StateType(%d)", int(s))
+}
+
+// Entry 是 log 中的一筆資料，Data 為 nil 表示 leader 上任時寫入的 no-op
+type Entry struct {
+	Term  uint64
+	Index uint64
+	Data  []byte
+}
+
+type MessageType int
+
+const (
+	MsgVote     MessageType = iota // candidate 請求投票
+	MsgVoteResp                    // 投票結果
+	MsgApp                         // leader 複製 log（沒有 Entries 時就是 heartbeat）
+	MsgAppResp                     // follower 回覆複製結果
+)
+
+func (t MessageType) String() string {
+	return [...]string{"MsgVote", "MsgVoteResp", "MsgApp", "MsgAppResp"}[t]
+}
+
+type Message struct {
+	Type MessageType
+	From uint64
+	To   uint64
+	Term uint64
+
+	// MsgVote：candidate 最後一筆 log 的 term 與 index
+	// MsgApp：Entries 前一筆的 term 與 index（prevLogTerm / prevLogIndex）
+	// MsgAppResp：follower 已與 leader 一致的最後 index
+	LogTerm uint64
+	Index   uint64
+
+	Entries []Entry
+	Commit  uint64
+	Reject  bool
+	// Hint 在 MsgApp 被拒絕時是 follower 的最後 index，讓 leader 快速退回 nextIndex
+	Hint uint64
+}
+
+type Status struct {
+	ID        uint64
+	State     StateType
+	Term      uint64
+	Lead      uint64
+	Commit    uint64
+	LastIndex uint64
+}
@@ -0,0 +1,25 @@
+package secrets
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+)
+
+/*
+比較 token、API key 這類秘密值時不能用 == 或 bytes.Equal：
+它們在第一個不同的 byte 就返回，攻擊者可以從回應時間逐 byte 猜出正確值。
+subtle.ConstantTimeCompare 的執行時間只與長度有關，但長度不同時會立即返回，
+所以 EqualString 先把兩邊都做 sha256，讓長度也不會從時間洩漏。
+*/
+
+// Equal 以固定時間比較 a 與 b，長度不同時仍會洩漏「長度不同」這件事
+func Equal(a, b []byte) bool {
+	return subtle.ConstantTimeCompare(a, b) == 1
+}
+
+// EqualString 先雜湊成固定長度再比較，連長度都不洩漏
+func EqualString(a, b string) bool {
+	ha := sha256.Sum256([]byte(a))
+	hb := sha256.Sum256([]byte(b))
+	return subtle.ConstantTimeCompare(ha[:], hb[:]) == 1
+}
@@ -0,0 +1,217 @@
+package secrets
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+/*
+Envelope encryption：
+  - 每筆資料產生一把隨機的 data key (DEK)，用 AES-256-GCM 加密資料
+  - DEK 再用 key-encryption key (KEK) 加密後，和密文存在一起
+  - KEK 只存在 Keyring（實務上是 KMS / 環境變數），設定檔裡只有密文
+
+輸出格式（皆為不補 = 的 base64url）：
+	v1.<kid>.<wrapped DEK>.<nonce+ciphertext>
+
+Key rotation：
+  - Rotate 加入新的 KEK 並設為 primary，之後 Encrypt 都用新 key
+  - 舊 KEK 留在 Keyring 中，舊的密文仍可解密
+  - Rewrap 只用新 KEK 重新包裝 DEK，不需要解密資料本身；全部 rewrap 完才能移除舊 key
+aad（additional authenticated data）不會被加密但會被驗證，
+例如傳入設定的欄位名稱，可避免把 A 欄位的密文搬到 B 欄位使用。
+*/
+
+var (
+	ErrUnknownKey  = errors.New("secrets: unknown key id")
+	ErrBadEnvelope = errors.New("secrets: malformed envelope")
+	ErrDecrypt     = errors.New("secrets: decryption failed")
+	ErrInvalidKey  = errors.New("secrets: key must be 32 bytes")
+	ErrKeyInUse    = errors.New("secrets: cannot remove primary key")
+)
+
+const envelopeVersion = "v1"
+
+var b64url = base64.RawURLEncoding
+
+type Keyring struct {
+	mu      sync.RWMutex
+	keys    map[string][]byte
+	primary string
+}
+
+// NewKeyring 建立只有一把 primary KEK 的 Keyring
+func NewKeyring(id string, key []byte) (*Keyring, error) {
+	k := &Keyring{keys: make(map[string][]byte)}
+	if err := k.Rotate(id, key); err != nil {
+		return nil, err
+	}
+	return k, nil
+}
+
+// Rotate 加入（或取代）一把 KEK 並設為 primary
+func (k *Keyring) Rotate(id string, key []byte) error {
+	if len(key) != 32 {
+		return ErrInvalidKey
+	}
+	if id == "" || strings.Contains(id, ".") {
+		return fmt.Errorf("secrets: invalid key id %q", id)
+	}
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	k.keys[id] = append([]byte(nil), key...)
+	k.primary = id
+	return nil
+}
+
+// Remove 移除舊的 KEK，用它包裝的密文之後都無法解密
+func (k *Keyring) Remove(id string) error {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	if id == k.primary {
+		return ErrKeyInUse
+	}
+	delete(k.keys, id)
+	return nil
+}
+
+func (k *Keyring) Primary() string {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+	return k.primary
+}
+
+func (k *Keyring) key(id string) ([]byte, error) {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+	key, ok := k.keys[id]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownKey, id)
+	}
+	return key, nil
+}
+
+func (k *Keyring) Encrypt(plaintext, aad []byte) (string, error) {
+	k.mu.RLock()
+	id, kek := k.primary, k.keys[k.primary]
+	k.mu.RUnlock()
+
+	dek := make([]byte, 32)
+	if _, err := rand.Read(dek); err != nil {
+		return "", err
+	}
+	ct, err := seal(dek, plaintext, aad)
+	if err != nil {
+		return "", err
+	}
+	wrapped, err := seal(kek, dek, []byte(id))
+	if err != nil {
+		return "", err
+	}
+	return strings.Join([]string{envelopeVersion, id, b64url.EncodeToString(wrapped), b64url.EncodeToString(ct)}, "."), nil
+}
+
+func (k *Keyring) Decrypt(envelope string, aad []byte) ([]byte, error) {
+	id, wrapped, ct, err := parseEnvelope(envelope)
+	if err != nil {
+		return nil, err
+	}
+	dek, err := k.unwrap(id, wrapped)
+	if err != nil {
+		return nil, err
+	}
+	return open(dek, ct, aad)
+}
+
+// Rewrap 以 primary KEK 重新包裝 DEK，資料密文保持不變；已是 primary 時原樣回傳
+func (k *Keyring) Rewrap(envelope string) (string, error) {
+	id, wrapped, ct, err := parseEnvelope(envelope)
+	if err != nil {
+		return "", err
+	}
+	k.mu.RLock()
+	primary, kek := k.primary, k.keys[k.primary]
+	k.mu.RUnlock()
+	if id == primary {
+		return envelope, nil
+	}
+	dek, err := k.unwrap(id, wrapped)
+	if err != nil {
+		return "", err
+	}
+	if wrapped, err = seal(kek, dek, []byte(primary)); err != nil {
+		return "", err
+	}
+	return strings.Join([]string{envelopeVersion, primary, b64url.EncodeToString(wrapped), b64url.EncodeToString(ct)}, "."), nil
+}
+
+// KeyID 回傳 envelope 使用的 KEK，用來找出還沒 rewrap 的資料
+func KeyID(envelope string) (string, error) {
+	id, _, _, err := parseEnvelope(envelope)
+	return id, err
+}
+
+func (k *Keyring) unwrap(id string, wrapped []byte) ([]byte, error) {
+	kek, err := k.key(id)
+	if err != nil {
+		return nil, err
+	}
+	// 以 kid 當 aad，避免竄改 envelope 中的 kid
+	return open(kek, wrapped, []byte(id))
+}
+
+func parseEnvelope(s string) (id string, wrapped, ct []byte, err error) {
+	parts := strings.Split(s, ".")
+	if len(parts) != 4 || parts[0] != envelopeVersion {
+		return "", nil, nil, ErrBadEnvelope
+	}
+	if wrapped, err = b64url.DecodeString(parts[2]); err != nil {
+		return "", nil, nil, ErrBadEnvelope
+	}
+	if ct, err = b64url.DecodeString(parts[3]); err != nil {
+		return "", nil, nil, ErrBadEnvelope
+	}
+	return parts[1], wrapped, ct, nil
+}
+
+// seal 回傳 nonce + ciphertext
+func seal(key, plaintext, aad []byte) ([]byte, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, gcm.NonceSize(), gcm.NonceSize()+len(plaintext)+gcm.Overhead())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	return gcm.Seal(nonce, nonce, plaintext, aad), nil
+}
+
+func open(key, data, aad []byte) ([]byte, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) < gcm.NonceSize() {
+		return nil, ErrBadEnvelope
+	}
+	pt, err := gcm.Open(nil, data[:gcm.NonceSize()], data[gcm.NonceSize():], aad)
+	if err != nil {
+		return nil, ErrDecrypt
+	}
+	return pt, nil
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
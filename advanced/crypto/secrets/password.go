@@ -0,0 +1,160 @@
+package secrets
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+/*
+密碼雜湊不能用 sha256 這類「快」的 hash，攻擊者拿到資料庫後每秒可以試上億次。
+bcrypt / argon2 刻意設計得很慢，而且可以調整成本：
+  - bcrypt：cost 每 +1 計算時間加倍，輸入超過 72 bytes 的部分會被忽略
+  - argon2id：同時消耗 CPU（time）與記憶體（memory），讓 GPU / ASIC 平行破解的成本變高，
+    是 OWASP 目前建議的首選
+雜湊結果都是自帶參數的字串，調高成本後舊的雜湊仍可驗證，
+登入成功時用 NeedsRehash 判斷是否要用新參數重新雜湊。
+
+argon2id 使用 PHC 字串格式：
+	$argon2id$v=19$m=65536,t=1,p=4$<salt>$<hash>   （salt 與 hash 為不補 = 的 base64）
+*/
+
+var (
+	ErrMismatch      = errors.New("secrets: password mismatch")
+	ErrUnknownFormat = errors.New("secrets: unknown hash format")
+)
+
+type Hasher interface {
+	Hash(password string) (string, error)
+	Verify(password, encoded string) error
+	// NeedsRehash 回報 encoded 是否以不同於目前設定的演算法或參數產生
+	NeedsRehash(encoded string) bool
+}
+
+type Bcrypt struct {
+	Cost int
+}
+
+var _ Hasher = Bcrypt{}
+
+func NewBcrypt(cost int) Bcrypt {
+	return Bcrypt{Cost: cost}
+}
+
+func (b Bcrypt) Hash(password string) (string, error) {
+	h, err := bcrypt.GenerateFromPassword([]byte(password), b.Cost)
+	return string(h), err
+}
+
+func (b Bcrypt) Verify(password, encoded string) error {
+	err := bcrypt.CompareHashAndPassword([]byte(encoded), []byte(password))
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, bcrypt.ErrMismatchedHashAndPassword):
+		return ErrMismatch
+	default:
+		return fmt.Errorf("%w: %v", ErrUnknownFormat, err)
+	}
+}
+
+func (b Bcrypt) NeedsRehash(encoded string) bool {
+	cost, err := bcrypt.Cost([]byte(encoded))
+	return err != nil || cost != b.Cost
+}
+
+type Argon2 struct {
+	Time    uint32
+	Memory  uint32 // KiB
+	Threads uint8
+	KeyLen  uint32
+	SaltLen int
+}
+
+var _ Hasher = Argon2{}
+
+// DefaultArgon2 為 OWASP 建議的其中一組參數：64 MiB、1 次、4 threads
+var DefaultArgon2 = Argon2{Time: 1, Memory: 64 * 1024, Threads: 4, KeyLen: 32, SaltLen: 16}
+
+// 驗證時參數來自儲存的字串，超過上限的直接拒絕，避免一筆被竄改的雜湊耗盡記憶體或 CPU
+const (
+	maxArgon2Memory = 4 * 1024 * 1024 // KiB，4 GiB
+	maxArgon2Time   = 64
+)
+
+var b64 = base64.RawStdEncoding
+
+func (a Argon2) Hash(password string) (string, error) {
+	salt := make([]byte, a.SaltLen)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+	key := argon2.IDKey([]byte(password), salt, a.Time, a.Memory, a.Threads, a.KeyLen)
+	return fmt.Sprintf("$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s",
+		argon2.Version, a.Memory, a.Time, a.Threads, b64.EncodeToString(salt), b64.EncodeToString(key)), nil
+}
+
+// parseArgon2 解析 PHC 字串，回傳其中的參數、salt 與 hash
+func parseArgon2(encoded string) (Argon2, []byte, []byte, error) {
+	var p Argon2
+	parts := strings.Split(encoded, "$")
+	if len(parts) != 6 || parts[1] != "argon2id" {
+		return p, nil, nil, ErrUnknownFormat
+	}
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return p, nil, nil, ErrUnknownFormat
+	}
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &p.Memory, &p.Time, &p.Threads); err != nil {
+		return p, nil, nil, ErrUnknownFormat
+	}
+	// argon2.IDKey 在 threads 為 0 時 panic，memory 則會照單全收地配置
+	if p.Memory == 0 || p.Memory > maxArgon2Memory || p.Time == 0 || p.Time > maxArgon2Time || p.Threads == 0 {
+		return p, nil, nil, fmt.Errorf("%w: argon2 parameters out of range: m=%d,t=%d,p=%d", ErrUnknownFormat, p.Memory, p.Time, p.Threads)
+	}
+	salt, err := b64.DecodeString(parts[4])
+	if err != nil {
+		return p, nil, nil, ErrUnknownFormat
+	}
+	key, err := b64.DecodeString(parts[5])
+	if err != nil {
+		return p, nil, nil, ErrUnknownFormat
+	}
+	p.SaltLen, p.KeyLen = len(salt), uint32(len(key))
+	return p, salt, key, nil
+}
+
+func (a Argon2) Verify(password, encoded string) error {
+	p, salt, key, err := parseArgon2(encoded)
+	if err != nil {
+		return err
+	}
+	// 以字串內的參數重新計算，調整 a 的參數不影響舊雜湊的驗證
+	got := argon2.IDKey([]byte(password), salt, p.Time, p.Memory, p.Threads, p.KeyLen)
+	if subtle.ConstantTimeCompare(got, key) != 1 {
+		return ErrMismatch
+	}
+	return nil
+}
+
+func (a Argon2) NeedsRehash(encoded string) bool {
+	p, _, _, err := parseArgon2(encoded)
+	return err != nil || p != a
+}
+
+// Verify 依 encoded 的格式選擇演算法，方便從 bcrypt 遷移到 argon2 的過渡期使用
+func Verify(password, encoded string) error {
+	switch {
+	case strings.HasPrefix(encoded, "$argon2id$"):
+		return Argon2{}.Verify(password, encoded)
+	case strings.HasPrefix(encoded, "$2a$"), strings.HasPrefix(encoded, "$2b$"), strings.HasPrefix(encoded, "$2y$"):
+		return Bcrypt{}.Verify(password, encoded)
+	}
+	return ErrUnknownFormat
+}
@@ -0,0 +1,210 @@
+package secrets
+
+import (
+	"bytes"
+	"crypto/rand"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// 測試用低成本參數，正式環境請用 DefaultArgon2 / bcrypt.DefaultCost 以上
+var (
+	fastBcrypt = NewBcrypt(bcrypt.MinCost)
+	fastArgon2 = Argon2{Time: 1, Memory: 1024, Threads: 1, KeyLen: 32, SaltLen: 16}
+)
+
+func TestHashers(t *testing.T) {
+	for name, h := range map[string]Hasher{"bcrypt": fastBcrypt, "argon2": fastArgon2} {
+		t.Run(name, func(t *testing.T) {
+			enc, err := h.Hash("hunter2")
+			require.NoError(t, err)
+			assert.NoError(t, h.Verify("hunter2", enc))
+			assert.ErrorIs(t, h.Verify("hunter3", enc), ErrMismatch)
+			assert.NoError(t, Verify("hunter2", enc), "依格式自動選擇演算法")
+			assert.False(t, h.NeedsRehash(enc))
+
+			again, err := h.Hash("hunter2")
+			require.NoError(t, err)
+			assert.NotEqual(t, enc, again, "每次使用不同的 salt")
+		})
+	}
+}
+
+func TestArgon2Format(t *testing.T) {
+	enc, err := fastArgon2.Hash("pw")
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(enc, "$argon2id$v=19$m=1024,t=1,p=1$"), enc)
+
+	stronger := fastArgon2
+	stronger.Time = 2
+	assert.True(t, stronger.NeedsRehash(enc))
+	assert.NoError(t, stronger.Verify("pw", enc), "舊參數的雜湊仍可驗證")
+
+	for _, bad := range []string{"", "$argon2i$v=19$m=1,t=1,p=1$AA$AA", "$argon2id$v=18$m=1,t=1,p=1$AA$AA", "$argon2id$v=19$x$AA$AA", "$argon2id$v=19$m=1,t=1,p=1$!$AA"} {
+		assert.ErrorIs(t, fastArgon2.Verify("pw", bad), ErrUnknownFormat, bad)
+	}
+	assert.ErrorIs(t, Verify("pw", "plain"), ErrUnknownFormat)
+
+	// 超出範圍的參數在雜湊之前就被拒絕，不會 panic 或配置大量記憶體
+	for _, params := range []string{"m=1024,t=1,p=0", "m=0,t=1,p=1", "m=1024,t=0,p=1", "m=4294967295,t=1,p=1", "m=1024,t=4294967295,p=1"} {
+		bad := "$argon2id$v=19$" + params + "$AAAAAAAAAAAAAAAAAAAAAA$AAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAA"
+		assert.ErrorIs(t, Verify("pw", bad), ErrUnknownFormat, params)
+	}
+}
+
+func TestBcryptRehash(t *testing.T) {
+	enc, err := fastBcrypt.Hash("pw")
+	require.NoError(t, err)
+	assert.True(t, NewBcrypt(bcrypt.MinCost+1).NeedsRehash(enc))
+	assert.ErrorIs(t, fastBcrypt.Verify("pw", "$2a$bad"), ErrUnknownFormat)
+}
+
+func TestEqual(t *testing.T) {
+	assert.True(t, Equal([]byte("abc"), []byte("abc")))
+	assert.False(t, Equal([]byte("abc"), []byte("abd")))
+	assert.False(t, Equal([]byte("abc"), []byte("abcd")))
+	assert.True(t, EqualString("token", "token"))
+	assert.False(t, EqualString("token", "token2"))
+}
+
+func newKey(t testing.TB) []byte {
+	k := make([]byte, 32)
+	_, err := rand.Read(k)
+	require.NoError(t, err)
+	return k
+}
+
+func TestEnvelope(t *testing.T) {
+	ring, err := NewKeyring("k1", newKey(t))
+	require.NoError(t, err)
+	aad := []byte("db.password")
+
+	env, err := ring.Encrypt([]byte("s3cr3t"), aad)
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(env, "v1.k1."), env)
+
+	pt, err := ring.Decrypt(env, aad)
+	require.NoError(t, err)
+	assert.Equal(t, "s3cr3t", string(pt))
+
+	_, err = ring.Decrypt(env, []byte("api.token"))
+	assert.ErrorIs(t, err, ErrDecrypt, "aad 不符")
+
+	// 竄改密文
+	parts := strings.Split(env, ".")
+	ct, _ := b64url.DecodeString(parts[3])
+	ct[len(ct)-1] ^= 1
+	parts[3] = b64url.EncodeToString(ct)
+	_, err = ring.Decrypt(strings.Join(parts, "."), aad)
+	assert.ErrorIs(t, err, ErrDecrypt)
+
+	_, err = ring.Decrypt("v2.k1.AA.AA", aad)
+	assert.ErrorIs(t, err, ErrBadEnvelope)
+	_, err = NewKeyring("short", []byte("x"))
+	assert.ErrorIs(t, err, ErrInvalidKey)
+}
+
+func TestRotation(t *testing.T) {
+	ring, err := NewKeyring("k1", newKey(t))
+	require.NoError(t, err)
+	old, err := ring.Encrypt([]byte("value"), nil)
+	require.NoError(t, err)
+
+	require.NoError(t, ring.Rotate("k2", newKey(t)))
+	assert.Equal(t, "k2", ring.Primary())
+
+	pt, err := ring.Decrypt(old, nil)
+	require.NoError(t, err, "舊 key 的密文仍可解密")
+	assert.Equal(t, "value", string(pt))
+
+	fresh, err := ring.Encrypt([]byte("value"), nil)
+	require.NoError(t, err)
+	id, err := KeyID(fresh)
+	require.NoError(t, err)
+	assert.Equal(t, "k2", id)
+
+	rewrapped, err := ring.Rewrap(old)
+	require.NoError(t, err)
+	id, _ = KeyID(rewrapped)
+	assert.Equal(t, "k2", id)
+	assert.Equal(t, strings.Split(old, ".")[3], strings.Split(rewrapped, ".")[3], "資料密文不變")
+
+	assert.ErrorIs(t, ring.Remove("k2"), ErrKeyInUse)
+	require.NoError(t, ring.Remove("k1"))
+	_, err = ring.Decrypt(old, nil)
+	assert.ErrorIs(t, err, ErrUnknownKey)
+	pt, err = ring.Decrypt(rewrapped, nil)
+	require.NoError(t, err)
+	assert.Equal(t, "value", string(pt))
+
+	// 竄改 kid 會讓 DEK 解包失敗
+	require.NoError(t, ring.Rotate("k3", newKey(t)))
+	parts := strings.Split(rewrapped, ".")
+	parts[1] = "k3"
+	_, err = ring.Decrypt(strings.Join(parts, "."), nil)
+	assert.ErrorIs(t, err, ErrDecrypt)
+}
+
+func BenchmarkBcrypt(b *testing.B) {
+	for _, cost := range []int{10, 12, 14} {
+		h := NewBcrypt(cost)
+		b.Run(fmt.Sprintf("cost=%d", cost), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				if _, err := h.Hash("correct horse battery staple"); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkArgon2(b *testing.B) {
+	for _, p := range []Argon2{
+		{Time: 1, Memory: 19 * 1024, Threads: 1, KeyLen: 32, SaltLen: 16},
+		DefaultArgon2,
+		{Time: 3, Memory: 64 * 1024, Threads: 4, KeyLen: 32, SaltLen: 16},
+	} {
+		b.Run(fmt.Sprintf("t=%d,m=%dMiB,p=%d", p.Time, p.Memory/1024, p.Threads), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				if _, err := p.Hash("correct horse battery staple"); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkEnvelope(b *testing.B) {
+	ring, _ := NewKeyring("k1", newKey(b))
+	data := bytes.Repeat([]byte("x"), 1024)
+	for i := 0; i < b.N; i++ {
+		env, _ := ring.Encrypt(data, nil)
+		if _, err := ring.Decrypt(env, nil); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+/*
+go test -run x -bench . -benchtime 3x -benchmem ./crypto/secrets/
+（sandbox 只有 1 顆 CPU，argon2 的 p=4 無法真正平行）
+
+BenchmarkBcrypt/cost=10           3    76690064 ns/op       5266 B/op   10 allocs/op
+BenchmarkBcrypt/cost=12           3   311612708 ns/op       5266 B/op   10 allocs/op
+BenchmarkBcrypt/cost=14           3  1270857277 ns/op       5266 B/op   10 allocs/op
+BenchmarkArgon2/t=1,m=19MiB,p=1   3    20048511 ns/op   19925386 B/op   25 allocs/op
+BenchmarkArgon2/t=1,m=64MiB,p=4   3    66971784 ns/op   67114186 B/op   43 allocs/op
+BenchmarkArgon2/t=3,m=64MiB,p=4   3   161699189 ns/op   67115850 B/op   83 allocs/op
+BenchmarkEnvelope                 3       17926 ns/op      13744 B/op   25 allocs/op
+
+  - bcrypt cost 每 +2 時間約 ×4，記憶體固定約 4KB，所以 GPU 很容易大量平行
+  - argon2 的時間與 t 成正比，而且每次雜湊都要配置 m 的記憶體，
+    破解端的平行度受記憶體限制；登入 API 的並發數也要依 m 估算記憶體用量
+  - 一般建議登入雜湊落在 50~250ms：cost=10~12 或 DefaultArgon2 都在這個範圍
+  - envelope 加解密 1KB 約 18µs，相比之下可以忽略
+*/
@@ -0,0 +1,108 @@
+package ctxutil
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+/*
+Detach：保留 parent 的 value，但不繼承 cancel 與 deadline。
+常見場景是 request 結束後還要做的 fire-and-forget 工作（寫 log、送 metrics），
+如果直接用 request 的 ctx，request 一結束工作就會被取消。
+*/
+
+type detachedCtx struct {
+	parent context.Context
+}
+
+func (detachedCtx) Deadline() (time.Time, bool) { return time.Time{}, false }
+func (detachedCtx) Done() <-chan struct{}       { return nil }
+func (detachedCtx) Err() error                  { return nil }
+
+func (d detachedCtx) Value(key interface{}) interface{} {
+	return d.parent.Value(key)
+}
+
+func Detach(ctx context.Context) context.Context {
+	return detachedCtx{parent: ctx}
+}
+
+/*
+MergeCancel：兩個 parent 任一個被取消，回傳的 ctx 就跟著取消。
+	- Deadline 取兩者中較早的那個
+	- Value 先找 ctx1 找不到再找 ctx2
+*/
+
+type mergedCtx struct {
+	ctx1, ctx2 context.Context
+	done       chan struct{}
+	mu         sync.Mutex
+	err        error
+}
+
+func MergeCancel(ctx1, ctx2 context.Context) (context.Context, context.CancelFunc) {
+	m := &mergedCtx{
+		ctx1: ctx1,
+		ctx2: ctx2,
+		done: make(chan struct{}),
+	}
+	stop := make(chan struct{})
+	go func() {
+		select {
+		case <-ctx1.Done():
+			m.cancel(ctx1.Err())
+		case <-ctx2.Done():
+			m.cancel(ctx2.Err())
+		case <-stop:
+		}
+	}()
+	var once sync.Once
+	return m, func() {
+		m.cancel(context.Canceled)
+		once.Do(func() { close(stop) })
+	}
+}
+
+func (m *mergedCtx) cancel(err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.err != nil {
+		return
+	}
+	m.err = err
+	close(m.done)
+}
+
+func (m *mergedCtx) Deadline() (time.Time, bool) {
+	d1, ok1 := m.ctx1.Deadline()
+	d2, ok2 := m.ctx2.Deadline()
+	switch {
+	case ok1 && ok2:
+		if d2.Before(d1) {
+			return d2, true
+		}
+		return d1, true
+	case ok1:
+		return d1, true
+	default:
+		return d2, ok2
+	}
+}
+
+func (m *mergedCtx) Done() <-chan struct{} {
+	return m.done
+}
+
+func (m *mergedCtx) Err() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.err
+}
+
+func (m *mergedCtx) Value(key interface{}) interface{} {
+	if v := m.ctx1.Value(key); v != nil {
+		return v
+	}
+	return m.ctx2.Value(key)
+}
@@ -0,0 +1,99 @@
+package ctxutil
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDetach(t *testing.T) {
+	parent, cancel := context.WithTimeout(RequestIDKey.With(context.Background(), "req-1"), time.Millisecond)
+	ctx := Detach(parent)
+	cancel()
+
+	<-parent.Done()
+	assert.Nil(t, ctx.Err())
+	assert.Nil(t, ctx.Done())
+	_, ok := ctx.Deadline()
+	assert.False(t, ok)
+
+	id, ok := RequestIDKey.From(ctx)
+	assert.True(t, ok)
+	assert.Equal(t, "req-1", id)
+}
+
+func TestMergeCancelEitherParent(t *testing.T) {
+	ctx1, cancel1 := context.WithCancel(context.Background())
+	ctx2, cancel2 := context.WithCancel(context.Background())
+	defer cancel1()
+
+	ctx, cancel := MergeCancel(ctx1, ctx2)
+	defer cancel()
+	assert.Nil(t, ctx.Err())
+
+	cancel2()
+	select {
+	case <-ctx.Done():
+	case <-time.After(time.Second):
+		t.Fatal("merged context was not cancelled")
+	}
+	assert.Equal(t, context.Canceled, ctx.Err())
+}
+
+func TestMergeCancelOwnCancel(t *testing.T) {
+	ctx, cancel := MergeCancel(context.Background(), context.Background())
+	cancel()
+	cancel() // 重複呼叫不會 panic
+	<-ctx.Done()
+	assert.Equal(t, context.Canceled, ctx.Err())
+}
+
+// 延伸 basic/goroutine 的 WithDeadline 範例：兩個 deadline 取較早的那個
+func TestMergeCancelDeadlineSelection(t *testing.T) {
+	now := time.Now()
+	early := now.Add(50 * time.Millisecond)
+	late := now.Add(time.Hour)
+
+	ctx1, cancel1 := context.WithDeadline(context.Background(), late)
+	defer cancel1()
+	ctx2, cancel2 := context.WithDeadline(context.Background(), early)
+	defer cancel2()
+
+	ctx, cancel := MergeCancel(ctx1, ctx2)
+	defer cancel()
+	d, ok := ctx.Deadline()
+	assert.True(t, ok)
+	assert.Equal(t, early, d)
+
+	<-ctx.Done()
+	assert.Equal(t, context.DeadlineExceeded, ctx.Err())
+
+	// 只有一邊有 deadline
+	ctx3, cancel3 := MergeCancel(context.Background(), ctx1)
+	defer cancel3()
+	d, ok = ctx3.Deadline()
+	assert.True(t, ok)
+	assert.Equal(t, late, d)
+
+	// 兩邊都沒有
+	ctx4, cancel4 := MergeCancel(context.Background(), context.Background())
+	defer cancel4()
+	_, ok = ctx4.Deadline()
+	assert.False(t, ok)
+}
+
+func TestMergeCancelValues(t *testing.T) {
+	ctx1 := RequestIDKey.With(context.Background(), "req-1")
+	ctx2 := RequestIDKey.With(context.Background(), "req-2")
+	ctx2 = ClaimsKey.With(ctx2, Claims{"sub": "tom"})
+
+	ctx, cancel := MergeCancel(ctx1, ctx2)
+	defer cancel()
+	id, _ := RequestIDKey.From(ctx)
+	assert.Equal(t, "req-1", id)
+	claims, ok := ClaimsKey.From(ctx)
+	assert.True(t, ok)
+	assert.Equal(t, "tom", claims["sub"])
+}
@@ -0,0 +1,48 @@
+package ctxutil_test
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"advanced/ctxutil"
+)
+
+// 延伸 basic/goroutine 的 WithDeadline 範例：兩個沒有父子關係的 context，
+// 合併後任一個結束就結束，deadline 取較早的那個
+func ExampleMergeCancel() {
+	base := time.Now()
+	request, cancel1 := context.WithDeadline(context.Background(), base.Add(time.Hour))
+	defer cancel1()
+	shutdown, cancel2 := context.WithDeadline(context.Background(), base.Add(20*time.Millisecond))
+	defer cancel2()
+
+	ctx, cancel := ctxutil.MergeCancel(request, shutdown)
+	defer cancel()
+	d, _ := ctx.Deadline()
+	fmt.Println("deadline from shutdown:", d.Equal(base.Add(20*time.Millisecond)))
+
+	<-ctx.Done()
+	fmt.Println(ctx.Err())
+	fmt.Println("request still alive:", request.Err() == nil)
+	// Output:
+	// deadline from shutdown: true
+	// context deadline exceeded
+	// request still alive: true
+}
+
+// request 結束後的背景工作：留著 request id，但不會跟著 request 被取消
+func ExampleDetach() {
+	request, cancel := context.WithDeadline(ctxutil.RequestIDKey.With(context.Background(), "req-1"), time.Now())
+	defer cancel()
+	<-request.Done()
+
+	bg := ctxutil.Detach(request)
+	_, hasDeadline := bg.Deadline()
+	id, _ := ctxutil.RequestIDKey.From(bg)
+	fmt.Println(request.Err())
+	fmt.Println(bg.Err(), hasDeadline, id)
+	// Output:
+	// context deadline exceeded
+	// <nil> false req-1
+}
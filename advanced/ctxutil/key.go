@@ -0,0 +1,64 @@
+package ctxutil
+
+import (
+	"context"
+	"log"
+)
+
+/*
+context.WithValue 的 key 如果直接用 string，不同 package 很容易撞名：
+	ctx = context.WithValue(ctx, "id", 1)   // A package
+	ctx = context.WithValue(ctx, "id", "x") // B package 把 A 的值蓋掉了
+官方建議 key 使用自訂型別，這裡再配合泛型，讓取值時也不需要類型斷言。
+*/
+
+// Key 是帶型別的 context key，使用 pointer 當作 key，所以每個 NewKey 都是唯一的
+type Key[T any] struct {
+	name string
+}
+
+func NewKey[T any](name string) *Key[T] {
+	return &Key[T]{name: name}
+}
+
+// With 回傳帶有 v 的子 context
+func (k *Key[T]) With(ctx context.Context, v T) context.Context {
+	return context.WithValue(ctx, k, v)
+}
+
+// From 從 context 取值，不存在或型別不符時 ok 為 false
+func (k *Key[T]) From(ctx context.Context) (T, bool) {
+	v, ok := ctx.Value(k).(T)
+	return v, ok
+}
+
+// MustFrom 取不到值時直接 panic，適合一定會被 middleware 注入的值
+func (k *Key[T]) MustFrom(ctx context.Context) T {
+	v, ok := k.From(ctx)
+	if !ok {
+		panic("ctxutil: missing value for key " + k.name)
+	}
+	return v
+}
+
+func (k *Key[T]) String() string {
+	return "ctxutil.Key(" + k.name + ")"
+}
+
+// Claims 為驗證後的使用者資訊
+type Claims map[string]interface{}
+
+// 給 HTTP/gRPC middleware 共用的 key
+var (
+	RequestIDKey = NewKey[string]("request_id")
+	LoggerKey    = NewKey[*log.Logger]("logger")
+	ClaimsKey    = NewKey[Claims]("claims")
+)
+
+// Logger 取出 context 中的 logger，沒有的話回傳 log.Default()
+func Logger(ctx context.Context) *log.Logger {
+	if l, ok := LoggerKey.From(ctx); ok && l != nil {
+		return l
+	}
+	return log.Default()
+}
@@ -0,0 +1,56 @@
+package ctxutil
+
+import (
+	"bytes"
+	"context"
+	"log"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestKeyWithFrom(t *testing.T) {
+	ctx := RequestIDKey.With(context.Background(), "req-1")
+	id, ok := RequestIDKey.From(ctx)
+	assert.True(t, ok)
+	assert.Equal(t, "req-1", id)
+
+	_, ok = RequestIDKey.From(context.Background())
+	assert.False(t, ok)
+}
+
+// 同名的 key 不會互相覆蓋
+func TestKeyNoCollision(t *testing.T) {
+	a := NewKey[string]("id")
+	b := NewKey[string]("id")
+	ctx := a.With(context.Background(), "a")
+	ctx = b.With(ctx, "b")
+
+	va, _ := a.From(ctx)
+	vb, _ := b.From(ctx)
+	assert.Equal(t, "a", va)
+	assert.Equal(t, "b", vb)
+
+	// 與 string key 也不衝突
+	ctx = context.WithValue(ctx, "id", "string")
+	va, _ = a.From(ctx)
+	assert.Equal(t, "a", va)
+}
+
+func TestMustFrom(t *testing.T) {
+	assert.Panics(t, func() {
+		ClaimsKey.MustFrom(context.Background())
+	})
+	ctx := ClaimsKey.With(context.Background(), Claims{"sub": "tom"})
+	assert.Equal(t, "tom", ClaimsKey.MustFrom(ctx)["sub"])
+}
+
+func TestLogger(t *testing.T) {
+	assert.Equal(t, log.Default(), Logger(context.Background()))
+
+	var buf bytes.Buffer
+	l := log.New(&buf, "", 0)
+	ctx := LoggerKey.With(context.Background(), l)
+	Logger(ctx).Print("hello")
+	assert.Equal(t, "hello\n", buf.String())
+}
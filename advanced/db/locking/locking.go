@@ -0,0 +1,218 @@
+package locking
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"advanced/timex"
+)
+
+/*
+同一個用例「扣庫存」的兩種寫法：
+
+悲觀鎖（pessimistic）：先鎖再讀，確定沒人能改之後才判斷與寫入
+
+	BEGIN
+	SELECT qty FROM inventory WHERE sku = $1 FOR UPDATE   -- 其他交易在這裡排隊
+	UPDATE inventory SET qty = qty - $2 WHERE sku = $1
+	COMMIT
+
+樂觀鎖（optimistic）：不鎖，寫入時用 version 欄位確認「讀取之後沒有人改過」，被改過就重來
+
+	SELECT qty, version FROM inventory WHERE sku = $1
+	UPDATE inventory SET qty = qty - $2, version = version + 1
+	 WHERE sku = $1 AND version = $3                      -- 影響 0 筆 = 衝突，重試
+
+怎麼選（測試中以重試次數驗證）：
+  - 沒有競爭時樂觀鎖一次成功，不持有鎖、不需要交易，延遲最低
+  - 同一列競爭激烈（熱門商品）時，樂觀鎖大量重試，每次重試都是一次白做的讀寫；
+    悲觀鎖的重試次數永遠是 0，等待變成排隊，吞吐量反而比較穩定
+  - 悲觀鎖要注意鎖的持有時間（交易內不要做網路呼叫）與多列時的加鎖順序（deadlock）
+
+SQLite 沒有 SELECT ... FOR UPDATE，它的鎖是整個資料庫；
+以 BEGIN IMMEDIATE（mattn/go-sqlite3 的 _txlock=immediate）在交易開始時就取得寫鎖，效果等同悲觀鎖。
+兩種資料庫的差異放在 Dialect。
+*/
+
+var (
+	ErrOutOfStock      = errors.New("locking: out of stock")
+	ErrNotFound        = errors.New("locking: sku not found")
+	ErrTooManyRetries  = errors.New("locking: too many retries")
+	errVersionConflict = errors.New("locking: version conflict")
+)
+
+type Dialect struct {
+	Name string
+	// ForUpdate 加在 SELECT 之後的鎖定子句；SQLite 為空字串，改由 BEGIN IMMEDIATE 取得寫鎖
+	ForUpdate string
+}
+
+var (
+	SQLite   = Dialect{Name: "sqlite"}
+	Postgres = Dialect{Name: "postgres", ForUpdate: " FOR UPDATE"}
+)
+
+// SQLiteDSN 回傳悲觀鎖需要的 SQLite 連線字串：交易一開始就取得寫鎖，拿不到時最多等 busy
+func SQLiteDSN(path string, busy time.Duration) string {
+	return fmt.Sprintf("file:%s?_txlock=immediate&_busy_timeout=%d&_journal_mode=WAL", path, busy.Milliseconds())
+}
+
+// Setup 建立 inventory 資料表並設定 sku 的庫存
+func Setup(ctx context.Context, db *sql.DB, sku string, qty int) error {
+	if _, err := db.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS inventory (
+		sku     TEXT PRIMARY KEY,
+		qty     INTEGER NOT NULL,
+		version INTEGER NOT NULL DEFAULT 0
+	)`); err != nil {
+		return err
+	}
+	_, err := db.ExecContext(ctx, `INSERT INTO inventory (sku, qty) VALUES ($1, $2)
+		ON CONFLICT (sku) DO UPDATE SET qty = excluded.qty, version = 0`, sku, qty)
+	return err
+}
+
+// Stock 回傳目前庫存
+func Stock(ctx context.Context, db *sql.DB, sku string) (int, error) {
+	var qty int
+	err := db.QueryRowContext(ctx, `SELECT qty FROM inventory WHERE sku = $1`, sku).Scan(&qty)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, ErrNotFound
+	}
+	return qty, err
+}
+
+// Decrementer 扣庫存，回傳這次呼叫重試了幾次
+type Decrementer interface {
+	Decrement(ctx context.Context, sku string, n int) (retries int, err error)
+}
+
+type Pessimistic struct {
+	DB      *sql.DB
+	Dialect Dialect
+}
+
+func (p *Pessimistic) Decrement(ctx context.Context, sku string, n int) (int, error) {
+	tx, err := p.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	var qty int
+	err = tx.QueryRowContext(ctx, `SELECT qty FROM inventory WHERE sku = $1`+p.Dialect.ForUpdate, sku).Scan(&qty)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, ErrNotFound
+	}
+	if err != nil {
+		return 0, err
+	}
+	if qty < n {
+		return 0, ErrOutOfStock
+	}
+	if _, err := tx.ExecContext(ctx, `UPDATE inventory SET qty = qty - $1, version = version + 1 WHERE sku = $2`, n, sku); err != nil {
+		return 0, err
+	}
+	return 0, tx.Commit()
+}
+
+type Optimistic struct {
+	DB *sql.DB
+	// MaxRetries 衝突後最多重試幾次，0 表示不重試
+	MaxRetries int
+	// Backoff 每次重試前等待的時間，讓競爭的交易錯開
+	Backoff time.Duration
+
+	afterRead func() // 測試用：在讀取與寫入之間插入其他寫入
+}
+
+func (o *Optimistic) Decrement(ctx context.Context, sku string, n int) (int, error) {
+	for retries := 0; ; retries++ {
+		err := o.try(ctx, sku, n)
+		if !errors.Is(err, errVersionConflict) {
+			return retries, err
+		}
+		if retries == o.MaxRetries {
+			return retries, ErrTooManyRetries
+		}
+		if o.Backoff > 0 {
+			if err := timex.Sleep(ctx, o.Backoff); err != nil {
+				return retries, err
+			}
+		}
+	}
+}
+
+func (o *Optimistic) try(ctx context.Context, sku string, n int) error {
+	var qty, version int
+	err := o.DB.QueryRowContext(ctx, `SELECT qty, version FROM inventory WHERE sku = $1`, sku).Scan(&qty, &version)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrNotFound
+	}
+	if err != nil {
+		return err
+	}
+	if qty < n {
+		return ErrOutOfStock
+	}
+	if o.afterRead != nil {
+		o.afterRead()
+	}
+	res, err := o.DB.ExecContext(ctx, `UPDATE inventory SET qty = qty - $1, version = version + 1
+		WHERE sku = $2 AND version = $3`, n, sku, version)
+	if err != nil {
+		return err
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return errVersionConflict
+	}
+	return nil
+}
+
+// Report 是 Contend 的結果
+type Report struct {
+	Succeeded  int
+	OutOfStock int
+	GaveUp     int // ErrTooManyRetries
+	Retries    int
+}
+
+// Contend 讓 workers 個 goroutine 各扣 attempts 次（每次 1 件），統計結果；遇到其他錯誤時回傳第一個
+func Contend(ctx context.Context, d Decrementer, sku string, workers, attempts int) (Report, error) {
+	type result struct {
+		retries int
+		err     error
+	}
+	results := make(chan result)
+	for w := 0; w < workers; w++ {
+		go func() {
+			for i := 0; i < attempts; i++ {
+				retries, err := d.Decrement(ctx, sku, 1)
+				results <- result{retries, err}
+			}
+		}()
+	}
+	var r Report
+	var first error
+	for i := 0; i < workers*attempts; i++ {
+		res := <-results
+		r.Retries += res.retries
+		switch {
+		case res.err == nil:
+			r.Succeeded++
+		case errors.Is(res.err, ErrOutOfStock):
+			r.OutOfStock++
+		case errors.Is(res.err, ErrTooManyRetries):
+			r.GaveUp++
+		case first == nil:
+			first = res.err
+		}
+	}
+	return r, first
+}
@@ -0,0 +1,184 @@
+package deepx
+
+import (
+	"reflect"
+	"time"
+	"unsafe"
+)
+
+/*
+以反射實作的深拷貝 / 深比較，跟 gob、json round-trip 比起來：
+	- 不需要序列化，速度快、配置少
+	- 可以處理未導出欄位、循環引用（自己指向自己的 pointer）
+	- 可以針對特定型別掛 hook，或忽略某些欄位
+
+給 prototype（複製原型）與 memento（保存快照）這類設計模式使用。
+chan、func 無法複製，會直接共用。
+*/
+
+type options struct {
+	copyHooks  map[reflect.Type]func(reflect.Value) reflect.Value
+	equalHooks map[reflect.Type]func(a, b reflect.Value) bool
+	ignore     map[string]bool
+}
+
+type Option func(*options)
+
+// CopyHook 指定型別 T 的複製方式
+func CopyHook[T any](fn func(T) T) Option {
+	return func(o *options) {
+		o.copyHooks[reflect.TypeOf((*T)(nil)).Elem()] = func(v reflect.Value) reflect.Value {
+			return reflect.ValueOf(fn(v.Interface().(T)))
+		}
+	}
+}
+
+// EqualHook 指定型別 T 的比較方式
+func EqualHook[T any](fn func(a, b T) bool) Option {
+	return func(o *options) {
+		o.equalHooks[reflect.TypeOf((*T)(nil)).Elem()] = func(a, b reflect.Value) bool {
+			return fn(a.Interface().(T), b.Interface().(T))
+		}
+	}
+}
+
+// IgnoreFields 複製時該欄位保持零值，比較時略過；名稱可以是 "Field" 或 "Type.Field"
+func IgnoreFields(names ...string) Option {
+	return func(o *options) {
+		for _, n := range names {
+			o.ignore[n] = true
+		}
+	}
+}
+
+func newOptions(opts []Option) *options {
+	o := &options{
+		copyHooks: map[reflect.Type]func(reflect.Value) reflect.Value{
+			// time.Time 是不可變的值，直接共用，避免把 *Location 也複製一份
+			reflect.TypeOf(time.Time{}): func(v reflect.Value) reflect.Value { return v },
+		},
+		equalHooks: make(map[reflect.Type]func(a, b reflect.Value) bool),
+		ignore:     make(map[string]bool),
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+func (o *options) ignored(t reflect.Type, field string) bool {
+	return o.ignore[field] || o.ignore[t.Name()+"."+field]
+}
+
+type visit struct {
+	ptr uintptr
+	len int
+	typ reflect.Type
+}
+
+// Copy 回傳 v 的深拷貝
+func Copy[T any](v T, opts ...Option) T {
+	c := &copier{options: newOptions(opts), seen: make(map[visit]reflect.Value)}
+	src := reflect.ValueOf(&v).Elem()
+	dst := reflect.New(src.Type()).Elem()
+	c.copy(dst, src)
+	return dst.Interface().(T)
+}
+
+type copier struct {
+	*options
+	seen map[visit]reflect.Value
+}
+
+// settable 透過 unsafe 取得可寫入的欄位，讓未導出欄位也能複製，v 必須可定址
+func settable(v reflect.Value) reflect.Value {
+	if v.CanSet() {
+		return v
+	}
+	return reflect.NewAt(v.Type(), unsafe.Pointer(v.UnsafeAddr())).Elem()
+}
+
+func (c *copier) copy(dst, src reflect.Value) {
+	if hook, ok := c.copyHooks[src.Type()]; ok && src.CanInterface() {
+		dst.Set(hook(src))
+		return
+	}
+	switch src.Kind() {
+	case reflect.Ptr:
+		if src.IsNil() {
+			return
+		}
+		key := visit{ptr: src.Pointer(), typ: src.Type()}
+		if p, ok := c.seen[key]; ok {
+			dst.Set(p)
+			return
+		}
+		p := reflect.New(src.Type().Elem())
+		c.seen[key] = p
+		c.copy(p.Elem(), src.Elem())
+		dst.Set(p)
+	case reflect.Interface:
+		if src.IsNil() {
+			return
+		}
+		elem := src.Elem()
+		n := reflect.New(elem.Type()).Elem()
+		c.copy(n, elem)
+		dst.Set(n)
+	case reflect.Struct:
+		if !src.CanAddr() {
+			tmp := reflect.New(src.Type()).Elem()
+			tmp.Set(src)
+			src = tmp
+		}
+		t := src.Type()
+		for i := 0; i < t.NumField(); i++ {
+			if c.ignored(t, t.Field(i).Name) {
+				continue
+			}
+			c.copy(settable(dst.Field(i)), settable(src.Field(i)))
+		}
+	case reflect.Slice:
+		if src.IsNil() {
+			return
+		}
+		key := visit{ptr: src.Pointer(), len: src.Len(), typ: src.Type()}
+		if s, ok := c.seen[key]; ok {
+			dst.Set(s)
+			return
+		}
+		s := reflect.MakeSlice(src.Type(), src.Len(), src.Len())
+		c.seen[key] = s
+		for i := 0; i < src.Len(); i++ {
+			c.copy(s.Index(i), src.Index(i))
+		}
+		dst.Set(s)
+	case reflect.Array:
+		for i := 0; i < src.Len(); i++ {
+			c.copy(dst.Index(i), src.Index(i))
+		}
+	case reflect.Map:
+		if src.IsNil() {
+			return
+		}
+		key := visit{ptr: src.Pointer(), typ: src.Type()}
+		if m, ok := c.seen[key]; ok {
+			dst.Set(m)
+			return
+		}
+		m := reflect.MakeMapWithSize(src.Type(), src.Len())
+		c.seen[key] = m
+		iter := src.MapRange()
+		for iter.Next() {
+			k := reflect.New(src.Type().Key()).Elem()
+			c.copy(k, iter.Key())
+			v := reflect.New(src.Type().Elem()).Elem()
+			c.copy(v, iter.Value())
+			m.SetMapIndex(k, v)
+		}
+		dst.Set(m)
+	default:
+		// 基本型別、chan、func 直接指定
+		dst.Set(src)
+	}
+}
@@ -0,0 +1,235 @@
+package deepx
+
+import (
+	"bytes"
+	"encoding/gob"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type Node struct {
+	Name     string
+	Children []*Node
+	Parent   *Node
+	Attrs    map[string]interface{}
+	secret   []int
+}
+
+func TestCopyBasic(t *testing.T) {
+	src := &Node{
+		Name:   "root",
+		Attrs:  map[string]interface{}{"n": 1, "list": []string{"a"}},
+		secret: []int{1, 2},
+	}
+	dst := Copy(src)
+	assert.True(t, Equal(src, dst))
+	assert.NotSame(t, src, dst)
+
+	// 修改拷貝不影響原本的值，包含未導出欄位
+	dst.Attrs["list"].([]string)[0] = "changed"
+	dst.secret[0] = 100
+	assert.Equal(t, "a", src.Attrs["list"].([]string)[0])
+	assert.Equal(t, 1, src.secret[0])
+	assert.False(t, Equal(src, dst))
+}
+
+func TestCopyCycle(t *testing.T) {
+	root := &Node{Name: "root"}
+	child := &Node{Name: "child", Parent: root}
+	root.Children = []*Node{child}
+	root.Attrs = map[string]interface{}{"self": root}
+
+	cp := Copy(root)
+	assert.NotSame(t, root, cp)
+	// 循環結構被保留，而且指向新的物件
+	assert.Same(t, cp, cp.Children[0].Parent)
+	assert.Same(t, cp, cp.Attrs["self"])
+	assert.True(t, Equal(root, cp))
+
+	cp.Children[0].Name = "other"
+	assert.False(t, Equal(root, cp))
+}
+
+func TestCopySharedPointer(t *testing.T) {
+	shared := &Node{Name: "shared"}
+	src := []*Node{shared, shared}
+	cp := Copy(src)
+	assert.Same(t, cp[0], cp[1])
+	assert.NotSame(t, shared, cp[0])
+}
+
+func TestCopyPreservesNil(t *testing.T) {
+	type S struct {
+		P *int
+		M map[string]int
+		L []int
+		I interface{}
+	}
+	cp := Copy(S{})
+	assert.Nil(t, cp.P)
+	assert.Nil(t, cp.M)
+	assert.Nil(t, cp.L)
+	assert.Nil(t, cp.I)
+	assert.Equal(t, [3]int{1, 2, 3}, Copy([3]int{1, 2, 3}))
+}
+
+func TestCopyTime(t *testing.T) {
+	type Event struct {
+		At time.Time
+	}
+	e := Event{At: time.Now().UTC()}
+	cp := Copy(e)
+	assert.Equal(t, time.UTC, cp.At.Location())
+	assert.True(t, e.At.Equal(cp.At))
+}
+
+func TestHooks(t *testing.T) {
+	type Doc struct {
+		Title string
+		Body  string
+	}
+	upper := CopyHook(func(s string) string { return strings.ToUpper(s) })
+	assert.Equal(t, Doc{"A", "B"}, Copy(Doc{"a", "b"}, upper))
+
+	fold := EqualHook(func(a, b string) bool { return strings.EqualFold(a, b) })
+	assert.True(t, Equal(Doc{"a", "b"}, Doc{"A", "B"}, fold))
+	assert.False(t, Equal(Doc{"a", "b"}, Doc{"A", "B"}))
+}
+
+func TestIgnoreFields(t *testing.T) {
+	type Account struct {
+		ID       int
+		Password string
+		Updated  time.Time
+	}
+	a := Account{ID: 1, Password: "secret", Updated: time.Now()}
+	cp := Copy(a, IgnoreFields("Account.Password"))
+	assert.Equal(t, "", cp.Password)
+	assert.Equal(t, 1, cp.ID)
+
+	b := Account{ID: 1, Password: "other"}
+	assert.False(t, Equal(a, b))
+	assert.True(t, Equal(a, b, IgnoreFields("Password", "Updated")))
+}
+
+func TestEqualSemantics(t *testing.T) {
+	assert.False(t, Equal([]int(nil), []int{}))
+	assert.False(t, Equal(1, int64(1)))
+	assert.True(t, Equal(nil, nil))
+	assert.False(t, Equal(nil, 1))
+	assert.True(t, Equal(map[string][]int{"a": {1}}, map[string][]int{"a": {1}}))
+	assert.False(t, Equal(map[string]int{"a": 1}, map[string]int{"b": 1}))
+	assert.False(t, Equal(Node{secret: []int{1}}, Node{secret: []int{2}}))
+}
+
+/*
+prototype 模式：以現有物件為原型複製出新物件再修改，
+原型中的 slice/map 如果只做淺拷貝，修改新物件會污染原型。
+*/
+
+type Monster struct {
+	Name   string
+	Skills []string
+	Stats  map[string]int
+}
+
+var goblinPrototype = &Monster{
+	Name:   "goblin",
+	Skills: []string{"scratch"},
+	Stats:  map[string]int{"hp": 10},
+}
+
+func (m *Monster) Clone() *Monster {
+	return Copy(m)
+}
+
+func TestPrototype(t *testing.T) {
+	boss := goblinPrototype.Clone()
+	boss.Name = "goblin king"
+	boss.Skills = append(boss.Skills, "summon")
+	boss.Stats["hp"] = 100
+
+	assert.Equal(t, []string{"scratch"}, goblinPrototype.Skills)
+	assert.Equal(t, 10, goblinPrototype.Stats["hp"])
+}
+
+/*
+memento 模式：保存物件某個時間點的狀態，之後可以還原。
+*/
+
+type Editor struct {
+	Lines []string
+}
+
+type Memento struct {
+	state Editor
+}
+
+func (e *Editor) Save() Memento           { return Memento{state: Copy(*e)} }
+func (e *Editor) Restore(m Memento)       { *e = Copy(m.state) }
+func (e *Editor) Write(line string)       { e.Lines = append(e.Lines, line) }
+func (e *Editor) Replace(i int, s string) { e.Lines[i] = s }
+
+func TestMemento(t *testing.T) {
+	e := &Editor{}
+	e.Write("hello")
+	snap := e.Save()
+
+	e.Replace(0, "changed")
+	e.Write("world")
+	e.Restore(snap)
+	assert.Equal(t, []string{"hello"}, e.Lines)
+
+	// 還原後再修改也不會影響快照
+	e.Replace(0, "again")
+	e.Restore(snap)
+	assert.Equal(t, []string{"hello"}, e.Lines)
+}
+
+type Payload struct {
+	ID    int
+	Tags  []string
+	Attrs map[string]string
+	Items []Item
+}
+
+type Item struct {
+	Name  string
+	Price float64
+}
+
+func newPayload() Payload {
+	p := Payload{ID: 1, Tags: []string{"a", "b", "c"}, Attrs: map[string]string{"k": "v", "x": "y"}}
+	for i := 0; i < 20; i++ {
+		p.Items = append(p.Items, Item{Name: "item", Price: float64(i)})
+	}
+	return p
+}
+
+func BenchmarkDeepCopy(b *testing.B) {
+	p := newPayload()
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		_ = Copy(p)
+	}
+}
+
+func BenchmarkGobRoundTrip(b *testing.B) {
+	p := newPayload()
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		var buf bytes.Buffer
+		_ = gob.NewEncoder(&buf).Encode(p)
+		var out Payload
+		_ = gob.NewDecoder(&buf).Decode(&out)
+	}
+}
+
+/*
+BenchmarkDeepCopy     	  163173	      7651 ns/op	    1608 B/op	      22 allocs/op
+BenchmarkGobRoundTrip 	   37386	     38997 ns/op	   13120 B/op	     270 allocs/op
+gob 每次都要重新傳送型別資訊並編碼/解碼，反射拷貝快了約 5 倍
+*/
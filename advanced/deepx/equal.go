@@ -0,0 +1,101 @@
+package deepx
+
+import "reflect"
+
+// Equal 與 reflect.DeepEqual 的規則相同（nil slice 與空 slice 不相等），
+// 另外支援 EqualHook 與 IgnoreFields
+func Equal(a, b interface{}, opts ...Option) bool {
+	e := &equaler{options: newOptions(opts), seen: make(map[[2]visit]bool)}
+	return e.equal(reflect.ValueOf(a), reflect.ValueOf(b))
+}
+
+type equaler struct {
+	*options
+	seen map[[2]visit]bool
+}
+
+func (e *equaler) equal(a, b reflect.Value) bool {
+	if !a.IsValid() || !b.IsValid() {
+		return a.IsValid() == b.IsValid()
+	}
+	if a.Type() != b.Type() {
+		return false
+	}
+	if hook, ok := e.equalHooks[a.Type()]; ok && a.CanInterface() && b.CanInterface() {
+		return hook(a, b)
+	}
+
+	switch a.Kind() {
+	case reflect.Ptr, reflect.Map, reflect.Slice:
+		// 循環引用：同一組 (a, b) 已經在比較中，視為相等讓外層繼續比
+		if a.IsNil() || b.IsNil() {
+			return a.IsNil() == b.IsNil()
+		}
+		key := [2]visit{{ptr: a.Pointer(), typ: a.Type()}, {ptr: b.Pointer(), typ: b.Type()}}
+		if a.Kind() == reflect.Slice {
+			key[0].len, key[1].len = a.Len(), b.Len()
+		}
+		if e.seen[key] {
+			return true
+		}
+		e.seen[key] = true
+	}
+
+	switch a.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if a.IsNil() || b.IsNil() {
+			return a.IsNil() == b.IsNil()
+		}
+		return e.equal(a.Elem(), b.Elem())
+	case reflect.Struct:
+		t := a.Type()
+		for i := 0; i < t.NumField(); i++ {
+			if e.ignored(t, t.Field(i).Name) {
+				continue
+			}
+			if !e.equal(a.Field(i), b.Field(i)) {
+				return false
+			}
+		}
+		return true
+	case reflect.Slice, reflect.Array:
+		if a.Len() != b.Len() {
+			return false
+		}
+		for i := 0; i < a.Len(); i++ {
+			if !e.equal(a.Index(i), b.Index(i)) {
+				return false
+			}
+		}
+		return true
+	case reflect.Map:
+		if a.Len() != b.Len() {
+			return false
+		}
+		iter := a.MapRange()
+		for iter.Next() {
+			bv := b.MapIndex(iter.Key())
+			if !bv.IsValid() || !e.equal(iter.Value(), bv) {
+				return false
+			}
+		}
+		return true
+	case reflect.Func:
+		return a.IsNil() && b.IsNil()
+	case reflect.Bool:
+		return a.Bool() == b.Bool()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return a.Int() == b.Int()
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return a.Uint() == b.Uint()
+	case reflect.Float32, reflect.Float64:
+		return a.Float() == b.Float()
+	case reflect.Complex64, reflect.Complex128:
+		return a.Complex() == b.Complex()
+	case reflect.String:
+		return a.String() == b.String()
+	case reflect.Chan, reflect.UnsafePointer:
+		return a.Pointer() == b.Pointer()
+	}
+	return false
+}
@@ -0,0 +1,65 @@
+package diag
+
+import (
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGODEBUG(t *testing.T) {
+	assert.Equal(t, "schedtrace=250,scheddetail=1", GODEBUG(250*time.Millisecond))
+	assert.Equal(t, "schedtrace=1,scheddetail=1", GODEBUG(0))
+}
+
+func TestDemuxCapturedTrace(t *testing.T) {
+	// 由 3 個 sleep goroutine 的小程式以 schedtrace=100 產生
+	f, err := os.Open("testdata/schedtrace.txt")
+	require.NoError(t, err)
+	defer f.Close()
+
+	var out strings.Builder
+	var samples []Sample
+	require.NoError(t, Demux(f, &out, func(s Sample) { samples = append(samples, s) }))
+
+	assert.Equal(t, "hello\n", out.String())
+	require.Len(t, samples, 2)
+	assert.Equal(t, Sample{GOMAXPROCS: 1, Threads: 2, Goroutines: 1, Running: 1}, samples[0])
+	assert.Equal(t, 105*time.Millisecond, samples[1].Elapsed)
+	assert.Equal(t, 3, samples[1].Threads)
+	assert.Equal(t, 9, samples[1].Goroutines)
+	assert.Equal(t, 9, samples[1].Waiting)
+}
+
+func TestDemuxSkipsDeadGoroutines(t *testing.T) {
+	in := strings.Join([]string{
+		"SCHED 500ms: gomaxprocs=4 idleprocs=3 threads=6 runqueue=2",
+		"  P0: status=1 m=0 runqsize=0",
+		"  G1: status=2() m=0 lockedm=-1",
+		"  G7: status=1() m=-1 lockedm=-1",
+		"  G8: status=6() m=-1 lockedm=-1",
+		"=== RUN   TestX",
+		"  G9: not part of the trace",
+	}, "\n")
+
+	var out strings.Builder
+	var samples []Sample
+	require.NoError(t, Demux(strings.NewReader(in), &out, func(s Sample) { samples = append(samples, s) }))
+
+	require.Len(t, samples, 1)
+	assert.Equal(t, Sample{
+		Elapsed: 500 * time.Millisecond, GOMAXPROCS: 4, Threads: 6, RunQueue: 2,
+		Goroutines: 2, Running: 1, Runnable: 1,
+	}, samples[0])
+	// trace 區塊結束後，長得像 G 行的一般輸出要保留
+	assert.Equal(t, "=== RUN   TestX\n  G9: not part of the trace\n", out.String())
+}
+
+func TestReadSelf(t *testing.T) {
+	s := ReadSelf()
+	assert.GreaterOrEqual(t, s.Goroutines, 1)
+	assert.NotZero(t, s.HeapAlloc)
+}
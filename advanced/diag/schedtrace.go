@@ -0,0 +1,166 @@
+package diag
+
+import (
+	"bufio"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+/*
+觀察「另一個行程」的 goroutine 數量：runtime.NumGoroutine 只能看自己，
+但設定 GODEBUG=schedtrace=<ms>,scheddetail=1 後，runtime 會每隔 <ms> 在 stderr 印出：
+
+	SCHED 1004ms: gomaxprocs=1 idleprocs=0 threads=5 spinningthreads=0 ... runqueue=0 ...
+	  P0: status=1 schedtick=12 syscalltick=3 m=0 runqsize=0 gfreecnt=0 timerslen=1
+	  M0: p=0 curg=1 mallocing=0 throwing=0 ...
+	  G1: status=2(chan receive) m=0 lockedm=-1
+	  G2: status=4(force gc (idle)) m=-1 lockedm=-1
+	  ...
+
+每個 G 行代表一個 goroutine（status=6 是已結束、等待重用的 G，不計入），
+因此數 G 行就能得到子行程的即時 goroutine 數量。注意這個數字包含 runtime 內部的
+goroutine（GC worker、finalizer...），會比子行程自己呼叫 runtime.NumGoroutine 大幾個。
+
+Demux 把 trace 行從 stderr 中拆出來轉成 Sample，其他行原封不動寫到 out，
+所以範例本身的輸出不會被 trace 淹沒。
+*/
+
+// GODEBUG 回傳子行程要設定的 GODEBUG 值
+func GODEBUG(interval time.Duration) string {
+	ms := interval.Milliseconds()
+	if ms < 1 {
+		ms = 1
+	}
+	return "schedtrace=" + strconv.FormatInt(ms, 10) + ",scheddetail=1"
+}
+
+// Sample 是一次 schedtrace 的摘要
+type Sample struct {
+	Elapsed    time.Duration // 子行程啟動到這次 trace 的時間
+	GOMAXPROCS int
+	Threads    int
+	RunQueue   int // 全域 run queue 長度
+	Goroutines int // 未結束的 goroutine（含 runtime 內部）
+	Running    int // status=2
+	Runnable   int // status=1
+	Waiting    int // status=4
+}
+
+const gDead = 6
+
+// Demux 逐行讀取 r：trace 行解析後呼叫 fn，其他行寫到 out，讀到 EOF 時回傳
+func Demux(r io.Reader, out io.Writer, fn func(Sample)) error {
+	var (
+		cur     Sample
+		inTrace bool
+	)
+	flush := func() {
+		if inTrace {
+			fn(cur)
+			inTrace = false
+		}
+	}
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 64*1024), 1024*1024)
+	for sc.Scan() {
+		line := sc.Text()
+		if s, ok := parseHeader(line); ok {
+			flush()
+			cur, inTrace = s, true
+			continue
+		}
+		if inTrace {
+			if kind, rest, ok := detailLine(line); ok {
+				if kind == 'G' {
+					cur.addG(rest)
+				}
+				continue
+			}
+			flush()
+		}
+		if _, err := io.WriteString(out, line+"\n"); err != nil {
+			return err
+		}
+	}
+	flush()
+	return sc.Err()
+}
+
+func parseHeader(line string) (Sample, bool) {
+	rest, ok := strings.CutPrefix(line, "SCHED ")
+	if !ok {
+		return Sample{}, false
+	}
+	elapsed, fields, ok := strings.Cut(rest, ": ")
+	if !ok {
+		return Sample{}, false
+	}
+	var s Sample
+	if ms, err := strconv.Atoi(strings.TrimSuffix(elapsed, "ms")); err == nil {
+		s.Elapsed = time.Duration(ms) * time.Millisecond
+	}
+	for _, f := range strings.Fields(fields) {
+		k, v, ok := strings.Cut(f, "=")
+		if !ok {
+			continue
+		}
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			continue
+		}
+		switch k {
+		case "gomaxprocs":
+			s.GOMAXPROCS = n
+		case "threads":
+			s.Threads = n
+		case "runqueue":
+			s.RunQueue = n
+		}
+	}
+	return s, true
+}
+
+// detailLine 辨識 scheddetail 的 "  P0: ..."、"  M1: ..."、"  G12: ..." 行
+func detailLine(line string) (kind byte, rest string, ok bool) {
+	if !strings.HasPrefix(line, "  ") || len(line) < 4 {
+		return 0, "", false
+	}
+	kind = line[2]
+	if kind != 'P' && kind != 'M' && kind != 'G' {
+		return 0, "", false
+	}
+	id, rest, ok := strings.Cut(line[3:], ": ")
+	if !ok {
+		return 0, "", false
+	}
+	if _, err := strconv.Atoi(id); err != nil {
+		return 0, "", false
+	}
+	return kind, rest, true
+}
+
+func (s *Sample) addG(rest string) {
+	v, ok := strings.CutPrefix(rest, "status=")
+	if !ok {
+		return
+	}
+	end := strings.IndexAny(v, "( ")
+	if end >= 0 {
+		v = v[:end]
+	}
+	status, err := strconv.Atoi(v)
+	if err != nil || status == gDead {
+		return
+	}
+	s.Goroutines++
+	switch status {
+	case 1:
+		s.Runnable++
+	case 2:
+		s.Running++
+	case 4:
+		s.Waiting++
+	}
+}
@@ -0,0 +1,21 @@
+package diag
+
+import "runtime"
+
+// Self 是目前行程的概況，用來確認工具本身（例如反覆 re-run 的 TUI）沒有洩漏 goroutine
+type Self struct {
+	Goroutines int
+	HeapAlloc  uint64
+	NumGC      uint32
+}
+
+// ReadSelf 會呼叫 runtime.ReadMemStats（短暫 stop-the-world），不要在熱路徑上使用
+func ReadSelf() Self {
+	var ms runtime.MemStats
+	runtime.ReadMemStats(&ms)
+	return Self{
+		Goroutines: runtime.NumGoroutine(),
+		HeapAlloc:  ms.HeapAlloc,
+		NumGC:      ms.NumGC,
+	}
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"net"
+	"os"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+
+	"advanced/distrib/mapreduce"
+	"advanced/distrib/mapreduce/mrpb"
+	"advanced/timex"
+)
+
+// 以 word count 示範，在同一個目錄下開多個 terminal：
+//
+//	go run ./distrib/mapreduce/cmd/mr coordinator -nreduce 3 testdata/*.txt
+//	go run ./distrib/mapreduce/cmd/mr worker
+//	go run ./distrib/mapreduce/cmd/mr worker
+func main() {
+	if len(os.Args) < 2 {
+		fmt.Fprintln(os.Stderr, "usage: mr coordinator|worker [flags]")
+		os.Exit(2)
+	}
+	switch os.Args[1] {
+	case "coordinator":
+		coordinator(os.Args[2:])
+	case "worker":
+		worker(os.Args[2:])
+	default:
+		log.Fatalf("unknown command %q", os.Args[1])
+	}
+}
+
+func coordinator(args []string) {
+	fs := flag.NewFlagSet("coordinator", flag.ExitOnError)
+	addr := fs.String("addr", "127.0.0.1:7777", "listen address")
+	nReduce := fs.Int("nreduce", 3, "number of reduce tasks")
+	timeout := fs.Duration("timeout", 10*time.Second, "task timeout before re-assignment")
+	fs.Parse(args)
+
+	lis, err := net.Listen("tcp", *addr)
+	if err != nil {
+		log.Fatal(err)
+	}
+	c := mapreduce.NewCoordinator(fs.Args(), *nReduce, mapreduce.WithTaskTimeout(*timeout))
+	srv := grpc.NewServer()
+	mrpb.RegisterCoordinatorServer(srv, c)
+	go srv.Serve(lis)
+
+	ctx := context.Background()
+	c.Wait(ctx)
+	// 給 worker 一點時間拿到 EXIT 再關閉
+	_ = timex.Sleep(ctx, time.Second)
+	srv.GracefulStop()
+	log.Printf("done: %+v", c.Stats())
+}
+
+func worker(args []string) {
+	fs := flag.NewFlagSet("worker", flag.ExitOnError)
+	addr := fs.String("addr", "127.0.0.1:7777", "coordinator address")
+	dir := fs.String("dir", ".", "shared directory for intermediate and output files")
+	fs.Parse(args)
+
+	conn, err := grpc.NewClient(*addr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer conn.Close()
+	w := mapreduce.NewWorker(mrpb.NewCoordinatorClient(conn), mapreduce.WordCount, mapreduce.WithDir(*dir))
+	if err := w.Run(context.Background()); err != nil {
+		log.Fatal(err)
+	}
+}
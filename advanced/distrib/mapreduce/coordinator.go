@@ -0,0 +1,201 @@
+package mapreduce
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"advanced/distrib/mapreduce/mrpb"
+	"advanced/timex"
+)
+
+/*
+流程：
+ 1. 每個輸入檔是一個 map task；map 輸出依 hash(key) % nReduce 分成 nReduce 個中間檔 mr-<map>-<reduce>
+ 2. 所有 map 完成後才開始 reduce：reduce task r 讀取所有 mr-*-r，排序、分組後寫出 mr-out-<r>
+ 3. worker 用 RequestTask 拉工作、ReportTask 回報完成
+
+Straggler（卡住或當掉的 worker）：task 分配出去超過 taskTimeout 還沒回報，就重新分配給下一個來要工作的 worker。
+同一個 task 可能因此被執行兩次，所以 worker 一律先寫暫存檔再 rename，
+輸出要嘛完整、要嘛不存在；先回報的結果被採用，遲到的回報只會得到 accepted=false。
+*/
+
+type taskState int
+
+const (
+	idle taskState = iota
+	inProgress
+	completed
+)
+
+type task struct {
+	state   taskState
+	started time.Time
+	attempt int64
+}
+
+type Stats struct {
+	MapDone    int
+	ReduceDone int
+	Reassigned int // 因逾時而重新分配的次數
+	Stale      int // 被忽略的遲到回報
+}
+
+type Coordinator struct {
+	mrpb.UnimplementedCoordinatorServer
+
+	files   []string
+	nReduce int
+	timeout time.Duration
+	clock   timex.Clock
+
+	mu      sync.Mutex
+	maps    []task
+	reduces []task
+	attempt int64
+	stats   Stats
+	done    chan struct{}
+	finish  sync.Once
+}
+
+type Option func(*Coordinator)
+
+// WithTaskTimeout 設定 task 被視為 straggler 的時間，預設 10 秒
+func WithTaskTimeout(d time.Duration) Option {
+	return func(c *Coordinator) { c.timeout = d }
+}
+
+// WithClock 設定判斷 task 逾時用的時鐘，預設 timex.Real
+func WithClock(clock timex.Clock) Option {
+	return func(c *Coordinator) { c.clock = clock }
+}
+
+func NewCoordinator(files []string, nReduce int, opts ...Option) *Coordinator {
+	c := &Coordinator{
+		files:   files,
+		nReduce: nReduce,
+		timeout: 10 * time.Second,
+		clock:   timex.Real{},
+		maps:    make([]task, len(files)),
+		reduces: make([]task, nReduce),
+		done:    make(chan struct{}),
+	}
+	for _, o := range opts {
+		o(c)
+	}
+	// 沒有輸入檔或沒有 reduce task 時沒事可做，worker 一來要工作就回 EXIT
+	if len(files) == 0 || nReduce == 0 {
+		c.close()
+	}
+	return c
+}
+
+// close 只關閉 done 一次；建構時與最後一個 reduce 回報都可能呼叫
+func (c *Coordinator) close() {
+	c.finish.Do(func() { close(c.done) })
+}
+
+func (c *Coordinator) RequestTask(ctx context.Context, req *mrpb.RequestTaskRequest) (*mrpb.Task, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	t := &mrpb.Task{NMap: int32(len(c.files)), NReduce: int32(c.nReduce)}
+	switch {
+	case c.Done():
+		t.Type = mrpb.TaskType_TASK_TYPE_EXIT
+		return t, nil
+	case c.stats.MapDone < len(c.maps):
+		t.Type = mrpb.TaskType_TASK_TYPE_MAP
+		if id, ok := c.assign(c.maps); ok {
+			t.Id, t.InputFile, t.Attempt = int32(id), c.files[id], c.maps[id].attempt
+			return t, nil
+		}
+	case c.stats.ReduceDone < len(c.reduces):
+		t.Type = mrpb.TaskType_TASK_TYPE_REDUCE
+		if id, ok := c.assign(c.reduces); ok {
+			t.Id, t.Attempt = int32(id), c.reduces[id].attempt
+			return t, nil
+		}
+	default:
+		t.Type = mrpb.TaskType_TASK_TYPE_EXIT
+		return t, nil
+	}
+	t.Type = mrpb.TaskType_TASK_TYPE_WAIT
+	return t, nil
+}
+
+// assign 挑一個閒置或逾時的 task
+func (c *Coordinator) assign(tasks []task) (int, bool) {
+	for i := range tasks {
+		t := &tasks[i]
+		switch {
+		case t.state == idle:
+		case t.state == inProgress && c.clock.Now().Sub(t.started) >= c.timeout:
+			c.stats.Reassigned++
+		default:
+			continue
+		}
+		c.attempt++
+		t.state, t.started, t.attempt = inProgress, c.clock.Now(), c.attempt
+		return i, true
+	}
+	return 0, false
+}
+
+func (c *Coordinator) ReportTask(ctx context.Context, req *mrpb.ReportTaskRequest) (*mrpb.ReportTaskResponse, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var tasks []task
+	var counter *int
+	switch req.Type {
+	case mrpb.TaskType_TASK_TYPE_MAP:
+		tasks, counter = c.maps, &c.stats.MapDone
+	case mrpb.TaskType_TASK_TYPE_REDUCE:
+		tasks, counter = c.reduces, &c.stats.ReduceDone
+	default:
+		return nil, status.Errorf(codes.InvalidArgument, "unexpected task type %v", req.Type)
+	}
+	if req.Id < 0 || int(req.Id) >= len(tasks) {
+		return nil, status.Errorf(codes.InvalidArgument, "task %d out of range", req.Id)
+	}
+	t := &tasks[req.Id]
+	if t.state == completed {
+		c.stats.Stale++
+		return &mrpb.ReportTaskResponse{Accepted: false}, nil
+	}
+	t.state = completed
+	*counter++
+	if c.stats.ReduceDone == len(c.reduces) {
+		c.close()
+	}
+	return &mrpb.ReportTaskResponse{Accepted: true}, nil
+}
+
+// Done 在所有 reduce task 完成後回傳 true
+func (c *Coordinator) Done() bool {
+	select {
+	case <-c.done:
+		return true
+	default:
+		return false
+	}
+}
+
+func (c *Coordinator) Wait(ctx context.Context) error {
+	select {
+	case <-c.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (c *Coordinator) Stats() Stats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.stats
+}
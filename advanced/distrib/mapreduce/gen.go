@@ -0,0 +1,11 @@
+// Package mapreduce 是 MapReduce 的教學版實作：coordinator 透過 gRPC 分配 map / reduce task，
+// worker 讀寫共用目錄中的中間檔。
+//
+// 產生的 *.pb.go 已經 commit，修改 .proto 後需重新產生：
+//
+//	go install google.golang.org/protobuf/cmd/protoc-gen-go@v1.34.2
+//	go install google.golang.org/grpc/cmd/protoc-gen-go-grpc@v1.5.1
+//	go generate ./distrib/mapreduce
+package mapreduce
+
+//go:generate protoc -I . --go_out=. --go_opt=paths=source_relative --go-grpc_out=. --go-grpc_opt=paths=source_relative mrpb/mapreduce.proto
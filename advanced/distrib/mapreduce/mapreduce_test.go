@@ -0,0 +1,172 @@
+package mapreduce
+
+import (
+	"context"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+
+	"advanced/distrib/mapreduce/mrpb"
+	"advanced/timex"
+)
+
+func TestCoordinatorReassignsStragglers(t *testing.T) {
+	clock := timex.NewFake(time.Unix(0, 0))
+	ctx := context.Background()
+	c := NewCoordinator([]string{"a", "b"}, 1, WithTaskTimeout(time.Second), WithClock(clock))
+	req := &mrpb.RequestTaskRequest{WorkerId: "w"}
+
+	t1, _ := c.RequestTask(ctx, req)
+	t2, _ := c.RequestTask(ctx, req)
+	assert.Equal(t, mrpb.TaskType_TASK_TYPE_MAP, t1.Type)
+	assert.Equal(t, []int32{0, 1}, []int32{t1.Id, t2.Id})
+
+	// 全部分配出去後只能等
+	w, _ := c.RequestTask(ctx, req)
+	assert.Equal(t, mrpb.TaskType_TASK_TYPE_WAIT, w.Type)
+
+	// task 1 完成，task 0 逾時後被重新分配
+	_, err := c.ReportTask(ctx, &mrpb.ReportTaskRequest{Type: mrpb.TaskType_TASK_TYPE_MAP, Id: 1})
+	require.NoError(t, err)
+	clock.Advance(time.Second)
+	again, _ := c.RequestTask(ctx, req)
+	assert.Equal(t, int32(0), again.Id)
+	assert.Greater(t, again.Attempt, t1.Attempt)
+
+	resp, _ := c.ReportTask(ctx, &mrpb.ReportTaskRequest{Type: mrpb.TaskType_TASK_TYPE_MAP, Id: 0, Attempt: again.Attempt})
+	assert.True(t, resp.Accepted)
+	// 原本的 worker 遲到的回報被忽略
+	resp, _ = c.ReportTask(ctx, &mrpb.ReportTaskRequest{Type: mrpb.TaskType_TASK_TYPE_MAP, Id: 0, Attempt: t1.Attempt})
+	assert.False(t, resp.Accepted)
+
+	r, _ := c.RequestTask(ctx, req)
+	assert.Equal(t, mrpb.TaskType_TASK_TYPE_REDUCE, r.Type)
+	assert.Equal(t, int32(2), r.NMap)
+	assert.False(t, c.Done())
+	_, err = c.ReportTask(ctx, &mrpb.ReportTaskRequest{Type: mrpb.TaskType_TASK_TYPE_REDUCE, Id: 0})
+	require.NoError(t, err)
+	assert.True(t, c.Done())
+
+	e, _ := c.RequestTask(ctx, req)
+	assert.Equal(t, mrpb.TaskType_TASK_TYPE_EXIT, e.Type)
+	assert.Equal(t, Stats{MapDone: 2, ReduceDone: 1, Reassigned: 1, Stale: 1}, c.Stats())
+
+	_, err = c.ReportTask(ctx, &mrpb.ReportTaskRequest{Type: mrpb.TaskType_TASK_TYPE_MAP, Id: 5})
+	assert.Error(t, err)
+}
+
+// 沒有輸入檔或 nReduce 為 0 時一開始就完成，之後的要求都回 EXIT，重複的回報也不會 panic
+func TestCoordinatorNothingToDo(t *testing.T) {
+	ctx := context.Background()
+	req := &mrpb.RequestTaskRequest{WorkerId: "w"}
+	for name, c := range map[string]*Coordinator{
+		"no files":  NewCoordinator(nil, 2),
+		"no reduce": NewCoordinator([]string{"a", "b"}, 0),
+	} {
+		assert.True(t, c.Done(), name)
+		require.NoError(t, c.Wait(ctx), name)
+		task, err := c.RequestTask(ctx, req)
+		require.NoError(t, err, name)
+		assert.Equal(t, mrpb.TaskType_TASK_TYPE_EXIT, task.Type, name)
+
+		for typ, n := range map[mrpb.TaskType]int{mrpb.TaskType_TASK_TYPE_MAP: len(c.maps), mrpb.TaskType_TASK_TYPE_REDUCE: len(c.reduces)} {
+			for id := 0; id < n; id++ {
+				_, err := c.ReportTask(ctx, &mrpb.ReportTaskRequest{Type: typ, Id: int32(id)})
+				require.NoError(t, err, name)
+			}
+		}
+		assert.True(t, c.Done(), name)
+	}
+}
+
+func startCoordinator(t *testing.T, c *Coordinator) mrpb.CoordinatorClient {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	srv := grpc.NewServer()
+	mrpb.RegisterCoordinatorServer(srv, c)
+	go srv.Serve(lis)
+	t.Cleanup(srv.Stop)
+
+	conn, err := grpc.NewClient(lis.Addr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+	return mrpb.NewCoordinatorClient(conn)
+}
+
+// sequential 在單一 process 中跑完 map 與 reduce，作為對照答案
+func sequential(t *testing.T, files []string, app App) map[string]string {
+	groups := make(map[string][]string)
+	for _, f := range files {
+		b, err := os.ReadFile(f)
+		require.NoError(t, err)
+		for _, kv := range app.Map(f, string(b)) {
+			groups[kv.Key] = append(groups[kv.Key], kv.Value)
+		}
+	}
+	out := make(map[string]string)
+	for k, vs := range groups {
+		out[k] = app.Reduce(k, vs)
+	}
+	return out
+}
+
+func TestEndToEndWithStraggler(t *testing.T) {
+	files, err := filepath.Glob("testdata/*.txt")
+	require.NoError(t, err)
+	const nReduce = 3
+	dir := t.TempDir()
+	c := NewCoordinator(files, nReduce, WithTaskTimeout(200*time.Millisecond))
+	client := startCoordinator(t, c)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	// straggler 拿到第一個 map task 後就卡住，直到測試放行
+	took, release := make(chan struct{}), make(chan struct{})
+	var once sync.Once
+	slow := App{
+		Map: func(name, contents string) []KeyValue {
+			once.Do(func() { close(took) })
+			<-release
+			return WordCount.Map(name, contents)
+		},
+		Reduce: WordCount.Reduce,
+	}
+	var wg sync.WaitGroup
+	run := func(w *Worker) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			assert.NoError(t, w.Run(ctx))
+		}()
+	}
+	run(NewWorker(client, slow, WithDir(dir), WithID("straggler"), WithPollInterval(10*time.Millisecond)))
+	<-took
+	for i := 0; i < 2; i++ {
+		run(NewWorker(client, WordCount, WithDir(dir), WithID("w"+strconv.Itoa(i)), WithPollInterval(10*time.Millisecond)))
+	}
+
+	require.NoError(t, c.Wait(ctx))
+	got, err := ReadOutput(dir, nReduce)
+	require.NoError(t, err)
+	assert.Equal(t, sequential(t, files, WordCount), got)
+	assert.Equal(t, "5", got["the"])
+
+	close(release)
+	wg.Wait()
+	st := c.Stats()
+	assert.GreaterOrEqual(t, st.Reassigned, 1)
+	assert.Equal(t, 1, st.Stale)
+
+	// 暫存檔都已清除
+	tmp, _ := filepath.Glob(filepath.Join(dir, "*.tmp-*"))
+	assert.Empty(t, tmp)
+}
@@ -0,0 +1,502 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.34.2
+// 	protoc        (unknown)
+// source: mrpb/mapreduce.proto
+
+package mrpb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type TaskType int32
+
+const (
+	TaskType_TASK_TYPE_UNSPECIFIED TaskType = 0
+	TaskType_TASK_TYPE_MAP         TaskType = 1
+	TaskType_TASK_TYPE_REDUCE      TaskType = 2
+	// 目前沒有可分配的工作（例如 map 還沒全部完成），稍後再問
+	TaskType_TASK_TYPE_WAIT TaskType = 3
+	// 全部完成，worker 可以結束
+	TaskType_TASK_TYPE_EXIT TaskType = 4
+)
+
+// Enum value maps for TaskType.
+var (
+	TaskType_name = map[int32]string{
+		0: "TASK_TYPE_UNSPECIFIED",
+		1: "TASK_TYPE_MAP",
+		2: "TASK_TYPE_REDUCE",
+		3: "TASK_TYPE_WAIT",
+		4: "TASK_TYPE_EXIT",
+	}
+	TaskType_value = map[string]int32{
+		"TASK_TYPE_UNSPECIFIED": 0,
+		"TASK_TYPE_MAP":         1,
+		"TASK_TYPE_REDUCE":      2,
+		"TASK_TYPE_WAIT":        3,
+		"TASK_TYPE_EXIT":        4,
+	}
+)
+
+func (x TaskType) Enum() *TaskType {
+	p := new(TaskType)
+	*p = x
+	return p
+}
+
+func (x TaskType) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (TaskType) Descriptor() protoreflect.EnumDescriptor {
+	return file_mrpb_mapreduce_proto_enumTypes[0].Descriptor()
+}
+
+func (TaskType) Type() protoreflect.EnumType {
+	return &file_mrpb_mapreduce_proto_enumTypes[0]
+}
+
+func (x TaskType) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use TaskType.Descriptor instead.
+func (TaskType) EnumDescriptor() ([]byte, []int) {
+	return file_mrpb_mapreduce_proto_rawDescGZIP(), []int{0}
+}
+
+type RequestTaskRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	WorkerId string `protobuf:"bytes,1,opt,name=worker_id,json=workerId,proto3" json:"worker_id,omitempty"`
+}
+
+func (x *RequestTaskRequest) Reset() {
+	*x = RequestTaskRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_mrpb_mapreduce_proto_msgTypes[0]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *RequestTaskRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RequestTaskRequest) ProtoMessage() {}
+
+func (x *RequestTaskRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_mrpb_mapreduce_proto_msgTypes[0]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RequestTaskRequest.ProtoReflect.Descriptor instead.
+func (*RequestTaskRequest) Descriptor() ([]byte, []int) {
+	return file_mrpb_mapreduce_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *RequestTaskRequest) GetWorkerId() string {
+	if x != nil {
+		return x.WorkerId
+	}
+	return ""
+}
+
+type Task struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Type TaskType `protobuf:"varint,1,opt,name=type,proto3,enum=golearn.mapreduce.v1.TaskType" json:"type,omitempty"`
+	Id   int32    `protobuf:"varint,2,opt,name=id,proto3" json:"id,omitempty"`
+	// map task 的輸入檔
+	InputFile string `protobuf:"bytes,3,opt,name=input_file,json=inputFile,proto3" json:"input_file,omitempty"`
+	NMap      int32  `protobuf:"varint,4,opt,name=n_map,json=nMap,proto3" json:"n_map,omitempty"`
+	NReduce   int32  `protobuf:"varint,5,opt,name=n_reduce,json=nReduce,proto3" json:"n_reduce,omitempty"`
+	// 每次分配遞增，用來辨識重新分配後舊 worker 遲到的回報
+	Attempt int64 `protobuf:"varint,6,opt,name=attempt,proto3" json:"attempt,omitempty"`
+}
+
+func (x *Task) Reset() {
+	*x = Task{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_mrpb_mapreduce_proto_msgTypes[1]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Task) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Task) ProtoMessage() {}
+
+func (x *Task) ProtoReflect() protoreflect.Message {
+	mi := &file_mrpb_mapreduce_proto_msgTypes[1]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Task.ProtoReflect.Descriptor instead.
+func (*Task) Descriptor() ([]byte, []int) {
+	return file_mrpb_mapreduce_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *Task) GetType() TaskType {
+	if x != nil {
+		return x.Type
+	}
+	return TaskType_TASK_TYPE_UNSPECIFIED
+}
+
+func (x *Task) GetId() int32 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *Task) GetInputFile() string {
+	if x != nil {
+		return x.InputFile
+	}
+	return ""
+}
+
+func (x *Task) GetNMap() int32 {
+	if x != nil {
+		return x.NMap
+	}
+	return 0
+}
+
+func (x *Task) GetNReduce() int32 {
+	if x != nil {
+		return x.NReduce
+	}
+	return 0
+}
+
+func (x *Task) GetAttempt() int64 {
+	if x != nil {
+		return x.Attempt
+	}
+	return 0
+}
+
+type ReportTaskRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	WorkerId string   `protobuf:"bytes,1,opt,name=worker_id,json=workerId,proto3" json:"worker_id,omitempty"`
+	Type     TaskType `protobuf:"varint,2,opt,name=type,proto3,enum=golearn.mapreduce.v1.TaskType" json:"type,omitempty"`
+	Id       int32    `protobuf:"varint,3,opt,name=id,proto3" json:"id,omitempty"`
+	Attempt  int64    `protobuf:"varint,4,opt,name=attempt,proto3" json:"attempt,omitempty"`
+}
+
+func (x *ReportTaskRequest) Reset() {
+	*x = ReportTaskRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_mrpb_mapreduce_proto_msgTypes[2]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ReportTaskRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ReportTaskRequest) ProtoMessage() {}
+
+func (x *ReportTaskRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_mrpb_mapreduce_proto_msgTypes[2]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ReportTaskRequest.ProtoReflect.Descriptor instead.
+func (*ReportTaskRequest) Descriptor() ([]byte, []int) {
+	return file_mrpb_mapreduce_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *ReportTaskRequest) GetWorkerId() string {
+	if x != nil {
+		return x.WorkerId
+	}
+	return ""
+}
+
+func (x *ReportTaskRequest) GetType() TaskType {
+	if x != nil {
+		return x.Type
+	}
+	return TaskType_TASK_TYPE_UNSPECIFIED
+}
+
+func (x *ReportTaskRequest) GetId() int32 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *ReportTaskRequest) GetAttempt() int64 {
+	if x != nil {
+		return x.Attempt
+	}
+	return 0
+}
+
+type ReportTaskResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// false 表示這個回報已經過時（task 已由其他 worker 完成）
+	Accepted bool `protobuf:"varint,1,opt,name=accepted,proto3" json:"accepted,omitempty"`
+}
+
+func (x *ReportTaskResponse) Reset() {
+	*x = ReportTaskResponse{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_mrpb_mapreduce_proto_msgTypes[3]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ReportTaskResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ReportTaskResponse) ProtoMessage() {}
+
+func (x *ReportTaskResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_mrpb_mapreduce_proto_msgTypes[3]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ReportTaskResponse.ProtoReflect.Descriptor instead.
+func (*ReportTaskResponse) Descriptor() ([]byte, []int) {
+	return file_mrpb_mapreduce_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *ReportTaskResponse) GetAccepted() bool {
+	if x != nil {
+		return x.Accepted
+	}
+	return false
+}
+
+var File_mrpb_mapreduce_proto protoreflect.FileDescriptor
+
+var file_mrpb_mapreduce_proto_rawDesc = []byte{
+	0x0a, 0x14, 0x6d, 0x72, 0x70, 0x62, 0x2f, 0x6d, 0x61, 0x70, 0x72, 0x65, 0x64, 0x75, 0x63, 0x65,
+	0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x12, 0x14, 0x67, 0x6f, 0x6c, 0x65, 0x61, 0x72, 0x6e, 0x2e,
+	0x6d, 0x61, 0x70, 0x72, 0x65, 0x64, 0x75, 0x63, 0x65, 0x2e, 0x76, 0x31, 0x22, 0x31, 0x0a, 0x12,
+	0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x54, 0x61, 0x73, 0x6b, 0x52, 0x65, 0x71, 0x75, 0x65,
+	0x73, 0x74, 0x12, 0x1b, 0x0a, 0x09, 0x77, 0x6f, 0x72, 0x6b, 0x65, 0x72, 0x5f, 0x69, 0x64, 0x18,
+	0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x08, 0x77, 0x6f, 0x72, 0x6b, 0x65, 0x72, 0x49, 0x64, 0x22,
+	0xb3, 0x01, 0x0a, 0x04, 0x54, 0x61, 0x73, 0x6b, 0x12, 0x32, 0x0a, 0x04, 0x74, 0x79, 0x70, 0x65,
+	0x18, 0x01, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x1e, 0x2e, 0x67, 0x6f, 0x6c, 0x65, 0x61, 0x72, 0x6e,
+	0x2e, 0x6d, 0x61, 0x70, 0x72, 0x65, 0x64, 0x75, 0x63, 0x65, 0x2e, 0x76, 0x31, 0x2e, 0x54, 0x61,
+	0x73, 0x6b, 0x54, 0x79, 0x70, 0x65, 0x52, 0x04, 0x74, 0x79, 0x70, 0x65, 0x12, 0x0e, 0x0a, 0x02,
+	0x69, 0x64, 0x18, 0x02, 0x20, 0x01, 0x28, 0x05, 0x52, 0x02, 0x69, 0x64, 0x12, 0x1d, 0x0a, 0x0a,
+	0x69, 0x6e, 0x70, 0x75, 0x74, 0x5f, 0x66, 0x69, 0x6c, 0x65, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09,
+	0x52, 0x09, 0x69, 0x6e, 0x70, 0x75, 0x74, 0x46, 0x69, 0x6c, 0x65, 0x12, 0x13, 0x0a, 0x05, 0x6e,
+	0x5f, 0x6d, 0x61, 0x70, 0x18, 0x04, 0x20, 0x01, 0x28, 0x05, 0x52, 0x04, 0x6e, 0x4d, 0x61, 0x70,
+	0x12, 0x19, 0x0a, 0x08, 0x6e, 0x5f, 0x72, 0x65, 0x64, 0x75, 0x63, 0x65, 0x18, 0x05, 0x20, 0x01,
+	0x28, 0x05, 0x52, 0x07, 0x6e, 0x52, 0x65, 0x64, 0x75, 0x63, 0x65, 0x12, 0x18, 0x0a, 0x07, 0x61,
+	0x74, 0x74, 0x65, 0x6d, 0x70, 0x74, 0x18, 0x06, 0x20, 0x01, 0x28, 0x03, 0x52, 0x07, 0x61, 0x74,
+	0x74, 0x65, 0x6d, 0x70, 0x74, 0x22, 0x8e, 0x01, 0x0a, 0x11, 0x52, 0x65, 0x70, 0x6f, 0x72, 0x74,
+	0x54, 0x61, 0x73, 0x6b, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x1b, 0x0a, 0x09, 0x77,
+	0x6f, 0x72, 0x6b, 0x65, 0x72, 0x5f, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x08,
+	0x77, 0x6f, 0x72, 0x6b, 0x65, 0x72, 0x49, 0x64, 0x12, 0x32, 0x0a, 0x04, 0x74, 0x79, 0x70, 0x65,
+	0x18, 0x02, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x1e, 0x2e, 0x67, 0x6f, 0x6c, 0x65, 0x61, 0x72, 0x6e,
+	0x2e, 0x6d, 0x61, 0x70, 0x72, 0x65, 0x64, 0x75, 0x63, 0x65, 0x2e, 0x76, 0x31, 0x2e, 0x54, 0x61,
+	0x73, 0x6b, 0x54, 0x79, 0x70, 0x65, 0x52, 0x04, 0x74, 0x79, 0x70, 0x65, 0x12, 0x0e, 0x0a, 0x02,
+	0x69, 0x64, 0x18, 0x03, 0x20, 0x01, 0x28, 0x05, 0x52, 0x02, 0x69, 0x64, 0x12, 0x18, 0x0a, 0x07,
+	0x61, 0x74, 0x74, 0x65, 0x6d, 0x70, 0x74, 0x18, 0x04, 0x20, 0x01, 0x28, 0x03, 0x52, 0x07, 0x61,
+	0x74, 0x74, 0x65, 0x6d, 0x70, 0x74, 0x22, 0x30, 0x0a, 0x12, 0x52, 0x65, 0x70, 0x6f, 0x72, 0x74,
+	0x54, 0x61, 0x73, 0x6b, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x1a, 0x0a, 0x08,
+	0x61, 0x63, 0x63, 0x65, 0x70, 0x74, 0x65, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x08, 0x52, 0x08,
+	0x61, 0x63, 0x63, 0x65, 0x70, 0x74, 0x65, 0x64, 0x2a, 0x76, 0x0a, 0x08, 0x54, 0x61, 0x73, 0x6b,
+	0x54, 0x79, 0x70, 0x65, 0x12, 0x19, 0x0a, 0x15, 0x54, 0x41, 0x53, 0x4b, 0x5f, 0x54, 0x59, 0x50,
+	0x45, 0x5f, 0x55, 0x4e, 0x53, 0x50, 0x45, 0x43, 0x49, 0x46, 0x49, 0x45, 0x44, 0x10, 0x00, 0x12,
+	0x11, 0x0a, 0x0d, 0x54, 0x41, 0x53, 0x4b, 0x5f, 0x54, 0x59, 0x50, 0x45, 0x5f, 0x4d, 0x41, 0x50,
+	0x10, 0x01, 0x12, 0x14, 0x0a, 0x10, 0x54, 0x41, 0x53, 0x4b, 0x5f, 0x54, 0x59, 0x50, 0x45, 0x5f,
+	0x52, 0x45, 0x44, 0x55, 0x43, 0x45, 0x10, 0x02, 0x12, 0x12, 0x0a, 0x0e, 0x54, 0x41, 0x53, 0x4b,
+	0x5f, 0x54, 0x59, 0x50, 0x45, 0x5f, 0x57, 0x41, 0x49, 0x54, 0x10, 0x03, 0x12, 0x12, 0x0a, 0x0e,
+	0x54, 0x41, 0x53, 0x4b, 0x5f, 0x54, 0x59, 0x50, 0x45, 0x5f, 0x45, 0x58, 0x49, 0x54, 0x10, 0x04,
+	0x32, 0xc3, 0x01, 0x0a, 0x0b, 0x43, 0x6f, 0x6f, 0x72, 0x64, 0x69, 0x6e, 0x61, 0x74, 0x6f, 0x72,
+	0x12, 0x53, 0x0a, 0x0b, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x54, 0x61, 0x73, 0x6b, 0x12,
+	0x28, 0x2e, 0x67, 0x6f, 0x6c, 0x65, 0x61, 0x72, 0x6e, 0x2e, 0x6d, 0x61, 0x70, 0x72, 0x65, 0x64,
+	0x75, 0x63, 0x65, 0x2e, 0x76, 0x31, 0x2e, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x54, 0x61,
+	0x73, 0x6b, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x1a, 0x2e, 0x67, 0x6f, 0x6c, 0x65,
+	0x61, 0x72, 0x6e, 0x2e, 0x6d, 0x61, 0x70, 0x72, 0x65, 0x64, 0x75, 0x63, 0x65, 0x2e, 0x76, 0x31,
+	0x2e, 0x54, 0x61, 0x73, 0x6b, 0x12, 0x5f, 0x0a, 0x0a, 0x52, 0x65, 0x70, 0x6f, 0x72, 0x74, 0x54,
+	0x61, 0x73, 0x6b, 0x12, 0x27, 0x2e, 0x67, 0x6f, 0x6c, 0x65, 0x61, 0x72, 0x6e, 0x2e, 0x6d, 0x61,
+	0x70, 0x72, 0x65, 0x64, 0x75, 0x63, 0x65, 0x2e, 0x76, 0x31, 0x2e, 0x52, 0x65, 0x70, 0x6f, 0x72,
+	0x74, 0x54, 0x61, 0x73, 0x6b, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x28, 0x2e, 0x67,
+	0x6f, 0x6c, 0x65, 0x61, 0x72, 0x6e, 0x2e, 0x6d, 0x61, 0x70, 0x72, 0x65, 0x64, 0x75, 0x63, 0x65,
+	0x2e, 0x76, 0x31, 0x2e, 0x52, 0x65, 0x70, 0x6f, 0x72, 0x74, 0x54, 0x61, 0x73, 0x6b, 0x52, 0x65,
+	0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x42, 0x26, 0x5a, 0x24, 0x61, 0x64, 0x76, 0x61, 0x6e, 0x63,
+	0x65, 0x64, 0x2f, 0x64, 0x69, 0x73, 0x74, 0x72, 0x69, 0x62, 0x2f, 0x6d, 0x61, 0x70, 0x72, 0x65,
+	0x64, 0x75, 0x63, 0x65, 0x2f, 0x6d, 0x72, 0x70, 0x62, 0x3b, 0x6d, 0x72, 0x70, 0x62, 0x62, 0x06,
+	0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
+}
+
+var (
+	file_mrpb_mapreduce_proto_rawDescOnce sync.Once
+	file_mrpb_mapreduce_proto_rawDescData = file_mrpb_mapreduce_proto_rawDesc
+)
+
+func file_mrpb_mapreduce_proto_rawDescGZIP() []byte {
+	file_mrpb_mapreduce_proto_rawDescOnce.Do(func() {
+		file_mrpb_mapreduce_proto_rawDescData = protoimpl.X.CompressGZIP(file_mrpb_mapreduce_proto_rawDescData)
+	})
+	return file_mrpb_mapreduce_proto_rawDescData
+}
+
+var file_mrpb_mapreduce_proto_enumTypes = make([]protoimpl.EnumInfo, 1)
+var file_mrpb_mapreduce_proto_msgTypes = make([]protoimpl.MessageInfo, 4)
+var file_mrpb_mapreduce_proto_goTypes = []any{
+	(TaskType)(0),              // 0: golearn.mapreduce.v1.TaskType
+	(*RequestTaskRequest)(nil), // 1: golearn.mapreduce.v1.RequestTaskRequest
+	(*Task)(nil),               // 2: golearn.mapreduce.v1.Task
+	(*ReportTaskRequest)(nil),  // 3: golearn.mapreduce.v1.ReportTaskRequest
+	(*ReportTaskResponse)(nil), // 4: golearn.mapreduce.v1.ReportTaskResponse
+}
+var file_mrpb_mapreduce_proto_depIdxs = []int32{
+	0, // 0: golearn.mapreduce.v1.Task.type:type_name -> golearn.mapreduce.v1.TaskType
+	0, // 1: golearn.mapreduce.v1.ReportTaskRequest.type:type_name -> golearn.mapreduce.v1.TaskType
+	1, // 2: golearn.mapreduce.v1.Coordinator.RequestTask:input_type -> golearn.mapreduce.v1.RequestTaskRequest
+	3, // 3: golearn.mapreduce.v1.Coordinator.ReportTask:input_type -> golearn.mapreduce.v1.ReportTaskRequest
+	2, // 4: golearn.mapreduce.v1.Coordinator.RequestTask:output_type -> golearn.mapreduce.v1.Task
+	4, // 5: golearn.mapreduce.v1.Coordinator.ReportTask:output_type -> golearn.mapreduce.v1.ReportTaskResponse
+	4, // [4:6] is the sub-list for method output_type
+	2, // [2:4] is the sub-list for method input_type
+	2, // [2:2] is the sub-list for extension type_name
+	2, // [2:2] is the sub-list for extension extendee
+	0, // [0:2] is the sub-list for field type_name
+}
+
+func init() { file_mrpb_mapreduce_proto_init() }
+func file_mrpb_mapreduce_proto_init() {
+	if File_mrpb_mapreduce_proto != nil {
+		return
+	}
+	if !protoimpl.UnsafeEnabled {
+		file_mrpb_mapreduce_proto_msgTypes[0].Exporter = func(v any, i int) any {
+			switch v := v.(*RequestTaskRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_mrpb_mapreduce_proto_msgTypes[1].Exporter = func(v any, i int) any {
+			switch v := v.(*Task); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_mrpb_mapreduce_proto_msgTypes[2].Exporter = func(v any, i int) any {
+			switch v := v.(*ReportTaskRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_mrpb_mapreduce_proto_msgTypes[3].Exporter = func(v any, i int) any {
+			switch v := v.(*ReportTaskResponse); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: file_mrpb_mapreduce_proto_rawDesc,
+			NumEnums:      1,
+			NumMessages:   4,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_mrpb_mapreduce_proto_goTypes,
+		DependencyIndexes: file_mrpb_mapreduce_proto_depIdxs,
+		EnumInfos:         file_mrpb_mapreduce_proto_enumTypes,
+		MessageInfos:      file_mrpb_mapreduce_proto_msgTypes,
+	}.Build()
+	File_mrpb_mapreduce_proto = out.File
+	file_mrpb_mapreduce_proto_rawDesc = nil
+	file_mrpb_mapreduce_proto_goTypes = nil
+	file_mrpb_mapreduce_proto_depIdxs = nil
+}
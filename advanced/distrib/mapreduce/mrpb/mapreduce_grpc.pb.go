@@ -0,0 +1,163 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             (unknown)
+// source: mrpb/mapreduce.proto
+
+package mrpb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	Coordinator_RequestTask_FullMethodName = "/golearn.mapreduce.v1.Coordinator/RequestTask"
+	Coordinator_ReportTask_FullMethodName  = "/golearn.mapreduce.v1.Coordinator/ReportTask"
+)
+
+// CoordinatorClient is the client API for Coordinator service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// worker 主動向 coordinator 要工作（pull），coordinator 不需要知道 worker 的位址
+type CoordinatorClient interface {
+	RequestTask(ctx context.Context, in *RequestTaskRequest, opts ...grpc.CallOption) (*Task, error)
+	ReportTask(ctx context.Context, in *ReportTaskRequest, opts ...grpc.CallOption) (*ReportTaskResponse, error)
+}
+
+type coordinatorClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewCoordinatorClient(cc grpc.ClientConnInterface) CoordinatorClient {
+	return &coordinatorClient{cc}
+}
+
+func (c *coordinatorClient) RequestTask(ctx context.Context, in *RequestTaskRequest, opts ...grpc.CallOption) (*Task, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Task)
+	err := c.cc.Invoke(ctx, Coordinator_RequestTask_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *coordinatorClient) ReportTask(ctx context.Context, in *ReportTaskRequest, opts ...grpc.CallOption) (*ReportTaskResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ReportTaskResponse)
+	err := c.cc.Invoke(ctx, Coordinator_ReportTask_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// CoordinatorServer is the server API for Coordinator service.
+// All implementations must embed UnimplementedCoordinatorServer
+// for forward compatibility.
+//
+// worker 主動向 coordinator 要工作（pull），coordinator 不需要知道 worker 的位址
+type CoordinatorServer interface {
+	RequestTask(context.Context, *RequestTaskRequest) (*Task, error)
+	ReportTask(context.Context, *ReportTaskRequest) (*ReportTaskResponse, error)
+	mustEmbedUnimplementedCoordinatorServer()
+}
+
+// UnimplementedCoordinatorServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedCoordinatorServer struct{}
+
+func (UnimplementedCoordinatorServer) RequestTask(context.Context, *RequestTaskRequest) (*Task, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RequestTask not implemented")
+}
+func (UnimplementedCoordinatorServer) ReportTask(context.Context, *ReportTaskRequest) (*ReportTaskResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ReportTask not implemented")
+}
+func (UnimplementedCoordinatorServer) mustEmbedUnimplementedCoordinatorServer() {}
+func (UnimplementedCoordinatorServer) testEmbeddedByValue()                     {}
+
+// UnsafeCoordinatorServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to CoordinatorServer will
+// result in compilation errors.
+type UnsafeCoordinatorServer interface {
+	mustEmbedUnimplementedCoordinatorServer()
+}
+
+func RegisterCoordinatorServer(s grpc.ServiceRegistrar, srv CoordinatorServer) {
+	// If the following call pancis, it indicates UnimplementedCoordinatorServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&Coordinator_ServiceDesc, srv)
+}
+
+func _Coordinator_RequestTask_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RequestTaskRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CoordinatorServer).RequestTask(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Coordinator_RequestTask_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CoordinatorServer).RequestTask(ctx, req.(*RequestTaskRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Coordinator_ReportTask_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ReportTaskRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CoordinatorServer).ReportTask(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Coordinator_ReportTask_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CoordinatorServer).ReportTask(ctx, req.(*ReportTaskRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// Coordinator_ServiceDesc is the grpc.ServiceDesc for Coordinator service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var Coordinator_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "golearn.mapreduce.v1.Coordinator",
+	HandlerType: (*CoordinatorServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "RequestTask",
+			Handler:    _Coordinator_RequestTask_Handler,
+		},
+		{
+			MethodName: "ReportTask",
+			Handler:    _Coordinator_ReportTask_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "mrpb/mapreduce.proto",
+}
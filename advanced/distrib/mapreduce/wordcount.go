@@ -0,0 +1,22 @@
+package mapreduce
+
+import (
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// WordCount 是經典範例：map 對每個單字輸出 (word, "1")，reduce 計算個數
+var WordCount = App{
+	Map: func(_ string, contents string) []KeyValue {
+		words := strings.FieldsFunc(contents, func(r rune) bool { return !unicode.IsLetter(r) })
+		kvs := make([]KeyValue, len(words))
+		for i, w := range words {
+			kvs[i] = KeyValue{Key: strings.ToLower(w), Value: "1"}
+		}
+		return kvs
+	},
+	Reduce: func(_ string, values []string) string {
+		return strconv.Itoa(len(values))
+	},
+}
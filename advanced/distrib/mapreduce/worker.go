@@ -0,0 +1,218 @@
+package mapreduce
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"advanced/distrib/mapreduce/mrpb"
+	"advanced/timex"
+)
+
+type KeyValue struct {
+	Key   string
+	Value string
+}
+
+// App 是使用者提供的 map 與 reduce 函式
+type App struct {
+	Map    func(filename, contents string) []KeyValue
+	Reduce func(key string, values []string) string
+}
+
+type Worker struct {
+	client mrpb.CoordinatorClient
+	app    App
+	id     string
+	dir    string
+	poll   time.Duration
+}
+
+type WorkerOption func(*Worker)
+
+// WithDir 設定中間檔與輸出檔的目錄，所有 worker 必須共用同一個目錄，預設為目前目錄
+func WithDir(dir string) WorkerOption {
+	return func(w *Worker) { w.dir = dir }
+}
+
+func WithID(id string) WorkerOption {
+	return func(w *Worker) { w.id = id }
+}
+
+// WithPollInterval 設定收到 WAIT 後多久再詢問，預設 100ms
+func WithPollInterval(d time.Duration) WorkerOption {
+	return func(w *Worker) { w.poll = d }
+}
+
+func NewWorker(client mrpb.CoordinatorClient, app App, opts ...WorkerOption) *Worker {
+	w := &Worker{client: client, app: app, dir: ".", poll: 100 * time.Millisecond}
+	for _, o := range opts {
+		o(w)
+	}
+	if w.id == "" {
+		host, _ := os.Hostname()
+		w.id = fmt.Sprintf("%s-%d", host, os.Getpid())
+	}
+	return w
+}
+
+// Run 持續拉取並執行 task，直到 coordinator 回覆 EXIT 或 ctx 結束
+func (w *Worker) Run(ctx context.Context) error {
+	for {
+		t, err := w.client.RequestTask(ctx, &mrpb.RequestTaskRequest{WorkerId: w.id})
+		if err != nil {
+			return fmt.Errorf("mapreduce: request task: %w", err)
+		}
+		switch t.Type {
+		case mrpb.TaskType_TASK_TYPE_MAP:
+			err = w.doMap(t)
+		case mrpb.TaskType_TASK_TYPE_REDUCE:
+			err = w.doReduce(t)
+		case mrpb.TaskType_TASK_TYPE_EXIT:
+			return nil
+		default:
+			if err := timex.Sleep(ctx, w.poll); err != nil {
+				return err
+			}
+			continue
+		}
+		if err != nil {
+			// 不回報失敗：task 逾時後 coordinator 會交給別人重做
+			return fmt.Errorf("mapreduce: %v task %d: %w", t.Type, t.Id, err)
+		}
+		_, err = w.client.ReportTask(ctx, &mrpb.ReportTaskRequest{WorkerId: w.id, Type: t.Type, Id: t.Id, Attempt: t.Attempt})
+		if err != nil {
+			return fmt.Errorf("mapreduce: report task: %w", err)
+		}
+	}
+}
+
+func intermediateName(m, r int) string { return fmt.Sprintf("mr-%d-%d", m, r) }
+
+// OutputName 是 reduce task r 的輸出檔名
+func OutputName(r int) string { return fmt.Sprintf("mr-out-%d", r) }
+
+func ihash(key string) int {
+	h := fnv.New32a()
+	h.Write([]byte(key))
+	return int(h.Sum32() & 0x7fffffff)
+}
+
+func (w *Worker) doMap(t *mrpb.Task) error {
+	content, err := os.ReadFile(t.InputFile)
+	if err != nil {
+		return err
+	}
+	buckets := make([][]KeyValue, t.NReduce)
+	for _, kv := range w.app.Map(t.InputFile, string(content)) {
+		r := ihash(kv.Key) % int(t.NReduce)
+		buckets[r] = append(buckets[r], kv)
+	}
+	for r, kvs := range buckets {
+		err := w.writeAtomic(intermediateName(int(t.Id), r), func(f io.Writer) error {
+			enc := json.NewEncoder(f)
+			for _, kv := range kvs {
+				if err := enc.Encode(kv); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (w *Worker) doReduce(t *mrpb.Task) error {
+	var kvs []KeyValue
+	for m := 0; m < int(t.NMap); m++ {
+		f, err := os.Open(filepath.Join(w.dir, intermediateName(m, int(t.Id))))
+		if err != nil {
+			return err
+		}
+		dec := json.NewDecoder(f)
+		for {
+			var kv KeyValue
+			if err := dec.Decode(&kv); err == io.EOF {
+				break
+			} else if err != nil {
+				f.Close()
+				return err
+			}
+			kvs = append(kvs, kv)
+		}
+		f.Close()
+	}
+	sort.SliceStable(kvs, func(i, j int) bool { return kvs[i].Key < kvs[j].Key })
+
+	return w.writeAtomic(OutputName(int(t.Id)), func(f io.Writer) error {
+		for i := 0; i < len(kvs); {
+			j := i
+			var values []string
+			for ; j < len(kvs) && kvs[j].Key == kvs[i].Key; j++ {
+				values = append(values, kvs[j].Value)
+			}
+			if _, err := fmt.Fprintf(f, "%s %s\n", kvs[i].Key, w.app.Reduce(kvs[i].Key, values)); err != nil {
+				return err
+			}
+			i = j
+		}
+		return nil
+	})
+}
+
+// writeAtomic 先寫暫存檔再 rename，重複執行的 task 不會讓讀取端看到寫到一半的檔案
+func (w *Worker) writeAtomic(name string, write func(io.Writer) error) error {
+	tmp, err := os.CreateTemp(w.dir, name+".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	bw := bufio.NewWriter(tmp)
+	if err := write(bw); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := bw.Flush(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), filepath.Join(w.dir, name))
+}
+
+// ReadOutput 讀取所有 mr-out-* 並合併成 map，方便檢查結果
+func ReadOutput(dir string, nReduce int) (map[string]string, error) {
+	out := make(map[string]string)
+	for r := 0; r < nReduce; r++ {
+		f, err := os.Open(filepath.Join(dir, OutputName(r)))
+		if err != nil {
+			return nil, err
+		}
+		sc := bufio.NewScanner(f)
+		for sc.Scan() {
+			var k, v string
+			if _, err := fmt.Sscan(sc.Text(), &k, &v); err != nil {
+				f.Close()
+				return nil, fmt.Errorf("%s: %w", OutputName(r), err)
+			}
+			out[k] = v
+		}
+		f.Close()
+		if err := sc.Err(); err != nil {
+			return nil, err
+		}
+	}
+	return out, nil
+}
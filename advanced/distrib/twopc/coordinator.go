@@ -0,0 +1,349 @@
+package twopc
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+	"time"
+)
+
+/*
+Two-phase commit：
+
+	phase 1  coordinator ──prepare──> participants      每個參與者寫入 prepared 記錄後投 yes，或投 no
+	         coordinator <──vote────
+	決議     全部 yes 才 commit，否則 abort；決議先寫入 coordinator 的 log 才算成立
+	phase 2  coordinator ──commit/abort──> participants  重送直到全部 ack，最後寫入 end
+
+當機處理：
+  - participant 重啟：從 log 重建；prepared 但不知道結果的交易定期向 coordinator 詢問（query）
+  - coordinator 重啟：有決議但沒有 end 的交易重送決議；有 begin 但沒有決議的交易一律 abort（presumed abort）
+  - coordinator 在決議前當機：已投 yes 的參與者不能自行決定，只能鎖著資源等待 —— 這就是 2PC 會 block 的原因
+*/
+
+var (
+	ErrCrashed = errors.New("twopc: coordinator crashed")
+	ErrTxExist = errors.New("twopc: transaction already exists")
+)
+
+type Outcome int
+
+const (
+	Committed Outcome = iota + 1
+	Aborted
+)
+
+func (o Outcome) String() string {
+	switch o {
+	case Committed:
+		return "committed"
+	case Aborted:
+		return "aborted"
+	}
+	return "unknown"
+}
+
+// CrashPoint 是可以注入當機的位置，用來測試 coordinator 失敗的情境
+type CrashPoint int
+
+const (
+	CrashAfterPrepare  CrashPoint = iota + 1 // 已送出 prepare，尚未決議
+	CrashAfterDecision                       // 決議已寫入 log，尚未通知參與者
+)
+
+type Coordinator struct {
+	id      string
+	net     *Network
+	inbox   <-chan Message
+	log     Log
+	timeout time.Duration
+	retry   time.Duration
+	crashAt func(CrashPoint, string) bool
+
+	mu        sync.Mutex
+	active    map[string]chan Message // 進行中的交易，Run 把 vote / ack 轉給它
+	decisions map[string]Outcome
+	halted    chan struct{}
+	haltOnce  sync.Once
+	wg        sync.WaitGroup
+
+	unfinished []unfinished
+}
+
+type Option func(*Coordinator)
+
+// WithVoteTimeout 設定等待投票的時間，逾時視為 no，預設 200ms
+func WithVoteTimeout(d time.Duration) Option {
+	return func(c *Coordinator) { c.timeout = d }
+}
+
+// WithRetry 設定重送 commit / abort 的間隔，預設 50ms
+func WithRetry(d time.Duration) Option {
+	return func(c *Coordinator) { c.retry = d }
+}
+
+// WithCrash 在 f 回傳 true 的位置模擬當機：Commit 回傳 ErrCrashed，Run 停止
+func WithCrash(f func(p CrashPoint, txID string) bool) Option {
+	return func(c *Coordinator) { c.crashAt = f }
+}
+
+// NewCoordinator 從 log 重建狀態：有決議但沒有 end 的交易會在 Run 時重送決議，
+// 沒有決議的交易直接記為 abort
+func NewCoordinator(id string, net *Network, log Log, opts ...Option) *Coordinator {
+	c := &Coordinator{
+		id:        id,
+		net:       net,
+		inbox:     net.Register(id),
+		log:       log,
+		timeout:   200 * time.Millisecond,
+		retry:     50 * time.Millisecond,
+		crashAt:   func(CrashPoint, string) bool { return false },
+		active:    make(map[string]chan Message),
+		decisions: make(map[string]Outcome),
+		halted:    make(chan struct{}),
+	}
+	for _, o := range opts {
+		o(c)
+	}
+	c.recover()
+	return c
+}
+
+// Run 先完成 log 中未結束的交易，再處理訊息；模擬當機後回傳 ErrCrashed
+func (c *Coordinator) Run(ctx context.Context) error {
+	defer c.wg.Wait()
+	for _, u := range c.unfinished {
+		ch := c.register(u.tx)
+		c.wg.Add(1)
+		go func(u unfinished) {
+			defer c.wg.Done()
+			defer c.unregister(u.tx)
+			_ = c.finish(ctx, u.tx, u.parts, c.decision(u.tx), ch)
+		}(u)
+	}
+	c.unfinished = nil
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-c.halted:
+			return ErrCrashed
+		case m := <-c.inbox:
+			c.handle(m)
+		}
+	}
+}
+
+type unfinished struct {
+	tx    string
+	parts []string
+}
+
+// recover 從 log 找出有 begin 但沒有 end 的交易，必須在接受新交易前完成
+func (c *Coordinator) recover() {
+	begun := make(map[string][]string)
+	var order []string
+	ended := make(map[string]bool)
+	for _, r := range c.log.Records() {
+		switch r.Type {
+		case RecBegin:
+			begun[r.TxID] = r.Participants
+			order = append(order, r.TxID)
+		case RecCommit:
+			c.decisions[r.TxID] = Committed
+		case RecAbort:
+			c.decisions[r.TxID] = Aborted
+		case RecEnd:
+			ended[r.TxID] = true
+		}
+	}
+	for _, tx := range order {
+		if ended[tx] {
+			continue
+		}
+		if _, ok := c.decisions[tx]; !ok {
+			if err := c.log.Append(Record{Type: RecAbort, TxID: tx}); err != nil {
+				continue
+			}
+			c.decisions[tx] = Aborted
+		}
+		c.unfinished = append(c.unfinished, unfinished{tx: tx, parts: begun[tx]})
+	}
+}
+
+func (c *Coordinator) decision(tx string) Outcome {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.decisions[tx]
+}
+
+func (c *Coordinator) handle(m Message) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	switch m.Type {
+	case MsgVote, MsgAck:
+		if ch, ok := c.active[m.TxID]; ok {
+			select {
+			case ch <- m:
+			default:
+			}
+		}
+	case MsgQuery:
+		if d, ok := c.decisions[m.TxID]; ok {
+			c.send(Message{Type: decisionMsg(d), To: m.From, TxID: m.TxID})
+		} else if _, running := c.active[m.TxID]; !running {
+			// 沒有任何記錄的交易一定沒有 commit（presumed abort）
+			c.send(Message{Type: MsgAbort, To: m.From, TxID: m.TxID})
+		}
+	}
+}
+
+func decisionMsg(o Outcome) MsgType {
+	if o == Committed {
+		return MsgCommit
+	}
+	return MsgAbort
+}
+
+func (c *Coordinator) send(m Message) {
+	select {
+	case <-c.halted:
+		return // 當機後什麼都送不出去
+	default:
+	}
+	m.From = c.id
+	c.net.Send(m)
+}
+
+func (c *Coordinator) halt() {
+	c.haltOnce.Do(func() { close(c.halted) })
+}
+
+func (c *Coordinator) register(tx string) chan Message {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	ch := make(chan Message, 64)
+	c.active[tx] = ch
+	return ch
+}
+
+func (c *Coordinator) unregister(tx string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.active, tx)
+}
+
+// Commit 以 2PC 提交交易，writes 是每個參與者要寫入的資料；Run 必須已在執行。
+// 決議寫入 log 之後結果就確定了；若 ctx 在收齊 ack 前結束，會同時回傳結果與 ctx 的錯誤，
+// 剩下的通知在 coordinator 重啟時完成。
+func (c *Coordinator) Commit(ctx context.Context, txID string, writes map[string]map[string]string) (Outcome, error) {
+	parts := make([]string, 0, len(writes))
+	for p := range writes {
+		parts = append(parts, p)
+	}
+	sort.Strings(parts)
+
+	c.mu.Lock()
+	_, running := c.active[txID]
+	_, decided := c.decisions[txID]
+	c.mu.Unlock()
+	if running || decided {
+		return 0, ErrTxExist
+	}
+	if err := c.log.Append(Record{Type: RecBegin, TxID: txID, Participants: parts}); err != nil {
+		return 0, err
+	}
+	ch := c.register(txID)
+	defer c.unregister(txID)
+
+	// phase 1
+	for _, p := range parts {
+		c.send(Message{Type: MsgPrepare, To: p, TxID: txID, Writes: writes[p]})
+	}
+	if c.crashAt(CrashAfterPrepare, txID) {
+		c.halt()
+		return 0, ErrCrashed
+	}
+	decision := c.collectVotes(ctx, ch, parts)
+
+	if err := c.log.Append(Record{Type: recordOf(decision), TxID: txID}); err != nil {
+		return 0, err
+	}
+	c.mu.Lock()
+	c.decisions[txID] = decision
+	c.mu.Unlock()
+	if c.crashAt(CrashAfterDecision, txID) {
+		c.halt()
+		return 0, ErrCrashed
+	}
+
+	// phase 2
+	return decision, c.finish(ctx, txID, parts, decision, ch)
+}
+
+func recordOf(o Outcome) RecordType {
+	if o == Committed {
+		return RecCommit
+	}
+	return RecAbort
+}
+
+func (c *Coordinator) collectVotes(ctx context.Context, ch <-chan Message, parts []string) Outcome {
+	timer := time.NewTimer(c.timeout)
+	defer timer.Stop()
+	yes := make(map[string]bool)
+	for len(yes) < len(parts) {
+		select {
+		case m := <-ch:
+			if m.Type != MsgVote {
+				continue
+			}
+			if !m.Yes {
+				return Aborted
+			}
+			yes[m.From] = true
+		case <-timer.C:
+			return Aborted
+		case <-ctx.Done():
+			return Aborted
+		case <-c.halted:
+			return Aborted
+		}
+	}
+	return Committed
+}
+
+// finish 重送決議直到所有參與者 ack，然後寫入 end
+func (c *Coordinator) finish(ctx context.Context, tx string, parts []string, d Outcome, ch <-chan Message) error {
+	pending := make(map[string]bool)
+	for _, p := range parts {
+		pending[p] = true
+	}
+	ticker := time.NewTicker(c.retry)
+	defer ticker.Stop()
+	for len(pending) > 0 {
+		for p := range pending {
+			c.send(Message{Type: decisionMsg(d), To: p, TxID: tx})
+		}
+	wait:
+		for {
+			select {
+			case m := <-ch:
+				if m.Type == MsgAck {
+					delete(pending, m.From)
+					if len(pending) == 0 {
+						break wait
+					}
+				}
+			case <-ticker.C:
+				break wait
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-c.halted:
+				return ErrCrashed
+			}
+		}
+	}
+	return c.log.Append(Record{Type: RecEnd, TxID: tx})
+}
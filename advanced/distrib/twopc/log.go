@@ -0,0 +1,44 @@
+package twopc
+
+import "sync"
+
+type RecordType int
+
+const (
+	RecBegin    RecordType = iota // coordinator：交易開始，記下參與者
+	RecPrepared                   // participant：已投 yes，記下要寫入的資料（redo）
+	RecCommit                     // 決議 / 結果為 commit
+	RecAbort                      // 決議 / 結果為 abort
+	RecEnd                        // coordinator：所有參與者都已 ack，之後可以忘記這筆交易
+)
+
+type Record struct {
+	Type         RecordType
+	TxID         string
+	Participants []string
+	Writes       map[string]string
+}
+
+// Log 代表寫入後就不會遺失的儲存（實務上是 fsync 過的檔案），節點「當機」後用同一個 Log 重建狀態
+type Log interface {
+	Append(r Record) error
+	Records() []Record
+}
+
+type MemLog struct {
+	mu      sync.Mutex
+	records []Record
+}
+
+func (l *MemLog) Append(r Record) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.records = append(l.records, r)
+	return nil
+}
+
+func (l *MemLog) Records() []Record {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return append([]Record(nil), l.records...)
+}
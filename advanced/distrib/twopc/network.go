@@ -0,0 +1,74 @@
+package twopc
+
+import "sync"
+
+type MsgType int
+
+const (
+	MsgPrepare MsgType = iota
+	MsgVote
+	MsgCommit
+	MsgAbort
+	MsgAck
+	MsgQuery // participant 詢問交易結果
+)
+
+func (t MsgType) String() string {
+	return [...]string{"prepare", "vote", "commit", "abort", "ack", "query"}[t]
+}
+
+type Message struct {
+	Type   MsgType
+	From   string
+	To     string
+	TxID   string
+	Writes map[string]string // MsgPrepare：這個參與者要寫入的資料
+	Yes    bool              // MsgVote
+}
+
+// Network 以 channel 連接各節點，可以讓節點斷線或依條件丟掉訊息
+type Network struct {
+	mu      sync.Mutex
+	inboxes map[string]chan Message
+	down    map[string]bool
+	drop    func(Message) bool
+}
+
+func NewNetwork() *Network {
+	return &Network{inboxes: make(map[string]chan Message), down: make(map[string]bool)}
+}
+
+// Register 建立（或在重啟時替換）節點的 inbox
+func (n *Network) Register(id string) <-chan Message {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	ch := make(chan Message, 64)
+	n.inboxes[id] = ch
+	return ch
+}
+
+// SetDown 讓節點斷線：送給它與它送出的訊息都會遺失
+func (n *Network) SetDown(id string, down bool) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.down[id] = down
+}
+
+// SetDrop 設定丟棄訊息的條件，nil 表示不丟
+func (n *Network) SetDrop(f func(Message) bool) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.drop = f
+}
+
+func (n *Network) Send(m Message) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if n.down[m.From] || n.down[m.To] || (n.drop != nil && n.drop(m)) {
+		return
+	}
+	select {
+	case n.inboxes[m.To] <- m:
+	default:
+	}
+}
@@ -0,0 +1,201 @@
+package twopc
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+	"time"
+)
+
+var ErrLocked = errors.New("twopc: key locked by another transaction")
+
+type txState int
+
+const (
+	prepared txState = iota + 1
+	committed
+	aborted
+)
+
+type Participant struct {
+	id          string
+	coordinator string
+	net         *Network
+	inbox       <-chan Message
+	log         Log
+	validate    func(writes map[string]string) error
+	retry       time.Duration
+
+	mu    sync.Mutex
+	data  map[string]string
+	locks map[string]string // key -> 持有鎖的交易
+	txs   map[string]txState
+	redo  map[string]map[string]string
+}
+
+type ParticipantOption func(*Participant)
+
+// WithValidate 設定 prepare 時的檢查，回傳錯誤就投 no
+func WithValidate(f func(writes map[string]string) error) ParticipantOption {
+	return func(p *Participant) { p.validate = f }
+}
+
+// WithQueryInterval 設定 prepared 狀態下多久向 coordinator 詢問一次結果，預設 50ms
+func WithQueryInterval(d time.Duration) ParticipantOption {
+	return func(p *Participant) { p.retry = d }
+}
+
+// NewParticipant 從 log 重建狀態：已 commit 的資料重新套用，prepared 但沒有結果的交易維持 in-doubt 並鎖住資源
+func NewParticipant(id, coordinator string, net *Network, log Log, opts ...ParticipantOption) *Participant {
+	p := &Participant{
+		id:          id,
+		coordinator: coordinator,
+		net:         net,
+		inbox:       net.Register(id),
+		log:         log,
+		validate:    func(map[string]string) error { return nil },
+		retry:       50 * time.Millisecond,
+		data:        make(map[string]string),
+		locks:       make(map[string]string),
+		txs:         make(map[string]txState),
+		redo:        make(map[string]map[string]string),
+	}
+	for _, o := range opts {
+		o(p)
+	}
+	for _, r := range log.Records() {
+		switch r.Type {
+		case RecPrepared:
+			p.lock(r.TxID, r.Writes)
+		case RecCommit:
+			p.apply(r.TxID)
+		case RecAbort:
+			p.release(r.TxID, aborted)
+		}
+	}
+	return p
+}
+
+func (p *Participant) Get(key string) (string, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	v, ok := p.data[key]
+	return v, ok
+}
+
+// InDoubt 回傳已投 yes 但還不知道結果的交易；coordinator 不回應時它們會一直鎖住資源，這是 2PC 的 blocking 問題
+func (p *Participant) InDoubt() []string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	var out []string
+	for tx, st := range p.txs {
+		if st == prepared {
+			out = append(out, tx)
+		}
+	}
+	sort.Strings(out)
+	return out
+}
+
+// Run 處理訊息直到 ctx 結束（模擬當機時直接取消 ctx）
+func (p *Participant) Run(ctx context.Context) {
+	ticker := time.NewTicker(p.retry)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			for _, tx := range p.InDoubt() {
+				p.send(Message{Type: MsgQuery, To: p.coordinator, TxID: tx})
+			}
+		case m := <-p.inbox:
+			p.handle(m)
+		}
+	}
+}
+
+func (p *Participant) send(m Message) {
+	m.From = p.id
+	p.net.Send(m)
+}
+
+func (p *Participant) handle(m Message) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	switch m.Type {
+	case MsgPrepare:
+		yes := p.prepare(m.TxID, m.Writes)
+		p.send(Message{Type: MsgVote, To: m.From, TxID: m.TxID, Yes: yes})
+	case MsgCommit:
+		if p.txs[m.TxID] == prepared {
+			// 先寫 log 再套用，套用到一半當機也能從 log 重做
+			if err := p.log.Append(Record{Type: RecCommit, TxID: m.TxID}); err != nil {
+				return
+			}
+			p.apply(m.TxID)
+		}
+		p.send(Message{Type: MsgAck, To: m.From, TxID: m.TxID})
+	case MsgAbort:
+		if p.txs[m.TxID] == prepared {
+			if err := p.log.Append(Record{Type: RecAbort, TxID: m.TxID}); err != nil {
+				return
+			}
+			p.release(m.TxID, aborted)
+		}
+		p.send(Message{Type: MsgAck, To: m.From, TxID: m.TxID})
+	}
+}
+
+func (p *Participant) prepare(tx string, writes map[string]string) bool {
+	switch p.txs[tx] {
+	case prepared, committed:
+		return true // 重送的 prepare
+	case aborted:
+		return false
+	}
+	err := p.validate(writes)
+	for k := range writes {
+		if owner, ok := p.locks[k]; ok && owner != tx {
+			err = ErrLocked
+		}
+	}
+	if err != nil {
+		// 投 no 的參與者可以直接 abort，不需要等 coordinator
+		_ = p.log.Append(Record{Type: RecAbort, TxID: tx})
+		p.txs[tx] = aborted
+		return false
+	}
+	// 投 yes 前必須先寫入 log：之後不論是否當機都要能履行承諾
+	if err := p.log.Append(Record{Type: RecPrepared, TxID: tx, Writes: writes}); err != nil {
+		return false
+	}
+	p.lock(tx, writes)
+	return true
+}
+
+func (p *Participant) lock(tx string, writes map[string]string) {
+	p.txs[tx] = prepared
+	p.redo[tx] = writes
+	for k := range writes {
+		p.locks[k] = tx
+	}
+}
+
+func (p *Participant) apply(tx string) {
+	for k, v := range p.redo[tx] {
+		p.data[k] = v
+	}
+	p.release(tx, committed)
+}
+
+func (p *Participant) release(tx string, st txState) {
+	for k := range p.redo[tx] {
+		if p.locks[k] == tx {
+			delete(p.locks, k)
+		}
+	}
+	delete(p.redo, tx)
+	p.txs[tx] = st
+}
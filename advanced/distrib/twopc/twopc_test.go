@@ -0,0 +1,230 @@
+package twopc
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type node struct {
+	log    *MemLog
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+type harness struct {
+	t     *testing.T
+	net   *Network
+	coord *Coordinator
+	cnode node
+	parts map[string]*Participant
+	pnode map[string]*node
+}
+
+func newHarness(t *testing.T, ids ...string) *harness {
+	h := &harness{t: t, net: NewNetwork(), parts: make(map[string]*Participant), pnode: make(map[string]*node)}
+	h.cnode.log = &MemLog{}
+	for _, id := range ids {
+		h.pnode[id] = &node{log: &MemLog{}}
+		h.startParticipant(id)
+	}
+	t.Cleanup(func() {
+		h.stopCoordinator()
+		for id := range h.pnode {
+			h.crashParticipant(id)
+		}
+	})
+	return h
+}
+
+func (h *harness) startCoordinator(opts ...Option) {
+	h.coord = NewCoordinator("coord", h.net, h.cnode.log, append([]Option{WithVoteTimeout(100 * time.Millisecond), WithRetry(10 * time.Millisecond)}, opts...)...)
+	ctx, cancel := context.WithCancel(context.Background())
+	h.cnode.cancel, h.cnode.done = cancel, make(chan struct{})
+	go func(c *Coordinator, done chan struct{}) {
+		defer close(done)
+		c.Run(ctx)
+	}(h.coord, h.cnode.done)
+}
+
+func (h *harness) stopCoordinator() {
+	if h.cnode.cancel != nil {
+		h.cnode.cancel()
+		<-h.cnode.done
+		h.cnode.cancel = nil
+	}
+}
+
+func (h *harness) startParticipant(id string, opts ...ParticipantOption) {
+	n := h.pnode[id]
+	p := NewParticipant(id, "coord", h.net, n.log, append([]ParticipantOption{WithQueryInterval(10 * time.Millisecond)}, opts...)...)
+	h.parts[id] = p
+	ctx, cancel := context.WithCancel(context.Background())
+	n.cancel, n.done = cancel, make(chan struct{})
+	go func() {
+		defer close(n.done)
+		p.Run(ctx)
+	}()
+}
+
+func (h *harness) crashParticipant(id string) {
+	n := h.pnode[id]
+	if n.cancel != nil {
+		n.cancel()
+		<-n.done
+		n.cancel = nil
+	}
+}
+
+func (h *harness) commit(tx string, writes map[string]map[string]string) (Outcome, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	return h.coord.Commit(ctx, tx, writes)
+}
+
+func (h *harness) value(id, key string) string {
+	v, _ := h.parts[id].Get(key)
+	return v
+}
+
+func transfer(from, to string) map[string]map[string]string {
+	return map[string]map[string]string{
+		"bank-a": {"alice": from},
+		"bank-b": {"bob": to},
+	}
+}
+
+func TestCommit(t *testing.T) {
+	h := newHarness(t, "bank-a", "bank-b")
+	h.startCoordinator()
+
+	out, err := h.commit("tx1", transfer("90", "110"))
+	require.NoError(t, err)
+	assert.Equal(t, Committed, out)
+	assert.Equal(t, "90", h.value("bank-a", "alice"))
+	assert.Equal(t, "110", h.value("bank-b", "bob"))
+
+	_, err = h.commit("tx1", transfer("0", "0"))
+	assert.ErrorIs(t, err, ErrTxExist)
+}
+
+func TestAbortOnNoVote(t *testing.T) {
+	h := newHarness(t, "bank-a")
+	h.pnode["bank-b"] = &node{log: &MemLog{}}
+	h.startParticipant("bank-b", WithValidate(func(w map[string]string) error {
+		if w["bob"] == "reject" {
+			return errors.New("insufficient funds")
+		}
+		return nil
+	}))
+	h.startCoordinator()
+
+	out, err := h.commit("tx1", transfer("90", "reject"))
+	require.NoError(t, err)
+	assert.Equal(t, Aborted, out)
+	assert.Empty(t, h.value("bank-a", "alice"))
+
+	// abort 後鎖已釋放，下一筆交易可以成功
+	out, err = h.commit("tx2", transfer("80", "120"))
+	require.NoError(t, err)
+	assert.Equal(t, Committed, out)
+	assert.Equal(t, "80", h.value("bank-a", "alice"))
+}
+
+func TestUnreachableParticipantAborts(t *testing.T) {
+	h := newHarness(t, "bank-a", "bank-b")
+	h.startCoordinator()
+	h.net.SetDown("bank-b", true)
+
+	done := make(chan Outcome)
+	go func() {
+		out, _ := h.commit("tx1", transfer("90", "110"))
+		done <- out
+	}()
+	// bank-a 投了 yes，收到 abort 後釋放；bank-b 要重新連線才能收到 abort 並 ack
+	assert.Eventually(t, func() bool { return len(h.parts["bank-a"].InDoubt()) == 0 && len(h.pnode["bank-a"].log.Records()) == 2 }, time.Second, 5*time.Millisecond)
+	h.net.SetDown("bank-b", false)
+	assert.Equal(t, Aborted, <-done)
+	assert.Empty(t, h.value("bank-a", "alice"))
+}
+
+func TestParticipantCrashRecovery(t *testing.T) {
+	h := newHarness(t, "bank-a", "bank-b")
+	h.startCoordinator()
+	// bank-b 投完票後當機，收不到 commit
+	h.net.SetDrop(func(m Message) bool { return m.Type == MsgCommit && m.To == "bank-b" })
+
+	done := make(chan Outcome)
+	go func() {
+		out, _ := h.commit("tx1", transfer("90", "110"))
+		done <- out
+	}()
+	assert.Eventually(t, func() bool { return len(h.parts["bank-b"].InDoubt()) == 1 }, time.Second, 5*time.Millisecond)
+	h.crashParticipant("bank-b")
+	h.net.SetDrop(nil)
+
+	// 重啟後從 log 得知 tx1 是 prepared，鎖仍在，詢問 coordinator 後完成 commit
+	h.startParticipant("bank-b")
+	assert.Equal(t, Committed, <-done)
+	assert.Eventually(t, func() bool { return h.value("bank-b", "bob") == "110" }, time.Second, 5*time.Millisecond)
+
+	// 再重啟一次，已 commit 的資料從 log 重建
+	h.crashParticipant("bank-b")
+	h.startParticipant("bank-b")
+	assert.Equal(t, "110", h.value("bank-b", "bob"))
+	assert.Empty(t, h.parts["bank-b"].InDoubt())
+}
+
+func TestCoordinatorCrashBeforeDecision(t *testing.T) {
+	h := newHarness(t, "bank-a", "bank-b")
+	h.startCoordinator(WithCrash(func(p CrashPoint, _ string) bool { return p == CrashAfterPrepare }))
+
+	_, err := h.commit("tx1", transfer("90", "110"))
+	assert.ErrorIs(t, err, ErrCrashed)
+	<-h.cnode.done
+	h.cnode.cancel = nil
+
+	// 參與者已投 yes，coordinator 不在時只能一直等
+	assert.Eventually(t, func() bool {
+		return len(h.parts["bank-a"].InDoubt()) == 1 && len(h.parts["bank-b"].InDoubt()) == 1
+	}, time.Second, 5*time.Millisecond)
+	assert.Never(t, func() bool { return len(h.parts["bank-a"].InDoubt()) == 0 }, 100*time.Millisecond, 10*time.Millisecond)
+
+	// coordinator 重啟：沒有決議的交易 presumed abort
+	h.startCoordinator()
+	assert.Eventually(t, func() bool {
+		return len(h.parts["bank-a"].InDoubt()) == 0 && len(h.parts["bank-b"].InDoubt()) == 0
+	}, time.Second, 5*time.Millisecond)
+	assert.Empty(t, h.value("bank-a", "alice"))
+	assert.Eventually(t, func() bool { return lastRecord(h.cnode.log).Type == RecEnd }, time.Second, 5*time.Millisecond)
+}
+
+func TestCoordinatorCrashAfterDecision(t *testing.T) {
+	h := newHarness(t, "bank-a", "bank-b")
+	h.startCoordinator(WithCrash(func(p CrashPoint, _ string) bool { return p == CrashAfterDecision }))
+
+	_, err := h.commit("tx1", transfer("90", "110"))
+	assert.ErrorIs(t, err, ErrCrashed)
+	<-h.cnode.done
+	h.cnode.cancel = nil
+	assert.Empty(t, h.value("bank-a", "alice"))
+
+	// commit 決議已寫入 log，重啟後必須把 commit 送達
+	h.startCoordinator()
+	assert.Eventually(t, func() bool {
+		return h.value("bank-a", "alice") == "90" && h.value("bank-b", "bob") == "110"
+	}, time.Second, 5*time.Millisecond)
+	assert.Eventually(t, func() bool { return lastRecord(h.cnode.log).Type == RecEnd }, time.Second, 5*time.Millisecond)
+}
+
+func lastRecord(l *MemLog) Record {
+	rs := l.Records()
+	if len(rs) == 0 {
+		return Record{Type: -1}
+	}
+	return rs[len(rs)-1]
+}
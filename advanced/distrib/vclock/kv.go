@@ -0,0 +1,117 @@
+package vclock
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+)
+
+/*
+多寫入者 KV（Dynamo / Riak 風格）：每個 Replica 都可以接受寫入，彼此用 Sync 交換資料。
+
+	Get 回傳目前所有版本（siblings）與它們合併後的 context
+	Put 帶上讀到的 context：新版本的 clock = context.Tick(replica)，
+	    被新版本涵蓋的舊版本會被丟掉，彼此 Concurrent 的版本則保留下來
+
+兩個 client 各自讀到同一版本後寫入不同 replica，Sync 之後會出現兩個 sibling，
+Value 回傳 *ConflictError 把衝突交給呼叫端；呼叫端合併後用 Get 得到的 context 寫回即可解決。
+*/
+
+var ErrNotFound = errors.New("vclock: key not found")
+
+type Version struct {
+	Value string
+	Clock VClock
+}
+
+type ConflictError struct {
+	Key      string
+	Versions []Version
+}
+
+func (e *ConflictError) Error() string {
+	return fmt.Sprintf("vclock: %d conflicting versions of %q", len(e.Versions), e.Key)
+}
+
+type Replica struct {
+	id   string
+	mu   sync.Mutex
+	data map[string][]Version
+}
+
+func NewReplica(id string) *Replica {
+	return &Replica{id: id, data: make(map[string][]Version)}
+}
+
+func (r *Replica) ID() string { return r.id }
+
+// Get 回傳所有版本（依 clock 字串排序，結果穩定）與合併後的 context
+func (r *Replica) Get(key string) ([]Version, VClock) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	ctx := New()
+	vs := make([]Version, len(r.data[key]))
+	for i, v := range r.data[key] {
+		vs[i] = Version{Value: v.Value, Clock: v.Clock.Copy()}
+		ctx = ctx.Merge(v.Clock)
+	}
+	sort.Slice(vs, func(i, j int) bool { return vs[i].Clock.String() < vs[j].Clock.String() })
+	return vs, ctx
+}
+
+// Value 只有一個版本時回傳它；有多個版本時回傳 *ConflictError
+func (r *Replica) Value(key string) (string, VClock, error) {
+	vs, ctx := r.Get(key)
+	switch len(vs) {
+	case 0:
+		return "", ctx, ErrNotFound
+	case 1:
+		return vs[0].Value, ctx, nil
+	}
+	return "", ctx, &ConflictError{Key: key, Versions: vs}
+}
+
+// Put 以 ctx（通常來自 Get）為基礎寫入新版本；ctx 為 nil 表示盲寫，會與既有版本並存
+func (r *Replica) Put(key, value string, ctx VClock) VClock {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	clock := New().Merge(ctx).Tick(r.id)
+	r.add(key, Version{Value: value, Clock: clock})
+	return clock.Copy()
+}
+
+// add 加入版本並移除被涵蓋的舊版本
+func (r *Replica) add(key string, v Version) {
+	var kept []Version
+	for _, old := range r.data[key] {
+		if v.Clock.Descends(old.Clock) {
+			continue
+		}
+		if old.Clock.Descends(v.Clock) {
+			return // 已經有更新（或相同）的版本
+		}
+		kept = append(kept, old)
+	}
+	r.data[key] = append(kept, v)
+}
+
+// Sync 把 from 的所有版本合併到 r（單向 anti-entropy）
+func (r *Replica) Sync(from *Replica) {
+	from.mu.Lock()
+	snapshot := make(map[string][]Version, len(from.data))
+	for k, vs := range from.data {
+		for _, v := range vs {
+			snapshot[k] = append(snapshot[k], Version{Value: v.Value, Clock: v.Clock.Copy()})
+		}
+	}
+	from.mu.Unlock()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for k, vs := range snapshot {
+		for _, v := range vs {
+			r.add(k, v)
+		}
+	}
+}
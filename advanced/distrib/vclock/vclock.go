@@ -0,0 +1,120 @@
+package vclock
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+/*
+Vector clock：每個節點各自維護一個計數器，VClock 記錄「看過每個節點的第幾個事件」。
+
+	本地事件 / 寫入：自己的計數器 +1               (Tick)
+	收到別人的資料：每個欄位取 max，再視情況 Tick   (Merge)
+
+比較 a、b：
+  - a 每個欄位都 <= b 且至少一個 <：a happens-before b（b 是 a 的後續版本，a 可以丟掉）
+  - 反過來則 a 在 b 之後
+  - 各有比對方大的欄位：Concurrent，兩個寫入彼此不知道對方，是衝突，需要由應用程式決定如何合併
+缺少的欄位視為 0。
+*/
+
+type Ordering int
+
+const (
+	Equal Ordering = iota
+	Before
+	After
+	Concurrent
+)
+
+func (o Ordering) String() string {
+	switch o {
+	case Equal:
+		return "equal"
+	case Before:
+		return "before"
+	case After:
+		return "after"
+	case Concurrent:
+		return "concurrent"
+	}
+	return fmt.Sprintf("Ordering(%d)", int(o))
+}
+
+type VClock map[string]uint64
+
+func New() VClock { return VClock{} }
+
+func (c VClock) Copy() VClock {
+	out := make(VClock, len(c))
+	for k, v := range c {
+		out[k] = v
+	}
+	return out
+}
+
+// Tick 把 node 的計數器加一並回傳 c 本身，方便串接
+func (c VClock) Tick(node string) VClock {
+	c[node]++
+	return c
+}
+
+// Merge 回傳每個欄位取 max 的新 clock，不修改 c 與 o
+func (c VClock) Merge(o VClock) VClock {
+	out := c.Copy()
+	for k, v := range o {
+		if v > out[k] {
+			out[k] = v
+		}
+	}
+	return out
+}
+
+func (c VClock) Compare(o VClock) Ordering {
+	less, greater := false, false
+	for k, v := range c {
+		if v > o[k] {
+			greater = true
+		} else if v < o[k] {
+			less = true
+		}
+	}
+	for k, v := range o {
+		if _, ok := c[k]; !ok && v > 0 {
+			less = true
+		}
+	}
+	switch {
+	case less && greater:
+		return Concurrent
+	case less:
+		return Before
+	case greater:
+		return After
+	}
+	return Equal
+}
+
+func (c VClock) HappensBefore(o VClock) bool { return c.Compare(o) == Before }
+func (c VClock) Concurrent(o VClock) bool    { return c.Compare(o) == Concurrent }
+
+// Descends 表示 c 包含 o 的所有事件（After 或 Equal）
+func (c VClock) Descends(o VClock) bool {
+	ord := c.Compare(o)
+	return ord == After || ord == Equal
+}
+
+// String 依節點名稱排序輸出，例如 {a:2 b:1}
+func (c VClock) String() string {
+	keys := make([]string, 0, len(c))
+	for k := range c {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	parts := make([]string, len(keys))
+	for i, k := range keys {
+		parts[i] = fmt.Sprintf("%s:%d", k, c[k])
+	}
+	return "{" + strings.Join(parts, " ") + "}"
+}
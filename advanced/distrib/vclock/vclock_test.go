@@ -0,0 +1,119 @@
+package vclock
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompare(t *testing.T) {
+	tests := []struct {
+		a, b VClock
+		want Ordering
+	}{
+		{VClock{}, VClock{}, Equal},
+		{VClock{"a": 1}, VClock{"a": 1, "b": 0}, Equal},
+		{VClock{"a": 1}, VClock{"a": 2}, Before},
+		{VClock{"a": 1}, VClock{"a": 1, "b": 1}, Before},
+		{VClock{"a": 2, "b": 1}, VClock{"a": 1, "b": 1}, After},
+		{VClock{"a": 1}, VClock{"b": 1}, Concurrent},
+		{VClock{"a": 2, "b": 1}, VClock{"a": 1, "b": 2}, Concurrent},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, tt.a.Compare(tt.b), "%v vs %v", tt.a, tt.b)
+	}
+}
+
+func TestMessagePassing(t *testing.T) {
+	// a 發生事件後送訊息給 b，b 收到時 merge 再 tick
+	a := New().Tick("a")
+	b := New().Tick("b")
+	assert.True(t, a.Concurrent(b))
+
+	b = b.Merge(a).Tick("b")
+	assert.True(t, a.HappensBefore(b))
+	assert.Equal(t, "{a:1 b:2}", b.String())
+
+	// Merge 不修改原本的 clock
+	c := VClock{"a": 5}
+	_ = c.Merge(VClock{"a": 9, "c": 1})
+	assert.Equal(t, VClock{"a": 5}, c)
+}
+
+func TestReplicaSequentialWrites(t *testing.T) {
+	r := NewReplica("r1")
+	_, _, err := r.Value("k")
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	r.Put("k", "v1", nil)
+	_, ctx := r.Get("k")
+	r.Put("k", "v2", ctx)
+
+	v, ctx, err := r.Value("k")
+	require.NoError(t, err)
+	assert.Equal(t, "v2", v)
+	assert.Equal(t, VClock{"r1": 2}, ctx)
+}
+
+func TestReplicaConflict(t *testing.T) {
+	r1, r2 := NewReplica("r1"), NewReplica("r2")
+	r1.Put("cart", "milk", nil)
+	r2.Sync(r1)
+
+	// 兩個 client 讀到同一版本，各自寫到不同 replica
+	_, ctx1 := r1.Get("cart")
+	_, ctx2 := r2.Get("cart")
+	r1.Put("cart", "milk,eggs", ctx1)
+	r2.Put("cart", "milk,bread", ctx2)
+
+	r1.Sync(r2)
+	_, _, err := r1.Value("cart")
+	var conflict *ConflictError
+	require.True(t, errors.As(err, &conflict))
+	assert.Equal(t, []Version{
+		{Value: "milk,bread", Clock: VClock{"r1": 1, "r2": 1}},
+		{Value: "milk,eggs", Clock: VClock{"r1": 2}},
+	}, conflict.Versions)
+
+	// 呼叫端合併 siblings 後帶著 context 寫回，衝突解除
+	_, ctx := r1.Get("cart")
+	r1.Put("cart", "milk,eggs,bread", ctx)
+	r2.Sync(r1)
+	for _, r := range []*Replica{r1, r2} {
+		v, clock, err := r.Value("cart")
+		require.NoError(t, err, r.ID())
+		assert.Equal(t, "milk,eggs,bread", v)
+		assert.Equal(t, VClock{"r1": 3, "r2": 1}, clock)
+	}
+}
+
+func TestSyncDropsStaleVersions(t *testing.T) {
+	r1, r2 := NewReplica("r1"), NewReplica("r2")
+	old := r1.Put("k", "old", nil)
+	r2.Sync(r1)
+	r2.Put("k", "new", old)
+
+	// 舊版本再同步一次也不會蓋掉新版本
+	r2.Sync(r1)
+	v, _, err := r2.Value("k")
+	require.NoError(t, err)
+	assert.Equal(t, "new", v)
+
+	r1.Sync(r2)
+	v, _, err = r1.Value("k")
+	require.NoError(t, err)
+	assert.Equal(t, "new", v)
+}
+
+func ExampleVClock_Compare() {
+	a := VClock{"a": 2, "b": 1}
+	b := VClock{"a": 1, "b": 2}
+	fmt.Println(a.Compare(b))
+	fmt.Println(a.Compare(a.Merge(b)))
+	// Output:
+	// concurrent
+	// before
+}
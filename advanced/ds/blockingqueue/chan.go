@@ -0,0 +1,116 @@
+package blockingqueue
+
+import (
+	"sync"
+	"time"
+)
+
+// Chan 以 buffered channel 實作；資料 channel 永遠不關閉（關閉後還有 Put 會 panic），改用 done 通知
+type Chan[T any] struct {
+	ch        chan T
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+var _ Queue[int] = (*Chan[int])(nil)
+
+func NewChan[T any](capacity int) *Chan[T] {
+	return &Chan[T]{ch: make(chan T, capacity), done: make(chan struct{})}
+}
+
+func (q *Chan[T]) closed() bool {
+	select {
+	case <-q.done:
+		return true
+	default:
+		return false
+	}
+}
+
+func (q *Chan[T]) Put(v T) error {
+	if q.closed() {
+		return ErrClosed
+	}
+	select {
+	case q.ch <- v:
+		return nil
+	case <-q.done:
+		return ErrClosed
+	}
+}
+
+func (q *Chan[T]) Take() (T, error) {
+	select {
+	case v := <-q.ch:
+		return v, nil
+	case <-q.done:
+		return q.drain()
+	}
+}
+
+// drain 在關閉後取出剩下的元素
+func (q *Chan[T]) drain() (T, error) {
+	select {
+	case v := <-q.ch:
+		return v, nil
+	default:
+		var zero T
+		return zero, ErrClosed
+	}
+}
+
+func (q *Chan[T]) PutTimeout(v T, d time.Duration) error {
+	if q.closed() {
+		return ErrClosed
+	}
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case q.ch <- v:
+		return nil
+	case <-q.done:
+		return ErrClosed
+	case <-t.C:
+		return ErrTimeout
+	}
+}
+
+func (q *Chan[T]) TakeTimeout(d time.Duration) (T, error) {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case v := <-q.ch:
+		return v, nil
+	case <-q.done:
+		return q.drain()
+	case <-t.C:
+		var zero T
+		return zero, ErrTimeout
+	}
+}
+
+func (q *Chan[T]) TryPut(v T) bool {
+	if q.closed() {
+		return false
+	}
+	select {
+	case q.ch <- v:
+		return true
+	default:
+		return false
+	}
+}
+
+func (q *Chan[T]) TryTake() (T, bool) {
+	select {
+	case v := <-q.ch:
+		return v, true
+	default:
+		var zero T
+		return zero, false
+	}
+}
+
+func (q *Chan[T]) Close()   { q.closeOnce.Do(func() { close(q.done) }) }
+func (q *Chan[T]) Len() int { return len(q.ch) }
+func (q *Chan[T]) Cap() int { return cap(q.ch) }
@@ -0,0 +1,147 @@
+package blockingqueue
+
+import (
+	"sync"
+	"time"
+)
+
+// Cond 以 ring buffer + 兩個 sync.Cond 實作
+type Cond[T any] struct {
+	mu       sync.Mutex
+	notFull  *sync.Cond
+	notEmpty *sync.Cond
+	buf      []T
+	head     int
+	size     int
+	closed   bool
+}
+
+var _ Queue[int] = (*Cond[int])(nil)
+
+func NewCond[T any](capacity int) *Cond[T] {
+	q := &Cond[T]{buf: make([]T, capacity)}
+	q.notFull = sync.NewCond(&q.mu)
+	q.notEmpty = sync.NewCond(&q.mu)
+	return q
+}
+
+func (q *Cond[T]) Put(v T) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	// 一定要用 for：Wait 回來時條件不保證成立（被 Broadcast 一起叫醒、或被別人搶先）
+	for q.size == len(q.buf) && !q.closed {
+		q.notFull.Wait()
+	}
+	return q.push(v)
+}
+
+func (q *Cond[T]) Take() (T, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for q.size == 0 && !q.closed {
+		q.notEmpty.Wait()
+	}
+	return q.pop()
+}
+
+func (q *Cond[T]) PutTimeout(v T, d time.Duration) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if err := q.waitUntil(q.notFull, d, func() bool { return q.size < len(q.buf) || q.closed }); err != nil {
+		return err
+	}
+	return q.push(v)
+}
+
+func (q *Cond[T]) TakeTimeout(d time.Duration) (T, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if err := q.waitUntil(q.notEmpty, d, func() bool { return q.size > 0 || q.closed }); err != nil {
+		var zero T
+		return zero, err
+	}
+	return q.pop()
+}
+
+// waitUntil 在持有 q.mu 的情況下等待 ready 成立，最多 d。
+// 期限到時由 timer Broadcast 叫醒所有等待者；對其他人來說這就是一次「沒有原因」的喚醒，
+// 他們的 for 迴圈會重新檢查條件後繼續睡，這正是條件必須放在迴圈裡的原因。
+func (q *Cond[T]) waitUntil(c *sync.Cond, d time.Duration, ready func() bool) error {
+	if ready() {
+		return nil
+	}
+	deadline := time.Now().Add(d)
+	timer := time.AfterFunc(d, func() {
+		q.mu.Lock()
+		c.Broadcast()
+		q.mu.Unlock()
+	})
+	defer timer.Stop()
+	for !ready() {
+		if !time.Now().Before(deadline) {
+			return ErrTimeout
+		}
+		c.Wait()
+	}
+	return nil
+}
+
+func (q *Cond[T]) TryPut(v T) bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.size == len(q.buf) || q.closed {
+		return false
+	}
+	return q.push(v) == nil
+}
+
+func (q *Cond[T]) TryTake() (T, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.size == 0 {
+		var zero T
+		return zero, false
+	}
+	v, err := q.pop()
+	return v, err == nil
+}
+
+// push / pop 必須持有 q.mu，且呼叫前條件已成立
+func (q *Cond[T]) push(v T) error {
+	if q.closed {
+		return ErrClosed
+	}
+	q.buf[(q.head+q.size)%len(q.buf)] = v
+	q.size++
+	q.notEmpty.Signal()
+	return nil
+}
+
+func (q *Cond[T]) pop() (T, error) {
+	var zero T
+	if q.size == 0 {
+		return zero, ErrClosed
+	}
+	v := q.buf[q.head]
+	q.buf[q.head] = zero // 讓 GC 可以回收
+	q.head = (q.head + 1) % len(q.buf)
+	q.size--
+	q.notFull.Signal()
+	return v, nil
+}
+
+func (q *Cond[T]) Close() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.closed = true
+	q.notFull.Broadcast()
+	q.notEmpty.Broadcast()
+}
+
+func (q *Cond[T]) Len() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.size
+}
+
+func (q *Cond[T]) Cap() int { return len(q.buf) }
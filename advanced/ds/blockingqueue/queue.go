@@ -0,0 +1,36 @@
+package blockingqueue
+
+import (
+	"errors"
+	"time"
+)
+
+/*
+有界阻塞佇列，三種等待方式：
+  - Put / Take：一直等到成功（或佇列被關閉）
+  - PutTimeout / TakeTimeout：最多等 d，逾時回傳 ErrTimeout
+  - TryPut / TryTake：完全不等，立刻回傳成功與否
+
+同一個介面有兩個實作：
+  - Chan：buffered channel 本身就是有界阻塞佇列，select + time.After / default 就有三種等待方式
+  - Cond：Mutex + sync.Cond，自己處理等待與喚醒；sync.Cond 沒有「等待逾時」，
+    要靠 time.AfterFunc 在期限到時 Broadcast，所有等待者醒來後自己重新檢查條件
+*/
+
+var (
+	ErrTimeout = errors.New("blockingqueue: timeout")
+	ErrClosed  = errors.New("blockingqueue: closed")
+)
+
+type Queue[T any] interface {
+	Put(v T) error
+	Take() (T, error)
+	PutTimeout(v T, d time.Duration) error
+	TakeTimeout(d time.Duration) (T, error)
+	TryPut(v T) bool
+	TryTake() (T, bool)
+	// Close 之後 Put 一律回傳 ErrClosed，Take 會先取完剩下的元素再回傳 ErrClosed
+	Close()
+	Len() int
+	Cap() int
+}
@@ -0,0 +1,215 @@
+package mvcc
+
+import (
+	"errors"
+	"sort"
+	"sync"
+)
+
+/*
+MVCC（multi-version concurrency control）：寫入不覆蓋舊值，而是在 key 的版本鏈尾端加上新版本，
+每個版本帶有提交時的 timestamp（遞增的 commit 序號）。
+
+交易開始時記下 startTS，之後只看得到 ts <= startTS 的版本 —— 整個交易讀到的是同一個一致的快照，
+讀取完全不需要鎖住其他交易（snapshot isolation）：
+
+	key "x":  [ts=1 "a"] -> [ts=4 "b"] -> [ts=7 "c"]
+	startTS=5 的交易讀到 "b"，即使 ts=7 已經提交
+
+寫入先暫存在交易自己的 write set，Commit 時才一次套用。衝突偵測採 first-committer-wins：
+Commit 時若 write set 中任一 key 在 startTS 之後已被其他交易提交過新版本，回傳 ErrConflict，
+呼叫端重試（Update 會自動重試）。
+
+snapshot isolation 可以避免的異常：
+  - dirty read：未提交的寫入只在 write set 裡，其他交易看不到
+  - non-repeatable read / read skew：同一交易內重複讀取都來自同一個快照
+  - lost update：兩個交易都讀了 x 再寫回，後提交的會因衝突失敗
+但不能避免 write skew：兩個交易讀同一組 key、各自寫「不同的」key，write set 沒有交集所以都能提交
+（測試中有示範）；需要的話要把讀到的 key 也一起寫入，讓衝突偵測看得到。
+
+舊版本在沒有任何進行中的交易需要時才能回收，GC 以最舊的 startTS 為界。
+*/
+
+var (
+	ErrConflict = errors.New("mvcc: write conflict")
+	ErrTxnDone  = errors.New("mvcc: transaction already committed or rolled back")
+)
+
+type version struct {
+	ts      uint64
+	value   []byte
+	deleted bool
+}
+
+type Store struct {
+	mu       sync.RWMutex
+	versions map[string][]version // 依 ts 遞增
+	ts       uint64               // 最後一次提交的 timestamp
+	active   map[uint64]int       // startTS -> 進行中的交易數，GC 用
+}
+
+func New() *Store {
+	return &Store{versions: make(map[string][]version), active: make(map[uint64]int)}
+}
+
+// Begin 開始一個讀寫交易，快照為目前最後一次提交的狀態
+func (s *Store) Begin() *Txn {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.active[s.ts]++
+	return &Txn{s: s, start: s.ts, writes: make(map[string]write)}
+}
+
+// View 在唯讀交易中執行 fn
+func (s *Store) View(fn func(*Txn) error) error {
+	tx := s.Begin()
+	defer tx.Rollback()
+	return fn(tx)
+}
+
+// Update 在交易中執行 fn 並提交；遇到 ErrConflict 時以新的快照重新執行 fn，其他錯誤直接回傳
+func (s *Store) Update(fn func(*Txn) error) error {
+	for {
+		tx := s.Begin()
+		if err := fn(tx); err != nil {
+			tx.Rollback()
+			return err
+		}
+		err := tx.Commit()
+		if !errors.Is(err, ErrConflict) {
+			return err
+		}
+	}
+}
+
+// read 回傳 ts <= at 的最新版本
+func (s *Store) read(key string, at uint64) ([]byte, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	vs := s.versions[key]
+	i := sort.Search(len(vs), func(i int) bool { return vs[i].ts > at }) - 1
+	if i < 0 || vs[i].deleted {
+		return nil, false
+	}
+	return vs[i].value, true
+}
+
+// GC 回收所有進行中交易都不再需要的舊版本，回傳回收的版本數
+func (s *Store) GC() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	horizon := s.ts
+	for start := range s.active {
+		if start < horizon {
+			horizon = start
+		}
+	}
+	removed := 0
+	for key, vs := range s.versions {
+		// 保留 horizon 看得到的那個版本（ts <= horizon 的最新一個）以及之後的所有版本
+		i := sort.Search(len(vs), func(i int) bool { return vs[i].ts > horizon }) - 1
+		if i < 0 {
+			continue
+		}
+		if vs[i].deleted && i == len(vs)-1 {
+			delete(s.versions, key)
+			removed += len(vs)
+			continue
+		}
+		if i > 0 {
+			s.versions[key] = append([]version(nil), vs[i:]...)
+			removed += i
+		}
+	}
+	return removed
+}
+
+// Versions 回傳 key 目前保留的版本數（包含刪除標記），觀察 GC 用
+func (s *Store) Versions(key string) int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return len(s.versions[key])
+}
+
+type write struct {
+	value   []byte
+	deleted bool
+}
+
+// Txn 不是 concurrency-safe 的，一個交易只應由一個 goroutine 使用
+type Txn struct {
+	s      *Store
+	start  uint64
+	writes map[string]write
+	done   bool
+}
+
+// Get 先看自己的 write set，再從快照讀取；回傳的 slice 不可修改
+func (tx *Txn) Get(key string) ([]byte, bool, error) {
+	if tx.done {
+		return nil, false, ErrTxnDone
+	}
+	if w, ok := tx.writes[key]; ok {
+		return w.value, !w.deleted, nil
+	}
+	v, ok := tx.s.read(key, tx.start)
+	return v, ok, nil
+}
+
+func (tx *Txn) Set(key string, value []byte) error {
+	if tx.done {
+		return ErrTxnDone
+	}
+	tx.writes[key] = write{value: append([]byte(nil), value...)}
+	return nil
+}
+
+func (tx *Txn) Delete(key string) error {
+	if tx.done {
+		return ErrTxnDone
+	}
+	tx.writes[key] = write{deleted: true}
+	return nil
+}
+
+// Commit 檢查衝突並套用 write set；失敗時交易同樣結束，需要重新 Begin
+func (tx *Txn) Commit() error {
+	if tx.done {
+		return ErrTxnDone
+	}
+	s := tx.s
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	tx.finish()
+	for key := range tx.writes {
+		if vs := s.versions[key]; len(vs) > 0 && vs[len(vs)-1].ts > tx.start {
+			return ErrConflict
+		}
+	}
+	if len(tx.writes) == 0 {
+		return nil
+	}
+	s.ts++
+	for key, w := range tx.writes {
+		s.versions[key] = append(s.versions[key], version{ts: s.ts, value: w.value, deleted: w.deleted})
+	}
+	return nil
+}
+
+// Rollback 丟棄 write set；已結束的交易呼叫也沒關係，方便 defer
+func (tx *Txn) Rollback() {
+	if tx.done {
+		return
+	}
+	tx.s.mu.Lock()
+	defer tx.s.mu.Unlock()
+	tx.finish()
+}
+
+// finish 需持有 s.mu
+func (tx *Txn) finish() {
+	tx.done = true
+	if tx.s.active[tx.start]--; tx.s.active[tx.start] == 0 {
+		delete(tx.s.active, tx.start)
+	}
+}
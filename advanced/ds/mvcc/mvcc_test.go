@@ -0,0 +1,258 @@
+package mvcc
+
+import (
+	"fmt"
+	"math/rand"
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func get(t *testing.T, tx *Txn, key string) string {
+	t.Helper()
+	v, ok, err := tx.Get(key)
+	require.NoError(t, err)
+	if !ok {
+		return "<none>"
+	}
+	return string(v)
+}
+
+func set(t *testing.T, s *Store, kvs ...string) {
+	t.Helper()
+	require.NoError(t, s.Update(func(tx *Txn) error {
+		for i := 0; i < len(kvs); i += 2 {
+			tx.Set(kvs[i], []byte(kvs[i+1]))
+		}
+		return nil
+	}))
+}
+
+func TestReadYourWrites(t *testing.T) {
+	s := New()
+	set(t, s, "a", "1")
+	tx := s.Begin()
+	assert.Equal(t, "1", get(t, tx, "a"))
+	tx.Set("a", []byte("2"))
+	tx.Delete("b")
+	assert.Equal(t, "2", get(t, tx, "a"))
+	assert.Equal(t, "<none>", get(t, tx, "b"))
+	require.NoError(t, tx.Commit())
+
+	_, _, err := tx.Get("a")
+	assert.ErrorIs(t, err, ErrTxnDone)
+	assert.ErrorIs(t, tx.Commit(), ErrTxnDone)
+	tx.Rollback()
+
+	s.View(func(tx *Txn) error {
+		assert.Equal(t, "2", get(t, tx, "a"))
+		return nil
+	})
+}
+
+func TestNoDirtyRead(t *testing.T) {
+	s := New()
+	set(t, s, "x", "old")
+	w := s.Begin()
+	w.Set("x", []byte("uncommitted"))
+	r := s.Begin()
+	assert.Equal(t, "old", get(t, r, "x"))
+	w.Rollback()
+	r.Rollback()
+}
+
+// TestRepeatableRead 同一交易內讀到的永遠是開始時的快照，包含多個 key 之間的一致性（read skew）
+func TestRepeatableRead(t *testing.T) {
+	s := New()
+	set(t, s, "x", "50", "y", "50")
+	r := s.Begin()
+	assert.Equal(t, "50", get(t, r, "x"))
+
+	set(t, s, "x", "0", "y", "100") // 轉帳
+	s.Update(func(tx *Txn) error { return tx.Delete("z") })
+
+	assert.Equal(t, "50", get(t, r, "x"), "non-repeatable read")
+	assert.Equal(t, "50", get(t, r, "y"), "read skew")
+	r.Rollback()
+}
+
+func TestLostUpdate(t *testing.T) {
+	s := New()
+	set(t, s, "n", "0")
+	a, b := s.Begin(), s.Begin()
+	get(t, a, "n")
+	get(t, b, "n")
+	a.Set("n", []byte("1"))
+	b.Set("n", []byte("1"))
+	require.NoError(t, a.Commit())
+	assert.ErrorIs(t, b.Commit(), ErrConflict, "first committer wins")
+}
+
+// TestWriteSkew snapshot isolation 允許的異常：兩位值班醫生各自確認「還有另一個人在」後請假
+func TestWriteSkew(t *testing.T) {
+	s := New()
+	set(t, s, "alice", "on", "bob", "on")
+	onCall := func(tx *Txn) int {
+		n := 0
+		for _, k := range []string{"alice", "bob"} {
+			if get(t, tx, k) == "on" {
+				n++
+			}
+		}
+		return n
+	}
+	a, b := s.Begin(), s.Begin()
+	require.Equal(t, 2, onCall(a))
+	require.Equal(t, 2, onCall(b))
+	a.Set("alice", []byte("off"))
+	b.Set("bob", []byte("off"))
+	require.NoError(t, a.Commit())
+	require.NoError(t, b.Commit(), "write set 沒有交集，偵測不到")
+	s.View(func(tx *Txn) error {
+		assert.Equal(t, 0, onCall(tx))
+		return nil
+	})
+
+	// 把讀到的 key 一起寫回（materialize the conflict），第二個交易就會衝突
+	set(t, s, "alice", "on", "bob", "on")
+	a, b = s.Begin(), s.Begin()
+	onCall(a)
+	onCall(b)
+	a.Set("alice", []byte("off"))
+	a.Set("bob", []byte("on"))
+	b.Set("bob", []byte("off"))
+	b.Set("alice", []byte("on"))
+	require.NoError(t, a.Commit())
+	assert.ErrorIs(t, b.Commit(), ErrConflict)
+}
+
+// TestConcurrentTransfers 多個 goroutine 同時轉帳，讀取端在任何快照看到的總額都不變，也不會有遺失的更新
+func TestConcurrentTransfers(t *testing.T) {
+	const accounts, writers, transfers, total = 8, 8, 200, 8000
+	s := New()
+	require.NoError(t, s.Update(func(tx *Txn) error {
+		for i := 0; i < accounts; i++ {
+			tx.Set(fmt.Sprint("acct", i), []byte(strconv.Itoa(total/accounts)))
+		}
+		return nil
+	}))
+	balance := func(tx *Txn, k string) int {
+		v, _, _ := tx.Get(k)
+		n, _ := strconv.Atoi(string(v))
+		return n
+	}
+
+	var writersWG, readersWG sync.WaitGroup
+	stop := make(chan struct{})
+	var moved [writers]int
+	for w := 0; w < writers; w++ {
+		writersWG.Add(1)
+		go func(w int) {
+			defer writersWG.Done()
+			r := rand.New(rand.NewSource(int64(w)))
+			for i := 0; i < transfers; i++ {
+				from, to := fmt.Sprint("acct", r.Intn(accounts)), fmt.Sprint("acct", r.Intn(accounts))
+				err := s.Update(func(tx *Txn) error {
+					if from == to {
+						return nil
+					}
+					tx.Set(from, []byte(strconv.Itoa(balance(tx, from)-1)))
+					tx.Set(to, []byte(strconv.Itoa(balance(tx, to)+1)))
+					return nil
+				})
+				if err != nil {
+					t.Error(err)
+					return
+				}
+				moved[w]++
+			}
+		}(w)
+	}
+	for r := 0; r < 2; r++ {
+		readersWG.Add(1)
+		go func() {
+			defer readersWG.Done()
+			for {
+				select {
+				case <-stop:
+					return
+				default:
+				}
+				s.View(func(tx *Txn) error {
+					sum := 0
+					for i := 0; i < accounts; i++ {
+						sum += balance(tx, fmt.Sprint("acct", i))
+					}
+					if sum != total {
+						t.Errorf("snapshot sum = %d, want %d", sum, total)
+					}
+					return nil
+				})
+			}
+		}()
+	}
+	writersWG.Wait()
+	close(stop)
+	readersWG.Wait()
+
+	s.View(func(tx *Txn) error {
+		sum := 0
+		for i := 0; i < accounts; i++ {
+			sum += balance(tx, fmt.Sprint("acct", i))
+		}
+		assert.Equal(t, total, sum)
+		return nil
+	})
+}
+
+func TestConcurrentCounter(t *testing.T) {
+	const goroutines, increments = 8, 100
+	s := New()
+	var wg sync.WaitGroup
+	for g := 0; g < goroutines; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < increments; i++ {
+				s.Update(func(tx *Txn) error {
+					v, _, _ := tx.Get("n")
+					n, _ := strconv.Atoi(string(v))
+					return tx.Set("n", []byte(strconv.Itoa(n+1)))
+				})
+			}
+		}()
+	}
+	wg.Wait()
+	s.View(func(tx *Txn) error {
+		assert.Equal(t, strconv.Itoa(goroutines*increments), get(t, tx, "n"), "沒有遺失的更新")
+		return nil
+	})
+}
+
+func TestGC(t *testing.T) {
+	s := New()
+	for i := 0; i < 5; i++ {
+		set(t, s, "k", strconv.Itoa(i))
+	}
+	old := s.Begin() // 看得到 "4"
+	set(t, s, "k", "5")
+	set(t, s, "gone", "x")
+	s.Update(func(tx *Txn) error { return tx.Delete("gone") })
+
+	assert.Equal(t, 4, s.GC(), "k 的 0..3 與 gone 的舊版本以外都還要留著")
+	assert.Equal(t, 2, s.Versions("k"))
+	assert.Equal(t, "4", get(t, old, "k"))
+	old.Rollback()
+
+	assert.Equal(t, 3, s.GC())
+	assert.Equal(t, 1, s.Versions("k"))
+	assert.Zero(t, s.Versions("gone"))
+	s.View(func(tx *Txn) error {
+		assert.Equal(t, "5", get(t, tx, "k"))
+		assert.Equal(t, "<none>", get(t, tx, "gone"))
+		return nil
+	})
+}
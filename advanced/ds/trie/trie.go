@@ -0,0 +1,167 @@
+package trie
+
+import (
+	"sort"
+	"sync"
+)
+
+/*
+Trie（prefix tree）：每個節點代表一個前綴，從 root 走到某節點經過的字元就是該前綴。
+	- 查詢前綴 O(len(prefix))，與字典大小無關
+	- 找出某前綴下的所有字只需要走訪該節點的子樹
+以 rune 當邊，children 用 map；每個字可以帶一個權重（例如搜尋次數）供排序使用。
+
+Node 提供唯讀的走訪方法，讓外部（例如 typeahead 的模糊比對）可以自己控制 DFS，
+走訪時需持有 RLock，透過 Trie.View 進行。
+*/
+
+type Node struct {
+	children map[rune]*Node
+	terminal bool
+	word     string
+	weight   float64
+}
+
+// Child 回傳沿著 r 往下的節點，不存在時回傳 nil
+func (n *Node) Child(r rune) *Node {
+	return n.children[r]
+}
+
+// Each 依字元順序走訪子節點，fn 回傳 false 時停止
+func (n *Node) Each(fn func(r rune, child *Node) bool) {
+	keys := make([]rune, 0, len(n.children))
+	for r := range n.children {
+		keys = append(keys, r)
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i] < keys[j] })
+	for _, r := range keys {
+		if !fn(r, n.children[r]) {
+			return
+		}
+	}
+}
+
+// Word 回傳以此節點結尾的字與權重；ok 為 false 表示此節點只是前綴
+func (n *Node) Word() (word string, weight float64, ok bool) {
+	return n.word, n.weight, n.terminal
+}
+
+// Walk 以 DFS 走訪子樹中所有的字（字典序），fn 回傳 false 時停止
+func (n *Node) Walk(fn func(word string, weight float64) bool) bool {
+	if n.terminal && !fn(n.word, n.weight) {
+		return false
+	}
+	cont := true
+	n.Each(func(_ rune, c *Node) bool {
+		cont = c.Walk(fn)
+		return cont
+	})
+	return cont
+}
+
+type Trie struct {
+	mu   sync.RWMutex
+	root *Node
+	size int
+}
+
+func New() *Trie {
+	return &Trie{root: &Node{}}
+}
+
+// Insert 加入 word；已存在時更新權重
+func (t *Trie) Insert(word string, weight float64) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	n := t.root
+	for _, r := range word {
+		c, ok := n.children[r]
+		if !ok {
+			if n.children == nil {
+				n.children = make(map[rune]*Node)
+			}
+			c = &Node{}
+			n.children[r] = c
+		}
+		n = c
+	}
+	if !n.terminal {
+		t.size++
+	}
+	n.terminal, n.word, n.weight = true, word, weight
+}
+
+func (t *Trie) Get(word string) (float64, bool) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	n := t.find(word)
+	if n == nil || !n.terminal {
+		return 0, false
+	}
+	return n.weight, true
+}
+
+// Delete 移除 word，並剪掉因此變成空的分支
+func (t *Trie) Delete(word string) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	rs := []rune(word)
+	path := make([]*Node, 0, len(rs)+1)
+	n := t.root
+	path = append(path, n)
+	for _, r := range rs {
+		if n = n.children[r]; n == nil {
+			return false
+		}
+		path = append(path, n)
+	}
+	if !n.terminal {
+		return false
+	}
+	n.terminal, n.word, n.weight = false, "", 0
+	t.size--
+	for i := len(rs); i > 0; i-- {
+		if c := path[i]; c.terminal || len(c.children) > 0 {
+			break
+		}
+		delete(path[i-1].children, rs[i-1])
+	}
+	return true
+}
+
+func (t *Trie) find(prefix string) *Node {
+	n := t.root
+	for _, r := range prefix {
+		if n = n.children[r]; n == nil {
+			return nil
+		}
+	}
+	return n
+}
+
+// WithPrefix 依字典序回傳以 prefix 開頭的字，limit <= 0 表示全部
+func (t *Trie) WithPrefix(prefix string, limit int) []string {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	var out []string
+	if n := t.find(prefix); n != nil {
+		n.Walk(func(w string, _ float64) bool {
+			out = append(out, w)
+			return limit <= 0 || len(out) < limit
+		})
+	}
+	return out
+}
+
+// View 在持有讀鎖的情況下把 root 交給 fn，fn 返回後不可再使用任何 Node
+func (t *Trie) View(fn func(root *Node)) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	fn(t.root)
+}
+
+func (t *Trie) Len() int {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	return t.size
+}
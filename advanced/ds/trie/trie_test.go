@@ -0,0 +1,62 @@
+package trie
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTrie(t *testing.T) {
+	tr := New()
+	for i, w := range []string{"go", "gopher", "golang", "google", "rust", "咖啡"} {
+		tr.Insert(w, float64(i))
+	}
+	tr.Insert("go", 10)
+	assert.Equal(t, 6, tr.Len())
+
+	w, ok := tr.Get("go")
+	assert.True(t, ok)
+	assert.Equal(t, 10.0, w)
+	_, ok = tr.Get("gop")
+	assert.False(t, ok, "只是前綴")
+
+	assert.Equal(t, []string{"go", "golang", "google", "gopher"}, tr.WithPrefix("go", 0))
+	assert.Equal(t, []string{"go", "golang"}, tr.WithPrefix("go", 2))
+	assert.Equal(t, []string{"咖啡"}, tr.WithPrefix("咖", 0))
+	assert.Empty(t, tr.WithPrefix("java", 0))
+}
+
+func TestDelete(t *testing.T) {
+	tr := New()
+	tr.Insert("go", 1)
+	tr.Insert("gopher", 1)
+
+	assert.False(t, tr.Delete("gop"))
+	assert.True(t, tr.Delete("gopher"))
+	assert.False(t, tr.Delete("gopher"))
+	assert.Equal(t, []string{"go"}, tr.WithPrefix("", 0))
+	tr.View(func(root *Node) {
+		assert.Nil(t, root.Child('g').Child('o').Child('p'), "空的分支被剪掉")
+	})
+
+	assert.True(t, tr.Delete("go"))
+	assert.Equal(t, 0, tr.Len())
+	tr.View(func(root *Node) {
+		assert.Nil(t, root.Child('g'))
+	})
+}
+
+func TestNodeWalk(t *testing.T) {
+	tr := New()
+	for _, w := range []string{"b", "a", "ab", "c"} {
+		tr.Insert(w, 0)
+	}
+	var got []string
+	tr.View(func(root *Node) {
+		root.Walk(func(w string, _ float64) bool {
+			got = append(got, w)
+			return len(got) < 3
+		})
+	})
+	assert.Equal(t, []string{"a", "ab", "b"}, got)
+}
@@ -0,0 +1,82 @@
+package election
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"advanced/timex"
+)
+
+/*
+Leader election：多個 instance 中只有一個（leader）執行特定工作，例如排程、清理任務。
+  - Campaign 會阻塞直到成為 leader（或 ctx 結束）
+  - 成為 leader 後要持續注意 Lost()：網路斷線、lease 過期時會失去領導權，
+    此時必須立刻停止工作，否則會出現兩個 leader 同時執行（split brain）
+  - Resign 主動交出領導權，讓其他 instance 接手（例如 graceful shutdown）
+
+Backend：
+  - FileLock：同一台機器上的多個 process，以 flock 實作，process 結束時 OS 自動釋放鎖
+  - Etcd：跨機器，以 etcd 的 lease + election 實作，lease 過期即失去領導權
+*/
+
+type Elector interface {
+	Campaign(ctx context.Context) error
+	Resign() error
+	// Lost 在成為 leader 之後有效，失去領導權（包含 Resign）時關閉
+	Lost() <-chan struct{}
+}
+
+var (
+	ErrNotLeader   = errors.New("election: not leader")
+	ErrUnsupported = errors.New("election: unsupported on this platform")
+)
+
+type Callbacks struct {
+	// OnStartedLeading 在成為 leader 後以新的 goroutine 執行，ctx 在失去領導權時取消
+	OnStartedLeading func(ctx context.Context)
+	// OnStoppedLeading 在 OnStartedLeading 返回之後呼叫
+	OnStoppedLeading func()
+}
+
+// RetryInterval 是 Campaign 失敗後重試前的等待時間
+var RetryInterval = time.Second
+
+// Run 持續參與選舉直到 ctx 結束：成為 leader 時執行 OnStartedLeading，
+// 失去領導權後重新參選；ctx 結束時若仍是 leader 會主動 Resign
+func Run(ctx context.Context, e Elector, cb Callbacks) error {
+	for {
+		if err := e.Campaign(ctx); err != nil {
+			if timex.Sleep(ctx, RetryInterval) != nil {
+				return nil
+			}
+			continue
+		}
+		// Campaign 成功的同時 ctx 可能已經結束：交出領導權，不要在 shutdown 之後才開始工作
+		if ctx.Err() != nil {
+			return e.Resign()
+		}
+
+		leaderCtx, cancel := context.WithCancel(ctx)
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			if cb.OnStartedLeading != nil {
+				cb.OnStartedLeading(leaderCtx)
+			}
+		}()
+
+		select {
+		case <-e.Lost():
+		case <-ctx.Done():
+		}
+		cancel()
+		<-done
+		if cb.OnStoppedLeading != nil {
+			cb.OnStoppedLeading()
+		}
+		if ctx.Err() != nil {
+			return e.Resign()
+		}
+	}
+}
@@ -0,0 +1,120 @@
+//go:build unix
+
+package election
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFileLockCampaignResign(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "leader.lock")
+	a, b := NewFileLock(path), NewFileLock(path)
+
+	assert.NoError(t, a.Campaign(context.Background()))
+	content, _ := os.ReadFile(path)
+	assert.Equal(t, strconv.Itoa(os.Getpid()), strings.TrimSpace(string(content)))
+
+	// b 在 a 持有鎖時無法成為 leader
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, b.Campaign(ctx), context.DeadlineExceeded)
+
+	lost := a.Lost()
+	assert.NoError(t, a.Resign())
+	select {
+	case <-lost:
+	default:
+		t.Fatal("Lost not closed after Resign")
+	}
+	assert.ErrorIs(t, a.Resign(), ErrNotLeader)
+
+	assert.NoError(t, b.Campaign(context.Background()))
+	assert.NoError(t, b.Resign())
+}
+
+func TestRunCallbacks(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "leader.lock")
+	e := NewFileLock(path)
+
+	var events []string
+	var mu sync.Mutex
+	add := func(s string) {
+		mu.Lock()
+		events = append(events, s)
+		mu.Unlock()
+	}
+	started := make(chan struct{}, 2)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() {
+		done <- Run(ctx, e, Callbacks{
+			OnStartedLeading: func(ctx context.Context) {
+				add("start")
+				started <- struct{}{}
+				<-ctx.Done()
+				add("ctx done")
+			},
+			OnStoppedLeading: func() { add("stop") },
+		})
+	}()
+
+	<-started
+	// 失去領導權（這裡以 Resign 模擬）後會重新參選
+	assert.NoError(t, e.Resign())
+	<-started
+	cancel()
+	assert.NoError(t, <-done)
+
+	assert.Equal(t, []string{"start", "ctx done", "stop", "start", "ctx done", "stop"}, events)
+	// ctx 結束時已主動 Resign，其他候選者可以立刻接手
+	other := NewFileLock(path)
+	assert.NoError(t, other.Campaign(context.Background()))
+	other.Resign()
+}
+
+// 多個候選者同時競爭時，任何時刻最多只有一個 leader
+func TestSingleLeader(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "leader.lock")
+	var leaders, maxLeaders, terms atomic.Int32
+	ctx, cancel := context.WithCancel(context.Background())
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			Run(ctx, NewFileLock(path), Callbacks{
+				OnStartedLeading: func(ctx context.Context) {
+					n := leaders.Add(1)
+					for {
+						m := maxLeaders.Load()
+						if n <= m || maxLeaders.CompareAndSwap(m, n) {
+							break
+						}
+					}
+					terms.Add(1)
+					select {
+					case <-time.After(20 * time.Millisecond):
+					case <-ctx.Done():
+					}
+					leaders.Add(-1)
+				},
+			})
+		}()
+	}
+	// OnStartedLeading 提早返回不會釋放領導權，只有 ctx 結束時才交棒
+	time.Sleep(100 * time.Millisecond)
+	cancel()
+	wg.Wait()
+	assert.Equal(t, int32(1), maxLeaders.Load())
+	assert.Equal(t, int32(1), terms.Load())
+}
@@ -0,0 +1,86 @@
+package election
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	clientv3 "go.etcd.io/etcd/client/v3"
+	"go.etcd.io/etcd/client/v3/concurrency"
+)
+
+// Etcd 以 etcd 的 lease 實作跨機器的選舉：leader 持續 keepalive，
+// process 當掉或網路中斷超過 TTL，lease 過期、key 被刪除，下一個候選者成為 leader
+type Etcd struct {
+	client *clientv3.Client
+	prefix string
+	value  string
+	ttl    int
+
+	mu       sync.Mutex
+	session  *concurrency.Session
+	election *concurrency.Election
+}
+
+var _ Elector = (*Etcd)(nil)
+
+// NewEtcd 建立候選者；prefix 相同的候選者互相競爭，value 通常是 hostname 或 instance ID
+func NewEtcd(client *clientv3.Client, prefix, value string, ttlSeconds int) *Etcd {
+	return &Etcd{client: client, prefix: prefix, value: value, ttl: ttlSeconds}
+}
+
+func (e *Etcd) Campaign(ctx context.Context) error {
+	session, err := concurrency.NewSession(e.client, concurrency.WithTTL(e.ttl), concurrency.WithContext(ctx))
+	if err != nil {
+		return err
+	}
+	election := concurrency.NewElection(session, e.prefix)
+	if err := election.Campaign(ctx, e.value); err != nil {
+		session.Close()
+		return err
+	}
+	e.mu.Lock()
+	e.session, e.election = session, election
+	e.mu.Unlock()
+	return nil
+}
+
+func (e *Etcd) Resign() error {
+	e.mu.Lock()
+	session, election := e.session, e.election
+	e.session, e.election = nil, nil
+	e.mu.Unlock()
+	if session == nil {
+		return ErrNotLeader
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	err := election.Resign(ctx)
+	// Close 會 revoke lease，Lost() 隨之關閉
+	if cerr := session.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+// Lost 在 lease 過期或被 revoke 時關閉
+func (e *Etcd) Lost() <-chan struct{} {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.session == nil {
+		return nil
+	}
+	return e.session.Done()
+}
+
+// Leader 回傳目前 leader 的 value
+func Leader(ctx context.Context, client *clientv3.Client, prefix string) (string, error) {
+	resp, err := client.Get(ctx, prefix+"/", clientv3.WithFirstCreate()...)
+	if err != nil {
+		return "", err
+	}
+	if len(resp.Kvs) == 0 {
+		return "", concurrency.ErrElectionNoLeader
+	}
+	return string(resp.Kvs[0].Value), nil
+}
@@ -0,0 +1,55 @@
+package election
+
+import (
+	"context"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	clientv3 "go.etcd.io/etcd/client/v3"
+)
+
+// 需要 etcd：ETCD_ENDPOINTS=localhost:2379 go test -run Etcd ./election
+func etcdClient(t *testing.T) *clientv3.Client {
+	endpoints := os.Getenv("ETCD_ENDPOINTS")
+	if endpoints == "" {
+		t.Skip("ETCD_ENDPOINTS not set")
+	}
+	c, err := clientv3.New(clientv3.Config{Endpoints: strings.Split(endpoints, ","), DialTimeout: 5 * time.Second})
+	require.NoError(t, err)
+	t.Cleanup(func() { c.Close() })
+	return c
+}
+
+func TestEtcdElection(t *testing.T) {
+	client := etcdClient(t)
+	prefix := "/go-learn/election/" + t.Name()
+	ctx := context.Background()
+
+	a := NewEtcd(client, prefix, "node-a", 5)
+	b := NewEtcd(client, prefix, "node-b", 5)
+	require.NoError(t, a.Campaign(ctx))
+	leader, err := Leader(ctx, client, prefix)
+	require.NoError(t, err)
+	assert.Equal(t, "node-a", leader)
+
+	won := make(chan error, 1)
+	go func() { won <- b.Campaign(ctx) }()
+	select {
+	case <-won:
+		t.Fatal("b became leader while a holds the lease")
+	case <-time.After(200 * time.Millisecond):
+	}
+
+	lost := a.Lost()
+	require.NoError(t, a.Resign())
+	<-lost
+	require.NoError(t, <-won)
+	leader, _ = Leader(ctx, client, prefix)
+	assert.Equal(t, "node-b", leader)
+	assert.NoError(t, b.Resign())
+	assert.ErrorIs(t, b.Resign(), ErrNotLeader)
+}
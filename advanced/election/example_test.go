@@ -0,0 +1,84 @@
+//go:build unix
+
+package election_test
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"advanced/election"
+)
+
+// every 是極簡的排程器：每隔 interval 執行一次 job，ctx 結束時停止
+func every(ctx context.Context, interval time.Duration, job func(n int) bool) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for n := 1; ; n++ {
+		select {
+		case <-ticker.C:
+			if !job(n) {
+				<-ctx.Done()
+				return
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// 兩個 node 都啟動排程，但只有 leader 真的執行；leader 關閉後另一個 node 接手
+func Example_leaderOnlyScheduler() {
+	dir, _ := os.MkdirTemp("", "election")
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "cron.lock")
+
+	node := func(ctx context.Context, name string, ran chan<- struct{}) <-chan error {
+		done := make(chan error)
+		go func() {
+			done <- election.Run(ctx, election.NewFileLock(path), election.Callbacks{
+				OnStartedLeading: func(ctx context.Context) {
+					fmt.Println(name, "is leader")
+					every(ctx, 10*time.Millisecond, func(n int) bool {
+						fmt.Println(name, "runs cleanup job", n)
+						if n == 2 {
+							ran <- struct{}{}
+							return false
+						}
+						return true
+					})
+				},
+				OnStoppedLeading: func() { fmt.Println(name, "stopped leading") },
+			})
+		}()
+		return done
+	}
+
+	ctxA, stopA := context.WithCancel(context.Background())
+	ranA := make(chan struct{})
+	doneA := node(ctxA, "node-a", ranA)
+	<-ranA
+
+	ctxB, stopB := context.WithCancel(context.Background())
+	ranB := make(chan struct{})
+	doneB := node(ctxB, "node-b", ranB)
+
+	// node-a 關機：主動 Resign，node-b 在下一次嘗試時成為 leader
+	stopA()
+	<-doneA
+	<-ranB
+	stopB()
+	<-doneB
+
+	// Output:
+	// node-a is leader
+	// node-a runs cleanup job 1
+	// node-a runs cleanup job 2
+	// node-a stopped leading
+	// node-b is leader
+	// node-b runs cleanup job 1
+	// node-b runs cleanup job 2
+	// node-b stopped leading
+}
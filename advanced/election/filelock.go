@@ -0,0 +1,74 @@
+package election
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// FileLock 以檔案鎖選出 leader，只適用於同一台機器（同一個檔案系統）上的 process
+type FileLock struct {
+	path string
+	poll time.Duration
+
+	mu   sync.Mutex
+	lock lockedFile
+	lost chan struct{}
+}
+
+var _ Elector = (*FileLock)(nil)
+
+func NewFileLock(path string) *FileLock {
+	return &FileLock{path: path, poll: 50 * time.Millisecond}
+}
+
+// Campaign 以非阻塞的方式反覆嘗試取得鎖：阻塞式的 flock 無法被 ctx 取消
+func (f *FileLock) Campaign(ctx context.Context) error {
+	ticker := time.NewTicker(f.poll)
+	defer ticker.Stop()
+	for {
+		// ticker 與 ctx.Done 同時就緒時 select 是隨機挑的，取消之後不能再拿鎖
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		lf, err := tryLock(f.path)
+		if err != nil {
+			return err
+		}
+		if lf != nil {
+			f.mu.Lock()
+			f.lock = lf
+			f.lost = make(chan struct{})
+			f.mu.Unlock()
+			return nil
+		}
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+func (f *FileLock) Resign() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.lock == nil {
+		return ErrNotLeader
+	}
+	err := f.lock.Unlock()
+	f.lock = nil
+	close(f.lost)
+	return err
+}
+
+// 檔案鎖只會因 Resign 或 process 結束而釋放
+func (f *FileLock) Lost() <-chan struct{} {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.lost
+}
+
+type lockedFile interface {
+	Unlock() error
+}
@@ -0,0 +1,5 @@
+//go:build !unix
+
+package election
+
+func tryLock(path string) (lockedFile, error) { return nil, ErrUnsupported }
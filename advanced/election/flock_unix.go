@@ -0,0 +1,40 @@
+//go:build unix
+
+package election
+
+import (
+	"errors"
+	"os"
+	"strconv"
+	"syscall"
+)
+
+type flockFile struct{ f *os.File }
+
+// tryLock 取得鎖時回傳 lockedFile；鎖被其他人持有時回傳 nil, nil。
+// flock 綁定在 open file description 上，同一個 process 開兩次也會互斥
+func tryLock(path string) (lockedFile, error) {
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	if err := syscall.Flock(int(f.Fd()), syscall.LOCK_EX|syscall.LOCK_NB); err != nil {
+		f.Close()
+		if errors.Is(err, syscall.EWOULDBLOCK) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	// 寫入 pid 方便除錯，鎖本身不依賴檔案內容
+	f.Truncate(0)
+	f.WriteAt([]byte(strconv.Itoa(os.Getpid())+"\n"), 0)
+	return &flockFile{f}, nil
+}
+
+func (l *flockFile) Unlock() error {
+	if err := syscall.Flock(int(l.f.Fd()), syscall.LOCK_UN); err != nil {
+		l.f.Close()
+		return err
+	}
+	return l.f.Close()
+}
@@ -0,0 +1,350 @@
+package eventlog
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"advanced/snapshot"
+	"advanced/timex"
+)
+
+/*
+只能附加（append-only）的事件紀錄，每筆紀錄有遞增的 offset：
+
+	Append ──> [0][1][2][3][4] ...
+	                 ^        ^
+	         cursor "proj"   cursor "mail"
+
+  - 多個讀者各自持有 Cursor，從自己的 offset 往後讀，互不影響；讀取不會移除資料
+  - Cursor 有名稱，Commit 會記住讀到哪裡，下次以同名建立 Cursor 從上次 Commit 的位置繼續
+  - Seek(0) 重播全部的事件，例如重建 read model（CQRS 的投影）或新加入的訂閱者補歷史
+  - Next 在沒有新資料時阻塞，Append 時喚醒（與 sync-ext/rcu 相同，關閉 changed channel 通知所有等待者）
+
+NewMemory 只存在記憶體；Open 另外寫到檔案（格式見 format.go），重新 Open 時讀回所有紀錄與 Commit 的 offset。
+
+Compact 依 key 壓縮：同一個 key 只保留最新的一筆，data 為 nil 的紀錄是刪除標記（tombstone），
+壓縮時連同它之前的同 key 紀錄一起移除。key 為空字串的紀錄不參與壓縮。
+offset 不會重新編號，壓縮後會有空號，讀取時直接跳到下一筆存在的紀錄；
+最後一筆紀錄永遠保留，重新 Open 時才能從它接續 offset。
+*/
+
+var (
+	ErrClosed    = errors.New("eventlog: closed")
+	ErrAnonymous = errors.New("eventlog: commit on anonymous cursor")
+)
+
+type Record struct {
+	Offset uint64
+	Time   time.Time
+	Key    string
+	Data   []byte // nil 表示 tombstone
+}
+
+type config struct {
+	sync  bool
+	clock timex.Clock
+}
+
+type Option func(*config)
+
+// WithSync 讓每次 Append 都 fsync，當機時不會遺失已回傳的紀錄；預設只寫入 OS 的 page cache
+func WithSync(on bool) Option {
+	return func(c *config) { c.sync = on }
+}
+
+// WithClock 設定 Record.Time 的時間來源，預設 timex.Real
+func WithClock(clock timex.Clock) Option {
+	return func(c *config) { c.clock = clock }
+}
+
+type Log struct {
+	cfg config
+
+	mu      sync.RWMutex
+	records []Record // 依 offset 排序，壓縮後可能有空號
+	next    uint64
+	changed chan struct{}
+	closed  bool
+	offsets map[string]uint64 // 各 cursor Commit 的位置
+
+	path string
+	f    *os.File
+	w    *bufio.Writer
+}
+
+func newLog(opts []Option) *Log {
+	cfg := config{clock: timex.Real{}}
+	for _, o := range opts {
+		o(&cfg)
+	}
+	return &Log{cfg: cfg, changed: make(chan struct{}), offsets: map[string]uint64{}}
+}
+
+// NewMemory 建立只存在記憶體的 log
+func NewMemory(opts ...Option) *Log {
+	return newLog(opts)
+}
+
+// Open 開啟（或建立）以檔案保存的 log，讀回既有的紀錄；最後一筆寫到一半的紀錄會被截掉
+func Open(path string, opts ...Option) (*Log, error) {
+	l := newLog(opts)
+	l.path = path
+	if err := snapshot.CleanTemp(path); err != nil {
+		return nil, err
+	}
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	records, good, err := decodeAll(f)
+	if err != nil {
+		if err := f.Truncate(good); err != nil {
+			f.Close()
+			return nil, err
+		}
+	}
+	if _, err := f.Seek(good, 0); err != nil {
+		f.Close()
+		return nil, err
+	}
+	l.records = records
+	if n := len(records); n > 0 {
+		l.next = records[n-1].Offset + 1
+	}
+	l.f, l.w = f, bufio.NewWriter(f)
+
+	if err := snapshot.ReadFile(l.offsetsPath(), &l.offsets); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		f.Close()
+		return nil, fmt.Errorf("eventlog: offsets: %w", err)
+	}
+	return l, nil
+}
+
+func (l *Log) offsetsPath() string { return l.path + ".offsets" }
+
+// Append 附加一筆紀錄並回傳它的 offset
+func (l *Log) Append(key string, data []byte) (uint64, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.closed {
+		return 0, ErrClosed
+	}
+	r := Record{Offset: l.next, Time: l.cfg.clock.Now(), Key: key}
+	if data != nil {
+		r.Data = append([]byte{}, data...)
+	}
+	if l.f != nil {
+		if err := l.write(r); err != nil {
+			return 0, err
+		}
+	}
+	l.records = append(l.records, r)
+	l.next++
+	close(l.changed)
+	l.changed = make(chan struct{})
+	return r.Offset, nil
+}
+
+func (l *Log) write(r Record) error {
+	if err := encode(l.w, r); err != nil {
+		return err
+	}
+	if err := l.w.Flush(); err != nil {
+		return err
+	}
+	if l.cfg.sync {
+		return l.f.Sync()
+	}
+	return nil
+}
+
+// Delete 附加 key 的 tombstone，下次 Compact 時移除 key 的所有紀錄
+func (l *Log) Delete(key string) (uint64, error) {
+	return l.Append(key, nil)
+}
+
+// Next 回傳下一筆 Append 會得到的 offset
+func (l *Log) Next() uint64 {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return l.next
+}
+
+// Len 回傳目前保留的紀錄數（壓縮後可能小於 Next）
+func (l *Log) Len() int {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return len(l.records)
+}
+
+// index 回傳第一筆 offset >= from 的位置，呼叫時需持有讀鎖
+func (l *Log) index(from uint64) int {
+	return sort.Search(len(l.records), func(i int) bool { return l.records[i].Offset >= from })
+}
+
+// Read 回傳 offset >= from 的紀錄，最多 max 筆（max <= 0 表示全部）；回傳的 Data 不可修改
+func (l *Log) Read(from uint64, max int) []Record {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	rs := l.records[l.index(from):]
+	if max > 0 && len(rs) > max {
+		rs = rs[:max]
+	}
+	return append([]Record(nil), rs...)
+}
+
+// Compact 依 key 壓縮，回傳移除的紀錄數；檔案以暫存檔 + rename 的方式整個改寫
+func (l *Log) Compact() (int, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.closed {
+		return 0, ErrClosed
+	}
+	latest := map[string]uint64{}
+	for _, r := range l.records {
+		if r.Key != "" {
+			latest[r.Key] = r.Offset
+		}
+	}
+	kept := make([]Record, 0, len(l.records))
+	for i, r := range l.records {
+		last := i == len(l.records)-1
+		if r.Key == "" || last || (latest[r.Key] == r.Offset && r.Data != nil) {
+			kept = append(kept, r)
+		}
+	}
+	removed := len(l.records) - len(kept)
+	if removed == 0 {
+		return 0, nil
+	}
+	if l.f != nil {
+		if err := l.rewrite(kept); err != nil {
+			return 0, err
+		}
+	}
+	l.records = kept
+	return removed, nil
+}
+
+// rewrite 把 records 寫到暫存檔，fsync 後 rename 取代原本的檔案，再改為 append 新檔；
+// 作法與 snapshot.WriteFile 相同，但寫的是 frame 而不是 gob，之後還要繼續 append
+func (l *Log) rewrite(records []Record) error {
+	tmp, err := os.CreateTemp(filepath.Dir(l.path), filepath.Base(l.path)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	w := bufio.NewWriter(tmp)
+	for _, r := range records {
+		if err := encode(w, r); err != nil {
+			tmp.Close()
+			return err
+		}
+	}
+	if err := w.Flush(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := os.Rename(tmp.Name(), l.path); err != nil {
+		tmp.Close()
+		return err
+	}
+	l.f.Close()
+	l.f, l.w = tmp, bufio.NewWriter(tmp)
+	return nil
+}
+
+// Close 喚醒所有等待中的 Cursor（回傳 ErrClosed）並關閉檔案
+func (l *Log) Close() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.closed {
+		return nil
+	}
+	l.closed = true
+	close(l.changed)
+	if l.f != nil {
+		return l.f.Close()
+	}
+	return nil
+}
+
+// Committed 回傳名為 name 的 cursor 上次 Commit 的位置，沒有的話回傳 0
+func (l *Log) Committed(name string) uint64 {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return l.offsets[name]
+}
+
+func (l *Log) commit(name string, offset uint64) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.offsets[name] = offset
+	if l.path == "" {
+		return nil
+	}
+	return snapshot.WriteFile(l.offsetsPath(), l.offsets)
+}
+
+// Cursor 是一個讀者的位置，不可由多個 goroutine 同時使用
+type Cursor struct {
+	log  *Log
+	name string
+	next uint64
+}
+
+// Cursor 回傳名為 name 的讀者，從上次 Commit 的位置開始；name 為空字串時從頭開始且不能 Commit
+func (l *Log) Cursor(name string) *Cursor {
+	return &Cursor{log: l, name: name, next: l.Committed(name)}
+}
+
+// Offset 回傳下一筆要讀的 offset
+func (c *Cursor) Offset() uint64 { return c.next }
+
+// Seek 移到 offset，Seek(0) 從頭重播
+func (c *Cursor) Seek(offset uint64) { c.next = offset }
+
+// Next 回傳下一筆紀錄，沒有新資料時等待；ctx 結束時回傳 ctx.Err()，log 關閉時回傳 ErrClosed
+func (c *Cursor) Next(ctx context.Context) (Record, error) {
+	for {
+		l := c.log
+		l.mu.RLock()
+		i := l.index(c.next)
+		if i < len(l.records) {
+			r := l.records[i]
+			l.mu.RUnlock()
+			c.next = r.Offset + 1
+			return r, nil
+		}
+		closed, changed := l.closed, l.changed
+		l.mu.RUnlock()
+		if closed {
+			return Record{}, ErrClosed
+		}
+		select {
+		case <-changed:
+		case <-ctx.Done():
+			return Record{}, ctx.Err()
+		}
+	}
+}
+
+// Commit 記住目前的位置，下次以同名建立的 Cursor 從這裡開始
+func (c *Cursor) Commit() error {
+	if c.name == "" {
+		return ErrAnonymous
+	}
+	return c.log.commit(c.name, c.next)
+}
@@ -0,0 +1,233 @@
+package eventlog
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"advanced/timex"
+)
+
+func appendAll(t *testing.T, l *Log, kvs ...string) {
+	t.Helper()
+	for i := 0; i < len(kvs); i += 2 {
+		var data []byte
+		if kvs[i+1] != "<nil>" {
+			data = []byte(kvs[i+1])
+		}
+		_, err := l.Append(kvs[i], data)
+		require.NoError(t, err)
+	}
+}
+
+func values(rs []Record) []string {
+	out := make([]string, len(rs))
+	for i, r := range rs {
+		out[i] = r.Key + "=" + string(r.Data)
+		if r.Data == nil {
+			out[i] = r.Key + "=<nil>"
+		}
+	}
+	return out
+}
+
+func offsets(rs []Record) []uint64 {
+	out := make([]uint64, len(rs))
+	for i, r := range rs {
+		out[i] = r.Offset
+	}
+	return out
+}
+
+func next(t *testing.T, c *Cursor) Record {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	r, err := c.Next(ctx)
+	require.NoError(t, err)
+	return r
+}
+
+func TestAppendRead(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+	l := NewMemory(WithClock(timex.NewFake(now)))
+	off, err := l.Append("a", []byte("1"))
+	require.NoError(t, err)
+	assert.Zero(t, off)
+	appendAll(t, l, "b", "2", "a", "3")
+
+	rs := l.Read(1, 0)
+	assert.Equal(t, []string{"b=2", "a=3"}, values(rs))
+	assert.Equal(t, now, rs[0].Time)
+	assert.Len(t, l.Read(0, 2), 2)
+	assert.Empty(t, l.Read(3, 0))
+	assert.Equal(t, uint64(3), l.Next())
+}
+
+// TestCursors 每個 cursor 有自己的位置，Commit 後同名的 cursor 從該處繼續，Seek(0) 重播
+func TestCursors(t *testing.T) {
+	l := NewMemory()
+	appendAll(t, l, "a", "1", "b", "2", "c", "3")
+
+	proj := l.Cursor("projection")
+	mail := l.Cursor("mailer")
+	assert.Equal(t, "a=1", values([]Record{next(t, proj)})[0])
+	assert.Equal(t, "b=2", values([]Record{next(t, proj)})[0])
+	assert.Equal(t, "a=1", values([]Record{next(t, mail)})[0], "互不影響")
+
+	require.NoError(t, proj.Commit())
+	assert.Equal(t, uint64(2), l.Committed("projection"))
+	assert.Equal(t, uint64(2), l.Cursor("projection").Offset())
+	assert.Zero(t, l.Cursor("mailer").Offset(), "mailer 沒有 Commit")
+
+	proj.Seek(0)
+	assert.Equal(t, uint64(0), next(t, proj).Offset, "重播")
+
+	assert.ErrorIs(t, l.Cursor("").Commit(), ErrAnonymous)
+}
+
+// TestNextWaits 沒有新資料時 Next 阻塞，Append 後喚醒；Close 時回傳 ErrClosed
+func TestNextWaits(t *testing.T) {
+	l := NewMemory()
+	c := l.Cursor("")
+	got := make(chan Record)
+	go func() {
+		r, err := c.Next(context.Background())
+		if err == nil {
+			got <- r
+		}
+	}()
+	select {
+	case <-got:
+		t.Fatal("Next returned before Append")
+	case <-time.After(20 * time.Millisecond):
+	}
+	appendAll(t, l, "k", "v")
+	assert.Equal(t, "k", (<-got).Key)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err := c.Next(ctx)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+	errc := make(chan error)
+	go func() {
+		_, err := c.Next(context.Background())
+		errc <- err
+	}()
+	require.NoError(t, l.Close())
+	assert.ErrorIs(t, <-errc, ErrClosed)
+	_, err = l.Append("k", nil)
+	assert.ErrorIs(t, err, ErrClosed)
+}
+
+func TestCompact(t *testing.T) {
+	l := NewMemory()
+	appendAll(t, l,
+		"a", "1", // 0
+		"b", "1", // 1
+		"a", "2", // 2
+		"", "x", // 3 沒有 key，保留
+		"c", "1", // 4
+		"c", "<nil>", // 5 tombstone
+		"b", "2", // 6
+		"d", "<nil>", // 7 最後一筆永遠保留
+	)
+	removed, err := l.Compact()
+	require.NoError(t, err)
+	assert.Equal(t, 4, removed)
+	rs := l.Read(0, 0)
+	assert.Equal(t, []uint64{2, 3, 6, 7}, offsets(rs))
+	assert.Equal(t, []string{"a=2", "=x", "b=2", "d=<nil>"}, values(rs))
+
+	c := l.Cursor("")
+	c.Seek(4)
+	assert.Equal(t, uint64(6), next(t, c).Offset, "跳過壓縮掉的空號")
+
+	off, err := l.Append("e", []byte("1"))
+	require.NoError(t, err)
+	assert.Equal(t, uint64(8), off, "offset 不會重用")
+
+	removed, err = l.Compact()
+	require.NoError(t, err)
+	assert.Equal(t, 1, removed, "d 的 tombstone 不再是最後一筆")
+}
+
+func TestFileReopen(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "events.log")
+	l, err := Open(path, WithSync(true))
+	require.NoError(t, err)
+	appendAll(t, l, "a", "1", "b", "", "c", "<nil>")
+	c := l.Cursor("proj")
+	next(t, c)
+	require.NoError(t, c.Commit())
+	require.NoError(t, l.Close())
+
+	l, err = Open(path)
+	require.NoError(t, err)
+	rs := l.Read(0, 0)
+	assert.Equal(t, []string{"a=1", "b=", "c=<nil>"}, values(rs))
+	assert.NotNil(t, rs[1].Data, "空的 data 與 tombstone 不同")
+	assert.Equal(t, uint64(1), l.Cursor("proj").Offset())
+	appendAll(t, l, "d", "4")
+	assert.Equal(t, uint64(4), l.Next())
+	require.NoError(t, l.Close())
+}
+
+// TestTornWrite 最後一筆只寫了一半：Open 截掉它，之後的 Append 接在完整的紀錄後面
+func TestTornWrite(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "events.log")
+	l, err := Open(path)
+	require.NoError(t, err)
+	appendAll(t, l, "a", "1", "b", "2")
+	require.NoError(t, l.Close())
+
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	require.NoError(t, os.Truncate(path, info.Size()-3))
+
+	l, err = Open(path)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a=1"}, values(l.Read(0, 0)))
+	appendAll(t, l, "c", "3")
+	require.NoError(t, l.Close())
+
+	l, err = Open(path)
+	require.NoError(t, err)
+	defer l.Close()
+	rs := l.Read(0, 0)
+	assert.Equal(t, []string{"a=1", "c=3"}, values(rs))
+	assert.Equal(t, []uint64{0, 1}, offsets(rs))
+}
+
+func TestFileCompact(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "events.log")
+	l, err := Open(path)
+	require.NoError(t, err)
+	appendAll(t, l, "a", "1", "a", "2", "a", "3", "b", "1")
+	before, err := os.Stat(path)
+	require.NoError(t, err)
+	removed, err := l.Compact()
+	require.NoError(t, err)
+	assert.Equal(t, 2, removed)
+	appendAll(t, l, "a", "4")
+	require.NoError(t, l.Close())
+
+	after, err := os.Stat(path)
+	require.NoError(t, err)
+	assert.Less(t, after.Size(), before.Size()+before.Size()/4)
+
+	l, err = Open(path)
+	require.NoError(t, err)
+	defer l.Close()
+	rs := l.Read(0, 0)
+	assert.Equal(t, []uint64{2, 3, 4}, offsets(rs))
+	assert.Equal(t, []string{"a=3", "b=1", "a=4"}, values(rs))
+	matches, _ := filepath.Glob(path + ".tmp-*")
+	assert.Empty(t, matches, "沒有留下暫存檔")
+}
@@ -0,0 +1,93 @@
+package eventlog
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"io"
+	"time"
+)
+
+/*
+檔案格式：一筆紀錄一個 frame，依序 append
+
+	+---------+---------+---------------------------------------------------+
+	| len (4) | crc (4) | offset (8) | time (8) | keylen (4) | key | data     |
+	+---------+---------+---------------------------------------------------+
+
+crc 涵蓋 len 之後的 payload。append 寫到一半當機只會留下最後一個不完整的 frame（torn write），
+Open 時讀到第一個不完整或 crc 錯誤的 frame 就把檔案截斷到它之前。
+data 為 nil 與空 slice 無法區分，因此以 keylen 的最高位元標記 tombstone（data 為 nil）。
+*/
+
+const (
+	frameHeader = 8
+	tombstone   = 1 << 31
+	maxFrame    = 64 << 20
+)
+
+var errTorn = errors.New("eventlog: torn frame")
+
+func encode(w io.Writer, r Record) error {
+	keyLen := uint32(len(r.Key))
+	if r.Data == nil {
+		keyLen |= tombstone
+	}
+	payload := make([]byte, 20, 20+len(r.Key)+len(r.Data))
+	binary.BigEndian.PutUint64(payload[0:8], r.Offset)
+	binary.BigEndian.PutUint64(payload[8:16], uint64(r.Time.UnixNano()))
+	binary.BigEndian.PutUint32(payload[16:20], keyLen)
+	payload = append(payload, r.Key...)
+	payload = append(payload, r.Data...)
+
+	var h [frameHeader]byte
+	binary.BigEndian.PutUint32(h[0:4], uint32(len(payload)))
+	binary.BigEndian.PutUint32(h[4:8], crc32.ChecksumIEEE(payload))
+	if _, err := w.Write(h[:]); err != nil {
+		return err
+	}
+	_, err := w.Write(payload)
+	return err
+}
+
+// decodeAll 讀出所有完整的紀錄，回傳最後一個完整 frame 結束的位置；之後若有殘缺的資料，err 為 errTorn
+func decodeAll(r io.Reader) (records []Record, good int64, err error) {
+	br := bufio.NewReader(r)
+	for {
+		var h [frameHeader]byte
+		if _, err := io.ReadFull(br, h[:]); err != nil {
+			if err == io.EOF {
+				return records, good, nil
+			}
+			return records, good, errTorn
+		}
+		n := binary.BigEndian.Uint32(h[0:4])
+		if n < 20 || n > maxFrame {
+			return records, good, errTorn
+		}
+		payload := make([]byte, n)
+		if _, err := io.ReadFull(br, payload); err != nil {
+			return records, good, errTorn
+		}
+		if crc32.ChecksumIEEE(payload) != binary.BigEndian.Uint32(h[4:8]) {
+			return records, good, errTorn
+		}
+		keyLen := binary.BigEndian.Uint32(payload[16:20])
+		isTombstone := keyLen&tombstone != 0
+		keyLen &^= tombstone
+		if 20+keyLen > n {
+			return records, good, errTorn
+		}
+		rec := Record{
+			Offset: binary.BigEndian.Uint64(payload[0:8]),
+			Time:   time.Unix(0, int64(binary.BigEndian.Uint64(payload[8:16]))),
+			Key:    string(payload[20 : 20+keyLen]),
+		}
+		if !isTombstone {
+			rec.Data = append([]byte{}, payload[20+keyLen:]...)
+		}
+		records = append(records, rec)
+		good += frameHeader + int64(n)
+	}
+}
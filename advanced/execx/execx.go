@@ -0,0 +1,232 @@
+package execx
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"os"
+	"os/exec"
+	"strconv"
+	"sync"
+	"time"
+)
+
+/*
+os/exec 的包裝：
+	- context timeout：超時後砍掉整個 process group（包含子孫 process）
+	- stdout/stderr 逐行透過 channel 即時送出，同時保留完整輸出
+	- 以 Option 設定環境變數、工作目錄
+*/
+
+type Stream int
+
+const (
+	Stdout Stream = iota
+	Stderr
+)
+
+func (s Stream) String() string {
+	if s == Stderr {
+		return "stderr"
+	}
+	return "stdout"
+}
+
+type Line struct {
+	Stream Stream
+	Text   string
+}
+
+type Result struct {
+	ExitCode int
+	Stdout   []byte
+	Stderr   []byte
+	Duration time.Duration
+}
+
+type config struct {
+	env     []string
+	dir     string
+	timeout time.Duration
+	stdin   io.Reader
+}
+
+type Option func(*config)
+
+// WithEnv 追加環境變數，格式為 "KEY=VALUE"，會繼承目前 process 的環境變數
+func WithEnv(kv ...string) Option {
+	return func(c *config) {
+		c.env = append(c.env, kv...)
+	}
+}
+
+func WithDir(dir string) Option {
+	return func(c *config) {
+		c.dir = dir
+	}
+}
+
+func WithTimeout(d time.Duration) Option {
+	return func(c *config) {
+		c.timeout = d
+	}
+}
+
+func WithStdin(r io.Reader) Option {
+	return func(c *config) {
+		c.stdin = r
+	}
+}
+
+// Process 是已啟動的外部命令
+type Process struct {
+	cmd    *exec.Cmd
+	lines  chan Line
+	done   chan struct{}
+	cancel context.CancelFunc
+	start  time.Time
+	stdout bytes.Buffer
+	stderr bytes.Buffer
+	result *Result
+	err    error
+}
+
+// Start 啟動命令，呼叫端必須讀完 Lines()（或直接呼叫 Wait）否則輸出會卡住
+func Start(ctx context.Context, name string, args []string, opts ...Option) (*Process, error) {
+	cfg := &config{}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+
+	cancel := context.CancelFunc(func() {})
+	if cfg.timeout > 0 {
+		ctx, cancel = context.WithTimeout(ctx, cfg.timeout)
+	}
+
+	cmd := exec.CommandContext(ctx, name, args...)
+	cmd.Dir = cfg.dir
+	cmd.Stdin = cfg.stdin
+	if len(cfg.env) > 0 {
+		cmd.Env = append(os.Environ(), cfg.env...)
+	}
+	setProcessGroup(cmd)
+	// process 結束後，背景執行的子孫 process 可能還握著 stdout/stderr；
+	// 最多再等這麼久，exec 就關閉 pipe 讓 Wait 返回
+	cmd.WaitDelay = time.Second
+
+	// 不用 StdoutPipe：它要求讀完才能 Wait，子孫握著 pipe 時讀取永遠等不到 EOF。
+	// 交給 exec 複製到 io.Pipe，Wait 與讀取同時進行，WaitDelay 才有作用
+	stdout, stdoutW := io.Pipe()
+	stderr, stderrW := io.Pipe()
+	cmd.Stdout, cmd.Stderr = stdoutW, stderrW
+
+	p := &Process{
+		cmd:    cmd,
+		lines:  make(chan Line, 64),
+		done:   make(chan struct{}),
+		cancel: cancel,
+		start:  time.Now(),
+	}
+	if err := cmd.Start(); err != nil {
+		cancel()
+		return nil, err
+	}
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go p.scan(&wg, stdout, Stdout, &p.stdout)
+	go p.scan(&wg, stderr, Stderr, &p.stderr)
+	go func() {
+		err := cmd.Wait()
+		// Wait 返回時 exec 已複製完所有輸出，關閉寫入端讓 scan 讀到 EOF
+		stdoutW.Close()
+		stderrW.Close()
+		wg.Wait()
+		p.finish(ctx, err)
+		close(p.lines)
+		close(p.done)
+	}()
+	return p, nil
+}
+
+func (p *Process) scan(wg *sync.WaitGroup, r io.Reader, s Stream, buf *bytes.Buffer) {
+	defer wg.Done()
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 64*1024), 1024*1024)
+	for sc.Scan() {
+		text := sc.Text()
+		buf.WriteString(text)
+		buf.WriteByte('\n')
+		p.lines <- Line{Stream: s, Text: text}
+	}
+	// 行太長等錯誤時把剩下的讀掉，避免子 process 卡在寫入
+	_, _ = io.Copy(io.Discard, r)
+}
+
+func (p *Process) finish(ctx context.Context, err error) {
+	defer p.cancel()
+	res := &Result{
+		ExitCode: p.cmd.ProcessState.ExitCode(),
+		Stdout:   p.stdout.Bytes(),
+		Stderr:   p.stderr.Bytes(),
+		Duration: time.Since(p.start),
+	}
+	p.result = res
+	if ctx.Err() != nil {
+		// 被取消時回傳 context 的錯誤比 "signal: killed" 更有意義
+		p.err = ctx.Err()
+		return
+	}
+	if errors.Is(err, exec.ErrWaitDelay) {
+		// 命令本身成功結束，只是留下仍握著輸出的背景 process
+		err = nil
+	}
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		p.err = &ExitError{Code: res.ExitCode, Stderr: res.Stderr}
+		return
+	}
+	p.err = err
+}
+
+// Lines 依序回傳 stdout/stderr 的每一行，命令結束後 channel 會被關閉
+func (p *Process) Lines() <-chan Line {
+	return p.lines
+}
+
+// Wait 丟棄尚未讀取的行，等待命令結束
+func (p *Process) Wait() (*Result, error) {
+	for range p.lines {
+	}
+	<-p.done
+	return p.result, p.err
+}
+
+func (p *Process) Pid() int {
+	return p.cmd.Process.Pid
+}
+
+// Run 執行命令直到結束，回傳完整輸出
+func Run(ctx context.Context, name string, args []string, opts ...Option) (*Result, error) {
+	p, err := Start(ctx, name, args, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return p.Wait()
+}
+
+// ExitError 表示命令以非 0 的 exit code 結束
+type ExitError struct {
+	Code   int
+	Stderr []byte
+}
+
+func (e *ExitError) Error() string {
+	msg := "execx: exit status " + strconv.Itoa(e.Code)
+	if len(e.Stderr) > 0 {
+		msg += ": " + string(bytes.TrimSpace(e.Stderr))
+	}
+	return msg
+}
@@ -0,0 +1,169 @@
+package execx
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+/*
+測試用的 helper binary：重新執行測試程式本身，並只跑 TestHelperProcess，
+這是標準庫 os/exec 測試使用的技巧，不需要依賴系統上有哪些命令。
+*/
+
+func helper(args ...string) (string, []string) {
+	return os.Args[0], append([]string{"-test.run=TestHelperProcess", "--"}, args...)
+}
+
+func helperEnv() Option {
+	return WithEnv("GO_WANT_HELPER_PROCESS=1")
+}
+
+func TestHelperProcess(t *testing.T) {
+	if os.Getenv("GO_WANT_HELPER_PROCESS") != "1" {
+		return
+	}
+	defer os.Exit(0)
+
+	args := os.Args
+	for len(args) > 0 && args[0] != "--" {
+		args = args[1:]
+	}
+	args = args[1:]
+	switch args[0] {
+	case "echo":
+		for _, a := range args[1:] {
+			fmt.Println(a)
+		}
+	case "mixed":
+		fmt.Println("out1")
+		fmt.Fprintln(os.Stderr, "err1")
+		fmt.Println("out2")
+	case "exit":
+		code, _ := strconv.Atoi(args[1])
+		fmt.Fprintln(os.Stderr, "bad things")
+		os.Exit(code)
+	case "env":
+		fmt.Println(os.Getenv(args[1]))
+	case "pwd":
+		wd, _ := os.Getwd()
+		fmt.Println(wd)
+	case "sleep":
+		d, _ := time.ParseDuration(args[1])
+		fmt.Println("sleeping")
+		time.Sleep(d)
+	case "spawn":
+		// 啟動一個會一直握著 stdout 的孫 process
+		cmd := exec.Command(os.Args[0], "-test.run=TestHelperProcess", "--", "sleep", "10s")
+		cmd.Stdout = os.Stdout
+		_ = cmd.Start()
+		fmt.Println("spawned")
+		time.Sleep(10 * time.Second)
+	case "background":
+		// 像 sh -c "sleep 5 &"：孫 process 在背景握著 stdout，自己馬上結束
+		cmd := exec.Command(os.Args[0], "-test.run=TestHelperProcess", "--", "sleep", "5s")
+		cmd.Stdout = os.Stdout
+		_ = cmd.Start()
+		fmt.Println("started")
+	}
+}
+
+func TestRun(t *testing.T) {
+	name, args := helper("echo", "hello", "world")
+	res, err := Run(context.Background(), name, args, helperEnv())
+	assert.NoError(t, err)
+	assert.Equal(t, 0, res.ExitCode)
+	assert.Equal(t, "hello\nworld\n", string(res.Stdout))
+}
+
+func TestRunExitCode(t *testing.T) {
+	name, args := helper("exit", "3")
+	res, err := Run(context.Background(), name, args, helperEnv())
+	assert.Equal(t, 3, res.ExitCode)
+	var exitErr *ExitError
+	assert.ErrorAs(t, err, &exitErr)
+	assert.Equal(t, 3, exitErr.Code)
+	assert.Equal(t, "execx: exit status 3: bad things", err.Error())
+}
+
+func TestEnvAndDir(t *testing.T) {
+	name, args := helper("env", "EXECX_TEST")
+	res, err := Run(context.Background(), name, args, helperEnv(), WithEnv("EXECX_TEST=42"))
+	assert.NoError(t, err)
+	assert.Equal(t, "42\n", string(res.Stdout))
+
+	dir := t.TempDir()
+	name, args = helper("pwd")
+	res, err = Run(context.Background(), name, args, helperEnv(), WithDir(dir))
+	assert.NoError(t, err)
+	want, _ := os.Stat(dir)
+	got, _ := os.Stat(string(res.Stdout[:len(res.Stdout)-1]))
+	assert.True(t, os.SameFile(want, got))
+}
+
+func TestStreamLines(t *testing.T) {
+	name, args := helper("mixed")
+	p, err := Start(context.Background(), name, args, helperEnv())
+	assert.NoError(t, err)
+
+	var stdout, stderr []string
+	for l := range p.Lines() {
+		if l.Stream == Stdout {
+			stdout = append(stdout, l.Text)
+		} else {
+			stderr = append(stderr, l.Text)
+		}
+	}
+	res, err := p.Wait()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"out1", "out2"}, stdout)
+	assert.Equal(t, []string{"err1"}, stderr)
+	assert.Equal(t, "err1\n", string(res.Stderr))
+}
+
+func TestTimeout(t *testing.T) {
+	name, args := helper("sleep", "10s")
+	start := time.Now()
+	res, err := Run(context.Background(), name, args, helperEnv(), WithTimeout(100*time.Millisecond))
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Less(t, time.Since(start), 5*time.Second)
+	assert.Equal(t, "sleeping\n", string(res.Stdout))
+}
+
+// 沒有砍 process group 的話，孫 process 握著 stdout，Run 會等到它結束才返回
+func TestCancelKillsProcessGroup(t *testing.T) {
+	name, args := helper("spawn")
+	ctx, cancel := context.WithCancel(context.Background())
+	p, err := Start(ctx, name, args, helperEnv())
+	assert.NoError(t, err)
+
+	l := <-p.Lines()
+	assert.Equal(t, "spawned", l.Text)
+	start := time.Now()
+	cancel()
+	_, err = p.Wait()
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Less(t, time.Since(start), 3*time.Second)
+}
+
+// 沒有 timeout 時，背景的孫 process 握著 stdout 也不會讓 Run 等到它結束：WaitDelay 之後 pipe 被關閉
+func TestBackgroundGrandchild(t *testing.T) {
+	name, args := helper("background")
+	start := time.Now()
+	res, err := Run(context.Background(), name, args, helperEnv())
+	assert.NoError(t, err)
+	assert.Less(t, time.Since(start), 3*time.Second)
+	assert.Equal(t, 0, res.ExitCode)
+	assert.Contains(t, string(res.Stdout), "started\n")
+}
+
+func TestStartError(t *testing.T) {
+	_, err := Run(context.Background(), "execx-no-such-binary", nil)
+	assert.Error(t, err)
+}
@@ -0,0 +1,8 @@
+//go:build !unix
+
+package execx
+
+import "os/exec"
+
+// 非 unix 平台沒有 process group，維持 exec.CommandContext 預設行為
+func setProcessGroup(cmd *exec.Cmd) {}
@@ -0,0 +1,17 @@
+//go:build unix
+
+package execx
+
+import (
+	"os/exec"
+	"syscall"
+)
+
+// 讓子 process 自成一個 process group，取消時對整個 group 送 SIGKILL，
+// 否則只會砍掉 shell 本身，shell 啟動的子 process 會變成孤兒繼續執行
+func setProcessGroup(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	cmd.Cancel = func() error {
+		return syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+	}
+}
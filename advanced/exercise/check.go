@@ -0,0 +1,35 @@
+package exercise
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+)
+
+var (
+	ErrSkeletonPasses = errors.New("exercise: tests pass against the skeleton")
+	ErrSolutionFails  = errors.New("exercise: tests fail against the reference solution")
+)
+
+// Check 確認練習可以交付：兩組 build tag 都能編譯、skeleton 的測試失敗、參考解答的測試通過
+func Check(ctx context.Context, dir string) error {
+	for _, tag := range []string{"exercise", "solution"} {
+		if out, err := goCmd(ctx, dir, "vet", "-tags", tag, "."); err != nil {
+			return fmt.Errorf("exercise: go vet -tags %s: %w\n%s", tag, err, out)
+		}
+	}
+	if out, err := goCmd(ctx, dir, "test", "-count=1", "-tags", "exercise", "."); err == nil {
+		return fmt.Errorf("%w\n%s", ErrSkeletonPasses, out)
+	}
+	if out, err := goCmd(ctx, dir, "test", "-count=1", "-tags", "solution", "."); err != nil {
+		return fmt.Errorf("%w: %v\n%s", ErrSolutionFails, err, out)
+	}
+	return nil
+}
+
+func goCmd(ctx context.Context, dir string, args ...string) ([]byte, error) {
+	cmd := exec.CommandContext(ctx, "go", args...)
+	cmd.Dir = dir
+	return cmd.CombinedOutput()
+}
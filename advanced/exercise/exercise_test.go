@@ -0,0 +1,158 @@
+package exercise
+
+import (
+	"context"
+	"flag"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// go test ./exercise -update 重新產生 golden 檔
+var update = flag.Bool("update", false, "update golden files")
+
+func mustFuncs(t *testing.T, sigs ...string) []Func {
+	var fs []Func
+	for _, sig := range sigs {
+		f, err := ParseFunc(sig)
+		require.NoError(t, err, sig)
+		fs = append(fs, f)
+	}
+	return fs
+}
+
+func TestParseFunc(t *testing.T) {
+	f, err := ParseFunc("Divide(a, b int) (int, error)")
+	require.NoError(t, err)
+	assert.Equal(t, []Param{{Name: "a", Type: "int"}, {Name: "b", Type: "int"}}, f.Params)
+	assert.Equal(t, []Param{{Type: "int", Zero: "0"}, {Type: "error", Zero: "nil"}}, f.Results)
+	assert.True(t, f.ReturnsError())
+
+	f, err = ParseFunc("func Fetch(context.Context, *url.URL, ...string) (time.Duration, [2]byte, map[string]int)")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"in0", "in1", "in2"}, []string{f.Params[0].Name, f.Params[1].Name, f.Params[2].Name})
+	assert.True(t, f.Variadic())
+	assert.Equal(t, []string{"*new(time.Duration)", "*new([2]byte)", "nil"}, []string{f.Results[0].Zero, f.Results[1].Zero, f.Results[2].Zero})
+	assert.Equal(t, []string{"context", "time", "url"}, f.Packages)
+
+	f, err = ParseFunc("Map[T, U any](xs []T, fn func(T) U) []U")
+	require.NoError(t, err)
+	assert.Equal(t, "[T, U any]", f.TypeParams)
+	assert.Equal(t, "nil", f.Results[0].Zero)
+
+	for _, bad := range []string{"reverse(s string) string", "(r *R) Get() int", "Broken(", "A(); func B()"} {
+		_, err := ParseFunc(bad)
+		assert.ErrorIs(t, err, ErrInvalidSpec, bad)
+	}
+}
+
+func TestPackageName(t *testing.T) {
+	assert.Equal(t, "ringbuffer", Spec{Topic: "ring-buffer"}.Package())
+	assert.Equal(t, "ringbuffer", Spec{Topic: "ring_buffer"}.Package())
+
+	_, err := Render(Spec{Topic: "Ring Buffer", Funcs: mustFuncs(t, "A()")})
+	assert.ErrorIs(t, err, ErrInvalidSpec)
+	_, err = Render(Spec{Topic: "ring"})
+	assert.ErrorIs(t, err, ErrInvalidSpec)
+	_, err = Render(Spec{Topic: "ring", Funcs: mustFuncs(t, "A()", "A(x int)")})
+	assert.ErrorIs(t, err, ErrInvalidSpec)
+}
+
+func TestRenderGolden(t *testing.T) {
+	files, err := Render(Spec{
+		Topic: "word_count",
+		Title: "計算文字出現次數",
+		Path:  "exercises/word_count",
+		Funcs: mustFuncs(t,
+			"Count(text string, name string) (map[string]int, error)",
+			"Top(counts map[string]int, n int) ([]string, int)",
+			"Fetch(ctx context.Context, u *url.URL) ([]byte, error)",
+			"Merge[K comparable](ms ...map[K]int) map[K]int",
+			"Print(counts map[string]int)",
+		),
+	})
+	require.NoError(t, err)
+
+	var names []string
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var b strings.Builder
+	for _, name := range names {
+		b.WriteString("-- " + name + " --\n")
+		b.Write(files[name])
+	}
+
+	path := filepath.Join("testdata", "word_count.golden")
+	if *update {
+		require.NoError(t, os.WriteFile(path, []byte(b.String()), 0o644))
+	}
+	want, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, string(want), b.String())
+}
+
+const reverseSolution = `//go:build solution
+
+package reverse
+
+// Reverse 的參考解答
+func Reverse(s string) string {
+	r := []rune(s)
+	for i, j := 0, len(r)-1; i < j; i, j = i+1, j-1 {
+		r[i], r[j] = r[j], r[i]
+	}
+	return string(r)
+}
+`
+
+// TestScaffoldCheck 在暫存的 module 中產生練習，逐步完成並以 Check 驗證每個階段
+func TestScaffoldCheck(t *testing.T) {
+	if testing.Short() {
+		t.Skip("runs go vet and go test in a temporary module")
+	}
+	// 複製這個 module 的 go.mod / go.sum，產生的測試才找得到 testify
+	root := t.TempDir()
+	for _, name := range []string{"go.mod", "go.sum"} {
+		b, err := os.ReadFile(filepath.Join("..", name))
+		require.NoError(t, err)
+		require.NoError(t, os.WriteFile(filepath.Join(root, name), b, 0o644))
+	}
+	spec := Spec{Topic: "reverse", Title: "反轉字串", Funcs: mustFuncs(t, "Reverse(s string) string")}
+	paths, err := Scaffold(root, spec)
+	require.NoError(t, err)
+	assert.Len(t, paths, 4)
+	_, err = Scaffold(root, spec)
+	assert.ErrorIs(t, err, ErrExists)
+
+	ctx := context.Background()
+	dir := filepath.Join(root, "reverse")
+	assert.ErrorIs(t, Check(ctx, dir), ErrSolutionFails, "剛產生時參考解答還沒寫")
+
+	cmd := exec.Command("go", "test", "./...")
+	cmd.Dir = root
+	out, err := cmd.CombinedOutput()
+	assert.NoError(t, err, "沒有 build tag 時不會跑練習的測試：%s", out)
+
+	// 出題者補上解答與測試案例
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "reverse_solution.go"), []byte(reverseSolution), 0o644))
+	testPath := filepath.Join(dir, "reverse_test.go")
+	test, err := os.ReadFile(testPath)
+	require.NoError(t, err)
+	filled := strings.Replace(string(test), "// TODO: 加入測試案例", `{name: "ascii", s: "abc", want: "cba"},
+		{name: "unicode", s: "你好", want: "好你"},`, 1)
+	require.NoError(t, os.WriteFile(testPath, []byte(filled), 0o644))
+	require.NoError(t, Check(ctx, dir))
+
+	// skeleton 不小心寫成答案時，Check 要提醒
+	skeleton := strings.Replace(reverseSolution, "//go:build solution", "//go:build !solution", 1)
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "reverse.go"), []byte(skeleton), 0o644))
+	assert.ErrorIs(t, Check(ctx, dir), ErrSkeletonPasses)
+}
@@ -0,0 +1,340 @@
+package exercise
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+)
+
+/*
+Scaffold 產生的目錄（以 ring_buffer 為例）：
+
+	ring_buffer/
+	  doc.go                    練習說明（TODO），沒有 build tag
+	  ringbuffer.go             //go:build !solution   給學習者實作的 skeleton，回傳零值
+	  ringbuffer_solution.go    //go:build solution    參考解答，由出題者完成
+	  ringbuffer_test.go        //go:build exercise || solution
+
+測試帶 build tag，是因為 skeleton 的測試本來就會失敗，不能讓 go test ./... 變紅：
+	go test -tags exercise ./ring_buffer    學習者：實作 skeleton 直到通過
+	go test -tags solution ./ring_buffer    CI：參考解答必須通過
+Check 同時確認這兩件事（skeleton 失敗、解答通過），出題者完成後執行一次即可。
+
+測試是 table-driven 的骨架：依簽名產生參數與期望值欄位、呼叫與比對，
+出題者只需要填入測試案例；泛型函式無法自動產生呼叫，只留下 TODO。
+*/
+
+var templates = template.Must(template.New("").Funcs(template.FuncMap{
+	"signature": signature,
+	"zeros":     zeros,
+	"field":     fieldName,
+	"fieldType": func(p Param) string { return strings.Replace(p.Type, "...", "[]", 1) },
+	"call":      call,
+	"gots":      gots,
+	"wants":     wants,
+	// 路徑第一段含有 "." 的是第三方套件，與標準庫分成兩組
+	"thirdParty": func(p string) bool { return strings.Contains(strings.SplitN(p, "/", 2)[0], ".") },
+}).Parse(`
+{{define "doc"}}/*
+Package {{.Package}}：{{.Title}}
+
+TODO: 說明這個練習的背景知識、要實作的行為與需要注意的邊界情況。
+
+練習：實作 {{.Package}}.go 直到測試通過
+
+	go test -tags exercise ./{{.Path}}
+
+參考解答：
+
+	go test -tags solution ./{{.Path}}
+*/
+package {{.Package}}
+{{end}}
+
+{{define "skeleton"}}//go:build !solution
+
+package {{.Package}}
+
+{{template "imports" .}}
+{{range .Funcs}}
+// {{.Name}} TODO: 說明要實作的行為
+func {{signature .}} {
+	// TODO: 實作
+{{- with zeros .}}
+	{{.}}
+{{- end}}
+}
+{{end}}{{end}}
+
+{{define "solution"}}//go:build solution
+
+package {{.Package}}
+
+{{template "imports" .}}
+{{range .Funcs}}
+// {{.Name}} 的參考解答
+func {{signature .}} {
+	panic("exercise: reference solution for {{.Name}} is not written yet")
+}
+{{end}}{{end}}
+
+{{define "test"}}//go:build exercise || solution
+
+package {{.Package}}
+
+{{template "imports" .}}
+{{range .Funcs}}{{if .Generic}}
+func Test{{.Name}}(t *testing.T) {
+	// TODO: {{.Name}} 是泛型函式，選定型別參數後寫成 table-driven 測試
+	t.Fatal("exercise: write tests for {{.Name}}")
+}
+{{else}}
+func Test{{.Name}}(t *testing.T) {
+	tests := []struct {
+		name string
+{{- range .Params}}
+		{{field .}} {{fieldType .}}
+{{- end}}
+{{- range wants .}}
+		{{.Want}} {{.Type}}
+{{- end}}
+{{- if .ReturnsError}}
+		wantErr bool
+{{- end}}
+	}{
+		// TODO: 加入測試案例
+	}
+	require.NotEmpty(t, tests, "exercise: add test cases for {{.Name}}")
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			{{call .}}
+{{- if .ReturnsError}}
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+{{- end}}
+{{- range wants .}}
+			assert.Equal(t, tt.{{.Want}}, {{.Got}})
+{{- end}}
+{{- if not .Results}}
+			// TODO: {{.Name}} 沒有回傳值，檢查它的副作用
+{{- end}}
+		})
+	}
+}
+{{end}}{{end}}{{end}}
+
+{{define "imports"}}{{if .Imports}}import (
+{{- range .Imports}}{{if not (thirdParty .)}}
+	{{printf "%q" .}}
+{{- end}}{{end}}
+{{range .Imports}}{{if thirdParty .}}
+	{{printf "%q" .}}
+{{- end}}{{end}}
+)
+{{end}}{{end}}
+`))
+
+type data struct {
+	Spec
+	Package string
+	Imports []string
+}
+
+// Render 產生練習的所有檔案，key 為檔名
+func Render(s Spec) (map[string][]byte, error) {
+	if err := s.validate(); err != nil {
+		return nil, err
+	}
+	if s.Title == "" {
+		s.Title = "TODO: 一行說明"
+	}
+	if s.Path == "" {
+		s.Path = s.Topic
+	}
+	pkg := s.Package()
+	imports := s.imports()
+	files := map[string]struct {
+		tmpl    string
+		imports []string
+	}{
+		"doc.go":             {"doc", nil},
+		pkg + ".go":          {"skeleton", imports},
+		pkg + "_solution.go": {"solution", imports},
+		pkg + "_test.go":     {"test", append([]string{"testing", "github.com/stretchr/testify/assert", "github.com/stretchr/testify/require"}, imports...)},
+	}
+
+	out := map[string][]byte{}
+	for name, f := range files {
+		code, err := render(f.tmpl, data{Spec: s, Package: pkg, Imports: f.imports})
+		if err != nil {
+			return nil, fmt.Errorf("exercise: %s: %w", name, err)
+		}
+		out[name] = code
+	}
+	return out, nil
+}
+
+// render 執行兩次：第一次列出所有 import，parse 後只保留實際用到的再輸出一次
+func render(name string, d data) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := templates.ExecuteTemplate(&buf, name, d); err != nil {
+		return nil, err
+	}
+	used, err := usedImports(buf.Bytes(), d.Imports)
+	if err != nil {
+		return nil, fmt.Errorf("%w\n%s", err, buf.Bytes())
+	}
+	d.Imports = used
+	buf.Reset()
+	if err := templates.ExecuteTemplate(&buf, name, d); err != nil {
+		return nil, err
+	}
+	return format.Source(buf.Bytes())
+}
+
+func usedImports(src []byte, imports []string) ([]string, error) {
+	file, err := parser.ParseFile(token.NewFileSet(), "", src, 0)
+	if err != nil {
+		return nil, err
+	}
+	names := map[string]bool{}
+	ast.Inspect(file, func(n ast.Node) bool {
+		if sel, ok := n.(*ast.SelectorExpr); ok {
+			if id, ok := sel.X.(*ast.Ident); ok {
+				names[id.Name] = true
+			}
+		}
+		return true
+	})
+	var used []string
+	for _, p := range imports {
+		if names[path.Base(p)] {
+			used = append(used, p)
+		}
+	}
+	return used, nil
+}
+
+// Scaffold 在 dir 底下建立 s.Topic 目錄並寫入所有檔案，回傳檔案路徑；目錄已存在時回傳 ErrExists
+func Scaffold(dir string, s Spec) ([]string, error) {
+	files, err := Render(s)
+	if err != nil {
+		return nil, err
+	}
+	target := filepath.Join(dir, s.Topic)
+	if _, err := os.Stat(target); err == nil {
+		return nil, fmt.Errorf("%w: %s", ErrExists, target)
+	}
+	if err := os.MkdirAll(target, 0o755); err != nil {
+		return nil, err
+	}
+	var paths []string
+	for name, code := range files {
+		p := filepath.Join(target, name)
+		if err := os.WriteFile(p, code, 0o644); err != nil {
+			return nil, err
+		}
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+	return paths, nil
+}
+
+func signature(f Func) string {
+	var params []string
+	for _, p := range f.Params {
+		params = append(params, p.Name+" "+p.Type)
+	}
+	s := f.Name + f.TypeParams + "(" + strings.Join(params, ", ") + ")"
+	switch len(f.Results) {
+	case 0:
+	case 1:
+		s += " " + f.Results[0].Type
+	default:
+		var rs []string
+		for _, r := range f.Results {
+			rs = append(rs, r.Type)
+		}
+		s += " (" + strings.Join(rs, ", ") + ")"
+	}
+	return s
+}
+
+func zeros(f Func) string {
+	if len(f.Results) == 0 {
+		return ""
+	}
+	var zs []string
+	for _, r := range f.Results {
+		zs = append(zs, r.Zero)
+	}
+	return "return " + strings.Join(zs, ", ")
+}
+
+// fieldName 避免參數名稱與測試表格保留的欄位（name、want*）衝突
+func fieldName(p Param) string {
+	if p.Name == "name" || strings.HasPrefix(p.Name, "want") {
+		return p.Name + "Arg"
+	}
+	return p.Name
+}
+
+type want struct {
+	Want, Got, Type string
+}
+
+// wants 為每個非 error 的回傳值命名：want/got、want1/got1...
+func wants(f Func) []want {
+	var out []want
+	for i, r := range f.Results {
+		if i == len(f.Results)-1 && f.ReturnsError() {
+			break
+		}
+		suffix := ""
+		if i > 0 {
+			suffix = strconv.Itoa(i)
+		}
+		out = append(out, want{Want: "want" + suffix, Got: "got" + suffix, Type: r.Type})
+	}
+	return out
+}
+
+func gots(f Func) string {
+	var names []string
+	for _, w := range wants(f) {
+		names = append(names, w.Got)
+	}
+	if f.ReturnsError() {
+		names = append(names, "err")
+	}
+	return strings.Join(names, ", ")
+}
+
+func call(f Func) string {
+	var args []string
+	for _, p := range f.Params {
+		args = append(args, "tt."+fieldName(p))
+	}
+	c := f.Name + "(" + strings.Join(args, ", ")
+	if f.Variadic() {
+		c += "..."
+	}
+	c += ")"
+	if g := gots(f); g != "" {
+		return g + " := " + c
+	}
+	return c
+}
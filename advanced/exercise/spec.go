@@ -0,0 +1,241 @@
+package exercise
+
+import (
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+/*
+一個練習由 Spec 描述：主題（目錄名稱）、標題，以及要實作的函式簽名。
+簽名用 Go 的語法寫，例如：
+
+	Reverse(s string) string
+	Divide(a, b int) (int, error)
+	Map[T, U any](xs []T, f func(T) U) []U
+
+ParseFunc 以 go/parser 解析簽名，同時算出每個回傳值的零值（skeleton 用），
+以及簽名用到的套件（context.Context → "context"）。
+*/
+
+var (
+	ErrInvalidSpec = errors.New("exercise: invalid spec")
+	ErrExists      = errors.New("exercise: directory already exists")
+)
+
+type Spec struct {
+	Topic   string   // 目錄名稱，例如 "ring_buffer"
+	Title   string   // 一行說明
+	Path    string   // 相對 module 的路徑，只用在產生的說明中
+	Funcs   []Func   // 要實作的函式
+	Imports []string // 額外的 import 路徑，用於 knownImports 以外的套件
+}
+
+// Package 由主題轉成合法的套件名稱："ring-buffer" → "ringbuffer"
+func (s Spec) Package() string {
+	return strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' || r >= '0' && r <= '9' {
+			return r
+		}
+		return -1
+	}, strings.ToLower(s.Topic))
+}
+
+var topicRE = regexp.MustCompile(`^[a-z][a-z0-9_-]*$`)
+
+func (s Spec) validate() error {
+	if !topicRE.MatchString(s.Topic) {
+		return fmt.Errorf("%w: topic %q must match %s", ErrInvalidSpec, s.Topic, topicRE)
+	}
+	if len(s.Funcs) == 0 {
+		return fmt.Errorf("%w: at least one function is required", ErrInvalidSpec)
+	}
+	seen := map[string]bool{}
+	for _, f := range s.Funcs {
+		if seen[f.Name] {
+			return fmt.Errorf("%w: duplicate function %s", ErrInvalidSpec, f.Name)
+		}
+		seen[f.Name] = true
+	}
+	return nil
+}
+
+type Param struct {
+	Name string
+	Type string // 變長參數為 "...T"
+	Zero string // 只有回傳值使用
+}
+
+type Func struct {
+	Name       string
+	TypeParams string // 例如 "[T, U any]"，沒有時為空字串
+	Params     []Param
+	Results    []Param
+	Packages   []string // 簽名中用到的套件名稱
+}
+
+func (f Func) Generic() bool {
+	return f.TypeParams != ""
+}
+
+// Variadic 回報最後一個參數是否為 ...T
+func (f Func) Variadic() bool {
+	return len(f.Params) > 0 && strings.HasPrefix(f.Params[len(f.Params)-1].Type, "...")
+}
+
+// ReturnsError 回報最後一個回傳值是否為 error
+func (f Func) ReturnsError() bool {
+	return len(f.Results) > 0 && f.Results[len(f.Results)-1].Type == "error"
+}
+
+// ParseFunc 解析 "Name[TP](params) results" 形式的簽名
+func ParseFunc(sig string) (Func, error) {
+	src := "package p\nfunc " + strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(sig), "func ")) + " {}\n"
+	file, err := parser.ParseFile(token.NewFileSet(), "", src, 0)
+	if err != nil {
+		return Func{}, fmt.Errorf("%w: %q: %v", ErrInvalidSpec, sig, err)
+	}
+	if len(file.Decls) != 1 {
+		return Func{}, fmt.Errorf("%w: %q: expected a single function", ErrInvalidSpec, sig)
+	}
+	decl, ok := file.Decls[0].(*ast.FuncDecl)
+	if !ok || decl.Recv != nil {
+		return Func{}, fmt.Errorf("%w: %q: expected a function without receiver", ErrInvalidSpec, sig)
+	}
+	if !ast.IsExported(decl.Name.Name) {
+		return Func{}, fmt.Errorf("%w: %q: function must be exported so tests read like the API", ErrInvalidSpec, sig)
+	}
+
+	f := Func{Name: decl.Name.Name}
+	typeParams := map[string]bool{}
+	if tp := decl.Type.TypeParams; tp != nil {
+		var parts []string
+		for _, field := range tp.List {
+			var names []string
+			for _, n := range field.Names {
+				names = append(names, n.Name)
+				typeParams[n.Name] = true
+			}
+			parts = append(parts, strings.Join(names, ", ")+" "+types.ExprString(field.Type))
+		}
+		f.TypeParams = "[" + strings.Join(parts, ", ") + "]"
+	}
+	f.Params = fields(decl.Type.Params, "in", nil)
+	f.Results = fields(decl.Type.Results, "", typeParams)
+
+	pkgs := map[string]bool{}
+	ast.Inspect(decl.Type, func(n ast.Node) bool {
+		if sel, ok := n.(*ast.SelectorExpr); ok {
+			if id, ok := sel.X.(*ast.Ident); ok {
+				pkgs[id.Name] = true
+			}
+		}
+		return true
+	})
+	for p := range pkgs {
+		f.Packages = append(f.Packages, p)
+	}
+	sort.Strings(f.Packages)
+	return f, nil
+}
+
+// fields 展開 "a, b int" 為兩個 Param；沒有名稱時以 prefix 加序號命名（prefix 為空則不命名）
+func fields(list *ast.FieldList, prefix string, typeParams map[string]bool) []Param {
+	if list == nil {
+		return nil
+	}
+	var out []Param
+	for _, field := range list.List {
+		typ := types.ExprString(field.Type)
+		zero := ""
+		if typeParams != nil {
+			zero = zeroValue(field.Type)
+		}
+		if len(field.Names) == 0 {
+			name := ""
+			if prefix != "" {
+				name = prefix + strconv.Itoa(len(out))
+			}
+			out = append(out, Param{Name: name, Type: typ, Zero: zero})
+			continue
+		}
+		for _, n := range field.Names {
+			out = append(out, Param{Name: n.Name, Type: typ, Zero: zero})
+		}
+	}
+	return out
+}
+
+// zeroValue 回傳型別的零值運算式；無法從語法判斷的具名型別使用 *new(T)，任何型別都成立
+func zeroValue(expr ast.Expr) string {
+	switch t := expr.(type) {
+	case *ast.Ident:
+		switch t.Name {
+		case "string":
+			return `""`
+		case "bool":
+			return "false"
+		case "error", "any":
+			return "nil"
+		case "int", "int8", "int16", "int32", "int64",
+			"uint", "uint8", "uint16", "uint32", "uint64", "uintptr",
+			"float32", "float64", "complex64", "complex128", "byte", "rune":
+			return "0"
+		}
+	case *ast.StarExpr, *ast.MapType, *ast.ChanType, *ast.FuncType, *ast.InterfaceType:
+		return "nil"
+	case *ast.ArrayType:
+		if t.Len == nil {
+			return "nil"
+		}
+	}
+	return "*new(" + types.ExprString(expr) + ")"
+}
+
+// knownImports 是簽名中常見、套件名稱與路徑不同的標準庫套件
+var knownImports = map[string]string{
+	"atomic":   "sync/atomic",
+	"filepath": "path/filepath",
+	"http":     "net/http",
+	"json":     "encoding/json",
+	"rand":     "math/rand",
+	"url":      "net/url",
+	"big":      "math/big",
+	"fs":       "io/fs",
+}
+
+// imports 回傳所有函式簽名需要的 import 路徑
+func (s Spec) imports() []string {
+	byName := map[string]string{}
+	for _, p := range s.Imports {
+		byName[path.Base(p)] = p
+	}
+	set := map[string]bool{}
+	for _, f := range s.Funcs {
+		for _, name := range f.Packages {
+			p, ok := byName[name]
+			if !ok {
+				p, ok = knownImports[name]
+			}
+			if !ok {
+				// 單一層的標準庫套件，例如 context、time、io
+				p = name
+			}
+			set[p] = true
+		}
+	}
+	var out []string
+	for p := range set {
+		out = append(out, p)
+	}
+	sort.Strings(out)
+	return out
+}
@@ -0,0 +1,146 @@
+package featureflag
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"advanced/timex"
+)
+
+/*
+Client 把所有 flag 放在一個不可變的 Snapshot，以 atomic.Pointer 整份替換：
+  - 讀取（每個 request 都會呼叫）完全不需要 lock
+  - reload 時不會出現「一半新一半舊」的狀態
+  - 新設定解析失敗時保留舊的 Snapshot，不會因為一份壞掉的設定把所有 flag 關掉
+
+Override 是給測試用的：優先於 Snapshot，回傳的 restore 可直接交給 t.Cleanup。
+*/
+
+type Snapshot struct {
+	Version string
+	Loaded  time.Time
+	flags   map[string]*Flag
+}
+
+func newSnapshot(flags []Flag, version string, loaded time.Time) *Snapshot {
+	// 複製一份再取位址，呼叫端之後修改自己的 slice 不會改到已發布的 snapshot
+	flags = append([]Flag(nil), flags...)
+	s := &Snapshot{Version: version, Loaded: loaded, flags: make(map[string]*Flag, len(flags))}
+	for i := range flags {
+		s.flags[flags[i].Name] = &flags[i]
+	}
+	return s
+}
+
+func (s *Snapshot) Flag(name string) (Flag, bool) {
+	f, ok := s.flags[name]
+	if !ok {
+		return Flag{}, false
+	}
+	return *f, true
+}
+
+func (s *Snapshot) Len() int { return len(s.flags) }
+
+type Client struct {
+	snap      atomic.Pointer[Snapshot]
+	onChange  func(old, new *Snapshot)
+	onError   func(error)
+	mu        sync.RWMutex
+	overrides map[string]bool
+}
+
+type Option func(*Client)
+
+// WithOnChange 在 Snapshot 被替換後呼叫
+func WithOnChange(fn func(old, new *Snapshot)) Option {
+	return func(c *Client) { c.onChange = fn }
+}
+
+// WithErrorHandler 接收 Watch 過程中載入失敗的錯誤
+func WithErrorHandler(fn func(error)) Option {
+	return func(c *Client) { c.onError = fn }
+}
+
+func New(flags []Flag, opts ...Option) *Client {
+	c := &Client{overrides: make(map[string]bool)}
+	for _, opt := range opts {
+		opt(c)
+	}
+	c.snap.Store(newSnapshot(flags, "", time.Now()))
+	return c
+}
+
+// Snapshot 回傳目前的設定，同一個 Snapshot 內的多次判斷保證一致
+func (c *Client) Snapshot() *Snapshot {
+	return c.snap.Load()
+}
+
+// Enabled 判斷 flag 對 u 是否開啟；不存在的 flag 視為關閉
+func (c *Client) Enabled(name string, u User) bool {
+	c.mu.RLock()
+	v, ok := c.overrides[name]
+	c.mu.RUnlock()
+	if ok {
+		return v
+	}
+	f, ok := c.snap.Load().flags[name]
+	return ok && f.Evaluate(u)
+}
+
+// Override 強制 flag 的結果，回傳的函式會還原成覆寫前的狀態
+func (c *Client) Override(name string, on bool) (restore func()) {
+	c.mu.Lock()
+	prev, had := c.overrides[name]
+	c.overrides[name] = on
+	c.mu.Unlock()
+	return func() {
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		if had {
+			c.overrides[name] = prev
+		} else {
+			delete(c.overrides, name)
+		}
+	}
+}
+
+// Update 直接替換整份設定
+func (c *Client) Update(flags []Flag, version string) {
+	next := newSnapshot(flags, version, time.Now())
+	old := c.snap.Swap(next)
+	if c.onChange != nil {
+		c.onChange(old, next)
+	}
+}
+
+// Refresh 從 src 載入一次，內容沒變時不替換 Snapshot
+func (c *Client) Refresh(ctx context.Context, src Source) error {
+	flags, version, err := src.Fetch(ctx, c.snap.Load().Version)
+	if errors.Is(err, ErrNotModified) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	c.Update(flags, version)
+	return nil
+}
+
+// Watch 每隔 interval 呼叫一次 Refresh，直到 ctx 被取消；第一次載入失敗會直接回傳
+func (c *Client) Watch(ctx context.Context, src Source, interval time.Duration) error {
+	if err := c.Refresh(ctx, src); err != nil {
+		return err
+	}
+	for {
+		if err := timex.Sleep(ctx, interval); err != nil {
+			return nil
+		}
+		if err := c.Refresh(ctx, src); err != nil && c.onError != nil && ctx.Err() == nil {
+			c.onError(err)
+		}
+	}
+}
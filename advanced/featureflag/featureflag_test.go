@@ -0,0 +1,203 @@
+package featureflag
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"advanced/timex"
+)
+
+func TestEvaluate(t *testing.T) {
+	c := New([]Flag{
+		{Name: "on", Enabled: true},
+		{Name: "off", Enabled: false, Users: []string{"u1"}},
+		{Name: "beta", Enabled: true, Percentage: Percent(0), Users: []string{"u1"},
+			Rules: []Rule{{Attribute: "plan", Values: []string{"pro", "team"}}}},
+	})
+	u1 := User{ID: "u1"}
+	u2 := User{ID: "u2"}
+	pro := User{ID: "u3", Attributes: map[string]string{"plan": "pro"}}
+
+	assert.True(t, c.Enabled("on", u2))
+	assert.False(t, c.Enabled("off", u1), "kill switch 優先於名單")
+	assert.True(t, c.Enabled("beta", u1))
+	assert.True(t, c.Enabled("beta", pro))
+	assert.False(t, c.Enabled("beta", u2))
+	assert.False(t, c.Enabled("missing", u1))
+}
+
+func TestPercentageBucketing(t *testing.T) {
+	f := Flag{Name: "rollout", Enabled: true, Percentage: Percent(30)}
+	on := map[string]bool{}
+	for i := 0; i < 10000; i++ {
+		id := fmt.Sprintf("user-%d", i)
+		on[id] = f.Evaluate(User{ID: id})
+		assert.Equal(t, on[id], f.Evaluate(User{ID: id}), "同一個使用者結果固定")
+	}
+	count := 0
+	for _, v := range on {
+		if v {
+			count++
+		}
+	}
+	assert.InDelta(t, 3000, count, 300)
+
+	// 調高比例時，原本開啟的使用者仍維持開啟
+	f.Percentage = Percent(60)
+	for id, v := range on {
+		if v {
+			assert.True(t, f.Evaluate(User{ID: id}), id)
+		}
+	}
+	assert.False(t, f.Evaluate(User{}), "沒有 ID 無法分桶")
+	assert.NotEqual(t, Bucket("a", "user-1"), Bucket("b", "user-1"))
+}
+
+func TestOverride(t *testing.T) {
+	c := New([]Flag{{Name: "x", Enabled: false}})
+	restore := c.Override("x", true)
+	assert.True(t, c.Enabled("x", User{}))
+	inner := c.Override("x", false)
+	assert.False(t, c.Enabled("x", User{}))
+	inner()
+	assert.True(t, c.Enabled("x", User{}))
+	restore()
+	assert.False(t, c.Enabled("x", User{}))
+
+	t.Run("cleanup", func(t *testing.T) {
+		t.Cleanup(c.Override("y", true))
+		assert.True(t, c.Enabled("y", User{}))
+	})
+	assert.False(t, c.Enabled("y", User{}))
+}
+
+func TestSnapshotOwnsFlags(t *testing.T) {
+	flags := []Flag{{Name: "x", Enabled: true}}
+	c := New(flags)
+	flags[0].Enabled = false
+	assert.True(t, c.Enabled("x", User{}), "修改傳入的 slice 不影響 snapshot")
+}
+
+func TestParse(t *testing.T) {
+	flags, err := Parse([]byte(`{"flags":[{"name":"a","enabled":true,"percentage":12.5}]}`))
+	require.NoError(t, err)
+	assert.Equal(t, 12.5, *flags[0].Percentage)
+
+	for _, bad := range []string{
+		`{"flags":[{"enabled":true}]}`,
+		`{"flags":[{"name":"a","percentage":101}]}`,
+		`{"flags":[{"name":"a"},{"name":"a"}]}`,
+	} {
+		_, err := Parse([]byte(bad))
+		assert.ErrorIs(t, err, ErrInvalidFlag, bad)
+	}
+	_, err = Parse([]byte(`{`))
+	assert.Error(t, err)
+}
+
+func TestFileReload(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "flags.json")
+	write := func(s string) { require.NoError(t, os.WriteFile(path, []byte(s), 0o644)) }
+	write(`{"flags":[{"name":"a","enabled":false}]}`)
+
+	var changes atomic.Int32
+	var errs []error
+	c := New(nil,
+		WithOnChange(func(old, new *Snapshot) { changes.Add(1) }),
+		WithErrorHandler(func(err error) { errs = append(errs, err) }))
+	src := FileSource{Path: path}
+	ctx := context.Background()
+
+	require.NoError(t, c.Refresh(ctx, src))
+	assert.False(t, c.Enabled("a", User{}))
+	require.NoError(t, c.Refresh(ctx, src))
+	assert.Equal(t, int32(1), changes.Load(), "內容沒變不替換")
+
+	write(`{"flags":[{"name":"a","enabled":true}]}`)
+	require.NoError(t, c.Refresh(ctx, src))
+	assert.True(t, c.Enabled("a", User{}))
+
+	before := c.Snapshot()
+	write(`{"flags":[{"name":"a","percentage":500}]}`)
+	assert.ErrorIs(t, c.Refresh(ctx, src), ErrInvalidFlag)
+	assert.Same(t, before, c.Snapshot(), "壞掉的設定不會取代舊的")
+	assert.True(t, c.Enabled("a", User{}))
+}
+
+func TestWatch(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "flags.json")
+	require.NoError(t, os.WriteFile(path, []byte(`{"flags":[{"name":"a","enabled":false}]}`), 0o644))
+
+	clock := timex.NewFake(time.Now())
+	ctx, cancel := context.WithCancel(timex.WithClock(context.Background(), clock))
+	reloaded := make(chan struct{}, 1)
+	c := New(nil, WithOnChange(func(_, _ *Snapshot) { reloaded <- struct{}{} }))
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		assert.NoError(t, c.Watch(ctx, FileSource{Path: path}, time.Minute))
+	}()
+	<-reloaded
+	assert.False(t, c.Enabled("a", User{}))
+
+	require.NoError(t, os.WriteFile(path, []byte(`{"flags":[{"name":"a","enabled":true}]}`), 0o644))
+	clock.BlockUntil(1)
+	clock.Advance(time.Minute)
+	<-reloaded
+	assert.True(t, c.Enabled("a", User{}))
+
+	cancel()
+	wg.Wait()
+}
+
+func TestHTTPSource(t *testing.T) {
+	var body atomic.Value
+	body.Store(`{"flags":[{"name":"a","enabled":true}]}`)
+	var hits, notModified atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/broken" {
+			http.Error(w, "boom", http.StatusInternalServerError)
+			return
+		}
+		hits.Add(1)
+		etag := fmt.Sprintf(`"%d"`, len(body.Load().(string)))
+		if r.Header.Get("If-None-Match") == etag {
+			notModified.Add(1)
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", etag)
+		_, _ = w.Write([]byte(body.Load().(string)))
+	}))
+	defer srv.Close()
+
+	c := New(nil)
+	src := HTTPSource{URL: srv.URL}
+	ctx := context.Background()
+	require.NoError(t, c.Refresh(ctx, src))
+	assert.True(t, c.Enabled("a", User{}))
+	require.NoError(t, c.Refresh(ctx, src))
+	assert.Equal(t, int32(1), notModified.Load())
+
+	body.Store(`{"flags":[{"name":"a","enabled":false}]}`)
+	require.NoError(t, c.Refresh(ctx, src))
+	assert.False(t, c.Enabled("a", User{}))
+	assert.Equal(t, int32(3), hits.Load())
+
+	err := c.Refresh(ctx, HTTPSource{URL: srv.URL + "/broken", Client: srv.Client()})
+	assert.ErrorContains(t, err, "500")
+	assert.False(t, c.Enabled("a", User{}), "失敗時保留舊設定")
+}
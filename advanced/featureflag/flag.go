@@ -0,0 +1,130 @@
+package featureflag
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/fnv"
+)
+
+/*
+Flag 的判斷順序：
+ 1. Enabled = false → 一律關閉（kill switch，出事時一鍵關掉）
+ 2. 使用者在 Users 名單，或符合任一 Rule → 開啟（targeted）
+ 3. 沒有設定 Percentage → 開啟（單純的 boolean flag）
+ 4. 依使用者 ID 分桶，bucket < Percentage → 開啟（percentage rollout）
+
+分桶：hash("<flag>:<user id>") 對應到 [0, 100)，同一個使用者對同一個 flag 永遠落在同一桶，
+所以 rollout 從 10% 調到 20% 時，原本開啟的使用者仍是開啟；
+hash 加上 flag 名稱，不同 flag 的 10% 不會剛好是同一批人。
+
+設定檔（JSON）：
+	{"flags": [
+		{"name": "new-checkout", "enabled": true, "percentage": 25},
+		{"name": "beta-ui", "enabled": true, "users": ["u1"], "rules": [{"attribute": "plan", "values": ["pro"]}], "percentage": 0}
+	]}
+*/
+
+var (
+	ErrInvalidFlag = errors.New("featureflag: invalid flag")
+	ErrNotModified = errors.New("featureflag: not modified")
+)
+
+type Rule struct {
+	Attribute string   `json:"attribute"`
+	Values    []string `json:"values"`
+}
+
+type Flag struct {
+	Name       string   `json:"name"`
+	Enabled    bool     `json:"enabled"`
+	Percentage *float64 `json:"percentage,omitempty"`
+	Users      []string `json:"users,omitempty"`
+	Rules      []Rule   `json:"rules,omitempty"`
+}
+
+// User 是判斷 flag 時的對象；ID 為空時 percentage flag 一律關閉
+type User struct {
+	ID         string
+	Attributes map[string]string
+}
+
+// Percent 方便建立 Percentage 欄位
+func Percent(p float64) *float64 { return &p }
+
+func (f *Flag) validate() error {
+	if f.Name == "" {
+		return fmt.Errorf("%w: missing name", ErrInvalidFlag)
+	}
+	if p := f.Percentage; p != nil && (*p < 0 || *p > 100) {
+		return fmt.Errorf("%w: %s: percentage %v out of range", ErrInvalidFlag, f.Name, *p)
+	}
+	return nil
+}
+
+func (f *Flag) Evaluate(u User) bool {
+	if !f.Enabled {
+		return false
+	}
+	if f.targets(u) {
+		return true
+	}
+	if f.Percentage == nil {
+		return true
+	}
+	if u.ID == "" {
+		return false
+	}
+	return Bucket(f.Name, u.ID) < *f.Percentage
+}
+
+func (f *Flag) targets(u User) bool {
+	for _, id := range f.Users {
+		if u.ID != "" && id == u.ID {
+			return true
+		}
+	}
+	for _, r := range f.Rules {
+		v, ok := u.Attributes[r.Attribute]
+		if !ok {
+			continue
+		}
+		for _, want := range r.Values {
+			if v == want {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Bucket 把使用者對應到 [0, 100)，精度 0.01%
+func Bucket(flag, userID string) float64 {
+	h := fnv.New32a()
+	h.Write([]byte(flag))
+	h.Write([]byte{':'})
+	h.Write([]byte(userID))
+	return float64(h.Sum32()%10000) / 100
+}
+
+// Parse 解析設定檔並檢查每個 flag，名稱重複也視為錯誤
+func Parse(data []byte) ([]Flag, error) {
+	var doc struct {
+		Flags []Flag `json:"flags"`
+	}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("featureflag: parse: %w", err)
+	}
+	seen := make(map[string]bool, len(doc.Flags))
+	for i := range doc.Flags {
+		f := &doc.Flags[i]
+		if err := f.validate(); err != nil {
+			return nil, err
+		}
+		if seen[f.Name] {
+			return nil, fmt.Errorf("%w: duplicate flag %s", ErrInvalidFlag, f.Name)
+		}
+		seen[f.Name] = true
+	}
+	return doc.Flags, nil
+}
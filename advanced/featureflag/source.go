@@ -0,0 +1,91 @@
+package featureflag
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+)
+
+/*
+Source 負責取得設定，version 用來判斷內容是否改變：
+  - FileSource：version 是檔案內容的 sha256，檔案被重寫但內容相同也不會觸發 onChange
+  - HTTPSource：version 是回應的 ETag，以 If-None-Match 發送，server 回 304 時不需要重新解析；
+    server 沒有給 ETag 時退回使用內容的 sha256
+*/
+
+type Source interface {
+	// Fetch 回傳最新的設定；內容與 prev 版本相同時回傳 ErrNotModified
+	Fetch(ctx context.Context, prev string) (flags []Flag, version string, err error)
+}
+
+type FileSource struct {
+	Path string
+}
+
+func (s FileSource) Fetch(_ context.Context, prev string) ([]Flag, string, error) {
+	data, err := os.ReadFile(s.Path)
+	if err != nil {
+		return nil, "", err
+	}
+	return parseVersioned(data, contentVersion(data), prev)
+}
+
+func contentVersion(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func parseVersioned(data []byte, version, prev string) ([]Flag, string, error) {
+	if version == prev {
+		return nil, prev, ErrNotModified
+	}
+	flags, err := Parse(data)
+	if err != nil {
+		return nil, "", err
+	}
+	return flags, version, nil
+}
+
+type HTTPSource struct {
+	URL    string
+	Client *http.Client // nil 時使用 http.DefaultClient
+}
+
+func (s HTTPSource) Fetch(ctx context.Context, prev string) ([]Flag, string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.URL, nil)
+	if err != nil {
+		return nil, "", err
+	}
+	if prev != "" {
+		req.Header.Set("If-None-Match", prev)
+	}
+	client := s.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, "", err
+	}
+	defer resp.Body.Close()
+	switch resp.StatusCode {
+	case http.StatusNotModified:
+		return nil, prev, ErrNotModified
+	case http.StatusOK:
+	default:
+		return nil, "", fmt.Errorf("featureflag: GET %s: %s", s.URL, resp.Status)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 4<<20))
+	if err != nil {
+		return nil, "", err
+	}
+	version := resp.Header.Get("ETag")
+	if version == "" {
+		version = contentVersion(data)
+	}
+	return parseVersioned(data, version, prev)
+}
@@ -0,0 +1,53 @@
+package example
+
+import (
+	"context"
+	"time"
+)
+
+/*
+genpattern 的使用範例，example_genpattern.go 由下面的 go:generate 產生：
+	go generate ./genpattern/example
+修改這個檔案中帶有 //genpattern: 的型別後需要重新產生，TestGeneratedUpToDate 會檢查是否忘記。
+*/
+
+//go:generate go run advanced/cmd/genpattern -src $GOFILE
+
+//genpattern:singleton
+type Registry struct {
+	services map[string]string
+}
+
+//genpattern:options
+type config struct {
+	addr    string        `default:"\":8080\""`
+	timeout time.Duration `default:"5 * time.Second"`
+	tags    []string
+	started time.Time `genpattern:"-"`
+}
+
+//genpattern:mock
+type Store interface {
+	Get(ctx context.Context, key string) ([]byte, error)
+	Put(ctx context.Context, key string, value []byte) error
+	Keys(prefix string, exclude ...string) []string
+}
+
+type Server struct {
+	cfg   config
+	store Store
+}
+
+func NewServer(store Store, opts ...Option) *Server {
+	s := &Server{store: store}
+	applyOptions(&s.cfg, opts...)
+	s.cfg.started = time.Now()
+	return s
+}
+
+func (s *Server) Lookup(ctx context.Context, key string) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.cfg.timeout)
+	defer cancel()
+	b, err := s.store.Get(ctx, key)
+	return string(b), err
+}
@@ -0,0 +1,120 @@
+// Code generated by genpattern. DO NOT EDIT.
+
+package example
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// RegistryHolder 延遲建立 *Registry，只有第一次 Get 會呼叫 init，之後都回傳同一個值（含錯誤）
+type RegistryHolder struct {
+	once sync.Once
+	init func() (*Registry, error)
+	v    *Registry
+	err  error
+}
+
+func NewRegistryHolder(init func() (*Registry, error)) *RegistryHolder {
+	return &RegistryHolder{init: init}
+}
+
+func (h *RegistryHolder) Get() (*Registry, error) {
+	h.once.Do(func() { h.v, h.err = h.init() })
+	return h.v, h.err
+}
+
+// MustGet 在 init 失敗時 panic，適合程式啟動階段使用
+func (h *RegistryHolder) MustGet() *Registry {
+	v, err := h.Get()
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
+type Option func(*config)
+
+func WithAddr(v string) Option {
+	return func(c *config) { c.addr = v }
+}
+
+func WithTimeout(v time.Duration) Option {
+	return func(c *config) { c.timeout = v }
+}
+
+func WithTags(v []string) Option {
+	return func(c *config) { c.tags = v }
+}
+
+// applyOptions 先套用預設值再依序套用 opts，後面的 option 會覆蓋前面的
+func applyOptions(c *config, opts ...Option) {
+	c.addr = ":8080"
+	c.timeout = 5 * time.Second
+	for _, opt := range opts {
+		opt(c)
+	}
+}
+
+// MockStore 的每個方法都轉呼叫對應的 XxxFunc，並記錄呼叫參數
+type MockStore struct {
+	mu        sync.Mutex
+	GetFunc   func(context.Context, string) ([]byte, error)
+	GetCalls  []MockStoreGetCall
+	PutFunc   func(context.Context, string, []byte) error
+	PutCalls  []MockStorePutCall
+	KeysFunc  func(string, ...string) []string
+	KeysCalls []MockStoreKeysCall
+}
+
+var _ Store = (*MockStore)(nil)
+
+type MockStoreGetCall struct {
+	Ctx context.Context
+	Key string
+}
+
+type MockStorePutCall struct {
+	Ctx   context.Context
+	Key   string
+	Value []byte
+}
+
+type MockStoreKeysCall struct {
+	Prefix  string
+	Exclude []string
+}
+
+func (m *MockStore) Get(ctx context.Context, key string) ([]byte, error) {
+	m.mu.Lock()
+	m.GetCalls = append(m.GetCalls, MockStoreGetCall{Ctx: ctx, Key: key})
+	fn := m.GetFunc
+	m.mu.Unlock()
+	if fn == nil {
+		panic("MockStore.Get: GetFunc is not set")
+	}
+	return fn(ctx, key)
+}
+
+func (m *MockStore) Put(ctx context.Context, key string, value []byte) error {
+	m.mu.Lock()
+	m.PutCalls = append(m.PutCalls, MockStorePutCall{Ctx: ctx, Key: key, Value: value})
+	fn := m.PutFunc
+	m.mu.Unlock()
+	if fn == nil {
+		panic("MockStore.Put: PutFunc is not set")
+	}
+	return fn(ctx, key, value)
+}
+
+func (m *MockStore) Keys(prefix string, exclude ...string) []string {
+	m.mu.Lock()
+	m.KeysCalls = append(m.KeysCalls, MockStoreKeysCall{Prefix: prefix, Exclude: exclude})
+	fn := m.KeysFunc
+	m.mu.Unlock()
+	if fn == nil {
+		panic("MockStore.Keys: KeysFunc is not set")
+	}
+	return fn(prefix, exclude...)
+}
@@ -0,0 +1,61 @@
+package example
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOptions(t *testing.T) {
+	s := NewServer(nil)
+	assert.Equal(t, ":8080", s.cfg.addr)
+	assert.Equal(t, 5*time.Second, s.cfg.timeout)
+
+	s = NewServer(nil, WithAddr(":9090"), WithTags([]string{"a"}))
+	assert.Equal(t, ":9090", s.cfg.addr)
+	assert.Equal(t, []string{"a"}, s.cfg.tags)
+}
+
+func TestMock(t *testing.T) {
+	m := &MockStore{GetFunc: func(ctx context.Context, key string) ([]byte, error) {
+		_, ok := ctx.Deadline()
+		assert.True(t, ok, "Lookup 會加上 timeout")
+		return []byte("v:" + key), nil
+	}}
+	v, err := NewServer(m).Lookup(context.Background(), "k")
+	require.NoError(t, err)
+	assert.Equal(t, "v:k", v)
+	require.Len(t, m.GetCalls, 1)
+	assert.Equal(t, "k", m.GetCalls[0].Key)
+
+	m.KeysFunc = func(prefix string, exclude ...string) []string { return exclude }
+	assert.Equal(t, []string{"x", "y"}, m.Keys("p", "x", "y"))
+	assert.Equal(t, []string{"x", "y"}, m.KeysCalls[0].Exclude)
+
+	assert.PanicsWithValue(t, "MockStore.Put: PutFunc is not set", func() {
+		_ = m.Put(context.Background(), "k", nil)
+	})
+}
+
+func TestHolder(t *testing.T) {
+	calls := 0
+	h := NewRegistryHolder(func() (*Registry, error) {
+		calls++
+		return &Registry{services: map[string]string{}}, nil
+	})
+	a := h.MustGet()
+	b, err := h.Get()
+	require.NoError(t, err)
+	assert.Same(t, a, b)
+	assert.Equal(t, 1, calls)
+
+	boom := errors.New("boom")
+	failing := NewRegistryHolder(func() (*Registry, error) { return nil, boom })
+	_, err = failing.Get()
+	assert.ErrorIs(t, err, boom)
+	assert.Panics(t, func() { failing.MustGet() })
+}
@@ -0,0 +1,112 @@
+package genpattern
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// go test ./genpattern -update 重新產生 golden 檔
+var update = flag.Bool("update", false, "update golden files")
+
+func golden(t *testing.T, name string, got []byte) {
+	t.Helper()
+	path := filepath.Join("testdata", name)
+	if *update {
+		require.NoError(t, os.WriteFile(path, got, 0o644))
+	}
+	want, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, string(want), string(got))
+}
+
+func TestYAMLGolden(t *testing.T) {
+	data, err := os.ReadFile("testdata/store.yaml")
+	require.NoError(t, err)
+	s, err := ParseYAML(data)
+	require.NoError(t, err)
+	code, err := Generate(s)
+	require.NoError(t, err)
+	golden(t, "store.golden", code)
+}
+
+// TestGeneratedUpToDate 確認 example 的產生檔與原始碼同步，忘記 go generate 時會失敗
+func TestGeneratedUpToDate(t *testing.T) {
+	src, err := os.ReadFile("example/example.go")
+	require.NoError(t, err)
+	s, err := ParseSource("example.go", src)
+	require.NoError(t, err)
+	code, err := Generate(s)
+	require.NoError(t, err)
+
+	want, err := os.ReadFile("example/example_genpattern.go")
+	require.NoError(t, err)
+	assert.Equal(t, string(want), string(code), "run: go generate ./genpattern/example")
+}
+
+func TestParseSource(t *testing.T) {
+	src := []byte(`package p
+
+import (
+	"io"
+	str "strings"
+)
+
+//genpattern:options prefix=Use
+type Client struct {
+	r, w io.Reader
+	skip int ` + "`genpattern:\"-\"`" + `
+}
+
+//genpattern:mock
+type Finder interface {
+	Find(string, int) (n int, err error)
+}
+`)
+	s, err := ParseSource("p.go", src)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"io", "str strings"}, s.Imports)
+	assert.Equal(t, []Options{{Target: "Client", Prefix: "Use", Fields: []Field{
+		{Name: "r", Type: "io.Reader"}, {Name: "w", Type: "io.Reader"},
+	}}}, s.Options)
+	assert.Equal(t, []Method{{
+		Name:    "Find",
+		Params:  []Param{{Type: "string"}, {Type: "int"}},
+		Results: []Param{{Name: "n", Type: "int"}, {Name: "err", Type: "error"}},
+	}}, s.Mocks[0].Methods)
+
+	code, err := Generate(s)
+	require.NoError(t, err)
+	out := string(code)
+	assert.Contains(t, out, "func UseR(v io.Reader) ClientOption")
+	assert.Contains(t, out, "func (m *MockFinder) Find(p0 string, p1 int) (int, error)")
+	assert.NotContains(t, out, `"strings"`, "沒用到的 import 被移除")
+	assert.NotContains(t, out, "skip")
+}
+
+func TestInvalidSpec(t *testing.T) {
+	for _, bad := range []string{
+		`package: ""`,
+		`package: p`,
+		"package: p\nsingletons: [{name: X}]",
+		"package: p\nmocks: [{interface: 1x}]",
+		"package: p\noptions: [{target: T, fields: [{name: a-b, type: int}]}]",
+	} {
+		_, err := ParseYAML([]byte(bad))
+		assert.ErrorIs(t, err, ErrInvalidSpec, bad)
+	}
+
+	for _, src := range []string{
+		"package p\n//genpattern:mock\ntype T struct{}",
+		"package p\n//genpattern:options\ntype T interface{}",
+		"package p\n//genpattern:unknown\ntype T struct{}",
+		"package p\n//genpattern:mock\ntype T interface{ io.Reader }",
+	} {
+		_, err := ParseSource("p.go", []byte(src))
+		assert.ErrorIs(t, err, ErrInvalidSpec, src)
+	}
+}
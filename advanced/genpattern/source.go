@@ -0,0 +1,173 @@
+package genpattern
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+/*
+也可以直接在 Go 原始碼中以註解標記，搭配 go:generate 使用：
+
+	//go:generate go run advanced/cmd/genpattern -src $GOFILE
+
+	//genpattern:singleton
+	type Registry struct{ ... }        → RegistryHolder
+
+	//genpattern:options prefix=With
+	type config struct {               → Option 型別與每個欄位的 WithXxx
+		addr    string `default:"\":8080\""`
+		retries int    `genpattern:"-"`  // 略過
+	}
+
+	//genpattern:mock
+	type Store interface{ ... }        → MockStore
+
+options 的型別名稱：target 為 config 時產生 Option，其他則為 <Target>Option。
+*/
+
+const directive = "//genpattern:"
+
+// ParseSource 從 Go 原始碼的註解產生 Spec，原始檔的 import 都會帶入，用不到的會在產生時移除
+func ParseSource(filename string, src []byte) (*Spec, error) {
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, filename, src, parser.ParseComments)
+	if err != nil {
+		return nil, err
+	}
+	s := &Spec{Package: f.Name.Name}
+	for _, imp := range f.Imports {
+		path, _ := strconv.Unquote(imp.Path.Value)
+		if imp.Name != nil {
+			path = imp.Name.Name + " " + path
+		}
+		s.Imports = append(s.Imports, path)
+	}
+
+	for _, decl := range f.Decls {
+		gd, ok := decl.(*ast.GenDecl)
+		if !ok || gd.Tok != token.TYPE {
+			continue
+		}
+		for _, spec := range gd.Specs {
+			ts := spec.(*ast.TypeSpec)
+			doc := ts.Doc
+			if doc == nil && len(gd.Specs) == 1 {
+				doc = gd.Doc
+			}
+			for _, d := range directives(doc) {
+				if err := s.addFromType(d, ts); err != nil {
+					return nil, fmt.Errorf("%s: %w", fset.Position(ts.Pos()), err)
+				}
+			}
+		}
+	}
+	if err := s.validate(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+type directiveLine struct {
+	kind string
+	args map[string]string
+}
+
+func directives(doc *ast.CommentGroup) []directiveLine {
+	if doc == nil {
+		return nil
+	}
+	var out []directiveLine
+	for _, c := range doc.List {
+		if !strings.HasPrefix(c.Text, directive) {
+			continue
+		}
+		fields := strings.Fields(strings.TrimPrefix(c.Text, directive))
+		if len(fields) == 0 {
+			continue
+		}
+		d := directiveLine{kind: fields[0], args: map[string]string{}}
+		for _, kv := range fields[1:] {
+			k, v, _ := strings.Cut(kv, "=")
+			d.args[k] = v
+		}
+		out = append(out, d)
+	}
+	return out
+}
+
+func (s *Spec) addFromType(d directiveLine, ts *ast.TypeSpec) error {
+	name := ts.Name.Name
+	switch d.kind {
+	case "singleton":
+		s.Singletons = append(s.Singletons, Singleton{Name: name, Type: "*" + name})
+	case "options":
+		st, ok := ts.Type.(*ast.StructType)
+		if !ok {
+			return fmt.Errorf("%w: options target %s is not a struct", ErrInvalidSpec, name)
+		}
+		o := Options{Target: name, Prefix: d.args["prefix"]}
+		for _, f := range st.Fields.List {
+			var tag reflect.StructTag
+			if f.Tag != nil {
+				v, _ := strconv.Unquote(f.Tag.Value)
+				tag = reflect.StructTag(v)
+			}
+			if tag.Get("genpattern") == "-" {
+				continue
+			}
+			for _, n := range f.Names {
+				o.Fields = append(o.Fields, Field{Name: n.Name, Type: types.ExprString(f.Type), Default: tag.Get("default")})
+			}
+		}
+		s.Options = append(s.Options, o)
+	case "mock":
+		it, ok := ts.Type.(*ast.InterfaceType)
+		if !ok {
+			return fmt.Errorf("%w: mock target %s is not an interface", ErrInvalidSpec, name)
+		}
+		m := Mock{Interface: name}
+		for _, f := range it.Methods.List {
+			ft, ok := f.Type.(*ast.FuncType)
+			if !ok || len(f.Names) == 0 {
+				return fmt.Errorf("%w: %s: embedded interfaces are not supported", ErrInvalidSpec, name)
+			}
+			meth := Method{Name: f.Names[0].Name, Results: fieldList(ft.Results)}
+			meth.Params = fieldList(ft.Params)
+			if n := len(meth.Params); n > 0 {
+				if t := meth.Params[n-1].Type; strings.HasPrefix(t, "...") {
+					meth.Params[n-1].Type = strings.TrimPrefix(t, "...")
+					meth.Variadic = true
+				}
+			}
+			m.Methods = append(m.Methods, meth)
+		}
+		s.Mocks = append(s.Mocks, m)
+	default:
+		return fmt.Errorf("%w: unknown directive %q", ErrInvalidSpec, d.kind)
+	}
+	return nil
+}
+
+func fieldList(fl *ast.FieldList) []Param {
+	if fl == nil {
+		return nil
+	}
+	var out []Param
+	for _, f := range fl.List {
+		t := types.ExprString(f.Type)
+		if len(f.Names) == 0 {
+			out = append(out, Param{Type: t})
+			continue
+		}
+		for _, n := range f.Names {
+			out = append(out, Param{Name: n.Name, Type: t})
+		}
+	}
+	return out
+}
@@ -0,0 +1,128 @@
+package genpattern
+
+import (
+	"errors"
+	"fmt"
+	"go/token"
+
+	"gopkg.in/yaml.v3"
+)
+
+/*
+Spec 描述要產生的程式碼，可以由 YAML 或 Go 原始碼中的註解產生（見 source.go）：
+
+	package: store
+	imports: [context, database/sql]
+	singletons:
+	  - name: DB               # 產生 DBHolder
+	    type: "*sql.DB"
+	options:
+	  - target: Server         # 產生 ServerOption、WithAddr…
+	    fields:
+	      - {name: Addr, type: string, default: '":8080"'}
+	mocks:
+	  - interface: Store       # 產生 MockStore
+	    methods:
+	      - name: Get
+	        params: [{name: ctx, type: context.Context}, {name: key, type: string}]
+	        results: [{type: "[]byte"}, {type: error}]
+*/
+
+var ErrInvalidSpec = errors.New("genpattern: invalid spec")
+
+type Spec struct {
+	Package    string      `yaml:"package"`
+	Imports    []string    `yaml:"imports"`
+	Singletons []Singleton `yaml:"singletons"`
+	Options    []Options   `yaml:"options"`
+	Mocks      []Mock      `yaml:"mocks"`
+}
+
+type Singleton struct {
+	Name string `yaml:"name"`
+	Type string `yaml:"type"`
+}
+
+type Options struct {
+	Target string  `yaml:"target"`
+	Prefix string  `yaml:"prefix"` // option 函式的前綴，預設 "With"
+	Fields []Field `yaml:"fields"`
+}
+
+type Field struct {
+	Name    string `yaml:"name"`
+	Type    string `yaml:"type"`
+	Default string `yaml:"default"` // Go 運算式，空字串表示使用零值
+}
+
+type Mock struct {
+	Interface string   `yaml:"interface"`
+	Methods   []Method `yaml:"methods"`
+}
+
+type Method struct {
+	Name     string  `yaml:"name"`
+	Params   []Param `yaml:"params"`
+	Results  []Param `yaml:"results"`
+	Variadic bool    `yaml:"variadic"` // 最後一個參數是否為 ...T（Type 寫 T）
+}
+
+type Param struct {
+	Name string `yaml:"name"`
+	Type string `yaml:"type"`
+}
+
+func ParseYAML(data []byte) (*Spec, error) {
+	var s Spec
+	if err := yaml.Unmarshal(data, &s); err != nil {
+		return nil, fmt.Errorf("genpattern: %w", err)
+	}
+	if err := s.validate(); err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+func (s *Spec) validate() error {
+	if !token.IsIdentifier(s.Package) {
+		return fmt.Errorf("%w: package %q", ErrInvalidSpec, s.Package)
+	}
+	check := func(kind, name string) error {
+		if !token.IsIdentifier(name) {
+			return fmt.Errorf("%w: %s name %q", ErrInvalidSpec, kind, name)
+		}
+		return nil
+	}
+	for _, sg := range s.Singletons {
+		if err := check("singleton", sg.Name); err != nil {
+			return err
+		}
+		if sg.Type == "" {
+			return fmt.Errorf("%w: singleton %s has no type", ErrInvalidSpec, sg.Name)
+		}
+	}
+	for _, o := range s.Options {
+		if err := check("options target", o.Target); err != nil {
+			return err
+		}
+		for _, f := range o.Fields {
+			if err := check("field", f.Name); err != nil {
+				return err
+			}
+		}
+	}
+	for _, m := range s.Mocks {
+		if err := check("interface", m.Interface); err != nil {
+			return err
+		}
+		for _, meth := range m.Methods {
+			if err := check("method", meth.Name); err != nil {
+				return err
+			}
+		}
+	}
+	if len(s.Singletons)+len(s.Options)+len(s.Mocks) == 0 {
+		return fmt.Errorf("%w: nothing to generate", ErrInvalidSpec)
+	}
+	return nil
+}
@@ -0,0 +1,92 @@
+package golearn
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+/*
+Run 經過 go 工具執行，子行程的環境變數（例如 GODEBUG）也會套用到 go 工具本身。
+需要觀察範例行程的工具（golearn-tui）改用 Build：先 go test -c 編譯出測試執行檔，
+之後每次執行都直接啟動該檔案，re-run 也不用重新編譯：
+
+	go test -c -o <dir>/<pkg>.test [-race] ./<pkg>
+	<pkg>.test -test.run '^(TestA)$' -test.count N -test.cpu P -test.v
+
+執行檔的工作目錄設為套件目錄，與 go test 相同，testdata 等相對路徑才讀得到。
+*/
+
+var ErrMultiplePackages = errors.New("golearn: examples span multiple packages")
+
+type Binary struct {
+	Path  string // 測試執行檔
+	Dir   string // 套件目錄
+	funcs []string
+}
+
+// Build 把 examples 所在的套件編譯成測試執行檔放到 outDir，examples 必須屬於同一個套件
+func Build(ctx context.Context, examples []Example, race bool, outDir string) (*Binary, error) {
+	if len(examples) == 0 {
+		return nil, ErrNoMatch
+	}
+	first := examples[0]
+	b := &Binary{Dir: filepath.Join(first.ModuleDir, first.Package)}
+	for _, e := range examples {
+		if e.ModuleDir != first.ModuleDir || e.Package != first.Package {
+			return nil, ErrMultiplePackages
+		}
+		b.funcs = append(b.funcs, regexp.QuoteMeta(e.Func))
+	}
+	b.Path = filepath.Join(outDir, strings.ReplaceAll(first.Module+"/"+first.Package, "/", "_")+".test")
+
+	args := []string{"test", "-c", "-o", b.Path}
+	if race {
+		args = append(args, "-race")
+	}
+	args = append(args, "./"+first.Package)
+	cmd := exec.CommandContext(ctx, "go", args...)
+	cmd.Dir = first.ModuleDir
+	var stderr bytes.Buffer
+	cmd.Stdout, cmd.Stderr = &stderr, &stderr
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("golearn: build %s: %w\n%s", first.Package, err, stderr.Bytes())
+	}
+	// 套件沒有測試檔時 go test -c 不會產生檔案
+	if _, err := os.Stat(b.Path); err != nil {
+		return nil, fmt.Errorf("golearn: build %s: %w", first.Package, err)
+	}
+	return b, nil
+}
+
+// Command 產生執行測試執行檔的指令，cfg.Race 已在 Build 時決定，這裡不使用
+func (b *Binary) Command(ctx context.Context, cfg RunConfig) *exec.Cmd {
+	iterations := cfg.Iterations
+	if iterations < 1 {
+		iterations = 1
+	}
+	args := []string{"-test.run", "^(" + strings.Join(b.funcs, "|") + ")$", "-test.count", strconv.Itoa(iterations)}
+	if cfg.GOMAXPROCS > 0 {
+		args = append(args, "-test.cpu", strconv.Itoa(cfg.GOMAXPROCS))
+	}
+	if cfg.Verbose {
+		args = append(args, "-test.v")
+	}
+	cmd := exec.CommandContext(ctx, b.Path, args...)
+	cmd.Dir = b.Dir
+	cmd.Stdout, cmd.Stderr = cfg.Stdout, cfg.Stderr
+	if cmd.Stdout == nil {
+		cmd.Stdout = os.Stdout
+	}
+	if cmd.Stderr == nil {
+		cmd.Stderr = os.Stderr
+	}
+	return cmd
+}
@@ -0,0 +1,237 @@
+package golearn
+
+import (
+	"bufio"
+	"errors"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+/*
+repo 中的範例都寫成 Test 函式（搭配大段的說明註解），以往只能 cd 到各個 module 再 go test -run 執行。
+Discover 掃描 root 底下的每個 module（含 go.mod 的目錄），解析 _test.go 收集範例：
+  - TestXxx(t *testing.T) 與 ExampleXxx()，Benchmark / Fuzz 不列入
+  - 名稱去掉 Test 前綴與套件名稱，轉成 kebab-case：
+    basic/goroutine 的 TestGoroutineUseSelect → goroutine/use-select
+  - 說明取 doc comment 的第一行
+不同 module 中可能有同名的套件，ID 不含 module，比對時以 Module 欄位區分。
+*/
+
+type Example struct {
+	Module    string // module 路徑，例如 "basic"
+	ModuleDir string // module 的絕對路徑，go test 在這裡執行
+	Package   string // 相對於 module 的目錄，例如 "concurrency/workerpool"
+	Func      string // 函式名稱，例如 "TestGoroutineUseSelect"
+	Name      string // kebab-case 名稱，例如 "use-select"
+	Doc       string
+}
+
+func (e Example) ID() string {
+	return e.Package + "/" + e.Name
+}
+
+// Discover 回傳 root 底下所有 module 的範例，依 module、ID 排序
+func Discover(root string) ([]Example, error) {
+	var out []Example
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() {
+			return nil
+		}
+		if skipDir(path, root, d.Name()) {
+			return filepath.SkipDir
+		}
+		mod, err := modulePath(filepath.Join(path, "go.mod"))
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		ex, err := discoverModule(mod, path)
+		if err != nil {
+			return err
+		}
+		out = append(out, ex...)
+		return filepath.SkipDir // 子目錄已由 discoverModule 處理
+	})
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Module != out[j].Module {
+			return out[i].Module < out[j].Module
+		}
+		return out[i].ID() < out[j].ID()
+	})
+	return out, err
+}
+
+// RepoRoot 從目前目錄往上找到含有 .git 的目錄，找不到時使用目前目錄；
+// 供 cmd/golearn 與 cmd/golearn-tui 當作 -root 的預設值
+func RepoRoot() string {
+	wd, err := os.Getwd()
+	if err != nil {
+		return "."
+	}
+	for dir := wd; ; {
+		if _, err := os.Stat(filepath.Join(dir, ".git")); err == nil {
+			return dir
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return wd
+		}
+		dir = parent
+	}
+}
+
+func skipDir(path, root, name string) bool {
+	if path == root {
+		return false
+	}
+	return name == "testdata" || name == "vendor" || strings.HasPrefix(name, ".") || strings.HasPrefix(name, "_")
+}
+
+func modulePath(gomod string) (string, error) {
+	f, err := os.Open(gomod)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		if rest, ok := strings.CutPrefix(strings.TrimSpace(sc.Text()), "module "); ok {
+			return strings.Trim(strings.TrimSpace(rest), `"`), nil
+		}
+	}
+	return "", errors.New("golearn: no module directive in " + gomod)
+}
+
+func discoverModule(mod, dir string) ([]Example, error) {
+	var out []Example
+	fset := token.NewFileSet()
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if path != dir {
+				if skipDir(path, dir, d.Name()) {
+					return filepath.SkipDir
+				}
+				// 巢狀的 module 由外層的 Discover 另外處理
+				if _, err := os.Stat(filepath.Join(path, "go.mod")); err == nil {
+					return filepath.SkipDir
+				}
+			}
+			return nil
+		}
+		if !strings.HasSuffix(path, "_test.go") {
+			return nil
+		}
+		f, err := parser.ParseFile(fset, path, nil, parser.ParseComments|parser.SkipObjectResolution)
+		if err != nil {
+			return err
+		}
+		rel, _ := filepath.Rel(dir, filepath.Dir(path))
+		rel = filepath.ToSlash(rel)
+		pkg := strings.TrimSuffix(f.Name.Name, "_test")
+		for _, decl := range f.Decls {
+			fn, ok := decl.(*ast.FuncDecl)
+			if !ok || fn.Recv != nil || !isExample(fn) {
+				continue
+			}
+			out = append(out, Example{
+				Module:    mod,
+				ModuleDir: dir,
+				Package:   rel,
+				Func:      fn.Name.Name,
+				Name:      exampleName(fn.Name.Name, pkg),
+				Doc:       firstLine(fn.Doc),
+			})
+		}
+		return nil
+	})
+	return out, err
+}
+
+// isExample 判斷是否為 go test 會執行的 TestXxx(t *testing.T) 或 ExampleXxx()
+func isExample(fn *ast.FuncDecl) bool {
+	name := fn.Name.Name
+	params := fn.Type.Params.List
+	switch {
+	case strings.HasPrefix(name, "Test") && name != "TestMain":
+		if len(params) != 1 {
+			return false
+		}
+		star, ok := params[0].Type.(*ast.StarExpr)
+		if !ok {
+			return false
+		}
+		sel, ok := star.X.(*ast.SelectorExpr)
+		return ok && sel.Sel.Name == "T" && validSuffix(name[len("Test"):])
+	case strings.HasPrefix(name, "Example"):
+		return len(params) == 0 && validSuffix(name[len("Example"):])
+	}
+	return false
+}
+
+// validSuffix 與 go test 的規則相同：TestXxx 的 Xxx 不可以小寫字母開頭
+func validSuffix(s string) bool {
+	if s == "" {
+		return true
+	}
+	return !unicode.IsLower([]rune(s)[0])
+}
+
+// exampleName 去掉 Test/Example 前綴與套件名稱後轉成 kebab-case
+func exampleName(fn, pkg string) string {
+	name := strings.TrimPrefix(strings.TrimPrefix(fn, "Test"), "Example")
+	name = strings.TrimPrefix(name, "_")
+	// 只在字的邊界去掉套件名稱：套件 p 的 TestPass 仍是 pass
+	if len(name) > len(pkg) && strings.EqualFold(name[:len(pkg)], pkg) {
+		if next := rune(name[len(pkg)]); unicode.IsUpper(next) || unicode.IsDigit(next) || next == '_' {
+			name = strings.TrimPrefix(name[len(pkg):], "_")
+		}
+	}
+	if name == "" {
+		return strings.ToLower(pkg)
+	}
+	return kebab(name)
+}
+
+// kebab 把 CamelCase 轉成 kebab-case，連續的大寫視為一個縮寫："HTTPServer" → "http-server"
+func kebab(s string) string {
+	rs := []rune(s)
+	var b strings.Builder
+	for i, r := range rs {
+		if r == '_' {
+			b.WriteByte('-')
+			continue
+		}
+		if i > 0 && unicode.IsUpper(r) && rs[i-1] != '_' {
+			prevLower := unicode.IsLower(rs[i-1]) || unicode.IsDigit(rs[i-1])
+			nextLower := i+1 < len(rs) && unicode.IsLower(rs[i+1])
+			if prevLower || (unicode.IsUpper(rs[i-1]) && nextLower) {
+				b.WriteByte('-')
+			}
+		}
+		b.WriteRune(unicode.ToLower(r))
+	}
+	return b.String()
+}
+
+func firstLine(doc *ast.CommentGroup) string {
+	if doc == nil {
+		return ""
+	}
+	line, _, _ := strings.Cut(strings.TrimSpace(doc.Text()), "\n")
+	return line
+}
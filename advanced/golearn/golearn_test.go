@@ -0,0 +1,213 @@
+package golearn
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func write(t *testing.T, root string, files map[string]string) {
+	for name, content := range files {
+		path := filepath.Join(root, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+	}
+}
+
+func tree(t *testing.T) string {
+	root := t.TempDir()
+	write(t, root, map[string]string{
+		"basic/go.mod": "module basic\n\ngo 1.18\n",
+		"basic/select/select_test.go": `package select_test
+
+import "testing"
+
+// TestSelect 示範 select 的多路復用
+// 第二行不會出現在說明中
+func TestSelect(t *testing.T) {}
+
+func TestSelectTimeout(t *testing.T) {}
+
+func ExampleSelect_default() {}
+
+func BenchmarkSelect(b *testing.B) {}
+func TestMain(m *testing.M)       {}
+func Testlower(t *testing.T)      {}
+func helper(t *testing.T)         {}
+`,
+		"basic/goroutine/goroutine_test.go": `package goroutine
+
+import "testing"
+
+func TestGoroutineUseSelect(t *testing.T) {}
+func TestHTTPServer(t *testing.T)         {}
+`,
+		"basic/goroutine/testdata/skip_test.go": "package skip\n\nimport \"testing\"\n\nfunc TestSkipped(t *testing.T) {}\n",
+		"advanced/go.mod":                       "module advanced\n",
+		"advanced/concurrency/workerpool/pool_test.go": `package workerpool
+
+import "testing"
+
+func TestPauseResume(t *testing.T) {}
+`,
+		"advanced/select/x_test.go": "package selectx\n\nimport \"testing\"\n\nfunc TestOther(t *testing.T) {}\n",
+	})
+	return root
+}
+
+func ids(ex []Example) []string {
+	out := make([]string, len(ex))
+	for i, e := range ex {
+		out[i] = e.Module + ":" + e.ID()
+	}
+	return out
+}
+
+func TestDiscover(t *testing.T) {
+	root := tree(t)
+	ex, err := Discover(root)
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		"advanced:concurrency/workerpool/pause-resume",
+		"advanced:select/other",
+		"basic:goroutine/http-server",
+		"basic:goroutine/use-select",
+		"basic:select/default",
+		"basic:select/select",
+		"basic:select/timeout",
+	}, ids(ex))
+
+	for _, e := range ex {
+		if e.Func == "TestSelect" {
+			assert.Equal(t, "TestSelect 示範 select 的多路復用", e.Doc)
+			assert.Equal(t, filepath.Join(root, "basic"), e.ModuleDir)
+		}
+	}
+}
+
+func TestKebab(t *testing.T) {
+	for in, want := range map[string]string{
+		"UseSelect":     "use-select",
+		"HTTPServer":    "http-server",
+		"GetURLForID":   "get-url-for-id",
+		"Channel2Close": "channel2-close",
+		"with_Under":    "with-under",
+	} {
+		assert.Equal(t, want, kebab(in), in)
+	}
+	assert.Equal(t, "use-select", exampleName("TestGoroutineUseSelect", "goroutine"))
+	assert.Equal(t, "pass", exampleName("TestPass", "p"), "只在字的邊界去掉套件名稱")
+	assert.Equal(t, "goroutine", exampleName("TestGoroutine", "goroutine"))
+	assert.Equal(t, "default", exampleName("ExampleSelect_default", "select"))
+}
+
+func TestMatch(t *testing.T) {
+	ex, err := Discover(tree(t))
+	require.NoError(t, err)
+
+	cases := map[string][]string{
+		"goroutine/use-select": {"basic:goroutine/use-select"},
+		"goroutine/select":     {"basic:goroutine/use-select"},
+		"workerpool/pause":     {"advanced:concurrency/workerpool/pause-resume"},
+		"select":               {"advanced:select/other", "basic:select/default", "basic:select/select", "basic:select/timeout"},
+		"basic:select/time":    {"basic:select/timeout"},
+	}
+	for q, want := range cases {
+		got, err := Match(ex, q)
+		require.NoError(t, err, q)
+		assert.Equal(t, want, ids(got), q)
+	}
+	_, err = Match(ex, "nothing/here")
+	assert.ErrorIs(t, err, ErrNoMatch)
+	_, err = Match(ex, "advanced:goroutine")
+	assert.ErrorIs(t, err, ErrNoMatch)
+}
+
+func TestCommands(t *testing.T) {
+	ex, err := Discover(tree(t))
+	require.NoError(t, err)
+	matched, err := Match(ex, "select")
+	require.NoError(t, err)
+
+	cmds := Commands(context.Background(), matched, RunConfig{Iterations: 3, GOMAXPROCS: 2, Race: true, Verbose: true})
+	require.Len(t, cmds, 2, "依 module 與套件分組")
+	assert.Equal(t, []string{"go", "test", "-run", "^(TestOther)$", "-count", "3", "-cpu", "2", "-race", "-v", "./select"}, cmds[0].Args)
+	assert.Equal(t, []string{"go", "test", "-run", "^(ExampleSelect_default|TestSelect|TestSelectTimeout)$", "-count", "3", "-cpu", "2", "-race", "-v", "./select"}, cmds[1].Args)
+	assert.Equal(t, "advanced", filepath.Base(cmds[0].Dir))
+	assert.Equal(t, "basic", filepath.Base(cmds[1].Dir))
+}
+
+func TestRun(t *testing.T) {
+	root := t.TempDir()
+	write(t, root, map[string]string{
+		"m/go.mod": "module m\n\ngo 1.18\n",
+		"m/p/p_test.go": `package p
+
+import "testing"
+
+func TestPass(t *testing.T) { t.Log("ran") }
+func TestFail(t *testing.T) { t.Fatal("boom") }
+`,
+	})
+	ex, err := Discover(root)
+	require.NoError(t, err)
+
+	var out bytes.Buffer
+	pass, err := Match(ex, "p/pass")
+	require.NoError(t, err)
+	require.NoError(t, Run(context.Background(), pass, RunConfig{Iterations: 2, Verbose: true, Stdout: &out, Stderr: &out}))
+	assert.Equal(t, 2, bytes.Count(out.Bytes(), []byte("--- PASS: TestPass")), out.String())
+
+	fail, err := Match(ex, "p/fail")
+	require.NoError(t, err)
+	out.Reset()
+	assert.Error(t, Run(context.Background(), fail, RunConfig{Stdout: &out, Stderr: &out}))
+	assert.Contains(t, out.String(), "boom")
+}
+
+func TestBuild(t *testing.T) {
+	root := t.TempDir()
+	write(t, root, map[string]string{
+		"m/go.mod":             "module m\n\ngo 1.18\n",
+		"m/p/testdata/msg.txt": "from testdata",
+		"m/p/p_test.go": `package p
+
+import (
+	"os"
+	"testing"
+)
+
+func TestRead(t *testing.T) {
+	b, err := os.ReadFile("testdata/msg.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Log(string(b))
+}
+func TestOther(t *testing.T) { t.Log("other") }
+`,
+		"m/q/q_test.go": "package q\n\nimport \"testing\"\n\nfunc TestQ(t *testing.T) {}\n",
+	})
+	ex, err := Discover(root)
+	require.NoError(t, err)
+
+	_, err = Build(context.Background(), ex, false, t.TempDir())
+	assert.ErrorIs(t, err, ErrMultiplePackages)
+
+	read, err := Match(ex, "p/read")
+	require.NoError(t, err)
+	bin, err := Build(context.Background(), read, false, t.TempDir())
+	require.NoError(t, err)
+
+	var out bytes.Buffer
+	cmd := bin.Command(context.Background(), RunConfig{Iterations: 2, GOMAXPROCS: 1, Verbose: true, Stdout: &out, Stderr: &out})
+	assert.Equal(t, []string{bin.Path, "-test.run", "^(TestRead)$", "-test.count", "2", "-test.cpu", "1", "-test.v"}, cmd.Args)
+	require.NoError(t, cmd.Run(), out.String())
+	assert.Equal(t, 2, bytes.Count(out.Bytes(), []byte("from testdata")), "在套件目錄執行")
+	assert.NotContains(t, out.String(), "TestOther")
+}
@@ -0,0 +1,145 @@
+package golearn
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+/*
+Match 依查詢找出範例，規則依序為：
+ 1. 完全符合 ID（goroutine/use-select）
+ 2. 完全符合套件（select、concurrency/workerpool）→ 該套件所有範例
+ 3. <套件尾段>/<名稱片段>：套件以查詢的套件部分結尾，名稱包含查詢的名稱部分
+    goroutine/select → goroutine/use-select
+查詢可加上 "<module>:" 前綴限定 module，例如 basic:timers。
+
+Run 以 go test 執行：
+	go test -run '^(TestA|TestB)$' -count <iterations> -cpu <gomaxprocs> [-race] -v ./<pkg>
+-count 讓同一個範例重複執行（不使用快取），-cpu 會在執行期間設定 GOMAXPROCS。
+*/
+
+var ErrNoMatch = errors.New("golearn: no matching example")
+
+func Match(examples []Example, query string) ([]Example, error) {
+	mod, q, ok := strings.Cut(query, ":")
+	if !ok {
+		mod, q = "", query
+	}
+	q = strings.Trim(q, "/")
+	var pool []Example
+	for _, e := range examples {
+		if mod == "" || e.Module == mod {
+			pool = append(pool, e)
+		}
+	}
+
+	filters := []func(Example) bool{
+		func(e Example) bool { return e.ID() == q },
+		func(e Example) bool { return e.Package == q },
+		func(e Example) bool {
+			pkg, name, ok := cutLast(q, "/")
+			if !ok {
+				return false
+			}
+			return (e.Package == pkg || strings.HasSuffix(e.Package, "/"+pkg)) && strings.Contains(e.Name, name)
+		},
+	}
+	for _, match := range filters {
+		var out []Example
+		for _, e := range pool {
+			if match(e) {
+				out = append(out, e)
+			}
+		}
+		if len(out) > 0 {
+			return out, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: %s", ErrNoMatch, query)
+}
+
+func cutLast(s, sep string) (before, after string, ok bool) {
+	i := strings.LastIndex(s, sep)
+	if i < 0 {
+		return "", s, false
+	}
+	return s[:i], s[i+len(sep):], true
+}
+
+type RunConfig struct {
+	Iterations int  // -count，預設 1
+	GOMAXPROCS int  // -cpu，0 表示不指定
+	Race       bool // -race
+	Verbose    bool // -v
+	Stdout     io.Writer
+	Stderr     io.Writer
+}
+
+type group struct {
+	dir, pkg string
+	funcs    []string
+}
+
+// Commands 依套件分組，每個套件產生一個 go test 指令
+func Commands(ctx context.Context, examples []Example, cfg RunConfig) []*exec.Cmd {
+	var groups []*group
+	index := map[string]*group{}
+	for _, e := range examples {
+		key := e.ModuleDir + "\x00" + e.Package
+		g, ok := index[key]
+		if !ok {
+			g = &group{dir: e.ModuleDir, pkg: e.Package}
+			index[key] = g
+			groups = append(groups, g)
+		}
+		g.funcs = append(g.funcs, regexp.QuoteMeta(e.Func))
+	}
+
+	iterations := cfg.Iterations
+	if iterations < 1 {
+		iterations = 1
+	}
+	var cmds []*exec.Cmd
+	for _, g := range groups {
+		args := []string{"test", "-run", "^(" + strings.Join(g.funcs, "|") + ")$", "-count", strconv.Itoa(iterations)}
+		if cfg.GOMAXPROCS > 0 {
+			args = append(args, "-cpu", strconv.Itoa(cfg.GOMAXPROCS))
+		}
+		if cfg.Race {
+			args = append(args, "-race")
+		}
+		if cfg.Verbose {
+			args = append(args, "-v")
+		}
+		args = append(args, "./"+g.pkg)
+		cmd := exec.CommandContext(ctx, "go", args...)
+		cmd.Dir = g.dir
+		cmd.Stdout, cmd.Stderr = cfg.Stdout, cfg.Stderr
+		if cmd.Stdout == nil {
+			cmd.Stdout = os.Stdout
+		}
+		if cmd.Stderr == nil {
+			cmd.Stderr = os.Stderr
+		}
+		cmds = append(cmds, cmd)
+	}
+	return cmds
+}
+
+// Run 依序執行每個套件的指令，全部執行完後回傳第一個錯誤
+func Run(ctx context.Context, examples []Example, cfg RunConfig) error {
+	var first error
+	for _, cmd := range Commands(ctx, examples, cfg) {
+		if err := cmd.Run(); err != nil && first == nil {
+			first = fmt.Errorf("golearn: %s: %w", strings.Join(cmd.Args, " "), err)
+		}
+	}
+	return first
+}
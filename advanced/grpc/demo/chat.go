@@ -0,0 +1,237 @@
+package demo
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strconv"
+	"sync/atomic"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"advanced/concurrency/pubsub"
+	"advanced/protocol/pubsubpb"
+	"advanced/timex"
+)
+
+/*
+雙向串流的聊天室：每個連線同時送出與接收，server 透過 concurrency/pubsub 把訊息轉給同一個 room 的所有連線。
+
+	stream A ─Recv─► receive ─Publish(room)─► Broker ─► 每個連線自己的 send queue ─► forward ─Send─► stream B
+
+每個 stream 由三個 goroutine 組成：
+  - handler 本身只負責等待：收訊息結束、送訊息失敗、或訂閱被踢掉，任何一個發生就結束這個 RPC
+  - receive：Recv 到的訊息補上 id、room、user、時間後 Publish
+  - forward：從訂閱的 channel（send queue）取出訊息 Send 給 client
+
+流量控制分兩段：
+  - client ⇄ server 之間是 HTTP/2 的 flow control：client 不讀，server 的 Send 在 window 用完後就會卡住
+  - Send 卡住時訊息堆在 send queue（WithQueueSize），滿了之後依 WithSlowPolicy 處理：
+      Close（預設）：踢掉這個連線，client 收到 ResourceExhausted，重新連線後自己補齊歷史
+      Drop：這個連線漏掉訊息，其他人不受影響
+      Block：Publish 等待，發言者的 receive 也停止 Recv，背壓一路傳回發言者的 HTTP/2 window；
+             一個慢的 client 會拖慢整個 room，只適合不能漏訊息的場景
+
+同一則 *pubsubpb.Message 會被多個 forward 同時 Send（只讀取做 marshal），Publish 之後不能再修改。
+
+和 grpc/gateway 一樣沒有 protoc：訊息沿用 protocol/pubsubpb.Message（Topic 是 room、Key 是發言者、Data 是內容），
+ServiceDesc 與 client 手寫。room 與 user 放在 metadata，見 WithIdentity。
+*/
+
+const (
+	Chat_Chat_FullMethodName = "/golearn.chat.v1.Chat/Chat"
+
+	roomKey = "chat-room"
+	userKey = "chat-user"
+)
+
+type Chat_ChatServer = grpc.BidiStreamingServer[pubsubpb.Message, pubsubpb.Message]
+type Chat_ChatClient = grpc.BidiStreamingClient[pubsubpb.Message, pubsubpb.Message]
+
+type ChatServer interface {
+	Chat(Chat_ChatServer) error
+}
+
+var Chat_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "golearn.chat.v1.Chat",
+	HandlerType: (*ChatServer)(nil),
+	Streams: []grpc.StreamDesc{{
+		StreamName: "Chat",
+		Handler: func(srv any, stream grpc.ServerStream) error {
+			return srv.(ChatServer).Chat(&grpc.GenericServerStream[pubsubpb.Message, pubsubpb.Message]{ServerStream: stream})
+		},
+		ServerStreams: true,
+		ClientStreams: true,
+	}},
+	Metadata: "chat (hand-written)",
+}
+
+func RegisterChatServer(s grpc.ServiceRegistrar, srv ChatServer) {
+	s.RegisterService(&Chat_ServiceDesc, srv)
+}
+
+type ChatClient interface {
+	Chat(ctx context.Context, opts ...grpc.CallOption) (Chat_ChatClient, error)
+}
+
+type chatClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewChatClient(cc grpc.ClientConnInterface) ChatClient {
+	return &chatClient{cc}
+}
+
+func (c *chatClient) Chat(ctx context.Context, opts ...grpc.CallOption) (Chat_ChatClient, error) {
+	stream, err := c.cc.NewStream(ctx, &Chat_ServiceDesc.Streams[0], Chat_Chat_FullMethodName, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return &grpc.GenericClientStream[pubsubpb.Message, pubsubpb.Message]{ClientStream: stream}, nil
+}
+
+// WithIdentity 把 room 與 user 放進 outgoing metadata，開 stream 前呼叫
+func WithIdentity(ctx context.Context, room, user string) context.Context {
+	return metadata.AppendToOutgoingContext(ctx, roomKey, room, userKey, user)
+}
+
+func identity(ctx context.Context) (room, user string, err error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	if v := md.Get(roomKey); len(v) == 1 {
+		room = v[0]
+	}
+	if v := md.Get(userKey); len(v) == 1 {
+		user = v[0]
+	}
+	if room == "" || user == "" {
+		return "", "", status.Errorf(codes.InvalidArgument, "chat: metadata %q and %q are required", roomKey, userKey)
+	}
+	return room, user, nil
+}
+
+type Server struct {
+	broker *pubsub.Broker[*pubsubpb.Message]
+	queue  int
+	policy pubsub.Policy
+	clock  timex.Clock
+	seq    atomic.Uint64
+}
+
+type Option func(*Server)
+
+// WithQueueSize 設定每個連線的 send queue 長度，預設 64
+func WithQueueSize(n int) Option {
+	return func(s *Server) { s.queue = n }
+}
+
+// WithSlowPolicy 設定 send queue 滿了時的處理方式，預設 pubsub.Close
+func WithSlowPolicy(p pubsub.Policy) Option {
+	return func(s *Server) { s.policy = p }
+}
+
+// WithClock 設定訊息 published_at 的時間來源，預設 timex.Real
+func WithClock(c timex.Clock) Option {
+	return func(s *Server) { s.clock = c }
+}
+
+func NewServer(opts ...Option) *Server {
+	s := &Server{broker: pubsub.New[*pubsubpb.Message](), queue: 64, policy: pubsub.Close, clock: timex.Real{}}
+	for _, o := range opts {
+		o(s)
+	}
+	return s
+}
+
+// Close 結束所有連線，client 收到 Unavailable
+func (s *Server) Close() {
+	s.broker.Close()
+}
+
+var errShutdown = status.Error(codes.Unavailable, "chat: server shutting down")
+
+func (s *Server) Chat(stream Chat_ChatServer) error {
+	ctx := stream.Context()
+	room, user, err := identity(ctx)
+	if err != nil {
+		return err
+	}
+	sub, err := s.broker.Subscribe(ctx, room, pubsub.WithBuffer(s.queue), pubsub.WithPolicy(s.policy))
+	if err != nil {
+		return errShutdown
+	}
+	defer sub.Unsubscribe()
+
+	recvErr := make(chan error, 1)
+	go func() { recvErr <- s.receive(ctx, stream, room, user) }()
+	sendErr := make(chan error, 1)
+	go func() { sendErr <- forward(stream, sub) }()
+
+	select {
+	case err := <-recvErr: // client CloseSend 時為 nil
+		return err
+	case err := <-sendErr:
+		return err
+	case <-sub.Done():
+		err := sub.Err()
+		switch {
+		case errors.Is(err, pubsub.ErrSlowSubscriber):
+			return status.Errorf(codes.ResourceExhausted, "chat: send queue full (%d messages), reconnect to resume", s.queue)
+		case errors.Is(err, pubsub.ErrClosed):
+			return errShutdown
+		}
+		return status.FromContextError(err).Err()
+	}
+}
+
+func (s *Server) receive(ctx context.Context, stream Chat_ChatServer, room, user string) error {
+	for {
+		in, err := stream.Recv()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		msg := &pubsubpb.Message{
+			Id:          strconv.FormatUint(s.seq.Add(1), 10),
+			Topic:       room,
+			Key:         user,
+			Headers:     in.GetHeaders(),
+			Data:        in.GetData(),
+			PublishedAt: timestamppb.New(s.clock.Now()),
+		}
+		if _, err := s.broker.Publish(ctx, room, msg); err != nil {
+			if errors.Is(err, pubsub.ErrClosed) {
+				return errShutdown
+			}
+			return status.FromContextError(err).Err()
+		}
+	}
+}
+
+// forward 把 send queue 的訊息送給 client；訂閱結束後就不再 Send，
+// 避免 handler 返回後還在呼叫 Send（grpc 不允許）。只有卡在 Send 裡的那一次會等到 stream 結束才返回
+func forward(stream Chat_ChatServer, sub *pubsub.Subscription[*pubsubpb.Message]) error {
+	for {
+		select {
+		case <-sub.Done():
+			return nil
+		case msg, ok := <-sub.C():
+			if !ok {
+				return nil
+			}
+			select {
+			case <-sub.Done():
+				return nil
+			default:
+			}
+			if err := stream.Send(msg); err != nil {
+				return err
+			}
+		}
+	}
+}
@@ -0,0 +1,175 @@
+package demo
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+
+	"advanced/concurrency/pubsub"
+	"advanced/protocol/pubsubpb"
+	"advanced/timex"
+)
+
+type env struct {
+	server *Server
+	lis    *bufconn.Listener
+}
+
+func newEnv(t *testing.T, opts ...Option) *env {
+	e := &env{server: NewServer(opts...), lis: bufconn.Listen(1 << 20)}
+	srv := grpc.NewServer()
+	RegisterChatServer(srv, e.server)
+	go srv.Serve(e.lis)
+	t.Cleanup(srv.Stop)
+	return e
+}
+
+// dial 每次開新的連線，各自有自己的 HTTP/2 flow control window
+func (e *env) dial(t *testing.T, opts ...grpc.DialOption) ChatClient {
+	opts = append(opts,
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return e.lis.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	conn, err := grpc.NewClient("passthrough:///bufnet", opts...)
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+	return NewChatClient(conn)
+}
+
+func (e *env) join(t *testing.T, room, user string, opts ...grpc.DialOption) Chat_ChatClient {
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	stream, err := e.dial(t, opts...).Chat(WithIdentity(ctx, room, user))
+	require.NoError(t, err)
+	// 等 server 完成訂閱，之後送出的訊息一定收得到
+	require.Eventually(t, func() bool { return e.server.broker.Subscribers(room) > 0 }, time.Second, time.Millisecond)
+	return stream
+}
+
+func (e *env) joinAll(t *testing.T, room string, users ...string) []Chat_ChatClient {
+	var streams []Chat_ChatClient
+	for i, u := range users {
+		ctx, cancel := context.WithCancel(context.Background())
+		t.Cleanup(cancel)
+		stream, err := e.dial(t).Chat(WithIdentity(ctx, room, u))
+		require.NoError(t, err)
+		streams = append(streams, stream)
+		require.Eventually(t, func() bool { return e.server.broker.Subscribers(room) == i+1 }, time.Second, time.Millisecond)
+	}
+	return streams
+}
+
+func TestFanOutWithinRoom(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	e := newEnv(t, WithClock(timex.NewFake(start)))
+	gophers := e.joinAll(t, "go", "ann", "bob", "cat")
+	rust := e.join(t, "rust", "dan")
+
+	for i, sender := range gophers {
+		require.NoError(t, sender.Send(&pubsubpb.Message{Data: []byte{byte('a' + i)}}))
+		for _, s := range gophers {
+			got, err := s.Recv()
+			require.NoError(t, err)
+			assert.Equal(t, []byte{byte('a' + i)}, got.GetData())
+			assert.Equal(t, "go", got.GetTopic())
+			assert.Equal(t, []string{"ann", "bob", "cat"}[i], got.GetKey(), "sender stamped by the server")
+			assert.NotEmpty(t, got.GetId())
+			assert.Equal(t, start, got.GetPublishedAt().AsTime())
+		}
+	}
+
+	// 其他 room 收不到；CloseSend 後 server 結束 stream
+	require.NoError(t, rust.CloseSend())
+	_, err := rust.Recv()
+	assert.ErrorIs(t, err, io.EOF, "no messages leaked from room go")
+}
+
+func TestMissingIdentity(t *testing.T) {
+	e := newEnv(t)
+	stream, err := e.dial(t).Chat(context.Background())
+	require.NoError(t, err)
+	_, err = stream.Recv()
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+// 不讀訊息的 client：HTTP/2 window 用完、send queue 塞滿後被踢掉，其他人照常收到所有訊息
+func TestSlowConsumerDisconnected(t *testing.T) {
+	e := newEnv(t, WithQueueSize(4))
+	fast := e.join(t, "room", "fast")
+	// 固定 window 大小（同時關掉 BDP 自動放大），讓 window 很快用完
+	slow := e.join(t, "room", "slow", grpc.WithInitialWindowSize(64<<10), grpc.WithInitialConnWindowSize(64<<10))
+	require.Eventually(t, func() bool { return e.server.broker.Subscribers("room") == 2 }, time.Second, time.Millisecond)
+
+	payload := bytes.Repeat([]byte("x"), 16<<10)
+	const n = 64
+	for i := 0; i < n; i++ {
+		require.NoError(t, fast.Send(&pubsubpb.Message{Data: payload}))
+		_, err := fast.Recv()
+		require.NoError(t, err, "fast consumer never falls behind (message %d)", i)
+	}
+
+	received := 0
+	for {
+		_, err := slow.Recv()
+		if err != nil {
+			assert.Equal(t, codes.ResourceExhausted, status.Code(err), err)
+			break
+		}
+		received++
+	}
+	assert.Less(t, received, n)
+	assert.Equal(t, 1, e.server.broker.Subscribers("room"))
+}
+
+// Drop 模式：慢的 client 漏掉訊息但不斷線
+func TestSlowConsumerDropped(t *testing.T) {
+	e := newEnv(t, WithQueueSize(4), WithSlowPolicy(pubsub.Drop))
+	fast := e.join(t, "room", "fast")
+	slow := e.join(t, "room", "slow", grpc.WithInitialWindowSize(64<<10), grpc.WithInitialConnWindowSize(64<<10))
+	require.Eventually(t, func() bool { return e.server.broker.Subscribers("room") == 2 }, time.Second, time.Millisecond)
+
+	payload := bytes.Repeat([]byte("x"), 16<<10)
+	const n = 64
+	for i := 0; i < n; i++ {
+		require.NoError(t, fast.Send(&pubsubpb.Message{Data: payload}))
+		_, err := fast.Recv()
+		require.NoError(t, err)
+	}
+	// slow 漏掉訊息，但連線還在
+	assert.Equal(t, 2, e.server.broker.Subscribers("room"))
+
+	require.NoError(t, slow.CloseSend())
+	received := 0
+	for {
+		if _, err := slow.Recv(); err != nil {
+			assert.ErrorIs(t, err, io.EOF)
+			break
+		}
+		received++
+	}
+	assert.Less(t, received, n, "some messages were dropped")
+}
+
+func TestServerCloseEndsStreams(t *testing.T) {
+	e := newEnv(t)
+	streams := e.joinAll(t, "room", "a", "b")
+	e.server.Close()
+	for _, s := range streams {
+		_, err := s.Recv()
+		assert.Equal(t, codes.Unavailable, status.Code(err))
+	}
+	stream, err := e.dial(t).Chat(WithIdentity(context.Background(), "room", "late"))
+	require.NoError(t, err)
+	_, err = stream.Recv()
+	assert.Equal(t, codes.Unavailable, status.Code(err))
+}
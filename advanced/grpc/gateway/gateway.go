@@ -0,0 +1,205 @@
+package gateway
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+
+	"advanced/httpbind"
+	"advanced/protocol/jobqueuepb"
+)
+
+const maxBody = 1 << 20
+
+// StatusClientClosedRequest 是 nginx 的非標準狀態碼，grpc-gateway 也用它表示 Canceled
+const StatusClientClosedRequest = 499
+
+// Gateway 把 REST 請求翻成對 JobsClient 的 RPC
+type Gateway struct {
+	client JobsClient
+}
+
+func New(client JobsClient) *Gateway {
+	return &Gateway{client: client}
+}
+
+func (g *Gateway) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path == "/v1/jobs" {
+		if !allow(w, r, http.MethodPost) {
+			return
+		}
+		g.enqueue(w, r)
+		return
+	}
+	rest, ok := strings.CutPrefix(r.URL.Path, "/v1/jobs/")
+	if !ok || rest == "" || strings.Contains(rest, "/") {
+		httpbind.WriteProblem(w, r, httpbind.NewProblem(http.StatusNotFound, ""))
+		return
+	}
+	if id, ok := strings.CutSuffix(rest, ":complete"); ok {
+		if allow(w, r, http.MethodPost) {
+			g.complete(w, r, id)
+		}
+		return
+	}
+	if allow(w, r, http.MethodGet) {
+		g.get(w, r, rest)
+	}
+}
+
+func allow(w http.ResponseWriter, r *http.Request, method string) bool {
+	if r.Method == method {
+		return true
+	}
+	w.Header().Set("Allow", method)
+	httpbind.WriteProblem(w, r, httpbind.NewProblem(http.StatusMethodNotAllowed, ""))
+	return false
+}
+
+func (g *Gateway) enqueue(w http.ResponseWriter, r *http.Request) {
+	job := new(jobqueuepb.Job)
+	if !decode(w, r, job) {
+		return
+	}
+	req := &jobqueuepb.EnqueueRequest{Job: job}
+	if err := Validate(req); err != nil {
+		writeError(w, r, err)
+		return
+	}
+	out, err := g.client.Enqueue(r.Context(), req)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	w.Header().Set("Location", "/v1/jobs/"+out.GetId())
+	write(w, http.StatusCreated, out)
+}
+
+func (g *Gateway) get(w http.ResponseWriter, r *http.Request, id string) {
+	req := wrapperspb.String(id)
+	if err := Validate(req); err != nil {
+		writeError(w, r, err)
+		return
+	}
+	out, err := g.client.GetJob(r.Context(), req)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	write(w, http.StatusOK, out)
+}
+
+func (g *Gateway) complete(w http.ResponseWriter, r *http.Request, id string) {
+	req := new(jobqueuepb.JobResult)
+	if !decode(w, r, req) {
+		return
+	}
+	// 路徑參數優先，和 google.api.http 的規則相同
+	req.JobId = id
+	if err := Validate(req); err != nil {
+		writeError(w, r, err)
+		return
+	}
+	out, err := g.client.Complete(r.Context(), req)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	write(w, http.StatusOK, out)
+}
+
+// decode 以 protojson 解析 body，欄位名稱接受 lowerCamelCase 與原始的 snake_case
+func decode(w http.ResponseWriter, r *http.Request, m proto.Message) bool {
+	b, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxBody))
+	var mbe *http.MaxBytesError
+	switch {
+	case errors.As(err, &mbe):
+		httpbind.WriteProblem(w, r, httpbind.NewProblem(http.StatusRequestEntityTooLarge, err.Error()))
+		return false
+	case err != nil:
+		httpbind.WriteProblem(w, r, httpbind.NewProblem(http.StatusBadRequest, err.Error()))
+		return false
+	}
+	if err := protojson.Unmarshal(b, m); err != nil {
+		httpbind.WriteProblem(w, r, httpbind.NewProblem(http.StatusBadRequest, fmt.Sprintf("invalid JSON body: %v", err)))
+		return false
+	}
+	return true
+}
+
+func write(w http.ResponseWriter, code int, m proto.Message) {
+	b, err := protojson.Marshal(m)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	w.Write(b)
+}
+
+// HTTPStatus 把 gRPC status code 對應到 HTTP status，對照表與 grpc-gateway 相同
+func HTTPStatus(c codes.Code) int {
+	switch c {
+	case codes.OK:
+		return http.StatusOK
+	case codes.Canceled:
+		return StatusClientClosedRequest
+	case codes.InvalidArgument, codes.FailedPrecondition, codes.OutOfRange:
+		return http.StatusBadRequest
+	case codes.DeadlineExceeded:
+		return http.StatusGatewayTimeout
+	case codes.NotFound:
+		return http.StatusNotFound
+	case codes.AlreadyExists, codes.Aborted:
+		return http.StatusConflict
+	case codes.PermissionDenied:
+		return http.StatusForbidden
+	case codes.Unauthenticated:
+		return http.StatusUnauthorized
+	case codes.ResourceExhausted:
+		return http.StatusTooManyRequests
+	case codes.Unimplemented:
+		return http.StatusNotImplemented
+	case codes.Unavailable:
+		return http.StatusServiceUnavailable
+	}
+	return http.StatusInternalServerError
+}
+
+// writeError 把 gRPC status 轉成 problem+json；BadRequest 的欄位放進 errors，
+// Unknown / Internal / DataLoss 的訊息不回傳給 client
+func writeError(w http.ResponseWriter, r *http.Request, err error) {
+	st, ok := status.FromError(err)
+	if !ok {
+		// client 斷線或 deadline 到了，RPC 還沒送出就失敗時拿到的是 ctx 的錯誤
+		st = status.FromContextError(err)
+	}
+	code := HTTPStatus(st.Code())
+	detail := st.Message()
+	switch st.Code() {
+	case codes.Unknown, codes.Internal, codes.DataLoss:
+		detail = ""
+	}
+	p := httpbind.NewProblem(code, detail)
+	if code == StatusClientClosedRequest {
+		p.Title = "Client Closed Request"
+	}
+	for _, d := range st.Details() {
+		if br, ok := d.(*errdetails.BadRequest); ok {
+			for _, fv := range br.GetFieldViolations() {
+				p.Errors = append(p.Errors, httpbind.FieldError{Field: fv.GetField(), Reason: fv.GetDescription()})
+			}
+		}
+	}
+	httpbind.WriteProblem(w, r, p)
+}
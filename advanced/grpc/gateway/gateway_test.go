@@ -0,0 +1,233 @@
+package gateway
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+
+	"advanced/httpbind"
+	"advanced/protocol/jobqueuepb"
+	"advanced/timex"
+)
+
+var start = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+type env struct {
+	grpc  JobsClient
+	http  *httptest.Server
+	calls atomic.Int32 // 到達後端的 RPC 數
+}
+
+// newEnv 啟動同一個 Service，一邊是 gRPC client，一邊是經過 Gateway 的 HTTP server
+func newEnv(t *testing.T) *env {
+	e := &env{}
+	lis := bufconn.Listen(1 << 20)
+	count := func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		e.calls.Add(1)
+		return handler(ctx, req)
+	}
+	srv := grpc.NewServer(grpc.ChainUnaryInterceptor(count, UnaryValidate))
+	RegisterJobsServer(srv, NewService(WithClock(timex.NewFake(start))))
+	go srv.Serve(lis)
+	t.Cleanup(srv.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+
+	e.grpc = NewJobsClient(conn)
+	e.http = httptest.NewServer(New(e.grpc))
+	t.Cleanup(e.http.Close)
+	return e
+}
+
+func (e *env) do(t *testing.T, method, path, body string) (*http.Response, []byte) {
+	req, err := http.NewRequest(method, e.http.URL+path, strings.NewReader(body))
+	require.NoError(t, err)
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	var b json.RawMessage
+	_ = json.NewDecoder(resp.Body).Decode(&b)
+	return resp, b
+}
+
+func (e *env) doJob(t *testing.T, method, path, body string) (int, *jobqueuepb.Job) {
+	resp, b := e.do(t, method, path, body)
+	job := new(jobqueuepb.Job)
+	if resp.StatusCode < 300 {
+		require.NoError(t, protojson.Unmarshal(b, job))
+	}
+	return resp.StatusCode, job
+}
+
+func problem(t *testing.T, b []byte) httpbind.Problem {
+	var p httpbind.Problem
+	require.NoError(t, json.Unmarshal(b, &p))
+	return p
+}
+
+// 兩種 transport 操作的是同一份資料
+func TestBothTransportsShareService(t *testing.T) {
+	e := newEnv(t)
+	ctx := context.Background()
+
+	code, created := e.doJob(t, http.MethodPost, "/v1/jobs", `{"queue":"emails","payload":"aGk=","maxAttempts":2,"metadata":{"tenant":"a"}}`)
+	require.Equal(t, http.StatusCreated, code)
+	assert.Equal(t, "job-1", created.GetId())
+	assert.Equal(t, jobqueuepb.JobStatus_JOB_STATUS_PENDING, created.GetStatus())
+	assert.Equal(t, []byte("hi"), created.GetPayload())
+	assert.Equal(t, start, created.GetRunAt().AsTime(), "run_at defaults to the service clock")
+
+	got, err := e.grpc.GetJob(ctx, wrapperspb.String(created.GetId()))
+	require.NoError(t, err)
+	assert.Equal(t, "emails", got.GetQueue())
+	assert.Equal(t, map[string]string{"tenant": "a"}, got.GetMetadata())
+
+	// gRPC 回報一次失敗，REST 看得到
+	_, err = e.grpc.Complete(ctx, &jobqueuepb.JobResult{JobId: got.GetId(), Error: "smtp timeout"})
+	require.NoError(t, err)
+	code, viaHTTP := e.doJob(t, http.MethodGet, "/v1/jobs/job-1", "")
+	require.Equal(t, http.StatusOK, code)
+	assert.EqualValues(t, 1, viaHTTP.GetAttempts())
+	assert.Equal(t, "smtp timeout", viaHTTP.GetLastError())
+	assert.Equal(t, jobqueuepb.JobStatus_JOB_STATUS_PENDING, viaHTTP.GetStatus())
+
+	// REST 完成，snake_case 欄位名稱也接受；body 中的 job_id 被路徑覆蓋
+	code, done := e.doJob(t, http.MethodPost, "/v1/jobs/job-1:complete", `{"ok":true,"job_id":"ignored"}`)
+	require.Equal(t, http.StatusOK, code)
+	assert.Equal(t, jobqueuepb.JobStatus_JOB_STATUS_SUCCEEDED, done.GetStatus())
+
+	_, err = e.grpc.Complete(ctx, &jobqueuepb.JobResult{JobId: "job-1", Ok: true})
+	assert.Equal(t, codes.FailedPrecondition, status.Code(err))
+	resp, b := e.do(t, http.MethodPost, "/v1/jobs/job-1:complete", `{"ok":true}`)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	assert.Contains(t, problem(t, b).Detail, "already JOB_STATUS_SUCCEEDED")
+}
+
+// 同一份驗證規則：gRPC 拿到 BadRequest details，REST 拿到相同欄位的 problem+json，
+// 而且 gateway 在轉送前就擋下，不會打到後端
+func TestSharedValidation(t *testing.T) {
+	e := newEnv(t)
+	cases := []struct {
+		name   string
+		grpc   func() error
+		method string
+		path   string
+		body   string
+		fields []string
+	}{
+		{
+			name: "enqueue",
+			grpc: func() error {
+				_, err := e.grpc.Enqueue(context.Background(), &jobqueuepb.EnqueueRequest{Job: &jobqueuepb.Job{Id: "x", Queue: "Bad Queue", MaxAttempts: 500}})
+				return err
+			},
+			method: http.MethodPost, path: "/v1/jobs", body: `{"id":"x","queue":"Bad Queue","maxAttempts":500}`,
+			fields: []string{"job.id", "job.queue", "job.max_attempts"},
+		},
+		{
+			name: "complete",
+			grpc: func() error {
+				_, err := e.grpc.Complete(context.Background(), &jobqueuepb.JobResult{JobId: "job-1", Ok: true, Error: "?"})
+				return err
+			},
+			method: http.MethodPost, path: "/v1/jobs/job-1:complete", body: `{"ok":true,"error":"?"}`,
+			fields: []string{"error"},
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			before := e.calls.Load()
+			err := tc.grpc()
+			require.Equal(t, codes.InvalidArgument, status.Code(err))
+			var grpcFields []string
+			for _, d := range status.Convert(err).Details() {
+				for _, fv := range d.(*errdetails.BadRequest).GetFieldViolations() {
+					grpcFields = append(grpcFields, fv.GetField())
+				}
+			}
+			assert.Equal(t, tc.fields, grpcFields)
+			assert.Equal(t, before+1, e.calls.Load())
+
+			resp, b := e.do(t, tc.method, tc.path, tc.body)
+			require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+			assert.Equal(t, "application/problem+json", resp.Header.Get("Content-Type"))
+			var httpFields []string
+			for _, fe := range problem(t, b).Errors {
+				httpFields = append(httpFields, fe.Field)
+			}
+			assert.Equal(t, tc.fields, httpFields)
+			assert.Equal(t, before+1, e.calls.Load(), "rejected by the gateway without an RPC")
+		})
+	}
+}
+
+func TestGatewayErrors(t *testing.T) {
+	e := newEnv(t)
+
+	resp, b := e.do(t, http.MethodGet, "/v1/jobs/missing", "")
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+	assert.Equal(t, `job "missing" not found`, problem(t, b).Detail)
+
+	resp, _ = e.do(t, http.MethodDelete, "/v1/jobs/job-1", "")
+	assert.Equal(t, http.StatusMethodNotAllowed, resp.StatusCode)
+	assert.Equal(t, http.MethodGet, resp.Header.Get("Allow"))
+
+	resp, _ = e.do(t, http.MethodGet, "/v1/jobs", "")
+	assert.Equal(t, http.StatusMethodNotAllowed, resp.StatusCode)
+
+	for _, path := range []string{"/v1/jobs/", "/v1/jobs/a/b", "/v2/jobs"} {
+		resp, _ = e.do(t, http.MethodGet, path, "")
+		assert.Equal(t, http.StatusNotFound, resp.StatusCode, path)
+	}
+
+	resp, b = e.do(t, http.MethodPost, "/v1/jobs", `{"queue":`)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	assert.Contains(t, problem(t, b).Detail, "invalid JSON body")
+
+	resp, _ = e.do(t, http.MethodPost, "/v1/jobs", `{"queue":"q","unknownField":1}`)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode, "unknown fields are rejected")
+
+	resp, _ = e.do(t, http.MethodPost, "/v1/jobs", `{"queue":"q","payload":"`+strings.Repeat("A", maxBody)+`"}`)
+	assert.Equal(t, http.StatusRequestEntityTooLarge, resp.StatusCode)
+}
+
+func TestWriteErrorHidesInternalDetail(t *testing.T) {
+	for _, tc := range []struct {
+		err    error
+		code   int
+		detail string
+	}{
+		{status.Error(codes.Internal, "db password wrong"), http.StatusInternalServerError, ""},
+		{context.Canceled, StatusClientClosedRequest, "context canceled"},
+		{status.Error(codes.Unavailable, "backend down"), http.StatusServiceUnavailable, "backend down"},
+		{status.Error(codes.ResourceExhausted, "slow down"), http.StatusTooManyRequests, "slow down"},
+	} {
+		rec := httptest.NewRecorder()
+		writeError(rec, httptest.NewRequest(http.MethodGet, "/v1/jobs/x", nil), tc.err)
+		assert.Equal(t, tc.code, rec.Code)
+		p := problem(t, rec.Body.Bytes())
+		assert.Equal(t, tc.detail, p.Detail)
+		assert.NotEmpty(t, p.Title)
+	}
+}
@@ -0,0 +1,202 @@
+package gateway
+
+import (
+	"context"
+	"strconv"
+	"sync"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+
+	"advanced/protocol/jobqueuepb"
+	"advanced/timex"
+)
+
+/*
+同一個 service 實作同時透過 gRPC 與 REST 提供：
+
+	gRPC client ──────────────────────────────┐
+	                                          ▼
+	HTTP client ─► Gateway（JSON ⇄ protobuf）─► gRPC client ─► Server（UnaryValidate）─► Service
+
+  - Service 只實作 JobsServer，不知道自己被哪一種 transport 呼叫
+  - Gateway 本身也是 gRPC client：把 REST 請求翻成 RPC 轉給後端，再把 status code 翻成 HTTP status。
+    和 grpc-gateway 的做法相同，gateway 與 service 可以分開部署
+  - 驗證（validate.go）放在 server interceptor，兩種 transport 都會經過；
+    gateway 轉送前也先跑同一份規則，不合法的請求不用多一趟 RPC，錯誤格式也完全相同
+
+路由對應（相當於 google.api.http annotation）：
+
+	POST /v1/jobs                 Enqueue    body 是 Job
+	GET  /v1/jobs/{id}            GetJob
+	POST /v1/jobs/{id}:complete   Complete   body 是 JobResult，job_id 取自路徑
+
+環境中沒有 protoc，所以沒有新增 .proto：訊息沿用 protocol/jobqueuepb 產生的型別，
+ServiceDesc 與 client 手寫（形狀與 protoc-gen-go-grpc 產生的相同，可以對照 distrib/mapreduce/mrpb）。
+*/
+
+const (
+	serviceName = "golearn.jobqueue.v1.Jobs"
+
+	Jobs_Enqueue_FullMethodName  = "/" + serviceName + "/Enqueue"
+	Jobs_GetJob_FullMethodName   = "/" + serviceName + "/GetJob"
+	Jobs_Complete_FullMethodName = "/" + serviceName + "/Complete"
+)
+
+// JobsServer 是 demo service 的介面
+type JobsServer interface {
+	Enqueue(context.Context, *jobqueuepb.EnqueueRequest) (*jobqueuepb.Job, error)
+	GetJob(context.Context, *wrapperspb.StringValue) (*jobqueuepb.Job, error)
+	Complete(context.Context, *jobqueuepb.JobResult) (*jobqueuepb.Job, error)
+}
+
+// unaryHandler 對應產生的 _Jobs_X_Handler：decode 請求，有 interceptor 時經過 interceptor 再呼叫實作
+func unaryHandler[Req any, PReq interface {
+	*Req
+	proto.Message
+}](method string, call func(JobsServer, context.Context, PReq) (*jobqueuepb.Job, error)) func(any, context.Context, func(any) error, grpc.UnaryServerInterceptor) (any, error) {
+	return func(srv any, ctx context.Context, dec func(any) error, interceptor grpc.UnaryServerInterceptor) (any, error) {
+		in := PReq(new(Req))
+		if err := dec(in); err != nil {
+			return nil, err
+		}
+		if interceptor == nil {
+			return call(srv.(JobsServer), ctx, in)
+		}
+		info := &grpc.UnaryServerInfo{Server: srv, FullMethod: method}
+		return interceptor(ctx, in, info, func(ctx context.Context, req any) (any, error) {
+			return call(srv.(JobsServer), ctx, req.(PReq))
+		})
+	}
+}
+
+var Jobs_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: serviceName,
+	HandlerType: (*JobsServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{MethodName: "Enqueue", Handler: unaryHandler(Jobs_Enqueue_FullMethodName, JobsServer.Enqueue)},
+		{MethodName: "GetJob", Handler: unaryHandler(Jobs_GetJob_FullMethodName, JobsServer.GetJob)},
+		{MethodName: "Complete", Handler: unaryHandler(Jobs_Complete_FullMethodName, JobsServer.Complete)},
+	},
+	Metadata: "jobs (hand-written)",
+}
+
+func RegisterJobsServer(s grpc.ServiceRegistrar, srv JobsServer) {
+	s.RegisterService(&Jobs_ServiceDesc, srv)
+}
+
+// JobsClient 是 JobsServer 的 client 端；Gateway 也透過它呼叫後端
+type JobsClient interface {
+	Enqueue(ctx context.Context, in *jobqueuepb.EnqueueRequest, opts ...grpc.CallOption) (*jobqueuepb.Job, error)
+	GetJob(ctx context.Context, in *wrapperspb.StringValue, opts ...grpc.CallOption) (*jobqueuepb.Job, error)
+	Complete(ctx context.Context, in *jobqueuepb.JobResult, opts ...grpc.CallOption) (*jobqueuepb.Job, error)
+}
+
+type jobsClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewJobsClient(cc grpc.ClientConnInterface) JobsClient {
+	return &jobsClient{cc}
+}
+
+func (c *jobsClient) invoke(ctx context.Context, method string, in proto.Message, opts []grpc.CallOption) (*jobqueuepb.Job, error) {
+	out := new(jobqueuepb.Job)
+	if err := c.cc.Invoke(ctx, method, in, out, opts...); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *jobsClient) Enqueue(ctx context.Context, in *jobqueuepb.EnqueueRequest, opts ...grpc.CallOption) (*jobqueuepb.Job, error) {
+	return c.invoke(ctx, Jobs_Enqueue_FullMethodName, in, opts)
+}
+
+func (c *jobsClient) GetJob(ctx context.Context, in *wrapperspb.StringValue, opts ...grpc.CallOption) (*jobqueuepb.Job, error) {
+	return c.invoke(ctx, Jobs_GetJob_FullMethodName, in, opts)
+}
+
+func (c *jobsClient) Complete(ctx context.Context, in *jobqueuepb.JobResult, opts ...grpc.CallOption) (*jobqueuepb.Job, error) {
+	return c.invoke(ctx, Jobs_Complete_FullMethodName, in, opts)
+}
+
+// Service 是記憶體中的 JobsServer 實作，只負責業務邏輯；請求的格式檢查由 UnaryValidate 負責
+type Service struct {
+	mu    sync.Mutex
+	jobs  map[string]*jobqueuepb.Job
+	seq   int
+	clock timex.Clock
+}
+
+type ServiceOption func(*Service)
+
+// WithClock 設定沒有指定 run_at 時填入的時間，預設 timex.Real
+func WithClock(c timex.Clock) ServiceOption {
+	return func(s *Service) { s.clock = c }
+}
+
+func NewService(opts ...ServiceOption) *Service {
+	s := &Service{jobs: make(map[string]*jobqueuepb.Job), clock: timex.Real{}}
+	for _, o := range opts {
+		o(s)
+	}
+	return s
+}
+
+func (s *Service) Enqueue(_ context.Context, req *jobqueuepb.EnqueueRequest) (*jobqueuepb.Job, error) {
+	job := proto.Clone(req.GetJob()).(*jobqueuepb.Job)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.seq++
+	job.Id = "job-" + strconv.Itoa(s.seq)
+	job.Status = jobqueuepb.JobStatus_JOB_STATUS_PENDING
+	if job.RunAt == nil {
+		job.RunAt = timestamppb.New(s.clock.Now())
+	}
+	if job.MaxAttempts == 0 {
+		job.MaxAttempts = 3
+	}
+	s.jobs[job.Id] = job
+	return proto.Clone(job).(*jobqueuepb.Job), nil
+}
+
+func (s *Service) GetJob(_ context.Context, id *wrapperspb.StringValue) (*jobqueuepb.Job, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	job, ok := s.jobs[id.GetValue()]
+	if !ok {
+		return nil, status.Errorf(codes.NotFound, "job %q not found", id.GetValue())
+	}
+	return proto.Clone(job).(*jobqueuepb.Job), nil
+}
+
+// Complete 記錄執行結果；失敗且還有次數時重新排入 pending，已經結束的 job 回傳 FailedPrecondition
+func (s *Service) Complete(_ context.Context, res *jobqueuepb.JobResult) (*jobqueuepb.Job, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	job, ok := s.jobs[res.GetJobId()]
+	if !ok {
+		return nil, status.Errorf(codes.NotFound, "job %q not found", res.GetJobId())
+	}
+	switch job.Status {
+	case jobqueuepb.JobStatus_JOB_STATUS_SUCCEEDED, jobqueuepb.JobStatus_JOB_STATUS_FAILED:
+		return nil, status.Errorf(codes.FailedPrecondition, "job %q already %s", job.Id, job.Status)
+	}
+	job.Attempts++
+	switch {
+	case res.GetOk():
+		job.Status = jobqueuepb.JobStatus_JOB_STATUS_SUCCEEDED
+		job.LastError = ""
+	case job.Attempts >= job.MaxAttempts:
+		job.Status = jobqueuepb.JobStatus_JOB_STATUS_FAILED
+		job.LastError = res.GetError()
+	default:
+		job.Status = jobqueuepb.JobStatus_JOB_STATUS_PENDING
+		job.LastError = res.GetError()
+	}
+	return proto.Clone(job).(*jobqueuepb.Job), nil
+}
@@ -0,0 +1,95 @@
+package gateway
+
+import (
+	"context"
+	"regexp"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+
+	"advanced/protocol/jobqueuepb"
+)
+
+// 驗證規則只寫一份：錯誤以 InvalidArgument 加上 errdetails.BadRequest 表示，
+// gRPC client 可以從 status details 取出欄位，gateway 則轉成 problem+json 的 errors。
+
+const (
+	maxPayload     = 64 << 10
+	maxAttemptsCap = 100
+)
+
+var queueName = regexp.MustCompile(`^[a-z0-9][a-z0-9-]{0,62}$`)
+
+type violations []*errdetails.BadRequest_FieldViolation
+
+func (v *violations) add(field, desc string) {
+	*v = append(*v, &errdetails.BadRequest_FieldViolation{Field: field, Description: desc})
+}
+
+func (v violations) err() error {
+	if len(v) == 0 {
+		return nil
+	}
+	st := status.New(codes.InvalidArgument, "request validation failed")
+	if withDetails, err := st.WithDetails(&errdetails.BadRequest{FieldViolations: v}); err == nil {
+		st = withDetails
+	}
+	return st.Err()
+}
+
+// Validate 檢查 Jobs 的請求；不認得的型別直接通過
+func Validate(req any) error {
+	var v violations
+	switch req := req.(type) {
+	case *jobqueuepb.EnqueueRequest:
+		job := req.GetJob()
+		if job == nil {
+			v.add("job", "required")
+			break
+		}
+		if job.GetId() != "" {
+			v.add("job.id", "assigned by the server, must be empty")
+		}
+		if !queueName.MatchString(job.GetQueue()) {
+			v.add("job.queue", "must match "+queueName.String())
+		}
+		if len(job.GetPayload()) > maxPayload {
+			v.add("job.payload", "must be at most 64KiB")
+		}
+		if job.GetMaxAttempts() > maxAttemptsCap {
+			v.add("job.max_attempts", "must be at most 100")
+		}
+		if job.GetStatus() != jobqueuepb.JobStatus_JOB_STATUS_UNSPECIFIED {
+			v.add("job.status", "assigned by the server, must be unspecified")
+		}
+		if job.RunAt != nil && job.RunAt.CheckValid() != nil {
+			v.add("job.run_at", "invalid timestamp")
+		}
+		if job.Timeout != nil && (job.Timeout.CheckValid() != nil || job.Timeout.AsDuration() < 0) {
+			v.add("job.timeout", "must be a non-negative duration")
+		}
+	case *wrapperspb.StringValue:
+		if req.GetValue() == "" {
+			v.add("id", "required")
+		}
+	case *jobqueuepb.JobResult:
+		if req.GetJobId() == "" {
+			v.add("job_id", "required")
+		}
+		if req.GetOk() && req.GetError() != "" {
+			v.add("error", "must be empty when ok is true")
+		}
+	}
+	return v.err()
+}
+
+// UnaryValidate 是在 handler 之前執行 Validate 的 server interceptor
+func UnaryValidate(ctx context.Context, req any, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	if err := Validate(req); err != nil {
+		return nil, err
+	}
+	return handler(ctx, req)
+}
@@ -0,0 +1,223 @@
+package httpbind
+
+import (
+	"bytes"
+	"context"
+	"encoding"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"reflect"
+	"strings"
+
+	"advanced/reflectx"
+)
+
+/*
+把 request 綁定到 struct，驗證後再交給 handler；錯誤一律以 RFC 7807 problem+json 回應。
+
+	type listReq struct {
+		Owner string `path:"owner"`
+		Limit int    `query:"limit" validate:"min=1,max=100"`
+		Tags  []string `query:"tag"`              // ?tag=a&tag=b
+	}
+	type createReq struct {
+		URL  string `json:"url" validate:"required,max=2048"`
+		Code string `json:"code" validate:"max=32"`
+	}
+
+	req, err := httpbind.Bind[createReq](r)
+	if err != nil {
+		httpbind.Error(w, r, err) // 400 / 413 / 415 / 422 problem+json
+		return
+	}
+
+綁定順序（後面覆蓋前面）：JSON body → query → path。
+  - body 只在有內容時解析，Content-Type 必須是 application/json，未知欄位視為錯誤
+  - query / path 的字串依欄位型別轉換：數字、bool、time.Duration、TextUnmarshaler，slice 取重複的參數
+  - path 參數：module 是 go 1.21，ServeMux 還沒有 {name} pattern，
+    由 Match 解析後用 WithPathValues 放進 request
+  - 驗證由 reflectx.Validate 執行，tag 與 config/yamlenv 相同：required、min、max、oneof；
+    T 實作 Validator 時再做跨欄位檢查。tag 寫錯時 Bind 回傳一般錯誤（500），不會 panic
+  - 所有驗證錯誤一次回報（422 的 errors 陣列），欄位名稱用 tag 中的名稱，client 看得懂
+*/
+
+// MaxBodySize 是 JSON body 的上限
+var MaxBodySize int64 = 1 << 20
+
+var (
+	ErrUnsupportedMediaType = errors.New("httpbind: content type must be application/json")
+	ErrBodyTooLarge         = errors.New("httpbind: request body too large")
+)
+
+// Validator 讓 struct 自行做跨欄位檢查；回傳 ValidationErrors 時會與 tag 的錯誤合併
+type Validator interface {
+	Validate() error
+}
+
+// DecodeError 表示 request 的格式錯誤（400），例如 JSON 語法錯誤或 query 不是數字
+type DecodeError struct {
+	In    string // body、query 或 path
+	Field string
+	Err   error
+}
+
+func (e *DecodeError) Error() string {
+	if e.Field == "" {
+		return fmt.Sprintf("httpbind: %s: %v", e.In, e.Err)
+	}
+	return fmt.Sprintf("httpbind: %s %q: %v", e.In, e.Field, e.Err)
+}
+
+func (e *DecodeError) Unwrap() error { return e.Err }
+
+type FieldError struct {
+	Field  string `json:"field"`
+	Reason string `json:"reason"`
+}
+
+// ValidationErrors 收集所有驗證失敗的欄位（422）
+type ValidationErrors []FieldError
+
+func (es ValidationErrors) Error() string {
+	msgs := make([]string, len(es))
+	for i, e := range es {
+		msgs[i] = e.Field + ": " + e.Reason
+	}
+	return "httpbind: invalid request: " + strings.Join(msgs, "; ")
+}
+
+type pathKey struct{}
+
+// WithPathValues 把路由解析出的 path 參數放進 request，給 `path:"name"` 欄位使用
+func WithPathValues(r *http.Request, vals map[string]string) *http.Request {
+	return r.WithContext(context.WithValue(r.Context(), pathKey{}, vals))
+}
+
+// PathValue 回傳 WithPathValues 放入的參數
+func PathValue(r *http.Request, name string) string {
+	vals, _ := r.Context().Value(pathKey{}).(map[string]string)
+	return vals[name]
+}
+
+// Match 比對 "/api/links/{code}" 這種 pattern，每個 {name} 對應一個非空的 path segment
+func Match(pattern, path string) (map[string]string, bool) {
+	ps := strings.Split(strings.Trim(pattern, "/"), "/")
+	ss := strings.Split(strings.Trim(path, "/"), "/")
+	if len(ps) != len(ss) {
+		return nil, false
+	}
+	vals := make(map[string]string)
+	for i, p := range ps {
+		if strings.HasPrefix(p, "{") && strings.HasSuffix(p, "}") {
+			if ss[i] == "" {
+				return nil, false
+			}
+			vals[p[1:len(p)-1]] = ss[i]
+		} else if p != ss[i] {
+			return nil, false
+		}
+	}
+	return vals, true
+}
+
+// Bind 解析 r 到 T（必須是 struct）並驗證
+func Bind[T any](r *http.Request) (T, error) {
+	var out T
+	v := reflect.ValueOf(&out).Elem()
+	if v.Kind() != reflect.Struct {
+		return out, fmt.Errorf("httpbind: %T is not a struct", out)
+	}
+	if err := decodeBody(r, &out); err != nil {
+		return out, err
+	}
+	if err := bindStrings(&out, "query", func(name string) ([]string, bool) {
+		vs, ok := r.URL.Query()[name]
+		return vs, ok
+	}); err != nil {
+		return out, err
+	}
+	if err := bindStrings(&out, "path", func(name string) ([]string, bool) {
+		vals, _ := r.Context().Value(pathKey{}).(map[string]string)
+		s, ok := vals[name]
+		return []string{s}, ok
+	}); err != nil {
+		return out, err
+	}
+	errs, err := validate(&out)
+	if err != nil {
+		return out, err
+	}
+	if len(errs) > 0 {
+		return out, errs
+	}
+	return out, nil
+}
+
+func decodeBody(r *http.Request, out any) error {
+	if r.Body == nil || r.Body == http.NoBody {
+		return nil
+	}
+	data, err := io.ReadAll(io.LimitReader(r.Body, MaxBodySize+1))
+	if err != nil {
+		return &DecodeError{In: "body", Err: err}
+	}
+	if int64(len(data)) > MaxBodySize {
+		return ErrBodyTooLarge
+	}
+	if len(bytes.TrimSpace(data)) == 0 {
+		return nil
+	}
+	if mt, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type")); mt != "application/json" {
+		return ErrUnsupportedMediaType
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(out); err != nil {
+		var te *json.UnmarshalTypeError
+		if errors.As(err, &te) {
+			return &DecodeError{In: "body", Field: te.Field, Err: fmt.Errorf("must be %s", te.Type)}
+		}
+		return &DecodeError{In: "body", Err: err}
+	}
+	if dec.More() {
+		return &DecodeError{In: "body", Err: errors.New("unexpected data after JSON value")}
+	}
+	return nil
+}
+
+var textUnmarshaler = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+
+// bindStrings 設定有 tag 的欄位（最外層與嵌入的 struct）；lookup 找不到時保留原值（body 或零值）
+func bindStrings(out any, tag string, lookup func(string) ([]string, bool)) error {
+	return reflectx.Walk(out, func(f reflectx.Field) error {
+		name := f.Tag(tag)
+		if name == "" {
+			return reflectx.SkipStruct
+		}
+		vals, ok := lookup(name)
+		if !ok || len(vals) == 0 {
+			return reflectx.SkipStruct
+		}
+		fv := f.Value
+		var err error
+		if fv.Kind() == reflect.Slice && !fv.Addr().Type().Implements(textUnmarshaler) {
+			sl := reflect.MakeSlice(fv.Type(), len(vals), len(vals))
+			for j, s := range vals {
+				if err = reflectx.FromString(sl.Index(j), s); err != nil {
+					break
+				}
+			}
+			fv.Set(sl)
+		} else {
+			err = reflectx.FromString(fv, vals[len(vals)-1])
+		}
+		if err != nil {
+			return &DecodeError{In: tag, Field: name, Err: err}
+		}
+		return reflectx.SkipStruct
+	})
+}
@@ -0,0 +1,172 @@
+package httpbind
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type address struct {
+	City string `json:"city" validate:"required"`
+}
+
+type createUser struct {
+	Org     string        `path:"org" validate:"required"`
+	DryRun  bool          `query:"dry_run"`
+	Timeout time.Duration `query:"timeout"`
+	Name    string        `json:"name" validate:"required,max=10"`
+	Age     int           `json:"age" validate:"min=0,max=150"`
+	Role    string        `json:"role" validate:"oneof=admin member"`
+	Tags    []string      `json:"tags" query:"tag" validate:"max=3"`
+	Address *address      `json:"address"`
+}
+
+func (c *createUser) Validate() error {
+	if c.Role == "admin" && c.Org == "public" {
+		return ValidationErrors{{Field: "role", Reason: "public org cannot have admins"}}
+	}
+	return nil
+}
+
+func request(method, target, contentType, body string) *http.Request {
+	r := httptest.NewRequest(method, target, strings.NewReader(body))
+	if contentType != "" {
+		r.Header.Set("Content-Type", contentType)
+	}
+	vals, ok := Match("/orgs/{org}/users", r.URL.Path)
+	if ok {
+		r = WithPathValues(r, vals)
+	}
+	return r
+}
+
+func TestBind(t *testing.T) {
+	r := request("POST", "/orgs/acme/users?dry_run=true&timeout=2s&tag=x&tag=y", "application/json; charset=utf-8",
+		`{"name":"gopher","age":13,"role":"member","tags":["ignored"],"address":{"city":"Taipei"}}`)
+	got, err := Bind[createUser](r)
+	require.NoError(t, err)
+	assert.Equal(t, createUser{
+		Org: "acme", DryRun: true, Timeout: 2 * time.Second,
+		Name: "gopher", Age: 13, Role: "member",
+		Tags:    []string{"x", "y"}, // query 覆蓋 body
+		Address: &address{City: "Taipei"},
+	}, got)
+	assert.Equal(t, "acme", PathValue(r, "org"))
+}
+
+func TestValidation(t *testing.T) {
+	r := request("POST", "/orgs/public/users", "application/json",
+		`{"name":"a very long name","age":-1,"role":"admin","tags":["a","b","c","d"],"address":{}}`)
+	_, err := Bind[createUser](r)
+	var ves ValidationErrors
+	require.ErrorAs(t, err, &ves)
+	assert.Equal(t, ValidationErrors{
+		{Field: "name", Reason: "length must be <= 10"},
+		{Field: "age", Reason: "must be >= 0"},
+		{Field: "tag", Reason: "length must be <= 3"}, // 有多個 tag 時用 query 的名稱,
+		{Field: "address.city", Reason: "is required"},
+		{Field: "role", Reason: "public org cannot have admins"},
+	}, ves)
+
+	_, err = Bind[createUser](request("POST", "/orgs/acme/users", "", ""))
+	require.ErrorAs(t, err, &ves)
+	assert.Equal(t, ValidationErrors{
+		{Field: "name", Reason: "is required"},
+		{Field: "role", Reason: "must be one of [admin member]"},
+	}, ves, "沒有 body 時只做驗證")
+}
+
+func TestDecodeErrors(t *testing.T) {
+	for _, tc := range []struct {
+		name, target, contentType, body string
+		status                          int
+		field                           string
+	}{
+		{"syntax", "/orgs/a/users", "application/json", `{"name":`, http.StatusBadRequest, ""},
+		{"type", "/orgs/a/users", "application/json", `{"name":"x","age":"old"}`, http.StatusBadRequest, "age"},
+		{"unknown field", "/orgs/a/users", "application/json", `{"nmae":"x"}`, http.StatusBadRequest, ""},
+		{"trailing data", "/orgs/a/users", "application/json", `{"name":"x"} {}`, http.StatusBadRequest, ""},
+		{"query", "/orgs/a/users?timeout=soon", "", "", http.StatusBadRequest, "timeout"},
+		{"media type", "/orgs/a/users", "text/plain", `name=x`, http.StatusUnsupportedMediaType, ""},
+		{"too large", "/orgs/a/users", "application/json", `{"name":"` + strings.Repeat("x", int(MaxBodySize)) + `"}`, http.StatusRequestEntityTooLarge, ""},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := Bind[createUser](request("POST", tc.target, tc.contentType, tc.body))
+			require.Error(t, err)
+			p := ProblemFor(err)
+			assert.Equal(t, tc.status, p.Status)
+			if tc.field != "" {
+				require.Len(t, p.Errors, 1)
+				assert.Equal(t, tc.field, p.Errors[0].Field)
+			}
+		})
+	}
+}
+
+func TestMatch(t *testing.T) {
+	vals, ok := Match("/api/links/{code}", "/api/links/abc")
+	assert.True(t, ok)
+	assert.Equal(t, map[string]string{"code": "abc"}, vals)
+	for _, path := range []string{"/api/links", "/api/links/", "/api/links/a/b", "/api/other/abc"} {
+		_, ok := Match("/api/links/{code}", path)
+		assert.False(t, ok, path)
+	}
+}
+
+func TestWriteProblem(t *testing.T) {
+	r := request("POST", "/orgs/acme/users", "application/json", `{"age":200,"role":"member"}`)
+	_, err := Bind[createUser](r)
+	w := httptest.NewRecorder()
+	Error(w, r, err)
+
+	assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+	assert.Equal(t, "application/problem+json", w.Header().Get("Content-Type"))
+	assert.JSONEq(t, `{
+		"type": "about:blank",
+		"title": "Unprocessable Entity",
+		"status": 422,
+		"detail": "request validation failed",
+		"instance": "/orgs/acme/users",
+		"errors": [
+			{"field": "name", "reason": "is required"},
+			{"field": "age", "reason": "must be <= 150"}
+		]
+	}`, w.Body.String())
+
+	// 內部錯誤不洩漏細節
+	w = httptest.NewRecorder()
+	Error(w, r, errors.New("pq: password authentication failed"))
+	var p Problem
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&p))
+	assert.Equal(t, http.StatusInternalServerError, p.Status)
+	assert.Empty(t, p.Detail)
+
+	// handler 自己的 Problem 原樣回傳
+	w = httptest.NewRecorder()
+	Error(w, r, NewProblem(http.StatusConflict, "user exists"))
+	assert.Equal(t, http.StatusConflict, w.Code)
+	assert.Contains(t, w.Body.String(), `"detail":"user exists"`)
+}
+
+// tag 寫錯是程式的 bug：回傳 500，不會在處理 request 時 panic
+func TestBadRuleIsServerError(t *testing.T) {
+	type badReq struct {
+		Name string `json:"name" validate:"maxlen=3"`
+	}
+	_, err := Bind[badReq](request("POST", "/x", "application/json", `{"name":"x"}`))
+	require.Error(t, err)
+	assert.Equal(t, http.StatusInternalServerError, ProblemFor(err).Status)
+
+	type runes struct {
+		Name string `json:"name" validate:"max=2"`
+	}
+	_, err = Bind[runes](request("POST", "/x", "application/json", `{"name":"地球"}`))
+	assert.NoError(t, err, "長度以字元計算")
+}
@@ -0,0 +1,73 @@
+package httpbind
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+)
+
+// Problem 是 RFC 7807 的 problem details；Errors 是擴充欄位，列出驗證失敗的欄位
+type Problem struct {
+	Type     string       `json:"type"`
+	Title    string       `json:"title"`
+	Status   int          `json:"status"`
+	Detail   string       `json:"detail,omitempty"`
+	Instance string       `json:"instance,omitempty"`
+	Errors   []FieldError `json:"errors,omitempty"`
+}
+
+func (p *Problem) Error() string {
+	if p.Detail == "" {
+		return p.Title
+	}
+	return p.Title + ": " + p.Detail
+}
+
+// NewProblem 建立 type 為 about:blank 的 Problem，title 為 status 的標準說明
+func NewProblem(status int, detail string) *Problem {
+	return &Problem{Type: "about:blank", Title: http.StatusText(status), Status: status, Detail: detail}
+}
+
+// ProblemFor 把 Bind 的錯誤對應到 status；其他錯誤視為 500，不把內部訊息回傳給 client
+func ProblemFor(err error) *Problem {
+	var (
+		p   *Problem
+		ves ValidationErrors
+		de  *DecodeError
+	)
+	switch {
+	case errors.As(err, &p):
+		return p
+	case errors.As(err, &ves):
+		p := NewProblem(http.StatusUnprocessableEntity, "request validation failed")
+		p.Errors = ves
+		return p
+	case errors.Is(err, ErrBodyTooLarge):
+		return NewProblem(http.StatusRequestEntityTooLarge, err.Error())
+	case errors.Is(err, ErrUnsupportedMediaType):
+		return NewProblem(http.StatusUnsupportedMediaType, err.Error())
+	case errors.As(err, &de):
+		p := NewProblem(http.StatusBadRequest, de.Error())
+		if de.Field != "" {
+			p.Errors = []FieldError{{Field: de.Field, Reason: de.Err.Error()}}
+		}
+		return p
+	}
+	return NewProblem(http.StatusInternalServerError, "")
+}
+
+// WriteProblem 以 application/problem+json 回應；Instance 為空時填入 request 的 path
+func WriteProblem(w http.ResponseWriter, r *http.Request, p *Problem) {
+	out := *p
+	if out.Instance == "" {
+		out.Instance = r.URL.Path
+	}
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.WriteHeader(out.Status)
+	json.NewEncoder(w).Encode(out)
+}
+
+// Error 等於 WriteProblem(w, r, ProblemFor(err))
+func Error(w http.ResponseWriter, r *http.Request, err error) {
+	WriteProblem(w, r, ProblemFor(err))
+}
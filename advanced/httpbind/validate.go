@@ -0,0 +1,48 @@
+package httpbind
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"advanced/reflectx"
+)
+
+// fieldName 依序取 path、query、json tag，與 client 送來的名稱一致
+func fieldName(sf reflect.StructField) string {
+	for _, tag := range []string{"path", "query", "json"} {
+		if name, _, _ := strings.Cut(sf.Tag.Get(tag), ","); name != "" && name != "-" {
+			return name
+		}
+	}
+	return sf.Name
+}
+
+func join(prefix, name string) string {
+	if prefix == "" {
+		return name
+	}
+	return prefix + "." + name
+}
+
+// validate 以 reflectx.Validate 檢查 out；Validator 回傳的 ValidationErrors 攤平後加上 struct 的路徑。
+// tag 寫錯時回傳一般的錯誤，ProblemFor 會把它當成 500
+func validate(out any) (ValidationErrors, error) {
+	var errs ValidationErrors
+	err := reflectx.Validate(out, fieldName, func(path string, err error) {
+		var ves ValidationErrors
+		if errors.As(err, &ves) {
+			for _, fe := range ves {
+				fe.Field = join(path, fe.Field)
+				errs = append(errs, fe)
+			}
+			return
+		}
+		errs = append(errs, FieldError{Field: path, Reason: err.Error()})
+	})
+	if err != nil {
+		return nil, fmt.Errorf("httpbind: %w", err)
+	}
+	return errs, nil
+}
@@ -0,0 +1,228 @@
+package httpcompress
+
+import (
+	"bufio"
+	"compress/flate"
+	"compress/gzip"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/andybalholm/brotli"
+)
+
+/*
+依 Accept-Encoding 壓縮回應（br、gzip、deflate）：
+
+	h = httpcompress.Middleware(httpcompress.WithMinSize(1024))(h)
+
+  - 協商：依 client 的 q 值挑選，q 相同時依 server 的偏好順序（預設 br > gzip > deflate）；
+    一律加上 Vary: Accept-Encoding，讓中間的快取不會把壓縮過的內容給不支援的 client
+  - 串流：不會把整個 body 讀進記憶體，只先暫存前 MinSize bytes 用來判斷要不要壓縮，
+    之後邊寫邊壓縮；handler 呼叫 Flush 時壓縮器也會 Flush（SSE、長輪詢照常運作）
+  - 略過：body 小於 MinSize（壓縮的 header 開銷比省下的還多）、已經有 Content-Encoding、
+    本身就是壓縮格式的 Content-Type（圖片、影音、zip…）、HEAD 與沒有 body 的狀態碼
+  - 壓縮器很大（gzip 約 800KB、brotli 更多），每個 request 都 New 一個會讓 GC 很忙，
+    所以每種編碼、每個 level 一個 sync.Pool，用 Reset 重複使用
+*/
+
+type encoder interface {
+	io.WriteCloser
+	Flush() error
+	Reset(io.Writer)
+}
+
+type config struct {
+	minSize   int
+	level     int
+	encodings []string
+	skipTypes []string
+}
+
+type Option func(*config)
+
+// WithMinSize 設定要壓縮的最小 body 大小，預設 1024
+func WithMinSize(n int) Option {
+	return func(c *config) { c.minSize = n }
+}
+
+// WithLevel 設定壓縮等級（1 最快 ~ 9 最小），brotli 使用相同的數字（最大 11）；預設 5
+func WithLevel(l int) Option {
+	return func(c *config) { c.level = l }
+}
+
+// WithEncodings 設定支援的編碼與偏好順序，預設 "br", "gzip", "deflate"
+func WithEncodings(encs ...string) Option {
+	return func(c *config) { c.encodings = encs }
+}
+
+// 已經壓縮過的格式，再壓縮只是浪費 CPU；結尾為 / 的表示整個類別
+var defaultSkip = []string{
+	"image/", "video/", "audio/",
+	"application/zip", "application/gzip", "application/x-gzip", "application/zstd",
+	"application/x-7z-compressed", "application/x-rar-compressed", "font/woff", "font/woff2",
+}
+
+func (c *config) skipType(contentType string) bool {
+	mt, _, _ := mime.ParseMediaType(contentType)
+	if mt == "image/svg+xml" {
+		return false // 文字格式
+	}
+	for _, s := range c.skipTypes {
+		if mt == s || (strings.HasSuffix(s, "/") && strings.HasPrefix(mt, s)) {
+			return true
+		}
+	}
+	return false
+}
+
+var pools sync.Map // "gzip/5" → *sync.Pool
+
+func getEncoder(enc string, level int, w io.Writer) encoder {
+	p, _ := pools.LoadOrStore(enc+"/"+strconv.Itoa(level), &sync.Pool{New: func() any {
+		if enc == "br" {
+			return brotli.NewWriterLevel(nil, level)
+		}
+		l := min(level, flate.BestCompression) // gzip 與 deflate 最大是 9
+		if enc == "gzip" {
+			zw, _ := gzip.NewWriterLevel(nil, l)
+			return zw
+		}
+		fw, _ := flate.NewWriter(nil, l)
+		return fw
+	}})
+	e := p.(*sync.Pool).Get().(encoder)
+	e.Reset(w)
+	return e
+}
+
+func putEncoder(enc string, level int, e encoder) {
+	e.Reset(io.Discard) // 放開對 ResponseWriter 的參考
+	p, _ := pools.Load(enc + "/" + strconv.Itoa(level))
+	p.(*sync.Pool).Put(e)
+}
+
+func Middleware(opts ...Option) func(http.Handler) http.Handler {
+	c := config{minSize: 1024, level: 5, encodings: []string{"br", "gzip", "deflate"}, skipTypes: defaultSkip}
+	for _, o := range opts {
+		o(&c)
+	}
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Add("Vary", "Accept-Encoding")
+			enc := negotiate(r.Header.Get("Accept-Encoding"), c.encodings)
+			if enc == "" || r.Method == http.MethodHead {
+				next.ServeHTTP(w, r)
+				return
+			}
+			cw := &writer{ResponseWriter: w, cfg: &c, enc: enc, status: http.StatusOK}
+			defer cw.close()
+			next.ServeHTTP(cw, r)
+		})
+	}
+}
+
+// writer 先暫存 minSize bytes，決定要不要壓縮之後才真的寫出 header
+type writer struct {
+	http.ResponseWriter
+	cfg         *config
+	enc         string
+	status      int
+	wroteHeader bool // handler 呼叫過 WriteHeader
+	decided     bool // 已經寫出 header
+	buf         []byte
+	zw          encoder
+}
+
+func (w *writer) WriteHeader(status int) {
+	if w.wroteHeader || w.decided {
+		return
+	}
+	w.status, w.wroteHeader = status, true
+	if status < 200 || status == http.StatusNoContent || status == http.StatusNotModified {
+		w.decide(false, nil) // 沒有 body
+	}
+}
+
+func (w *writer) Write(p []byte) (int, error) {
+	if !w.decided {
+		if len(w.buf)+len(p) < w.cfg.minSize {
+			w.buf = append(w.buf, p...)
+			return len(p), nil
+		}
+		// 達到 minSize：寫出暫存的部分，p 直接往下寫，不必再複製一次
+		if err := w.decide(true, p); err != nil {
+			return 0, err
+		}
+	}
+	if w.zw != nil {
+		return w.zw.Write(p)
+	}
+	return w.ResponseWriter.Write(p)
+}
+
+// decide 寫出 header 與暫存的資料；large 表示 body 已經達到 minSize，next 是接著要寫的資料（用來判斷型別）
+func (w *writer) decide(large bool, next []byte) error {
+	w.decided = true
+	h := w.Header()
+	compress := large && h.Get("Content-Encoding") == "" && !w.cfg.skipType(h.Get("Content-Type"))
+	if compress {
+		if h.Get("Content-Type") == "" {
+			// 之後就看不到原始內容了，先用原始內容判斷型別
+			h.Set("Content-Type", http.DetectContentType(append(w.buf[:len(w.buf):len(w.buf)], next[:min(len(next), 512)]...)))
+		}
+		h.Del("Content-Length")
+		h.Set("Content-Encoding", w.enc)
+		w.ResponseWriter.WriteHeader(w.status)
+		w.zw = getEncoder(w.enc, w.cfg.level, w.ResponseWriter)
+		_, err := w.zw.Write(w.buf)
+		w.buf = nil
+		return err
+	}
+	w.ResponseWriter.WriteHeader(w.status)
+	if len(w.buf) == 0 {
+		return nil
+	}
+	_, err := w.ResponseWriter.Write(w.buf)
+	w.buf = nil
+	return err
+}
+
+// Flush 讓串流的回應可以立即送出；還沒決定時以目前的大小決定
+func (w *writer) Flush() {
+	if !w.decided {
+		w.decide(len(w.buf) >= w.cfg.minSize, nil)
+	}
+	if w.zw != nil {
+		w.zw.Flush()
+	}
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Hijack 讓 WebSocket 等協定升級可以通過 middleware
+func (w *writer) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	if hj, ok := w.ResponseWriter.(http.Hijacker); ok {
+		w.decided = true
+		return hj.Hijack()
+	}
+	return nil, nil, http.ErrNotSupported
+}
+
+func (w *writer) Unwrap() http.ResponseWriter { return w.ResponseWriter }
+
+func (w *writer) close() {
+	if !w.decided {
+		w.decide(false, nil)
+	}
+	if w.zw != nil {
+		w.zw.Close()
+		putEncoder(w.enc, w.cfg.level, w.zw)
+		w.zw = nil
+	}
+}
@@ -0,0 +1,44 @@
+package httpcompress
+
+import (
+	"strconv"
+	"strings"
+)
+
+// negotiate 依 Accept-Encoding 的 q 值挑出編碼，q 相同時依 supported 的順序（server 偏好）；
+// 沒有可用的編碼時回傳 ""（identity）
+func negotiate(header string, supported []string) string {
+	if header == "" {
+		return ""
+	}
+	q := make(map[string]float64)
+	star, hasStar := 0.0, false
+	for _, part := range strings.Split(header, ",") {
+		name, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		name = strings.ToLower(strings.TrimSpace(name))
+		weight := 1.0
+		if v, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			w, err := strconv.ParseFloat(v, 64)
+			if err != nil {
+				continue
+			}
+			weight = w
+		}
+		if name == "*" {
+			star, hasStar = weight, true
+			continue
+		}
+		q[name] = weight
+	}
+	best, bestQ := "", 0.0
+	for _, enc := range supported {
+		w, ok := q[enc]
+		if !ok && hasStar {
+			w, ok = star, true
+		}
+		if ok && w > bestQ {
+			best, bestQ = enc, w
+		}
+	}
+	return best
+}
@@ -0,0 +1,155 @@
+package idempotency
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"advanced/timex"
+)
+
+// 模擬建立訂單：每次執行都產生新的訂單編號
+func orderHandler(calls *atomic.Int32, delay time.Duration) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := calls.Add(1)
+		time.Sleep(delay)
+		body, _ := io.ReadAll(r.Body)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		fmt.Fprintf(w, `{"order":%d,"req":%q}`, n, body)
+	})
+}
+
+func post(h http.Handler, key, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/orders", strings.NewReader(body))
+	if key != "" {
+		req.Header.Set(DefaultHeader, key)
+	}
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestReplay(t *testing.T) {
+	var calls atomic.Int32
+	h := Middleware(NewLRUStore(100))(orderHandler(&calls, 0))
+
+	first := post(h, "k1", "item=1")
+	assert.Equal(t, http.StatusCreated, first.Code)
+	assert.Empty(t, first.Header().Get(ReplayHeader))
+
+	second := post(h, "k1", "item=1")
+	assert.Equal(t, http.StatusCreated, second.Code)
+	assert.Equal(t, first.Body.String(), second.Body.String())
+	assert.Equal(t, "application/json", second.Header().Get("Content-Type"))
+	assert.Equal(t, "true", second.Header().Get(ReplayHeader))
+	assert.Equal(t, int32(1), calls.Load())
+
+	// 不同的 key、沒有 key、GET 都不去重
+	post(h, "k2", "item=1")
+	post(h, "", "item=1")
+	post(h, "", "item=1")
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/orders", nil))
+	assert.Equal(t, int32(5), calls.Load())
+}
+
+func TestKeyReusedWithDifferentRequest(t *testing.T) {
+	var calls atomic.Int32
+	h := Middleware(NewLRUStore(100))(orderHandler(&calls, 0))
+	post(h, "k1", "item=1")
+	rec := post(h, "k1", "item=2")
+	assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+	assert.Equal(t, int32(1), calls.Load())
+}
+
+// 5xx 不快取，client 重試時會再執行一次
+func TestServerErrorNotCached(t *testing.T) {
+	var calls atomic.Int32
+	h := Middleware(NewLRUStore(100))(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) == 1 {
+			http.Error(w, "db down", http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte("ok"))
+	}))
+	assert.Equal(t, http.StatusServiceUnavailable, post(h, "k1", "x").Code)
+	assert.Equal(t, http.StatusOK, post(h, "k1", "x").Code)
+	rec := post(h, "k1", "x")
+	assert.Equal(t, "true", rec.Header().Get(ReplayHeader))
+	assert.Equal(t, int32(2), calls.Load())
+}
+
+func TestTTLAndScope(t *testing.T) {
+	clock := timex.NewFake(time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC))
+	store := NewLRUStore(100, WithClock(clock))
+
+	var calls atomic.Int32
+	h := Middleware(store, WithTTL(time.Hour), WithScope(func(r *http.Request) string {
+		return r.Header.Get("X-User")
+	}))(orderHandler(&calls, 0))
+
+	send := func(user string) {
+		req := httptest.NewRequest(http.MethodPost, "/orders", strings.NewReader("x"))
+		req.Header.Set(DefaultHeader, "same-key")
+		req.Header.Set("X-User", user)
+		h.ServeHTTP(httptest.NewRecorder(), req)
+	}
+	send("alice")
+	send("bob") // 不同使用者的同一個 key 互不影響
+	send("alice")
+	assert.Equal(t, int32(2), calls.Load())
+
+	clock.Advance(time.Hour)
+	send("alice") // 過期後重新執行
+	assert.Equal(t, int32(3), calls.Load())
+}
+
+// 同一個 key 的並行重複請求只會執行一次 handler
+func TestConcurrentDuplicates(t *testing.T) {
+	var calls atomic.Int32
+	h := Middleware(NewLRUStore(100))(orderHandler(&calls, 50*time.Millisecond))
+
+	const n = 50
+	var wg sync.WaitGroup
+	bodies := make([]string, n)
+	replayed := make([]bool, n)
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			rec := post(h, "dup", "item=1")
+			assert.Equal(t, http.StatusCreated, rec.Code)
+			bodies[i] = rec.Body.String()
+			replayed[i] = rec.Header().Get(ReplayHeader) == "true"
+		}(i)
+	}
+	wg.Wait()
+
+	assert.Equal(t, int32(1), calls.Load())
+	originals := 0
+	for i := 0; i < n; i++ {
+		assert.Equal(t, bodies[0], bodies[i])
+		if !replayed[i] {
+			originals++
+		}
+	}
+	assert.Equal(t, 1, originals)
+}
+
+func TestLRUStoreEviction(t *testing.T) {
+	var calls atomic.Int32
+	h := Middleware(NewLRUStore(2))(orderHandler(&calls, 0))
+	post(h, "a", "x")
+	post(h, "b", "x")
+	post(h, "c", "x") // a 被淘汰
+	post(h, "a", "x")
+	assert.Equal(t, int32(4), calls.Load())
+}
@@ -0,0 +1,176 @@
+package idempotency
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"time"
+
+	"golang.org/x/sync/singleflight"
+)
+
+/*
+Idempotency-Key：client 對每個「操作」產生一個唯一 key，網路逾時重送時帶同一個 key，
+server 保證只執行一次，之後的重送直接回傳第一次的結果（例如付款不會扣兩次）。
+
+流程：
+ 1. 沒有 key 或不是 POST → 直接交給 handler
+ 2. store 有結果 → 比對 fingerprint，相同則重播回應（加上 Idempotent-Replayed: true），
+    不同表示 client 誤用同一個 key，回傳 422
+ 3. store 沒有 → 以 singleflight 合併同時到達的重複請求，只有一個會真的執行 handler
+ 4. 2xx/4xx 的回應存入 store；5xx 不存，讓 client 可以重試
+*/
+
+const (
+	DefaultHeader = "Idempotency-Key"
+	ReplayHeader  = "Idempotent-Replayed"
+)
+
+type config struct {
+	header  string
+	ttl     time.Duration
+	methods map[string]bool
+	scope   func(*http.Request) string
+	maxBody int64
+}
+
+type Option func(*config)
+
+func WithHeader(name string) Option {
+	return func(c *config) { c.header = name }
+}
+
+// WithTTL 設定回應保留多久，預設 24 小時
+func WithTTL(d time.Duration) Option {
+	return func(c *config) { c.ttl = d }
+}
+
+// WithMethods 設定要去重的 method，預設只有 POST
+func WithMethods(methods ...string) Option {
+	return func(c *config) {
+		c.methods = make(map[string]bool)
+		for _, m := range methods {
+			c.methods[m] = true
+		}
+	}
+}
+
+// WithScope 讓 key 依使用者區隔，避免不同使用者的 key 互相衝突
+func WithScope(fn func(*http.Request) string) Option {
+	return func(c *config) { c.scope = fn }
+}
+
+func Middleware(store Store, opts ...Option) func(http.Handler) http.Handler {
+	c := config{
+		header:  DefaultHeader,
+		ttl:     24 * time.Hour,
+		methods: map[string]bool{http.MethodPost: true},
+		maxBody: 1 << 20,
+	}
+	for _, opt := range opts {
+		opt(&c)
+	}
+	var group singleflight.Group
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			key := r.Header.Get(c.header)
+			if key == "" || !c.methods[r.Method] {
+				next.ServeHTTP(w, r)
+				return
+			}
+			if c.scope != nil {
+				key = c.scope(r) + ":" + key
+			}
+
+			body, err := io.ReadAll(io.LimitReader(r.Body, c.maxBody+1))
+			if err != nil {
+				http.Error(w, "read body: "+err.Error(), http.StatusBadRequest)
+				return
+			}
+			if int64(len(body)) > c.maxBody {
+				http.Error(w, "request body too large", http.StatusRequestEntityTooLarge)
+				return
+			}
+			r.Body = io.NopCloser(bytes.NewReader(body))
+			fp := fingerprint(r, body)
+
+			if resp, ok, err := store.Get(r.Context(), key); err != nil {
+				http.Error(w, "idempotency store: "+err.Error(), http.StatusInternalServerError)
+				return
+			} else if ok {
+				replay(w, resp, fp, true)
+				return
+			}
+
+			executed := false
+			v, err, _ := group.Do(key, func() (interface{}, error) {
+				executed = true
+				// 等待期間另一個 instance 可能已完成，再查一次
+				if resp, ok, err := store.Get(r.Context(), key); err == nil && ok {
+					executed = false
+					return resp, nil
+				}
+				rec := &recorder{header: make(http.Header), status: http.StatusOK}
+				next.ServeHTTP(rec, r)
+				resp := &Response{Status: rec.status, Header: rec.header, Body: rec.body.Bytes(), Fingerprint: fp}
+				if resp.Status < 500 {
+					if err := store.Set(r.Context(), key, resp, c.ttl); err != nil {
+						return nil, err
+					}
+				}
+				return resp, nil
+			})
+			if err != nil {
+				http.Error(w, "idempotency store: "+err.Error(), http.StatusInternalServerError)
+				return
+			}
+			replay(w, v.(*Response), fp, !executed)
+		})
+	}
+}
+
+func fingerprint(r *http.Request, body []byte) string {
+	h := sha256.New()
+	io.WriteString(h, r.Method+" "+r.URL.RequestURI()+"\n")
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func replay(w http.ResponseWriter, resp *Response, fp string, replayed bool) {
+	if resp.Fingerprint != fp {
+		http.Error(w, "idempotency key reused with a different request", http.StatusUnprocessableEntity)
+		return
+	}
+	for k, vs := range resp.Header {
+		w.Header()[k] = append([]string(nil), vs...)
+	}
+	if replayed {
+		w.Header().Set(ReplayHeader, "true")
+	}
+	w.WriteHeader(resp.Status)
+	w.Write(resp.Body)
+}
+
+// recorder 把 handler 的輸出暫存起來，之後寫給每個等待中的請求
+type recorder struct {
+	header      http.Header
+	body        bytes.Buffer
+	status      int
+	wroteHeader bool
+}
+
+func (r *recorder) Header() http.Header { return r.header }
+
+func (r *recorder) WriteHeader(status int) {
+	if !r.wroteHeader {
+		r.status, r.wroteHeader = status, true
+	}
+}
+
+func (r *recorder) Write(b []byte) (int, error) {
+	r.wroteHeader = true
+	return r.body.Write(b)
+}
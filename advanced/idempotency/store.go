@@ -0,0 +1,69 @@
+package idempotency
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"advanced/lru"
+	"advanced/timex"
+)
+
+// Response 是快取起來的 HTTP 回應
+type Response struct {
+	Status      int
+	Header      http.Header
+	Body        []byte
+	Fingerprint string // 原始請求的 method + path + body 雜湊，用來偵測同一個 key 被用在不同請求
+}
+
+// Store 保存已完成的回應；實作 Redis 版本時以 SET key value EX ttl 即可
+type Store interface {
+	Get(ctx context.Context, key string) (*Response, bool, error)
+	Set(ctx context.Context, key string, resp *Response, ttl time.Duration) error
+}
+
+type lruEntry struct {
+	resp    *Response
+	expires time.Time
+}
+
+// LRUStore 以 lru.Cache 保存回應，過期的項目在讀取時才移除
+type LRUStore struct {
+	cache *lru.Cache[string, lruEntry]
+	clock timex.Clock
+}
+
+var _ Store = (*LRUStore)(nil)
+
+type StoreOption func(*LRUStore)
+
+// WithClock 設定判斷過期的時鐘，預設 timex.Real
+func WithClock(c timex.Clock) StoreOption {
+	return func(s *LRUStore) { s.clock = c }
+}
+
+func NewLRUStore(capacity int, opts ...StoreOption) *LRUStore {
+	s := &LRUStore{cache: lru.New[string, lruEntry](capacity), clock: timex.Real{}}
+	for _, o := range opts {
+		o(s)
+	}
+	return s
+}
+
+func (s *LRUStore) Get(_ context.Context, key string) (*Response, bool, error) {
+	e, ok := s.cache.Get(key)
+	if !ok {
+		return nil, false, nil
+	}
+	if !s.clock.Now().Before(e.expires) {
+		s.cache.Delete(key)
+		return nil, false, nil
+	}
+	return e.resp, true, nil
+}
+
+func (s *LRUStore) Set(_ context.Context, key string, resp *Response, ttl time.Duration) error {
+	s.cache.Set(key, lruEntry{resp: resp, expires: s.clock.Now().Add(ttl)})
+	return nil
+}
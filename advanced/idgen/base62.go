@@ -0,0 +1,59 @@
+package idgen
+
+import (
+	"errors"
+	"math"
+)
+
+/*
+Base62（0-9A-Za-z）：只用 URL 安全的字元、不需要跳脫，適合短網址這類要給人看、放在路徑裡的 ID。
+Snowflake ID 編碼後約 11 個字元，比十進位的 19 位短。
+和 ULID 的 Crockford base32 不同，base62 區分大小寫。
+*/
+
+var ErrInvalidBase62 = errors.New("idgen: invalid base62 string")
+
+const base62 = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz"
+
+// EncodeBase62 把 n 編成 base62，0 編成 "0"
+func EncodeBase62(n uint64) string {
+	if n == 0 {
+		return "0"
+	}
+	var out [11]byte // 62^11 > 2^64
+	i := len(out)
+	for n > 0 {
+		i--
+		out[i] = base62[n%62]
+		n /= 62
+	}
+	return string(out[i:])
+}
+
+// DecodeBase62 是 EncodeBase62 的反向，超出 uint64 範圍或有非法字元時回傳 ErrInvalidBase62
+func DecodeBase62(s string) (uint64, error) {
+	if s == "" {
+		return 0, ErrInvalidBase62
+	}
+	var n uint64
+	for i := 0; i < len(s); i++ {
+		d := base62Digit(s[i])
+		if d < 0 || n > (math.MaxUint64-uint64(d))/62 {
+			return 0, ErrInvalidBase62
+		}
+		n = n*62 + uint64(d)
+	}
+	return n, nil
+}
+
+func base62Digit(c byte) int {
+	switch {
+	case '0' <= c && c <= '9':
+		return int(c - '0')
+	case 'A' <= c && c <= 'Z':
+		return int(c-'A') + 10
+	case 'a' <= c && c <= 'z':
+		return int(c-'a') + 36
+	}
+	return -1
+}
@@ -0,0 +1,267 @@
+package idgen
+
+import (
+	"bytes"
+	"math"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// manualClock 給 generator 用的假時間，sleep 直接推進時間
+type manualClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func (c *manualClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *manualClock) Sleep(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+func (c *manualClock) Set(t time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = t
+}
+
+func TestULIDEncoding(t *testing.T) {
+	assert.Equal(t, "00000000000000000000000000", ULID{}.String())
+	var max ULID
+	for i := range max {
+		max[i] = 0xFF
+	}
+	assert.Equal(t, "7ZZZZZZZZZZZZZZZZZZZZZZZZZ", max.String())
+
+	g := NewULIDGenerator()
+	for i := 0; i < 1000; i++ {
+		u := g.MustNew()
+		p, err := ParseULID(u.String())
+		require.NoError(t, err)
+		assert.Equal(t, u, p)
+	}
+	lower, err := ParseULID("01an4z07by79ka1307sr9x4mv3")
+	require.NoError(t, err)
+	assert.Equal(t, "01AN4Z07BY79KA1307SR9X4MV3", lower.String())
+	assert.Equal(t, int64(1465824320894), lower.Time().UnixMilli())
+
+	for _, bad := range []string{"", "8ZZZZZZZZZZZZZZZZZZZZZZZZZ", "01AN4Z07BY79KA1307SR9X4MVU", "01AN4Z07BY79KA1307SR9X4MV"} {
+		_, err := ParseULID(bad)
+		assert.ErrorIs(t, err, ErrInvalidULID, bad)
+	}
+}
+
+func TestULIDMonotonic(t *testing.T) {
+	clock := &manualClock{now: time.UnixMilli(1700000000000)}
+	g := NewULIDGenerator(WithULIDClock(clock.Now))
+
+	var prev ULID
+	for i := 0; i < 10000; i++ {
+		switch i {
+		case 3000:
+			clock.Sleep(time.Millisecond)
+		case 6000:
+			clock.Sleep(-time.Second) // 時鐘倒退
+		}
+		u := g.MustNew()
+		require.Equal(t, 1, bytes.Compare(u[:], prev[:]), "ulid %d not increasing", i)
+		require.Greater(t, u.String(), prev.String())
+		prev = u
+	}
+	assert.Equal(t, int64(1700000000001), prev.Time().UnixMilli(), "timestamp never goes back")
+}
+
+type constReader byte
+
+func (c constReader) Read(p []byte) (int, error) {
+	for i := range p {
+		p[i] = byte(c)
+	}
+	return len(p), nil
+}
+
+func TestULIDOverflow(t *testing.T) {
+	clock := &manualClock{now: time.UnixMilli(1700000000000)}
+	g := NewULIDGenerator(WithULIDClock(clock.Now), WithEntropy(constReader(0xFF)))
+	_, err := g.New()
+	require.NoError(t, err)
+	_, err = g.New()
+	assert.ErrorIs(t, err, ErrOverflow)
+	clock.Sleep(time.Millisecond)
+	_, err = g.New()
+	assert.NoError(t, err)
+}
+
+func TestSnowflakeLayout(t *testing.T) {
+	clock := &manualClock{now: Epoch.Add(time.Hour)}
+	s, err := NewSnowflake(42, WithSnowflakeClock(clock.Now, clock.Sleep))
+	require.NoError(t, err)
+	id, err := s.Next()
+	require.NoError(t, err)
+	ts, node, seq := Decompose(id)
+	assert.Equal(t, Epoch.Add(time.Hour), ts)
+	assert.EqualValues(t, 42, node)
+	assert.EqualValues(t, 0, seq)
+
+	_, err = NewSnowflake(MaxNode + 1)
+	assert.Error(t, err)
+}
+
+func TestSnowflakeSequenceExhaustion(t *testing.T) {
+	clock := &manualClock{now: Epoch.Add(time.Hour)}
+	s, _ := NewSnowflake(1, WithSnowflakeClock(clock.Now, clock.Sleep))
+	var prev int64
+	for i := 0; i < maxSeq+10; i++ {
+		id, err := s.Next()
+		require.NoError(t, err)
+		require.Greater(t, id, prev)
+		prev = id
+	}
+	// 一毫秒只有 4096 個序號，之後的 9 個 ID 落在下一毫秒
+	ts, _, seq := Decompose(prev)
+	assert.Equal(t, Epoch.Add(time.Hour+time.Millisecond), ts)
+	assert.EqualValues(t, 8, seq)
+}
+
+func TestSnowflakeClockBackwards(t *testing.T) {
+	clock := &manualClock{now: Epoch.Add(time.Hour)}
+	s, _ := NewSnowflake(1, WithSnowflakeClock(clock.Now, clock.Sleep), WithMaxBackwards(5*time.Millisecond))
+	first, err := s.Next()
+	require.NoError(t, err)
+
+	// 小幅倒退：等時鐘追上
+	clock.Set(Epoch.Add(time.Hour - 3*time.Millisecond))
+	id, err := s.Next()
+	require.NoError(t, err)
+	assert.Greater(t, id, first)
+
+	// 倒退太多：回傳錯誤
+	clock.Set(Epoch.Add(time.Hour - time.Second))
+	_, err = s.Next()
+	assert.ErrorIs(t, err, ErrClockBackwards)
+}
+
+// 多個 goroutine 共用 generator 產生兩百萬個 ID，沒有重複；每個 goroutine 看到的 ID 嚴格遞增
+func TestNoCollisionsAcrossGoroutines(t *testing.T) {
+	const goroutines = 8
+	per := 250_000
+	if testing.Short() {
+		per = 10_000
+	}
+
+	t.Run("ulid", func(t *testing.T) {
+		g := NewULIDGenerator()
+		results := make([][]ULID, goroutines)
+		var wg sync.WaitGroup
+		for w := 0; w < goroutines; w++ {
+			wg.Add(1)
+			go func(w int) {
+				defer wg.Done()
+				ids := make([]ULID, per)
+				for i := range ids {
+					ids[i] = g.MustNew()
+				}
+				results[w] = ids
+			}(w)
+		}
+		wg.Wait()
+
+		seen := make(map[ULID]struct{}, goroutines*per)
+		for _, ids := range results {
+			for i, u := range ids {
+				if i > 0 && bytes.Compare(u[:], ids[i-1][:]) <= 0 {
+					t.Fatalf("not monotonic within goroutine")
+				}
+				seen[u] = struct{}{}
+			}
+		}
+		assert.Len(t, seen, goroutines*per)
+	})
+
+	t.Run("snowflake", func(t *testing.T) {
+		// 兩個節點各自產生，模擬兩台機器
+		nodes := []*Snowflake{}
+		for n := int64(0); n < 2; n++ {
+			s, err := NewSnowflake(n)
+			require.NoError(t, err)
+			nodes = append(nodes, s)
+		}
+		results := make([][]int64, goroutines)
+		var wg sync.WaitGroup
+		for w := 0; w < goroutines; w++ {
+			wg.Add(1)
+			go func(w int) {
+				defer wg.Done()
+				s := nodes[w%2]
+				ids := make([]int64, per)
+				for i := range ids {
+					id, err := s.Next()
+					if err != nil {
+						t.Error(err)
+						return
+					}
+					ids[i] = id
+				}
+				results[w] = ids
+			}(w)
+		}
+		wg.Wait()
+
+		all := make([]int64, 0, goroutines*per)
+		for _, ids := range results {
+			require.True(t, sort.SliceIsSorted(ids, func(i, j int) bool { return ids[i] < ids[j] }))
+			all = append(all, ids...)
+		}
+		sort.Slice(all, func(i, j int) bool { return all[i] < all[j] })
+		for i := 1; i < len(all); i++ {
+			if all[i] == all[i-1] {
+				t.Fatalf("duplicate id %d", all[i])
+			}
+		}
+	})
+}
+
+func BenchmarkULID(b *testing.B) {
+	g := NewULIDGenerator()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			g.MustNew()
+		}
+	})
+}
+
+func BenchmarkSnowflake(b *testing.B) {
+	s, _ := NewSnowflake(1)
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			_, _ = s.Next()
+		}
+	})
+}
+
+func TestBase62(t *testing.T) {
+	for _, n := range []uint64{0, 1, 61, 62, 3843, 3844, 1 << 40, math.MaxUint64} {
+		s := EncodeBase62(n)
+		got, err := DecodeBase62(s)
+		require.NoError(t, err, s)
+		assert.Equal(t, n, got, s)
+	}
+	assert.Equal(t, "10", EncodeBase62(62))
+	assert.Equal(t, "LygHa16AHYF", EncodeBase62(math.MaxUint64))
+
+	for _, s := range []string{"", "abc-", "LygHa16AHYG", "zzzzzzzzzzzz"} {
+		_, err := DecodeBase62(s)
+		assert.ErrorIs(t, err, ErrInvalidBase62, s)
+	}
+}
@@ -0,0 +1,106 @@
+package idgen
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+/*
+Snowflake（Twitter）：64 bits 整數，可以直接當資料庫的 BIGINT 主鍵
+
+	1 bit 0 | 41 bits 毫秒（自 Epoch 起） | 10 bits 節點 | 12 bits 序號
+
+  - 同一毫秒內序號遞增，一毫秒最多 4096 個，用完就等到下一毫秒
+  - 不同節點的 node ID 不同，所以不需要協調也不會重複
+  - 時鐘倒退：小幅倒退（<= MaxBackwards）時等待時鐘追上；倒退太多回傳 ErrClockBackwards，
+    不能沿用舊時間戳，否則重啟後可能與倒退前產生的 ID 重複
+*/
+
+const (
+	nodeBits = 10
+	seqBits  = 12
+	MaxNode  = 1<<nodeBits - 1
+	maxSeq   = 1<<seqBits - 1
+)
+
+// Epoch 為 2024-01-01，41 bits 毫秒可以用到 2093 年
+var Epoch = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+var ErrClockBackwards = errors.New("idgen: clock moved backwards")
+
+type Snowflake struct {
+	mu           sync.Mutex
+	node         int64
+	now          func() time.Time
+	sleep        func(time.Duration)
+	maxBackwards time.Duration
+	lastMs       int64
+	seq          int64
+}
+
+type SnowflakeOption func(*Snowflake)
+
+func WithSnowflakeClock(now func() time.Time, sleep func(time.Duration)) SnowflakeOption {
+	return func(s *Snowflake) { s.now, s.sleep = now, sleep }
+}
+
+// WithMaxBackwards 設定可以等待的最大時鐘倒退，預設 10ms
+func WithMaxBackwards(d time.Duration) SnowflakeOption {
+	return func(s *Snowflake) { s.maxBackwards = d }
+}
+
+func NewSnowflake(node int64, opts ...SnowflakeOption) (*Snowflake, error) {
+	if node < 0 || node > MaxNode {
+		return nil, fmt.Errorf("idgen: node %d out of range [0, %d]", node, MaxNode)
+	}
+	s := &Snowflake{node: node, now: time.Now, sleep: time.Sleep, maxBackwards: 10 * time.Millisecond, lastMs: -1}
+	for _, o := range opts {
+		o(s)
+	}
+	return s, nil
+}
+
+func (s *Snowflake) millis() int64 {
+	return s.now().Sub(Epoch).Milliseconds()
+}
+
+func (s *Snowflake) Next() (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	ms := s.millis()
+	if ms < s.lastMs {
+		back := time.Duration(s.lastMs-ms) * time.Millisecond
+		if back > s.maxBackwards {
+			return 0, fmt.Errorf("%w by %s", ErrClockBackwards, back)
+		}
+		for ms < s.lastMs {
+			s.sleep(time.Duration(s.lastMs-ms) * time.Millisecond)
+			ms = s.millis()
+		}
+	}
+	if ms == s.lastMs {
+		s.seq = (s.seq + 1) & maxSeq
+		if s.seq == 0 {
+			// 這一毫秒的序號用完了，等到下一毫秒
+			for ms <= s.lastMs {
+				s.sleep(time.Millisecond / 10)
+				ms = s.millis()
+			}
+		}
+	} else {
+		s.seq = 0
+	}
+	if ms >= 1<<41 {
+		return 0, errors.New("idgen: snowflake timestamp overflow")
+	}
+	s.lastMs = ms
+	return ms<<(nodeBits+seqBits) | s.node<<seqBits | s.seq, nil
+}
+
+// Decompose 拆出時間、節點與序號
+func Decompose(id int64) (t time.Time, node, seq int64) {
+	ms := id >> (nodeBits + seqBits)
+	return Epoch.Add(time.Duration(ms) * time.Millisecond), id >> seqBits & MaxNode, id & maxSeq
+}
@@ -0,0 +1,153 @@
+package idgen
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"io"
+	"sync"
+	"time"
+)
+
+/*
+ULID（Universally Unique Lexicographically Sortable Identifier）：
+
+	 48 bits 毫秒時間戳 | 80 bits 亂數
+	 01AN4Z07BY          | 79KA1307SR9X4MV3
+	以 Crockford base32 編成 26 個字元，字串排序 = 時間排序
+
+同一毫秒內產生多個 ULID 時，單純取亂數無法保證順序，
+monotonic 模式改成把上一個 ULID 的亂數部分 +1，所以同一個 generator 產生的 ULID 一定嚴格遞增；
+80 bits 加到溢位（實務上不可能）時回傳 ErrOverflow。
+
+時鐘倒退（NTP 校正）時沿用上一次的時間戳繼續遞增，而不是產生比較小的 ID。
+*/
+
+var ErrOverflow = errors.New("idgen: ulid entropy overflow within one millisecond")
+
+type ULID [16]byte
+
+// Crockford base32：去掉容易混淆的 I L O U
+const encoding = "0123456789ABCDEFGHJKMNPQRSTVWXYZ"
+
+func (u ULID) Time() time.Time {
+	ms := uint64(u[0])<<40 | uint64(u[1])<<32 | uint64(u[2])<<24 | uint64(u[3])<<16 | uint64(u[4])<<8 | uint64(u[5])
+	return time.UnixMilli(int64(ms))
+}
+
+// String 把 128 bits 編成 26 個字元（第一個字元只用到 3 bits）
+func (u ULID) String() string {
+	var out [26]byte
+	hi := binary.BigEndian.Uint64(u[:8])
+	lo := binary.BigEndian.Uint64(u[8:])
+	for i := 25; i >= 0; i-- {
+		out[i] = encoding[lo&31]
+		lo = lo>>5 | hi<<59
+		hi >>= 5
+	}
+	return string(out[:])
+}
+
+var decodeTable = func() [256]byte {
+	var t [256]byte
+	for i := range t {
+		t[i] = 0xFF
+	}
+	for i := 0; i < len(encoding); i++ {
+		t[encoding[i]] = byte(i)
+		t[encoding[i]|0x20] = byte(i) // 小寫
+	}
+	return t
+}()
+
+var ErrInvalidULID = errors.New("idgen: invalid ulid")
+
+func ParseULID(s string) (ULID, error) {
+	var u ULID
+	if len(s) != 26 || decodeTable[s[0]] > 7 {
+		return u, ErrInvalidULID
+	}
+	var hi, lo uint64
+	for i := 0; i < 26; i++ {
+		v := decodeTable[s[i]]
+		if v == 0xFF {
+			return u, ErrInvalidULID
+		}
+		hi = hi<<5 | lo>>59
+		lo = lo<<5 | uint64(v)
+	}
+	binary.BigEndian.PutUint64(u[:8], hi)
+	binary.BigEndian.PutUint64(u[8:], lo)
+	return u, nil
+}
+
+// ULIDGenerator 可以並行使用，產生的 ULID 嚴格遞增
+type ULIDGenerator struct {
+	mu      sync.Mutex
+	entropy io.Reader
+	now     func() time.Time
+	lastMs  uint64
+	last    ULID
+}
+
+type ULIDOption func(*ULIDGenerator)
+
+// WithEntropy 設定亂數來源，預設 crypto/rand
+func WithEntropy(r io.Reader) ULIDOption {
+	return func(g *ULIDGenerator) { g.entropy = r }
+}
+
+func WithULIDClock(now func() time.Time) ULIDOption {
+	return func(g *ULIDGenerator) { g.now = now }
+}
+
+func NewULIDGenerator(opts ...ULIDOption) *ULIDGenerator {
+	g := &ULIDGenerator{entropy: rand.Reader, now: time.Now}
+	for _, o := range opts {
+		o(g)
+	}
+	return g
+}
+
+func (g *ULIDGenerator) New() (ULID, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	ms := uint64(g.now().UnixMilli())
+	if ms <= g.lastMs && g.lastMs != 0 {
+		// 同一毫秒或時鐘倒退：沿用上一個時間戳，亂數部分 +1
+		u := g.last
+		if !increment(u[6:]) {
+			return ULID{}, ErrOverflow
+		}
+		g.last = u
+		return u, nil
+	}
+
+	var u ULID
+	u[0], u[1], u[2], u[3], u[4], u[5] = byte(ms>>40), byte(ms>>32), byte(ms>>24), byte(ms>>16), byte(ms>>8), byte(ms)
+	if _, err := io.ReadFull(g.entropy, u[6:]); err != nil {
+		return ULID{}, err
+	}
+	g.lastMs, g.last = ms, u
+	return u, nil
+}
+
+// MustNew 與 New 相同，發生錯誤時 panic
+func (g *ULIDGenerator) MustNew() ULID {
+	u, err := g.New()
+	if err != nil {
+		panic(err)
+	}
+	return u
+}
+
+// increment 把 big-endian 的 b 加 1，溢位時回傳 false
+func increment(b []byte) bool {
+	for i := len(b) - 1; i >= 0; i-- {
+		b[i]++
+		if b[i] != 0 {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,46 @@
+package iox
+
+import (
+	"io"
+	"sync/atomic"
+)
+
+// CountingReader 計算讀了多少 bytes，Count 可以在其他 goroutine 安全地呼叫
+type CountingReader struct {
+	r io.Reader
+	n atomic.Int64
+}
+
+func NewCountingReader(r io.Reader) *CountingReader {
+	return &CountingReader{r: r}
+}
+
+func (c *CountingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n.Add(int64(n))
+	return n, err
+}
+
+func (c *CountingReader) Count() int64 {
+	return c.n.Load()
+}
+
+// CountingWriter 計算寫了多少 bytes
+type CountingWriter struct {
+	w io.Writer
+	n atomic.Int64
+}
+
+func NewCountingWriter(w io.Writer) *CountingWriter {
+	return &CountingWriter{w: w}
+}
+
+func (c *CountingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n.Add(int64(n))
+	return n, err
+}
+
+func (c *CountingWriter) Count() int64 {
+	return c.n.Load()
+}
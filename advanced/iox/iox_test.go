@@ -0,0 +1,147 @@
+package iox
+
+import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"testing/iotest"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type failingWriter struct {
+	after int
+	n     int
+}
+
+func (f *failingWriter) Write(p []byte) (int, error) {
+	if f.n >= f.after {
+		return 0, errors.New("disk full")
+	}
+	f.n++
+	return len(p), nil
+}
+
+func TestMultiWriterIsolation(t *testing.T) {
+	var a, b bytes.Buffer
+	bad := &failingWriter{after: 1}
+	mw := NewMultiWriter(&a, bad, &b)
+
+	for _, s := range []string{"one ", "two ", "three"} {
+		n, err := mw.Write([]byte(s))
+		assert.NoError(t, err)
+		assert.Equal(t, len(s), n)
+	}
+	assert.Equal(t, "one two three", a.String())
+	assert.Equal(t, "one two three", b.String())
+	assert.Equal(t, 2, mw.Healthy())
+	assert.ErrorContains(t, mw.Err(), "sink 1: disk full")
+	// 失敗後不會再寫入
+	assert.Equal(t, 1, bad.n)
+}
+
+func TestMultiWriterAllFailed(t *testing.T) {
+	mw := NewMultiWriter(&failingWriter{}, &failingWriter{})
+	_, err := mw.Write([]byte("x"))
+	assert.ErrorIs(t, err, ErrAllSinksFailed)
+	assert.ErrorContains(t, err, "sink 0")
+	assert.ErrorContains(t, err, "sink 1")
+	assert.Nil(t, NewMultiWriter(io.Discard).Err())
+}
+
+// 用假的時鐘讓速率計算可以預測：每次 Read 前進 100ms
+func TestProgressReader(t *testing.T) {
+	data := strings.Repeat("x", 1000)
+	var tee bytes.Buffer
+	var got []Progress
+	pr := NewProgressReader(iotest.HalfReader(strings.NewReader(data)), &tee, int64(len(data)), 200*time.Millisecond,
+		func(p Progress) { got = append(got, p) })
+	now := time.Unix(0, 0)
+	pr.now = func() time.Time {
+		now = now.Add(100 * time.Millisecond)
+		return now
+	}
+
+	buf := make([]byte, 100)
+	_, err := io.CopyBuffer(struct{ io.Writer }{io.Discard}, pr, buf)
+	assert.NoError(t, err)
+	assert.Equal(t, data, tee.String())
+
+	// HalfReader 每次讀 50 bytes，共 20 次 + 1 次 EOF；每 2 次 Read 回報一次
+	assert.Len(t, got, 11)
+	assert.Equal(t, int64(100), got[0].Bytes)
+	assert.InDelta(t, 10.0, got[0].Percent(), 0.001)
+	assert.InDelta(t, 500.0, got[0].Rate, 0.001)
+	last := got[len(got)-1]
+	assert.True(t, last.Done)
+	assert.Equal(t, int64(1000), last.Bytes)
+	assert.Equal(t, 100.0, last.Percent())
+}
+
+func TestProgressUnknownTotal(t *testing.T) {
+	var last Progress
+	pr := NewProgressReader(strings.NewReader("abc"), nil, -1, time.Hour, func(p Progress) { last = p })
+	b, err := io.ReadAll(pr)
+	assert.NoError(t, err)
+	assert.Equal(t, "abc", string(b))
+	assert.True(t, last.Done)
+	assert.Equal(t, -1.0, last.Percent())
+}
+
+func TestCounting(t *testing.T) {
+	cr := NewCountingReader(strings.NewReader("hello world"))
+	var buf bytes.Buffer
+	cw := NewCountingWriter(&buf)
+	_, err := io.Copy(cw, cr)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(11), cr.Count())
+	assert.Equal(t, int64(11), cw.Count())
+}
+
+// downloader 範例：邊下載邊回報進度，同時寫入檔案與計算 checksum 的 writer
+func TestDownloaderExample(t *testing.T) {
+	body := strings.Repeat("0123456789", 1000)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Length", strconv.Itoa(len(body)))
+		_, _ = io.WriteString(w, body)
+	}))
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL)
+	assert.NoError(t, err)
+	defer resp.Body.Close()
+
+	var file, mirror bytes.Buffer
+	out := NewCountingWriter(NewMultiWriter(&file, &failingWriter{after: 2}, &mirror))
+	var last Progress
+	pr := NewProgressReader(resp.Body, nil, resp.ContentLength, 10*time.Millisecond, func(p Progress) { last = p })
+
+	_, err = io.Copy(out, pr)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(len(body)), out.Count())
+	assert.Equal(t, body, file.String())
+	assert.Equal(t, body, mirror.String())
+	assert.True(t, last.Done)
+	assert.Equal(t, 100.0, last.Percent())
+}
+
+// decorator 範例：在 gzip writer 外層與底層各包一個 CountingWriter，得到壓縮前後的大小
+func TestDecoratorExample(t *testing.T) {
+	var compressed bytes.Buffer
+	raw := NewCountingWriter(&compressed)
+	zw := gzip.NewWriter(raw)
+	plain := NewCountingWriter(zw)
+
+	_, _ = io.WriteString(plain, strings.Repeat("a", 10000))
+	assert.NoError(t, zw.Close())
+	assert.Equal(t, int64(10000), plain.Count())
+	assert.Equal(t, int64(compressed.Len()), raw.Count())
+	assert.Less(t, raw.Count(), plain.Count())
+}
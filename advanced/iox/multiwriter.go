@@ -0,0 +1,75 @@
+package iox
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+)
+
+/*
+io.MultiWriter 只要其中一個 writer 出錯就整個停下來，
+但像「同時寫 log 到檔案、stdout、遠端」的情境，遠端掛掉不應該影響寫檔。
+
+MultiWriter 會把出錯的 sink 標記為不健康並記錄錯誤，之後只寫健康的 sink，
+全部 sink 都失敗時才回傳錯誤。
+*/
+
+var ErrAllSinksFailed = errors.New("iox: all sinks failed")
+
+type MultiWriter struct {
+	mu      sync.Mutex
+	writers []io.Writer
+	failed  []error // 與 writers 對應，nil 表示健康
+}
+
+func NewMultiWriter(writers ...io.Writer) *MultiWriter {
+	return &MultiWriter{
+		writers: writers,
+		failed:  make([]error, len(writers)),
+	}
+}
+
+func (m *MultiWriter) Write(p []byte) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	healthy := 0
+	for i, w := range m.writers {
+		if m.failed[i] != nil {
+			continue
+		}
+		n, err := w.Write(p)
+		if err == nil && n != len(p) {
+			err = io.ErrShortWrite
+		}
+		if err != nil {
+			m.failed[i] = fmt.Errorf("sink %d: %w", i, err)
+			continue
+		}
+		healthy++
+	}
+	if healthy == 0 {
+		return 0, fmt.Errorf("%w: %w", ErrAllSinksFailed, errors.Join(m.failed...))
+	}
+	return len(p), nil
+}
+
+// Err 回傳所有失敗 sink 的錯誤，沒有失敗時為 nil
+func (m *MultiWriter) Err() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return errors.Join(m.failed...)
+}
+
+// Healthy 回傳仍然可以寫入的 sink 數量
+func (m *MultiWriter) Healthy() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	n := 0
+	for _, err := range m.failed {
+		if err == nil {
+			n++
+		}
+	}
+	return n
+}
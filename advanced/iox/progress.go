@@ -0,0 +1,77 @@
+package iox
+
+import (
+	"io"
+	"time"
+)
+
+type Progress struct {
+	Bytes   int64
+	Total   int64 // 未知時為 -1
+	Elapsed time.Duration
+	Rate    float64 // bytes/sec
+	Done    bool
+}
+
+// Percent 回傳完成百分比，Total 未知時回傳 -1
+func (p Progress) Percent() float64 {
+	if p.Total <= 0 {
+		return -1
+	}
+	return float64(p.Bytes) * 100 / float64(p.Total)
+}
+
+// ProgressReader 類似 io.TeeReader，讀取的同時寫入 w（w 可以為 nil），
+// 並且每隔 Interval 透過 callback 回報進度，讀到 EOF 時一定會回報一次
+type ProgressReader struct {
+	r        io.Reader
+	w        io.Writer
+	total    int64
+	interval time.Duration
+	fn       func(Progress)
+	now      func() time.Time
+
+	n        int64
+	start    time.Time
+	lastCall time.Time
+	done     bool
+}
+
+func NewProgressReader(r io.Reader, w io.Writer, total int64, interval time.Duration, fn func(Progress)) *ProgressReader {
+	return &ProgressReader{r: r, w: w, total: total, interval: interval, fn: fn, now: time.Now}
+}
+
+func (p *ProgressReader) Read(b []byte) (int, error) {
+	if p.start.IsZero() {
+		p.start = p.now()
+		p.lastCall = p.start
+	}
+	n, err := p.r.Read(b)
+	if n > 0 {
+		if p.w != nil {
+			if _, werr := p.w.Write(b[:n]); werr != nil {
+				return n, werr
+			}
+		}
+		p.n += int64(n)
+	}
+	now := p.now()
+	switch {
+	case err == io.EOF && !p.done:
+		p.done = true
+		p.report(now)
+	case err == nil && now.Sub(p.lastCall) >= p.interval:
+		p.report(now)
+	}
+	return n, err
+}
+
+func (p *ProgressReader) report(now time.Time) {
+	p.lastCall = now
+	elapsed := now.Sub(p.start)
+	var rate float64
+	if elapsed > 0 {
+		rate = float64(p.n) / elapsed.Seconds()
+	}
+	p.fn(Progress{Bytes: p.n, Total: p.total, Elapsed: elapsed, Rate: rate, Done: p.done})
+}
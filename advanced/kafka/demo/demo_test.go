@@ -0,0 +1,201 @@
+package demo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/IBM/sarama"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOffsetTracker(t *testing.T) {
+	var tr offsetTracker
+	for _, o := range []int64{5, 6, 7, 9} { // 8 被 compaction 刪掉
+		tr.add(o)
+	}
+	_, ok := tr.complete(7)
+	assert.False(t, ok) // 5、6 還沒完成
+	next, ok := tr.complete(5)
+	assert.True(t, ok)
+	assert.Equal(t, int64(6), next)
+	next, _ = tr.complete(6)
+	assert.Equal(t, int64(8), next) // 5、6、7 都完成
+	next, _ = tr.complete(9)
+	assert.Equal(t, int64(10), next)
+}
+
+type recorder struct {
+	mu    sync.Mutex
+	seen  map[string]int
+	total int
+}
+
+func (r *recorder) record(m *sarama.ConsumerMessage) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.seen == nil {
+		r.seen = make(map[string]int)
+	}
+	r.seen[string(m.Value)]++
+	r.total++
+}
+
+func (r *recorder) count() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.total
+}
+
+func (r *recorder) unique() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.seen)
+}
+
+func quiet() Option { return WithLogger(log.New(io.Discard, "", 0)) }
+
+func produce(b *FakeBroker, topic string, partitions, n int) {
+	for i := 0; i < n; i++ {
+		b.Produce(topic, int32(i%partitions), nil, []byte(fmt.Sprint(i)))
+	}
+}
+
+func start(ctx context.Context, g ConsumerGroup, w *Worker) <-chan error {
+	errc := make(chan error, 1)
+	go func() { errc <- Run(ctx, g, []string{"orders"}, w) }()
+	return errc
+}
+
+func TestProcessAndCommit(t *testing.T) {
+	b := NewFakeBroker()
+	b.CreateTopic("orders", 3)
+	produce(b, "orders", 3, 30)
+
+	var rec recorder
+	w := NewWorker(func(ctx context.Context, m *sarama.ConsumerMessage) error {
+		rec.record(m)
+		return nil
+	}, WithWorkers(4), quiet())
+	ctx, cancel := context.WithCancel(context.Background())
+	errc := start(ctx, b.Group(), w)
+
+	assert.Eventually(t, func() bool { return rec.unique() == 30 }, 2*time.Second, 5*time.Millisecond)
+	produce(b, "orders", 3, 3) // 持續有新訊息也會處理
+	assert.Eventually(t, func() bool { return rec.count() == 33 }, 2*time.Second, 5*time.Millisecond)
+	cancel()
+	assert.NoError(t, <-errc)
+	for p := int32(0); p < 3; p++ {
+		assert.Equal(t, int64(11), b.Committed("orders", p))
+	}
+}
+
+// 失敗的訊息與其後的訊息都不 commit：重啟後從失敗的地方繼續
+func TestCommitOnlyAfterSuccess(t *testing.T) {
+	b := NewFakeBroker()
+	b.CreateTopic("orders", 1)
+	produce(b, "orders", 1, 5)
+
+	var attempts int
+	var mu sync.Mutex
+	w := NewWorker(func(ctx context.Context, m *sarama.ConsumerMessage) error {
+		if m.Offset == 2 {
+			mu.Lock()
+			attempts++
+			mu.Unlock()
+			return errors.New("downstream unavailable")
+		}
+		return nil
+	}, WithRetry(2, time.Millisecond), quiet())
+
+	err := <-start(context.Background(), b.Group(), w)
+	assert.ErrorIs(t, err, ErrPermanent)
+	assert.Contains(t, err.Error(), "orders/0@2")
+	assert.Equal(t, 3, attempts)
+	assert.Equal(t, int64(2), b.Committed("orders", 0))
+
+	// 修好之後重啟，從 offset 2 開始
+	var rec recorder
+	w = NewWorker(func(ctx context.Context, m *sarama.ConsumerMessage) error {
+		rec.record(m)
+		return nil
+	}, quiet())
+	ctx, cancel := context.WithCancel(context.Background())
+	errc := start(ctx, b.Group(), w)
+	assert.Eventually(t, func() bool { return rec.unique() == 3 }, 2*time.Second, 5*time.Millisecond)
+	cancel()
+	assert.NoError(t, <-errc)
+	assert.Equal(t, map[string]int{"2": 1, "3": 1, "4": 1}, rec.seen)
+	assert.Equal(t, int64(5), b.Committed("orders", 0))
+}
+
+// rebalance 時等處理中的訊息完成並 commit，新的 generation 不會重複處理
+func TestRebalanceDrainsInflight(t *testing.T) {
+	b := NewFakeBroker()
+	b.CreateTopic("orders", 2)
+	produce(b, "orders", 2, 40)
+
+	var rec recorder
+	w := NewWorker(func(ctx context.Context, m *sarama.ConsumerMessage) error {
+		time.Sleep(2 * time.Millisecond)
+		rec.record(m)
+		return nil
+	}, WithWorkers(3), quiet())
+	g := b.Group()
+	ctx, cancel := context.WithCancel(context.Background())
+	errc := start(ctx, g, w)
+
+	assert.Eventually(t, func() bool { return rec.unique() >= 5 }, 2*time.Second, time.Millisecond)
+	g.Rebalance()
+	assert.Eventually(t, func() bool { return rec.unique() >= 20 }, 2*time.Second, time.Millisecond)
+	g.Rebalance()
+	assert.Eventually(t, func() bool { return rec.unique() == 40 }, 2*time.Second, time.Millisecond)
+	cancel()
+	assert.NoError(t, <-errc)
+
+	assert.GreaterOrEqual(t, g.Generation(), int32(3))
+	assert.Equal(t, 40, rec.total, "no message processed twice")
+}
+
+// 當機時未 commit 的訊息會重新處理（at-least-once）
+func TestCrashRedelivers(t *testing.T) {
+	b := NewFakeBroker()
+	b.CreateTopic("orders", 1)
+	produce(b, "orders", 1, 10)
+
+	var rec recorder
+	w := NewWorker(func(ctx context.Context, m *sarama.ConsumerMessage) error {
+		rec.record(m)
+		return nil
+	}, WithWorkers(1), quiet())
+	g := b.Group()
+	ctx, cancel := context.WithCancel(context.Background())
+	errc := start(ctx, g, w)
+
+	assert.Eventually(t, func() bool { return rec.unique() == 10 }, 2*time.Second, time.Millisecond)
+	g.Crash()
+	assert.Eventually(t, func() bool { return rec.count() == 20 }, 2*time.Second, time.Millisecond)
+	cancel()
+	assert.NoError(t, <-errc)
+	assert.Equal(t, int64(10), b.Committed("orders", 0))
+}
+
+func TestCloseStopsRun(t *testing.T) {
+	b := NewFakeBroker()
+	b.CreateTopic("orders", 1)
+	g := b.Group()
+	errc := start(context.Background(), g, NewWorker(func(context.Context, *sarama.ConsumerMessage) error { return nil }, quiet()))
+	time.Sleep(10 * time.Millisecond)
+	assert.NoError(t, g.Close())
+	select {
+	case err := <-errc:
+		assert.NoError(t, err)
+	case <-time.After(time.Second):
+		t.Fatal("Run did not return after Close")
+	}
+}
@@ -0,0 +1,284 @@
+package demo
+
+import (
+	"context"
+	"sort"
+	"sync"
+
+	"github.com/IBM/sarama"
+)
+
+/*
+FakeBroker 是單元測試用的記憶體 broker，行為對應 sarama 的 consumer group：
+  - 每次 Consume 是一個 generation：Setup → 每個 partition 一個 ConsumeClaim → Cleanup
+  - MarkOffset 只是標記，Commit() 或 session 正常結束時才寫入 committed offset
+  - Rebalance() 結束目前的 generation（正常結束，會 commit），Crash() 模擬 process 當掉（不 commit）
+  - 只支援單一 member，assignment 為所有 partition
+*/
+
+type FakeBroker struct {
+	mu        sync.Mutex
+	logs      map[string][][]*sarama.ConsumerMessage // topic -> partition -> messages
+	committed map[string]map[int32]int64
+	notify    chan struct{}
+}
+
+func NewFakeBroker() *FakeBroker {
+	return &FakeBroker{
+		logs:      make(map[string][][]*sarama.ConsumerMessage),
+		committed: make(map[string]map[int32]int64),
+		notify:    make(chan struct{}),
+	}
+}
+
+// CreateTopic 建立有 partitions 個 partition 的 topic
+func (b *FakeBroker) CreateTopic(topic string, partitions int) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.logs[topic] = make([][]*sarama.ConsumerMessage, partitions)
+}
+
+// Produce 寫入訊息並回傳其 offset
+func (b *FakeBroker) Produce(topic string, partition int32, key, value []byte) int64 {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	log := b.logs[topic][partition]
+	msg := &sarama.ConsumerMessage{Topic: topic, Partition: partition, Offset: int64(len(log)), Key: key, Value: value}
+	b.logs[topic][partition] = append(log, msg)
+	close(b.notify)
+	b.notify = make(chan struct{})
+	return msg.Offset
+}
+
+// Committed 回傳 group 已 commit 的 offset（下一個要讀的），沒有 commit 過為 0
+func (b *FakeBroker) Committed(topic string, partition int32) int64 {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.committed[topic][partition]
+}
+
+func (b *FakeBroker) commit(marks map[string]map[int32]int64) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for topic, ps := range marks {
+		if b.committed[topic] == nil {
+			b.committed[topic] = make(map[int32]int64)
+		}
+		for p, off := range ps {
+			if off > b.committed[topic][p] {
+				b.committed[topic][p] = off
+			}
+		}
+	}
+}
+
+// fetch 回傳 offset 之後的訊息，沒有新訊息時回傳等待用的 channel
+func (b *FakeBroker) fetch(topic string, partition int32, offset int64) ([]*sarama.ConsumerMessage, <-chan struct{}) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	log := b.logs[topic][partition]
+	if offset < int64(len(log)) {
+		return log[offset:], nil
+	}
+	return nil, b.notify
+}
+
+// Group 回傳一個可供 Run 使用的 consumer group
+func (b *FakeBroker) Group() *FakeGroup {
+	return &FakeGroup{broker: b, closed: make(chan struct{})}
+}
+
+type FakeGroup struct {
+	broker *FakeBroker
+
+	mu         sync.Mutex
+	generation int32
+	end        func(commit bool)
+	closed     chan struct{}
+	closeOnce  sync.Once
+}
+
+var _ ConsumerGroup = (*FakeGroup)(nil)
+
+// Rebalance 正常結束目前的 generation，Consume 會返回，呼叫端重新 Consume 後取得新的 generation
+func (g *FakeGroup) Rebalance() { g.stop(true) }
+
+// Crash 結束目前的 generation 且不 commit 尚未 commit 的 offset，模擬 process 當機
+func (g *FakeGroup) Crash() { g.stop(false) }
+
+func (g *FakeGroup) stop(commit bool) {
+	g.mu.Lock()
+	end := g.end
+	g.mu.Unlock()
+	if end != nil {
+		end(commit)
+	}
+}
+
+// Generation 回傳目前（或最後一次）的 generation 編號
+func (g *FakeGroup) Generation() int32 {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.generation
+}
+
+func (g *FakeGroup) Close() error {
+	g.closeOnce.Do(func() { close(g.closed) })
+	g.stop(true)
+	return nil
+}
+
+func (g *FakeGroup) Consume(ctx context.Context, topics []string, handler sarama.ConsumerGroupHandler) error {
+	select {
+	case <-g.closed:
+		return sarama.ErrClosedConsumerGroup
+	default:
+	}
+
+	sctx, cancel := context.WithCancel(ctx)
+	sess := &fakeSession{ctx: sctx, broker: g.broker, claims: make(map[string][]int32), marks: make(map[string]map[int32]int64)}
+	commitOnEnd := true
+	var endOnce sync.Once
+	end := func(commit bool) {
+		endOnce.Do(func() {
+			sess.mu.Lock()
+			commitOnEnd = commit
+			sess.crashed = !commit
+			sess.mu.Unlock()
+			cancel()
+		})
+	}
+
+	g.mu.Lock()
+	g.generation++
+	sess.generation = g.generation
+	g.end = end
+	g.mu.Unlock()
+	defer cancel()
+
+	g.broker.mu.Lock()
+	for _, t := range topics {
+		for p := range g.broker.logs[t] {
+			sess.claims[t] = append(sess.claims[t], int32(p))
+		}
+		sort.Slice(sess.claims[t], func(i, j int) bool { return sess.claims[t][i] < sess.claims[t][j] })
+	}
+	g.broker.mu.Unlock()
+
+	if err := handler.Setup(sess); err != nil {
+		return err
+	}
+	var wg sync.WaitGroup
+	for topic, parts := range sess.claims {
+		for _, p := range parts {
+			claim := &fakeClaim{topic: topic, partition: p, offset: g.broker.Committed(topic, p), messages: make(chan *sarama.ConsumerMessage)}
+			wg.Add(2)
+			go func() {
+				defer wg.Done()
+				claim.feed(sctx, g.broker)
+			}()
+			go func() {
+				defer wg.Done()
+				// 與 sarama 相同：任何一個 claim 結束都會結束整個 session
+				defer cancel()
+				handler.ConsumeClaim(sess, claim)
+			}()
+		}
+	}
+	wg.Wait()
+	err := handler.Cleanup(sess)
+
+	sess.mu.Lock()
+	commit := commitOnEnd
+	sess.mu.Unlock()
+	if commit {
+		sess.Commit()
+	}
+	return err
+}
+
+type fakeSession struct {
+	ctx        context.Context
+	broker     *FakeBroker
+	generation int32
+	claims     map[string][]int32
+
+	mu      sync.Mutex
+	marks   map[string]map[int32]int64
+	crashed bool
+}
+
+func (s *fakeSession) Claims() map[string][]int32 { return s.claims }
+func (s *fakeSession) MemberID() string           { return "fake-member" }
+func (s *fakeSession) GenerationID() int32        { return s.generation }
+func (s *fakeSession) Context() context.Context   { return s.ctx }
+
+func (s *fakeSession) MarkOffset(topic string, partition int32, offset int64, metadata string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.marks[topic] == nil {
+		s.marks[topic] = make(map[int32]int64)
+	}
+	if offset > s.marks[topic][partition] {
+		s.marks[topic][partition] = offset
+	}
+}
+
+func (s *fakeSession) MarkMessage(msg *sarama.ConsumerMessage, metadata string) {
+	s.MarkOffset(msg.Topic, msg.Partition, msg.Offset+1, metadata)
+}
+
+func (s *fakeSession) ResetOffset(topic string, partition int32, offset int64, metadata string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.marks[topic] == nil {
+		s.marks[topic] = make(map[int32]int64)
+	}
+	s.marks[topic][partition] = offset
+}
+
+// Commit 把標記的 offset 寫入 broker；Crash 之後的 commit 會被忽略（process 已經不在了）
+func (s *fakeSession) Commit() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.crashed {
+		return
+	}
+	s.broker.commit(s.marks)
+}
+
+type fakeClaim struct {
+	topic     string
+	partition int32
+	offset    int64
+	messages  chan *sarama.ConsumerMessage
+}
+
+func (c *fakeClaim) Topic() string                            { return c.topic }
+func (c *fakeClaim) Partition() int32                         { return c.partition }
+func (c *fakeClaim) InitialOffset() int64                     { return c.offset }
+func (c *fakeClaim) HighWaterMarkOffset() int64               { return 0 }
+func (c *fakeClaim) Messages() <-chan *sarama.ConsumerMessage { return c.messages }
+
+func (c *fakeClaim) feed(ctx context.Context, b *FakeBroker) {
+	defer close(c.messages)
+	next := c.offset
+	for {
+		msgs, wait := b.fetch(c.topic, c.partition, next)
+		for _, m := range msgs {
+			select {
+			case c.messages <- m:
+				next = m.Offset + 1
+			case <-ctx.Done():
+				return
+			}
+		}
+		if wait != nil {
+			select {
+			case <-wait:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}
+}
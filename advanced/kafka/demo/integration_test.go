@@ -0,0 +1,71 @@
+//go:build integration
+
+package demo
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/IBM/sarama"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/testcontainers/testcontainers-go/modules/kafka"
+)
+
+// 需要 Docker：go test -tags integration -run Integration ./kafka/demo
+func TestIntegrationConsumerGroup(t *testing.T) {
+	ctx := context.Background()
+	container, err := kafka.Run(ctx, "confluentinc/confluent-local:7.5.0", kafka.WithClusterID("go-learn"))
+	require.NoError(t, err)
+	t.Cleanup(func() { container.Terminate(context.Background()) })
+
+	brokers, err := container.Brokers(ctx)
+	require.NoError(t, err)
+
+	cfg := sarama.NewConfig()
+	cfg.Version = sarama.V3_5_0_0
+	cfg.Producer.Return.Successes = true
+	cfg.Consumer.Offsets.Initial = sarama.OffsetOldest
+	cfg.Consumer.Offsets.AutoCommit.Interval = 100 * time.Millisecond
+
+	admin, err := sarama.NewClusterAdmin(brokers, cfg)
+	require.NoError(t, err)
+	defer admin.Close()
+	require.NoError(t, admin.CreateTopic("orders", &sarama.TopicDetail{NumPartitions: 3, ReplicationFactor: 1}, false))
+
+	producer, err := sarama.NewSyncProducer(brokers, cfg)
+	require.NoError(t, err)
+	defer producer.Close()
+	const n = 30
+	for i := 0; i < n; i++ {
+		_, _, err := producer.SendMessage(&sarama.ProducerMessage{Topic: "orders", Key: sarama.StringEncoder(fmt.Sprint(i % 5)), Value: sarama.StringEncoder(fmt.Sprint(i))})
+		require.NoError(t, err)
+	}
+
+	group, err := sarama.NewConsumerGroup(brokers, "demo", cfg)
+	require.NoError(t, err)
+	var rec recorder
+	w := NewWorker(func(ctx context.Context, m *sarama.ConsumerMessage) error {
+		rec.record(m)
+		return nil
+	}, quiet())
+	runCtx, cancel := context.WithCancel(ctx)
+	errc := make(chan error, 1)
+	go func() { errc <- Run(runCtx, group, []string{"orders"}, w) }()
+
+	assert.Eventually(t, func() bool { return rec.unique() == n }, 60*time.Second, 100*time.Millisecond)
+	cancel()
+	assert.NoError(t, <-errc)
+	require.NoError(t, group.Close())
+
+	// Cleanup 會同步 commit，所有 partition 的 offset 都已前進到最後
+	offsets, err := admin.ListConsumerGroupOffsets("demo", map[string][]int32{"orders": {0, 1, 2}})
+	require.NoError(t, err)
+	var committed int64
+	for p := int32(0); p < 3; p++ {
+		committed += offsets.GetBlock("orders", p).Offset
+	}
+	assert.Equal(t, int64(n), committed)
+}
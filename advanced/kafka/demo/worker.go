@@ -0,0 +1,253 @@
+package demo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/IBM/sarama"
+
+	"advanced/timex"
+)
+
+/*
+Kafka consumer group 的處理流程（以 sarama 的 ConsumerGroupHandler 為模型）：
+
+	Consume() ──> Setup ──> ConsumeClaim × N（每個 partition 一個 goroutine）──> Cleanup
+	   ^                                                                          |
+	   └──────────────────────── rebalance：重新分配 partition ────────────────────┘
+
+重點：
+  - 訊息交給 worker pool 並行處理，但 offset 只在「該 offset 之前的訊息都成功」後才 mark，
+    例如 5、6、7 中 7 先完成、6 還在處理，commit 點仍停在 6，當機重啟只會重複處理、不會漏掉
+  - rebalance 時 sarama 會取消 session context：停止派發新訊息、等處理中的完成、
+    在 Cleanup 同步 commit，新的 owner 才不會重複處理已完成的訊息
+  - 重試後仍失敗的訊息不 commit，Run 回傳錯誤讓 process 停下，避免略過資料
+  - 同一個 partition 內的訊息可能亂序完成；需要依 key 保持順序時應改成依 key 分派到固定 worker
+*/
+
+// ConsumerGroup 是 sarama.ConsumerGroup 中用到的部分，方便以 FakeBroker 取代
+type ConsumerGroup interface {
+	Consume(ctx context.Context, topics []string, handler sarama.ConsumerGroupHandler) error
+	Close() error
+}
+
+type Handler func(ctx context.Context, msg *sarama.ConsumerMessage) error
+
+type Worker struct {
+	handler Handler
+	workers int
+	retries int
+	backoff time.Duration
+	logger  *log.Logger
+
+	tasks chan task
+	pool  sync.WaitGroup
+
+	mu  sync.Mutex
+	err error // 第一個無法處理的訊息錯誤
+}
+
+type task struct {
+	ctx  context.Context
+	msg  *sarama.ConsumerMessage
+	done func(error)
+}
+
+type Option func(*Worker)
+
+func WithWorkers(n int) Option {
+	return func(w *Worker) { w.workers = n }
+}
+
+// WithRetry 設定失敗時的重試次數與間隔
+func WithRetry(retries int, backoff time.Duration) Option {
+	return func(w *Worker) { w.retries, w.backoff = retries, backoff }
+}
+
+func WithLogger(l *log.Logger) Option {
+	return func(w *Worker) { w.logger = l }
+}
+
+var ErrPermanent = errors.New("demo: message failed after retries")
+
+func NewWorker(h Handler, opts ...Option) *Worker {
+	w := &Worker{handler: h, workers: 4, retries: 3, backoff: 100 * time.Millisecond, logger: log.Default()}
+	for _, opt := range opts {
+		opt(w)
+	}
+	return w
+}
+
+var _ sarama.ConsumerGroupHandler = (*Worker)(nil)
+
+// Setup 在每次分配 partition 後呼叫，啟動這一輪的 worker pool
+func (w *Worker) Setup(sess sarama.ConsumerGroupSession) error {
+	w.logger.Printf("kafka: generation %d assigned %v", sess.GenerationID(), sess.Claims())
+	w.tasks = make(chan task)
+	for i := 0; i < w.workers; i++ {
+		w.pool.Add(1)
+		go func() {
+			defer w.pool.Done()
+			for t := range w.tasks {
+				t.done(w.process(t.ctx, t.msg))
+			}
+		}()
+	}
+	return nil
+}
+
+// Cleanup 在所有 ConsumeClaim 結束後呼叫；此時已沒有處理中的訊息，同步 commit 後交出 partition
+func (w *Worker) Cleanup(sess sarama.ConsumerGroupSession) error {
+	close(w.tasks)
+	w.pool.Wait()
+	sess.Commit()
+	w.logger.Printf("kafka: generation %d released", sess.GenerationID())
+	return nil
+}
+
+func (w *Worker) ConsumeClaim(sess sarama.ConsumerGroupSession, claim sarama.ConsumerGroupClaim) error {
+	var (
+		inflight sync.WaitGroup
+		mu       sync.Mutex
+		tracker  offsetTracker
+		failed   = make(chan error, 1)
+	)
+	done := func(offset int64) func(error) {
+		return func(err error) {
+			defer inflight.Done()
+			if err != nil {
+				select {
+				case failed <- err:
+				default:
+				}
+				return
+			}
+			mu.Lock()
+			next, ok := tracker.complete(offset)
+			mu.Unlock()
+			if ok {
+				sess.MarkOffset(claim.Topic(), claim.Partition(), next, "")
+			}
+		}
+	}
+
+	// 處理中的訊息不使用 session context：rebalance 時讓它們做完再交出 partition
+	ctx := context.WithoutCancel(sess.Context())
+	var err error
+loop:
+	for {
+		select {
+		case msg, ok := <-claim.Messages():
+			if !ok {
+				break loop
+			}
+			mu.Lock()
+			tracker.add(msg.Offset)
+			mu.Unlock()
+			inflight.Add(1)
+			select {
+			case w.tasks <- task{ctx: ctx, msg: msg, done: done(msg.Offset)}:
+			case <-sess.Context().Done():
+				inflight.Done()
+				break loop
+			case err = <-failed:
+				inflight.Done()
+				break loop
+			}
+		case <-sess.Context().Done():
+			break loop
+		case err = <-failed:
+			break loop
+		}
+	}
+	inflight.Wait()
+	if err == nil {
+		select {
+		case err = <-failed:
+		default:
+		}
+	}
+	if err != nil {
+		w.setErr(err)
+		return err
+	}
+	return nil
+}
+
+func (w *Worker) process(ctx context.Context, msg *sarama.ConsumerMessage) error {
+	var err error
+	for attempt := 0; attempt <= w.retries; attempt++ {
+		if attempt > 0 {
+			if err := timex.Sleep(ctx, w.backoff); err != nil {
+				return err
+			}
+		}
+		if err = w.handler(ctx, msg); err == nil {
+			return nil
+		}
+		w.logger.Printf("kafka: %s/%d@%d attempt %d: %v", msg.Topic, msg.Partition, msg.Offset, attempt+1, err)
+	}
+	return fmt.Errorf("%w: %s/%d@%d: %v", ErrPermanent, msg.Topic, msg.Partition, msg.Offset, err)
+}
+
+func (w *Worker) setErr(err error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.err == nil {
+		w.err = err
+	}
+}
+
+// Err 回傳第一個無法處理的訊息錯誤
+func (w *Worker) Err() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.err
+}
+
+// Run 持續呼叫 Consume：每次 rebalance Consume 都會返回，需要重新加入 group。
+// ctx 結束時回傳 nil；有訊息重試後仍失敗時回傳該錯誤
+func Run(ctx context.Context, group ConsumerGroup, topics []string, w *Worker) error {
+	for {
+		if err := group.Consume(ctx, topics, w); err != nil {
+			if errors.Is(err, sarama.ErrClosedConsumerGroup) {
+				return w.Err()
+			}
+			return err
+		}
+		if err := w.Err(); err != nil {
+			return err
+		}
+		if ctx.Err() != nil {
+			return nil
+		}
+	}
+}
+
+// offsetTracker 記錄派發順序，只有最前面連續完成的 offset 才能 commit
+type offsetTracker struct {
+	order []int64
+	done  map[int64]bool
+}
+
+func (t *offsetTracker) add(offset int64) {
+	if t.done == nil {
+		t.done = make(map[int64]bool)
+	}
+	t.order = append(t.order, offset)
+}
+
+// complete 回傳新的 commit 點（下一個要讀的 offset）；沒有前進時 ok 為 false
+func (t *offsetTracker) complete(offset int64) (next int64, ok bool) {
+	t.done[offset] = true
+	for len(t.order) > 0 && t.done[t.order[0]] {
+		next, ok = t.order[0]+1, true
+		delete(t.done, t.order[0])
+		t.order = t.order[1:]
+	}
+	return next, ok
+}
@@ -0,0 +1,86 @@
+package kv
+
+import (
+	"sort"
+	"sync"
+
+	"advanced/snapshot"
+)
+
+// Store 是以 RWMutex 保護的記憶體 key-value store，值為 []byte
+type Store struct {
+	mu   sync.RWMutex
+	data map[string][]byte
+}
+
+func New() *Store {
+	return &Store{data: make(map[string][]byte)}
+}
+
+// Get 回傳值的副本，呼叫端修改不會影響 store 內的資料
+func (s *Store) Get(key string) ([]byte, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	v, ok := s.data[key]
+	if !ok {
+		return nil, false
+	}
+	return append([]byte(nil), v...), true
+}
+
+func (s *Store) Set(key string, value []byte) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.data[key] = append([]byte(nil), value...)
+}
+
+func (s *Store) Delete(key string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.data, key)
+}
+
+func (s *Store) Len() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return len(s.data)
+}
+
+// Keys 回傳排序後的所有 key
+func (s *Store) Keys() []string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	keys := make([]string, 0, len(s.data))
+	for k := range s.data {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Save 把目前內容寫成 snapshot。
+// 只在複製 map 時持有讀鎖，編碼與寫檔不會擋住其他寫入
+func (s *Store) Save(path string) error {
+	s.mu.RLock()
+	data := make(map[string][]byte, len(s.data))
+	for k, v := range s.data {
+		data[k] = v // Set 每次都存新的 slice，共用底層陣列是安全的
+	}
+	s.mu.RUnlock()
+	return snapshot.WriteFile(path, data)
+}
+
+// Load 以 snapshot 的內容取代目前的資料
+func (s *Store) Load(path string) error {
+	var data map[string][]byte
+	if err := snapshot.ReadFile(path, &data); err != nil {
+		return err
+	}
+	if data == nil {
+		data = make(map[string][]byte)
+	}
+	s.mu.Lock()
+	s.data = data
+	s.mu.Unlock()
+	return nil
+}
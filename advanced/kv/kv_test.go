@@ -0,0 +1,78 @@
+package kv
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"advanced/snapshot"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStore(t *testing.T) {
+	s := New()
+	v := []byte("v1")
+	s.Set("a", v)
+	v[0] = 'X' // 呼叫端之後修改不影響 store
+	got, ok := s.Get("a")
+	assert.True(t, ok)
+	assert.Equal(t, []byte("v1"), got)
+
+	s.Set("b", nil)
+	assert.Equal(t, []string{"a", "b"}, s.Keys())
+	s.Delete("a")
+	_, ok = s.Get("a")
+	assert.False(t, ok)
+	assert.Equal(t, 1, s.Len())
+}
+
+func TestSaveLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "kv.snap")
+	s := New()
+	for i := 0; i < 100; i++ {
+		s.Set(fmt.Sprintf("k%03d", i), []byte(fmt.Sprint(i)))
+	}
+	assert.NoError(t, s.Save(path))
+
+	restored := New()
+	restored.Set("stale", []byte("x"))
+	assert.NoError(t, restored.Load(path))
+	assert.Equal(t, s.Keys(), restored.Keys())
+	v, _ := restored.Get("k042")
+	assert.Equal(t, []byte("42"), v)
+
+	// 損壞的 snapshot 不會覆蓋目前資料
+	full, _ := os.ReadFile(path)
+	assert.NoError(t, os.WriteFile(path, full[:len(full)-3], 0o644))
+	assert.ErrorIs(t, restored.Load(path), snapshot.ErrCorrupt)
+	assert.Equal(t, 100, restored.Len())
+}
+
+// 週期性存檔期間持續寫入，停止後 snapshot 包含最後的狀態
+func TestPeriodicSnapshot(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "kv.snap")
+	s := New()
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		snapshot.Run(ctx, 5*time.Millisecond, func() error { return s.Save(path) }, func(err error) { t.Error(err) })
+		close(done)
+	}()
+
+	for i := 0; i < 200; i++ {
+		s.Set(fmt.Sprint(i), []byte("v"))
+		if i%50 == 0 {
+			time.Sleep(10 * time.Millisecond)
+		}
+	}
+	cancel()
+	<-done
+
+	restored := New()
+	assert.NoError(t, restored.Load(path))
+	assert.Equal(t, 200, restored.Len())
+}
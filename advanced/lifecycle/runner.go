@@ -0,0 +1,169 @@
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+/*
+Runner 管理「同一時間只有一個」的背景工作，例如 TUI 中正在執行的範例：
+  - Start 會先取消並等待前一次執行結束，才開始新的一次（re-run）；
+    等待可能要一段時間，UI 可以在另一個 goroutine 呼叫，多個 Start 會依序進行
+  - Cancel 透過 context 取消，工作需自行監聽 ctx.Done()
+  - 每次狀態改變都呼叫 onChange，UI 不需要輪詢
+
+結束狀態的判斷：
+	fn 回傳 nil                   → Succeeded（即使之後才被取消）
+	fn 回傳錯誤且 ctx 已被取消     → Cancelled
+	其他錯誤                      → Failed
+*/
+
+type State int
+
+const (
+	Idle State = iota
+	Running
+	Succeeded
+	Failed
+	Cancelled
+)
+
+func (s State) String() string {
+	switch s {
+	case Idle:
+		return "idle"
+	case Running:
+		return "running"
+	case Succeeded:
+		return "succeeded"
+	case Failed:
+		return "failed"
+	case Cancelled:
+		return "cancelled"
+	}
+	return "unknown"
+}
+
+// Done 回報狀態是否為結束狀態
+func (s State) Done() bool {
+	return s == Succeeded || s == Failed || s == Cancelled
+}
+
+type Status struct {
+	State   State
+	Err     error
+	Run     int // 第幾次執行，從 1 開始
+	Started time.Time
+	Ended   time.Time
+}
+
+// Elapsed 執行中時回傳到目前為止的時間
+func (s Status) Elapsed() time.Duration {
+	switch {
+	case s.Started.IsZero():
+		return 0
+	case s.Ended.IsZero():
+		return time.Since(s.Started)
+	}
+	return s.Ended.Sub(s.Started)
+}
+
+type Runner struct {
+	startMu  sync.Mutex // 讓 Start 依序進行，避免兩次執行重疊
+	mu       sync.Mutex
+	status   Status
+	cancel   context.CancelFunc
+	done     chan struct{}
+	onChange func(Status)
+}
+
+type Option func(*Runner)
+
+// WithOnChange 在狀態改變時呼叫（不持有鎖，可以呼叫 Runner 的方法）
+func WithOnChange(fn func(Status)) Option {
+	return func(r *Runner) { r.onChange = fn }
+}
+
+func NewRunner(opts ...Option) *Runner {
+	r := &Runner{}
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r
+}
+
+// Start 取消並等待目前的執行後，以 ctx 衍生的 context 在新的 goroutine 執行 fn
+func (r *Runner) Start(ctx context.Context, fn func(ctx context.Context) error) {
+	r.startMu.Lock()
+	defer r.startMu.Unlock()
+	r.Cancel()
+	r.Wait()
+
+	ctx, cancel := context.WithCancel(ctx)
+	done := make(chan struct{})
+	r.mu.Lock()
+	r.cancel, r.done = cancel, done
+	r.status = Status{State: Running, Run: r.status.Run + 1, Started: time.Now()}
+	st := r.status
+	r.mu.Unlock()
+	r.notify(st)
+
+	go func() {
+		defer close(done)
+		defer cancel()
+		err := fn(ctx)
+		state := Succeeded
+		switch {
+		case err != nil && ctx.Err() != nil:
+			state = Cancelled
+		case err != nil:
+			state = Failed
+		}
+		r.mu.Lock()
+		r.status.State, r.status.Err, r.status.Ended = state, err, time.Now()
+		st := r.status
+		r.mu.Unlock()
+		r.notify(st)
+	}()
+}
+
+func (r *Runner) notify(st Status) {
+	if r.onChange != nil {
+		r.onChange(st)
+	}
+}
+
+// Cancel 取消目前的執行，回報是否有正在執行的工作
+func (r *Runner) Cancel() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.cancel == nil || r.status.State != Running {
+		return false
+	}
+	r.cancel()
+	return true
+}
+
+// Wait 等待目前的執行結束並回傳最終狀態；沒有執行過時立即回傳
+func (r *Runner) Wait() Status {
+	r.mu.Lock()
+	done := r.done
+	r.mu.Unlock()
+	if done != nil {
+		<-done
+	}
+	return r.Status()
+}
+
+func (r *Runner) Status() Status {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.status
+}
+
+// IsCancelled 判斷 err 是否來自 context 取消
+func IsCancelled(err error) bool {
+	return errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded)
+}
@@ -0,0 +1,125 @@
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRunnerStates(t *testing.T) {
+	var mu sync.Mutex
+	var seen []State
+	r := NewRunner(WithOnChange(func(s Status) {
+		mu.Lock()
+		seen = append(seen, s.State)
+		mu.Unlock()
+	}))
+	assert.Equal(t, Idle, r.Wait().State)
+
+	r.Start(context.Background(), func(context.Context) error { return nil })
+	st := r.Wait()
+	assert.Equal(t, Succeeded, st.State)
+	assert.Equal(t, 1, st.Run)
+	assert.False(t, st.Ended.IsZero())
+
+	boom := errors.New("boom")
+	r.Start(context.Background(), func(context.Context) error { return boom })
+	st = r.Wait()
+	assert.Equal(t, Failed, st.State)
+	assert.ErrorIs(t, st.Err, boom)
+	assert.Equal(t, 2, st.Run)
+
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Equal(t, []State{Running, Succeeded, Running, Failed}, seen)
+}
+
+func TestRunnerCancel(t *testing.T) {
+	r := NewRunner()
+	assert.False(t, r.Cancel())
+
+	started := make(chan struct{})
+	r.Start(context.Background(), func(ctx context.Context) error {
+		close(started)
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	<-started
+	assert.Equal(t, Running, r.Status().State)
+	assert.True(t, r.Cancel())
+
+	st := r.Wait()
+	assert.Equal(t, Cancelled, st.State)
+	assert.True(t, IsCancelled(st.Err))
+	assert.False(t, r.Cancel())
+}
+
+func TestRunnerRestartCancelsPrevious(t *testing.T) {
+	r := NewRunner()
+	firstDone := make(chan error, 1)
+	started := make(chan struct{})
+	r.Start(context.Background(), func(ctx context.Context) error {
+		close(started)
+		<-ctx.Done()
+		firstDone <- ctx.Err()
+		return ctx.Err()
+	})
+	<-started
+
+	// 第二次 Start 要等第一次真的結束才開始
+	r.Start(context.Background(), func(context.Context) error {
+		assert.Len(t, firstDone, 1)
+		return nil
+	})
+	st := r.Wait()
+	assert.Equal(t, Succeeded, st.State)
+	assert.Equal(t, 2, st.Run)
+	assert.ErrorIs(t, <-firstDone, context.Canceled)
+}
+
+func TestRunnerParentCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	r := NewRunner()
+	r.Start(ctx, func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	cancel()
+	assert.Equal(t, Cancelled, r.Wait().State)
+}
+
+func TestRunnerConcurrentStartDoesNotOverlap(t *testing.T) {
+	r := NewRunner()
+	var mu sync.Mutex
+	active, maxActive := 0, 0
+	fn := func(ctx context.Context) error {
+		mu.Lock()
+		active++
+		if active > maxActive {
+			maxActive = active
+		}
+		mu.Unlock()
+		<-ctx.Done()
+		mu.Lock()
+		active--
+		mu.Unlock()
+		return ctx.Err()
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			r.Start(context.Background(), fn)
+		}()
+	}
+	wg.Wait()
+	r.Cancel()
+	st := r.Wait()
+	assert.Equal(t, 8, st.Run)
+	assert.Equal(t, 1, maxActive)
+}
@@ -0,0 +1,183 @@
+package async
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+)
+
+/*
+非同步的 slog.Handler：Handle 只把 record 放進有容量的 channel，由背景 goroutine 交給真正的 handler 寫出，
+呼叫端不必等 I/O。channel 滿了（寫出跟不上）時依 Policy 處理：
+
+  - Block：等到有空位，不遺失 log，但呼叫端會被拖慢
+  - Drop ：直接丟掉並計數，呼叫端永遠不會被 log 卡住，可用 Dropped 查看丟了多少
+
+程式結束前要呼叫 Close，把 channel 中剩下的 record 寫完，否則最後幾筆 log 會遺失。
+
+	h := async.New(slog.NewJSONHandler(os.Stderr, nil), async.WithBuffer(4096), async.WithPolicy(async.Drop))
+	defer h.Close(context.Background())
+	logger := slog.New(h)
+*/
+
+var ErrClosed = errors.New("async: handler closed")
+
+type Policy int
+
+const (
+	Block Policy = iota
+	Drop
+)
+
+type entry struct {
+	h     slog.Handler // WithAttrs / WithGroup 產生的 handler 共用同一個 queue，所以每筆要記住用哪個 handler
+	r     slog.Record
+	flush chan struct{} // 不為 nil 時是 Flush 的標記
+}
+
+// core 是同一個 New 產生的所有 Handler 共用的狀態
+type core struct {
+	policy  Policy
+	queue   chan entry
+	mu      sync.RWMutex // Handle 持有讀鎖、Close 持有寫鎖，避免送進已關閉的 channel
+	closed  bool
+	done    chan struct{}
+	dropped atomic.Int64
+	errs    atomic.Int64
+	onError func(error)
+}
+
+type Handler struct {
+	inner slog.Handler
+	c     *core
+}
+
+type Option func(*core)
+
+// WithBuffer 設定 channel 容量，預設 1024
+func WithBuffer(n int) Option {
+	return func(c *core) { c.queue = make(chan entry, n) }
+}
+
+func WithPolicy(p Policy) Option {
+	return func(c *core) { c.policy = p }
+}
+
+// WithErrorHandler 設定 inner handler 寫出失敗時的處理方式，預設只計數
+func WithErrorHandler(f func(error)) Option {
+	return func(c *core) { c.onError = f }
+}
+
+func New(inner slog.Handler, opts ...Option) *Handler {
+	c := &core{queue: make(chan entry, 1024), done: make(chan struct{})}
+	for _, o := range opts {
+		o(c)
+	}
+	go c.run()
+	return &Handler{inner: inner, c: c}
+}
+
+func (c *core) run() {
+	defer close(c.done)
+	for e := range c.queue {
+		if e.flush != nil {
+			close(e.flush)
+			continue
+		}
+		// 背景寫出不屬於任何 request，用 Background 避免呼叫端的 ctx 已經取消
+		if err := e.h.Handle(context.Background(), e.r); err != nil {
+			c.errs.Add(1)
+			if c.onError != nil {
+				c.onError(err)
+			}
+		}
+	}
+}
+
+func (h *Handler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.inner.Enabled(ctx, level)
+}
+
+func (h *Handler) Handle(ctx context.Context, r slog.Record) error {
+	c := h.c
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if c.closed {
+		return ErrClosed
+	}
+	// Record 的 attr 可能與呼叫端共用底層陣列，交給其他 goroutine 前要 Clone
+	e := entry{h: h.inner, r: r.Clone()}
+	if c.policy == Drop {
+		select {
+		case c.queue <- e:
+		default:
+			c.dropped.Add(1)
+		}
+		return nil
+	}
+	select {
+	case c.queue <- e:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (h *Handler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &Handler{inner: h.inner.WithAttrs(attrs), c: h.c}
+}
+
+func (h *Handler) WithGroup(name string) slog.Handler {
+	return &Handler{inner: h.inner.WithGroup(name), c: h.c}
+}
+
+// Flush 等到呼叫前已經放進 queue 的 record 都寫出；Drop 模式下 Flush 本身也會等空位，不會被丟掉
+func (h *Handler) Flush(ctx context.Context) error {
+	c := h.c
+	c.mu.RLock()
+	if c.closed {
+		c.mu.RUnlock()
+		return ErrClosed
+	}
+	mark := make(chan struct{})
+	select {
+	case c.queue <- entry{flush: mark}:
+	case <-ctx.Done():
+		c.mu.RUnlock()
+		return ctx.Err()
+	}
+	c.mu.RUnlock()
+	select {
+	case <-mark:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Close 不再接受新的 record，等 queue 中剩下的寫完；ctx 到期時不再等待，但背景 goroutine 仍會把剩下的寫完
+func (h *Handler) Close(ctx context.Context) error {
+	c := h.c
+	c.mu.Lock()
+	if !c.closed {
+		c.closed = true
+		close(c.queue)
+	}
+	c.mu.Unlock()
+	select {
+	case <-c.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Dropped 回傳 Drop 模式下因 queue 已滿而丟掉的筆數
+func (h *Handler) Dropped() int64 { return h.c.dropped.Load() }
+
+// Errors 回傳 inner handler 寫出失敗的次數
+func (h *Handler) Errors() int64 { return h.c.errs.Load() }
+
+var _ slog.Handler = (*Handler)(nil)
@@ -0,0 +1,196 @@
+package async
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"log/slog"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// gated 在 gate 打開前卡住每一筆 Handle，用來模擬很慢的輸出
+type gated struct {
+	slog.Handler
+	started chan struct{}
+	gate    chan struct{}
+	once    sync.Once
+}
+
+func newGated(inner slog.Handler) *gated {
+	return &gated{Handler: inner, started: make(chan struct{}), gate: make(chan struct{})}
+}
+
+func (g *gated) Handle(ctx context.Context, r slog.Record) error {
+	g.once.Do(func() { close(g.started) })
+	<-g.gate
+	return g.Handler.Handle(ctx, r)
+}
+
+func textHandler(buf io.Writer) slog.Handler {
+	return slog.NewTextHandler(buf, &slog.HandlerOptions{
+		ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+			if a.Key == slog.TimeKey && len(groups) == 0 {
+				return slog.Attr{}
+			}
+			return a
+		},
+	})
+}
+
+func TestOrderAttrsAndClose(t *testing.T) {
+	var buf bytes.Buffer
+	h := New(textHandler(&buf), WithBuffer(4))
+	logger := slog.New(h).With("svc", "api").WithGroup("req")
+	for i := 0; i < 20; i++ {
+		logger.Info("hit", "n", i)
+	}
+	require.NoError(t, h.Close(context.Background()))
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	require.Len(t, lines, 20)
+	assert.Equal(t, "level=INFO msg=hit svc=api req.n=0", lines[0])
+	assert.Equal(t, "level=INFO msg=hit svc=api req.n=19", lines[19])
+
+	assert.ErrorIs(t, h.Handle(context.Background(), slog.Record{}), ErrClosed)
+	assert.ErrorIs(t, h.Flush(context.Background()), ErrClosed)
+}
+
+func TestDropPolicy(t *testing.T) {
+	var buf bytes.Buffer
+	g := newGated(textHandler(&buf))
+	h := New(g, WithBuffer(2), WithPolicy(Drop))
+	logger := slog.New(h)
+
+	logger.Info("first")
+	<-g.started // 第一筆已被背景 goroutine 取走，卡在 gate
+	for i := 0; i < 9; i++ {
+		logger.Info("more") // 2 筆進 queue，其餘丟掉，而且不會卡住
+	}
+	assert.EqualValues(t, 7, h.Dropped())
+
+	close(g.gate)
+	require.NoError(t, h.Close(context.Background()))
+	assert.Equal(t, 3, strings.Count(buf.String(), "\n"))
+}
+
+func TestBlockPolicyRespectsContext(t *testing.T) {
+	g := newGated(slog.NewTextHandler(&bytes.Buffer{}, nil))
+	h := New(g, WithBuffer(1))
+	rec := slog.NewRecord(time.Now(), slog.LevelInfo, "x", 0)
+
+	require.NoError(t, h.Handle(context.Background(), rec))
+	<-g.started
+	require.NoError(t, h.Handle(context.Background(), rec)) // 放進 queue
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, h.Handle(ctx, rec), context.DeadlineExceeded)
+
+	close(g.gate)
+	require.NoError(t, h.Close(context.Background()))
+	assert.Zero(t, h.Dropped())
+}
+
+func TestFlush(t *testing.T) {
+	var mu sync.Mutex
+	var buf bytes.Buffer
+	h := New(textHandler(&lockedBuffer{mu: &mu, b: &buf}))
+	defer h.Close(context.Background())
+
+	slog.New(h).Info("before flush")
+	require.NoError(t, h.Flush(context.Background()))
+	mu.Lock()
+	assert.Contains(t, buf.String(), "before flush")
+	mu.Unlock()
+}
+
+func TestCloseTimeout(t *testing.T) {
+	g := newGated(slog.NewTextHandler(&bytes.Buffer{}, nil))
+	h := New(g)
+	slog.New(h).Info("stuck")
+	<-g.started
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, h.Close(ctx), context.DeadlineExceeded)
+	close(g.gate)
+	require.NoError(t, h.Close(context.Background()))
+}
+
+type lockedBuffer struct {
+	mu *sync.Mutex
+	b  *bytes.Buffer
+}
+
+func (l *lockedBuffer) Write(p []byte) (int, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.b.Write(p)
+}
+
+// slowWriter 模擬每次寫入需要一點時間的輸出（檔案、網路）
+type slowWriter struct{}
+
+func (slowWriter) Write(p []byte) (int, error) {
+	deadline := time.Now().Add(2 * time.Microsecond)
+	for time.Now().Before(deadline) {
+	}
+	return len(p), nil
+}
+
+func BenchmarkLogging(b *testing.B) {
+	newInner := func() slog.Handler { return slog.NewTextHandler(slowWriter{}, nil) }
+	cases := []struct {
+		name string
+		h    func() (slog.Handler, func())
+	}{
+		{"sync", func() (slog.Handler, func()) { return newInner(), func() {} }},
+		{"async-block", func() (slog.Handler, func()) {
+			h := New(newInner(), WithBuffer(4096))
+			return h, func() { h.Close(context.Background()) }
+		}},
+		{"async-drop", func() (slog.Handler, func()) {
+			h := New(newInner(), WithBuffer(4096), WithPolicy(Drop))
+			return h, func() { h.Close(context.Background()) }
+		}},
+	}
+	for _, c := range cases {
+		b.Run(c.name, func(b *testing.B) {
+			h, done := c.h()
+			logger := slog.New(h)
+			b.RunParallel(func(pb *testing.PB) {
+				for pb.Next() {
+					logger.Info("request", "path", "/api/users", "status", 200)
+				}
+			})
+			b.StopTimer()
+			done()
+			if ah, ok := h.(*Handler); ok && ah.c.policy == Drop {
+				b.ReportMetric(float64(ah.Dropped())/float64(b.N), "dropped/op")
+			}
+		})
+	}
+}
+
+/*
+go test -run xxx -bench . -cpu 1,4 ./logx/async
+（每次寫入約 2µs 的 slowWriter，單核心機器）
+
+BenchmarkLogging/sync           	  415336	      2928 ns/op
+BenchmarkLogging/sync-4         	  357087	      3554 ns/op
+BenchmarkLogging/async-block    	  453295	      3638 ns/op
+BenchmarkLogging/async-block-4  	  410023	      3618 ns/op
+BenchmarkLogging/async-drop     	 2250289	       631.5 ns/op	         0.9194 dropped/op
+BenchmarkLogging/async-drop-4   	 1886766	       548.9 ns/op	         0.9595 dropped/op
+
+  - 持續寫得比輸出快時 buffer 很快就滿了，Block 模式最後仍被輸出速度限制，還多了 channel 的成本；
+    非同步只能吸收「突發」的 log，不能提高長期的輸出速度
+  - Drop 模式呼叫端幾乎不受影響，代價是這個壓力下九成以上的 log 被丟掉，所以 Dropped 一定要監控
+  - sync-4 比 sync 慢：多個 goroutine 搶 TextHandler 內部的鎖
+*/
@@ -0,0 +1,154 @@
+package lru
+
+import (
+	"container/list"
+	"sync"
+
+	"advanced/snapshot"
+)
+
+/*
+LRU (Least Recently Used) cache：
+  - map 負責 O(1) 查詢
+  - container/list 維持使用順序，最近使用的放在最前面，滿了就淘汰最後面的
+*/
+
+type entry[K comparable, V any] struct {
+	key   K
+	value V
+}
+
+type Cache[K comparable, V any] struct {
+	mu       sync.Mutex
+	capacity int
+	ll       *list.List
+	items    map[K]*list.Element
+	onEvict  func(K, V)
+}
+
+type Option[K comparable, V any] func(*Cache[K, V])
+
+// WithOnEvict 在項目因容量不足被淘汰時呼叫（在持有鎖的情況下執行，不可再呼叫 Cache 的方法）
+func WithOnEvict[K comparable, V any](fn func(K, V)) Option[K, V] {
+	return func(c *Cache[K, V]) { c.onEvict = fn }
+}
+
+func New[K comparable, V any](capacity int, opts ...Option[K, V]) *Cache[K, V] {
+	if capacity <= 0 {
+		panic("lru: capacity must be positive")
+	}
+	c := &Cache[K, V]{capacity: capacity, ll: list.New(), items: make(map[K]*list.Element)}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+func (c *Cache[K, V]) Get(key K) (V, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if e, ok := c.items[key]; ok {
+		c.ll.MoveToFront(e)
+		return e.Value.(*entry[K, V]).value, true
+	}
+	var zero V
+	return zero, false
+}
+
+// Peek 查詢但不更新使用順序
+func (c *Cache[K, V]) Peek(key K) (V, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if e, ok := c.items[key]; ok {
+		return e.Value.(*entry[K, V]).value, true
+	}
+	var zero V
+	return zero, false
+}
+
+func (c *Cache[K, V]) Set(key K, value V) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.set(key, value)
+}
+
+func (c *Cache[K, V]) set(key K, value V) {
+	if e, ok := c.items[key]; ok {
+		e.Value.(*entry[K, V]).value = value
+		c.ll.MoveToFront(e)
+		return
+	}
+	c.items[key] = c.ll.PushFront(&entry[K, V]{key, value})
+	if c.ll.Len() > c.capacity {
+		oldest := c.ll.Back()
+		c.ll.Remove(oldest)
+		en := oldest.Value.(*entry[K, V])
+		delete(c.items, en.key)
+		if c.onEvict != nil {
+			c.onEvict(en.key, en.value)
+		}
+	}
+}
+
+func (c *Cache[K, V]) Delete(key K) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if e, ok := c.items[key]; ok {
+		c.ll.Remove(e)
+		delete(c.items, key)
+	}
+}
+
+func (c *Cache[K, V]) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.ll.Len()
+}
+
+// Keys 依使用順序回傳，最近使用的在前面
+func (c *Cache[K, V]) Keys() []K {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	keys := make([]K, 0, c.ll.Len())
+	for e := c.ll.Front(); e != nil; e = e.Next() {
+		keys = append(keys, e.Value.(*entry[K, V]).key)
+	}
+	return keys
+}
+
+// Item 是 snapshot 中的一筆資料，欄位需 exported 才能被 gob 編碼
+type Item[K comparable, V any] struct {
+	Key   K
+	Value V
+}
+
+// Save 依「最舊到最新」的順序寫入 snapshot，Load 時依序 Set 即可還原使用順序
+func (c *Cache[K, V]) Save(path string) error {
+	c.mu.Lock()
+	items := make([]Item[K, V], 0, c.ll.Len())
+	for e := c.ll.Back(); e != nil; e = e.Prev() {
+		en := e.Value.(*entry[K, V])
+		items = append(items, Item[K, V]{en.key, en.value})
+	}
+	c.mu.Unlock()
+	return snapshot.WriteFile(path, items)
+}
+
+// Load 以 snapshot 取代目前內容；snapshot 比容量大時只保留最新的部分
+func (c *Cache[K, V]) Load(path string) error {
+	var items []Item[K, V]
+	if err := snapshot.ReadFile(path, &items); err != nil {
+		return err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.ll.Init()
+	c.items = make(map[K]*list.Element, len(items))
+	if len(items) > c.capacity {
+		items = items[len(items)-c.capacity:]
+	}
+	for _, it := range items {
+		c.set(it.Key, it.Value)
+	}
+	return nil
+}
@@ -0,0 +1,77 @@
+package lru
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"advanced/snapshot"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEviction(t *testing.T) {
+	var evicted []string
+	c := New[string, int](2, WithOnEvict(func(k string, v int) { evicted = append(evicted, k) }))
+	c.Set("a", 1)
+	c.Set("b", 2)
+	c.Get("a") // a 變成最近使用
+	c.Set("c", 3)
+
+	assert.Equal(t, []string{"b"}, evicted)
+	assert.Equal(t, []string{"c", "a"}, c.Keys())
+
+	// Peek 不影響順序
+	v, ok := c.Peek("a")
+	assert.True(t, ok)
+	assert.Equal(t, 1, v)
+	c.Set("d", 4)
+	assert.Equal(t, []string{"b", "a"}, evicted)
+
+	c.Set("c", 30)
+	v, _ = c.Get("c")
+	assert.Equal(t, 30, v)
+	c.Delete("c")
+	assert.Equal(t, 1, c.Len())
+	assert.Panics(t, func() { New[string, int](0) })
+}
+
+type page struct {
+	Title string
+	Body  []byte
+}
+
+func TestSaveLoadKeepsRecency(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "lru.snap")
+	c := New[string, page](3)
+	c.Set("a", page{Title: "A"})
+	c.Set("b", page{Title: "B"})
+	c.Set("c", page{Title: "C", Body: []byte("body")})
+	c.Get("a")
+	assert.NoError(t, c.Save(path))
+
+	restored := New[string, page](3)
+	assert.NoError(t, restored.Load(path))
+	assert.Equal(t, []string{"a", "c", "b"}, restored.Keys())
+	p, _ := restored.Get("c")
+	assert.Equal(t, page{Title: "C", Body: []byte("body")}, p)
+
+	// 還原到較小的 cache 只保留最近使用的
+	small := New[string, page](2)
+	assert.NoError(t, small.Load(path))
+	assert.Equal(t, []string{"a", "c"}, small.Keys())
+}
+
+func TestLoadTornSnapshot(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "lru.snap")
+	c := New[int, string](10)
+	c.Set(1, "one")
+	assert.NoError(t, c.Save(path))
+
+	full, _ := os.ReadFile(path)
+	assert.NoError(t, os.WriteFile(path, full[:len(full)/2], 0o644))
+	restored := New[int, string](10)
+	restored.Set(2, "two")
+	assert.ErrorIs(t, restored.Load(path), snapshot.ErrCorrupt)
+	assert.Equal(t, []int{2}, restored.Keys())
+}
@@ -0,0 +1,115 @@
+package counter
+
+import (
+	"math/rand"
+	"runtime"
+	"sync/atomic"
+)
+
+/*
+三種並行安全的計數器：
+
+  - Atomic ：一個 atomic.Int64。簡單、讀取便宜，但所有 CPU 都在改同一條 cache line，
+             核心越多，cache line 在核心之間來回搬移（cache line bouncing）的成本越高
+  - Sharded：每個 P 大約一個 shard，各自 atomic 累加，Load 時加總。寫入幾乎不互相干擾，代價是 Load 要掃過全部 shard
+  - Approx ：OSTEP 的 approximate（sloppy）counter。shard 累積到 threshold 才併入全域值，
+             Load 只讀全域值，非常便宜但最多少算 shards × threshold；Sum 才是精確值
+
+Go 沒有公開「目前在哪個 P」的 API（sync.Pool 是用 runtime 內部的 procPin），
+這裡用 math/rand 的全域函式隨機選 shard（Go 1.20 起它不需要鎖），效果接近 per-P。
+每個 shard 補滿 64 bytes，避免兩個 shard 落在同一條 cache line 上（false sharing）。
+*/
+
+type Counter interface {
+	Add(n int64)
+	Load() int64
+}
+
+type Atomic struct{ v atomic.Int64 }
+
+func (c *Atomic) Add(n int64)  { c.v.Add(n) }
+func (c *Atomic) Load() int64  { return c.v.Load() }
+func (c *Atomic) Reset() int64 { return c.v.Swap(0) }
+
+const cacheLine = 64
+
+type shard struct {
+	v atomic.Int64
+	_ [cacheLine - 8]byte
+}
+
+// shardCount 回傳不小於 GOMAXPROCS 的 2 的次方，方便用 mask 取代除法
+func shardCount() int {
+	n := 1
+	for n < runtime.GOMAXPROCS(0) {
+		n <<= 1
+	}
+	return n
+}
+
+type Sharded struct {
+	shards []shard
+	mask   uint32
+}
+
+func NewSharded() *Sharded {
+	n := shardCount()
+	return &Sharded{shards: make([]shard, n), mask: uint32(n - 1)}
+}
+
+func (c *Sharded) Add(n int64) {
+	c.shards[rand.Uint32()&c.mask].v.Add(n)
+}
+
+// Load 加總所有 shard；與 Add 並行時結果是某個中間值，但不會少算已經完成的 Add
+func (c *Sharded) Load() int64 {
+	var sum int64
+	for i := range c.shards {
+		sum += c.shards[i].v.Load()
+	}
+	return sum
+}
+
+type Approx struct {
+	global    atomic.Int64
+	_         [cacheLine - 8]byte
+	local     []shard
+	mask      uint32
+	threshold int64
+}
+
+// NewApprox 建立 approximate counter，每個 shard 累積到 threshold 才併入全域值
+func NewApprox(threshold int64) *Approx {
+	n := shardCount()
+	return &Approx{local: make([]shard, n), mask: uint32(n - 1), threshold: threshold}
+}
+
+func (c *Approx) Add(n int64) {
+	s := &c.local[rand.Uint32()&c.mask]
+	v := s.v.Add(n)
+	if v >= c.threshold || v <= -c.threshold {
+		// 用 Swap 取走目前累積的量，避免兩個 goroutine 重複併入
+		c.global.Add(s.v.Swap(0))
+	}
+}
+
+// Load 只讀全域值，比實際值少最多 shards × threshold
+func (c *Approx) Load() int64 { return c.global.Load() }
+
+// Sum 加上各 shard 尚未併入的量，得到精確值
+func (c *Approx) Sum() int64 {
+	sum := c.global.Load()
+	for i := range c.local {
+		sum += c.local[i].v.Load()
+	}
+	return sum
+}
+
+// MaxError 回傳 Load 與實際值之間的最大誤差
+func (c *Approx) MaxError() int64 { return int64(len(c.local)) * c.threshold }
+
+var (
+	_ Counter = (*Atomic)(nil)
+	_ Counter = (*Sharded)(nil)
+	_ Counter = (*Approx)(nil)
+)
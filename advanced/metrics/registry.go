@@ -0,0 +1,112 @@
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+/*
+最小的 metrics registry：以名稱取得（沒有就建立）metric，更新只用 atomic，不需要鎖；
+只有第一次建立 metric 時會用到 registry 的鎖。
+
+  - Counter：只會增加的次數
+  - Gauge：目前的值，可增可減
+  - Summary：觀察值的次數與總和，用來算平均（延遲、queue 長度）
+*/
+
+type Counter struct{ v atomic.Int64 }
+
+func (c *Counter) Add(n int64)  { c.v.Add(n) }
+func (c *Counter) Value() int64 { return c.v.Load() }
+
+type Gauge struct{ v atomic.Int64 }
+
+func (g *Gauge) Set(n int64)  { g.v.Store(n) }
+func (g *Gauge) Add(n int64)  { g.v.Add(n) }
+func (g *Gauge) Value() int64 { return g.v.Load() }
+
+type Summary struct {
+	count atomic.Int64
+	sum   atomic.Int64
+}
+
+func (s *Summary) Observe(v int64) {
+	s.count.Add(1)
+	s.sum.Add(v)
+}
+
+// ObserveDuration 以 nanosecond 記錄
+func (s *Summary) ObserveDuration(d time.Duration) { s.Observe(int64(d)) }
+
+func (s *Summary) Count() int64 { return s.count.Load() }
+func (s *Summary) Sum() int64   { return s.sum.Load() }
+
+func (s *Summary) Mean() float64 {
+	// 兩個值分開讀，並行更新時可能差一筆，作為統計值可以接受
+	n := s.count.Load()
+	if n == 0 {
+		return 0
+	}
+	return float64(s.sum.Load()) / float64(n)
+}
+
+type Registry struct {
+	mu        sync.Mutex
+	counters  map[string]*Counter
+	gauges    map[string]*Gauge
+	summaries map[string]*Summary
+}
+
+func NewRegistry() *Registry {
+	return &Registry{
+		counters:  make(map[string]*Counter),
+		gauges:    make(map[string]*Gauge),
+		summaries: make(map[string]*Summary),
+	}
+}
+
+// Default 給不需要隔離的程式使用
+var Default = NewRegistry()
+
+func getOrCreate[T any](mu *sync.Mutex, m map[string]*T, name string) *T {
+	mu.Lock()
+	defer mu.Unlock()
+	v, ok := m[name]
+	if !ok {
+		v = new(T)
+		m[name] = v
+	}
+	return v
+}
+
+func (r *Registry) Counter(name string) *Counter { return getOrCreate(&r.mu, r.counters, name) }
+func (r *Registry) Gauge(name string) *Gauge     { return getOrCreate(&r.mu, r.gauges, name) }
+func (r *Registry) Summary(name string) *Summary { return getOrCreate(&r.mu, r.summaries, name) }
+
+// WriteText 依名稱排序輸出所有 metric，Summary 輸出 _count 與 _sum 兩行
+func (r *Registry) WriteText(w io.Writer) error {
+	r.mu.Lock()
+	lines := make([]string, 0, len(r.counters)+len(r.gauges)+2*len(r.summaries))
+	for name, c := range r.counters {
+		lines = append(lines, fmt.Sprintf("%s %d", name, c.Value()))
+	}
+	for name, g := range r.gauges {
+		lines = append(lines, fmt.Sprintf("%s %d", name, g.Value()))
+	}
+	for name, s := range r.summaries {
+		lines = append(lines, fmt.Sprintf("%s_count %d", name, s.Count()), fmt.Sprintf("%s_sum %d", name, s.Sum()))
+	}
+	r.mu.Unlock()
+
+	sort.Strings(lines)
+	for _, l := range lines {
+		if _, err := fmt.Fprintln(w, l); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,39 @@
+package metrics
+
+import (
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegistry(t *testing.T) {
+	r := NewRegistry()
+	assert.Same(t, r.Counter("req"), r.Counter("req"))
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				r.Counter("req").Add(1)
+				r.Summary("latency").ObserveDuration(time.Millisecond)
+			}
+		}()
+	}
+	wg.Wait()
+	r.Gauge("queue").Set(3)
+	r.Gauge("queue").Add(-1)
+
+	assert.EqualValues(t, 1000, r.Counter("req").Value())
+	assert.EqualValues(t, 2, r.Gauge("queue").Value())
+	assert.Equal(t, float64(time.Millisecond), r.Summary("latency").Mean())
+
+	var b strings.Builder
+	require.NoError(t, r.WriteText(&b))
+	assert.Equal(t, "latency_count 1000\nlatency_sum 1000000000\nqueue 2\nreq 1000\n", b.String())
+}
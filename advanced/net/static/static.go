@@ -0,0 +1,285 @@
+package static
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"html/template"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/url"
+	"path"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+/*
+從 fs.FS 提供靜態檔案（embed.FS 或 os.DirFS 都可以）：
+
+	mux.Handle("/static/", http.StripPrefix("/static", static.New(assets.Static(fsys),
+		static.WithListing(true))))
+
+和 http.FileServer 比起來多做了幾件事：
+
+  - Range：交給 http.ServeContent，支援單一與多段 Range、If-Range，超出範圍回 416；
+    檔案沒有實作 io.Seeker 時先讀進記憶體
+  - 快取：ETag 為內容的 SHA-256（embed.FS 的 ModTime 是零值，不能只靠 Last-Modified），
+    依 name + 大小 + 修改時間快取，檔案沒變就不必重算；If-None-Match 命中回 304
+  - Cache-Control 由 CachePolicy 決定，預設：檔名帶 hash（app.3f9a1c2b.js）的永久快取、
+    html 每次都要驗證、其他快取一小時
+  - 路徑：拒絕含 ".."、反斜線、NUL 的路徑與 . 開頭的隱藏檔（.env、.git），
+    以 fs.ValidPath 再檢查一次；不合法一律回 404，不透露檔案是否存在
+  - 目錄：有 index.html 就提供它，否則依 WithListing 決定列出內容或 404
+
+注意：os.DirFS 會跟隨 symlink，根目錄內指向外面的 symlink 仍然讀得到，部署時不要放不信任的 symlink。
+*/
+
+// CachePolicy 依檔名回傳 Cache-Control，空字串表示不設定
+type CachePolicy func(name string) string
+
+var fingerprint = regexp.MustCompile(`[.-][0-9a-f]{8,}\.[A-Za-z0-9]+$`)
+
+// DefaultCachePolicy 檔名帶 hash 的永久快取、html 必須重新驗證、其他快取一小時
+func DefaultCachePolicy(name string) string {
+	switch {
+	case fingerprint.MatchString(name):
+		return "public, max-age=31536000, immutable"
+	case strings.HasSuffix(name, ".html"):
+		return "no-cache"
+	}
+	return "public, max-age=3600"
+}
+
+// NoCache 每次都要向 server 驗證（仍然可以拿到 304）
+func NoCache(string) string { return "no-cache" }
+
+type Server struct {
+	fsys    fs.FS
+	listing bool
+	policy  CachePolicy
+	index   string
+
+	mu    sync.Mutex
+	etags map[string]etagEntry
+}
+
+type etagEntry struct {
+	size    int64
+	modTime time.Time
+	etag    string
+}
+
+type Option func(*Server)
+
+// WithListing 沒有 index 的目錄是否列出內容，預設關閉
+func WithListing(on bool) Option {
+	return func(s *Server) { s.listing = on }
+}
+
+// WithCachePolicy 設定 Cache-Control 的規則，預設 DefaultCachePolicy
+func WithCachePolicy(p CachePolicy) Option {
+	return func(s *Server) { s.policy = p }
+}
+
+// WithIndex 設定目錄的預設檔案，預設 index.html
+func WithIndex(name string) Option {
+	return func(s *Server) { s.index = name }
+}
+
+func New(fsys fs.FS, opts ...Option) *Server {
+	s := &Server{fsys: fsys, policy: DefaultCachePolicy, index: "index.html", etags: make(map[string]etagEntry)}
+	for _, o := range opts {
+		o(s)
+	}
+	return s
+}
+
+var errInvalidPath = errors.New("static: invalid path")
+
+// clean 把 URL 路徑轉成 fs.FS 的名稱（根目錄為 "."）
+func clean(urlPath string) (string, error) {
+	if strings.ContainsAny(urlPath, "\\\x00") {
+		return "", errInvalidPath
+	}
+	for _, seg := range strings.Split(urlPath, "/") {
+		if seg == ".." || (strings.HasPrefix(seg, ".") && seg != ".") {
+			return "", errInvalidPath
+		}
+	}
+	name := strings.TrimPrefix(path.Clean("/"+urlPath), "/")
+	if name == "" {
+		name = "."
+	}
+	if !fs.ValidPath(name) {
+		return "", errInvalidPath
+	}
+	return name, nil
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+	name, err := clean(r.URL.Path)
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+	f, err := s.fsys.Open(name)
+	if err != nil {
+		s.openError(w, r, err)
+		return
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	if info.IsDir() {
+		// 與 http.FileServer 相同：目錄一定以 / 結尾，否則相對路徑的連結會錯
+		if r.URL.Path != "" && !strings.HasSuffix(r.URL.Path, "/") {
+			redirect(w, r, path.Base(r.URL.Path)+"/")
+			return
+		}
+		index := path.Join(name, s.index)
+		if fi, err := fs.Stat(s.fsys, index); err == nil && !fi.IsDir() {
+			f.Close()
+			if f, err = s.fsys.Open(index); err != nil {
+				s.openError(w, r, err)
+				return
+			}
+			defer f.Close()
+			name, info = index, fi
+		} else if s.listing {
+			s.list(w, r, name)
+			return
+		} else {
+			http.NotFound(w, r)
+			return
+		}
+	}
+	s.serveFile(w, r, name, f, info)
+}
+
+func (s *Server) openError(w http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case errors.Is(err, fs.ErrNotExist), errors.Is(err, fs.ErrInvalid):
+		http.NotFound(w, r)
+	case errors.Is(err, fs.ErrPermission):
+		http.Error(w, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+	default:
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+	}
+}
+
+func (s *Server) serveFile(w http.ResponseWriter, r *http.Request, name string, f fs.File, info fs.FileInfo) {
+	content, ok := f.(io.ReadSeeker)
+	if !ok {
+		b, err := io.ReadAll(f)
+		if err != nil {
+			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+			return
+		}
+		content = bytes.NewReader(b)
+	}
+	etag, err := s.etag(name, info, content)
+	if err != nil {
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+	h := w.Header()
+	h.Set("ETag", etag)
+	if cc := s.policy(name); cc != "" {
+		h.Set("Cache-Control", cc)
+	}
+	h.Set("X-Content-Type-Options", "nosniff")
+	// ServeContent 處理 Range、If-Range、If-None-Match、If-Modified-Since 與 Content-Type
+	http.ServeContent(w, r, info.Name(), info.ModTime(), content)
+}
+
+// etag 回傳快取的 ETag，檔案大小或修改時間變了才重新計算；計算完把 content 移回開頭
+func (s *Server) etag(name string, info fs.FileInfo, content io.ReadSeeker) (string, error) {
+	s.mu.Lock()
+	e, ok := s.etags[name]
+	s.mu.Unlock()
+	if ok && e.size == info.Size() && e.modTime.Equal(info.ModTime()) {
+		return e.etag, nil
+	}
+	h := sha256.New()
+	if _, err := io.Copy(h, content); err != nil {
+		return "", err
+	}
+	if _, err := content.Seek(0, io.SeekStart); err != nil {
+		return "", err
+	}
+	etag := `"` + hex.EncodeToString(h.Sum(nil)[:16]) + `"`
+	s.mu.Lock()
+	s.etags[name] = etagEntry{size: info.Size(), modTime: info.ModTime(), etag: etag}
+	s.mu.Unlock()
+	return etag, nil
+}
+
+var listTmpl = template.Must(template.New("list").Parse(`<!doctype html>
+<meta charset="utf-8">
+<title>{{.Path}}</title>
+<h1>{{.Path}}</h1>
+<ul>
+{{- if ne .Path "/"}}
+<li><a href="../">../</a></li>
+{{- end}}
+{{- range .Entries}}
+<li><a href="{{.Href}}">{{.Name}}</a></li>
+{{- end}}
+</ul>
+`))
+
+type listEntry struct {
+	Name string
+	Href string
+}
+
+func (s *Server) list(w http.ResponseWriter, r *http.Request, dir string) {
+	entries, err := fs.ReadDir(s.fsys, dir)
+	if err != nil {
+		s.openError(w, r, err)
+		return
+	}
+	data := struct {
+		Path    string
+		Entries []listEntry
+	}{Path: r.URL.Path}
+	for _, e := range entries {
+		if strings.HasPrefix(e.Name(), ".") {
+			continue // 隱藏檔不列出，反正也拿不到
+		}
+		name := e.Name()
+		if e.IsDir() {
+			name += "/"
+		}
+		// 檔名可能含 ? # 等字元，href 要先做 path escape；加上 ./ 避免 a:b 被當成 scheme
+		href := (&url.URL{Path: "./" + name}).String()
+		data.Entries = append(data.Entries, listEntry{Name: name, Href: href})
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-cache")
+	if r.Method == http.MethodHead {
+		return
+	}
+	listTmpl.Execute(w, data)
+}
+
+func redirect(w http.ResponseWriter, r *http.Request, target string) {
+	if q := r.URL.RawQuery; q != "" {
+		target += "?" + q
+	}
+	w.Header().Set("Location", target)
+	w.WriteHeader(http.StatusMovedPermanently)
+}
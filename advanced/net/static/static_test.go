@@ -0,0 +1,233 @@
+package static
+
+import (
+	"embed"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+//go:embed testdata/site
+var embedded embed.FS
+
+func site(t *testing.T) fs.FS {
+	sub, err := fs.Sub(embedded, "testdata/site")
+	require.NoError(t, err)
+	return sub
+}
+
+func get(h http.Handler, target string, header ...string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.URL.Path = target // 不經過 URL 解析，測試 handler 收到的原始路徑
+	for i := 0; i+1 < len(header); i += 2 {
+		r.Header.Set(header[i], header[i+1])
+	}
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, r)
+	return rec
+}
+
+func TestServeEmbedded(t *testing.T) {
+	s := New(site(t))
+
+	rec := get(s, "/docs/guide.txt")
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "guide\n", rec.Body.String())
+	assert.Equal(t, "text/plain; charset=utf-8", rec.Header().Get("Content-Type"))
+	assert.Equal(t, "public, max-age=3600", rec.Header().Get("Cache-Control"))
+	assert.NotEmpty(t, rec.Header().Get("ETag"))
+
+	rec = get(s, "/")
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "<h1>home</h1>\n", rec.Body.String())
+	assert.Equal(t, "no-cache", rec.Header().Get("Cache-Control"))
+
+	rec = get(s, "/assets/app.3f9a1c2b.css")
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "public, max-age=31536000, immutable", rec.Header().Get("Cache-Control"))
+	assert.Contains(t, rec.Header().Get("Content-Type"), "text/css")
+
+	assert.Equal(t, http.StatusNotFound, get(s, "/missing.txt").Code)
+}
+
+func TestConditional(t *testing.T) {
+	s := New(site(t))
+	etag := get(s, "/docs/guide.txt").Header().Get("ETag")
+
+	rec := get(s, "/docs/guide.txt", "If-None-Match", etag)
+	assert.Equal(t, http.StatusNotModified, rec.Code)
+	assert.Empty(t, rec.Body.String())
+
+	rec = get(s, "/docs/guide.txt", "If-None-Match", `"other"`)
+	assert.Equal(t, http.StatusOK, rec.Code)
+}
+
+func TestRange(t *testing.T) {
+	fsys := fstest.MapFS{"data.bin": {Data: []byte("0123456789abcdef"), ModTime: time.Unix(1700000000, 0)}}
+	s := New(fsys)
+
+	rec := get(s, "/data.bin", "Range", "bytes=2-5")
+	require.Equal(t, http.StatusPartialContent, rec.Code)
+	assert.Equal(t, "2345", rec.Body.String())
+	assert.Equal(t, "bytes 2-5/16", rec.Header().Get("Content-Range"))
+
+	rec = get(s, "/data.bin", "Range", "bytes=-3")
+	assert.Equal(t, "def", rec.Body.String())
+
+	rec = get(s, "/data.bin", "Range", "bytes=0-1,10-11")
+	require.Equal(t, http.StatusPartialContent, rec.Code)
+	assert.True(t, strings.HasPrefix(rec.Header().Get("Content-Type"), "multipart/byteranges"))
+	assert.Contains(t, rec.Body.String(), "01")
+	assert.Contains(t, rec.Body.String(), "ab")
+
+	rec = get(s, "/data.bin", "Range", "bytes=100-200")
+	assert.Equal(t, http.StatusRequestedRangeNotSatisfiable, rec.Code)
+
+	// If-Range 的 ETag 不符（檔案已經換了）時回傳整個檔案
+	rec = get(s, "/data.bin", "Range", "bytes=2-5", "If-Range", `"stale"`)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, 16, rec.Body.Len())
+	etag := rec.Header().Get("ETag")
+	rec = get(s, "/data.bin", "Range", "bytes=2-5", "If-Range", etag)
+	assert.Equal(t, http.StatusPartialContent, rec.Code)
+}
+
+func TestDirectories(t *testing.T) {
+	t.Run("no listing", func(t *testing.T) {
+		s := New(site(t))
+		assert.Equal(t, http.StatusNotFound, get(s, "/docs/").Code)
+	})
+
+	t.Run("listing", func(t *testing.T) {
+		s := New(site(t), WithListing(true))
+		rec := get(s, "/docs/")
+		require.Equal(t, http.StatusOK, rec.Code)
+		assert.Contains(t, rec.Body.String(), `<a href="./guide.txt">guide.txt</a>`)
+
+		rec = get(s, "/")
+		assert.Contains(t, rec.Body.String(), "home", "index.html wins over listing")
+	})
+
+	t.Run("listing escapes names", func(t *testing.T) {
+		fsys := fstest.MapFS{
+			"dir/<i>x.txt": {Data: []byte("x")},
+			"dir/a?b.txt":  {Data: []byte("x")},
+			"dir/.secret":  {Data: []byte("x")},
+			"dir/sub/f":    {Data: []byte("x")},
+		}
+		rec := get(New(fsys, WithListing(true)), "/dir/")
+		body := rec.Body.String()
+		assert.NotContains(t, body, "<i>")
+		assert.Contains(t, body, "&lt;i&gt;x.txt")
+		assert.Contains(t, body, `href="./a%3Fb.txt"`)
+		assert.Contains(t, body, `href="./sub/"`)
+		assert.NotContains(t, body, "secret")
+	})
+
+	t.Run("redirect", func(t *testing.T) {
+		rec := get(New(site(t)), "/docs")
+		assert.Equal(t, http.StatusMovedPermanently, rec.Code)
+		assert.Equal(t, "docs/", rec.Header().Get("Location"))
+	})
+}
+
+func TestPathTraversal(t *testing.T) {
+	// 根目錄外放一個檔案，確認怎麼繞都拿不到
+	parent := t.TempDir()
+	root := filepath.Join(parent, "public")
+	require.NoError(t, os.MkdirAll(root, 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(parent, "secret.txt"), []byte("secret"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(root, "ok.txt"), []byte("ok"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(root, ".env"), []byte("secret"), 0o644))
+
+	for name, fsys := range map[string]fs.FS{"disk": os.DirFS(root), "embed": site(t)} {
+		s := New(fsys, WithListing(true))
+		for _, p := range []string{
+			"/../secret.txt",
+			"/../../secret.txt",
+			"/docs/../../secret.txt",
+			"../secret.txt",
+			"/..\\secret.txt",
+			"\\..\\secret.txt",
+			"/ok.txt\x00.png",
+			"/.env",
+			"/docs/../.env",
+			"//.env",
+		} {
+			rec := get(s, p)
+			assert.Equal(t, http.StatusNotFound, rec.Code, "%s %q", name, p)
+			assert.NotContains(t, rec.Body.String(), "secret", "%s %q", name, p)
+		}
+	}
+
+	// 經過真正的 server 與 URL 解碼（%2e%2e、%2f）
+	srv := httptest.NewServer(New(os.DirFS(root)))
+	defer srv.Close()
+	for _, p := range []string{"/%2e%2e/secret.txt", "/..%2fsecret.txt", "/%2e%2e%2fsecret.txt", "/%2eenv"} {
+		resp, err := http.Get(srv.URL + p)
+		require.NoError(t, err)
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		assert.NotEqual(t, http.StatusOK, resp.StatusCode, p)
+		assert.NotContains(t, string(body), "secret", p)
+	}
+	resp, err := http.Get(srv.URL + "/ok.txt")
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+}
+
+func TestMethodsAndPolicy(t *testing.T) {
+	s := New(site(t), WithCachePolicy(NoCache))
+	r := httptest.NewRequest(http.MethodPost, "/docs/guide.txt", nil)
+	rec := httptest.NewRecorder()
+	s.ServeHTTP(rec, r)
+	assert.Equal(t, http.StatusMethodNotAllowed, rec.Code)
+	assert.Equal(t, "GET, HEAD", rec.Header().Get("Allow"))
+
+	r = httptest.NewRequest(http.MethodHead, "/docs/guide.txt", nil)
+	rec = httptest.NewRecorder()
+	s.ServeHTTP(rec, r)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Empty(t, rec.Body.String())
+	assert.Equal(t, "no-cache", rec.Header().Get("Cache-Control"))
+	assert.Equal(t, "6", rec.Header().Get("Content-Length"))
+}
+
+func TestETagTracksChanges(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "f.txt")
+	require.NoError(t, os.WriteFile(file, []byte("v1"), 0o644))
+	s := New(os.DirFS(dir))
+	first := get(s, "/f.txt").Header().Get("ETag")
+	assert.Equal(t, first, get(s, "/f.txt").Header().Get("ETag"))
+
+	require.NoError(t, os.WriteFile(file, []byte("v2!"), 0o644))
+	rec := get(s, "/f.txt", "If-None-Match", first)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "v2!", rec.Body.String())
+	assert.NotEqual(t, first, rec.Header().Get("ETag"))
+}
+
+func TestDefaultCachePolicy(t *testing.T) {
+	for name, want := range map[string]string{
+		"app.3f9a1c2b.js":             "public, max-age=31536000, immutable",
+		"assets/chunk-0a1b2c3d4e.css": "public, max-age=31536000, immutable",
+		"index.html":                  "no-cache",
+		"docs/page.html":              "no-cache",
+		"logo.png":                    "public, max-age=3600",
+		"app.js":                      "public, max-age=3600",
+	} {
+		assert.Equal(t, want, DefaultCachePolicy(name), name)
+	}
+}
@@ -0,0 +1,164 @@
+package signals
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+)
+
+/*
+server 範例共用的優雅關閉流程：
+ 1. 第一次收到 SIGINT/SIGTERM：取消 ctx，開始依 LIFO 順序執行 cleanup hook
+ 2. 第二次收到訊號：不等 cleanup 了，直接 exit(1)
+
+LIFO 的原因跟 defer 一樣：後建立的資源通常依賴先建立的資源（例如 HTTP server 依賴 DB），
+所以要先關 server 再關 DB。
+*/
+
+// 方便測試替換
+var (
+	notify = signal.Notify
+	stop   = signal.Stop
+	exit   = os.Exit
+)
+
+var DefaultSignals = []os.Signal{os.Interrupt, syscall.SIGTERM}
+
+// NotifyContext 類似 signal.NotifyContext，差別在於收到第二次訊號時強制結束程式；
+// parent 結束時與呼叫 stop 一樣停止接收訊號
+func NotifyContext(parent context.Context, sigs ...os.Signal) (context.Context, context.CancelFunc) {
+	if len(sigs) == 0 {
+		sigs = DefaultSignals
+	}
+	ctx, cancel := context.WithCancel(parent)
+	ch := make(chan os.Signal, 2)
+	notify(ch, sigs...)
+
+	done := make(chan struct{})
+	var once sync.Once
+	stopFn := func() {
+		once.Do(func() {
+			stop(ch)
+			close(done)
+			cancel()
+		})
+	}
+
+	go func() {
+		select {
+		case <-ch:
+			cancel()
+		case <-parent.Done():
+			// 上層先結束：不會再有人等訊號，停止接收並讓 goroutine 離開
+			stopFn()
+			return
+		case <-done:
+			return
+		}
+		select {
+		case <-ch:
+			exit(1)
+		case <-done:
+		}
+	}()
+	return ctx, stopFn
+}
+
+type hook struct {
+	fn      func(context.Context) error
+	timeout time.Duration
+}
+
+// Stack 是 cleanup hook 的堆疊，Shutdown 時後註冊的先執行
+type Stack struct {
+	mu      sync.Mutex
+	hooks   []hook
+	Timeout time.Duration // 每個 hook 的預設 timeout，0 表示不限制
+}
+
+func NewStack(timeout time.Duration) *Stack {
+	return &Stack{Timeout: timeout}
+}
+
+func (s *Stack) OnShutdown(fn func(context.Context) error) {
+	s.OnShutdownTimeout(fn, s.Timeout)
+}
+
+// OnShutdownTimeout 為單一 hook 指定 timeout
+func (s *Stack) OnShutdownTimeout(fn func(context.Context) error, timeout time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.hooks = append(s.hooks, hook{fn: fn, timeout: timeout})
+}
+
+// Shutdown 依 LIFO 執行所有 hook，一個失敗或超時不影響後面的 hook，錯誤會合併回傳
+func (s *Stack) Shutdown(ctx context.Context) error {
+	s.mu.Lock()
+	hooks := s.hooks
+	s.hooks = nil
+	s.mu.Unlock()
+
+	var errs []error
+	for i := len(hooks) - 1; i >= 0; i-- {
+		if err := runHook(ctx, hooks[i]); err != nil {
+			errs = append(errs, fmt.Errorf("hook %d: %w", i, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func runHook(ctx context.Context, h hook) error {
+	if h.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, h.timeout)
+		defer cancel()
+	}
+	errCh := make(chan error, 1)
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				errCh <- fmt.Errorf("panic: %v", r)
+			}
+		}()
+		errCh <- h.fn(ctx)
+	}()
+	// hook 不理會 ctx 時也不會卡住整個關閉流程
+	select {
+	case err := <-errCh:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Wait 等到 ctx 被取消（通常是 NotifyContext 收到訊號）後執行 Shutdown
+func (s *Stack) Wait(ctx context.Context) error {
+	<-ctx.Done()
+	return s.Shutdown(context.Background())
+}
+
+var defaultStack = NewStack(5 * time.Second)
+
+func OnShutdown(fn func(context.Context) error) {
+	defaultStack.OnShutdown(fn)
+}
+
+func Shutdown(ctx context.Context) error {
+	return defaultStack.Shutdown(ctx)
+}
+
+// Run 是 server main 的樣板：等待訊號，然後執行預設 stack 的 cleanup
+//
+//	ctx, stop := signals.NotifyContext(context.Background())
+//	defer stop()
+//	go srv.ListenAndServe()
+//	signals.OnShutdown(srv.Shutdown)
+//	signals.Run(ctx)
+func Run(ctx context.Context) error {
+	return defaultStack.Wait(ctx)
+}
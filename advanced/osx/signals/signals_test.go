@@ -0,0 +1,146 @@
+package signals
+
+import (
+	"context"
+	"errors"
+	"os"
+	"sync"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// 用假的 notify 取代 signal.Notify，測試時直接往 channel 送訊號
+func fakeNotify(t *testing.T) (send func(os.Signal), exited <-chan int) {
+	var mu sync.Mutex
+	var ch chan<- os.Signal
+	exitCh := make(chan int, 1)
+
+	origNotify, origStop, origExit := notify, stop, exit
+	notify = func(c chan<- os.Signal, sig ...os.Signal) {
+		mu.Lock()
+		ch = c
+		mu.Unlock()
+	}
+	stop = func(c chan<- os.Signal) {}
+	exit = func(code int) { exitCh <- code }
+	t.Cleanup(func() {
+		notify, stop, exit = origNotify, origStop, origExit
+	})
+	return func(s os.Signal) {
+		mu.Lock()
+		defer mu.Unlock()
+		ch <- s
+	}, exitCh
+}
+
+func TestNotifyContext(t *testing.T) {
+	send, exited := fakeNotify(t)
+	ctx, stopFn := NotifyContext(context.Background())
+	defer stopFn()
+
+	assert.Nil(t, ctx.Err())
+	send(syscall.SIGTERM)
+	<-ctx.Done()
+	assert.Equal(t, context.Canceled, ctx.Err())
+
+	// 第二次訊號強制結束
+	send(syscall.SIGTERM)
+	select {
+	case code := <-exited:
+		assert.Equal(t, 1, code)
+	case <-time.After(time.Second):
+		t.Fatal("second signal did not force exit")
+	}
+}
+
+func TestNotifyContextStop(t *testing.T) {
+	fakeNotify(t)
+	ctx, stopFn := NotifyContext(context.Background())
+	stopFn()
+	stopFn()
+	<-ctx.Done()
+}
+
+// parent 被取消時停止接收訊號，goroutine 不會等到下一個訊號才離開
+func TestNotifyContextParentCancelled(t *testing.T) {
+	fakeNotify(t)
+	stopped := make(chan struct{})
+	stop = func(chan<- os.Signal) { close(stopped) }
+
+	parent, cancel := context.WithCancel(context.Background())
+	ctx, stopFn := NotifyContext(parent)
+	defer stopFn()
+	cancel()
+	<-ctx.Done()
+	select {
+	case <-stopped:
+	case <-time.After(time.Second):
+		t.Fatal("signal.Stop not called after parent was cancelled")
+	}
+}
+
+func TestStackLIFO(t *testing.T) {
+	s := NewStack(time.Second)
+	var order []int
+	for i := 0; i < 3; i++ {
+		i := i
+		s.OnShutdown(func(ctx context.Context) error {
+			order = append(order, i)
+			return nil
+		})
+	}
+	assert.NoError(t, s.Shutdown(context.Background()))
+	assert.Equal(t, []int{2, 1, 0}, order)
+
+	// hook 只會執行一次
+	assert.NoError(t, s.Shutdown(context.Background()))
+	assert.Equal(t, []int{2, 1, 0}, order)
+}
+
+func TestStackHookTimeout(t *testing.T) {
+	s := NewStack(time.Second)
+	ran := false
+	s.OnShutdown(func(ctx context.Context) error {
+		ran = true
+		return nil
+	})
+	// 不理會 ctx 的 hook 也會因為 timeout 被跳過
+	s.OnShutdownTimeout(func(ctx context.Context) error {
+		time.Sleep(time.Second)
+		return nil
+	}, 20*time.Millisecond)
+	s.OnShutdown(func(ctx context.Context) error {
+		return errors.New("boom")
+	})
+	s.OnShutdown(func(ctx context.Context) error {
+		panic("oops")
+	})
+
+	start := time.Now()
+	err := s.Shutdown(context.Background())
+	assert.Less(t, time.Since(start), 500*time.Millisecond)
+	assert.True(t, ran)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.ErrorContains(t, err, "boom")
+	assert.ErrorContains(t, err, "panic: oops")
+}
+
+func TestStackWait(t *testing.T) {
+	send, _ := fakeNotify(t)
+	ctx, stopFn := NotifyContext(context.Background(), syscall.SIGINT)
+	defer stopFn()
+
+	s := NewStack(time.Second)
+	closed := make(chan struct{})
+	s.OnShutdown(func(ctx context.Context) error {
+		close(closed)
+		return nil
+	})
+
+	go send(syscall.SIGINT)
+	assert.NoError(t, s.Wait(ctx))
+	<-closed
+}
@@ -0,0 +1,219 @@
+package statemachine
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"advanced/eventlog"
+	"advanced/snapshot"
+)
+
+/*
+Durable 讓狀態機在 crash 後可以復原，做法和資料庫一樣：WAL + checkpoint。
+
+  - WAL（write-ahead log）：每次轉換「先」寫入 dir/wal.log，寫成功才改變記憶體中的狀態。
+    沒寫進 WAL 的轉換等於沒發生過，呼叫端也會收到錯誤
+  - checkpoint：把目前狀態與序號寫成 dir/state.snap（snapshot 套件，atomic rename），
+    接著 compact WAL，WAL 不會無限成長
+
+每筆 WAL 紀錄帶有遞增的序號 Seq，snapshot 記錄「已包含到哪個序號之前」。復原時：
+
+	1. 讀 snapshot（沒有就從 Definition.Initial 開始）
+	2. 依序重播 WAL 中 Seq >= snapshot.Seq 的紀錄，較舊的直接略過
+	3. WAL 最後一筆寫到一半（torn write）會被 eventlog 截掉，等同那次 Fire 沒有成功
+
+各種 crash 時間點：
+  - 寫 WAL 途中：該筆被截掉，狀態停在上一筆
+  - 寫 snapshot 途中：留下暫存檔（啟動時清掉），正式的 snapshot 還是舊的，WAL 也還沒 compact → 重播即可
+  - snapshot 完成、compact 之前：WAL 中的舊紀錄 Seq 都小於 snapshot.Seq，全部略過
+
+重播時每筆紀錄都會重新走一次轉換表，確認 From/To 與紀錄相符；
+不相符代表 WAL 與 Definition 不一致（例如改了轉換表），回傳 ErrCorruptLog 而不是默默得到錯的狀態。
+*/
+
+var ErrCorruptLog = errors.New("statemachine: wal does not match definition")
+
+const (
+	walFile  = "wal.log"
+	snapFile = "state.snap"
+	walKey   = "transition" // 所有紀錄共用同一個 key，compact 時只留最後一筆
+)
+
+type entry struct {
+	Seq   uint64
+	From  State
+	Event Event
+	To    State
+}
+
+type checkpoint struct {
+	State State
+	Seq   uint64 // 下一個要寫入的序號，之前的都已經包含在 State 裡
+}
+
+type persistConfig struct {
+	every int
+	sync  bool
+}
+
+type PersistOption func(*persistConfig)
+
+// WithCheckpointEvery 每 n 次轉換自動做一次 checkpoint，0 表示只在呼叫 Checkpoint 時做
+func WithCheckpointEvery(n int) PersistOption {
+	return func(c *persistConfig) { c.every = n }
+}
+
+// WithSync 每次寫入 WAL 都 fsync，預設開啟
+func WithSync(on bool) PersistOption {
+	return func(c *persistConfig) { c.sync = on }
+}
+
+// Recovery 描述 Open 時如何重建狀態
+type Recovery struct {
+	FromSnapshot bool
+	Replayed     int // 從 WAL 重播的轉換數
+}
+
+type Durable struct {
+	mu       sync.Mutex
+	m        *Machine
+	wal      *eventlog.Log
+	snapPath string
+	cfg      persistConfig
+	seq      uint64
+	since    int // 上次 checkpoint 之後的轉換數
+	recovery Recovery
+}
+
+// Open 從 dir 中的 snapshot 與 WAL 復原狀態機；dir 需已存在
+func Open(dir string, def Definition, opts ...PersistOption) (*Durable, error) {
+	cfg := persistConfig{sync: true}
+	for _, o := range opts {
+		o(&cfg)
+	}
+	m, err := New(def)
+	if err != nil {
+		return nil, err
+	}
+	d := &Durable{m: m, snapPath: filepath.Join(dir, snapFile), cfg: cfg}
+
+	if err := snapshot.CleanTemp(d.snapPath); err != nil {
+		return nil, err
+	}
+	var cp checkpoint
+	switch err := snapshot.ReadFile(d.snapPath, &cp); {
+	case err == nil:
+		m.state = cp.State
+		d.seq = cp.Seq
+		d.recovery.FromSnapshot = true
+	case !errors.Is(err, fs.ErrNotExist):
+		return nil, err
+	}
+
+	d.wal, err = eventlog.Open(filepath.Join(dir, walFile), eventlog.WithSync(cfg.sync))
+	if err != nil {
+		return nil, err
+	}
+	if err := d.replay(cp.Seq); err != nil {
+		d.wal.Close()
+		return nil, err
+	}
+	return d, nil
+}
+
+func (d *Durable) replay(from uint64) error {
+	for _, r := range d.wal.Read(0, 0) {
+		var e entry
+		if err := json.Unmarshal(r.Data, &e); err != nil {
+			return fmt.Errorf("%w: offset %d: %v", ErrCorruptLog, r.Offset, err)
+		}
+		if e.Seq >= d.seq {
+			d.seq = e.Seq + 1
+		}
+		if e.Seq < from {
+			continue
+		}
+		if e.From != d.m.state {
+			return fmt.Errorf("%w: seq %d starts from %q, machine is in %q", ErrCorruptLog, e.Seq, e.From, d.m.state)
+		}
+		to, err := d.m.Fire(e.Event)
+		if err != nil || to != e.To {
+			return fmt.Errorf("%w: seq %d %s --%s--> %s", ErrCorruptLog, e.Seq, e.From, e.Event, e.To)
+		}
+		d.recovery.Replayed++
+		d.since++
+	}
+	return nil
+}
+
+// Recovery 回傳 Open 時的復原資訊
+func (d *Durable) Recovery() Recovery { return d.recovery }
+
+func (d *Durable) State() State {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.m.state
+}
+
+// Fire 先把轉換寫入 WAL 再套用。
+// 達到 WithCheckpointEvery 的次數時會順便 checkpoint；此時回傳的錯誤只代表 checkpoint 失敗，轉換本身已經生效
+func (d *Durable) Fire(e Event) (State, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	from := d.m.state
+	to, err := d.m.next(e)
+	if err != nil {
+		return from, err
+	}
+	data, err := json.Marshal(entry{Seq: d.seq, From: from, Event: e, To: to})
+	if err != nil {
+		return from, err
+	}
+	if _, err := d.wal.Append(walKey, data); err != nil {
+		return from, fmt.Errorf("statemachine: wal: %w", err)
+	}
+	d.seq++
+	d.m.state = to
+	d.since++
+	if d.cfg.every > 0 && d.since >= d.cfg.every {
+		if err := d.checkpoint(); err != nil {
+			return to, fmt.Errorf("statemachine: checkpoint: %w", err)
+		}
+	}
+	return to, nil
+}
+
+// Checkpoint 寫入 snapshot 並 compact WAL
+func (d *Durable) Checkpoint() error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.checkpoint()
+}
+
+func (d *Durable) checkpoint() error {
+	if err := snapshot.WriteFile(d.snapPath, checkpoint{State: d.m.state, Seq: d.seq}); err != nil {
+		return err
+	}
+	d.since = 0
+	// 持有鎖，WAL 中所有紀錄都已包含在 snapshot 內；compact 後只剩最後一筆，用來延續序號
+	_, err := d.wal.Compact()
+	return err
+}
+
+// Run 每隔 interval checkpoint 一次，ctx 結束時做最後一次後返回
+func (d *Durable) Run(ctx context.Context, interval time.Duration, onErr func(error)) {
+	snapshot.Run(ctx, interval, d.Checkpoint, onErr)
+}
+
+// Close 關閉 WAL，不會自動 checkpoint；下次 Open 時從 WAL 重播
+func (d *Durable) Close() error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.wal.Close()
+}
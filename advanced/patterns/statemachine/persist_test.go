@@ -0,0 +1,150 @@
+package statemachine
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"advanced/snapshot"
+)
+
+func fire(t *testing.T, d *Durable, events ...Event) {
+	t.Helper()
+	for _, e := range events {
+		_, err := d.Fire(e)
+		require.NoError(t, err)
+	}
+}
+
+func reopen(t *testing.T, dir string, opts ...PersistOption) *Durable {
+	t.Helper()
+	d, err := Open(dir, order, opts...)
+	require.NoError(t, err)
+	t.Cleanup(func() { d.Close() })
+	return d
+}
+
+func TestReplayWAL(t *testing.T) {
+	dir := t.TempDir()
+	d := reopen(t, dir)
+	assert.Equal(t, Recovery{}, d.Recovery())
+	fire(t, d, "pay", "ship")
+	_, err := d.Fire("pay")
+	assert.ErrorIs(t, err, ErrInvalidTransition)
+	require.NoError(t, d.Close())
+
+	d = reopen(t, dir)
+	assert.Equal(t, State("shipped"), d.State())
+	assert.Equal(t, Recovery{Replayed: 2}, d.Recovery(), "非法事件不會寫入 WAL")
+}
+
+func TestCheckpoint(t *testing.T) {
+	dir := t.TempDir()
+	d := reopen(t, dir, WithCheckpointEvery(2))
+	fire(t, d, "pay", "ship", "deliver")
+	require.NoError(t, d.Close())
+
+	d = reopen(t, dir)
+	assert.Equal(t, State("delivered"), d.State())
+	assert.Equal(t, Recovery{FromSnapshot: true, Replayed: 1}, d.Recovery(), "只重播 checkpoint 之後的轉換")
+
+	require.NoError(t, d.Checkpoint())
+	require.NoError(t, d.Close())
+	d = reopen(t, dir)
+	assert.Equal(t, Recovery{FromSnapshot: true}, d.Recovery())
+}
+
+// TestTornWAL 最後一筆 WAL 只寫了一半：那次 Fire 視為沒有發生
+func TestTornWAL(t *testing.T) {
+	dir := t.TempDir()
+	d := reopen(t, dir)
+	fire(t, d, "pay", "ship")
+	require.NoError(t, d.Close())
+
+	wal := filepath.Join(dir, walFile)
+	info, err := os.Stat(wal)
+	require.NoError(t, err)
+	require.NoError(t, os.Truncate(wal, info.Size()-5))
+
+	d = reopen(t, dir)
+	assert.Equal(t, State("paid"), d.State())
+	assert.Equal(t, 1, d.Recovery().Replayed)
+
+	// 復原後可以繼續，而且再次重啟結果一致
+	fire(t, d, "cancel")
+	require.NoError(t, d.Close())
+	d = reopen(t, dir)
+	assert.Equal(t, State("cancelled"), d.State())
+}
+
+// TestCrashBeforeCompact snapshot 已寫入但 WAL 還沒 compact：舊紀錄依序號略過，不會重複套用
+func TestCrashBeforeCompact(t *testing.T) {
+	dir := t.TempDir()
+	d := reopen(t, dir)
+	fire(t, d, "pay", "ship")
+	require.NoError(t, snapshot.WriteFile(filepath.Join(dir, snapFile), checkpoint{State: d.State(), Seq: d.seq}))
+	require.NoError(t, d.Close())
+
+	d = reopen(t, dir)
+	assert.Equal(t, State("shipped"), d.State())
+	assert.Equal(t, Recovery{FromSnapshot: true}, d.Recovery())
+
+	fire(t, d, "deliver")
+	require.NoError(t, d.Close())
+	d = reopen(t, dir)
+	assert.Equal(t, State("delivered"), d.State())
+	assert.Equal(t, Recovery{FromSnapshot: true, Replayed: 1}, d.Recovery())
+}
+
+// TestCrashDuringSnapshot 寫 snapshot 時當機：留下暫存檔，正式檔仍是舊的，從 WAL 補回
+func TestCrashDuringSnapshot(t *testing.T) {
+	dir := t.TempDir()
+	d := reopen(t, dir)
+	fire(t, d, "pay")
+	require.NoError(t, d.Checkpoint())
+	fire(t, d, "ship")
+	require.NoError(t, d.Close())
+	tmp := filepath.Join(dir, snapFile+".tmp-123")
+	require.NoError(t, os.WriteFile(tmp, []byte("partial"), 0o644))
+
+	d = reopen(t, dir)
+	assert.Equal(t, State("shipped"), d.State())
+	assert.Equal(t, Recovery{FromSnapshot: true, Replayed: 1}, d.Recovery())
+	assert.NoFileExists(t, tmp)
+}
+
+// TestDefinitionChanged WAL 與轉換表對不上時回報錯誤，而不是得到錯誤的狀態
+func TestDefinitionChanged(t *testing.T) {
+	dir := t.TempDir()
+	d := reopen(t, dir)
+	fire(t, d, "pay")
+	require.NoError(t, d.Close())
+
+	changed := Definition{Initial: "created", Transitions: []Transition{{From: "created", Event: "pay", To: "settled"}}}
+	_, err := Open(dir, changed)
+	assert.ErrorIs(t, err, ErrCorruptLog)
+}
+
+func TestRun(t *testing.T) {
+	dir := t.TempDir()
+	d := reopen(t, dir, WithSync(false))
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		d.Run(ctx, time.Millisecond, func(err error) { t.Error(err) })
+	}()
+	fire(t, d, "pay", "ship")
+	cancel()
+	<-done
+	require.NoError(t, d.Close())
+
+	d = reopen(t, dir)
+	assert.Equal(t, State("shipped"), d.State())
+	assert.Equal(t, Recovery{FromSnapshot: true}, d.Recovery(), "結束前做了最後一次 checkpoint")
+}
@@ -0,0 +1,104 @@
+package statemachine
+
+import (
+	"errors"
+	"fmt"
+)
+
+/*
+有限狀態機（FSM）：狀態只能透過事件改變，每個 (目前狀態, 事件) 最多對應一個目標狀態。
+轉換表在建立時就驗證完，執行期間 Fire 只做查表，非法的事件回傳 ErrInvalidTransition，狀態不變。
+
+	created --pay--> paid --ship--> shipped --deliver--> delivered
+	   |                |
+	   +----cancel------+--> cancelled
+
+因為轉換是確定性的（同樣的起點、同樣的事件序列 → 同樣的終點），
+只要把「發生過哪些事件」記下來，就能重建狀態；persist.go 以此為基礎做 WAL + snapshot。
+*/
+
+var (
+	ErrInvalidTransition = errors.New("statemachine: invalid transition")
+	ErrInvalidDefinition = errors.New("statemachine: invalid definition")
+)
+
+type (
+	State string
+	Event string
+)
+
+type Transition struct {
+	From  State
+	Event Event
+	To    State
+}
+
+// Definition 描述初始狀態與所有合法的轉換
+type Definition struct {
+	Initial     State
+	Transitions []Transition
+}
+
+type key struct {
+	from  State
+	event Event
+}
+
+// table 是編譯過的轉換表
+type table map[key]State
+
+func (d Definition) compile() (table, error) {
+	if d.Initial == "" {
+		return nil, fmt.Errorf("%w: empty initial state", ErrInvalidDefinition)
+	}
+	t := make(table, len(d.Transitions))
+	for _, tr := range d.Transitions {
+		k := key{tr.From, tr.Event}
+		if to, ok := t[k]; ok && to != tr.To {
+			return nil, fmt.Errorf("%w: %s --%s--> %s and %s", ErrInvalidDefinition, tr.From, tr.Event, to, tr.To)
+		}
+		t[k] = tr.To
+	}
+	return t, nil
+}
+
+// Machine 不是 concurrency-safe 的，需要共用時由呼叫端加鎖（Durable 已經加了）
+type Machine struct {
+	table table
+	state State
+}
+
+func New(def Definition) (*Machine, error) {
+	t, err := def.compile()
+	if err != nil {
+		return nil, err
+	}
+	return &Machine{table: t, state: def.Initial}, nil
+}
+
+func (m *Machine) State() State { return m.state }
+
+// Can 回傳事件在目前狀態是否合法
+func (m *Machine) Can(e Event) bool {
+	_, ok := m.table[key{m.state, e}]
+	return ok
+}
+
+// Fire 套用事件，回傳新的狀態
+func (m *Machine) Fire(e Event) (State, error) {
+	to, err := m.next(e)
+	if err != nil {
+		return m.state, err
+	}
+	m.state = to
+	return to, nil
+}
+
+// next 回傳事件會轉換到的狀態，但不改變目前狀態
+func (m *Machine) next(e Event) (State, error) {
+	to, ok := m.table[key{m.state, e}]
+	if !ok {
+		return "", fmt.Errorf("%w: %q in state %q", ErrInvalidTransition, e, m.state)
+	}
+	return to, nil
+}
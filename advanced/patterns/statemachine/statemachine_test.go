@@ -0,0 +1,48 @@
+package statemachine
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var order = Definition{
+	Initial: "created",
+	Transitions: []Transition{
+		{From: "created", Event: "pay", To: "paid"},
+		{From: "paid", Event: "ship", To: "shipped"},
+		{From: "shipped", Event: "deliver", To: "delivered"},
+		{From: "created", Event: "cancel", To: "cancelled"},
+		{From: "paid", Event: "cancel", To: "cancelled"},
+	},
+}
+
+func TestMachine(t *testing.T) {
+	m, err := New(order)
+	require.NoError(t, err)
+	assert.Equal(t, State("created"), m.State())
+	assert.True(t, m.Can("pay"))
+	assert.False(t, m.Can("ship"))
+
+	_, err = m.Fire("ship")
+	assert.ErrorIs(t, err, ErrInvalidTransition)
+	assert.Equal(t, State("created"), m.State(), "非法事件不改變狀態")
+
+	for _, e := range []Event{"pay", "ship", "deliver"} {
+		_, err := m.Fire(e)
+		require.NoError(t, err)
+	}
+	assert.Equal(t, State("delivered"), m.State())
+}
+
+func TestDefinition(t *testing.T) {
+	_, err := New(Definition{})
+	assert.ErrorIs(t, err, ErrInvalidDefinition)
+
+	_, err = New(Definition{Initial: "a", Transitions: []Transition{
+		{From: "a", Event: "go", To: "b"},
+		{From: "a", Event: "go", To: "c"},
+	}})
+	assert.ErrorIs(t, err, ErrInvalidDefinition, "同一個 (狀態, 事件) 對應兩個目標")
+}
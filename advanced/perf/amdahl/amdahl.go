@@ -0,0 +1,128 @@
+package amdahl
+
+import (
+	"errors"
+	"math"
+	"sync"
+)
+
+/*
+Amdahl 定律：程式中有比例 s 的部分只能循序執行，剩下的 1-s 可以平均分給 n 個 worker，
+理論上的加速比是
+
+	S(n) = 1 / (s + (1-s)/n)
+
+n 趨近無限大時 S 趨近 1/s：只要有 10% 是循序的，再多核心也快不過 10 倍。
+
+basic/goroutine 的 TestGoroutinePROCS 示範了 GOMAXPROCS=1 時兩個 goroutine 只能輪流執行；
+這個套件把它量化：
+  - Workload：可調整循序比例的 CPU 工作（純運算，不 sleep，才會真的吃 CPU）
+  - Measure：在不同 worker 數量下量測實際的加速比
+  - Report：把量測值、GOMAXPROCS 限制下的理論值與理想值畫在同一張圖
+
+worker 數量超過實際能平行執行的數量（GOMAXPROCS 與 CPU 數取小）之後，多出來的 goroutine 只是輪流執行，
+所以「受限理論值」用 min(n, procs) 代入：
+
+	Bound(n) = S(min(n, procs))
+
+量測值與理論值的差距來自排程、cache、記憶體頻寬等額外成本，可以用 Karp–Flatt 指標
+反推「實際表現得像多少循序比例」：
+
+	e = (1/S - 1/p) / (1 - 1/p)
+
+e 隨 p 增加而上升，代表額外成本（同步、競爭）隨 worker 數增加，而不只是固定的循序部分。
+*/
+
+var ErrInvalidWorkload = errors.New("amdahl: invalid workload")
+
+// Speedup 回傳循序比例 serial、n 個 worker 時的理論加速比
+func Speedup(serial float64, n int) float64 {
+	if n < 1 {
+		return 0
+	}
+	return 1 / (serial + (1-serial)/float64(n))
+}
+
+// KarpFlatt 由 p 個 worker 量測到的加速比反推循序比例；p <= 1 時沒有定義，回傳 NaN
+func KarpFlatt(speedup float64, p int) float64 {
+	if p <= 1 || speedup <= 0 {
+		return math.NaN()
+	}
+	inv := 1 / float64(p)
+	return (1/speedup - inv) / (1 - inv)
+}
+
+// Workload 是 Units 個工作單位，其中比例 Serial 必須循序執行
+type Workload struct {
+	Units  int     // 工作單位總數
+	Serial float64 // 循序的比例，0 ~ 1
+	Cost   int     // 每個單位的運算次數，預設 DefaultCost
+}
+
+// DefaultCost 在一般的機器上每個單位約 0.1 ~ 0.3 ms
+const DefaultCost = 100_000
+
+func (w Workload) validate() error {
+	if w.Units < 1 || w.Serial < 0 || w.Serial > 1 || w.Cost < 0 {
+		return ErrInvalidWorkload
+	}
+	return nil
+}
+
+func (w Workload) cost() int {
+	if w.Cost == 0 {
+		return DefaultCost
+	}
+	return w.Cost
+}
+
+// split 回傳循序與可平行的單位數
+func (w Workload) split() (serial, parallel int) {
+	serial = int(math.Round(float64(w.Units) * w.Serial))
+	return serial, w.Units - serial
+}
+
+// partition 把 units 盡量平均分給 n 個 worker，前 units%n 個多分一個
+func partition(units, n int) []int {
+	out := make([]int, n)
+	for i := range out {
+		out[i] = units / n
+		if i < units%n {
+			out[i]++
+		}
+	}
+	return out
+}
+
+// Run 以 workers 個 goroutine 執行 w：先循序執行 serial 部分，再平行執行其餘部分，回傳計算結果（避免被編譯器優化掉）
+func (w Workload) Run(workers int) uint64 {
+	serial, parallel := w.split()
+	sum := spin(serial, w.cost(), 1)
+
+	parts := partition(parallel, workers)
+	results := make([]uint64, workers)
+	var wg sync.WaitGroup
+	for i, units := range parts {
+		wg.Add(1)
+		go func(i, units int) {
+			defer wg.Done()
+			results[i] = spin(units, w.cost(), uint64(i)+2)
+		}(i, units)
+	}
+	wg.Wait()
+	for _, r := range results {
+		sum += r
+	}
+	return sum
+}
+
+// spin 做 units*cost 次 xorshift，純 CPU 運算，沒有記憶體存取與同步
+func spin(units, cost int, seed uint64) uint64 {
+	x := seed*0x9E3779B97F4A7C15 | 1
+	for i := 0; i < units*cost; i++ {
+		x ^= x << 13
+		x ^= x >> 7
+		x ^= x << 17
+	}
+	return x
+}
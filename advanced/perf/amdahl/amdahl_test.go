@@ -0,0 +1,119 @@
+package amdahl
+
+import (
+	"bytes"
+	"context"
+	"flag"
+	"math"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// go test ./perf/amdahl -update 重新產生 golden 檔
+var update = flag.Bool("update", false, "update golden files")
+
+func TestSpeedup(t *testing.T) {
+	assert.Equal(t, 1.0, Speedup(0.1, 1))
+	assert.InDelta(t, 4.0, Speedup(0, 4), 1e-9, "完全可平行時線性成長")
+	assert.Equal(t, 1.0, Speedup(1, 64), "完全循序時沒有加速")
+	assert.InDelta(t, 1/(0.1+0.9/8), Speedup(0.1, 8), 1e-9)
+	assert.Less(t, Speedup(0.1, 1<<20), 10.0, "上限是 1/s")
+	assert.Zero(t, Speedup(0.1, 0))
+}
+
+// TestKarpFlatt 對理論值反推，應該得到原本的循序比例
+func TestKarpFlatt(t *testing.T) {
+	for _, s := range []float64{0, 0.05, 0.25, 1} {
+		for _, p := range []int{2, 4, 16} {
+			assert.InDelta(t, s, KarpFlatt(Speedup(s, p), p), 1e-9, "s=%v p=%d", s, p)
+		}
+	}
+	assert.True(t, math.IsNaN(KarpFlatt(1, 1)))
+}
+
+func TestPartition(t *testing.T) {
+	assert.Equal(t, []int{4, 3, 3}, partition(10, 3))
+	assert.Equal(t, []int{1, 1, 0, 0}, partition(2, 4))
+
+	w := Workload{Units: 10, Serial: 0.25}
+	serial, parallel := w.split()
+	assert.Equal(t, 3, serial)
+	assert.Equal(t, 7, parallel)
+}
+
+// TestRunDeterministic 結果只取決於 workload 與 worker 數量
+func TestRunDeterministic(t *testing.T) {
+	w := Workload{Units: 8, Serial: 0.5, Cost: 100}
+	assert.Equal(t, w.Run(4), w.Run(4))
+}
+
+func TestMeasure(t *testing.T) {
+	w := Workload{Units: 40, Serial: 0.2, Cost: 2000}
+	c, err := Measure(context.Background(), w, Config{Workers: []int{4, 2, 2, 0}, Procs: 1, Runs: 1})
+	require.NoError(t, err)
+	assert.Equal(t, 1, c.Procs, "量測期間 GOMAXPROCS=1")
+
+	var workers []int
+	for _, p := range c.Points {
+		workers = append(workers, p.Workers)
+		assert.Equal(t, 1.0, p.Bound, "只有一個 P 時理論上沒有加速")
+		assert.Positive(t, p.Elapsed)
+	}
+	assert.Equal(t, []int{1, 2, 4}, workers, "排序、去重複並加入基準 1")
+	assert.Equal(t, 1.0, c.Points[0].Speedup)
+	assert.InDelta(t, Speedup(0.2, 4), c.Points[2].Ideal, 1e-9)
+
+	_, err = Measure(context.Background(), Workload{Units: 1, Serial: 2}, Config{})
+	assert.ErrorIs(t, err, ErrInvalidWorkload)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = Measure(ctx, w, Config{})
+	assert.ErrorIs(t, err, context.Canceled)
+}
+
+func TestReportGolden(t *testing.T) {
+	c := &Curve{Workload: Workload{Units: 1000, Serial: 0.1}, Procs: 4}
+	measured := []float64{1, 1.8, 3.0, 2.9, 2.8}
+	for i, n := range []int{1, 2, 4, 8, 16} {
+		c.Points = append(c.Points, Point{
+			Workers: n,
+			Elapsed: time.Duration(float64(time.Second) / measured[i]),
+			Speedup: measured[i],
+			Bound:   Speedup(0.1, min(n, 4)),
+			Ideal:   Speedup(0.1, n),
+		})
+	}
+	var out bytes.Buffer
+	require.NoError(t, Report(&out, c, 12))
+
+	path := "testdata/report.golden"
+	if *update {
+		require.NoError(t, os.WriteFile(path, out.Bytes(), 0o644))
+	}
+	want, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, string(want), out.String())
+}
+
+// TestExperiment 實際量測並印出報告：go test ./perf/amdahl -run Experiment -v
+// 單核心的機器上 GOMAXPROCS 只有 1，量測值會停在 1 附近，與受限理論值相同
+func TestExperiment(t *testing.T) {
+	if testing.Short() {
+		t.Skip("量測需要數秒")
+	}
+	c, err := Measure(context.Background(), Workload{Units: 200, Serial: 0.1}, Config{})
+	require.NoError(t, err)
+	var out bytes.Buffer
+	require.NoError(t, Report(&out, c, 10))
+	t.Log("\n" + out.String())
+
+	for _, p := range c.Points[1:] {
+		// 加速不會超過理想值太多（容許量測誤差）
+		assert.Less(t, p.Speedup, p.Ideal*1.5, "workers=%d", p.Workers)
+	}
+}
@@ -0,0 +1,120 @@
+package amdahl
+
+import (
+	"context"
+	"runtime"
+	"sort"
+	"time"
+)
+
+// Config 控制 Measure 的量測方式
+type Config struct {
+	Workers []int // 要量測的 worker 數量，預設 1, 2, 4, ... 到 2*procs
+	Procs   int   // 量測期間的 GOMAXPROCS，0 表示不改變
+	Runs    int   // 每個 worker 數量執行幾次取中位數，預設 3
+}
+
+// Point 是一個 worker 數量的量測結果
+type Point struct {
+	Workers int
+	Elapsed time.Duration // Runs 次的中位數
+	Speedup float64       // 相對於 1 個 worker 的實際加速比
+	Bound   float64       // 受 procs 限制的理論加速比
+	Ideal   float64       // 不受限制的理論加速比
+}
+
+// KarpFlatt 回傳這個點的 Karp–Flatt 指標，以實際能平行的數量計算
+func (p Point) KarpFlatt(procs int) float64 {
+	n := p.Workers
+	if n > procs {
+		n = procs
+	}
+	return KarpFlatt(p.Speedup, n)
+}
+
+// Curve 是一次量測的所有點
+type Curve struct {
+	Workload Workload
+	Procs    int // 實際能平行執行的數量：min(GOMAXPROCS, NumCPU)
+	Points   []Point
+}
+
+// Procs 回傳目前實際能平行執行的 goroutine 數量
+func Procs() int {
+	return min(runtime.GOMAXPROCS(0), runtime.NumCPU())
+}
+
+func defaultWorkers(procs int) []int {
+	var out []int
+	for n := 1; n <= 2*procs; n *= 2 {
+		out = append(out, n)
+	}
+	return out
+}
+
+// Measure 依序以 cfg.Workers 的每個 worker 數量執行 w，回傳加速曲線；
+// 第一個點必定是 1 個 worker（不在列表中時自動加入），作為加速比的基準
+func Measure(ctx context.Context, w Workload, cfg Config) (*Curve, error) {
+	if err := w.validate(); err != nil {
+		return nil, err
+	}
+	if cfg.Procs > 0 {
+		defer runtime.GOMAXPROCS(runtime.GOMAXPROCS(cfg.Procs))
+	}
+	if cfg.Runs < 1 {
+		cfg.Runs = 3
+	}
+	procs := Procs()
+	workers := cfg.Workers
+	if len(workers) == 0 {
+		workers = defaultWorkers(procs)
+	}
+	workers = normalize(workers)
+
+	c := &Curve{Workload: w, Procs: procs}
+	var base time.Duration
+	for _, n := range workers {
+		elapsed, err := median(ctx, w, n, cfg.Runs)
+		if err != nil {
+			return nil, err
+		}
+		if n == 1 {
+			base = elapsed
+		}
+		c.Points = append(c.Points, Point{
+			Workers: n,
+			Elapsed: elapsed,
+			Speedup: float64(base) / float64(elapsed),
+			Bound:   Speedup(w.Serial, min(n, procs)),
+			Ideal:   Speedup(w.Serial, n),
+		})
+	}
+	return c, nil
+}
+
+// normalize 排序、去除重複與小於 1 的值，並確保包含 1
+func normalize(workers []int) []int {
+	out := []int{1}
+	sorted := append([]int(nil), workers...)
+	sort.Ints(sorted)
+	for _, n := range sorted {
+		if n > out[len(out)-1] {
+			out = append(out, n)
+		}
+	}
+	return out
+}
+
+func median(ctx context.Context, w Workload, workers, runs int) (time.Duration, error) {
+	times := make([]time.Duration, runs)
+	for i := range times {
+		if err := ctx.Err(); err != nil {
+			return 0, err
+		}
+		start := time.Now()
+		w.Run(workers)
+		times[i] = time.Since(start)
+	}
+	sort.Slice(times, func(i, j int) bool { return times[i] < times[j] })
+	return times[runs/2], nil
+}
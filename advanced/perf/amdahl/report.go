@@ -0,0 +1,73 @@
+package amdahl
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// Report 輸出表格與文字圖表：* 為量測值、o 為受 procs 限制的理論值、. 為理想值，重疊時優先顯示 *
+func Report(out io.Writer, c *Curve, height int) error {
+	w := c.Workload
+	fmt.Fprintf(out, "workload: %d units, serial %.0f%%, procs %d\n\n", w.Units, w.Serial*100, c.Procs)
+
+	tw := tabwriter.NewWriter(out, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(tw, "workers\telapsed\tspeedup\tbound\tideal\tkarp-flatt\t")
+	for _, p := range c.Points {
+		kf := "-"
+		if e := p.KarpFlatt(c.Procs); !math.IsNaN(e) {
+			kf = fmt.Sprintf("%.3f", e)
+		}
+		fmt.Fprintf(tw, "%d\t%v\t%.2f\t%.2f\t%.2f\t%s\t\n", p.Workers, p.Elapsed.Round(10*time.Microsecond), p.Speedup, p.Bound, p.Ideal, kf)
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	fmt.Fprintln(out)
+	_, err := io.WriteString(out, chart(c.Points, height))
+	return err
+}
+
+// chart 畫出 y 軸為加速比、x 軸為各個 worker 數量的散佈圖，每個點佔一欄
+func chart(points []Point, height int) string {
+	if height < 2 {
+		height = 10
+	}
+	top := 1.0
+	for _, p := range points {
+		top = math.Max(top, math.Max(p.Speedup, p.Ideal))
+	}
+	const col = 4
+	grid := make([][]byte, height)
+	for i := range grid {
+		grid[i] = []byte(strings.Repeat(" ", len(points)*col))
+	}
+	row := func(v float64) int {
+		return height - 1 - int(math.Round(v/top*float64(height-1)))
+	}
+	for i, p := range points {
+		x := i*col + col - 1 // 對齊下方 worker 數量的最後一位
+		// 先畫優先權低的，後畫的會覆蓋
+		grid[row(p.Ideal)][x] = '.'
+		grid[row(p.Bound)][x] = 'o'
+		grid[row(p.Speedup)][x] = '*'
+	}
+
+	var b strings.Builder
+	for i, line := range grid {
+		label := "      "
+		if i == 0 || i == height-1 || i == height/2 {
+			label = fmt.Sprintf("%5.1f ", top*float64(height-1-i)/float64(height-1))
+		}
+		b.WriteString(label + "|" + strings.TrimRight(string(line), " ") + "\n")
+	}
+	b.WriteString("      +" + strings.Repeat("-", len(points)*col) + "\n       ")
+	for _, p := range points {
+		fmt.Fprintf(&b, "%*d", col, p.Workers)
+	}
+	b.WriteString("  workers\n       * measured  o bound  . ideal\n")
+	return b.String()
+}
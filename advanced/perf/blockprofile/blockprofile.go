@@ -0,0 +1,197 @@
+package blockprofile
+
+import (
+	"fmt"
+	"io"
+	"runtime"
+	"sort"
+	"strings"
+	"text/tabwriter"
+)
+
+/*
+block profile 記錄 goroutine「等待」的位置與時間：channel 的 send/recv、select、
+sync.Mutex.Lock、WaitGroup.Wait、Cond.Wait。CPU profile 看不到這些，因為等待時不佔用 CPU，
+但它們正是並行程式變慢的主因。
+
+	runtime.SetBlockProfileRate(1) // 每一次阻塞都記錄；n > 1 時平均每阻塞 n 奈秒取樣一次
+	...
+	go tool pprof http://localhost:6060/debug/pprof/block
+
+這個套件不用 pprof 工具，而是直接讀 runtime.BlockProfile，用程式找出誰在等：
+  - Snapshot：讀出目前累計的紀錄；profile 從程式開始一直累加，用 Diff 取得某段程式執行期間的差異
+  - 每筆紀錄的 stack 最上層是阻塞的操作（runtime.chansend1、sync.(*Mutex).Lock ...），決定 Kind；
+    往下第一個不在 runtime、sync 內的 frame 就是發生阻塞的程式碼位置（Site）
+  - Sites：依 (Kind, Site) 加總，依等待時間排序，Share 為佔全部等待時間的比例
+
+Cycles 是 CPU tick，runtime 沒有公開換算成時間的比例，所以只比較相對大小。
+*/
+
+// Kind 是阻塞的操作種類
+type Kind string
+
+const (
+	KindChanSend  Kind = "chan send"
+	KindChanRecv  Kind = "chan recv"
+	KindSelect    Kind = "select"
+	KindMutex     Kind = "mutex"
+	KindRWMutex   Kind = "rwmutex"
+	KindWaitGroup Kind = "waitgroup"
+	KindCond      Kind = "cond"
+	KindOther     Kind = "other"
+)
+
+var kinds = map[string]Kind{
+	"runtime.chansend1":      KindChanSend,
+	"runtime.chanrecv1":      KindChanRecv,
+	"runtime.chanrecv2":      KindChanRecv,
+	"runtime.selectgo":       KindSelect,
+	"runtime.block":          KindSelect, // select {}
+	"sync.(*Mutex).Lock":     KindMutex,
+	"sync.(*RWMutex).Lock":   KindRWMutex,
+	"sync.(*RWMutex).RLock":  KindRWMutex,
+	"sync.(*WaitGroup).Wait": KindWaitGroup,
+	"sync.(*Cond).Wait":      KindCond,
+}
+
+type Frame struct {
+	Function string
+	File     string
+	Line     int
+}
+
+func (f Frame) String() string {
+	return fmt.Sprintf("%s:%d", f.Function, f.Line)
+}
+
+// Record 是一個 stack 的累計阻塞紀錄
+type Record struct {
+	Kind   Kind
+	Site   Frame   // 發生阻塞的程式碼位置
+	Stack  []Frame // 由內而外
+	Count  int64   // 阻塞次數
+	Cycles int64   // 累計等待的 CPU tick
+
+	key string // stack 的 PC，用於 Diff
+}
+
+// Enable 設定取樣率並回傳還原（關閉）的函式；rate 為 1 時記錄每一次阻塞
+func Enable(rate int) (disable func()) {
+	runtime.SetBlockProfileRate(rate)
+	return func() { runtime.SetBlockProfileRate(0) }
+}
+
+// Snapshot 讀出目前累計的 block profile
+func Snapshot() []Record {
+	var raw []runtime.BlockProfileRecord
+	n, ok := runtime.BlockProfile(nil)
+	for !ok {
+		// 讀取之間可能有新的 stack，多留一些空間
+		raw = make([]runtime.BlockProfileRecord, n+16)
+		n, ok = runtime.BlockProfile(raw)
+	}
+	out := make([]Record, 0, n)
+	for _, r := range raw[:n] {
+		out = append(out, newRecord(r.Count, r.Cycles, r.Stack()))
+	}
+	return out
+}
+
+func newRecord(count, cycles int64, pcs []uintptr) Record {
+	r := Record{Count: count, Cycles: cycles, Kind: KindOther, key: fmt.Sprint(pcs)}
+	frames := runtime.CallersFrames(pcs)
+	for {
+		f, more := frames.Next()
+		r.Stack = append(r.Stack, Frame{Function: f.Function, File: f.File, Line: f.Line})
+		if !more {
+			break
+		}
+	}
+	if len(r.Stack) > 0 {
+		if k, ok := kinds[r.Stack[0].Function]; ok {
+			r.Kind = k
+		}
+	}
+	for _, f := range r.Stack {
+		if !internal(f.Function) {
+			r.Site = f
+			break
+		}
+	}
+	return r
+}
+
+func internal(fn string) bool {
+	return strings.HasPrefix(fn, "runtime.") || strings.HasPrefix(fn, "sync.") || strings.HasPrefix(fn, "internal/")
+}
+
+// Diff 回傳 after 相對於 before 增加的部分，只保留有增加的紀錄
+func Diff(before, after []Record) []Record {
+	prev := make(map[string]Record, len(before))
+	for _, r := range before {
+		prev[r.key] = r
+	}
+	var out []Record
+	for _, r := range after {
+		p := prev[r.key]
+		r.Count -= p.Count
+		r.Cycles -= p.Cycles
+		if r.Count > 0 || r.Cycles > 0 {
+			out = append(out, r)
+		}
+	}
+	return out
+}
+
+// Site 是同一個位置、同一種阻塞的加總
+type Site struct {
+	Kind   Kind
+	Frame  Frame
+	Count  int64
+	Cycles int64
+	Share  float64 // 佔全部等待時間的比例
+}
+
+// Sites 依 (Kind, Site) 加總 records，依 Cycles 由大到小排序
+func Sites(records []Record) []Site {
+	type key struct {
+		kind Kind
+		fn   string
+		line int
+	}
+	idx := map[key]int{}
+	var out []Site
+	var total int64
+	for _, r := range records {
+		k := key{r.Kind, r.Site.Function, r.Site.Line}
+		i, ok := idx[k]
+		if !ok {
+			i = len(out)
+			idx[k] = i
+			out = append(out, Site{Kind: r.Kind, Frame: r.Site})
+		}
+		out[i].Count += r.Count
+		out[i].Cycles += r.Cycles
+		total += r.Cycles
+	}
+	for i := range out {
+		if total > 0 {
+			out[i].Share = float64(out[i].Cycles) / float64(total)
+		}
+	}
+	sort.SliceStable(out, func(i, j int) bool { return out[i].Cycles > out[j].Cycles })
+	return out
+}
+
+// Report 輸出前 n 個等待最久的位置，n <= 0 時全部輸出
+func Report(w io.Writer, sites []Site, n int) error {
+	if n > 0 && n < len(sites) {
+		sites = sites[:n]
+	}
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "share\tcount\tkind\tsite")
+	for _, s := range sites {
+		fmt.Fprintf(tw, "%5.1f%%\t%d\t%s\t%s\n", s.Share*100, s.Count, s.Kind, s.Frame)
+	}
+	return tw.Flush()
+}
@@ -0,0 +1,80 @@
+package blockprofile
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// profile 在 block profile 開啟的情況下執行 f，回傳 f 執行期間的阻塞位置
+func profile(t *testing.T, f func()) []Site {
+	t.Helper()
+	defer Enable(1)()
+	before := Snapshot()
+	f()
+	sites := Sites(Diff(before, Snapshot()))
+	var out bytes.Buffer
+	require.NoError(t, Report(&out, sites, 5))
+	t.Log("\n" + out.String())
+	require.NotEmpty(t, sites)
+	return sites
+}
+
+func TestChannelContention(t *testing.T) {
+	var n int
+	sites := profile(t, func() { n = ChannelContention(4, 20, 100*time.Microsecond) })
+	assert.Equal(t, 80, n)
+
+	top := sites[0]
+	assert.Equal(t, KindChanSend, top.Kind)
+	assert.True(t, strings.HasSuffix(top.Frame.Function, "blockprofile.produce"), top.Frame.String())
+	assert.Greater(t, top.Share, 0.5, "producer 等待 send 佔大部分")
+}
+
+func TestMutexContention(t *testing.T) {
+	var n int
+	sites := profile(t, func() { n = MutexContention(4, 20, 50*time.Microsecond) })
+	assert.Equal(t, 80, n)
+
+	top := sites[0]
+	assert.Equal(t, KindMutex, top.Kind)
+	assert.True(t, strings.HasSuffix(top.Frame.Function, "blockprofile.increment"), top.Frame.String())
+	assert.Greater(t, top.Share, 0.5, "worker 等待鎖佔大部分")
+}
+
+// TestDiff 沒有新的阻塞時 Diff 為空，profile 是累計的
+func TestDiff(t *testing.T) {
+	defer Enable(1)()
+	ChannelContention(2, 5, 0)
+	before := Snapshot()
+	assert.Empty(t, Diff(before, before))
+
+	ChannelContention(2, 5, 0)
+	after := Snapshot()
+	diff := Diff(before, after)
+	require.NotEmpty(t, diff)
+	for _, r := range diff {
+		assert.Positive(t, r.Count)
+	}
+}
+
+func TestSites(t *testing.T) {
+	a := Frame{Function: "p.a", Line: 1}
+	b := Frame{Function: "p.b", Line: 2}
+	sites := Sites([]Record{
+		{Kind: KindMutex, Site: a, Count: 1, Cycles: 10},
+		{Kind: KindChanSend, Site: b, Count: 2, Cycles: 60},
+		{Kind: KindMutex, Site: a, Count: 3, Cycles: 30}, // 同一位置不同 stack
+	})
+	require.Len(t, sites, 2)
+	assert.Equal(t, Site{Kind: KindChanSend, Frame: b, Count: 2, Cycles: 60, Share: 0.6}, sites[0])
+	assert.Equal(t, Site{Kind: KindMutex, Frame: a, Count: 4, Cycles: 40, Share: 0.4}, sites[1])
+
+	var out bytes.Buffer
+	require.NoError(t, Report(&out, sites, 1))
+	assert.Equal(t, "share   count  kind       site\n 60.0%  2      chan send  p.b:2\n", out.String())
+}
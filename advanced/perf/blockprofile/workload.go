@@ -0,0 +1,66 @@
+package blockprofile
+
+import (
+	"sync"
+	"time"
+)
+
+/*
+兩個刻意製造競爭的 workload，site 都是具名函式，方便在 profile 中辨認：
+  - ChannelContention：多個 producer 送進 unbuffered channel，唯一的 consumer 每筆都要處理一段時間，
+    producer 大部分的時間都卡在 produce 的 ch <- i
+  - MutexContention：多個 worker 搶同一把鎖，而且在鎖內做慢的事（模擬在鎖內做 I/O），
+    其他 worker 都卡在 increment 的 mu.Lock()
+
+常見的修正：channel 加 buffer 或增加 consumer；縮小鎖的範圍，把慢的事移到鎖外。
+*/
+
+// ChannelContention 啟動 producers 個 producer，各送出 items 筆，consumer 每筆花 work 處理
+func ChannelContention(producers, items int, work time.Duration) int {
+	ch := make(chan int)
+	var wg sync.WaitGroup
+	for p := 0; p < producers; p++ {
+		wg.Add(1)
+		go produce(ch, items, &wg)
+	}
+	go func() {
+		wg.Wait()
+		close(ch)
+	}()
+	received := 0
+	for range ch {
+		time.Sleep(work)
+		received++
+	}
+	return received
+}
+
+func produce(ch chan<- int, items int, wg *sync.WaitGroup) {
+	defer wg.Done()
+	for i := 0; i < items; i++ {
+		ch <- i
+	}
+}
+
+// MutexContention 啟動 workers 個 worker，各做 iters 次持有鎖 hold 的遞增
+func MutexContention(workers, iters int, hold time.Duration) int {
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	counter := 0
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go increment(&mu, &counter, iters, hold, &wg)
+	}
+	wg.Wait()
+	return counter
+}
+
+func increment(mu *sync.Mutex, counter *int, iters int, hold time.Duration, wg *sync.WaitGroup) {
+	defer wg.Done()
+	for i := 0; i < iters; i++ {
+		mu.Lock()
+		time.Sleep(hold) // 鎖內做慢的事，其他 worker 只能等
+		*counter++
+		mu.Unlock()
+	}
+}
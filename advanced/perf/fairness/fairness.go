@@ -0,0 +1,150 @@
+package fairness
+
+import (
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+	"unsafe"
+)
+
+/*
+鎖的公平性：一個 goroutine 反覆 Lock/Unlock 時，其他等待者多久才輪得到？
+
+sync.Mutex 有兩種模式：
+  - normal mode：Unlock 喚醒一個等待者，但被喚醒者要和新來的（通常是剛 Unlock、還在 CPU 上的那個）競爭，
+    新來的幾乎總是贏（barging）。吞吐量最高，但等待者可能一直輸
+  - starvation mode：等待者醒來後發現已經等了超過 1ms，就把鎖切換成 starvation mode，
+    之後 Unlock 直接把鎖交給佇列最前面的等待者，新來的不能搶，只能排隊；
+    佇列清空或等待者等不到 1ms 就切回 normal mode
+
+Go 沒有 goroutine 優先權，所以也沒有作業系統那種 priority inversion / priority inheritance 的問題，
+能發生的是「飢餓」：starvation mode 就是 Go 的解法，它把最壞的等待時間限制在 1ms 左右加上一次持有時間。
+
+這個套件用兩種 workload 觀察：
+  - Barging：holder 持有鎖 hold 後 Unlock 立刻再 Lock，共 rounds 次；另一個 waiter 在第一次持有期間開始等待。
+    回傳 waiter 拿到鎖之前 holder 搶了幾次，以及 holder 在持有期間是否看到 starvation 旗標
+  - Contend：多個 goroutine 持續搶同一把鎖，記錄每個 goroutine 每次的等待時間，比較分佈
+
+比較對象是 sync.Mutex 與 sync-ext/fairlock 的 FIFO 鎖：FIFO 鎖從不讓人插隊，
+等待時間穩定但每次交棒都要 context switch，總取得次數較少。典型的結果：
+  - sync.Mutex：p50 只有幾十 ns（大多是搶到的），max 卻可能到數十 ms（一直搶輸，直到被搶占或進入 starvation mode）
+  - fairlock：p50 約等於「其他人各持有一次」的時間，max 穩定在同一個量級
+*/
+
+const mutexStarving = 4 // internal/sync.mutexStarving
+
+// Starving 回傳 m 目前是否處於 starvation mode。
+// 依賴 sync.Mutex 的內部結構（第一個欄位是 int32 的 state），只用於教學觀察，不可用於正式程式
+func Starving(m *sync.Mutex) bool {
+	return atomic.LoadInt32((*int32)(unsafe.Pointer(m)))&mutexStarving != 0
+}
+
+// BargingResult 是 Barging 的結果
+type BargingResult struct {
+	Before   int  // waiter 拿到鎖之前 holder 取得鎖的次數（含 waiter 開始等待時的那一次）
+	Starving bool // holder 持有鎖時曾經看到 starvation 旗標（只有 sync.Mutex 會設定）
+}
+
+// Barging 讓 holder 連續 rounds 次持有 mu 各 hold 的時間，waiter 在第一次持有期間開始等待；
+// starving 可以為 nil，不為 nil 時 holder 每次 Unlock 前檢查一次
+func Barging(mu sync.Locker, rounds int, hold time.Duration, starving func() bool) BargingResult {
+	var (
+		res      BargingResult
+		acquired int32 // holder 已取得的次數，waiter 拿到鎖時讀取
+		before   = make(chan int, 1)
+		waiting  = make(chan struct{})
+	)
+	go func() {
+		<-waiting
+		mu.Lock()
+		before <- int(atomic.LoadInt32(&acquired))
+		mu.Unlock()
+	}()
+
+	for i := 0; i < rounds; i++ {
+		mu.Lock()
+		atomic.AddInt32(&acquired, 1)
+		if i == 0 {
+			close(waiting)
+		}
+		time.Sleep(hold)
+		if starving != nil && starving() {
+			res.Starving = true
+		}
+		mu.Unlock()
+	}
+	res.Before = <-before
+	return res
+}
+
+// Stats 是一個 goroutine 的等待時間分佈
+type Stats struct {
+	ID       int
+	Acquired int
+	P50      time.Duration
+	P99      time.Duration
+	Max      time.Duration
+}
+
+// Contend 讓 goroutines 個 goroutine 在 d 期間持續搶 mu，每次持有時忙碌 hold，回傳每個 goroutine 的統計
+func Contend(mu sync.Locker, goroutines int, d, hold time.Duration) []Stats {
+	var stop int32
+	waits := make([][]time.Duration, goroutines)
+	var wg sync.WaitGroup
+	for g := 0; g < goroutines; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for atomic.LoadInt32(&stop) == 0 {
+				start := time.Now()
+				mu.Lock()
+				waits[g] = append(waits[g], time.Since(start))
+				busy(hold)
+				mu.Unlock()
+			}
+		}(g)
+	}
+	time.Sleep(d)
+	atomic.StoreInt32(&stop, 1)
+	wg.Wait()
+
+	out := make([]Stats, goroutines)
+	for g, w := range waits {
+		out[g] = stats(g, w)
+	}
+	return out
+}
+
+// busy 佔用 CPU 一段時間，不會讓出（與 sleep 不同）
+func busy(d time.Duration) {
+	for start := time.Now(); time.Since(start) < d; {
+	}
+}
+
+func stats(id int, waits []time.Duration) Stats {
+	s := Stats{ID: id, Acquired: len(waits)}
+	if len(waits) == 0 {
+		return s
+	}
+	sorted := append([]time.Duration(nil), waits...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	s.P50 = sorted[len(sorted)/2]
+	s.P99 = sorted[len(sorted)*99/100]
+	s.Max = sorted[len(sorted)-1]
+	return s
+}
+
+// Jain 回傳各 goroutine 取得次數的 Jain 公平指數：1 為完全平均，1/n 為全部集中在一個 goroutine
+func Jain(stats []Stats) float64 {
+	var sum, sq float64
+	for _, s := range stats {
+		x := float64(s.Acquired)
+		sum += x
+		sq += x * x
+	}
+	if sq == 0 {
+		return 1
+	}
+	return sum * sum / (float64(len(stats)) * sq)
+}
@@ -0,0 +1,79 @@
+package fairness
+
+import (
+	"bytes"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"advanced/sync-ext/fairlock"
+)
+
+// hold 遠大於排程延遲，也大於 starvation 的門檻 1ms
+const hold = 3 * time.Millisecond
+
+// TestBargingMutex holder 第一次 Unlock 後立刻搶回鎖；waiter 醒來發現已等超過 1ms，
+// 切換成 starvation mode，下一次 Unlock 就直接交棒，所以 holder 最多搶到第 2 次
+func TestBargingMutex(t *testing.T) {
+	var mu sync.Mutex
+	res := Barging(&mu, 10, hold, func() bool { return Starving(&mu) })
+	t.Logf("sync.Mutex: holder acquired %d times before waiter, starving=%v", res.Before, res.Starving)
+	assert.LessOrEqual(t, res.Before, 2)
+	assert.True(t, res.Starving || res.Before == 1, "waiter 若沒有在第一次交棒拿到鎖，必定經過 starvation mode")
+	assert.False(t, Starving(&mu), "佇列清空後回到 normal mode")
+}
+
+// TestBargingFair FIFO 鎖在第一次 Unlock 就交給 waiter
+func TestBargingFair(t *testing.T) {
+	var mu fairlock.Mutex
+	res := Barging(&mu, 10, hold, nil)
+	assert.Equal(t, 1, res.Before)
+}
+
+func TestStarving(t *testing.T) {
+	var mu sync.Mutex
+	assert.False(t, Starving(&mu))
+	mu.Lock()
+	assert.False(t, Starving(&mu), "只有持有者時不會 starving")
+	mu.Unlock()
+}
+
+func TestContend(t *testing.T) {
+	if testing.Short() {
+		t.Skip("每種鎖量測 200ms")
+	}
+	var out bytes.Buffer
+	var std sync.Mutex
+	stdStats := Contend(&std, 4, 200*time.Millisecond, 20*time.Microsecond)
+	require.NoError(t, Report(&out, "sync.Mutex", stdStats))
+
+	var fair fairlock.Mutex
+	fairStats := Contend(&fair, 4, 200*time.Millisecond, 20*time.Microsecond)
+	require.NoError(t, Report(&out, "fairlock.Mutex", fairStats))
+	t.Log("\n" + out.String())
+
+	// FIFO 鎖依序輪流，每個 goroutine 取得的次數幾乎相同；
+	// 只有最先執行的 goroutine 在其他人開始排隊之前（單核心時是一個 time slice）會多拿一些
+	assert.Greater(t, Jain(fairStats), 0.9)
+	for _, s := range fairStats {
+		assert.Positive(t, s.Acquired)
+	}
+}
+
+func TestJain(t *testing.T) {
+	assert.Equal(t, 1.0, Jain([]Stats{{Acquired: 5}, {Acquired: 5}}))
+	assert.Equal(t, 0.5, Jain([]Stats{{Acquired: 10}, {Acquired: 0}}))
+	assert.Equal(t, 1.0, Jain(nil))
+}
+
+func TestStats(t *testing.T) {
+	var waits []time.Duration
+	for i := 100; i >= 1; i-- {
+		waits = append(waits, time.Duration(i))
+	}
+	assert.Equal(t, Stats{ID: 2, Acquired: 100, P50: 51, P99: 100, Max: 100}, stats(2, waits))
+	assert.Equal(t, Stats{ID: 1}, stats(1, nil))
+}
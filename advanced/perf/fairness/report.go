@@ -0,0 +1,24 @@
+package fairness
+
+import (
+	"fmt"
+	"io"
+	"text/tabwriter"
+)
+
+// Report 輸出每個 goroutine 的取得次數與等待時間分佈，最後一行是 Jain 公平指數
+func Report(w io.Writer, name string, stats []Stats) error {
+	fmt.Fprintf(w, "%s\n", name)
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(tw, "goroutine\tacquired\tp50\tp99\tmax\t")
+	total := 0
+	for _, s := range stats {
+		total += s.Acquired
+		fmt.Fprintf(tw, "%d\t%d\t%v\t%v\t%v\t\n", s.ID, s.Acquired, s.P50, s.P99, s.Max)
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	_, err := fmt.Fprintf(w, "total %d, fairness %.3f\n", total, Jain(stats))
+	return err
+}
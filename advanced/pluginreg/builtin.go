@@ -0,0 +1,107 @@
+package pluginreg
+
+import (
+	"encoding/json"
+	"errors"
+	"math/rand"
+	"sync"
+)
+
+// 三種可擴充的元件：codec、cache 淘汰策略、負載平衡策略
+
+type Codec interface {
+	Marshal(v interface{}) ([]byte, error)
+	Unmarshal(data []byte, v interface{}) error
+}
+
+// EvictionPolicy 只負責決定淘汰誰，資料本身由 cache 保存
+type EvictionPolicy interface {
+	Add(key string)
+	Access(key string)
+	Remove(key string)
+	Victim() (string, bool)
+}
+
+type Balancer interface {
+	Pick(backends []string) (string, error)
+}
+
+var (
+	Codecs           = NewRegistry[Codec]("codec")
+	EvictionPolicies = NewRegistry[EvictionPolicy]("eviction policy")
+	Balancers        = NewRegistry[Balancer]("balancer")
+)
+
+var ErrNoBackend = errors.New("pluginreg: no backend available")
+
+func init() {
+	Codecs.Register("json", func() Codec { return jsonCodec{} })
+	EvictionPolicies.Register("fifo", func() EvictionPolicy { return &fifo{} })
+	EvictionPolicies.Register("lru", func() EvictionPolicy { return &lru{} })
+	Balancers.Register("round_robin", func() Balancer { return &roundRobin{} })
+	Balancers.Register("random", func() Balancer { return random{} })
+}
+
+type jsonCodec struct{}
+
+func (jsonCodec) Marshal(v interface{}) ([]byte, error)      { return json.Marshal(v) }
+func (jsonCodec) Unmarshal(data []byte, v interface{}) error { return json.Unmarshal(data, v) }
+
+// fifo 依加入順序淘汰，Access 不影響順序
+type fifo struct {
+	keys []string
+}
+
+func (f *fifo) Add(key string)    { f.keys = append(f.keys, key) }
+func (f *fifo) Access(key string) {}
+
+func (f *fifo) Remove(key string) {
+	for i, k := range f.keys {
+		if k == key {
+			f.keys = append(f.keys[:i], f.keys[i+1:]...)
+			return
+		}
+	}
+}
+
+func (f *fifo) Victim() (string, bool) {
+	if len(f.keys) == 0 {
+		return "", false
+	}
+	return f.keys[0], true
+}
+
+// lru 每次 Access 都把 key 移到最後，淘汰最前面的（教學用，O(n) 實作）
+type lru struct {
+	fifo
+}
+
+func (l *lru) Access(key string) {
+	l.Remove(key)
+	l.Add(key)
+}
+
+type roundRobin struct {
+	mu   sync.Mutex
+	next int
+}
+
+func (r *roundRobin) Pick(backends []string) (string, error) {
+	if len(backends) == 0 {
+		return "", ErrNoBackend
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	b := backends[r.next%len(backends)]
+	r.next++
+	return b, nil
+}
+
+type random struct{}
+
+func (random) Pick(backends []string) (string, error) {
+	if len(backends) == 0 {
+		return "", ErrNoBackend
+	}
+	return backends[rand.Intn(len(backends))], nil
+}
@@ -0,0 +1,28 @@
+// Package gobcodec 示範在核心 package 之外擴充 codec，
+// 只要 import _ "advanced/pluginreg/ext/gobcodec" 就能使用 "gob"
+package gobcodec
+
+import (
+	"bytes"
+	"encoding/gob"
+
+	"advanced/pluginreg"
+)
+
+func init() {
+	pluginreg.Codecs.Register("gob", func() pluginreg.Codec { return codec{} })
+}
+
+type codec struct{}
+
+func (codec) Marshal(v interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(v); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (codec) Unmarshal(data []byte, v interface{}) error {
+	return gob.NewDecoder(bytes.NewReader(data)).Decode(v)
+}
@@ -0,0 +1,29 @@
+package pluginreg_test
+
+import (
+	"testing"
+
+	"advanced/pluginreg"
+	_ "advanced/pluginreg/ext/gobcodec"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type payload struct {
+	Name string
+	Tags []string
+}
+
+// 只靠 import 就多了一個 "gob" codec，pluginreg 本身完全沒有修改
+func TestExtensionWithoutModifyingCore(t *testing.T) {
+	assert.Equal(t, []string{"gob", "json"}, pluginreg.Codecs.Names())
+
+	c, err := pluginreg.Codecs.New("gob")
+	assert.NoError(t, err)
+	in := payload{Name: "tom", Tags: []string{"a", "b"}}
+	b, err := c.Marshal(in)
+	assert.NoError(t, err)
+	var out payload
+	assert.NoError(t, c.Unmarshal(b, &out))
+	assert.Equal(t, in, out)
+}
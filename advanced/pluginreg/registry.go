@@ -0,0 +1,87 @@
+package pluginreg
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+)
+
+/*
+自註冊(self-registration) 模式，跟 database/sql 的 driver 一樣：
+	import _ "github.com/go-sql-driver/mysql"
+driver 在自己的 init() 裡呼叫 sql.Register，核心 package 完全不需要知道有哪些實作，
+要加新的實作只要多 import 一個 package 就好，不用修改核心程式碼。
+*/
+
+var (
+	ErrDuplicate = errors.New("pluginreg: duplicate name")
+	ErrNotFound  = errors.New("pluginreg: not found")
+)
+
+type Factory[T any] func() T
+
+type Registry[T any] struct {
+	kind      string
+	mu        sync.RWMutex
+	factories map[string]Factory[T]
+}
+
+func NewRegistry[T any](kind string) *Registry[T] {
+	return &Registry[T]{
+		kind:      kind,
+		factories: make(map[string]Factory[T]),
+	}
+}
+
+// TryRegister 註冊 factory，名稱重複時回傳 ErrDuplicate
+func (r *Registry[T]) TryRegister(name string, f Factory[T]) error {
+	if f == nil {
+		return fmt.Errorf("pluginreg: %s %q: nil factory", r.kind, name)
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.factories[name]; ok {
+		return fmt.Errorf("%w: %s %q", ErrDuplicate, r.kind, name)
+	}
+	r.factories[name] = f
+	return nil
+}
+
+// Register 給 init() 使用，衝突時直接 panic，讓問題在程式啟動時就被發現
+func (r *Registry[T]) Register(name string, f Factory[T]) {
+	if err := r.TryRegister(name, f); err != nil {
+		panic(err)
+	}
+}
+
+// New 以名稱建立一個新的實例
+func (r *Registry[T]) New(name string) (T, error) {
+	r.mu.RLock()
+	f, ok := r.factories[name]
+	r.mu.RUnlock()
+	if !ok {
+		var zero T
+		return zero, fmt.Errorf("%w: %s %q", ErrNotFound, r.kind, name)
+	}
+	return f(), nil
+}
+
+// Names 回傳排序後的所有已註冊名稱
+func (r *Registry[T]) Names() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	names := make([]string, 0, len(r.factories))
+	for name := range r.factories {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// unregister 只給測試用
+func (r *Registry[T]) unregister(name string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.factories, name)
+}
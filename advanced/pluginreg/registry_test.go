@@ -0,0 +1,80 @@
+package pluginreg
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRegisterConflict(t *testing.T) {
+	r := NewRegistry[Balancer]("balancer")
+	assert.NoError(t, r.TryRegister("rr", func() Balancer { return &roundRobin{} }))
+	assert.ErrorIs(t, r.TryRegister("rr", func() Balancer { return &roundRobin{} }), ErrDuplicate)
+	assert.Error(t, r.TryRegister("nil", nil))
+	assert.Panics(t, func() {
+		r.Register("rr", func() Balancer { return random{} })
+	})
+	assert.Equal(t, []string{"rr"}, r.Names())
+
+	r.unregister("rr")
+	assert.Empty(t, r.Names())
+}
+
+func TestNewReturnsFreshInstance(t *testing.T) {
+	a, err := Balancers.New("round_robin")
+	assert.NoError(t, err)
+	b, _ := Balancers.New("round_robin")
+	backends := []string{"a", "b"}
+	// 各自的狀態互不影響
+	pa, _ := a.Pick(backends)
+	pb, _ := b.Pick(backends)
+	assert.Equal(t, "a", pa)
+	assert.Equal(t, "a", pb)
+	pa, _ = a.Pick(backends)
+	assert.Equal(t, "b", pa)
+
+	_, err = Balancers.New("weighted")
+	assert.ErrorIs(t, err, ErrNotFound)
+}
+
+func TestBalancers(t *testing.T) {
+	for _, name := range Balancers.Names() {
+		b, _ := Balancers.New(name)
+		_, err := b.Pick(nil)
+		assert.ErrorIs(t, err, ErrNoBackend, name)
+		got, err := b.Pick([]string{"only"})
+		assert.NoError(t, err)
+		assert.Equal(t, "only", got)
+	}
+}
+
+func TestEvictionPolicies(t *testing.T) {
+	fifo, _ := EvictionPolicies.New("fifo")
+	lru, _ := EvictionPolicies.New("lru")
+	for _, p := range []EvictionPolicy{fifo, lru} {
+		p.Add("a")
+		p.Add("b")
+		p.Add("c")
+		p.Access("a")
+	}
+	v, _ := fifo.Victim()
+	assert.Equal(t, "a", v)
+	v, _ = lru.Victim()
+	assert.Equal(t, "b", v)
+
+	lru.Remove("b")
+	lru.Remove("c")
+	lru.Remove("a")
+	_, ok := lru.Victim()
+	assert.False(t, ok)
+}
+
+func TestJSONCodec(t *testing.T) {
+	c, err := Codecs.New("json")
+	assert.NoError(t, err)
+	b, err := c.Marshal(map[string]int{"a": 1})
+	assert.NoError(t, err)
+	var m map[string]int
+	assert.NoError(t, c.Unmarshal(b, &m))
+	assert.Equal(t, 1, m["a"])
+}
@@ -0,0 +1,140 @@
+package progress
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"advanced/timex"
+)
+
+// Aggregate 保留每個子任務最新的 Update，Total 回傳加總後的整體進度
+type Aggregate struct {
+	tasks map[string]Update
+	order []string
+}
+
+func NewAggregate() *Aggregate {
+	return &Aggregate{tasks: make(map[string]Update)}
+}
+
+// Apply 更新子任務的狀態；已經 Finished 的任務不會被較舊的 Update 蓋掉
+func (a *Aggregate) Apply(u Update) {
+	old, ok := a.tasks[u.Task]
+	if !ok {
+		a.order = append(a.order, u.Task)
+	}
+	if ok && old.Finished {
+		return
+	}
+	a.tasks[u.Task] = u
+}
+
+// Tasks 依第一次出現的順序回傳每個子任務的狀態
+func (a *Aggregate) Tasks() []Update {
+	out := make([]Update, len(a.order))
+	for i, name := range a.order {
+		out[i] = a.tasks[name]
+	}
+	return out
+}
+
+// Total 加總所有子任務：任一子任務 Total 未知則整體未知；Elapsed 取最長的；全部完成才算完成
+func (a *Aggregate) Total() Update {
+	t := Update{Task: "total", Finished: len(a.tasks) > 0}
+	var errs []error
+	for _, name := range a.order {
+		u := a.tasks[name]
+		t.Done += u.Done
+		if t.Total >= 0 {
+			if u.Total < 0 {
+				t.Total = -1
+			} else {
+				t.Total += u.Total
+			}
+		}
+		if u.Elapsed > t.Elapsed {
+			t.Elapsed = u.Elapsed
+		}
+		t.Finished = t.Finished && u.Finished
+		if u.Err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", u.Task, u.Err))
+		}
+	}
+	t.Err = errors.Join(errs...)
+	return t
+}
+
+// Renderer 以一行進度條顯示整體進度，用 \r 覆寫同一行
+type Renderer struct {
+	w        io.Writer
+	interval time.Duration
+	width    int
+	clock    timex.Clock
+	last     time.Time
+}
+
+func NewRenderer(w io.Writer, interval time.Duration, opts ...Option) *Renderer {
+	return &Renderer{w: w, interval: interval, width: 30, clock: newConfig(opts).clock}
+}
+
+// Run 讀取 updates 直到 channel 關閉，回傳最後的整體狀態
+func (r *Renderer) Run(updates <-chan Update) Update {
+	agg := NewAggregate()
+	for u := range updates {
+		agg.Apply(u)
+		t := r.clock.Now()
+		if r.last.IsZero() || t.Sub(r.last) >= r.interval {
+			r.last = t
+			fmt.Fprint(r.w, "\r"+Format(agg.Total(), r.width))
+		}
+	}
+	total := agg.Total()
+	fmt.Fprintln(r.w, "\r"+Format(total, r.width))
+	return total
+}
+
+// Format 把 Update 轉成一行文字：[#######       ]  50.0%  512B/1.0KiB  1.0KiB/s  ETA 1s
+func Format(u Update, width int) string {
+	var b strings.Builder
+	pct := u.Percent()
+	b.WriteByte('[')
+	if pct >= 0 {
+		fill := int(pct / 100 * float64(width))
+		if fill > width {
+			fill = width
+		}
+		b.WriteString(strings.Repeat("#", fill))
+		b.WriteString(strings.Repeat(" ", width-fill))
+		fmt.Fprintf(&b, "] %5.1f%%  %s/%s", pct, Bytes(u.Done), Bytes(u.Total))
+	} else {
+		b.WriteString(strings.Repeat("?", width))
+		fmt.Fprintf(&b, "]    ?%%  %s", Bytes(u.Done))
+	}
+	fmt.Fprintf(&b, "  %s/s", Bytes(int64(u.Rate())))
+	switch eta := u.ETA(); {
+	case u.Err != nil:
+		b.WriteString("  failed")
+	case u.Finished:
+		b.WriteString("  done")
+	case eta >= 0:
+		fmt.Fprintf(&b, "  ETA %s", eta.Round(time.Second))
+	}
+	return b.String()
+}
+
+// Bytes 以 1024 為單位格式化
+func Bytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%dB", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f%ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
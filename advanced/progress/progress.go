@@ -0,0 +1,163 @@
+package progress
+
+import (
+	"io"
+	"sync"
+	"time"
+
+	"advanced/iox"
+	"advanced/timex"
+)
+
+/*
+長時間任務的進度回報。
+
+	updates := make(chan progress.Update, 64)
+	rep := progress.NewReporter(updates, "download", size)
+	io.Copy(dst, progress.NewReader(resp.Body, rep))
+
+	go progress.NewRenderer(os.Stderr, 100*time.Millisecond).Run(updates)
+
+  - Reporter 由任務本身持有，每次 Add 都送出「累計」的狀態，所以 channel 滿的時候直接略過這次也沒關係，
+    下一次的 Update 會帶著最新的值；只有 Finish 一定會送出（會等待），確保最終狀態不會遺失
+  - 多個並行的子任務共用同一個 channel，Aggregate 依 Task 名稱保留各自最新的狀態並加總
+  - Renderer 把 Aggregate 畫成一行進度條，最多每 interval 更新一次，避免大量的小 Update 把終端機刷爆
+
+單一 reader 的百分比、速率與讀取計數沿用 iox.Progress / iox.ProgressReader，
+這裡加上的是「多個任務經由 channel 回報、彙總、顯示」。
+目前的使用者是 api/upload 與 apps/importer；repo 中還沒有 downloader 或 external sort，
+progress_test.go 的 Range 並行下載示範了接法。
+*/
+
+type config struct {
+	clock timex.Clock
+}
+
+type Option func(*config)
+
+// WithClock 設定計算 Elapsed 與節流用的時鐘，預設 timex.Real；測試時用 timex.Fake
+func WithClock(c timex.Clock) Option {
+	return func(cfg *config) { cfg.clock = c }
+}
+
+func newConfig(opts []Option) config {
+	cfg := config{clock: timex.Real{}}
+	for _, o := range opts {
+		o(&cfg)
+	}
+	return cfg
+}
+
+type Update struct {
+	Task     string
+	Done     int64
+	Total    int64 // 未知時為 -1
+	Elapsed  time.Duration
+	Finished bool
+	Err      error
+}
+
+// Progress 轉成 iox.Progress（Bytes 為完成的量，單位不一定是 byte）
+func (u Update) Progress() iox.Progress {
+	p := iox.Progress{Bytes: u.Done, Total: u.Total, Elapsed: u.Elapsed, Done: u.Finished}
+	if u.Elapsed > 0 {
+		p.Rate = float64(u.Done) / u.Elapsed.Seconds()
+	}
+	return p
+}
+
+// Percent 回傳完成百分比，Total 未知時回傳 -1
+func (u Update) Percent() float64 { return u.Progress().Percent() }
+
+// Rate 回傳每秒完成的量
+func (u Update) Rate() float64 { return u.Progress().Rate }
+
+// ETA 以目前的平均速率估計剩餘時間，無法估計時回傳 -1
+func (u Update) ETA() time.Duration {
+	if u.Finished {
+		return 0
+	}
+	rate := u.Rate()
+	if u.Total <= 0 || rate == 0 {
+		return -1
+	}
+	return time.Duration(float64(u.Total-u.Done) / rate * float64(time.Second))
+}
+
+type Reporter struct {
+	ch    chan<- Update
+	task  string
+	total int64
+	clock timex.Clock
+
+	mu       sync.Mutex
+	start    time.Time
+	done     int64
+	finished bool
+}
+
+func NewReporter(ch chan<- Update, task string, total int64, opts ...Option) *Reporter {
+	cfg := newConfig(opts)
+	return &Reporter{ch: ch, task: task, total: total, clock: cfg.clock, start: cfg.clock.Now()}
+}
+
+func (r *Reporter) snapshot() Update {
+	return Update{Task: r.task, Done: r.done, Total: r.total, Elapsed: r.clock.Now().Sub(r.start), Finished: r.finished}
+}
+
+// Add 增加完成的量並嘗試送出 Update；channel 滿時略過
+func (r *Reporter) Add(n int64) {
+	r.mu.Lock()
+	if r.finished {
+		r.mu.Unlock()
+		return
+	}
+	r.done += n
+	u := r.snapshot()
+	r.mu.Unlock()
+	select {
+	case r.ch <- u:
+	default:
+	}
+}
+
+// Finish 送出最終狀態，會等到 channel 有空位；重複呼叫只有第一次有效
+func (r *Reporter) Finish(err error) {
+	r.mu.Lock()
+	if r.finished {
+		r.mu.Unlock()
+		return
+	}
+	r.finished = true
+	u := r.snapshot()
+	u.Err = err
+	r.mu.Unlock()
+	r.ch <- u
+}
+
+type reader struct {
+	r   *iox.ProgressReader
+	rep *Reporter
+}
+
+// NewReader 每次讀取都回報讀到的 bytes，讀到 EOF 或發生錯誤時呼叫 Finish。
+// 計數由 iox.ProgressReader 負責（interval 為 0，每次讀取都回呼），節流交給 channel 與 Renderer
+func NewReader(r io.Reader, rep *Reporter) io.Reader {
+	var reported int64
+	pr := iox.NewProgressReader(r, nil, rep.total, 0, func(p iox.Progress) {
+		rep.Add(p.Bytes - reported)
+		reported = p.Bytes
+	})
+	return &reader{r: pr, rep: rep}
+}
+
+func (p *reader) Read(b []byte) (int, error) {
+	n, err := p.r.Read(b)
+	switch {
+	case err == io.EOF:
+		p.rep.Finish(nil)
+	case err != nil:
+		p.rep.Finish(err)
+	}
+	return n, err
+}
@@ -0,0 +1,197 @@
+package progress
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"testing/iotest"
+	"time"
+
+	"advanced/timex"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func fakeClock() *timex.Fake {
+	return timex.NewFake(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+}
+
+func TestUpdateMath(t *testing.T) {
+	u := Update{Done: 25, Total: 100, Elapsed: 5 * time.Second}
+	assert.Equal(t, 25.0, u.Percent())
+	assert.Equal(t, 5.0, u.Rate())
+	assert.Equal(t, 15*time.Second, u.ETA())
+
+	unknown := Update{Done: 25, Total: -1, Elapsed: time.Second}
+	assert.Equal(t, -1.0, unknown.Percent())
+	assert.Equal(t, time.Duration(-1), unknown.ETA())
+	assert.Equal(t, time.Duration(0), Update{Finished: true}.ETA())
+}
+
+func TestReader(t *testing.T) {
+	clock := fakeClock()
+	ch := make(chan Update)
+	collected := make(chan []Update)
+	go func() {
+		var all []Update
+		for u := range ch {
+			all = append(all, u)
+		}
+		collected <- all
+	}()
+
+	rep := NewReporter(ch, "file", 1000, WithClock(clock))
+	r := NewReader(iotest.OneByteReader(strings.NewReader(strings.Repeat("x", 1000))), rep)
+	buf := make([]byte, 1)
+	for i := 0; i < 500; i++ {
+		_, _ = r.Read(buf)
+	}
+	clock.Advance(time.Second)
+	_, err := io.Copy(io.Discard, r)
+	require.NoError(t, err)
+	rep.Add(10) // Finish 之後的 Add 會被忽略
+	close(ch)
+
+	all := <-collected
+	require.NotEmpty(t, all)
+	last := all[len(all)-1]
+	assert.True(t, last.Finished)
+	assert.EqualValues(t, 1000, last.Done)
+	assert.Equal(t, time.Second, last.Elapsed)
+}
+
+// channel 滿的時候 Add 直接略過，不會卡住任務
+func TestAddSkipsWhenFull(t *testing.T) {
+	ch := make(chan Update, 1)
+	rep := NewReporter(ch, "x", 10)
+	rep.Add(1)
+	rep.Add(1)
+	rep.Add(1)
+	assert.Len(t, ch, 1)
+	assert.EqualValues(t, 1, (<-ch).Done)
+
+	rep.Add(1)
+	assert.EqualValues(t, 4, (<-ch).Done, "next update carries the cumulative total")
+}
+
+func TestReaderError(t *testing.T) {
+	ch := make(chan Update, 10)
+	boom := errors.New("boom")
+	r := NewReader(iotest.ErrReader(boom), NewReporter(ch, "x", 10))
+	_, err := io.ReadAll(r)
+	assert.ErrorIs(t, err, boom)
+	u := <-ch
+	assert.True(t, u.Finished)
+	assert.ErrorIs(t, u.Err, boom)
+}
+
+func TestAggregate(t *testing.T) {
+	a := NewAggregate()
+	a.Apply(Update{Task: "a", Done: 10, Total: 100, Elapsed: time.Second})
+	a.Apply(Update{Task: "b", Done: 30, Total: 100, Elapsed: 2 * time.Second})
+	a.Apply(Update{Task: "a", Done: 100, Total: 100, Elapsed: 3 * time.Second, Finished: true})
+	a.Apply(Update{Task: "a", Done: 50, Total: 100}) // 較舊的 Update 晚到，不會蓋掉完成的狀態
+
+	total := a.Total()
+	assert.EqualValues(t, 130, total.Done)
+	assert.EqualValues(t, 200, total.Total)
+	assert.Equal(t, 3*time.Second, total.Elapsed)
+	assert.False(t, total.Finished)
+
+	a.Apply(Update{Task: "c", Done: 5, Total: -1, Finished: true, Err: errors.New("disk full")})
+	a.Apply(Update{Task: "b", Done: 100, Total: 100, Finished: true})
+	total = a.Total()
+	assert.EqualValues(t, -1, total.Total)
+	assert.True(t, total.Finished)
+	assert.EqualError(t, total.Err, "c: disk full")
+	assert.Len(t, a.Tasks(), 3)
+}
+
+func TestFormat(t *testing.T) {
+	assert.Equal(t, "[#####     ]  50.0%  512B/1.0KiB  256B/s  ETA 2s",
+		Format(Update{Done: 512, Total: 1024, Elapsed: 2 * time.Second}, 10))
+	assert.Equal(t, "[??????????]    ?%  3.0MiB  3.0MiB/s",
+		Format(Update{Done: 3 << 20, Total: -1, Elapsed: time.Second}, 10))
+	assert.Equal(t, "[##########] 100.0%  1.0KiB/1.0KiB  1.0KiB/s  done",
+		Format(Update{Done: 1024, Total: 1024, Elapsed: time.Second, Finished: true}, 10))
+}
+
+func TestRendererThrottles(t *testing.T) {
+	clock := fakeClock()
+	var out bytes.Buffer
+	ch := make(chan Update)
+	done := make(chan Update)
+	go func() { done <- NewRenderer(&out, 100*time.Millisecond, WithClock(clock)).Run(ch) }()
+
+	for i := 1; i <= 50; i++ {
+		ch <- Update{Task: "t", Done: int64(i), Total: 100}
+		if i == 30 {
+			clock.Advance(100 * time.Millisecond)
+		}
+	}
+	close(ch)
+	final := <-done
+
+	// 第一筆、時間前進後的第 30 筆、結束時各畫一次
+	assert.Equal(t, 3, strings.Count(out.String(), "\r"))
+	assert.EqualValues(t, 50, final.Done)
+	assert.True(t, strings.HasSuffix(out.String(), "\n"))
+}
+
+// downloader 範例：以 4 個 Range request 並行下載，各自回報進度，Renderer 顯示整體進度
+func TestParallelDownloadExample(t *testing.T) {
+	body := strings.Repeat("0123456789", 10000)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.ServeContent(w, r, "data.bin", time.Time{}, strings.NewReader(body))
+	}))
+	defer srv.Close()
+
+	const parts = 4
+	size := len(body)
+	chunk := size / parts
+	updates := make(chan Update, 16)
+	var out bytes.Buffer
+	rendered := make(chan Update)
+	go func() { rendered <- NewRenderer(&out, 10*time.Millisecond).Run(updates) }()
+
+	result := make([][]byte, parts)
+	var wg sync.WaitGroup
+	for i := 0; i < parts; i++ {
+		start, end := i*chunk, (i+1)*chunk-1
+		if i == parts-1 {
+			end = size - 1
+		}
+		wg.Add(1)
+		go func(i, start, end int) {
+			defer wg.Done()
+			req, _ := http.NewRequest(http.MethodGet, srv.URL, nil)
+			req.Header.Set("Range", fmt.Sprintf("bytes=%d-%d", start, end))
+			rep := NewReporter(updates, fmt.Sprintf("part-%d", i), int64(end-start+1))
+			resp, err := http.DefaultClient.Do(req)
+			if err != nil {
+				rep.Finish(err)
+				return
+			}
+			defer resp.Body.Close()
+			result[i], err = io.ReadAll(NewReader(resp.Body, rep))
+			assert.NoError(t, err)
+		}(i, start, end)
+	}
+	wg.Wait()
+	close(updates)
+	final := <-rendered
+
+	assert.Equal(t, body, string(bytes.Join(result, nil)))
+	assert.NoError(t, final.Err)
+	assert.True(t, final.Finished)
+	assert.EqualValues(t, size, final.Done)
+	assert.EqualValues(t, size, final.Total)
+	assert.Contains(t, out.String(), "100.0%")
+}
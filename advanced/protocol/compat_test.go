@@ -0,0 +1,63 @@
+package protocol
+
+import (
+	"testing"
+
+	compatrenumbered "advanced/protocol/internal/compat/renumbered"
+	compatv1 "advanced/protocol/internal/compat/v1"
+	compatv2 "advanced/protocol/internal/compat/v2"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/protobuf/proto"
+)
+
+/*
+模擬不同版本的服務互相傳遞資料：
+  - 新增欄位（v1 <-> v2）：雙向相容，舊程式會保留不認得的欄位
+  - 修改編號（v1 -> renumbered）：解碼不會報錯，但資料默默放錯欄位
+*/
+
+func TestNewReaderOldWriter(t *testing.T) {
+	b, err := proto.Marshal(&compatv1.Job{Id: "j1", Queue: "q", Payload: []byte("p"), Attempts: 1})
+	assert.NoError(t, err)
+
+	var v2 compatv2.Job
+	assert.NoError(t, proto.Unmarshal(b, &v2))
+	assert.Equal(t, "j1", v2.Id)
+	assert.Equal(t, uint32(1), v2.Attempts)
+	// 新欄位為零值
+	assert.Zero(t, v2.MaxAttempts)
+	assert.Empty(t, v2.Owner)
+}
+
+func TestOldReaderNewWriter(t *testing.T) {
+	b, err := proto.Marshal(&compatv2.Job{Id: "j1", Queue: "q", Attempts: 1, MaxAttempts: 5, Owner: "alice"})
+	assert.NoError(t, err)
+
+	var v1 compatv1.Job
+	assert.NoError(t, proto.Unmarshal(b, &v1))
+	assert.Equal(t, "j1", v1.Id)
+	assert.NotEmpty(t, v1.ProtoReflect().GetUnknown())
+
+	// 舊程式修改後再送出，新欄位仍然保留（例如經過舊版的中介服務轉送）
+	v1.Attempts++
+	b, err = proto.Marshal(&v1)
+	assert.NoError(t, err)
+	var v2 compatv2.Job
+	assert.NoError(t, proto.Unmarshal(b, &v2))
+	assert.Equal(t, uint32(2), v2.Attempts)
+	assert.Equal(t, uint32(5), v2.MaxAttempts)
+	assert.Equal(t, "alice", v2.Owner)
+}
+
+func TestRenumberingBreaksSilently(t *testing.T) {
+	b, err := proto.Marshal(&compatv1.Job{Id: "j1", Queue: "email", Payload: []byte("data"), Attempts: 3})
+	assert.NoError(t, err)
+
+	var r compatrenumbered.Job
+	assert.NoError(t, proto.Unmarshal(b, &r)) // 沒有錯誤
+	assert.Equal(t, "data", r.Queue)          // payload 被當成 queue
+	assert.Equal(t, []byte("email"), r.Payload)
+	assert.Zero(t, r.Attempts) // 編號 4 變成 unknown field
+	assert.NotEmpty(t, r.ProtoReflect().GetUnknown())
+}
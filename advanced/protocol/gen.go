@@ -0,0 +1,9 @@
+// Package protocol 定義 job queue 與 pubsub 的 domain struct，以及與 protobuf 型別之間的轉換。
+//
+// 產生的 *.pb.go 已經 commit，修改 .proto 後需重新產生（需要 protoc 與 protoc-gen-go）：
+//
+//	go install google.golang.org/protobuf/cmd/protoc-gen-go@v1.34.2
+//	go generate ./protocol
+package protocol
+
+//go:generate protoc -I . --go_out=. --go_opt=paths=source_relative jobqueuepb/job.proto pubsubpb/message.proto internal/compat/v1/job.proto internal/compat/v2/job.proto internal/compat/renumbered/job.proto
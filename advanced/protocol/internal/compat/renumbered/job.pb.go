@@ -0,0 +1,176 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.34.2
+// 	protoc        (unknown)
+// source: internal/compat/renumbered/job.proto
+
+package compatrenumbered
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// 錯誤示範：把 queue 與 payload 的編號對調、attempts 改成 5
+type Job struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Id       string `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Queue    string `protobuf:"bytes,3,opt,name=queue,proto3" json:"queue,omitempty"`
+	Payload  []byte `protobuf:"bytes,2,opt,name=payload,proto3" json:"payload,omitempty"`
+	Attempts uint32 `protobuf:"varint,5,opt,name=attempts,proto3" json:"attempts,omitempty"`
+}
+
+func (x *Job) Reset() {
+	*x = Job{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_internal_compat_renumbered_job_proto_msgTypes[0]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Job) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Job) ProtoMessage() {}
+
+func (x *Job) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_compat_renumbered_job_proto_msgTypes[0]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Job.ProtoReflect.Descriptor instead.
+func (*Job) Descriptor() ([]byte, []int) {
+	return file_internal_compat_renumbered_job_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *Job) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *Job) GetQueue() string {
+	if x != nil {
+		return x.Queue
+	}
+	return ""
+}
+
+func (x *Job) GetPayload() []byte {
+	if x != nil {
+		return x.Payload
+	}
+	return nil
+}
+
+func (x *Job) GetAttempts() uint32 {
+	if x != nil {
+		return x.Attempts
+	}
+	return 0
+}
+
+var File_internal_compat_renumbered_job_proto protoreflect.FileDescriptor
+
+var file_internal_compat_renumbered_job_proto_rawDesc = []byte{
+	0x0a, 0x24, 0x69, 0x6e, 0x74, 0x65, 0x72, 0x6e, 0x61, 0x6c, 0x2f, 0x63, 0x6f, 0x6d, 0x70, 0x61,
+	0x74, 0x2f, 0x72, 0x65, 0x6e, 0x75, 0x6d, 0x62, 0x65, 0x72, 0x65, 0x64, 0x2f, 0x6a, 0x6f, 0x62,
+	0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x12, 0x19, 0x67, 0x6f, 0x6c, 0x65, 0x61, 0x72, 0x6e, 0x2e,
+	0x63, 0x6f, 0x6d, 0x70, 0x61, 0x74, 0x2e, 0x72, 0x65, 0x6e, 0x75, 0x6d, 0x62, 0x65, 0x72, 0x65,
+	0x64, 0x22, 0x61, 0x0a, 0x03, 0x4a, 0x6f, 0x62, 0x12, 0x0e, 0x0a, 0x02, 0x69, 0x64, 0x18, 0x01,
+	0x20, 0x01, 0x28, 0x09, 0x52, 0x02, 0x69, 0x64, 0x12, 0x14, 0x0a, 0x05, 0x71, 0x75, 0x65, 0x75,
+	0x65, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x71, 0x75, 0x65, 0x75, 0x65, 0x12, 0x18,
+	0x0a, 0x07, 0x70, 0x61, 0x79, 0x6c, 0x6f, 0x61, 0x64, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0c, 0x52,
+	0x07, 0x70, 0x61, 0x79, 0x6c, 0x6f, 0x61, 0x64, 0x12, 0x1a, 0x0a, 0x08, 0x61, 0x74, 0x74, 0x65,
+	0x6d, 0x70, 0x74, 0x73, 0x18, 0x05, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x08, 0x61, 0x74, 0x74, 0x65,
+	0x6d, 0x70, 0x74, 0x73, 0x42, 0x3f, 0x5a, 0x3d, 0x61, 0x64, 0x76, 0x61, 0x6e, 0x63, 0x65, 0x64,
+	0x2f, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x63, 0x6f, 0x6c, 0x2f, 0x69, 0x6e, 0x74, 0x65, 0x72, 0x6e,
+	0x61, 0x6c, 0x2f, 0x63, 0x6f, 0x6d, 0x70, 0x61, 0x74, 0x2f, 0x72, 0x65, 0x6e, 0x75, 0x6d, 0x62,
+	0x65, 0x72, 0x65, 0x64, 0x3b, 0x63, 0x6f, 0x6d, 0x70, 0x61, 0x74, 0x72, 0x65, 0x6e, 0x75, 0x6d,
+	0x62, 0x65, 0x72, 0x65, 0x64, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
+}
+
+var (
+	file_internal_compat_renumbered_job_proto_rawDescOnce sync.Once
+	file_internal_compat_renumbered_job_proto_rawDescData = file_internal_compat_renumbered_job_proto_rawDesc
+)
+
+func file_internal_compat_renumbered_job_proto_rawDescGZIP() []byte {
+	file_internal_compat_renumbered_job_proto_rawDescOnce.Do(func() {
+		file_internal_compat_renumbered_job_proto_rawDescData = protoimpl.X.CompressGZIP(file_internal_compat_renumbered_job_proto_rawDescData)
+	})
+	return file_internal_compat_renumbered_job_proto_rawDescData
+}
+
+var file_internal_compat_renumbered_job_proto_msgTypes = make([]protoimpl.MessageInfo, 1)
+var file_internal_compat_renumbered_job_proto_goTypes = []any{
+	(*Job)(nil), // 0: golearn.compat.renumbered.Job
+}
+var file_internal_compat_renumbered_job_proto_depIdxs = []int32{
+	0, // [0:0] is the sub-list for method output_type
+	0, // [0:0] is the sub-list for method input_type
+	0, // [0:0] is the sub-list for extension type_name
+	0, // [0:0] is the sub-list for extension extendee
+	0, // [0:0] is the sub-list for field type_name
+}
+
+func init() { file_internal_compat_renumbered_job_proto_init() }
+func file_internal_compat_renumbered_job_proto_init() {
+	if File_internal_compat_renumbered_job_proto != nil {
+		return
+	}
+	if !protoimpl.UnsafeEnabled {
+		file_internal_compat_renumbered_job_proto_msgTypes[0].Exporter = func(v any, i int) any {
+			switch v := v.(*Job); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: file_internal_compat_renumbered_job_proto_rawDesc,
+			NumEnums:      0,
+			NumMessages:   1,
+			NumExtensions: 0,
+			NumServices:   0,
+		},
+		GoTypes:           file_internal_compat_renumbered_job_proto_goTypes,
+		DependencyIndexes: file_internal_compat_renumbered_job_proto_depIdxs,
+		MessageInfos:      file_internal_compat_renumbered_job_proto_msgTypes,
+	}.Build()
+	File_internal_compat_renumbered_job_proto = out.File
+	file_internal_compat_renumbered_job_proto_rawDesc = nil
+	file_internal_compat_renumbered_job_proto_goTypes = nil
+	file_internal_compat_renumbered_job_proto_depIdxs = nil
+}
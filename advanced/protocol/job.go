@@ -0,0 +1,120 @@
+package protocol
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"advanced/protocol/jobqueuepb"
+
+	"google.golang.org/protobuf/types/known/durationpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+/*
+為什麼不直接在程式中使用產生的型別？
+  - 產生的 struct 內含 protoimpl 的內部狀態，不能複製（go vet copylocks），也不適合當 map key 或比較
+  - time.Time、time.Duration、enum 的表示方式不同，domain 邏輯不該依賴 wire format
+  - schema 演進時只要修改轉換層，domain 程式不受影響
+*/
+
+type JobStatus string
+
+const (
+	JobPending   JobStatus = "pending"
+	JobRunning   JobStatus = "running"
+	JobSucceeded JobStatus = "succeeded"
+	JobFailed    JobStatus = "failed"
+)
+
+var ErrInvalid = errors.New("protocol: invalid message")
+
+type Job struct {
+	ID          string
+	Queue       string
+	Payload     []byte
+	Attempts    int
+	MaxAttempts int
+	RunAt       time.Time
+	Status      JobStatus
+	LastError   string
+	Metadata    map[string]string
+	Timeout     time.Duration
+}
+
+var statusToProto = map[JobStatus]jobqueuepb.JobStatus{
+	JobPending:   jobqueuepb.JobStatus_JOB_STATUS_PENDING,
+	JobRunning:   jobqueuepb.JobStatus_JOB_STATUS_RUNNING,
+	JobSucceeded: jobqueuepb.JobStatus_JOB_STATUS_SUCCEEDED,
+	JobFailed:    jobqueuepb.JobStatus_JOB_STATUS_FAILED,
+}
+
+var statusFromProto = func() map[jobqueuepb.JobStatus]JobStatus {
+	m := make(map[jobqueuepb.JobStatus]JobStatus, len(statusToProto))
+	for k, v := range statusToProto {
+		m[v] = k
+	}
+	return m
+}()
+
+// JobToProto 轉成 protobuf 型別；零值的時間與 timeout 不輸出
+func JobToProto(j Job) *jobqueuepb.Job {
+	pb := &jobqueuepb.Job{
+		Id:          j.ID,
+		Queue:       j.Queue,
+		Payload:     j.Payload,
+		Attempts:    uint32(j.Attempts),
+		MaxAttempts: uint32(j.MaxAttempts),
+		Status:      statusToProto[j.Status],
+		LastError:   j.LastError,
+		Metadata:    j.Metadata,
+	}
+	if !j.RunAt.IsZero() {
+		pb.RunAt = timestamppb.New(j.RunAt)
+	}
+	if j.Timeout != 0 {
+		pb.Timeout = durationpb.New(j.Timeout)
+	}
+	return pb
+}
+
+// JobFromProto 轉成 domain struct 並檢查必要欄位；未指定的 status 視為 pending
+func JobFromProto(pb *jobqueuepb.Job) (Job, error) {
+	if pb == nil {
+		return Job{}, fmt.Errorf("%w: nil job", ErrInvalid)
+	}
+	if pb.GetId() == "" || pb.GetQueue() == "" {
+		return Job{}, fmt.Errorf("%w: job requires id and queue", ErrInvalid)
+	}
+	status := JobPending
+	if pb.GetStatus() != jobqueuepb.JobStatus_JOB_STATUS_UNSPECIFIED {
+		s, ok := statusFromProto[pb.GetStatus()]
+		if !ok {
+			return Job{}, fmt.Errorf("%w: unknown job status %d", ErrInvalid, pb.GetStatus())
+		}
+		status = s
+	}
+	j := Job{
+		ID:          pb.GetId(),
+		Queue:       pb.GetQueue(),
+		Payload:     pb.GetPayload(),
+		Attempts:    int(pb.GetAttempts()),
+		MaxAttempts: int(pb.GetMaxAttempts()),
+		Status:      status,
+		LastError:   pb.GetLastError(),
+		Metadata:    pb.GetMetadata(),
+	}
+	if pb.RunAt != nil {
+		if err := pb.RunAt.CheckValid(); err != nil {
+			return Job{}, fmt.Errorf("%w: run_at: %v", ErrInvalid, err)
+		}
+		j.RunAt = pb.RunAt.AsTime()
+	}
+	if pb.Timeout != nil {
+		if err := pb.Timeout.CheckValid(); err != nil {
+			return Job{}, fmt.Errorf("%w: timeout: %v", ErrInvalid, err)
+		}
+		j.Timeout = pb.Timeout.AsDuration()
+	}
+	return j, nil
+}
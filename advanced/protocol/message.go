@@ -0,0 +1,56 @@
+package protocol
+
+import (
+	"fmt"
+	"time"
+
+	"advanced/protocol/pubsubpb"
+
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+type Message struct {
+	ID          string
+	Topic       string
+	Key         string
+	Headers     map[string]string
+	Data        []byte
+	PublishedAt time.Time
+}
+
+func MessageToProto(m Message) *pubsubpb.Message {
+	pb := &pubsubpb.Message{
+		Id:      m.ID,
+		Topic:   m.Topic,
+		Key:     m.Key,
+		Headers: m.Headers,
+		Data:    m.Data,
+	}
+	if !m.PublishedAt.IsZero() {
+		pb.PublishedAt = timestamppb.New(m.PublishedAt)
+	}
+	return pb
+}
+
+func MessageFromProto(pb *pubsubpb.Message) (Message, error) {
+	if pb == nil {
+		return Message{}, fmt.Errorf("%w: nil message", ErrInvalid)
+	}
+	if pb.GetTopic() == "" {
+		return Message{}, fmt.Errorf("%w: message requires topic", ErrInvalid)
+	}
+	m := Message{
+		ID:      pb.GetId(),
+		Topic:   pb.GetTopic(),
+		Key:     pb.GetKey(),
+		Headers: pb.GetHeaders(),
+		Data:    pb.GetData(),
+	}
+	if pb.PublishedAt != nil {
+		if err := pb.PublishedAt.CheckValid(); err != nil {
+			return Message{}, fmt.Errorf("%w: published_at: %v", ErrInvalid, err)
+		}
+		m.PublishedAt = pb.PublishedAt.AsTime()
+	}
+	return m, nil
+}
@@ -0,0 +1,94 @@
+package protocol
+
+import (
+	"testing"
+	"time"
+
+	"advanced/protocol/jobqueuepb"
+	"advanced/protocol/pubsubpb"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func TestJobRoundTrip(t *testing.T) {
+	j := Job{
+		ID: "j1", Queue: "email", Payload: []byte(`{"to":"a@b.c"}`),
+		Attempts: 2, MaxAttempts: 5,
+		RunAt:  time.Date(2022, 4, 1, 12, 0, 0, 0, time.UTC),
+		Status: JobFailed, LastError: "smtp timeout",
+		Metadata: map[string]string{"tenant": "t1"},
+		Timeout:  30 * time.Second,
+	}
+	b, err := proto.Marshal(JobToProto(j))
+	assert.NoError(t, err)
+
+	var pb jobqueuepb.Job
+	assert.NoError(t, proto.Unmarshal(b, &pb))
+	got, err := JobFromProto(&pb)
+	assert.NoError(t, err)
+	assert.Equal(t, j, got)
+}
+
+func TestJobFromProtoValidation(t *testing.T) {
+	_, err := JobFromProto(nil)
+	assert.ErrorIs(t, err, ErrInvalid)
+	_, err = JobFromProto(&jobqueuepb.Job{Id: "j1"})
+	assert.ErrorIs(t, err, ErrInvalid)
+	_, err = JobFromProto(&jobqueuepb.Job{Id: "j1", Queue: "q", Status: 99})
+	assert.ErrorIs(t, err, ErrInvalid)
+	_, err = JobFromProto(&jobqueuepb.Job{Id: "j1", Queue: "q", RunAt: &timestamppb.Timestamp{Nanos: -1}})
+	assert.ErrorIs(t, err, ErrInvalid)
+
+	// 未指定 status 視為 pending，零值時間維持零值
+	j, err := JobFromProto(&jobqueuepb.Job{Id: "j1", Queue: "q"})
+	assert.NoError(t, err)
+	assert.Equal(t, JobPending, j.Status)
+	assert.True(t, j.RunAt.IsZero())
+}
+
+func TestMessageRoundTrip(t *testing.T) {
+	m := Message{
+		ID: "m1", Topic: "orders", Key: "user-1",
+		Headers:     map[string]string{"trace": "abc"},
+		Data:        []byte("hello"),
+		PublishedAt: time.Date(2022, 4, 1, 12, 0, 0, 5, time.UTC),
+	}
+	b, err := proto.Marshal(MessageToProto(m))
+	assert.NoError(t, err)
+	var pb pubsubpb.Message
+	assert.NoError(t, proto.Unmarshal(b, &pb))
+	got, err := MessageFromProto(&pb)
+	assert.NoError(t, err)
+	assert.Equal(t, m, got)
+
+	_, err = MessageFromProto(&pubsubpb.Message{Id: "m1"})
+	assert.ErrorIs(t, err, ErrInvalid)
+}
+
+// 防止有人修改已發佈的欄位編號：編號變動時這個測試會失敗
+func TestFieldNumbersAreStable(t *testing.T) {
+	numbers := func(md protoreflect.MessageDescriptor) map[string]protoreflect.FieldNumber {
+		m := make(map[string]protoreflect.FieldNumber)
+		fields := md.Fields()
+		for i := 0; i < fields.Len(); i++ {
+			m[string(fields.Get(i).Name())] = fields.Get(i).Number()
+		}
+		return m
+	}
+
+	job := (&jobqueuepb.Job{}).ProtoReflect().Descriptor()
+	assert.Equal(t, map[string]protoreflect.FieldNumber{
+		"id": 1, "queue": 2, "payload": 3, "attempts": 4, "max_attempts": 5,
+		"run_at": 6, "status": 7, "last_error": 8, "metadata": 10, "timeout": 11,
+	}, numbers(job))
+	// 已移除的欄位保留編號與名稱，避免被重複使用
+	assert.True(t, job.ReservedRanges().Has(9))
+	assert.True(t, job.ReservedNames().Has("priority"))
+
+	assert.Equal(t, map[string]protoreflect.FieldNumber{
+		"id": 1, "topic": 2, "key": 3, "headers": 4, "data": 5, "published_at": 6,
+	}, numbers((&pubsubpb.Message{}).ProtoReflect().Descriptor()))
+}
@@ -0,0 +1,193 @@
+package quiz
+
+import (
+	_ "embed"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+/*
+題庫是 bank.yaml：每一題是一個完整的 main 程式，使用者預測它的輸出或行為，
+Engine 實際編譯執行後評分，因此題目的答案是「機器驗證」過的，而不是出題者的想像。
+
+兩種題型：
+  - output：預測 stdout，比對時忽略空白與換行的差異
+  - choice：從選項中選一個，每個選項描述一種可觀察的結果：
+      outcome  執行結果（ok、panic、fatal、deadlock、race、timeout、exit）
+      stdout   stdout 要符合的正規表示式
+      lines    stdout 的每一行排序後要等於這些行（順序不定的輸出）
+      varies   不同 seed 的執行落在不同選項時，答案就是這個選項
+
+每一題可以指定：
+  - go：go.mod 的版本，決定語意（例如 1.22 起迴圈變數每次迭代都是新的）
+  - procs：GOMAXPROCS，預設 1，讓排程順序可以預測
+  - runs：以 seed 1..runs 各執行一次，預設 3
+  - race：以 -race 編譯
+  - timeout：單次執行的時間上限，超過視為 timeout（例如永遠卡住但不是 deadlock 的程式）
+*/
+
+//go:embed bank.yaml
+var bankYAML []byte
+
+var ErrInvalidBank = errors.New("quiz: invalid bank")
+
+type Kind string
+
+const (
+	KindOutput Kind = "output"
+	KindChoice Kind = "choice"
+)
+
+type Choice struct {
+	ID      string  `yaml:"id"`
+	Text    string  `yaml:"text"`
+	Outcome Outcome `yaml:"outcome"`
+	Stdout  string  `yaml:"stdout"`
+	Lines   string  `yaml:"lines"`
+	Varies  bool    `yaml:"varies"`
+
+	re *regexp.Regexp
+}
+
+type Scenario struct {
+	ID       string        `yaml:"id"`
+	Title    string        `yaml:"title"`
+	Question string        `yaml:"question"`
+	Go       string        `yaml:"go"`
+	Procs    int           `yaml:"procs"`
+	Runs     int           `yaml:"runs"`
+	Race     bool          `yaml:"race"`
+	Timeout  time.Duration `yaml:"timeout"`
+	Choices  []Choice      `yaml:"choices"`
+	Answer   string        `yaml:"answer"`
+	Explain  string        `yaml:"explain"`
+	Source   string        `yaml:"source"`
+}
+
+func (s *Scenario) Kind() Kind {
+	if len(s.Choices) > 0 {
+		return KindChoice
+	}
+	return KindOutput
+}
+
+func (s *Scenario) Choice(id string) (Choice, bool) {
+	for _, c := range s.Choices {
+		if c.ID == id {
+			return c, true
+		}
+	}
+	return Choice{}, false
+}
+
+type Bank []*Scenario
+
+// Load 讀取嵌入的題庫
+func Load() (Bank, error) {
+	return Parse(bankYAML)
+}
+
+// Parse 解析題庫並補上預設值
+func Parse(data []byte) (Bank, error) {
+	var b Bank
+	if err := yaml.Unmarshal(data, &b); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidBank, err)
+	}
+	seen := map[string]bool{}
+	for _, s := range b {
+		if err := s.validate(); err != nil {
+			return nil, fmt.Errorf("%w: %s: %v", ErrInvalidBank, s.ID, err)
+		}
+		if seen[s.ID] {
+			return nil, fmt.Errorf("%w: duplicate id %q", ErrInvalidBank, s.ID)
+		}
+		seen[s.ID] = true
+	}
+	return b, nil
+}
+
+func (s *Scenario) validate() error {
+	switch {
+	case s.ID == "":
+		return errors.New("missing id")
+	case !strings.Contains(s.Source, "package main"):
+		return errors.New("source must be a main package")
+	case s.Answer == "":
+		return errors.New("missing answer")
+	}
+	if s.Go == "" {
+		s.Go = "1.22"
+	}
+	if s.Procs <= 0 {
+		s.Procs = 1
+	}
+	if s.Runs <= 0 {
+		s.Runs = 3
+	}
+	if s.Timeout <= 0 {
+		s.Timeout = 5 * time.Second
+	}
+	if s.Kind() == KindOutput {
+		return nil
+	}
+
+	varies := 0
+	for i := range s.Choices {
+		c := &s.Choices[i]
+		if c.Varies {
+			varies++
+			continue
+		}
+		if c.Outcome == "" {
+			c.Outcome = OK
+		}
+		if c.Stdout != "" {
+			re, err := regexp.Compile(c.Stdout)
+			if err != nil {
+				return fmt.Errorf("choice %s: %v", c.ID, err)
+			}
+			c.re = re
+		}
+	}
+	if varies > 1 {
+		return errors.New("more than one varies choice")
+	}
+	if _, ok := s.Choice(s.Answer); !ok {
+		return fmt.Errorf("answer %q is not a choice", s.Answer)
+	}
+	return nil
+}
+
+// match 回報一次執行結果是否符合選項
+func (c Choice) match(r Result) bool {
+	if c.Varies || r.Outcome != c.Outcome {
+		return false
+	}
+	if c.re != nil && !c.re.MatchString(r.Stdout) {
+		return false
+	}
+	if c.Lines != "" && sortedLines(r.Stdout) != sortedLines(c.Lines) {
+		return false
+	}
+	return true
+}
+
+func sortedLines(s string) string {
+	lines := strings.Split(strings.TrimSpace(s), "\n")
+	for i := range lines {
+		lines[i] = strings.TrimSpace(lines[i])
+	}
+	sort.Strings(lines)
+	return strings.Join(lines, "\n")
+}
+
+// normalize 讓 output 題忽略空白與換行的差異
+func normalize(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
@@ -0,0 +1,295 @@
+package quiz
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+/*
+Engine 評分的流程：
+ 1. 把題目寫成獨立的 module（go.mod 帶題目的 go 版本）再加上 seed.go，go build 一次
+ 2. 以 seed 1..Runs、GOMAXPROCS=Procs 各執行一次，依 stdout / stderr / exit code 判斷 Outcome
+ 3. choice 題：每次執行對應到第一個符合的選項；不同 seed 對應到不同選項時，答案是 varies 選項
+    output 題：每次執行的 stdout 都相同才有標準答案
+觀察結果會依題目 ID 快取，同一題重複作答不需要重新編譯執行。
+
+seed.go 提供題目可以使用的 jitter(i)：依 QUIZ_SEED 與 i 決定的延遲（0、10...40ms），
+goroutine 之間的先後因此由 seed 控制，每次執行都能重現。
+*/
+
+type Outcome string
+
+const (
+	OK       Outcome = "ok"
+	Panic    Outcome = "panic"
+	Fatal    Outcome = "fatal"    // runtime fatal error，例如 concurrent map writes
+	Deadlock Outcome = "deadlock" // all goroutines are asleep
+	Race     Outcome = "race"     // race detector 回報
+	Timeout  Outcome = "timeout"
+	Exit     Outcome = "exit" // 其他非 0 的 exit code
+)
+
+var (
+	ErrUnknownScenario = errors.New("quiz: unknown scenario")
+	ErrInconsistent    = errors.New("quiz: runs disagree and the scenario has no varies choice")
+)
+
+const seedSource = `package main
+
+import (
+	"os"
+	"strconv"
+	"time"
+)
+
+// jitter 依 QUIZ_SEED 與 i 決定延遲，讓 goroutine 的先後可以由 seed 重現
+func jitter(i int) time.Duration {
+	seed, _ := strconv.ParseUint(os.Getenv("QUIZ_SEED"), 10, 64)
+	x := seed*0x9e3779b97f4a7c15 + uint64(i+1)*0xbf58476d1ce4e5b9
+	x ^= x >> 31
+	return time.Duration(x%5) * 10 * time.Millisecond
+}
+`
+
+type Result struct {
+	Seed     int
+	Outcome  Outcome
+	Stdout   string
+	Stderr   string
+	ExitCode int
+	Duration time.Duration
+}
+
+type Observation struct {
+	Scenario *Scenario
+	Results  []Result
+	Answer   string         // 實際執行得出的答案：choice 題是選項 ID，output 題是正規化後的 stdout
+	Counts   map[string]int // choice 題每個選項被幾次執行命中，"" 表示不符合任何選項
+}
+
+type Verdict struct {
+	Correct  bool
+	Given    string
+	Expected string
+	Observation
+}
+
+type Engine struct {
+	workDir string
+	goBin   string
+
+	mu    sync.Mutex
+	cache map[string]*Observation
+}
+
+type Option func(*Engine)
+
+// WithWorkDir 指定編譯題目的目錄，預設為 os.TempDir 底下的新目錄
+func WithWorkDir(dir string) Option {
+	return func(e *Engine) { e.workDir = dir }
+}
+
+// WithGo 指定 go 指令，預設為 PATH 中的 go
+func WithGo(bin string) Option {
+	return func(e *Engine) { e.goBin = bin }
+}
+
+func NewEngine(opts ...Option) (*Engine, error) {
+	e := &Engine{goBin: "go", cache: map[string]*Observation{}}
+	for _, opt := range opts {
+		opt(e)
+	}
+	if e.workDir == "" {
+		dir, err := os.MkdirTemp("", "quiz")
+		if err != nil {
+			return nil, err
+		}
+		e.workDir = dir
+	}
+	return e, nil
+}
+
+// Close 刪除編譯用的目錄
+func (e *Engine) Close() error {
+	return os.RemoveAll(e.workDir)
+}
+
+// Grade 依實際執行結果評分
+func (e *Engine) Grade(ctx context.Context, s *Scenario, answer string) (Verdict, error) {
+	obs, err := e.Observe(ctx, s)
+	if err != nil {
+		return Verdict{}, err
+	}
+	v := Verdict{Given: answer, Expected: obs.Answer, Observation: *obs}
+	if s.Kind() == KindOutput {
+		v.Correct = normalize(answer) == obs.Answer
+	} else {
+		v.Correct = strings.EqualFold(strings.TrimSpace(answer), obs.Answer)
+	}
+	return v, nil
+}
+
+// Observe 編譯並以每個 seed 執行題目，結果依題目 ID 快取
+func (e *Engine) Observe(ctx context.Context, s *Scenario) (*Observation, error) {
+	e.mu.Lock()
+	obs, ok := e.cache[s.ID]
+	e.mu.Unlock()
+	if ok {
+		return obs, nil
+	}
+
+	bin, err := e.build(ctx, s)
+	if err != nil {
+		return nil, err
+	}
+	obs = &Observation{Scenario: s}
+	for seed := 1; seed <= s.Runs; seed++ {
+		r, err := e.run(ctx, s, bin, seed)
+		if err != nil {
+			return nil, err
+		}
+		obs.Results = append(obs.Results, r)
+	}
+	if err := obs.classify(); err != nil {
+		return nil, err
+	}
+
+	e.mu.Lock()
+	e.cache[s.ID] = obs
+	e.mu.Unlock()
+	return obs, nil
+}
+
+func (e *Engine) build(ctx context.Context, s *Scenario) (string, error) {
+	dir := filepath.Join(e.workDir, s.ID)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", err
+	}
+	files := map[string]string{
+		"go.mod":  "module quiz\n\ngo " + s.Go + "\n",
+		"main.go": s.Source,
+		"seed.go": seedSource,
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			return "", err
+		}
+	}
+
+	bin := filepath.Join(dir, "quiz.bin")
+	args := []string{"build", "-o", bin}
+	if s.Race {
+		args = append(args, "-race")
+	}
+	cmd := exec.CommandContext(ctx, e.goBin, args...)
+	cmd.Dir = dir
+	// 題目的 go 版本比工具鏈新時直接失敗，不要自動下載工具鏈
+	cmd.Env = append(os.Environ(), "GOTOOLCHAIN=local", "GOFLAGS=")
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return "", fmt.Errorf("quiz: build %s: %w\n%s", s.ID, err, out)
+	}
+	return bin, nil
+}
+
+func (e *Engine) run(ctx context.Context, s *Scenario, bin string, seed int) (Result, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.Timeout)
+	defer cancel()
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, bin)
+	cmd.Dir = filepath.Dir(bin)
+	cmd.Stdout, cmd.Stderr = &stdout, &stderr
+	cmd.Env = append(os.Environ(),
+		"QUIZ_SEED="+strconv.Itoa(seed),
+		"GOMAXPROCS="+strconv.Itoa(s.Procs),
+		"GORACE=halt_on_error=1",
+	)
+	start := time.Now()
+	err := cmd.Run()
+	r := Result{
+		Seed:     seed,
+		Stdout:   stdout.String(),
+		Stderr:   stderr.String(),
+		ExitCode: cmd.ProcessState.ExitCode(),
+		Duration: time.Since(start),
+	}
+
+	var exitErr *exec.ExitError
+	switch {
+	case err == nil:
+		r.Outcome = OK
+	case ctx.Err() == context.DeadlineExceeded:
+		r.Outcome = Timeout
+	case ctx.Err() != nil:
+		return r, ctx.Err()
+	case errors.As(err, &exitErr):
+		r.Outcome = outcome(r.Stderr)
+	default:
+		return r, err
+	}
+	return r, nil
+}
+
+// outcome 從 stderr 判斷非 0 結束的原因
+func outcome(stderr string) Outcome {
+	switch {
+	case strings.Contains(stderr, "WARNING: DATA RACE"):
+		return Race
+	case strings.Contains(stderr, "all goroutines are asleep - deadlock!"):
+		return Deadlock
+	case strings.Contains(stderr, "fatal error:"):
+		return Fatal
+	case strings.HasPrefix(stderr, "panic:") || strings.Contains(stderr, "\npanic:"):
+		return Panic
+	}
+	return Exit
+}
+
+func (o *Observation) classify() error {
+	s := o.Scenario
+	if s.Kind() == KindOutput {
+		answers := map[string]bool{}
+		for _, r := range o.Results {
+			answers[normalize(r.Stdout)] = true
+		}
+		if len(answers) != 1 {
+			return fmt.Errorf("%w: %s: %d different outputs", ErrInconsistent, s.ID, len(answers))
+		}
+		o.Answer = normalize(o.Results[0].Stdout)
+		return nil
+	}
+
+	o.Counts = map[string]int{}
+	for _, r := range o.Results {
+		id := ""
+		for _, c := range s.Choices {
+			if c.match(r) {
+				id = c.ID
+				break
+			}
+		}
+		o.Counts[id]++
+	}
+	if len(o.Counts) == 1 {
+		for id := range o.Counts {
+			o.Answer = id
+		}
+		return nil
+	}
+	for _, c := range s.Choices {
+		if c.Varies {
+			o.Answer = c.ID
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: %s: %v", ErrInconsistent, s.ID, o.Counts)
+}
@@ -0,0 +1,140 @@
+package quiz
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func engine(t *testing.T) *Engine {
+	e, err := NewEngine(WithWorkDir(t.TempDir()))
+	require.NoError(t, err)
+	return e
+}
+
+// TestBankAnswers 實際執行每一題，確認題庫的答案與觀察結果一致
+func TestBankAnswers(t *testing.T) {
+	if testing.Short() {
+		t.Skip("builds and runs every scenario")
+	}
+	bank, err := Load()
+	require.NoError(t, err)
+	require.GreaterOrEqual(t, len(bank), 20)
+
+	e := engine(t)
+	for _, s := range bank {
+		t.Run(s.ID, func(t *testing.T) {
+			obs, err := e.Observe(context.Background(), s)
+			require.NoError(t, err)
+			want := s.Answer
+			if s.Kind() == KindOutput {
+				want = normalize(want)
+			}
+			assert.Equal(t, want, obs.Answer, "counts=%v results=%+v", obs.Counts, obs.Results)
+		})
+	}
+}
+
+func TestGrade(t *testing.T) {
+	bank, err := Parse([]byte(`
+- id: out
+  answer: "a b"
+  source: |
+    package main
+
+    import "fmt"
+
+    func main() { fmt.Println("a"); fmt.Println("b") }
+- id: exit
+  choices:
+    - {id: ok, text: ok}
+    - {id: exit, text: exit 3, outcome: exit}
+  answer: exit
+  source: |
+    package main
+
+    import "os"
+
+    func main() { os.Exit(3) }
+`))
+	require.NoError(t, err)
+	e := engine(t)
+	ctx := context.Background()
+
+	v, err := e.Grade(ctx, bank[0], "a\n  b\n")
+	require.NoError(t, err)
+	assert.True(t, v.Correct, "output 題忽略空白差異")
+	v, err = e.Grade(ctx, bank[0], "b a")
+	require.NoError(t, err)
+	assert.False(t, v.Correct)
+	assert.Equal(t, "a b", v.Expected)
+	assert.Len(t, v.Results, 3)
+
+	v, err = e.Grade(ctx, bank[1], " EXIT ")
+	require.NoError(t, err)
+	assert.True(t, v.Correct)
+	assert.Equal(t, 3, v.Results[0].ExitCode)
+	assert.Equal(t, map[string]int{"exit": 3}, v.Counts)
+}
+
+func TestSeedControlsJitter(t *testing.T) {
+	bank, err := Parse([]byte(`
+- id: jitter
+  runs: 6
+  answer: unused
+  choices:
+    - {id: unused, text: unused}
+  source: |
+    package main
+
+    import "fmt"
+
+    func main() { fmt.Println(jitter(0), jitter(1)) }
+`))
+	require.NoError(t, err)
+	e := engine(t)
+	bin, err := e.build(context.Background(), bank[0])
+	require.NoError(t, err)
+
+	first, err := e.run(context.Background(), bank[0], bin, 1)
+	require.NoError(t, err)
+	again, err := e.run(context.Background(), bank[0], bin, 1)
+	require.NoError(t, err)
+	other, err := e.run(context.Background(), bank[0], bin, 3)
+	require.NoError(t, err)
+	assert.Equal(t, first.Stdout, again.Stdout, "相同 seed 相同延遲")
+	assert.NotEqual(t, first.Stdout, other.Stdout)
+}
+
+func TestInconsistentWithoutVaries(t *testing.T) {
+	s := &Scenario{ID: "x", Choices: []Choice{{ID: "a", Outcome: OK}, {ID: "b", Outcome: Panic}}}
+	obs := &Observation{Scenario: s, Results: []Result{{Outcome: OK}, {Outcome: Panic}}}
+	assert.ErrorIs(t, obs.classify(), ErrInconsistent)
+
+	s.Choices = append(s.Choices, Choice{ID: "c", Varies: true})
+	require.NoError(t, obs.classify())
+	assert.Equal(t, "c", obs.Answer)
+}
+
+func TestParseInvalid(t *testing.T) {
+	for name, yml := range map[string]string{
+		"no source":      "- {id: a, answer: x}",
+		"unknown answer": "- {id: a, answer: z, source: package main, choices: [{id: x}]}",
+		"bad regexp":     "- {id: a, answer: x, source: package main, choices: [{id: x, stdout: '('}]}",
+		"duplicate":      "- {id: a, answer: x, source: package main}\n- {id: a, answer: x, source: package main}",
+		"two varies":     "- {id: a, answer: x, source: package main, choices: [{id: x, varies: true}, {id: y, varies: true}]}",
+	} {
+		_, err := Parse([]byte(yml))
+		assert.ErrorIs(t, err, ErrInvalidBank, name)
+	}
+}
+
+func TestOutcome(t *testing.T) {
+	assert.Equal(t, Deadlock, outcome("fatal error: all goroutines are asleep - deadlock!\n"))
+	assert.Equal(t, Fatal, outcome("fatal error: concurrent map writes\n"))
+	assert.Equal(t, Panic, outcome("panic: boom\n\ngoroutine 1 [running]:\n"))
+	assert.Equal(t, Race, outcome("==================\nWARNING: DATA RACE\n"))
+	assert.Equal(t, Exit, outcome(""))
+}
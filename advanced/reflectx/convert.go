@@ -0,0 +1,78 @@
+package reflectx
+
+import (
+	"encoding"
+	"fmt"
+	"reflect"
+	"strconv"
+	"time"
+)
+
+var textUnmarshaler = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+
+// ParseError 表示字串不符合欄位的型別；Error 只描述期望的格式（"must be an integer"），
+// 不含輸入值，可以直接回給 client，原始的 strconv / time 錯誤由 Unwrap 取得
+type ParseError struct {
+	Want string
+	Err  error
+}
+
+func (e *ParseError) Error() string { return "must be " + e.Want }
+func (e *ParseError) Unwrap() error { return e.Err }
+
+// FromString 把 s 依 v 的型別轉換後寫入，v 必須可以 Set。支援：
+//   - 實作 encoding.TextUnmarshaler 的型別（time.Time、net.IP……）
+//   - time.Duration（"1m30s"）、string、bool、整數、浮點數
+//   - pointer：nil 時自動配置
+//
+// 格式不符時回傳 *ParseError
+func FromString(v reflect.Value, s string) error {
+	if v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			v.Set(reflect.New(v.Type().Elem()))
+		}
+		return FromString(v.Elem(), s)
+	}
+	if v.CanAddr() && v.Addr().Type().Implements(textUnmarshaler) {
+		return v.Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(s))
+	}
+	if v.Type() == reflect.TypeOf(time.Duration(0)) {
+		d, err := time.ParseDuration(s)
+		if err != nil {
+			return &ParseError{Want: "a duration", Err: err}
+		}
+		v.SetInt(int64(d))
+		return nil
+	}
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(s)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return &ParseError{Want: "a boolean", Err: err}
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(s, 10, v.Type().Bits())
+		if err != nil {
+			return &ParseError{Want: "an integer", Err: err}
+		}
+		v.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(s, 10, v.Type().Bits())
+		if err != nil {
+			return &ParseError{Want: "a non-negative integer", Err: err}
+		}
+		v.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		n, err := strconv.ParseFloat(s, v.Type().Bits())
+		if err != nil {
+			return &ParseError{Want: "a number", Err: err}
+		}
+		v.SetFloat(n)
+	default:
+		return fmt.Errorf("unsupported type %s", v.Type())
+	}
+	return nil
+}
@@ -0,0 +1,138 @@
+package reflectx
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+)
+
+// ToMap 把 struct 轉成 map，key 優先使用 tag 名稱，tag 為 "-" 的欄位略過
+func ToMap(v interface{}, tag string) (map[string]interface{}, error) {
+	rv := indirect(reflect.ValueOf(v))
+	if rv.Kind() != reflect.Struct {
+		return nil, errors.New("reflectx: ToMap requires a struct")
+	}
+	m := make(map[string]interface{})
+	toMap(rv, tag, m)
+	return m, nil
+}
+
+func toMap(v reflect.Value, tag string, m map[string]interface{}) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		name, skip := fieldKey(sf, tag)
+		if skip {
+			continue
+		}
+		fv := v.Field(i)
+		if sf.Anonymous && name == "" {
+			if inner := indirect(fv); inner.Kind() == reflect.Struct {
+				toMap(inner, tag, m)
+				continue
+			}
+		}
+		if !sf.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = sf.Name
+		}
+		m[name] = toValue(fv, tag)
+	}
+}
+
+func toValue(v reflect.Value, tag string) interface{} {
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if v.IsNil() {
+			return nil
+		}
+		return toValue(v.Elem(), tag)
+	case reflect.Struct:
+		if !hasExportedFields(v.Type()) {
+			return v.Interface()
+		}
+		m := make(map[string]interface{})
+		toMap(v, tag, m)
+		return m
+	case reflect.Slice, reflect.Array:
+		if v.Kind() == reflect.Slice && v.IsNil() {
+			return nil
+		}
+		if v.Type().Elem().Kind() != reflect.Struct && v.Type().Elem().Kind() != reflect.Ptr {
+			return v.Interface()
+		}
+		s := make([]interface{}, v.Len())
+		for i := range s {
+			s[i] = toValue(v.Index(i), tag)
+		}
+		return s
+	}
+	return v.Interface()
+}
+
+func fieldKey(sf reflect.StructField, tag string) (name string, skip bool) {
+	if tag == "" {
+		return "", false
+	}
+	name, _ = TagName(sf, tag)
+	return name, name == "-"
+}
+
+// FromMap 是 ToMap 的反向操作，out 必須是指向 struct 的指標
+func FromMap(m map[string]interface{}, out interface{}, tag string) error {
+	rv := reflect.ValueOf(out)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() || rv.Elem().Kind() != reflect.Struct {
+		return errors.New("reflectx: FromMap requires a pointer to struct")
+	}
+	return fromMap(m, rv.Elem(), tag)
+}
+
+func fromMap(m map[string]interface{}, v reflect.Value, tag string) error {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		name, skip := fieldKey(sf, tag)
+		if skip {
+			continue
+		}
+		fv := v.Field(i)
+		if sf.Anonymous && name == "" && indirectType(sf.Type).Kind() == reflect.Struct {
+			if fv.Kind() == reflect.Ptr {
+				if fv.IsNil() {
+					if !fv.CanSet() {
+						continue
+					}
+					fv.Set(reflect.New(sf.Type.Elem()))
+				}
+				fv = fv.Elem()
+			}
+			if err := fromMap(m, fv, tag); err != nil {
+				return err
+			}
+			continue
+		}
+		if !sf.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = sf.Name
+		}
+		val, ok := m[name]
+		if !ok {
+			continue
+		}
+		if err := assign(fv, val, tag); err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+func indirectType(t reflect.Type) reflect.Type {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t
+}
@@ -0,0 +1,246 @@
+package reflectx
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+var (
+	ErrNotFound   = errors.New("reflectx: field not found")
+	ErrUnexported = errors.New("reflectx: unexported field")
+	ErrIndex      = errors.New("reflectx: index out of range")
+)
+
+type segment struct {
+	name  string
+	index int // -1 表示這段是欄位名稱
+}
+
+// parsePath 把 "a.b[2].c" 拆成 a, b, [2], c
+func parsePath(path string) ([]segment, error) {
+	var segs []segment
+	for _, part := range strings.Split(path, ".") {
+		name := part
+		if i := strings.IndexByte(part, '['); i >= 0 {
+			name = part[:i]
+			part = part[i:]
+		} else {
+			part = ""
+		}
+		if name != "" {
+			segs = append(segs, segment{name: name, index: -1})
+		}
+		for part != "" {
+			end := strings.IndexByte(part, ']')
+			if part[0] != '[' || end < 0 {
+				return nil, fmt.Errorf("reflectx: invalid path %q", path)
+			}
+			idx, err := strconv.Atoi(part[1:end])
+			if err != nil || idx < 0 {
+				return nil, fmt.Errorf("reflectx: invalid index in path %q", path)
+			}
+			segs = append(segs, segment{index: idx})
+			part = part[end+1:]
+		}
+		if name == "" && len(segs) == 0 {
+			return nil, fmt.Errorf("reflectx: invalid path %q", path)
+		}
+	}
+	return segs, nil
+}
+
+// Get 以路徑讀取欄位值
+func Get(v interface{}, path string) (interface{}, error) {
+	segs, err := parsePath(path)
+	if err != nil {
+		return nil, err
+	}
+	rv := reflect.ValueOf(v)
+	for _, seg := range segs {
+		rv = indirect(rv)
+		if !rv.IsValid() {
+			return nil, fmt.Errorf("%w: nil pointer before %q", ErrNotFound, path)
+		}
+		if rv, err = step(rv, seg); err != nil {
+			return nil, err
+		}
+	}
+	if !rv.CanInterface() {
+		return nil, fmt.Errorf("%w: %s", ErrUnexported, path)
+	}
+	return rv.Interface(), nil
+}
+
+func step(v reflect.Value, seg segment) (reflect.Value, error) {
+	if seg.index >= 0 {
+		if v.Kind() != reflect.Slice && v.Kind() != reflect.Array {
+			return reflect.Value{}, fmt.Errorf("reflectx: cannot index %s", v.Kind())
+		}
+		if seg.index >= v.Len() {
+			return reflect.Value{}, fmt.Errorf("%w: %d >= %d", ErrIndex, seg.index, v.Len())
+		}
+		return v.Index(seg.index), nil
+	}
+	switch v.Kind() {
+	case reflect.Struct:
+		sf, ok := v.Type().FieldByName(seg.name)
+		if !ok {
+			return reflect.Value{}, fmt.Errorf("%w: %s", ErrNotFound, seg.name)
+		}
+		if !sf.IsExported() {
+			return reflect.Value{}, fmt.Errorf("%w: %s", ErrUnexported, seg.name)
+		}
+		f, err := v.FieldByIndexErr(sf.Index)
+		if err != nil {
+			return reflect.Value{}, fmt.Errorf("%w: %s", ErrNotFound, seg.name)
+		}
+		return f, nil
+	case reflect.Map:
+		key, err := mapKey(v.Type(), seg.name)
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		mv := v.MapIndex(key)
+		if !mv.IsValid() {
+			return reflect.Value{}, fmt.Errorf("%w: %s", ErrNotFound, seg.name)
+		}
+		return mv, nil
+	}
+	return reflect.Value{}, fmt.Errorf("reflectx: cannot select %q from %s", seg.name, v.Kind())
+}
+
+// mapKey 把路徑中的名稱轉成 map 的 key；只支援以 string 為底層型別的 key
+func mapKey(t reflect.Type, name string) (reflect.Value, error) {
+	if t.Key().Kind() != reflect.String {
+		return reflect.Value{}, fmt.Errorf("reflectx: map key must be string, got %s", t.Key())
+	}
+	return reflect.ValueOf(name).Convert(t.Key()), nil
+}
+
+// Set 以路徑設定欄位值，ptr 必須是指向 struct 的指標
+func Set(ptr interface{}, path string, value interface{}) error {
+	rv := reflect.ValueOf(ptr)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() {
+		return errors.New("reflectx: Set requires a non-nil pointer")
+	}
+	segs, err := parsePath(path)
+	if err != nil {
+		return err
+	}
+	return setPath(rv.Elem(), segs, value)
+}
+
+func setPath(v reflect.Value, segs []segment, value interface{}) error {
+	if len(segs) == 0 {
+		return assign(v, value, "")
+	}
+	for v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			v.Set(reflect.New(v.Type().Elem()))
+		}
+		v = v.Elem()
+	}
+	seg := segs[0]
+	if v.Kind() == reflect.Map && seg.index < 0 {
+		// map 的元素不可定址，先複製出來修改再寫回去
+		key, err := mapKey(v.Type(), seg.name)
+		if err != nil {
+			return err
+		}
+		elem := reflect.New(v.Type().Elem()).Elem()
+		if old := v.MapIndex(key); old.IsValid() {
+			elem.Set(old)
+		}
+		if err := setPath(elem, segs[1:], value); err != nil {
+			return err
+		}
+		if v.IsNil() {
+			v.Set(reflect.MakeMap(v.Type()))
+		}
+		v.SetMapIndex(key, elem)
+		return nil
+	}
+	if v.Kind() == reflect.Struct && seg.index < 0 {
+		// 走過 nil 的嵌入 pointer 時要先配置
+		sf, ok := v.Type().FieldByName(seg.name)
+		if ok && sf.IsExported() {
+			f := v
+			for _, i := range sf.Index {
+				if f.Kind() == reflect.Ptr {
+					if f.IsNil() {
+						if !f.CanSet() {
+							return fmt.Errorf("%w: nil embedded pointer", ErrUnexported)
+						}
+						f.Set(reflect.New(f.Type().Elem()))
+					}
+					f = f.Elem()
+				}
+				f = f.Field(i)
+			}
+			return setPath(f, segs[1:], value)
+		}
+	}
+	next, err := step(v, seg)
+	if err != nil {
+		return err
+	}
+	return setPath(next, segs[1:], value)
+}
+
+// assign 把 src 指定給 dst，必要時做型別轉換
+func assign(dst reflect.Value, src interface{}, tag string) error {
+	if !dst.CanSet() {
+		return ErrUnexported
+	}
+	if src == nil {
+		dst.Set(reflect.Zero(dst.Type()))
+		return nil
+	}
+	sv := reflect.ValueOf(src)
+	if sv.Type().AssignableTo(dst.Type()) {
+		dst.Set(sv)
+		return nil
+	}
+	if dst.Kind() == reflect.Ptr {
+		elem := reflect.New(dst.Type().Elem())
+		if err := assign(elem.Elem(), src, tag); err != nil {
+			return err
+		}
+		dst.Set(elem)
+		return nil
+	}
+	switch m := src.(type) {
+	case map[string]interface{}:
+		if dst.Kind() == reflect.Struct {
+			return fromMap(m, dst, tag)
+		}
+	case []interface{}:
+		if dst.Kind() == reflect.Slice {
+			s := reflect.MakeSlice(dst.Type(), len(m), len(m))
+			for i, e := range m {
+				if err := assign(s.Index(i), e, tag); err != nil {
+					return fmt.Errorf("[%d]: %w", i, err)
+				}
+			}
+			dst.Set(s)
+			return nil
+		}
+	}
+	if convertible(sv.Kind(), dst.Kind()) && sv.Type().ConvertibleTo(dst.Type()) {
+		dst.Set(sv.Convert(dst.Type()))
+		return nil
+	}
+	return fmt.Errorf("reflectx: cannot assign %s to %s", sv.Type(), dst.Type())
+}
+
+// 只允許數字之間、字串之間的轉換，避免 int -> string 變成 rune
+func convertible(from, to reflect.Kind) bool {
+	return (isNumber(from) && isNumber(to)) || (from == reflect.String && to == reflect.String)
+}
+
+func isNumber(k reflect.Kind) bool {
+	return k >= reflect.Int && k <= reflect.Float64
+}
@@ -0,0 +1,314 @@
+package reflectx
+
+import (
+	"errors"
+	"reflect"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type Address struct {
+	City string `json:"city"`
+	Zip  string `json:"zip,omitempty"`
+}
+
+type Base struct {
+	ID      int       `json:"id"`
+	Created time.Time `json:"created"`
+}
+
+type inner struct {
+	Note string `json:"note"`
+}
+
+type User struct {
+	Base
+	*inner
+	Name      string            `json:"name" validate:"required"`
+	Age       int               `json:"age"`
+	Home      *Address          `json:"home"`
+	Addresses []Address         `json:"addresses"`
+	Tags      []string          `json:"tags"`
+	Meta      map[string]string `json:"meta"`
+	Password  string            `json:"-"`
+	secret    string
+}
+
+func TestWalk(t *testing.T) {
+	u := User{
+		Name:      "tom",
+		Home:      &Address{City: "Taipei"},
+		Addresses: []Address{{City: "A"}, {City: "B"}},
+		secret:    "x",
+	}
+	var paths []string
+	tags := map[string]string{}
+	err := Walk(&u, func(f Field) error {
+		paths = append(paths, f.Path)
+		tags[f.Path] = f.Tag("json")
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{
+		"ID", "Created", // 嵌入的 Base 被攤平，time.Time 不往下走
+		"Name", "Age",
+		"Home", "Home.City", "Home.Zip",
+		"Addresses", "Addresses[0].City", "Addresses[0].Zip", "Addresses[1].City", "Addresses[1].Zip",
+		"Tags", "Meta", "Password",
+	}, paths)
+	assert.Equal(t, "zip", tags["Home.Zip"])
+	assert.Equal(t, "-", tags["Password"])
+	assert.NotContains(t, paths, "secret")
+}
+
+func TestWalkNamed(t *testing.T) {
+	u := User{Name: "tom", Home: &Address{City: "Taipei"}, Addresses: []Address{{City: "A"}}}
+	byPath := map[string]Field{}
+	err := WalkNamed(&u, func(sf reflect.StructField) string { return sf.Tag.Get("json") }, func(f Field) error {
+		byPath[f.Path] = f
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Contains(t, byPath, "home.city")
+	assert.Contains(t, byPath, "addresses[0].city")
+
+	// Index 可以從根 struct 找回同一個欄位，嵌入的 struct 也一樣；經過 slice 的欄位沒有 Index
+	rv := reflect.ValueOf(&u).Elem()
+	for _, path := range []string{"id", "name", "home.city"} {
+		f := byPath[path]
+		assert.Equal(t, f.Value.Interface(), rv.FieldByIndex(f.Index).Interface(), path)
+	}
+	assert.Nil(t, byPath["addresses[0].city"].Index)
+}
+
+func TestFromString(t *testing.T) {
+	var v struct {
+		S  string
+		B  bool
+		I  int8
+		U  uint
+		F  float64
+		D  time.Duration
+		T  time.Time
+		P  *int
+		Ch chan int
+	}
+	rv := reflect.ValueOf(&v).Elem()
+	for name, s := range map[string]string{"S": "x", "B": "true", "I": "-8", "U": "7", "F": "1.5", "D": "1m30s", "T": "2024-01-02T03:04:05Z", "P": "42"} {
+		assert.NoError(t, FromString(rv.FieldByName(name), s), name)
+	}
+	assert.Equal(t, "x", v.S)
+	assert.True(t, v.B)
+	assert.Equal(t, int8(-8), v.I)
+	assert.Equal(t, uint(7), v.U)
+	assert.Equal(t, 1.5, v.F)
+	assert.Equal(t, 90*time.Second, v.D)
+	assert.Equal(t, time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC), v.T)
+	assert.Equal(t, 42, *v.P)
+
+	for name, s := range map[string]string{"B": "yes", "I": "300", "U": "-1", "F": "one", "D": "soon"} {
+		assert.Error(t, FromString(rv.FieldByName(name), s), name)
+	}
+	err := FromString(rv.FieldByName("I"), "abc")
+	assert.EqualError(t, err, "must be an integer")
+	assert.ErrorIs(t, err, strconv.ErrSyntax)
+	assert.Error(t, FromString(rv.FieldByName("Ch"), "1"))
+}
+
+func TestWalkSkipStruct(t *testing.T) {
+	u := User{Home: &Address{}}
+	var paths []string
+	_ = Walk(u, func(f Field) error {
+		paths = append(paths, f.Path)
+		if f.Path == "Home" {
+			return SkipStruct
+		}
+		return nil
+	})
+	assert.Contains(t, paths, "Home")
+	assert.NotContains(t, paths, "Home.City")
+
+	assert.Error(t, Walk(1, func(Field) error { return nil }))
+}
+
+func TestTagName(t *testing.T) {
+	sf, _ := reflect.TypeOf(User{}).FieldByName("Name")
+	name, opts := TagName(sf, "validate")
+	assert.Equal(t, "required", name)
+	assert.Empty(t, opts)
+
+	sf, _ = reflect.TypeOf(Address{}).FieldByName("Zip")
+	name, opts = TagName(sf, "json")
+	assert.Equal(t, "zip", name)
+	assert.Equal(t, []string{"omitempty"}, opts)
+}
+
+func TestSetAndGet(t *testing.T) {
+	var u User
+	assert.NoError(t, Set(&u, "Name", "tom"))
+	assert.NoError(t, Set(&u, "Age", 18.0)) // float64 -> int 轉換
+	assert.NoError(t, Set(&u, "Home.City", "Taipei"))
+	assert.NoError(t, Set(&u, "ID", 7))
+	// 未導出的嵌入 pointer 為 nil 時無法配置，跟 encoding/json 的限制一樣
+	assert.ErrorIs(t, Set(&u, "Note", "x"), ErrUnexported)
+	u.inner = &inner{}
+	assert.NoError(t, Set(&u, "Note", "embedded pointer"))
+	assert.NoError(t, Set(&u, "Meta.env", "prod"))
+
+	u.Addresses = make([]Address, 3)
+	assert.NoError(t, Set(&u, "Addresses[2].City", "Tainan"))
+
+	assert.Equal(t, "tom", u.Name)
+	assert.Equal(t, 18, u.Age)
+	assert.Equal(t, "Taipei", u.Home.City)
+	assert.Equal(t, 7, u.ID)
+	assert.Equal(t, "embedded pointer", u.inner.Note)
+	assert.Equal(t, "prod", u.Meta["env"])
+	assert.Equal(t, "Tainan", u.Addresses[2].City)
+
+	v, err := Get(u, "Addresses[2].City")
+	assert.NoError(t, err)
+	assert.Equal(t, "Tainan", v)
+	v, err = Get(&u, "Home.City")
+	assert.NoError(t, err)
+	assert.Equal(t, "Taipei", v)
+	v, err = Get(u, "Meta.env")
+	assert.NoError(t, err)
+	assert.Equal(t, "prod", v)
+}
+
+func TestSetErrors(t *testing.T) {
+	var u User
+	assert.ErrorIs(t, Set(&u, "secret", "x"), ErrUnexported)
+	assert.ErrorIs(t, Set(&u, "Missing", 1), ErrNotFound)
+	assert.ErrorIs(t, Set(&u, "Addresses[0].City", "x"), ErrIndex)
+	assert.Error(t, Set(&u, "Name", 1)) // int 不會被轉成 string
+	assert.Error(t, Set(u, "Name", "x"))
+	assert.Error(t, Set(&u, "Name[", "x"))
+	assert.Error(t, Set(&u, "Tags[-1]", "x"))
+
+	// 非 string key 的 map 回傳錯誤，不能 panic
+	var ids struct{ M map[int]string }
+	assert.Error(t, Set(&ids, "M.foo", "x"))
+	assert.Nil(t, ids.M)
+
+	_, err := Get(u, "Home.City") // nil pointer
+	assert.ErrorIs(t, err, ErrNotFound)
+	_, err = Get(u, "secret")
+	assert.ErrorIs(t, err, ErrUnexported)
+	_, err = Get(ids, "M.foo")
+	assert.Error(t, err)
+}
+
+func TestToMapFromMap(t *testing.T) {
+	created := time.Date(2022, 1, 2, 3, 4, 5, 0, time.UTC)
+	u := User{
+		Base:      Base{ID: 1, Created: created},
+		inner:     &inner{Note: "n"},
+		Name:      "tom",
+		Home:      &Address{City: "Taipei"},
+		Addresses: []Address{{City: "A", Zip: "100"}},
+		Tags:      []string{"a"},
+		Password:  "hidden",
+		secret:    "x",
+	}
+	m, err := ToMap(&u, "json")
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{
+		"id":        1,
+		"created":   created,
+		"note":      "n",
+		"name":      "tom",
+		"age":       0,
+		"home":      map[string]interface{}{"city": "Taipei", "zip": ""},
+		"addresses": []interface{}{map[string]interface{}{"city": "A", "zip": "100"}},
+		"tags":      []string{"a"},
+		"meta":      map[string]string(nil),
+	}, m)
+
+	out := User{inner: &inner{}} // 未導出的嵌入 pointer 需要事先配置
+	assert.NoError(t, FromMap(m, &out, "json"))
+	u.Password, u.secret = "", ""
+	assert.Equal(t, u, out)
+}
+
+// 沒有指定 tag 時使用欄位名稱；像 JSON decode 出來的 float64 也能轉回 int
+func TestFromMapConversion(t *testing.T) {
+	m := map[string]interface{}{
+		"Name":      "amy",
+		"Age":       float64(20),
+		"Addresses": []interface{}{map[string]interface{}{"City": "X"}},
+		"Home":      map[string]interface{}{"City": "Y"},
+	}
+	var u User
+	assert.NoError(t, FromMap(m, &u, ""))
+	assert.Equal(t, 20, u.Age)
+	assert.Equal(t, "X", u.Addresses[0].City)
+	assert.Equal(t, "Y", u.Home.City)
+
+	assert.Error(t, FromMap(map[string]interface{}{"Age": "old"}, &u, ""))
+	assert.Error(t, FromMap(m, u, ""))
+	_, err := ToMap(1, "")
+	assert.Error(t, err)
+}
+
+type shipment struct {
+	To    Address  `json:"to"`
+	Items []string `json:"items" validate:"min=1,max=2"`
+	Note  string   `json:"note" validate:"max=3"`
+	Speed string   `json:"speed" validate:"required,oneof=slow fast"`
+}
+
+func (s *shipment) Validate() error {
+	if s.Speed == "fast" && len(s.Items) > 1 {
+		return errors.New("fast shipments carry one item")
+	}
+	return nil
+}
+
+func (a *Address) Validate() error {
+	if a.City == "" {
+		return errors.New("city is empty")
+	}
+	return nil
+}
+
+func TestValidate(t *testing.T) {
+	var got []string
+	report := func(path string, err error) { got = append(got, path+": "+err.Error()) }
+	byTag := func(sf reflect.StructField) string { return sf.Tag.Get("json") }
+
+	s := shipment{Items: []string{"a", "b", "c"}, Note: "三個字", Speed: "fast"}
+	assert.NoError(t, Validate(&s, byTag, report))
+	assert.Equal(t, []string{
+		"items: length must be <= 2",
+		// note 是 3 個 rune（9 bytes），沒有超過
+		"to: city is empty", // 先內層的 Validator，再外層
+		": fast shipments carry one item",
+	}, got)
+
+	got = nil
+	assert.NoError(t, Validate(&shipment{To: Address{City: "x"}, Items: []string{"a"}}, nil, report))
+	assert.Equal(t, []string{"Speed: is required"}, got, "同一個欄位只回報第一個錯誤")
+}
+
+func TestValidateBadRule(t *testing.T) {
+	report := func(path string, err error) { t.Errorf("unexpected report %s: %v", path, err) }
+	for _, v := range []interface{}{
+		&struct {
+			N int `validate:"min=one"`
+		}{},
+		&struct {
+			B bool `validate:"max=1"`
+		}{},
+		&struct {
+			S string `validate:"email"`
+		}{},
+	} {
+		err := Validate(v, nil, report)
+		assert.ErrorIs(t, err, ErrBadRule)
+	}
+}
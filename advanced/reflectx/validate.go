@@ -0,0 +1,145 @@
+package reflectx
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+/*
+Validate 依 struct tag 檢查欄位，httpbind 與 config/yamlenv 共用同一套規則：
+
+	Name string `validate:"required,max=32"`
+	Port int    `validate:"min=1,max=65535"`
+	Mode string `validate:"oneof=debug info"`
+
+  - required：不是零值
+  - min / max：數字比較值本身，string 比較字元（rune）數，slice / map 比較長度
+  - oneof：以空白分隔的候選值
+  - 同一個欄位只回報第一個不符合的規則
+  - 欄位檢查完之後，由內而外呼叫實作 Validator 的 struct，最外層的最後呼叫
+
+tag 寫錯（未知的規則、min 不是數字、型別不支援）是程式的 bug，不是輸入的問題：
+Validate 停下來回傳 ErrBadRule，而不是把它當成欄位錯誤回報，也不會 panic。
+*/
+
+var (
+	ErrRequired = errors.New("is required")
+	ErrBadRule  = errors.New("reflectx: bad validate rule")
+)
+
+// Validator 讓 struct 自行做跨欄位檢查
+type Validator interface {
+	Validate() error
+}
+
+var validatorType = reflect.TypeOf((*Validator)(nil)).Elem()
+
+// Validate 檢查 v（struct 或 struct 指標），每個錯誤連同欄位路徑交給 report；
+// 路徑由 name 組成（nil 時使用欄位名稱），struct 自己的 Validate 以該 struct 的路徑回報，最外層為 ""
+func Validate(v interface{}, name func(reflect.StructField) string, report func(path string, err error)) error {
+	type pending struct {
+		path string
+		v    Validator
+	}
+	var validators []pending
+	if val, ok := validatorOf(reflect.ValueOf(v)); ok {
+		validators = append(validators, pending{"", val})
+	}
+	err := WalkNamed(v, name, func(f Field) error {
+		if rules := f.Struct.Tag.Get("validate"); rules != "" {
+			for _, rule := range strings.Split(rules, ",") {
+				if err := checkRule(f.Value, rule); err != nil {
+					if errors.Is(err, ErrBadRule) {
+						return fmt.Errorf("%s: %w", f.Path, err)
+					}
+					report(f.Path, err)
+					break
+				}
+			}
+		}
+		if val, ok := validatorOf(f.Value); ok {
+			validators = append(validators, pending{f.Path, val})
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	// Walk 是先序走訪，反過來就是先內層再外層
+	for i := len(validators) - 1; i >= 0; i-- {
+		if err := validators[i].v.Validate(); err != nil {
+			report(validators[i].path, err)
+		}
+	}
+	return nil
+}
+
+// validatorOf 回傳實作 Validator 的 struct；pointer receiver 也算
+func validatorOf(v reflect.Value) (Validator, bool) {
+	v = indirect(v)
+	if v.Kind() != reflect.Struct || !v.CanAddr() || !v.Addr().Type().Implements(validatorType) {
+		return nil, false
+	}
+	return v.Addr().Interface().(Validator), true
+}
+
+func checkRule(v reflect.Value, rule string) error {
+	name, arg, _ := strings.Cut(strings.TrimSpace(rule), "=")
+	switch name {
+	case "required":
+		if v.IsZero() {
+			return ErrRequired
+		}
+	case "min", "max":
+		limit, err := strconv.ParseFloat(arg, 64)
+		if err != nil {
+			return fmt.Errorf("%w %q", ErrBadRule, rule)
+		}
+		n, isLen, ok := measure(v)
+		if !ok {
+			return fmt.Errorf("%w %q for %s", ErrBadRule, rule, v.Type())
+		}
+		what := "must be"
+		if isLen {
+			what = "length must be"
+		}
+		if name == "min" && n < limit {
+			return fmt.Errorf("%s >= %s", what, arg)
+		}
+		if name == "max" && n > limit {
+			return fmt.Errorf("%s <= %s", what, arg)
+		}
+	case "oneof":
+		s := fmt.Sprint(v.Interface())
+		for _, opt := range strings.Fields(arg) {
+			if s == opt {
+				return nil
+			}
+		}
+		return fmt.Errorf("must be one of [%s]", arg)
+	default:
+		return fmt.Errorf("%w %q", ErrBadRule, rule)
+	}
+	return nil
+}
+
+// measure：數字比較值本身，string 比較 rune 數，slice / map 比較長度
+func measure(v reflect.Value) (n float64, isLen, ok bool) {
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(v.Int()), false, true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(v.Uint()), false, true
+	case reflect.Float32, reflect.Float64:
+		return v.Float(), false, true
+	case reflect.String:
+		return float64(utf8.RuneCountInString(v.String())), true, true
+	case reflect.Slice, reflect.Map:
+		return float64(v.Len()), true, true
+	}
+	return 0, false, false
+}
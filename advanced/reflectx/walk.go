@@ -0,0 +1,153 @@
+package reflectx
+
+import (
+	"errors"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+/*
+reflect 的常用工具，給 validation、config 這類「依 struct tag 做事」的 package 共用：
+	- Walk / WalkNamed：遞迴走訪 struct 的每個欄位
+	- Get / Set：以路徑 "a.b[2].c" 讀寫欄位
+	- ToMap / FromMap：struct 與 map[string]interface{} 互轉
+	- FromString：把字串（環境變數、query、CSV 欄位）依欄位型別轉換後寫入
+
+規則：
+	- pointer 會自動解參考，Set 時遇到 nil pointer 會自動配置
+	- 匿名嵌入(embedded) 的 struct 欄位會被攤平，跟 encoding/json 一樣
+	- 未導出(unexported) 的欄位無法透過反射修改，一律略過
+*/
+
+// SkipStruct 由 Walk 的 callback 回傳，表示不要往下走訪這個欄位
+var SkipStruct = errors.New("reflectx: skip struct")
+
+type Field struct {
+	Path   string // 例如 "DB.Replicas[1].Host"
+	Depth  int
+	Value  reflect.Value
+	Struct reflect.StructField
+	// Index 是從根 struct 到這個欄位的索引，可以傳給 FieldByIndex；經過 slice 元素的欄位為 nil
+	Index []int
+}
+
+// Tag 回傳 tag 逗號前的名稱，例如 `json:"name,omitempty"` 回傳 "name"
+func (f Field) Tag(key string) string {
+	name, _ := TagName(f.Struct, key)
+	return name
+}
+
+// TagName 解析 struct tag，回傳名稱與其餘選項
+func TagName(sf reflect.StructField, key string) (string, []string) {
+	tag, ok := sf.Tag.Lookup(key)
+	if !ok {
+		return "", nil
+	}
+	parts := strings.Split(tag, ",")
+	return parts[0], parts[1:]
+}
+
+// Walk 以深度優先走訪 v 的所有導出欄位（包含 slice 中的 struct 元素）
+func Walk(v interface{}, fn func(Field) error) error {
+	return WalkNamed(v, nil, fn)
+}
+
+// WalkNamed 與 Walk 相同，但 Path 由 name 決定每一段的名稱（例如依 tag 命名）；name 為 nil 時使用欄位名稱
+func WalkNamed(v interface{}, name func(reflect.StructField) string, fn func(Field) error) error {
+	rv := indirect(reflect.ValueOf(v))
+	if rv.Kind() != reflect.Struct {
+		return errors.New("reflectx: Walk requires a struct, got " + rv.Kind().String())
+	}
+	if name == nil {
+		name = func(sf reflect.StructField) string { return sf.Name }
+	}
+	w := walker{name: name, fn: fn}
+	return w.walkStruct(rv, "", []int{}, 0)
+}
+
+type walker struct {
+	name func(reflect.StructField) string
+	fn   func(Field) error
+}
+
+func (w walker) walkStruct(v reflect.Value, prefix string, index []int, depth int) error {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		fv := v.Field(i)
+		var idx []int
+		if index != nil {
+			idx = append(append([]int(nil), index...), i)
+		}
+		if sf.Anonymous {
+			inner := indirect(fv)
+			if inner.Kind() == reflect.Struct {
+				// 嵌入的 struct 攤平，路徑不加上型別名稱
+				if err := w.walkStruct(inner, prefix, idx, depth); err != nil {
+					return err
+				}
+				continue
+			}
+		}
+		if !sf.IsExported() {
+			continue
+		}
+		path := joinPath(prefix, w.name(sf))
+		err := w.fn(Field{Path: path, Depth: depth, Value: fv, Struct: sf, Index: idx})
+		if err == SkipStruct {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		if err := w.walkValue(fv, path, idx, depth+1); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (w walker) walkValue(v reflect.Value, path string, index []int, depth int) error {
+	v = indirect(v)
+	switch v.Kind() {
+	case reflect.Struct:
+		if hasExportedFields(v.Type()) {
+			return w.walkStruct(v, path, index, depth)
+		}
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			if err := w.walkValue(v.Index(i), path+"["+strconv.Itoa(i)+"]", nil, depth); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func joinPath(prefix, name string) string {
+	if prefix == "" {
+		return name
+	}
+	return prefix + "." + name
+}
+
+func indirect(v reflect.Value) reflect.Value {
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return reflect.Value{}
+		}
+		v = v.Elem()
+	}
+	return v
+}
+
+// 像 time.Time 這種沒有導出欄位的 struct 視為一個單一的值
+func hasExportedFields(t reflect.Type) bool {
+	for i := 0; i < t.NumField(); i++ {
+		if t.Field(i).IsExported() {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,241 @@
+package render
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	htmltemplate "html/template"
+	"io"
+	"io/fs"
+	"net/http"
+	"path"
+	"strings"
+	"sync"
+	texttemplate "text/template"
+	"time"
+)
+
+/*
+樣板的目錄結構：
+	layouts/*.html   外框，定義 {{define "base"}}，內容區以 {{block "content" .}} 保留
+	partials/*.html  共用片段，例如 {{define "nav"}}
+	pages/*.html     每一頁覆寫 {{define "content"}}
+
+每一頁 = layouts + partials + 該頁的檔案，各自 parse 成一個獨立的 template，
+避免不同頁面的 "content" 互相覆蓋。
+
+html/template 會依照輸出位置（HTML 內文、屬性、URL、JS）自動跳脫，防止 XSS；
+text/template 不會跳脫，只能用在 email、設定檔這類非 HTML 的輸出。
+*/
+
+var ErrPageNotFound = errors.New("render: page not found")
+
+type Options struct {
+	FS       fs.FS
+	Layouts  string // glob，預設 "layouts/*.html"
+	Partials string // glob，預設 "partials/*.html"
+	Pages    string // 目錄，預設 "pages"
+	Entry    string // 執行的 template 名稱，預設 "base"
+	Funcs    map[string]interface{}
+	// Reload 為 true 時每次都重新 parse，開發時修改樣板不用重啟
+	Reload bool
+}
+
+func (o *Options) setDefaults() {
+	if o.Layouts == "" {
+		o.Layouts = "layouts/*.html"
+	}
+	if o.Partials == "" {
+		o.Partials = "partials/*.html"
+	}
+	if o.Pages == "" {
+		o.Pages = "pages"
+	}
+	if o.Entry == "" {
+		o.Entry = "base"
+	}
+}
+
+// DefaultFuncs 預設提供的樣板函式
+func DefaultFuncs() map[string]interface{} {
+	return map[string]interface{}{
+		"upper": strings.ToUpper,
+		"lower": strings.ToLower,
+		"join":  strings.Join,
+		"date": func(layout string, t time.Time) string {
+			return t.Format(layout)
+		},
+		"default": func(def, v interface{}) interface{} {
+			if v == nil || v == "" {
+				return def
+			}
+			return v
+		},
+	}
+}
+
+func (o *Options) funcs() map[string]interface{} {
+	funcs := DefaultFuncs()
+	for k, v := range o.Funcs {
+		funcs[k] = v
+	}
+	return funcs
+}
+
+// executor 是 html/template 與 text/template 共同的部分
+type executor interface {
+	ExecuteTemplate(w io.Writer, name string, data interface{}) error
+}
+
+type loader struct {
+	opts  Options
+	parse func(files []string) (executor, error)
+
+	once  sync.Once
+	mu    sync.RWMutex
+	cache map[string]executor
+	err   error
+}
+
+// lookup 第一次呼叫時才 parse 所有頁面，之後直接使用快取（跟 singleton 的 sync.Once 寫法一樣）
+func (l *loader) lookup(page string) (executor, error) {
+	if l.opts.Reload {
+		cache, err := l.parseAll()
+		if err != nil {
+			return nil, err
+		}
+		return pick(cache, page)
+	}
+	l.once.Do(func() {
+		cache, err := l.parseAll()
+		l.mu.Lock()
+		l.cache, l.err = cache, err
+		l.mu.Unlock()
+	})
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	if l.err != nil {
+		return nil, l.err
+	}
+	return pick(l.cache, page)
+}
+
+func pick(cache map[string]executor, page string) (executor, error) {
+	t, ok := cache[page]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrPageNotFound, page)
+	}
+	return t, nil
+}
+
+func (l *loader) parseAll() (map[string]executor, error) {
+	layouts, err := fs.Glob(l.opts.FS, l.opts.Layouts)
+	if err != nil {
+		return nil, err
+	}
+	partials, err := fs.Glob(l.opts.FS, l.opts.Partials)
+	if err != nil {
+		return nil, err
+	}
+	pages, err := fs.Glob(l.opts.FS, path.Join(l.opts.Pages, "*"))
+	if err != nil {
+		return nil, err
+	}
+	base := append(layouts, partials...)
+	cache := make(map[string]executor, len(pages))
+	for _, p := range pages {
+		t, err := l.parse(append(append([]string(nil), base...), p))
+		if err != nil {
+			return nil, fmt.Errorf("render: parse %s: %w", p, err)
+		}
+		name := strings.TrimSuffix(path.Base(p), path.Ext(p))
+		cache[name] = t
+	}
+	return cache, nil
+}
+
+func (l *loader) execute(w io.Writer, page string, data interface{}) error {
+	t, err := l.lookup(page)
+	if err != nil {
+		return err
+	}
+	return t.ExecuteTemplate(w, l.opts.Entry, data)
+}
+
+// HTML 使用 html/template 輸出網頁
+type HTML struct {
+	loader
+}
+
+func NewHTML(opts Options) *HTML {
+	opts.setDefaults()
+	funcs := htmltemplate.FuncMap(opts.funcs())
+	r := &HTML{}
+	r.opts = opts
+	r.parse = func(files []string) (executor, error) {
+		return htmltemplate.New(path.Base(files[0])).Funcs(funcs).ParseFS(opts.FS, files...)
+	}
+	return r
+}
+
+// Execute 把 page 輸出到任意 writer
+func (r *HTML) Execute(w io.Writer, page string, data interface{}) error {
+	return r.execute(w, page, data)
+}
+
+// Render 先輸出到 buffer，成功才寫出，出錯時回應 500 而不是半頁 HTML
+func (r *HTML) Render(w http.ResponseWriter, status int, page string, data interface{}) error {
+	var buf bytes.Buffer
+	if err := r.execute(&buf, page, data); err != nil {
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return err
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(status)
+	_, err := buf.WriteTo(w)
+	return err
+}
+
+// Stream 邊執行邊寫出並 flush，適合很大的頁面，瀏覽器可以先顯示前面的部分；
+// 代價是一旦開始輸出就無法再改 status code，出錯時頁面只會有一半
+func (r *HTML) Stream(w http.ResponseWriter, page string, data interface{}) error {
+	t, err := r.lookup(page)
+	if err != nil {
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return err
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	return t.ExecuteTemplate(flushWriter{w}, r.opts.Entry, data)
+}
+
+type flushWriter struct {
+	w http.ResponseWriter
+}
+
+func (f flushWriter) Write(p []byte) (int, error) {
+	n, err := f.w.Write(p)
+	if fl, ok := f.w.(http.Flusher); ok {
+		fl.Flush()
+	}
+	return n, err
+}
+
+// Text 使用 text/template，不做任何跳脫
+type Text struct {
+	loader
+}
+
+func NewText(opts Options) *Text {
+	opts.setDefaults()
+	funcs := texttemplate.FuncMap(opts.funcs())
+	r := &Text{}
+	r.opts = opts
+	r.parse = func(files []string) (executor, error) {
+		return texttemplate.New(path.Base(files[0])).Funcs(funcs).ParseFS(opts.FS, files...)
+	}
+	return r
+}
+
+func (r *Text) Execute(w io.Writer, page string, data interface{}) error {
+	return r.execute(w, page, data)
+}
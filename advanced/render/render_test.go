@@ -0,0 +1,155 @@
+package render
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func testFS() fstest.MapFS {
+	return fstest.MapFS{
+		"layouts/base.html": {Data: []byte(
+			`{{define "base"}}<html><head><title>{{block "title" .}}site{{end}}</title></head>` +
+				`<body>{{template "nav" .}}{{block "content" .}}{{end}}</body></html>{{end}}`)},
+		"partials/nav.html": {Data: []byte(`{{define "nav"}}<nav>{{upper .Site}}</nav>{{end}}`)},
+		"pages/home.html": {Data: []byte(
+			`{{define "title"}}Home{{end}}{{define "content"}}<h1>Hello, {{.Name}}</h1>{{end}}`)},
+		"pages/profile.html": {Data: []byte(
+			`{{define "content"}}<a href="{{.URL}}" title="{{.Name}}">{{.Name}}</a>` +
+				`<script>var user = {{.Name}};</script>{{end}}`)},
+		"pages/list.html": {Data: []byte(
+			`{{define "content"}}{{range .Items}}<p>{{.}}</p>{{end}}{{shout "x"}}{{end}}`)},
+		"pages/broken.html": {Data: []byte(`{{define "content"}}{{.Missing.Field}}{{end}}`)},
+		"pages/email.txt": {Data: []byte(
+			`{{define "content"}}Hi {{.Name}}, joined {{date "2006-01-02" .At}}{{end}}`)},
+	}
+}
+
+func newHTML(reload bool) *HTML {
+	return NewHTML(Options{
+		FS:     testFS(),
+		Funcs:  map[string]interface{}{"shout": func(s string) string { return s + "!" }},
+		Reload: reload,
+	})
+}
+
+type data struct {
+	Site  string
+	Name  string
+	URL   string
+	Items []string
+}
+
+func TestLayoutComposition(t *testing.T) {
+	r := newHTML(false)
+	var sb strings.Builder
+	assert.NoError(t, r.Execute(&sb, "home", data{Site: "go", Name: "Tom"}))
+	assert.Equal(t, `<html><head><title>Home</title></head><body><nav>GO</nav><h1>Hello, Tom</h1></body></html>`, sb.String())
+
+	// 沒有覆寫 title 的頁面使用 layout 的預設值，且不會拿到 home 的 title
+	sb.Reset()
+	assert.NoError(t, r.Execute(&sb, "list", data{Items: []string{"a", "b"}}))
+	assert.Equal(t, `<html><head><title>site</title></head><body><nav></nav><p>a</p><p>b</p>x!</body></html>`, sb.String())
+
+	assert.ErrorIs(t, r.Execute(&sb, "nope", nil), ErrPageNotFound)
+}
+
+func TestXSSEscaping(t *testing.T) {
+	r := newHTML(false)
+	var sb strings.Builder
+	err := r.Execute(&sb, "profile", data{
+		Name: `<script>alert("x")</script>" onmouseover="alert(1)`,
+		URL:  `javascript:alert(1)`,
+	})
+	assert.NoError(t, err)
+	out := sb.String()
+
+	assert.NotContains(t, out, `<script>alert`)
+	assert.NotContains(t, out, `" onmouseover="`)
+	// HTML 內文
+	assert.Contains(t, out, `&lt;script&gt;alert(&#34;x&#34;)&lt;/script&gt;`)
+	// 危險的 URL scheme 會被換成 #ZgotmplZ
+	assert.Contains(t, out, `href="#ZgotmplZ"`)
+	// JS 內容會被轉成 JS 字串字面值，< > 也會被跳脫成 \u003c \u003e
+	assert.Contains(t, out, `var user = "\u003cscript\u003ealert(\"x\")\u003c/script\u003e\" onmouseover=\"alert(1)";`)
+}
+
+func TestTextTemplateNoEscaping(t *testing.T) {
+	r := NewText(Options{
+		FS:    testFS(),
+		Entry: "content",
+		Funcs: map[string]interface{}{"shout": strings.ToUpper},
+	})
+	var sb strings.Builder
+	at := time.Date(2022, 3, 4, 0, 0, 0, 0, time.UTC)
+	assert.NoError(t, r.Execute(&sb, "email", map[string]interface{}{"Name": "<Tom>", "At": at}))
+	assert.Equal(t, "Hi <Tom>, joined 2022-03-04", sb.String())
+}
+
+func TestRenderBuffered(t *testing.T) {
+	r := newHTML(false)
+	rec := httptest.NewRecorder()
+	assert.NoError(t, r.Render(rec, http.StatusCreated, "home", data{Name: "Tom"}))
+	assert.Equal(t, http.StatusCreated, rec.Code)
+	assert.Equal(t, "text/html; charset=utf-8", rec.Header().Get("Content-Type"))
+
+	// 執行失敗時不會輸出半頁
+	rec = httptest.NewRecorder()
+	assert.Error(t, r.Render(rec, http.StatusOK, "broken", data{}))
+	assert.Equal(t, http.StatusInternalServerError, rec.Code)
+	assert.NotContains(t, rec.Body.String(), "<html>")
+}
+
+func TestStream(t *testing.T) {
+	r := newHTML(false)
+	rec := httptest.NewRecorder()
+	assert.NoError(t, r.Stream(rec, "home", data{Name: "Tom"}))
+	assert.True(t, rec.Flushed)
+	assert.Contains(t, rec.Body.String(), "Hello, Tom")
+
+	// 串流模式出錯時前面的內容已經送出
+	rec = httptest.NewRecorder()
+	assert.Error(t, r.Stream(rec, "broken", data{}))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), "<html>")
+}
+
+func TestCacheAndReload(t *testing.T) {
+	fsys := testFS()
+	cached := NewHTML(Options{FS: fsys, Funcs: map[string]interface{}{"shout": strings.ToUpper}})
+	reload := NewHTML(Options{FS: fsys, Funcs: map[string]interface{}{"shout": strings.ToUpper}, Reload: true})
+
+	var sb strings.Builder
+	assert.NoError(t, cached.Execute(&sb, "home", data{Name: "a"}))
+	assert.NoError(t, reload.Execute(&sb, "home", data{Name: "a"}))
+
+	fsys["pages/home.html"] = &fstest.MapFile{Data: []byte(`{{define "content"}}changed{{end}}`)}
+
+	sb.Reset()
+	assert.NoError(t, cached.Execute(&sb, "home", data{}))
+	assert.NotContains(t, sb.String(), "changed")
+	sb.Reset()
+	assert.NoError(t, reload.Execute(&sb, "home", data{}))
+	assert.Contains(t, sb.String(), "changed")
+}
+
+func TestParseError(t *testing.T) {
+	fsys := testFS()
+	fsys["pages/bad.html"] = &fstest.MapFile{Data: []byte(`{{define "content"}}{{if}}{{end}}`)}
+	r := NewHTML(Options{FS: fsys, Funcs: map[string]interface{}{"shout": strings.ToUpper}})
+	err := r.Execute(&strings.Builder{}, "home", nil)
+	assert.ErrorContains(t, err, "pages/bad.html")
+}
+
+func BenchmarkCachedRender(b *testing.B) {
+	r := newHTML(false)
+	d := data{Site: "go", Name: "Tom"}
+	for i := 0; i < b.N; i++ {
+		_ = r.Execute(&strings.Builder{}, "home", d)
+	}
+}
@@ -0,0 +1,47 @@
+package worksteal
+
+import "sync"
+
+/*
+每個 worker 一個 deque：
+  - 自己從 bottom 放、從 bottom 拿（LIFO）：剛產生的子任務資料還在 cache 裡，局部性好
+  - 別人從 top 偷（FIFO）：偷到的是最早放進去的任務，在遞迴分割的工作中通常是最大的一塊，
+    一次偷到大塊工作，就不用一直回來偷
+真正的實作（Go runtime 的 runq、Chase-Lev deque）是 lock-free 的，這裡用 mutex 把重點放在排程策略上。
+*/
+
+type deque struct {
+	mu    sync.Mutex
+	tasks []Task
+}
+
+func (d *deque) pushBottom(t Task) {
+	d.mu.Lock()
+	d.tasks = append(d.tasks, t)
+	d.mu.Unlock()
+}
+
+func (d *deque) popBottom() (Task, bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	n := len(d.tasks)
+	if n == 0 {
+		return nil, false
+	}
+	t := d.tasks[n-1]
+	d.tasks[n-1] = nil
+	d.tasks = d.tasks[:n-1]
+	return t, true
+}
+
+func (d *deque) stealTop() (Task, bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if len(d.tasks) == 0 {
+		return nil, false
+	}
+	t := d.tasks[0]
+	d.tasks[0] = nil
+	d.tasks = d.tasks[1:]
+	return t, true
+}
@@ -0,0 +1,241 @@
+package worksteal
+
+import (
+	"fmt"
+	"math/rand"
+	"runtime"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+/*
+Work stealing vs 單一全域 queue：
+
+	Global：所有 worker 共用一個 queue，每次放、拿任務都要搶同一把鎖，worker 越多競爭越嚴重
+	Stealing：任務放在產生它的 worker 自己的 deque，平常不跟別人競爭；
+	          自己沒事做時才隨機挑一個 victim 從它的 deque 偷
+
+skewed workload（所有任務一開始都在同一個 worker，或任務會不斷產生子任務）時，
+Stealing 靠偷取把工作分散出去，Global 則是每個子任務都要經過那把全域的鎖。
+*/
+
+// Task 可以透過 Spawner 產生子任務
+type Task func(s Spawner)
+
+type Spawner interface {
+	Spawn(Task)
+}
+
+type Scheduler interface {
+	// Run 執行 roots 以及它們產生的所有子任務，全部完成後回傳統計
+	Run(roots ...Task) Stats
+}
+
+type Stats struct {
+	Executed []int64 // 每個 worker 執行的任務數
+	Steals   []int64 // 每個 worker 偷成功的次數
+}
+
+func (s Stats) Total() int64 {
+	var n int64
+	for _, v := range s.Executed {
+		n += v
+	}
+	return n
+}
+
+func (s Stats) TotalSteals() int64 {
+	var n int64
+	for _, v := range s.Steals {
+		n += v
+	}
+	return n
+}
+
+// Render 畫出每個 worker 執行與偷取的數量，長條寬度以最大值為 width
+func (s Stats) Render(width int) string {
+	var maxExec int64 = 1
+	for _, v := range s.Executed {
+		if v > maxExec {
+			maxExec = v
+		}
+	}
+	var b strings.Builder
+	for i, v := range s.Executed {
+		bar := strings.Repeat("#", int(v*int64(width)/maxExec))
+		var steals int64
+		if i < len(s.Steals) {
+			steals = s.Steals[i]
+		}
+		fmt.Fprintf(&b, "worker %2d |%-*s| executed %6d  steals %5d\n", i, width, bar, v, steals)
+	}
+	return b.String()
+}
+
+// Stealing 是 work-stealing 排程器
+type Stealing struct {
+	workers int
+	seed    int64
+}
+
+// NewStealing 建立有 workers 個 worker 的排程器，workers < 1 時視為 1
+func NewStealing(workers int, seed int64) *Stealing {
+	workers = max(workers, 1)
+	return &Stealing{workers: workers, seed: seed}
+}
+
+type stealWorker struct {
+	id     int
+	s      *stealRun
+	deque  deque
+	rnd    *rand.Rand
+	exec   int64
+	steals int64
+}
+
+// stealRun 是一次 Run 的狀態
+type stealRun struct {
+	workers []*stealWorker
+	pending atomic.Int64 // 尚未完成的任務數（含還在 deque 裡的）
+}
+
+func (w *stealWorker) Spawn(t Task) {
+	w.s.pending.Add(1)
+	w.deque.pushBottom(t)
+}
+
+func (w *stealWorker) steal() (Task, bool) {
+	n := len(w.s.workers)
+	if n == 1 {
+		return nil, false
+	}
+	// 從隨機的 victim 開始輪一圈
+	start := w.rnd.Intn(n)
+	for i := 0; i < n; i++ {
+		v := w.s.workers[(start+i)%n]
+		if v == w {
+			continue
+		}
+		if t, ok := v.deque.stealTop(); ok {
+			w.steals++
+			return t, true
+		}
+	}
+	return nil, false
+}
+
+func (w *stealWorker) loop(wg *sync.WaitGroup) {
+	defer wg.Done()
+	for {
+		t, ok := w.deque.popBottom()
+		if !ok {
+			t, ok = w.steal()
+		}
+		if !ok {
+			if w.s.pending.Load() == 0 {
+				return
+			}
+			runtime.Gosched()
+			continue
+		}
+		t(w)
+		w.exec++
+		w.s.pending.Add(-1)
+	}
+}
+
+// Run 把所有 roots 放在 worker 0，其他 worker 只能靠偷取拿到工作
+func (s *Stealing) Run(roots ...Task) Stats {
+	r := &stealRun{workers: make([]*stealWorker, s.workers)}
+	for i := range r.workers {
+		r.workers[i] = &stealWorker{s: r, rnd: rand.New(rand.NewSource(s.seed + int64(i)))}
+	}
+	for _, t := range roots {
+		r.workers[0].Spawn(t)
+	}
+
+	var wg sync.WaitGroup
+	wg.Add(s.workers)
+	for _, w := range r.workers {
+		go w.loop(&wg)
+	}
+	wg.Wait()
+
+	st := Stats{Executed: make([]int64, s.workers), Steals: make([]int64, s.workers)}
+	for i, w := range r.workers {
+		st.Executed[i], st.Steals[i] = w.exec, w.steals
+	}
+	return st
+}
+
+// Global 是所有 worker 共用一個 FIFO queue 的排程器
+type Global struct {
+	workers int
+}
+
+// NewGlobal 建立有 workers 個 worker 的排程器，workers < 1 時視為 1
+func NewGlobal(workers int) *Global {
+	workers = max(workers, 1)
+	return &Global{workers: workers}
+}
+
+type globalRun struct {
+	mu      sync.Mutex
+	tasks   []Task
+	pending atomic.Int64
+}
+
+func (g *globalRun) Spawn(t Task) {
+	g.pending.Add(1)
+	g.mu.Lock()
+	g.tasks = append(g.tasks, t)
+	g.mu.Unlock()
+}
+
+func (g *globalRun) pop() (Task, bool) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if len(g.tasks) == 0 {
+		return nil, false
+	}
+	t := g.tasks[0]
+	g.tasks[0] = nil
+	g.tasks = g.tasks[1:]
+	return t, true
+}
+
+func (g *Global) Run(roots ...Task) Stats {
+	r := &globalRun{}
+	for _, t := range roots {
+		r.Spawn(t)
+	}
+	st := Stats{Executed: make([]int64, g.workers), Steals: make([]int64, g.workers)}
+	var wg sync.WaitGroup
+	wg.Add(g.workers)
+	for i := 0; i < g.workers; i++ {
+		go func(i int) {
+			defer wg.Done()
+			for {
+				t, ok := r.pop()
+				if !ok {
+					if r.pending.Load() == 0 {
+						return
+					}
+					runtime.Gosched()
+					continue
+				}
+				t(r)
+				st.Executed[i]++
+				r.pending.Add(-1)
+			}
+		}(i)
+	}
+	wg.Wait()
+	return st
+}
+
+var (
+	_ Scheduler = (*Stealing)(nil)
+	_ Scheduler = (*Global)(nil)
+)
@@ -0,0 +1,40 @@
+package worksteal
+
+import "sync/atomic"
+
+/*
+測試與 benchmark 用的工作負載：
+  - Tree：每個任務產生兩個子任務直到 depth 為 0（類似 fork-join 的遞迴分割）
+  - Skewed：一個任務一次產生 n 個子任務，成本依 cost(i) 而不同，大部分很輕、少數很重
+*/
+
+var sink atomic.Int64
+
+// spin 模擬 CPU 工作
+func spin(n int) {
+	x := 0
+	for i := 0; i < n; i++ {
+		x += i * i
+	}
+	sink.Add(int64(x & 1))
+}
+
+func Tree(depth, work int) Task {
+	return func(s Spawner) {
+		if depth == 0 {
+			spin(work)
+			return
+		}
+		s.Spawn(Tree(depth-1, work))
+		s.Spawn(Tree(depth-1, work))
+	}
+}
+
+func Skewed(n int, cost func(i int) int) Task {
+	return func(s Spawner) {
+		for i := 0; i < n; i++ {
+			c := cost(i)
+			s.Spawn(func(Spawner) { spin(c) })
+		}
+	}
+}
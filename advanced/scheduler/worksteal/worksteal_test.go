@@ -0,0 +1,136 @@
+package worksteal
+
+import (
+	"runtime"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDeque(t *testing.T) {
+	var d deque
+	for i := 0; i < 3; i++ {
+		i := i
+		d.pushBottom(func(Spawner) { sink.Store(int64(i)) })
+	}
+	run := func(t Task, ok bool) int64 {
+		t(nil)
+		return sink.Load()
+	}
+	assert.EqualValues(t, 2, run(d.popBottom()), "owner pops newest")
+	assert.EqualValues(t, 0, run(d.stealTop()), "thief steals oldest")
+	assert.EqualValues(t, 1, run(d.popBottom()))
+	_, ok := d.stealTop()
+	assert.False(t, ok)
+}
+
+func TestRunsEveryTask(t *testing.T) {
+	for _, s := range []Scheduler{NewStealing(4, 1), NewGlobal(4), NewStealing(0, 1), NewGlobal(0)} {
+		var leaves atomic.Int64
+		var tree func(depth int) Task
+		tree = func(depth int) Task {
+			return func(sp Spawner) {
+				if depth == 0 {
+					leaves.Add(1)
+					return
+				}
+				sp.Spawn(tree(depth - 1))
+				sp.Spawn(tree(depth - 1))
+			}
+		}
+		st := s.Run(tree(10), tree(3))
+		assert.EqualValues(t, 1024+8, leaves.Load())
+		// 每一層的節點都是一個任務：2^(d+1)-1
+		assert.EqualValues(t, 2047+15, st.Total())
+	}
+}
+
+// 所有工作一開始都在 worker 0，其他 worker 只能靠偷取分到工作
+func TestStealingSpreadsSkewedWork(t *testing.T) {
+	defer runtime.GOMAXPROCS(runtime.GOMAXPROCS(4))
+	st := NewStealing(4, 1).Run(Skewed(2000, func(i int) int { return 20000 }))
+	assert.EqualValues(t, 2001, st.Total())
+	assert.Positive(t, st.TotalSteals())
+	busy := 0
+	for _, n := range st.Executed {
+		if n > 0 {
+			busy++
+		}
+	}
+	assert.Greater(t, busy, 1)
+	t.Log("\n" + st.Render(30))
+}
+
+func TestRender(t *testing.T) {
+	st := Stats{Executed: []int64{10, 5}, Steals: []int64{0, 3}}
+	assert.Equal(t, ""+
+		"worker  0 |##########| executed     10  steals     0\n"+
+		"worker  1 |#####     | executed      5  steals     3\n", st.Render(10))
+}
+
+func benchmark(b *testing.B, root func() Task) {
+	const workers = 4
+	for _, s := range []struct {
+		name string
+		s    Scheduler
+	}{
+		{"global", NewGlobal(workers)},
+		{"steal", NewStealing(workers, 1)},
+	} {
+		b.Run(s.name, func(b *testing.B) {
+			var steals int64
+			for i := 0; i < b.N; i++ {
+				steals += s.s.Run(root()).TotalSteals()
+			}
+			b.ReportMetric(float64(steals)/float64(b.N), "steals/op")
+		})
+	}
+}
+
+// 很多細小的任務：Global 每個任務都要搶全域鎖
+func BenchmarkTreeFine(b *testing.B) {
+	benchmark(b, func() Task { return Tree(14, 50) })
+}
+
+func BenchmarkTreeCoarse(b *testing.B) {
+	benchmark(b, func() Task { return Tree(8, 20000) })
+}
+
+// 每 100 個任務有一個是其他任務的 100 倍重
+func BenchmarkSkewed(b *testing.B) {
+	benchmark(b, func() Task {
+		return Skewed(5000, func(i int) int {
+			if i%100 == 0 {
+				return 100000
+			}
+			return 1000
+		})
+	})
+}
+
+/*
+go test -run xxx -bench . -cpu 1,4 ./scheduler/worksteal
+（4 個 worker，在只有 1 個 CPU 的機器上執行）
+
+BenchmarkTreeFine/global           	     219	   5230264 ns/op	         0 steals/op
+BenchmarkTreeFine/global-4         	     109	  12235056 ns/op	         0 steals/op
+BenchmarkTreeFine/steal            	     380	   2861202 ns/op	         1.000 steals/op
+BenchmarkTreeFine/steal-4          	     100	  10134685 ns/op	         9.770 steals/op
+BenchmarkTreeCoarse/global         	     457	   2910345 ns/op	         0 steals/op
+BenchmarkTreeCoarse/global-4       	     148	   8080529 ns/op	         0 steals/op
+BenchmarkTreeCoarse/steal          	     372	   2855341 ns/op	         1.000 steals/op
+BenchmarkTreeCoarse/steal-4        	     134	   8805684 ns/op	         6.903 steals/op
+BenchmarkSkewed/global             	     178	   6703731 ns/op	         0 steals/op
+BenchmarkSkewed/global-4           	      69	  18179919 ns/op	         0 steals/op
+BenchmarkSkewed/steal              	     177	   6598356 ns/op	         1.000 steals/op
+BenchmarkSkewed/steal-4            	      63	  16793397 ns/op	      2497 steals/op
+
+  - 細小任務（TreeFine）差異最明顯：Global 每個任務都要經過同一把鎖，Stealing 大多只碰自己的 deque
+  - 任務夠大（TreeCoarse）時排程成本被工作本身蓋過，兩者差不多
+  - GOMAXPROCS=1 時偷一次（第一個 root）就夠了，其他 worker 根本沒機會執行；
+    GOMAXPROCS=4 時 Skewed 需要偷上千次才能把 worker 0 上的工作分出去，
+    子任務會再產生子任務的 Tree 只要偷幾次就能分到一整棵子樹
+  - 這台機器只有 1 個 CPU，GOMAXPROCS=4 只會增加切換成本，看不到並行的好處；
+    多核心機器上 Stealing 在 skewed workload 的優勢會更明顯
+*/
@@ -0,0 +1,124 @@
+package search
+
+import (
+	"context"
+	"sort"
+	"sync"
+
+	"advanced/concurrency/workerpool"
+)
+
+/*
+Inverted index：term → posting list。
+每個 posting 記錄文件編號與 term 在文件中出現的位置（positional postings），
+位置讓 phrase 查詢可以檢查 "tom sawyer" 是否真的相鄰，而不只是同時出現。
+
+	"tom"    → [{doc 0, pos [3 17]}, {doc 2, pos [0]}]
+	"sawyer" → [{doc 0, pos [4]},    {doc 5, pos [9]}]
+
+posting list 依文件編號遞增排列，AND 查詢就是有序串列的交集。
+
+並發建立索引：tokenize 與建立單一文件的 term → positions 是最花時間的部分，
+交給 workerpool 平行處理；merge 進共用 index 時才上鎖，並在那時分配文件編號，
+所以 posting list 永遠是遞增的，不需要額外排序。
+*/
+
+type Posting struct {
+	Doc       int
+	Positions []int
+}
+
+type Document struct {
+	ID   string
+	Text string
+}
+
+type docInfo struct {
+	id     string
+	length int // token 數
+}
+
+type Index struct {
+	mu       sync.RWMutex
+	docs     []docInfo
+	postings map[string][]Posting
+	tokens   int
+}
+
+func New() *Index {
+	return &Index{postings: make(map[string][]Posting)}
+}
+
+type analyzed struct {
+	id     string
+	length int
+	terms  map[string][]int
+}
+
+func analyze(d Document) analyzed {
+	tokens := Tokenize(d.Text)
+	terms := make(map[string][]int)
+	for pos, tok := range tokens {
+		terms[tok] = append(terms[tok], pos)
+	}
+	return analyzed{id: d.ID, length: len(tokens), terms: terms}
+}
+
+func (ix *Index) merge(a analyzed) {
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+	doc := len(ix.docs)
+	ix.docs = append(ix.docs, docInfo{id: a.id, length: a.length})
+	ix.tokens += a.length
+	for term, pos := range a.terms {
+		ix.postings[term] = append(ix.postings[term], Posting{Doc: doc, Positions: pos})
+	}
+}
+
+// Add 加入一份文件；同一個 ID 加兩次會被視為兩份文件
+func (ix *Index) Add(d Document) {
+	ix.merge(analyze(d))
+}
+
+// Build 用 workers 個 worker 平行加入 docs，文件編號的順序不保證與 docs 相同
+func (ix *Index) Build(ctx context.Context, docs []Document, workers int) error {
+	pool := workerpool.New(workers, workerpool.WithQueueSize(workers*4))
+	defer pool.Close()
+	for _, d := range docs {
+		d := d
+		if err := pool.Submit(ctx, func() { ix.merge(analyze(d)) }); err != nil {
+			return err
+		}
+	}
+	pool.Wait()
+	return nil
+}
+
+func (ix *Index) Len() int {
+	ix.mu.RLock()
+	defer ix.mu.RUnlock()
+	return len(ix.docs)
+}
+
+// Terms 回傳不重複的 term 數
+func (ix *Index) Terms() int {
+	ix.mu.RLock()
+	defer ix.mu.RUnlock()
+	return len(ix.postings)
+}
+
+// Postings 回傳 term 的 posting list（呼叫者不可修改）
+func (ix *Index) Postings(term string) []Posting {
+	ix.mu.RLock()
+	defer ix.mu.RUnlock()
+	return ix.postings[term]
+}
+
+// find 在有序的 posting list 中找 doc，呼叫前需持有讀鎖
+func find(list []Posting, doc int) (Posting, bool) {
+	i := sort.Search(len(list), func(i int) bool { return list[i].Doc >= doc })
+	if i < len(list) && list[i].Doc == doc {
+		return list[i], true
+	}
+	return Posting{}, false
+}
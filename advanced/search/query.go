@@ -0,0 +1,201 @@
+package search
+
+import (
+	"errors"
+	"math"
+	"sort"
+	"strings"
+)
+
+/*
+查詢語法：
+	tom sawyer          兩個字都要出現（AND）
+	tom OR huck         任一個出現
+	"tom sawyer"        相鄰且依序出現（phrase）
+	aunt polly OR "injun joe"   = (aunt AND polly) OR ("injun joe")
+OR 的優先順序最低，不支援括號。
+
+排序使用 TF-IDF：
+	tf  = 1 + ln(term 在文件中出現的次數)    次數的效益遞減，出現 100 次不等於 100 倍相關
+	idf = ln(1 + N / df)                    出現在越少文件的 term 越有鑑別力
+	score = Σ tf × idf / sqrt(文件長度)     長文件不會只因為字多而排在前面
+phrase 視為一個 term，次數為 phrase 在文件中出現的次數。
+*/
+
+var ErrEmptyQuery = errors.New("search: empty query")
+
+// term 是一個字或一個 phrase
+type term []string
+
+// Query 是 OR 連接的多個子句，每個子句內的 term 以 AND 連接
+type Query [][]term
+
+func ParseQuery(q string) (Query, error) {
+	var out Query
+	var clause []term
+	flush := func() {
+		if len(clause) > 0 {
+			out = append(out, clause)
+			clause = nil
+		}
+	}
+	for len(q) > 0 {
+		q = strings.TrimLeft(q, " \t\n")
+		if q == "" {
+			break
+		}
+		if q[0] == '"' {
+			end := strings.IndexByte(q[1:], '"')
+			var phrase string
+			if end < 0 {
+				phrase, q = q[1:], "" // 沒有結尾的引號就到查詢結尾
+			} else {
+				phrase, q = q[1:end+1], q[end+2:]
+			}
+			if toks := Tokenize(phrase); len(toks) > 0 {
+				clause = append(clause, term(toks))
+			}
+			continue
+		}
+		word := q
+		if i := strings.IndexAny(q, " \t\n\""); i >= 0 {
+			word, q = q[:i], q[i:]
+		} else {
+			q = ""
+		}
+		if word == "OR" {
+			flush()
+			continue
+		}
+		for _, tok := range Tokenize(word) {
+			clause = append(clause, term{tok})
+		}
+	}
+	flush()
+	if len(out) == 0 {
+		return nil, ErrEmptyQuery
+	}
+	return out, nil
+}
+
+type Hit struct {
+	ID    string
+	Score float64
+}
+
+// Search 回傳分數最高的 limit 筆結果，limit <= 0 表示全部
+func (ix *Index) Search(q string, limit int) ([]Hit, error) {
+	query, err := ParseQuery(q)
+	if err != nil {
+		return nil, err
+	}
+	ix.mu.RLock()
+	defer ix.mu.RUnlock()
+
+	scores := make(map[int]float64)
+	for _, clause := range query {
+		matches := make([]map[int]int, len(clause))
+		for i, t := range clause {
+			matches[i] = ix.match(t)
+		}
+		for doc := range intersect(matches) {
+			for _, m := range matches {
+				scores[doc] += ix.score(m[doc], len(m), doc)
+			}
+		}
+	}
+
+	hits := make([]Hit, 0, len(scores))
+	for doc, s := range scores {
+		hits = append(hits, Hit{ID: ix.docs[doc].id, Score: s})
+	}
+	sort.Slice(hits, func(i, j int) bool {
+		if hits[i].Score != hits[j].Score {
+			return hits[i].Score > hits[j].Score
+		}
+		return hits[i].ID < hits[j].ID
+	})
+	if limit > 0 && len(hits) > limit {
+		hits = hits[:limit]
+	}
+	return hits, nil
+}
+
+func (ix *Index) score(freq, df, doc int) float64 {
+	tf := 1 + math.Log(float64(freq))
+	idf := math.Log(1 + float64(len(ix.docs))/float64(df))
+	return tf * idf / math.Sqrt(float64(ix.docs[doc].length))
+}
+
+// match 回傳 doc → 出現次數
+func (ix *Index) match(t term) map[int]int {
+	first := ix.postings[t[0]]
+	out := make(map[int]int)
+	if len(t) == 1 {
+		for _, p := range first {
+			out[p.Doc] = len(p.Positions)
+		}
+		return out
+	}
+	rest := make([][]Posting, len(t)-1)
+	for i, w := range t[1:] {
+		rest[i] = ix.postings[w]
+		if len(rest[i]) == 0 {
+			return out
+		}
+	}
+next:
+	for _, p := range first {
+		others := make([][]int, len(rest))
+		for i, list := range rest {
+			q, ok := find(list, p.Doc)
+			if !ok {
+				continue next
+			}
+			others[i] = q.Positions
+		}
+		if n := phraseCount(p.Positions, others); n > 0 {
+			out[p.Doc] = n
+		}
+	}
+	return out
+}
+
+// phraseCount 計算 start 中有幾個位置 p 滿足 others[i] 含有 p+i+1
+func phraseCount(start []int, others [][]int) int {
+	n := 0
+	for _, p := range start {
+		ok := true
+		for i, pos := range others {
+			want := p + i + 1
+			j := sort.SearchInts(pos, want)
+			if j == len(pos) || pos[j] != want {
+				ok = false
+				break
+			}
+		}
+		if ok {
+			n++
+		}
+	}
+	return n
+}
+
+// intersect 回傳所有 map 都有的 doc，從最小的集合開始檢查
+func intersect(sets []map[int]int) map[int]struct{} {
+	sort.Slice(sets, func(i, j int) bool { return len(sets[i]) < len(sets[j]) })
+	out := make(map[int]struct{})
+	for doc := range sets[0] {
+		in := true
+		for _, s := range sets[1:] {
+			if _, ok := s[doc]; !ok {
+				in = false
+				break
+			}
+		}
+		if in {
+			out[doc] = struct{}{}
+		}
+	}
+	return out
+}
@@ -0,0 +1,234 @@
+package search
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func small() *Index {
+	ix := New()
+	for _, d := range []Document{
+		{"d1", "The quick brown fox jumps over the lazy dog"},
+		{"d2", "A quick brown dog outpaces a quick fox"},
+		{"d3", "Lazy afternoons: the dog sleeps, the fox waits."},
+		{"d4", "Brown bread and quick breakfasts"},
+	} {
+		ix.Add(d)
+	}
+	return ix
+}
+
+func ids(hits []Hit) []string {
+	out := make([]string, len(hits))
+	for i, h := range hits {
+		out[i] = h.ID
+	}
+	return out
+}
+
+func TestTokenize(t *testing.T) {
+	assert.Equal(t, []string{"tom", "s", "fence", "1876", "café"}, Tokenize("Tom's fence -- 1876, Café!"))
+	assert.Empty(t, Tokenize(" ,.; "))
+}
+
+func TestParseQuery(t *testing.T) {
+	q, err := ParseQuery(`aunt polly OR "injun joe" OR huck`)
+	require.NoError(t, err)
+	assert.Equal(t, Query{
+		{{"aunt"}, {"polly"}},
+		{{"injun", "joe"}},
+		{{"huck"}},
+	}, q)
+
+	q, err = ParseQuery(`"unterminated phrase`)
+	require.NoError(t, err)
+	assert.Equal(t, Query{{{"unterminated", "phrase"}}}, q)
+
+	for _, bad := range []string{"", "  ", "OR", `""`} {
+		_, err := ParseQuery(bad)
+		assert.ErrorIs(t, err, ErrEmptyQuery, bad)
+	}
+}
+
+func TestSearch(t *testing.T) {
+	ix := small()
+	hits, err := ix.Search("quick fox", 0)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"d1", "d2"}, ids(hits))
+	assert.Equal(t, "d2", hits[0].ID, "quick 出現兩次且文件較短")
+
+	hits, err = ix.Search("bread OR sleeps", 0)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"d3", "d4"}, ids(hits))
+
+	hits, err = ix.Search(`"brown dog"`, 0)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"d2"}, ids(hits), "d1 有 brown 與 dog 但不相鄰")
+
+	hits, err = ix.Search(`"the lazy dog" OR breakfasts`, 1)
+	require.NoError(t, err)
+	assert.Len(t, hits, 1)
+
+	hits, err = ix.Search("zebra", 0)
+	require.NoError(t, err)
+	assert.Empty(t, hits)
+	hits, err = ix.Search(`"fox zebra"`, 0)
+	require.NoError(t, err)
+	assert.Empty(t, hits)
+}
+
+func TestIDFRanking(t *testing.T) {
+	ix := small()
+	// "the" 幾乎每份文件都有，"lazy" 只有兩份，含 lazy 的文件應該排前面
+	hits, err := ix.Search("the OR lazy", 0)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"d1", "d3"}, ids(hits[:2]))
+}
+
+func TestPhrasePositions(t *testing.T) {
+	assert.Equal(t, 2, phraseCount([]int{0, 5, 9}, [][]int{{1, 6}, {2, 7}}))
+	assert.Equal(t, 0, phraseCount([]int{0}, [][]int{{2}}))
+}
+
+var paragraphSep = regexp.MustCompile(`\n\s*\n`)
+
+// corpus 把 testdata 中的《湯姆歷險記》（Project Gutenberg 公有領域）依段落切成文件
+func corpus(tb testing.TB) []Document {
+	data, err := os.ReadFile("testdata/tom_sawyer.txt")
+	require.NoError(tb, err)
+	var docs []Document
+	for i, p := range paragraphSep.Split(string(data), -1) {
+		if len(Tokenize(p)) < 5 {
+			continue
+		}
+		docs = append(docs, Document{ID: fmt.Sprintf("p%04d", i), Text: p})
+	}
+	return docs
+}
+
+func TestCorpus(t *testing.T) {
+	docs := corpus(t)
+	seq := New()
+	for _, d := range docs {
+		seq.Add(d)
+	}
+	par := New()
+	require.NoError(t, par.Build(context.Background(), docs, 4))
+	assert.Equal(t, seq.Len(), par.Len())
+	assert.Equal(t, seq.Terms(), par.Terms())
+
+	for _, q := range []string{"tom sawyer", `"injun joe"`, "aunt polly OR becky", `"whitewash" fence`} {
+		a, err := seq.Search(q, 0)
+		require.NoError(t, err)
+		b, err := par.Search(q, 0)
+		require.NoError(t, err)
+		assert.NotEmpty(t, a, q)
+		assert.Equal(t, a, b, "平行建立的 index 結果相同：%s", q)
+	}
+
+	hits, err := par.Search(`"injun joe"`, 0)
+	require.NoError(t, err)
+	for _, h := range hits {
+		text := docs[indexOf(docs, h.ID)].Text
+		assert.Regexp(t, `(?i)injun\s+joe`, text)
+	}
+}
+
+func indexOf(docs []Document, id string) int {
+	for i, d := range docs {
+		if d.ID == id {
+			return i
+		}
+	}
+	return -1
+}
+
+func TestConcurrentSearch(t *testing.T) {
+	ix := New()
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 50; j++ {
+				ix.Add(Document{ID: fmt.Sprintf("%d-%d", i, j), Text: strings.Repeat("go gopher ", j+1)})
+				_, err := ix.Search(`"go gopher"`, 5)
+				assert.NoError(t, err)
+			}
+		}(i)
+	}
+	wg.Wait()
+	assert.Equal(t, 200, ix.Len())
+}
+
+func BenchmarkBuild(b *testing.B) {
+	docs := corpus(b)
+	for _, workers := range []int{1, 2, 4, 8} {
+		b.Run(fmt.Sprintf("workers=%d", workers), func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				ix := New()
+				if err := ix.Build(context.Background(), docs, workers); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+	b.Run("sequential", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			ix := New()
+			for _, d := range docs {
+				ix.Add(d)
+			}
+		}
+	})
+}
+
+func BenchmarkSearch(b *testing.B) {
+	ix := New()
+	if err := ix.Build(context.Background(), corpus(b), 4); err != nil {
+		b.Fatal(err)
+	}
+	for _, q := range []string{"tom", "tom huck", "tom OR huck", `"injun joe"`, `"said tom"`} {
+		b.Run(q, func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				if _, err := ix.Search(q, 10); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+/*
+go test -run x -bench . ./search/
+corpus：《湯姆歷險記》約 390KB，依段落切成約 2000 份文件
+（sandbox 只有 1 顆 CPU，worker 數的差異主要是排程雜訊，不代表多核心的加速比）
+
+BenchmarkBuild/workers=1        50   42913183 ns/op   17002124 B/op   102094 allocs/op
+BenchmarkBuild/workers=2        63   41426451 ns/op   16989412 B/op   101685 allocs/op
+BenchmarkBuild/workers=4        75   29139006 ns/op   16990267 B/op   101379 allocs/op
+BenchmarkBuild/workers=8        79   34660995 ns/op   16998200 B/op   101179 allocs/op
+BenchmarkBuild/sequential       56   42522576 ns/op   16888758 B/op    99214 allocs/op
+BenchmarkSearch/tom           3680     372703 ns/op     126120 B/op       59 allocs/op
+BenchmarkSearch/tom_huck     10000     112483 ns/op      58280 B/op       64 allocs/op
+BenchmarkSearch/tom_OR_huck   2512     487575 ns/op     148136 B/op       90 allocs/op
+BenchmarkSearch/"injun_joe"  33170      36483 ns/op      15368 B/op       40 allocs/op
+BenchmarkSearch/"said_tom"   68010      17942 ns/op       3912 B/op       28 allocs/op
+
+  - 建立索引時 analyze 佔大部分時間且可平行，merge 持鎖的部分很短，多核心時應接近線性加速
+  - 單字查詢的成本與 posting list 長度成正比："tom" 出現在數百份文件，需要為每份文件計分
+  - AND 從最小的集合開始交集，"tom huck" 反而比 "tom" 快（只需為少數文件計分）
+  - phrase 從第一個字的 posting list 出發再比對位置，"injun" 很少見，所以 "injun joe" 很快；
+    "said tom" 雖然兩個字都常見，但符合 phrase 的文件少，計分與排序的成本也跟著變小
+*/
@@ -0,0 +1,19 @@
+package search
+
+import (
+	"strings"
+	"unicode"
+)
+
+/*
+Tokenize 的規則刻意保持簡單：
+  - 以非字母、非數字的字元切開，轉成小寫（"Tom's" → "tom", "s"）
+  - 不做 stemming、不移除 stop word：phrase 查詢需要完整的位置資訊，
+    常見字對排序的影響則由 IDF 壓低
+*/
+
+func Tokenize(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
@@ -0,0 +1,144 @@
+package snapshot
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+/*
+用 gob 把記憶體中的資料存成 snapshot 檔案。
+
+直接 os.WriteFile 覆蓋舊檔有兩個問題：
+ 1. 寫到一半當機，舊的 snapshot 已經被截斷，新的又不完整 → 兩份都沒了
+ 2. 讀取端可能讀到寫到一半的檔案
+
+做法：寫到同目錄下的暫存檔 → fsync → rename 蓋過正式檔 → fsync 目錄。
+rename 在同一個檔案系統內是 atomic 的，正式檔永遠是「舊的完整版」或「新的完整版」。
+
+檔案格式另外加上 header（magic + 長度 + crc32），
+即使檔案被其他方式截斷或損壞（例如磁碟問題、手動複製到一半），讀取時也能偵測出來。
+	+-------+----------+----------+------------------+
+	| GLSN  | len (4)  | crc (4)  | gob data         |
+	+-------+----------+----------+------------------+
+*/
+
+var ErrCorrupt = errors.New("snapshot: corrupt file")
+
+var magic = [4]byte{'G', 'L', 'S', 'N'}
+
+// 測試時替換，模擬 rename 前當機
+var rename = os.Rename
+
+const headerSize = 12
+
+// WriteFile 以 gob 編碼 v，並以 atomic 的方式取代 path
+func WriteFile(path string, v interface{}) error {
+	var data bytes.Buffer
+	if err := gob.NewEncoder(&data).Encode(v); err != nil {
+		return fmt.Errorf("snapshot: encode: %w", err)
+	}
+
+	dir := filepath.Dir(path)
+	tmp, err := os.CreateTemp(dir, filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	// 任何一步失敗都要清掉暫存檔；rename 成功後 Remove 會回傳錯誤，直接忽略
+	defer os.Remove(tmp.Name())
+
+	if err := writeTo(tmp, data.Bytes()); err != nil {
+		tmp.Close()
+		return err
+	}
+	// 確保資料真的落到磁碟，否則 rename 之後當機仍可能得到空檔案
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := rename(tmp.Name(), path); err != nil {
+		return err
+	}
+	return syncDir(dir)
+}
+
+func writeTo(w io.Writer, data []byte) error {
+	var h [headerSize]byte
+	copy(h[:4], magic[:])
+	binary.BigEndian.PutUint32(h[4:8], uint32(len(data)))
+	binary.BigEndian.PutUint32(h[8:12], crc32.ChecksumIEEE(data))
+	bw := bufio.NewWriter(w)
+	bw.Write(h[:])
+	bw.Write(data)
+	return bw.Flush()
+}
+
+// ReadFile 讀取並驗證 snapshot，解碼到 v；檔案不存在時回傳的錯誤滿足 errors.Is(err, fs.ErrNotExist)
+func ReadFile(path string, v interface{}) error {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	if len(b) < headerSize || !bytes.Equal(b[:4], magic[:]) {
+		return fmt.Errorf("%w: bad header", ErrCorrupt)
+	}
+	n := binary.BigEndian.Uint32(b[4:8])
+	data := b[headerSize:]
+	if uint32(len(data)) != n {
+		return fmt.Errorf("%w: want %d bytes, got %d", ErrCorrupt, n, len(data))
+	}
+	if crc32.ChecksumIEEE(data) != binary.BigEndian.Uint32(b[8:12]) {
+		return fmt.Errorf("%w: checksum mismatch", ErrCorrupt)
+	}
+	if err := gob.NewDecoder(bytes.NewReader(data)).Decode(v); err != nil {
+		return fmt.Errorf("%w: %v", ErrCorrupt, err)
+	}
+	return nil
+}
+
+// CleanTemp 刪除上次寫到一半留下的暫存檔，通常在啟動時呼叫
+func CleanTemp(path string) error {
+	matches, err := filepath.Glob(path + ".tmp-*")
+	if err != nil {
+		return err
+	}
+	for _, m := range matches {
+		if err := os.Remove(m); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Run 每隔 interval 呼叫一次 save，ctx 結束時再做最後一次 save 後返回。
+// save 的錯誤交給 onErr 處理（可為 nil），不會中斷週期
+func Run(ctx context.Context, interval time.Duration, save func() error, onErr func(error)) {
+	report := func(err error) {
+		if err != nil && onErr != nil {
+			onErr(err)
+		}
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			report(save())
+		case <-ctx.Done():
+			report(save())
+			return
+		}
+	}
+}
@@ -0,0 +1,106 @@
+package snapshot
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type state struct {
+	Version int
+	Items   map[string]int
+}
+
+func TestWriteReadFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.snap")
+	want := state{Version: 1, Items: map[string]int{"a": 1, "b": 2}}
+	assert.NoError(t, WriteFile(path, want))
+
+	var got state
+	assert.NoError(t, ReadFile(path, &got))
+	assert.Equal(t, want, got)
+
+	// 沒有留下暫存檔
+	matches, _ := filepath.Glob(path + ".tmp-*")
+	assert.Empty(t, matches)
+
+	err := ReadFile(filepath.Join(t.TempDir(), "missing"), &got)
+	assert.ErrorIs(t, err, fs.ErrNotExist)
+}
+
+// 模擬寫完暫存檔、rename 之前當機：正式檔仍是上一版
+func TestCrashBeforeRename(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.snap")
+	assert.NoError(t, WriteFile(path, state{Version: 1}))
+
+	defer func(orig func(string, string) error) { rename = orig }(rename)
+	rename = func(string, string) error { return errors.New("power loss") }
+	assert.Error(t, WriteFile(path, state{Version: 2}))
+
+	var got state
+	assert.NoError(t, ReadFile(path, &got))
+	assert.Equal(t, 1, got.Version)
+}
+
+// 模擬寫到一半的各種情況
+func TestTornWrite(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "state.snap")
+	assert.NoError(t, WriteFile(path, state{Version: 1, Items: map[string]int{"a": 1}}))
+	full, err := os.ReadFile(path)
+	assert.NoError(t, err)
+
+	// 1. 當機時留下不完整的暫存檔：不影響正式檔，啟動時可清掉
+	assert.NoError(t, os.WriteFile(path+".tmp-123", full[:len(full)/2], 0o644))
+	var got state
+	assert.NoError(t, ReadFile(path, &got))
+	assert.Equal(t, 1, got.Version)
+	assert.NoError(t, CleanTemp(path))
+	matches, _ := filepath.Glob(path + ".tmp-*")
+	assert.Empty(t, matches)
+
+	// 2. 不是透過 WriteFile 寫入、被截斷的檔案：每一種截斷長度都要被偵測到
+	for n := 0; n < len(full); n++ {
+		assert.NoError(t, os.WriteFile(path, full[:n], 0o644))
+		err := ReadFile(path, &state{})
+		assert.ErrorIs(t, err, ErrCorrupt, "truncated to %d bytes", n)
+	}
+
+	// 3. 內容損壞
+	bad := append([]byte(nil), full...)
+	bad[len(bad)-1] ^= 0xff
+	assert.NoError(t, os.WriteFile(path, bad, 0o644))
+	assert.ErrorIs(t, ReadFile(path, &state{}), ErrCorrupt)
+}
+
+func TestRun(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	var saves int32
+	errs := make(chan error, 10)
+	done := make(chan struct{})
+	go func() {
+		Run(ctx, 10*time.Millisecond, func() error {
+			if atomic.AddInt32(&saves, 1) == 1 {
+				return errors.New("disk full")
+			}
+			return nil
+		}, func(err error) { errs <- err })
+		close(done)
+	}()
+
+	assert.Eventually(t, func() bool { return atomic.LoadInt32(&saves) >= 3 }, time.Second, 5*time.Millisecond)
+	cancel()
+	<-done
+	// 第一次失敗不會中斷週期，結束時還會再存一次
+	assert.EqualError(t, <-errs, "disk full")
+	n := atomic.LoadInt32(&saves)
+	time.Sleep(30 * time.Millisecond)
+	assert.Equal(t, n, atomic.LoadInt32(&saves))
+}
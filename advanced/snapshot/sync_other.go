@@ -0,0 +1,6 @@
+//go:build !unix
+
+package snapshot
+
+// Windows 無法對目錄 fsync
+func syncDir(dir string) error { return nil }
@@ -0,0 +1,15 @@
+//go:build unix
+
+package snapshot
+
+import "os"
+
+// rename 只是修改目錄內容，要 fsync 目錄本身才能確保 rename 也被寫入磁碟
+func syncDir(dir string) error {
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer d.Close()
+	return d.Sync()
+}
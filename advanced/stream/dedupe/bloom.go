@@ -0,0 +1,53 @@
+package dedupe
+
+import "math"
+
+// bloom 是固定大小的 Bloom filter：k 個 hash 各設定 m 個 bit 中的一個，
+// 查詢時 k 個 bit 都是 1 才算「可能出現過」，所以只會誤判出現過（false positive），不會漏判
+type bloom struct {
+	bits []uint64
+	m    uint64 // bit 數
+	k    int    // hash 數
+}
+
+// sizeFor 依預期的元素數 n 與 false positive 機率 p 計算最佳的 m、k：
+//
+//	m = -n·ln p / (ln 2)²，k = (m/n)·ln 2
+func sizeFor(n int, p float64) (m uint64, k int) {
+	mf := math.Ceil(-float64(n) * math.Log(p) / (math.Ln2 * math.Ln2))
+	m = uint64(math.Max(mf, 64))
+	k = int(math.Max(math.Round(mf/float64(n)*math.Ln2), 1))
+	return m, k
+}
+
+func newBloom(m uint64, k int) *bloom {
+	return &bloom{bits: make([]uint64, (m+63)/64), m: m, k: k}
+}
+
+// positions 以 double hashing 由一個 64 位元 hash 推出 k 個位置：h1 + i·h2
+func (b *bloom) positions(h uint64, f func(pos uint64) bool) bool {
+	h1, h2 := h, h>>32|1
+	for i := 0; i < b.k; i++ {
+		if !f((h1 + uint64(i)*h2) % b.m) {
+			return false
+		}
+	}
+	return true
+}
+
+func (b *bloom) contains(h uint64) bool {
+	return b.positions(h, func(pos uint64) bool { return b.bits[pos/64]&(1<<(pos%64)) != 0 })
+}
+
+func (b *bloom) add(h uint64) {
+	b.positions(h, func(pos uint64) bool {
+		b.bits[pos/64] |= 1 << (pos % 64)
+		return true
+	})
+}
+
+func (b *bloom) reset() {
+	for i := range b.bits {
+		b.bits[i] = 0
+	}
+}
@@ -0,0 +1,153 @@
+package dedupe
+
+import (
+	"context"
+	"hash/maphash"
+	"sync"
+)
+
+/*
+串流去重複：無限的資料流不能用 map 記住所有看過的 key，記憶體會一直長。
+
+改用兩個輪替的 Bloom filter（current、previous），記憶體固定：
+  - 查詢：兩個 filter 任一個說「出現過」就丟掉
+  - 加入：不在 current 中的 key 都加到 current（包含只在 previous 中的，讓它留久一點）；
+    current 加滿 window 個之後，previous 清空後變成新的 current（輪替）
+  - 所以最近加入的 window 個 key 一定記得（不會漏掉重複），更早的最多再記 window 個，之後就忘了：
+    視窗是「至少 window、至多 2·window」個最近加入的 key
+
+代價是 false positive：沒出現過的 key 可能被誤判成重複而丟掉。
+每個 filter 以 FalsePositiveRate/2 計算大小，兩個一起查詢的誤判率約為 FalsePositiveRate。
+這適合「少丟一點可以接受、多送重複很貴」的情境，例如重送的訊息、爬蟲的 URL；
+一筆都不能錯丟時要用精確的結構（例如以時間分桶的 map）。
+*/
+
+type config struct {
+	window int
+	fpRate float64
+	seed   maphash.Seed
+}
+
+type Option func(*config)
+
+// WithWindow 設定一定會被記住的最近 key 數量，預設 100000
+func WithWindow(n int) Option {
+	return func(c *config) { c.window = n }
+}
+
+// WithFalsePositiveRate 設定目標誤判率，預設 0.01
+func WithFalsePositiveRate(p float64) Option {
+	return func(c *config) { c.fpRate = p }
+}
+
+// WithSeed 固定 hash 的 seed，讓結果可以重現；預設為隨機
+func WithSeed(seed maphash.Seed) Option {
+	return func(c *config) { c.seed = seed }
+}
+
+// Stats 是 Filter 的累計統計
+type Stats struct {
+	Checked   int64
+	Dropped   int64 // 判定為重複的次數（含誤判）
+	Rotations int64
+}
+
+// Filter 是有固定記憶體上限的「看過了嗎」判斷，可由多個 goroutine 共用
+type Filter struct {
+	mu       sync.Mutex
+	window   int
+	seed     maphash.Seed
+	cur      *bloom
+	prev     *bloom
+	inserted int // 已加入 cur 的數量
+	stats    Stats
+}
+
+func NewFilter(opts ...Option) *Filter {
+	cfg := config{window: 100000, fpRate: 0.01, seed: maphash.MakeSeed()}
+	for _, o := range opts {
+		o(&cfg)
+	}
+	if cfg.window < 1 {
+		cfg.window = 1
+	}
+	if cfg.fpRate <= 0 || cfg.fpRate >= 1 {
+		cfg.fpRate = 0.01
+	}
+	m, k := sizeFor(cfg.window, cfg.fpRate/2)
+	return &Filter{window: cfg.window, seed: cfg.seed, cur: newBloom(m, k), prev: newBloom(m, k)}
+}
+
+// Seen 回傳 key 是否可能出現過；沒出現過時記住它
+func (f *Filter) Seen(key string) bool {
+	h := maphash.String(f.seed, key)
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.stats.Checked++
+	if f.cur.contains(h) {
+		f.stats.Dropped++
+		return true
+	}
+	// 只在 previous 中的也加進 current，否則下次輪替就會被忘記
+	seen := f.prev.contains(h)
+	if f.inserted == f.window {
+		f.cur, f.prev = f.prev, f.cur
+		f.cur.reset()
+		f.inserted = 0
+		f.stats.Rotations++
+	}
+	f.cur.add(h)
+	f.inserted++
+	if seen {
+		f.stats.Dropped++
+	}
+	return seen
+}
+
+// Contains 回傳 key 是否可能出現過，不會記住它，也不計入 Stats
+func (f *Filter) Contains(key string) bool {
+	h := maphash.String(f.seed, key)
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.cur.contains(h) || f.prev.contains(h)
+}
+
+func (f *Filter) Stats() Stats {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.stats
+}
+
+// Bytes 回傳兩個 filter 佔用的記憶體，與處理過的資料量無關
+func (f *Filter) Bytes() int {
+	return 2 * 8 * len(f.cur.bits)
+}
+
+// Stream 轉送 in 中第一次出現的項目，重複（依 key 判斷）的丟掉；in 關閉或 ctx 取消時關閉輸出
+func Stream[T any](ctx context.Context, in <-chan T, f *Filter, key func(T) string) <-chan T {
+	out := make(chan T)
+	go func() {
+		defer close(out)
+		for {
+			var v T
+			var ok bool
+			select {
+			case v, ok = <-in:
+			case <-ctx.Done():
+				return
+			}
+			if !ok {
+				return
+			}
+			if f.Seen(key(v)) {
+				continue
+			}
+			select {
+			case out <- v:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return out
+}
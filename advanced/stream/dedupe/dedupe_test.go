@@ -0,0 +1,118 @@
+package dedupe
+
+import (
+	"context"
+	"hash/maphash"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var seed = maphash.MakeSeed()
+
+func TestSizeFor(t *testing.T) {
+	m, k := sizeFor(1000, 0.01)
+	assert.InDelta(t, 9586, m, 1, "約 9.6 bit / 元素")
+	assert.Equal(t, 7, k)
+
+	m, k = sizeFor(1, 0.5)
+	assert.Equal(t, uint64(64), m, "至少 64 bit")
+	assert.Equal(t, 1, k)
+}
+
+func TestBloomNoFalseNegatives(t *testing.T) {
+	m, k := sizeFor(1000, 0.01)
+	b := newBloom(m, k)
+	for i := 0; i < 1000; i++ {
+		b.add(maphash.String(seed, strconv.Itoa(i)))
+	}
+	for i := 0; i < 1000; i++ {
+		require.True(t, b.contains(maphash.String(seed, strconv.Itoa(i))), i)
+	}
+	b.reset()
+	assert.False(t, b.contains(maphash.String(seed, "0")))
+}
+
+// TestWindow 最近 window 個一定記得；超過 2·window 之前的會被忘記
+func TestWindow(t *testing.T) {
+	f := NewFilter(WithWindow(100), WithFalsePositiveRate(0.001), WithSeed(seed))
+	for i := 0; i < 1000; i++ {
+		f.Seen(strconv.Itoa(i))
+		// 最近 100 個（含自己）都要判定為重複
+		for j := i; j >= 0 && j > i-100; j -= 7 {
+			require.True(t, f.Contains(strconv.Itoa(j)), "i=%d j=%d", i, j)
+		}
+	}
+	assert.LessOrEqual(t, f.Stats().Rotations, int64(9), "誤判的 key 不會加入")
+	assert.True(t, f.Seen("999"))
+
+	// 只在 previous 中的 key 再次出現時會加進 current，多記一個 window
+	assert.True(t, f.Seen("850"))
+	for i := 1000; i < 1100; i++ {
+		f.Seen(strconv.Itoa(i))
+	}
+	assert.True(t, f.Contains("850"))
+	assert.False(t, f.Contains("851") && f.Contains("852") && f.Contains("853"), "沒有再出現的已經忘記")
+
+	forgotten := 0
+	for i := 0; i < 700; i++ {
+		if !f.Seen(strconv.Itoa(i)) {
+			forgotten++
+		}
+	}
+	assert.Greater(t, forgotten, 650, "早於 2·window 的 key 已被忘記（只剩誤判）")
+}
+
+// TestFalsePositiveRate 全部都是新的 key，被丟掉的都是誤判；量測值應接近設定的誤判率
+func TestFalsePositiveRate(t *testing.T) {
+	const n = 200000
+	for _, p := range []float64{0.1, 0.01, 0.001} {
+		f := NewFilter(WithWindow(10000), WithFalsePositiveRate(p), WithSeed(seed))
+		for i := 0; i < n; i++ {
+			f.Seen("key-" + strconv.Itoa(i))
+		}
+		st := f.Stats()
+		rate := float64(st.Dropped) / float64(st.Checked)
+		t.Logf("target %.3f measured %.4f memory %d bytes", p, rate, f.Bytes())
+		assert.Less(t, rate, p*1.5, "target %v", p)
+		assert.Greater(t, rate, p/10, "target %v：兩個 filter 剛輪替後較空，整體會低於上限，但不會差太多", p)
+	}
+}
+
+func TestBytesBounded(t *testing.T) {
+	f := NewFilter(WithWindow(1000), WithSeed(seed))
+	before := f.Bytes()
+	for i := 0; i < 100000; i++ {
+		f.Seen(strconv.Itoa(i))
+	}
+	assert.Equal(t, before, f.Bytes())
+	assert.Less(t, f.Bytes(), 4096)
+}
+
+func TestStream(t *testing.T) {
+	in := make(chan int)
+	go func() {
+		defer close(in)
+		for _, v := range []int{1, 2, 1, 3, 2, 4, 1} {
+			in <- v
+		}
+	}()
+	f := NewFilter(WithWindow(10), WithSeed(seed))
+	var got []int
+	for v := range Stream(context.Background(), in, f, strconv.Itoa) {
+		got = append(got, v)
+	}
+	assert.Equal(t, []int{1, 2, 3, 4}, got)
+	assert.Equal(t, Stats{Checked: 7, Dropped: 3}, f.Stats())
+}
+
+func TestStreamCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	in := make(chan string)
+	out := Stream(ctx, in, NewFilter(), func(s string) string { return s })
+	cancel()
+	_, ok := <-out
+	assert.False(t, ok, "ctx 取消後關閉輸出")
+}
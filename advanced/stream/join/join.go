@@ -0,0 +1,304 @@
+package join
+
+import (
+	"container/heap"
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+/*
+兩條事件流的 interval join：key 相同、時間相差不超過 Window 的左右事件配成一對。
+
+	left:  (user1, 10:00:01, click)
+	right: (user1, 10:00:03, purchase)   Window 5s → 配對
+
+串流無法像 batch 一樣把兩邊全部讀完再 nested loop，只能各自暫存（state），
+每個新事件到達時去對面的暫存中找同 key、時間在範圍內的事件；每一對只會在「後到的那一個」到達時輸出一次。
+
+state 不能無限長，要知道什麼時候可以丟：
+  - watermark = min(左邊看過最大的時間, 右邊看過最大的時間) - Lateness，
+    表示兩邊早於 watermark 的事件都到齊了；只看其中一邊的話，較慢的那邊還沒到的事件會配不到
+  - 暫存的事件 e 只能與時間 >= e.Time - Window 的對面事件配對，而之後到的對面事件都 >= watermark，
+    所以 e.Time + Window < watermark 時 e 不可能再配對，可以丟掉（expire）
+  - Lateness 是容許的亂序：同一邊的事件最多晚 Lateness 到達時，結果與 batch join 完全相同
+  - 早於 watermark 才到的事件是遲到事件，它的配對對象可能已經被丟掉，直接捨棄並計入 Stats.Late
+
+只有一邊有資料（另一邊停住）時 watermark 不會前進，所以每邊另外有 MaxBuffered 的上限，
+超過時丟掉最舊的事件（計入 Stats.Evicted）：記憶體有上限，代價是可能漏掉配對。
+*/
+
+var ErrInvalidWindow = errors.New("join: invalid window")
+
+type Event[K comparable, V any] struct {
+	Key   K
+	Time  time.Time
+	Value V
+}
+
+type Pair[K comparable, L, R any] struct {
+	Key   K
+	Left  Event[K, L]
+	Right Event[K, R]
+}
+
+type Stats struct {
+	Pairs   int64
+	Late    int64 // 遲到而捨棄的事件數
+	Expired int64 // 因 watermark 從 state 移除的事件數
+	Evicted int64 // 因 MaxBuffered 被提早移除的事件數
+	// Buffered 是目前兩邊暫存的事件數，MaxBuffered 之外還受 watermark 限制
+	Buffered int
+}
+
+type config struct {
+	lateness    time.Duration
+	maxBuffered int
+	buffer      int
+}
+
+type Option func(*config)
+
+// WithLateness 設定容許的亂序時間，預設 0
+func WithLateness(d time.Duration) Option {
+	return func(c *config) { c.lateness = d }
+}
+
+// WithMaxBuffered 設定每一邊最多暫存的事件數，預設 100000
+func WithMaxBuffered(n int) Option {
+	return func(c *config) { c.maxBuffered = n }
+}
+
+// WithBuffer 設定輸出 channel 的容量，預設 0
+func WithBuffer(n int) Option {
+	return func(c *config) { c.buffer = n }
+}
+
+// Joiner 執行一次 join，Stats 可以在執行中或結束後讀取
+type Joiner[K comparable, L, R any] struct {
+	window time.Duration
+	cfg    config
+
+	left  *side[K, L]
+	right *side[K, R]
+
+	mu    sync.Mutex
+	stats Stats
+}
+
+func New[K comparable, L, R any](window time.Duration, opts ...Option) (*Joiner[K, L, R], error) {
+	if window < 0 {
+		return nil, ErrInvalidWindow
+	}
+	cfg := config{maxBuffered: 100000}
+	for _, o := range opts {
+		o(&cfg)
+	}
+	return &Joiner[K, L, R]{window: window, cfg: cfg, left: newSide[K, L](), right: newSide[K, R]()}, nil
+}
+
+func (j *Joiner[K, L, R]) Stats() Stats {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return j.stats
+}
+
+func (j *Joiner[K, L, R]) count(f func(s *Stats)) {
+	j.mu.Lock()
+	f(&j.stats)
+	j.mu.Unlock()
+}
+
+// Run 從兩邊讀取事件並輸出配對，兩邊都關閉或 ctx 取消時關閉輸出；只能呼叫一次
+func (j *Joiner[K, L, R]) Run(ctx context.Context, left <-chan Event[K, L], right <-chan Event[K, R]) <-chan Pair[K, L, R] {
+	out := make(chan Pair[K, L, R], j.cfg.buffer)
+	go func() {
+		defer close(out)
+		for left != nil || right != nil {
+			var pairs []Pair[K, L, R]
+			select {
+			case l, ok := <-left:
+				if !ok {
+					left = nil
+					continue
+				}
+				pairs = j.onLeft(l)
+			case r, ok := <-right:
+				if !ok {
+					right = nil
+					continue
+				}
+				pairs = j.onRight(r)
+			case <-ctx.Done():
+				return
+			}
+			for _, p := range pairs {
+				select {
+				case out <- p:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+	return out
+}
+
+func (j *Joiner[K, L, R]) onLeft(l Event[K, L]) []Pair[K, L, R] {
+	if j.late(l.Time) {
+		j.count(func(s *Stats) { s.Late++ })
+		return nil
+	}
+	var pairs []Pair[K, L, R]
+	for _, e := range j.right.byKey[l.Key] {
+		if within(l.Time, e.ev.Time, j.window) {
+			pairs = append(pairs, Pair[K, L, R]{Key: l.Key, Left: l, Right: e.ev})
+		}
+	}
+	j.left.observe(l.Time)
+	evicted := j.left.add(l, j.cfg.maxBuffered)
+	j.finish(len(pairs), evicted)
+	return pairs
+}
+
+func (j *Joiner[K, L, R]) onRight(r Event[K, R]) []Pair[K, L, R] {
+	if j.late(r.Time) {
+		j.count(func(s *Stats) { s.Late++ })
+		return nil
+	}
+	var pairs []Pair[K, L, R]
+	for _, e := range j.left.byKey[r.Key] {
+		if within(e.ev.Time, r.Time, j.window) {
+			pairs = append(pairs, Pair[K, L, R]{Key: r.Key, Left: e.ev, Right: r})
+		}
+	}
+	j.right.observe(r.Time)
+	evicted := j.right.add(r, j.cfg.maxBuffered)
+	j.finish(len(pairs), evicted)
+	return pairs
+}
+
+// finish 推進 watermark、清除過期的 state 並更新統計
+func (j *Joiner[K, L, R]) finish(pairs, evicted int) {
+	expired := 0
+	if wm, ok := j.watermark(); ok {
+		limit := wm.Add(-j.window)
+		expired = j.left.expire(limit) + j.right.expire(limit)
+	}
+	j.count(func(s *Stats) {
+		s.Pairs += int64(pairs)
+		s.Evicted += int64(evicted)
+		s.Expired += int64(expired)
+		s.Buffered = j.left.size() + j.right.size()
+	})
+}
+
+func (j *Joiner[K, L, R]) watermark() (time.Time, bool) {
+	if !j.left.seen || !j.right.seen {
+		return time.Time{}, false
+	}
+	m := j.left.max
+	if j.right.max.Before(m) {
+		m = j.right.max
+	}
+	return m.Add(-j.cfg.lateness), true
+}
+
+func (j *Joiner[K, L, R]) late(t time.Time) bool {
+	wm, ok := j.watermark()
+	return ok && t.Before(wm)
+}
+
+func within(a, b time.Time, window time.Duration) bool {
+	d := a.Sub(b)
+	return d <= window && d >= -window
+}
+
+// side 是一邊的 state：依 key 查詢，另有依時間排序的 heap 用於過期與淘汰
+type side[K comparable, V any] struct {
+	byKey map[K][]*entry[K, V]
+	order entries[K, V]
+	max   time.Time
+	seen  bool
+}
+
+type entry[K comparable, V any] struct {
+	ev  Event[K, V]
+	seq uint64 // 時間相同時依到達順序
+}
+
+func newSide[K comparable, V any]() *side[K, V] {
+	return &side[K, V]{byKey: map[K][]*entry[K, V]{}}
+}
+
+func (s *side[K, V]) observe(t time.Time) {
+	if !s.seen || t.After(s.max) {
+		s.max, s.seen = t, true
+	}
+}
+
+// add 暫存 e，超過 max 時移除最舊的，回傳移除的數量
+func (s *side[K, V]) add(e Event[K, V], max int) int {
+	en := &entry[K, V]{ev: e, seq: s.order.next}
+	s.order.next++
+	heap.Push(&s.order, en)
+	s.byKey[e.Key] = append(s.byKey[e.Key], en)
+	evicted := 0
+	for len(s.order.items) > max {
+		s.remove(heap.Pop(&s.order).(*entry[K, V]))
+		evicted++
+	}
+	return evicted
+}
+
+// expire 移除時間早於 limit 的事件，回傳移除的數量
+func (s *side[K, V]) expire(limit time.Time) int {
+	n := 0
+	for len(s.order.items) > 0 && s.order.items[0].ev.Time.Before(limit) {
+		s.remove(heap.Pop(&s.order).(*entry[K, V]))
+		n++
+	}
+	return n
+}
+
+func (s *side[K, V]) remove(en *entry[K, V]) {
+	list := s.byKey[en.ev.Key]
+	for i, e := range list {
+		if e == en {
+			list = append(list[:i], list[i+1:]...)
+			break
+		}
+	}
+	if len(list) == 0 {
+		delete(s.byKey, en.ev.Key)
+	} else {
+		s.byKey[en.ev.Key] = list
+	}
+}
+
+func (s *side[K, V]) size() int { return len(s.order.items) }
+
+// entries 實作 heap.Interface，依 (Time, seq) 排序
+type entries[K comparable, V any] struct {
+	items []*entry[K, V]
+	next  uint64
+}
+
+func (h entries[K, V]) Len() int { return len(h.items) }
+func (h entries[K, V]) Less(i, j int) bool {
+	a, b := h.items[i], h.items[j]
+	if !a.ev.Time.Equal(b.ev.Time) {
+		return a.ev.Time.Before(b.ev.Time)
+	}
+	return a.seq < b.seq
+}
+func (h entries[K, V]) Swap(i, j int) { h.items[i], h.items[j] = h.items[j], h.items[i] }
+func (h *entries[K, V]) Push(x any)   { h.items = append(h.items, x.(*entry[K, V])) }
+func (h *entries[K, V]) Pop() any {
+	old := h.items
+	x := old[len(old)-1]
+	old[len(old)-1] = nil
+	h.items = old[:len(old)-1]
+	return x
+}
@@ -0,0 +1,180 @@
+package join
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var base = time.Unix(1_700_000_000, 0)
+
+type click = Event[string, int]
+type buy = Event[string, string]
+
+func feed[V any](events []Event[string, V]) <-chan Event[string, V] {
+	ch := make(chan Event[string, V])
+	go func() {
+		defer close(ch)
+		for _, e := range events {
+			ch <- e
+		}
+	}()
+	return ch
+}
+
+func run(t *testing.T, j *Joiner[string, int, string], left []click, right []buy) []string {
+	t.Helper()
+	var got []string
+	for p := range j.Run(context.Background(), feed(left), feed(right)) {
+		got = append(got, format(p))
+	}
+	sort.Strings(got)
+	return got
+}
+
+func format(p Pair[string, int, string]) string {
+	return fmt.Sprintf("%s %d %s", p.Key, p.Left.Value, p.Right.Value)
+}
+
+// reference 是 batch 版的 nested loop join，作為正確答案
+func reference(left []click, right []buy, window time.Duration) []string {
+	var out []string
+	for _, l := range left {
+		for _, r := range right {
+			if l.Key == r.Key && within(l.Time, r.Time, window) {
+				out = append(out, format(Pair[string, int, string]{Key: l.Key, Left: l, Right: r}))
+			}
+		}
+	}
+	sort.Strings(out)
+	return out
+}
+
+func at(ms int) time.Time { return base.Add(time.Duration(ms) * time.Millisecond) }
+
+func TestJoin(t *testing.T) {
+	// 兩邊各由一個 goroutine 送入，到達的相對順序不固定，Lateness 要涵蓋兩邊的時間差
+	j, err := New[string, int, string](5*time.Second, WithLateness(time.Minute))
+	require.NoError(t, err)
+	got := run(t, j,
+		[]click{{"a", at(1000), 1}, {"b", at(2000), 2}, {"a", at(7000), 3}},
+		[]buy{{"a", at(3000), "x"}, {"c", at(3000), "y"}, {"a", at(20000), "z"}})
+	assert.Equal(t, []string{"a 1 x", "a 3 x"}, got)
+	assert.Equal(t, int64(2), j.Stats().Pairs)
+}
+
+// shuffle 產生時間遞增的事件後，讓每個事件最多晚 disorder 到達
+func shuffle[V any](rng *rand.Rand, events []Event[string, V], disorder time.Duration) []Event[string, V] {
+	type keyed struct {
+		e   Event[string, V]
+		arr time.Time
+	}
+	ks := make([]keyed, len(events))
+	for i, e := range events {
+		ks[i] = keyed{e, e.Time.Add(time.Duration(rng.Int63n(int64(disorder))))}
+	}
+	sort.SliceStable(ks, func(a, b int) bool { return ks[a].arr.Before(ks[b].arr) })
+	out := make([]Event[string, V], len(ks))
+	for i, k := range ks {
+		out[i] = k.e
+	}
+	return out
+}
+
+// TestMatchesReference 亂序不超過 Lateness 時，結果與 batch join 相同，且 state 維持在小範圍內
+func TestMatchesReference(t *testing.T) {
+	const n = 2000
+	window := 500 * time.Millisecond
+	disorder := 2 * time.Second
+
+	for seed := int64(1); seed <= 5; seed++ {
+		rng := rand.New(rand.NewSource(seed))
+		var left []click
+		var right []buy
+		for i := 0; i < n; i++ {
+			key := fmt.Sprintf("k%d", rng.Intn(20))
+			left = append(left, click{key, at(i*100 + rng.Intn(100)), i})
+			key = fmt.Sprintf("k%d", rng.Intn(20))
+			right = append(right, buy{key, at(i*100 + rng.Intn(100)), fmt.Sprint("r", i)})
+		}
+		want := reference(left, right, window)
+
+		j, err := New[string, int, string](window, WithLateness(disorder))
+		require.NoError(t, err)
+		got := run(t, j, shuffle(rng, left, disorder), shuffle(rng, right, disorder))
+		require.Equal(t, want, got, "seed %d", seed)
+
+		st := j.Stats()
+		assert.Zero(t, st.Late)
+		assert.Zero(t, st.Evicted)
+		assert.Greater(t, st.Expired, int64(n), "state 有在過期")
+		assert.Less(t, st.Buffered, 200, "只保留 window + lateness 範圍內的事件")
+		t.Logf("seed %d: %d pairs, %+v", seed, len(got), st)
+	}
+}
+
+func TestLate(t *testing.T) {
+	j, err := New[string, int, string](time.Second)
+	require.NoError(t, err)
+	left := make(chan click)
+	right := make(chan buy)
+	out := j.Run(context.Background(), left, right)
+
+	left <- click{"a", at(10000), 1}
+	right <- buy{"a", at(10500), "x"}
+	assert.Equal(t, "a 1 x", format(<-out))
+	// watermark = 10s，早於它的事件遲到
+	left <- click{"a", at(9800), 2}
+	right <- buy{"a", at(12000), "y"}
+	close(left)
+	close(right)
+	_, ok := <-out
+	assert.False(t, ok)
+	assert.Equal(t, int64(1), j.Stats().Late)
+}
+
+// TestMaxBuffered 另一邊沒有資料時 watermark 不前進，靠 MaxBuffered 限制記憶體
+func TestMaxBuffered(t *testing.T) {
+	j, err := New[string, int, string](time.Hour, WithMaxBuffered(3))
+	require.NoError(t, err)
+	left := make(chan click)
+	right := make(chan buy)
+	out := j.Run(context.Background(), left, right)
+	for i := 0; i < 10; i++ {
+		left <- click{"a", at(i * 1000), i}
+	}
+	right <- buy{"a", at(20000), "x"}
+	close(left)
+	close(right)
+
+	var got []string
+	for p := range out {
+		got = append(got, format(p))
+	}
+	sort.Strings(got)
+	assert.Equal(t, []string{"a 7 x", "a 8 x", "a 9 x"}, got, "只剩最新的 3 筆")
+	st := j.Stats()
+	assert.Equal(t, int64(7), st.Evicted)
+	assert.Equal(t, 4, st.Buffered)
+}
+
+func TestInvalidWindow(t *testing.T) {
+	_, err := New[string, int, int](-time.Second)
+	assert.ErrorIs(t, err, ErrInvalidWindow)
+}
+
+func TestCancel(t *testing.T) {
+	j, err := New[string, int, string](time.Second)
+	require.NoError(t, err)
+	ctx, cancel := context.WithCancel(context.Background())
+	out := j.Run(ctx, make(chan click), make(chan buy))
+	cancel()
+	_, ok := <-out
+	assert.False(t, ok)
+}
@@ -0,0 +1,270 @@
+package window
+
+import (
+	"context"
+	"errors"
+	"math"
+	"sort"
+	"time"
+
+	"advanced/timex"
+)
+
+/*
+時間視窗聚合：把事件依「事件時間」（Event.Time，而不是收到的時間）分到視窗，每個視窗計算 count/sum/百分位數。
+
+	Tumbling(time.Minute)                 [00:00,01:00) [01:00,02:00) ... 不重疊
+	Sliding(time.Minute, 10*time.Second)  [00:00,01:00) [00:10,01:10) ... 每個事件屬於 size/slide 個視窗
+
+事件可能亂序、遲到，所以要決定「視窗什麼時候算完」—— watermark：
+「時間早於 watermark 的事件應該都到齊了」。這裡的 watermark 是
+
+	max(看過最大的事件時間, clock.Now()) - Lateness
+
+  - 只看事件時間：沒有新事件時 watermark 不會前進，最後幾個視窗要等到 in 關閉才輸出
+  - 加上 WithClock：依處理時間定期（Tick）推進，資料流停住時視窗也會準時輸出；
+    適合事件時間接近真實時間的情境，重播歷史資料時不要用
+  - Lateness 是容許的亂序程度：越大越不容易丟資料，但視窗越晚輸出
+
+視窗在 watermark >= End 時輸出並丟棄。之後才到、而且所屬視窗都已經輸出的事件是遲到事件，
+交給 OnLate（例如記錄或另外修正），不會改變已輸出的結果。
+沒有任何事件的視窗不輸出。
+*/
+
+var ErrInvalidSpec = errors.New("window: invalid spec")
+
+// Spec 描述視窗的大小與間隔
+type Spec struct {
+	Size  time.Duration
+	Slide time.Duration
+}
+
+// Tumbling 回傳不重疊、首尾相接的視窗
+func Tumbling(size time.Duration) Spec {
+	return Spec{Size: size, Slide: size}
+}
+
+// Sliding 回傳大小 size、每 slide 開始一個的重疊視窗
+func Sliding(size, slide time.Duration) Spec {
+	return Spec{Size: size, Slide: slide}
+}
+
+func (s Spec) validate() error {
+	if s.Size <= 0 || s.Slide <= 0 || s.Slide > s.Size {
+		return ErrInvalidSpec
+	}
+	return nil
+}
+
+// starts 回傳包含 t 的所有視窗的開始時間，由早到晚；視窗以 Unix 0 對齊
+func (s Spec) starts(t time.Time) []time.Time {
+	// Truncate 以 Go 的零時間（西元 1 年）對齊，Slide 不能整除一天時會與 Unix 0 錯開，所以自己算
+	off := time.Duration(t.UnixNano() % int64(s.Slide))
+	if off < 0 {
+		off += s.Slide
+	}
+	last := t.Add(-off)
+	var out []time.Time
+	for start := last; start.After(t.Add(-s.Size)); start = start.Add(-s.Slide) {
+		out = append(out, start)
+	}
+	for i, j := 0, len(out)-1; i < j; i, j = i+1, j-1 {
+		out[i], out[j] = out[j], out[i]
+	}
+	return out
+}
+
+type Event struct {
+	Time  time.Time
+	Value float64
+}
+
+// Result 是一個視窗的聚合結果
+type Result struct {
+	Start, End time.Time
+	Count      int
+	Sum        float64
+	values     []float64 // 已排序
+}
+
+func (r Result) Mean() float64 {
+	if r.Count == 0 {
+		return 0
+	}
+	return r.Sum / float64(r.Count)
+}
+
+func (r Result) Min() float64 { return r.values[0] }
+func (r Result) Max() float64 { return r.values[len(r.values)-1] }
+
+// Quantile 以 nearest-rank 回傳第 q（0~1）分位數
+func (r Result) Quantile(q float64) float64 {
+	i := int(math.Ceil(q*float64(len(r.values)))) - 1
+	if i < 0 {
+		i = 0
+	}
+	return r.values[i]
+}
+
+type config struct {
+	lateness time.Duration
+	clock    timex.Clock
+	tick     time.Duration
+	onLate   func(Event)
+	buffer   int
+}
+
+type Option func(*config)
+
+// WithLateness 設定容許的亂序時間，預設 0
+func WithLateness(d time.Duration) Option {
+	return func(c *config) { c.lateness = d }
+}
+
+// WithClock 讓 watermark 也依處理時間每 tick 推進一次；tick 為 0 時使用視窗的 Slide
+func WithClock(clock timex.Clock, tick time.Duration) Option {
+	return func(c *config) { c.clock, c.tick = clock, tick }
+}
+
+// OnLate 設定遲到事件的處理函式，在聚合的 goroutine 中呼叫
+func OnLate(fn func(Event)) Option {
+	return func(c *config) { c.onLate = fn }
+}
+
+// WithBuffer 設定輸出 channel 的容量，預設 0
+func WithBuffer(n int) Option {
+	return func(c *config) { c.buffer = n }
+}
+
+// Aggregate 從 in 讀取事件，依 spec 聚合，視窗完成時依 End（相同時依 Start）的順序送出結果；
+// in 關閉時輸出剩下的所有視窗後關閉輸出，ctx 取消時直接關閉輸出
+func Aggregate(ctx context.Context, in <-chan Event, spec Spec, opts ...Option) (<-chan Result, error) {
+	if err := spec.validate(); err != nil {
+		return nil, err
+	}
+	cfg := config{}
+	for _, o := range opts {
+		o(&cfg)
+	}
+	if cfg.tick <= 0 {
+		cfg.tick = spec.Slide
+	}
+	a := &aggregator{spec: spec, cfg: cfg, open: map[int64]*Result{}}
+	out := make(chan Result, cfg.buffer)
+	go func() {
+		defer close(out)
+		a.run(ctx, in, out)
+	}()
+	return out, nil
+}
+
+type aggregator struct {
+	spec      Spec
+	cfg       config
+	open      map[int64]*Result // 以 Start 的 UnixNano 為 key，不受 Location 影響
+	watermark time.Time
+}
+
+func (a *aggregator) run(ctx context.Context, in <-chan Event, out chan<- Result) {
+	var tick <-chan time.Time
+	var timer timex.Timer
+	if a.cfg.clock != nil {
+		timer = a.cfg.clock.NewTimer(a.cfg.tick)
+		defer func() { timer.Stop() }()
+		tick = timer.C()
+		a.advance(a.cfg.clock.Now())
+	}
+	for {
+		var ready []Result
+		select {
+		case e, ok := <-in:
+			if !ok {
+				a.emit(ctx, out, a.drain())
+				return
+			}
+			a.add(e)
+			ready = a.advance(e.Time)
+		case <-tick:
+			timer = a.cfg.clock.NewTimer(a.cfg.tick)
+			tick = timer.C()
+			ready = a.advance(a.cfg.clock.Now())
+		case <-ctx.Done():
+			return
+		}
+		if !a.emit(ctx, out, ready) {
+			return
+		}
+	}
+}
+
+func (a *aggregator) add(e Event) {
+	added := false
+	for _, start := range a.spec.starts(e.Time) {
+		end := start.Add(a.spec.Size)
+		if !end.After(a.watermark) {
+			continue // 這個視窗已經輸出
+		}
+		r := a.open[start.UnixNano()]
+		if r == nil {
+			r = &Result{Start: start, End: end}
+			a.open[start.UnixNano()] = r
+		}
+		r.Count++
+		r.Sum += e.Value
+		i := sort.SearchFloat64s(r.values, e.Value)
+		r.values = append(r.values, 0)
+		copy(r.values[i+1:], r.values[i:])
+		r.values[i] = e.Value
+		added = true
+	}
+	if !added && a.cfg.onLate != nil {
+		a.cfg.onLate(e)
+	}
+}
+
+// advance 以時間 t 推進 watermark，回傳因此完成的視窗
+func (a *aggregator) advance(t time.Time) []Result {
+	wm := t.Add(-a.cfg.lateness)
+	if !wm.After(a.watermark) {
+		return nil
+	}
+	a.watermark = wm
+	var ready []Result
+	for start, r := range a.open {
+		if !r.End.After(wm) {
+			ready = append(ready, *r)
+			delete(a.open, start)
+		}
+	}
+	sortResults(ready)
+	return ready
+}
+
+func (a *aggregator) drain() []Result {
+	ready := make([]Result, 0, len(a.open))
+	for _, r := range a.open {
+		ready = append(ready, *r)
+	}
+	sortResults(ready)
+	return ready
+}
+
+func sortResults(rs []Result) {
+	sort.Slice(rs, func(i, j int) bool {
+		if !rs[i].End.Equal(rs[j].End) {
+			return rs[i].End.Before(rs[j].End)
+		}
+		return rs[i].Start.Before(rs[j].Start)
+	})
+}
+
+func (a *aggregator) emit(ctx context.Context, out chan<- Result, rs []Result) bool {
+	for _, r := range rs {
+		select {
+		case out <- r:
+		case <-ctx.Done():
+			return false
+		}
+	}
+	return true
+}
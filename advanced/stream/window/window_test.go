@@ -0,0 +1,168 @@
+package window
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"advanced/timex"
+)
+
+var base = time.Unix(1_700_000_000, 0).UTC() // 對齊 10 秒
+
+func at(sec float64) time.Time {
+	return base.Add(time.Duration(sec * float64(time.Second)))
+}
+
+func ev(sec, v float64) Event { return Event{Time: at(sec), Value: v} }
+
+type span struct {
+	Start, End float64
+	Count      int
+	Sum        float64
+}
+
+func spanOf(r Result) span {
+	return span{r.Start.Sub(base).Seconds(), r.End.Sub(base).Seconds(), r.Count, r.Sum}
+}
+
+// collect 送出所有事件後關閉 in，回傳全部結果
+func collect(t *testing.T, spec Spec, events []Event, opts ...Option) []span {
+	t.Helper()
+	in := make(chan Event)
+	out, err := Aggregate(context.Background(), in, spec, opts...)
+	require.NoError(t, err)
+	go func() {
+		defer close(in)
+		for _, e := range events {
+			in <- e
+		}
+	}()
+	var got []span
+	for r := range out {
+		got = append(got, spanOf(r))
+	}
+	return got
+}
+
+func TestTumbling(t *testing.T) {
+	got := collect(t, Tumbling(10*time.Second), []Event{ev(0, 1), ev(1, 2), ev(9.9, 3), ev(10, 4), ev(35, 5)})
+	assert.Equal(t, []span{{0, 10, 3, 6}, {10, 20, 1, 4}, {30, 40, 1, 5}}, got, "空的視窗不輸出")
+}
+
+func TestSliding(t *testing.T) {
+	got := collect(t, Sliding(10*time.Second, 5*time.Second), []Event{ev(2, 1), ev(7, 2), ev(12, 4)})
+	assert.Equal(t, []span{
+		{-5, 5, 1, 1},
+		{0, 10, 2, 3},
+		{5, 15, 2, 6},
+		{10, 20, 1, 4},
+	}, got)
+}
+
+func TestStarts(t *testing.T) {
+	s := Sliding(10*time.Second, 5*time.Second)
+	assert.Equal(t, []time.Time{at(0), at(5)}, s.starts(at(7)))
+	assert.Equal(t, []time.Time{at(0), at(5)}, s.starts(at(5)), "邊界屬於開始的視窗")
+	assert.Equal(t, []time.Time{at(10)}, Tumbling(10*time.Second).starts(at(10)))
+
+	// 7 分鐘不能整除一天，仍以 Unix 0 對齊，Unix 0 之前也一樣
+	for _, ts := range []time.Time{time.Unix(1_700_000_000, 0), time.Unix(-1000, 0)} {
+		for _, start := range Tumbling(7 * time.Minute).starts(ts) {
+			assert.Zero(t, start.UnixNano()%int64(7*time.Minute), start)
+		}
+	}
+}
+
+// TestWatermark 事件時間超過視窗結束時才輸出；Lateness 內的亂序事件仍算進去，更晚的交給 OnLate
+func TestWatermark(t *testing.T) {
+	in := make(chan Event)
+	var late []Event
+	out, err := Aggregate(context.Background(), in, Tumbling(10*time.Second),
+		WithLateness(2*time.Second), OnLate(func(e Event) { late = append(late, e) }))
+	require.NoError(t, err)
+
+	in <- ev(1, 1)
+	in <- ev(11, 1) // watermark 9，[0,10) 還沒完成
+	in <- ev(9, 1)  // 亂序但在容許範圍內
+	in <- ev(13, 1) // watermark 11，[0,10) 完成
+	assert.Equal(t, span{0, 10, 2, 2}, spanOf(<-out))
+
+	in <- ev(8, 1) // [0,10) 已輸出：遲到
+	in <- ev(25, 1)
+	assert.Equal(t, span{10, 20, 2, 2}, spanOf(<-out))
+	close(in)
+	assert.Equal(t, span{20, 30, 1, 1}, spanOf(<-out))
+	_, ok := <-out
+	assert.False(t, ok)
+	assert.Equal(t, []Event{ev(8, 1)}, late)
+}
+
+// TestClock 資料流停住時，依處理時間推進的 watermark 仍會讓視窗輸出
+func TestClock(t *testing.T) {
+	clock := timex.NewFake(at(0))
+	in := make(chan Event)
+	out, err := Aggregate(context.Background(), in, Tumbling(10*time.Second),
+		WithLateness(time.Second), WithClock(clock, time.Second))
+	require.NoError(t, err)
+
+	in <- ev(3, 5)
+	in <- ev(4, 7)
+	for i := 0; i < 10; i++ {
+		clock.BlockUntil(1)
+		clock.Advance(time.Second)
+		select {
+		case r := <-out:
+			t.Fatalf("window emitted too early at %v: %+v", clock.Now().Sub(base), spanOf(r))
+		default:
+		}
+	}
+	clock.BlockUntil(1)
+	clock.Advance(time.Second) // now 11，watermark 10
+	r := <-out
+	assert.Equal(t, span{0, 10, 2, 12}, spanOf(r))
+	close(in)
+	_, ok := <-out
+	assert.False(t, ok)
+}
+
+func TestStats(t *testing.T) {
+	var events []Event
+	for v := 100; v >= 1; v-- {
+		events = append(events, ev(1, float64(v)))
+	}
+	in := make(chan Event, len(events))
+	for _, e := range events {
+		in <- e
+	}
+	close(in)
+	out, err := Aggregate(context.Background(), in, Tumbling(time.Minute))
+	require.NoError(t, err)
+	r := <-out
+	assert.Equal(t, 100, r.Count)
+	assert.Equal(t, 50.5, r.Mean())
+	assert.Equal(t, 1.0, r.Min())
+	assert.Equal(t, 100.0, r.Max())
+	assert.Equal(t, 50.0, r.Quantile(0.5))
+	assert.Equal(t, 99.0, r.Quantile(0.99))
+	assert.Equal(t, 1.0, r.Quantile(0))
+}
+
+func TestInvalidSpec(t *testing.T) {
+	for _, s := range []Spec{{}, Tumbling(-time.Second), Sliding(time.Second, 2*time.Second)} {
+		_, err := Aggregate(context.Background(), nil, s)
+		assert.ErrorIs(t, err, ErrInvalidSpec, "%+v", s)
+	}
+}
+
+func TestCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	out, err := Aggregate(ctx, make(chan Event), Tumbling(time.Second))
+	require.NoError(t, err)
+	cancel()
+	_, ok := <-out
+	assert.False(t, ok)
+}
@@ -0,0 +1,165 @@
+package streamio
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"sync"
+)
+
+/*
+並行的逐筆處理流程：
+
+	reader goroutine ──> jobs ──> worker × N ──> results ──> ordered writer
+
+	- 每筆資料帶著序號，writer 用一個小的 reorder buffer 依序號輸出，保證輸出順序與輸入相同
+	- inflight 是一個容量為 Buffer 的 token channel，reader 拿到 token 才能送出下一筆，
+	  writer 寫出後才歸還，所以記憶體中最多只有 Buffer 筆資料：
+	  worker 或 writer 變慢時 reader 會自動停下來（backpressure）
+*/
+
+type Options struct {
+	Split   bufio.SplitFunc // 預設 bufio.ScanLines
+	Workers int             // 預設 1
+	Buffer  int             // 同時在處理中的最大筆數，預設 Workers*2
+	MaxSize int             // 單筆最大長度，預設 bufio.MaxScanTokenSize
+	// Delimiter 寫出每筆結果後附加的分隔符號，預設 "\n"
+	Delimiter []byte
+}
+
+// Process 從 r 讀出每筆資料交給 fn 處理，依原本順序寫入 w，
+// 任何一筆失敗就停止並回傳第一個錯誤
+func Process(ctx context.Context, r io.Reader, w io.Writer, opts Options, fn func([]byte) ([]byte, error)) error {
+	if opts.Split == nil {
+		opts.Split = bufio.ScanLines
+	}
+	if opts.MaxSize <= 0 {
+		opts.MaxSize = bufio.MaxScanTokenSize
+	}
+	if opts.Delimiter == nil {
+		opts.Delimiter = []byte("\n")
+	}
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 0, 4096), opts.MaxSize)
+	sc.Split(opts.Split)
+
+	read := func() ([]byte, error) {
+		if !sc.Scan() {
+			if err := sc.Err(); err != nil {
+				return nil, err
+			}
+			return nil, io.EOF
+		}
+		// Scanner 會重複使用底層 buffer，交給其他 goroutine 前要複製一份
+		return append([]byte(nil), sc.Bytes()...), nil
+	}
+	write := func(_ []byte, out []byte, err error) error {
+		if err != nil {
+			return err
+		}
+		if _, err := w.Write(out); err != nil {
+			return err
+		}
+		_, err = w.Write(opts.Delimiter)
+		return err
+	}
+	return Ordered(ctx, opts.Workers, opts.Buffer, read, fn, write)
+}
+
+type item[In, Out any] struct {
+	seq int
+	in  In
+	out Out
+	err error
+}
+
+// Ordered 是 Process 的通用版本：read 在自己的 goroutine 中逐筆讀取（回傳 io.EOF 表示結束），
+// workers 個 goroutine 執行 fn，write 依讀取的順序收到每筆的輸入、結果與 fn 的錯誤。
+// write 回傳錯誤時停止並回傳該錯誤；fn 的錯誤要不要中止由 write 決定。
+// 記憶體中最多只有 buffer 筆資料，buffer <= 0 時為 workers*2
+func Ordered[In, Out any](ctx context.Context, workers, buffer int, read func() (In, error), fn func(In) (Out, error), write func(in In, out Out, err error) error) error {
+	if workers <= 0 {
+		workers = 1
+	}
+	if buffer <= 0 {
+		buffer = workers * 2
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	inflight := make(chan struct{}, buffer)
+	jobs := make(chan item[In, Out])
+	results := make(chan item[In, Out], buffer)
+
+	var readErr error
+	go func() {
+		defer close(jobs)
+		for seq := 0; ; seq++ {
+			select {
+			case inflight <- struct{}{}:
+			case <-ctx.Done():
+				return
+			}
+			v, err := read()
+			if err != nil {
+				if err != io.EOF {
+					readErr = err
+				}
+				return
+			}
+			select {
+			case jobs <- item[In, Out]{seq: seq, in: v}:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	var wg sync.WaitGroup
+	wg.Add(workers)
+	for i := 0; i < workers; i++ {
+		go func() {
+			defer wg.Done()
+			for it := range jobs {
+				it.out, it.err = fn(it.in)
+				results <- it
+			}
+		}()
+	}
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+
+	pending := make(map[int]item[In, Out])
+	next := 0
+	var firstErr error
+	for res := range results {
+		if firstErr != nil {
+			continue // 出錯後只需要把 results 消化完，讓 worker 可以結束
+		}
+		pending[res.seq] = res
+		for {
+			it, ok := pending[next]
+			if !ok {
+				break
+			}
+			delete(pending, next)
+			next++
+			if err := write(it.in, it.out, it.err); err != nil {
+				firstErr = err
+				cancel()
+				break
+			}
+			<-inflight
+		}
+	}
+	if firstErr != nil {
+		return firstErr
+	}
+	if readErr != nil {
+		return readErr
+	}
+	return ctx.Err()
+}
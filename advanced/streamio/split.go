@@ -0,0 +1,99 @@
+package streamio
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+/*
+bufio.Scanner 預設以行分割，透過自訂 SplitFunc 可以處理各種格式：
+	func(data []byte, atEOF bool) (advance int, token []byte, err error)
+	- data 不夠組成一個 token 時回傳 (0, nil, nil)，Scanner 會讀更多資料再呼叫一次
+	- atEOF 為 true 時代表沒有更多資料了，剩下的 data 要自己決定怎麼處理
+*/
+
+var ErrFrameTooLarge = errors.New("streamio: frame too large")
+
+// ScanLengthPrefixed 解析 4 bytes big-endian 長度 + payload 的 frame
+func ScanLengthPrefixed(maxSize int) bufio.SplitFunc {
+	return func(data []byte, atEOF bool) (int, []byte, error) {
+		if len(data) < 4 {
+			if atEOF && len(data) > 0 {
+				return 0, nil, fmt.Errorf("streamio: truncated frame header (%d bytes)", len(data))
+			}
+			return 0, nil, nil
+		}
+		size := int(binary.BigEndian.Uint32(data))
+		if size > maxSize {
+			return 0, nil, fmt.Errorf("%w: %d > %d", ErrFrameTooLarge, size, maxSize)
+		}
+		if len(data) < 4+size {
+			if atEOF {
+				return 0, nil, fmt.Errorf("streamio: truncated frame: want %d bytes, got %d", size, len(data)-4)
+			}
+			return 0, nil, nil
+		}
+		return 4 + size, data[4 : 4+size], nil
+	}
+}
+
+// AppendFrame 編碼一個 length-prefixed frame，與 ScanLengthPrefixed 對應
+func AppendFrame(dst, payload []byte) []byte {
+	dst = binary.BigEndian.AppendUint32(dst, uint32(len(payload)))
+	return append(dst, payload...)
+}
+
+// ScanNull 以 '\0' 分隔紀錄，例如 find -print0 的輸出
+func ScanNull(data []byte, atEOF bool) (int, []byte, error) {
+	if i := bytes.IndexByte(data, 0); i >= 0 {
+		return i + 1, data[:i], nil
+	}
+	if atEOF && len(data) > 0 {
+		return len(data), data, nil
+	}
+	return 0, nil, nil
+}
+
+// ScanMultiLine 把多行 log 合併成一筆：isStart 為 true 的行開始新的一筆，
+// 其餘的行（例如 stack trace）附加到上一筆。回傳的 token 不含最後的換行
+func ScanMultiLine(isStart func(line []byte) bool) bufio.SplitFunc {
+	return func(data []byte, atEOF bool) (int, []byte, error) {
+		if atEOF && len(data) == 0 {
+			return 0, nil, nil
+		}
+		// 第一行一定屬於目前這筆，從第二行開始找下一筆的開頭
+		first := bytes.IndexByte(data, '\n')
+		if first < 0 {
+			if atEOF {
+				return len(data), dropCR(data), nil
+			}
+			return 0, nil, nil
+		}
+		pos := first + 1
+		for pos < len(data) {
+			end := bytes.IndexByte(data[pos:], '\n')
+			if end < 0 {
+				// 最後一行還沒讀完整，無法判斷是不是新的一筆
+				break
+			}
+			if isStart(dropCR(data[pos : pos+end])) {
+				return pos, dropCR(data[:pos-1]), nil
+			}
+			pos += end + 1
+		}
+		if atEOF {
+			if pos < len(data) && isStart(data[pos:]) {
+				return pos, dropCR(data[:pos-1]), nil
+			}
+			return len(data), dropCR(bytes.TrimSuffix(data, []byte("\n"))), nil
+		}
+		return 0, nil, nil
+	}
+}
+
+func dropCR(b []byte) []byte {
+	return bytes.TrimSuffix(b, []byte("\r"))
+}
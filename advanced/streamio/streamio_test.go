@@ -0,0 +1,177 @@
+package streamio
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"regexp"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"testing/iotest"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func scanAll(r *bufio.Scanner) ([]string, error) {
+	var out []string
+	for r.Scan() {
+		out = append(out, r.Text())
+	}
+	return out, r.Err()
+}
+
+func TestScanLengthPrefixed(t *testing.T) {
+	var buf []byte
+	for _, p := range []string{"hello", "", "world!"} {
+		buf = AppendFrame(buf, []byte(p))
+	}
+	// OneByteReader 讓每次只讀 1 byte，確認資料不足時會等待
+	sc := bufio.NewScanner(iotest.OneByteReader(bytes.NewReader(buf)))
+	sc.Split(ScanLengthPrefixed(1024))
+	out, err := scanAll(sc)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"hello", "", "world!"}, out)
+}
+
+func TestScanLengthPrefixedErrors(t *testing.T) {
+	big := AppendFrame(nil, make([]byte, 100))
+	sc := bufio.NewScanner(bytes.NewReader(big))
+	sc.Split(ScanLengthPrefixed(10))
+	_, err := scanAll(sc)
+	assert.ErrorIs(t, err, ErrFrameTooLarge)
+
+	truncated := AppendFrame(nil, []byte("hello"))[:6]
+	sc = bufio.NewScanner(bytes.NewReader(truncated))
+	sc.Split(ScanLengthPrefixed(10))
+	_, err = scanAll(sc)
+	assert.ErrorContains(t, err, "truncated frame")
+
+	sc = bufio.NewScanner(bytes.NewReader([]byte{0, 0}))
+	sc.Split(ScanLengthPrefixed(10))
+	_, err = scanAll(sc)
+	assert.ErrorContains(t, err, "truncated frame header")
+}
+
+func TestScanNull(t *testing.T) {
+	sc := bufio.NewScanner(strings.NewReader("a.txt\x00dir/b c.txt\x00last"))
+	sc.Split(ScanNull)
+	out, err := scanAll(sc)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"a.txt", "dir/b c.txt", "last"}, out)
+}
+
+var logStart = regexp.MustCompile(`^\d{4}-\d{2}-\d{2} `)
+
+func TestScanMultiLine(t *testing.T) {
+	input := "2022-01-01 INFO start\n" +
+		"2022-01-01 ERROR panic: boom\n" +
+		"goroutine 1 [running]:\n" +
+		"\tmain.go:10\r\n" +
+		"2022-01-02 INFO recovered\n" +
+		"2022-01-02 WARN tail without newline"
+	for name, r := range map[string]func() *bufio.Scanner{
+		"whole":    func() *bufio.Scanner { return bufio.NewScanner(strings.NewReader(input)) },
+		"one byte": func() *bufio.Scanner { return bufio.NewScanner(iotest.OneByteReader(strings.NewReader(input))) },
+	} {
+		t.Run(name, func(t *testing.T) {
+			sc := r()
+			sc.Split(ScanMultiLine(logStart.Match))
+			out, err := scanAll(sc)
+			assert.NoError(t, err)
+			assert.Equal(t, []string{
+				"2022-01-01 INFO start",
+				"2022-01-01 ERROR panic: boom\ngoroutine 1 [running]:\n\tmain.go:10",
+				"2022-01-02 INFO recovered",
+				"2022-01-02 WARN tail without newline",
+			}, out)
+		})
+	}
+}
+
+func TestProcessOrdered(t *testing.T) {
+	var in strings.Builder
+	var want strings.Builder
+	for i := 0; i < 200; i++ {
+		fmt.Fprintf(&in, "line-%d\n", i)
+		fmt.Fprintf(&want, "LINE-%d\n", i)
+	}
+	var out bytes.Buffer
+	err := Process(context.Background(), strings.NewReader(in.String()), &out, Options{Workers: 8},
+		func(b []byte) ([]byte, error) {
+			// 隨機延遲讓 worker 完成的順序打亂
+			time.Sleep(time.Duration(rand.Intn(200)) * time.Microsecond)
+			return bytes.ToUpper(b), nil
+		})
+	assert.NoError(t, err)
+	assert.Equal(t, want.String(), out.String())
+}
+
+func TestProcessBackpressure(t *testing.T) {
+	var in strings.Builder
+	for i := 0; i < 100; i++ {
+		in.WriteString("x\n")
+	}
+	var current, peak int32
+	err := Process(context.Background(), strings.NewReader(in.String()), &bytes.Buffer{},
+		Options{Workers: 4, Buffer: 5},
+		func(b []byte) ([]byte, error) {
+			n := atomic.AddInt32(&current, 1)
+			for {
+				p := atomic.LoadInt32(&peak)
+				if n <= p || atomic.CompareAndSwapInt32(&peak, p, n) {
+					break
+				}
+			}
+			time.Sleep(100 * time.Microsecond)
+			atomic.AddInt32(&current, -1)
+			return b, nil
+		})
+	assert.NoError(t, err)
+	assert.LessOrEqual(t, atomic.LoadInt32(&peak), int32(4))
+}
+
+func TestProcessError(t *testing.T) {
+	boom := errors.New("boom")
+	var out bytes.Buffer
+	err := Process(context.Background(), strings.NewReader("a\nb\nbad\nc\nd\n"), &out, Options{Workers: 2},
+		func(b []byte) ([]byte, error) {
+			if string(b) == "bad" {
+				return nil, boom
+			}
+			return b, nil
+		})
+	assert.ErrorIs(t, err, boom)
+	assert.Equal(t, "a\nb\n", out.String())
+}
+
+func TestProcessCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	r := strings.NewReader(strings.Repeat("x\n", 10000))
+	var n int32
+	err := Process(ctx, r, &bytes.Buffer{}, Options{Workers: 2}, func(b []byte) ([]byte, error) {
+		if atomic.AddInt32(&n, 1) == 10 {
+			cancel()
+		}
+		return b, nil
+	})
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Less(t, atomic.LoadInt32(&n), int32(10000))
+}
+
+func TestProcessFrames(t *testing.T) {
+	var in []byte
+	for _, p := range []string{"a", "bb", "ccc"} {
+		in = AppendFrame(in, []byte(p))
+	}
+	var out bytes.Buffer
+	err := Process(context.Background(), bytes.NewReader(in), &out,
+		Options{Split: ScanLengthPrefixed(16), Workers: 3, Delimiter: []byte{0}},
+		func(b []byte) ([]byte, error) { return []byte(fmt.Sprint(len(b))), nil })
+	assert.NoError(t, err)
+	assert.Equal(t, "1\x002\x003\x00", out.String())
+}
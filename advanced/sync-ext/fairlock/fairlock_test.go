@@ -0,0 +1,105 @@
+package fairlock
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// waitQueued 等待排隊人數達到 n
+func waitQueued(t *testing.T, m *Mutex, n int) {
+	t.Helper()
+	require.Eventually(t, func() bool { return m.Waiters() == n }, time.Second, time.Millisecond)
+}
+
+func TestFIFO(t *testing.T) {
+	var m Mutex
+	m.Lock()
+
+	var mu sync.Mutex
+	var order []int
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			m.Lock()
+			mu.Lock()
+			order = append(order, i)
+			mu.Unlock()
+			m.Unlock()
+		}(i)
+		waitQueued(t, &m, i+1) // 確定 i 已經排隊才啟動下一個
+	}
+	m.Unlock()
+	wg.Wait()
+	assert.Equal(t, []int{0, 1, 2, 3, 4}, order)
+	assert.True(t, m.TryLock(), "全部釋放後可以再取得")
+}
+
+// TestNoBarging 剛 Unlock 的 goroutine 再 Lock 要排在等待者後面
+func TestNoBarging(t *testing.T) {
+	var m Mutex
+	m.Lock()
+	got := make(chan struct{})
+	go func() {
+		m.Lock()
+		close(got)
+		m.Unlock()
+	}()
+	waitQueued(t, &m, 1)
+
+	m.Unlock()
+	assert.False(t, m.TryLock(), "鎖已交給等待者")
+	m.Lock()
+	select {
+	case <-got:
+	default:
+		t.Fatal("re-Lock barged ahead of the waiter")
+	}
+	m.Unlock()
+}
+
+func TestLockContext(t *testing.T) {
+	var m Mutex
+	m.Lock()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	errc := make(chan error)
+	go func() { errc <- m.LockContext(ctx) }()
+	waitQueued(t, &m, 1)
+	cancel()
+	assert.ErrorIs(t, <-errc, context.Canceled)
+	assert.Zero(t, m.Waiters(), "取消後離開佇列")
+
+	m.Unlock()
+	assert.NoError(t, m.LockContext(context.Background()))
+	m.Unlock()
+}
+
+// TestLockContextHandoffRace 取消與交棒同時發生時，鎖要傳給下一個人而不是遺失
+func TestLockContextHandoffRace(t *testing.T) {
+	for i := 0; i < 200; i++ {
+		var m Mutex
+		m.Lock()
+		ctx, cancel := context.WithCancel(context.Background())
+		errc := make(chan error)
+		go func() { errc <- m.LockContext(ctx) }()
+		waitQueued(t, &m, 1)
+		go cancel()
+		m.Unlock()
+		if err := <-errc; err == nil {
+			m.Unlock()
+		}
+		require.True(t, m.TryLock(), "iteration %d: lock leaked", i)
+	}
+}
+
+func TestUnlockUnlocked(t *testing.T) {
+	var m Mutex
+	assert.PanicsWithValue(t, "fairlock: unlock of unlocked mutex", m.Unlock)
+}
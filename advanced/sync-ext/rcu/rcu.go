@@ -0,0 +1,100 @@
+package rcu
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+)
+
+/*
+RCU（read-copy-update）風格的設定持有者，適合讀遠多於寫的資料：
+  - 讀：atomic.Value.Load 拿到目前版本的指標，完全不加鎖，讀者之間也不會互相干擾 cache line
+  - 寫：複製一份、修改副本、用 Store 一次換上新版本；寫者之間用 mutex 排隊
+  - 舊版本仍被讀者持有時不會被改動（immutable），讀者手上的永遠是一致的快照，
+    沒有人引用後由 GC 回收 —— 這就是 C 的 RCU 需要 grace period 而 Go 不需要的原因
+
+注意 Value 必須當成唯讀：Load 回傳的若是 map、slice 或指標，修改它等於修改所有讀者看到的版本，
+一律透過 Update 複製後再改。
+
+每個版本帶一個 changed channel，換上新版本時關閉它，
+等待者用 Wait / Changed 就能得知「我看到的版本已經過時」，不需要訂閱清單。
+*/
+
+type snapshot[T any] struct {
+	value   T
+	version uint64
+	changed chan struct{}
+}
+
+type Holder[T any] struct {
+	v  atomic.Value // 永遠存放 *snapshot[T]
+	mu sync.Mutex   // 只有寫者使用
+}
+
+func New[T any](initial T) *Holder[T] {
+	h := &Holder[T]{}
+	h.v.Store(&snapshot[T]{value: initial, version: 1, changed: make(chan struct{})})
+	return h
+}
+
+func (h *Holder[T]) load() *snapshot[T] { return h.v.Load().(*snapshot[T]) }
+
+// Load 回傳目前的值
+func (h *Holder[T]) Load() T { return h.load().value }
+
+// LoadVersioned 回傳目前的值與版本號；版本號從 1 開始，每次發佈加一
+func (h *Holder[T]) LoadVersioned() (T, uint64) {
+	s := h.load()
+	return s.value, s.version
+}
+
+// Changed 回傳一個在目前版本被取代時關閉的 channel
+func (h *Holder[T]) Changed() <-chan struct{} { return h.load().changed }
+
+// Store 發佈新版本並回傳版本號
+func (h *Holder[T]) Store(v T) uint64 {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.publish(v)
+}
+
+// Update 以目前的值計算新版本；fn 收到的是目前版本，必須回傳新的物件而不是就地修改
+func (h *Holder[T]) Update(fn func(old T) T) uint64 {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.publish(fn(h.load().value))
+}
+
+// CompareAndStore 只有在目前版本仍是 version 時才發佈，用於「讀取、計算、寫回」之間不持有鎖的情境
+func (h *Holder[T]) CompareAndStore(version uint64, v T) (uint64, bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if cur := h.load().version; cur != version {
+		return cur, false
+	}
+	return h.publish(v), true
+}
+
+func (h *Holder[T]) publish(v T) uint64 {
+	old := h.load()
+	next := &snapshot[T]{value: v, version: old.version + 1, changed: make(chan struct{})}
+	h.v.Store(next)
+	close(old.changed)
+	return next.version
+}
+
+// Wait 等到版本大於 after，回傳新的值與版本
+func (h *Holder[T]) Wait(ctx context.Context, after uint64) (T, uint64, error) {
+	for {
+		s := h.load()
+		if s.version > after {
+			return s.value, s.version, nil
+		}
+		select {
+		case <-s.changed:
+		case <-ctx.Done():
+			var zero T
+			return zero, 0, ctx.Err()
+		}
+	}
+}
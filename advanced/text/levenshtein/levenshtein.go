@@ -0,0 +1,66 @@
+package levenshtein
+
+/*
+Levenshtein distance：把 a 變成 b 所需的最少插入、刪除、替換次數。
+	dp[i][j] = a[:i] 與 b[:j] 的距離
+	dp[i][j] = min(dp[i-1][j] + 1,            刪除 a[i-1]
+	               dp[i][j-1] + 1,            插入 b[j-1]
+	               dp[i-1][j-1] + (a[i-1] != b[j-1]))
+每一列只依賴上一列，所以只需要保留一列，空間 O(len(b))。
+
+Row 把「一次往 a 加一個字元」包成增量計算，適合在 trie 上 DFS：
+往下走一層就 Next 一次，回溯時直接丟掉，不需要重算整個表。
+以 rune 為單位計算，中文等多 byte 字元算一個字元。
+*/
+
+// Distance 回傳 a 與 b 的編輯距離
+func Distance(a, b string) int {
+	row := NewRow(b)
+	for _, r := range a {
+		row = row.Next(r)
+	}
+	return row.Distance()
+}
+
+// Row 是 DP 表中的一列，對應目前已輸入的字串與 target 每個前綴的距離
+type Row struct {
+	target []rune
+	cells  []int
+}
+
+func NewRow(target string) Row {
+	t := []rune(target)
+	cells := make([]int, len(t)+1)
+	for j := range cells {
+		cells[j] = j
+	}
+	return Row{target: t, cells: cells}
+}
+
+// Next 回傳多輸入一個字元 r 之後的列，原本的 Row 不會被修改
+func (r Row) Next(c rune) Row {
+	next := make([]int, len(r.cells))
+	next[0] = r.cells[0] + 1
+	for j := 1; j < len(next); j++ {
+		cost := 1
+		if r.target[j-1] == c {
+			cost = 0
+		}
+		next[j] = min(r.cells[j]+1, next[j-1]+1, r.cells[j-1]+cost)
+	}
+	return Row{target: r.target, cells: next}
+}
+
+// Distance 是目前輸入與整個 target 的距離
+func (r Row) Distance() int {
+	return r.cells[len(r.cells)-1]
+}
+
+// Min 是這一列的最小值；後續再多輸入任何字元，距離都不會小於它，可用來剪枝
+func (r Row) Min() int {
+	m := r.cells[0]
+	for _, c := range r.cells[1:] {
+		m = min(m, c)
+	}
+	return m
+}
@@ -0,0 +1,39 @@
+package levenshtein
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDistance(t *testing.T) {
+	cases := []struct {
+		a, b string
+		want int
+	}{
+		{"", "", 0},
+		{"", "abc", 3},
+		{"kitten", "sitting", 3},
+		{"flaw", "lawn", 2},
+		{"gopher", "gopher", 0},
+		{"gopehr", "gopher", 2},
+		{"咖啡", "咖哩", 1},
+	}
+	for _, c := range cases {
+		assert.Equal(t, c.want, Distance(c.a, c.b), "%q → %q", c.a, c.b)
+		assert.Equal(t, c.want, Distance(c.b, c.a), "對稱")
+	}
+}
+
+func TestRowMin(t *testing.T) {
+	row := NewRow("gopher")
+	for _, r := range "gop" {
+		row = row.Next(r)
+	}
+	assert.Equal(t, 0, row.Min(), "gop 是 gopher 的前綴")
+	assert.Equal(t, 3, row.Distance())
+
+	branch := row.Next('x').Next('y')
+	assert.Equal(t, 2, branch.Min())
+	assert.Equal(t, 3, row.Distance(), "Next 不修改原本的 row")
+}
@@ -0,0 +1,129 @@
+package timex
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"advanced/ctxutil"
+)
+
+/*
+與 basic/timers 相同的 Clock 抽象（advanced 是獨立的 module，無法直接引用），只保留 timex 需要的部分。
+Clock 放在 context 裡：Sleep、Backoff 等 helper 都從 ctx 取得 Clock，
+測試時用 WithClock 放入 Fake，被測的程式碼完全不需要改寫。
+*/
+
+type Clock interface {
+	Now() time.Time
+	NewTimer(d time.Duration) Timer
+}
+
+type Timer interface {
+	C() <-chan time.Time
+	Stop() bool
+}
+
+var clockKey = ctxutil.NewKey[Clock]("clock")
+
+// WithClock 讓由 ctx 衍生的呼叫都使用 c
+func WithClock(ctx context.Context, c Clock) context.Context {
+	return clockKey.With(ctx, c)
+}
+
+// ClockFrom 回傳 ctx 中的 Clock，沒有的話回傳 Real
+func ClockFrom(ctx context.Context) Clock {
+	if c, ok := clockKey.From(ctx); ok && c != nil {
+		return c
+	}
+	return Real{}
+}
+
+type Real struct{}
+
+func (Real) Now() time.Time { return time.Now() }
+
+func (Real) NewTimer(d time.Duration) Timer { return realTimer{time.NewTimer(d)} }
+
+type realTimer struct{ t *time.Timer }
+
+func (r realTimer) C() <-chan time.Time { return r.t.C }
+func (r realTimer) Stop() bool          { return r.t.Stop() }
+
+// Fake 只有在呼叫 Advance 時時間才會前進
+type Fake struct {
+	mu      sync.Mutex
+	cond    *sync.Cond
+	now     time.Time
+	waiters []*fakeTimer
+}
+
+func NewFake(now time.Time) *Fake {
+	f := &Fake{now: now}
+	f.cond = sync.NewCond(&f.mu)
+	return f
+}
+
+func (f *Fake) Now() time.Time {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.now
+}
+
+func (f *Fake) NewTimer(d time.Duration) Timer {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	t := &fakeTimer{f: f, ch: make(chan time.Time, 1), when: f.now.Add(d)}
+	if d <= 0 {
+		t.ch <- f.now
+		return t
+	}
+	f.waiters = append(f.waiters, t)
+	f.cond.Broadcast()
+	return t
+}
+
+// Advance 推進時間，依時間順序觸發到期的 timer
+func (f *Fake) Advance(d time.Duration) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	end := f.now.Add(d)
+	sort.SliceStable(f.waiters, func(i, j int) bool { return f.waiters[i].when.Before(f.waiters[j].when) })
+	for len(f.waiters) > 0 && !f.waiters[0].when.After(end) {
+		t := f.waiters[0]
+		f.waiters = f.waiters[1:]
+		f.now = t.when
+		t.ch <- f.now
+	}
+	f.now = end
+}
+
+// BlockUntil 等到至少有 n 個 timer 在等待，確認被測的 goroutine 已經開始睡覺後再推進時間
+func (f *Fake) BlockUntil(n int) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for len(f.waiters) < n {
+		f.cond.Wait()
+	}
+}
+
+type fakeTimer struct {
+	f    *Fake
+	ch   chan time.Time
+	when time.Time
+}
+
+func (t *fakeTimer) C() <-chan time.Time { return t.ch }
+
+func (t *fakeTimer) Stop() bool {
+	t.f.mu.Lock()
+	defer t.f.mu.Unlock()
+	for i, w := range t.f.waiters {
+		if w == t {
+			t.f.waiters = append(t.f.waiters[:i], t.f.waiters[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
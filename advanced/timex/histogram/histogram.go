@@ -0,0 +1,190 @@
+package histogram
+
+import (
+	"fmt"
+	"math"
+	"math/bits"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+/*
+記錄延遲用的 histogram：每個 bucket 一個 atomic 計數器，Record 不需要鎖，可以在熱路徑上呼叫。
+
+bucket 採 log-linear（類似 HdrHistogram）：每個 2 的次方區間再平均切成 8 份，
+  [0,8) 每個值一個 bucket，[8,16) 每 1ns 一個，[16,32) 每 2ns 一個，[1024,2048) 每 128ns 一個…
+所以相對誤差固定在 1/8 以內，而 64 個次方 × 8 = 512 個 bucket 就能涵蓋整個 int64 的範圍。
+
+Quantile 回傳 bucket 的上界，誤差不超過 12.5%，對延遲的 p50/p99 來說已經足夠。
+*/
+
+const (
+	subBits    = 3
+	subBuckets = 1 << subBits
+	numBuckets = 64 * subBuckets
+)
+
+type Histogram struct {
+	counts [numBuckets]atomic.Uint64
+	count  atomic.Uint64
+	sum    atomic.Int64
+	max    atomic.Int64
+	min    atomic.Int64 // 0 表示尚未記錄，實際的最小值存 v+1
+}
+
+func New() *Histogram { return &Histogram{} }
+
+// bucketOf 回傳 v 所在的 bucket
+func bucketOf(v uint64) int {
+	if v < subBuckets {
+		return int(v)
+	}
+	e := bits.Len64(v) - 1 // v 在 [2^e, 2^(e+1))
+	sub := (v >> (e - subBits)) & (subBuckets - 1)
+	return (e-subBits+1)*subBuckets + int(sub)
+}
+
+// upperBound 回傳 bucket i 涵蓋的最大值
+func upperBound(i int) uint64 {
+	if i < subBuckets {
+		return uint64(i)
+	}
+	e := i/subBuckets + subBits - 1
+	sub := uint64(i % subBuckets)
+	lower := uint64(1)<<e | sub<<(e-subBits)
+	return lower + uint64(1)<<(e-subBits) - 1
+}
+
+// Record 記錄一次延遲，負值視為 0
+func (h *Histogram) Record(d time.Duration) {
+	v := int64(d)
+	if v < 0 {
+		v = 0
+	}
+	h.counts[bucketOf(uint64(v))].Add(1)
+	h.count.Add(1)
+	h.sum.Add(v)
+	for {
+		m := h.max.Load()
+		if v <= m || h.max.CompareAndSwap(m, v) {
+			break
+		}
+	}
+	for {
+		m := h.min.Load()
+		if (m != 0 && v+1 >= m) || h.min.CompareAndSwap(m, v+1) {
+			break
+		}
+	}
+}
+
+// Since 記錄從 start 到現在的時間，方便 defer h.Since(time.Now())
+func (h *Histogram) Since(start time.Time) {
+	h.Record(time.Since(start))
+}
+
+// Time 執行 fn 並記錄所花的時間
+func (h *Histogram) Time(fn func()) {
+	start := time.Now()
+	fn()
+	h.Since(start)
+}
+
+func (h *Histogram) Count() uint64 { return h.count.Load() }
+
+// Snapshot 複製目前的計數；並行 Record 時各欄位之間可能差幾筆
+func (h *Histogram) Snapshot() *Snapshot {
+	s := &Snapshot{
+		Count: h.count.Load(),
+		Sum:   time.Duration(h.sum.Load()),
+		Max:   time.Duration(h.max.Load()),
+	}
+	if m := h.min.Load(); m > 0 {
+		s.Min = time.Duration(m - 1)
+	}
+	for i := range h.counts {
+		s.counts[i] = h.counts[i].Load()
+	}
+	return s
+}
+
+// Reset 清空所有計數；與 Record 並行時可能遺失少數幾筆
+func (h *Histogram) Reset() {
+	for i := range h.counts {
+		h.counts[i].Store(0)
+	}
+	h.count.Store(0)
+	h.sum.Store(0)
+	h.max.Store(0)
+	h.min.Store(0)
+}
+
+type Snapshot struct {
+	Count    uint64
+	Sum      time.Duration
+	Min, Max time.Duration
+	counts   [numBuckets]uint64
+}
+
+func (s *Snapshot) Mean() time.Duration {
+	if s.Count == 0 {
+		return 0
+	}
+	return s.Sum / time.Duration(s.Count)
+}
+
+// Quantile 回傳 q（0~1）分位數的近似值，結果不會超過 Max
+func (s *Snapshot) Quantile(q float64) time.Duration {
+	var total uint64
+	for _, c := range s.counts {
+		total += c
+	}
+	if total == 0 {
+		return 0
+	}
+	rank := uint64(math.Ceil(q * float64(total)))
+	if rank == 0 {
+		rank = 1
+	}
+	var seen uint64
+	for i, c := range s.counts {
+		seen += c
+		if seen >= rank {
+			v := time.Duration(upperBound(i))
+			if v > s.Max {
+				v = s.Max
+			}
+			return v
+		}
+	}
+	return s.Max
+}
+
+// Merge 把 o 的計數加進 s，用來合併多個 histogram（例如每個 worker 一個）
+func (s *Snapshot) Merge(o *Snapshot) {
+	if o.Count == 0 {
+		return
+	}
+	if s.Count == 0 || o.Min < s.Min {
+		s.Min = o.Min
+	}
+	if o.Max > s.Max {
+		s.Max = o.Max
+	}
+	s.Count += o.Count
+	s.Sum += o.Sum
+	for i := range s.counts {
+		s.counts[i] += o.counts[i]
+	}
+}
+
+func (s *Snapshot) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "count=%d min=%s mean=%s", s.Count, s.Min, s.Mean())
+	for _, q := range []float64{0.5, 0.9, 0.99} {
+		fmt.Fprintf(&b, " p%g=%s", q*100, s.Quantile(q))
+	}
+	fmt.Fprintf(&b, " max=%s", s.Max)
+	return b.String()
+}
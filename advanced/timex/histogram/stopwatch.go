@@ -0,0 +1,34 @@
+package histogram
+
+import "time"
+
+// Stopwatch 量測一段程式的時間，可以分段（Lap）記錄
+//
+//	sw := histogram.Start()
+//	parse()
+//	sw.Lap(parseHist)
+//	render()
+//	sw.Lap(renderHist)
+type Stopwatch struct {
+	start time.Time
+	lap   time.Time
+}
+
+func Start() *Stopwatch {
+	now := time.Now()
+	return &Stopwatch{start: now, lap: now}
+}
+
+// Elapsed 回傳從 Start 到現在的時間
+func (s *Stopwatch) Elapsed() time.Duration { return time.Since(s.start) }
+
+// Lap 回傳並記錄（h 不為 nil 時）從上一次 Lap 到現在的時間
+func (s *Stopwatch) Lap(h *Histogram) time.Duration {
+	now := time.Now()
+	d := now.Sub(s.lap)
+	s.lap = now
+	if h != nil {
+		h.Record(d)
+	}
+	return d
+}
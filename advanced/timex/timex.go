@@ -0,0 +1,123 @@
+package timex
+
+import (
+	"context"
+	"math/rand"
+	"time"
+)
+
+/*
+取代散落各處的 time.Sleep：
+  - Sleep(ctx, d)：可以被取消，shutdown 時不會卡在 sleep 裡
+  - Backoff：產生重試間隔的 iterator，指數成長、有上限、可加 jitter
+  - DeadlineFraction：從上層剩下的時間切出一部分給子呼叫，留時間給後續的處理（fallback、回應）
+*/
+
+// Sleep 等待 d，ctx 先結束時回傳 ctx.Err()
+func Sleep(ctx context.Context, d time.Duration) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	t := ClockFrom(ctx).NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-t.C():
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Backoff 描述重試間隔：Initial × Multiplier^n，不超過 Max。
+// Jitter 為 0~1，實際間隔在 [d×(1-Jitter), d] 之間隨機，避免大量 client 同時重試。
+type Backoff struct {
+	Initial     time.Duration
+	Max         time.Duration // 0 表示沒有上限
+	Multiplier  float64       // 預設 2
+	Jitter      float64
+	MaxAttempts int        // Next 最多回傳幾次，0 表示不限
+	Rand        *rand.Rand // nil 時使用 math/rand 的全域來源
+}
+
+// Iter 是一次重試流程的狀態，不可並行使用
+type Iter struct {
+	b       Backoff
+	attempt int
+	cur     time.Duration
+}
+
+func (b Backoff) Iter() *Iter {
+	if b.Multiplier == 0 {
+		b.Multiplier = 2
+	}
+	return &Iter{b: b, cur: b.Initial}
+}
+
+// Next 回傳下一次重試前要等待的時間，次數用完時 ok 為 false
+func (it *Iter) Next() (d time.Duration, ok bool) {
+	if it.b.MaxAttempts > 0 && it.attempt >= it.b.MaxAttempts {
+		return 0, false
+	}
+	it.attempt++
+	d = it.cur
+	next := time.Duration(float64(it.cur) * it.b.Multiplier)
+	if it.b.Max > 0 && (next > it.b.Max || next < it.cur) { // next < cur 表示溢位
+		next = it.b.Max
+	}
+	it.cur = next
+
+	if it.b.Jitter > 0 {
+		f := rand.Float64
+		if it.b.Rand != nil {
+			f = it.b.Rand.Float64
+		}
+		d -= time.Duration(f() * it.b.Jitter * float64(d))
+	}
+	return d, true
+}
+
+// Attempt 回傳 Next 已經回傳過幾次
+func (it *Iter) Attempt() int { return it.attempt }
+
+// Wait 等待下一個間隔；次數用完時回傳 false 與 nil，被取消時回傳 ctx.Err()
+func (it *Iter) Wait(ctx context.Context) (bool, error) {
+	d, ok := it.Next()
+	if !ok {
+		return false, nil
+	}
+	return true, Sleep(ctx, d)
+}
+
+// Retry 執行 fn 直到成功、次數用完或 ctx 被取消，回傳最後一次的錯誤
+func Retry(ctx context.Context, b Backoff, fn func(ctx context.Context) error) error {
+	it := b.Iter()
+	for {
+		err := fn(ctx)
+		if err == nil {
+			return nil
+		}
+		ok, werr := it.Wait(ctx)
+		if werr != nil {
+			return werr
+		}
+		if !ok {
+			return err
+		}
+	}
+}
+
+// DeadlineFraction 建立子 context，deadline 為 ctx 剩餘時間的 f 倍（0 < f <= 1）。
+// ctx 沒有 deadline 時只回傳可取消的子 context。
+// context 的 deadline 是真實時間，所以這裡不使用 ctx 中的 Clock。
+func DeadlineFraction(ctx context.Context, f float64) (context.Context, context.CancelFunc) {
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		return context.WithCancel(ctx)
+	}
+	now := time.Now()
+	remaining := deadline.Sub(now)
+	if remaining <= 0 {
+		return context.WithDeadline(ctx, deadline)
+	}
+	return context.WithDeadline(ctx, now.Add(time.Duration(float64(remaining)*f)))
+}
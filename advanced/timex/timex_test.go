@@ -0,0 +1,142 @@
+package timex
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var epoch = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+func TestSleepWithFakeClock(t *testing.T) {
+	clock := NewFake(epoch)
+	ctx := WithClock(context.Background(), clock)
+
+	done := make(chan error)
+	go func() { done <- Sleep(ctx, time.Hour) }()
+
+	clock.BlockUntil(1)
+	clock.Advance(59 * time.Minute)
+	select {
+	case <-done:
+		t.Fatal("woke up too early")
+	default:
+	}
+	clock.Advance(time.Minute)
+	assert.NoError(t, <-done)
+	assert.Equal(t, epoch.Add(time.Hour), clock.Now())
+}
+
+func TestSleepCancel(t *testing.T) {
+	clock := NewFake(epoch)
+	ctx, cancel := context.WithCancel(WithClock(context.Background(), clock))
+	done := make(chan error)
+	go func() { done <- Sleep(ctx, time.Hour) }()
+	clock.BlockUntil(1)
+	cancel()
+	assert.ErrorIs(t, <-done, context.Canceled)
+	// 被取消後 timer 已經 Stop，不會留在 clock 裡
+	assert.Empty(t, clock.waiters)
+
+	assert.ErrorIs(t, Sleep(ctx, 0), context.Canceled)
+}
+
+func TestSleepRealClock(t *testing.T) {
+	start := time.Now()
+	require.NoError(t, Sleep(context.Background(), 5*time.Millisecond))
+	assert.GreaterOrEqual(t, time.Since(start), 5*time.Millisecond)
+}
+
+func delays(it *Iter) []time.Duration {
+	var out []time.Duration
+	for d, ok := it.Next(); ok; d, ok = it.Next() {
+		out = append(out, d)
+	}
+	return out
+}
+
+func TestBackoff(t *testing.T) {
+	b := Backoff{Initial: 100 * time.Millisecond, Max: time.Second, MaxAttempts: 6}
+	ms := time.Millisecond
+	assert.Equal(t, []time.Duration{100 * ms, 200 * ms, 400 * ms, 800 * ms, time.Second, time.Second}, delays(b.Iter()))
+
+	// 每次 Iter 都是新的流程
+	it := b.Iter()
+	it.Next()
+	assert.Equal(t, 1, it.Attempt())
+	assert.Len(t, delays(b.Iter()), 6)
+
+	// 沒有上限時也不會溢位成負數
+	huge := Backoff{Initial: time.Duration(1 << 62), Max: time.Duration(1<<63 - 1), MaxAttempts: 3}
+	for _, d := range delays(huge.Iter()) {
+		assert.Positive(t, d)
+	}
+}
+
+func TestBackoffJitter(t *testing.T) {
+	b := Backoff{Initial: time.Second, Multiplier: 1, Jitter: 0.5, MaxAttempts: 100, Rand: rand.New(rand.NewSource(1))}
+	ds := delays(b.Iter())
+	require.Len(t, ds, 100)
+	distinct := make(map[time.Duration]bool)
+	for _, d := range ds {
+		assert.GreaterOrEqual(t, d, 500*time.Millisecond)
+		assert.LessOrEqual(t, d, time.Second)
+		distinct[d] = true
+	}
+	assert.Greater(t, len(distinct), 50)
+}
+
+func TestRetry(t *testing.T) {
+	clock := NewFake(epoch)
+	ctx := WithClock(context.Background(), clock)
+	b := Backoff{Initial: time.Second, MaxAttempts: 3}
+
+	calls := 0
+	done := make(chan error)
+	go func() {
+		done <- Retry(ctx, b, func(context.Context) error {
+			calls++
+			if calls < 3 {
+				return errors.New("unavailable")
+			}
+			return nil
+		})
+	}()
+	clock.BlockUntil(1)
+	clock.Advance(time.Second)
+	clock.BlockUntil(1)
+	clock.Advance(2 * time.Second)
+	require.NoError(t, <-done)
+	assert.Equal(t, 3, calls)
+	assert.Equal(t, epoch.Add(3*time.Second), clock.Now())
+
+	// 次數用完回傳最後的錯誤
+	boom := errors.New("boom")
+	err := Retry(context.Background(), Backoff{Initial: time.Microsecond, MaxAttempts: 2}, func(context.Context) error { return boom })
+	assert.ErrorIs(t, err, boom)
+}
+
+func TestDeadlineFraction(t *testing.T) {
+	parent, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	want, _ := parent.Deadline()
+
+	child, cancelChild := DeadlineFraction(parent, 0.8)
+	defer cancelChild()
+	got, ok := child.Deadline()
+	require.True(t, ok)
+	assert.WithinDuration(t, want.Add(-200*time.Millisecond), got, 20*time.Millisecond)
+
+	// 沒有 deadline 的 ctx 不會被加上 deadline
+	free, cancelFree := DeadlineFraction(context.Background(), 0.5)
+	defer cancelFree()
+	_, ok = free.Deadline()
+	assert.False(t, ok)
+	cancelFree()
+	assert.ErrorIs(t, free.Err(), context.Canceled)
+}
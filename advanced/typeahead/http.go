@@ -0,0 +1,50 @@
+package typeahead
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+)
+
+const (
+	DefaultLimit = 10
+	MaxLimit     = 50
+)
+
+type response struct {
+	Query       string       `json:"query"`
+	Suggestions []Suggestion `json:"suggestions"`
+}
+
+// Handler 處理 GET ?q=<prefix>&limit=<n>
+func Handler(s *Suggester) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			w.Header().Set("Allow", http.MethodGet)
+			http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+			return
+		}
+		q := r.URL.Query().Get("q")
+		if q == "" {
+			http.Error(w, "missing q", http.StatusBadRequest)
+			return
+		}
+		limit := DefaultLimit
+		if v := r.URL.Query().Get("limit"); v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil || n <= 0 {
+				http.Error(w, "invalid limit", http.StatusBadRequest)
+				return
+			}
+			limit = min(n, MaxLimit)
+		}
+		res := response{Query: q, Suggestions: s.Suggest(q, limit)}
+		if res.Suggestions == nil {
+			res.Suggestions = []Suggestion{} // 輸出 [] 而不是 null
+		}
+		w.Header().Set("Content-Type", "application/json")
+		// 建議結果變動不頻繁，讓瀏覽器短暫快取同一個前綴的結果
+		w.Header().Set("Cache-Control", "max-age=60")
+		_ = json.NewEncoder(w).Encode(res)
+	})
+}
@@ -0,0 +1,136 @@
+package typeahead
+
+import (
+	"sort"
+	"strings"
+
+	"advanced/ds/trie"
+	"advanced/text/levenshtein"
+)
+
+/*
+Typeahead：使用者輸入一個（可能打錯的）前綴，回傳最可能的完整詞。
+
+比對方式是「前綴編輯距離」：query 與詞的某個前綴之間的 Levenshtein distance ≤ maxDist 即算符合，
+例如 maxDist = 1 時 "gopehr" 不符合 "gopher"（距離 2），但 "gopj" 符合（gop + 替換一個字元）。
+
+在 trie 上 DFS，每往下一層就把 levenshtein.Row 往前推一格：
+  - row.Distance() 是 query 與目前路徑（詞的前綴）的距離，≤ maxDist 時子樹中所有詞都符合
+  - row.Min() > maxDist 時再往下也不可能變小，整棵子樹剪掉
+  - 已經符合且 row.Min() 不可能再更小時，直接收集子樹，不再計算 DP
+
+query 太短時容錯會匹配到幾乎所有詞（"a" 的距離 1 包含空前綴），所以少於 minFuzzyLen 個字元只做精確前綴。
+
+排序：距離小的優先 → 權重高的優先 → 較短的詞優先 → 字典序
+*/
+
+type Suggestion struct {
+	Term     string  `json:"term"`
+	Weight   float64 `json:"weight"`
+	Distance int     `json:"distance"`
+}
+
+type Suggester struct {
+	t           *trie.Trie
+	maxDist     int
+	minFuzzyLen int
+}
+
+type Option func(*Suggester)
+
+// WithMaxDistance 設定容許的編輯距離，預設 1
+func WithMaxDistance(d int) Option {
+	return func(s *Suggester) { s.maxDist = d }
+}
+
+// WithMinFuzzyLen 設定 query 至少幾個字元才啟用容錯，預設 3
+func WithMinFuzzyLen(n int) Option {
+	return func(s *Suggester) { s.minFuzzyLen = n }
+}
+
+func New(opts ...Option) *Suggester {
+	s := &Suggester{t: trie.New(), maxDist: 1, minFuzzyLen: 3}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+func normalize(s string) string {
+	return strings.ToLower(strings.TrimSpace(s))
+}
+
+// Add 加入詞與權重（例如搜尋次數），已存在時更新權重
+func (s *Suggester) Add(term string, weight float64) {
+	if term = normalize(term); term != "" {
+		s.t.Insert(term, weight)
+	}
+}
+
+func (s *Suggester) Len() int { return s.t.Len() }
+
+// Suggest 回傳最多 limit 筆建議
+func (s *Suggester) Suggest(query string, limit int) []Suggestion {
+	query = normalize(query)
+	if query == "" || limit <= 0 {
+		return nil
+	}
+	maxDist := s.maxDist
+	if len([]rune(query)) < s.minFuzzyLen {
+		maxDist = 0
+	}
+
+	best := make(map[string]Suggestion)
+	add := func(word string, weight float64, dist int) bool {
+		if cur, ok := best[word]; !ok || dist < cur.Distance {
+			best[word] = Suggestion{Term: word, Weight: weight, Distance: dist}
+		}
+		return true
+	}
+
+	s.t.View(func(root *trie.Node) {
+		var dfs func(n *trie.Node, row levenshtein.Row, found int)
+		dfs = func(n *trie.Node, row levenshtein.Row, found int) {
+			found = min(found, row.Distance())
+			if found <= maxDist && row.Min() >= found {
+				// 子樹中的詞都不可能得到更小的距離
+				n.Walk(func(w string, weight float64) bool { return add(w, weight, found) })
+				return
+			}
+			if found <= maxDist {
+				if w, weight, ok := n.Word(); ok {
+					add(w, weight, found)
+				}
+			} else if row.Min() > maxDist {
+				return
+			}
+			n.Each(func(r rune, c *trie.Node) bool {
+				dfs(c, row.Next(r), found)
+				return true
+			})
+		}
+		dfs(root, levenshtein.NewRow(query), maxDist+1)
+	})
+
+	out := make([]Suggestion, 0, len(best))
+	for _, sg := range best {
+		out = append(out, sg)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		a, b := out[i], out[j]
+		if a.Distance != b.Distance {
+			return a.Distance < b.Distance
+		}
+		if a.Weight != b.Weight {
+			return a.Weight > b.Weight
+		}
+		if len(a.Term) != len(b.Term) {
+			return len(a.Term) < len(b.Term)
+		}
+		return a.Term < b.Term
+	})
+	if len(out) > limit {
+		out = out[:limit]
+	}
+	return out
+}
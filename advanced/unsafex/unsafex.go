@@ -0,0 +1,34 @@
+package unsafex
+
+import "unsafe"
+
+/*
+string(b) 與 []byte(s) 都會配置新的記憶體並複製內容，
+在 framing 解析、log 組字串這類熱點路徑上，這些複製是可以避免的。
+
+Go 1.20 提供 unsafe.String / unsafe.StringData / unsafe.Slice，
+可以在不複製的情況下共用同一塊底層記憶體，取代以前 reflect.StringHeader 的寫法。
+
+使用前提（違反的話是 undefined behavior，不會有編譯錯誤）：
+ 1. BytesToString 之後，原本的 []byte 不可以再被修改，
+    否則 string 的內容會跟著變，破壞 string 不可變的保證（例如 map key 會壞掉）
+ 2. StringToBytes 回傳的 []byte 絕對不可以修改，
+    string 常數放在唯讀記憶體區段，寫入會直接 segmentation fault
+ 3. 回傳值與原本的值共用記憶體，只要其中一個還活著，整塊記憶體就不會被 GC 回收
+*/
+
+// BytesToString 零複製把 []byte 轉成 string，呼叫後不可再修改 b
+func BytesToString(b []byte) string {
+	if len(b) == 0 {
+		return ""
+	}
+	return unsafe.String(unsafe.SliceData(b), len(b))
+}
+
+// StringToBytes 零複製把 string 轉成 []byte，回傳的 slice 為唯讀
+func StringToBytes(s string) []byte {
+	if s == "" {
+		return nil
+	}
+	return unsafe.Slice(unsafe.StringData(s), len(s))
+}
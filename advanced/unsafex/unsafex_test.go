@@ -0,0 +1,120 @@
+package unsafex
+
+import (
+	"bytes"
+	"strconv"
+	"testing"
+	"unsafe"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBytesToString(t *testing.T) {
+	b := []byte("hello")
+	s := BytesToString(b)
+	assert.Equal(t, "hello", s)
+	// 共用同一塊記憶體，沒有複製
+	assert.Equal(t, unsafe.Pointer(&b[0]), unsafe.Pointer(unsafe.StringData(s)))
+
+	assert.Equal(t, "", BytesToString(nil))
+	assert.Equal(t, "", BytesToString([]byte{}))
+}
+
+// 示範違反前提 1 的後果：修改 []byte 後 string 也變了
+func TestBytesToStringAliasing(t *testing.T) {
+	b := []byte("abc")
+	s := BytesToString(b)
+	b[0] = 'x'
+	assert.Equal(t, "xbc", s)
+
+	safe := string(b)
+	b[0] = 'y'
+	assert.Equal(t, "xbc", safe)
+}
+
+func TestStringToBytes(t *testing.T) {
+	s := strconv.Itoa(123456) // 動態產生，不是放在唯讀區段的常數
+	b := StringToBytes(s)
+	assert.Equal(t, []byte("123456"), b)
+	assert.Equal(t, len(s), len(b))
+	assert.Equal(t, len(s), cap(b))
+	assert.Equal(t, unsafe.Pointer(unsafe.StringData(s)), unsafe.Pointer(&b[0]))
+
+	assert.Nil(t, StringToBytes(""))
+}
+
+func TestRoundTrip(t *testing.T) {
+	for _, s := range []string{"", "a", "中文字串", string([]byte{0, 1, 2, 255})} {
+		assert.Equal(t, s, BytesToString(StringToBytes(s)))
+	}
+}
+
+func TestNoAllocs(t *testing.T) {
+	b := []byte("payload")
+	s := "payload"
+	var sink int
+	allocs := testing.AllocsPerRun(100, func() {
+		sink += len(BytesToString(b))
+		sink += len(StringToBytes(s))
+	})
+	assert.Equal(t, 0.0, allocs)
+	_ = sink
+}
+
+/*
+熱點路徑的模擬，轉換結果會被保存下來（逃逸到 heap），編譯器無法把複製最佳化掉：
+	- framing：把 payload 解析成 string 欄位存進 frame
+	- logging：把 string 訊息轉成 []byte 交給 writer 保存
+*/
+
+type frame struct {
+	Kind    string
+	Payload string
+}
+
+var (
+	frameSink frame
+	logSink   [][]byte
+	payload   = bytes.Repeat([]byte("x"), 256)
+	msg       = strconv.Itoa(1<<40) + " request handled"
+)
+
+func BenchmarkFrameDecodeCopy(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		frameSink = frame{Kind: "DATA", Payload: string(payload)}
+	}
+}
+
+func BenchmarkFrameDecodeUnsafe(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		frameSink = frame{Kind: "DATA", Payload: BytesToString(payload)}
+	}
+}
+
+func BenchmarkLogWriteCopy(b *testing.B) {
+	b.ReportAllocs()
+	logSink = make([][]byte, 1)
+	for i := 0; i < b.N; i++ {
+		logSink[0] = []byte(msg)
+	}
+}
+
+func BenchmarkLogWriteUnsafe(b *testing.B) {
+	b.ReportAllocs()
+	logSink = make([][]byte, 1)
+	for i := 0; i < b.N; i++ {
+		logSink[0] = StringToBytes(msg)
+	}
+}
+
+/*
+goos: linux
+goarch: amd64
+pkg: advanced/unsafex
+BenchmarkFrameDecodeCopy   	13903287	        96.80 ns/op	     256 B/op	       1 allocs/op
+BenchmarkFrameDecodeUnsafe 	516641170	         2.199 ns/op	       0 B/op	       0 allocs/op
+BenchmarkLogWriteCopy      	21629637	        51.68 ns/op	      32 B/op	       1 allocs/op
+BenchmarkLogWriteUnsafe    	578592739	         2.112 ns/op	       0 B/op	       0 allocs/op
+*/
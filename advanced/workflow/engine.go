@@ -0,0 +1,227 @@
+package workflow
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+
+	"advanced/eventlog"
+)
+
+type State string
+
+const (
+	Running      State = "running"
+	Compensating State = "compensating"
+	Completed    State = "completed"
+	Failed       State = "failed"
+)
+
+type StepState string
+
+const (
+	StepCompleted   StepState = "completed"
+	StepFailed      StepState = "failed" // 最近一次嘗試失敗（可能還會重試）
+	StepCompensated StepState = "compensated"
+)
+
+type StepStatus struct {
+	State    StepState
+	Attempts int
+	Error    string
+}
+
+// Status 是 run 的 event 投影出來的狀態
+type Status struct {
+	Run      string
+	Workflow string
+	State    State
+	Vars     Vars
+	Steps    map[string]StepStatus
+	Error    string
+}
+
+func (s Status) clone() Status {
+	s.Vars = s.Vars.clone()
+	steps := make(map[string]StepStatus, len(s.Steps))
+	for k, v := range s.Steps {
+		steps[k] = v
+	}
+	s.Steps = steps
+	return s
+}
+
+const (
+	evStarted       = "started"
+	evStepCompleted = "step_completed"
+	evStepFailed    = "step_failed"
+	evAborting      = "aborting"
+	evCompensated   = "compensated"
+	evCompleted     = "completed"
+	evFailed        = "failed"
+)
+
+type event struct {
+	Run      string
+	Type     string
+	Workflow string `json:",omitempty"`
+	Input    Vars   `json:",omitempty"`
+	Step     string `json:",omitempty"`
+	Output   Vars   `json:",omitempty"`
+	Error    string `json:",omitempty"`
+}
+
+// Engine 執行 workflow 並把 event 寫入 log；同一個 log 只應由一個 Engine 使用。
+// event 都沒有 key，不要對 log 做 Compact
+type Engine struct {
+	log *eventlog.Log
+
+	mu        sync.Mutex
+	workflows map[string]*Workflow
+	runs      map[string]*execution
+}
+
+func NewEngine(log *eventlog.Log) *Engine {
+	return &Engine{log: log, workflows: make(map[string]*Workflow), runs: make(map[string]*execution)}
+}
+
+// Register 加入 workflow 定義；Resume 前必須先註冊 log 中出現過的 workflow
+func (e *Engine) Register(ws ...*Workflow) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	for _, w := range ws {
+		e.workflows[w.name] = w
+	}
+}
+
+func (e *Engine) newExecution(run string, w *Workflow) *execution {
+	return &execution{
+		engine:      e,
+		wf:          w,
+		status:      Status{Run: run, Workflow: w.name, Steps: make(map[string]StepStatus)},
+		completed:   make(map[string]bool),
+		compensated: make(map[string]bool),
+		done:        make(chan struct{}),
+	}
+}
+
+// Start 在背景執行一個 run；ctx 結束時 run 停在目前的位置（不算失敗），之後可以 Resume
+func (e *Engine) Start(ctx context.Context, workflow, run string, input Vars) error {
+	e.mu.Lock()
+	w, ok := e.workflows[workflow]
+	if !ok {
+		e.mu.Unlock()
+		return fmt.Errorf("%w: %q", ErrUnknownWorkflow, workflow)
+	}
+	if _, ok := e.runs[run]; ok {
+		e.mu.Unlock()
+		return fmt.Errorf("%w: %q", ErrRunExists, run)
+	}
+	ex := e.newExecution(run, w)
+	e.runs[run] = ex
+	e.mu.Unlock()
+
+	if err := ex.record(event{Type: evStarted, Workflow: workflow, Input: input}); err != nil {
+		e.mu.Lock()
+		delete(e.runs, run)
+		e.mu.Unlock()
+		return err
+	}
+	go ex.execute(ctx)
+	return nil
+}
+
+// Resume 從 log 重建所有 run 的狀態，並在背景繼續還沒結束的 run，回傳繼續執行的 run
+func (e *Engine) Resume(ctx context.Context) ([]string, error) {
+	byRun := make(map[string][]event)
+	var order []string
+	for _, r := range e.log.Read(0, 0) {
+		var ev event
+		if err := json.Unmarshal(r.Data, &ev); err != nil {
+			return nil, fmt.Errorf("workflow: offset %d: %w", r.Offset, err)
+		}
+		if _, ok := byRun[ev.Run]; !ok {
+			order = append(order, ev.Run)
+		}
+		byRun[ev.Run] = append(byRun[ev.Run], ev)
+	}
+
+	var resumed []string
+	var errs []error
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	for _, run := range order {
+		if _, ok := e.runs[run]; ok {
+			continue
+		}
+		events := byRun[run]
+		w, ok := e.workflows[events[0].Workflow]
+		if !ok {
+			errs = append(errs, fmt.Errorf("%w: %q (run %q)", ErrUnknownWorkflow, events[0].Workflow, run))
+			continue
+		}
+		ex := e.newExecution(run, w)
+		for _, ev := range events {
+			ex.apply(ev)
+		}
+		e.runs[run] = ex
+		if ex.status.State == Completed || ex.status.State == Failed {
+			close(ex.done)
+			continue
+		}
+		resumed = append(resumed, run)
+		go ex.execute(ctx)
+	}
+	return resumed, errors.Join(errs...)
+}
+
+func (e *Engine) lookup(run string) (*execution, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	ex, ok := e.runs[run]
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", ErrUnknownRun, run)
+	}
+	return ex, nil
+}
+
+func (e *Engine) Status(run string) (Status, error) {
+	ex, err := e.lookup(run)
+	if err != nil {
+		return Status{}, err
+	}
+	return ex.snapshotStatus(), nil
+}
+
+// List 依 run 名稱排序回傳所有 run 的狀態
+func (e *Engine) List() []Status {
+	e.mu.Lock()
+	runs := make([]*execution, 0, len(e.runs))
+	for _, ex := range e.runs {
+		runs = append(runs, ex)
+	}
+	e.mu.Unlock()
+	out := make([]Status, len(runs))
+	for i, ex := range runs {
+		out[i] = ex.snapshotStatus()
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Run < out[j].Run })
+	return out
+}
+
+// Wait 等到 run 的 goroutine 結束（完成、失敗或因 ctx 被取消而停下）後回傳狀態
+func (e *Engine) Wait(ctx context.Context, run string) (Status, error) {
+	ex, err := e.lookup(run)
+	if err != nil {
+		return Status{}, err
+	}
+	select {
+	case <-ex.done:
+		return ex.snapshotStatus(), nil
+	case <-ctx.Done():
+		return Status{}, ctx.Err()
+	}
+}
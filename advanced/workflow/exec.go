@@ -0,0 +1,199 @@
+package workflow
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+
+	"advanced/timex"
+)
+
+// execution 是一個 run 的執行狀態；所有改變都經過 record → apply
+type execution struct {
+	engine *Engine
+	wf     *Workflow
+
+	mu          sync.Mutex // 保護下面的欄位，也讓 Parallel 分支的 event 依序寫入
+	status      Status
+	completed   map[string]bool
+	order       []string // Step 的完成順序，補償時反過來
+	compensated map[string]bool
+
+	done chan struct{}
+}
+
+// record 先寫入 log，成功後才更新記憶體中的狀態
+func (ex *execution) record(ev event) error {
+	ev.Run = ex.status.Run
+	data, err := json.Marshal(ev)
+	if err != nil {
+		return err
+	}
+	ex.mu.Lock()
+	defer ex.mu.Unlock()
+	if _, err := ex.engine.log.Append("", data); err != nil {
+		return fmt.Errorf("workflow: append event: %w", err)
+	}
+	ex.applyLocked(ev)
+	return nil
+}
+
+func (ex *execution) apply(ev event) {
+	ex.mu.Lock()
+	defer ex.mu.Unlock()
+	ex.applyLocked(ev)
+}
+
+func (ex *execution) applyLocked(ev event) {
+	st := &ex.status
+	switch ev.Type {
+	case evStarted:
+		st.State = Running
+		st.Vars = ev.Input.clone()
+	case evStepCompleted:
+		for k, v := range ev.Output {
+			st.Vars[k] = v
+		}
+		s := st.Steps[ev.Step]
+		st.Steps[ev.Step] = StepStatus{State: StepCompleted, Attempts: s.Attempts + 1}
+		ex.completed[ev.Step] = true
+		ex.order = append(ex.order, ev.Step)
+	case evStepFailed:
+		s := st.Steps[ev.Step]
+		st.Steps[ev.Step] = StepStatus{State: StepFailed, Attempts: s.Attempts + 1, Error: ev.Error}
+	case evAborting:
+		st.State = Compensating
+		st.Error = ev.Error
+	case evCompensated:
+		s := st.Steps[ev.Step]
+		s.State = StepCompensated
+		st.Steps[ev.Step] = s
+		ex.compensated[ev.Step] = true
+	case evCompleted:
+		st.State = Completed
+	case evFailed:
+		st.State = Failed
+		st.Error = ev.Error
+	}
+}
+
+func (ex *execution) snapshot() Vars {
+	ex.mu.Lock()
+	defer ex.mu.Unlock()
+	return ex.status.Vars.clone()
+}
+
+func (ex *execution) snapshotStatus() Status {
+	ex.mu.Lock()
+	defer ex.mu.Unlock()
+	return ex.status.clone()
+}
+
+func (ex *execution) execute(ctx context.Context) {
+	defer close(ex.done)
+	ex.mu.Lock()
+	state, cause := ex.status.State, ex.status.Error
+	ex.mu.Unlock()
+
+	if state == Running {
+		err := ex.wf.root.run(ctx, ex)
+		var se *StepError
+		if !errors.As(err, &se) {
+			if err == nil {
+				ex.record(event{Type: evCompleted})
+			}
+			return // 其他錯誤是 ctx 被取消或寫不進 log：停在這裡等 Resume
+		}
+		cause = err.Error()
+		if ex.record(event{Type: evAborting, Error: cause}) != nil {
+			return
+		}
+	}
+	// Compensating：包含 Resume 時已經在補償中的 run
+	if err := ex.compensate(ctx); err != nil {
+		var se *StepError
+		if !errors.As(err, &se) {
+			return
+		}
+		cause = fmt.Sprintf("%s; compensation: %v", cause, err)
+	}
+	ex.record(event{Type: evFailed, Error: cause})
+}
+
+// runStep 執行一個 Step；已經完成的（重播）直接略過
+func (ex *execution) runStep(ctx context.Context, s *step) error {
+	ex.mu.Lock()
+	if ex.completed[s.name] {
+		ex.mu.Unlock()
+		return nil
+	}
+	in := ex.status.Vars.clone()
+	ex.mu.Unlock()
+
+	return ex.retry(ctx, s, func(ctx context.Context) error {
+		out, err := s.fn(ctx, in)
+		if err != nil {
+			return err
+		}
+		return ex.record(event{Type: evStepCompleted, Step: s.name, Output: out})
+	}, true)
+}
+
+// retry 依 Step 的 backoff 執行 fn；recordFailures 為 true 時每次失敗都寫入 step_failed
+func (ex *execution) retry(ctx context.Context, s *step, fn func(context.Context) error, recordFailures bool) error {
+	var it *timex.Iter
+	if s.backoff != nil {
+		it = s.backoff.Iter()
+	}
+	for attempt := 1; ; attempt++ {
+		err := fn(ctx)
+		if err == nil {
+			return nil
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if recordFailures {
+			if rerr := ex.record(event{Type: evStepFailed, Step: s.name, Error: err.Error()}); rerr != nil {
+				return rerr
+			}
+		}
+		if it == nil {
+			return &StepError{Step: s.name, Attempts: attempt, Err: err}
+		}
+		ok, werr := it.Wait(ctx)
+		if werr != nil {
+			return werr
+		}
+		if !ok {
+			return &StepError{Step: s.name, Attempts: attempt, Err: err}
+		}
+	}
+}
+
+// compensate 依完成順序的相反補償還沒補償過的 Step
+func (ex *execution) compensate(ctx context.Context) error {
+	ex.mu.Lock()
+	order := append([]string(nil), ex.order...)
+	ex.mu.Unlock()
+	for i := len(order) - 1; i >= 0; i-- {
+		name := order[i]
+		ex.mu.Lock()
+		done := ex.compensated[name]
+		ex.mu.Unlock()
+		s := ex.wf.steps[name]
+		if done || s == nil || s.compensate == nil {
+			continue
+		}
+		err := ex.retry(ctx, s, func(ctx context.Context) error { return s.compensate(ctx, ex.snapshot()) }, false)
+		if err != nil {
+			return err
+		}
+		if err := ex.record(event{Type: evCompensated, Step: name}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,227 @@
+package workflow
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"advanced/timex"
+)
+
+/*
+小型的 workflow engine：用 Sequence / Parallel / Branch 把 Step 組成流程，
+每一步的結果寫入 eventlog，程序當掉重啟後從 log 重建狀態繼續執行。
+
+	w, _ := workflow.New("order", workflow.Sequence(
+		workflow.Step("reserve", reserve, workflow.Compensate(release)),
+		workflow.Parallel(
+			workflow.Step("charge", charge, workflow.Retry(backoff), workflow.Compensate(refund)),
+			workflow.Step("invoice", invoice),
+		),
+		workflow.Branch("express?", isExpress, workflow.Step("ship-express", express), workflow.Step("ship", ship)),
+	))
+
+資料流：workflow 有一組 Vars（map[string]string），Step 收到目前 Vars 的副本，
+回傳的 Vars 合併回去，後面的 Step 就看得到。Branch 的條件只依賴 Vars，重播時會得到相同的選擇。
+
+持久化（event sourcing）：每次狀態改變都先 Append 一筆 event，記憶體中的狀態只是 event 的投影。
+
+	started → step_completed(output) … → completed
+	                 ↘ step_failed(attempt) → 重試
+	                 ↘ aborting(error) → compensated … → failed
+
+恢復（Engine.Resume）：讀出每個 run 的 event，已完成的 Step 直接套用紀錄的 output、不再執行，
+其他 Step 照常執行；若已經在 aborting，則繼續補償還沒補償的 Step。
+當掉時正在執行的 Step 沒有 step_completed，重啟後會再執行一次 —— Step 必須是 idempotent（at-least-once）。
+
+失敗與補償（saga）：Step 重試用完仍失敗時，依「完成順序的相反」呼叫已完成 Step 的 Compensate，
+Parallel 中的其他分支會被取消，已經完成的分支同樣會被補償。
+
+ctx 被取消（程序要結束）時不算失敗：不寫任何 event、不補償，狀態停在 running，等下次 Resume。
+*/
+
+var (
+	ErrDuplicateStep   = errors.New("workflow: duplicate step name")
+	ErrUnknownWorkflow = errors.New("workflow: unknown workflow")
+	ErrUnknownRun      = errors.New("workflow: unknown run")
+	ErrRunExists       = errors.New("workflow: run already exists")
+)
+
+type Vars map[string]string
+
+func (v Vars) clone() Vars {
+	out := make(Vars, len(v))
+	for k, val := range v {
+		out[k] = val
+	}
+	return out
+}
+
+// StepFunc 收到 Vars 的副本，回傳要合併回 workflow 的 Vars
+type StepFunc func(ctx context.Context, in Vars) (Vars, error)
+
+// CompensateFunc 撤銷 Step 的效果，in 是補償時的 Vars（包含該 Step 的 output）
+type CompensateFunc func(ctx context.Context, in Vars) error
+
+// Node 是流程中的一個節點：Step、Sequence、Parallel 或 Branch
+type Node interface {
+	run(ctx context.Context, ex *execution) error
+	walk(fn func(*step))
+}
+
+type step struct {
+	name       string
+	fn         StepFunc
+	compensate CompensateFunc
+	backoff    *timex.Backoff // nil 表示不重試
+}
+
+type StepOption func(*step)
+
+// Retry 失敗時依 b 重試，b.MaxAttempts 為重試次數（不含第一次，0 表示不限）；預設不重試
+func Retry(b timex.Backoff) StepOption {
+	return func(s *step) { s.backoff = &b }
+}
+
+// Compensate 設定 workflow 失敗時撤銷這一步的動作；補償失敗時也依 Retry 的設定重試
+func Compensate(fn CompensateFunc) StepOption {
+	return func(s *step) { s.compensate = fn }
+}
+
+// Step 的 name 在 workflow 內必須唯一，它是 event 中辨識 Step 的依據
+func Step(name string, fn StepFunc, opts ...StepOption) Node {
+	s := &step{name: name, fn: fn}
+	for _, o := range opts {
+		o(s)
+	}
+	return s
+}
+
+func (s *step) walk(fn func(*step)) { fn(s) }
+
+func (s *step) run(ctx context.Context, ex *execution) error { return ex.runStep(ctx, s) }
+
+type sequence []Node
+
+// Sequence 依序執行，任一個失敗就停止
+func Sequence(nodes ...Node) Node { return sequence(nodes) }
+
+func (q sequence) walk(fn func(*step)) {
+	for _, n := range q {
+		n.walk(fn)
+	}
+}
+
+func (q sequence) run(ctx context.Context, ex *execution) error {
+	for _, n := range q {
+		if err := n.run(ctx, ex); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type parallel []Node
+
+// Parallel 同時執行所有分支，任一個失敗時取消其他分支
+func Parallel(nodes ...Node) Node { return parallel(nodes) }
+
+func (p parallel) walk(fn func(*step)) {
+	for _, n := range p {
+		n.walk(fn)
+	}
+}
+
+func (p parallel) run(ctx context.Context, ex *execution) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	errs := make(chan error, len(p))
+	for _, n := range p {
+		go func(n Node) {
+			err := n.run(ctx, ex)
+			if err != nil {
+				cancel()
+			}
+			errs <- err
+		}(n)
+	}
+	// 優先回傳真正的失敗，而不是因為被取消而產生的 context.Canceled
+	var first error
+	for range p {
+		err := <-errs
+		var se *StepError
+		if errors.As(err, &se) && !errors.As(first, &se) {
+			first = err
+		} else if first == nil {
+			first = err
+		}
+	}
+	return first
+}
+
+type branch struct {
+	name      string
+	cond      func(Vars) bool
+	then, els Node
+}
+
+// Branch 依 cond 的結果執行 then 或 els（可為 nil）
+func Branch(name string, cond func(Vars) bool, then, els Node) Node {
+	return &branch{name: name, cond: cond, then: then, els: els}
+}
+
+func (b *branch) walk(fn func(*step)) {
+	for _, n := range []Node{b.then, b.els} {
+		if n != nil {
+			n.walk(fn)
+		}
+	}
+}
+
+func (b *branch) run(ctx context.Context, ex *execution) error {
+	n := b.els
+	if b.cond(ex.snapshot()) {
+		n = b.then
+	}
+	if n == nil {
+		return nil
+	}
+	return n.run(ctx, ex)
+}
+
+type Workflow struct {
+	name  string
+	root  Node
+	steps map[string]*step
+}
+
+// New 檢查 Step 名稱不重複
+func New(name string, root Node) (*Workflow, error) {
+	w := &Workflow{name: name, root: root, steps: make(map[string]*step)}
+	var err error
+	root.walk(func(s *step) {
+		if _, ok := w.steps[s.name]; ok && err == nil {
+			err = fmt.Errorf("%w: %q", ErrDuplicateStep, s.name)
+		}
+		w.steps[s.name] = s
+	})
+	if err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+func (w *Workflow) Name() string { return w.name }
+
+// StepError 表示某個 Step 重試用完仍然失敗
+type StepError struct {
+	Step     string
+	Attempts int
+	Err      error
+}
+
+func (e *StepError) Error() string {
+	return fmt.Sprintf("workflow: step %q failed after %d attempts: %v", e.Step, e.Attempts, e.Err)
+}
+
+func (e *StepError) Unwrap() error { return e.Err }
@@ -0,0 +1,282 @@
+package workflow
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"advanced/eventlog"
+	"advanced/timex"
+)
+
+// recorder 記錄 Step 與補償的呼叫順序
+type recorder struct {
+	mu    sync.Mutex
+	calls []string
+}
+
+func (r *recorder) add(s string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.calls = append(r.calls, s)
+}
+
+func (r *recorder) get() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]string(nil), r.calls...)
+}
+
+func (r *recorder) step(name string, out Vars) StepFunc {
+	return func(ctx context.Context, in Vars) (Vars, error) {
+		r.add(name)
+		return out, nil
+	}
+}
+
+func (r *recorder) undo(name string) CompensateFunc {
+	return func(ctx context.Context, in Vars) error {
+		r.add("undo " + name)
+		return nil
+	}
+}
+
+func wait(t *testing.T, e *Engine, run string) Status {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	st, err := e.Wait(ctx, run)
+	require.NoError(t, err)
+	return st
+}
+
+func TestHappyPath(t *testing.T) {
+	r := &recorder{}
+	w, err := New("order", Sequence(
+		Step("reserve", func(ctx context.Context, in Vars) (Vars, error) {
+			r.add("reserve")
+			return Vars{"reservation": "R-" + in["order"]}, nil
+		}),
+		Parallel(
+			Step("charge", r.step("charge", Vars{"payment": "P1"})),
+			Step("invoice", r.step("invoice", Vars{"invoice": "I1"})),
+		),
+		Branch("express?", func(v Vars) bool { return v["express"] == "yes" },
+			Step("ship-express", r.step("ship-express", nil)),
+			Step("ship", func(ctx context.Context, in Vars) (Vars, error) {
+				r.add("ship")
+				return Vars{"shipped": in["reservation"] + "/" + in["payment"]}, nil
+			}),
+		),
+	))
+	require.NoError(t, err)
+
+	e := NewEngine(eventlog.NewMemory())
+	e.Register(w)
+	require.NoError(t, e.Start(context.Background(), "order", "run-1", Vars{"order": "42"}))
+	st := wait(t, e, "run-1")
+
+	assert.Equal(t, Completed, st.State)
+	assert.Equal(t, Vars{"order": "42", "reservation": "R-42", "payment": "P1", "invoice": "I1", "shipped": "R-42/P1"}, st.Vars)
+	assert.Equal(t, StepStatus{State: StepCompleted, Attempts: 1}, st.Steps["ship"])
+	assert.NotContains(t, st.Steps, "ship-express")
+	calls := r.get()
+	assert.Equal(t, "reserve", calls[0])
+	assert.ElementsMatch(t, []string{"charge", "invoice"}, calls[1:3])
+	assert.Equal(t, "ship", calls[3])
+
+	assert.ErrorIs(t, e.Start(context.Background(), "order", "run-1", nil), ErrRunExists)
+	assert.ErrorIs(t, e.Start(context.Background(), "nope", "run-2", nil), ErrUnknownWorkflow)
+	_, err = e.Status("run-2")
+	assert.ErrorIs(t, err, ErrUnknownRun)
+	assert.Len(t, e.List(), 1)
+}
+
+func TestDuplicateStep(t *testing.T) {
+	noop := func(ctx context.Context, in Vars) (Vars, error) { return nil, nil }
+	_, err := New("dup", Sequence(Step("a", noop), Parallel(Step("b", noop), Step("a", noop))))
+	assert.ErrorIs(t, err, ErrDuplicateStep)
+}
+
+func TestRetry(t *testing.T) {
+	var calls atomic.Int32
+	w, _ := New("flaky", Step("call", func(ctx context.Context, in Vars) (Vars, error) {
+		if calls.Add(1) < 3 {
+			return nil, errors.New("503")
+		}
+		return Vars{"ok": "1"}, nil
+	}, Retry(timex.Backoff{Initial: time.Millisecond, MaxAttempts: 5})))
+
+	e := NewEngine(eventlog.NewMemory())
+	e.Register(w)
+	require.NoError(t, e.Start(context.Background(), "flaky", "r", nil))
+	st := wait(t, e, "r")
+	assert.Equal(t, Completed, st.State)
+	assert.Equal(t, StepStatus{State: StepCompleted, Attempts: 3}, st.Steps["call"])
+}
+
+func TestCompensation(t *testing.T) {
+	r := &recorder{}
+	var payAttempts atomic.Int32
+	w, _ := New("order", Sequence(
+		Step("reserve", r.step("reserve", nil), Compensate(r.undo("reserve"))),
+		Step("notify", r.step("notify", nil)), // 沒有補償動作
+		Parallel(
+			Step("invoice", r.step("invoice", nil), Compensate(r.undo("invoice"))),
+			Step("charge", func(ctx context.Context, in Vars) (Vars, error) {
+				payAttempts.Add(1)
+				return nil, errors.New("card declined")
+			}, Retry(timex.Backoff{Initial: time.Millisecond, MaxAttempts: 2}), Compensate(r.undo("charge"))),
+		),
+		Step("ship", r.step("ship", nil)),
+	))
+
+	e := NewEngine(eventlog.NewMemory())
+	e.Register(w)
+	require.NoError(t, e.Start(context.Background(), "order", "r", nil))
+	st := wait(t, e, "r")
+
+	assert.Equal(t, Failed, st.State)
+	assert.Contains(t, st.Error, "card declined")
+	assert.EqualValues(t, 3, payAttempts.Load(), "1 次 + 2 次重試")
+	assert.Equal(t, StepStatus{State: StepFailed, Attempts: 3, Error: "card declined"}, st.Steps["charge"])
+	assert.Equal(t, StepCompensated, st.Steps["reserve"].State)
+	assert.Equal(t, StepCompensated, st.Steps["invoice"].State)
+	assert.Equal(t, StepCompleted, st.Steps["notify"].State)
+	// 失敗的 Step 不補償，補償順序與完成順序相反
+	assert.Equal(t, []string{"reserve", "notify", "invoice", "undo invoice", "undo reserve"}, r.get())
+}
+
+// blockUntilCancel 模擬當機時正在執行的 Step
+func blockUntilCancel(started chan<- struct{}) StepFunc {
+	return func(ctx context.Context, in Vars) (Vars, error) {
+		close(started)
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}
+}
+
+func TestResumeAfterCrash(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "workflow.log")
+	r := &recorder{}
+	started := make(chan struct{})
+	var crashed atomic.Bool
+	crashed.Store(true)
+
+	build := func() *Workflow {
+		w, err := New("order", Sequence(
+			Step("reserve", r.step("reserve", Vars{"reservation": "R1"})),
+			Step("charge", func(ctx context.Context, in Vars) (Vars, error) {
+				if crashed.Load() {
+					return blockUntilCancel(started)(ctx, in)
+				}
+				r.add("charge " + in["reservation"])
+				return Vars{"payment": "P1"}, nil
+			}),
+			Step("ship", r.step("ship", nil)),
+		))
+		require.NoError(t, err)
+		return w
+	}
+
+	log, err := eventlog.Open(path)
+	require.NoError(t, err)
+	e := NewEngine(log)
+	e.Register(build())
+	ctx, cancel := context.WithCancel(context.Background())
+	require.NoError(t, e.Start(ctx, "order", "r", Vars{"order": "42"}))
+	<-started
+	cancel() // 程序結束：不寫 event、不補償
+	st := wait(t, e, "r")
+	assert.Equal(t, Running, st.State)
+	require.NoError(t, log.Close())
+
+	// 重啟
+	crashed.Store(false)
+	log, err = eventlog.Open(path)
+	require.NoError(t, err)
+	defer log.Close()
+	e = NewEngine(log)
+	e.Register(build())
+	resumed, err := e.Resume(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, []string{"r"}, resumed)
+
+	st = wait(t, e, "r")
+	assert.Equal(t, Completed, st.State)
+	assert.Equal(t, Vars{"order": "42", "reservation": "R1", "payment": "P1"}, st.Vars)
+	// reserve 只執行一次，charge 看得到重播回來的 output
+	assert.Equal(t, []string{"reserve", "charge R1", "ship"}, r.get())
+
+	// 已經結束的 run 再 Resume 不會重新執行
+	e = NewEngine(log)
+	e.Register(build())
+	resumed, err = e.Resume(context.Background())
+	require.NoError(t, err)
+	assert.Empty(t, resumed)
+	st, err = e.Status("r")
+	require.NoError(t, err)
+	assert.Equal(t, Completed, st.State)
+}
+
+func TestResumeDuringCompensation(t *testing.T) {
+	log := eventlog.NewMemory()
+	r := &recorder{}
+	started := make(chan struct{})
+	var crashed atomic.Bool
+	crashed.Store(true)
+
+	w, _ := New("order", Sequence(
+		Step("a", r.step("a", nil), Compensate(r.undo("a"))),
+		Step("b", r.step("b", nil), Compensate(func(ctx context.Context, in Vars) error {
+			if crashed.Load() {
+				_, err := blockUntilCancel(started)(ctx, in)
+				return err
+			}
+			r.add("undo b")
+			return nil
+		})),
+		Step("c", r.step("c", nil), Compensate(r.undo("c"))),
+		Step("fail", func(ctx context.Context, in Vars) (Vars, error) { return nil, errors.New("boom") }),
+	))
+
+	e := NewEngine(log)
+	e.Register(w)
+	ctx, cancel := context.WithCancel(context.Background())
+	require.NoError(t, e.Start(ctx, "order", "r", nil))
+	<-started
+	cancel()
+	st := wait(t, e, "r")
+	assert.Equal(t, Compensating, st.State)
+	assert.Equal(t, StepCompensated, st.Steps["c"].State)
+
+	crashed.Store(false)
+	e = NewEngine(log)
+	e.Register(w)
+	_, err := e.Resume(context.Background())
+	require.NoError(t, err)
+	st = wait(t, e, "r")
+	assert.Equal(t, Failed, st.State)
+	assert.Contains(t, st.Error, "boom")
+	// c 已經補償過，不會再補償一次
+	assert.Equal(t, []string{"a", "b", "c", "undo c", "undo b", "undo a"}, r.get())
+}
+
+func TestResumeUnknownWorkflow(t *testing.T) {
+	log := eventlog.NewMemory()
+	w, _ := New("w", Step("s", func(ctx context.Context, in Vars) (Vars, error) { return nil, nil }))
+	e := NewEngine(log)
+	e.Register(w)
+	require.NoError(t, e.Start(context.Background(), "w", "r", nil))
+	wait(t, e, "r")
+
+	_, err := NewEngine(log).Resume(context.Background())
+	assert.ErrorIs(t, err, ErrUnknownWorkflow)
+}
@@ -5,6 +5,8 @@ import (
 	"runtime"
 	"testing"
 	"time"
+
+	"basic/testutil/chant"
 )
 
 // 利用channel時要注意goroutine阻塞洩漏問題
@@ -26,11 +28,12 @@ func FirstResponseUnBufferCh() string {
 	return <-ch
 }
 func TestFirstResponseUnBufferCh(t *testing.T) {
-	t.Log("Before:", runtime.NumGoroutine()) //算goroutine數量
+	before := runtime.NumGoroutine() //算goroutine數量
+	t.Log("Before:", before)
 	t.Log(FirstResponseUnBufferCh())
-	time.Sleep(time.Second * 1)
+	// 發現goroutine阻塞與洩漏：其餘 9 個卡在 ch <- ret，永遠不會結束
+	chant.Eventually(t, func() bool { return runtime.NumGoroutine() >= before+9 }, time.Second)
 	t.Log("After:", runtime.NumGoroutine())
-	// 發現goroutine阻塞與洩漏
 
 }
 
@@ -47,9 +50,11 @@ func FirstResponseBufferCh() string {
 	return <-ch
 }
 func TestFirstResponseBufferCh(t *testing.T) {
-	t.Log("Before:", runtime.NumGoroutine())
+	before := runtime.NumGoroutine()
+	t.Log("Before:", before)
 	t.Log(FirstResponseBufferCh())
-	time.Sleep(time.Second * 1)
+	// buffer 夠大，所有 goroutine 都能送出後結束
+	chant.Eventually(t, func() bool { return runtime.NumGoroutine() <= before }, time.Second)
 	t.Log("After:", runtime.NumGoroutine())
 
 }
@@ -72,9 +77,10 @@ func AllResponseBufferCh() string {
 }
 
 func TestAllResponseBufferCh(t *testing.T) {
-	t.Log("Before:", runtime.NumGoroutine())
+	before := runtime.NumGoroutine()
+	t.Log("Before:", before)
 	t.Log(AllResponseBufferCh())
-	time.Sleep(time.Second * 1)
+	chant.Eventually(t, func() bool { return runtime.NumGoroutine() <= before }, time.Second)
 	t.Log("After:", runtime.NumGoroutine())
 
 }
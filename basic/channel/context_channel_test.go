@@ -4,8 +4,11 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"sync"
 	"testing"
 	"time"
+
+	"basic/testutil/capture"
 )
 
 /*
@@ -98,9 +101,13 @@ func isCancelled(ctx context.Context) bool {
 
 // 透過context來關閉channel
 func TestCancelByContext(t *testing.T) {
+	c := capture.Start(t)
+	var wg sync.WaitGroup
 	ctx, cancel := context.WithCancel(context.Background())
 	for i := 0; i < 5; i++ {
+		wg.Add(1)
 		go func(i int, ctx context.Context) {
+			defer wg.Done()
 			for {
 				if isCancelled(ctx) {
 					break
@@ -113,7 +120,9 @@ func TestCancelByContext(t *testing.T) {
 		}(i, ctx)
 	}
 	cancel()
-	time.Sleep(time.Second * 1)
+	wg.Wait()
+	// 一次 cancel，5 個 goroutine 都收到通知；結束的順序不固定
+	c.Stop().ElementsMatch(t, "0 Cancelled", "1 Cancelled", "2 Cancelled", "3 Cancelled", "4 Cancelled")
 }
 
 /*
@@ -4,6 +4,8 @@ import (
 	"fmt"
 	"testing"
 	"time"
+
+	"basic/testutil/chant"
 )
 
 func isCancelled(cancelChan chan struct{}) bool {
@@ -25,6 +27,7 @@ func cancel_2(cancelChan chan struct{}) {
 
 func TestCancel1(t *testing.T) {
 	cancelChan := make(chan struct{})
+	done := make(chan int, 5) // 每個被取消的協程送出自己的編號
 	for i := 0; i < 5; i++ {
 		go func(i int, cancelCh chan struct{}) {
 			for {
@@ -36,15 +39,19 @@ func TestCancel1(t *testing.T) {
 
 			}
 			fmt.Println(i, "Cancelled")
+			done <- i
 		}(i, cancelChan)
 	}
 	cancel_1(cancelChan)
 	// 只會取消一個協程，因為只有一個阻塞被取出
-	time.Sleep(time.Second * 1)
+	chant.RequireRecv(t, done, time.Second)
+	chant.RequireNoRecv(t, done, 50*time.Millisecond)
+	close(cancelChan) // 讓其他協程結束，避免洩漏到後面的測試
 }
 
 func TestCancel2(t *testing.T) {
 	cancelChan := make(chan struct{})
+	done := make(chan int, 5)
 	for i := 0; i < 5; i++ {
 		go func(i int, cancelCh chan struct{}) {
 			for {
@@ -56,9 +63,12 @@ func TestCancel2(t *testing.T) {
 
 			}
 			fmt.Println(i, "Cancelled")
+			done <- i
 		}(i, cancelChan)
 	}
 	cancel_2(cancelChan)
 	// 若是透過close() ，用到同一個channel的所有的協程都會被關閉
-	time.Sleep(time.Second * 1)
+	for i := 0; i < 5; i++ {
+		chant.RequireRecv(t, done, time.Second)
+	}
 }
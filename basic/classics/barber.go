@@ -0,0 +1,61 @@
+package classics
+
+import (
+	"context"
+	"time"
+)
+
+/*
+睡覺的理髮師：理髮店有一位理髮師與 N 張等待椅。
+  - 沒有客人時理髮師睡覺；客人來了叫醒他
+  - 客人進門時椅子坐滿就直接離開
+難點在於「檢查有沒有客人」和「去睡覺」之間不能有空隙，否則客人叫不醒理髮師，兩邊互等。
+在 Go 裡等待椅就是 buffered channel：送不進去（select default）代表椅子滿了，
+理髮師從 channel 收資料時阻塞就是睡覺，檢查與睡覺是同一個原子動作。
+*/
+
+type Barbershop struct {
+	chairs  chan customer
+	cutTime time.Duration
+}
+
+type customer struct {
+	id   int
+	done chan struct{}
+}
+
+func NewBarbershop(chairs int, cutTime time.Duration) *Barbershop {
+	return &Barbershop{chairs: make(chan customer, chairs), cutTime: cutTime}
+}
+
+// Run 是理髮師的工作迴圈，onCut 在每次剪完頭髮後被呼叫
+func (b *Barbershop) Run(ctx context.Context, onCut func(id int)) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case c := <-b.chairs: // 沒有客人就在這裡睡覺
+			time.Sleep(b.cutTime)
+			if onCut != nil {
+				onCut(c.id)
+			}
+			close(c.done)
+		}
+	}
+}
+
+// Visit 回傳 false 表示椅子坐滿、客人直接離開；回傳 true 時頭髮已經剪好
+func (b *Barbershop) Visit(ctx context.Context, id int) bool {
+	c := customer{id: id, done: make(chan struct{})}
+	select {
+	case b.chairs <- c:
+	default:
+		return false
+	}
+	select {
+	case <-c.done:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
@@ -0,0 +1,298 @@
+package classics
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/goleak"
+)
+
+func philosophers(n int) []string {
+	names := make([]string, n)
+	for i := range names {
+		names[i] = PhilosopherName(i)
+	}
+	return names
+}
+
+func TestDiningPhilosophers(t *testing.T) {
+	for _, s := range []Strategy{OrderedForks, Arbitrator} {
+		t.Run(s.String(), func(t *testing.T) {
+			defer goleak.VerifyNone(t, goleak.IgnoreCurrent())
+			cfg := DineConfig{Philosophers: 5, Meals: 20, Strategy: s, Hold: 100 * time.Microsecond, Eat: 100 * time.Microsecond}
+			tally := NewTally()
+			err := Within(5*time.Second, func(ctx context.Context) error { return Dine(ctx, cfg, tally) })
+			require.NoError(t, err)
+			assert.NoError(t, tally.CheckStarvation(philosophers(5), cfg.Meals))
+		})
+	}
+}
+
+// 每個人拿起左叉後停一下，保證大家都拿到左叉，naive 版本必定 deadlock
+func TestDiningPhilosophersNaiveDeadlocks(t *testing.T) {
+	defer goleak.VerifyNone(t, goleak.IgnoreCurrent())
+	cfg := DineConfig{Philosophers: 5, Meals: 20, Strategy: Naive, Hold: 20 * time.Millisecond}
+	err := Within(300*time.Millisecond, func(ctx context.Context) error { return Dine(ctx, cfg, NewTally()) })
+	assert.ErrorIs(t, err, ErrDeadlock)
+}
+
+func TestProducerConsumer(t *testing.T) {
+	defer goleak.VerifyNone(t, goleak.IgnoreCurrent())
+	const producers, consumers, perProducer = 4, 3, 500
+	buf := NewBoundedBuffer[int](8)
+	tally := NewTally()
+
+	var mu sync.Mutex
+	var got []int
+	err := Within(5*time.Second, func(ctx context.Context) error {
+		var pwg, cwg sync.WaitGroup
+		for p := 0; p < producers; p++ {
+			pwg.Add(1)
+			go func(p int) {
+				defer pwg.Done()
+				for i := 0; i < perProducer; i++ {
+					if buf.Put(p*perProducer+i) != nil {
+						return
+					}
+				}
+			}(p)
+		}
+		for c := 0; c < consumers; c++ {
+			cwg.Add(1)
+			go func(c int) {
+				defer cwg.Done()
+				for {
+					v, ok := buf.Get()
+					if !ok {
+						return
+					}
+					assert.LessOrEqual(t, buf.Len(), 8)
+					tally.Inc(string(rune('a' + c)))
+					mu.Lock()
+					got = append(got, v)
+					mu.Unlock()
+				}
+			}(c)
+		}
+		pwg.Wait()
+		buf.Close()
+		cwg.Wait()
+		return nil
+	})
+	require.NoError(t, err)
+
+	// 每個項目剛好被消費一次
+	sort.Ints(got)
+	require.Len(t, got, producers*perProducer)
+	for i, v := range got {
+		require.Equal(t, i, v)
+	}
+	assert.ErrorIs(t, buf.Put(1), ErrBufferClosed)
+}
+
+// hammer 用 readers 個 goroutine 不斷交替持有讀鎖，保證任何時刻都至少有一個讀者在讀
+func hammer(l RWLock, readers int, stop <-chan struct{}) *sync.WaitGroup {
+	var wg sync.WaitGroup
+	for i := 0; i < readers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-stop:
+					return
+				default:
+				}
+				l.RLock()
+				time.Sleep(time.Millisecond)
+				l.RUnlock()
+			}
+		}()
+	}
+	return &wg
+}
+
+func TestReadersWritersStarvation(t *testing.T) {
+	tests := []struct {
+		name          string
+		lock          RWLock
+		writerStarves bool
+	}{
+		{"reader-preferring", NewReaderPreferring(), true},
+		{"writer-preferring", NewWriterPreferring(), false},
+		{"sync.RWMutex", &sync.RWMutex{}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := tt.lock
+			stop := make(chan struct{})
+			wg := hammer(l, 4, stop)
+			time.Sleep(5 * time.Millisecond)
+
+			acquired := make(chan struct{})
+			go func() {
+				l.Lock()
+				close(acquired)
+				l.Unlock()
+			}()
+			select {
+			case <-acquired:
+				assert.False(t, tt.writerStarves, "writer should have starved")
+			case <-time.After(200 * time.Millisecond):
+				assert.True(t, tt.writerStarves, "writer starved")
+			}
+			close(stop)
+			wg.Wait()
+			<-acquired // 讀者停下來之後寫者一定拿得到
+		})
+	}
+}
+
+func TestWriterPreferringStarvesReaders(t *testing.T) {
+	l := NewWriterPreferring()
+	stop := make(chan struct{})
+	var wg sync.WaitGroup
+	for i := 0; i < 2; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-stop:
+					return
+				default:
+				}
+				l.Lock()
+				time.Sleep(time.Millisecond)
+				l.Unlock()
+			}
+		}()
+	}
+	time.Sleep(5 * time.Millisecond)
+
+	read := make(chan struct{})
+	go func() {
+		l.RLock()
+		close(read)
+		l.RUnlock()
+	}()
+	select {
+	case <-read:
+		t.Fatal("reader should starve while writers keep queueing")
+	case <-time.After(100 * time.Millisecond):
+	}
+	close(stop)
+	wg.Wait()
+	<-read
+}
+
+func TestRWLockExclusion(t *testing.T) {
+	for name, l := range map[string]RWLock{"reader": NewReaderPreferring(), "writer": NewWriterPreferring()} {
+		t.Run(name, func(t *testing.T) {
+			var mu sync.Mutex
+			readers, writers := 0, 0
+			check := func() {
+				mu.Lock()
+				defer mu.Unlock()
+				assert.True(t, writers == 0 || (writers == 1 && readers == 0), "readers=%d writers=%d", readers, writers)
+			}
+			var wg sync.WaitGroup
+			for i := 0; i < 8; i++ {
+				wg.Add(1)
+				go func(i int) {
+					defer wg.Done()
+					for j := 0; j < 50; j++ {
+						if (i+j)%4 == 0 {
+							l.Lock()
+							mu.Lock()
+							writers++
+							mu.Unlock()
+							check()
+							mu.Lock()
+							writers--
+							mu.Unlock()
+							l.Unlock()
+						} else {
+							l.RLock()
+							mu.Lock()
+							readers++
+							mu.Unlock()
+							check()
+							mu.Lock()
+							readers--
+							mu.Unlock()
+							l.RUnlock()
+						}
+					}
+				}(i)
+			}
+			wg.Wait()
+		})
+	}
+}
+
+func TestSleepingBarber(t *testing.T) {
+	defer goleak.VerifyNone(t, goleak.IgnoreCurrent())
+	ctx, cancel := context.WithCancel(context.Background())
+	shop := NewBarbershop(3, 5*time.Millisecond)
+	tally := NewTally()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		shop.Run(ctx, func(int) { tally.Inc("cut") })
+	}()
+
+	// 一次湧入 10 位客人：理髮師 + 3 張椅子，必定有人被拒
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	served, turnedAway := 0, 0
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			ok := shop.Visit(ctx, i)
+			mu.Lock()
+			defer mu.Unlock()
+			if ok {
+				served++
+			} else {
+				turnedAway++
+			}
+		}(i)
+	}
+	wg.Wait()
+	assert.Equal(t, 10, served+turnedAway)
+	assert.Positive(t, turnedAway)
+	assert.Equal(t, served, tally.Get("cut"))
+
+	// 客人一個一個來，理髮師在中間睡覺，每個人都能被服務
+	for i := 0; i < 5; i++ {
+		assert.True(t, shop.Visit(ctx, 100+i))
+	}
+	assert.Equal(t, served+5, tally.Get("cut"))
+
+	cancel()
+	<-done
+}
+
+func TestWithinAndTally(t *testing.T) {
+	assert.ErrorIs(t, Within(10*time.Millisecond, func(ctx context.Context) error {
+		<-ctx.Done()
+		return nil
+	}), ErrDeadlock)
+
+	tally := NewTally()
+	tally.Inc("a")
+	tally.Inc("a")
+	tally.Inc("b")
+	assert.NoError(t, tally.CheckStarvation([]string{"a", "b"}, 1))
+	err := tally.CheckStarvation([]string{"a", "b", "c"}, 2)
+	assert.ErrorIs(t, err, ErrStarvation)
+	assert.Contains(t, err.Error(), "b=1")
+	assert.Contains(t, err.Error(), "c=0")
+}
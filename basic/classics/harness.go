@@ -0,0 +1,86 @@
+package classics
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+/*
+並行程式的兩種典型錯誤：
+  - deadlock：所有參與者互相等待，誰都無法前進 —— 用「限時內必須完成」來檢查
+  - starvation：系統整體有進展，但某些參與者一直輪不到 —— 統計每個參與者的進度，檢查最少的那個
+*/
+
+var (
+	ErrDeadlock   = errors.New("classics: no completion before deadline (deadlock?)")
+	ErrStarvation = errors.New("classics: participant starved")
+)
+
+// Within 執行 f 並等待它在 d 內結束；f 收到的 ctx 會在逾時後取消，讓卡住的 goroutine 有機會退出
+func Within(d time.Duration, f func(ctx context.Context) error) error {
+	ctx, cancel := context.WithTimeout(context.Background(), d)
+	defer cancel()
+	done := make(chan error, 1)
+	go func() { done <- f(ctx) }()
+	select {
+	case err := <-done:
+		if errors.Is(err, context.DeadlineExceeded) {
+			return ErrDeadlock
+		}
+		return err
+	case <-ctx.Done():
+		<-done
+		return ErrDeadlock
+	}
+}
+
+// Tally 記錄每個參與者完成的次數
+type Tally struct {
+	mu     sync.Mutex
+	counts map[string]int
+}
+
+func NewTally() *Tally {
+	return &Tally{counts: make(map[string]int)}
+}
+
+func (t *Tally) Inc(name string) {
+	t.mu.Lock()
+	t.counts[name]++
+	t.mu.Unlock()
+}
+
+func (t *Tally) Get(name string) int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.counts[name]
+}
+
+func (t *Tally) Counts() map[string]int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	out := make(map[string]int, len(t.counts))
+	for k, v := range t.counts {
+		out[k] = v
+	}
+	return out
+}
+
+// CheckStarvation 檢查 names 中每個參與者至少完成 min 次
+func (t *Tally) CheckStarvation(names []string, min int) error {
+	var starved []string
+	for _, n := range names {
+		if t.Get(n) < min {
+			starved = append(starved, fmt.Sprintf("%s=%d", n, t.Get(n)))
+		}
+	}
+	if len(starved) > 0 {
+		sort.Strings(starved)
+		return fmt.Errorf("%w: %v (want >= %d)", ErrStarvation, starved, min)
+	}
+	return nil
+}
@@ -0,0 +1,111 @@
+package classics
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+/*
+哲學家用餐問題：N 位哲學家圍著圓桌，兩兩之間放一支叉子，吃飯需要同時拿到左右兩支。
+
+Naive：每個人都先拿左邊再拿右邊。如果所有人同時拿起左邊的叉子，
+就形成 circular wait，所有人都在等右邊的叉子 —— deadlock。
+
+破解 deadlock 的四個必要條件之一即可：
+  - OrderedForks（resource ordering）：叉子編號，一律先拿編號小的。
+    最後一位哲學家的左右順序因此反過來，環狀等待不會成立
+  - Arbitrator：拿叉子前先取得服務生（一把 mutex）的許可，兩支都拿到才放開服務生，
+    等於「同時拿起兩支」，不會只拿一支卡在中間
+*/
+
+type Strategy int
+
+const (
+	Naive Strategy = iota
+	OrderedForks
+	Arbitrator
+)
+
+func (s Strategy) String() string {
+	return [...]string{"naive", "ordered-forks", "arbitrator"}[s]
+}
+
+type DineConfig struct {
+	Philosophers int
+	Meals        int // 每人要吃幾餐
+	Strategy     Strategy
+	Hold         time.Duration // 拿起第一支叉子後到拿第二支之間的停頓，放大 race window
+	Eat          time.Duration
+}
+
+// fork 用容量為 1 的 channel 表示，拿取可以被 ctx 中斷，deadlock 時測試才能收尾
+type fork chan struct{}
+
+func (f fork) take(ctx context.Context) error {
+	select {
+	case f <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (f fork) put() { <-f }
+
+// PhilosopherName 是 Tally 中第 i 位哲學家的名稱
+func PhilosopherName(i int) string { return fmt.Sprintf("philosopher-%d", i) }
+
+// Dine 讓每位哲學家吃完 Meals 餐；ctx 結束時回傳 ctx.Err()
+func Dine(ctx context.Context, cfg DineConfig, tally *Tally) error {
+	n := cfg.Philosophers
+	forks := make([]fork, n)
+	for i := range forks {
+		forks[i] = make(fork, 1)
+	}
+	var waiter sync.Mutex
+
+	errs := make(chan error, n)
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			left, right := i, (i+1)%n
+			first, second := left, right
+			if cfg.Strategy == OrderedForks && first > second {
+				first, second = second, first
+			}
+			takeBoth := func() error {
+				if cfg.Strategy == Arbitrator {
+					waiter.Lock()
+					defer waiter.Unlock()
+				}
+				if err := forks[first].take(ctx); err != nil {
+					return err
+				}
+				time.Sleep(cfg.Hold)
+				if err := forks[second].take(ctx); err != nil {
+					forks[first].put()
+					return err
+				}
+				return nil
+			}
+			for meal := 0; meal < cfg.Meals; meal++ {
+				if err := takeBoth(); err != nil {
+					errs <- err
+					return
+				}
+
+				tally.Inc(PhilosopherName(i))
+				time.Sleep(cfg.Eat)
+				forks[second].put()
+				forks[first].put()
+			}
+		}(i)
+	}
+	wg.Wait()
+	close(errs)
+	return <-errs
+}
@@ -0,0 +1,82 @@
+package classics
+
+import (
+	"errors"
+	"sync"
+)
+
+/*
+生產者-消費者（bounded buffer）：緩衝區滿了生產者要等、空了消費者要等。
+Go 裡直接用 buffered channel 就是答案；這裡用 Mutex + sync.Cond 實作，
+看清楚 channel 在底層幫我們做了什麼：
+  - 條件要用 for 迴圈檢查（被喚醒時條件不一定成立，可能被別人搶先）
+  - notFull / notEmpty 分成兩個 Cond，只叫醒真正需要的一方
+*/
+
+var ErrBufferClosed = errors.New("classics: buffer closed")
+
+type BoundedBuffer[T any] struct {
+	mu       sync.Mutex
+	notFull  *sync.Cond
+	notEmpty *sync.Cond
+	items    []T
+	head     int
+	size     int
+	closed   bool
+}
+
+func NewBoundedBuffer[T any](capacity int) *BoundedBuffer[T] {
+	b := &BoundedBuffer[T]{items: make([]T, capacity)}
+	b.notFull = sync.NewCond(&b.mu)
+	b.notEmpty = sync.NewCond(&b.mu)
+	return b
+}
+
+// Put 在緩衝區滿時阻塞；Close 之後回傳 ErrBufferClosed
+func (b *BoundedBuffer[T]) Put(v T) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for b.size == len(b.items) && !b.closed {
+		b.notFull.Wait()
+	}
+	if b.closed {
+		return ErrBufferClosed
+	}
+	b.items[(b.head+b.size)%len(b.items)] = v
+	b.size++
+	b.notEmpty.Signal()
+	return nil
+}
+
+// Get 在緩衝區空時阻塞；Close 之後仍會先取完剩下的資料，取完才回傳 ok=false
+func (b *BoundedBuffer[T]) Get() (v T, ok bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for b.size == 0 && !b.closed {
+		b.notEmpty.Wait()
+	}
+	if b.size == 0 {
+		return v, false
+	}
+	v = b.items[b.head]
+	var zero T
+	b.items[b.head] = zero
+	b.head = (b.head + 1) % len(b.items)
+	b.size--
+	b.notFull.Signal()
+	return v, true
+}
+
+func (b *BoundedBuffer[T]) Close() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.closed = true
+	b.notFull.Broadcast()
+	b.notEmpty.Broadcast()
+}
+
+func (b *BoundedBuffer[T]) Len() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.size
+}
@@ -0,0 +1,125 @@
+package classics
+
+import "sync"
+
+/*
+讀者-寫者問題：多個讀者可以同時讀，寫者必須獨佔。差別在於「讀寫同時在排隊時誰優先」：
+
+  - ReaderPreferring：只要還有讀者在讀，新來的讀者直接進場。
+    讀者源源不絕時寫者永遠等不到 —— writer starvation
+  - WriterPreferring：有寫者在排隊時新讀者必須等。
+    寫者源源不絕時換成讀者餓死 —— reader starvation
+
+標準庫的 sync.RWMutex 屬於後者（Lock 等待中會擋住新的 RLock），
+但它保證等待中的 Lock 前面的讀者結束後就輪到寫者，所以不會讓讀者無限期餓死。
+*/
+
+type RWLock interface {
+	RLock()
+	RUnlock()
+	Lock()
+	Unlock()
+}
+
+type ReaderPreferring struct {
+	mu      sync.Mutex
+	cond    *sync.Cond
+	readers int
+	writing bool
+}
+
+func NewReaderPreferring() *ReaderPreferring {
+	l := &ReaderPreferring{}
+	l.cond = sync.NewCond(&l.mu)
+	return l
+}
+
+func (l *ReaderPreferring) RLock() {
+	l.mu.Lock()
+	for l.writing {
+		l.cond.Wait()
+	}
+	l.readers++
+	l.mu.Unlock()
+}
+
+func (l *ReaderPreferring) RUnlock() {
+	l.mu.Lock()
+	l.readers--
+	if l.readers == 0 {
+		l.cond.Broadcast()
+	}
+	l.mu.Unlock()
+}
+
+func (l *ReaderPreferring) Lock() {
+	l.mu.Lock()
+	for l.writing || l.readers > 0 {
+		l.cond.Wait()
+	}
+	l.writing = true
+	l.mu.Unlock()
+}
+
+func (l *ReaderPreferring) Unlock() {
+	l.mu.Lock()
+	l.writing = false
+	l.cond.Broadcast()
+	l.mu.Unlock()
+}
+
+type WriterPreferring struct {
+	mu             sync.Mutex
+	cond           *sync.Cond
+	readers        int
+	writing        bool
+	waitingWriters int
+}
+
+func NewWriterPreferring() *WriterPreferring {
+	l := &WriterPreferring{}
+	l.cond = sync.NewCond(&l.mu)
+	return l
+}
+
+func (l *WriterPreferring) RLock() {
+	l.mu.Lock()
+	for l.writing || l.waitingWriters > 0 {
+		l.cond.Wait()
+	}
+	l.readers++
+	l.mu.Unlock()
+}
+
+func (l *WriterPreferring) RUnlock() {
+	l.mu.Lock()
+	l.readers--
+	if l.readers == 0 {
+		l.cond.Broadcast()
+	}
+	l.mu.Unlock()
+}
+
+func (l *WriterPreferring) Lock() {
+	l.mu.Lock()
+	l.waitingWriters++
+	for l.writing || l.readers > 0 {
+		l.cond.Wait()
+	}
+	l.waitingWriters--
+	l.writing = true
+	l.mu.Unlock()
+}
+
+func (l *WriterPreferring) Unlock() {
+	l.mu.Lock()
+	l.writing = false
+	l.cond.Broadcast()
+	l.mu.Unlock()
+}
+
+var (
+	_ RWLock = (*ReaderPreferring)(nil)
+	_ RWLock = (*WriterPreferring)(nil)
+	_ RWLock = (*sync.RWMutex)(nil)
+)
@@ -5,6 +5,8 @@ import (
 	"sync"
 	"testing"
 	"time"
+
+	"basic/testutil/capture"
 )
 
 func service() string {
@@ -20,8 +22,11 @@ func otherTask() {
 
 // 正常情況下，順序執行，耗时将是service()和otherTask()之和.
 func TestService(t *testing.T) {
-	fmt.Println(service())
-	otherTask()
+	out := capture.Run(t, func() {
+		fmt.Println(service())
+		otherTask()
+	})
+	out.Equal(t, "Done service", "working on something else", "Task is done")
 }
 
 // 為提高效能使用channel，來建構異步服務
@@ -9,6 +9,8 @@ import (
 
 	"github.com/stretchr/testify/assert"
 	"go.uber.org/goleak"
+
+	"basic/testutil/chant"
 )
 
 /*
@@ -175,19 +177,28 @@ func TestPubSub(t *testing.T) {
 	_ = h.publish(ctx, &message{data: []byte("test01")})
 	_ = h.publish(ctx, &message{data: []byte("test02")})
 	_ = h.publish(ctx, &message{data: []byte("test03")})
-	time.Sleep(1 * time.Second)
+	drained(t, sub01, sub02, sub03)
 
 	h.unsubscribe(ctx, sub03)
 	_ = h.publish(ctx, &message{data: []byte("test04")})
 	_ = h.publish(ctx, &message{data: []byte("test05")})
-
-	time.Sleep(1 * time.Second)
+	drained(t, sub01, sub02)
+	assert.Empty(t, sub03.handler, "取消訂閱後不會再收到訊息")
 
 	//關閉剩下的goruntine避免goleak檢測不通過
 	h.unsubscribe(ctx, sub01)
 	h.unsubscribe(ctx, sub02)
 }
 
+// drained 等待 subscriber 把收到的訊息都處理完，取代 sleep
+func drained(t *testing.T, subs ...*subscriber) {
+	t.Helper()
+	for _, s := range subs {
+		s := s
+		chant.Eventually(t, func() bool { return len(s.handler) == 0 }, time.Second, "%s handler not drained", s.name)
+	}
+}
+
 /*
 驗證看看出來的訊息是不是有按照我們的模式跑出結果
 另外為了驗證全部的 goroutine 都可以正常關閉，用 go.uber.org/goleak 來撰寫測試驗證。
@@ -233,8 +244,7 @@ func TestCancelSubscriber(t *testing.T) {
 
 	// cancel subscriber 03
 	cancel()
-	time.Sleep(100 * time.Millisecond)
-	assert.Equal(t, 2, h.subscribers())
+	chant.Eventually(t, func() bool { return h.subscribers() == 2 }, time.Second)
 
 	h.unsubscribe(ctx, sub01)
 	h.unsubscribe(ctx, sub02)
@@ -5,6 +5,8 @@ import (
 	"fmt"
 	"os"
 	"testing"
+
+	"basic/testutil/capture"
 )
 
 // os.Exit退出時不會調用defer指定的函數，也不會輸出調用訊息
@@ -37,14 +39,17 @@ func TestPanic(t *testing.T) {
 // }
 // Go 可以在panic之前調用defer來實現recover
 func TestRecover(t *testing.T) {
-	defer func() {
-		if err := recover(); err != nil {
-			fmt.Println("recovered from", err)
-		}
-	}()
-	fmt.Println("Start panic")
-	panic(errors.New("Something wrong!"))
-
+	out := capture.Run(t, func() {
+		defer func() {
+			if err := recover(); err != nil {
+				fmt.Println("recovered from", err)
+			}
+		}()
+		fmt.Println("Start panic")
+		panic(errors.New("Something wrong!"))
+	})
+	// recover 之後 defer 照常執行，panic 不會讓測試失敗
+	out.Equal(t, "Start panic", "recovered from Something wrong!")
 }
 
 // 常見的"錯誤"使用panic
@@ -6,18 +6,30 @@ import (
 	"log"
 	"math/rand"
 	"runtime"
+	"strings"
 	"sync"
 	"testing"
 	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"basic/testutil/capture"
 )
 
 func TestGoroutine(t *testing.T) {
-	for i := 0; i < 10; i++ {
-		go func(i int) {
-			fmt.Println(i)
-		}(i) //值傳遞會複製一份 丟進協程
-	}
-	time.Sleep(time.Millisecond * 50)
+	out := capture.Run(t, func() {
+		var wg sync.WaitGroup
+		for i := 0; i < 10; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				fmt.Println(i)
+			}(i) //值傳遞會複製一份 丟進協程
+		}
+		wg.Wait()
+	})
+	// 執行順序不固定，但 0~9 每個數字剛好印一次
+	out.ElementsMatch(t, "0", "1", "2", "3", "4", "5", "6", "7", "8", "9")
 }
 
 // 錯誤的寫法
@@ -37,10 +49,10 @@ func memConsumed() uint64 {
 	return memStat.Sys
 }
 
-//在Go語言中，對於多執行緒是相當友善好用的，相對其他語言所需要的資源與行數都少很多。
-//以Java 8為例，執行一個Thread 預設需要分配1MB 記憶體，而Golang只需要幾kB 。
-//goroutine 所佔用的記憶體，均在stack中進行管理
-//goroutine 所佔用的棧空間大小，由 runtime 按需進行分配
+// 在Go語言中，對於多執行緒是相當友善好用的，相對其他語言所需要的資源與行數都少很多。
+// 以Java 8為例，執行一個Thread 預設需要分配1MB 記憶體，而Golang只需要幾kB 。
+// goroutine 所佔用的記憶體，均在stack中進行管理
+// goroutine 所佔用的棧空間大小，由 runtime 按需進行分配
 func TestGetGoroutineMemConsume(t *testing.T) {
 	var c chan int
 	var wg sync.WaitGroup
@@ -67,6 +79,7 @@ func TestGoroutinePROCS(t *testing.T) {
 	//對於邏輯處理器的個數，不是越多越好，要根據電腦的實際物理核數，如果不是多核的，設置再多的邏輯處理器個數也沒用，
 	//如果需要設置的話，一般我們採用如下代碼設置。
 	// runtime.GOMAXPROCS(runtime.NumCPU())
+	c := capture.Start(t)
 	var wg sync.WaitGroup
 	wg.Add(2)
 	go func() {
@@ -84,6 +97,10 @@ func TestGoroutinePROCS(t *testing.T) {
 		}
 	}()
 	wg.Wait()
+	out := c.Stop()
+	// A、B 之間交錯的順序不固定，各自的順序則是固定的
+	out.Filter("A:").Equal(t, "A: 1", "A: 2", "A: 3", "A: 4")
+	out.Filter("B:").Equal(t, "B: 1", "B: 2", "B: 3", "B: 4")
 }
 
 // 展示主執行緒執行結束後，會將子執行緒release
@@ -160,9 +177,13 @@ func TestGoroutineByValue(t *testing.T) {
 // Java可以聯想到Join的概念，而在Golang中要做到等待的這件事情有兩個方法，一個是sync.WaitGroup、另一個是channel。
 // 首先Sync.WaitGroup 像是一個計數器，啟動一條Goroutine 計數器 +1; 反之結束一條 -1。若計數器為複數代表Error。
 
-//範例: 等待一執行緒結束後再接續工作(使用WaitGroup)
+// 範例: 等待一執行緒結束後再接續工作(使用WaitGroup)
 func TestGoroutineWaitGroup(t *testing.T) {
+	c := capture.Start(t)
 	var wg sync.WaitGroup
+	// 計數器+1 一定要在 go 之前：放在 go 之後的話，Wait 可能先執行、看到計數器為 0 就直接返回
+	// 需要回傳錯誤、處理 panic 或 ctx 的版本見 advanced/concurrency/waitgroup
+	wg.Add(1)
 	// 執行執行緒
 	go func() {
 		defer wg.Done() //defer表示最後執行，因此該行為最後執行wg.Done()將計數器-1
@@ -170,10 +191,11 @@ func TestGoroutineWaitGroup(t *testing.T) {
 		log.Println("start a go routine")
 		time.Sleep(time.Second) //休息一秒鐘
 	}()
-	wg.Add(1)                         //計數器+1
 	time.Sleep(time.Millisecond * 30) //休息30 ms
 	log.Println("wait a goroutine")
 	wg.Wait() //等待計數器歸0
+	// goroutine 睡一秒，主執行緒只睡 30 ms，所以 "wait a goroutine" 一定在 "goroutine drop out" 之前
+	c.Stop().Equal(t, "start a go routine", "wait a goroutine", "goroutine drop out")
 }
 
 // Channel 的作法是利用等待提取、等待可注入會lock住的特性，達到Sync.WaitGroup 的功能。
@@ -198,11 +220,13 @@ func TestGoroutineByChannel2(t *testing.T) {
 
 // example 5: 多執行緒共用同一個變數
 
-//範例: 多個執行序讀寫同一個變數
+// 範例: 多個執行序讀寫同一個變數
 func TestGoroutineUseLock(t *testing.T) {
+	c := capture.Start(t)
 	var lock sync.Mutex   // 宣告Lock 用以資源佔有與解鎖
 	var wg sync.WaitGroup // 宣告WaitGroup 用以等待執行序
 	val := 0
+	wg.Add(2) //記數器+2，要在 go 之前
 	// 執行 執行緒: 將變數val+1
 	go func() {
 		defer wg.Done() //wg 計數器-1
@@ -227,8 +251,16 @@ func TestGoroutineUseLock(t *testing.T) {
 			time.Sleep(1000)
 		}
 	}()
-	wg.Add(2) //記數器+2
 	wg.Wait() //等待計數器歸零
+
+	// 印出在鎖內，所以不論哪個 goroutine 印的，val 都依序是 1~20
+	out := c.Stop()
+	assert.Len(t, out.Lines, 20)
+	for i, line := range out.Lines {
+		assert.True(t, strings.HasSuffix(line, fmt.Sprintf("val = %d", i+1)), line)
+	}
+	assert.Len(t, out.Filter("First").Lines, 10)
+	assert.Len(t, out.Filter("Sec").Lines, 10)
 }
 
 // sync.Mutex: 宣告資源鎖
@@ -242,7 +274,7 @@ func TestGoroutineUseLock(t *testing.T) {
 
 // example 6: 不同執行緒產出影響後續邏輯
 
-//範例:不同執行緒產出影響後續邏輯，使用多路復用。
+// 範例:不同執行緒產出影響後續邏輯，使用多路復用。
 func TestGoroutineUseSelect(t *testing.T) {
 	firstRoutine := make(chan string) //宣告給第1個執行序的channel
 	secRoutine := make(chan string)   //宣告給第2個執行序的channel
@@ -270,6 +302,7 @@ func TestGoroutineUseSelect(t *testing.T) {
 
 // 上面程式碼的例子，當其中一條Goroutine先結束時，主程式就會自動結束。
 // 而Select的用法就是去聽哪一個channel已經先被注入資料，而做相對應的動作，若同時則是隨機採用對應的方案。
+// 這種「開 goroutine、結果放進 channel、再等」的寫法包成可重用的 Future[T]（Get / Then / All / Any）見 advanced/concurrency/future
 
 // 5. 兄弟執行緒間不求同生只求同死
 // 在Goroutine主要的基本用法與應用，在上述都可以做到。
@@ -311,6 +344,37 @@ func TestGoroutineUseContext(t *testing.T) {
 
 // Tips: context.Background(): 取得Context的實體
 // context.WithDeadline(Context實體, 時間): 使用WithDeadline並設定好時間 Cancel 則是在程式結束前需要被使用，否則會有memory leak的錯誤訊息
+// 上面只示範時間到一起結束；「其中一個失敗，其他兄弟跟著取消」要用 WithCancel，
+// 完整的實作（errgroup 的 Go / Wait / SetLimit）見 advanced/concurrency/group
+
+// 延伸 WithDeadline：deadline 只會往前縮，不會往後延。子 context 設得比 parent 晚時仍以 parent 為準，
+// 設得比較早就用自己的，而且子 context 到期不會影響 parent
+func Example_deadlineSelection() {
+	base := time.Now()
+	parent, cancel := context.WithDeadline(context.Background(), base.Add(time.Second))
+	defer cancel()
+
+	later, cancelLater := context.WithDeadline(parent, base.Add(time.Hour))
+	defer cancelLater()
+	d, _ := later.Deadline()
+	fmt.Println("later child keeps the parent's deadline:", d.Equal(base.Add(time.Second)))
+
+	sooner, cancelSooner := context.WithDeadline(parent, base.Add(10*time.Millisecond))
+	defer cancelSooner()
+	d, _ = sooner.Deadline()
+	fmt.Println("sooner child uses its own:", d.Equal(base.Add(10*time.Millisecond)))
+
+	<-sooner.Done()
+	fmt.Println(sooner.Err(), parent.Err())
+	// Output:
+	// later child keeps the parent's deadline: true
+	// sooner child uses its own: true
+	// context deadline exceeded <nil>
+}
+
+// 兩個沒有父子關係的 context（例如 request 與 server shutdown）要合併成「任一個結束就結束、deadline 取較早的」，
+// 或是背景工作要留著 value 但不跟著 request 被取消，標準庫沒有現成的寫法，
+// 見 advanced/ctxutil 的 MergeCancel 與 Detach（ExampleMergeCancel、ExampleDetach）
 
 // 總結
 // 在Golang多執行緒的世界中，最常用的就是共用變數、channel、 Select、sync.WaitGroup、sync.Lock等方式，比較進階的用法是Context。
@@ -0,0 +1,90 @@
+//go:build !racefix
+
+package races
+
+import (
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// Fixed 回報目前編譯的是修正版本（-tags racefix）
+const Fixed = false
+
+// CountWords 以 workers 個 goroutine 統計字數
+// race：所有 goroutine 沒有同步地寫同一個 map
+func CountWords(words []string, workers int) map[string]int {
+	counts := make(map[string]int)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for i := w; i < len(words); i += workers {
+				counts[strings.ToLower(words[i])]++
+			}
+		}(w)
+	}
+	wg.Wait()
+	return counts
+}
+
+// Collect 以 n 個 goroutine 各產生一個值並收集起來，順序不保證
+// race：append 會讀寫 slice header（len、底層陣列），多個 goroutine 同時 append 會互相覆蓋
+func Collect(n int, f func(i int) int) []int {
+	var out []int
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			out = append(out, f(i))
+		}(i)
+	}
+	wg.Wait()
+	return out
+}
+
+// Lazy 在第一次 Get 時建立 Config
+type Lazy struct {
+	inits int32
+	v     *Config
+}
+
+// Get 與 singleton 的 GetRaceInstance 相同
+// race：多個 goroutine 可能同時看到 nil，各自初始化並覆寫 l.v
+func (l *Lazy) Get() *Config {
+	if l.v == nil {
+		atomic.AddInt32(&l.inits, 1)
+		l.v = &Config{Name: "lazy"}
+	}
+	return l.v
+}
+
+// Inits 回傳初始化執行的次數，正確的實作永遠是 0 或 1
+func (l *Lazy) Inits() int {
+	return int(atomic.LoadInt32(&l.inits))
+}
+
+// Squares 回傳 0..n-1 的平方，順序不保證
+// race：goroutine 讀取 closure 捕捉的 i 的同時，迴圈繼續 i++
+func Squares(n int) []int {
+	var (
+		mu  sync.Mutex
+		out []int
+		wg  sync.WaitGroup
+	)
+	i := 0
+	for i < n {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			mu.Lock()
+			out = append(out, i*i)
+			mu.Unlock()
+		}()
+		i++
+	}
+	wg.Wait()
+	return out
+}
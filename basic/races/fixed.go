@@ -0,0 +1,95 @@
+//go:build racefix
+
+package races
+
+import (
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// Fixed 回報目前編譯的是修正版本（-tags racefix）
+const Fixed = true
+
+// CountWords 以 workers 個 goroutine 統計字數
+// fix：以 Mutex 保護 map；更好的做法是每個 worker 先統計自己的 map，最後再合併
+func CountWords(words []string, workers int) map[string]int {
+	counts := make(map[string]int)
+	var (
+		mu sync.Mutex
+		wg sync.WaitGroup
+	)
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for i := w; i < len(words); i += workers {
+				mu.Lock()
+				counts[strings.ToLower(words[i])]++
+				mu.Unlock()
+			}
+		}(w)
+	}
+	wg.Wait()
+	return counts
+}
+
+// Collect 以 n 個 goroutine 各產生一個值並收集起來
+// fix：事先配置好長度，每個 goroutine 只寫自己的 index，不同元素之間不會 race
+func Collect(n int, f func(i int) int) []int {
+	out := make([]int, n)
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			out[i] = f(i)
+		}(i)
+	}
+	wg.Wait()
+	return out
+}
+
+// Lazy 在第一次 Get 時建立 Config
+type Lazy struct {
+	inits int32
+	once  sync.Once
+	v     *Config
+}
+
+// Get 以 sync.Once 初始化：Do 返回時初始化一定已經完成，並與之後的讀取建立 happens-before
+func (l *Lazy) Get() *Config {
+	l.once.Do(func() {
+		atomic.AddInt32(&l.inits, 1)
+		l.v = &Config{Name: "lazy"}
+	})
+	return l.v
+}
+
+// Inits 回傳初始化執行的次數，正確的實作永遠是 0 或 1
+func (l *Lazy) Inits() int {
+	return int(atomic.LoadInt32(&l.inits))
+}
+
+// Squares 回傳 0..n-1 的平方，順序不保證
+// fix：把 i 當參數傳入，每個 goroutine 拿到的是自己的副本
+func Squares(n int) []int {
+	var (
+		mu  sync.Mutex
+		out []int
+		wg  sync.WaitGroup
+	)
+	i := 0
+	for i < n {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			mu.Lock()
+			out = append(out, i*i)
+			mu.Unlock()
+		}(i)
+		i++
+	}
+	wg.Wait()
+	return out
+}
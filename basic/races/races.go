@@ -0,0 +1,33 @@
+package races
+
+/*
+Race-condition playground：四種最常見的 data race，每一種都有「錯誤」與「修正」兩個版本，
+以 build tag racefix 切換，兩個版本的函式簽名完全相同：
+
+	go test -race ./races                 錯誤版本：race detector 回報，測試失敗
+	go test -race -tags racefix ./races   修正版本：通過
+	go test ./races                       錯誤版本不開 -race 時結果不可靠，測試直接 skip
+
+	                  錯誤版本（broken.go）                 修正版本（fixed.go）
+	CountWords        多個 goroutine 同時寫同一個 map         sync.Mutex 保護 map
+	Collect           多個 goroutine 同時 append 同一個 slice  每個 goroutine 寫自己的 index
+	Lazy.Get          nil 檢查後初始化（GetRaceInstance）      sync.Once
+	Squares           closure 直接使用外層的計數器 i           把 i 當參數傳入
+
+為什麼單核心也抓得到：data race 的定義是「兩個存取之間沒有 happens-before 關係，且至少一個是寫入」，
+與是否真的同時執行無關。race detector 依 go、channel、Mutex、WaitGroup 等同步事件建立 happens-before，
+所以即使 goroutine 剛好輪流執行，只要程式沒有同步，一樣會回報。
+
+反過來說，沒開 -race 時錯誤版本也「常常」算出正確答案，這正是 data race 難以發現的原因；
+map 的並行寫入甚至可能直接讓 runtime 以 fatal error: concurrent map writes 結束行程。
+
+Squares 刻意把 i 宣告在迴圈外：
+  - basic/goroutine 的 TestGoroutineWrongUse 是 for i := ... 的版本，go vet 的 loopclosure 抓得到；
+    換成外層變數 vet 就看不出來，只有 race detector 能發現
+  - go 1.22 起 for 子句的變數每次迭代都是新的，但外層變數不受影響，升級 go 版本也救不了這種寫法
+*/
+
+// Config 是 Lazy 延遲初始化的物件
+type Config struct {
+	Name string
+}
@@ -0,0 +1,74 @@
+package races
+
+import (
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+/*
+每個測試在兩種模式下做同樣的檢查：
+  - 修正版本：有沒有 -race 都必須通過
+  - 錯誤版本：只有在 -race 下才執行，由 race detector 讓測試失敗（"race detected during execution of test"）；
+    沒有 -race 時結果取決於排程，可能碰巧正確，也可能 fatal error，所以直接 skip
+*/
+
+func mode(t *testing.T) {
+	t.Helper()
+	if !Fixed && !raceEnabled {
+		t.Skip("broken version: run with -race to see the report, or -tags racefix for the fix")
+	}
+}
+
+func TestCountWords(t *testing.T) {
+	mode(t)
+	words := strings.Fields(strings.Repeat("the quick brown fox jumps over The lazy dog ", 50))
+	counts := CountWords(words, 4)
+	assert.Equal(t, 100, counts["the"])
+	assert.Equal(t, 50, counts["dog"])
+	assert.Len(t, counts, 8)
+}
+
+func TestCollect(t *testing.T) {
+	mode(t)
+	got := Collect(100, func(i int) int { return i * 2 })
+	require.Len(t, got, 100, "append 被覆蓋時會少元素")
+	sort.Ints(got)
+	for i, v := range got {
+		assert.Equal(t, i*2, v)
+	}
+}
+
+func TestLazy(t *testing.T) {
+	mode(t)
+	var l Lazy
+	var wg sync.WaitGroup
+	configs := make([]*Config, 50)
+	for i := range configs {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			configs[i] = l.Get()
+		}(i)
+	}
+	wg.Wait()
+	assert.Equal(t, 1, l.Inits())
+	for _, c := range configs {
+		assert.Same(t, configs[0], c, "所有 goroutine 拿到同一個實例")
+	}
+}
+
+func TestSquares(t *testing.T) {
+	mode(t)
+	got := Squares(20)
+	sort.Ints(got)
+	want := make([]int, 20)
+	for i := range want {
+		want[i] = i * i
+	}
+	assert.Equal(t, want, got)
+}
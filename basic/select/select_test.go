@@ -2,33 +2,76 @@ package select_test
 
 import (
 	"fmt"
+	"io"
 	"testing"
-	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"basic/testutil/sched"
 )
 
-func service() string {
-	time.Sleep(time.Millisecond * 500)
-	// time.Sleep(time.Millisecond * 50)
+/*
+原本的版本用 time.Sleep(500ms) 模擬耗時的 service，再用 time.After(100ms) 做超時，
+結果完全由兩個時間決定（500ms > 100ms，所以永遠超時），輸出也只能靠 Println 用看的。
+
+這裡把「時間」換成測試控制的 sched.Gate：
+  - work 打開時 service 才完成，取代 time.Sleep
+  - timeout 打開時超時，取代 time.After
+select 的結果因此由測試決定，輸出寫到 sched.Log 後可以直接 assert。
+實際程式中 work 就是真正的工作、timeout 就是 time.After，select 的寫法完全相同。
+*/
+
+// service 模擬耗時的工作，work 被關閉時才完成
+func service(work <-chan struct{}) string {
+	<-work
 	return "Done service"
 }
 
-func AsyncService() chan string {
+func AsyncService(work <-chan struct{}, out io.Writer) chan string {
 	retCh := make(chan string)
 	go func() {
-		ret := service()
-		fmt.Println("return result ")
+		ret := service(work)
+		fmt.Fprintln(out, "return result")
 		retCh <- ret //阻塞，等待取出，取出後才會往下跑
-		fmt.Println("service exited")
+		fmt.Fprintln(out, "service exited")
 	}()
 	return retCh
 }
 
 // 透過select多路選擇，實現超時機制
 func TestSelect(t *testing.T) {
-	select {
-	case ret := <-AsyncService():
-		t.Log(ret)
-	case <-time.After(time.Millisecond * 100):
-		t.Error("time out")
-	}
+	t.Run("result before timeout", func(t *testing.T) {
+		work, timeout, log := sched.NewGate(), sched.NewGate(), &sched.Log{}
+		work.Open()
+
+		// timeout 永遠不會打開，select 一定等到結果
+		select {
+		case ret := <-AsyncService(work.C(), log):
+			assert.Equal(t, "Done service", ret)
+		case <-timeout.C():
+			t.Fatal("time out")
+		}
+		assert.Equal(t, []string{"return result", "service exited"}, log.Await(t, 2))
+	})
+
+	t.Run("timeout before result", func(t *testing.T) {
+		work, timeout, log := sched.NewGate(), sched.NewGate(), &sched.Log{}
+		retCh := AsyncService(work.C(), log)
+		timeout.Open()
+
+		// service 還卡在 work，只有 timeout 就緒
+		select {
+		case ret := <-retCh:
+			t.Fatalf("unexpected result %q", ret)
+		case <-timeout.C():
+		}
+		assert.Empty(t, log.Lines())
+
+		// 超時後沒有人接收，unbuffered 的 retCh 會讓 service 永遠卡在傳送（goroutine 洩漏）；
+		// 這裡手動取走結果讓它結束，實務上應該把 retCh 改成 buffer 為 1
+		work.Open()
+		assert.Equal(t, []string{"return result"}, log.Await(t, 1))
+		<-retCh
+		assert.Equal(t, []string{"return result", "service exited"}, log.Await(t, 2))
+	})
 }
@@ -3,6 +3,7 @@ package singleton
 import (
 	"database/sql"
 	"fmt"
+	"io"
 	"log"
 	"os"
 	"strconv"
@@ -11,6 +12,10 @@ import (
 	"testing"
 	"time"
 	"unsafe"
+
+	"github.com/stretchr/testify/assert"
+
+	"basic/testutil/sched"
 )
 
 /*
@@ -28,10 +33,16 @@ var singleInstance *Singleton
 
 // 1-1. race condition 版本的 singleton
 
+// raceWindow 放大「檢查 nil」與「初始化」之間的空隙；測試會換成 sched.Point 精確控制交錯
+var raceWindow = func() { time.Sleep(100 * time.Millisecond) }
+
+// out 是初始化訊息的輸出，測試換成 sched.Log 以便 assert
+var out io.Writer = os.Stdout
+
 func GetRaceInstance() *Singleton {
-	time.Sleep(100 * time.Millisecond)
 	if singleInstance == nil {
-		fmt.Println("INIT singleInstance")
+		raceWindow()
+		fmt.Fprintln(out, "INIT singleInstance")
 		singleInstance = &Singleton{}
 	}
 	return singleInstance
@@ -50,16 +61,36 @@ func GetRaceInstance() *Singleton {
 
 */
 func TestGetRaceInstance(t *testing.T) {
-	for i := 0; i < 100; i++ {
+	singleInstance = nil
+	p, rec := sched.NewPoint("after nil check"), &sched.Log{}
+	oldWindow, oldOut := raceWindow, out
+	raceWindow, out = p.Yield, rec
+	t.Cleanup(func() {
+		singleInstance, raceWindow, out = nil, oldWindow, oldOut
+	})
+
+	results := make(chan *Singleton)
+	for i := 0; i < 2; i++ {
 		go func() {
-			GetRaceInstance()
+			results <- GetRaceInstance()
 		}()
 	}
-	time.Sleep(2 * time.Second)
+	// 兩個 goroutine 都看到 nil，停在初始化之前
+	p.Await(t)
+	p.Await(t)
+	// 依序放行：第一個初始化完成後，第二個仍然會再初始化一次
+	p.Release(t)
+	<-results
+	p.Release(t)
+	<-results
+
+	assert.Equal(t, []string{"INIT singleInstance", "INIT singleInstance"}, rec.Lines())
 }
 
 /*
 這樣是有可能同時多個 goroutine 都進入 instance == nil 的條件裡面並且初始化，（"INIT singleInstance" 輸出多次）
+原本用 100 個 goroutine 加上 sleep 碰運氣，單核心的機器上常常只看到一次；
+測試改用 sched.Point 讓兩個 goroutine 都停在檢查之後，每次執行都能重現兩次初始化。
 試想如果初始化後裡面的 field 值也許是給 defualt 值，但是同時 singleton 也有提供 func 去對裡面的 field 進行計算的話，
 這樣會導致每個 goroutine 都可能會拿到不 consistent 的值。
 
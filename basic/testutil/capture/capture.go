@@ -0,0 +1,176 @@
+package capture
+
+import (
+	"bytes"
+	"io"
+	"log"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+/*
+basic 的範例大多以 fmt.Println / log.Println 展示結果，測試本身什麼都沒檢查，
+輸出錯了也只能靠眼睛看。capture 把輸出擷取下來，讓測試可以 assert：
+
+	out := capture.Run(t, func() { ... })
+	out.Equal(t, "Start panic", "recovered from Something wrong!")
+
+擷取的方式：
+  - os.Stdout 換成 os.Pipe 的寫入端，fmt.Print* 每次呼叫只有一次 write，
+    小於 PIPE_BUF（4KB）的 write 是原子的，多個 goroutine 同時印也不會有半行交錯
+  - 標準 log 同時導到同一個 pipe，並暫時 SetFlags(0)，輸出不帶時間，才能精確比對
+  - 背景 goroutine 持續讀取 pipe，輸出再多也不會塞住 pipe
+
+並行的輸出順序通常不固定，所以除了 Equal 之外還有不看順序的檢查：
+  - ElementsMatch：不看順序，只看每一行出現的次數
+  - Filter + Equal：只看某個 goroutine 自己的輸出（例如 "A:" 開頭），檢查它自己的順序
+  - Before：只檢查兩行之間的先後
+
+限制：Start 與 Stop 會修改 os.Stdout 這個全域變數，呼叫時不能有其他 goroutine 正在印，
+也就是要在啟動 goroutine 之前 Start、在它們結束（WaitGroup、channel）之後 Stop，否則 -race 會回報。
+t.Log 的輸出由 testing 處理，不會被擷取。
+*/
+
+type Capture struct {
+	r, w *os.File
+	done chan struct{}
+	buf  bytes.Buffer
+
+	stdout    *os.File
+	logOut    io.Writer
+	logFlags  int
+	logPrefix string
+	stopped   bool
+}
+
+// Start 開始擷取 os.Stdout 與標準 log 的輸出，測試結束時會自動 Stop
+func Start(t testing.TB) *Capture {
+	t.Helper()
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatalf("capture: %v", err)
+	}
+	c := &Capture{
+		r: r, w: w, done: make(chan struct{}),
+		stdout: os.Stdout, logOut: log.Writer(), logFlags: log.Flags(), logPrefix: log.Prefix(),
+	}
+	os.Stdout = w
+	log.SetOutput(w)
+	log.SetFlags(0)
+	log.SetPrefix("")
+	go func() {
+		io.Copy(&c.buf, r)
+		close(c.done)
+	}()
+	t.Cleanup(func() { c.Stop() })
+	return c
+}
+
+// Stop 還原 os.Stdout 與 log，回傳擷取到的輸出；可以重複呼叫
+func (c *Capture) Stop() Output {
+	if !c.stopped {
+		c.stopped = true
+		os.Stdout = c.stdout
+		log.SetOutput(c.logOut)
+		log.SetFlags(c.logFlags)
+		log.SetPrefix(c.logPrefix)
+		c.w.Close()
+		<-c.done
+		c.r.Close()
+	}
+	return parse(c.buf.String())
+}
+
+// Run 擷取 f 執行期間的輸出，f 啟動的 goroutine 必須在 f 返回前結束
+func Run(t testing.TB, f func()) Output {
+	t.Helper()
+	c := Start(t)
+	f()
+	return c.Stop()
+}
+
+// Output 是擷取到的輸出，一行一個元素（不含換行）
+type Output struct {
+	Lines []string
+}
+
+func parse(s string) Output {
+	s = strings.TrimSuffix(s, "\n")
+	if s == "" {
+		return Output{}
+	}
+	return Output{Lines: strings.Split(s, "\n")}
+}
+
+func (o Output) String() string {
+	return strings.Join(o.Lines, "\n")
+}
+
+// Filter 回傳以 prefix 開頭的行，保留原本的順序
+func (o Output) Filter(prefix string) Output {
+	var out Output
+	for _, l := range o.Lines {
+		if strings.HasPrefix(l, prefix) {
+			out.Lines = append(out.Lines, l)
+		}
+	}
+	return out
+}
+
+// Count 回傳與 line 完全相同的行數
+func (o Output) Count(line string) int {
+	n := 0
+	for _, l := range o.Lines {
+		if l == line {
+			n++
+		}
+	}
+	return n
+}
+
+func (o Output) index(line string) int {
+	for i, l := range o.Lines {
+		if l == line {
+			return i
+		}
+	}
+	return -1
+}
+
+// Equal 檢查輸出與 want 完全相同，包含順序
+func (o Output) Equal(t testing.TB, want ...string) bool {
+	t.Helper()
+	return assert.Equal(t, nonNil(want), nonNil(o.Lines))
+}
+
+// ElementsMatch 檢查輸出與 want 有相同的行（含重複次數），不看順序
+func (o Output) ElementsMatch(t testing.TB, want ...string) bool {
+	t.Helper()
+	return assert.ElementsMatch(t, want, o.Lines)
+}
+
+// Contains 檢查輸出中有與 line 完全相同的一行
+func (o Output) Contains(t testing.TB, line string) bool {
+	t.Helper()
+	return assert.Contains(t, o.Lines, line, "output:\n%s", o)
+}
+
+// Before 檢查 a 第一次出現在 b 第一次出現之前
+func (o Output) Before(t testing.TB, a, b string) bool {
+	t.Helper()
+	i, j := o.index(a), o.index(b)
+	if i < 0 || j < 0 {
+		return assert.Fail(t, "line not found", "%q at %d, %q at %d in output:\n%s", a, i, b, j, o)
+	}
+	return assert.Less(t, i, j, "%q should be printed before %q, output:\n%s", a, b, o)
+}
+
+func nonNil(s []string) []string {
+	if s == nil {
+		return []string{}
+	}
+	return s
+}
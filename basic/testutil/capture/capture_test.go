@@ -0,0 +1,102 @@
+package capture
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// recorder 記錄 assertion 是否失敗，用來測試失敗的情況而不讓外層測試失敗
+type recorder struct {
+	testing.TB
+	failed bool
+}
+
+func (r *recorder) Helper()                           {}
+func (r *recorder) Name() string                      { return "recorder" }
+func (r *recorder) Errorf(format string, args ...any) { r.failed = true }
+
+func TestRunRestores(t *testing.T) {
+	stdout, flags, prefix := os.Stdout, log.Flags(), log.Prefix()
+	out := Run(t, func() {
+		fmt.Println("from fmt")
+		log.Println("from log")
+		fmt.Print("no newline")
+	})
+	out.Equal(t, "from fmt", "from log", "no newline")
+	assert.Same(t, stdout, os.Stdout)
+	assert.Equal(t, flags, log.Flags())
+	assert.Equal(t, prefix, log.Prefix())
+}
+
+func TestEmpty(t *testing.T) {
+	out := Run(t, func() {})
+	assert.Empty(t, out.Lines)
+	out.Equal(t)
+}
+
+func TestStopTwice(t *testing.T) {
+	c := Start(t)
+	fmt.Println("once")
+	assert.Equal(t, c.Stop(), c.Stop())
+}
+
+// TestConcurrentLines 多個 goroutine 同時印，每一行都要完整，不能交錯
+func TestConcurrentLines(t *testing.T) {
+	const n = 50
+	line := func(i int) string { return fmt.Sprintf("%02d:%s", i, strings.Repeat("x", 200)) }
+	out := Run(t, func() {
+		var wg sync.WaitGroup
+		for i := 0; i < n; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				for j := 0; j < 10; j++ {
+					fmt.Println(line(i))
+				}
+			}(i)
+		}
+		wg.Wait()
+	})
+	assert.Len(t, out.Lines, n*10)
+	for i := 0; i < n; i++ {
+		assert.Equal(t, 10, out.Count(line(i)))
+	}
+}
+
+// TestLargeOutput 輸出超過 pipe 的緩衝區大小也不會卡住
+func TestLargeOutput(t *testing.T) {
+	out := Run(t, func() {
+		for i := 0; i < 10000; i++ {
+			fmt.Println(strings.Repeat("y", 100))
+		}
+	})
+	assert.Len(t, out.Lines, 10000)
+}
+
+func TestAssertions(t *testing.T) {
+	out := Output{Lines: []string{"A: 1", "B: 1", "A: 2", "B: 2"}}
+
+	assert.True(t, out.ElementsMatch(t, "B: 2", "A: 1", "B: 1", "A: 2"))
+	assert.True(t, out.Filter("A:").Equal(t, "A: 1", "A: 2"))
+	assert.True(t, out.Contains(t, "B: 2"))
+	assert.True(t, out.Before(t, "A: 1", "A: 2"))
+	assert.Equal(t, "A: 1\nB: 1\nA: 2\nB: 2", out.String())
+
+	for name, check := range map[string]func(testing.TB) bool{
+		"Equal":         func(t testing.TB) bool { return out.Equal(t, "A: 1", "A: 2", "B: 1", "B: 2") },
+		"ElementsMatch": func(t testing.TB) bool { return out.ElementsMatch(t, "A: 1", "B: 1", "A: 2") },
+		"Contains":      func(t testing.TB) bool { return out.Contains(t, "A:") },
+		"Before":        func(t testing.TB) bool { return out.Before(t, "B: 2", "A: 1") },
+		"BeforeMissing": func(t testing.TB) bool { return out.Before(t, "A: 1", "C: 1") },
+	} {
+		r := &recorder{}
+		assert.False(t, check(r), name)
+		assert.True(t, r.failed, name)
+	}
+}
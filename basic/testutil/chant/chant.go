@@ -0,0 +1,131 @@
+package chant
+
+import (
+	"bytes"
+	"fmt"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+/*
+channel 範例的測試常寫成「開 goroutine → time.Sleep(time.Second) → 希望事情已經發生」：
+  - sleep 太短：機器一忙就失敗；sleep 太長：每個測試都白白等一秒
+  - 真的卡住（忘了 close、沒人接收）時，不是等到 go test 的 10 分鐘逾時，就是什麼都沒檢查就通過
+
+chant 把「等待 channel」變成有逾時的 assertion，事情一發生就立刻返回，
+沒發生時在逾時的當下失敗，並附上診斷資訊：channel 的 len/cap 與相關 goroutine 的 stack，
+一眼就能看出是誰卡在哪個 send/recv 上。
+
+  - RequireRecv：timeout 內要收到一個值（關閉的 channel 不算）
+  - RequireNoRecv：wait 期間不能收到值，例如「cancel 只通知到一個 goroutine」
+  - RequireClosed：timeout 內 channel 要被關閉，收到值也算失敗
+  - Eventually：條件最終成立，例如 goroutine 數量、subscriber 數量
+
+所有 helper 都在呼叫者的 goroutine 中等待，不會額外啟動 goroutine，與 goleak 一起使用也不會誤報。
+*/
+
+// PollInterval 是 Eventually 檢查條件的間隔
+var PollInterval = 5 * time.Millisecond
+
+// RequireRecv 在 timeout 內從 ch 收到一個值並回傳，逾時或 channel 已關閉時 t.Fatal
+func RequireRecv[T any](t testing.TB, ch <-chan T, timeout time.Duration) T {
+	t.Helper()
+	select {
+	case v, ok := <-ch:
+		if !ok {
+			t.Fatalf("chant: channel closed, expected a value")
+		}
+		return v
+	case <-time.After(timeout):
+		t.Fatalf("chant: no value received within %v (len=%d cap=%d)\n%s", timeout, len(ch), cap(ch), Goroutines())
+	}
+	panic("unreachable")
+}
+
+// RequireNoRecv 等待 wait，期間從 ch 收到值或 channel 被關閉時 t.Fatal
+func RequireNoRecv[T any](t testing.TB, ch <-chan T, wait time.Duration) {
+	t.Helper()
+	select {
+	case v, ok := <-ch:
+		if !ok {
+			t.Fatalf("chant: channel closed, expected no receive for %v", wait)
+		}
+		t.Fatalf("chant: unexpected value received: %v", v)
+	case <-time.After(wait):
+	}
+}
+
+// RequireClosed 在 timeout 內 ch 要被關閉；收到值或逾時時 t.Fatal
+func RequireClosed[T any](t testing.TB, ch <-chan T, timeout time.Duration) {
+	t.Helper()
+	select {
+	case v, ok := <-ch:
+		if ok {
+			t.Fatalf("chant: expected closed channel, received: %v", v)
+		}
+	case <-time.After(timeout):
+		t.Fatalf("chant: channel not closed within %v (len=%d cap=%d)\n%s", timeout, len(ch), cap(ch), Goroutines())
+	}
+}
+
+// Eventually 每 PollInterval 檢查一次 cond，timeout 內都不成立時 t.Fatal
+func Eventually(t testing.TB, cond func() bool, timeout time.Duration, msgAndArgs ...interface{}) {
+	t.Helper()
+	deadline := time.Now().Add(timeout)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("chant: condition not met within %v%s\n%s", timeout, message(msgAndArgs), Goroutines())
+		}
+		time.Sleep(PollInterval)
+	}
+}
+
+func message(msgAndArgs []interface{}) string {
+	if len(msgAndArgs) == 0 {
+		return ""
+	}
+	if format, ok := msgAndArgs[0].(string); ok {
+		return ": " + fmt.Sprintf(format, msgAndArgs[1:]...)
+	}
+	return ": " + fmt.Sprint(msgAndArgs...)
+}
+
+// Goroutines 回傳其他 goroutine 的 stack，略過呼叫者自己與只在 runtime、testing 內的 goroutine
+func Goroutines() string {
+	buf := make([]byte, 1<<16)
+	for {
+		n := runtime.Stack(buf, true)
+		if n < len(buf) {
+			buf = buf[:n]
+			break
+		}
+		buf = make([]byte, 2*len(buf))
+	}
+	var out []string
+	// 第一個是呼叫者自己，也就是卡在 chant 裡的測試，不需要列出
+	for _, g := range bytes.Split(buf, []byte("\n\n"))[1:] {
+		if relevant(string(g)) {
+			out = append(out, string(g))
+		}
+	}
+	if len(out) == 0 {
+		return "goroutines: (none outside runtime/testing)"
+	}
+	return "goroutines:\n" + strings.Join(out, "\n\n")
+}
+
+// relevant 判斷 goroutine 的 stack 中有沒有 runtime、testing 與 go test 產生的 main 以外的函式
+func relevant(g string) bool {
+	for _, line := range strings.Split(g, "\n")[1:] {
+		if line == "" || strings.HasPrefix(line, "\t") {
+			continue // 檔案:行號
+		}
+		fn := strings.TrimPrefix(line, "created by ")
+		if !strings.HasPrefix(fn, "runtime.") && !strings.HasPrefix(fn, "testing.") && !strings.HasPrefix(fn, "main.") {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,114 @@
+package chant
+
+import (
+	"fmt"
+	"runtime"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeTB 攔截 Fatalf，確認失敗時會帶著診斷訊息結束，而不是卡住
+type fakeTB struct {
+	testing.TB
+	failed chan string
+}
+
+func (f *fakeTB) Helper() {}
+func (f *fakeTB) Fatalf(format string, args ...interface{}) {
+	f.failed <- fmt.Sprintf(format, args...)
+	runtime.Goexit()
+}
+
+// fail 在另一個 goroutine 執行 f，回傳 Fatalf 的訊息；f 正常結束時回傳空字串
+func fail(t *testing.T, f func(tb testing.TB)) string {
+	tb := &fakeTB{TB: t, failed: make(chan string, 1)}
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		f(tb)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		require.Fail(t, "helper did not return")
+	}
+	select {
+	case msg := <-tb.failed:
+		return msg
+	default:
+		return ""
+	}
+}
+
+func TestRequireRecv(t *testing.T) {
+	ch := make(chan int)
+	go func() { ch <- 42 }()
+	assert.Equal(t, 42, RequireRecv(t, ch, time.Second), "雙向 channel 可以直接傳入")
+
+	blocked := make(chan int, 3)
+	msg := fail(t, func(tb testing.TB) { RequireRecv(tb, blocked, 10*time.Millisecond) })
+	assert.Contains(t, msg, "no value received within 10ms (len=0 cap=3)")
+
+	closed := make(chan int)
+	close(closed)
+	assert.Contains(t, fail(t, func(tb testing.TB) { RequireRecv(tb, closed, time.Second) }), "channel closed")
+}
+
+func TestRequireNoRecv(t *testing.T) {
+	ch := make(chan string, 1)
+	assert.Empty(t, fail(t, func(tb testing.TB) { RequireNoRecv(tb, ch, 10*time.Millisecond) }))
+
+	ch <- "late"
+	assert.Contains(t, fail(t, func(tb testing.TB) { RequireNoRecv(tb, ch, time.Second) }), "unexpected value received: late")
+
+	close(ch)
+	assert.Contains(t, fail(t, func(tb testing.TB) { RequireNoRecv(tb, ch, time.Second) }), "channel closed")
+}
+
+func TestRequireClosed(t *testing.T) {
+	ch := make(chan struct{})
+	go close(ch)
+	RequireClosed(t, ch, time.Second)
+
+	open := make(chan int, 1)
+	assert.Contains(t, fail(t, func(tb testing.TB) { RequireClosed(tb, open, 10*time.Millisecond) }), "not closed within")
+	open <- 1
+	assert.Contains(t, fail(t, func(tb testing.TB) { RequireClosed(tb, open, time.Second) }), "received: 1")
+}
+
+func TestEventually(t *testing.T) {
+	var n int32
+	go func() {
+		for i := 0; i < 3; i++ {
+			time.Sleep(time.Millisecond)
+			atomic.AddInt32(&n, 1)
+		}
+	}()
+	Eventually(t, func() bool { return atomic.LoadInt32(&n) == 3 }, time.Second)
+
+	msg := fail(t, func(tb testing.TB) {
+		Eventually(tb, func() bool { return false }, 10*time.Millisecond, "want %d subscribers", 2)
+	})
+	assert.Contains(t, msg, "condition not met within 10ms: want 2 subscribers")
+}
+
+func blockForever(ch chan int) { ch <- 1 }
+
+// TestGoroutinesDiagnostics 卡住的 goroutine 會出現在診斷訊息中，只在 runtime 內的 goroutine 不會
+func TestGoroutinesDiagnostics(t *testing.T) {
+	ch := make(chan int)
+	go blockForever(ch)
+	Eventually(t, func() bool { return strings.Contains(Goroutines(), "chant.blockForever") }, time.Second)
+
+	runtime.GC() // 確保 GC worker 存在
+	g := Goroutines()
+	assert.NotContains(t, g, "runtime.gcBgMarkWorker")
+	assert.NotContains(t, g, "chant.Goroutines(", "呼叫者自己不列出")
+	assert.Equal(t, 1, strings.Count(g, "\ngoroutine "), g)
+	<-ch
+}
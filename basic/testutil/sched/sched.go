@@ -0,0 +1,207 @@
+package sched
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+/*
+並行範例常見的寫法是「開一堆 goroutine → time.Sleep 等一下 → 看 Println 的輸出」，
+結果取決於排程：機器快慢、GOMAXPROCS、-race 都會改變輸出，測試也只能用肉眼檢查。
+
+這個套件提供讓交錯順序由測試決定的工具，取代 sleep 與 print：
+  - Gate：一次性的開關，取代「sleep 之後才完成的工作」與 time.After
+  - Point：受控的讓出點，被測程式在這裡停下，由測試決定誰、何時繼續
+  - Steps：步驟屏障，讓多個 goroutine 依照步驟編號前進
+  - Log：執行緒安全的輸出紀錄，取代 fmt.Println，讓輸出可以被 assert
+
+所有「等待」的方法都有逾時（DefaultTimeout），交錯寫錯時測試會失敗而不是卡住。
+這些同步都經過 channel / Mutex，被控制的交錯之間有 happens-before，-race 也不會誤報。
+*/
+
+// DefaultTimeout 是 Await、Release 等方法等待的上限
+var DefaultTimeout = 5 * time.Second
+
+// Gate 是一次性的開關，Open 之後所有 Wait 立即返回
+type Gate struct {
+	once sync.Once
+	ch   chan struct{}
+}
+
+func NewGate() *Gate {
+	return &Gate{ch: make(chan struct{})}
+}
+
+// Open 可以重複呼叫
+func (g *Gate) Open() {
+	g.once.Do(func() { close(g.ch) })
+}
+
+// C 回傳 Open 時被關閉的 channel，可以放在 select 中取代 time.After
+func (g *Gate) C() <-chan struct{} {
+	return g.ch
+}
+
+func (g *Gate) Wait() {
+	<-g.ch
+}
+
+func (g *Gate) IsOpen() bool {
+	select {
+	case <-g.ch:
+		return true
+	default:
+		return false
+	}
+}
+
+// Point 是被測程式與測試之間的會合點：
+// 被測程式呼叫 Yield 停下，測試以 Await 確認它到了，再以 Release 讓它繼續
+type Point struct {
+	name    string
+	arrived chan struct{}
+	release chan struct{}
+}
+
+func NewPoint(name string) *Point {
+	return &Point{name: name, arrived: make(chan struct{}), release: make(chan struct{})}
+}
+
+// Yield 由被測程式呼叫：通知測試已到達，並等待 Release
+func (p *Point) Yield() {
+	p.arrived <- struct{}{}
+	<-p.release
+}
+
+// Await 等待一個 goroutine 到達 Yield
+func (p *Point) Await(t testing.TB) {
+	t.Helper()
+	select {
+	case <-p.arrived:
+	case <-time.After(DefaultTimeout):
+		t.Fatalf("sched: no goroutine reached %q within %v", p.name, DefaultTimeout)
+	}
+}
+
+// Release 讓一個已到達的 goroutine 繼續
+func (p *Point) Release(t testing.TB) {
+	t.Helper()
+	select {
+	case p.release <- struct{}{}:
+	case <-time.After(DefaultTimeout):
+		t.Fatalf("sched: no goroutine waiting at %q within %v", p.name, DefaultTimeout)
+	}
+}
+
+// Steps 是步驟屏障：Wait(n) 阻塞到步驟推進到 n，Advance 由測試（或其他 goroutine）推進
+type Steps struct {
+	mu      sync.Mutex
+	current int
+	reached chan struct{} // 每次 Advance 時關閉並換新，喚醒所有等待者重新檢查
+}
+
+func NewSteps() *Steps {
+	return &Steps{reached: make(chan struct{})}
+}
+
+// Wait 阻塞到目前的步驟 >= n
+func (s *Steps) Wait(n int) {
+	for {
+		s.mu.Lock()
+		if s.current >= n {
+			s.mu.Unlock()
+			return
+		}
+		ch := s.reached
+		s.mu.Unlock()
+		<-ch
+	}
+}
+
+// Advance 推進一步並回傳新的步驟編號
+func (s *Steps) Advance() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.current++
+	close(s.reached)
+	s.reached = make(chan struct{})
+	return s.current
+}
+
+func (s *Steps) Current() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.current
+}
+
+// Log 記錄被測程式的輸出，實作 io.Writer，可以取代 os.Stdout 傳給被測程式
+type Log struct {
+	mu      sync.Mutex
+	partial bytes.Buffer
+	lines   []string
+	changed chan struct{}
+}
+
+// Write 以換行切成一行一行記錄
+func (l *Log) Write(p []byte) (int, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.partial.Write(p)
+	for {
+		line, err := l.partial.ReadString('\n')
+		if err != nil {
+			// 不完整的一行放回去，等下一次 Write
+			rest := line
+			l.partial.Reset()
+			l.partial.WriteString(rest)
+			break
+		}
+		l.lines = append(l.lines, strings.TrimSuffix(line, "\n"))
+	}
+	if l.changed != nil {
+		close(l.changed)
+		l.changed = nil
+	}
+	return len(p), nil
+}
+
+// Println 與 fmt.Println 相同，但寫到 Log
+func (l *Log) Println(a ...interface{}) {
+	fmt.Fprintln(l, a...)
+}
+
+// Lines 回傳目前為止的完整行
+func (l *Log) Lines() []string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return append([]string(nil), l.lines...)
+}
+
+// Await 等待至少 n 行後回傳全部的行
+func (l *Log) Await(t testing.TB, n int) []string {
+	t.Helper()
+	deadline := time.After(DefaultTimeout)
+	for {
+		l.mu.Lock()
+		if len(l.lines) >= n {
+			lines := append([]string(nil), l.lines...)
+			l.mu.Unlock()
+			return lines
+		}
+		if l.changed == nil {
+			l.changed = make(chan struct{})
+		}
+		ch := l.changed
+		l.mu.Unlock()
+
+		select {
+		case <-ch:
+		case <-deadline:
+			t.Fatalf("sched: want %d lines within %v, got %q", n, DefaultTimeout, l.Lines())
+		}
+	}
+}
@@ -0,0 +1,115 @@
+package sched
+
+import (
+	"fmt"
+	"runtime"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGate(t *testing.T) {
+	g := NewGate()
+	assert.False(t, g.IsOpen())
+	select {
+	case <-g.C():
+		t.Fatal("gate opened too early")
+	default:
+	}
+	g.Open()
+	g.Open()
+	g.Wait()
+	assert.True(t, g.IsOpen())
+}
+
+func TestPointOrdersGoroutines(t *testing.T) {
+	p := NewPoint("before append")
+	var mu sync.Mutex
+	var order []string
+	done := make(chan struct{})
+	for _, name := range []string{"a", "b"} {
+		name := name
+		go func() {
+			p.Yield()
+			mu.Lock()
+			order = append(order, name)
+			mu.Unlock()
+			done <- struct{}{}
+		}()
+	}
+	p.Await(t)
+	p.Await(t)
+	// 兩個都停在 Yield，由測試一個一個放行
+	p.Release(t)
+	<-done
+	p.Release(t)
+	<-done
+	assert.Len(t, order, 2)
+}
+
+func TestSteps(t *testing.T) {
+	s := NewSteps()
+	log := &Log{}
+	var wg sync.WaitGroup
+	for i := 3; i >= 1; i-- {
+		wg.Add(1)
+		go func(step int) {
+			defer wg.Done()
+			s.Wait(step)
+			log.Println("step", step)
+		}(i)
+	}
+	for i := 1; i <= 3; i++ {
+		assert.Equal(t, i, s.Advance())
+		log.Await(t, i)
+	}
+	wg.Wait()
+	assert.Equal(t, []string{"step 1", "step 2", "step 3"}, log.Lines())
+	assert.Equal(t, 3, s.Current())
+	s.Wait(2) // 已經過了的步驟立即返回
+}
+
+func TestLogPartialLines(t *testing.T) {
+	l := &Log{}
+	fmt.Fprint(l, "hel")
+	assert.Empty(t, l.Lines())
+	fmt.Fprint(l, "lo\nwor")
+	fmt.Fprint(l, "ld\n")
+	assert.Equal(t, []string{"hello", "world"}, l.Lines())
+}
+
+// fakeTB 攔截 Fatalf，確認逾時會讓測試失敗而不是卡住
+type fakeTB struct {
+	testing.TB
+	failed chan string
+}
+
+func (f *fakeTB) Helper() {}
+func (f *fakeTB) Fatalf(format string, args ...interface{}) {
+	f.failed <- fmt.Sprintf(format, args...)
+	runtime.Goexit() // 與 t.FailNow 相同，結束目前的 goroutine
+}
+
+func TestTimeouts(t *testing.T) {
+	old := DefaultTimeout
+	DefaultTimeout = 10 * time.Millisecond
+	defer func() { DefaultTimeout = old }()
+
+	for name, f := range map[string]func(tb testing.TB){
+		"await":   func(tb testing.TB) { NewPoint("p").Await(tb) },
+		"release": func(tb testing.TB) { NewPoint("p").Release(tb) },
+		"log":     func(tb testing.TB) { (&Log{}).Await(tb, 1) },
+	} {
+		tb := &fakeTB{TB: t, failed: make(chan string, 1)}
+		go f(tb)
+		select {
+		case msg := <-tb.failed:
+			assert.Contains(t, msg, "sched:", name)
+		case <-time.After(time.Second):
+			require.Fail(t, "no timeout", name)
+		}
+	}
+}
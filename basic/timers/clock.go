@@ -0,0 +1,186 @@
+package timers
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+/*
+Clock 把 time.Now / time.NewTimer / time.NewTicker 抽象出來，
+正式程式用 RealClock，測試用 FakeClock 手動推進時間，
+測試就不需要真的 time.Sleep，結果也不會因為機器忙碌而不穩定。
+*/
+
+type Clock interface {
+	Now() time.Time
+	NewTimer(d time.Duration) Timer
+	NewTicker(d time.Duration) Ticker
+}
+
+type Timer interface {
+	C() <-chan time.Time
+	Stop() bool
+	Reset(d time.Duration) bool
+}
+
+type Ticker interface {
+	C() <-chan time.Time
+	Stop()
+	Reset(d time.Duration)
+}
+
+type RealClock struct{}
+
+func (RealClock) Now() time.Time { return time.Now() }
+
+func (RealClock) NewTimer(d time.Duration) Timer {
+	return realTimer{time.NewTimer(d)}
+}
+
+func (RealClock) NewTicker(d time.Duration) Ticker {
+	return realTicker{time.NewTicker(d)}
+}
+
+type realTimer struct{ t *time.Timer }
+
+func (r realTimer) C() <-chan time.Time        { return r.t.C }
+func (r realTimer) Stop() bool                 { return r.t.Stop() }
+func (r realTimer) Reset(d time.Duration) bool { return r.t.Reset(d) }
+
+type realTicker struct{ t *time.Ticker }
+
+func (r realTicker) C() <-chan time.Time   { return r.t.C }
+func (r realTicker) Stop()                 { r.t.Stop() }
+func (r realTicker) Reset(d time.Duration) { r.t.Reset(d) }
+
+// FakeClock 只有在呼叫 Advance 時時間才會前進
+type FakeClock struct {
+	mu      sync.Mutex
+	cond    *sync.Cond
+	now     time.Time
+	waiters []*fakeTimer
+}
+
+func NewFakeClock(now time.Time) *FakeClock {
+	c := &FakeClock{now: now}
+	c.cond = sync.NewCond(&c.mu)
+	return c
+}
+
+func (c *FakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *FakeClock) NewTimer(d time.Duration) Timer {
+	return c.add(d, 0)
+}
+
+func (c *FakeClock) NewTicker(d time.Duration) Ticker {
+	if d <= 0 {
+		panic("timers: non-positive interval for NewTicker")
+	}
+	return fakeTicker{c.add(d, d)}
+}
+
+func (c *FakeClock) add(d, period time.Duration) *fakeTimer {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	t := &fakeTimer{clock: c, ch: make(chan time.Time, 1), period: period}
+	c.schedule(t, d)
+	return t
+}
+
+// schedule 需要持有 c.mu
+func (c *FakeClock) schedule(t *fakeTimer, d time.Duration) {
+	t.when = c.now.Add(d)
+	t.active = true
+	c.waiters = append(c.waiters, t)
+	c.cond.Broadcast()
+}
+
+// remove 需要持有 c.mu
+func (c *FakeClock) remove(t *fakeTimer) bool {
+	for i, w := range c.waiters {
+		if w == t {
+			c.waiters = append(c.waiters[:i], c.waiters[i+1:]...)
+			t.active = false
+			return true
+		}
+	}
+	return false
+}
+
+// Advance 推進時間，並依時間順序觸發到期的 timer/ticker
+func (c *FakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	end := c.now.Add(d)
+	for {
+		sort.Slice(c.waiters, func(i, j int) bool { return c.waiters[i].when.Before(c.waiters[j].when) })
+		if len(c.waiters) == 0 || c.waiters[0].when.After(end) {
+			break
+		}
+		t := c.waiters[0]
+		c.waiters = c.waiters[1:]
+		c.now = t.when
+		// 與真正的 timer 相同：channel 滿了就丟棄這次的值
+		select {
+		case t.ch <- c.now:
+		default:
+		}
+		if t.period > 0 {
+			t.when = t.when.Add(t.period)
+			c.waiters = append(c.waiters, t)
+		} else {
+			t.active = false
+		}
+	}
+	c.now = end
+}
+
+// BlockUntil 等到至少有 n 個 timer/ticker 在等待中，
+// 用來確認被測的 goroutine 已經建立好 timer，再去推進時間
+func (c *FakeClock) BlockUntil(n int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for len(c.waiters) < n {
+		c.cond.Wait()
+	}
+}
+
+type fakeTimer struct {
+	clock  *FakeClock
+	ch     chan time.Time
+	when   time.Time
+	period time.Duration
+	active bool
+}
+
+func (t *fakeTimer) C() <-chan time.Time { return t.ch }
+
+func (t *fakeTimer) Stop() bool {
+	t.clock.mu.Lock()
+	defer t.clock.mu.Unlock()
+	return t.clock.remove(t)
+}
+
+func (t *fakeTimer) Reset(d time.Duration) bool {
+	t.clock.mu.Lock()
+	defer t.clock.mu.Unlock()
+	active := t.clock.remove(t)
+	if t.period > 0 {
+		t.period = d
+	}
+	t.clock.schedule(t, d)
+	return active
+}
+
+type fakeTicker struct {
+	*fakeTimer
+}
+
+func (t fakeTicker) Stop()                 { t.fakeTimer.Stop() }
+func (t fakeTicker) Reset(d time.Duration) { t.fakeTimer.Reset(d) }
@@ -0,0 +1,88 @@
+package timers
+
+import (
+	"context"
+	"time"
+)
+
+/*
+SafeTimer 把 time.Timer 的坑包起來：
+	- Reset 前一定要 Stop 並清空 channel，否則可能馬上收到上一輪過期的值
+	- 清空 channel 要用非阻塞的 select，值已經被別人讀走時直接 <-t.C 會永遠卡住
+	- 等待時要能被 context 取消
+*/
+
+type SafeTimer struct {
+	t Timer
+}
+
+func NewSafeTimer(c Clock, d time.Duration) *SafeTimer {
+	return &SafeTimer{t: c.NewTimer(d)}
+}
+
+// Stop 停止 timer 並清掉還沒被讀取的值，之後可以安全地 Reset
+func (s *SafeTimer) Stop() {
+	if !s.t.Stop() {
+		select {
+		case <-s.t.C():
+		default:
+		}
+	}
+}
+
+// Reset 不論 timer 目前的狀態（執行中、已觸發未讀、已讀）都能安全地重新計時
+func (s *SafeTimer) Reset(d time.Duration) {
+	s.Stop()
+	s.t.Reset(d)
+}
+
+// Wait 等待 timer 觸發，ctx 先結束時回傳 ctx.Err()
+func (s *SafeTimer) Wait(ctx context.Context) error {
+	select {
+	case <-s.t.C():
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (s *SafeTimer) C() <-chan time.Time {
+	return s.t.C()
+}
+
+// SafeTicker 確保 ticker 一定會被 Stop，避免 goroutine 與 ticker 洩漏
+type SafeTicker struct {
+	t Ticker
+}
+
+func NewSafeTicker(c Clock, d time.Duration) *SafeTicker {
+	return &SafeTicker{t: c.NewTicker(d)}
+}
+
+// Run 每次 tick 呼叫 fn，直到 ctx 結束，結束前會 Stop ticker
+func (s *SafeTicker) Run(ctx context.Context, fn func(time.Time)) error {
+	defer s.t.Stop()
+	for {
+		select {
+		case now := <-s.t.C():
+			fn(now)
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+func (s *SafeTicker) Reset(d time.Duration) {
+	s.t.Reset(d)
+}
+
+func (s *SafeTicker) Stop() {
+	s.t.Stop()
+}
+
+// Sleep 可被取消的 time.Sleep
+func Sleep(ctx context.Context, c Clock, d time.Duration) error {
+	t := NewSafeTimer(c, d)
+	defer t.Stop()
+	return t.Wait(ctx)
+}
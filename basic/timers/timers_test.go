@@ -0,0 +1,221 @@
+package timers
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/goleak"
+)
+
+var epoch = time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+
+/*
+1. Timer 重複使用的坑
+
+timer 觸發後值會放在 C（容量 1）裡，如果沒有被讀走就直接 Reset，
+下一次讀 C 會立刻拿到上一輪過期的值，而不是等新的時間到。
+（go.mod 的 go 版本 >= 1.23 時 runtime 會幫忙清掉，這個 module 是 1.18 所以會重現）
+*/
+func TestTimerResetWithoutDrain(t *testing.T) {
+	timer := time.NewTimer(time.Millisecond)
+	time.Sleep(10 * time.Millisecond) // timer 已觸發，但沒有人讀 C
+
+	timer.Reset(time.Hour)
+	select {
+	case v := <-timer.C:
+		fmt.Println("收到過期的值:", v) // 明明 Reset 成一小時，卻立刻收到值
+	case <-time.After(50 * time.Millisecond):
+		fmt.Println("沒有收到值")
+	}
+	timer.Stop()
+}
+
+/*
+2. Stop 之後 drain 的坑
+
+官方文件的寫法：
+
+	if !t.Stop() {
+		<-t.C
+	}
+
+只有在確定「還沒有人讀過 C」時才正確，如果 C 的值已經被讀走，<-t.C 會永遠卡住。
+所以要用非阻塞的 select 來清空。
+*/
+func TestStopDrainDeadlock(t *testing.T) {
+	timer := time.NewTimer(time.Millisecond)
+	<-timer.C // 值已經被讀走
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		if !timer.Stop() {
+			select {
+			case <-timer.C:
+			default: // 用 <-timer.C 的話會卡在這裡
+			}
+		}
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("drain blocked")
+	}
+}
+
+/*
+3. Ticker 洩漏
+
+time.NewTicker 沒有 Stop 的話，runtime 會一直替它觸發；
+負責讀取 ticker 的 goroutine 如果沒有結束條件，也會跟著洩漏。
+*/
+func leakyWorker(stop chan struct{}) {
+	ticker := time.NewTicker(time.Millisecond) // 忘記 defer ticker.Stop()
+	go func() {
+		for range ticker.C { // ticker 沒停，這個 goroutine 永遠不會結束
+		}
+	}()
+	<-stop
+}
+
+func TestTickerLeak(t *testing.T) {
+	before := runtime.NumGoroutine()
+	stop := make(chan struct{})
+	close(stop)
+	for i := 0; i < 10; i++ {
+		leakyWorker(stop)
+	}
+	time.Sleep(10 * time.Millisecond)
+	fmt.Println("洩漏的 goroutine 數量:", runtime.NumGoroutine()-before)
+}
+
+// 使用 SafeTicker.Run，ctx 結束時 ticker 與 goroutine 都會被回收
+func TestSafeTickerNoLeak(t *testing.T) {
+	defer goleak.VerifyNone(t, goleak.IgnoreCurrent())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_ = NewSafeTicker(RealClock{}, time.Millisecond).Run(ctx, func(time.Time) {})
+		}()
+	}
+	time.Sleep(5 * time.Millisecond)
+	cancel()
+	wg.Wait()
+}
+
+func TestSafeTimerResetAfterFire(t *testing.T) {
+	clock := NewFakeClock(epoch)
+	timer := NewSafeTimer(clock, time.Second)
+
+	clock.Advance(time.Second) // 觸發但沒有讀
+	timer.Reset(time.Minute)   // SafeTimer 會清掉過期的值
+
+	select {
+	case <-timer.C():
+		t.Fatal("received stale value")
+	default:
+	}
+	clock.Advance(time.Minute)
+	assert.Equal(t, epoch.Add(time.Second+time.Minute), <-timer.C())
+}
+
+func TestSafeTimerStopStates(t *testing.T) {
+	clock := NewFakeClock(epoch)
+	timer := NewSafeTimer(clock, time.Second)
+
+	// 已讀過的 timer 再 Stop 不會卡住
+	clock.Advance(time.Second)
+	<-timer.C()
+	timer.Stop()
+	timer.Stop()
+
+	// 執行中的 timer
+	timer.Reset(time.Second)
+	timer.Stop()
+	clock.Advance(time.Hour)
+	select {
+	case <-timer.C():
+		t.Fatal("stopped timer fired")
+	default:
+	}
+}
+
+func TestSafeTimerWait(t *testing.T) {
+	clock := NewFakeClock(epoch)
+	timer := NewSafeTimer(clock, time.Second)
+
+	errCh := make(chan error)
+	go func() { errCh <- timer.Wait(context.Background()) }()
+	clock.Advance(time.Second)
+	assert.NoError(t, <-errCh)
+
+	timer.Reset(time.Hour)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	assert.ErrorIs(t, timer.Wait(ctx), context.Canceled)
+}
+
+func TestSleep(t *testing.T) {
+	clock := NewFakeClock(epoch)
+	errCh := make(chan error)
+	go func() { errCh <- Sleep(context.Background(), clock, time.Minute) }()
+
+	clock.BlockUntil(1)
+	clock.Advance(59 * time.Second)
+	select {
+	case <-errCh:
+		t.Fatal("woke up too early")
+	default:
+	}
+	clock.Advance(time.Second)
+	assert.NoError(t, <-errCh)
+	assert.Equal(t, epoch.Add(time.Minute), clock.Now())
+}
+
+func TestSafeTickerRun(t *testing.T) {
+	clock := NewFakeClock(epoch)
+	ticker := NewSafeTicker(clock, time.Second)
+	ctx, cancel := context.WithCancel(context.Background())
+
+	ticks := make(chan time.Time)
+	done := make(chan error)
+	go func() {
+		done <- ticker.Run(ctx, func(now time.Time) { ticks <- now })
+	}()
+
+	for i := 1; i <= 3; i++ {
+		clock.Advance(time.Second)
+		assert.Equal(t, epoch.Add(time.Duration(i)*time.Second), <-ticks)
+	}
+
+	ticker.Reset(time.Minute)
+	clock.Advance(time.Minute)
+	assert.Equal(t, epoch.Add(3*time.Second+time.Minute), <-ticks)
+
+	cancel()
+	assert.ErrorIs(t, <-done, context.Canceled)
+}
+
+// ticker 的 channel 容量只有 1，讀太慢時中間的 tick 會被丟掉
+func TestFakeTickerDropsTicks(t *testing.T) {
+	clock := NewFakeClock(epoch)
+	ticker := clock.NewTicker(time.Second)
+	defer ticker.Stop()
+
+	clock.Advance(5 * time.Second)
+	assert.Equal(t, epoch.Add(time.Second), <-ticker.C())
+	select {
+	case <-ticker.C():
+		t.Fatal("expected dropped ticks")
+	default:
+	}
+}
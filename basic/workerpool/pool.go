@@ -0,0 +1,118 @@
+package workerpool
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+/*
+限制同時執行數量的 worker pool。
+
+goroutine 範例裡常看到每個工作直接 `go func()`：
+工作量小時沒問題，但一次來十萬個請求就會開十萬個 goroutine，
+每個都要 stack、都可能同時去開檔案或連資料庫，資源很快被耗盡。
+
+Pool 固定開 workers 個 goroutine，從有容量的 queue（buffered channel）取任務：
+
+	Submit ──> [ queue (queueSize) ] ──> worker × N
+
+  - Submit：queue 滿時等待（back-pressure），呼叫端自然被放慢；Shutdown 之後回傳 ErrClosed
+  - Wait：等待目前為止送出的任務都做完，pool 仍可繼續使用
+  - Shutdown：不再接受任務，把 queue 裡剩下的做完、worker 都結束後回傳；ctx 到期時先回傳 ctx.Err()
+  - 任務 panic 不會讓 worker 消失，交給 WithPanicHandler（預設忽略）
+*/
+
+var ErrClosed = errors.New("workerpool: closed")
+
+type Pool struct {
+	tasks   chan func()
+	mu      sync.RWMutex // 保護 closed 與 close(tasks)，避免 Submit 送到已關閉的 channel
+	closed  bool
+	pending sync.WaitGroup // 已送出但還沒做完的任務
+	workers sync.WaitGroup
+	onPanic func(any)
+}
+
+type Option func(*Pool)
+
+// WithQueueSize 設定 queue 的容量，預設與 worker 數相同
+func WithQueueSize(n int) Option {
+	return func(p *Pool) { p.tasks = make(chan func(), n) }
+}
+
+// WithPanicHandler 任務 panic 時呼叫 fn，worker 繼續處理下一個任務
+func WithPanicHandler(fn func(any)) Option {
+	return func(p *Pool) { p.onPanic = fn }
+}
+
+func New(workers int, opts ...Option) *Pool {
+	if workers < 1 {
+		panic("workerpool: workers must be positive")
+	}
+	p := &Pool{tasks: make(chan func(), workers), onPanic: func(any) {}}
+	for _, o := range opts {
+		o(p)
+	}
+	p.workers.Add(workers)
+	for i := 0; i < workers; i++ {
+		go p.worker()
+	}
+	return p
+}
+
+func (p *Pool) worker() {
+	defer p.workers.Done()
+	for task := range p.tasks {
+		p.run(task)
+	}
+}
+
+func (p *Pool) run(task func()) {
+	defer p.pending.Done()
+	defer func() {
+		if r := recover(); r != nil {
+			p.onPanic(r)
+		}
+	}()
+	task()
+}
+
+// Submit 把任務放進 queue，queue 滿時等待
+func (p *Pool) Submit(task func()) error {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	if p.closed {
+		return ErrClosed
+	}
+	p.pending.Add(1)
+	p.tasks <- task
+	return nil
+}
+
+// Wait 等待已經送出的任務全部完成
+func (p *Pool) Wait() {
+	p.pending.Wait()
+}
+
+// Shutdown 停止接受任務並等待 queue 清空；可以重複呼叫
+func (p *Pool) Shutdown(ctx context.Context) error {
+	p.mu.Lock()
+	if !p.closed {
+		p.closed = true
+		close(p.tasks)
+	}
+	p.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		p.workers.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
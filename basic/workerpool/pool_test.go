@@ -0,0 +1,147 @@
+package workerpool
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/goleak"
+)
+
+func TestMain(m *testing.M) {
+	goleak.VerifyTestMain(m)
+}
+
+func TestBoundedConcurrency(t *testing.T) {
+	const workers = 4
+	p := New(workers, WithQueueSize(10))
+
+	var running, peak, done int32
+	for i := 0; i < 100; i++ {
+		require.NoError(t, p.Submit(func() {
+			n := atomic.AddInt32(&running, 1)
+			for {
+				old := atomic.LoadInt32(&peak)
+				if n <= old || atomic.CompareAndSwapInt32(&peak, old, n) {
+					break
+				}
+			}
+			time.Sleep(time.Millisecond)
+			atomic.AddInt32(&running, -1)
+			atomic.AddInt32(&done, 1)
+		}))
+	}
+	p.Wait()
+	assert.EqualValues(t, 100, atomic.LoadInt32(&done))
+	assert.LessOrEqual(t, atomic.LoadInt32(&peak), int32(workers), "同時執行的任務不超過 worker 數")
+
+	// Wait 之後 pool 還可以繼續用
+	require.NoError(t, p.Submit(func() { atomic.AddInt32(&done, 1) }))
+	p.Wait()
+	assert.EqualValues(t, 101, atomic.LoadInt32(&done))
+	require.NoError(t, p.Shutdown(context.Background()))
+}
+
+func TestShutdownDrainsQueue(t *testing.T) {
+	p := New(1, WithQueueSize(5))
+	release := make(chan struct{})
+	var done int32
+	require.NoError(t, p.Submit(func() { <-release }))
+	for i := 0; i < 5; i++ {
+		require.NoError(t, p.Submit(func() { atomic.AddInt32(&done, 1) }))
+	}
+
+	// 第一個任務卡住時 Shutdown 等不到 worker 結束
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, p.Shutdown(ctx), context.DeadlineExceeded)
+	assert.ErrorIs(t, p.Submit(func() {}), ErrClosed, "Shutdown 之後不接受新任務")
+
+	close(release)
+	require.NoError(t, p.Shutdown(context.Background()))
+	assert.EqualValues(t, 5, atomic.LoadInt32(&done), "queue 中的任務都會做完")
+}
+
+func TestSubmitBlocksWhenQueueFull(t *testing.T) {
+	p := New(1, WithQueueSize(1))
+	release := make(chan struct{})
+	require.NoError(t, p.Submit(func() { <-release })) // worker 在執行
+	require.NoError(t, p.Submit(func() {}))            // 佔滿 queue
+
+	submitted := make(chan struct{})
+	go func() {
+		p.Submit(func() {})
+		close(submitted)
+	}()
+	select {
+	case <-submitted:
+		t.Fatal("queue 滿時 Submit 應該等待")
+	case <-time.After(20 * time.Millisecond):
+	}
+	close(release)
+	<-submitted
+	require.NoError(t, p.Shutdown(context.Background()))
+}
+
+func TestPanicDoesNotKillWorker(t *testing.T) {
+	var mu sync.Mutex
+	var panics []any
+	p := New(1, WithPanicHandler(func(r any) {
+		mu.Lock()
+		panics = append(panics, r)
+		mu.Unlock()
+	}))
+	var done int32
+	require.NoError(t, p.Submit(func() { panic("boom") }))
+	require.NoError(t, p.Submit(func() { atomic.AddInt32(&done, 1) }))
+	p.Wait()
+	assert.EqualValues(t, 1, atomic.LoadInt32(&done))
+	assert.Equal(t, []any{"boom"}, panics)
+	require.NoError(t, p.Shutdown(context.Background()))
+}
+
+/*
+每個任務做一點 CPU 工作。pool 版固定 4 個 worker，unbounded 版每個任務一個 goroutine。
+
+	go test -bench . -benchmem ./workerpool（1 CPU）
+	BenchmarkUnbounded 	  914293	      1467 ns/op	      16 B/op	       1 allocs/op
+	BenchmarkPool      	 6110938	       193.3 ns/op	       0 B/op	       0 allocs/op
+
+任務很小時，開 goroutine 的成本（stack 配置、排程）就佔了大部分時間，
+pool 重複使用 goroutine，也沒有每個任務的配置；
+更重要的是同時存在的 goroutine 數量有上限，任務會去開連線或檔案時不會把資源耗盡。
+*/
+func work() {
+	x := 0
+	for i := 0; i < 100; i++ {
+		x += i * i
+	}
+	_ = x
+}
+
+func BenchmarkUnbounded(b *testing.B) {
+	b.ReportAllocs()
+	var wg sync.WaitGroup
+	for i := 0; i < b.N; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			work()
+		}()
+	}
+	wg.Wait()
+}
+
+func BenchmarkPool(b *testing.B) {
+	b.ReportAllocs()
+	p := New(4, WithQueueSize(1024))
+	for i := 0; i < b.N; i++ {
+		p.Submit(work)
+	}
+	p.Wait()
+	p.Shutdown(context.Background())
+}